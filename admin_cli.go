@@ -0,0 +1,424 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// paasadmin: offline store inspection and repair (cmd/paasadmin)
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	adminExitOK    = 0
+	adminExitError = 1
+	adminExitUsage = 2
+
+	adminUsage = `usage: paasadmin [-store-dir DIR | -nats-url URL] <command> [flags]
+
+Opens the JetStream store directly (the server must be stopped) or connects
+to a running NATS server, for recovery when the HTTP API will not start.
+
+commands:
+  projects [-view NAME]    list projects, or those a saved view selects
+  views                    list saved project views
+  ops [-project ID]        list ops, oldest first
+  releases [-project ID]   list release records, oldest first
+  export                   dump projects, ops, and releases as JSON
+  repair [-apply]          find dangling references; -apply fixes them
+  artifact-roots           list artifact roots and project placements
+  move-artifacts -project ID -root NAME [-apply]
+                           move a project's artifacts to another root
+                           (store directory only; -apply moves them)
+`
+)
+
+// RunAdmin runs one paasadmin command and returns the process exit code.
+func RunAdmin(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("paasadmin", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { _, _ = io.WriteString(stderr, adminUsage) }
+	storeDir := global.String("store-dir", "", "JetStream store directory (default: "+natsStoreDirEnv+" or built-in)")
+	natsURL := global.String("nats-url", "", "connect to this NATS server instead of opening the store directory")
+	if err := global.Parse(args); err != nil {
+		return adminExitUsage
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return adminExitUsage
+	}
+
+	cfg, err := loadRuntimeConfig()
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "paasadmin: config: %v\n", err)
+		return adminExitError
+	}
+	ctx, cancel := context.WithTimeout(withRuntimeConfig(context.Background(), cfg), time.Minute)
+	defer cancel()
+	store, closeStore, err := openAdminStore(ctx, *storeDir, *natsURL)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "paasadmin: %v\n", err)
+		return adminExitError
+	}
+	defer closeStore()
+
+	offline := strings.TrimSpace(*natsURL) == ""
+	if err = runAdminCommand(ctx, store, offline, global.Arg(0), global.Args()[1:], stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return adminExitUsage
+		}
+		_, _ = fmt.Fprintf(stderr, "paasadmin: %v\n", err)
+		return adminExitError
+	}
+	return adminExitOK
+}
+
+func runAdminCommand(
+	ctx context.Context,
+	store *Store,
+	offline bool,
+	command string,
+	args []string,
+	stdout, stderr io.Writer,
+) error {
+	fs := flag.NewFlagSet("paasadmin "+command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	projectID := fs.String("project", "", "only show records for this project")
+	apply := fs.Bool("apply", false, "write fixes instead of only reporting them")
+	rootName := fs.String("root", "", "target artifact root name (move-artifacts)")
+	viewName := fs.String("view", "", "saved view name or ID (projects)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch command {
+	case "projects":
+		return adminListProjects(ctx, store, strings.TrimSpace(*viewName), stdout)
+	case "views":
+		return adminListViews(ctx, store, stdout)
+	case "ops":
+		return adminListOps(ctx, store, strings.TrimSpace(*projectID), stdout)
+	case "releases":
+		return adminListReleases(ctx, store, strings.TrimSpace(*projectID), stdout)
+	case "export":
+		export, err := store.exportAll(ctx)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(export)
+	case "repair":
+		return adminRepair(ctx, store, *apply, stdout)
+	case "artifact-roots":
+		return adminArtifactRoots(ctx, store, stdout)
+	case "move-artifacts":
+		if *apply && !offline {
+			return errors.New("move-artifacts -apply needs -store-dir: stop the server before moving files")
+		}
+		return adminMoveArtifacts(ctx, store, strings.TrimSpace(*projectID), strings.TrimSpace(*rootName), *apply, stdout)
+	default:
+		return fmt.Errorf("unknown command %q (run paasadmin -h)", command)
+	}
+}
+
+// openAdminStore connects to natsURL when given, with the PAAS_NATS_CREDS and
+// TLS settings of an external cluster, otherwise starts a private loopback
+// JetStream server over the store directory. An existing directory
+// is required so a typo never initializes an empty store.
+func openAdminStore(ctx context.Context, storeDir, natsURL string) (*Store, func(), error) {
+	closers := []func(){}
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+
+	var endpoint natsEndpoint
+	if url := strings.TrimSpace(natsURL); url != "" {
+		var err error
+		if endpoint, err = externalNATSEndpoint(url); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		dir := strings.TrimSpace(storeDir)
+		if dir == "" {
+			resolved := resolveNATSStoreDir()
+			if resolved.isEphemeral {
+				return nil, nil, errors.New("store dir is ephemeral; there is nothing on disk to open")
+			}
+			dir = resolved.storeDir
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, nil, fmt.Errorf("store dir %s does not exist", dir)
+		}
+		ns, embedded, err := startNATSServerAt(dir, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open store dir %s (is the server still running?): %w", dir, err)
+		}
+		closers = append(closers, func() {
+			ns.Shutdown()
+			ns.WaitForShutdown()
+		})
+		endpoint = embedded
+	}
+
+	nc, err := endpoint.connect("paasadmin")
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("connect nats: %w", err)
+	}
+	closers = append(closers, nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("jetstream: %w", err)
+	}
+	store, err := newStore(ctx, js)
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("store: %w", err)
+	}
+	return store, closeAll, nil
+}
+
+func adminListProjects(ctx context.Context, store *Store, viewName string, stdout io.Writer) error {
+	projects, err := store.ListProjects(ctx)
+	if err != nil {
+		return err
+	}
+	if viewName != "" {
+		view, findErr := adminFindView(ctx, store, viewName)
+		if findErr != nil {
+			return findErr
+		}
+		projects = filterAndSortProjects(projects, view.Filter, view.Sort)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tNAME\tPHASE\tLAST OP\tUPDATED")
+	for _, project := range projects {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			project.ID,
+			project.Spec.Name,
+			project.Status.Phase,
+			project.Status.LastOpID,
+			project.UpdatedAt.Format(time.RFC3339),
+		)
+	}
+	return tw.Flush()
+}
+
+func adminListViews(ctx context.Context, store *Store, stdout io.Writer) error {
+	views, err := store.listProjectViews(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tNAME\tFILTER\tSORT\tCREATED BY")
+	for _, view := range views {
+		filter, _ := json.Marshal(view.Filter)
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", view.ID, view.Name, filter, view.Sort, view.CreatedBy)
+	}
+	return tw.Flush()
+}
+
+// adminFindView resolves a saved view by ID or, ignoring case, by name.
+func adminFindView(ctx context.Context, store *Store, nameOrID string) (ProjectView, error) {
+	views, err := store.listProjectViews(ctx)
+	if err != nil {
+		return ProjectView{}, err
+	}
+	for _, view := range views {
+		if view.ID == nameOrID || strings.EqualFold(view.Name, nameOrID) {
+			return view, nil
+		}
+	}
+	return ProjectView{}, fmt.Errorf("no saved view named %q (run paasadmin views)", nameOrID)
+}
+
+func adminListOps(ctx context.Context, store *Store, projectID string, stdout io.Writer) error {
+	ops, err := store.listAllOps(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tPROJECT\tKIND\tSTATUS\tREQUESTED\tERROR")
+	for _, op := range ops {
+		if projectID != "" && op.ProjectID != projectID {
+			continue
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			op.ID,
+			op.ProjectID,
+			op.Kind,
+			op.Status,
+			op.Requested.Format(time.RFC3339),
+			op.Error,
+		)
+	}
+	return tw.Flush()
+}
+
+func adminListReleases(ctx context.Context, store *Store, projectID string, stdout io.Writer) error {
+	releases, err := store.listAllReleases(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tPROJECT\tENV\tOP\tIMAGE\tCREATED")
+	for _, release := range releases {
+		if projectID != "" && release.ProjectID != projectID {
+			continue
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			release.ID,
+			release.ProjectID,
+			release.Environment,
+			release.OpID,
+			release.Image,
+			release.CreatedAt.Format(time.RFC3339),
+		)
+	}
+	return tw.Flush()
+}
+
+func adminRepair(ctx context.Context, store *Store, apply bool, stdout io.Writer) error {
+	findings, err := store.repairStore(ctx, apply)
+	for _, finding := range findings {
+		_, _ = fmt.Fprintf(stdout, "%s: %s -> %s\n", finding.Key, finding.Problem, finding.Fix)
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(findings) == 0:
+		_, _ = fmt.Fprintln(stdout, "no dangling references found")
+	case apply:
+		_, _ = fmt.Fprintf(stdout, "%d finding(s); fixes applied where listed\n", len(findings))
+	default:
+		_, _ = fmt.Fprintf(stdout, "%d finding(s); rerun with repair -apply to fix\n", len(findings))
+	}
+	return nil
+}
+
+// adminArtifacts builds the artifact layout the server would use from the
+// runtime config and stored placements, without creating any directories.
+func adminArtifacts(ctx context.Context, store *Store) (*FSArtifacts, error) {
+	roots, err := artifactRootsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	artifacts := NewFSArtifacts(resolveArtifactsRoot(runtimeConfigFromContext(ctx)).root)
+	artifacts.setRoots(roots)
+	if _, err = loadArtifactPlacements(ctx, store, artifacts); err != nil {
+		return nil, err
+	}
+	artifacts.projectOrg = store.projectOrg
+	return artifacts, nil
+}
+
+func adminArtifactRoots(ctx context.Context, store *Store, stdout io.Writer) error {
+	artifacts, err := adminArtifacts(ctx, store)
+	if err != nil {
+		return err
+	}
+	roots := artifacts.alternateRoots()
+	names := slices.Sorted(maps.Keys(roots))
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ROOT\tDIR")
+	_, _ = fmt.Fprintf(tw, "%s\t%s\n", defaultArtifactRootName, artifacts.root)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", name, roots[name])
+	}
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+
+	placements, err := store.listArtifactPlacements(ctx)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(stdout)
+	tw = tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROJECT\tROOT\tUPDATED")
+	for _, placement := range placements {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n",
+			placement.ProjectID,
+			placement.Root,
+			placement.UpdatedAt.Format(time.RFC3339),
+		)
+	}
+	return tw.Flush()
+}
+
+// adminMoveArtifacts moves one project's tree to rootName and records the
+// placement. Files move first; if the placement cannot be written they are
+// moved back so the store and disk keep agreeing.
+func adminMoveArtifacts(
+	ctx context.Context,
+	store *Store,
+	projectID, rootName string,
+	apply bool,
+	stdout io.Writer,
+) error {
+	if projectID == "" || rootName == "" {
+		return errors.New("move-artifacts needs -project and -root")
+	}
+	if _, err := store.GetProject(ctx, projectID); err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return fmt.Errorf("project %s not found", projectID)
+		}
+		return err
+	}
+	artifacts, err := adminArtifacts(ctx, store)
+	if err != nil {
+		return err
+	}
+	targetRoot, ok := artifacts.rootDir(rootName)
+	if !ok {
+		return fmt.Errorf("artifact root %q is not configured in %s", rootName, artifactRootsEnv)
+	}
+	currentName := artifacts.projectRootName(projectID)
+	if rootName == currentName {
+		_, _ = fmt.Fprintf(stdout, "project %s is already on root %s\n", projectID, rootName)
+		return nil
+	}
+	from := artifacts.ProjectDir(projectID)
+	to := filepath.Join(targetRoot, artifacts.projectSubdir(artifacts.projectRoot(projectID), projectID))
+	files, err := countArtifactFiles(from)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if !apply {
+		_, _ = fmt.Fprintf(stdout, "would move %d file(s) from %s (%s) to %s (%s); rerun with -apply\n",
+			files, from, currentName, to, rootName)
+		return nil
+	}
+
+	moved, err := moveArtifactTree(from, to)
+	if err != nil {
+		return err
+	}
+	placement := artifactPlacement{ProjectID: projectID, Root: rootName, UpdatedAt: time.Time{}}
+	if err = store.putArtifactPlacement(ctx, placement); err != nil {
+		if _, backErr := moveArtifactTree(to, from); backErr != nil {
+			return fmt.Errorf("record placement: %w (moving files back also failed: %w)", err, backErr)
+		}
+		return fmt.Errorf("record placement: %w (files moved back)", err)
+	}
+	_, _ = fmt.Fprintf(stdout, "moved %d file(s) from %s (%s) to %s (%s)\n", moved, from, currentName, to, rootName)
+	return nil
+}
@@ -0,0 +1,156 @@
+//nolint:testpackage,exhaustruct // Admin CLI tests seed a store directory through the internal store.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestAdmin_RepairFixesDanglingReferencesOffline(t *testing.T) {
+	storeDir := t.TempDir()
+	seedAdminStore(t, storeDir)
+
+	out := runAdminForTest(t, "-store-dir", storeDir, "repair")
+	for _, want := range []string{
+		"project_ops/p1: 1 op id(s) point at missing ops",
+		"project_release_current/p1/dev: current release rel-gone is missing -> repoint to release rel-1",
+		"project_delete_plan/p-gone: pending delete plan for missing project p-gone",
+		"3 finding(s); rerun with repair -apply",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("dry run output missing %q:\n%s", want, out)
+		}
+	}
+	if again := runAdminForTest(t, "-store-dir", storeDir, "repair"); !strings.Contains(again, "3 finding(s)") {
+		t.Fatalf("expected dry run to leave the store untouched:\n%s", again)
+	}
+
+	runAdminForTest(t, "-store-dir", storeDir, "repair", "-apply")
+	if clean := runAdminForTest(t, "-store-dir", storeDir, "repair"); !strings.Contains(clean, "no dangling references") {
+		t.Fatalf("expected repaired store to be clean:\n%s", clean)
+	}
+
+	var export storeExport
+	if err := json.Unmarshal([]byte(runAdminForTest(t, "-store-dir", storeDir, "export")), &export); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if len(export.Projects) != 1 || len(export.Ops) != 1 || len(export.Releases) != 1 {
+		t.Fatalf("unexpected export: %#v", export)
+	}
+}
+
+func TestAdmin_MoveArtifactsRecordsPlacement(t *testing.T) {
+	storeDir := t.TempDir()
+	seedAdminStore(t, storeDir)
+	mainRoot := t.TempDir()
+	bigRoot := filepath.Join(t.TempDir(), "big")
+	t.Setenv(artifactsRootEnv, mainRoot)
+	t.Setenv(artifactRootsEnv, "bigdata="+bigRoot)
+	imagePath := filepath.Join(mainRoot, "p1", "build", "image.txt")
+	if err := os.MkdirAll(filepath.Dir(imagePath), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(imagePath, []byte("img\n"), 0o600); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+
+	dryRun := runAdminForTest(t, "-store-dir", storeDir, "move-artifacts", "-project", "p1", "-root", "bigdata")
+	if !strings.Contains(dryRun, "would move 1 file(s)") {
+		t.Fatalf("unexpected dry run output:\n%s", dryRun)
+	}
+	if _, err := os.Stat(imagePath); err != nil {
+		t.Fatalf("expected dry run to leave files in place: %v", err)
+	}
+
+	runAdminForTest(t, "-store-dir", storeDir, "move-artifacts", "-project", "p1", "-root", "bigdata", "-apply")
+	if _, err := os.Stat(filepath.Join(bigRoot, "p1", "build", "image.txt")); err != nil {
+		t.Fatalf("expected artifact on the bigdata root: %v", err)
+	}
+	roots := runAdminForTest(t, "-store-dir", storeDir, "artifact-roots")
+	if !strings.Contains(roots, "bigdata  "+bigRoot) || !strings.Contains(roots, "p1       bigdata") {
+		t.Fatalf("expected placement to be listed:\n%s", roots)
+	}
+
+	runAdminForTest(t, "-store-dir", storeDir, "move-artifacts", "-project", "p1", "-root", "default", "-apply")
+	if _, err := os.Stat(imagePath); err != nil {
+		t.Fatalf("expected artifact back on the main root: %v", err)
+	}
+	if roots = runAdminForTest(t, "-store-dir", storeDir, "artifact-roots"); strings.Contains(roots, "p1 ") {
+		t.Fatalf("expected moving back to clear the placement:\n%s", roots)
+	}
+}
+
+func TestAdmin_RefusesMissingStoreDir(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := RunAdmin([]string{"-store-dir", t.TempDir() + "/missing", "projects"}, &stdout, &stderr)
+	if code != adminExitError || !strings.Contains(stderr.String(), "does not exist") {
+		t.Fatalf("expected missing store dir to fail, code=%d stderr=%q", code, stderr.String())
+	}
+}
+
+// seedAdminStore writes one healthy project plus dangling references, then
+// shuts the server down so paasadmin opens the directory on its own.
+func seedAdminStore(t *testing.T, storeDir string) {
+	t.Helper()
+	ns, endpoint, err := startNATSServerAt(storeDir, false)
+	if err != nil {
+		t.Skipf("embedded nats unavailable: %v", err)
+	}
+	defer func() {
+		ns.Shutdown()
+		ns.WaitForShutdown()
+	}()
+	nc, err := endpoint.connect("admin-seed")
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("jetstream: %v", err)
+	}
+	ctx := context.Background()
+	store, err := newStore(ctx, js)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if err = store.PutProject(ctx, Project{ID: "p1", CreatedAt: now, Spec: workerRuntimeSpec("admin-app")}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	if err = store.PutOp(ctx, Operation{ID: "op-1", Kind: OpCreate, ProjectID: "p1", Requested: now,
+		Finished: now, Status: opStatusDone, Steps: []OpStep{}}); err != nil {
+		t.Fatalf("put op: %v", err)
+	}
+	if err = store.recordProjectOp(ctx, "p1", "op-missing"); err != nil {
+		t.Fatalf("record dangling op: %v", err)
+	}
+	if _, err = store.PutRelease(ctx, ReleaseRecord{ID: "rel-1", ProjectID: "p1", Environment: "dev",
+		OpID: "op-1", OpKind: OpDeploy, CreatedAt: now}); err != nil {
+		t.Fatalf("put release: %v", err)
+	}
+	if err = store.writeProjectReleaseCurrent(ctx, "p1", "dev", "rel-gone"); err != nil {
+		t.Fatalf("write dangling current release: %v", err)
+	}
+	if err = store.putDeletePlan(ctx, DeletePlan{ID: "plan-1", ProjectID: "p-gone"}); err != nil {
+		t.Fatalf("put orphan delete plan: %v", err)
+	}
+}
+
+func runAdminForTest(t *testing.T, args ...string) string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if code := RunAdmin(args, &stdout, &stderr); code != adminExitOK {
+		t.Fatalf("paasadmin %v exited %d: %s", args, code, stderr.String())
+	}
+	return stdout.String()
+}
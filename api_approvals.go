@@ -0,0 +1,564 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Release approvals: with PAAS_RELEASE_APPROVALS set to N, a release to
+// production is parked as a pending approval instead of being queued. Once N
+// different people approve it the API queues the release itself; a single
+// rejection ends it. An approval covers one source image, so a rebuild in the
+// source environment needs a fresh one, and the promoter checks it again
+// before it touches anything.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	approvalStatusPending    = "pending"
+	approvalStatusApproved   = "approved"
+	approvalStatusRejected   = "rejected"
+	approvalStatusSuperseded = "superseded"
+
+	approvalDecisionApprove = "approve"
+	approvalDecisionReject  = "reject"
+
+	maxReleaseApprovals      = 10
+	maxApprovalCommentLength = 2000
+	maxApprovalByLength      = 128
+
+	transitionGateApproval = "release_approval"
+)
+
+type approvalDecisionRequest struct {
+	Comment string `json:"comment"`
+	// By names the approver while API auth is off; with it on, the token's
+	// name is used instead.
+	By string `json:"by"`
+}
+
+// approvalDecisionResponse is the approval after a decision. Op is the
+// release the deciding approval queued; ReleaseError says why it could not
+// be queued, in which case re-sending the release event retries it.
+type approvalDecisionResponse struct {
+	Approval     ReleaseApproval `json:"approval"`
+	Op           *Operation      `json:"op,omitempty"`
+	ReleaseError string          `json:"release_error,omitempty"`
+}
+
+// releaseApprovalPending is how runTransitionLifecycle reports a release it
+// parked for approval rather than queued. The handlers answer it with 202.
+type releaseApprovalPending struct {
+	Approval ReleaseApproval
+	Project  Project
+}
+
+func (e releaseApprovalPending) Error() string {
+	return fmt.Sprintf("release to %s awaits approval: %d of %d",
+		e.Approval.ToEnv, e.Approval.approvals(), e.Approval.Required)
+}
+
+// releaseApprovalRequiredError refuses a release queued without a granted
+// approval, such as a fan-out child or a release whose approval was used up.
+type releaseApprovalRequiredError struct {
+	ProjectID   string
+	Environment string
+	Required    int
+	Approval    *ReleaseApproval
+}
+
+func (e releaseApprovalRequiredError) Error() string {
+	if e.Approval != nil {
+		return fmt.Sprintf("release to %s needs a granted approval; approval %s is %s with %d of %d approvals",
+			e.Environment, e.Approval.ID, e.Approval.Status, e.Approval.approvals(), e.Approval.Required)
+	}
+	return fmt.Sprintf("release to %s needs %d approval(s) first", e.Environment, e.Required)
+}
+
+// releaseApprovalsRequired is how many approvals a production release needs;
+// 0 turns approvals off. A value that does not parse asks for one, so a
+// typo never lets releases through unapproved.
+func releaseApprovalsRequired() int {
+	raw := strings.TrimSpace(os.Getenv(releaseApprovalsEnv))
+	if raw == "" {
+		return 0
+	}
+	required, err := strconv.Atoi(raw)
+	if err != nil || required < 0 {
+		return 1
+	}
+	return min(required, maxReleaseApprovals)
+}
+
+func (ap ReleaseApproval) approvals() int {
+	count := 0
+	for _, decision := range ap.Decisions {
+		if decision.Decision == approvalDecisionApprove {
+			count++
+		}
+	}
+	return count
+}
+
+func (ap ReleaseApproval) granted() bool {
+	return ap.Status == approvalStatusApproved && ap.approvals() >= ap.Required
+}
+
+func (ap ReleaseApproval) approvers() []string {
+	out := []string{}
+	for _, decision := range ap.Decisions {
+		if decision.Decision == approvalDecisionApprove {
+			out = append(out, decision.By)
+		}
+	}
+	return out
+}
+
+// decide records by's decision on a pending approval. The approval is
+// granted by the Required-th approval and ends at the first rejection.
+func (ap *ReleaseApproval) decide(by, decision, comment string, at time.Time) error {
+	if ap.Status != approvalStatusPending {
+		return requestError(http.StatusConflict, fmt.Sprintf("approval %s is already %s", ap.ID, ap.Status))
+	}
+	if decision == approvalDecisionApprove && ap.RequestedBy != "" && strings.EqualFold(by, ap.RequestedBy) {
+		return requestError(http.StatusForbidden, "a release cannot be approved by whoever requested it")
+	}
+	for _, earlier := range ap.Decisions {
+		if strings.EqualFold(earlier.By, by) {
+			return requestError(http.StatusConflict, fmt.Sprintf("%s already decided on approval %s", by, ap.ID))
+		}
+	}
+	ap.Decisions = append(ap.Decisions, ApprovalDecision{By: by, Decision: decision, Comment: comment, At: at})
+	switch {
+	case decision == approvalDecisionReject:
+		ap.Status = approvalStatusRejected
+	case ap.approvals() >= ap.Required:
+		ap.Status = approvalStatusApproved
+	}
+	return nil
+}
+
+// releaseApprovalGate refuses a production release that does not carry a
+// granted, unused approval for its target. Dry runs release nothing and pass.
+func (a *API) releaseApprovalGate(ctx context.Context, projectID string, kind OperationKind, opts opRunOptions) error {
+	required := releaseApprovalsRequired()
+	// A follow-up releases only the image the canary or staged color was approved for.
+	if kind != OpRelease || opts.execution.DryRun || required == 0 || opts.delivery.followUp() {
+		return nil
+	}
+	refused := releaseApprovalRequiredError{
+		ProjectID:   projectID,
+		Environment: opts.toEnv,
+		Required:    required,
+		Approval:    nil,
+	}
+	if opts.approvalID == "" {
+		return refused
+	}
+	approval, err := a.store.getReleaseApproval(ctx, opts.approvalID)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return refused
+	}
+	if err != nil {
+		return fmt.Errorf("read release approval: %w", err)
+	}
+	if approval.ProjectID != projectID || approval.ToEnv != opts.toEnv || !approval.granted() ||
+		!a.approvalUnused(ctx, approval) {
+		refused.Approval = &approval
+		return refused
+	}
+	return nil
+}
+
+func writeReleaseApprovalRequired(w http.ResponseWriter, err error) bool {
+	var approvalErr releaseApprovalRequiredError
+	if !errors.As(err, &approvalErr) {
+		return false
+	}
+	writeErrorDetails(w, http.StatusConflict, errorCodeApproval, map[string]any{
+		"accepted":    false,
+		"reason":      approvalErr.Error(),
+		"project_id":  approvalErr.ProjectID,
+		"environment": approvalErr.Environment,
+		"required":    approvalErr.Required,
+		"approval":    approvalErr.Approval,
+		"next_step":   "send the release event on its own to request approval",
+	})
+	return true
+}
+
+func writeReleaseApprovalPending(w http.ResponseWriter, err error) bool {
+	var pending releaseApprovalPending
+	if !errors.As(err, &pending) {
+		return false
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"accepted": true,
+		"project":  pending.Project,
+		"approval": pending.Approval,
+		"next_step": fmt.Sprintf("the release is queued once %d approver(s) POST /api/approvals/%s/approve",
+			pending.Approval.Required-pending.Approval.approvals(), pending.Approval.ID),
+	})
+	return true
+}
+
+// requestReleaseApproval finds what a release request needs before it can
+// be queued. A granted, unused approval for the same source image goes on
+// opts; otherwise the request is parked as a pending approval, reusing one
+// already open for the same image and superseding one for another image.
+func (a *API) requestReleaseApproval(
+	r *http.Request,
+	lifecycle transitionLifecycleContext,
+	opts opRunOptions,
+) (opRunOptions, error) {
+	required := releaseApprovalsRequired()
+	if lifecycle.kind != OpRelease || opts.execution.DryRun || required == 0 {
+		return opts, nil
+	}
+	ctx := r.Context()
+	image, err := a.transitionSourceImage(lifecycle.project.ID, lifecycle.spec, lifecycle.fromEnv)
+	if err != nil {
+		return opts, err
+	}
+	projectMu := a.projectStartLock(lifecycle.project.ID)
+	projectMu.Lock()
+	defer projectMu.Unlock()
+
+	approvals, err := a.store.listReleaseApprovals(ctx, lifecycle.project.ID)
+	if err != nil {
+		return opts, fmt.Errorf("read release approvals: %w", err)
+	}
+	for _, approval := range approvals {
+		if approval.ToEnv != lifecycle.toEnv {
+			continue
+		}
+		sameRelease := approval.FromEnv == lifecycle.fromEnv && approval.Image == image
+		switch {
+		case sameRelease && approval.granted() && a.approvalUnused(ctx, approval):
+			opts.approvalID = approval.ID
+			return opts, nil
+		case sameRelease && approval.Status == approvalStatusPending:
+			return opts, releaseApprovalPending{Approval: approval, Project: lifecycle.project}
+		case approval.Status == approvalStatusPending:
+			a.supersedeReleaseApproval(ctx, approval)
+		}
+	}
+
+	now := time.Now().UTC()
+	approval := ReleaseApproval{
+		ID:                    newID(),
+		ProjectID:             lifecycle.project.ID,
+		FromEnv:               lifecycle.fromEnv,
+		ToEnv:                 lifecycle.toEnv,
+		Image:                 image,
+		Required:              required,
+		Status:                approvalStatusPending,
+		RequestedBy:           "",
+		Decisions:             []ApprovalDecision{},
+		VulnerabilityOverride: opts.vulnOverride,
+		FreezeOverride:        opts.freezeOverride,
+		OpID:                  "",
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if principal, ok := requestPrincipal(ctx); ok {
+		approval.RequestedBy = principal.Name
+	}
+	if err = a.store.createReleaseApproval(ctx, approval); err != nil {
+		return opts, fmt.Errorf("store release approval: %w", err)
+	}
+	a.auditReleaseApproval(approval, "requested", approval.RequestedBy, "")
+	return opts, releaseApprovalPending{Approval: approval, Project: lifecycle.project}
+}
+
+// approvalUnused reports whether a granted approval can still start a
+// release: none was queued under it yet, or the last one failed.
+func (a *API) approvalUnused(ctx context.Context, approval ReleaseApproval) bool {
+	if approval.OpID == "" {
+		return true
+	}
+	op, err := a.store.GetOp(ctx, approval.OpID)
+	if err != nil {
+		return false
+	}
+	return op.Status == opStatusError || op.Status == opStatusCancelled
+}
+
+func (a *API) supersedeReleaseApproval(ctx context.Context, approval ReleaseApproval) {
+	_, err := a.store.updateReleaseApproval(ctx, approval.ID, func(ap *ReleaseApproval) error {
+		if ap.Status != approvalStatusPending {
+			return nil
+		}
+		ap.Status = approvalStatusSuperseded
+		return nil
+	})
+	if err != nil {
+		appLoggerForProcess().Source("api").Warnf("supersede release approval %s: %v", approval.ID, err)
+		return
+	}
+	a.auditReleaseApproval(approval, approvalStatusSuperseded, "", "")
+}
+
+// recordApprovalRelease notes on the approval the release op queued under
+// it, which uses the approval up unless that op fails.
+func (a *API) recordApprovalRelease(ctx context.Context, op Operation) {
+	if op.ApprovalID == "" {
+		return
+	}
+	_, err := a.store.updateReleaseApproval(ctx, op.ApprovalID, func(ap *ReleaseApproval) error {
+		ap.OpID = op.ID
+		return nil
+	})
+	if err != nil {
+		appLoggerForProcess().Source("api").Warnf("record release op %s on approval %s: %v", op.ID, op.ApprovalID, err)
+	}
+}
+
+// startApprovedRelease queues the release an approval was just granted for,
+// with the overrides it was requested with. The source environment must
+// still run the approved image.
+func (a *API) startApprovedRelease(ctx context.Context, approval ReleaseApproval) (Operation, error) {
+	lifecycle, err := a.resolveTransitionLifecycleContext(
+		ctx, approval.ProjectID, approval.FromEnv, approval.ToEnv, true,
+	)
+	if err != nil {
+		return Operation{}, err
+	}
+	image, err := a.transitionSourceImage(lifecycle.project.ID, lifecycle.spec, lifecycle.fromEnv)
+	if err != nil {
+		return Operation{}, err
+	}
+	if image != approval.Image {
+		return Operation{}, fmt.Errorf("%s now runs %s, not the approved %s; request the release again",
+			lifecycle.fromEnv, image, approval.Image)
+	}
+	opts := transitionOpRunOptions(lifecycle.fromEnv, lifecycle.toEnv, lifecycle.stage)
+	opts.vulnOverride = approval.VulnerabilityOverride
+	opts.freezeOverride = approval.FreezeOverride
+	opts.approvalID = approval.ID
+	op, err := a.enqueueOp(ctx, lifecycle.kind, lifecycle.project.ID, lifecycle.spec, opts)
+	if err != nil {
+		return Operation{}, err
+	}
+	a.auditVulnerabilityOverride(op)
+	a.recordApprovalRelease(ctx, op)
+	return op, nil
+}
+
+// transitionSourceImage is the image running in fromEnv, the one a
+// transition out of it would ship.
+func (a *API) transitionSourceImage(projectID string, spec ProjectSpec, fromEnv string) (string, error) {
+	imageByEnv, err := loadManifestImageTags(a.artifacts, projectID, spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to read manifest image tags: %w", err)
+	}
+	image, err := resolvePromotionSourceImage(a.artifacts, projectID, fromEnv, imageByEnv)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve source image: %w", err)
+	}
+	return image, nil
+}
+
+// releaseApprovalCheck is what a preview knows about a release's approval:
+// how many it needs and the approval it would be queued under, if any.
+type releaseApprovalCheck struct {
+	release  bool
+	required int
+	approval *ReleaseApproval
+}
+
+func (a *API) checkReleaseApproval(
+	ctx context.Context,
+	projectID string,
+	kind OperationKind,
+	fromEnv, toEnv, image string,
+) (releaseApprovalCheck, error) {
+	check := releaseApprovalCheck{release: kind == OpRelease, required: releaseApprovalsRequired(), approval: nil}
+	if !check.release || check.required == 0 {
+		return check, nil
+	}
+	approvals, err := a.store.listReleaseApprovals(ctx, projectID)
+	if err != nil {
+		return check, fmt.Errorf("failed to read release approvals: %w", err)
+	}
+	for _, approval := range approvals {
+		if approval.FromEnv != fromEnv || approval.ToEnv != toEnv || approval.Image != image {
+			continue
+		}
+		if approval.Status == approvalStatusPending || (approval.granted() && a.approvalUnused(ctx, approval)) {
+			check.approval = &approval
+			break
+		}
+	}
+	return check, nil
+}
+
+func approvalPreviewGate(check releaseApprovalCheck) TransitionPreviewGate {
+	gate := TransitionPreviewGate{
+		Code:   transitionGateApproval,
+		Title:  "Production release is approved",
+		Status: previewGatePassed,
+		Detail: "",
+	}
+	switch {
+	case !check.release:
+		gate.Detail = "Only production releases need approval."
+	case check.required == 0:
+		gate.Detail = "Releases need no approval (" + releaseApprovalsEnv + " is unset)."
+	case check.approval == nil:
+		gate.Status = previewGateWarning
+		gate.Detail = fmt.Sprintf("Needs %d approval(s); sending the release opens a request for them.",
+			check.required)
+	case check.approval.granted():
+		gate.Detail = fmt.Sprintf("Approved by %s.", strings.Join(check.approval.approvers(), ", "))
+	default:
+		gate.Status = previewGateWarning
+		gate.Detail = fmt.Sprintf("Approval %s has %d of %d approvals.",
+			check.approval.ID, check.approval.approvals(), check.approval.Required)
+	}
+	return gate
+}
+
+// handleApprovals serves GET /api/approvals, GET /api/approvals/{id}, and
+// POST /api/approvals/{id}/approve or /reject.
+func (a *API) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "approval data unavailable", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/approvals"), "/")
+	parts := strings.Split(rest, "/")
+	switch {
+	case len(parts) == 2 && (parts[1] == approvalDecisionApprove || parts[1] == approvalDecisionReject):
+		a.handleApprovalDecision(w, r, parts[0], parts[1])
+	case r.Method != http.MethodGet:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	case rest == "":
+		a.handleApprovalList(w, r)
+	case len(parts) == 1:
+		approval, err := a.store.getReleaseApproval(r.Context(), parts[0])
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			writeAPIError(w, "approval not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeAPIError(w, "failed to read approval", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, approval)
+	default:
+		writeAPIError(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (a *API) handleApprovalList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := strings.TrimSpace(query.Get("status"))
+	switch status {
+	case "", approvalStatusPending, approvalStatusApproved, approvalStatusRejected, approvalStatusSuperseded:
+	default:
+		writeAPIError(w, "status must be pending, approved, rejected, or superseded", http.StatusBadRequest)
+		return
+	}
+	approvals, err := a.store.listReleaseApprovals(r.Context(), strings.TrimSpace(query.Get("project_id")))
+	if err != nil {
+		writeAPIError(w, "failed to list approvals", http.StatusInternalServerError)
+		return
+	}
+	out := make([]ReleaseApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		if status == "" || approval.Status == status {
+			out = append(out, approval)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleApprovalDecision records an approval or rejection, and queues the
+// release when the decision grants the approval.
+func (a *API) handleApprovalDecision(w http.ResponseWriter, r *http.Request, id, decision string) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req approvalDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	by := strings.TrimSpace(req.By)
+	if principal, ok := requestPrincipal(r.Context()); ok {
+		by = principal.Name
+	}
+	comment := strings.TrimSpace(req.Comment)
+	switch {
+	case by == "" || utf8.RuneCountInString(by) > maxApprovalByLength:
+		writeAPIError(w, fmt.Sprintf("by required, at most %d characters", maxApprovalByLength), http.StatusBadRequest)
+		return
+	case decision == approvalDecisionReject && comment == "":
+		writeAPIError(w, "comment required to reject", http.StatusBadRequest)
+		return
+	case utf8.RuneCountInString(comment) > maxApprovalCommentLength:
+		writeAPIError(w, "comment is too long", http.StatusBadRequest)
+		return
+	}
+
+	approval, err := a.store.updateReleaseApproval(r.Context(), id, func(ap *ReleaseApproval) error {
+		return ap.decide(by, decision, comment, time.Now().UTC())
+	})
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		writeAPIError(w, "approval not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+	a.auditReleaseApproval(approval, decision, by, comment)
+	resp := approvalDecisionResponse{Approval: approval, Op: nil, ReleaseError: ""}
+	if approval.Status == approvalStatusApproved {
+		op, releaseErr := a.startApprovedRelease(r.Context(), approval)
+		if releaseErr != nil {
+			appLoggerForProcess().Source("api").Warnf("approved release %s not queued: %v", approval.ID, releaseErr)
+			resp.ReleaseError = releaseErr.Error()
+		} else {
+			resp.Op = &op
+			resp.Approval.OpID = op.ID
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// auditReleaseApproval logs a step in an approval's life and appends it to
+// the project's approvals audit log.
+func (a *API) auditReleaseApproval(approval ReleaseApproval, action, actor, comment string) {
+	appLoggerForProcess().Source("api").Infof(
+		"release approval %s id=%s project=%s env=%s image=%s actor=%q",
+		action,
+		approval.ID,
+		approval.ProjectID,
+		approval.ToEnv,
+		approval.Image,
+		actor,
+	)
+	a.appendProjectAuditLine(approval.ProjectID, "approvals", fmt.Sprintf(
+		"%s approval %s id=%s env=%s image=%s actor=%q comment=%q",
+		time.Now().UTC().Format(time.RFC3339),
+		action,
+		approval.ID,
+		approval.ToEnv,
+		approval.Image,
+		actor,
+		comment,
+	))
+}
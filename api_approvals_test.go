@@ -0,0 +1,148 @@
+//nolint:testpackage,exhaustruct // Approval tests reuse the internal promotion preview fixture.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_ProductionReleaseWaitsForApprovals(t *testing.T) {
+	t.Setenv(releaseApprovalsEnv, "2")
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	image := "example.local/approve:v1"
+	writePreviewDeploymentImage(t, fixture.artifacts, fixture.projectID, "staging", image)
+	if _, err := fixture.api.store.PutRelease(ctx, ReleaseRecord{
+		ProjectID: fixture.projectID, Environment: "staging", OpID: "op-approval-source", OpKind: OpPromote,
+		DeliveryStage: DeliveryStagePromote, ToEnv: "staging", Image: image, CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("put source release: %v", err)
+	}
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	call := func(method, path string, body any) (int, map[string]any) {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	release := map[string]any{"project_id": fixture.projectID, "from_env": "staging"}
+
+	preview := requestPromotionPreviewForTest(t, fixture, map[string]any{
+		"project_id": fixture.projectID, "from_env": "staging", "to_env": "prod",
+	})
+	if gate := findPreviewGate(preview, transitionGateApproval); gate.Status != previewGateWarning {
+		t.Fatalf("expected the approval gate to warn before a request, got %+v", gate)
+	}
+
+	code, out := call(http.MethodPost, "/api/events/release", release)
+	approval, _ := out["approval"].(map[string]any)
+	if code != http.StatusAccepted || approval["status"] != approvalStatusPending || out["op"] != nil {
+		t.Fatalf("expected the release parked as a pending approval, got %d %v", code, out)
+	}
+	approvalID, _ := approval["id"].(string)
+	_, out = call(http.MethodPost, "/api/events/release", release)
+	if out["approval"].(map[string]any)["id"] != approvalID {
+		t.Fatalf("expected a repeated request to reuse approval %s, got %v", approvalID, out)
+	}
+	if err := fixture.api.releaseApprovalGate(ctx, fixture.projectID, OpRelease,
+		transitionOpRunOptions("staging", "prod", DeliveryStageRelease)); err == nil {
+		t.Fatal("expected a release queued without an approval to be refused")
+	}
+
+	approvePath := "/api/approvals/" + approvalID + "/approve"
+	if code, out = call(http.MethodPost, approvePath, map[string]any{"by": "alice"}); code != http.StatusOK ||
+		out["op"] != nil {
+		t.Fatalf("expected one approval to leave the release waiting, got %d %v", code, out)
+	}
+	if code, _ = call(http.MethodPost, approvePath, map[string]any{"by": "Alice"}); code != http.StatusConflict {
+		t.Fatalf("expected a second decision by the same person refused, got %d", code)
+	}
+	code, out = call(http.MethodPost, approvePath, map[string]any{"by": "bob", "comment": "CAB ok"})
+	op, _ := out["op"].(map[string]any)
+	if code != http.StatusOK || op == nil || op["approval_id"] != approvalID {
+		t.Fatalf("expected the second approval to queue the release, got %d %v", code, out)
+	}
+	stored, err := fixture.api.store.getReleaseApproval(ctx, approvalID)
+	if err != nil || !stored.granted() || stored.OpID != op["id"] {
+		t.Fatalf("expected the approval granted and used by the release, got %+v (%v)", stored, err)
+	}
+	listReq, _ := http.NewRequestWithContext(ctx, http.MethodGet,
+		srv.URL+"/api/approvals?status=approved&project_id="+fixture.projectID, nil)
+	resp, err := srv.Client().Do(listReq)
+	if err != nil {
+		t.Fatalf("list approvals: %v", err)
+	}
+	var listed []ReleaseApproval
+	_ = json.NewDecoder(resp.Body).Decode(&listed)
+	resp.Body.Close()
+	if len(listed) != 1 || listed[0].ID != approvalID {
+		t.Fatalf("expected only the granted approval listed, got %+v", listed)
+	}
+
+	msg := ProjectOpMsg{Kind: OpRelease, ProjectID: fixture.projectID, ApprovalID: approvalID}
+	if err = verifyReleaseApproval(ctx, fixture.api.store, msg, "prod", image); err != nil {
+		t.Fatalf("expected the promoter to accept the approved image, got %v", err)
+	}
+	if err = verifyReleaseApproval(ctx, fixture.api.store, msg, "prod", "example.local/approve:v2"); err == nil {
+		t.Fatal("expected the promoter to refuse an image the approval does not cover")
+	}
+	msg.ApprovalID = ""
+	if err = verifyReleaseApproval(ctx, fixture.api.store, msg, "prod", image); err == nil {
+		t.Fatal("expected the promoter to refuse a release without an approval")
+	}
+
+	// The queued release still holds the approval, so a new request opens
+	// another one, which a rejection ends.
+	_, out = call(http.MethodPost, "/api/events/release", release)
+	second, _ := out["approval"].(map[string]any)["id"].(string)
+	if second == "" || second == approvalID {
+		t.Fatalf("expected a new approval while the first is in use, got %v", out)
+	}
+	rejectPath := "/api/approvals/" + second + "/reject"
+	if code, _ = call(http.MethodPost, rejectPath, map[string]any{"by": "carol"}); code != http.StatusBadRequest {
+		t.Fatalf("expected a rejection without a comment refused, got %d", code)
+	}
+	code, out = call(http.MethodPost, rejectPath, map[string]any{"by": "carol", "comment": "not this week"})
+	if code != http.StatusOK || out["approval"].(map[string]any)["status"] != approvalStatusRejected {
+		t.Fatalf("expected the approval rejected, got %d %v", code, out)
+	}
+	if code, _ = call(http.MethodPost, "/api/approvals/"+second+"/approve", map[string]any{"by": "dan"}); code !=
+		http.StatusConflict {
+		t.Fatalf("expected a rejected approval to stay rejected, got %d", code)
+	}
+
+	auditDir := filepath.Join(filepath.Dir(fixture.artifacts.ProjectDir(fixture.projectID)), "_audit")
+	audit, _ := os.ReadFile(filepath.Join(auditDir, fixture.projectID+".approvals.log"))
+	if !strings.Contains(string(audit), "approval approve id="+approvalID) ||
+		!strings.Contains(string(audit), "approval reject id="+second) {
+		t.Fatalf("expected approval decisions in the audit log, got %q", audit)
+	}
+}
+
+func findPreviewGate(preview PromotionPreviewResponse, code string) TransitionPreviewGate {
+	for _, gate := range preview.Gates {
+		if gate.Code == code {
+			return gate
+		}
+	}
+	return TransitionPreviewGate{}
+}
@@ -0,0 +1,73 @@
+//nolint:testpackage,exhaustruct // Cleanup tests drive enqueueOp and the worker action against the internal store.
+package platform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_ArtifactCleanupRemovesBuildOutputsOnly(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	projectID := "project-artifact-cleanup"
+	now := time.Now().UTC()
+	if err := fixture.store.PutProject(context.Background(), Project{
+		ID:        projectID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Spec:      workerRuntimeSpec("cleanup-app"),
+		Status:    ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now},
+	}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	artifacts := NewFSArtifacts(t.TempDir())
+	for _, rel := range []string{"build/image.txt", "repos/source/main.go"} {
+		if _, err := artifacts.WriteFile(projectID, rel, []byte("x\n")); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	api := &API{nc: fixture.nc, store: fixture.store, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	artifactsURL := srv.URL + "/api/projects/" + projectID + "/artifacts"
+
+	for _, prefix := range []string{"repos/", "../build", ""} {
+		if status := deleteProjectForTest(t, srv, artifactsURL+"?prefix="+prefix); status != http.StatusBadRequest {
+			t.Fatalf("expected 400 for prefix %q, got %d", prefix, status)
+		}
+	}
+	if status := deleteProjectForTest(t, srv, artifactsURL+"?prefix=build/"); status != http.StatusAccepted {
+		t.Fatalf("expected 202 for build cleanup, got %d", status)
+	}
+
+	page, err := fixture.store.listProjectOps(context.Background(), projectID, projectOpsListQuery{Limit: 1})
+	if err != nil || len(page.Ops) != 1 || page.Ops[0].Kind != OpCleanup {
+		t.Fatalf("expected queued cleanup op, got %#v err=%v", page.Ops, err)
+	}
+	ops := page.Ops
+	msg := newProjectOpMsg(ops[0].ID, OpCleanup, projectID, workerRuntimeSpec("cleanup-app"), cleanupOpRunOptions("build"), now)
+	if _, err = artifactCleanupWorkerAction(context.Background(), fixture.store, artifacts, msg); err != nil {
+		t.Fatalf("run cleanup: %v", err)
+	}
+
+	files, err := artifacts.ListFiles(projectID)
+	if err != nil || len(files) != 1 || files[0] != "repos/source/main.go" {
+		t.Fatalf("expected only repo files to remain, got %#v err=%v", files, err)
+	}
+	op, err := fixture.store.GetOp(context.Background(), ops[0].ID)
+	if err != nil || op.Status != opStatusDone {
+		t.Fatalf("expected finalized cleanup op, got %#v err=%v", op, err)
+	}
+	audit, err := os.ReadFile(filepath.Join(filepath.Dir(artifacts.ProjectDir(projectID)), "_audit", projectID+".cleanup.log"))
+	if err != nil || !strings.Contains(string(audit), "build/image.txt") {
+		t.Fatalf("expected cleanup audit to list removed files, got %q err=%v", audit, err)
+	}
+}
@@ -0,0 +1,242 @@
+package platform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Artifact uploads: tools outside the pipeline (scanners, test runners) add
+// files to a project's tree. Only allowlisted paths and content types are
+// taken. Files under evidence/, and the vulnerability report, are listed in
+// the project journey and captured on every release recorded after them.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	evidenceArtifactRoot       = "evidence"
+	defaultArtifactUploadPaths = evidenceArtifactRoot + "/," + vulnerabilityReportPath
+	artifactUploadAction       = "upload artifacts to"
+)
+
+// ArtifactUploadResponse is the 201 body of an artifact upload.
+type ArtifactUploadResponse struct {
+	ProjectID   string    `json:"project_id"`
+	Path        string    `json:"path"`
+	Size        int       `json:"size"`
+	SHA256      string    `json:"sha256"`
+	ContentType string    `json:"content_type"`
+	UploadedBy  string    `json:"uploaded_by,omitempty"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// handleProjectArtifactUpload stores the request body at relPath:
+//
+//	POST /api/projects/{id}/artifacts/evidence/junit.xml
+//
+// An upload replaces any file already at the path. The body must be
+// non-empty, at most artifactUploadMaxBytes, and of an accepted content
+// type; JSON bodies must parse. An upload that would take the project past
+// its artifact_bytes quota gets 429.
+func (a *API) handleProjectArtifactUpload(w http.ResponseWriter, r *http.Request, projectID, rawPath string) {
+	if a.store == nil || a.artifacts == nil {
+		writeAPIError(w, "artifact upload unavailable", http.StatusInternalServerError)
+		return
+	}
+	relPath, err := normalizeArtifactUploadPath(rawPath, artifactUploadPaths())
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	contentType, err := artifactUploadContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeAPIError(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	if err = a.authorizeProjectChange(r.Context(), project, artifactUploadAction); err != nil {
+		if writeProjectAccessDenied(w, err) {
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, ok := readArtifactUploadBody(w, r, contentType)
+	if !ok {
+		return
+	}
+	if err = a.artifactQuotaConflict(projectID, int64(len(data))); err != nil {
+		if writeProjectQuotaExceeded(w, err) {
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	written, err := a.artifacts.WriteFile(projectID, relPath, data)
+	if err != nil {
+		writeAPIError(w, "failed to write artifact", http.StatusInternalServerError)
+		return
+	}
+	principal, _ := requestPrincipal(r.Context())
+	sum := sha256.Sum256(data)
+	out := ArtifactUploadResponse{
+		ProjectID:   projectID,
+		Path:        written,
+		Size:        len(data),
+		SHA256:      hex.EncodeToString(sum[:]),
+		ContentType: contentType,
+		UploadedBy:  principal.Name,
+		UploadedAt:  time.Now().UTC(),
+	}
+	appLoggerForProcess().Source("api").Infof(
+		"project=%s artifact uploaded path=%s bytes=%d sha256=%s by=%q",
+		projectID, out.Path, out.Size, out.SHA256, out.UploadedBy,
+	)
+	writeJSON(w, http.StatusCreated, out)
+}
+
+// readArtifactUploadBody reads the whole upload, writing the error response
+// itself when the body is too large, empty, or not the JSON it claims to be.
+func readArtifactUploadBody(w http.ResponseWriter, r *http.Request, contentType string) ([]byte, bool) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeAPIErrorResponse(w, http.StatusRequestEntityTooLarge, apiErrorResponse{
+				Code:    errorCodeTooLarge,
+				Message: "artifact too large (limit " + strconv.FormatInt(tooLarge.Limit, 10) + " bytes)",
+				Field:   "",
+				Details: map[string]any{"limit_bytes": tooLarge.Limit},
+				OpID:    "",
+			})
+			return nil, false
+		}
+		writeAPIError(w, "failed to read artifact body", http.StatusBadRequest)
+		return nil, false
+	}
+	if len(data) == 0 {
+		writeAPIError(w, "artifact body is empty", http.StatusBadRequest)
+		return nil, false
+	}
+	if isJSONUploadType(contentType) && !json.Valid(data) {
+		writeAPIError(w, "artifact body is not valid json", http.StatusBadRequest)
+		return nil, false
+	}
+	return data, true
+}
+
+// isArtifactUploadRequest matches POST /api/projects/{id}/artifacts/{path...}.
+func isArtifactUploadRequest(r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, "/api/projects/")
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	return ok && len(parts) > projectRelPathPartsMin && parts[1] == "artifacts"
+}
+
+// artifactUploadPaths reads PAAS_ARTIFACT_UPLOAD_PATHS, a comma-separated
+// allowlist: an entry ending in "/" admits everything below it, any other
+// entry that one file.
+func artifactUploadPaths() []string {
+	raw := strings.TrimSpace(os.Getenv(artifactUploadPathsEnv))
+	if raw == "" {
+		raw = defaultArtifactUploadPaths
+	}
+	out := []string{}
+	for entry := range strings.SplitSeq(raw, ",") {
+		entry = strings.TrimPrefix(strings.TrimSpace(entry), "/")
+		if entry != "" {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// normalizeArtifactUploadPath rejects anything but a plain relative path
+// inside the allowlist. Traversal, empty segments, and .git directories are
+// refused before the allowlist is consulted.
+func normalizeArtifactUploadPath(raw string, allowed []string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(raw, "/") || strings.Contains(raw, `\`) {
+		return "", errors.New("artifact path must be a relative path")
+	}
+	for segment := range strings.SplitSeq(raw, "/") {
+		if segment == "" || segment == "." || segment == ".." || segment == ".git" {
+			return "", fmt.Errorf("artifact path %q has an invalid segment %q", raw, segment)
+		}
+	}
+	for _, entry := range allowed {
+		if raw == entry || (strings.HasSuffix(entry, "/") && strings.HasPrefix(raw, entry)) {
+			return raw, nil
+		}
+	}
+	return "", fmt.Errorf("artifact path %s is not open to uploads (allowed: %s)", raw, strings.Join(allowed, ", "))
+}
+
+func artifactUploadContentTypes() []string {
+	return []string{
+		"application/json",
+		"application/sarif+json",
+		"application/x-ndjson",
+		"application/xml",
+		"application/yaml",
+		"text/csv",
+		"text/markdown",
+		"text/plain",
+		"text/xml",
+	}
+}
+
+// artifactUploadContentType returns the media type of header, without
+// parameters, when uploads accept it.
+func artifactUploadContentType(header string) (string, error) {
+	accepted := artifactUploadContentTypes()
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil || !slices.Contains(accepted, mediaType) {
+		return "", fmt.Errorf("content type %q is not accepted (use one of %s)", header, strings.Join(accepted, ", "))
+	}
+	return mediaType, nil
+}
+
+func isJSONUploadType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isEvidencePath reports whether an artifact is evidence contributed from
+// outside the pipeline.
+func isEvidencePath(path string) bool {
+	return strings.HasPrefix(path, evidenceArtifactRoot+"/") || path == vulnerabilityReportPath
+}
+
+// artifactEvidence lists a project's evidence files. A listing failure
+// leaves a release without evidence rather than failing it.
+func artifactEvidence(artifacts ArtifactStore, projectID string) []ArtifactFileInfo {
+	if artifacts == nil {
+		return nil
+	}
+	infos, err := artifacts.StatFiles(projectID)
+	if err != nil {
+		return nil
+	}
+	var out []ArtifactFileInfo
+	for _, info := range infos {
+		if isEvidencePath(info.Path) {
+			out = append(out, info)
+		}
+	}
+	return out
+}
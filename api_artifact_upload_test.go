@@ -0,0 +1,102 @@
+//nolint:testpackage,exhaustruct // Upload tests drive the internal router and read back the internal store.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPI_ArtifactUploadHonorsAllowlistAndType(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-upload"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-upload", OpCreate, workerRuntimeSpec("upload-app"))
+	artifacts := NewFSArtifacts(t.TempDir())
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	upload := func(path, contentType string, body []byte) (int, map[string]any) {
+		t.Helper()
+		url := srv.URL + "/api/projects/" + projectID + "/artifacts/" + path
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("upload %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	junit := []byte(`<testsuite name="unit" tests="1"></testsuite>`)
+	status, body := upload("evidence/tests/junit.xml", "application/xml; charset=utf-8", junit)
+	if status != http.StatusCreated || body["path"] != "evidence/tests/junit.xml" || body["sha256"] == "" {
+		t.Fatalf("expected the upload to be stored, got %d %v", status, body)
+	}
+	onDisk, err := artifacts.ReadFile(projectID, "evidence/tests/junit.xml")
+	if err != nil || !bytes.Equal(onDisk, junit) {
+		t.Fatalf("expected the uploaded bytes on disk, got %q err=%v", onDisk, err)
+	}
+
+	for _, tc := range []struct {
+		path, contentType, body string
+		want                    int
+	}{
+		{path: "build/image.txt", contentType: "text/plain", body: "evil:latest", want: http.StatusBadRequest},
+		{path: "evidence/.git/config", contentType: "text/plain", body: "x", want: http.StatusBadRequest},
+		{
+			path: "evidence/report.bin", contentType: "application/octet-stream", body: "x",
+			want: http.StatusUnsupportedMediaType,
+		},
+		{path: vulnerabilityReportPath, contentType: "application/json", body: "{", want: http.StatusBadRequest},
+		{path: "evidence/empty.txt", contentType: "text/plain", body: "", want: http.StatusBadRequest},
+	} {
+		if status, body = upload(tc.path, tc.contentType, []byte(tc.body)); status != tc.want {
+			t.Fatalf("upload %s as %s: expected %d, got %d %v", tc.path, tc.contentType, tc.want, status, body)
+		}
+	}
+	oversized := bytes.Repeat([]byte("x"), artifactUploadMaxBytes+1)
+	if status, body = upload("evidence/big.txt", "text/plain", oversized); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for an oversized upload, got %d %v", status, body)
+	}
+
+	t.Setenv(artifactUploadPathsEnv, "qa/")
+	if status, _ = upload("qa/results.csv", "text/csv", []byte("case,result\n")); status != http.StatusCreated {
+		t.Fatalf("expected the configured allowlist to admit qa/, got %d", status)
+	}
+	if status, _ = upload("evidence/late.txt", "text/plain", []byte("x")); status != http.StatusBadRequest {
+		t.Fatalf("expected the configured allowlist to replace the default, got %d", status)
+	}
+
+	resp, err := http.Get(srv.URL + "/api/projects/" + projectID + "/journey")
+	if err != nil {
+		t.Fatalf("get journey: %v", err)
+	}
+	defer resp.Body.Close()
+	var journey projectJourneyResponse
+	if err = json.NewDecoder(resp.Body).Decode(&journey); err != nil {
+		t.Fatalf("decode journey: %v", err)
+	}
+	if strings.Join(journey.Journey.Evidence, ",") != "evidence/tests/junit.xml" ||
+		journey.Journey.ArtifactStats.Evidence != 1 {
+		t.Fatalf("expected the upload in the journey evidence, got %#v", journey.Journey)
+	}
+
+	release := ReleaseRecord{ID: "release-upload", ProjectID: projectID, Environment: "dev", OpID: "op-upload"}
+	if err = persistReleaseRecord(ctx, fixture.store, artifacts, release); err != nil {
+		t.Fatalf("persist release: %v", err)
+	}
+	stored, err := fixture.store.GetRelease(ctx, release.ID)
+	if err != nil || len(stored.Evidence) != 1 || stored.Evidence[0].Path != "evidence/tests/junit.xml" {
+		t.Fatalf("expected the release to capture the evidence, got %#v err=%v", stored.Evidence, err)
+	}
+}
@@ -0,0 +1,138 @@
+package platform
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Artifact downloads name their content type from the file so a browser (or
+// the UI's fetch) can show YAML, JSON and text in place. ?inline=1 asks for
+// inline disposition, ?stat=1 for metadata instead of the body.
+
+const (
+	artifactContentTypeBinary   = "application/octet-stream"
+	artifactContentTypeJSON     = "application/json"
+	artifactContentTypeText     = "text/plain; charset=utf-8"
+	artifactContentTypeYAML     = "text/yaml; charset=utf-8"
+	artifactContentTypeMarkdown = "text/markdown; charset=utf-8"
+
+	// artifactSniffBytes bounds how much of an unrecognised file is checked
+	// for text before it is served as binary.
+	artifactSniffBytes = 8 << 10
+)
+
+// artifactStatResponse is the ?stat=1 view of one artifact.
+type artifactStatResponse struct {
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time,omitzero"`
+	SHA256      string    `json:"sha256"`
+	ContentType string    `json:"content_type"`
+}
+
+// artifactContentType picks a content type from the file name, falling back
+// to text/plain for unrecognised files that look like UTF-8 text. YAML and
+// Markdown use text/ types, which browsers render rather than download.
+func artifactContentType(relPath string, data []byte) string {
+	base := strings.ToLower(path.Base(relPath))
+	switch path.Ext(base) {
+	case ".yaml", ".yml":
+		return artifactContentTypeYAML
+	case ".json":
+		return artifactContentTypeJSON
+	case ".md":
+		return artifactContentTypeMarkdown
+	case ".txt", ".log", ".dockerfile":
+		return artifactContentTypeText
+	}
+	if base == "dockerfile" || base == "containerfile" || strings.HasPrefix(base, "dockerfile.") {
+		return artifactContentTypeText
+	}
+	sniff := data[:min(len(data), artifactSniffBytes)]
+	if !bytes.ContainsRune(sniff, 0) && utf8.Valid(trimPartialRune(sniff, len(data))) {
+		return artifactContentTypeText
+	}
+	return artifactContentTypeBinary
+}
+
+// trimPartialRune drops a rune the sniff window cut in half, so a long text
+// file is not mistaken for binary.
+func trimPartialRune(sniff []byte, total int) []byte {
+	if len(sniff) == total {
+		return sniff
+	}
+	for i := 0; i < utf8.UTFMax && len(sniff) > 0; i++ {
+		if r, _ := utf8.DecodeLastRune(sniff); r != utf8.RuneError {
+			break
+		}
+		sniff = sniff[:len(sniff)-1]
+	}
+	return sniff
+}
+
+// artifactQueryFlag reports a true ?name= flag; "1" and "true" both count.
+func artifactQueryFlag(r *http.Request, name string) bool {
+	value, err := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get(name)))
+	return err == nil && value
+}
+
+// serveProjectArtifact writes one artifact with a detected content type, a
+// content-hash ETag and the file's mtime as Last-Modified; http.ServeContent
+// answers conditional and range requests from those.
+func (a *API) serveProjectArtifact(w http.ResponseWriter, r *http.Request, projectID, relPath string) {
+	info, err := a.artifacts.StatFile(projectID, relPath)
+	var data []byte
+	if err == nil {
+		data, err = a.artifacts.ReadFile(projectID, relPath)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeAPIError(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeAPIError(w, "failed to read artifact", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	contentType := artifactContentType(relPath, data)
+	w.Header().Set("ETag", `"`+digest+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if artifactQueryFlag(r, "stat") {
+		if etagListMatches(r.Header.Get("If-None-Match"), `"`+digest+`"`) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		writeJSON(w, http.StatusOK, artifactStatResponse{
+			Path:        info.Path,
+			Size:        int64(len(data)),
+			ModTime:     info.ModTime,
+			SHA256:      digest,
+			ContentType: contentType,
+		})
+		return
+	}
+
+	// A client that stops reading holds the slot only until this deadline.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(artifactDownloadWriteWait(len(data))))
+
+	disposition := "attachment"
+	if artifactQueryFlag(r, "inline") {
+		disposition = "inline"
+	}
+	name := path.Base(relPath)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, name))
+	http.ServeContent(w, r, name, info.ModTime, bytes.NewReader(data))
+}
@@ -0,0 +1,107 @@
+//nolint:testpackage,exhaustruct // Artifact view tests drive the internal router against the internal store.
+package platform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPI_ArtifactDownloadsDetectTypeAndServeInlineAndStat(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-artifact-view"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-view", OpCreate, workerRuntimeSpec("view-app"))
+	artifacts := NewFSArtifacts(t.TempDir())
+	files := map[string]string{
+		"deploy/dev/deployment.yaml":    "kind: Deployment\n",
+		"build/publish.json":            `{"image":"local/view:1"}`,
+		"repos/source/Dockerfile":       "FROM scratch\n",
+		"release-notes/r1/notes.md":     "# Notes\n",
+		"build/output.bin":              "\x00\x01\x02",
+		"repos/source/cmd/server/go.go": "package main\n",
+	}
+	for rel, content := range files {
+		if _, err := artifacts.WriteFile(projectID, rel, []byte(content)); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	get := func(target string, header http.Header) (*http.Response, []byte) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", target, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, body
+	}
+	base := "/api/projects/" + projectID + "/artifacts/"
+
+	for rel, want := range map[string]string{
+		"deploy/dev/deployment.yaml":    artifactContentTypeYAML,
+		"build/publish.json":            artifactContentTypeJSON,
+		"repos/source/Dockerfile":       artifactContentTypeText,
+		"release-notes/r1/notes.md":     artifactContentTypeMarkdown,
+		"build/output.bin":              artifactContentTypeBinary,
+		"repos/source/cmd/server/go.go": artifactContentTypeText,
+	} {
+		resp, body := get(base+rel, nil)
+		if resp.StatusCode != http.StatusOK || string(body) != files[rel] {
+			t.Fatalf("expected %s to download, got %d %q", rel, resp.StatusCode, body)
+		}
+		if got := resp.Header.Get("Content-Type"); got != want {
+			t.Fatalf("expected %s to be served as %q, got %q", rel, want, got)
+		}
+	}
+
+	resp, _ := get(base+"deploy/dev/deployment.yaml", nil)
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="deployment.yaml"` {
+		t.Fatalf("expected attachment disposition by default, got %q", got)
+	}
+	if resp.Header.Get("Last-Modified") == "" {
+		t.Fatal("expected a Last-Modified header from the file's mtime")
+	}
+	etag := resp.Header.Get("ETag")
+	sum := sha256.Sum256([]byte(files["deploy/dev/deployment.yaml"]))
+	if etag != `"`+hex.EncodeToString(sum[:])+`"` {
+		t.Fatalf("expected the content hash as ETag, got %q", etag)
+	}
+	resp, _ = get(base+"deploy/dev/deployment.yaml?inline=1", nil)
+	if got := resp.Header.Get("Content-Disposition"); got != `inline; filename="deployment.yaml"` {
+		t.Fatalf("expected inline disposition, got %q", got)
+	}
+	resp, body := get(base+"deploy/dev/deployment.yaml", http.Header{"If-None-Match": {etag}})
+	if resp.StatusCode != http.StatusNotModified || len(body) != 0 {
+		t.Fatalf("expected 304 for a matching ETag, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, body = get(base+"build/publish.json?stat=1", nil)
+	var stat artifactStatResponse
+	if err := json.Unmarshal(body, &stat); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected stat JSON, got %d %s (%v)", resp.StatusCode, body, err)
+	}
+	sum = sha256.Sum256([]byte(files["build/publish.json"]))
+	if stat.Path != "build/publish.json" || stat.Size != int64(len(files["build/publish.json"])) ||
+		stat.SHA256 != hex.EncodeToString(sum[:]) || stat.ContentType != artifactContentTypeJSON ||
+		stat.ModTime.IsZero() {
+		t.Fatalf("unexpected stat response: %+v", stat)
+	}
+	if resp, _ = get(base+"build/missing.json?stat=1", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 stat for a missing artifact, got %d", resp.StatusCode)
+	}
+}
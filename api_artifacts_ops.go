@@ -1,12 +1,11 @@
 package platform
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,8 +15,10 @@ import (
 
 type systemStatusNATSSummary struct {
 	Embedded     bool   `json:"embedded"`
+	URL          string `json:"url,omitempty"`
 	StoreDir     string `json:"store_dir,omitempty"`
 	StoreDirMode string `json:"store_dir_mode"`
+	Auth         string `json:"auth,omitempty"`
 }
 
 type systemStatusRealtimeSummary struct {
@@ -30,18 +31,20 @@ type systemStatusResponse struct {
 	Version              string                      `json:"version,omitempty"`
 	HTTPAddr             string                      `json:"http_addr"`
 	ArtifactsRoot        string                      `json:"artifacts_root"`
+	ArtifactRoots        map[string]string           `json:"artifact_roots,omitempty"`
 	BuilderModeRequested string                      `json:"builder_mode_requested"`
 	BuilderModeEffective string                      `json:"builder_mode_effective"`
 	BuilderModeReason    string                      `json:"builder_mode_reason,omitempty"`
 	CommitWatcherEnabled bool                        `json:"commit_watcher_enabled"`
 	NATS                 systemStatusNATSSummary     `json:"nats"`
 	Realtime             systemStatusRealtimeSummary `json:"realtime"`
+	ReadOnly             readOnlyState               `json:"read_only"`
 	Time                 time.Time                   `json:"time"`
 }
 
 func (a *API) handleSystem(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	builderReason := ""
@@ -52,27 +55,44 @@ func (a *API) handleSystem(w http.ResponseWriter, r *http.Request) {
 		Version:              strings.TrimSpace(a.runtimeVersion),
 		HTTPAddr:             strings.TrimSpace(a.runtimeHTTPAddr),
 		ArtifactsRoot:        strings.TrimSpace(a.runtimeArtifactsRoot),
+		ArtifactRoots:        a.alternateArtifactRoots(),
 		BuilderModeRequested: string(a.runtimeBuilderMode.requestedMode),
 		BuilderModeEffective: string(a.runtimeBuilderMode.effectiveMode),
 		BuilderModeReason:    builderReason,
 		CommitWatcherEnabled: a.runtimeCommitWatcherEnabled,
 		NATS: systemStatusNATSSummary{
 			Embedded:     a.runtimeNATSEmbedded,
+			URL:          a.runtimeNATSURL,
 			StoreDir:     strings.TrimSpace(a.runtimeNATSStoreDir),
-			StoreDirMode: natsStoreModeLabel(a.runtimeNATSStoreEphemeral),
+			StoreDirMode: natsStoreModeLabel(a.runtimeNATSEmbedded, a.runtimeNATSStoreEphemeral),
+			Auth:         string(a.runtimeNATSAuth),
 		},
 		Realtime: systemStatusRealtimeSummary{
 			SSEEnabled:           a.realtimeSSEEnabled(),
 			SSEReplayWindow:      a.realtimeSSEReplayWindow(),
 			SSEHeartbeatInterval: a.effectiveOpHeartbeatInterval().String(),
 		},
-		Time: time.Now().UTC(),
+		ReadOnly: a.systemReadOnlyState(r),
+		Time:     time.Now().UTC(),
 	})
 }
 
+// systemReadOnlyState reports read-only mode as off when it cannot be read;
+// mutations still check it themselves.
+func (a *API) systemReadOnlyState(r *http.Request) readOnlyState {
+	if a.store == nil || a.store.kvOps == nil {
+		return readOnlyState{}
+	}
+	state, err := a.store.getReadOnlyState(r.Context())
+	if err != nil {
+		return readOnlyState{}
+	}
+	return state
+}
+
 func (a *API) handleHealthz(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -81,7 +101,38 @@ func (a *API) handleHealthz(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func natsStoreModeLabel(ephemeral bool) string {
+// handleReadyz reports whether every pipeline worker has bound its consumer
+// and is heartbeating; load balancers should route ops only to ready replicas.
+func (a *API) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.readiness == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"ready": true, "time": time.Now().UTC()})
+		return
+	}
+	status := a.readiness.status()
+	code := http.StatusOK
+	if !status.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, status)
+}
+
+// alternateArtifactRoots lists the PAAS_ARTIFACT_ROOTS entries, if any.
+func (a *API) alternateArtifactRoots() map[string]string {
+	fsArtifacts, ok := a.artifacts.(*FSArtifacts)
+	if !ok {
+		return nil
+	}
+	return fsArtifacts.alternateRoots()
+}
+
+func natsStoreModeLabel(embedded, ephemeral bool) string {
+	if !embedded {
+		return "external"
+	}
 	if ephemeral {
 		return "ephemeral"
 	}
@@ -101,6 +152,7 @@ func (a *API) realtimeSSEReplayWindow() int {
 
 type projectOpsListItem struct {
 	ID                string        `json:"id"`
+	ProjectID         string        `json:"project_id"`
 	Kind              OperationKind `json:"kind"`
 	Status            string        `json:"status"`
 	Requested         time.Time     `json:"requested"`
@@ -109,6 +161,7 @@ type projectOpsListItem struct {
 	SummaryMessage    string        `json:"summary_message,omitempty"`
 	LastEventSequence int64         `json:"last_event_sequence"`
 	LastUpdateAt      time.Time     `json:"last_update_at"`
+	SLABreached       bool          `json:"sla_breached,omitempty"`
 }
 
 type projectOpsListResponse struct {
@@ -120,84 +173,147 @@ func (a *API) handleProjectArtifacts(w http.ResponseWriter, r *http.Request) {
 	// Routes:
 	//  - GET /api/projects/{id}/artifacts              -> list files
 	//  - GET /api/projects/{id}/artifacts/{path...}    -> download file
+	//  - POST /api/projects/{id}/artifacts/{path...}   -> upload file
+	//  - DELETE /api/projects/{id}/artifacts?prefix=   -> queue scoped cleanup
 	if !strings.HasPrefix(r.URL.Path, "/api/projects/") {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
 	parts := strings.Split(rest, "/")
 	if len(parts) < 2 || parts[1] != "artifacts" {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 
 	projectID := strings.TrimSpace(parts[0])
 	if projectID == "" {
-		http.Error(w, "bad project id", http.StatusBadRequest)
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
 		return
 	}
 
+	if r.Method == http.MethodDelete && len(parts) == projectRelPathPartsMin {
+		a.handleProjectArtifactCleanup(w, r, projectID)
+		return
+	}
+	if r.Method == http.MethodPost && len(parts) > projectRelPathPartsMin {
+		a.handleProjectArtifactUpload(w, r, projectID, strings.Join(parts[2:], "/"))
+		return
+	}
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	// list
 	if len(parts) == projectRelPathPartsMin {
-		files, err := a.artifacts.ListFiles(projectID)
+		if a.artifactListNotModified(w, r, projectID) {
+			return
+		}
+		entries, err := a.artifacts.StatFiles(projectID)
 		if err != nil {
-			http.Error(w, "failed to list artifacts", http.StatusInternalServerError)
+			writeAPIError(w, "failed to list artifacts", http.StatusInternalServerError)
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"files": files})
+		files := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			files = append(files, entry.Path)
+		}
+		writeJSON(w, http.StatusOK, artifactListResponse{Files: files, Entries: entries})
 		return
 	}
 
 	// download
-	relPath := strings.Join(parts[2:], "/")
-	relPath = strings.TrimPrefix(relPath, "/")
-	data, err := a.artifacts.ReadFile(projectID, relPath)
+	releaseSlot, ok := a.acquireArtifactDownload()
+	if !ok {
+		w.Header().Set("Retry-After", "1")
+		writeAPIError(w, "too many concurrent artifact downloads", http.StatusServiceUnavailable)
+		return
+	}
+	defer releaseSlot()
+	relPath := strings.TrimPrefix(strings.Join(parts[2:], "/"), "/")
+	a.serveProjectArtifact(w, r, projectID, relPath)
+}
+
+func (a *API) handleProjectArtifactCleanup(w http.ResponseWriter, r *http.Request, projectID string) {
+	prefix, err := normalizeArtifactCleanupPrefix(r.URL.Query().Get("prefix"))
 	if err != nil {
-		if os.IsNotExist(err) {
-			http.Error(w, "not found", http.StatusNotFound)
+		writeBadRequest(w, err)
+		return
+	}
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	opts := cleanupOpRunOptions(prefix).withExecution(execution)
+	op, err := a.enqueueOp(r.Context(), OpCleanup, projectID, project.Spec, opts)
+	if err != nil {
+		if writeAsyncOpError(w, err) {
 			return
 		}
-		http.Error(w, "failed to read artifact", http.StatusInternalServerError)
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"accepted":   true,
+		"op":         op,
+		"project_id": projectID,
+		"prefix":     prefix,
+	})
+}
 
-	// Minimal content type handling
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.Header().
-		Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(relPath)))
-	http.ServeContent(w, r, filepath.Base(relPath), time.Time{}, bytes.NewReader(data))
+// artifactListNotModified validates the artifact list against the project
+// record and its latest op: workers write files inside op steps, and each
+// step end rewrites the op, so a new file always comes with a new revision.
+// A failed lookup just skips the conditional check.
+func (a *API) artifactListNotModified(w http.ResponseWriter, r *http.Request, projectID string) bool {
+	if a.store == nil {
+		return false
+	}
+	project, projectRev, err := a.store.getProjectRevision(r.Context(), projectID)
+	if err != nil {
+		return false
+	}
+	validator := newCacheValidator("artifacts").add(projectRev)
+	if lastOpID := strings.TrimSpace(project.Status.LastOpID); lastOpID != "" {
+		opRev, revErr := a.store.opsKeyRevision(r.Context(), kvOpKeyPrefix+lastOpID)
+		if revErr != nil {
+			return false
+		}
+		validator.add(opRev)
+	}
+	return validator.notModified(w, r)
 }
 
 func (a *API) handleProjectOps(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	if a.store == nil {
-		http.Error(w, "operation data unavailable", http.StatusInternalServerError)
+		writeAPIError(w, "operation data unavailable", http.StatusInternalServerError)
 		return
 	}
 	if !strings.HasPrefix(r.URL.Path, "/api/projects/") {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 
 	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
 	parts := strings.Split(rest, "/")
 	if len(parts) != projectRelPathPartsMin || parts[1] != "ops" {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 
 	projectID := strings.TrimSpace(parts[0])
 	if projectID == "" {
-		http.Error(w, "bad project id", http.StatusBadRequest)
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
 		return
 	}
 	if _, ok := a.getProjectOrWriteError(w, r, projectID); !ok {
@@ -206,7 +322,7 @@ func (a *API) handleProjectOps(w http.ResponseWriter, r *http.Request) {
 
 	limit, err := parseProjectOpsLimitParam(r.URL.Query().Get("limit"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeBadRequest(w, err)
 		return
 	}
 
@@ -220,14 +336,62 @@ func (a *API) handleProjectOps(w http.ResponseWriter, r *http.Request) {
 		},
 	)
 	if err != nil {
-		http.Error(w, "failed to list operations", http.StatusInternalServerError)
+		writeAPIError(w, "failed to list operations", http.StatusInternalServerError)
+		return
+	}
+	breaches, _, err := a.store.getOpSLABreaches(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to read op SLA breaches", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, a.projectOpsListResponse(page, breaches))
+}
+
+// handleOps serves GET /api/ops: every op across projects, newest first,
+// filtered by project_id, kind, status (kind and status take
+// comma-separated lists), and a since/until window on the request time.
+func (a *API) handleOps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.store == nil {
+		writeAPIError(w, "operation data unavailable", http.StatusInternalServerError)
+		return
+	}
+	query, err := parseOpsListQuery(r.URL.Query())
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	page, err := a.store.listOps(r.Context(), query)
+	if err != nil {
+		var cursorErr opsCursorError
+		if errors.As(err, &cursorErr) {
+			writeBadRequest(w, err)
+			return
+		}
+		writeAPIError(w, "failed to list operations", http.StatusInternalServerError)
 		return
 	}
+	breaches, _, err := a.store.getOpSLABreaches(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to read op SLA breaches", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, a.projectOpsListResponse(page, breaches))
+}
 
+func (a *API) projectOpsListResponse(
+	page projectOpsListPage,
+	breaches map[string][]OpSLABreach,
+) projectOpsListResponse {
 	items := make([]projectOpsListItem, 0, len(page.Ops))
 	for _, op := range page.Ops {
 		items = append(items, projectOpsListItem{
 			ID:                op.ID,
+			ProjectID:         op.ProjectID,
 			Kind:              op.Kind,
 			Status:            op.Status,
 			Requested:         op.Requested,
@@ -236,61 +400,150 @@ func (a *API) handleProjectOps(w http.ResponseWriter, r *http.Request) {
 			SummaryMessage:    opSummaryMessage(op),
 			LastEventSequence: a.store.latestOpEventSequence(op.ID),
 			LastUpdateAt:      opLastUpdateAt(op),
+			SLABreached:       len(breaches[op.ID]) > 0,
 		})
 	}
-
-	writeJSON(w, http.StatusOK, projectOpsListResponse{
+	return projectOpsListResponse{
 		Items:      items,
 		NextCursor: page.NextCursor,
-	})
+	}
 }
 
 func (a *API) handleOpByID(w http.ResponseWriter, r *http.Request) {
 	// GET /api/ops/{id}
 	// GET /api/ops/{id}/events
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	// GET /api/ops/{id}/logs
+	// POST /api/ops/{id}/cancel
+	// GET|POST /api/ops/{id}/notes
 	if !strings.HasPrefix(r.URL.Path, "/api/ops/") {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 
 	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/ops/"), "/")
 	if rest == "" {
-		http.Error(w, "bad op id", http.StatusBadRequest)
+		writeAPIError(w, "bad op id", http.StatusBadRequest)
 		return
 	}
 	parts := strings.Split(rest, "/")
 	opID := strings.TrimSpace(parts[0])
 	if opID == "" {
-		http.Error(w, "bad op id", http.StatusBadRequest)
+		writeAPIError(w, "bad op id", http.StatusBadRequest)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "cancel" {
+		a.handleOpCancel(w, r, opID)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "notes" {
+		a.handleOpNotes(w, r, opID)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	if len(parts) == 2 && parts[1] == "events" {
 		a.handleOpEvents(w, r, opID)
 		return
 	}
+	if len(parts) == 2 && parts[1] == "logs" {
+		a.handleOpLogs(w, r, opID)
+		return
+	}
 	if len(parts) != 1 {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 	if a.store == nil {
-		http.Error(w, "operation data unavailable", http.StatusInternalServerError)
+		writeAPIError(w, "operation data unavailable", http.StatusInternalServerError)
 		return
 	}
 
-	op, err := a.store.GetOp(r.Context(), opID)
+	op, rev, err := a.store.getOpRevision(r.Context(), opID)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
-			http.Error(w, "not found", http.StatusNotFound)
+			writeAPIError(w, "not found", http.StatusNotFound)
 			return
 		}
-		http.Error(w, "failed to read op", http.StatusInternalServerError)
+		writeAPIError(w, "failed to read op", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, http.StatusOK, op)
+	notes, notesRev, err := a.store.getOpNotes(r.Context(), opID)
+	if err != nil {
+		writeAPIError(w, "failed to read op notes", http.StatusInternalServerError)
+		return
+	}
+	breaches, slaRev, err := a.store.getOpSLABreaches(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to read op SLA breaches", http.StatusInternalServerError)
+		return
+	}
+	if newCacheValidator("op").add(rev).add(notesRev).add(slaRev).notModified(w, r) {
+		return
+	}
+	if len(notes) > 0 {
+		op.Notes = notes
+	}
+	writeJSON(w, http.StatusOK, withOpSLA(op, breaches))
+}
+
+func parseOpsListQuery(values url.Values) (opsListQuery, error) {
+	limit, err := parseProjectOpsLimitParam(values.Get("limit"))
+	if err != nil {
+		return opsListQuery{}, err
+	}
+	query := opsListQuery{
+		ProjectID: strings.TrimSpace(values.Get("project_id")),
+		Kinds:     nil,
+		Statuses:  nil,
+		Since:     time.Time{},
+		Until:     time.Time{},
+		Limit:     limit,
+		Cursor:    values.Get("cursor"),
+	}
+	for _, raw := range splitQueryList(values.Get("kind")) {
+		kind := OperationKind(raw)
+		if !slices.Contains(allOperationKinds(), kind) {
+			return opsListQuery{}, fmt.Errorf("bad kind %q", raw)
+		}
+		query.Kinds = append(query.Kinds, kind)
+	}
+	for _, status := range splitQueryList(values.Get("status")) {
+		if !isOperationStatusActive(status) && !isOperationStatusTerminal(status) {
+			return opsListQuery{}, fmt.Errorf("bad status %q", status)
+		}
+		query.Statuses = append(query.Statuses, status)
+	}
+	if query.Since, err = parseOpsTimeParam(values, "since"); err != nil {
+		return opsListQuery{}, err
+	}
+	if query.Until, err = parseOpsTimeParam(values, "until"); err != nil {
+		return opsListQuery{}, err
+	}
+	return query, nil
+}
+
+func parseOpsTimeParam(values url.Values, name string) (time.Time, error) {
+	raw := strings.TrimSpace(values.Get(name))
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	ts, ok := parseProjectOpsBeforeTime(raw)
+	if !ok {
+		return time.Time{}, fmt.Errorf("bad %s: want an RFC3339 time", name)
+	}
+	return ts, nil
+}
+
+func splitQueryList(raw string) []string {
+	out := []string{}
+	for part := range strings.SplitSeq(raw, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
 }
 
 func parseProjectOpsLimitParam(raw string) (int, error) {
@@ -325,21 +578,31 @@ func opSummaryMessage(op Operation) string {
 			return errMsg
 		}
 		return opMessageFailed
+	case opStatusCancelled:
+		return opMessageCancel
+	case opStatusInterrupted:
+		return "operation " + opInterruptedMessage
 	default:
 		return ""
 	}
 }
 
+// opLastUpdateAt is the op's most recent sign of life. Step heartbeats count,
+// so a long-running step stays fresh while its worker is alive.
 func opLastUpdateAt(op Operation) time.Time {
 	if !op.Finished.IsZero() {
 		return op.Finished.UTC()
 	}
 	for idx := len(op.Steps) - 1; idx >= 0; idx-- {
-		if !op.Steps[idx].EndedAt.IsZero() {
-			return op.Steps[idx].EndedAt.UTC()
+		step := op.Steps[idx]
+		if !step.EndedAt.IsZero() {
+			return step.EndedAt.UTC()
+		}
+		if !step.HeartbeatAt.IsZero() {
+			return step.HeartbeatAt.UTC()
 		}
-		if !op.Steps[idx].StartedAt.IsZero() {
-			return op.Steps[idx].StartedAt.UTC()
+		if !step.StartedAt.IsZero() {
+			return step.StartedAt.UTC()
 		}
 	}
 	if !op.Requested.IsZero() {
@@ -25,6 +25,7 @@ import (
 
 type asyncAPIFixture struct {
 	api      *API
+	endpoint natsEndpoint
 	nc       *nats.Conn
 	nsDir    string
 	nsDirTmp bool
@@ -36,12 +37,12 @@ func newAsyncAPIFixture(t *testing.T, heartbeat time.Duration) *asyncAPIFixture
 
 	ctx := context.Background()
 	t.Setenv(natsStoreDirEnv, natsStoreDirModeTemp)
-	ns, natsURL, nsDir, nsDirTmp, err := startEmbeddedNATS()
+	ns, endpoint, nsDir, nsDirTmp, err := startEmbeddedNATS()
 	if err != nil {
 		t.Skipf("embedded nats is unavailable in this environment: %v", err)
 	}
 
-	nc, err := nats.Connect(natsURL, nats.Name("api-async-test"))
+	nc, err := endpoint.connect("api-async-test")
 	if err != nil {
 		ns.Shutdown()
 		ns.WaitForShutdown()
@@ -99,6 +100,7 @@ func newAsyncAPIFixture(t *testing.T, heartbeat time.Duration) *asyncAPIFixture
 
 	return &asyncAPIFixture{
 		api:      api,
+		endpoint: endpoint,
 		nc:       nc,
 		nsDir:    nsDir,
 		nsDirTmp: nsDirTmp,
@@ -118,11 +120,7 @@ func makeClosedPublishConn(t *testing.T, fixture *asyncAPIFixture, name string)
 	if fixture == nil || fixture.nc == nil {
 		t.Fatal("fixture nats connection is unavailable")
 	}
-	url := strings.TrimSpace(fixture.nc.ConnectedUrl())
-	if url == "" {
-		t.Fatal("fixture nats connected url is empty")
-	}
-	nc, err := nats.Connect(url, nats.Name(name))
+	nc, err := fixture.endpoint.connect(name)
 	if err != nil {
 		t.Fatalf("connect closed publish fixture: %v", err)
 	}
@@ -172,7 +170,7 @@ func putProjectFixture(
 			Message:    "running",
 		},
 	}
-	if err := fixture.api.store.PutProject(context.Background(), project); err != nil {
+	if err := overwriteProjectForTest(context.Background(), fixture.api.store, project); err != nil {
 		t.Fatalf("put project fixture: %v", err)
 	}
 }
@@ -629,6 +627,130 @@ func TestAPI_OpEventsStreamsBootstrapStepAndHeartbeat(t *testing.T) {
 	}
 }
 
+func TestAPI_ProjectEventsMultiplexOpsStatusAndReleases(t *testing.T) {
+	fixture := newAsyncAPIFixture(t, 40*time.Millisecond)
+	defer fixture.Close()
+
+	projectID := "project-events-stream"
+	putProjectFixture(t, fixture, projectID, testProjectSpec("events-stream"), "op-events-a", OpDeploy)
+	putOpFixture(t, fixture, "op-events-a", projectID, OpDeploy, opStatusRunning)
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/projects/" + projectID + "/events")
+	if err != nil {
+		t.Fatalf("stream project events: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		payload, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected 200, got %d body=%q", resp.StatusCode, string(payload))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	events := make(chan sseEvent, 32)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			eventItem, readErr := readNextSSEEvent(reader)
+			if readErr != nil {
+				errCh <- readErr
+				return
+			}
+			events <- eventItem
+		}
+	}()
+
+	bootstrap := waitForSSEEvent(t, events, errCh, projectEventBootstrap, 2*time.Second)
+	var bootstrapPayload projectEventPayload
+	if err = json.Unmarshal([]byte(bootstrap.data), &bootstrapPayload); err != nil {
+		t.Fatalf("decode bootstrap payload: %v", err)
+	}
+	if bootstrapPayload.Status == nil || len(bootstrapPayload.Ops) != 1 ||
+		bootstrapPayload.Ops[0].OpID != "op-events-a" {
+		t.Fatalf("expected bootstrap with project status and op-events-a, got %+v", bootstrapPayload)
+	}
+
+	op, err := fixture.api.store.GetOp(context.Background(), "op-events-a")
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	emitOpStepStarted(fixture.api.opEvents, op, "deployer", 1, "deploy dev")
+	started := waitForSSEEvent(t, events, errCh, opEventStarted, 2*time.Second)
+	var startedPayload projectEventPayload
+	if err = json.Unmarshal([]byte(started.data), &startedPayload); err != nil {
+		t.Fatalf("decode step.started payload: %v", err)
+	}
+	if started.id == "" || startedPayload.Op == nil || startedPayload.Op.Worker != "deployer" {
+		t.Fatalf("expected replayable step.started for deployer, got id=%q payload=%+v", started.id, startedPayload)
+	}
+
+	if _, err = fixture.api.store.PutRelease(context.Background(), ReleaseRecord{
+		ProjectID:   projectID,
+		Environment: defaultDeployEnvironment,
+		OpID:        "op-events-a",
+		OpKind:      OpDeploy,
+		Image:       "local/events-stream:abc",
+	}); err != nil {
+		t.Fatalf("put release: %v", err)
+	}
+	release := waitForSSEEvent(t, events, errCh, projectEventRelease, 2*time.Second)
+	var releasePayload projectEventPayload
+	if err = json.Unmarshal([]byte(release.data), &releasePayload); err != nil {
+		t.Fatalf("decode release payload: %v", err)
+	}
+	if releasePayload.Type != projectEventRelease || releasePayload.Release == nil ||
+		releasePayload.Release.Image != "local/events-stream:abc" {
+		t.Fatalf("unexpected release event: %+v", releasePayload)
+	}
+
+	stopRelay, err := subscribeWorkerResultRelay(fixture.nc, fixture.api.opEvents)
+	if err != nil {
+		t.Fatalf("subscribe worker result relay: %v", err)
+	}
+	defer stopRelay()
+	remoteResult, err := json.Marshal(WorkerResultMsg{
+		OpID:      "op-events-a",
+		Kind:      OpDeploy,
+		ProjectID: projectID,
+		Worker:    "deployer",
+		Message:   "deployed on another replica",
+		At:        time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("marshal worker result: %v", err)
+	}
+	if err = fixture.nc.Publish(natsSubject(subjectDeploymentDone), remoteResult); err != nil {
+		t.Fatalf("publish worker result: %v", err)
+	}
+	ended := waitForSSEEvent(t, events, errCh, opEventEnded, 2*time.Second)
+	var endedPayload projectEventPayload
+	if err = json.Unmarshal([]byte(ended.data), &endedPayload); err != nil {
+		t.Fatalf("decode step.ended payload: %v", err)
+	}
+	if endedPayload.Op == nil || endedPayload.Op.Message != "deployed on another replica" {
+		t.Fatalf("expected relayed step.ended from the worker result subject, got %+v", endedPayload)
+	}
+
+	putProjectFixture(t, fixture, projectID, testProjectSpec("events-stream"), "op-events-a", OpDeploy)
+	waitForSSEEvent(t, events, errCh, projectEventStatus, 2*time.Second)
+
+	heartbeat := waitForSSEEvent(t, events, errCh, projectEventHeartbeat, 2*time.Second)
+	if heartbeat.id != "" {
+		t.Fatalf("expected heartbeat protocol id to be omitted, got %q", heartbeat.id)
+	}
+
+	missing, err := http.Get(srv.URL + "/api/projects/project-events-missing/events")
+	if err != nil {
+		t.Fatalf("stream missing project events: %v", err)
+	}
+	missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing project, got %d", missing.StatusCode)
+	}
+}
+
 func TestAPI_RegistrationUpdateRejectsWhenProjectHasActiveOperation(t *testing.T) {
 	fixture := newAsyncAPIFixture(t, opEventsHeartbeatInterval)
 	defer fixture.Close()
@@ -0,0 +1,278 @@
+package platform
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// API authentication: with PAAS_API_AUTH on, every /api request but the probes,
+// the OpenAPI document, and a main push to the source webhook needs a bearer
+// token whose role covers the endpoint. PAAS_OPERATOR_TOKEN counts as an admin
+// token, which is how the first stored tokens get created.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// sourceWebhookSecretHeader carries PAAS_SOURCE_WEBHOOK_SECRET on
+	// source webhook events that are not a push to main.
+	sourceWebhookSecretHeader = "X-PaaS-Hook-Secret"
+	// sourceWebhookPrincipal is who a source webhook event sent with the
+	// hook secret acts as.
+	sourceWebhookPrincipal = "source-webhook"
+	// projectChangeSourcePush is the action checked for source webhook
+	// events that are not a push to main.
+	projectChangeSourcePush = "push tags or branches to"
+)
+
+// apiPrincipal is who a request authenticated as.
+type apiPrincipal struct {
+	TokenID string
+	Name    string
+	Role    apiRole
+	Teams   []string
+	Org     string // "" for an instance-wide token
+}
+
+type apiPrincipalKey struct{}
+
+func apiAuthEnabled() bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(apiAuthEnv)))
+	return err == nil && enabled
+}
+
+// apiRouteRole is the role a request needs. The second result is true for
+// requests that need none.
+func apiRouteRole(method, path string) (apiRole, bool) {
+	switch {
+	case !strings.HasPrefix(path, "/api/"):
+		return "", true
+	case path == "/api/healthz", path == "/api/readyz", path == "/api/openapi.json":
+		return "", true
+	case path == "/api/webhooks/source":
+		// Posted by the git hook in the local source repo, which holds no
+		// token and only sends main pushes. The handler authorizes every
+		// other event (tags, branches, deletes) itself; see
+		// authorizeSourceWebhook.
+		return "", true
+	case path == "/api/tokens" || strings.HasPrefix(path, "/api/tokens/"), path == "/api/config",
+		strings.HasPrefix(path, "/api/admin/"):
+		return apiRoleAdmin, false
+	case (path == "/api/orgs" || isOrgItemPath(path)) && method != http.MethodGet && method != http.MethodHead:
+		return apiRoleAdmin, false
+	case method == http.MethodDelete && isProjectItemPath(path):
+		return apiRoleAdmin, false
+	case method == http.MethodDelete && (isEnvironmentFreezePath(path) || isProjectHoldsPath(path)):
+		// Lifting a freeze undoes a gate only an admin may override, and
+		// lifting a hold defeats it.
+		return apiRoleAdmin, false
+	case method == http.MethodGet, method == http.MethodHead, strings.HasSuffix(path, "/preview"),
+		path == "/api/projects/validate":
+		return apiRoleViewer, false
+	default:
+		return apiRoleDeveloper, false
+	}
+}
+
+// isProjectItemPath matches /api/projects/{id} and nothing below it.
+func isProjectItemPath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/api/projects/")
+	rest = strings.Trim(rest, "/")
+	return ok && rest != "" && !strings.Contains(rest, "/")
+}
+
+// isEnvironmentFreezePath matches /api/projects/{id}/environments/{env}/freeze.
+func isEnvironmentFreezePath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/api/projects/")
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	return ok && len(parts) == 4 && parts[0] != "" && parts[1] == "environments" && parts[2] != "" &&
+		parts[3] == "freeze"
+}
+
+// isProjectHoldsPath matches /api/projects/{id}/holds.
+func isProjectHoldsPath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/api/projects/")
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	return ok && len(parts) == 2 && parts[0] != "" && parts[1] == "holds"
+}
+
+// isOrgItemPath matches /api/orgs/{org} and nothing below it.
+func isOrgItemPath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/api/orgs/")
+	rest = strings.Trim(rest, "/")
+	return ok && rest != "" && !strings.Contains(rest, "/")
+}
+
+func (a *API) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !apiAuthEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		need, public := apiRouteRole(r.Method, r.URL.Path)
+		if public {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, ok := a.authenticate(w, r)
+		if !ok {
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), apiPrincipalKey{}, principal))
+		if !requireAPIRole(w, r, need) || !a.enforceOrgScope(w, r) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorizeSourceWebhook lets a source webhook event through when it is a
+// push to main, which the tokenless git hook sends, or when it carries the
+// PAAS_SOURCE_WEBHOOK_SECRET hook secret or a developer token. Tag pushes
+// release to prod and branch events build and tear down previews, so with
+// auth on they never go unauthenticated, and they need the same project
+// ownership as any other change. The hook secret names no one, so it acts as
+// the sourceWebhookPrincipal developer, which a project with owners admits
+// only when it lists that name as an owner. It returns the context the event
+// must be handled with, carrying the principal, and writes the refusal
+// itself.
+func (a *API) authorizeSourceWebhook(
+	w http.ResponseWriter,
+	r *http.Request,
+	evt SourceRepoWebhookEvent,
+) (context.Context, bool) {
+	ctx := r.Context()
+	if !apiAuthEnabled() || isSourceWebhookMainPush(evt) {
+		return ctx, true
+	}
+	principal := apiPrincipal{TokenID: "", Name: sourceWebhookPrincipal, Role: apiRoleDeveloper, Teams: nil, Org: ""}
+	if !sourceWebhookSecretMatches(r) {
+		var ok bool
+		if principal, ok = a.authenticate(w, r); !ok {
+			return ctx, false
+		}
+	}
+	ctx = context.WithValue(ctx, apiPrincipalKey{}, principal)
+	if !requireAPIRole(w, r.WithContext(ctx), apiRoleDeveloper) {
+		return ctx, false
+	}
+	if a.store == nil {
+		return ctx, true
+	}
+	project, err := a.store.GetProject(ctx, evt.ProjectID)
+	switch {
+	case errors.Is(err, jetstream.ErrKeyNotFound):
+		return ctx, true // the handler answers 404 itself
+	case err != nil:
+		writeAPIError(w, "failed to read project", http.StatusInternalServerError)
+		return ctx, false
+	case !orgVisible(ctx, project.Org):
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return ctx, false
+	}
+	if err = a.authorizeProjectChange(ctx, project, projectChangeSourcePush); err != nil {
+		if !writeProjectAccessDenied(w, err) {
+			writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return ctx, false
+	}
+	return ctx, true
+}
+
+func sourceWebhookSecretMatches(r *http.Request) bool {
+	secret := strings.TrimSpace(os.Getenv(sourceWebhookSecretEnv))
+	sent := strings.TrimSpace(r.Header.Get(sourceWebhookSecretHeader))
+	return secret != "" && sent != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(secret)) == 1
+}
+
+// isSourceWebhookMainPush reports whether evt is a push to main, the only
+// event the source repo's git hook sends.
+func isSourceWebhookMainPush(evt SourceRepoWebhookEvent) bool {
+	branch, ref := ciBranchName(evt.Branch), ciBranchName(evt.Ref)
+	return !evt.Deleted && (branch != "" || ref != "") &&
+		(branch == "" || branch == branchMain) && (ref == "" || ref == branchMain)
+}
+
+// authenticate resolves the bearer token of r, writing a 401 (or a 500 when
+// the token store cannot be read) when it does not name a principal. With
+// PAAS_API_RATE_LIMIT set, every 401 spends from a bucket kept per client
+// address, and an address whose bucket is empty gets a 429 before its token
+// is looked up, so a flood of bad tokens is limited too.
+func (a *API) authenticate(w http.ResponseWriter, r *http.Request) (apiPrincipal, bool) {
+	var principal apiPrincipal
+	if a.rateLimiter != nil {
+		if wait, ok := a.rateLimiter.available(authFailureKey(r), time.Now()); !ok {
+			writeRateLimited(w, a.rateLimiter.limit, wait)
+			return principal, false
+		}
+	}
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	bearer = strings.TrimSpace(bearer)
+	if !ok || bearer == "" {
+		a.refuseUnauthenticated(w, r, `Bearer realm="paas"`,
+			"authentication required (Authorization: Bearer <token>)")
+		return principal, false
+	}
+	if operatorRequest(r) {
+		principal.Name = "operator"
+		principal.Role = apiRoleAdmin
+		return principal, true
+	}
+	if a.store == nil {
+		writeAPIError(w, "token data unavailable", http.StatusInternalServerError)
+		return principal, false
+	}
+	token, found, err := a.store.lookupAPIToken(r.Context(), bearer)
+	if err != nil {
+		writeAPIError(w, "failed to check token", http.StatusInternalServerError)
+		return principal, false
+	}
+	if !found {
+		a.refuseUnauthenticated(w, r, `Bearer realm="paas", error="invalid_token"`, "invalid token")
+		return principal, false
+	}
+	principal.TokenID = token.ID
+	principal.Name = token.Name
+	principal.Role = token.Role
+	principal.Teams = token.Teams
+	principal.Org = token.Org
+	return principal, true
+}
+
+// refuseUnauthenticated writes a 401 and spends one of the address's
+// allowed authentication failures.
+func (a *API) refuseUnauthenticated(w http.ResponseWriter, r *http.Request, challenge, message string) {
+	if a.rateLimiter != nil {
+		a.rateLimiter.take(authFailureKey(r), time.Now())
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	writeAPIError(w, message, http.StatusUnauthorized)
+}
+
+// requireAPIRole checks a role a handler needs beyond what apiRouteRole could
+// tell from the path, such as a delete sent as a registration event. It
+// passes every request while authentication is off.
+func requireAPIRole(w http.ResponseWriter, r *http.Request, need apiRole) bool {
+	if !apiAuthEnabled() {
+		return true
+	}
+	principal, _ := r.Context().Value(apiPrincipalKey{}).(apiPrincipal)
+	if principal.Role.allows(need) {
+		return true
+	}
+	writeAPIErrorResponse(w, http.StatusForbidden, apiErrorResponse{
+		Code:    errorCodeForbidden,
+		Message: "role " + string(principal.Role) + " cannot do this; it needs " + string(need),
+		Field:   "",
+		Details: map[string]any{"role": principal.Role, "needs": need},
+		OpID:    "",
+	})
+	return false
+}
@@ -0,0 +1,263 @@
+//nolint:testpackage,exhaustruct // Auth tests drive the internal router and token store.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAPIAuth_TokensAndRoles(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	call := func(method, path, token string, body any) (int, []byte) {
+		t.Helper()
+		reader := bytes.NewReader(nil)
+		if body != nil {
+			raw, _ := json.Marshal(body)
+			reader = bytes.NewReader(raw)
+		}
+		req, _ := http.NewRequestWithContext(context.Background(), method, srv.URL+path, reader)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		return resp.StatusCode, buf.Bytes()
+	}
+
+	if status, _ := call(http.MethodGet, "/api/projects", "", nil); status != http.StatusOK {
+		t.Fatalf("expected open access while auth is off, got %d", status)
+	}
+
+	t.Setenv(apiAuthEnv, "true")
+	t.Setenv(operatorTokenEnv, "operator-secret")
+	if status, _ := call(http.MethodGet, "/api/projects", "", nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", status)
+	}
+	if status, _ := call(http.MethodGet, "/api/healthz", "", nil); status != http.StatusOK {
+		t.Fatalf("expected the liveness probe to stay public, got %d", status)
+	}
+	if status, _ := call(http.MethodGet, "/api/projects", "paas_unknown", nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unknown token, got %d", status)
+	}
+
+	mint := func(name, role string) apiTokenCreatedResponse {
+		t.Helper()
+		status, body := call(http.MethodPost, "/api/tokens", "operator-secret", apiTokenRequest{Name: name, Role: role})
+		var out apiTokenCreatedResponse
+		if status != http.StatusCreated || json.Unmarshal(body, &out) != nil || !strings.HasPrefix(out.Value, "paas_") {
+			t.Fatalf("expected the operator to create a %s token, got %d %s", role, status, body)
+		}
+		return out
+	}
+	viewer := mint("ci-dashboard", "viewer")
+	developer := mint("dev-laptop", "developer")
+	if status, _ := call(http.MethodPost, "/api/tokens", "operator-secret",
+		apiTokenRequest{Name: "x", Role: "root"}); status != http.StatusBadRequest {
+		t.Fatalf("expected an unknown role to be rejected, got %d", status)
+	}
+
+	status, body := call(http.MethodGet, "/api/tokens", "operator-secret", nil)
+	if status != http.StatusOK || strings.Contains(string(body), viewer.Value) ||
+		strings.Contains(string(body), "hash") || !strings.Contains(string(body), "dev-laptop") {
+		t.Fatalf("expected a token listing without values or hashes, got %d %s", status, body)
+	}
+
+	deployEvent := DeploymentEvent{ProjectID: "missing", Environment: "dev"}
+	for _, tc := range []struct {
+		name, method, path, token string
+		body                      any
+		want                      int
+	}{
+		{"viewer reads", http.MethodGet, "/api/projects", viewer.Value, nil, http.StatusOK},
+		{"viewer writes", http.MethodPost, "/api/events/deployment", viewer.Value, deployEvent, http.StatusForbidden},
+		{"viewer lists tokens", http.MethodGet, "/api/tokens", viewer.Value, nil, http.StatusForbidden},
+		{"developer writes", http.MethodPost, "/api/events/deployment", developer.Value, deployEvent, http.StatusNotFound},
+		{"developer deletes", http.MethodDelete, "/api/projects/p1", developer.Value, nil, http.StatusForbidden},
+		{
+			"developer deletes by event", http.MethodPost, "/api/events/registration", developer.Value,
+			RegistrationEvent{Action: "delete", ProjectID: "p1"}, http.StatusForbidden,
+		},
+	} {
+		if status, body = call(tc.method, tc.path, tc.token, tc.body); status != tc.want {
+			t.Errorf("%s: expected %d, got %d %s", tc.name, tc.want, status, body)
+		}
+	}
+
+	status, _ = call(http.MethodDelete, "/api/tokens/"+viewer.Token.ID, "operator-secret", nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected the viewer token to be revoked, got %d", status)
+	}
+	if status, _ = call(http.MethodGet, "/api/projects", viewer.Value, nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected a revoked token to be refused, got %d", status)
+	}
+}
+
+func TestStore_ConcurrentTokenCreateAndRevoke(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	store := fixture.store
+
+	revoked := map[string]bool{}
+	created := map[string]bool{}
+	for round := range 10 {
+		victim, _, err := store.createAPIToken(ctx, fmt.Sprintf("victim-%d", round), apiRoleAdmin, nil, "")
+		if err != nil {
+			t.Fatalf("create token to revoke: %v", err)
+		}
+		var wg sync.WaitGroup
+		var createErr, deleteErr error
+		var token apiToken
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			token, _, createErr = store.createAPIToken(ctx, fmt.Sprintf("new-%d", round), apiRoleViewer, nil, "")
+		}()
+		go func() {
+			defer wg.Done()
+			_, deleteErr = store.deleteAPIToken(ctx, victim.ID)
+		}()
+		wg.Wait()
+		if createErr != nil || deleteErr != nil {
+			t.Fatalf("round %d: create=%v delete=%v", round, createErr, deleteErr)
+		}
+		revoked[victim.ID] = true
+		created[token.ID] = true
+	}
+
+	tokens, err := store.getAPITokens(ctx)
+	if err != nil {
+		t.Fatalf("read tokens: %v", err)
+	}
+	for id := range revoked {
+		if _, ok := tokens.Tokens[id]; ok {
+			t.Errorf("expected revoked token %s to stay revoked", id)
+		}
+	}
+	for id := range created {
+		if _, ok := tokens.Tokens[id]; !ok {
+			t.Errorf("expected created token %s to be kept", id)
+		}
+	}
+}
+
+func TestAPIAuth_SourceWebhookNeedsATokenOrSecretBeyondMainPushes(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	t.Setenv(apiAuthEnv, "true")
+	t.Setenv(operatorTokenEnv, "operator-secret")
+	t.Setenv(sourceWebhookSecretEnv, "hook-secret")
+
+	post := func(evt SourceRepoWebhookEvent, header, value string) int {
+		t.Helper()
+		raw, _ := json.Marshal(evt)
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL+"/api/webhooks/source",
+			bytes.NewReader(raw))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("post webhook: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	_, viewerValue, err := fixture.store.createAPIToken(context.Background(), "dashboard", apiRoleViewer, nil, "")
+	if err != nil {
+		t.Fatalf("create viewer token: %v", err)
+	}
+	mainPush := SourceRepoWebhookEvent{ProjectID: "missing", Branch: "main", Commit: "abc"}
+	tagPush := SourceRepoWebhookEvent{ProjectID: "missing", Ref: "refs/tags/v1.0.0", Commit: "abc"}
+	branchPush := SourceRepoWebhookEvent{ProjectID: "missing", Ref: "refs/heads/feature/x", Commit: "abc"}
+	branchDelete := SourceRepoWebhookEvent{ProjectID: "missing", Branch: "feature/x", Deleted: true}
+	mainDelete := SourceRepoWebhookEvent{ProjectID: "missing", Branch: "main", Deleted: true}
+	mixedRefs := SourceRepoWebhookEvent{ProjectID: "missing", Branch: "main", Ref: "refs/tags/v1.0.0", Commit: "abc"}
+
+	// A project that does not exist answers 404 once the event is let through.
+	for _, tc := range []struct {
+		name          string
+		evt           SourceRepoWebhookEvent
+		header, value string
+		want          int
+	}{
+		{"main push from the hook", mainPush, "", "", http.StatusNotFound},
+		{"tag push without credentials", tagPush, "", "", http.StatusUnauthorized},
+		{"branch push without credentials", branchPush, "", "", http.StatusUnauthorized},
+		{"branch delete without credentials", branchDelete, "", "", http.StatusUnauthorized},
+		{"main delete without credentials", mainDelete, "", "", http.StatusUnauthorized},
+		{"main branch with a tag ref", mixedRefs, "", "", http.StatusUnauthorized},
+		{"tag push with a wrong secret", tagPush, sourceWebhookSecretHeader, "guess", http.StatusUnauthorized},
+		{"tag push with the hook secret", tagPush, sourceWebhookSecretHeader, "hook-secret", http.StatusNotFound},
+		{"branch delete by a viewer", branchDelete, "Authorization", "Bearer " + viewerValue, http.StatusForbidden},
+		{"branch delete by the operator", branchDelete, "Authorization", "Bearer operator-secret", http.StatusNotFound},
+	} {
+		if status := post(tc.evt, tc.header, tc.value); status != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, status)
+		}
+	}
+
+	// A project with owners takes tag and branch events only from them, an
+	// admin, or the hook secret once it lists sourceWebhookPrincipal.
+	ctx := context.Background()
+	if err = fixture.store.PutProject(ctx, Project{ID: "owned", Spec: ProjectSpec{Name: "owned"}}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	ownership := ProjectOwnership{Owners: []ProjectOwner{{Name: "sam@example.com"}}}
+	if _, err = fixture.store.setProjectOwnership(ctx, "owned", ownership); err != nil {
+		t.Fatalf("set ownership: %v", err)
+	}
+	_, ownerValue, err := fixture.store.createAPIToken(ctx, "sam@example.com", apiRoleDeveloper, nil, "")
+	if err != nil {
+		t.Fatalf("create owner token: %v", err)
+	}
+	_, outsiderValue, err := fixture.store.createAPIToken(ctx, "dev-laptop", apiRoleDeveloper, nil, "")
+	if err != nil {
+		t.Fatalf("create outsider token: %v", err)
+	}
+	ownedTag := SourceRepoWebhookEvent{ProjectID: "owned", Ref: "refs/tags/v1.0.0", Commit: "abc"}
+	// An allowed tag push is answered 202: the project has no ci.tags, so it
+	// is ignored.
+	for _, tc := range []struct {
+		name          string
+		header, value string
+		want          int
+	}{
+		{"owned tag push by an outsider", "Authorization", "Bearer " + outsiderValue, http.StatusForbidden},
+		{"owned tag push with the hook secret", sourceWebhookSecretHeader, "hook-secret", http.StatusForbidden},
+		{"owned tag push by the owner", "Authorization", "Bearer " + ownerValue, http.StatusAccepted},
+		{"owned tag push by the operator", "Authorization", "Bearer operator-secret", http.StatusAccepted},
+	} {
+		if status := post(ownedTag, tc.header, tc.value); status != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, status)
+		}
+	}
+	ownership.Owners = append(ownership.Owners, ProjectOwner{Name: sourceWebhookPrincipal})
+	if _, err = fixture.store.setProjectOwnership(ctx, "owned", ownership); err != nil {
+		t.Fatalf("set ownership: %v", err)
+	}
+	if status := post(ownedTag, sourceWebhookSecretHeader, "hook-secret"); status != http.StatusAccepted {
+		t.Errorf("expected the hook secret admitted once it is an owner, got %d", status)
+	}
+}
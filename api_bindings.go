@@ -0,0 +1,164 @@
+package platform
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	bindingPathPartsMax   = 5
+	projectChangeBindings = "change capability bindings of"
+)
+
+// capabilityBindingRequest is the body of a binding PUT; the project,
+// environment, and capability come from the path.
+type capabilityBindingRequest struct {
+	Type         CapabilityBindingType   `json:"type"`
+	Image        string                  `json:"image,omitempty"`
+	ContainerEnv map[string]string       `json:"container_env,omitempty"`
+	Env          map[string]string       `json:"env,omitempty"`
+	SecretEnv    map[string]SecretKeyRef `json:"secret_env,omitempty"`
+}
+
+// environmentBindingsResponse lists an environment's bindings alongside the
+// declared capabilities that have none.
+type environmentBindingsResponse struct {
+	ProjectID   string              `json:"project_id"`
+	Environment string              `json:"environment"`
+	Bindings    []CapabilityBinding `json:"bindings"`
+	Unbound     []string            `json:"unbound"`
+}
+
+// handleEnvironmentBindings serves /api/projects/{id}/environments/{env}/bindings
+// and /bindings/{capability}. Changes apply on the next deploy, promotion,
+// or rollback render of the environment.
+func (a *API) handleEnvironmentBindings(w http.ResponseWriter, r *http.Request, parts []string) {
+	if a.store == nil {
+		writeAPIError(w, "binding data unavailable", http.StatusInternalServerError)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	envName := strings.TrimSpace(parts[2])
+	if projectID == "" || envName == "" {
+		writeAPIError(w, "bad project id or environment", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	if _, exists := spec.Environments[envName]; !exists {
+		writeAPIError(w, "environment not found", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) < bindingPathPartsMax {
+		if r.Method != http.MethodGet {
+			writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		bindings, err := a.store.getCapabilityBindings(r.Context(), projectID)
+		if err != nil {
+			writeAPIError(w, "failed to read bindings", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newEnvironmentBindingsResponse(projectID, spec, envName, bindings))
+		return
+	}
+
+	capability := strings.TrimSpace(parts[4])
+	if capability == "" {
+		writeAPIError(w, "bad capability", http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
+		if !a.authorizeProjectChangeOrWriteError(w, r, project, projectChangeBindings) {
+			return
+		}
+	}
+	switch r.Method {
+	case http.MethodGet:
+		bindings, err := a.store.getCapabilityBindings(r.Context(), projectID)
+		if err != nil {
+			writeAPIError(w, "failed to read bindings", http.StatusInternalServerError)
+			return
+		}
+		binding, bound := bindings.Environments[envName][capability]
+		if !bound {
+			writeAPIError(w, "binding not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, binding)
+	case http.MethodPut:
+		a.handleEnvironmentBindingPut(w, r, spec, projectID, envName, capability)
+	case http.MethodDelete:
+		removed, found, err := a.store.deleteCapabilityBinding(r.Context(), projectID, envName, capability)
+		if err != nil {
+			writeAPIError(w, "failed to delete binding", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			writeAPIError(w, "binding not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, bindingDeletedResponse{Deleted: true, Binding: removed})
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleEnvironmentBindingPut(
+	w http.ResponseWriter,
+	r *http.Request,
+	spec ProjectSpec,
+	projectID, envName, capability string,
+) {
+	var req capabilityBindingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	var binding CapabilityBinding
+	binding.ProjectID = projectID
+	binding.Environment = envName
+	binding.Capability = capability
+	binding.Type = CapabilityBindingType(strings.TrimSpace(string(req.Type)))
+	binding.Image = strings.TrimSpace(req.Image)
+	binding.ContainerEnv = req.ContainerEnv
+	binding.Env = req.Env
+	binding.SecretEnv = req.SecretEnv
+	if err := validateCapabilityBinding(spec, binding); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	stored, err := a.store.putCapabilityBinding(r.Context(), binding)
+	if err != nil {
+		writeAPIError(w, "failed to save binding", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, stored)
+}
+
+func newEnvironmentBindingsResponse(
+	projectID string,
+	spec ProjectSpec,
+	envName string,
+	bindings projectCapabilityBindings,
+) environmentBindingsResponse {
+	out := environmentBindingsResponse{
+		ProjectID:   projectID,
+		Environment: envName,
+		Bindings:    bindings.forEnvironment(envName),
+		Unbound:     []string{},
+	}
+	for _, capability := range spec.Capabilities {
+		if _, bound := bindings.Environments[envName][capability]; !bound {
+			out.Unbound = append(out.Unbound, capability)
+		}
+	}
+	slices.Sort(out.Unbound)
+	return out
+}
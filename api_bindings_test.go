@@ -0,0 +1,138 @@
+//nolint:testpackage,exhaustruct // Binding tests seed projects through the internal store.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAPI_EnvironmentBindingsRoundTripAndRender(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	projectID := "project-bindings"
+	now := time.Now().UTC()
+	spec := normalizeProjectSpec(ProjectSpec{
+		Name:         "bound-app",
+		Runtime:      "go_1.26",
+		Capabilities: []string{"postgres", "redis"},
+		Environments: map[string]EnvConfig{
+			"dev":  {Vars: map[string]string{"LOG_LEVEL": "debug"}},
+			"prod": {Vars: map[string]string{}},
+		},
+	})
+	if err := fixture.store.PutProject(context.Background(), Project{
+		ID:        projectID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Spec:      spec,
+		Status:    ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now},
+	}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+
+	srv := httptest.NewServer((&API{store: fixture.store}).routes())
+	defer srv.Close()
+	base := srv.URL + "/api/projects/" + projectID + "/environments/"
+
+	put := func(env, capability, body string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPut, base+env+"/bindings/"+capability, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("put binding: %v", err)
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := put("dev", "postgres", `{"type":"container","image":"postgres:16",`+
+		`"container_env":{"POSTGRES_PASSWORD":"dev"},"env":{"DATABASE_URL":"postgres://localhost:5432/app"}}`); got != http.StatusOK {
+		t.Fatalf("expected container binding accepted, got %d", got)
+	}
+	if got := put("prod", "postgres", `{"type":"external",`+
+		`"secret_env":{"DATABASE_URL":{"name":"bound-app-db","key":"url"}}}`); got != http.StatusOK {
+		t.Fatalf("expected external binding accepted, got %d", got)
+	}
+	rejected := []struct{ env, capability, body string }{
+		{"prod", "kafka", `{"type":"external","env":{"KAFKA":"x"}}`},
+		{"prod", "redis", `{"type":"external","image":"redis:7"}`},
+		{"dev", "redis", `{"type":"container"}`},
+		{"dev", "redis", `{"type":"sidecar","image":"redis:7"}`},
+		{"prod", "redis", `{"type":"external","secret_env":{"REDIS_URL":{"name":"Bad_Name","key":"url"}}}`},
+	}
+	for _, tc := range rejected {
+		if got := put(tc.env, tc.capability, tc.body); got != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s %s %s, got %d", tc.env, tc.capability, tc.body, got)
+		}
+	}
+	if got := put("qa", "postgres", `{"type":"external"}`); got != http.StatusNotFound {
+		t.Fatalf("expected 404 for undefined environment, got %d", got)
+	}
+
+	resp, err := srv.Client().Get(base + "dev/bindings")
+	if err != nil {
+		t.Fatalf("list bindings: %v", err)
+	}
+	var listed environmentBindingsResponse
+	err = json.NewDecoder(resp.Body).Decode(&listed)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode bindings: %v", err)
+	}
+	if len(listed.Bindings) != 1 || listed.Bindings[0].Capability != "postgres" ||
+		!slices.Equal(listed.Unbound, []string{"redis"}) {
+		t.Fatalf("unexpected dev bindings: %+v", listed)
+	}
+
+	bindings, err := loadEnvCapabilityBindings(context.Background(), fixture.store, projectID)
+	if err != nil {
+		t.Fatalf("load bindings: %v", err)
+	}
+	devPatch := renderDeploymentEnvPatch(spec, "dev", bindings["dev"], nil)
+	for _, want := range []string{
+		"- name: DATABASE_URL\n          value: \"postgres://localhost:5432/app\"",
+		"- name: capability-postgres\n        image: postgres:16",
+		"- name: POSTGRES_PASSWORD\n          value: \"dev\"",
+	} {
+		if !bytes.Contains([]byte(devPatch), []byte(want)) {
+			t.Fatalf("dev patch missing %q:\n%s", want, devPatch)
+		}
+	}
+	prodPatch := renderDeploymentEnvPatch(spec, "prod", bindings["prod"], nil)
+	if !bytes.Contains([]byte(prodPatch), []byte("secretKeyRef:\n              name: bound-app-db\n              key: \"url\"")) ||
+		bytes.Contains([]byte(prodPatch), []byte("capability-postgres")) {
+		t.Fatalf("unexpected prod patch:\n%s", prodPatch)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, base+"dev/bindings/postgres", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	for _, want := range []int{http.StatusOK, http.StatusNotFound} {
+		deleted, doErr := srv.Client().Do(req)
+		if doErr != nil {
+			t.Fatalf("delete binding: %v", doErr)
+		}
+		deleted.Body.Close()
+		if deleted.StatusCode != want {
+			t.Fatalf("expected delete %d, got %d", want, deleted.StatusCode)
+		}
+	}
+	remaining, err := fixture.store.getCapabilityBindings(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("get bindings: %v", err)
+	}
+	if _, ok := remaining.Environments["dev"]; ok || len(remaining.Environments["prod"]) != 1 {
+		t.Fatalf("expected only the prod binding left, got %+v", remaining.Environments)
+	}
+}
@@ -0,0 +1,131 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	blueGreenPathParts = 4 // {id}/environments/{env}/blue-green
+
+	transitionBlockerBlueGreenActive = "blue_green_active"
+)
+
+// ReleaseCutoverEvent switches a blue/green environment's traffic to the
+// color its last release staged or, with Rollback, back to the color the
+// last cutover switched away from.
+type ReleaseCutoverEvent struct {
+	ProjectID      string                 `json:"project_id"`
+	Env            string                 `json:"env,omitempty"` // default prod
+	Rollback       bool                   `json:"rollback,omitempty"`
+	FreezeOverride *FreezeOverrideRequest `json:"freeze_override,omitempty"`
+}
+
+// addBlueGreenBlocker blocks a preview of a promotion that would replace a
+// blue/green environment's image without staging it on the idle color.
+func addBlueGreenBlocker(
+	artifacts ArtifactStore,
+	preview *PromotionPreviewResponse,
+	projectID, env string,
+) error {
+	release, active, err := readBlueGreenRelease(artifacts, projectID, env)
+	if err != nil || !active {
+		return err
+	}
+	preview.Blockers = append(preview.Blockers, TransitionPreviewBlocker{
+		Code:    transitionBlockerBlueGreenActive,
+		Message: fmt.Sprintf("Target environment %q releases blue/green.", env),
+		Why: fmt.Sprintf(
+			"%s serves %s; a new image goes to the idle color first.",
+			release.ActiveColor, release.image(release.ActiveColor),
+		),
+		NextAction: "Retry with strategy blue-green.",
+	})
+	return nil
+}
+
+func (a *API) handleReleaseCutoverEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var evt ReleaseCutoverEvent
+	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	projectID := strings.TrimSpace(evt.ProjectID)
+	if projectID == "" {
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
+		return
+	}
+	env := strings.TrimSpace(evt.Env)
+	if env == "" {
+		env = defaultReleaseEnvironment
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	release, active, err := readBlueGreenRelease(a.artifacts, projectID, env)
+	if err != nil {
+		writeAPIError(w, "failed to read blue/green state", http.StatusInternalServerError)
+		return
+	}
+	action := BlueGreenActionCutover
+	switch {
+	case !active:
+		writeAPIError(w, env+" does not release blue/green", http.StatusConflict)
+		return
+	case evt.Rollback && release.PreviousColor == "":
+		writeAPIError(w, env+" has no earlier color to roll back to", http.StatusConflict)
+		return
+	case evt.Rollback:
+		action = BlueGreenActionRollback
+	case release.StagedColor == "":
+		writeAPIError(w, env+" has no staged release to cut over to", http.StatusConflict)
+		return
+	}
+	a.enqueueStrategyFollowUp(w, r, project, release.FromEnv, env, DeliveryLifecycle{
+		Stage:           "",
+		Environment:     "",
+		FromEnv:         "",
+		ToEnv:           "",
+		Strategy:        DeliveryStrategyBlueGreen,
+		CanaryWeight:    0,
+		CanaryAction:    "",
+		BlueGreenAction: action,
+	}, evt.FreezeOverride)
+}
+
+// handleEnvironmentBlueGreen serves GET .../environments/{env}/blue-green,
+// the environment's colors and the one its Service selects.
+func (a *API) handleEnvironmentBlueGreen(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	env := strings.TrimSpace(parts[2])
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	if _, exists := normalizeProjectSpec(project.Spec).Environments[env]; !exists {
+		writeAPIError(w, "environment not found", http.StatusNotFound)
+		return
+	}
+	release, active, err := readBlueGreenRelease(a.artifacts, projectID, env)
+	if err != nil {
+		writeAPIError(w, "failed to read blue/green state", http.StatusInternalServerError)
+		return
+	}
+	if !active {
+		writeAPIError(w, env+" does not release blue/green", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, release)
+}
@@ -0,0 +1,97 @@
+package platform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Conditional GETs: read endpoints derive an ETag and Last-Modified from
+// the KV revisions their response is built from, so pollers that send
+// If-None-Match (or If-Modified-Since) get a bodyless 304 until one of
+// those keys is written again.
+
+const cacheETagHexLength = 20
+
+// cacheValidator accumulates the revisions behind one response. Revisions
+// must be read no later than the data they cover: a validator older than
+// the body only costs a spare 200, a newer one could hide a change.
+type cacheValidator struct {
+	parts    []string
+	modified time.Time
+}
+
+func newCacheValidator(scope string) *cacheValidator {
+	return &cacheValidator{parts: []string{scope}, modified: time.Time{}}
+}
+
+func (v *cacheValidator) add(rev kvRevision) *cacheValidator {
+	v.parts = append(v.parts, rev.Key+"@"+strconv.FormatUint(rev.Revision, 10))
+	if rev.Modified.After(v.modified) {
+		v.modified = rev.Modified
+	}
+	return v
+}
+
+// addQuery folds query parameters that change the response body (paging,
+// environment) into the tag.
+func (v *cacheValidator) addQuery(r *http.Request, names ...string) *cacheValidator {
+	query := r.URL.Query()
+	for _, name := range names {
+		v.parts = append(v.parts, name+"="+strings.TrimSpace(query.Get(name)))
+	}
+	return v
+}
+
+func (v *cacheValidator) etag() string {
+	sum := sha256.Sum256([]byte(strings.Join(v.parts, "\n")))
+	return `"` + hex.EncodeToString(sum[:])[:cacheETagHexLength] + `"`
+}
+
+// notModified sets the validator headers and, when the request's
+// conditions show the client already has this version, writes 304 and
+// returns true. If-None-Match takes precedence over If-Modified-Since.
+func (v *cacheValidator) notModified(w http.ResponseWriter, r *http.Request) bool {
+	tag := v.etag()
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if !v.modified.IsZero() {
+		w.Header().Set("Last-Modified", v.modified.Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	matched := false
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		matched = etagListMatches(inm, tag)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !v.modified.IsZero() {
+		since, err := http.ParseTime(ims)
+		matched = err == nil && !v.modified.Truncate(time.Second).After(since)
+	}
+	if matched {
+		w.WriteHeader(http.StatusNotModified)
+	}
+	return matched
+}
+
+// ifMatchFails reports whether the request's If-Match names a version other
+// than this one. Requests without If-Match always proceed.
+func (v *cacheValidator) ifMatchFails(r *http.Request) bool {
+	ifMatch := r.Header.Get("If-Match")
+	return ifMatch != "" && !etagListMatches(ifMatch, v.etag())
+}
+
+// etagListMatches applies the weak comparison If-None-Match calls for.
+func etagListMatches(header, tag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+//nolint:testpackage,exhaustruct // Conditional GET tests seed records through the internal store.
+package platform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPI_ConditionalGetsReturnNotModifiedUntilKVChanges(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-cache"
+	now := time.Now().UTC()
+	project := Project{
+		ID:        projectID,
+		CreatedAt: now,
+		Spec:      normalizeProjectSpec(workerRuntimeSpec("cache-app")),
+		Status:    ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now, LastOpID: "op-cache-1"},
+	}
+	if err := fixture.store.PutProject(ctx, project); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	op := Operation{
+		ID: "op-cache-1", Kind: OpDeploy, ProjectID: projectID, Requested: now,
+		Status: opStatusRunning, Steps: []OpStep{},
+	}
+	if err := fixture.store.PutOp(ctx, op); err != nil {
+		t.Fatalf("put op: %v", err)
+	}
+	release, err := fixture.store.PutRelease(ctx, ReleaseRecord{
+		ProjectID: projectID, Environment: "dev", OpID: op.ID, OpKind: OpDeploy,
+		DeliveryStage: DeliveryStageDeploy, Image: "local/cache:1", CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("put release: %v", err)
+	}
+	artifacts := NewFSArtifacts(t.TempDir())
+	if _, err = artifacts.WriteFile(projectID, "build/image.txt", []byte("local/cache:1\n")); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+
+	srv := httptest.NewServer((&API{store: fixture.store, artifacts: artifacts}).routes())
+	defer srv.Close()
+
+	paths := map[string]string{
+		"project":   "/api/projects/" + projectID,
+		"op":        "/api/ops/" + op.ID,
+		"releases":  "/api/projects/" + projectID + "/releases?environment=dev",
+		"release":   "/api/projects/" + projectID + "/releases/" + release.ID,
+		"artifacts": "/api/projects/" + projectID + "/artifacts",
+	}
+	tags := map[string]string{}
+	for name, path := range paths {
+		resp := conditionalGetForTest(t, srv.URL+path, "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", name, resp.StatusCode)
+		}
+		tags[name] = resp.Header.Get("ETag")
+		if tags[name] == "" || resp.Header.Get("Last-Modified") == "" {
+			t.Fatalf("%s: expected ETag and Last-Modified, got %v", name, resp.Header)
+		}
+		if again := conditionalGetForTest(t, srv.URL+path, tags[name]); again.StatusCode != http.StatusNotModified {
+			t.Fatalf("%s: expected 304 for matching If-None-Match, got %d", name, again.StatusCode)
+		}
+	}
+
+	// A step end rewrites the op; the op and artifact list change, the
+	// project record does not.
+	op.Steps = []OpStep{{Worker: "deployer", StartedAt: now, EndedAt: now, Message: "applied"}}
+	if err = fixture.store.PutOp(ctx, op); err != nil {
+		t.Fatalf("update op: %v", err)
+	}
+	for name, want := range map[string]int{
+		"op":        http.StatusOK,
+		"artifacts": http.StatusOK,
+		"project":   http.StatusNotModified,
+	} {
+		if got := conditionalGetForTest(t, srv.URL+paths[name], tags[name]).StatusCode; got != want {
+			t.Fatalf("%s after op write: expected %d, got %d", name, want, got)
+		}
+	}
+}
+
+func TestAPI_CacheValidatorIfModifiedSince(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+	validator := newCacheValidator("test").add(kvRevision{Key: "k", Revision: 7, Modified: modified})
+
+	cases := []struct {
+		since string
+		want  int
+	}{
+		{since: modified.Format(http.TimeFormat), want: http.StatusNotModified},
+		{since: modified.Add(time.Hour).Format(http.TimeFormat), want: http.StatusNotModified},
+		{since: modified.Add(-time.Minute).Format(http.TimeFormat), want: http.StatusOK},
+		{since: "not a date", want: http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-Modified-Since", tc.since)
+		rec := httptest.NewRecorder()
+		if !validator.notModified(rec, req) {
+			rec.WriteHeader(http.StatusOK)
+		}
+		if rec.Code != tc.want {
+			t.Fatalf("If-Modified-Since %q: expected %d, got %d", tc.since, tc.want, rec.Code)
+		}
+	}
+}
+
+func conditionalGetForTest(t *testing.T, url, etag string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get %s: %v", url, err)
+	}
+	_ = resp.Body.Close()
+	return resp
+}
@@ -0,0 +1,252 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	canaryPathParts       = 4 // {id}/environments/{env}/canary
+	canaryActionPathParts = 5 // .../canary/{promote|abort}
+
+	transitionBlockerCanaryRunning = "canary_running"
+)
+
+// canaryActionRequest is the optional body of a canary promote or abort.
+type canaryActionRequest struct {
+	FreezeOverride *FreezeOverrideRequest `json:"freeze_override,omitempty"`
+}
+
+// transitionStrategy is how a promotion or release delivers its image: to
+// every replica at once (the zero value), as a canary on weight percent of
+// them, or onto the idle color of a blue/green environment.
+type transitionStrategy struct {
+	strategy DeliveryStrategy
+	weight   int
+}
+
+// parseTransitionStrategy checks a transition request's strategy and
+// weight. A fanout promotion takes neither.
+func parseTransitionStrategy(strategy DeliveryStrategy, weight int, fanout bool) (transitionStrategy, error) {
+	switch {
+	case strategy != "" && fanout:
+		return transitionStrategy{}, fmt.Errorf("strategy %s promotes to a single to_env, not to_envs", strategy)
+	case strategy == DeliveryStrategyCanary:
+		if err := validateCanaryWeight(weight); err != nil {
+			return transitionStrategy{}, err
+		}
+	case strategy != "" && strategy != DeliveryStrategyBlueGreen:
+		return transitionStrategy{}, fmt.Errorf(
+			"unknown strategy %q; use %s or %s, or leave it empty",
+			strategy, DeliveryStrategyCanary, DeliveryStrategyBlueGreen,
+		)
+	case weight != 0:
+		return transitionStrategy{}, errors.New("weight applies only to strategy canary")
+	}
+	return transitionStrategy{strategy: strategy, weight: weight}, nil
+}
+
+// promotionStrategy checks a promotion's strategy.
+func promotionStrategy(evt PromotionEvent) (transitionStrategy, error) {
+	return parseTransitionStrategy(evt.Strategy, evt.Weight, len(evt.ToEnvs) > 0)
+}
+
+// withStrategy returns a copy of o that delivers with strategy; the zero
+// strategy leaves o unchanged.
+func (o opRunOptions) withStrategy(strategy transitionStrategy) opRunOptions {
+	o.delivery.Strategy = strategy.strategy
+	o.delivery.CanaryWeight = strategy.weight
+	return o
+}
+
+// strategyConflict refuses a transition into env while a canary runs there,
+// or one that skips the idle color of a blue/green environment.
+func (a *API) strategyConflict(projectID, env string, strategy transitionStrategy) error {
+	rollout, running, err := readCanaryRollout(a.artifacts, projectID, env)
+	if err != nil {
+		return err
+	}
+	if running {
+		return requestError(http.StatusConflict, fmt.Sprintf(
+			"%s runs canary %s at %d%%; promote or abort it first "+
+				"(POST /api/projects/%s/environments/%s/canary/promote)",
+			env, rollout.CanaryImage, rollout.Weight, projectID, env,
+		))
+	}
+	if strategy.strategy == DeliveryStrategyBlueGreen {
+		return nil
+	}
+	if err = ensureNoBlueGreen(a.artifacts, projectID, env); err != nil {
+		return requestError(http.StatusConflict, err.Error())
+	}
+	return nil
+}
+
+// addStrategyPreview blocks a preview whose target runs a canary, or
+// releases blue/green when the promotion does not, and for a canary
+// promotion plans its traffic split. It expects a valid transition.
+func (a *API) addStrategyPreview(
+	ctx context.Context,
+	preview *PromotionPreviewResponse,
+	evt PromotionEvent,
+	strategy transitionStrategy,
+) error {
+	project, err := a.store.GetProject(ctx, strings.TrimSpace(evt.ProjectID))
+	if err != nil {
+		return err
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	fromEnv, toEnv, _, _, err := resolveTransitionRequest(spec, evt.FromEnv, evt.ToEnv, false)
+	if err != nil {
+		return err
+	}
+	running, active, err := readCanaryRollout(a.artifacts, project.ID, toEnv)
+	if err != nil {
+		return err
+	}
+	if active {
+		preview.Blockers = append(preview.Blockers, TransitionPreviewBlocker{
+			Code:    transitionBlockerCanaryRunning,
+			Message: fmt.Sprintf("Target environment %q runs canary %s.", toEnv, running.CanaryImage),
+			Why: fmt.Sprintf(
+				"The canary has %d%% of %s's replicas until it is promoted or aborted.", running.Weight, toEnv,
+			),
+			NextAction: "Promote or abort the canary, then retry preview.",
+		})
+	}
+	if strategy.strategy != DeliveryStrategyBlueGreen {
+		if err = addBlueGreenBlocker(a.artifacts, preview, project.ID, toEnv); err != nil {
+			return err
+		}
+	}
+	if strategy.strategy != DeliveryStrategyCanary {
+		return nil
+	}
+	imageByEnv, err := loadManifestImageTags(a.artifacts, project.ID, spec)
+	if err != nil {
+		return err
+	}
+	sourceImage, err := resolvePromotionSourceImage(a.artifacts, project.ID, fromEnv, imageByEnv)
+	if err != nil {
+		return err
+	}
+	stableImage, err := resolvePromotionSourceImage(a.artifacts, project.ID, toEnv, imageByEnv)
+	if err != nil {
+		return err
+	}
+	plan := planCanaryRollout(spec, toEnv, fromEnv, stableImage, sourceImage, strategy.weight)
+	preview.TrafficSplit = &plan
+	return nil
+}
+
+// handleEnvironmentCanary serves GET .../environments/{env}/canary, the
+// canary running there, and POST .../canary/promote or .../canary/abort,
+// which queue the op that ends it.
+func (a *API) handleEnvironmentCanary(w http.ResponseWriter, r *http.Request, parts []string) {
+	projectID := strings.TrimSpace(parts[0])
+	env := strings.TrimSpace(parts[2])
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	if _, exists := spec.Environments[env]; !exists {
+		writeAPIError(w, "environment not found", http.StatusNotFound)
+		return
+	}
+	rollout, running, err := readCanaryRollout(a.artifacts, projectID, env)
+	if err != nil {
+		writeAPIError(w, "failed to read canary", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case len(parts) == canaryPathParts && r.Method == http.MethodGet:
+		if !running {
+			writeAPIError(w, "no canary running in "+env, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, rollout)
+	case len(parts) == canaryActionPathParts && r.Method == http.MethodPost:
+		action := CanaryAction(parts[4])
+		if action != CanaryActionPromote && action != CanaryActionAbort {
+			writeAPIError(w, "not found", http.StatusNotFound)
+			return
+		}
+		if !running {
+			writeAPIError(w, "no canary running in "+env, http.StatusConflict)
+			return
+		}
+		var req canaryActionRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeAPIError(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		a.enqueueStrategyFollowUp(w, r, project, rollout.FromEnv, rollout.Environment, DeliveryLifecycle{
+			Stage:           "",
+			Environment:     "",
+			FromEnv:         "",
+			ToEnv:           "",
+			Strategy:        DeliveryStrategyCanary,
+			CanaryWeight:    0,
+			CanaryAction:    action,
+			BlueGreenAction: "",
+		}, req.FreezeOverride)
+	case len(parts) == canaryPathParts || len(parts) == canaryActionPathParts:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		writeAPIError(w, "not found", http.StatusNotFound)
+	}
+}
+
+// enqueueStrategyFollowUp queues the transition from fromEnv into toEnv
+// that ends a canary or switches a blue/green environment's color, as
+// follow-up says.
+func (a *API) enqueueStrategyFollowUp(
+	w http.ResponseWriter,
+	r *http.Request,
+	project Project,
+	fromEnvRaw, toEnvRaw string,
+	followUp DeliveryLifecycle,
+	freezeOverride *FreezeOverrideRequest,
+) {
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	fromEnv, toEnv, stage, kind, err := resolveTransitionRequest(spec, fromEnvRaw, toEnvRaw, false)
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+	opts, err := transitionOpRunOptions(fromEnv, toEnv, stage).
+		withExecution(execution).
+		withFreezeOverride(r, freezeOverride)
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+	opts.delivery.Strategy = followUp.Strategy
+	opts.delivery.CanaryAction = followUp.CanaryAction
+	opts.delivery.BlueGreenAction = followUp.BlueGreenAction
+	op, err := a.enqueueOp(r.Context(), kind, project.ID, spec, opts)
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+	if latest, readErr := a.store.GetProject(r.Context(), project.ID); readErr == nil {
+		project = latest
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"accepted": true,
+		"project":  project,
+		"op":       op,
+	})
+}
@@ -0,0 +1,458 @@
+package platform
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Compliance report: one read of everything an auditor asks about a project,
+// the images each environment runs, how the last production release got
+// there, what is currently out of policy, and the recent audit trail. It is
+// served as JSON, or with ?format=html as a self-contained page meant to be
+// printed or saved as PDF.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	complianceAuditOpsLimit       = 20
+	complianceAuditLogLines       = 50
+	complianceFormatHTML          = "html"
+	complianceReportTemplate      = "compliance"
+	complianceViolationBudget     = "vulnerability_budget"
+	complianceViolationUnscanned  = "unscanned_image"
+	complianceViolationOverridden = "vulnerability_override"
+)
+
+type complianceReport struct {
+	ProjectID             string                       `json:"project_id"`
+	Name                  string                       `json:"name"`
+	SpecHash              string                       `json:"spec_hash"`
+	GeneratedAt           time.Time                    `json:"generated_at"`
+	Ownership             ProjectOwnership             `json:"ownership"`
+	Environments          []complianceEnvironment      `json:"environments"`
+	LastProductionRelease *complianceProductionRelease `json:"last_production_release,omitempty"`
+	Violations            []complianceViolation        `json:"violations"`
+	Holds                 projectHoldsResponse         `json:"holds"`
+	Audit                 complianceAudit              `json:"audit"`
+}
+
+// complianceEnvironment is what one environment runs. Digest is only known
+// for images whose build recorded one; Scan is nil while the scan report
+// covers some other image.
+type complianceEnvironment struct {
+	Environment    string                `json:"environment"`
+	Image          string                `json:"image"`
+	Digest         string                `json:"digest,omitempty"`
+	Release        *ReleaseRecord        `json:"release,omitempty"`
+	Scan           *VulnerabilitySummary `json:"scan,omitempty"`
+	BudgetExceeded []string              `json:"budget_exceeded,omitempty"`
+}
+
+// complianceProductionRelease is the current production release and the
+// sign-off recorded on the op that made it: the approval it was released
+// under, the vulnerability and freeze overrides, when they were needed, and
+// the notes left on the op.
+type complianceProductionRelease struct {
+	Release               ReleaseRecord          `json:"release"`
+	OpStatus              string                 `json:"op_status,omitempty"`
+	VulnerabilityOverride *VulnerabilityOverride `json:"vulnerability_override,omitempty"`
+	FreezeOverride        *FreezeOverride        `json:"freeze_override,omitempty"`
+	Approval              *ReleaseApproval       `json:"approval,omitempty"`
+	Notes                 []OpNote               `json:"notes"`
+}
+
+type complianceViolation struct {
+	Kind        string `json:"kind"`
+	Environment string `json:"environment"`
+	Image       string `json:"image,omitempty"`
+	Detail      string `json:"detail"`
+}
+
+// complianceAudit excerpts the op history and the tail of each _audit log.
+type complianceAudit struct {
+	Ops  []complianceAuditOp `json:"ops"`
+	Logs map[string][]string `json:"logs"`
+}
+
+type complianceAuditOp struct {
+	ID        string        `json:"id"`
+	Kind      OperationKind `json:"kind"`
+	Status    string        `json:"status"`
+	Requested time.Time     `json:"requested"`
+	Finished  time.Time     `json:"finished"`
+	Error     string        `json:"error,omitempty"`
+	Override  bool          `json:"vulnerability_override,omitempty"`
+	Frozen    bool          `json:"freeze_override,omitempty"`
+}
+
+func (a *API) handleProjectCompliance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.store == nil || a.artifacts == nil {
+		writeAPIError(w, "compliance data unavailable", http.StatusInternalServerError)
+		return
+	}
+	projectID, ok := projectIDFromSubresourcePath(w, r, "compliance")
+	if !ok {
+		return
+	}
+	project, found := a.getProjectOrWriteError(w, r, projectID)
+	if !found {
+		return
+	}
+	report, err := a.buildComplianceReport(r.Context(), project)
+	if err != nil {
+		writeAPIError(w, "failed to build compliance report", http.StatusInternalServerError)
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))) {
+	case "", "json":
+		writeJSON(w, http.StatusOK, report)
+	case complianceFormatHTML:
+		writeComplianceHTML(w, report)
+	default:
+		writeAPIError(w, "format must be json or html", http.StatusBadRequest)
+	}
+}
+
+func (a *API) buildComplianceReport(ctx context.Context, project Project) (complianceReport, error) {
+	spec := normalizeProjectSpec(project.Spec)
+	report := complianceReport{
+		ProjectID:             project.ID,
+		Name:                  spec.Name,
+		SpecHash:              project.SpecHash,
+		GeneratedAt:           time.Now().UTC(),
+		Ownership:             project.Ownership,
+		Environments:          nil,
+		LastProductionRelease: nil,
+		Violations:            []complianceViolation{},
+		Holds:                 projectHoldsResponse{ProjectID: project.ID, Project: nil, Releases: []ComplianceHold{}},
+		Audit:                 complianceAudit{Ops: []complianceAuditOp{}, Logs: map[string][]string{}},
+	}
+	var err error
+	if report.Environments, err = a.complianceEnvironments(ctx, project.ID, spec); err != nil {
+		return complianceReport{}, err
+	}
+	production, found, err := a.complianceProductionRelease(ctx, project.ID, spec)
+	if err != nil {
+		return complianceReport{}, err
+	}
+	if found {
+		report.LastProductionRelease = &production
+	}
+	report.Violations = complianceViolations(report.Environments, report.LastProductionRelease)
+
+	holds, err := a.store.getProjectHolds(ctx, project.ID)
+	if err != nil {
+		return complianceReport{}, fmt.Errorf("read compliance holds: %w", err)
+	}
+	report.Holds = newProjectHoldsResponse(project.ID, holds)
+
+	page, err := a.store.listProjectOps(ctx, project.ID, projectOpsListQuery{
+		Limit:  complianceAuditOpsLimit,
+		Cursor: "",
+		Before: "",
+	})
+	if err != nil {
+		return complianceReport{}, fmt.Errorf("list ops: %w", err)
+	}
+	for _, op := range page.Ops {
+		report.Audit.Ops = append(report.Audit.Ops, complianceAuditOp{
+			ID:        op.ID,
+			Kind:      op.Kind,
+			Status:    op.Status,
+			Requested: op.Requested,
+			Finished:  op.Finished,
+			Error:     op.Error,
+			Override:  op.VulnerabilityOverride != nil,
+			Frozen:    op.FreezeOverride != nil,
+		})
+	}
+	for _, kind := range []string{"holds", "freezes", "approvals", "overrides", "cleanup", "access", "remediation"} {
+		lines, readErr := readLogTail(a.projectAuditLogPath(project.ID, kind), complianceAuditLogLines)
+		if readErr != nil {
+			return complianceReport{}, fmt.Errorf("read %s audit log: %w", kind, readErr)
+		}
+		report.Audit.Logs[kind] = lines
+	}
+	return report, nil
+}
+
+// complianceEnvironments reports the image each environment runs: the one
+// its current release shipped, or else the one its overlay points at.
+func (a *API) complianceEnvironments(
+	ctx context.Context,
+	projectID string,
+	spec ProjectSpec,
+) ([]complianceEnvironment, error) {
+	imageByEnv, err := loadManifestImageTags(a.artifacts, projectID, spec)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest image tags: %w", err)
+	}
+	budget := vulnerabilityBudgetFromEnv()
+	out := make([]complianceEnvironment, 0, len(imageByEnv))
+	for _, env := range sortedKeys(imageByEnv) {
+		entry := complianceEnvironment{
+			Environment:    env,
+			Image:          imageByEnv[env],
+			Digest:         "",
+			Release:        nil,
+			Scan:           nil,
+			BudgetExceeded: nil,
+		}
+		release, ok, releaseErr := a.store.getProjectCurrentRelease(ctx, projectID, env)
+		if releaseErr != nil {
+			return nil, fmt.Errorf("read %s current release: %w", env, releaseErr)
+		}
+		if ok {
+			entry.Release = &release
+			if image := strings.TrimSpace(release.Image); image != "" {
+				entry.Image = image
+			}
+		}
+		entry.Digest = readBuildImageDigest(a.artifacts, projectID, entry.Image)
+		summary, scanned, scanErr := readVulnerabilitySummary(a.artifacts, projectID, entry.Image)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		if scanned {
+			entry.Scan = &summary
+			entry.BudgetExceeded = budget.exceeded(summary)
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+func (a *API) complianceProductionRelease(
+	ctx context.Context,
+	projectID string,
+	spec ProjectSpec,
+) (complianceProductionRelease, bool, error) {
+	var out complianceProductionRelease
+	env, ok := resolveProjectEnvironmentName(spec, defaultReleaseEnvironment)
+	if !ok {
+		return out, false, nil
+	}
+	release, found, err := a.store.getProjectCurrentRelease(ctx, projectID, env)
+	if err != nil || !found {
+		return out, false, err
+	}
+	out = complianceProductionRelease{
+		Release:               release,
+		OpStatus:              "",
+		VulnerabilityOverride: nil,
+		FreezeOverride:        nil,
+		Approval:              nil,
+		Notes:                 []OpNote{},
+	}
+	op, err := a.store.GetOp(ctx, release.OpID)
+	switch {
+	case errors.Is(err, jetstream.ErrKeyNotFound):
+		return out, true, nil
+	case err != nil:
+		return out, false, fmt.Errorf("read release op: %w", err)
+	}
+	out.OpStatus = op.Status
+	out.VulnerabilityOverride = op.VulnerabilityOverride
+	out.FreezeOverride = op.FreezeOverride
+	if op.ApprovalID != "" {
+		approval, approvalErr := a.store.getReleaseApproval(ctx, op.ApprovalID)
+		if approvalErr != nil && !errors.Is(approvalErr, jetstream.ErrKeyNotFound) {
+			return out, false, fmt.Errorf("read release approval: %w", approvalErr)
+		}
+		if approvalErr == nil {
+			out.Approval = &approval
+		}
+	}
+	notes, _, err := a.store.getOpNotes(ctx, op.ID)
+	if err != nil {
+		return out, false, fmt.Errorf("read release op notes: %w", err)
+	}
+	out.Notes = append(out.Notes, notes...)
+	return out, true, nil
+}
+
+// complianceViolations lists what is out of policy now: images over the
+// vulnerability budget, released images that were never scanned while the
+// budget is enforced, and a production release that needed an override.
+func complianceViolations(
+	envs []complianceEnvironment,
+	production *complianceProductionRelease,
+) []complianceViolation {
+	enforced := len(vulnerabilityBudgetFromEnv()) > 0
+	out := []complianceViolation{}
+	for _, env := range envs {
+		switch {
+		case len(env.BudgetExceeded) > 0:
+			out = append(out, complianceViolation{
+				Kind:        complianceViolationBudget,
+				Environment: env.Environment,
+				Image:       env.Image,
+				Detail:      "exceeds the vulnerability budget (" + strings.Join(env.BudgetExceeded, ", ") + ")",
+			})
+		case enforced && env.Scan == nil && env.Release != nil:
+			out = append(out, complianceViolation{
+				Kind:        complianceViolationUnscanned,
+				Environment: env.Environment,
+				Image:       env.Image,
+				Detail:      "no scan report covers the released image",
+			})
+		}
+	}
+	if production != nil && production.VulnerabilityOverride != nil {
+		override := production.VulnerabilityOverride
+		out = append(out, complianceViolation{
+			Kind:        complianceViolationOverridden,
+			Environment: production.Release.Environment,
+			Image:       production.Release.Image,
+			Detail: fmt.Sprintf(
+				"released over the vulnerability budget (%s) by %q: %s",
+				strings.Join(override.Exceeded, ", "),
+				override.By,
+				override.Justification,
+			),
+		})
+	}
+	return out
+}
+
+// readBuildImageDigest returns the digest the last build recorded for image,
+// or "" when the build was of another image or recorded none.
+func readBuildImageDigest(artifacts ArtifactStore, projectID, image string) string {
+	raw, err := artifacts.ReadFile(projectID, buildpacksPlanPath)
+	if err != nil {
+		return ""
+	}
+	var plan buildpacksPlan
+	if err = json.Unmarshal(raw, &plan); err != nil || strings.TrimSpace(plan.Image) != image {
+		return ""
+	}
+	_, digest, _ := strings.Cut(plan.ImageReference, "@")
+	return digest
+}
+
+// readLogTail returns the last n lines of the log at path; a missing log has
+// none.
+func readLogTail(path string, n int) ([]string, error) {
+	lines := []string{}
+	f, err := os.Open(path) // #nosec G304 -- path is built by projectAuditLogPath from a stored project ID.
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return lines, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func writeComplianceHTML(w http.ResponseWriter, report complianceReport) {
+	page, err := template.New(complianceReportTemplate).Parse(complianceReportHTML)
+	if err != nil {
+		writeAPIError(w, "failed to render compliance report", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = page.Execute(w, report)
+}
+
+const complianceReportHTML = `<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Compliance report: {{.Name}}</title>
+<style>
+@page { size: A4; margin: 16mm; }
+body { font: 11pt/1.4 system-ui, sans-serif; color: #111; margin: 0 auto; max-width: 60rem; }
+h1 { font-size: 18pt; margin-bottom: 0; }
+h2 { font-size: 13pt; border-bottom: 1px solid #999; margin-top: 1.5em; page-break-after: avoid; }
+table { border-collapse: collapse; width: 100%; page-break-inside: auto; }
+tr { page-break-inside: avoid; }
+th, td { border: 1px solid #ccc; padding: 3px 6px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+code, pre { font: 9pt ui-monospace, monospace; word-break: break-all; white-space: pre-wrap; }
+.meta { color: #555; }
+.none { color: #555; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>Compliance report: {{.Name}}</h1>
+<p class="meta">Project <code>{{.ProjectID}}</code> · spec <code>{{.SpecHash}}</code> ·
+generated {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+{{with .Ownership.Owners}}<p>Owners: {{range $i, $o := .}}{{if $i}}, {{end}}{{$o.Name}}{{end}}</p>{{end}}
+{{with .Ownership.OnCall}}<p>On call: {{.}}</p>{{end}}
+
+<h2>Environments</h2>
+<table>
+<tr><th>Environment</th><th>Image</th><th>Digest</th><th>Release</th><th>Scan (C/H/M/L/U)</th></tr>
+{{range .Environments}}<tr>
+<td>{{.Environment}}</td><td><code>{{.Image}}</code></td><td><code>{{.Digest}}</code></td>
+<td>{{with .Release}}<code>{{.ID}}</code><br>{{.CreatedAt.Format "2006-01-02 15:04"}}
+{{- else}}<span class="none">none</span>{{end}}</td>
+<td>{{with .Scan}}{{.Critical}}/{{.High}}/{{.Medium}}/{{.Low}}/{{.Unknown}}
+{{- else}}<span class="none">not scanned</span>{{end}}</td>
+</tr>{{end}}
+</table>
+
+<h2>Last production release</h2>
+{{with .LastProductionRelease}}<p>Release <code>{{.Release.ID}}</code> of <code>{{.Release.Image}}</code>
+to {{.Release.Environment}} on {{.Release.CreatedAt.Format "2006-01-02 15:04:05 MST"}}
+by op <code>{{.Release.OpID}}</code>{{with .OpStatus}} ({{.}}){{end}}.</p>
+{{with .VulnerabilityOverride}}<p>Vulnerability override by <strong>{{.By}}</strong> at
+{{.At.Format "2006-01-02 15:04:05 MST"}}: {{.Justification}}</p>{{end}}
+{{with .FreezeOverride}}<p>Released into a frozen {{.Environment}} by <strong>{{.By}}</strong> at
+{{.At.Format "2006-01-02 15:04:05 MST"}}: {{.Justification}}</p>{{end}}
+{{with .Approval}}<p>Approved under <code>{{.ID}}</code>:{{range .Decisions}} <strong>{{.By}}</strong>
+({{.At.Format "2006-01-02 15:04"}}){{with .Comment}}: {{.}}{{end}};{{end}}</p>{{end}}
+{{if .Notes}}<table><tr><th>Note by</th><th>At</th><th>Text</th></tr>
+{{range .Notes}}<tr><td>{{.Author}}</td><td>{{.CreatedAt.Format "2006-01-02 15:04"}}</td><td>{{.Text}}</td></tr>{{end}}
+</table>{{end}}
+{{else}}<p class="none">No production release.</p>{{end}}
+
+<h2>Open violations</h2>
+{{if .Violations}}<table><tr><th>Kind</th><th>Environment</th><th>Image</th><th>Detail</th></tr>
+{{range .Violations}}<tr><td>{{.Kind}}</td><td>{{.Environment}}</td><td><code>{{.Image}}</code></td>
+<td>{{.Detail}}</td></tr>{{end}}
+</table>{{else}}<p class="none">None.</p>{{end}}
+
+<h2>Compliance holds</h2>
+{{with .Holds.Project}}<p>Project hold by {{.PlacedBy}} since {{.PlacedAt.Format "2006-01-02"}}: {{.Reason}}</p>{{end}}
+{{if .Holds.Releases}}<table><tr><th>Release</th><th>Placed by</th><th>Since</th><th>Reason</th></tr>
+{{range .Holds.Releases}}<tr><td><code>{{.ReleaseID}}</code></td><td>{{.PlacedBy}}</td>
+<td>{{.PlacedAt.Format "2006-01-02"}}</td><td>{{.Reason}}</td></tr>{{end}}
+</table>{{else if not .Holds.Project}}<p class="none">None.</p>{{end}}
+
+<h2>Recent operations</h2>
+{{if .Audit.Ops}}<table><tr><th>Op</th><th>Kind</th><th>Status</th><th>Requested</th><th>Error</th></tr>
+{{range .Audit.Ops}}<tr><td><code>{{.ID}}</code></td><td>{{.Kind}}</td><td>{{.Status}}</td>
+<td>{{.Requested.Format "2006-01-02 15:04"}}</td><td>{{.Error}}</td></tr>{{end}}
+</table>{{else}}<p class="none">None.</p>{{end}}
+
+<h2>Audit log excerpts</h2>
+{{range $kind, $lines := .Audit.Logs}}<h3>{{$kind}}</h3>
+{{if $lines}}<pre>{{range $lines}}{{.}}
+{{end}}</pre>{{else}}<p class="none">Empty.</p>{{end}}{{end}}
+</body>
+</html>
+`
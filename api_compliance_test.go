@@ -0,0 +1,121 @@
+//nolint:testpackage,exhaustruct // Compliance tests seed releases, ops, and artifacts through internal fixtures.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_ProjectComplianceReport(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	api := fixture.api
+	t.Setenv(vulnBudgetEnv, "critical=0")
+
+	override := &VulnerabilityOverride{
+		Justification: "fix ships next sprint",
+		By:            "sec-lead",
+		Exceeded:      []string{"critical: 1 > 0"},
+		At:            time.Now().UTC(),
+	}
+	releaseOp := Operation{
+		ID:                    "op-compliance-release",
+		Kind:                  OpRelease,
+		ProjectID:             fixture.projectID,
+		Requested:             time.Now().UTC(),
+		Status:                opStatusDone,
+		VulnerabilityOverride: override,
+	}
+	if err := api.store.PutOp(ctx, releaseOp); err != nil {
+		t.Fatalf("put release op: %v", err)
+	}
+	note := OpNote{ID: "n1", Author: "release-manager", Text: "approved in CAB", CreatedAt: time.Now().UTC()}
+	if err := api.store.addOpNote(ctx, releaseOp, note); err != nil {
+		t.Fatalf("add op note: %v", err)
+	}
+	for _, release := range []ReleaseRecord{
+		{ProjectID: fixture.projectID, Environment: "prod", OpID: releaseOp.ID, Image: "local/app:prod"},
+		{ProjectID: fixture.projectID, Environment: "staging", OpID: "op-staging", Image: "local/app:staging"},
+	} {
+		if _, err := api.store.PutRelease(ctx, release); err != nil {
+			t.Fatalf("put %s release: %v", release.Environment, err)
+		}
+	}
+	writes := map[string]string{
+		buildpacksPlanPath: `{"image":"local/app:prod","image_reference":"local/app:prod@sha256:abc"}`,
+		vulnerabilityReportPath: `{"ArtifactName":"local/app:prod","Results":[` +
+			`{"Vulnerabilities":[{"VulnerabilityID":"CVE-1","Severity":"CRITICAL"}]}]}`,
+	}
+	for path, body := range writes {
+		if _, err := api.artifacts.WriteFile(fixture.projectID, path, []byte(body)); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	api.auditVulnerabilityOverride(releaseOp)
+
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	resp := getCompliance(t, srv, fixture.projectID, "")
+	var report complianceReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	prod := findComplianceEnvironment(report.Environments, "prod")
+	if prod.Digest != "sha256:abc" || prod.Scan == nil || prod.Scan.Critical != 1 || prod.Release == nil {
+		t.Fatalf("expected prod image, digest, release, and scan, got %+v", prod)
+	}
+	last := report.LastProductionRelease
+	if last == nil || last.VulnerabilityOverride == nil || last.VulnerabilityOverride.By != "sec-lead" ||
+		len(last.Notes) != 1 || last.Notes[0].Author != "release-manager" {
+		t.Fatalf("expected the prod release sign-off, got %+v", last)
+	}
+	kinds := []string{}
+	for _, violation := range report.Violations {
+		kinds = append(kinds, violation.Kind+"/"+violation.Environment)
+	}
+	want := "vulnerability_budget/prod,unscanned_image/staging,vulnerability_override/prod"
+	if strings.Join(kinds, ",") != want {
+		t.Fatalf("expected violations %s, got %v", want, kinds)
+	}
+	if len(report.Audit.Ops) != 1 || len(report.Audit.Logs["overrides"]) != 1 {
+		t.Fatalf("expected the op history and override log excerpt, got %+v", report.Audit)
+	}
+
+	resp = getCompliance(t, srv, fixture.projectID, "?format=html")
+	page, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") ||
+		!strings.Contains(string(page), "sec-lead") || !strings.Contains(string(page), "sha256:abc") {
+		t.Fatalf("expected an HTML rendering of the report, got %s", page)
+	}
+	if resp = getCompliance(t, srv, fixture.projectID, "?format=pdf"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected an unknown format to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func getCompliance(t *testing.T, srv *httptest.Server, projectID, query string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(
+		context.Background(), http.MethodGet, srv.URL+"/api/projects/"+projectID+"/compliance"+query, nil)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("get compliance report: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func findComplianceEnvironment(envs []complianceEnvironment, name string) complianceEnvironment {
+	for _, env := range envs {
+		if env.Environment == name {
+			return env
+		}
+	}
+	return complianceEnvironment{}
+}
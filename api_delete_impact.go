@@ -0,0 +1,161 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Delete impact: the part of a delete plan that reaches past the project's
+// own artifacts. A plan with any impact only applies when the delete also
+// says acknowledge_impact=true, so callers see who depends on the project
+// before it goes.
+
+const clusterServiceDomain = "svc.cluster.local"
+
+// buildDeleteImpact inventories live releases, their endpoints, projects
+// that call those endpoints, and credentials stored for project.
+func (a *API) buildDeleteImpact(ctx context.Context, project Project) (DeleteImpact, error) {
+	spec := normalizeProjectSpec(project.Spec)
+	impact := DeleteImpact{
+		LiveReleases: []string{},
+		Endpoints:    []string{},
+		Dependents:   []DeleteDependent{},
+		Credentials:  []string{},
+	}
+	for _, env := range sortedKeys(spec.Environments) {
+		current, ok, err := a.store.getProjectCurrentRelease(ctx, project.ID, env)
+		if err != nil {
+			return DeleteImpact{}, fmt.Errorf("read %s current release: %w", env, err)
+		}
+		if !ok {
+			continue
+		}
+		impact.LiveReleases = append(impact.LiveReleases, env+"/"+current.ID)
+		impact.Endpoints = append(impact.Endpoints, projectServiceHost(spec, env)+"."+clusterServiceDomain)
+	}
+
+	dependents, err := a.deleteDependents(ctx, project)
+	if err != nil {
+		return DeleteImpact{}, err
+	}
+	impact.Dependents = dependents
+
+	secrets, err := loadEnvStoredSecrets(ctx, a.store, project.ID)
+	if err != nil {
+		return DeleteImpact{}, fmt.Errorf("read stored secrets: %w", err)
+	}
+	for _, env := range sortedKeys(secrets) {
+		for _, name := range secrets[env] {
+			impact.Credentials = append(impact.Credentials, "secret "+env+"/"+name)
+		}
+	}
+	bindings, err := loadEnvCapabilityBindings(ctx, a.store, project.ID)
+	if err != nil {
+		return DeleteImpact{}, fmt.Errorf("read capability bindings: %w", err)
+	}
+	for _, env := range sortedKeys(bindings) {
+		for _, binding := range bindings[env] {
+			impact.Credentials = append(impact.Credentials, "binding "+env+"/"+binding.Capability)
+		}
+	}
+	slices.Sort(impact.Credentials)
+	return impact, nil
+}
+
+// deleteDependents finds other projects whose vars, or capability binding
+// env, name one of project's service hosts in any environment.
+func (a *API) deleteDependents(ctx context.Context, project Project) ([]DeleteDependent, error) {
+	spec := normalizeProjectSpec(project.Spec)
+	hosts := make([]string, 0, len(spec.Environments))
+	for _, env := range sortedKeys(spec.Environments) {
+		hosts = append(hosts, projectServiceHost(spec, env))
+	}
+	projects, err := a.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	dependents := []DeleteDependent{}
+	for _, other := range projects {
+		if other.ID == project.ID || !orgVisible(ctx, other.Org) {
+			continue
+		}
+		otherSpec := normalizeProjectSpec(other.Spec)
+		add := func(env, reference, value string) {
+			if host := firstHostMentioned(value, hosts); host != "" {
+				dependents = append(dependents, DeleteDependent{
+					ProjectID:   other.ID,
+					ProjectName: otherSpec.Name,
+					Environment: env,
+					Reference:   reference,
+					Endpoint:    host,
+				})
+			}
+		}
+		for _, name := range sortedKeys(otherSpec.Vars) {
+			add("", name, otherSpec.Vars[name])
+		}
+		for _, env := range sortedKeys(otherSpec.Environments) {
+			vars := otherSpec.Environments[env].Vars
+			for _, name := range sortedKeys(vars) {
+				add(env, name, vars[name])
+			}
+		}
+		bindings, bindingsErr := loadEnvCapabilityBindings(ctx, a.store, other.ID)
+		if bindingsErr != nil {
+			return nil, fmt.Errorf("read capability bindings of %s: %w", other.ID, bindingsErr)
+		}
+		for _, env := range sortedKeys(bindings) {
+			for _, binding := range bindings[env] {
+				for _, name := range sortedKeys(binding.Env) {
+					add(env, "binding "+binding.Capability+" "+name, binding.Env[name])
+				}
+			}
+		}
+	}
+	return dependents, nil
+}
+
+// projectServiceHost is the <service>.<namespace> name other workloads use
+// to reach project's Service in env.
+func projectServiceHost(spec ProjectSpec, env string) string {
+	return safeName(spec.Name) + "." + projectNamespace(spec, env)
+}
+
+// firstHostMentioned returns the first of hosts that value names as a whole
+// DNS label sequence, so "app.ns" does not match "app.ns-two".
+func firstHostMentioned(value string, hosts []string) string {
+	value = strings.ToLower(value)
+	for _, host := range hosts {
+		for offset := 0; ; {
+			i := strings.Index(value[offset:], host)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(host)
+			if !isHostChar(value, start-1) && !isHostChar(value, end) {
+				return host
+			}
+			offset = end
+		}
+	}
+	return ""
+}
+
+func isHostChar(value string, i int) bool {
+	if i < 0 || i >= len(value) {
+		return false
+	}
+	c := value[i]
+	return c == '-' || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
+
+func (i DeleteImpact) empty() bool {
+	return len(i.LiveReleases) == 0 && len(i.Endpoints) == 0 && len(i.Dependents) == 0 && len(i.Credentials) == 0
+}
+
+func (i DeleteImpact) summary() string {
+	return fmt.Sprintf("%d live release(s), %d endpoint(s), %d dependent reference(s), %d credential(s)",
+		len(i.LiveReleases), len(i.Endpoints), len(i.Dependents), len(i.Credentials))
+}
@@ -0,0 +1,320 @@
+package platform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// deletePlanError rejects a delete that does not name a current plan.
+type deletePlanError struct {
+	ProjectID string
+	PlanID    string
+	Status    int
+	Reason    string
+	Impact    *DeleteImpact // set when the plan's impact was not acknowledged
+}
+
+func (e deletePlanError) Error() string {
+	return e.Reason
+}
+
+func writeDeletePlanError(w http.ResponseWriter, err error) bool {
+	var planErr deletePlanError
+	if !errors.As(err, &planErr) {
+		return false
+	}
+	body := map[string]any{
+		"accepted":       false,
+		"reason":         planErr.Error(),
+		"project_id":     planErr.ProjectID,
+		"plan_id":        planErr.PlanID,
+		"requested_kind": OpDelete,
+		"next_step": fmt.Sprintf(
+			"create a plan via POST /api/projects/%s/delete-plan, review it, then DELETE /api/projects/%s?plan_id=<id>",
+			planErr.ProjectID,
+			planErr.ProjectID,
+		),
+	}
+	if planErr.Impact != nil {
+		body["impact"] = planErr.Impact
+		body["next_step"] = fmt.Sprintf(
+			"review the impact, then DELETE /api/projects/%s?plan_id=%s&acknowledge_impact=true",
+			planErr.ProjectID,
+			planErr.PlanID,
+		)
+	}
+	writeErrorDetails(w, planErr.Status, errorCodeDeletePlan, body)
+	return true
+}
+
+func (a *API) handleProjectDeletePlan(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "delete plan data unavailable", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != projectRelPathPartsMin || parts[1] != "delete-plan" {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	if projectID == "" {
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		plan, found, err := a.store.getDeletePlan(r.Context(), projectID)
+		if err != nil {
+			writeAPIError(w, "failed to read delete plan", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			writeAPIError(w, "delete plan not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, plan)
+	case http.MethodPost:
+		plan, err := a.buildDeletePlan(r.Context(), project)
+		if err != nil {
+			writeAPIError(w, "failed to build delete plan", http.StatusInternalServerError)
+			return
+		}
+		now := time.Now().UTC()
+		plan.ID = newID()
+		plan.CreatedAt = now
+		plan.ExpiresAt = now.Add(deletePlanTTL)
+		if err = a.store.putDeletePlan(r.Context(), plan); err != nil {
+			writeAPIError(w, "failed to store delete plan", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, plan)
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// buildDeletePlan inventories what a delete would remove. ID and timestamps
+// are left for the caller; the fingerprint covers only the inventory so a
+// plan can be re-derived and compared at apply time.
+func (a *API) buildDeletePlan(ctx context.Context, project Project) (DeletePlan, error) {
+	spec := normalizeProjectSpec(project.Spec)
+	plan := DeletePlan{
+		ID:               "",
+		ProjectID:        project.ID,
+		CreatedAt:        time.Time{},
+		ExpiresAt:        time.Time{},
+		Fingerprint:      "",
+		Repos:            []string{},
+		Images:           []string{},
+		Releases:         []string{},
+		ClusterResources: []string{},
+		ArtifactFiles:    0,
+		BlockedBy:        "",
+		Impact: DeleteImpact{
+			LiveReleases: nil,
+			Endpoints:    nil,
+			Dependents:   nil,
+			Credentials:  nil,
+		},
+		RequiresAcknowledgement: false,
+	}
+
+	images := map[string]struct{}{}
+	if a.artifacts != nil {
+		files, err := a.artifacts.ListFiles(project.ID)
+		if err != nil {
+			return DeletePlan{}, fmt.Errorf("list artifacts: %w", err)
+		}
+		plan.ArtifactFiles = len(files)
+		for _, repo := range []string{"repos/source", "repos/manifests"} {
+			if slices.ContainsFunc(files, func(path string) bool { return strings.HasPrefix(path, repo+"/") }) {
+				plan.Repos = append(plan.Repos, repo)
+			}
+		}
+		if raw, readErr := a.artifacts.ReadFile(project.ID, "build/image.txt"); readErr == nil {
+			if image := strings.TrimSpace(string(raw)); image != "" {
+				images[image] = struct{}{}
+			}
+		}
+	}
+
+	for _, env := range sortedKeys(spec.Environments) {
+		index, err := a.store.readProjectReleaseIndex(ctx, project.ID, env)
+		if err != nil {
+			return DeletePlan{}, fmt.Errorf("read %s releases: %w", env, err)
+		}
+		plan.Releases = append(plan.Releases, index.IDs...)
+		current, ok, err := a.store.getProjectCurrentRelease(ctx, project.ID, env)
+		if err != nil {
+			return DeletePlan{}, fmt.Errorf("read %s current release: %w", env, err)
+		}
+		if image := strings.TrimSpace(current.Image); ok && image != "" {
+			images[image] = struct{}{}
+		}
+	}
+	slices.Sort(plan.Releases)
+	plan.Images = sortedKeys(images)
+	for _, namespace := range projectNamespaces(spec) {
+		plan.ClusterResources = append(plan.ClusterResources, "Namespace/"+namespace)
+	}
+
+	holds, err := a.store.getProjectHolds(ctx, project.ID)
+	if err != nil {
+		return DeletePlan{}, fmt.Errorf("read compliance holds: %w", err)
+	}
+	if holds.active() {
+		plan.BlockedBy = projectHoldError{ProjectID: project.ID, RequestedKind: OpDelete, Holds: holds}.Error()
+	}
+	dependents, err := a.dependentProjects(ctx, project)
+	if err != nil {
+		return DeletePlan{}, err
+	}
+	if len(dependents) > 0 && plan.BlockedBy == "" {
+		plan.BlockedBy = projectDependentsError{
+			ProjectID:  project.ID,
+			Name:       project.Spec.Name,
+			Dependents: projectNames(dependents),
+		}.Error()
+	}
+	if plan.Impact, err = a.buildDeleteImpact(ctx, project); err != nil {
+		return DeletePlan{}, fmt.Errorf("build delete impact: %w", err)
+	}
+	plan.RequiresAcknowledgement = !plan.Impact.empty()
+	plan.Fingerprint = deletePlanFingerprint(plan)
+	return plan, nil
+}
+
+func deletePlanFingerprint(plan DeletePlan) string {
+	body, _ := json.Marshal([]any{
+		plan.Repos,
+		plan.Images,
+		plan.Releases,
+		plan.ClusterResources,
+		plan.ArtifactFiles,
+		plan.Impact,
+	})
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// confirmDeletePlan requires delete ops to name the project's pending plan
+// and refuses it once expired, once the inventory no longer matches, or
+// while a plan with impact has not been acknowledged.
+func (a *API) confirmDeletePlan(
+	ctx context.Context,
+	projectID string,
+	kind OperationKind,
+	planID string,
+	impactAcknowledged bool,
+) (DeletePlan, error) {
+	if kind != OpDelete || a.store == nil {
+		return DeletePlan{}, nil
+	}
+	if planID == "" {
+		return DeletePlan{}, deletePlanError{
+			ProjectID: projectID,
+			PlanID:    "",
+			Status:    http.StatusPreconditionRequired,
+			Reason:    "delete requires plan_id from a reviewed delete plan",
+			Impact:    nil,
+		}
+	}
+	stored, ok, err := a.store.getDeletePlan(ctx, projectID)
+	if err != nil {
+		return DeletePlan{}, fmt.Errorf("read delete plan: %w", err)
+	}
+	if !ok || stored.ID != planID {
+		return DeletePlan{}, deletePlanError{
+			ProjectID: projectID,
+			PlanID:    planID,
+			Status:    http.StatusConflict,
+			Reason:    fmt.Sprintf("delete plan %s is not the project's pending plan", planID),
+			Impact:    nil,
+		}
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return DeletePlan{}, deletePlanError{
+			ProjectID: projectID,
+			PlanID:    planID,
+			Status:    http.StatusConflict,
+			Reason:    fmt.Sprintf("delete plan %s expired at %s", planID, stored.ExpiresAt.Format(time.RFC3339)),
+			Impact:    nil,
+		}
+	}
+	project, err := a.store.GetProject(ctx, projectID)
+	if err != nil {
+		return DeletePlan{}, err
+	}
+	current, err := a.buildDeletePlan(ctx, project)
+	if err != nil {
+		return DeletePlan{}, err
+	}
+	if current.Fingerprint != stored.Fingerprint {
+		return DeletePlan{}, deletePlanError{
+			ProjectID: projectID,
+			PlanID:    planID,
+			Status:    http.StatusConflict,
+			Reason:    fmt.Sprintf("delete plan %s is stale; the project changed after it was created", planID),
+			Impact:    nil,
+		}
+	}
+	if stored.RequiresAcknowledgement && !impactAcknowledged {
+		return DeletePlan{}, deletePlanError{
+			ProjectID: projectID,
+			PlanID:    planID,
+			Status:    http.StatusPreconditionRequired,
+			Reason: fmt.Sprintf(
+				"delete plan %s affects more than the project (%s); the delete must acknowledge it",
+				planID,
+				stored.Impact.summary(),
+			),
+			Impact: &stored.Impact,
+		}
+	}
+	return stored, nil
+}
+
+// consumeDeletePlan retires an applied plan so it cannot be replayed and
+// keeps a copy under _audit, next to the delete audit the worker writes.
+func (a *API) consumeDeletePlan(ctx context.Context, plan DeletePlan, opID string) {
+	_ = a.store.deleteDeletePlan(ctx, plan.ProjectID)
+	appLoggerForProcess().Source("api").Infof(
+		"delete plan applied project=%s plan=%s op=%s",
+		plan.ProjectID,
+		plan.ID,
+		opID,
+	)
+	if a.artifacts == nil {
+		return
+	}
+	auditDir := filepath.Join(filepath.Dir(a.artifacts.ProjectDir(plan.ProjectID)), "_audit")
+	if err := os.MkdirAll(auditDir, dirModePrivateRead); err != nil {
+		return
+	}
+	body, err := json.MarshalIndent(map[string]any{"op_id": opID, "plan": plan}, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(
+		filepath.Join(auditDir, fmt.Sprintf("%s.delete-plan.json", plan.ProjectID)),
+		body,
+		fileModePrivate,
+	)
+}
@@ -0,0 +1,169 @@
+//nolint:testpackage,exhaustruct // Delete plan tests drive enqueueOp against the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_DeleteRequiresCurrentPlan(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	projectID := "project-delete-plan"
+	spec := workerRuntimeSpec("delete-plan-app")
+	now := time.Now().UTC()
+	if err := fixture.store.PutProject(context.Background(), Project{
+		ID:        projectID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Spec:      spec,
+		Status:    ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now},
+	}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	artifacts := NewFSArtifacts(t.TempDir())
+	if _, err := artifacts.WriteFile(projectID, "repos/source/main.go", []byte("package main\n")); err != nil {
+		t.Fatalf("write source repo file: %v", err)
+	}
+	if _, err := artifacts.WriteFile(projectID, "build/image.txt", []byte("local/delete-plan:abc\n")); err != nil {
+		t.Fatalf("write image artifact: %v", err)
+	}
+
+	api := &API{nc: fixture.nc, store: fixture.store, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	projectURL := srv.URL + "/api/projects/" + projectID
+
+	if status := deleteProjectForTest(t, srv, projectURL); status != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 without plan_id, got %d", status)
+	}
+
+	stale := createDeletePlanForTest(t, srv, projectURL)
+	if len(stale.Repos) != 1 || stale.Repos[0] != "repos/source" || len(stale.Images) != 1 {
+		t.Fatalf("unexpected plan inventory: %#v", stale)
+	}
+	if _, err := artifacts.WriteFile(projectID, "build/extra.txt", []byte("new build\n")); err != nil {
+		t.Fatalf("write extra artifact: %v", err)
+	}
+	if status := deleteProjectForTest(t, srv, projectURL+"?plan_id="+stale.ID); status != http.StatusConflict {
+		t.Fatalf("expected 409 for stale plan, got %d", status)
+	}
+
+	plan := createDeletePlanForTest(t, srv, projectURL)
+	if status := deleteProjectForTest(t, srv, projectURL+"?plan_id="+stale.ID); status != http.StatusConflict {
+		t.Fatalf("expected 409 for superseded plan, got %d", status)
+	}
+	if status := deleteProjectForTest(t, srv, projectURL+"?plan_id="+plan.ID); status != http.StatusAccepted {
+		t.Fatalf("expected 202 for current plan, got %d", status)
+	}
+	if _, ok, err := fixture.store.getDeletePlan(context.Background(), projectID); err != nil || ok {
+		t.Fatalf("expected applied plan to be consumed, ok=%v err=%v", ok, err)
+	}
+	auditPath := filepath.Join(filepath.Dir(artifacts.ProjectDir(projectID)), "_audit", projectID+".delete-plan.json")
+	if _, err := os.Stat(auditPath); err != nil {
+		t.Fatalf("expected applied plan audit: %v", err)
+	}
+}
+
+func TestAPI_DeletePlanImpactNeedsAcknowledgement(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+
+	spec := workerRuntimeSpec("orders")
+	caller := workerRuntimeSpec("checkout")
+	caller.Environments["dev"].Vars["ORDERS_URL"] = "http://orders.orders-dev.svc.cluster.local/v1"
+	caller.Vars = map[string]string{"AUDIT_URL": "http://orders.orders-dev-two:80"} // another namespace
+	now := time.Now().UTC()
+	for id, projectSpec := range map[string]ProjectSpec{"project-orders": spec, "project-checkout": caller} {
+		if err := fixture.store.PutProject(ctx, Project{
+			ID: id, CreatedAt: now, UpdatedAt: now, Spec: projectSpec,
+			Status: ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now},
+		}); err != nil {
+			t.Fatalf("put project %s: %v", id, err)
+		}
+	}
+	release, err := fixture.store.PutRelease(ctx, ReleaseRecord{
+		ProjectID: "project-orders", Environment: "dev", OpID: "op-orders-deploy", OpKind: OpDeploy,
+		ToEnv: "dev", Image: "local/orders:abc", CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("put release: %v", err)
+	}
+	if _, err = fixture.store.putCapabilityBinding(ctx, CapabilityBinding{
+		ProjectID: "project-orders", Environment: "dev", Capability: "postgres",
+		Type: CapabilityBindingExternal, Env: map[string]string{"DATABASE_HOST": "db.example.internal"},
+	}); err != nil {
+		t.Fatalf("put binding: %v", err)
+	}
+
+	api := &API{nc: fixture.nc, store: fixture.store, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	projectURL := srv.URL + "/api/projects/project-orders"
+
+	plan := createDeletePlanForTest(t, srv, projectURL)
+	impact := plan.Impact
+	if !plan.RequiresAcknowledgement ||
+		strings.Join(impact.LiveReleases, ",") != "dev/"+release.ID ||
+		strings.Join(impact.Endpoints, ",") != "orders.orders-dev.svc.cluster.local" ||
+		strings.Join(impact.Credentials, ",") != "binding dev/postgres" {
+		t.Fatalf("unexpected impact: %#v", plan)
+	}
+	if len(impact.Dependents) != 1 || impact.Dependents[0] != (DeleteDependent{
+		ProjectID: "project-checkout", ProjectName: "checkout", Environment: "dev",
+		Reference: "ORDERS_URL", Endpoint: "orders.orders-dev",
+	}) {
+		t.Fatalf("expected only checkout's dev ORDERS_URL as a dependent, got %#v", impact.Dependents)
+	}
+
+	if status := deleteProjectForTest(t, srv, projectURL+"?plan_id="+plan.ID); status != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 without acknowledging impact, got %d", status)
+	}
+	target := projectURL + "?plan_id=" + plan.ID + "&acknowledge_impact=true"
+	if status := deleteProjectForTest(t, srv, target); status != http.StatusAccepted {
+		t.Fatalf("expected 202 once impact is acknowledged, got %d", status)
+	}
+}
+
+func createDeletePlanForTest(t *testing.T, srv *httptest.Server, projectURL string) DeletePlan {
+	t.Helper()
+	resp, err := srv.Client().Post(projectURL+"/delete-plan", "application/json", nil)
+	if err != nil {
+		t.Fatalf("create delete plan: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 creating delete plan, got %d", resp.StatusCode)
+	}
+	var plan DeletePlan
+	if err = json.NewDecoder(resp.Body).Decode(&plan); err != nil {
+		t.Fatalf("decode delete plan: %v", err)
+	}
+	if plan.ID == "" || plan.Fingerprint == "" || !plan.ExpiresAt.After(plan.CreatedAt) {
+		t.Fatalf("incomplete delete plan: %#v", plan)
+	}
+	return plan
+}
+
+func deleteProjectForTest(t *testing.T, srv *httptest.Server, target string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodDelete, target, nil)
+	if err != nil {
+		t.Fatalf("build delete request: %v", err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("delete project: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
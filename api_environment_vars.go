@@ -0,0 +1,283 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Environment vars patch: PATCH /api/projects/{id}/environments/{env}/vars
+// adds, updates, and removes an environment's own vars without the whole
+// spec. The patched spec is validated like an update and rolled out by a ci
+// op that skips the image build and re-renders the environment with the
+// image it already runs.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	envVarsPatchMaxOperations = 100
+	projectChangeVars         = "change vars in"
+
+	envVarPatchAdd    = "add"
+	envVarPatchUpdate = "update"
+	envVarPatchRemove = "remove"
+)
+
+type envVarsPatchRequest struct {
+	Operations []envVarPatchOperation `json:"operations"`
+}
+
+// envVarPatchOperation is one change. Expected, on update and remove, is
+// the value the caller last read; the patch is refused if the var holds
+// anything else by now.
+type envVarPatchOperation struct {
+	Op       string  `json:"op"` // add | update | remove
+	Name     string  `json:"name"`
+	Value    string  `json:"value,omitempty"`
+	Expected *string `json:"expected,omitempty"`
+}
+
+type envVarsPatchResponse struct {
+	Accepted bool `json:"accepted"`
+	// Unchanged is set, with status 200 and no op, when the patch leaves
+	// the environment's vars as they were.
+	Unchanged bool       `json:"unchanged,omitempty"`
+	Project   Project    `json:"project"`
+	Op        *Operation `json:"op,omitempty"`
+	// RenderEnvironment is the environment the ci op re-renders: the
+	// patched one once it has been delivered, dev before that.
+	RenderEnvironment string                             `json:"render_environment,omitempty"`
+	EffectiveConfig   environmentEffectiveConfigResponse `json:"effective_config"`
+}
+
+// envVarConflictError refuses a patch whose operation does not fit the
+// environment's current vars, such as adding a var that is already set or
+// updating one that changed since the caller read it.
+type envVarConflictError struct {
+	ProjectID   string
+	Environment string
+	Name        string
+	Reason      string
+}
+
+func (e envVarConflictError) Error() string {
+	return fmt.Sprintf("var %s in %s %s", e.Name, e.Environment, e.Reason)
+}
+
+func writeEnvVarConflict(w http.ResponseWriter, err error) bool {
+	var conflict envVarConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	writeErrorDetails(w, http.StatusConflict, errorCodeConflict, map[string]any{
+		"accepted":    false,
+		"reason":      conflict.Error(),
+		"project_id":  conflict.ProjectID,
+		"environment": conflict.Environment,
+		"var":         conflict.Name,
+		"next_step":   "read the environment's vars again and retry the patch against them",
+	})
+	return true
+}
+
+func (a *API) handleEnvironmentVars(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != http.MethodPatch {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	if projectID == "" || strings.TrimSpace(parts[2]) == "" {
+		writeAPIError(w, "bad project id or environment", http.StatusBadRequest)
+		return
+	}
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	var req envVarsPatchRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	project, rev, ok := a.getProjectRevisionOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	env, exists := resolveProjectEnvironmentName(project.Spec, parts[2])
+	if !exists {
+		writeAPIError(w, "environment not found", http.StatusNotFound)
+		return
+	}
+	if newCacheValidator("project").add(rev).ifMatchFails(r) {
+		writeProjectRevisionConflict(w, projectRevisionConflictError{
+			ProjectID: projectID,
+			Create:    false,
+			Expected:  0,
+			Current:   project,
+		})
+		return
+	}
+	if err = a.authorizeProjectChange(r.Context(), project, projectChangeVars); err != nil {
+		if writeProjectAccessDenied(w, err) {
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	spec, changed, err := patchEnvironmentVars(project, env, req.Operations)
+	if err != nil {
+		if writeEnvVarConflict(w, err) {
+			return
+		}
+		writeBadRequest(w, err)
+		return
+	}
+	if err = a.validateSpec(spec); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	resp := envVarsPatchResponse{
+		Accepted:          false,
+		Unchanged:         false,
+		Project:           project,
+		Op:                nil,
+		RenderEnvironment: "",
+		EffectiveConfig:   newEnvironmentEffectiveConfig(projectID, spec, env),
+	}
+	if !changed {
+		resp.Unchanged = true
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	opts := emptyOpRunOptions().withExecution(execution).withProjectRevision(project.Revision)
+	opts.specChange, resp.RenderEnvironment = a.planEnvVarsRender(project, spec, env)
+	opts.ci = &CIBuild{
+		Ref: "", Commit: "", Release: "", ReleaseError: "", Preview: "", Environment: resp.RenderEnvironment,
+	}
+	op, err := a.enqueueOp(r.Context(), OpCI, projectID, spec, opts)
+	if err != nil {
+		if writeAsyncOpError(w, err) {
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp.Accepted = true
+	resp.Op = &op
+	if resp.Project, err = a.store.GetProject(r.Context(), projectID); err == nil && resp.Project.Revision != 0 {
+		w.Header().Set("ETag", projectETag(projectID, resp.Project.Revision))
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// patchEnvironmentVars applies operations, in order, to env's own vars and
+// returns the patched spec and whether the vars changed. Removing a var
+// that is also in the shared block falls back to the shared value.
+func patchEnvironmentVars(
+	project Project,
+	env string,
+	operations []envVarPatchOperation,
+) (ProjectSpec, bool, error) {
+	spec := normalizeProjectSpec(project.Spec)
+	if len(operations) == 0 {
+		return spec, false, specFieldErrorf("operations", "operations must name at least one var")
+	}
+	if len(operations) > envVarsPatchMaxOperations {
+		return spec, false, specFieldErrorf("operations", "at most %d operations per patch", envVarsPatchMaxOperations)
+	}
+	cfg := spec.Environments[env]
+	vars := maps.Clone(cfg.Vars)
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	seen := make([]string, 0, len(operations))
+	for i, operation := range operations {
+		field := fmt.Sprintf("operations[%d]", i)
+		name := strings.TrimSpace(operation.Name)
+		if len(name) > 128 || !envVarNameRe.MatchString(name) {
+			return spec, false, specFieldErrorf(field+".name", "invalid environment variable name %q", operation.Name)
+		}
+		if slices.Contains(seen, name) {
+			return spec, false, specFieldErrorf(field+".name", "var %q is patched more than once", name)
+		}
+		seen = append(seen, name)
+		op := strings.TrimSpace(operation.Op)
+		if op == envVarPatchRemove && operation.Value != "" {
+			return spec, false, specFieldErrorf(field+".value", "remove takes no value")
+		}
+		current, set := vars[name]
+		conflict := envVarConflictError{ProjectID: project.ID, Environment: env, Name: name, Reason: ""}
+		switch op {
+		case envVarPatchAdd:
+			if operation.Expected != nil {
+				return spec, false, specFieldErrorf(field+".expected", "add takes no expected value")
+			}
+			if set {
+				conflict.Reason = "is already set; use update"
+				return spec, false, conflict
+			}
+			vars[name] = operation.Value
+		case envVarPatchUpdate, envVarPatchRemove:
+			if !set {
+				conflict.Reason = "is not set in the environment"
+				if _, shared := spec.Vars[name]; shared && op == envVarPatchUpdate {
+					conflict.Reason += "; use add to override the shared value"
+				}
+				return spec, false, conflict
+			}
+			if operation.Expected != nil && *operation.Expected != current {
+				conflict.Reason = "changed since it was read"
+				return spec, false, conflict
+			}
+			if op == envVarPatchRemove {
+				delete(vars, name)
+				continue
+			}
+			vars[name] = operation.Value
+		default:
+			return spec, false, specFieldErrorf(field+".op", "op must be add, update, or remove")
+		}
+	}
+	if err := validateEnvironmentVars(env, "environments."+env+".vars", vars); err != nil {
+		return spec, false, err
+	}
+	changed := !maps.Equal(vars, cfg.Vars)
+	cfg.Vars = vars
+	envs := maps.Clone(spec.Environments)
+	envs[env] = cfg
+	spec.Environments = envs
+	return normalizeProjectSpec(spec), changed, nil
+}
+
+// planEnvVarsRender plans the ci op that rolls a vars patch out: the image
+// build is skipped when a build exists, and an environment that has been
+// delivered is re-rendered with the image it runs. Until then dev is
+// re-rendered, which writes the patched overlay to the manifests repo for
+// the environment's next delivery.
+func (a *API) planEnvVarsRender(project Project, spec ProjectSpec, env string) (*SpecChange, string) {
+	change := planSpecChange(a.artifacts, project, spec)
+	if change == nil {
+		return nil, defaultDeployEnvironment
+	}
+	// ci ops start after repoBootstrap, so neither it nor registrar is part
+	// of the plan.
+	earlier := func(stage string) bool { return stage == stageRegistrar || stage == stageRepoBootstrap }
+	change.Stages = slices.DeleteFunc(change.Stages, earlier)
+	change.Skipped = slices.DeleteFunc(change.Skipped, earlier)
+	if env == defaultDeployEnvironment || !slices.Contains(change.Skipped, stageImageBuilder) {
+		return change, defaultDeployEnvironment
+	}
+	running, err := readRenderedEnvImageTag(a.artifacts, project.ID, env)
+	if err != nil || running == "" {
+		return change, defaultDeployEnvironment
+	}
+	change.Image = running
+	return change, env
+}
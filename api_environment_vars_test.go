@@ -0,0 +1,182 @@
+//nolint:testpackage,exhaustruct // Vars patch tests check the queued ci op and render it without the worker loop.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_EnvironmentVarsPatchValidatesAndQueuesARender(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-vars-patch"
+	spec := workerRuntimeSpec("vars-patch")
+	spec.Vars = map[string]string{"REGION": "eu"}
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{"LOG_LEVEL": "info"}}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-vars-patch-create", OpCreate, spec)
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	if _, err := artifacts.WriteFile(projectID, imageBuildTagPath, []byte("local/vars-patch:build2\n")); err != nil {
+		t.Fatalf("write build image: %v", err)
+	}
+	deployment := "kind: Deployment\nspec:\n  template:\n    spec:\n      containers:\n" +
+		"      - name: app\n        image: local/vars-patch:build1\n"
+	if _, err := artifacts.WriteFile(projectID, "deploy/prod/"+manifestFileDeployment, []byte(deployment)); err != nil {
+		t.Fatalf("write prod deployment: %v", err)
+	}
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	patch := func(env, body, ifMatch string) (int, map[string]any) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPatch,
+			srv.URL+"/api/projects/"+projectID+"/environments/"+env+"/vars", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("build request: %v", err)
+		}
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("patch vars: %v", err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	for _, tc := range []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{body: `{"operations":[]}`, status: http.StatusBadRequest, want: "at least one var"},
+		{body: `{"operations":[{"op":"rename","name":"LOG_LEVEL"}]}`, status: http.StatusBadRequest, want: "op must be"},
+		{
+			body:   `{"operations":[{"op":"add","name":"log-level","value":"x"}]}`,
+			status: http.StatusBadRequest, want: "invalid",
+		},
+		{
+			body:   `{"operations":[{"op":"add","name":"A","value":"1"},{"op":"remove","name":"A"}]}`,
+			status: http.StatusBadRequest, want: "more than once",
+		},
+		{
+			body:   `{"operations":[{"op":"add","name":"LOG_LEVEL","value":"debug"}]}`,
+			status: http.StatusConflict, want: "use update",
+		},
+		{
+			body:   `{"operations":[{"op":"update","name":"REGION","value":"us"}]}`,
+			status: http.StatusConflict, want: "use add",
+		},
+		{
+			body:   `{"operations":[{"op":"update","name":"LOG_LEVEL","value":"debug","expected":"warn"}]}`,
+			status: http.StatusConflict, want: "changed since it was read",
+		},
+	} {
+		status, out := patch("prod", tc.body, "")
+		if status != tc.status || !strings.Contains(out["message"].(string), tc.want) {
+			t.Errorf("%s: expected %d %q, got %d %v", tc.body, tc.status, tc.want, status, out)
+		}
+	}
+	addA := `{"operations":[{"op":"add","name":"A","value":"1"}]}`
+	if status, _ := patch("qa", addA, ""); status != http.StatusNotFound {
+		t.Fatalf("expected an unknown environment to be 404, got %d", status)
+	}
+	if status, out := patch("prod", addA, `"stale"`); status != http.StatusConflict ||
+		out["code"] != errorCodeRevisionConflict {
+		t.Fatalf("expected a stale If-Match to be refused, got %d %v", status, out)
+	}
+
+	status, out := patch("prod", `{"operations":[
+		{"op":"update","name":"LOG_LEVEL","value":"debug","expected":"info"},
+		{"op":"add","name":"FEATURE_X","value":"on"}
+	]}`, "")
+	if status != http.StatusAccepted || out["render_environment"] != "prod" {
+		t.Fatalf("expected the patch to queue a prod render, got %d %v", status, out)
+	}
+	opID, _ := out["op"].(map[string]any)["id"].(string)
+	op, err := fixture.store.GetOp(ctx, opID)
+	if err != nil || op.Kind != OpCI || op.CI == nil || op.CI.Environment != "prod" || op.SpecChange == nil ||
+		!slices.Contains(op.SpecChange.Skipped, stageImageBuilder) || op.SpecChange.Image != "local/vars-patch:build1" {
+		t.Fatalf("expected a ci op re-rendering prod with its running image, got %+v %+v (%v)", op.CI, op.SpecChange, err)
+	}
+	project, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil || project.Spec.Environments["prod"].Vars["LOG_LEVEL"] != "debug" ||
+		project.Spec.Environments["prod"].Vars["FEATURE_X"] != "on" {
+		t.Fatalf("expected the patched prod vars on the project, got %+v (%v)", project.Spec.Environments["prod"], err)
+	}
+	effective, _ := out["effective_config"].(map[string]any)["vars"].(map[string]any)
+	if effective["REGION"] != "eu" || effective["LOG_LEVEL"] != "debug" {
+		t.Fatalf("expected the effective prod vars in the response, got %v", out["effective_config"])
+	}
+	status, out = patch("prod", `{"operations":[{"op":"remove","name":"FEATURE_X"}]}`, "")
+	if status != http.StatusConflict || out["code"] != errorCodeOpConflict {
+		t.Fatalf("expected a patch during the render to wait for it, got %d %v", status, out)
+	}
+	_ = finalizeOp(ctx, fixture.store, opID, projectID, OpCI, opStatusDone, "")
+
+	status, out = patch("prod", `{"operations":[{"op":"update","name":"LOG_LEVEL","value":"debug"}]}`, "")
+	if status != http.StatusOK || out["unchanged"] != true {
+		t.Fatalf("expected a patch that changes nothing to queue nothing, got %d %v", status, out)
+	}
+	status, out = patch("dev", `{"operations":[{"op":"add","name":"FEATURE_X","value":"on"}]}`, "")
+	if status != http.StatusAccepted || out["render_environment"] != "dev" {
+		t.Fatalf("expected a dev patch to re-render dev, got %d %v", status, out)
+	}
+	op, _ = fixture.store.GetOp(ctx, out["op"].(map[string]any)["id"].(string))
+	if op.SpecChange == nil || op.SpecChange.Image != "local/vars-patch:build2" {
+		t.Fatalf("expected dev to be re-rendered with the last build, got %+v", op.SpecChange)
+	}
+}
+
+func TestManifestRenderer_VarsPatchRendersItsEnvironment(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-vars-render"
+	spec := workerRuntimeSpec("orders")
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{"LOG_LEVEL": "debug"}}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-vars-render-create", OpCreate, spec)
+	createMsg := newProjectOpMsg(
+		"op-vars-render-create", OpCreate, projectID, spec, emptyOpRunOptions(), time.Now().UTC(),
+	)
+	if _, err := runRepoBootstrapCreateOrUpdate(ctx, artifacts, createMsg, spec); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+
+	opts := emptyOpRunOptions()
+	opts.ci = &CIBuild{Environment: "prod"}
+	opts.specChange = &SpecChange{
+		Classes: []SpecChangeClass{SpecChangeVars},
+		Stages:  []string{stageManifestRenderer},
+		Skipped: []string{stageImageBuilder},
+		Image:   "local/orders:running",
+	}
+	msg := newProjectOpMsg("op-vars-render", OpCI, projectID, spec, opts, time.Now().UTC())
+	if _, skipped := specChangeSkipOutcome(msg, stageImageBuilder, "image build"); !skipped {
+		t.Fatal("expected the vars patch's ci op to skip the image build")
+	}
+	if _, err := manifestRendererWorkerAction(ctx, fixture.store, artifacts, msg); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	rendered, err := artifacts.ReadFile(projectID, "deploy/prod/rendered.yaml")
+	if err != nil || !strings.Contains(string(rendered), "image: local/orders:running") ||
+		!strings.Contains(string(rendered), "name: LOG_LEVEL") {
+		t.Fatalf("expected prod re-rendered with its running image and vars, got:\n%s (%v)", rendered, err)
+	}
+	if _, err = artifacts.ReadFile(projectID, "deploy/dev/rendered.yaml"); err == nil {
+		t.Fatal("expected dev to be left alone by a prod vars patch")
+	}
+}
@@ -0,0 +1,99 @@
+package platform
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	effectiveConfigPathParts = 4
+
+	effectiveVarSourceShared      = "shared"
+	effectiveVarSourceEnvironment = "environment"
+)
+
+// environmentEffectiveConfigResponse shows what an environment actually runs
+// with after the shared vars block is overlaid with its overrides.
+type environmentEffectiveConfigResponse struct {
+	ProjectID   string            `json:"project_id"`
+	Environment string            `json:"environment"`
+	Vars        map[string]string `json:"vars"`
+	Sources     map[string]string `json:"sources"`    // var name -> shared | environment
+	Overridden  []string          `json:"overridden"` // shared var names this environment replaces
+}
+
+func (a *API) handleProjectEnvironments(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) < effectiveConfigPathParts || parts[1] != "environments" {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	switch {
+	case len(parts) == effectiveConfigPathParts && parts[3] == "effective-config":
+		a.handleEnvironmentEffectiveConfig(w, r, parts)
+	case len(parts) == effectiveConfigPathParts && parts[3] == "vars":
+		a.handleEnvironmentVars(w, r, parts)
+	case len(parts) == effectiveConfigPathParts && parts[3] == "freeze":
+		a.handleEnvironmentFreeze(w, r, parts)
+	case parts[3] == "bindings" && len(parts) <= bindingPathPartsMax:
+		a.handleEnvironmentBindings(w, r, parts)
+	case parts[3] == "canary" && len(parts) <= canaryActionPathParts:
+		a.handleEnvironmentCanary(w, r, parts)
+	case len(parts) == blueGreenPathParts && parts[3] == "blue-green":
+		a.handleEnvironmentBlueGreen(w, r, parts)
+	default:
+		writeAPIError(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (a *API) handleEnvironmentEffectiveConfig(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	envName := strings.TrimSpace(parts[2])
+	if projectID == "" || envName == "" {
+		writeAPIError(w, "bad project id or environment", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	if _, exists := spec.Environments[envName]; !exists {
+		writeAPIError(w, "environment not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, newEnvironmentEffectiveConfig(projectID, spec, envName))
+}
+
+func newEnvironmentEffectiveConfig(
+	projectID string,
+	spec ProjectSpec,
+	envName string,
+) environmentEffectiveConfigResponse {
+	overrides := spec.Environments[envName].Vars
+	out := environmentEffectiveConfigResponse{
+		ProjectID:   projectID,
+		Environment: envName,
+		Vars:        effectiveEnvironmentVars(spec, envName),
+		Sources:     map[string]string{},
+		Overridden:  []string{},
+	}
+	for key := range out.Vars {
+		if _, ok := overrides[key]; !ok {
+			out.Sources[key] = effectiveVarSourceShared
+			continue
+		}
+		out.Sources[key] = effectiveVarSourceEnvironment
+		if _, shared := spec.Vars[key]; shared {
+			out.Overridden = append(out.Overridden, key)
+		}
+	}
+	slices.Sort(out.Overridden)
+	return out
+}
@@ -0,0 +1,75 @@
+//nolint:testpackage,exhaustruct // Effective-config tests seed projects through the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAPI_EnvironmentEffectiveConfigMergesSharedVars(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	projectID := "project-effective-config"
+	now := time.Now().UTC()
+	spec := normalizeProjectSpec(ProjectSpec{
+		Name:    "effective-app",
+		Runtime: "go_1.26",
+		Vars:    map[string]string{"LOG_LEVEL": "info", "REGION": "us-east-1"},
+		Environments: map[string]EnvConfig{
+			"dev":  {Vars: map[string]string{"LOG_LEVEL": "debug", "REGION": "us-east-1"}},
+			"prod": {Vars: map[string]string{"REPLICAS": "3"}},
+		},
+	})
+	if _, redundant := spec.Environments["dev"].Vars["REGION"]; redundant {
+		t.Fatalf("expected normalization to drop inherited duplicate, got %v", spec.Environments["dev"].Vars)
+	}
+	if err := fixture.store.PutProject(context.Background(), Project{
+		ID:        projectID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Spec:      spec,
+		Status:    ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now},
+	}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+
+	srv := httptest.NewServer((&API{store: fixture.store}).routes())
+	defer srv.Close()
+
+	var body environmentEffectiveConfigResponse
+	resp, err := srv.Client().Get(srv.URL + "/api/projects/" + projectID + "/environments/dev/effective-config")
+	if err != nil {
+		t.Fatalf("get effective config: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode effective config: %v", err)
+	}
+	if body.Vars["LOG_LEVEL"] != "debug" || body.Vars["REGION"] != "us-east-1" || len(body.Vars) != 2 {
+		t.Fatalf("unexpected effective vars: %v", body.Vars)
+	}
+	if body.Sources["LOG_LEVEL"] != effectiveVarSourceEnvironment || body.Sources["REGION"] != effectiveVarSourceShared {
+		t.Fatalf("unexpected sources: %v", body.Sources)
+	}
+	if !slices.Equal(body.Overridden, []string{"LOG_LEVEL"}) {
+		t.Fatalf("unexpected overridden list: %v", body.Overridden)
+	}
+
+	missing, err := srv.Client().Get(srv.URL + "/api/projects/" + projectID + "/environments/qa/effective-config")
+	if err != nil {
+		t.Fatalf("get missing environment: %v", err)
+	}
+	missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown environment, got %d", missing.StatusCode)
+	}
+}
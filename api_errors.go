@@ -0,0 +1,133 @@
+package platform
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Error codes. Most follow the status; the rest name the conflict or check
+// that refused the request so clients can branch without parsing messages.
+const (
+	errorCodeBadRequest       = "bad_request"
+	errorCodeValidation       = "validation_failed"
+	errorCodeUnauthorized     = "unauthorized"
+	errorCodeForbidden        = "forbidden"
+	errorCodeNotFound         = "not_found"
+	errorCodeMethodNotAllowed = "method_not_allowed"
+	errorCodeConflict         = "conflict"
+	errorCodePrecondition     = "precondition_failed"
+	errorCodeTooLarge         = "payload_too_large"
+	errorCodeUnsupportedMedia = "unsupported_media_type"
+	errorCodeTooManyRequests  = "too_many_requests"
+	errorCodeInternal         = "internal"
+	errorCodeUnavailable      = "unavailable"
+
+	errorCodeOpConflict       = "op_conflict"
+	errorCodeRevisionConflict = "revision_conflict"
+	errorCodeHoldConflict     = "hold_conflict"
+	errorCodeAccessDenied     = "access_denied"
+	errorCodeDeletePlan       = "delete_plan_invalid"
+	errorCodeWorkersNotReady  = "workers_not_ready"
+	errorCodeEnqueueFailed    = "enqueue_failed"
+	errorCodeVulnBudget       = "vulnerability_budget_exceeded"
+	errorCodeFrozen           = "environment_frozen"
+	errorCodeApproval         = "approval_required"
+	errorCodeCancelConflict   = "cancel_conflict"
+	errorCodeReadOnly         = "read_only"
+	errorCodeRollbackBlocked  = "rollback_blocked"
+	errorCodeOrgQuota         = "org_quota_exceeded"
+	errorCodeQuotaExceeded    = "quota_exceeded"
+	errorCodeRateLimited      = "rate_limited"
+	errorCodeHasDependents    = "project_has_dependents"
+)
+
+// apiErrorResponse is the body of every API error. Field is the JSON path
+// of the spec field a validation error is about; OpID is set when the error
+// concerns an op.
+type apiErrorResponse struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Field   string         `json:"field,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+	OpID    string         `json:"op_id,omitempty"`
+}
+
+// writeAPIError replaces http.Error: same arguments, but the body is the
+// JSON error envelope with the code that goes with status.
+func writeAPIError(w http.ResponseWriter, message string, status int) {
+	writeAPIErrorResponse(w, status, apiErrorResponse{
+		Code:    errorCodeForStatus(status),
+		Message: strings.TrimSpace(message),
+		Field:   "",
+		Details: nil,
+		OpID:    "",
+	})
+}
+
+func writeAPIErrorResponse(w http.ResponseWriter, status int, body apiErrorResponse) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	writeJSON(w, status, body)
+}
+
+// writeBadRequest answers 400 for a body or spec that failed decoding or
+// validation, naming the field when the error knows it.
+func writeBadRequest(w http.ResponseWriter, err error) {
+	body := apiErrorResponse{
+		Code:    errorCodeBadRequest,
+		Message: err.Error(),
+		Field:   "",
+		Details: nil,
+		OpID:    "",
+	}
+	var fieldErr specFieldError
+	if errors.As(err, &fieldErr) {
+		body.Code = errorCodeValidation
+		body.Field = fieldErr.Field
+	}
+	writeAPIErrorResponse(w, http.StatusBadRequest, body)
+}
+
+// writeErrorDetails writes an error whose body predates the envelope. Its
+// fields stay at the top level, where existing clients read them, next to
+// code and message (taken from its reason).
+func writeErrorDetails(w http.ResponseWriter, status int, code string, body map[string]any) {
+	body["code"] = code
+	if _, ok := body["message"]; !ok {
+		body["message"] = body["reason"]
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	writeJSON(w, status, body)
+}
+
+func errorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return errorCodeBadRequest
+	case http.StatusUnauthorized:
+		return errorCodeUnauthorized
+	case http.StatusForbidden:
+		return errorCodeForbidden
+	case http.StatusNotFound:
+		return errorCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return errorCodeMethodNotAllowed
+	case http.StatusConflict:
+		return errorCodeConflict
+	case http.StatusPreconditionFailed:
+		return errorCodePrecondition
+	case http.StatusRequestEntityTooLarge:
+		return errorCodeTooLarge
+	case http.StatusUnsupportedMediaType:
+		return errorCodeUnsupportedMedia
+	case http.StatusTooManyRequests:
+		return errorCodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return errorCodeUnavailable
+	default:
+		if status >= http.StatusInternalServerError {
+			return errorCodeInternal
+		}
+		return errorCodeBadRequest
+	}
+}
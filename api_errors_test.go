@@ -0,0 +1,58 @@
+//nolint:testpackage,exhaustruct // Error envelope tests drive the internal router against the internal store.
+package platform
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPI_ErrorsUseJSONEnvelope(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	call := func(method, path, contentType, body string) (int, apiErrorResponse) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(t.Context(), method, srv.URL+path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+			t.Fatalf("%s %s: expected a JSON error, got content type %q", method, path, got)
+		}
+		var out apiErrorResponse
+		if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("%s %s: decode error body: %v", method, path, err)
+		}
+		return resp.StatusCode, out
+	}
+
+	status, body := call(http.MethodPost, "/api/projects", "application/json",
+		`{"name":"envelope-app","runtime":"Go 1.26"}`)
+	if status != http.StatusBadRequest || body.Code != errorCodeValidation || body.Field != "runtime" {
+		t.Fatalf("expected a field-level validation error, got %d %#v", status, body)
+	}
+	status, body = call(http.MethodPost, "/api/projects", "application/yaml",
+		"name: envelope-app\nruntime: go_1.26\nvars:\n  ENABLED: true\n")
+	if status != http.StatusBadRequest || body.Field != "vars.ENABLED" || body.Message == "" {
+		t.Fatalf("expected the yaml type error to name its field, got %d %#v", status, body)
+	}
+	if status, body = call(http.MethodGet, "/api/ops/op-missing", "", ""); status != http.StatusNotFound ||
+		body.Code != errorCodeNotFound {
+		t.Fatalf("expected a not_found envelope, got %d %#v", status, body)
+	}
+	if status, body = call(http.MethodGet, "/api/projects/validate", "", ""); status != http.StatusMethodNotAllowed ||
+		body.Code != errorCodeMethodNotAllowed {
+		t.Fatalf("expected a method_not_allowed envelope, got %d %#v", status, body)
+	}
+}
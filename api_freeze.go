@@ -0,0 +1,609 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Environment freezes: deploys, promotions, releases, and ci builds into a
+// frozen environment are refused at enqueue time. An environment is frozen
+// by a window in its spec, a PAAS_FREEZE_FILE window naming it (or naming no
+// environments), or a manual freeze placed through the API. An operator can
+// deliver anyway with freeze_override, which is recorded on the op.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	freezeSourceProject = "project"
+	freezeSourceGlobal  = "global"
+	freezeSourceManual  = "manual"
+
+	transitionBlockerFrozen = "environment_frozen"
+
+	maxFreezeWindows      = 50
+	maxFreezeReasonLength = 256
+
+	freezeAuditActionFrozen = "frozen"
+	freezeAuditActionLifted = "lifted"
+
+	// projectChangeFreeze is the action checked when a manual freeze is
+	// placed.
+	projectChangeFreeze = "freeze environments of"
+)
+
+// globalFreezeWindow is a PAAS_FREEZE_FILE window. Without Environments it
+// freezes every environment of every project.
+type globalFreezeWindow struct {
+	Name         string    `json:"name"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Reason       string    `json:"reason,omitempty"`
+	Environments []string  `json:"environments,omitempty"`
+}
+
+type freezeConfig struct {
+	Windows []globalFreezeWindow `json:"windows"`
+}
+
+// environmentFreezeStatus is what freezes an environment at one moment.
+// Windows are the scheduled windows that have not ended, in force or not.
+// UnfreezeAt is zero while a manual freeze is in force.
+type environmentFreezeStatus struct {
+	environment string
+	freezes     []EnvironmentFreeze
+	windows     []EnvironmentFreeze
+	unfreezeAt  time.Time
+}
+
+// environmentFrozenError refuses a delivery into a frozen environment.
+type environmentFrozenError struct {
+	ProjectID     string
+	RequestedKind OperationKind
+	Status        environmentFreezeStatus
+}
+
+type manualFreezeRequest struct {
+	Reason string `json:"reason"`
+	By     string `json:"by"`
+}
+
+type environmentFreezeResponse struct {
+	ProjectID   string              `json:"project_id"`
+	Environment string              `json:"environment"`
+	Frozen      bool                `json:"frozen"`
+	Freezes     []EnvironmentFreeze `json:"freezes"`
+	UnfreezeAt  time.Time           `json:"unfreeze_at,omitzero"`
+	Windows     []EnvironmentFreeze `json:"windows"`
+}
+
+func (e environmentFrozenError) Error() string {
+	return fmt.Sprintf("environment %s is frozen %s (%s)",
+		e.Status.environment, e.Status.until(), describeFreezes(e.Status.freezes))
+}
+
+func (s environmentFreezeStatus) frozen() bool {
+	return len(s.freezes) > 0
+}
+
+func (s environmentFreezeStatus) until() string {
+	if s.unfreezeAt.IsZero() {
+		return "until the manual freeze is lifted"
+	}
+	return "until " + s.unfreezeAt.Format(time.RFC3339)
+}
+
+func freezeConfigFromEnv() (freezeConfig, error) {
+	path := strings.TrimSpace(os.Getenv(freezeFileEnv))
+	if path == "" {
+		return freezeConfig{Windows: nil}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return freezeConfig{}, fmt.Errorf("%s: %w", freezeFileEnv, err)
+	}
+	config, err := parseFreezeConfig(raw)
+	if err != nil {
+		return freezeConfig{}, fmt.Errorf("%s %s: %w", freezeFileEnv, path, err)
+	}
+	return config, nil
+}
+
+func parseFreezeConfig(raw []byte) (freezeConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var config freezeConfig
+	if err := decoder.Decode(&config); err != nil {
+		return freezeConfig{}, err
+	}
+	if len(config.Windows) > maxFreezeWindows {
+		return freezeConfig{}, fmt.Errorf("at most %d windows", maxFreezeWindows)
+	}
+	for i := range config.Windows {
+		window := &config.Windows[i]
+		window.Name = strings.TrimSpace(window.Name)
+		if !projectNameRe.MatchString(window.Name) {
+			return freezeConfig{}, fmt.Errorf("windows[%d]: name must match %s", i, projectNameRe.String())
+		}
+		span := normalizeFreezeWindows([]FreezeWindow{{Start: window.Start, End: window.End, Reason: window.Reason}})
+		if err := validateFreezeWindows(fmt.Sprintf("windows[%d]", i), span); err != nil {
+			return freezeConfig{}, err
+		}
+		window.Start, window.End, window.Reason = span[0].Start, span[0].End, span[0].Reason
+		for _, env := range window.Environments {
+			if !envNameRe.MatchString(env) {
+				return freezeConfig{}, fmt.Errorf("windows[%d]: invalid environment name %q", i, env)
+			}
+		}
+	}
+	return config, nil
+}
+
+func normalizeFreezeWindows(windows []FreezeWindow) []FreezeWindow {
+	if len(windows) == 0 {
+		return nil
+	}
+	out := make([]FreezeWindow, 0, len(windows))
+	for _, window := range windows {
+		out = append(out, FreezeWindow{
+			Start:  window.Start.UTC(),
+			End:    window.End.UTC(),
+			Reason: strings.TrimSpace(window.Reason),
+		})
+	}
+	return out
+}
+
+// validateFreezeWindows checks a list of windows; field is its JSON path.
+func validateFreezeWindows(field string, windows []FreezeWindow) error {
+	if len(windows) > maxFreezeWindows {
+		return specFieldErrorf(field, "%s allows at most %d windows", field, maxFreezeWindows)
+	}
+	for i, window := range windows {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		if window.Start.IsZero() || window.End.IsZero() {
+			return specFieldErrorf(path, "%s needs a start and an end time", path)
+		}
+		if !window.End.After(window.Start) {
+			return specFieldErrorf(path+".end", "%s.end must be after its start", path)
+		}
+		if len(window.Reason) > maxFreezeReasonLength {
+			return specFieldErrorf(path+".reason", "%s.reason exceeds %d characters", path, maxFreezeReasonLength)
+		}
+	}
+	return nil
+}
+
+// freezeTargetEnvironment is the environment an op of kind delivers into,
+// or "" for ops a freeze does not stop. Rollbacks go back to a release the
+// environment already ran, and updates are how freeze windows are edited.
+func freezeTargetEnvironment(kind OperationKind, opts opRunOptions) string {
+	switch kind {
+	case OpDeploy:
+		return opts.deployEnv
+	case OpPromote, OpRelease:
+		return opts.toEnv
+	case OpCI:
+		if opts.ci != nil && opts.ci.Environment != "" {
+			return opts.ci.Environment
+		}
+		return defaultDeployEnvironment
+	case OpCreate, OpUpdate, OpDelete, OpRollback, OpCleanup, OpVarRollout, OpPromoteFanout,
+		OpRuntimeUpgrade, OpWebhookRefresh:
+		return ""
+	default:
+		return ""
+	}
+}
+
+// environmentFreezeStatus collects the freezes on env at now: windows from
+// spec and the global file, and the project's manual freeze.
+func (a *API) environmentFreezeStatus(
+	ctx context.Context,
+	projectID string,
+	spec ProjectSpec,
+	env string,
+	now time.Time,
+) (environmentFreezeStatus, error) {
+	status := environmentFreezeStatus{
+		environment: env,
+		freezes:     []EnvironmentFreeze{},
+		windows:     scheduledFreezeWindows(spec, a.freezes, env, now),
+		unfreezeAt:  time.Time{},
+	}
+	if env == "" {
+		return status, nil
+	}
+	manual := false
+	if a.store != nil {
+		freezes, err := a.store.getProjectFreezes(ctx, projectID)
+		if err != nil {
+			return status, err
+		}
+		if freeze, ok := freezes.Environments[env]; ok {
+			status.freezes = append(status.freezes, freeze)
+			manual = true
+		}
+	}
+	for _, window := range status.windows {
+		if !window.Since.After(now) {
+			status.freezes = append(status.freezes, window)
+		}
+	}
+	if status.frozen() && !manual {
+		status.unfreezeAt = freezeWindowsEnd(status.windows, now)
+	}
+	return status, nil
+}
+
+// scheduledFreezeWindows lists env's windows that end after now, earliest
+// first.
+func scheduledFreezeWindows(spec ProjectSpec, config freezeConfig, env string, now time.Time) []EnvironmentFreeze {
+	out := []EnvironmentFreeze{}
+	if env == "" {
+		return out
+	}
+	for _, window := range spec.Environments[env].Freeze {
+		if window.End.After(now) {
+			out = append(out, EnvironmentFreeze{
+				Source: freezeSourceProject,
+				Name:   "",
+				Reason: window.Reason,
+				By:     "",
+				Since:  window.Start,
+				Until:  window.End,
+			})
+		}
+	}
+	for _, window := range config.Windows {
+		if window.End.After(now) && (len(window.Environments) == 0 || slices.Contains(window.Environments, env)) {
+			out = append(out, EnvironmentFreeze{
+				Source: freezeSourceGlobal,
+				Name:   window.Name,
+				Reason: window.Reason,
+				By:     "",
+				Since:  window.Start,
+				Until:  window.End,
+			})
+		}
+	}
+	slices.SortStableFunc(out, func(x, y EnvironmentFreeze) int { return x.Since.Compare(y.Since) })
+	return out
+}
+
+// freezeWindowsEnd returns when windows stop covering at. A window that
+// overlaps or abuts the one in force carries the freeze on.
+func freezeWindowsEnd(windows []EnvironmentFreeze, at time.Time) time.Time {
+	for {
+		next := at
+		for _, window := range windows {
+			if !window.Since.After(at) && window.Until.After(next) {
+				next = window.Until
+			}
+		}
+		if next.Equal(at) {
+			return at
+		}
+		at = next
+	}
+}
+
+func describeFreezes(freezes []EnvironmentFreeze) string {
+	parts := make([]string, 0, len(freezes))
+	for _, freeze := range freezes {
+		part := freeze.Source + " freeze"
+		if freeze.Name != "" {
+			part += " " + freeze.Name
+		}
+		if freeze.By != "" {
+			part += " by " + freeze.By
+		}
+		if freeze.Reason != "" {
+			part += ": " + freeze.Reason
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// environmentFreezeGate refuses an op into a frozen environment unless opts
+// carry an operator's override. It returns the override to record on the
+// op, which has no Freezes when nothing was frozen. A dry run delivers
+// nothing, so no freeze stops it.
+func (a *API) environmentFreezeGate(
+	ctx context.Context,
+	projectID string,
+	kind OperationKind,
+	spec ProjectSpec,
+	opts opRunOptions,
+) (FreezeOverride, error) {
+	var override FreezeOverride
+	env := freezeTargetEnvironment(kind, opts)
+	if env == "" || opts.execution.DryRun {
+		return override, nil
+	}
+	status, err := a.environmentFreezeStatus(ctx, projectID, spec, env, time.Now().UTC())
+	if err != nil {
+		return override, fmt.Errorf("read environment freezes: %w", err)
+	}
+	if !status.frozen() {
+		return override, nil
+	}
+	if opts.freezeOverride == nil {
+		return override, environmentFrozenError{ProjectID: projectID, RequestedKind: kind, Status: status}
+	}
+	override = *opts.freezeOverride
+	override.Environment = env
+	override.Freezes = status.freezes
+	override.At = time.Now().UTC()
+	return override, nil
+}
+
+// recorded is the override as the op keeps it: nil when it overrode nothing.
+func (o FreezeOverride) recorded() *FreezeOverride {
+	if len(o.Freezes) == 0 {
+		return nil
+	}
+	return &o
+}
+
+// withFreezeOverride returns a copy of o carrying req, which is only honored
+// from an admin, or the operator token while auth is off, with a
+// justification.
+func (o opRunOptions) withFreezeOverride(r *http.Request, req *FreezeOverrideRequest) (opRunOptions, error) {
+	if req == nil {
+		return o, nil
+	}
+	principal, authenticated := requestPrincipal(r.Context())
+	if !operatorRequest(r) && (!authenticated || !principal.Role.allows(apiRoleAdmin)) {
+		return o, requestError(
+			http.StatusForbidden,
+			"freeze overrides require an admin token or the operator token (Authorization: Bearer $"+
+				operatorTokenEnv+")",
+		)
+	}
+	justification := strings.TrimSpace(req.Justification)
+	if justification == "" {
+		return o, requestError(http.StatusBadRequest, "freeze_override.justification is required")
+	}
+	o.freezeOverride = &FreezeOverride{
+		Environment:   "",
+		Justification: justification,
+		By:            requestActor(r.Context(), req.By),
+		Freezes:       nil,
+		At:            time.Time{},
+	}
+	return o, nil
+}
+
+func writeEnvironmentFrozen(w http.ResponseWriter, err error) bool {
+	var frozenErr environmentFrozenError
+	if !errors.As(err, &frozenErr) {
+		return false
+	}
+	var unfreezeAt any
+	if !frozenErr.Status.unfreezeAt.IsZero() {
+		unfreezeAt = frozenErr.Status.unfreezeAt
+	}
+	writeErrorDetails(w, http.StatusConflict, errorCodeFrozen, map[string]any{
+		"accepted":       false,
+		"reason":         frozenErr.Error(),
+		"project_id":     frozenErr.ProjectID,
+		"requested_kind": frozenErr.RequestedKind,
+		"environment":    frozenErr.Status.environment,
+		"freezes":        frozenErr.Status.freezes,
+		"unfreeze_at":    unfreezeAt,
+		"next_step":      "retry after unfreeze_at, or with freeze_override and an admin or the operator token",
+	})
+	return true
+}
+
+// auditFreezeOverride logs an op that overrode a freeze and appends it to
+// the project's override audit log.
+func (a *API) auditFreezeOverride(op Operation) {
+	override := op.FreezeOverride
+	if override == nil {
+		return
+	}
+	appLoggerForProcess().Source("api").Warnf(
+		"environment freeze overridden op=%s project=%s env=%s by=%q freezes=%q justification=%q",
+		op.ID,
+		op.ProjectID,
+		override.Environment,
+		override.By,
+		describeFreezes(override.Freezes),
+		override.Justification,
+	)
+	a.appendProjectAuditLine(op.ProjectID, "overrides", fmt.Sprintf(
+		"%s freeze override op=%s env=%s by=%q freezes=%q justification=%q",
+		override.At.Format(time.RFC3339),
+		op.ID,
+		override.Environment,
+		override.By,
+		describeFreezes(override.Freezes),
+		override.Justification,
+	))
+}
+
+// addFreezePreviewBlocker reads the freezes on a transition's target and
+// blocks the preview while one is in force.
+func (a *API) addFreezePreviewBlocker(
+	ctx context.Context,
+	projectID string,
+	spec ProjectSpec,
+	toEnv string,
+	blockersByCode map[string]TransitionPreviewBlocker,
+	blockerOrder *[]string,
+) (environmentFreezeStatus, error) {
+	status, err := a.environmentFreezeStatus(ctx, projectID, spec, toEnv, time.Now().UTC())
+	if err != nil {
+		return status, fmt.Errorf("failed to read environment freezes: %w", err)
+	}
+	if !status.frozen() {
+		return status, nil
+	}
+	next := fmt.Sprintf("Retry after %s, or have an operator confirm with freeze_override and a justification.",
+		status.unfreezeAt.Format(time.RFC3339))
+	if status.unfreezeAt.IsZero() {
+		next = fmt.Sprintf("Lift the manual freeze via DELETE /api/projects/%s/environments/%s/freeze, "+
+			"or have an operator confirm with freeze_override and a justification.", projectID, toEnv)
+	}
+	addTransitionPreviewBlocker(blockersByCode, blockerOrder, TransitionPreviewBlocker{
+		Code:       transitionBlockerFrozen,
+		Message:    fmt.Sprintf("Environment %q is frozen %s.", toEnv, status.until()),
+		Why:        describeFreezes(status.freezes) + ".",
+		NextAction: next,
+	})
+	return status, nil
+}
+
+func freezePreviewGate(status environmentFreezeStatus) TransitionPreviewGate {
+	gate := TransitionPreviewGate{
+		Code:   transitionBlockerFrozen,
+		Title:  "Target environment is not frozen",
+		Status: previewGatePassed,
+		Detail: "",
+	}
+	switch {
+	case status.environment == "":
+		gate.Detail = "No target environment to check."
+	case status.frozen():
+		gate.Status = previewGateBlocked
+		gate.Detail = fmt.Sprintf("Frozen %s: %s.", status.until(), describeFreezes(status.freezes))
+	case len(status.windows) > 0:
+		gate.Detail = fmt.Sprintf("Not frozen; the next freeze starts at %s.",
+			status.windows[0].Since.Format(time.RFC3339))
+	default:
+		gate.Detail = "No freeze is in force or scheduled."
+	}
+	return gate
+}
+
+// handleEnvironmentFreeze is /api/projects/{id}/environments/{env}/freeze:
+// GET shows the freezes on env, PUT places a manual freeze, and DELETE
+// lifts it.
+func (a *API) handleEnvironmentFreeze(w http.ResponseWriter, r *http.Request, parts []string) {
+	if a.store == nil {
+		writeAPIError(w, "freeze data unavailable", http.StatusInternalServerError)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	env := strings.TrimSpace(parts[2])
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	if _, exists := spec.Environments[env]; !exists {
+		writeAPIError(w, "environment not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ok = true
+	case http.MethodPut:
+		ok = a.handleManualFreezePlace(w, r, project, env)
+	case http.MethodDelete:
+		ok = a.handleManualFreezeLift(w, r, projectID, env)
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	status, err := a.environmentFreezeStatus(r.Context(), projectID, spec, env, time.Now().UTC())
+	if err != nil {
+		writeAPIError(w, "failed to read freezes", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, environmentFreezeResponse{
+		ProjectID:   projectID,
+		Environment: env,
+		Frozen:      status.frozen(),
+		Freezes:     status.freezes,
+		UnfreezeAt:  status.unfreezeAt,
+		Windows:     status.windows,
+	})
+}
+
+// handleManualFreezePlace freezes env. With auth on it needs the project's
+// ownership and records the principal as the one who froze it.
+func (a *API) handleManualFreezePlace(w http.ResponseWriter, r *http.Request, project Project, env string) bool {
+	if !a.authorizeProjectChangeOrWriteError(w, r, project, projectChangeFreeze) {
+		return false
+	}
+	projectID := project.ID
+	var req manualFreezeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return false
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" || len(reason) > maxFreezeReasonLength {
+		writeAPIError(w, fmt.Sprintf("reason required, at most %d characters", maxFreezeReasonLength),
+			http.StatusBadRequest)
+		return false
+	}
+	freeze, err := a.store.putManualFreeze(r.Context(), projectID, env, EnvironmentFreeze{
+		Source: freezeSourceManual,
+		Name:   "",
+		Reason: reason,
+		By:     requestActor(r.Context(), req.By),
+		Since:  time.Time{},
+		Until:  time.Time{},
+	})
+	if err != nil {
+		writeAPIError(w, "failed to freeze environment", http.StatusInternalServerError)
+		return false
+	}
+	a.auditManualFreeze(freezeAuditActionFrozen, projectID, env, freeze, freeze.By)
+	return true
+}
+
+// handleManualFreezeLift lifts env's manual freeze. It undoes a gate only an
+// admin may override, so apiRouteRole has it need admin.
+func (a *API) handleManualFreezeLift(w http.ResponseWriter, r *http.Request, projectID, env string) bool {
+	freeze, lifted, err := a.store.liftManualFreeze(r.Context(), projectID, env)
+	if err != nil {
+		writeAPIError(w, "failed to lift freeze", http.StatusInternalServerError)
+		return false
+	}
+	if !lifted {
+		writeAPIError(w, "environment has no manual freeze", http.StatusNotFound)
+		return false
+	}
+	a.auditManualFreeze(freezeAuditActionLifted, projectID, env, freeze,
+		requestActor(r.Context(), r.URL.Query().Get("lifted_by")))
+	return true
+}
+
+// auditManualFreeze appends a manual freeze or its lifting to the project's
+// freeze audit log.
+func (a *API) auditManualFreeze(action, projectID, env string, freeze EnvironmentFreeze, actor string) {
+	appLoggerForProcess().Source("api").Infof(
+		"environment freeze %s project=%s env=%s actor=%q reason=%q",
+		action,
+		projectID,
+		env,
+		actor,
+		freeze.Reason,
+	)
+	a.appendProjectAuditLine(projectID, "freezes", fmt.Sprintf(
+		"%s freeze %s env=%s actor=%q reason=%q",
+		time.Now().UTC().Format(time.RFC3339),
+		action,
+		env,
+		actor,
+		freeze.Reason,
+	))
+}
@@ -0,0 +1,279 @@
+//nolint:testpackage,exhaustruct // Freeze tests reuse the internal promotion preview fixture.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvironmentFreezeWindows(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	spec := normalizeProjectSpec(ProjectSpec{
+		Name:    "frozen",
+		Runtime: "go_1.26",
+		Environments: map[string]EnvConfig{
+			"dev":  {Freeze: []FreezeWindow{{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: " launch "}}},
+			"prod": {},
+		},
+	})
+	config, err := parseFreezeConfig([]byte(`{"windows":[` +
+		`{"name":"release-week","start":"` + now.Add(time.Hour).Format(time.RFC3339) +
+		`","end":"` + now.Add(3*time.Hour).Format(time.RFC3339) + `","environments":["dev"]},` +
+		`{"name":"next-month","start":"` + now.Add(48*time.Hour).Format(time.RFC3339) +
+		`","end":"` + now.Add(72*time.Hour).Format(time.RFC3339) + `"}]}`))
+	if err != nil {
+		t.Fatalf("parse freeze file: %v", err)
+	}
+	api := &API{freezes: config}
+	status, err := api.environmentFreezeStatus(context.Background(), "project-frozen", spec, "dev", now)
+	if err != nil || !status.frozen() || status.freezes[0].Reason != "launch" {
+		t.Fatalf("expected dev frozen by its spec window, got %+v (%v)", status, err)
+	}
+	if !status.unfreezeAt.Equal(now.Add(3 * time.Hour)) {
+		t.Fatalf("expected the abutting global window to carry the freeze on, got %s", status.unfreezeAt)
+	}
+	status, _ = api.environmentFreezeStatus(context.Background(), "project-frozen", spec, "prod", now)
+	if status.frozen() || len(status.windows) != 1 || freezePreviewGate(status).Status != previewGatePassed {
+		t.Fatalf("expected prod to only have the next-month window scheduled, got %+v", status)
+	}
+
+	deploy := deployOpRunOptions("dev")
+	if _, err = api.environmentFreezeGate(context.Background(), "project-frozen", OpDeploy, spec, deploy); err == nil {
+		t.Fatal("expected a deploy into frozen dev to be refused")
+	}
+	dryRun := deploy.withExecution(OpExecution{DryRun: true})
+	if override, gateErr := api.environmentFreezeGate(
+		context.Background(), "project-frozen", OpDeploy, spec, dryRun,
+	); gateErr != nil || override.recorded() != nil {
+		t.Fatalf("expected a dry run to pass the freeze, got %+v (%v)", override, gateErr)
+	}
+	rollback := rollbackOpRunOptions("dev", "release-1", RollbackScopeCodeOnly, false)
+	_, err = api.environmentFreezeGate(context.Background(), "project-frozen", OpRollback, spec, rollback)
+	if err != nil {
+		t.Fatalf("expected a rollback to pass the freeze, got %v", err)
+	}
+
+	changed := normalizeProjectSpec(spec)
+	changed.Environments["prod"] = EnvConfig{Freeze: []FreezeWindow{{Start: now, End: now.Add(time.Hour)}}}
+	if classes := classifySpecChange(spec, changed); !slices.Equal(classes, []SpecChangeClass{SpecChangeFreeze}) {
+		t.Fatalf("expected a freeze change, got %v", classes)
+	}
+	changed.Environments["prod"] = EnvConfig{Freeze: []FreezeWindow{{Start: now, End: now}}}
+	var fieldErr specFieldError
+	if !asSpecFieldError(validateProjectSpec(changed), &fieldErr) ||
+		fieldErr.Field != "environments.prod.freeze[0].end" {
+		t.Fatalf("expected an empty window refused on its end, got %v", validateProjectSpec(changed))
+	}
+	for _, raw := range []string{
+		`{"windows":[{"name":"x","start":"2026-01-02T00:00:00Z","end":"2026-01-01T00:00:00Z"}]}`,
+		`{"windows":[{"name":"ok","start":"2026-01-01T00:00:00Z","end":"2026-01-02T00:00:00Z","environments":["P"]}]}`,
+		`{"windows":[{"name":"ok","start":"2026-01-01T00:00:00Z","end":"2026-01-02T00:00:00Z","envs":["prod"]}]}`,
+	} {
+		if _, err = parseFreezeConfig([]byte(raw)); err == nil {
+			t.Errorf("expected %s to be refused", raw)
+		}
+	}
+}
+
+func TestAPI_FrozenEnvironmentRefusesDeliveriesUntilOverridden(t *testing.T) {
+	t.Setenv(operatorTokenEnv, "operator-secret")
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	image := "example.local/freeze:v1"
+	writePreviewDeploymentImage(t, fixture.artifacts, fixture.projectID, "dev", image)
+	if _, err := fixture.api.store.PutRelease(ctx, ReleaseRecord{
+		ProjectID: fixture.projectID, Environment: "dev", OpID: "op-freeze-source", OpKind: OpDeploy,
+		DeliveryStage: DeliveryStageDeploy, ToEnv: "dev", Image: image, CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("put source release: %v", err)
+	}
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	call := func(method, path, token string, body any) (int, map[string]any) {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	freezePath := "/api/projects/" + fixture.projectID + "/environments/staging/freeze"
+
+	project, err := fixture.api.store.GetProject(ctx, fixture.projectID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	project.Spec.Environments["dev"] = EnvConfig{
+		Freeze: []FreezeWindow{{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "launch"}},
+	}
+	if err = fixture.api.store.PutProject(ctx, project); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	code, out := call(http.MethodPost, "/api/events/deployment", "", map[string]any{"project_id": fixture.projectID})
+	if code != http.StatusConflict || out["code"] != errorCodeFrozen ||
+		out["unfreeze_at"] != now.Add(time.Hour).Format(time.RFC3339) {
+		t.Fatalf("expected 409 frozen until the window ends, got %d %v", code, out)
+	}
+
+	code, out = call(http.MethodPut, freezePath, "", map[string]any{"reason": "incident 42", "by": "oncall"})
+	if code != http.StatusOK || out["frozen"] != true || out["unfreeze_at"] != nil {
+		t.Fatalf("expected a manual freeze with no unfreeze time, got %d %v", code, out)
+	}
+	preview := requestPromotionPreviewForTest(t, fixture, map[string]any{
+		"project_id": fixture.projectID, "from_env": "dev", "to_env": "staging",
+	})
+	assertPromotionPreviewHasBlocker(t, preview, transitionBlockerFrozen)
+
+	promotion := map[string]any{"project_id": fixture.projectID, "from_env": "dev", "to_env": "staging"}
+	if code, out = call(http.MethodPost, "/api/events/promotion", "", promotion); code != http.StatusConflict ||
+		!strings.Contains(out["message"].(string), "incident 42") {
+		t.Fatalf("expected 409 while staging is frozen, got %d %v", code, out)
+	}
+	promotion["freeze_override"] = map[string]any{"justification": "hotfix for incident 42", "by": "oncall"}
+	if code, _ = call(http.MethodPost, "/api/events/promotion", "wrong-token", promotion); code !=
+		http.StatusForbidden {
+		t.Fatalf("expected 403 without the operator token, got %d", code)
+	}
+	if code, out = call(http.MethodPost, "/api/events/promotion", "operator-secret", promotion); code !=
+		http.StatusAccepted {
+		t.Fatalf("expected 202 with an operator override, got %d %v", code, out)
+	}
+	opID, _ := out["op"].(map[string]any)["id"].(string)
+	stored, err := fixture.api.store.GetOp(ctx, opID)
+	if err != nil || stored.FreezeOverride == nil || stored.FreezeOverride.Environment != "staging" ||
+		len(stored.FreezeOverride.Freezes) != 1 || stored.FreezeOverride.Freezes[0].Source != freezeSourceManual {
+		t.Fatalf("expected the freeze override recorded on the op, got %+v (%v)", stored.FreezeOverride, err)
+	}
+
+	if code, out = call(http.MethodDelete, freezePath+"?lifted_by=oncall", "", nil); code != http.StatusOK ||
+		out["frozen"] != false {
+		t.Fatalf("expected the manual freeze lifted, got %d %v", code, out)
+	}
+	if code, _ = call(http.MethodDelete, freezePath, "", nil); code != http.StatusNotFound {
+		t.Fatalf("expected 404 with no manual freeze left, got %d", code)
+	}
+	auditDir := filepath.Join(filepath.Dir(fixture.artifacts.ProjectDir(fixture.projectID)), "_audit")
+	overrides, _ := os.ReadFile(filepath.Join(auditDir, fixture.projectID+".overrides.log"))
+	freezes, _ := os.ReadFile(filepath.Join(auditDir, fixture.projectID+".freezes.log"))
+	if !strings.Contains(string(overrides), "freeze override op="+opID) ||
+		!strings.Contains(string(freezes), "freeze frozen env=staging") ||
+		!strings.Contains(string(freezes), "freeze lifted env=staging") {
+		t.Fatalf("expected the override and freeze audit lines, got %q and %q", overrides, freezes)
+	}
+}
+
+func TestAPI_ManualFreezesFollowRolesAndOwnershipWhileAuthIsOn(t *testing.T) {
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	t.Setenv(apiAuthEnv, "true")
+	t.Setenv(operatorTokenEnv, "operator-secret")
+
+	image := "example.local/freeze:v1"
+	writePreviewDeploymentImage(t, fixture.artifacts, fixture.projectID, "dev", image)
+	if _, err := fixture.api.store.PutRelease(ctx, ReleaseRecord{
+		ProjectID: fixture.projectID, Environment: "dev", OpID: "op-freeze-source", OpKind: OpDeploy,
+		DeliveryStage: DeliveryStageDeploy, ToEnv: "dev", Image: image, CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("put source release: %v", err)
+	}
+	mint := func(name string, role apiRole) string {
+		t.Helper()
+		_, value, err := fixture.api.store.createAPIToken(ctx, name, role, nil, "")
+		if err != nil {
+			t.Fatalf("create %s token: %v", name, err)
+		}
+		return value
+	}
+	owner := mint("sam@example.com", apiRoleDeveloper)
+	outsider := mint("dev-laptop", apiRoleDeveloper)
+	admin := mint("release-captain", apiRoleAdmin)
+	if _, err := fixture.api.store.setProjectOwnership(ctx, fixture.projectID, ProjectOwnership{
+		Owners: []ProjectOwner{{Name: "sam@example.com"}},
+	}); err != nil {
+		t.Fatalf("set ownership: %v", err)
+	}
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	call := func(method, path, token string, body any) (int, map[string]any) {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	freezePath := "/api/projects/" + fixture.projectID + "/environments/staging/freeze"
+	freezeBody := map[string]any{"reason": "incident 42", "by": "someone-else"}
+
+	if code, out := call(http.MethodPut, freezePath, outsider, freezeBody); code != http.StatusForbidden {
+		t.Fatalf("expected a non-owner refused a freeze, got %d %v", code, out)
+	}
+	code, out := call(http.MethodPut, freezePath, owner, freezeBody)
+	freezes, _ := out["freezes"].([]any)
+	if code != http.StatusOK || len(freezes) != 1 || freezes[0].(map[string]any)["by"] != "sam@example.com" {
+		t.Fatalf("expected the owner's freeze recorded under the token's name, got %d %v", code, out)
+	}
+
+	promotion := map[string]any{
+		"project_id": fixture.projectID, "from_env": "dev", "to_env": "staging",
+		"freeze_override": map[string]any{"justification": "hotfix for incident 42", "by": "someone-else"},
+	}
+	if code, out = call(http.MethodPost, "/api/events/promotion", owner, promotion); code != http.StatusForbidden {
+		t.Fatalf("expected a developer refused a freeze override, got %d %v", code, out)
+	}
+	if code, out = call(http.MethodPost, "/api/events/promotion", admin, promotion); code != http.StatusAccepted {
+		t.Fatalf("expected an admin token to override the freeze, got %d %v", code, out)
+	}
+	opID, _ := out["op"].(map[string]any)["id"].(string)
+	if stored, err := fixture.api.store.GetOp(ctx, opID); err != nil || stored.FreezeOverride == nil ||
+		stored.FreezeOverride.By != "release-captain" {
+		t.Fatalf("expected the override recorded under the admin's name, got %+v (%v)", stored.FreezeOverride, err)
+	}
+
+	if code, _ = call(http.MethodDelete, freezePath+"?lifted_by=someone-else", owner, nil); code !=
+		http.StatusForbidden {
+		t.Fatalf("expected a developer refused lifting a freeze, got %d", code)
+	}
+	if code, out = call(http.MethodDelete, freezePath+"?lifted_by=someone-else", admin, nil); code != http.StatusOK ||
+		out["frozen"] != false {
+		t.Fatalf("expected an admin to lift the freeze, got %d %v", code, out)
+	}
+	auditDir := filepath.Join(filepath.Dir(fixture.artifacts.ProjectDir(fixture.projectID)), "_audit")
+	audit, _ := os.ReadFile(filepath.Join(auditDir, fixture.projectID+".freezes.log"))
+	if !strings.Contains(string(audit), `freeze frozen env=staging actor="sam@example.com"`) ||
+		!strings.Contains(string(audit), `freeze lifted env=staging actor="release-captain"`) ||
+		strings.Contains(string(audit), "someone-else") {
+		t.Fatalf("expected the freeze audit to name the tokens, got %q", audit)
+	}
+}
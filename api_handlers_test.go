@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 
@@ -48,6 +49,16 @@ func (m *memArtifacts) WriteFile(projectID, relPath string, data []byte) (string
 	return relPath, nil
 }
 
+func (m *memArtifacts) AppendFile(projectID, relPath string, data []byte) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[projectID]; !ok {
+		m.files[projectID] = map[string][]byte{}
+	}
+	m.files[projectID][relPath] = append(m.files[projectID][relPath], data...)
+	return relPath, nil
+}
+
 func (m *memArtifacts) ListFiles(projectID string) ([]string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -63,6 +74,25 @@ func (m *memArtifacts) ListFiles(projectID string) ([]string, error) {
 	return out, nil
 }
 
+func (m *memArtifacts) StatFiles(projectID string) ([]platform.ArtifactFileInfo, error) {
+	files, _ := m.ListFiles(projectID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]platform.ArtifactFileInfo, 0, len(files))
+	for _, path := range files {
+		out = append(out, platform.ArtifactFileInfo{Path: path, Size: int64(len(m.files[projectID][path]))})
+	}
+	return out, nil
+}
+
+func (m *memArtifacts) StatFile(projectID, relPath string) (platform.ArtifactFileInfo, error) {
+	data, err := m.ReadFile(projectID, relPath)
+	if err != nil {
+		return platform.ArtifactFileInfo{}, err
+	}
+	return platform.ArtifactFileInfo{Path: relPath, Size: int64(len(data))}, nil
+}
+
 func (m *memArtifacts) ReadFile(projectID, relPath string) ([]byte, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -77,6 +107,20 @@ func (m *memArtifacts) ReadFile(projectID, relPath string) ([]byte, error) {
 	return append([]byte(nil), data...), nil
 }
 
+func (m *memArtifacts) RemoveFiles(projectID, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removed := []string{}
+	for path := range m.files[projectID] {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			delete(m.files[projectID], path)
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
+
 func (m *memArtifacts) RemoveProject(projectID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
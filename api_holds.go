@@ -0,0 +1,282 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	holdAuditActionPlaced = "placed"
+	holdAuditActionLifted = "lifted"
+
+	// projectChangeHold is the action checked when a hold is placed.
+	projectChangeHold = "place holds on"
+)
+
+type placeHoldRequest struct {
+	ReleaseID string `json:"release_id"`
+	Reason    string `json:"reason"`
+	PlacedBy  string `json:"placed_by"`
+}
+
+type projectHoldsResponse struct {
+	ProjectID string           `json:"project_id"`
+	Project   *ComplianceHold  `json:"project,omitempty"`
+	Releases  []ComplianceHold `json:"releases"`
+}
+
+type releaseDetailResponse struct {
+	ReleaseRecord
+
+	Hold *ComplianceHold `json:"hold,omitempty"`
+}
+
+// projectHoldError rejects a destructive operation while any compliance hold
+// is active on the project.
+type projectHoldError struct {
+	ProjectID     string
+	RequestedKind OperationKind
+	Holds         projectHolds
+}
+
+func (e projectHoldError) Error() string {
+	if e.Holds.Project != nil {
+		return fmt.Sprintf("project is under compliance hold (%s); lift the hold before deleting", e.Holds.Project.Reason)
+	}
+	return fmt.Sprintf(
+		"project has %d release(s) under compliance hold; lift the holds before deleting",
+		len(e.Holds.Releases),
+	)
+}
+
+func writeProjectHoldConflict(w http.ResponseWriter, err error) bool {
+	var holdErr projectHoldError
+	if !errors.As(err, &holdErr) {
+		return false
+	}
+	writeErrorDetails(w, http.StatusConflict, errorCodeHoldConflict, map[string]any{
+		"accepted":       false,
+		"reason":         holdErr.Error(),
+		"project_id":     holdErr.ProjectID,
+		"requested_kind": holdErr.RequestedKind,
+		"holds":          newProjectHoldsResponse(holdErr.ProjectID, holdErr.Holds),
+		"next_step":      fmt.Sprintf("lift holds via DELETE /api/projects/%s/holds, then retry", holdErr.ProjectID),
+	})
+	return true
+}
+
+func (a *API) projectHoldConflict(
+	ctx context.Context,
+	projectID string,
+	kind OperationKind,
+	opts opRunOptions,
+) error {
+	if a.store == nil || !holdGuardsOperation(kind, opts) {
+		return nil
+	}
+	holds, err := a.store.getProjectHolds(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("read compliance holds: %w", err)
+	}
+	if !holds.active() {
+		return nil
+	}
+	return projectHoldError{
+		ProjectID:     projectID,
+		RequestedKind: kind,
+		Holds:         holds,
+	}
+}
+
+// holdGuardsOperation reports whether kind can destroy evidence a hold
+// preserves. Cleanups only qualify when they reach beyond build outputs.
+func holdGuardsOperation(kind OperationKind, opts opRunOptions) bool {
+	switch kind {
+	case OpDelete:
+		return true
+	case OpCleanup:
+		return artifactCleanupTouchesReleaseEvidence(opts.artifactPrefix)
+	case OpCreate, OpUpdate, OpCI, OpDeploy, OpPromote, OpRelease, OpRollback, OpVarRollout, OpPromoteFanout,
+		OpRuntimeUpgrade, OpWebhookRefresh:
+		return false
+	default:
+		return false
+	}
+}
+
+func (a *API) handleProjectHolds(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "hold data unavailable", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != projectRelPathPartsMin || parts[1] != "holds" {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	if projectID == "" {
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		holds, err := a.store.getProjectHolds(r.Context(), projectID)
+		if err != nil {
+			writeAPIError(w, "failed to read holds", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newProjectHoldsResponse(projectID, holds))
+	case http.MethodPost:
+		a.handleProjectHoldPlace(w, r, project)
+	case http.MethodDelete:
+		a.handleProjectHoldLift(w, r, projectID)
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleProjectHoldPlace places a hold. With auth on it needs the project's
+// ownership and records the principal as the one who placed it.
+func (a *API) handleProjectHoldPlace(w http.ResponseWriter, r *http.Request, project Project) {
+	if !a.authorizeProjectChangeOrWriteError(w, r, project, projectChangeHold) {
+		return
+	}
+	projectID := project.ID
+	var req placeHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		writeAPIError(w, "reason required", http.StatusBadRequest)
+		return
+	}
+	releaseID := strings.TrimSpace(req.ReleaseID)
+	if releaseID != "" {
+		release, err := a.store.GetRelease(r.Context(), releaseID)
+		if err != nil || strings.TrimSpace(release.ProjectID) != projectID {
+			writeAPIError(w, "release not found", http.StatusNotFound)
+			return
+		}
+	}
+	hold, err := a.store.PlaceHold(r.Context(), ComplianceHold{
+		ProjectID: projectID,
+		ReleaseID: releaseID,
+		Reason:    req.Reason,
+		PlacedBy:  requestActor(r.Context(), req.PlacedBy),
+		PlacedAt:  time.Time{},
+	})
+	if err != nil {
+		writeAPIError(w, "failed to place hold", http.StatusInternalServerError)
+		return
+	}
+	a.auditHoldTransition(holdAuditActionPlaced, hold, hold.PlacedBy)
+	writeJSON(w, http.StatusCreated, hold)
+}
+
+// handleProjectHoldLift lifts a hold. Lifting one defeats it, so apiRouteRole
+// has it need admin.
+func (a *API) handleProjectHoldLift(w http.ResponseWriter, r *http.Request, projectID string) {
+	releaseID := strings.TrimSpace(r.URL.Query().Get("release_id"))
+	hold, ok, err := a.store.LiftHold(r.Context(), projectID, releaseID)
+	if err != nil {
+		writeAPIError(w, "failed to lift hold", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		writeAPIError(w, "hold not found", http.StatusNotFound)
+		return
+	}
+	a.auditHoldTransition(holdAuditActionLifted, hold, requestActor(r.Context(), r.URL.Query().Get("lifted_by")))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"lifted": true,
+		"hold":   hold,
+	})
+}
+
+// auditHoldTransition appends to the project's hold log under _audit, which
+// lives beside the project directories and so survives project deletion.
+func (a *API) auditHoldTransition(action string, hold ComplianceHold, actor string) {
+	scope := "project"
+	if hold.ReleaseID != "" {
+		scope = "release=" + hold.ReleaseID
+	}
+	appLoggerForProcess().Source("api").Infof(
+		"compliance hold %s project=%s %s actor=%q reason=%q",
+		action,
+		hold.ProjectID,
+		scope,
+		actor,
+		hold.Reason,
+	)
+	a.appendProjectAuditLine(hold.ProjectID, "holds", fmt.Sprintf(
+		"%s hold %s %s actor=%q reason=%q",
+		time.Now().UTC().Format(time.RFC3339),
+		action,
+		scope,
+		actor,
+		hold.Reason,
+	))
+}
+
+// appendProjectAuditLine appends line to the project's <kind> audit log, kept
+// beside the project directories under _audit so it outlives artifact purges.
+func (a *API) appendProjectAuditLine(projectID, kind, line string) {
+	if a.artifacts == nil {
+		return
+	}
+	path := a.projectAuditLogPath(projectID, kind)
+	if err := os.MkdirAll(filepath.Dir(path), dirModePrivateRead); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileModePrivate)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = fmt.Fprintln(f, line)
+}
+
+// projectAuditLogPath is the file appendProjectAuditLine writes kind lines to.
+func (a *API) projectAuditLogPath(projectID, kind string) string {
+	auditDir := filepath.Join(filepath.Dir(a.artifacts.ProjectDir(projectID)), "_audit")
+	return filepath.Join(auditDir, fmt.Sprintf("%s.%s.log", projectID, kind))
+}
+
+func (a *API) releaseDetailWithHold(ctx context.Context, release ReleaseRecord) (releaseDetailResponse, error) {
+	out := releaseDetailResponse{ReleaseRecord: release, Hold: nil}
+	holds, err := a.store.getProjectHolds(ctx, release.ProjectID)
+	if err != nil {
+		return releaseDetailResponse{}, err
+	}
+	if hold, ok := holds.releaseHold(release.ID); ok {
+		out.Hold = &hold
+	}
+	return out, nil
+}
+
+func newProjectHoldsResponse(projectID string, holds projectHolds) projectHoldsResponse {
+	releases := make([]ComplianceHold, 0, len(holds.Releases))
+	for _, id := range sortedKeys(holds.Releases) {
+		releases = append(releases, holds.Releases[id])
+	}
+	return projectHoldsResponse{
+		ProjectID: projectID,
+		Project:   holds.Project,
+		Releases:  releases,
+	}
+}
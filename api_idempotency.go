@@ -0,0 +1,247 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Idempotency keys: a POST to an op-starting endpoint may carry an
+// Idempotency-Key header. The first request with a key runs; its answer is
+// kept for idempotencyKeyTTL, and a retry with the same key and body gets
+// that answer again, original op included, instead of queueing another op.
+// Keys are scoped to the endpoint and the calling token.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	idempotencyKeyHeader        = "Idempotency-Key"
+	idempotencyReplayedHeader   = "Idempotent-Replayed"
+	idempotencyKeyMaxLength     = 255
+	idempotencyResponseMaxBytes = 512 << 10
+
+	errorCodeIdempotencyInFlight = "idempotency_key_in_flight"
+	errorCodeIdempotencyMismatch = "idempotency_key_mismatch"
+)
+
+// idempotencyRecorder passes a response through while keeping a copy of it.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+
+	status int
+	body   bytes.Buffer
+}
+
+func (r *idempotencyRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *idempotencyRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// withIdempotency wraps an op-starting handler. Requests without the header
+// pass straight through.
+func (a *API) withIdempotency(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+		if key == "" || r.Method != http.MethodPost || a.store == nil {
+			next(w, r)
+			return
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			writeAPIError(w, "Idempotency-Key is longer than 255 characters", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeRequestBodyReadError(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		principal, _ := requestPrincipal(r.Context())
+		storeKey := idempotencyStoreKey(principal.TokenID, r.URL.Path, key)
+		requestHash := idempotencyRequestHash(r.URL.RawQuery, body)
+		record, revision, claimed, err := a.store.claimIdempotencyKey(r.Context(), storeKey, requestHash)
+		if err != nil {
+			writeAPIError(w, "failed to read idempotency key", http.StatusInternalServerError)
+			return
+		}
+		if !claimed {
+			writeIdempotentReplay(w, record, requestHash)
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: w, status: 0, body: bytes.Buffer{}}
+		// Deferred so a handler that panics still frees the key.
+		defer a.finishIdempotentRequest(context.WithoutCancel(r.Context()), storeKey, revision, record, recorder)
+		next(recorder, r)
+	}
+}
+
+func writeRequestBodyReadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeAPIErrorResponse(w, http.StatusRequestEntityTooLarge, apiErrorResponse{
+			Code:    errorCodeTooLarge,
+			Message: "request body too large (limit " + strconv.FormatInt(tooLarge.Limit, 10) + " bytes)",
+			Field:   "",
+			Details: map[string]any{"limit_bytes": tooLarge.Limit},
+			OpID:    "",
+		})
+		return
+	}
+	writeAPIError(w, "failed to read request body", http.StatusBadRequest)
+}
+
+func idempotencyRequestHash(query string, body []byte) string {
+	sum := sha256.Sum256(append([]byte(query+"\n"), body...))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeIdempotentReplay answers a request whose key was already claimed.
+func writeIdempotentReplay(w http.ResponseWriter, record idempotencyRecord, requestHash string) {
+	switch {
+	case record.RequestHash != requestHash:
+		writeAPIErrorResponse(w, http.StatusUnprocessableEntity, apiErrorResponse{
+			Code:    errorCodeIdempotencyMismatch,
+			Message: "Idempotency-Key was already used for a different request",
+			Field:   "",
+			Details: nil,
+			OpID:    record.OpID,
+		})
+	case record.Status == 0:
+		writeAPIErrorResponse(w, http.StatusConflict, apiErrorResponse{
+			Code:    errorCodeIdempotencyInFlight,
+			Message: "a request with this Idempotency-Key is still running; retry shortly",
+			Field:   "",
+			Details: nil,
+			OpID:    "",
+		})
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(idempotencyReplayedHeader, "true")
+		w.WriteHeader(record.Status)
+		_, _ = w.Write(record.Body)
+	}
+}
+
+// finishIdempotentRequest keeps a successful answer for replay. Anything
+// else frees the key, so a retry runs the request again.
+func (a *API) finishIdempotentRequest(
+	ctx context.Context,
+	storeKey string,
+	revision uint64,
+	record idempotencyRecord,
+	recorder *idempotencyRecorder,
+) {
+	apiLog := appLoggerForProcess().Source("api")
+	succeeded := recorder.status >= http.StatusOK && recorder.status < http.StatusMultipleChoices
+	if !succeeded || recorder.body.Len() > idempotencyResponseMaxBytes {
+		if err := a.store.releaseIdempotencyKey(ctx, storeKey, revision); err != nil {
+			apiLog.Warnf("release idempotency key: %v", err)
+		}
+		return
+	}
+	var carrier struct {
+		Op *struct {
+			ID string `json:"id"`
+		} `json:"op"`
+	}
+	if json.Unmarshal(recorder.body.Bytes(), &carrier) == nil && carrier.Op != nil {
+		record.OpID = carrier.Op.ID
+	}
+	record.Status = recorder.status
+	record.Body = bytes.Clone(recorder.body.Bytes())
+	if _, err := a.store.completeIdempotencyKey(ctx, storeKey, revision, record); err != nil {
+		apiLog.Warnf("store idempotent response op=%s: %v", record.OpID, err)
+	}
+}
+
+// sourceCommitClaim is a replica's hold on building one source commit.
+type sourceCommitClaim struct {
+	key      string
+	commit   string
+	revision uint64
+	claimed  bool
+}
+
+// claimSourceCommit dedupes source webhooks on (project, commit) across
+// replicas through the idempotency bucket. It returns either a claim to
+// queue the commit's CI op or the op an earlier delivery queued; neither
+// while another replica is queueing it. A commit whose op failed or was
+// cancelled may be built again. Tag pushes and pushes without a commit are
+// not deduped here and always get a claim.
+func (a *API) claimSourceCommit(
+	ctx context.Context,
+	projectID string,
+	push ciPush,
+	commit string,
+) (sourceCommitClaim, *Operation, error) {
+	if push.tag || commit == "" {
+		return sourceCommitClaim{key: "", commit: commit, revision: 0, claimed: true}, nil, nil
+	}
+	key := idempotencyStoreKey("source-ci", projectID, commit)
+	for range idempotencyClaimAttempts {
+		record, revision, claimed, err := a.store.claimIdempotencyKey(ctx, key, commit)
+		if err != nil {
+			return sourceCommitClaim{}, nil, err
+		}
+		if claimed {
+			return sourceCommitClaim{key: key, commit: commit, revision: revision, claimed: true}, nil, nil
+		}
+		if record.OpID == "" {
+			return sourceCommitClaim{}, nil, nil
+		}
+		op, getErr := a.store.GetOp(ctx, record.OpID)
+		if getErr == nil && op.Status != opStatusError && op.Status != opStatusCancelled {
+			return sourceCommitClaim{}, &op, nil
+		}
+		if err = a.store.releaseIdempotencyKey(ctx, key, revision); err != nil {
+			return sourceCommitClaim{}, nil, err
+		}
+	}
+	return sourceCommitClaim{}, nil, fmt.Errorf(
+		"source commit %s of project %s kept changing", shortID(commit), projectID,
+	)
+}
+
+// finishSourceCommitClaim maps the claimed commit to op, or frees it when
+// nothing was queued.
+func (a *API) finishSourceCommitClaim(ctx context.Context, claim sourceCommitClaim, op *Operation) {
+	if claim.key == "" {
+		return
+	}
+	var err error
+	if op == nil {
+		err = a.store.releaseIdempotencyKey(ctx, claim.key, claim.revision)
+	} else {
+		_, err = a.store.completeIdempotencyKey(ctx, claim.key, claim.revision, idempotencyRecord{
+			RequestHash: claim.commit,
+			Status:      http.StatusAccepted,
+			Body:        nil,
+			OpID:        op.ID,
+			CreatedAt:   time.Now().UTC(),
+		})
+	}
+	if err != nil {
+		appLoggerForProcess().Source("api").Warnf("record source commit %s claim: %v", shortID(claim.commit), err)
+	}
+}
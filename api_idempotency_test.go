@@ -0,0 +1,81 @@
+//nolint:testpackage,exhaustruct // Idempotency tests reuse the internal promotion preview fixture.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPI_IdempotencyKeyReplaysTheOriginalOp(t *testing.T) {
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	deploy := func(key string, body map[string]any) (*http.Response, map[string]any) {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/api/events/deployment",
+			bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotencyKeyHeader, key)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("post deployment: %v", err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+	opID := func(out map[string]any) string {
+		op, _ := out["op"].(map[string]any)
+		id, _ := op["id"].(string)
+		return id
+	}
+
+	body := map[string]any{"project_id": fixture.projectID}
+	resp, first := deploy("deploy-1", body)
+	if resp.StatusCode != http.StatusAccepted || opID(first) == "" {
+		t.Fatalf("expected the first deploy queued, got %d %v", resp.StatusCode, first)
+	}
+	resp, again := deploy("deploy-1", body)
+	if resp.StatusCode != http.StatusAccepted || resp.Header.Get(idempotencyReplayedHeader) != "true" ||
+		opID(again) != opID(first) {
+		t.Fatalf("expected the retry to replay op %s, got %d %v", opID(first), resp.StatusCode, again)
+	}
+	ops, err := fixture.api.store.listAllOps(ctx)
+	if err != nil || len(ops) != 1 {
+		t.Fatalf("expected one op queued for both requests, got %d (%v)", len(ops), err)
+	}
+	resp, mismatch := deploy("deploy-1", map[string]any{"project_id": fixture.projectID, "environment": "prod"})
+	if resp.StatusCode != http.StatusUnprocessableEntity || mismatch["code"] != errorCodeIdempotencyMismatch {
+		t.Fatalf("expected a reused key with another body refused, got %d %v", resp.StatusCode, mismatch)
+	}
+
+	ops[0].Status = opStatusDone
+	if err = fixture.api.store.PutOp(ctx, ops[0]); err != nil {
+		t.Fatalf("finish deploy op: %v", err)
+	}
+
+	// A source webhook for a commit that already has an op answers with it.
+	push := SourceRepoWebhookEvent{ProjectID: fixture.projectID, Branch: branchMain, Commit: "abc123"}
+	queued, err := fixture.api.triggerSourceRepoCI(ctx, push, "source.main.webhook")
+	if err != nil || !queued.accepted || queued.op == nil {
+		t.Fatalf("expected the first push to queue ci, got %+v (%v)", queued, err)
+	}
+	// Drop this replica's file state so only the shared claim catches the
+	// redelivery, as it does on a replica that never saw the push.
+	if err = fixture.api.forgetSourcePush(fixture.projectID, ciPush{}, "abc123"); err != nil {
+		t.Fatalf("forget source push: %v", err)
+	}
+	duplicate, err := fixture.api.triggerSourceRepoCI(ctx, push, "source.main.webhook")
+	if err != nil || duplicate.accepted || duplicate.op == nil || duplicate.op.ID != queued.op.ID {
+		t.Fatalf("expected the redelivered push to return op %s, got %+v (%v)", queued.op.ID, duplicate, err)
+	}
+}
@@ -0,0 +1,89 @@
+package platform
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Slow and oversized clients: the server bounds how long a request may take
+// to arrive and a response to drain, each body-carrying route caps its body,
+// and the two paths that legitimately outlive the write timeout (SSE streams
+// and artifact downloads) set their own write deadlines instead of being
+// exempt from one.
+
+// newHTTPServer is the http.Server both the runtime and the self-test
+// serve the API with.
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: defaultReadHeaderWait,
+		ReadTimeout:       defaultReadWait,
+		WriteTimeout:      defaultWriteWait,
+		IdleTimeout:       defaultIdleWait,
+	}
+}
+
+// withBodyLimit caps the request body at limit bytes. A declared
+// Content-Length over the limit is refused with 413 before the handler
+// runs; an undeclared (chunked) body is cut off at the limit, which the
+// handler's decoder reports as a bad request.
+func withBodyLimit(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			writeAPIErrorResponse(w, http.StatusRequestEntityTooLarge, apiErrorResponse{
+				Code:    errorCodeTooLarge,
+				Message: "request body too large (limit " + strconv.FormatInt(limit, 10) + " bytes)",
+				Field:   "",
+				Details: map[string]any{"limit_bytes": limit},
+				OpID:    "",
+			})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next(w, r)
+	}
+}
+
+// withProjectBodyLimit caps /api/projects/ bodies at the spec limit, except
+// artifact uploads, which carry files rather than specs and get their own.
+func withProjectBodyLimit(next http.HandlerFunc) http.HandlerFunc {
+	specLimited := withBodyLimit(specBodyMaxBytes, next)
+	uploadLimited := withBodyLimit(artifactUploadMaxBytes, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isArtifactUploadRequest(r) {
+			uploadLimited(w, r)
+			return
+		}
+		specLimited(w, r)
+	}
+}
+
+// extendStreamWriteDeadline pushes the connection's write deadline out by
+// one frame's worth. Streams call it before every frame, so a reader that
+// stops draining is dropped while a live one is never cut off.
+func extendStreamWriteDeadline(w http.ResponseWriter) {
+	// Recorders in tests cannot set deadlines; that is not an error.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(sseFrameWriteWait))
+}
+
+// acquireArtifactDownload takes one of the download slots, reporting false
+// when all are busy. A nil slot channel means downloads are unlimited.
+func (a *API) acquireArtifactDownload() (func(), bool) {
+	if a.downloadSlots == nil {
+		return func() {}, true
+	}
+	select {
+	case a.downloadSlots <- struct{}{}:
+		return func() { <-a.downloadSlots }, true
+	default:
+		return nil, false
+	}
+}
+
+// artifactDownloadWriteWait gives a download of size bytes a base allowance
+// plus the time it takes at the slowest transfer rate still served.
+func artifactDownloadWriteWait(size int) time.Duration {
+	return artifactDownloadBaseWait + time.Duration(size/artifactDownloadMinBytesPerSec)*time.Second
+}
@@ -0,0 +1,127 @@
+//nolint:testpackage,exhaustruct // Limit tests build partial API values around internal handlers.
+package platform
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_BodyLimitRejectsOversizedBodies(t *testing.T) {
+	t.Parallel()
+
+	handler := withBodyLimit(16, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(strconv.Itoa(len(body))))
+	})
+
+	cases := []struct {
+		name          string
+		body          string
+		contentLength int64
+		want          int
+	}{
+		{name: "within limit", body: `{"a":1}`, contentLength: 7, want: http.StatusOK},
+		{name: "declared over limit", body: strings.Repeat("x", 17), contentLength: 17, want: http.StatusRequestEntityTooLarge},
+		{name: "chunked over limit", body: strings.Repeat("x", 64), contentLength: -1, want: http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/events/deployment", strings.NewReader(tc.body))
+		req.ContentLength = tc.contentLength
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d body=%q", tc.name, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestAPI_ArtifactDownloadsShareBoundedSlots(t *testing.T) {
+	t.Parallel()
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	if _, err := artifacts.WriteFile("project-limits", "build/image.txt", []byte("local/limits:1\n")); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+	api := &API{artifacts: artifacts, downloadSlots: make(chan struct{}, 1)}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	url := srv.URL + "/api/projects/project-limits/artifacts/build/image.txt"
+
+	api.downloadSlots <- struct{}{}
+	busy := conditionalGetForTest(t, url, "")
+	if busy.StatusCode != http.StatusServiceUnavailable || busy.Header.Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After while slots are full, got %d %v", busy.StatusCode, busy.Header)
+	}
+	<-api.downloadSlots
+	if got := conditionalGetForTest(t, url, "").StatusCode; got != http.StatusOK {
+		t.Fatalf("expected 200 once a slot frees, got %d", got)
+	}
+	if len(api.downloadSlots) != 0 {
+		t.Fatalf("expected download slot returned, %d still held", len(api.downloadSlots))
+	}
+
+	if got := artifactDownloadWriteWait(10 * artifactDownloadMinBytesPerSec); got != artifactDownloadBaseWait+10*time.Second {
+		t.Fatalf("expected write wait to scale with size, got %s", got)
+	}
+}
+
+func TestAPI_OpEventStreamOutlivesServerWriteTimeout(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	hub := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
+	op := Operation{
+		ID: "op-stream-deadline", Kind: OpDeploy, ProjectID: "project-stream-deadline",
+		Requested: time.Now().UTC(), Status: opStatusRunning, Steps: []OpStep{},
+	}
+	if err := fixture.store.PutOp(context.Background(), op); err != nil {
+		t.Fatalf("put op: %v", err)
+	}
+	emitOpBootstrap(hub, op, "operation accepted and queued")
+
+	api := &API{store: fixture.store, opEvents: hub, opHeartbeatInterval: 40 * time.Millisecond}
+	srv := httptest.NewUnstartedServer(api.routes())
+	srv.Config.WriteTimeout = 150 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/ops/"+op.ID+"/events", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Heartbeats keep arriving well past the server's WriteTimeout.
+	reader := bufio.NewReader(resp.Body)
+	deadline := time.Now().Add(500 * time.Millisecond)
+	heartbeats := 0
+	for time.Now().Before(deadline) {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil {
+			t.Fatalf("stream cut after %d heartbeats: %v", heartbeats, readErr)
+		}
+		if strings.TrimSpace(line) == "event: "+opEventHeartbeat {
+			heartbeats++
+		}
+	}
+	if heartbeats < 5 {
+		t.Fatalf("expected steady heartbeats, got %d", heartbeats)
+	}
+}
@@ -0,0 +1,147 @@
+package platform
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const lookupCommitMinPrefix = 7
+
+type lookupMatch struct {
+	ProjectID    string        `json:"project_id"`
+	ProjectName  string        `json:"project_name"`
+	Environment  string        `json:"environment"`
+	ReleaseID    string        `json:"release_id"`
+	OpID         string        `json:"op_id"`
+	OpKind       OperationKind `json:"op_kind"`
+	Image        string        `json:"image,omitempty"`
+	SourceCommit string        `json:"source_commit,omitempty"`
+	Current      bool          `json:"current"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type lookupResponse struct {
+	Image   string        `json:"image,omitempty"`
+	Commit  string        `json:"commit,omitempty"`
+	Matches []lookupMatch `json:"matches"`
+}
+
+type lookupQuery struct {
+	image  string
+	commit string
+}
+
+func (q lookupQuery) matches(release ReleaseRecord) bool {
+	if q.image != "" && strings.TrimSpace(release.Image) != q.image {
+		return false
+	}
+	if q.commit != "" && !strings.HasPrefix(strings.ToLower(release.SourceCommit), q.commit) {
+		return false
+	}
+	return true
+}
+
+// handleLookup answers "which project/release produced this?" for an image
+// or source commit observed in a cluster.
+func (a *API) handleLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := lookupQuery{
+		image:  strings.TrimSpace(r.URL.Query().Get("image")),
+		commit: strings.ToLower(strings.TrimSpace(r.URL.Query().Get("commit"))),
+	}
+	if query.image == "" && query.commit == "" {
+		writeAPIError(w, "image or commit query parameter is required", http.StatusBadRequest)
+		return
+	}
+	if query.commit != "" && len(query.commit) < lookupCommitMinPrefix {
+		writeAPIError(w, "commit must be at least 7 characters", http.StatusBadRequest)
+		return
+	}
+
+	matches, err := a.lookupReleases(r.Context(), query)
+	if err != nil {
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, lookupResponse{
+		Image:   query.image,
+		Commit:  query.commit,
+		Matches: matches,
+	})
+}
+
+func (a *API) lookupReleases(ctx context.Context, query lookupQuery) ([]lookupMatch, error) {
+	projects, err := a.store.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]lookupMatch, 0)
+	for _, project := range projects {
+		for _, env := range desiredManifestEnvironments(project.Spec) {
+			envMatches, envErr := a.lookupProjectEnvironmentReleases(ctx, project, env, query)
+			if envErr != nil {
+				return nil, envErr
+			}
+			matches = append(matches, envMatches...)
+		}
+	}
+	slices.SortStableFunc(matches, func(x, y lookupMatch) int {
+		return y.CreatedAt.Compare(x.CreatedAt)
+	})
+	return matches, nil
+}
+
+func (a *API) lookupProjectEnvironmentReleases(
+	ctx context.Context,
+	project Project,
+	env string,
+	query lookupQuery,
+) ([]lookupMatch, error) {
+	current, hasCurrent, err := a.store.getProjectCurrentRelease(ctx, project.ID, env)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]lookupMatch, 0)
+	cursor := ""
+	for {
+		page, pageErr := a.store.listProjectReleases(
+			ctx,
+			project.ID,
+			env,
+			projectReleaseListQuery{Limit: projectReleaseMaxLimit, Cursor: cursor},
+		)
+		if pageErr != nil {
+			return nil, pageErr
+		}
+		for _, release := range page.Items {
+			if query.matches(release) {
+				out = append(out, newLookupMatch(project, release, hasCurrent && current.ID == release.ID))
+			}
+		}
+		if page.NextCursor == "" {
+			return out, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func newLookupMatch(project Project, release ReleaseRecord, current bool) lookupMatch {
+	return lookupMatch{
+		ProjectID:    project.ID,
+		ProjectName:  project.Spec.Name,
+		Environment:  release.Environment,
+		ReleaseID:    release.ID,
+		OpID:         release.OpID,
+		OpKind:       release.OpKind,
+		Image:        release.Image,
+		SourceCommit: release.SourceCommit,
+		Current:      current,
+		CreatedAt:    release.CreatedAt,
+	}
+}
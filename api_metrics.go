@@ -0,0 +1,29 @@
+package platform
+
+import (
+	"net/http"
+	"time"
+)
+
+type metricsResponse struct {
+	Store   storeMetricsSnapshot        `json:"store"`
+	Workers map[string]workerQueueStats `json:"workers"`
+	Time    time.Time                   `json:"time"`
+}
+
+// handleMetrics reports in-process counters. Values reset on restart.
+func (a *API) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var store *storeMetrics
+	if a.store != nil {
+		store = a.store.metrics
+	}
+	writeJSON(w, http.StatusOK, metricsResponse{
+		Store:   store.snapshot(),
+		Workers: a.workerQueues.snapshot(r.Context()),
+		Time:    time.Now().UTC(),
+	})
+}
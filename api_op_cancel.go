@@ -0,0 +1,117 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type opCancelRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type opCancelResponse struct {
+	Cancelled bool      `json:"cancelled"`
+	Op        Operation `json:"op"`
+}
+
+// opCancelConflictResponse is the 409 body when an op cannot be cancelled.
+type opCancelConflictResponse struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Cancelled bool          `json:"cancelled"`
+	Reason    string        `json:"reason"`
+	OpID      string        `json:"op_id"`
+	Kind      OperationKind `json:"kind"`
+	Status    string        `json:"status"`
+}
+
+// handleOpCancel stops a queued or running op. The op is marked cancelled
+// and its project freed before workers hear about it, so the response never
+// waits on a worker; a worker mid-step stops at its next cancellation check.
+func (a *API) handleOpCancel(w http.ResponseWriter, r *http.Request, opID string) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.store == nil {
+		writeAPIError(w, "operation data unavailable", http.StatusInternalServerError)
+		return
+	}
+	var req opCancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+
+	op, err := a.store.GetOp(r.Context(), opID)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			writeAPIError(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeAPIError(w, "failed to read op", http.StatusInternalServerError)
+		return
+	}
+	// Holding the start lock keeps a new op from being admitted between
+	// the status check and the cancel.
+	projectMu := a.projectStartLock(op.ProjectID)
+	projectMu.Lock()
+	defer projectMu.Unlock()
+	if op, err = a.store.GetOp(r.Context(), opID); err != nil {
+		writeAPIError(w, "failed to read op", http.StatusInternalServerError)
+		return
+	}
+	if conflict := opCancelConflict(op); conflict != "" {
+		writeJSON(w, http.StatusConflict, opCancelConflictResponse{
+			Code:      errorCodeCancelConflict,
+			Message:   conflict,
+			Cancelled: false,
+			Reason:    conflict,
+			OpID:      op.ID,
+			Kind:      op.Kind,
+			Status:    op.Status,
+		})
+		return
+	}
+
+	cancelled, err := cancelOp(r.Context(), a.store, opID, reason)
+	if err != nil {
+		writeAPIError(w, "failed to cancel op", http.StatusInternalServerError)
+		return
+	}
+	apiLog := appLoggerForProcess().Source("api")
+	apiLog.Infof("cancelled op=%s kind=%s project=%s reason=%q", op.ID, op.Kind, op.ProjectID, reason)
+	if op.Kind == OpCI && a.artifacts != nil {
+		if stateErr := finalizeSourceCommitPendingOp(a.artifacts, op.ProjectID, op.ID, false); stateErr != nil {
+			apiLog.Warnf("finalize ci commit state on cancel op=%s failed: %v", op.ID, stateErr)
+		}
+	}
+	if a.nc != nil {
+		// Workers also check KV before each delivery, so a lost message
+		// only delays the stop until the running step ends.
+		if pubErr := publishOpCancel(a.nc, cancelled, reason); pubErr != nil {
+			apiLog.Warnf("publish cancel op=%s failed: %v", op.ID, pubErr)
+		}
+	}
+	writeJSON(w, http.StatusOK, opCancelResponse{Cancelled: true, Op: cancelled})
+}
+
+// opCancelConflict explains why op cannot be cancelled, or returns "".
+// Deletes are refused outright: stopping one partway would leave a project
+// with some of its artifacts gone and its delete plan already spent.
+func opCancelConflict(op Operation) string {
+	if !isOperationStatusActive(op.Status) {
+		return fmt.Sprintf("operation is already %s", op.Status)
+	}
+	if op.Kind == OpDelete {
+		return "delete operations cannot be cancelled"
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+//nolint:testpackage,exhaustruct // Cancel tests seed ops through the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_CancelOpFreesProjectAndRefusesFinishedOrDeleteOps(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-cancel-api"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-cancel-deploy", OpDeploy, workerRuntimeSpec("cancel-api"))
+	if err := markOpStepStart(ctx, fixture.store, "op-cancel-deploy", "deployer", time.Now().UTC(), "deploy"); err != nil {
+		t.Fatalf("start step: %v", err)
+	}
+	project, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	project.Status.Phase = "Reconciling"
+	project.Status.LastOpID = "op-cancel-deploy"
+	if err = fixture.store.PutProject(ctx, project); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	if err = fixture.store.PutOp(ctx, Operation{
+		ID: "op-cancel-delete", Kind: OpDelete, ProjectID: projectID,
+		Requested: time.Now().UTC(), Status: opStatusRunning, Steps: []OpStep{},
+	}); err != nil {
+		t.Fatalf("put delete op: %v", err)
+	}
+
+	hub := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
+	fixture.store.setOpEvents(hub)
+	_, events, _, unsubscribe := hub.subscribe("op-cancel-deploy", "")
+	defer unsubscribe()
+	srv := httptest.NewServer((&API{store: fixture.store, nc: fixture.nc, opEvents: hub}).routes())
+	defer srv.Close()
+
+	cancel := func(opID, body string) *http.Response {
+		t.Helper()
+		resp, postErr := srv.Client().Post(srv.URL+"/api/ops/"+opID+"/cancel", "application/json", strings.NewReader(body))
+		if postErr != nil {
+			t.Fatalf("cancel %s: %v", opID, postErr)
+		}
+		return resp
+	}
+
+	resp := cancel("op-cancel-deploy", `{"reason":"wrong image"}`)
+	var out opCancelResponse
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%v)", resp.StatusCode, err)
+	}
+	if !out.Cancelled || out.Op.Status != opStatusCancelled || out.Op.Error != "operation cancelled: wrong image" ||
+		out.Op.Finished.IsZero() || out.Op.Steps[0].EndedAt.IsZero() {
+		t.Fatalf("unexpected cancelled op: %+v", out.Op)
+	}
+	project, err = fixture.store.GetProject(ctx, projectID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if project.Status.Phase != projectPhaseReady {
+		t.Fatalf("expected project released from Reconciling, got %+v", project.Status)
+	}
+	sawCancelled := false
+	for !sawCancelled {
+		select {
+		case record := <-events:
+			sawCancelled = record.Name == opEventCancelled
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected op.cancelled event")
+		}
+	}
+
+	for opID, want := range map[string]string{
+		"op-cancel-deploy": "operation is already cancelled",
+		"op-cancel-delete": "delete operations cannot be cancelled",
+	} {
+		conflict := cancel(opID, "")
+		var body opCancelConflictResponse
+		err = json.NewDecoder(conflict.Body).Decode(&body)
+		conflict.Body.Close()
+		if err != nil || conflict.StatusCode != http.StatusConflict || body.Reason != want {
+			t.Fatalf("%s: expected 409 %q, got %d %+v (%v)", opID, want, conflict.StatusCode, body, err)
+		}
+	}
+	if missing := cancel("op-cancel-missing", ""); missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown op, got %d", missing.StatusCode)
+	}
+}
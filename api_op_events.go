@@ -13,7 +13,7 @@ import (
 
 func (a *API) handleOpEvents(w http.ResponseWriter, r *http.Request, opID string) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
@@ -44,23 +44,23 @@ func (a *API) prepareOpEventStream(
 	opID string,
 ) (Operation, http.Flusher, bool) {
 	if a.store == nil || a.opEvents == nil {
-		http.Error(w, "operation events unavailable", http.StatusInternalServerError)
+		writeAPIError(w, "operation events unavailable", http.StatusInternalServerError)
 		return Operation{}, nil, false
 	}
 
 	op, err := a.store.GetOp(r.Context(), opID)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
-			http.Error(w, "not found", http.StatusNotFound)
+			writeAPIError(w, "not found", http.StatusNotFound)
 			return Operation{}, nil, false
 		}
-		http.Error(w, "failed to read op", http.StatusInternalServerError)
+		writeAPIError(w, "failed to read op", http.StatusInternalServerError)
 		return Operation{}, nil, false
 	}
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		writeAPIError(w, "streaming unsupported", http.StatusInternalServerError)
 		return Operation{}, nil, false
 	}
 	return op, flusher, true
@@ -169,10 +169,26 @@ func writeSSEEvent(
 	if marshalErr != nil {
 		return marshalErr
 	}
-
+	eventID := ""
 	if includeProtocolID {
+		eventID = payload.EventID
+	}
+	return writeSSEFrame(w, flusher, eventID, eventName, body)
+}
+
+// writeSSEFrame writes one JSON-encoded SSE event; an empty eventID omits the
+// id field so the client's Last-Event-ID is left untouched.
+func writeSSEFrame(
+	w http.ResponseWriter,
+	flusher http.Flusher,
+	eventID string,
+	eventName string,
+	body []byte,
+) error {
+	extendStreamWriteDeadline(w)
+	if eventID != "" {
 		// #nosec G705 -- SSE id field intentionally carries sanitized event identifiers.
-		if _, err := w.Write([]byte("id: " + sanitizeSSEField(payload.EventID) + "\n")); err != nil {
+		if _, err := w.Write([]byte("id: " + sanitizeSSEField(eventID) + "\n")); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,114 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	maxOpNotes            = 100
+	maxOpNoteTextLength   = 4000
+	maxOpNoteAuthorLength = 128
+)
+
+type opNoteRequest struct {
+	Author string `json:"author"`
+	Text   string `json:"text"`
+}
+
+type opNotesResponse struct {
+	OpID  string   `json:"op_id"`
+	Notes []OpNote `json:"notes"`
+}
+
+type opNoteCreatedResponse struct {
+	OpID string `json:"op_id"`
+	Note OpNote `json:"note"`
+}
+
+// handleOpNotes lists (GET) or adds to (POST) the notes on an op. Notes can
+// be added in any status, so a finished op can be annotated as an incident
+// record after the fact.
+func (a *API) handleOpNotes(w http.ResponseWriter, r *http.Request, opID string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.store == nil {
+		writeAPIError(w, "operation data unavailable", http.StatusInternalServerError)
+		return
+	}
+	op, err := a.store.GetOp(r.Context(), opID)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			writeAPIError(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeAPIError(w, "failed to read op", http.StatusInternalServerError)
+		return
+	}
+	if r.Method == http.MethodGet {
+		notes, rev, notesErr := a.store.getOpNotes(r.Context(), op.ID)
+		if notesErr != nil {
+			writeAPIError(w, "failed to read op notes", http.StatusInternalServerError)
+			return
+		}
+		if newCacheValidator("op-notes").add(rev).notModified(w, r) {
+			return
+		}
+		writeJSON(w, http.StatusOK, opNotesResponse{OpID: op.ID, Notes: notes})
+		return
+	}
+
+	var req opNoteRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	note, msg := newOpNote(r.Context(), req)
+	if msg != "" {
+		writeAPIError(w, msg, http.StatusBadRequest)
+		return
+	}
+	if err = a.store.addOpNote(r.Context(), op, note); err != nil {
+		if errors.As(err, new(opNotesFullError)) {
+			writeAPIError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeAPIError(w, "failed to save op note", http.StatusInternalServerError)
+		return
+	}
+	appLoggerForProcess().Source("api").Infof("note added op=%s project=%s author=%q", op.ID, op.ProjectID, note.Author)
+	emitOpNote(a.opEvents, op, note)
+	writeJSON(w, http.StatusCreated, opNoteCreatedResponse{OpID: op.ID, Note: note})
+}
+
+// newOpNote validates req and stamps the note, or explains what is wrong.
+// With auth on, the author is the caller's token rather than the body.
+func newOpNote(ctx context.Context, req opNoteRequest) (OpNote, string) {
+	note := OpNote{
+		ID:        newID(),
+		Author:    requestActor(ctx, req.Author),
+		Text:      strings.TrimSpace(req.Text),
+		CreatedAt: time.Now().UTC(),
+	}
+	switch {
+	case note.Author == "":
+		return note, "author required"
+	case utf8.RuneCountInString(note.Author) > maxOpNoteAuthorLength:
+		return note, "author is too long"
+	case note.Text == "":
+		return note, "text required"
+	case utf8.RuneCountInString(note.Text) > maxOpNoteTextLength:
+		return note, "text is too long"
+	default:
+		return note, ""
+	}
+}
@@ -0,0 +1,139 @@
+//nolint:testpackage,exhaustruct // Note tests seed ops through the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_OpNotesSurviveOpRewritesAndShowOnTheOp(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-op-notes"
+	opID := "op-notes-deploy"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpDeploy, workerRuntimeSpec("op-notes"))
+
+	hub := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
+	fixture.store.setOpEvents(hub)
+	_, events, _, unsubscribe := hub.subscribe(opID, "")
+	defer unsubscribe()
+	srv := httptest.NewServer((&API{store: fixture.store, nc: fixture.nc, opEvents: hub}).routes())
+	defer srv.Close()
+
+	addNote := func(id, body string) int {
+		t.Helper()
+		resp, err := srv.Client().Post(srv.URL+"/api/ops/"+id+"/notes", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("add note to %s: %v", id, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	getOp := func() (Operation, string) {
+		t.Helper()
+		resp, err := srv.Client().Get(srv.URL + "/api/ops/" + opID)
+		if err != nil {
+			t.Fatalf("get op: %v", err)
+		}
+		defer resp.Body.Close()
+		var op Operation
+		if err = json.NewDecoder(resp.Body).Decode(&op); err != nil {
+			t.Fatalf("decode op: %v", err)
+		}
+		return op, resp.Header.Get("ETag")
+	}
+
+	for body, want := range map[string]int{
+		`{"author":"","text":"flaky"}`:   http.StatusBadRequest,
+		`{"author":"sam","text":"  "}`:   http.StatusBadRequest,
+		`{"author":"sam"`:                http.StatusBadRequest,
+		`{"author":"sam","text":"late"}`: http.StatusCreated,
+	} {
+		if got := addNote(opID, body); got != want {
+			t.Fatalf("POST %s: expected %d, got %d", body, want, got)
+		}
+	}
+	if got := addNote("op-missing", `{"author":"sam","text":"late"}`); got != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown op, got %d", got)
+	}
+	select {
+	case record := <-events:
+		if record.Name != opEventNote {
+			t.Fatalf("expected %s event, got %s", opEventNote, record.Name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected op.note event")
+	}
+
+	_, before := getOp()
+	if got := addNote(opID, `{"author":"kim","text":"failed due to flaky registry, retried"}`); got != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", got)
+	}
+	op, after := getOp()
+	if before == after {
+		t.Fatalf("expected the op ETag to change after a note, still %s", after)
+	}
+	if len(op.Notes) != 2 || op.Notes[0].Author != "sam" || op.Notes[1].Text != "failed due to flaky registry, retried" {
+		t.Fatalf("unexpected notes on op: %+v", op.Notes)
+	}
+
+	// Workers rewrite the whole op record; notes live apart and must survive.
+	stored, err := fixture.store.GetOp(ctx, opID)
+	if err != nil {
+		t.Fatalf("get stored op: %v", err)
+	}
+	stored.Status = opStatusDone
+	stored.Finished = time.Now().UTC()
+	if err = fixture.store.PutOp(ctx, stored); err != nil {
+		t.Fatalf("rewrite op: %v", err)
+	}
+	resp, err := srv.Client().Get(srv.URL + "/api/ops/" + opID + "/notes")
+	if err != nil {
+		t.Fatalf("list notes: %v", err)
+	}
+	var listed opNotesResponse
+	err = json.NewDecoder(resp.Body).Decode(&listed)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK || len(listed.Notes) != 2 {
+		t.Fatalf("expected two notes after the rewrite, got %d %+v (%v)", resp.StatusCode, listed, err)
+	}
+}
+
+func TestAPI_OpNoteAuthorIsTheTokenWhileAuthIsOn(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	opID := "op-notes-authored"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-op-notes-auth", opID, OpDeploy, workerRuntimeSpec("notes-auth"))
+	t.Setenv(apiAuthEnv, "true")
+	_, token, err := fixture.store.createAPIToken(ctx, "sam@example.com", apiRoleDeveloper, nil, "")
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer((&API{store: fixture.store, nc: fixture.nc}).routes())
+	defer srv.Close()
+
+	for _, body := range []string{`{"author":"someone-else","text":"retried"}`, `{"text":"no author given"}`} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/api/ops/"+opID+"/notes",
+			strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("add note: %v", err)
+		}
+		var created opNoteCreatedResponse
+		err = json.NewDecoder(resp.Body).Decode(&created)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusCreated || created.Note.Author != "sam@example.com" {
+			t.Fatalf("POST %s: expected the note authored by the token, got %d %+v (%v)", body, resp.StatusCode, created, err)
+		}
+	}
+}
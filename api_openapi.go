@@ -0,0 +1,641 @@
+package platform
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OpenAPI document: built from apiOperations and the Go request/response
+// types, served at /api/openapi.json, and the source for the generated web
+// client (api_tsclient.go).
+////////////////////////////////////////////////////////////////////////////////
+
+// Envelope types below exist so the document can describe responses that
+// handlers build as maps; keep their JSON tags in step with those handlers.
+
+type opAcceptedResponse struct {
+	Accepted bool `json:"accepted"`
+	// Unchanged is set, with status 200 and no op, when an update matched
+	// the project's current spec_hash.
+	Unchanged bool      `json:"unchanged,omitempty"`
+	Project   Project   `json:"project"`
+	Op        Operation `json:"op"`
+	// Approval is set, with no op, when a production release waits for
+	// approvals; see api_approvals.go.
+	Approval *ReleaseApproval `json:"approval,omitempty"`
+	// Lint is set on create and update; see spec_lint.go.
+	Lint []SpecLintWarning `json:"lint,omitempty"`
+}
+
+type projectDeleteAcceptedResponse struct {
+	Accepted  bool      `json:"accepted"`
+	Deleted   bool      `json:"deleted"`
+	ProjectID string    `json:"project_id"`
+	Op        Operation `json:"op"`
+}
+
+type sourceWebhookResponse struct {
+	Accepted bool       `json:"accepted"`
+	Reason   string     `json:"reason"`
+	Trigger  string     `json:"trigger"`
+	Project  string     `json:"project"`
+	Op       *Operation `json:"op"`
+	Commit   string     `json:"commit"`
+}
+
+type artifactListResponse struct {
+	Files   []string           `json:"files"`
+	Entries []ArtifactFileInfo `json:"entries"`
+}
+
+type artifactCleanupAcceptedResponse struct {
+	Accepted  bool      `json:"accepted"`
+	Op        Operation `json:"op"`
+	ProjectID string    `json:"project_id"`
+	Prefix    string    `json:"prefix"`
+}
+
+type runtimeUpgradeAcceptedResponse struct {
+	Accepted  bool      `json:"accepted"`
+	Op        Operation `json:"op"`
+	ProjectID string    `json:"project_id"`
+	Branch    string    `json:"branch"`
+}
+
+type projectOverviewResponse struct {
+	Project  Project         `json:"project"`
+	Overview projectOverview `json:"overview"`
+}
+
+type projectJourneyResponse struct {
+	Project Project        `json:"project"`
+	Journey projectJourney `json:"journey"`
+}
+
+type holdLiftedResponse struct {
+	Lifted bool           `json:"lifted"`
+	Hold   ComplianceHold `json:"hold"`
+}
+
+type bindingDeletedResponse struct {
+	Deleted bool              `json:"deleted"`
+	Binding CapabilityBinding `json:"binding"`
+}
+
+type scheduleDeletedResponse struct {
+	Deleted  bool       `json:"deleted"`
+	Schedule OpSchedule `json:"schedule"`
+}
+
+type healthzResponse struct {
+	OK   bool      `json:"ok"`
+	Time time.Time `json:"time"`
+}
+
+// apiOperation is one JSON endpoint in the OpenAPI document. ID doubles as
+// the generated web client method name.
+type apiOperation struct {
+	ID       string
+	Method   string
+	Path     string
+	Summary  string
+	Query    []string
+	Request  reflect.Type
+	Response reflect.Type
+	Status   int
+}
+
+func jsonOp(
+	id, method, path, summary string,
+	request, response reflect.Type,
+	status int,
+	query ...string,
+) apiOperation {
+	return apiOperation{
+		ID:       id,
+		Method:   method,
+		Path:     path,
+		Summary:  summary,
+		Query:    query,
+		Request:  request,
+		Response: response,
+		Status:   status,
+	}
+}
+
+// apiOperations lists the JSON endpoints. The SSE stream and raw artifact
+// downloads are not JSON and stay out of the generated client.
+func apiOperations() []apiOperation {
+	var none reflect.Type
+	accepted := reflect.TypeFor[opAcceptedResponse]()
+	return []apiOperation{
+		jsonOp("listProjects", http.MethodGet, "/api/projects", "List projects",
+			none, reflect.TypeFor[[]Project](), http.StatusOK,
+			"name", "name_prefix", "phase", "team", "owner", "environment", "runtime", "capability", "org",
+			"workspace", "sort", "limit", "cursor"),
+		jsonOp("createProject", http.MethodPost, "/api/projects", "Create a project",
+			reflect.TypeFor[ProjectSpec](), accepted, http.StatusAccepted, "trace", "org", "workspace"),
+		jsonOp("listTemplates", http.MethodGet, "/api/templates", "List project templates",
+			none, reflect.TypeFor[[]ProjectTemplate](), http.StatusOK),
+		jsonOp("listCapabilities", http.MethodGet, "/api/capabilities", "List capability plugins",
+			none, reflect.TypeFor[[]CapabilityInfo](), http.StatusOK),
+		jsonOp("createProjectFromTemplate", http.MethodPost, "/api/projects/from-template/{name}",
+			"Create a project from a template", reflect.TypeFor[ProjectSpec](), accepted, http.StatusAccepted,
+			"trace", "org", "workspace"),
+		jsonOp("validateProjectSpec", http.MethodPost, "/api/projects/validate",
+			"Validate a spec and render its artifacts without storing anything",
+			reflect.TypeFor[ProjectSpec](), reflect.TypeFor[SpecValidationResponse](), http.StatusOK),
+		jsonOp("getProject", http.MethodGet, "/api/projects/{id}", "Get a project",
+			none, reflect.TypeFor[Project](), http.StatusOK),
+		jsonOp("updateProject", http.MethodPut, "/api/projects/{id}", "Replace a project spec",
+			reflect.TypeFor[ProjectSpec](), accepted, http.StatusAccepted, "dry_run", "trace", "force"),
+		jsonOp("deleteProject", http.MethodDelete, "/api/projects/{id}", "Delete a project (applies a delete plan)",
+			none, reflect.TypeFor[projectDeleteAcceptedResponse](), http.StatusAccepted,
+			"plan_id", "acknowledge_impact", "dry_run", "trace"),
+		jsonOp("getProjectAtOp", http.MethodGet, "/api/projects/{id}/at", "Project state right after an op",
+			none, reflect.TypeFor[projectAtOpResponse](), http.StatusOK, "op"),
+		jsonOp("getProjectDeletePlan", http.MethodGet, "/api/projects/{id}/delete-plan", "Pending delete plan",
+			none, reflect.TypeFor[DeletePlan](), http.StatusOK),
+		jsonOp("createProjectDeletePlan", http.MethodPost, "/api/projects/{id}/delete-plan", "Plan a project delete",
+			none, reflect.TypeFor[DeletePlan](), http.StatusCreated),
+		jsonOp("getProjectOverview", http.MethodGet, "/api/projects/{id}/overview", "Project overview read model",
+			none, reflect.TypeFor[projectOverviewResponse](), http.StatusOK),
+		jsonOp("getProjectHealth", http.MethodGet, "/api/projects/{id}/health",
+			"Probed health and availability per environment",
+			none, reflect.TypeFor[projectHealthResponse](), http.StatusOK),
+		jsonOp("getProjectQuota", http.MethodGet, "/api/projects/{id}/quota", "Project quota usage",
+			none, reflect.TypeFor[projectQuotaResponse](), http.StatusOK),
+		jsonOp("getProjectJourney", http.MethodGet, "/api/projects/{id}/journey", "Project journey read model",
+			none, reflect.TypeFor[projectJourneyResponse](), http.StatusOK),
+		jsonOp("getProjectRevision", http.MethodGet, "/api/projects/{id}/revision",
+			"KV revisions behind the project's views, for staleness checks",
+			none, reflect.TypeFor[projectRevisionSnapshot](), http.StatusOK),
+		jsonOp("getProjectPromotionPlan", http.MethodGet, "/api/projects/{id}/promotion-plan",
+			"Simulate promoting dev's image through every environment",
+			none, reflect.TypeFor[PromotionPlanResponse](), http.StatusOK),
+		jsonOp("cloneProject", http.MethodPost, "/api/projects/{id}/clone",
+			"Create a project from another's spec and source repo",
+			reflect.TypeFor[ProjectSpec](), accepted, http.StatusAccepted, "trace"),
+		jsonOp("getEnvironmentEffectiveConfig", http.MethodGet, "/api/projects/{id}/environments/{env}/effective-config",
+			"Resolved environment vars", none, reflect.TypeFor[environmentEffectiveConfigResponse](), http.StatusOK),
+		jsonOp("patchEnvironmentVars", http.MethodPatch, "/api/projects/{id}/environments/{env}/vars",
+			"Add, update, or remove environment vars and re-render",
+			reflect.TypeFor[envVarsPatchRequest](), reflect.TypeFor[envVarsPatchResponse](), http.StatusAccepted,
+			"dry_run", "trace"),
+		jsonOp("listEnvironmentBindings", http.MethodGet, "/api/projects/{id}/environments/{env}/bindings",
+			"List capability bindings", none, reflect.TypeFor[environmentBindingsResponse](), http.StatusOK),
+		jsonOp("getEnvironmentBinding", http.MethodGet,
+			"/api/projects/{id}/environments/{env}/bindings/{capability}",
+			"Get a capability binding", none, reflect.TypeFor[CapabilityBinding](), http.StatusOK),
+		jsonOp("putEnvironmentBinding", http.MethodPut,
+			"/api/projects/{id}/environments/{env}/bindings/{capability}",
+			"Bind a capability in an environment",
+			reflect.TypeFor[capabilityBindingRequest](), reflect.TypeFor[CapabilityBinding](), http.StatusOK),
+		jsonOp("deleteEnvironmentBinding", http.MethodDelete,
+			"/api/projects/{id}/environments/{env}/bindings/{capability}",
+			"Remove a capability binding", none, reflect.TypeFor[bindingDeletedResponse](), http.StatusOK),
+		jsonOp("getEnvironmentFreeze", http.MethodGet, "/api/projects/{id}/environments/{env}/freeze",
+			"Freezes in force or scheduled", none, reflect.TypeFor[environmentFreezeResponse](), http.StatusOK),
+		jsonOp("freezeEnvironment", http.MethodPut, "/api/projects/{id}/environments/{env}/freeze",
+			"Place a manual freeze",
+			reflect.TypeFor[manualFreezeRequest](), reflect.TypeFor[environmentFreezeResponse](), http.StatusOK),
+		jsonOp("unfreezeEnvironment", http.MethodDelete, "/api/projects/{id}/environments/{env}/freeze",
+			"Lift the manual freeze", none, reflect.TypeFor[environmentFreezeResponse](), http.StatusOK, "lifted_by"),
+		jsonOp("getEnvironmentCanary", http.MethodGet, "/api/projects/{id}/environments/{env}/canary",
+			"The canary running in an environment", none, reflect.TypeFor[CanaryRollout](), http.StatusOK),
+		jsonOp("promoteCanary", http.MethodPost, "/api/projects/{id}/environments/{env}/canary/promote",
+			"Roll the canary image out to the whole environment",
+			reflect.TypeFor[canaryActionRequest](), accepted, http.StatusAccepted, "dry_run", "trace"),
+		jsonOp("abortCanary", http.MethodPost, "/api/projects/{id}/environments/{env}/canary/abort",
+			"Remove the canary and keep the stable image",
+			reflect.TypeFor[canaryActionRequest](), accepted, http.StatusAccepted, "dry_run", "trace"),
+		jsonOp("getEnvironmentBlueGreen", http.MethodGet, "/api/projects/{id}/environments/{env}/blue-green",
+			"A blue/green environment's colors", none, reflect.TypeFor[BlueGreenRelease](), http.StatusOK),
+		jsonOp("listProjectSecrets", http.MethodGet, "/api/projects/{id}/secrets/{env}",
+			"List stored secrets, values masked", none, reflect.TypeFor[storedSecretsResponse](), http.StatusOK),
+		jsonOp("putProjectSecrets", http.MethodPost, "/api/projects/{id}/secrets/{env}",
+			"Store encrypted secret values",
+			reflect.TypeFor[storedSecretsRequest](), reflect.TypeFor[storedSecretsResponse](), http.StatusOK),
+		jsonOp("deleteProjectSecrets", http.MethodDelete, "/api/projects/{id}/secrets/{env}",
+			"Remove stored secrets", none, reflect.TypeFor[storedSecretsDeletedResponse](), http.StatusOK, "name"),
+		jsonOp("listProjectOps", http.MethodGet, "/api/projects/{id}/ops", "Project operation history",
+			none, reflect.TypeFor[projectOpsListResponse](), http.StatusOK, "limit", "cursor", "before"),
+		jsonOp("listProjectArtifacts", http.MethodGet, "/api/projects/{id}/artifacts", "List artifact files",
+			none, reflect.TypeFor[artifactListResponse](), http.StatusOK),
+		jsonOp("statProjectArtifact", http.MethodGet, "/api/projects/{id}/artifacts/{path}",
+			"An artifact's size, mtime, sha256 and content type (requires stat=1)",
+			none, reflect.TypeFor[artifactStatResponse](), http.StatusOK, "stat"),
+		jsonOp("cleanupProjectArtifacts", http.MethodDelete, "/api/projects/{id}/artifacts",
+			"Remove artifacts under a prefix",
+			none, reflect.TypeFor[artifactCleanupAcceptedResponse](), http.StatusAccepted,
+			"prefix", "dry_run", "trace"),
+		jsonOp("listProjectReleases", http.MethodGet, "/api/projects/{id}/releases", "Environment release timeline",
+			none, reflect.TypeFor[projectReleaseListResponse](), http.StatusOK, "environment", "limit", "cursor"),
+		jsonOp("compareProjectReleases", http.MethodGet, "/api/projects/{id}/releases/compare", "Compare two releases",
+			none, reflect.TypeFor[ReleaseCompareResponse](), http.StatusOK, "from", "to"),
+		jsonOp("getProjectRelease", http.MethodGet, "/api/projects/{id}/releases/{release_id}", "Get a release",
+			none, reflect.TypeFor[releaseDetailResponse](), http.StatusOK),
+		jsonOp("getProjectReleaseNotes", http.MethodGet, "/api/projects/{id}/releases/{release_id}/notes",
+			"A release's notes (format=markdown for the notes document)",
+			none, reflect.TypeFor[ReleaseNotes](), http.StatusOK, "format"),
+		jsonOp("updateProjectReleaseNotes", http.MethodPut, "/api/projects/{id}/releases/{release_id}/notes",
+			"Replace a release's notes text",
+			reflect.TypeFor[releaseNotesRequest](), reflect.TypeFor[releaseDetailResponse](), http.StatusOK),
+		jsonOp("listProjectHolds", http.MethodGet, "/api/projects/{id}/holds", "List compliance holds",
+			none, reflect.TypeFor[projectHoldsResponse](), http.StatusOK),
+		jsonOp("placeProjectHold", http.MethodPost, "/api/projects/{id}/holds", "Place a compliance hold",
+			reflect.TypeFor[placeHoldRequest](), reflect.TypeFor[ComplianceHold](), http.StatusCreated),
+		jsonOp("liftProjectHold", http.MethodDelete, "/api/projects/{id}/holds", "Lift a compliance hold",
+			none, reflect.TypeFor[holdLiftedResponse](), http.StatusOK, "release_id", "lifted_by"),
+		jsonOp("listProjectSchedules", http.MethodGet, "/api/projects/{id}/schedules", "List op schedules",
+			none, reflect.TypeFor[projectSchedulesResponse](), http.StatusOK),
+		jsonOp("createProjectSchedule", http.MethodPost, "/api/projects/{id}/schedules",
+			"Run an op on a cron schedule",
+			reflect.TypeFor[opScheduleRequest](), reflect.TypeFor[OpSchedule](), http.StatusCreated),
+		jsonOp("getProjectSchedule", http.MethodGet, "/api/projects/{id}/schedules/{scheduleID}",
+			"Get an op schedule and its last run", none, reflect.TypeFor[OpSchedule](), http.StatusOK),
+		jsonOp("updateProjectSchedule", http.MethodPut, "/api/projects/{id}/schedules/{scheduleID}",
+			"Replace an op schedule's settings",
+			reflect.TypeFor[opScheduleRequest](), reflect.TypeFor[OpSchedule](), http.StatusOK),
+		jsonOp("deleteProjectSchedule", http.MethodDelete, "/api/projects/{id}/schedules/{scheduleID}",
+			"Remove an op schedule", none, reflect.TypeFor[scheduleDeletedResponse](), http.StatusOK),
+		jsonOp("getProjectCompliance", http.MethodGet, "/api/projects/{id}/compliance",
+			"Project compliance report", none, reflect.TypeFor[complianceReport](), http.StatusOK),
+		jsonOp("getProjectOwnership", http.MethodGet, "/api/projects/{id}/ownership", "Get project ownership",
+			none, reflect.TypeFor[projectOwnershipResponse](), http.StatusOK),
+		jsonOp("setProjectOwnership", http.MethodPut, "/api/projects/{id}/ownership", "Replace project ownership",
+			reflect.TypeFor[projectOwnershipRequest](), reflect.TypeFor[projectOwnershipResponse](), http.StatusOK),
+		jsonOp("startVarRollout", http.MethodPost, "/api/projects/{id}/var-rollout",
+			"Roll a var change out to environments in order",
+			reflect.TypeFor[varRolloutRequest](), accepted, http.StatusAccepted),
+		jsonOp("startRuntimeUpgrade", http.MethodPost, "/api/projects/{id}/runtime-upgrade",
+			"Trial a runtime upgrade on a branch",
+			reflect.TypeFor[runtimeUpgradeRequest](), reflect.TypeFor[runtimeUpgradeAcceptedResponse](),
+			http.StatusAccepted, "dry_run", "trace"),
+		jsonOp("listOps", http.MethodGet, "/api/ops", "List operations across projects",
+			none, reflect.TypeFor[projectOpsListResponse](), http.StatusOK,
+			"project_id", "kind", "status", "since", "until", "limit", "cursor"),
+		jsonOp("getOp", http.MethodGet, "/api/ops/{id}", "Get an operation",
+			none, reflect.TypeFor[Operation](), http.StatusOK),
+		jsonOp("cancelOp", http.MethodPost, "/api/ops/{id}/cancel", "Cancel a queued or running operation",
+			reflect.TypeFor[opCancelRequest](), reflect.TypeFor[opCancelResponse](), http.StatusOK),
+		jsonOp("listOpNotes", http.MethodGet, "/api/ops/{id}/notes", "List notes on an operation",
+			none, reflect.TypeFor[opNotesResponse](), http.StatusOK),
+		jsonOp("addOpNote", http.MethodPost, "/api/ops/{id}/notes", "Add a note to an operation",
+			reflect.TypeFor[opNoteRequest](), reflect.TypeFor[opNoteCreatedResponse](), http.StatusCreated),
+		jsonOp("listApprovals", http.MethodGet, "/api/approvals", "List release approvals",
+			none, reflect.TypeFor[[]ReleaseApproval](), http.StatusOK, "project_id", "status"),
+		jsonOp("getApproval", http.MethodGet, "/api/approvals/{id}", "Get a release approval",
+			none, reflect.TypeFor[ReleaseApproval](), http.StatusOK),
+		jsonOp("approveRelease", http.MethodPost, "/api/approvals/{id}/approve",
+			"Approve a release; the last approval needed queues it",
+			reflect.TypeFor[approvalDecisionRequest](), reflect.TypeFor[approvalDecisionResponse](), http.StatusOK),
+		jsonOp("rejectRelease", http.MethodPost, "/api/approvals/{id}/reject", "Reject a release",
+			reflect.TypeFor[approvalDecisionRequest](), reflect.TypeFor[approvalDecisionResponse](), http.StatusOK),
+		jsonOp("postRegistrationEvent", http.MethodPost, "/api/events/registration", "Registration event",
+			reflect.TypeFor[RegistrationEvent](), accepted, http.StatusAccepted, "org", "workspace"),
+		jsonOp("postDeploymentEvent", http.MethodPost, "/api/events/deployment", "Deploy to dev",
+			reflect.TypeFor[DeploymentEvent](), accepted, http.StatusAccepted, "dry_run", "trace"),
+		jsonOp("previewPromotion", http.MethodPost, "/api/events/promotion/preview", "Preview a promotion",
+			reflect.TypeFor[PromotionEvent](), reflect.TypeFor[PromotionPreviewResponse](), http.StatusOK),
+		jsonOp("postPromotionEvent", http.MethodPost, "/api/events/promotion", "Promote between environments",
+			reflect.TypeFor[PromotionEvent](), accepted, http.StatusAccepted, "dry_run", "trace"),
+		jsonOp("postReleaseEvent", http.MethodPost, "/api/events/release", "Release to production",
+			reflect.TypeFor[ReleaseEvent](), accepted, http.StatusAccepted, "dry_run", "trace"),
+		jsonOp("postReleaseCutoverEvent", http.MethodPost, "/api/events/release/cutover",
+			"Switch blue/green traffic to the staged color, or back",
+			reflect.TypeFor[ReleaseCutoverEvent](), accepted, http.StatusAccepted, "dry_run", "trace"),
+		jsonOp("previewRollback", http.MethodPost, "/api/events/rollback/preview", "Preview a rollback",
+			reflect.TypeFor[RollbackEvent](), reflect.TypeFor[RollbackPreviewResponse](), http.StatusOK),
+		jsonOp("postRollbackEvent", http.MethodPost, "/api/events/rollback", "Roll back to a release",
+			reflect.TypeFor[RollbackEvent](), accepted, http.StatusAccepted, "dry_run", "trace"),
+		jsonOp("postSourceWebhook", http.MethodPost, "/api/webhooks/source", "Source repo webhook",
+			reflect.TypeFor[SourceRepoWebhookEvent](), reflect.TypeFor[sourceWebhookResponse](), http.StatusAccepted),
+		jsonOp("listTokens", http.MethodGet, "/api/tokens", "List API tokens",
+			none, reflect.TypeFor[apiTokenListResponse](), http.StatusOK),
+		jsonOp("createToken", http.MethodPost, "/api/tokens", "Create an API token (its value is shown once)",
+			reflect.TypeFor[apiTokenRequest](), reflect.TypeFor[apiTokenCreatedResponse](), http.StatusCreated),
+		jsonOp("revokeToken", http.MethodDelete, "/api/tokens/{id}", "Revoke an API token",
+			none, reflect.TypeFor[apiTokenRevokedResponse](), http.StatusOK),
+		jsonOp("listOrgs", http.MethodGet, "/api/orgs", "List organizations with their usage",
+			none, reflect.TypeFor[orgListResponse](), http.StatusOK),
+		jsonOp("createOrg", http.MethodPost, "/api/orgs", "Create an organization",
+			reflect.TypeFor[orgRequest](), reflect.TypeFor[Organization](), http.StatusCreated),
+		jsonOp("getOrg", http.MethodGet, "/api/orgs/{id}", "Get an organization and its usage",
+			none, reflect.TypeFor[orgResponse](), http.StatusOK),
+		jsonOp("updateOrg", http.MethodPut, "/api/orgs/{id}", "Replace an organization's workspaces and quota",
+			reflect.TypeFor[orgRequest](), reflect.TypeFor[Organization](), http.StatusOK),
+		jsonOp("deleteOrg", http.MethodDelete, "/api/orgs/{id}", "Delete an organization without projects",
+			none, reflect.TypeFor[orgDeletedResponse](), http.StatusOK),
+		jsonOp("listOrgProjects", http.MethodGet, "/api/orgs/{id}/projects", "List an organization's projects",
+			none, reflect.TypeFor[orgProjectsResponse](), http.StatusOK,
+			"name", "name_prefix", "phase", "team", "owner", "environment", "runtime", "capability", "workspace",
+			"sort"),
+		jsonOp("listViews", http.MethodGet, "/api/views", "List saved project views",
+			none, reflect.TypeFor[viewListResponse](), http.StatusOK),
+		jsonOp("createView", http.MethodPost, "/api/views", "Save a project view",
+			reflect.TypeFor[viewRequest](), reflect.TypeFor[ProjectView](), http.StatusCreated),
+		jsonOp("getView", http.MethodGet, "/api/views/{id}", "Get a saved project view",
+			none, reflect.TypeFor[ProjectView](), http.StatusOK),
+		jsonOp("updateView", http.MethodPut, "/api/views/{id}", "Replace a saved project view",
+			reflect.TypeFor[viewRequest](), reflect.TypeFor[ProjectView](), http.StatusOK),
+		jsonOp("deleteView", http.MethodDelete, "/api/views/{id}", "Delete a saved project view",
+			none, reflect.TypeFor[viewDeletedResponse](), http.StatusOK),
+		jsonOp("listViewProjects", http.MethodGet, "/api/views/{id}/projects", "Projects a saved view selects",
+			none, reflect.TypeFor[viewProjectsResponse](), http.StatusOK),
+		jsonOp("getSystem", http.MethodGet, "/api/system", "Runtime capability and transport status",
+			none, reflect.TypeFor[systemStatusResponse](), http.StatusOK),
+		jsonOp("getConfig", http.MethodGet, "/api/config", "Resolved runtime config, secrets redacted",
+			none, reflect.TypeFor[runtimeConfigResponse](), http.StatusOK),
+		jsonOp("getReadOnly", http.MethodGet, "/api/admin/readonly", "Read-only maintenance mode",
+			none, reflect.TypeFor[readOnlyState](), http.StatusOK),
+		jsonOp("setReadOnly", http.MethodPost, "/api/admin/readonly", "Turn read-only maintenance mode on or off",
+			reflect.TypeFor[readOnlyRequest](), reflect.TypeFor[readOnlyState](), http.StatusOK),
+		jsonOp("getHealthz", http.MethodGet, "/api/healthz", "Liveness probe",
+			none, reflect.TypeFor[healthzResponse](), http.StatusOK),
+		jsonOp("getReadyz", http.MethodGet, "/api/readyz", "Worker readiness probe",
+			none, reflect.TypeFor[workerReadinessStatus](), http.StatusOK),
+		jsonOp("lookup", http.MethodGet, "/api/lookup", "Find releases by image or commit",
+			none, reflect.TypeFor[lookupResponse](), http.StatusOK, "image", "commit"),
+		jsonOp("getGraph", http.MethodGet, "/api/graph", "Project dependency graph",
+			none, reflect.TypeFor[ProjectGraph](), http.StatusOK),
+		jsonOp("getMetrics", http.MethodGet, "/api/metrics", "In-process counters",
+			none, reflect.TypeFor[metricsResponse](), http.StatusOK),
+	}
+}
+
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Nullable             bool                   `json:"-"`
+	PropertyOrder        []string               `json:"-"` // Go field order, for generated clients
+}
+
+func newJSONSchema(typ, format string) *jsonSchema {
+	return &jsonSchema{
+		Ref:                  "",
+		Type:                 typ,
+		Format:               format,
+		Items:                nil,
+		Properties:           nil,
+		Required:             nil,
+		AdditionalProperties: nil,
+		Nullable:             false,
+		PropertyOrder:        nil,
+	}
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       openAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components openAPIComponents                      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*jsonSchema `json:"schemas"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string     `json:"name"`
+	In       string     `json:"in"`
+	Required bool       `json:"required"`
+	Schema   jsonSchema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *jsonSchema `json:"schema"`
+}
+
+// buildOpenAPIDocument reflects every apiOperation into an OpenAPI 3.1
+// document. Named struct types become shared component schemas.
+func buildOpenAPIDocument() openAPIDocument {
+	reflector := &schemaReflector{schemas: map[string]*jsonSchema{}}
+	doc := openAPIDocument{
+		OpenAPI: "3.1.0",
+		Info: openAPIInfo{
+			Title:   "go-web-nats platform API",
+			Version: "1",
+		},
+		Paths:      map[string]map[string]openAPIOperation{},
+		Components: openAPIComponents{Schemas: reflector.schemas},
+	}
+	for _, op := range apiOperations() {
+		item := doc.Paths[op.Path]
+		if item == nil {
+			item = map[string]openAPIOperation{}
+			doc.Paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = reflector.operation(op)
+	}
+	return doc
+}
+
+type schemaReflector struct {
+	schemas map[string]*jsonSchema
+}
+
+func (s *schemaReflector) operation(op apiOperation) openAPIOperation {
+	out := openAPIOperation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Parameters:  nil,
+		RequestBody: nil,
+		Responses: map[string]openAPIResponse{
+			strconv.Itoa(op.Status): {
+				Description: http.StatusText(op.Status),
+				Content: map[string]openAPIMediaType{
+					"application/json": {Schema: s.schema(op.Response)},
+				},
+			},
+		},
+	}
+	if servesConditionalGet(op.ID) {
+		out.Responses[strconv.Itoa(http.StatusNotModified)] = openAPIResponse{
+			Description: "Not Modified (If-None-Match or If-Modified-Since matched)",
+			Content:     nil,
+		}
+	}
+	for _, name := range openAPIPathParams(op.Path) {
+		out.Parameters = append(out.Parameters, openAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   *newJSONSchema("string", ""),
+		})
+	}
+	for _, name := range op.Query {
+		out.Parameters = append(out.Parameters, openAPIParameter{
+			Name:     name,
+			In:       "query",
+			Required: false,
+			Schema:   *newJSONSchema("string", ""),
+		})
+	}
+	if op.Request != nil {
+		out.RequestBody = &openAPIBody{
+			Required: true,
+			Content: map[string]openAPIMediaType{
+				"application/json": {Schema: s.schema(op.Request)},
+			},
+		}
+		if acceptsYAMLSpecBody(op.Request) {
+			out.RequestBody.Content["application/yaml"] = openAPIMediaType{Schema: s.schema(op.Request)}
+		}
+	}
+	return out
+}
+
+// acceptsYAMLSpecBody mirrors the handlers that decode through decodeSpecBody.
+func acceptsYAMLSpecBody(request reflect.Type) bool {
+	return request == reflect.TypeFor[ProjectSpec]() || request == reflect.TypeFor[RegistrationEvent]()
+}
+
+// servesConditionalGet mirrors the handlers that answer through a
+// cacheValidator.
+func servesConditionalGet(operationID string) bool {
+	switch operationID {
+	case "getProject", "getOp", "listProjectReleases", "getProjectRelease", "listProjectArtifacts",
+		"statProjectArtifact":
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *schemaReflector) schema(t reflect.Type) *jsonSchema {
+	if t == reflect.TypeFor[time.Time]() {
+		return newJSONSchema("string", "date-time")
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		inner := *s.schema(t.Elem())
+		inner.Nullable = true
+		return &inner
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := openAPISchemaName(t)
+		if _, seen := s.schemas[name]; !seen {
+			s.schemas[name] = newJSONSchema("object", "")
+			*s.schemas[name] = *s.structSchema(t)
+		}
+		ref := newJSONSchema("", "")
+		ref.Ref = "#/components/schemas/" + name
+		return ref
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return newJSONSchema("string", "byte")
+		}
+		array := newJSONSchema("array", "")
+		array.Items = s.schema(t.Elem())
+		return array
+	case reflect.Map:
+		object := newJSONSchema("object", "")
+		object.AdditionalProperties = s.schema(t.Elem())
+		return object
+	case reflect.String:
+		return newJSONSchema("string", "")
+	case reflect.Bool:
+		return newJSONSchema("boolean", "")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return newJSONSchema("integer", "")
+	case reflect.Float32, reflect.Float64:
+		return newJSONSchema("number", "")
+	default:
+		return newJSONSchema("", "")
+	}
+}
+
+func (s *schemaReflector) structSchema(t reflect.Type) *jsonSchema {
+	out := newJSONSchema("object", "")
+	out.Properties = map[string]*jsonSchema{}
+	s.addStructFields(out, t)
+	return out
+}
+
+func (s *schemaReflector) addStructFields(out *jsonSchema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.addStructFields(out, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		out.Properties[name] = s.schema(field.Type)
+		out.PropertyOrder = append(out.PropertyOrder, name)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			out.Required = append(out.Required, name)
+		}
+	}
+	slices.Sort(out.Required)
+}
+
+// openAPISchemaName exports unexported Go type names so component names read
+// like the public types beside them.
+func openAPISchemaName(t reflect.Type) string {
+	runes := []rune(t.Name())
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func openAPIPathParams(path string) []string {
+	var names []string
+	for segment := range strings.SplitSeq(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.Trim(segment, "{}"))
+		}
+	}
+	return names
+}
+
+func (a *API) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, buildOpenAPIDocument())
+}
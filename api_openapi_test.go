@@ -0,0 +1,73 @@
+//nolint:testpackage // Generated-client checks render from the unexported OpenAPI builder.
+package platform
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAPIClient_GeneratedFilesUpToDate(t *testing.T) {
+	doc := buildOpenAPIDocument()
+	files := map[string]string{
+		webAPIClientTypesPath: renderTSDeclarations(doc),
+		webAPIClientPath:      renderJSClient(doc),
+	}
+	for path, want := range files {
+		if os.Getenv("UPDATE_GENERATED") == "1" {
+			if err := os.WriteFile(path, []byte(want), 0o600); err != nil {
+				t.Fatalf("write %s: %v", path, err)
+			}
+			continue
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if string(got) != want {
+			t.Fatalf("%s is stale; run `go generate ./...` (or `make gen-api-client`)", path)
+		}
+	}
+}
+
+func TestAPI_OpenAPIDocumentCoversClientOperations(t *testing.T) {
+	api := &API{}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/api/openapi.json")
+	if err != nil {
+		t.Fatalf("get openapi: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+				Required   []string                   `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	for _, op := range apiOperations() {
+		if _, ok := doc.Paths[op.Path][strings.ToLower(op.Method)]; !ok {
+			t.Fatalf("missing %s %s in document", op.Method, op.Path)
+		}
+	}
+	detail := doc.Components.Schemas["ReleaseDetailResponse"]
+	if _, ok := detail.Properties["image"]; !ok {
+		t.Fatalf("expected embedded ReleaseRecord fields to be flattened: %v", detail.Properties)
+	}
+	if _, ok := detail.Properties["hold"]; !ok {
+		t.Fatalf("expected hold property on release detail: %v", detail.Properties)
+	}
+}
@@ -0,0 +1,146 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"gopkg.in/yaml.v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Org scope: a token carrying an org sees only that org. Requests naming a
+// project of another org, by path, op, query, or event body, get the 404 a
+// missing project would; project listings are narrowed to the org; and the
+// instance-wide endpoints (tokens, config, admin, cross-project lookups) are
+// refused. Tokens without an org see every project, as before orgs.
+////////////////////////////////////////////////////////////////////////////////
+
+// principalOrg is the org of the request's token, "" for an instance-wide
+// token or while auth is off.
+func principalOrg(ctx context.Context) string {
+	principal, ok := requestPrincipal(ctx)
+	if !ok {
+		return ""
+	}
+	return principal.Org
+}
+
+// orgVisible reports whether the request's token may see org.
+func orgVisible(ctx context.Context, org string) bool {
+	tokenOrg := principalOrg(ctx)
+	return tokenOrg == "" || tokenOrg == org
+}
+
+// scopeProjectFilter narrows a project listing to the token's org.
+func scopeProjectFilter(ctx context.Context, filter ProjectFilter) ProjectFilter {
+	if tokenOrg := principalOrg(ctx); tokenOrg != "" {
+		filter.Org = tokenOrg
+	}
+	return filter
+}
+
+// orgScopeRefusesPath reports instance-wide endpoints an org token may not
+// use.
+func orgScopeRefusesPath(path string) bool {
+	for _, prefix := range []string{"/api/tokens", "/api/config", "/api/admin/", "/api/lookup", "/api/approvals",
+		"/api/metrics"} {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// enforceOrgScope enforces the org of the request's token; it runs after
+// authentication and passes requests by instance-wide tokens untouched.
+func (a *API) enforceOrgScope(w http.ResponseWriter, r *http.Request) bool {
+	tokenOrg := principalOrg(r.Context())
+	if tokenOrg == "" || a.store == nil {
+		return true
+	}
+	if orgScopeRefusesPath(r.URL.Path) {
+		writeAPIError(w, "this endpoint needs a token without an org", http.StatusForbidden)
+		return false
+	}
+	if r.URL.Path == "/api/ops" && strings.TrimSpace(r.URL.Query().Get("project_id")) == "" {
+		writeAPIError(w, "tokens with an org list ops per project (project_id)", http.StatusForbidden)
+		return false
+	}
+	projectID, err := a.requestProjectID(r)
+	if err != nil {
+		writeAPIError(w, "failed to read operation", http.StatusInternalServerError)
+		return false
+	}
+	if projectID == "" {
+		return true
+	}
+	org, err := a.store.projectOrg(r.Context(), projectID)
+	switch {
+	case errors.Is(err, jetstream.ErrKeyNotFound):
+		return true // the handler answers 404 itself
+	case err != nil:
+		writeAPIError(w, "failed to read project", http.StatusInternalServerError)
+		return false
+	case org != tokenOrg:
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// requestProjectID names the project a request is about: the {id} of
+// /api/projects/{id}/..., the project of /api/ops/{id}/..., a project_id
+// query param, or the project_id of an /api/events body. It returns "" for
+// requests about no single project.
+func (a *API) requestProjectID(r *http.Request) (string, error) {
+	path := r.URL.Path
+	rest, ok := strings.CutPrefix(path, "/api/projects/")
+	if ok && !strings.HasPrefix(path, projectFromTemplatePrefix) {
+		id, _, _ := strings.Cut(strings.Trim(rest, "/"), "/")
+		if id == "validate" {
+			return "", nil
+		}
+		return id, nil
+	}
+	if rest, ok = strings.CutPrefix(path, "/api/ops/"); ok {
+		opID, _, _ := strings.Cut(strings.Trim(rest, "/"), "/")
+		op, err := a.store.GetOp(r.Context(), opID)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return "", nil
+		}
+		return op.ProjectID, err
+	}
+	if projectID := strings.TrimSpace(r.URL.Query().Get("project_id")); projectID != "" {
+		return projectID, nil
+	}
+	if strings.HasPrefix(path, "/api/events/") && r.Body != nil {
+		return peekEventProjectID(r), nil
+	}
+	return "", nil
+}
+
+// peekEventProjectID reads project_id from an event body (JSON or YAML,
+// as decodeSpecBody accepts) and leaves the body for the handler, which
+// still applies its own size limit.
+func peekEventProjectID(r *http.Request) string {
+	head, err := io.ReadAll(io.LimitReader(r.Body, specBodyMaxBytes))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+	var evt struct {
+		ProjectID string `json:"project_id" yaml:"project_id"`
+	}
+	if yaml.Unmarshal(head, &evt) != nil {
+		return ""
+	}
+	return strings.TrimSpace(evt.ProjectID)
+}
@@ -0,0 +1,445 @@
+package platform
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Organizations: an org groups projects (optionally into workspaces) so
+// several teams can share one instance. A project joins an org when it is
+// created, via ?org= and ?workspace= or the org of the token creating it,
+// and stays there. Org quotas cap how many projects and environments an org
+// holds. API tokens carrying an org only see that org's projects (see
+// api_org_scope.go); its projects' artifact trees live under
+// orgs/<org>/ and their op events are journaled on op.events.<org>.<op>.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	orgIDMax          = 32
+	orgNameMax        = 64
+	maxOrgWorkspaces  = 50
+	orgProjectsSuffix = "/projects"
+)
+
+// orgIDPattern also covers workspace names. Both end up in artifact paths
+// and NATS subject tokens.
+var orgIDPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// orgRequest is the body of POST /api/orgs (which needs ID) and PUT
+// /api/orgs/{org}.
+type orgRequest struct {
+	ID         string   `json:"id,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Workspaces []string `json:"workspaces,omitempty"`
+	Quota      OrgQuota `json:"quota,omitzero"`
+}
+
+// OrgUsage is what an org holds against its quota.
+type OrgUsage struct {
+	Projects     int `json:"projects"`
+	Environments int `json:"environments"`
+}
+
+type orgResponse struct {
+	Org   Organization `json:"org"`
+	Usage OrgUsage     `json:"usage"`
+}
+
+type orgListResponse struct {
+	Orgs []orgResponse `json:"orgs"`
+}
+
+type orgProjectsResponse struct {
+	Org      Organization `json:"org"`
+	Projects []Project    `json:"projects"`
+}
+
+type orgDeletedResponse struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// projectPlacement is the org and workspace a new project joins; both are
+// empty for a project outside any org.
+type projectPlacement struct {
+	Org       string
+	Workspace string
+}
+
+// orgQuotaError refuses a project change that would take its org past a
+// quota.
+type orgQuotaError struct {
+	Org   string
+	What  string // "projects" or "environments"
+	Limit int
+	Would int
+}
+
+func (e orgQuotaError) Error() string {
+	return fmt.Sprintf("organization %s allows %d %s; this change would make %d", e.Org, e.Limit, e.What, e.Would)
+}
+
+func writeOrgQuotaExceeded(w http.ResponseWriter, err error) bool {
+	var quotaErr orgQuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	writeAPIErrorResponse(w, http.StatusConflict, apiErrorResponse{
+		Code:    errorCodeOrgQuota,
+		Message: quotaErr.Error(),
+		Field:   "",
+		Details: map[string]any{"org": quotaErr.Org, "quota": quotaErr.What, "limit": quotaErr.Limit},
+		OpID:    "",
+	})
+	return true
+}
+
+func validateOrgToken(kind, raw string) error {
+	if len(raw) > orgIDMax || !orgIDPattern.MatchString(raw) {
+		return fmt.Errorf("%s %q must be at most %d lowercase letters, digits, and inner dashes", kind, raw, orgIDMax)
+	}
+	return nil
+}
+
+// newOrganization validates req into an org stamped with the current time.
+// The caller fills in the ID and creation time.
+func newOrganization(req orgRequest) (Organization, error) {
+	name := strings.TrimSpace(req.Name)
+	if len(name) > orgNameMax {
+		return Organization{}, fmt.Errorf("name must be at most %d characters", orgNameMax)
+	}
+	if len(req.Workspaces) > maxOrgWorkspaces {
+		return Organization{}, fmt.Errorf("at most %d workspaces", maxOrgWorkspaces)
+	}
+	workspaces := []string{}
+	for _, raw := range req.Workspaces {
+		workspace := strings.TrimSpace(raw)
+		if err := validateOrgToken("workspace", workspace); err != nil {
+			return Organization{}, err
+		}
+		if !slices.Contains(workspaces, workspace) {
+			workspaces = append(workspaces, workspace)
+		}
+	}
+	slices.Sort(workspaces)
+	if req.Quota.MaxProjects < 0 || req.Quota.MaxEnvironments < 0 {
+		return Organization{}, errors.New("quota limits must not be negative")
+	}
+	now := time.Now().UTC()
+	return Organization{
+		ID:         "",
+		Name:       name,
+		Workspaces: workspaces,
+		Quota:      req.Quota,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Revision:   0,
+	}, nil
+}
+
+// orgUsage counts the projects and environments org holds, leaving out
+// skipProjectID.
+func orgUsage(projects []Project, org, skipProjectID string) OrgUsage {
+	usage := OrgUsage{Projects: 0, Environments: 0}
+	for _, project := range projects {
+		if project.Org != org || project.ID == skipProjectID {
+			continue
+		}
+		usage.Projects++
+		usage.Environments += len(project.Spec.Environments)
+	}
+	return usage
+}
+
+// handleOrgs serves /api/orgs, /api/orgs/{org}, and /api/orgs/{org}/projects.
+// withAuth only lets admins change orgs.
+func (a *API) handleOrgs(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "organization data unavailable", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/orgs"), "/")
+	id, projects := strings.CutSuffix(rest, orgProjectsSuffix)
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		a.handleOrgList(w, r)
+	case rest == "" && r.Method == http.MethodPost:
+		a.handleOrgSave(w, r, "")
+	case id == "" || strings.Contains(id, "/"):
+		writeAPIError(w, "not found", http.StatusNotFound)
+	case projects && r.Method == http.MethodGet:
+		a.handleOrgProjects(w, r, id)
+	case projects:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	case r.Method == http.MethodGet:
+		org, ok := a.readOrgOr404(w, r, id)
+		if !ok {
+			return
+		}
+		all, err := a.store.ListProjects(r.Context())
+		if err != nil {
+			writeAPIError(w, "failed to list projects", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, orgResponse{Org: org, Usage: orgUsage(all, org.ID, "")})
+	case r.Method == http.MethodPut:
+		a.handleOrgSave(w, r, id)
+	case r.Method == http.MethodDelete:
+		a.handleOrgDelete(w, r, id)
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleOrgList(w http.ResponseWriter, r *http.Request) {
+	orgs, err := a.store.listOrgs(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to list organizations", http.StatusInternalServerError)
+		return
+	}
+	all, err := a.store.ListProjects(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to list projects", http.StatusInternalServerError)
+		return
+	}
+	out := orgListResponse{Orgs: []orgResponse{}}
+	for _, org := range orgs {
+		if orgVisible(r.Context(), org.ID) {
+			out.Orgs = append(out.Orgs, orgResponse{Org: org, Usage: orgUsage(all, org.ID, "")})
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleOrgSave creates an org when id is empty and replaces org id
+// otherwise. A replacement may not drop a workspace a project still uses.
+func (a *API) handleOrgSave(w http.ResponseWriter, r *http.Request, id string) {
+	var req orgRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	org, err := newOrganization(req)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	status := http.StatusCreated
+	if id == "" {
+		org.ID = strings.TrimSpace(req.ID)
+		if err = validateOrgToken("id", org.ID); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+	} else {
+		existing, ok := a.readOrgOr404(w, r, id)
+		if !ok {
+			return
+		}
+		if used := a.droppedWorkspaceInUse(r.Context(), existing, org.Workspaces); used != "" {
+			writeAPIError(w, "workspace "+used+" still has projects", http.StatusConflict)
+			return
+		}
+		org.ID, org.CreatedAt, org.Revision = existing.ID, existing.CreatedAt, existing.Revision
+		status = http.StatusOK
+	}
+	saved, err := a.store.putOrg(r.Context(), org)
+	var exists orgExistsError
+	switch {
+	case errors.As(err, &exists):
+		writeAPIError(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, jetstream.ErrKeyExists):
+		writeAPIError(w, "organization changed concurrently; retry", http.StatusConflict)
+		return
+	case err != nil:
+		writeAPIError(w, "failed to save organization", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, status, saved)
+}
+
+// droppedWorkspaceInUse names a workspace of existing that workspaces
+// leaves out while a project is still in it, or returns "".
+func (a *API) droppedWorkspaceInUse(ctx context.Context, existing Organization, workspaces []string) string {
+	projects, err := a.store.ListProjects(ctx)
+	if err != nil {
+		return ""
+	}
+	for _, project := range projects {
+		if project.Org == existing.ID && project.Workspace != "" && !slices.Contains(workspaces, project.Workspace) {
+			return project.Workspace
+		}
+	}
+	return ""
+}
+
+func (a *API) handleOrgDelete(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := a.readOrgOr404(w, r, id); !ok {
+		return
+	}
+	projects, err := a.store.ListProjects(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to list projects", http.StatusInternalServerError)
+		return
+	}
+	if usage := orgUsage(projects, id, ""); usage.Projects > 0 {
+		writeAPIError(w, fmt.Sprintf("organization %s still has %d project(s)", id, usage.Projects),
+			http.StatusConflict)
+		return
+	}
+	deleted, err := a.store.deleteOrg(r.Context(), id)
+	if err != nil {
+		writeAPIError(w, "failed to delete organization", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, orgDeletedResponse{ID: id, Deleted: deleted})
+}
+
+// handleOrgProjects serves GET /api/orgs/{org}/projects, optionally narrowed
+// with ?workspace= and the GET /api/projects filter and sort params.
+func (a *API) handleOrgProjects(w http.ResponseWriter, r *http.Request, id string) {
+	org, ok := a.readOrgOr404(w, r, id)
+	if !ok {
+		return
+	}
+	filter, sortKey, err := parseProjectListQuery(r.URL.Query())
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	filter.Org = org.ID
+	projects, err := a.store.ListProjects(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to list projects", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, orgProjectsResponse{
+		Org:      org,
+		Projects: filterAndSortProjects(projects, filter, sortKey),
+	})
+}
+
+// readOrgOr404 reads org id, answering 404 when it does not exist or the
+// request's token belongs to another org.
+func (a *API) readOrgOr404(w http.ResponseWriter, r *http.Request, id string) (Organization, bool) {
+	if !orgVisible(r.Context(), id) {
+		writeAPIError(w, "organization not found", http.StatusNotFound)
+		return Organization{}, false
+	}
+	org, err := a.store.getOrg(r.Context(), id)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		writeAPIError(w, "organization not found", http.StatusNotFound)
+		return Organization{}, false
+	}
+	if err != nil {
+		writeAPIError(w, "failed to read organization", http.StatusInternalServerError)
+		return Organization{}, false
+	}
+	return org, true
+}
+
+// requestPlacementOrWriteError resolves where a project created by r goes:
+// ?org= and ?workspace= when given, else the token's org, else fallback.
+// A token with an org cannot place projects anywhere else.
+func (a *API) requestPlacementOrWriteError(
+	w http.ResponseWriter,
+	r *http.Request,
+	fallback projectPlacement,
+) (projectPlacement, bool) {
+	placement := projectPlacement{
+		Org:       strings.TrimSpace(r.URL.Query().Get("org")),
+		Workspace: strings.TrimSpace(r.URL.Query().Get("workspace")),
+	}
+	tokenOrg := principalOrg(r.Context())
+	switch {
+	case placement.Org == "" && placement.Workspace == "" && tokenOrg == "":
+		placement = fallback
+	case placement.Org == "" && placement.Workspace == "":
+		placement.Org = tokenOrg
+		if fallback.Org == tokenOrg {
+			placement.Workspace = fallback.Workspace
+		}
+	case placement.Org == "":
+		placement.Org = cmp.Or(tokenOrg, fallback.Org)
+	}
+	if placement.Org == "" {
+		if placement.Workspace != "" {
+			writeAPIError(w, "workspace needs an org", http.StatusBadRequest)
+			return projectPlacement{}, false
+		}
+		return placement, true
+	}
+	org, ok := a.readOrgOr404(w, r, placement.Org)
+	if !ok {
+		return projectPlacement{}, false
+	}
+	if placement.Workspace != "" && !slices.Contains(org.Workspaces, placement.Workspace) {
+		writeAPIError(w, fmt.Sprintf("organization %s has no workspace %q", org.ID, placement.Workspace),
+			http.StatusBadRequest)
+		return projectPlacement{}, false
+	}
+	return placement, true
+}
+
+// orgQuotaConflict checks that org stays within its quota once projectID
+// (empty for a new project) runs spec. Projects outside any org have no
+// quota. Concurrent creates may overshoot by the requests in flight.
+func (a *API) orgQuotaConflict(ctx context.Context, orgID, projectID string, spec ProjectSpec) error {
+	if orgID == "" || a.store == nil {
+		return nil
+	}
+	org, err := a.store.getOrg(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("read organization %s: %w", orgID, err)
+	}
+	if org.Quota.MaxProjects == 0 && org.Quota.MaxEnvironments == 0 {
+		return nil
+	}
+	projects, err := a.store.ListProjects(ctx)
+	if err != nil {
+		return fmt.Errorf("list projects: %w", err)
+	}
+	usage := orgUsage(projects, orgID, projectID)
+	usage.Projects++
+	usage.Environments += len(spec.Environments)
+	if limit := org.Quota.MaxProjects; limit > 0 && usage.Projects > limit && projectID == "" {
+		return orgQuotaError{Org: orgID, What: "projects", Limit: limit, Would: usage.Projects}
+	}
+	if limit := org.Quota.MaxEnvironments; limit > 0 && usage.Environments > limit {
+		return orgQuotaError{Org: orgID, What: "environments", Limit: limit, Would: usage.Environments}
+	}
+	return nil
+}
+
+// projectOrgQuotaConflict is the enqueueOp side of orgQuotaConflict: an
+// update may not take its org past the environment quota.
+func (a *API) projectOrgQuotaConflict(
+	ctx context.Context,
+	projectID string,
+	kind OperationKind,
+	spec ProjectSpec,
+) error {
+	if a.store == nil || kind != OpUpdate {
+		return nil
+	}
+	org, err := a.store.projectOrg(ctx, projectID)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read project org: %w", err)
+	}
+	return a.orgQuotaConflict(ctx, org, projectID, spec)
+}
@@ -0,0 +1,148 @@
+//nolint:testpackage,exhaustruct // Org tests drive the internal router with stored orgs and org-scoped tokens.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_OrgsPlaceProjectsEnforceQuotasAndScopeTokens(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	artifacts := NewFSArtifacts(t.TempDir())
+	artifacts.projectOrg = fixture.store.projectOrg
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	t.Setenv(apiAuthEnv, "true")
+	t.Setenv(operatorTokenEnv, "operator-secret")
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	call := func(method, path, token, body string) (int, []byte) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		return resp.StatusCode, buf.Bytes()
+	}
+	create := func(query, name string) (int, opAcceptedResponse, []byte) {
+		t.Helper()
+		status, body := call(http.MethodPost, "/api/projects"+query, "operator-secret",
+			`{"name":"`+name+`","runtime":"go_1.26","environments":{"dev":{}}}`)
+		var out opAcceptedResponse
+		_ = json.Unmarshal(body, &out)
+		return status, out, body
+	}
+
+	orgBody := `{"id":"acme","name":"Acme","workspaces":["payments"],"quota":{"max_projects":1}}`
+	if status, body := call(http.MethodPost, "/api/orgs", "operator-secret", orgBody); status != http.StatusCreated {
+		t.Fatalf("expected the org to be created, got %d %s", status, body)
+	}
+	if status, _ := call(http.MethodPost, "/api/orgs", "operator-secret", orgBody); status != http.StatusConflict {
+		t.Fatalf("expected 409 for a duplicate org, got %d", status)
+	}
+	if status, _, body := create("?org=acme&workspace=ledger", "acme-ledger"); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a workspace the org lacks, got %d %s", status, body)
+	}
+
+	starts, err := fixture.nc.SubscribeSync(natsSubject(subjectProjectOpStart) + ".>")
+	if err != nil {
+		t.Fatalf("subscribe op starts: %v", err)
+	}
+	status, inOrg, body := create("?org=acme&workspace=payments", "acme-orders")
+	if status != http.StatusAccepted || inOrg.Project.Org != "acme" || inOrg.Project.Workspace != "payments" {
+		t.Fatalf("expected a project in acme/payments, got %d %s", status, body)
+	}
+	start, err := starts.NextMsg(5 * time.Second)
+	if err != nil || workerSubjectOrg(start.Subject) != "acme" ||
+		workerSubjectWithoutOrg(start.Subject) != workerSubjectFor(natsSubject(subjectProjectOpStart), "", inOrg.Project.ID) {
+		t.Fatalf("expected the create op published on acme's subject, got %v (%v)", start, err)
+	}
+	_ = starts.Unsubscribe()
+	wantDir := filepath.Join(artifacts.root, artifactOrgsDir, "acme", inOrg.Project.ID)
+	if got := artifacts.ProjectDir(inOrg.Project.ID); got != wantDir {
+		t.Fatalf("expected the project's artifacts under %s, got %s", wantDir, got)
+	}
+	// Another replica, with nothing cached, finds the org in KV.
+	replicaStore, err := newStore(ctx, fixture.js)
+	if err != nil {
+		t.Fatalf("open replica store: %v", err)
+	}
+	replicaArtifacts := NewFSArtifacts(artifacts.root)
+	replicaArtifacts.projectOrg = replicaStore.projectOrg
+	if got := replicaArtifacts.ProjectDir(inOrg.Project.ID); got != wantDir {
+		t.Fatalf("expected another replica to place the project's artifacts under %s, got %s", wantDir, got)
+	}
+	status, _, body = create("?org=acme", "acme-billing")
+	var quotaErr apiErrorResponse
+	if status != http.StatusConflict || json.Unmarshal(body, &quotaErr) != nil || quotaErr.Code != errorCodeOrgQuota {
+		t.Fatalf("expected the project quota to refuse a second project, got %d %s", status, body)
+	}
+	status, outside, body := create("", "outside")
+	if status != http.StatusAccepted || outside.Project.Org != "" {
+		t.Fatalf("expected a project outside any org, got %d %s", status, body)
+	}
+
+	_, orgToken, err := api.store.createAPIToken(ctx, "acme-ci", apiRoleDeveloper, nil, "acme")
+	if err != nil {
+		t.Fatalf("create org token: %v", err)
+	}
+	status, body = call(http.MethodGet, "/api/projects", orgToken, "")
+	var listed []Project
+	if status != http.StatusOK || json.Unmarshal(body, &listed) != nil || len(listed) != 1 ||
+		listed[0].ID != inOrg.Project.ID {
+		t.Fatalf("expected the org token to list only acme's project, got %d %s", status, body)
+	}
+	status, _ = call(http.MethodGet, "/api/projects/"+outside.Project.ID, orgToken, "")
+	if status != http.StatusNotFound {
+		t.Fatalf("expected another org's project to be hidden, got %d", status)
+	}
+	if status, _ = call(http.MethodGet, "/api/ops/"+outside.Op.ID, orgToken, ""); status != http.StatusNotFound {
+		t.Fatalf("expected another org's op to be hidden, got %d", status)
+	}
+	if status, _ = call(http.MethodGet, "/api/projects/"+inOrg.Project.ID, orgToken, ""); status != http.StatusOK {
+		t.Fatalf("expected the org token to read its own project, got %d", status)
+	}
+	if status, _ = call(http.MethodGet, "/api/tokens", orgToken, ""); status != http.StatusForbidden {
+		t.Fatalf("expected instance-wide endpoints to refuse an org token, got %d", status)
+	}
+
+	if status, body = call(http.MethodDelete, "/api/orgs/acme", "operator-secret", ""); status != http.StatusConflict {
+		t.Fatalf("expected 409 deleting an org that has projects, got %d %s", status, body)
+	}
+	status, body = call(http.MethodGet, "/api/orgs/acme", "operator-secret", "")
+	var got orgResponse
+	if status != http.StatusOK || json.Unmarshal(body, &got) != nil || got.Usage.Projects != 1 ||
+		got.Usage.Environments != 1 {
+		t.Fatalf("expected acme's usage, got %d %s", status, body)
+	}
+}
+
+func TestOpEventJournalSubjectCarriesTheOrg(t *testing.T) {
+	t.Parallel()
+
+	prefix := natsSubject(subjectOpEventsPrefix)
+	if got := opEventJournalSubject("acme", "op-1"); got != prefix+"acme.op-1" {
+		t.Fatalf("expected the org in the subject, got %q", got)
+	}
+	if got := opEventJournalSubject("", "op-1"); got != prefix+"op-1" {
+		t.Fatalf("expected no org segment outside an org, got %q", got)
+	}
+	if got := opEventJournalSubject("acme", "op.1"); got != "" {
+		t.Fatalf("expected an op ID that is not one token to be skipped, got %q", got)
+	}
+}
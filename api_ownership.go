@@ -0,0 +1,179 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxProjectOwners         = 20
+	maxProjectTeams          = 20
+	maxProjectEscalation     = 10
+	maxOwnershipNameLength   = 128
+	maxOwnershipEmailLength  = 254
+	maxOwnershipChatLength   = 128
+	maxOwnershipOnCallLength = 256
+)
+
+type projectOwnershipRequest struct {
+	Owners     []ProjectOwner `json:"owners"`
+	Teams      []string       `json:"teams"`
+	OnCall     string         `json:"on_call"`
+	Escalation []string       `json:"escalation"`
+}
+
+type projectOwnershipResponse struct {
+	ProjectID string           `json:"project_id"`
+	Ownership ProjectOwnership `json:"ownership"`
+}
+
+// handleProjectOwnership reads (GET) or replaces (PUT) who owns a project.
+// Ownership is not part of the spec, so changing it starts no op.
+func (a *API) handleProjectOwnership(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "ownership data unavailable", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != projectRelPathPartsMin || parts[1] != "ownership" {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	if projectID == "" {
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, projectOwnershipResponse{ProjectID: project.ID, Ownership: project.Ownership})
+		return
+	}
+	if err := a.authorizeProjectChange(r.Context(), project, projectChangeOwnership); err != nil {
+		writeProjectAccessDenied(w, err)
+		return
+	}
+
+	var req projectOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	ownership, err := newProjectOwnership(req)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	project, err = a.store.setProjectOwnership(r.Context(), projectID, ownership)
+	if err != nil {
+		writeAPIError(w, "failed to save ownership", http.StatusInternalServerError)
+		return
+	}
+	appLoggerForProcess().Source("api").Infof(
+		"ownership updated project=%s owners=%d on_call=%q", project.ID, len(ownership.Owners), ownership.OnCall,
+	)
+	writeJSON(w, http.StatusOK, projectOwnershipResponse{ProjectID: project.ID, Ownership: project.Ownership})
+}
+
+// newProjectOwnership validates req and trims its fields. An empty request
+// clears the ownership.
+func newProjectOwnership(req projectOwnershipRequest) (ProjectOwnership, error) {
+	ownership := ProjectOwnership{
+		Owners:     make([]ProjectOwner, 0, len(req.Owners)),
+		Teams:      nil,
+		OnCall:     strings.TrimSpace(req.OnCall),
+		Escalation: make([]string, 0, len(req.Escalation)),
+		UpdatedAt:  time.Now().UTC(),
+	}
+	if len(req.Owners) > maxProjectOwners {
+		return ProjectOwnership{}, fmt.Errorf("at most %d owners", maxProjectOwners)
+	}
+	for i, owner := range req.Owners {
+		normalized, err := normalizeProjectOwner(owner)
+		if err != nil {
+			return ProjectOwnership{}, fmt.Errorf("owners[%d]: %w", i, err)
+		}
+		ownership.Owners = append(ownership.Owners, normalized)
+	}
+	teams, err := normalizeTeams(req.Teams)
+	if err != nil {
+		return ProjectOwnership{}, err
+	}
+	ownership.Teams = teams
+	if utf8.RuneCountInString(ownership.OnCall) > maxOwnershipOnCallLength {
+		return ProjectOwnership{}, errors.New("on_call is too long")
+	}
+	if len(req.Escalation) > maxProjectEscalation {
+		return ProjectOwnership{}, fmt.Errorf("at most %d escalation contacts", maxProjectEscalation)
+	}
+	for i, contact := range req.Escalation {
+		contact = strings.TrimSpace(contact)
+		if contact == "" || utf8.RuneCountInString(contact) > maxOwnershipOnCallLength {
+			return ProjectOwnership{}, fmt.Errorf("escalation[%d] must be 1-%d characters", i, maxOwnershipOnCallLength)
+		}
+		ownership.Escalation = append(ownership.Escalation, contact)
+	}
+	if len(ownership.Owners) == 0 && len(ownership.Teams) == 0 && ownership.OnCall == "" &&
+		len(ownership.Escalation) == 0 {
+		return ProjectOwnership{Owners: nil, Teams: nil, OnCall: "", Escalation: nil, UpdatedAt: time.Time{}}, nil
+	}
+	return ownership, nil
+}
+
+// normalizeTeams checks team names, which follow the project name rules, and
+// drops duplicates. Projects and API tokens share it.
+func normalizeTeams(raw []string) ([]string, error) {
+	if len(raw) > maxProjectTeams {
+		return nil, fmt.Errorf("at most %d teams", maxProjectTeams)
+	}
+	var teams []string
+	for i, team := range raw {
+		team = strings.ToLower(strings.TrimSpace(team))
+		if len(team) > maxOwnershipNameLength || !projectNameRe.MatchString(team) {
+			return nil, fmt.Errorf("teams[%d] must match %s", i, projectNameRe.String())
+		}
+		if !slices.Contains(teams, team) {
+			teams = append(teams, team)
+		}
+	}
+	return teams, nil
+}
+
+func normalizeProjectOwner(owner ProjectOwner) (ProjectOwner, error) {
+	owner = ProjectOwner{
+		Name:  strings.TrimSpace(owner.Name),
+		Email: strings.TrimSpace(owner.Email),
+		Chat:  strings.TrimSpace(owner.Chat),
+	}
+	if owner.Name == "" || utf8.RuneCountInString(owner.Name) > maxOwnershipNameLength {
+		return owner, fmt.Errorf("name must be 1-%d characters", maxOwnershipNameLength)
+	}
+	if owner.Email == "" && owner.Chat == "" {
+		return owner, errors.New("email or chat required")
+	}
+	if owner.Email != "" {
+		addr, err := mail.ParseAddress(owner.Email)
+		if err != nil || addr.Address != owner.Email || len(owner.Email) > maxOwnershipEmailLength {
+			return owner, fmt.Errorf("invalid email %q", owner.Email)
+		}
+	}
+	if owner.Chat != "" && (len(owner.Chat) > maxOwnershipChatLength || !chatHandleRe.MatchString(owner.Chat)) {
+		return owner, fmt.Errorf("invalid chat handle %q", owner.Chat)
+	}
+	return owner, nil
+}
@@ -0,0 +1,119 @@
+//nolint:testpackage,exhaustruct // Ownership tests seed projects through the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_ProjectOwnershipIsEditableWithoutAnOp(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-ownership"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-ownership-create", OpCreate, workerRuntimeSpec("owned"))
+	before, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+
+	hub := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
+	fixture.store.setOpEvents(hub)
+	_, events, _, unsubscribe := hub.subscribeProject(projectID, "")
+	defer unsubscribe()
+	api := &API{store: fixture.store, nc: fixture.nc, opEvents: hub, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	put := func(body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL+"/api/projects/"+projectID+"/ownership",
+			strings.NewReader(body))
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("put ownership: %v", err)
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(raw)
+	}
+
+	for body, wantErr := range map[string]string{
+		`{"owners":[{"name":"","email":"sam@example.com"}]}`:           "owners[0]: name must be",
+		`{"owners":[{"name":"Sam"}]}`:                                  "email or chat required",
+		`{"owners":[{"name":"Sam","email":"Sam <sam@x.io>"}]}`:         "invalid email",
+		`{"owners":[{"name":"Sam","chat":"@sam smith"}]}`:              "invalid chat handle",
+		`{"owners":[{"name":"Sam","chat":"@sam"}],"escalation":[" "]}`: "escalation[0]",
+		`{"teams":["payments","Web Team"]}`:                            "teams[1] must match",
+	} {
+		if status, msg := put(body); status != http.StatusBadRequest || !strings.Contains(msg, wantErr) {
+			t.Fatalf("PUT %s: expected 400 %q, got %d %q", body, wantErr, status, msg)
+		}
+	}
+
+	status, _ := put(`{
+		"owners": [{"name": " Payments Team ", "email": "payments@example.com", "chat": "#payments"}],
+		"on_call": "pagerduty:payments-primary",
+		"escalation": ["@lead", "eng-manager@example.com"]
+	}`)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	select {
+	case record := <-events:
+		if record.Name != projectEventOwnership || record.Payload.Ownership == nil ||
+			record.Payload.Ownership.Owners[0].Name != "Payments Team" {
+			t.Fatalf("unexpected ownership event: %s %+v", record.Name, record.Payload.Ownership)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected project.ownership event")
+	}
+
+	project, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if project.Status != before.Status || project.Spec.Name != before.Spec.Name {
+		t.Fatalf("expected ownership edits to leave status and spec alone, got %+v", project)
+	}
+
+	// A later status write keeps the ownership and carries it to subscribers.
+	project.Status.Phase = projectPhaseError
+	if err = fixture.store.PutProject(ctx, project); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	record := <-events
+	if record.Name != projectEventStatus || record.Payload.Ownership == nil ||
+		record.Payload.Ownership.OnCall != "pagerduty:payments-primary" {
+		t.Fatalf("expected status event with ownership, got %s %+v", record.Name, record.Payload.Ownership)
+	}
+
+	resp, err := srv.Client().Get(srv.URL + "/api/projects/" + projectID + "/overview")
+	if err != nil {
+		t.Fatalf("get overview: %v", err)
+	}
+	var overview struct {
+		Overview projectOverview `json:"overview"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&overview)
+	resp.Body.Close()
+	if err != nil || len(overview.Overview.Ownership.Escalation) != 2 ||
+		overview.Overview.Ownership.Owners[0].Chat != "#payments" {
+		t.Fatalf("expected ownership in the overview, got %+v (%v)", overview.Overview.Ownership, err)
+	}
+
+	if status, _ = put(`{}`); status != http.StatusOK {
+		t.Fatalf("expected clearing ownership to succeed, got %d", status)
+	}
+	if project, _ = fixture.store.GetProject(ctx, projectID); !project.Ownership.UpdatedAt.IsZero() ||
+		project.Status.Phase != projectPhaseError {
+		t.Fatalf("expected cleared ownership and kept status, got %+v", project)
+	}
+}
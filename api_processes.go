@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/nats-io/nats.go/jetstream"
@@ -47,18 +48,23 @@ type transitionLifecycleContext struct {
 
 func (a *API) handleDeploymentEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
 		return
 	}
 
 	var evt DeploymentEvent
-	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+	if err = json.NewDecoder(r.Body).Decode(&evt); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
 		return
 	}
 	evt.ProjectID = strings.TrimSpace(evt.ProjectID)
 	if evt.ProjectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
 		return
 	}
 	env := normalizeEnvironmentName(evt.Environment)
@@ -66,7 +72,7 @@ func (a *API) handleDeploymentEvents(w http.ResponseWriter, r *http.Request) {
 		env = defaultDeployEnvironment
 	}
 	if env != defaultDeployEnvironment {
-		http.Error(
+		writeAPIError(
 			w,
 			"deployment endpoint supports dev only; use promotion/release for higher environments",
 			http.StatusBadRequest,
@@ -77,25 +83,24 @@ func (a *API) handleDeploymentEvents(w http.ResponseWriter, r *http.Request) {
 	project, err := a.store.GetProject(r.Context(), evt.ProjectID)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
-			http.Error(w, "not found", http.StatusNotFound)
+			writeAPIError(w, "not found", http.StatusNotFound)
 			return
 		}
-		http.Error(w, "failed to read project", http.StatusInternalServerError)
+		writeAPIError(w, "failed to read project", http.StatusInternalServerError)
 		return
 	}
 
-	op, err := a.enqueueOp(
-		r.Context(),
-		OpDeploy,
-		project.ID,
-		project.Spec,
-		deployOpRunOptions(env),
-	)
+	opts, err := deployOpRunOptions(env).withExecution(execution).withFreezeOverride(r, evt.FreezeOverride)
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+	op, err := a.enqueueOp(r.Context(), OpDeploy, project.ID, project.Spec, opts)
 	if err != nil {
 		if writeAsyncOpError(w, err) {
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	project, _ = a.store.GetProject(r.Context(), project.ID)
@@ -108,18 +113,23 @@ func (a *API) handleDeploymentEvents(w http.ResponseWriter, r *http.Request) {
 
 func (a *API) handlePromotionPreviewEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var evt PromotionEvent
 	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
 		return
 	}
 	projectID := strings.TrimSpace(evt.ProjectID)
 	if projectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
+		return
+	}
+	strategy, err := promotionStrategy(evt)
+	if err != nil {
+		writeBadRequest(w, err)
 		return
 	}
 
@@ -129,6 +139,11 @@ func (a *API) handlePromotionPreviewEvents(w http.ResponseWriter, r *http.Reques
 		evt.FromEnv,
 		evt.ToEnv,
 	)
+	if err == nil && !slices.ContainsFunc(preview.Blockers, func(b TransitionPreviewBlocker) bool {
+		return b.Code == transitionBlockerInvalidMove
+	}) {
+		err = a.addStrategyPreview(r.Context(), &preview, evt, strategy)
+	}
 	if err != nil {
 		writeTransitionError(w, err)
 		return
@@ -138,17 +153,26 @@ func (a *API) handlePromotionPreviewEvents(w http.ResponseWriter, r *http.Reques
 
 func (a *API) handlePromotionEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var evt PromotionEvent
 	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
 		return
 	}
 	if strings.TrimSpace(evt.ProjectID) == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
+		return
+	}
+	strategy, err := promotionStrategy(evt)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if len(evt.ToEnvs) > 0 {
+		a.handlePromotionFanout(w, r, evt)
 		return
 	}
 	op, project, err := a.runTransitionLifecycle(
@@ -157,6 +181,9 @@ func (a *API) handlePromotionEvents(w http.ResponseWriter, r *http.Request) {
 		evt.FromEnv,
 		evt.ToEnv,
 		false,
+		strategy,
+		evt.VulnerabilityOverride,
+		evt.FreezeOverride,
 	)
 	if err != nil {
 		writeTransitionError(w, err)
@@ -171,17 +198,22 @@ func (a *API) handlePromotionEvents(w http.ResponseWriter, r *http.Request) {
 
 func (a *API) handleReleaseEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var evt ReleaseEvent
 	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
 		return
 	}
 	if strings.TrimSpace(evt.ProjectID) == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
+		return
+	}
+	strategy, err := parseTransitionStrategy(evt.Strategy, evt.Weight, false)
+	if err != nil {
+		writeBadRequest(w, err)
 		return
 	}
 	toEnv := evt.ToEnv
@@ -195,6 +227,9 @@ func (a *API) handleReleaseEvents(w http.ResponseWriter, r *http.Request) {
 		evt.FromEnv,
 		toEnv,
 		true,
+		strategy,
+		evt.VulnerabilityOverride,
+		evt.FreezeOverride,
 	)
 	if err != nil {
 		writeTransitionError(w, err)
@@ -209,7 +244,7 @@ func (a *API) handleReleaseEvents(w http.ResponseWriter, r *http.Request) {
 
 func (a *API) handleRollbackPreviewEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
@@ -227,7 +262,12 @@ func (a *API) handleRollbackPreviewEvents(w http.ResponseWriter, r *http.Request
 
 func (a *API) handleRollbackEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
 		return
 	}
 
@@ -241,7 +281,7 @@ func (a *API) handleRollbackEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if !lifecycle.preview.Ready {
-		writeJSON(w, http.StatusBadRequest, lifecycle.preview)
+		writeRollbackNotReady(w, lifecycle.preview)
 		return
 	}
 
@@ -255,13 +295,13 @@ func (a *API) handleRollbackEvents(w http.ResponseWriter, r *http.Request) {
 			lifecycle.release.ID,
 			lifecycle.scope,
 			lifecycle.override,
-		),
+		).withExecution(execution),
 	)
 	if err != nil {
 		if writeAsyncOpError(w, err) {
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	latestProject, readErr := a.store.GetProject(r.Context(), lifecycle.project.ID)
@@ -278,12 +318,12 @@ func (a *API) handleRollbackEvents(w http.ResponseWriter, r *http.Request) {
 func decodeRollbackEvent(w http.ResponseWriter, r *http.Request) (RollbackEvent, bool) {
 	var evt RollbackEvent
 	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
 		return RollbackEvent{}, false
 	}
 	evt.ProjectID = strings.TrimSpace(evt.ProjectID)
 	if evt.ProjectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
 		return RollbackEvent{}, false
 	}
 	return evt, true
@@ -819,16 +859,36 @@ func (e transitionRequestError) Error() string {
 	return e.msg
 }
 
+// rollbackNotReadyResponse is the 400 body of a rollback its preview blocks:
+// the preview, with the error envelope's code and message added.
+type rollbackNotReadyResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	RollbackPreviewResponse
+}
+
+func writeRollbackNotReady(w http.ResponseWriter, preview RollbackPreviewResponse) {
+	message := "rollback is not ready"
+	if len(preview.Blockers) > 0 {
+		message = preview.Blockers[0].Message
+	}
+	writeJSON(w, http.StatusBadRequest, rollbackNotReadyResponse{
+		Code:                    errorCodeRollbackBlocked,
+		Message:                 message,
+		RollbackPreviewResponse: preview,
+	})
+}
+
 func writeTransitionError(w http.ResponseWriter, err error) {
-	if writeAsyncOpError(w, err) {
+	if writeAsyncOpError(w, err) || writeVulnerabilityBudgetError(w, err) || writeReleaseApprovalPending(w, err) {
 		return
 	}
 	var reqErr transitionRequestError
 	if errors.As(err, &reqErr) {
-		http.Error(w, reqErr.msg, reqErr.status)
+		writeAPIError(w, reqErr.msg, reqErr.status)
 		return
 	}
-	http.Error(w, err.Error(), http.StatusInternalServerError)
+	writeAPIError(w, err.Error(), http.StatusInternalServerError)
 }
 
 func requestError(status int, msg string) error {
@@ -844,7 +904,14 @@ func (a *API) runTransitionLifecycle(
 	fromEnvRaw string,
 	toEnvRaw string,
 	releaseOnly bool,
+	strategy transitionStrategy,
+	vulnOverride *VulnerabilityOverrideRequest,
+	freezeOverride *FreezeOverrideRequest,
 ) (Operation, Project, error) {
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		return Operation{}, Project{}, requestError(http.StatusBadRequest, err.Error())
+	}
 	lifecycle, err := a.resolveTransitionLifecycleContext(
 		r.Context(),
 		projectID,
@@ -855,17 +922,41 @@ func (a *API) runTransitionLifecycle(
 	if err != nil {
 		return Operation{}, Project{}, err
 	}
+	if err = a.strategyConflict(lifecycle.project.ID, lifecycle.toEnv, strategy); err != nil {
+		return Operation{}, Project{}, err
+	}
+	opts, err := transitionOpRunOptions(lifecycle.fromEnv, lifecycle.toEnv, lifecycle.stage).
+		withStrategy(strategy).
+		withExecution(execution).
+		withFreezeOverride(r, freezeOverride)
+	if err != nil {
+		return Operation{}, Project{}, err
+	}
+	override, overridden, err := a.transitionVulnerabilityGate(r, lifecycle, vulnOverride)
+	if err != nil {
+		return Operation{}, Project{}, err
+	}
+	if overridden {
+		opts.vulnOverride = &override
+	}
+	if opts, err = a.requestReleaseApproval(r, lifecycle, opts); err != nil {
+		return Operation{}, Project{}, err
+	}
 
 	op, err := a.enqueueOp(
 		r.Context(),
 		lifecycle.kind,
 		lifecycle.project.ID,
 		lifecycle.spec,
-		transitionOpRunOptions(lifecycle.fromEnv, lifecycle.toEnv, lifecycle.stage),
+		opts,
 	)
 	if err != nil {
 		return Operation{}, Project{}, err
 	}
+	if !execution.DryRun {
+		a.auditVulnerabilityOverride(op)
+		a.recordApprovalRelease(r.Context(), op)
+	}
 	latestProject, readErr := a.store.GetProject(r.Context(), lifecycle.project.ID)
 	if readErr == nil {
 		lifecycle.project = latestProject
@@ -987,6 +1078,7 @@ func (a *API) runTransitionPreviewLifecycle(
 		Gates:         []TransitionPreviewGate{},
 		Blockers:      []TransitionPreviewBlocker{},
 		RolloutPlan:   transitionRolloutPlan(),
+		TrafficSplit:  nil,
 	}
 
 	blockersByCode := map[string]TransitionPreviewBlocker{}
@@ -1019,6 +1111,9 @@ func (a *API) runTransitionPreviewLifecycle(
 		targetReleaseFound: false,
 		sourceRelease:      nil,
 		targetRelease:      nil,
+		vulnerability:      vulnerabilityCheck{},
+		freeze:             environmentFreezeStatus{},
+		approval:           releaseApprovalCheck{release: false, required: 0, approval: nil},
 	}
 	if transitionErr != nil {
 		addTransitionPreviewBlocker(blockersByCode, &blockerOrder, TransitionPreviewBlocker{
@@ -1048,7 +1143,12 @@ func (a *API) runTransitionPreviewLifecycle(
 
 	preview.Blockers = orderedTransitionPreviewBlockers(blockersByCode, blockerOrder)
 	preview.ChangeSummary = transitionPreviewChangeSummary(preview, details)
-	preview.Gates = transitionPreviewGates(blockersByCode, details.targetReleaseFound)
+	preview.Gates = append(
+		transitionPreviewGates(blockersByCode, details.targetReleaseFound),
+		approvalPreviewGate(details.approval),
+		freezePreviewGate(details.freeze),
+		vulnerabilityPreviewGate(details.vulnerability),
+	)
 	return preview, nil
 }
 
@@ -1061,6 +1161,9 @@ type transitionPreviewDetails struct {
 	targetReleaseFound bool
 	sourceRelease      *TransitionPreviewRelease
 	targetRelease      *TransitionPreviewRelease
+	vulnerability      vulnerabilityCheck
+	freeze             environmentFreezeStatus
+	approval           releaseApprovalCheck
 }
 
 func (a *API) addActiveOperationPreviewBlocker(
@@ -1124,6 +1227,9 @@ func (a *API) resolveTransitionPreviewDetails(
 		targetReleaseFound: false,
 		sourceRelease:      nil,
 		targetRelease:      nil,
+		vulnerability:      vulnerabilityCheck{},
+		freeze:             environmentFreezeStatus{},
+		approval:           releaseApprovalCheck{release: false, required: 0, approval: nil},
 	}
 
 	sourceRelease, found, err := a.store.getProjectCurrentRelease(ctx, project.ID, resolvedFromEnv)
@@ -1176,7 +1282,36 @@ func (a *API) resolveTransitionPreviewDetails(
 	if err != nil {
 		return details, fmt.Errorf("failed to read target rendered image: %w", err)
 	}
-	return details, nil
+
+	details.vulnerability, err = a.checkVulnerabilityBudget(project.ID, details.sourceImage)
+	if err != nil {
+		return details, fmt.Errorf("failed to check vulnerability budget: %w", err)
+	}
+	addVulnerabilityPreviewBlocker(details.vulnerability, blockersByCode, blockerOrder)
+	details.freeze, err = a.addFreezePreviewBlocker(ctx, project.ID, spec, resolvedToEnv, blockersByCode, blockerOrder)
+	if err != nil {
+		return details, err
+	}
+	details.approval, err = a.checkReleaseApproval(
+		ctx, project.ID, kind, resolvedFromEnv, resolvedToEnv, details.sourceImage)
+	return details, err
+}
+
+func addVulnerabilityPreviewBlocker(
+	check vulnerabilityCheck,
+	blockersByCode map[string]TransitionPreviewBlocker,
+	blockerOrder *[]string,
+) {
+	if len(check.exceeded) == 0 {
+		return
+	}
+	addTransitionPreviewBlocker(blockersByCode, blockerOrder, TransitionPreviewBlocker{
+		Code:    transitionBlockerVulnBudget,
+		Message: fmt.Sprintf("Image %s exceeds the vulnerability budget.", check.image),
+		Why:     "Over budget: " + strings.Join(check.exceeded, ", ") + ".",
+		NextAction: "Fix the findings and rebuild, or have an operator confirm with " +
+			"vulnerability_override and a justification.",
+	})
 }
 
 func addTransitionPreviewBlocker(
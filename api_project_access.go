@@ -0,0 +1,193 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Project access: with API auth on, a project whose ownership names owners or
+// teams only takes changes from tokens named after one of the owners or
+// carrying one of the teams. Admins may still act on any project; doing so
+// as a non-owner is logged and kept in the project's access audit log.
+////////////////////////////////////////////////////////////////////////////////
+
+// projectChangeOwnership is the action checked when ownership itself is
+// edited, so a non-owner cannot add themselves.
+const projectChangeOwnership = "ownership"
+
+// projectAccessError refuses a change by a principal that is neither an
+// owner nor on an owning team.
+type projectAccessError struct {
+	ProjectID string
+	Action    string
+	Identity  apiPrincipal
+	Ownership ProjectOwnership
+}
+
+func (e projectAccessError) Error() string {
+	return fmt.Sprintf(
+		"%s %q is not an owner of project %s and shares none of its teams; only owners or an admin can %s it",
+		e.Identity.Role, e.Identity.Name, e.ProjectID, e.Action,
+	)
+}
+
+func writeProjectAccessDenied(w http.ResponseWriter, err error) bool {
+	var accessErr projectAccessError
+	if !errors.As(err, &accessErr) {
+		return false
+	}
+	owners := make([]string, 0, len(accessErr.Ownership.Owners))
+	for _, owner := range accessErr.Ownership.Owners {
+		owners = append(owners, owner.Name)
+	}
+	writeErrorDetails(w, http.StatusForbidden, errorCodeAccessDenied, map[string]any{
+		"accepted":       false,
+		"reason":         accessErr.Error(),
+		"project_id":     accessErr.ProjectID,
+		"requested_kind": accessErr.Action,
+		"identity": map[string]any{
+			"name":  accessErr.Identity.Name,
+			"role":  accessErr.Identity.Role,
+			"teams": accessErr.Identity.Teams,
+		},
+		"owners":    owners,
+		"teams":     accessErr.Ownership.Teams,
+		"next_step": "ask an owner or an admin to make the change, or to add your team to the project ownership",
+	})
+	return true
+}
+
+// projectAccessGuardsOperation reports whether kind changes what a project
+// runs. CI and cleanups only touch build outputs and stay open to any
+// developer.
+func projectAccessGuardsOperation(kind OperationKind) bool {
+	switch kind {
+	case OpUpdate, OpDelete, OpDeploy, OpPromote, OpRelease, OpRollback, OpVarRollout, OpPromoteFanout:
+		return true
+	case OpCreate, OpCI, OpCleanup, OpRuntimeUpgrade, OpWebhookRefresh:
+		return false
+	default:
+		return false
+	}
+}
+
+// projectAccessConflict is the enqueueOp side of authorizeProjectChange. It
+// only reads the project when a request principal could be refused.
+func (a *API) projectAccessConflict(ctx context.Context, projectID string, kind OperationKind) error {
+	if a.store == nil || !projectAccessGuardsOperation(kind) {
+		return nil
+	}
+	if _, ok := requestPrincipal(ctx); !ok {
+		return nil
+	}
+	project, err := a.store.GetProject(ctx, projectID)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read project ownership: %w", err)
+	}
+	return a.authorizeProjectChange(ctx, project, string(kind))
+}
+
+// authorizeProjectChange lets the request principal in ctx change project,
+// or returns a projectAccessError. Internal callers carry no principal and
+// are not checked, and neither is anyone while auth is off or the project
+// names no owners or teams.
+func (a *API) authorizeProjectChange(ctx context.Context, project Project, action string) error {
+	principal, ok := requestPrincipal(ctx)
+	if !ok {
+		return nil
+	}
+	ownership := project.Ownership
+	if len(ownership.Owners) == 0 && len(ownership.Teams) == 0 {
+		return nil
+	}
+	if ownership.admits(principal) {
+		return nil
+	}
+	if principal.Role.allows(apiRoleAdmin) {
+		a.auditAdminOverride(project.ID, action, principal)
+		return nil
+	}
+	return projectAccessError{
+		ProjectID: project.ID,
+		Action:    action,
+		Identity:  principal,
+		Ownership: ownership,
+	}
+}
+
+// admits matches the principal's name against the owners' names and emails,
+// and its teams against the owning teams.
+func (o ProjectOwnership) admits(principal apiPrincipal) bool {
+	for _, owner := range o.Owners {
+		if strings.EqualFold(principal.Name, owner.Name) ||
+			(owner.Email != "" && strings.EqualFold(principal.Name, owner.Email)) {
+			return true
+		}
+	}
+	for _, team := range principal.Teams {
+		if slices.Contains(o.Teams, team) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *API) auditAdminOverride(projectID, action string, principal apiPrincipal) {
+	appLoggerForProcess().Source("api").Warnf(
+		"admin override project=%s action=%s actor=%q token=%s", projectID, action, principal.Name, principal.TokenID,
+	)
+	a.appendProjectAuditLine(projectID, "access", fmt.Sprintf(
+		"%s admin override action=%s actor=%q token=%s",
+		time.Now().UTC().Format(time.RFC3339),
+		action,
+		principal.Name,
+		principal.TokenID,
+	))
+}
+
+// requestPrincipal returns the authenticated principal of an API request.
+func requestPrincipal(ctx context.Context) (apiPrincipal, bool) {
+	if !apiAuthEnabled() {
+		return apiPrincipal{}, false
+	}
+	principal, ok := ctx.Value(apiPrincipalKey{}).(apiPrincipal)
+	return principal, ok
+}
+
+// requestActor names who made a request: the principal while auth is on,
+// else the name the request gave.
+func requestActor(ctx context.Context, given string) string {
+	if principal, ok := requestPrincipal(ctx); ok {
+		return principal.Name
+	}
+	return strings.TrimSpace(given)
+}
+
+// authorizeProjectChangeOrWriteError is authorizeProjectChange for handlers:
+// it writes the 403, or a 500, itself.
+func (a *API) authorizeProjectChangeOrWriteError(
+	w http.ResponseWriter,
+	r *http.Request,
+	project Project,
+	action string,
+) bool {
+	err := a.authorizeProjectChange(r.Context(), project, action)
+	if err == nil {
+		return true
+	}
+	if !writeProjectAccessDenied(w, err) {
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+//nolint:testpackage,exhaustruct // Access tests drive the internal router with stored tokens and ownership.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAPI_ProjectAccessFollowsOwnership(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	api := fixture.api
+	t.Setenv(apiAuthEnv, "true")
+	t.Setenv(operatorTokenEnv, "operator-secret")
+
+	mint := func(name string, teams ...string) string {
+		t.Helper()
+		_, value, err := api.store.createAPIToken(ctx, name, apiRoleDeveloper, teams, "")
+		if err != nil {
+			t.Fatalf("create %s token: %v", name, err)
+		}
+		return value
+	}
+	owner := mint("sam@example.com")
+	teammate := mint("ci-payments", "payments")
+	outsider := mint("dev-laptop", "web")
+	ownership := ProjectOwnership{
+		Owners: []ProjectOwner{{Name: "Sam", Email: "sam@example.com"}},
+		Teams:  []string{"payments"},
+	}
+	if _, err := api.store.setProjectOwnership(ctx, fixture.projectID, ownership); err != nil {
+		t.Fatalf("set ownership: %v", err)
+	}
+
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	call := func(method, path, token string, body any) (int, []byte) {
+		t.Helper()
+		raw, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, bytes.NewReader(raw))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		return resp.StatusCode, buf.Bytes()
+	}
+	ownershipPath := "/api/projects/" + fixture.projectID + "/ownership"
+	ownershipBody := projectOwnershipRequest{Owners: ownership.Owners, Teams: []string{"payments", "web"}}
+	deploy := DeploymentEvent{ProjectID: fixture.projectID, Environment: "dev"}
+
+	status, body := call(http.MethodPost, "/api/events/deployment", outsider, deploy)
+	var denied struct {
+		Accepted      bool     `json:"accepted"`
+		RequestedKind string   `json:"requested_kind"`
+		Owners        []string `json:"owners"`
+		Teams         []string `json:"teams"`
+		NextStep      string   `json:"next_step"`
+	}
+	if status != http.StatusForbidden || json.Unmarshal(body, &denied) != nil || denied.Accepted ||
+		denied.RequestedKind != string(OpDeploy) || len(denied.Owners) != 1 || denied.Teams[0] != "payments" ||
+		denied.NextStep == "" {
+		t.Fatalf("expected a 403 naming the owners, got %d %s", status, body)
+	}
+	if status, body = call(http.MethodPut, ownershipPath, outsider, ownershipBody); status != http.StatusForbidden {
+		t.Fatalf("expected a non-owner to be refused an ownership change, got %d %s", status, body)
+	}
+	if status, body = call(http.MethodPut, ownershipPath, teammate, ownershipBody); status != http.StatusOK {
+		t.Fatalf("expected a teammate to edit ownership, got %d %s", status, body)
+	}
+
+	ownershipBody.Teams = []string{"payments"}
+	if status, body = call(http.MethodPut, ownershipPath, "operator-secret", ownershipBody); status != http.StatusOK {
+		t.Fatalf("expected the admin override, got %d %s", status, body)
+	}
+	audit, err := os.ReadFile(api.projectAuditLogPath(fixture.projectID, "access"))
+	if err != nil || !strings.Contains(string(audit), `admin override action=ownership actor="operator"`) {
+		t.Fatalf("expected the admin override in the access log, got %q (%v)", audit, err)
+	}
+
+	if status, body = call(http.MethodPost, "/api/events/deployment", owner, deploy); status != http.StatusAccepted {
+		t.Fatalf("expected an owner matched by email to deploy, got %d %s", status, body)
+	}
+}
+
+// projectAccessCaller is a fixture for the ownership checks of one project:
+// an owner, a non-owner developer, and an admin token, and a call helper
+// returning the status and body.
+type projectAccessCaller struct {
+	owner, outsider, admin string
+	call                   func(method, path, token string, body any) (int, []byte)
+}
+
+func newProjectAccessCaller(t *testing.T, api *API, projectID string) (projectAccessCaller, func()) {
+	t.Helper()
+	ctx := context.Background()
+	t.Setenv(apiAuthEnv, "true")
+	mint := func(name string, role apiRole) string {
+		t.Helper()
+		_, value, err := api.store.createAPIToken(ctx, name, role, nil, "")
+		if err != nil {
+			t.Fatalf("create %s token: %v", name, err)
+		}
+		return value
+	}
+	caller := projectAccessCaller{
+		owner:    mint("sam@example.com", apiRoleDeveloper),
+		outsider: mint("dev-laptop", apiRoleDeveloper),
+		admin:    mint("compliance", apiRoleAdmin),
+	}
+	if _, err := api.store.setProjectOwnership(ctx, projectID, ProjectOwnership{
+		Owners: []ProjectOwner{{Name: "sam@example.com"}},
+	}); err != nil {
+		t.Fatalf("set ownership: %v", err)
+	}
+	srv := httptest.NewServer(api.routes())
+	caller.call = func(method, path, token string, body any) (int, []byte) {
+		t.Helper()
+		raw, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, bytes.NewReader(raw))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		return resp.StatusCode, buf.Bytes()
+	}
+	return caller, srv.Close
+}
+
+func TestAPI_HoldsNeedOwnershipToPlaceAndAdminToLift(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+	caller, closeServer := newProjectAccessCaller(t, fixture.api, fixture.projectID)
+	defer closeServer()
+	holdsPath := "/api/projects/" + fixture.projectID + "/holds"
+	placeBody := map[string]any{"reason": "litigation 42", "placed_by": "someone-else"}
+
+	if status, body := caller.call(http.MethodPost, holdsPath, caller.outsider, placeBody); status !=
+		http.StatusForbidden {
+		t.Fatalf("expected a non-owner refused a hold, got %d %s", status, body)
+	}
+	status, body := caller.call(http.MethodPost, holdsPath, caller.owner, placeBody)
+	var hold ComplianceHold
+	if status != http.StatusCreated || json.Unmarshal(body, &hold) != nil || hold.PlacedBy != "sam@example.com" {
+		t.Fatalf("expected the owner's hold recorded under the token's name, got %d %s", status, body)
+	}
+	if status, body = caller.call(http.MethodDelete, holdsPath+"?lifted_by=someone-else", caller.owner, nil); status !=
+		http.StatusForbidden {
+		t.Fatalf("expected a developer refused lifting a hold, got %d %s", status, body)
+	}
+	if status, body = caller.call(http.MethodDelete, holdsPath+"?lifted_by=someone-else", caller.admin, nil); status !=
+		http.StatusOK {
+		t.Fatalf("expected an admin to lift the hold, got %d %s", status, body)
+	}
+	audit, err := os.ReadFile(fixture.api.projectAuditLogPath(fixture.projectID, "holds"))
+	if err != nil || strings.Contains(string(audit), "someone-else") || !strings.Contains(string(audit), "compliance") ||
+		!strings.Contains(string(audit), "sam@example.com") {
+		t.Fatalf("expected the hold audit to name the tokens, got %q (%v)", audit, err)
+	}
+}
+
+func TestAPI_SecretsAndBindingsNeedOwnershipToChange(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	projectID := "project-owned-config"
+	spec := workerRuntimeSpec("owned-config")
+	spec.Capabilities = []string{"postgres"}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-owned-config-create", OpCreate, spec)
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	caller, closeServer := newProjectAccessCaller(t, api, projectID)
+	defer closeServer()
+	t.Setenv(secretsKeyEnv, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", storedSecretsKeyBytes))))
+
+	secretsPath := "/api/projects/" + projectID + "/secrets/dev"
+	secrets := storedSecretsRequest{Secrets: map[string]string{"DB_PASSWORD": "hunter2"}}
+	bindingPath := "/api/projects/" + projectID + "/environments/dev/bindings/postgres"
+	binding := capabilityBindingRequest{Type: "container", Image: "postgres:16"}
+	for _, tc := range []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodPost, secretsPath, secrets},
+		{http.MethodDelete, secretsPath + "?name=DB_PASSWORD", nil},
+		{http.MethodPut, bindingPath, binding},
+		{http.MethodDelete, bindingPath, nil},
+	} {
+		if status, body := caller.call(tc.method, tc.path, caller.outsider, tc.body); status != http.StatusForbidden {
+			t.Fatalf("%s %s: expected a non-owner refused, got %d %s", tc.method, tc.path, status, body)
+		}
+		if status, body := caller.call(tc.method, tc.path, caller.owner, tc.body); status != http.StatusOK {
+			t.Fatalf("%s %s: expected the owner allowed, got %d %s", tc.method, tc.path, status, body)
+		}
+	}
+	if status, body := caller.call(http.MethodGet, secretsPath, caller.outsider, nil); status != http.StatusOK {
+		t.Fatalf("expected a non-owner to read the masked listing, got %d %s", status, body)
+	}
+}
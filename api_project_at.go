@@ -0,0 +1,196 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// projectAtOpResponse reconstructs a project as it stood right after one op
+// finished. Parts that can no longer be recovered are left empty and
+// explained in Warnings rather than filled from current state.
+type projectAtOpResponse struct {
+	ProjectID    string                `json:"project_id"`
+	Op           Operation             `json:"op"`
+	At           time.Time             `json:"at"`
+	Spec         *ProjectSpec          `json:"spec,omitempty"`
+	SpecRevision uint64                `json:"spec_revision,omitempty"`
+	Environments []projectAtOpEnvState `json:"environments"`
+	Warnings     []string              `json:"warnings"`
+}
+
+type projectAtOpEnvState struct {
+	Environment string         `json:"environment"`
+	Release     *ReleaseRecord `json:"release,omitempty"`
+	Image       string         `json:"image,omitempty"`
+	Rendered    string         `json:"rendered,omitempty"`
+}
+
+func (a *API) handleProjectAt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.store == nil {
+		writeAPIError(w, "project history unavailable", http.StatusInternalServerError)
+		return
+	}
+	projectID, ok := projectIDFromSubresourcePath(w, r, "at")
+	if !ok {
+		return
+	}
+	opID := strings.TrimSpace(r.URL.Query().Get("op"))
+	if opID == "" {
+		writeAPIError(w, "op query parameter required", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	op, err := a.store.GetOp(r.Context(), opID)
+	if err != nil || op.ProjectID != projectID {
+		if err == nil || errors.Is(err, jetstream.ErrKeyNotFound) {
+			writeAPIError(w, "op not found for project", http.StatusNotFound)
+			return
+		}
+		writeAPIError(w, "failed to read op", http.StatusInternalServerError)
+		return
+	}
+	if op.Finished.IsZero() {
+		writeAPIError(w, "op has not finished yet", http.StatusConflict)
+		return
+	}
+	out, err := a.buildProjectAtOp(r.Context(), project, op)
+	if err != nil {
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (a *API) buildProjectAtOp(ctx context.Context, project Project, op Operation) (projectAtOpResponse, error) {
+	out := projectAtOpResponse{
+		ProjectID:    project.ID,
+		Op:           op,
+		At:           op.Finished,
+		Spec:         nil,
+		SpecRevision: 0,
+		Environments: []projectAtOpEnvState{},
+		Warnings:     []string{},
+	}
+
+	spec := normalizeProjectSpec(project.Spec)
+	rev, found, err := a.store.projectRevisionAfterOp(ctx, project.ID, op.ID, op.Finished)
+	if err != nil {
+		return projectAtOpResponse{}, fmt.Errorf("read project history: %w", err)
+	}
+	if found {
+		spec = normalizeProjectSpec(rev.Project.Spec)
+		out.Spec = &spec
+		out.SpecRevision = rev.Revision
+	} else {
+		out.Warnings = append(out.Warnings,
+			"project spec history no longer reaches this op; environments below follow the current spec")
+	}
+
+	releases, err := a.projectReleasesByEnvironment(ctx, project.ID, spec)
+	if err != nil {
+		return projectAtOpResponse{}, err
+	}
+	for _, env := range journeyEnvironmentOrder(spec) {
+		state := projectAtOpEnvState{Environment: env, Release: nil, Image: "", Rendered: ""}
+		release, ok := releaseCurrentAt(releases[env], op)
+		if !ok {
+			out.Environments = append(out.Environments, state)
+			continue
+		}
+		state.Release = &release
+		state.Image = release.Image
+		rendered, warning := a.readRenderedAt(project.ID, release, releases)
+		state.Rendered = rendered
+		if warning != "" {
+			out.Warnings = append(out.Warnings, fmt.Sprintf("%s: %s", env, warning))
+		}
+		out.Environments = append(out.Environments, state)
+	}
+	return out, nil
+}
+
+func (a *API) projectReleasesByEnvironment(
+	ctx context.Context,
+	projectID string,
+	spec ProjectSpec,
+) (map[string][]ReleaseRecord, error) {
+	out := map[string][]ReleaseRecord{}
+	for _, env := range journeyEnvironmentOrder(spec) {
+		index, err := a.store.readProjectReleaseIndex(ctx, projectID, env)
+		if err != nil {
+			return nil, fmt.Errorf("read %s releases: %w", env, err)
+		}
+		for _, releaseID := range index.IDs {
+			release, getErr := a.store.GetRelease(ctx, releaseID)
+			if getErr != nil {
+				if errors.Is(getErr, jetstream.ErrKeyNotFound) {
+					continue
+				}
+				return nil, fmt.Errorf("read release %s: %w", releaseID, getErr)
+			}
+			out[env] = append(out[env], release)
+		}
+	}
+	return out, nil
+}
+
+// releaseCurrentAt picks the newest release that existed once op finished.
+// A release the op itself wrote always counts, even if its timestamp lands
+// a moment after op.Finished.
+func releaseCurrentAt(releases []ReleaseRecord, op Operation) (ReleaseRecord, bool) {
+	var best ReleaseRecord
+	found := false
+	for _, release := range releases {
+		if release.OpID != op.ID && release.CreatedAt.After(op.Finished) {
+			continue
+		}
+		if !found || release.CreatedAt.After(best.CreatedAt) {
+			best = release
+			found = true
+		}
+	}
+	return best, found
+}
+
+// readRenderedAt returns the release's rendered manifests unless a later
+// release has since rewritten the same artifact path, in which case the file
+// on disk describes that release instead and nothing is returned.
+func (a *API) readRenderedAt(
+	projectID string,
+	release ReleaseRecord,
+	releases map[string][]ReleaseRecord,
+) (string, string) {
+	path := strings.Trim(strings.TrimSpace(release.RenderedPath), "/")
+	if path == "" || a.artifacts == nil {
+		return "", "release has no rendered manifest snapshot"
+	}
+	for _, envReleases := range releases {
+		for _, later := range envReleases {
+			if later.ID != release.ID && later.RenderedPath == path && later.CreatedAt.After(release.CreatedAt) {
+				return "", fmt.Sprintf("%s was overwritten by release %s", path, later.ID)
+			}
+		}
+	}
+	raw, err := a.artifacts.ReadFile(projectID, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Sprintf("%s is no longer stored", path)
+		}
+		return "", fmt.Sprintf("failed to read %s", path)
+	}
+	return string(raw), ""
+}
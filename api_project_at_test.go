@@ -0,0 +1,106 @@
+//nolint:testpackage,exhaustruct // Time-travel tests seed KV history and releases through the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_ProjectAtOpReconstructsSpecAndImages(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-at-op"
+	artifacts := NewFSArtifacts(t.TempDir())
+	base := time.Now().UTC().Add(-time.Hour)
+	for i, step := range []struct {
+		opID  string
+		image string
+		vars  map[string]string
+	}{
+		{opID: "op-at-1", image: "local/at:1111", vars: map[string]string{"LOG_LEVEL": "info"}},
+		{opID: "op-at-2", image: "local/at:2222", vars: map[string]string{"LOG_LEVEL": "debug"}},
+	} {
+		at := base.Add(time.Duration(i) * time.Minute)
+		spec := workerRuntimeSpec("at-app")
+		spec.Vars = step.vars
+		if err := overwriteProjectForTest(ctx, fixture.store, Project{
+			ID:        projectID,
+			CreatedAt: base,
+			Spec:      normalizeProjectSpec(spec),
+			Status:    ProjectStatus{Phase: projectPhaseReady, UpdatedAt: at, LastOpID: step.opID},
+		}); err != nil {
+			t.Fatalf("put project: %v", err)
+		}
+		if err := fixture.store.PutOp(ctx, Operation{
+			ID: step.opID, Kind: OpDeploy, ProjectID: projectID, Requested: at, Finished: at.Add(time.Second),
+			Status: opStatusDone, Steps: []OpStep{},
+		}); err != nil {
+			t.Fatalf("put op: %v", err)
+		}
+		if _, err := fixture.store.PutRelease(ctx, ReleaseRecord{
+			ProjectID: projectID, Environment: "dev", OpID: step.opID, OpKind: OpDeploy,
+			DeliveryStage: DeliveryStageDeploy, Image: step.image,
+			RenderedPath: "deploy/dev/rendered.yaml", CreatedAt: at,
+		}); err != nil {
+			t.Fatalf("put release: %v", err)
+		}
+		if _, err := artifacts.WriteFile(projectID, "deploy/dev/rendered.yaml", []byte("image: "+step.image+"\n")); err != nil {
+			t.Fatalf("write rendered: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer((&API{store: fixture.store, artifacts: artifacts}).routes())
+	defer srv.Close()
+
+	first := getProjectAtForTest(t, srv, projectID, "op-at-1")
+	if first.Spec == nil || first.Spec.Vars["LOG_LEVEL"] != "info" {
+		t.Fatalf("expected spec as of first op, got %#v", first.Spec)
+	}
+	if len(first.Environments) == 0 || first.Environments[0].Image != "local/at:1111" {
+		t.Fatalf("expected first image in dev, got %#v", first.Environments)
+	}
+	if first.Environments[0].Rendered != "" || len(first.Warnings) == 0 {
+		t.Fatalf("expected overwritten snapshot to be withheld with a warning, got %#v", first)
+	}
+
+	second := getProjectAtForTest(t, srv, projectID, "op-at-2")
+	if second.Spec == nil || second.Spec.Vars["LOG_LEVEL"] != "debug" {
+		t.Fatalf("expected spec as of second op, got %#v", second.Spec)
+	}
+	if !strings.Contains(second.Environments[0].Rendered, "local/at:2222") {
+		t.Fatalf("expected current rendered snapshot, got %q", second.Environments[0].Rendered)
+	}
+
+	resp, err := srv.Client().Get(srv.URL + "/api/projects/" + projectID + "/at?op=missing")
+	if err != nil {
+		t.Fatalf("get unknown op: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown op, got %d", resp.StatusCode)
+	}
+}
+
+func getProjectAtForTest(t *testing.T, srv *httptest.Server, projectID, opID string) projectAtOpResponse {
+	t.Helper()
+	resp, err := srv.Client().Get(srv.URL + "/api/projects/" + projectID + "/at?op=" + opID)
+	if err != nil {
+		t.Fatalf("get project at %s: %v", opID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for op %s, got %d", opID, resp.StatusCode)
+	}
+	var out projectAtOpResponse
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode project at %s: %v", opID, err)
+	}
+	return out
+}
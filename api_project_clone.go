@@ -0,0 +1,76 @@
+package platform
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Project cloning: POST /api/projects/{id}/clone creates a project with the
+// source project's spec and a copy of its source repo, for per-developer
+// sandboxes of an existing service. The body is a partial spec applied over
+// the copied one and must give the clone a name of its own. The repo is
+// copied as of its HEAD commit and starts a fresh history; the source
+// project is only read.
+////////////////////////////////////////////////////////////////////////////////
+
+func (a *API) handleProjectClone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID, ok := projectIDFromSubresourcePath(w, r, "clone")
+	if !ok {
+		return
+	}
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if execution.DryRun {
+		writeAPIError(w, "dry_run is not supported for project creation", http.StatusBadRequest)
+		return
+	}
+	source, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	if _, err = gitRevParse(r.Context(), sourceRepoDir(a.artifacts, source.ID), "HEAD"); err != nil {
+		writeAPIError(w, "project "+source.ID+" has no source commits to clone yet", http.StatusConflict)
+		return
+	}
+	spec, err := projectSpecForClone(source.Spec, func(spec *ProjectSpec) error {
+		return decodeSpecBody(r, spec)
+	})
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	opts := emptyOpRunOptions().withExecution(execution)
+	opts.cloneOf = source.ID
+	opts.placement = projectPlacement{Org: source.Org, Workspace: source.Workspace}
+	a.createProjectAndRespond(w, r, spec, opts)
+}
+
+// projectSpecForClone copies source (through JSON, so the clone shares no
+// maps or slices with it) and applies the request body on top. The clone
+// keeps the source's template, since its files came from there.
+func projectSpecForClone(source ProjectSpec, decode func(*ProjectSpec) error) (ProjectSpec, error) {
+	raw, err := json.Marshal(source)
+	if err != nil {
+		return ProjectSpec{}, err
+	}
+	spec := zeroProjectSpec()
+	if err = json.Unmarshal(raw, &spec); err != nil {
+		return ProjectSpec{}, err
+	}
+	if err = decode(&spec); err != nil {
+		return ProjectSpec{}, err
+	}
+	spec = normalizeProjectSpec(spec)
+	if spec.Name == normalizeProjectSpec(source).Name {
+		return ProjectSpec{}, specFieldErrorf("name", "a clone needs a name other than %q", spec.Name)
+	}
+	return inheritProjectTemplate(source, spec)
+}
@@ -0,0 +1,117 @@
+//nolint:testpackage,exhaustruct // Clone tests bootstrap repos through the unexported worker actions.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func bootstrapCloneOrigin(t *testing.T, artifacts ArtifactStore, projectID string, spec ProjectSpec) string {
+	t.Helper()
+	ctx := context.Background()
+	msg := newProjectOpMsg("op-origin", OpCreate, projectID, spec, emptyOpRunOptions(), time.Now().UTC())
+	if _, err := runRepoBootstrapCreateOrUpdate(ctx, artifacts, msg, spec); err != nil {
+		t.Fatalf("bootstrap origin: %v", err)
+	}
+	sourceDir := sourceRepoDir(artifacts, projectID)
+	if err := os.WriteFile(filepath.Join(sourceDir, "handlers.go"), []byte("package main\n"), 0o600); err != nil {
+		t.Fatalf("write origin file: %v", err)
+	}
+	if _, err := gitCommitIfChanged(ctx, sourceDir, "add handlers"); err != nil {
+		t.Fatalf("commit origin file: %v", err)
+	}
+	head, err := gitRevParse(ctx, sourceDir, "HEAD")
+	if err != nil {
+		t.Fatalf("origin head: %v", err)
+	}
+	return head
+}
+
+func TestRepoBootstrapSeedsACloneWithoutHistory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := normalizeProjectSpec(ProjectSpec{
+		Name:         "orders",
+		Runtime:      "go_1.26",
+		Environments: map[string]EnvConfig{"dev": {}},
+	})
+	originHead := bootstrapCloneOrigin(t, artifacts, "project-origin", spec)
+
+	spec.Name = "orders-alice"
+	opts := emptyOpRunOptions()
+	opts.cloneOf = "project-origin"
+	msg := newProjectOpMsg("op-clone", OpCreate, "project-clone", spec, opts, time.Now().UTC())
+	if _, err := runRepoBootstrapCreateOrUpdate(ctx, artifacts, msg, spec); err != nil {
+		t.Fatalf("bootstrap clone: %v", err)
+	}
+
+	cloneDir := sourceRepoDir(artifacts, "project-clone")
+	body, err := os.ReadFile(filepath.Join(cloneDir, "handlers.go"))
+	if err != nil || string(body) != "package main\n" {
+		t.Fatalf("expected the origin's committed files in the clone, got %q (%v)", body, err)
+	}
+	meta, err := os.ReadFile(filepath.Join(cloneDir, ".paas", "repo.json"))
+	if err != nil || !strings.Contains(string(meta), "project-clone") ||
+		strings.Contains(string(meta), "project-origin") {
+		t.Fatalf("expected the clone's own repo metadata, got %s (%v)", meta, err)
+	}
+	if _, err = gitRevParse(ctx, cloneDir, originHead); err == nil {
+		t.Fatal("expected the clone to start without the origin's history")
+	}
+}
+
+func TestAPI_ProjectCloneCopiesTheSpec(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	artifacts := NewFSArtifacts(t.TempDir())
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	post := func(path, body string) (*http.Response, opAcceptedResponse) {
+		t.Helper()
+		resp, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		var out opAcceptedResponse
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+	resp, origin := post("/api/projects", `{"name":"orders","runtime":"go_1.26",`+
+		`"vars":{"LOG_LEVEL":"info"},"environments":{"dev":{}}}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("create origin: %d", resp.StatusCode)
+	}
+	clonePath := "/api/projects/" + origin.Project.ID + "/clone"
+
+	if resp, _ = post(clonePath, `{"name":"orders-alice"}`); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 before the origin has source commits, got %d", resp.StatusCode)
+	}
+	bootstrapCloneOrigin(t, artifacts, origin.Project.ID, origin.Project.Spec)
+
+	if resp, _ = post(clonePath, `{"runtime":"go_1.25"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a clone under the origin's name, got %d", resp.StatusCode)
+	}
+	resp, clone := post(clonePath, `{"name":"orders-alice","vars":{"LOG_LEVEL":"debug"}}`)
+	spec := clone.Project.Spec
+	if resp.StatusCode != http.StatusAccepted || clone.Project.ID == origin.Project.ID ||
+		spec.Name != "orders-alice" || spec.Runtime != "go_1.26" || spec.Vars["LOG_LEVEL"] != "debug" ||
+		clone.Op.Kind != OpCreate || clone.Op.CloneOf != origin.Project.ID {
+		t.Fatalf("expected a create op for the cloned spec, got %d %+v", resp.StatusCode, clone)
+	}
+	stored, err := api.store.GetProject(context.Background(), origin.Project.ID)
+	if err != nil || stored.Spec.Vars["LOG_LEVEL"] != "info" {
+		t.Fatalf("expected the origin's spec to be left alone, got %+v (%v)", stored.Spec.Vars, err)
+	}
+}
@@ -0,0 +1,131 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// handleProjectEvents streams every op event, status change, and release
+// record for one project over a single SSE connection.
+func (a *API) handleProjectEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID, ok := projectIDFromSubresourcePath(w, r, "events")
+	if !ok {
+		return
+	}
+	if a.store == nil || a.opEvents == nil {
+		writeAPIError(w, "project events unavailable", http.StatusInternalServerError)
+		return
+	}
+	project, err := a.store.GetProject(r.Context(), projectID)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			writeAPIError(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeAPIError(w, "failed to read project", http.StatusInternalServerError)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	replay, live, needsBootstrap, unsubscribe := a.opEvents.subscribeProject(projectID, readLastEventID(r))
+	defer unsubscribe()
+
+	lastSequence := a.opEvents.latestProjectSequence(projectID)
+	var page projectOpsListPage
+	if needsBootstrap {
+		// Read the ops after subscribing so nothing published in between is
+		// missed; a duplicate live event is harmless next to a snapshot.
+		page, err = a.store.listProjectOps(r.Context(), projectID, projectOpsListQuery{
+			Limit:  projectEventsBootstrapOps,
+			Cursor: "",
+			Before: "",
+		})
+		if err != nil {
+			writeAPIError(w, "failed to read project ops", http.StatusInternalServerError)
+			return
+		}
+	}
+	writeOpEventHeaders(w)
+
+	if needsBootstrap {
+		bootstrap := newProjectBootstrapSnapshot(project, page.Ops)
+		bootstrap.Sequence = lastSequence
+		bootstrap.EventID = "bootstrap"
+		if writeProjectSSEEvent(w, flusher, bootstrap, false) != nil {
+			return
+		}
+	}
+	for _, record := range replay {
+		lastSequence = record.Payload.Sequence
+		if writeProjectSSEEvent(w, flusher, record.Payload, true) != nil {
+			return
+		}
+	}
+
+	a.streamLiveProjectEvents(r, w, flusher, projectID, live, lastSequence)
+}
+
+func (a *API) streamLiveProjectEvents(
+	r *http.Request,
+	w http.ResponseWriter,
+	flusher http.Flusher,
+	projectID string,
+	live <-chan projectEventRecord,
+	lastSequence int64,
+) {
+	ticker := time.NewTicker(a.effectiveOpHeartbeatInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case record, streamOpen := <-live:
+			if !streamOpen {
+				return
+			}
+			lastSequence = record.Payload.Sequence
+			if writeProjectSSEEvent(w, flusher, record.Payload, true) != nil {
+				return
+			}
+		case <-ticker.C:
+			heartbeat := newProjectHeartbeatPayload(projectID, lastSequence)
+			if writeProjectSSEEvent(w, flusher, heartbeat, false) != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeProjectSSEEvent(
+	w http.ResponseWriter,
+	flusher http.Flusher,
+	payload projectEventPayload,
+	includeProtocolID bool,
+) error {
+	payload.At = payload.At.UTC()
+	if payload.EventID == "" {
+		payload.EventID = strconv.FormatInt(payload.Sequence, 10)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	eventID := ""
+	if includeProtocolID {
+		eventID = payload.EventID
+	}
+	return writeSSEFrame(w, flusher, eventID, payload.Type, body)
+}
@@ -20,6 +20,7 @@ type projectOpsHistoryFixture struct {
 
 type projectOpsListItemForTest struct {
 	ID                string        `json:"id"`
+	ProjectID         string        `json:"project_id"`
 	Kind              OperationKind `json:"kind"`
 	Status            string        `json:"status"`
 	Requested         time.Time     `json:"requested"`
@@ -581,3 +582,75 @@ func TestAPI_ProjectOpsHistoryRealtimeTerminalCoherence(t *testing.T) {
 		t.Fatalf("expected final summary message from persisted op, got %q", item.SummaryMessage)
 	}
 }
+
+func TestAPI_OpsListFiltersAndPagesAcrossProjects(t *testing.T) {
+	fixture := newProjectOpsHistoryFixture(t)
+	defer fixture.Close()
+
+	base := time.Now().UTC().Add(-time.Hour)
+	for i, op := range []Operation{
+		{ID: "op-list-1", Kind: OpCreate, ProjectID: "project-list-a", Status: opStatusDone},
+		{ID: "op-list-2", Kind: OpDeploy, ProjectID: "project-list-b", Status: opStatusError},
+		{ID: "op-list-3", Kind: OpDeploy, ProjectID: "project-list-a", Status: opStatusDone},
+		{ID: "op-list-4", Kind: OpPromote, ProjectID: "project-list-b", Status: opStatusRunning},
+		{ID: "op-list-5", Kind: OpDeploy, ProjectID: "project-list-a", Status: opStatusRunning},
+	} {
+		op.Requested = base.Add(time.Duration(i) * time.Minute)
+		op.Steps = []OpStep{}
+		putOpHistoryFixture(t, fixture.api.store, op)
+	}
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	client := srv.Client()
+	ids := func(body projectOpsListResponseForTest) []string {
+		out := make([]string, 0, len(body.Items))
+		for _, item := range body.Items {
+			out = append(out, item.ID)
+		}
+		return out
+	}
+
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: "[op-list-5 op-list-4 op-list-3 op-list-2 op-list-1]"},
+		{query: "project_id=project-list-b", want: "[op-list-4 op-list-2]"},
+		{query: "kind=deploy&status=done,error", want: "[op-list-3 op-list-2]"},
+		{
+			query: "since=" + url.QueryEscape(base.Add(time.Minute).Format(time.RFC3339Nano)) +
+				"&until=" + url.QueryEscape(base.Add(3*time.Minute).Format(time.RFC3339Nano)),
+			want: "[op-list-3 op-list-2]",
+		},
+	}
+	for _, tc := range cases {
+		body := fetchProjectOpsHistory(t, client, srv.URL+"/api/ops?"+tc.query)
+		if got := fmt.Sprint(ids(body)); got != tc.want {
+			t.Fatalf("query %q: expected %s, got %s", tc.query, tc.want, got)
+		}
+	}
+
+	first := fetchProjectOpsHistory(t, client, srv.URL+"/api/ops?kind=deploy&limit=2")
+	if fmt.Sprint(ids(first)) != "[op-list-5 op-list-3]" || first.NextCursor != "op-list-3" {
+		t.Fatalf("unexpected first page %v cursor=%q", ids(first), first.NextCursor)
+	}
+	if first.Items[0].ProjectID != "project-list-a" {
+		t.Fatalf("expected project_id on list items, got %q", first.Items[0].ProjectID)
+	}
+	second := fetchProjectOpsHistory(t, client, srv.URL+"/api/ops?kind=deploy&limit=2&cursor="+first.NextCursor)
+	if fmt.Sprint(ids(second)) != "[op-list-2]" || second.NextCursor != "" {
+		t.Fatalf("unexpected second page %v cursor=%q", ids(second), second.NextCursor)
+	}
+
+	for _, query := range []string{"kind=launch", "status=paused", "since=yesterday", "cursor=op-missing"} {
+		resp, err := client.Get(srv.URL + "/api/ops?" + query)
+		if err != nil {
+			t.Fatalf("request %q: %v", query, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("query %q: expected 400, got %d", query, resp.StatusCode)
+		}
+	}
+}
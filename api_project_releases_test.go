@@ -440,3 +440,133 @@ func fetchProjectReleaseListForTest(
 	}
 	return page
 }
+
+func TestAPI_LookupFindsReleaseByImageAndCommit(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+
+	release, err := fixture.api.store.PutRelease(context.Background(), ReleaseRecord{
+		ID:            "",
+		ProjectID:     fixture.projectID,
+		Environment:   "prod",
+		OpID:          "op-lookup-prod",
+		OpKind:        OpRelease,
+		DeliveryStage: DeliveryStageRelease,
+		FromEnv:       "staging",
+		ToEnv:         "prod",
+		Image:         "local/lookup:abc123",
+		SourceCommit:  "0123456789abcdef0123456789abcdef01234567",
+		CreatedAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("put lookup release: %v", err)
+	}
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+
+	for _, query := range []string{"image=local/lookup:abc123", "commit=0123456"} {
+		resp, getErr := srv.Client().Get(srv.URL + "/api/lookup?" + query)
+		if getErr != nil {
+			t.Fatalf("lookup %s: %v", query, getErr)
+		}
+		var payload lookupResponse
+		decodeErr := json.NewDecoder(resp.Body).Decode(&payload)
+		resp.Body.Close()
+		if decodeErr != nil {
+			t.Fatalf("decode lookup %s: %v", query, decodeErr)
+		}
+		if len(payload.Matches) != 1 {
+			t.Fatalf("lookup %s: expected 1 match, got %#v", query, payload.Matches)
+		}
+		match := payload.Matches[0]
+		if match.ReleaseID != release.ID || match.ProjectID != fixture.projectID || !match.Current {
+			t.Fatalf("lookup %s: unexpected match %#v", query, match)
+		}
+	}
+
+	resp, err := srv.Client().Get(srv.URL + "/api/lookup?commit=012")
+	if err != nil {
+		t.Fatalf("lookup short commit: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for short commit prefix, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPI_ComplianceHoldBlocksDeleteAndShowsOnReleaseDetail(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+
+	release, err := fixture.api.store.PutRelease(context.Background(), ReleaseRecord{
+		ID:            "",
+		ProjectID:     fixture.projectID,
+		Environment:   "staging",
+		OpID:          "op-hold-staging",
+		OpKind:        OpPromote,
+		DeliveryStage: DeliveryStagePromote,
+		FromEnv:       "dev",
+		ToEnv:         "staging",
+		Image:         "local/hold:abc123",
+		CreatedAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("put hold release: %v", err)
+	}
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	holdsURL := srv.URL + "/api/projects/" + fixture.projectID + "/holds"
+
+	placeBody := fmt.Sprintf(`{"release_id":%q,"reason":"litigation 42","placed_by":"legal"}`, release.ID)
+	resp, err := srv.Client().Post(holdsURL, "application/json", strings.NewReader(placeBody))
+	if err != nil {
+		t.Fatalf("place hold: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 placing hold, got %d", resp.StatusCode)
+	}
+
+	resp, err = srv.Client().Get(srv.URL + "/api/projects/" + fixture.projectID + "/releases/" + release.ID)
+	if err != nil {
+		t.Fatalf("get release detail: %v", err)
+	}
+	var detail releaseDetailResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&detail)
+	resp.Body.Close()
+	if decodeErr != nil {
+		t.Fatalf("decode release detail: %v", decodeErr)
+	}
+	if detail.Hold == nil || detail.Hold.Reason != "litigation 42" || detail.Hold.PlacedBy != "legal" {
+		t.Fatalf("expected hold on release detail, got %#v", detail.Hold)
+	}
+
+	deleteReq, _ := http.NewRequest(http.MethodDelete, srv.URL+"/api/projects/"+fixture.projectID, nil)
+	resp, err = srv.Client().Do(deleteReq)
+	if err != nil {
+		t.Fatalf("delete project: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 deleting held project, got %d", resp.StatusCode)
+	}
+
+	liftReq, _ := http.NewRequest(http.MethodDelete, holdsURL+"?release_id="+release.ID+"&lifted_by=legal", nil)
+	resp, err = srv.Client().Do(liftReq)
+	if err != nil {
+		t.Fatalf("lift hold: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 lifting hold, got %d", resp.StatusCode)
+	}
+	holds, err := fixture.api.store.getProjectHolds(context.Background(), fixture.projectID)
+	if err != nil {
+		t.Fatalf("read holds: %v", err)
+	}
+	if holds.active() {
+		t.Fatalf("expected no active holds after lift, got %#v", holds)
+	}
+}
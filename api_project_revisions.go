@@ -0,0 +1,154 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Optimistic locking: every project write is checked against the KV
+// revision it was based on. PUT /api/projects/{id} takes the ETag from
+// GET /api/projects/{id} in If-Match; a project that changed since is
+// refused with 409 and the current project, so the caller can reapply its
+// change instead of overwriting someone else's.
+
+// projectETag is the ETag GET /api/projects/{id} serves for revision.
+func projectETag(projectID string, revision uint64) string {
+	return newCacheValidator("project").add(kvRevision{
+		Key:      kvProjectKeyPrefix + projectID,
+		Revision: revision,
+		Modified: time.Time{},
+	}).etag()
+}
+
+// getProjectRevisionOrWriteError reads the project straight from KV, so the
+// revision it returns is the one a following write is checked against.
+func (a *API) getProjectRevisionOrWriteError(
+	w http.ResponseWriter,
+	r *http.Request,
+	projectID string,
+) (Project, kvRevision, bool) {
+	project, rev, err := a.store.getProjectRevision(r.Context(), projectID)
+	if err == nil {
+		return project, rev, true
+	}
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return Project{}, kvRevision{}, false
+	}
+	writeAPIError(w, "failed to read project", http.StatusInternalServerError)
+	return Project{}, kvRevision{}, false
+}
+
+// checkProjectRevision refuses an op whose request was based on a project
+// revision that is no longer current. Zero skips the check.
+func (a *API) checkProjectRevision(ctx context.Context, projectID string, expected uint64) error {
+	if expected == 0 || a.store == nil {
+		return nil
+	}
+	current, _, err := a.store.getProjectRevision(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("read project: %w", err)
+	}
+	if current.Revision == expected {
+		return nil
+	}
+	return projectRevisionConflictError{ProjectID: projectID, Create: false, Expected: expected, Current: current}
+}
+
+func writeProjectRevisionConflict(w http.ResponseWriter, err error) bool {
+	var conflict projectRevisionConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	body := map[string]any{
+		"accepted":   false,
+		"reason":     conflict.Error(),
+		"project_id": conflict.ProjectID,
+		"next_step":  "reapply the change to the current project and retry with its ETag in If-Match",
+	}
+	if conflict.Current.Revision != 0 {
+		w.Header().Set("ETag", projectETag(conflict.ProjectID, conflict.Current.Revision))
+		body["project"] = conflict.Current
+	}
+	writeErrorDetails(w, http.StatusConflict, errorCodeRevisionConflict, body)
+	return true
+}
+
+// projectRevisionSnapshot is the body of GET /api/projects/{id}/revision:
+// the KV revisions the project's views are built from, read without
+// decoding the records. Token changes whenever any of them does, so a
+// client can poll it after queueing an op and refetch only on a change.
+type projectRevisionSnapshot struct {
+	ProjectID string                    `json:"project_id"`
+	Token     string                    `json:"token"`
+	Project   uint64                    `json:"project"`
+	LatestOp  projectRevisionSnapshotOp `json:"latest_op"`
+	Releases  map[string]uint64         `json:"releases"` // env -> revision of its current-release pointer
+}
+
+type projectRevisionSnapshotOp struct {
+	ID       string `json:"id,omitempty"`
+	Revision uint64 `json:"revision"`
+}
+
+func (a *API) handleProjectRevision(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID, ok := projectIDFromSubresourcePath(w, r, "revision")
+	if !ok {
+		return
+	}
+	project, projectRev, ok := a.getProjectRevisionOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	snapshot, validator, err := a.buildProjectRevisionSnapshot(r.Context(), project, projectRev)
+	if err != nil {
+		writeAPIError(w, "failed to read revisions", http.StatusInternalServerError)
+		return
+	}
+	if validator.notModified(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (a *API) buildProjectRevisionSnapshot(
+	ctx context.Context,
+	project Project,
+	projectRev kvRevision,
+) (projectRevisionSnapshot, *cacheValidator, error) {
+	validator := newCacheValidator("revision").add(projectRev)
+	snapshot := projectRevisionSnapshot{
+		ProjectID: project.ID,
+		Token:     "",
+		Project:   projectRev.Revision,
+		LatestOp:  projectRevisionSnapshotOp{ID: project.Status.LastOpID, Revision: 0},
+		Releases:  map[string]uint64{},
+	}
+	if project.Status.LastOpID != "" {
+		opRev, err := a.store.opsKeyRevision(ctx, kvOpKeyPrefix+project.Status.LastOpID)
+		if err != nil {
+			return projectRevisionSnapshot{}, nil, err
+		}
+		validator.add(opRev)
+		snapshot.LatestOp.Revision = opRev.Revision
+	}
+	for _, env := range desiredManifestEnvironments(project.Spec) {
+		releaseRev, err := a.store.opsKeyRevision(ctx, projectReleaseCurrentKey(project.ID, env))
+		if err != nil {
+			return projectRevisionSnapshot{}, nil, err
+		}
+		validator.add(releaseRev)
+		snapshot.Releases[env] = releaseRev.Revision
+	}
+	snapshot.Token = validator.etag()
+	return snapshot, validator, nil
+}
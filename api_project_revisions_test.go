@@ -0,0 +1,216 @@
+//nolint:testpackage,exhaustruct // Revision tests race writes through the internal store.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStore_ProjectWritesAreRevisionChecked(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-revision-store"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-revision-store", OpCreate, workerRuntimeSpec("rev"))
+
+	ui, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil || ui.Revision == 0 {
+		t.Fatalf("expected a read to carry its revision, got %d err=%v", ui.Revision, err)
+	}
+	ci := ui
+	ui.Spec.Vars = map[string]string{"FROM_UI": "1"}
+	if err = fixture.store.PutProject(ctx, ui); err != nil {
+		t.Fatalf("put ui change: %v", err)
+	}
+	ci.Status.Message = "ci queued"
+	var conflict projectRevisionConflictError
+	if err = fixture.store.PutProject(ctx, ci); !errors.As(err, &conflict) {
+		t.Fatalf("expected a stale write to conflict, got %v", err)
+	}
+	if conflict.Current.Spec.Vars["FROM_UI"] != "1" || conflict.Current.Revision <= ci.Revision {
+		t.Fatalf("expected the conflict to carry the current project, got %#v", conflict.Current)
+	}
+	fresh := conflict.Current
+	fresh.Revision = 0
+	if err = fixture.store.PutProject(ctx, fresh); !errors.As(err, &conflict) || !conflict.Create {
+		t.Fatalf("expected a create over an existing project to conflict, got %v", err)
+	}
+
+	edits := 0
+	updated, err := fixture.store.updateProject(ctx, projectID, func(project *Project) bool {
+		edits++
+		if edits == 1 {
+			// Another writer lands between this edit's read and its write.
+			racing, _ := fixture.store.GetProject(ctx, projectID)
+			racing.Status.Message = "raced"
+			if putErr := fixture.store.PutProject(ctx, racing); putErr != nil {
+				t.Fatalf("racing write: %v", putErr)
+			}
+		}
+		project.Spec.Vars["FROM_UPDATE"] = "1"
+		return true
+	})
+	if err != nil || edits != 2 {
+		t.Fatalf("expected one retry, got edits=%d err=%v", edits, err)
+	}
+	if updated.Status.Message != "raced" || updated.Spec.Vars["FROM_UI"] != "1" ||
+		updated.Spec.Vars["FROM_UPDATE"] != "1" {
+		t.Fatalf("expected every write to survive, got %#v", updated)
+	}
+}
+
+func TestAPI_ProjectUpdateHonorsIfMatch(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-revision-api"
+	spec := workerRuntimeSpec("rev-api")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-revision-api", OpCreate, spec)
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	projectURL := srv.URL + "/api/projects/" + projectID
+
+	resp, err := srv.Client().Get(projectURL)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	resp.Body.Close()
+	staleETag := resp.Header.Get("ETag")
+	if staleETag == "" {
+		t.Fatal("expected GET to serve an ETag")
+	}
+
+	// A webhook-triggered status write lands after the UI read the project.
+	if _, err = fixture.store.updateProject(ctx, projectID, func(project *Project) bool {
+		project.Status.Message = "ci finished"
+		return true
+	}); err != nil {
+		t.Fatalf("status write: %v", err)
+	}
+
+	changed := workerRuntimeSpec("rev-api")
+	changed.Vars = map[string]string{"FEATURE": "on"}
+	put := func(ifMatch string) (*http.Response, map[string]json.RawMessage) {
+		t.Helper()
+		raw, _ := json.Marshal(changed)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPut, projectURL, bytes.NewReader(raw))
+		req.Header.Set("If-Match", ifMatch)
+		putResp, putErr := srv.Client().Do(req)
+		if putErr != nil {
+			t.Fatalf("put project: %v", putErr)
+		}
+		defer putResp.Body.Close()
+		var out map[string]json.RawMessage
+		_ = json.NewDecoder(putResp.Body).Decode(&out)
+		return putResp, out
+	}
+
+	stale, body := put(staleETag)
+	if stale.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 for a stale If-Match, got %d", stale.StatusCode)
+	}
+	var current Project
+	if err = json.Unmarshal(body["project"], &current); err != nil || current.Status.Message != "ci finished" {
+		t.Fatalf("expected the conflict to carry the current project, got %s err=%v", body["project"], err)
+	}
+	currentETag := stale.Header.Get("ETag")
+	if currentETag == "" || currentETag == staleETag {
+		t.Fatalf("expected the conflict to serve the current ETag, got %q", currentETag)
+	}
+	if project, _ := fixture.store.GetProject(ctx, projectID); project.Spec.Vars["FEATURE"] != "" {
+		t.Fatal("expected the stale update not to be applied")
+	}
+
+	accepted, _ := put(currentETag)
+	if accepted.StatusCode != http.StatusAccepted || accepted.Header.Get("ETag") == currentETag {
+		t.Fatalf("expected the current If-Match to be accepted with a new ETag, got %d", accepted.StatusCode)
+	}
+	project, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil || project.Spec.Vars["FEATURE"] != "on" {
+		t.Fatalf("expected the update to be queued with its spec, got %#v err=%v", project.Spec.Vars, err)
+	}
+}
+
+func TestAPI_ProjectRevisionSnapshotTracksProjectOpAndReleases(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-revision-snapshot"
+	opID := "op-revision-snapshot"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpDeploy, workerRuntimeSpec("snap"))
+	if _, err := fixture.store.updateProject(ctx, projectID, func(p *Project) bool {
+		p.Status.LastOpID = opID
+		return true
+	}); err != nil {
+		t.Fatalf("point project at op: %v", err)
+	}
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	get := func(etag string) (int, projectRevisionSnapshot) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/projects/"+projectID+"/revision", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("get revision: %v", err)
+		}
+		defer resp.Body.Close()
+		var out projectRevisionSnapshot
+		if resp.StatusCode == http.StatusOK {
+			_ = json.NewDecoder(resp.Body).Decode(&out)
+			if resp.Header.Get("ETag") != out.Token {
+				t.Fatalf("expected the ETag to be the token, got %q and %q", resp.Header.Get("ETag"), out.Token)
+			}
+		}
+		return resp.StatusCode, out
+	}
+
+	status, first := get("")
+	if status != http.StatusOK || first.Project == 0 || first.LatestOp.ID != opID || first.LatestOp.Revision == 0 ||
+		len(first.Releases) != 1 || first.Releases["dev"] != 0 {
+		t.Fatalf("unexpected snapshot: %d %#v", status, first)
+	}
+	if status, _ = get(first.Token); status != http.StatusNotModified {
+		t.Fatalf("expected 304 for an unchanged snapshot, got %d", status)
+	}
+
+	op, err := fixture.store.GetOp(ctx, opID)
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	op.Status = opStatusRunning
+	if err = fixture.store.PutOp(ctx, op); err != nil {
+		t.Fatalf("put op: %v", err)
+	}
+	status, second := get(first.Token)
+	if status != http.StatusOK || second.LatestOp.Revision <= first.LatestOp.Revision ||
+		second.Project != first.Project || second.Token == first.Token {
+		t.Fatalf("expected only the op revision to move, got %d %#v", status, second)
+	}
+
+	if err = fixture.store.writeProjectReleaseCurrent(ctx, projectID, "dev", "release-snapshot"); err != nil {
+		t.Fatalf("write current release: %v", err)
+	}
+	if status, third := get(second.Token); status != http.StatusOK || third.Releases["dev"] == 0 {
+		t.Fatalf("expected the dev release pointer to show up, got %d %#v", status, third)
+	}
+
+	resp, err := srv.Client().Get(srv.URL + "/api/projects/project-missing/revision")
+	if err != nil {
+		t.Fatalf("get missing revision: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown project, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,195 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// specValidationImageTag stands in for the build tag in validation renders,
+// which never build an image.
+const specValidationImageTag = "validate"
+
+// handleProjectValidate checks a spec the way create and update do and
+// renders what the workers would write for it, without storing anything:
+//
+//	POST /api/projects/validate
+//
+// A spec that fails validation is still 200 OK, with valid false and the
+// failures in errors, so CI can report every problem in one pass.
+func (a *API) handleProjectValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var raw ProjectSpec
+	if err := decodeSpecBody(r, &raw); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a.validateSpecDryRun(raw))
+}
+
+func (a *API) validateSpecDryRun(raw ProjectSpec) SpecValidationResponse {
+	spec := normalizeProjectSpec(raw)
+	out := SpecValidationResponse{
+		Valid:     false,
+		Spec:      spec,
+		SpecHash:  "",
+		Image:     "",
+		Errors:    a.specValidationErrors(spec),
+		Warnings:  specValidationWarnings(raw, spec),
+		Lint:      []SpecLintWarning{},
+		Artifacts: []SpecValidationArtifact{},
+	}
+	if len(out.Errors) > 0 {
+		return out
+	}
+	out.Lint = lintProjectSpec(spec)
+	out.Valid = true
+	out.SpecHash = projectSpecHash(spec)
+	out.Image = fmt.Sprintf("local/%s:%s", safeName(spec.Name), specValidationImageTag)
+	out.Artifacts = renderSpecValidationArtifacts(spec, out.Image)
+	return out
+}
+
+// specValidationErrors runs each part of validateSpec on its own, so one
+// bad section does not hide failures in the others. An issue names the exact
+// field when the validator knows it, and the section otherwise.
+func (a *API) specValidationErrors(spec ProjectSpec) []SpecValidationIssue {
+	extensionsErr := validateExtensionKeys(spec.Extensions)
+	if extensionsErr == nil {
+		extensionsErr = a.specExtensions.validate(spec.Extensions)
+	}
+	checks := []struct {
+		field string
+		err   error
+	}{
+		{field: "spec", err: validateProjectCore(spec)},
+		{field: "build", err: validateBuildConfig(spec)},
+		{field: "capabilities", err: validateCapabilities(spec)},
+		{field: "needs", err: validateProjectNeeds(spec)},
+		{field: "vars", err: validateEnvironmentVars("vars", "vars", spec.Vars)},
+		{field: "environments", err: validateEnvironments(spec.Environments)},
+		{field: "exposure", err: validateExposure(spec.Exposure)},
+		{field: "resources", err: validateResources(spec)},
+		{field: "networkPolicies", err: validateNetworkPolicies(spec.NetworkPolicies)},
+		{field: "repos", err: validateRepoRemotes(spec.Repos)},
+		{field: "ci", err: validateCIPolicy(spec.CI)},
+		{field: "extensions", err: extensionsErr},
+	}
+	issues := []SpecValidationIssue{}
+	for _, check := range checks {
+		if check.err == nil {
+			continue
+		}
+		field := check.field
+		var fieldErr specFieldError
+		if errors.As(check.err, &fieldErr) && fieldErr.Field != "" {
+			field = fieldErr.Field
+		}
+		issues = append(issues, SpecValidationIssue{Field: field, Message: check.err.Error()})
+	}
+	return issues
+}
+
+// specValidationWarnings reports what normalization filled in or dropped,
+// and render choices the caller may not expect. None of them block a write.
+func specValidationWarnings(raw, spec ProjectSpec) []SpecValidationIssue {
+	warnings := []SpecValidationIssue{}
+	warn := func(field, format string, args ...any) {
+		warnings = append(warnings, SpecValidationIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	if strings.TrimSpace(raw.APIVersion) == "" {
+		warn("apiVersion", "not set; defaults to %q", spec.APIVersion)
+	}
+	if strings.TrimSpace(raw.Kind) == "" {
+		warn("kind", "not set; defaults to %q", spec.Kind)
+	}
+	if strings.TrimSpace(raw.NetworkPolicies.Ingress) == "" {
+		warn("networkPolicies.ingress", "not set; defaults to %q", spec.NetworkPolicies.Ingress)
+	}
+	if strings.TrimSpace(raw.NetworkPolicies.Egress) == "" {
+		warn("networkPolicies.egress", "not set; defaults to %q", spec.NetworkPolicies.Egress)
+	}
+	if dropped := len(raw.Capabilities) - len(spec.Capabilities); dropped > 0 {
+		warn("capabilities", "%d blank or repeated entries are dropped", dropped)
+	}
+	for _, envName := range sortedKeys(raw.Environments) {
+		for _, key := range sortedKeys(raw.Environments[envName].Vars) {
+			if _, kept := spec.Environments[envName].Vars[key]; !kept {
+				warn("environments."+envName+".vars."+key, "repeats the shared value in vars and is dropped")
+			}
+		}
+	}
+	if _, ok := spec.Environments["dev"]; !ok && len(spec.Environments) > 0 {
+		envName, _ := preferredEnvironment(spec)
+		warn("environments", "no dev environment; manifests render with the vars of %q", envName)
+	}
+	if spec.Build.strategy() == buildStrategyBuildpacks {
+		warn("build.strategy", "%s builds use no Dockerfile, so none is rendered", buildStrategyBuildpacks)
+	}
+	if host := spec.Exposure.Ingress.Host; host != "" && len(desiredManifestEnvironments(spec)) > 1 &&
+		!strings.Contains(host, ingressEnvPlaceholder) {
+		warn("exposure.ingress.host", "has no %s, so every environment's Ingress claims %s",
+			ingressEnvPlaceholder, host)
+	}
+	return warnings
+}
+
+// renderSpecValidationArtifacts renders the files create writes for spec, at
+// the artifact paths the workers use. Manifests are rendered for the
+// environment the first deploy targets, with its Ingress when the spec sets
+// a host, its HPA when it autoscales, its NetworkPolicy when it needs other
+// projects, and the Helm chart when enabled.
+func renderSpecValidationArtifacts(spec ProjectSpec, image string) []SpecValidationArtifact {
+	envName, _ := preferredEnvironment(spec)
+	deployDir := path.Join("deploy", envName)
+	artifacts := []SpecValidationArtifact{
+		{Path: "registration/project.yaml", Content: string(renderProjectConfigYAML(spec))},
+	}
+	if spec.Build.strategy() != buildStrategyBuildpacks {
+		artifacts = append(artifacts, SpecValidationArtifact{
+			Path:    imageBuildDockerfilePath,
+			Content: string(renderImageBuilderDockerfile(spec)),
+		})
+	}
+	artifacts = append(artifacts,
+		SpecValidationArtifact{
+			Path:    path.Join(deployDir, manifestFileDeployment),
+			Content: renderDeploymentManifest(spec, image),
+		},
+		SpecValidationArtifact{Path: path.Join(deployDir, manifestFileService), Content: renderServiceManifest(spec)},
+	)
+	if spec.Exposure.Ingress.Host != "" {
+		artifacts = append(artifacts, SpecValidationArtifact{
+			Path:    path.Join(deployDir, overlayIngressFile),
+			Content: renderIngressManifest(spec, envName),
+		})
+	}
+	if spec.Environments[envName].Autoscaling.enabled() {
+		artifacts = append(artifacts, SpecValidationArtifact{
+			Path:    path.Join(deployDir, overlayHPAFile),
+			Content: renderHPAManifest(spec, envName),
+		})
+	}
+	if len(spec.Needs) > 0 {
+		artifacts = append(artifacts, SpecValidationArtifact{
+			Path:    path.Join(deployDir, overlayNetworkPolicyFile),
+			Content: renderNetworkPolicyManifest(spec, envName, nil),
+		})
+	}
+	if !spec.Helm.Enabled {
+		return artifacts
+	}
+	chart := helmChartFiles(spec, map[string]string{envName: image}, nil, nil)
+	for _, rel := range sortedKeys(chart) {
+		artifacts = append(artifacts, SpecValidationArtifact{
+			Path:    path.Join(helmChartArtifactDir, rel),
+			Content: chart[rel],
+		})
+	}
+	return artifacts
+}
@@ -0,0 +1,109 @@
+//nolint:testpackage,exhaustruct // Validation tests check the internal store stays empty.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPI_ProjectValidateRendersWithoutPersisting(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	validateURL := srv.URL + "/api/projects/validate"
+	post := func(contentType, body string) (int, SpecValidationResponse) {
+		t.Helper()
+		resp, err := http.Post(validateURL, contentType, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("post validate: %v", err)
+		}
+		defer resp.Body.Close()
+		var out SpecValidationResponse
+		if resp.StatusCode == http.StatusOK {
+			if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+				t.Fatalf("decode validate response: %v", err)
+			}
+		}
+		return resp.StatusCode, out
+	}
+
+	status, invalid := post("application/yaml", `name: Bad Name
+runtime: go_1.26
+environments:
+  dev:
+    vars:
+      lower: "x"
+networkPolicies:
+  ingress: open
+`)
+	if status != http.StatusOK || invalid.Valid || len(invalid.Artifacts) != 0 {
+		t.Fatalf("expected an invalid result without artifacts, got %d %#v", status, invalid)
+	}
+	fields := make([]string, 0, len(invalid.Errors))
+	for _, issue := range invalid.Errors {
+		fields = append(fields, issue.Field)
+	}
+	if strings.Join(fields, ",") != "name,environments.dev.vars.lower,networkPolicies.ingress" {
+		t.Fatalf("expected every failing section to be reported, got %#v", invalid.Errors)
+	}
+
+	status, valid := post("application/json", `{
+		"name": "validate-app",
+		"runtime": "go_1.26",
+		"capabilities": ["postgres", "postgres"],
+		"vars": {"LOG_LEVEL": "info"},
+		"environments": {"staging": {"vars": {"LOG_LEVEL": "info", "REPLICAS": "2"}}},
+		"networkPolicies": {"ingress": "internal", "egress": "none"}
+	}`)
+	if status != http.StatusOK || !valid.Valid || len(valid.Errors) != 0 {
+		t.Fatalf("expected a valid result, got %d %#v", status, valid)
+	}
+	if valid.Spec.APIVersion != projectAPIVersion || valid.SpecHash != projectSpecHash(valid.Spec) {
+		t.Fatalf("expected the normalized spec and its hash, got %#v", valid)
+	}
+	warned := map[string]bool{}
+	for _, issue := range valid.Warnings {
+		warned[issue.Field] = true
+	}
+	for _, field := range []string{
+		"apiVersion", "kind", "capabilities", "environments.staging.vars.LOG_LEVEL", "environments",
+	} {
+		if !warned[field] {
+			t.Fatalf("expected a warning on %s, got %#v", field, valid.Warnings)
+		}
+	}
+	paths := make([]string, 0, len(valid.Artifacts))
+	for _, artifact := range valid.Artifacts {
+		paths = append(paths, artifact.Path)
+	}
+	want := "registration/project.yaml,build/Dockerfile,deploy/staging/deployment.yaml,deploy/staging/service.yaml"
+	if strings.Join(paths, ",") != want {
+		t.Fatalf("expected artifacts %s, got %v", want, paths)
+	}
+	if !strings.Contains(valid.Artifacts[2].Content, valid.Image) ||
+		!strings.Contains(valid.Artifacts[0].Content, "REPLICAS") {
+		t.Fatalf("expected rendered artifacts to reflect the spec, got %#v", valid.Artifacts)
+	}
+
+	if status, _ = post("application/json", `{"name":`); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed body, got %d", status)
+	}
+	resp, err := http.Get(validateURL)
+	if err != nil {
+		t.Fatalf("get validate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET, got %d", resp.StatusCode)
+	}
+	projects, err := fixture.store.ListProjects(context.Background())
+	if err != nil || len(projects) != 0 {
+		t.Fatalf("expected validation to store nothing, got %d projects err=%v", len(projects), err)
+	}
+}
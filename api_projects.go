@@ -24,44 +24,89 @@ import (
 func (a *API) handleProjects(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		filter, sortKey, err := parseProjectListQuery(r.URL.Query())
+		if err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		filter = scopeProjectFilter(r.Context(), filter)
 		projects, err := a.store.ListProjects(r.Context())
 		if err != nil {
-			http.Error(w, "failed to list projects", http.StatusInternalServerError)
+			writeAPIError(w, "failed to list projects", http.StatusInternalServerError)
 			return
 		}
-		writeJSON(w, http.StatusOK, projects)
-
-	case http.MethodPost:
-		var spec ProjectSpec
-		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
-			http.Error(w, "invalid json", http.StatusBadRequest)
+		projects = filterAndSortProjects(projects, filter, sortKey)
+		if !projectListPaginated(r.URL.Query()) {
+			writeJSON(w, http.StatusOK, projects)
 			return
 		}
-		spec = normalizeProjectSpec(spec)
-		if err := validateProjectSpec(spec); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		limit, err := parseProjectListLimitParam(r.URL.Query().Get("limit"))
+		if err != nil {
+			writeBadRequest(w, err)
 			return
 		}
+		page, err := pageProjects(projects, r.URL.Query().Get("cursor"), limit)
+		if err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, page)
 
-		project, op, err := a.createProjectFromSpec(r.Context(), spec)
+	case http.MethodPost:
+		execution, err := opExecutionFromRequest(r)
 		if err != nil {
-			if writeAsyncOpError(w, err) {
-				return
-			}
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeBadRequest(w, err)
 			return
 		}
-		writeJSON(w, http.StatusAccepted, map[string]any{
-			"accepted": true,
-			"project":  project,
-			"op":       op,
-		})
+		if execution.DryRun {
+			// Create stores the project before the op runs, so it cannot be rehearsed.
+			writeAPIError(w, "dry_run is not supported for project creation", http.StatusBadRequest)
+			return
+		}
+		var spec ProjectSpec
+		if err = decodeSpecBody(r, &spec); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		a.createProjectAndRespond(w, r, normalizeProjectSpec(spec), emptyOpRunOptions().withExecution(execution))
 
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
+// createProjectAndRespond creates a project from a decoded spec and writes
+// the 202 every create endpoint answers with.
+func (a *API) createProjectAndRespond(w http.ResponseWriter, r *http.Request, spec ProjectSpec, opts opRunOptions) {
+	if err := a.validateSpec(spec); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	placement, ok := a.requestPlacementOrWriteError(w, r, opts.placement)
+	if !ok {
+		return
+	}
+	opts.placement = placement
+	project, op, err := a.createProjectFromSpec(r.Context(), spec, opts)
+	if err != nil {
+		if writeAsyncOpError(w, err) {
+			return
+		}
+		if isSpecValidationError(err) {
+			writeBadRequest(w, err)
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"accepted": true,
+		"project":  project,
+		"op":       op,
+		"lint":     lintProjectSpec(spec),
+	})
+}
+
 func (a *API) handleProjectByID(w http.ResponseWriter, r *http.Request) {
 	projectID, ok := a.resolveProjectIDFromPath(w, r)
 	if !ok {
@@ -75,87 +120,171 @@ func (a *API) handleProjectByID(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete:
 		a.handleProjectDeleteByID(w, r, projectID)
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
 func (a *API) resolveProjectIDFromPath(w http.ResponseWriter, r *http.Request) (string, bool) {
 	if !strings.HasPrefix(r.URL.Path, "/api/projects/") {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return "", false
 	}
 	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
 	if rest == "" {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return "", false
 	}
 	parts := strings.Split(rest, "/")
 	if len(parts) > 1 {
-		switch parts[1] {
-		case "artifacts":
-			a.handleProjectArtifacts(w, r)
-		case "ops":
-			a.handleProjectOps(w, r)
-		case "releases":
-			a.handleProjectReleases(w, r)
-		case "overview":
-			a.handleProjectOverview(w, r)
-		case "journey":
-			a.handleProjectJourney(w, r)
-		default:
-			http.NotFound(w, r)
-		}
+		a.handleProjectSubresource(w, r, parts[1])
 		return "", false
 	}
 	projectID := strings.TrimSpace(parts[0])
 	if projectID == "" {
-		http.Error(w, "bad project id", http.StatusBadRequest)
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
 		return "", false
 	}
 	return projectID, true
 }
 
+// handleProjectSubresource routes /api/projects/{id}/<name>/... to the
+// handler for name.
+func (a *API) handleProjectSubresource(w http.ResponseWriter, r *http.Request, name string) {
+	switch name {
+	case "artifacts":
+		a.handleProjectArtifacts(w, r)
+	case "ops":
+		a.handleProjectOps(w, r)
+	case "releases":
+		a.handleProjectReleases(w, r)
+	case "overview":
+		a.handleProjectOverview(w, r)
+	case "journey":
+		a.handleProjectJourney(w, r)
+	case "revision":
+		a.handleProjectRevision(w, r)
+	case "promotion-plan":
+		a.handleProjectPromotionPlan(w, r)
+	case "clone":
+		a.handleProjectClone(w, r)
+	case "holds":
+		a.handleProjectHolds(w, r)
+	case "schedules":
+		a.handleProjectSchedules(w, r)
+	case "ownership":
+		a.handleProjectOwnership(w, r)
+	case "var-rollout":
+		a.handleProjectVarRollout(w, r)
+	case "runtime-upgrade":
+		a.handleProjectRuntimeUpgrade(w, r)
+	case "environments":
+		a.handleProjectEnvironments(w, r)
+	case "secrets":
+		a.handleProjectSecrets(w, r)
+	case "delete-plan":
+		a.handleProjectDeletePlan(w, r)
+	case "compliance":
+		a.handleProjectCompliance(w, r)
+	case "at":
+		a.handleProjectAt(w, r)
+	case "events":
+		a.handleProjectEvents(w, r)
+	case "health":
+		a.handleProjectHealth(w, r)
+	case "quota":
+		a.handleProjectQuota(w, r)
+	default:
+		writeAPIError(w, "not found", http.StatusNotFound)
+	}
+}
+
 func (a *API) handleProjectGetByID(w http.ResponseWriter, r *http.Request, projectID string) {
-	project, ok := a.getProjectOrWriteError(w, r, projectID)
-	if !ok {
+	project, rev, err := a.store.getProjectRevision(r.Context(), projectID)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			writeAPIError(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeAPIError(w, "failed to read project", http.StatusInternalServerError)
+		return
+	}
+	if newCacheValidator("project").add(rev).notModified(w, r) {
 		return
 	}
 	writeJSON(w, http.StatusOK, project)
 }
 
 func (a *API) handleProjectUpdateByID(w http.ResponseWriter, r *http.Request, projectID string) {
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
 	var spec ProjectSpec
-	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+	if err = decodeSpecBody(r, &spec); err != nil {
+		writeBadRequest(w, err)
 		return
 	}
 	spec = normalizeProjectSpec(spec)
-	if err := validateProjectSpec(spec); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err = a.validateSpec(spec); err != nil {
+		writeBadRequest(w, err)
 		return
 	}
 
-	if _, ok := a.getProjectOrWriteError(w, r, projectID); !ok {
+	project, rev, ok := a.getProjectRevisionOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	if spec, err = inheritProjectTemplate(project.Spec, spec); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if newCacheValidator("project").add(rev).ifMatchFails(r) {
+		writeProjectRevisionConflict(w, projectRevisionConflictError{
+			ProjectID: projectID,
+			Create:    false,
+			Expected:  0,
+			Current:   project,
+		})
+		return
+	}
+	if !forceUpdateRequested(r) && specUnchanged(project, spec) {
+		writeSpecUnchanged(w, project)
+		return
+	}
+	if err = a.projectNeedsConflict(r.Context(), projectID, project.Org, spec); err != nil {
+		writeRegistrationError(w, err)
 		return
 	}
 
-	op, err := a.enqueueOp(r.Context(), OpUpdate, projectID, spec, emptyOpRunOptions())
+	opts := emptyOpRunOptions().withExecution(execution).withProjectRevision(project.Revision)
+	opts.specChange = planSpecChange(a.artifacts, project, spec)
+	op, err := a.enqueueOp(r.Context(), OpUpdate, projectID, spec, opts)
 	if err != nil {
 		if writeAsyncOpError(w, err) {
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	project, _ := a.store.GetProject(r.Context(), projectID)
+	project, _ = a.store.GetProject(r.Context(), projectID)
+	if project.Revision != 0 {
+		w.Header().Set("ETag", projectETag(projectID, project.Revision))
+	}
 	writeJSON(w, http.StatusAccepted, map[string]any{
 		"accepted": true,
 		"project":  project,
 		"op":       op,
+		"lint":     lintProjectSpec(spec),
 	})
 }
 
 func (a *API) handleProjectDeleteByID(w http.ResponseWriter, r *http.Request, projectID string) {
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
 	if _, ok := a.getProjectOrWriteError(w, r, projectID); !ok {
 		return
 	}
@@ -165,13 +294,13 @@ func (a *API) handleProjectDeleteByID(w http.ResponseWriter, r *http.Request, pr
 		OpDelete,
 		projectID,
 		zeroProjectSpec(),
-		emptyOpRunOptions(),
+		deleteOpRunOptions(r.URL.Query().Get("plan_id"), impactAcknowledged(r)).withExecution(execution),
 	)
 	if err != nil {
 		if writeAsyncOpError(w, err) {
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	writeJSON(w, http.StatusAccepted, map[string]any{
@@ -188,47 +317,55 @@ func (a *API) getProjectOrWriteError(
 	projectID string,
 ) (Project, bool) {
 	project, err := a.store.GetProject(r.Context(), projectID)
-	if err == nil {
+	if err == nil && orgVisible(r.Context(), project.Org) {
 		return project, true
 	}
-	if errors.Is(err, jetstream.ErrKeyNotFound) {
-		http.Error(w, "not found", http.StatusNotFound)
+	if err == nil || errors.Is(err, jetstream.ErrKeyNotFound) {
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return Project{}, false
 	}
-	http.Error(w, "failed to read project", http.StatusInternalServerError)
+	writeAPIError(w, "failed to read project", http.StatusInternalServerError)
 	return Project{}, false
 }
 
 func (a *API) handleProjectReleases(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	if a.store == nil {
-		http.Error(w, "release data unavailable", http.StatusInternalServerError)
+		writeAPIError(w, "release data unavailable", http.StatusInternalServerError)
 		return
 	}
 	if !strings.HasPrefix(r.URL.Path, "/api/projects/") {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 
 	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
 	parts := strings.Split(rest, "/")
 	if len(parts) < projectRelPathPartsMin || parts[1] != "releases" {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 
 	projectID := strings.TrimSpace(parts[0])
 	if projectID == "" {
-		http.Error(w, "bad project id", http.StatusBadRequest)
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
 		return
 	}
 	project, ok := a.getProjectOrWriteError(w, r, projectID)
 	if !ok {
 		return
 	}
+	if len(parts) == projectRelPathPartsMin+2 && parts[3] == "notes" {
+		a.handleProjectReleaseNotes(w, r, project.ID, strings.TrimSpace(parts[2]))
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
 	if len(parts) == projectRelPathPartsMin {
 		a.handleProjectReleaseList(w, r, project)
@@ -242,18 +379,18 @@ func (a *API) handleProjectReleases(w http.ResponseWriter, r *http.Request) {
 		a.handleProjectReleaseDetail(w, r, projectID, strings.TrimSpace(parts[2]))
 		return
 	}
-	http.NotFound(w, r)
+	writeAPIError(w, "not found", http.StatusNotFound)
 }
 
 func (a *API) handleProjectReleaseList(w http.ResponseWriter, r *http.Request, project Project) {
 	environmentRaw := normalizeEnvironmentName(r.URL.Query().Get("environment"))
 	if environmentRaw == "" {
-		http.Error(w, "environment query parameter required", http.StatusBadRequest)
+		writeAPIError(w, "environment query parameter required", http.StatusBadRequest)
 		return
 	}
 	environment, ok := resolveProjectEnvironmentName(project.Spec, environmentRaw)
 	if !ok {
-		http.Error(
+		writeAPIError(
 			w,
 			fmt.Sprintf("environment %q is not defined for project", environmentRaw),
 			http.StatusBadRequest,
@@ -263,7 +400,16 @@ func (a *API) handleProjectReleaseList(w http.ResponseWriter, r *http.Request, p
 
 	limit, err := parseProjectReleaseLimitParam(r.URL.Query().Get("limit"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeBadRequest(w, err)
+		return
+	}
+	indexRev, err := a.store.opsKeyRevision(r.Context(), projectReleaseIndexKey(project.ID, environment))
+	if err != nil {
+		writeAPIError(w, "failed to list releases", http.StatusInternalServerError)
+		return
+	}
+	validator := newCacheValidator("releases").add(indexRev).addQuery(r, "limit", "cursor")
+	if validator.notModified(w, r) {
 		return
 	}
 
@@ -277,7 +423,7 @@ func (a *API) handleProjectReleaseList(w http.ResponseWriter, r *http.Request, p
 		},
 	)
 	if err != nil {
-		http.Error(w, "failed to list releases", http.StatusInternalServerError)
+		writeAPIError(w, "failed to list releases", http.StatusInternalServerError)
 		return
 	}
 
@@ -291,60 +437,74 @@ func (a *API) handleProjectReleaseDetail(
 	releaseID string,
 ) {
 	if releaseID == "" {
-		http.Error(w, "bad release id", http.StatusBadRequest)
+		writeAPIError(w, "bad release id", http.StatusBadRequest)
 		return
 	}
-	release, err := a.store.GetRelease(r.Context(), releaseID)
+	release, releaseRev, err := a.store.getReleaseRevision(r.Context(), releaseID)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
-			http.Error(w, "not found", http.StatusNotFound)
+			writeAPIError(w, "not found", http.StatusNotFound)
 			return
 		}
-		http.Error(w, "failed to read release", http.StatusInternalServerError)
+		writeAPIError(w, "failed to read release", http.StatusInternalServerError)
 		return
 	}
 	if strings.TrimSpace(release.ProjectID) != strings.TrimSpace(projectID) {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	// The detail embeds the release's hold, so a hold change is a new version.
+	holdsRev, err := a.store.opsKeyRevision(r.Context(), projectHoldsKey(release.ProjectID))
+	if err != nil {
+		writeAPIError(w, "failed to read release holds", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, http.StatusOK, release)
+	if newCacheValidator("release").add(releaseRev).add(holdsRev).notModified(w, r) {
+		return
+	}
+	detail, err := a.releaseDetailWithHold(r.Context(), release)
+	if err != nil {
+		writeAPIError(w, "failed to read release holds", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, detail)
 }
 
 func (a *API) handleProjectReleaseCompare(w http.ResponseWriter, r *http.Request, projectID string) {
 	fromID := strings.TrimSpace(r.URL.Query().Get("from"))
 	toID := strings.TrimSpace(r.URL.Query().Get("to"))
 	if fromID == "" || toID == "" {
-		http.Error(w, "from and to query parameters are required", http.StatusBadRequest)
+		writeAPIError(w, "from and to query parameters are required", http.StatusBadRequest)
 		return
 	}
 
 	fromRelease, err := a.store.GetRelease(r.Context(), fromID)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
-			http.Error(w, "not found", http.StatusNotFound)
+			writeAPIError(w, "not found", http.StatusNotFound)
 			return
 		}
-		http.Error(w, "failed to read release", http.StatusInternalServerError)
+		writeAPIError(w, "failed to read release", http.StatusInternalServerError)
 		return
 	}
 	toRelease, err := a.store.GetRelease(r.Context(), toID)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
-			http.Error(w, "not found", http.StatusNotFound)
+			writeAPIError(w, "not found", http.StatusNotFound)
 			return
 		}
-		http.Error(w, "failed to read release", http.StatusInternalServerError)
+		writeAPIError(w, "failed to read release", http.StatusInternalServerError)
 		return
 	}
 	if strings.TrimSpace(fromRelease.ProjectID) != strings.TrimSpace(projectID) ||
 		strings.TrimSpace(toRelease.ProjectID) != strings.TrimSpace(projectID) {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return
 	}
 
 	response, err := a.buildReleaseCompareResponseFromRecords(r.Context(), projectID, fromRelease, toRelease)
 	if err != nil {
-		http.Error(w, "failed to compare releases", http.StatusInternalServerError)
+		writeAPIError(w, "failed to compare releases", http.StatusInternalServerError)
 		return
 	}
 	writeJSON(w, http.StatusOK, response)
@@ -418,8 +578,18 @@ func (a *API) buildReleaseCompareResponseFromRecords(
 		Updated: nil,
 	}
 
+	specDelta := ReleaseCompareDelta{
+		Changed: fromRelease.SpecHash != "" && toRelease.SpecHash != "" && fromRelease.SpecHash != toRelease.SpecHash,
+		From:    fromRelease.SpecHash,
+		To:      toRelease.SpecHash,
+		Added:   nil,
+		Removed: nil,
+		Updated: nil,
+	}
+
 	fromCopy := fromRelease
 	toCopy := toRelease
+	notes := a.compareReleaseNotes(ctx, projectID, fromRelease, toRelease, imageDelta, configDelta)
 	return ReleaseCompareResponse{
 		FromID:      strings.TrimSpace(fromRelease.ID),
 		ToID:        strings.TrimSpace(toRelease.ID),
@@ -436,9 +606,44 @@ func (a *API) buildReleaseCompareResponseFromRecords(
 		ImageDelta:    imageDelta,
 		ConfigDelta:   configDelta,
 		RenderedDelta: renderedDelta,
+		SpecDelta:     specDelta,
+		Notes:         notes,
 	}, nil
 }
 
+// compareReleaseNotes drafts notes from from's source commit up to to's.
+// They are best effort: a source repo that cannot be read leaves them
+// without commits. When to's commit does not descend from from's, the log
+// runs back to the limit.
+func (a *API) compareReleaseNotes(
+	ctx context.Context,
+	projectID string,
+	fromRelease, toRelease ReleaseRecord,
+	imageDelta, configDelta ReleaseCompareDelta,
+) *ReleaseNotes {
+	notes := &ReleaseNotes{
+		FromCommit:        fromRelease.SourceCommit,
+		ToCommit:          toRelease.SourceCommit,
+		Sections:          []ReleaseNotesSection{},
+		Truncated:         false,
+		Text:              "",
+		EditedBy:          "",
+		EditedAt:          time.Time{},
+		PreviousReleaseID: fromRelease.ID,
+		Image:             &imageDelta,
+		Config:            &configDelta,
+		Path:              "",
+	}
+	if a.artifacts != nil {
+		if err := addReleaseNoteCommits(ctx, a.artifacts, projectID, notes); err != nil {
+			appLoggerForProcess().Source("releases").
+				Warnf("compare %s..%s: draft notes failed: %v", fromRelease.ID, toRelease.ID, err)
+		}
+	}
+	notes.Text = renderReleaseNotes(notes)
+	return notes
+}
+
 func (a *API) readReleaseConfigVars(
 	ctx context.Context,
 	projectID string,
@@ -459,7 +664,17 @@ func (a *API) readReleaseDeploymentSnapshot(
 	if a == nil || a.artifacts == nil {
 		return nil, nil
 	}
+	return releaseDeploymentSnapshot(a.artifacts, projectID, release)
+}
+
+// releaseDeploymentSnapshot reads the Deployment a release rendered: the
+// copy its notes keep, or else the config and rendered paths it recorded,
+// which later deliveries into the same place overwrite.
+func releaseDeploymentSnapshot(artifacts ArtifactStore, projectID string, release ReleaseRecord) ([]byte, error) {
 	paths := []string{}
+	if release.ID != "" {
+		paths = append(paths, releaseConfigSnapshotPath(release.ID))
+	}
 	if path := strings.Trim(strings.TrimSpace(release.ConfigPath), "/"); path != "" {
 		paths = append(paths, path)
 	}
@@ -470,7 +685,7 @@ func (a *API) readReleaseDeploymentSnapshot(
 		paths = append(paths, renderedPath)
 	}
 	for _, path := range paths {
-		raw, err := a.artifacts.ReadFile(projectID, path)
+		raw, err := artifacts.ReadFile(projectID, path)
 		if err == nil {
 			return raw, nil
 		}
@@ -723,6 +938,8 @@ func shouldDropManifestCompareAnnotation(key string) bool {
 	switch key {
 	case "kubectl.kubernetes.io/last-applied-configuration", "deployment.kubernetes.io/revision":
 		return true
+	case traceAnnotationOpID, traceAnnotationReleaseID, traceAnnotationPlatformVersion:
+		return true
 	default:
 		return false
 	}
@@ -753,8 +970,11 @@ type projectJourney struct {
 	Summary        string                     `json:"summary"`
 	Milestones     []projectJourneyMilestone  `json:"milestones"`
 	Environments   []projectJourneyEnv        `json:"environments"`
+	Previews       []Preview                  `json:"previews"`
 	NextAction     projectJourneyNextAction   `json:"next_action"`
 	ArtifactStats  projectJourneyArtifactStat `json:"artifact_stats"`
+	Evidence       []string                   `json:"evidence"`
+	Lint           []SpecLintWarning          `json:"lint"`
 	RecentOp       *Operation                 `json:"recent_operation,omitempty"`
 	LastUpdateTime time.Time                  `json:"last_update_time"`
 }
@@ -767,13 +987,14 @@ type projectJourneyMilestone struct {
 }
 
 type projectJourneyEnv struct {
-	Name         string `json:"name"`
-	State        string `json:"state"` // live | pending
-	Image        string `json:"image,omitempty"`
-	ImageSource  string `json:"image_source,omitempty"`
-	DeliveryType string `json:"delivery_type,omitempty"` // deploy | promote | release
-	DeliveryPath string `json:"delivery_path,omitempty"`
-	Detail       string `json:"detail"`
+	Name         string             `json:"name"`
+	State        string             `json:"state"` // live | pending
+	Image        string             `json:"image,omitempty"`
+	ImageSource  string             `json:"image_source,omitempty"`
+	DeliveryType string             `json:"delivery_type,omitempty"` // deploy | promote | release
+	DeliveryPath string             `json:"delivery_path,omitempty"`
+	Detail       string             `json:"detail"`
+	Scaling      environmentScaling `json:"scaling"`
 }
 
 type projectJourneyNextAction struct {
@@ -793,24 +1014,30 @@ type projectJourneyArtifactStat struct {
 	Release      int `json:"release"`
 	Repository   int `json:"repository"`
 	Registration int `json:"registration"`
+	Evidence     int `json:"evidence"`
 	Other        int `json:"other"`
 }
 
 type projectOverview struct {
 	Summary      string               `json:"summary"`
+	Ownership    ProjectOwnership     `json:"ownership"`
 	Environments []projectOverviewEnv `json:"environments"`
+	Previews     []Preview            `json:"previews"`
 }
 
 type projectOverviewEnv struct {
-	Name             string     `json:"name"`
-	HealthStatus     string     `json:"health_status"`
-	DeliveryState    string     `json:"delivery_state"`
-	RunningImage     string     `json:"running_image,omitempty"`
-	DeliveryType     string     `json:"delivery_type"`
-	DeliveryPath     string     `json:"delivery_path,omitempty"`
-	ConfigReadiness  string     `json:"config_readiness"`
-	SecretsReadiness string     `json:"secrets_readiness"`
-	LastDeliveryAt   *time.Time `json:"last_delivery_at,omitempty"`
+	Name             string             `json:"name"`
+	HealthStatus     string             `json:"health_status"`
+	DeliveryState    string             `json:"delivery_state"`
+	RunningImage     string             `json:"running_image,omitempty"`
+	DeliveryType     string             `json:"delivery_type"`
+	DeliveryPath     string             `json:"delivery_path,omitempty"`
+	ConfigReadiness  string             `json:"config_readiness"`
+	SecretsReadiness string             `json:"secrets_readiness"`
+	URL              string             `json:"url"`
+	URLScope         string             `json:"url_scope"` // ingress | cluster
+	Scaling          environmentScaling `json:"scaling"`
+	LastDeliveryAt   *time.Time         `json:"last_delivery_at,omitempty"`
 }
 
 type projectReleaseListResponse struct {
@@ -848,7 +1075,10 @@ const (
 	overviewDeliveryTypeNone   = "none"
 	overviewConfigReadinessOK  = "ok"
 	overviewConfigReadinessUnk = "unknown"
-	overviewSecretsUnsupported = "unsupported"
+	overviewSecretsNone        = "none"
+	overviewSecretsReady       = "ready"
+	overviewSecretsExternal    = "external"
+	overviewSecretsLocked      = "locked"
 )
 
 func (a *API) handleProjectOverview(w http.ResponseWriter, r *http.Request) {
@@ -889,11 +1119,11 @@ func (a *API) handleProjectReadModel(
 	build func(context.Context, Project, []string) (any, error),
 ) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	if a.store == nil || a.artifacts == nil {
-		http.Error(w, unavailableMessage, http.StatusInternalServerError)
+		writeAPIError(w, unavailableMessage, http.StatusInternalServerError)
 		return
 	}
 
@@ -908,13 +1138,13 @@ func (a *API) handleProjectReadModel(
 	}
 	files, err := a.artifacts.ListFiles(projectID)
 	if err != nil {
-		http.Error(w, "failed to list artifacts", http.StatusInternalServerError)
+		writeAPIError(w, "failed to list artifacts", http.StatusInternalServerError)
 		return
 	}
 
 	readModel, err := build(r.Context(), project, files)
 	if err != nil {
-		http.Error(w, buildFailureMessage, http.StatusInternalServerError)
+		writeAPIError(w, buildFailureMessage, http.StatusInternalServerError)
 		return
 	}
 
@@ -927,19 +1157,19 @@ func (a *API) handleProjectReadModel(
 
 func projectIDFromSubresourcePath(w http.ResponseWriter, r *http.Request, subresource string) (string, bool) {
 	if !strings.HasPrefix(r.URL.Path, "/api/projects/") {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return "", false
 	}
 	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
 	parts := strings.Split(rest, "/")
 	if len(parts) != journeyPathPartsExpected || parts[1] != subresource {
-		http.NotFound(w, r)
+		writeAPIError(w, "not found", http.StatusNotFound)
 		return "", false
 	}
 
 	projectID := strings.TrimSpace(parts[0])
 	if projectID == "" {
-		http.Error(w, "bad project id", http.StatusBadRequest)
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
 		return "", false
 	}
 	return projectID, true
@@ -955,14 +1185,29 @@ func (a *API) buildProjectOverview(
 		return projectOverview{}, err
 	}
 
+	storedSecrets, err := loadEnvStoredSecrets(ctx, a.store, project.ID)
+	if err != nil {
+		return projectOverview{}, err
+	}
+	health, err := a.store.getProjectHealth(ctx, project.ID)
+	if err != nil {
+		return projectOverview{}, err
+	}
+	now := time.Now().UTC()
 	envs := make([]projectOverviewEnv, 0, len(journey.Environments))
 	for _, env := range journey.Environments {
-		envs = append(envs, buildOverviewEnvironment(project, env, journey.RecentOp))
+		overviewEnv := buildOverviewEnvironment(project, env, journey.RecentOp, storedSecrets[env.Name])
+		if probed, ok := health.Environments[env.Name]; ok {
+			overviewEnv.HealthStatus = overviewProbedHealthStatus(probed.withCurrent(now), overviewEnv.HealthStatus)
+		}
+		envs = append(envs, overviewEnv)
 	}
 
 	return projectOverview{
 		Summary:      journey.Summary,
+		Ownership:    project.Ownership,
 		Environments: envs,
+		Previews:     journey.Previews,
 	}, nil
 }
 
@@ -970,6 +1215,7 @@ func buildOverviewEnvironment(
 	project Project,
 	journeyEnv projectJourneyEnv,
 	recentOp *Operation,
+	storedSecrets []string,
 ) projectOverviewEnv {
 	deliveryType := strings.TrimSpace(journeyEnv.DeliveryType)
 	if deliveryType == "" {
@@ -980,6 +1226,7 @@ func buildOverviewEnvironment(
 	if journeyEnv.State == journeyEnvStateLive || strings.TrimSpace(journeyEnv.DeliveryPath) != "" {
 		configReadiness = overviewConfigReadinessOK
 	}
+	envURL, urlScope := environmentURL(project.Spec, journeyEnv.Name)
 
 	return projectOverviewEnv{
 		Name:             journeyEnv.Name,
@@ -989,11 +1236,52 @@ func buildOverviewEnvironment(
 		DeliveryType:     deliveryType,
 		DeliveryPath:     journeyEnv.DeliveryPath,
 		ConfigReadiness:  configReadiness,
-		SecretsReadiness: overviewSecretsUnsupported,
+		SecretsReadiness: overviewSecretsReadiness(project.Spec, journeyEnv.Name, storedSecrets),
+		URL:              envURL,
+		URLScope:         urlScope,
+		Scaling:          journeyEnv.Scaling,
 		LastDeliveryAt:   overviewLastDeliveryAt(journeyEnv.Name, recentOp),
 	}
 }
 
+// overviewSecretsReadiness says whether env's secrets can be resolved at
+// render time: stored secrets need PAAS_SECRETS_KEY, and external references
+// are only fetched when a render runs, so they are reported as such.
+func overviewSecretsReadiness(spec ProjectSpec, env string, stored []string) string {
+	spec = normalizeProjectSpec(spec)
+	stored = activeStoredSecretNames(spec, env, stored)
+	if len(stored) > 0 {
+		if _, err := storedSecretsCipher(); err != nil {
+			return overviewSecretsLocked
+		}
+	}
+	switch {
+	case len(spec.Environments[env].Secrets) > 0:
+		return overviewSecretsExternal
+	case len(stored) > 0:
+		return overviewSecretsReady
+	default:
+		return overviewSecretsNone
+	}
+}
+
+// overviewProbedHealthStatus reports a current probe result in place of
+// inferred, the status overviewHealthStatus guessed from the project phase.
+// An environment that answered its last probe but missed some in the window
+// is degraded.
+func overviewProbedHealthStatus(probed EnvironmentHealth, inferred string) string {
+	switch {
+	case !probed.Current:
+		return inferred
+	case probed.Status == healthStatusFailing:
+		return overviewHealthFailing
+	case probed.AvailabilityPercent < percentScale:
+		return overviewHealthDegraded
+	default:
+		return overviewHealthHealthy
+	}
+}
+
 func overviewHealthStatus(project Project, env projectJourneyEnv) string {
 	switch {
 	case project.Status.Phase == projectPhaseError:
@@ -1047,7 +1335,8 @@ func isRecentDeliveryForEnvironment(op Operation, env string) bool {
 			delivered = normalizeEnvironmentName(op.Delivery.ToEnv)
 		}
 		return delivered != "" && delivered == target
-	case OpCreate, OpUpdate, OpDelete, OpCI:
+	case OpCreate, OpUpdate, OpDelete, OpCI, OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade,
+		OpWebhookRefresh:
 		return false
 	default:
 		return false
@@ -1086,6 +1375,12 @@ func (a *API) buildProjectJourney(
 	}
 
 	artifactStats := summarizeArtifacts(files)
+	evidence := []string{}
+	for _, path := range files {
+		if isEvidencePath(path) {
+			evidence = append(evidence, path)
+		}
+	}
 	recentOp, foundRecentOp, err := a.readRecentOp(ctx, project.Status.LastOpID)
 	if err != nil {
 		return projectJourney{}, err
@@ -1097,6 +1392,11 @@ func (a *API) buildProjectJourney(
 		recentOpPtr = &recentOpCopy
 	}
 
+	previews, err := a.listPreviews(ctx, project.ID)
+	if err != nil {
+		return projectJourney{}, err
+	}
+
 	milestones := buildJourneyMilestones(project, buildImage, envs)
 	next := recommendJourneyAction(project, buildImage, envs)
 
@@ -1104,8 +1404,11 @@ func (a *API) buildProjectJourney(
 		Summary:        describeJourneySummary(project, buildImage, envs),
 		Milestones:     milestones,
 		Environments:   envs,
+		Previews:       previews,
 		NextAction:     next,
 		ArtifactStats:  artifactStats,
+		Evidence:       evidence,
+		Lint:           lintProjectSpec(project.Spec),
 		RecentOp:       recentOpPtr,
 		LastUpdateTime: time.Now().UTC(),
 	}, nil
@@ -1132,6 +1435,7 @@ func (a *API) buildJourneyEnvironment(
 		DeliveryType: deliveryType,
 		DeliveryPath: deliveryPath,
 		Detail:       detail,
+		Scaling:      environmentScalingFor(project.Spec, env),
 	}, nil
 }
 
@@ -1339,6 +1643,7 @@ func summarizeArtifacts(files []string) projectJourneyArtifactStat {
 		Release:      0,
 		Repository:   0,
 		Registration: 0,
+		Evidence:     0,
 		Other:        0,
 	}
 	for _, file := range files {
@@ -1355,6 +1660,8 @@ func summarizeArtifacts(files []string) projectJourneyArtifactStat {
 			stats.Repository++
 		case strings.HasPrefix(file, "registration/"):
 			stats.Registration++
+		case strings.HasPrefix(file, evidenceArtifactRoot+"/"):
+			stats.Evidence++
 		default:
 			stats.Other++
 		}
@@ -22,6 +22,8 @@ type projectOverviewEnvForTest struct {
 	DeliveryPath     string `json:"delivery_path"`
 	ConfigReadiness  string `json:"config_readiness"`
 	SecretsReadiness string `json:"secrets_readiness"`
+	URL              string `json:"url"`
+	URLScope         string `json:"url_scope"`
 	LastDeliveryAt   string `json:"last_delivery_at"`
 }
 
@@ -227,8 +229,12 @@ func assertProjectOverviewReadModelForTest(t *testing.T, overview projectOvervie
 	if got := overview.Environments[1].ConfigReadiness; got != "ok" {
 		t.Fatalf("expected staging config_readiness ok, got %q", got)
 	}
-	if got := overview.Environments[0].SecretsReadiness; got != "unsupported" {
-		t.Fatalf("expected secrets_readiness unsupported, got %q", got)
+	if got := overview.Environments[0].SecretsReadiness; got != "none" {
+		t.Fatalf("expected secrets_readiness none, got %q", got)
+	}
+	if dev := overview.Environments[0]; dev.URL != "http://overview-app.overview-app-dev.svc.cluster.local/" ||
+		dev.URLScope != "cluster" {
+		t.Fatalf("expected the in-cluster service url for dev, got %q (%s)", dev.URL, dev.URLScope)
 	}
 	if strings.TrimSpace(overview.Environments[1].LastDeliveryAt) == "" {
 		t.Fatal("expected last_delivery_at for staging")
@@ -0,0 +1,425 @@
+package platform
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Fan-out promotion: one source environment promoted to several targets at
+// once (dev → staging-eu and staging-us). The API runs the parent op itself
+// and starts a child promote/release op per target together. Targets are
+// independent: one failing neither stops nor undoes the others, and the
+// parent reports which ones made it.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	promotionFanoutMinTargets  = 2
+	promotionFanoutMaxTargets  = 8
+	promotionFanoutTimeout     = 30 * time.Minute
+	promotionFanoutStepPrefix  = "fanout."
+	promotionFanoutHoldMessage = "fan-out promotion in progress"
+
+	promotionFanoutTargetPending = "pending"
+	promotionFanoutSucceeded     = "succeeded"
+	promotionFanoutPartial       = "partial"
+	promotionFanoutFailed        = "failed"
+)
+
+// handlePromotionFanout is POST /api/events/promotion with to_envs in place
+// of to_env.
+func (a *API) handlePromotionFanout(w http.ResponseWriter, r *http.Request, evt PromotionEvent) {
+	if strings.TrimSpace(evt.ToEnv) != "" {
+		writeAPIError(w, "set to_env or to_envs, not both", http.StatusBadRequest)
+		return
+	}
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if execution.DryRun {
+		writeAPIError(w, "dry_run is not supported for fan-out promotions", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, strings.TrimSpace(evt.ProjectID))
+	if !ok {
+		return
+	}
+	fanout, lifecycle, err := newPromotionFanout(project, evt.FromEnv, evt.ToEnvs)
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+	override, overridden, err := a.transitionVulnerabilityGate(r, lifecycle, evt.VulnerabilityOverride)
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+	var vulnOverride *VulnerabilityOverride
+	if overridden {
+		vulnOverride = &override
+	}
+	requested, err := emptyOpRunOptions().withFreezeOverride(r, evt.FreezeOverride)
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+
+	op, err := a.startPromotionFanout(r.Context(), project, fanout, execution, vulnOverride, requested.freezeOverride)
+	if err != nil {
+		writeTransitionError(w, err)
+		return
+	}
+	a.auditVulnerabilityOverride(op)
+	go a.runPromotionFanout(context.WithoutCancel(r.Context()), op, lifecycle.spec)
+
+	project, _ = a.store.GetProject(r.Context(), project.ID)
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"accepted": true,
+		"project":  project,
+		"op":       op,
+	})
+}
+
+// newPromotionFanout checks every target the way a single promotion checks
+// its to_env. The returned lifecycle is the first target's; the source, and
+// so the vulnerability gate, is the same for all of them.
+func newPromotionFanout(
+	project Project,
+	fromEnvRaw string,
+	toEnvsRaw []string,
+) (PromotionFanout, transitionLifecycleContext, error) {
+	fanout := PromotionFanout{FromEnv: "", Outcome: "", Targets: []PromotionFanoutTarget{}}
+	var lifecycle transitionLifecycleContext
+	if len(toEnvsRaw) < promotionFanoutMinTargets || len(toEnvsRaw) > promotionFanoutMaxTargets {
+		return fanout, lifecycle, requestError(http.StatusBadRequest, fmt.Sprintf(
+			"to_envs must list %d to %d environments; use to_env for one",
+			promotionFanoutMinTargets, promotionFanoutMaxTargets,
+		))
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	for _, raw := range toEnvsRaw {
+		fromEnv, toEnv, stage, kind, err := resolveTransitionRequest(spec, fromEnvRaw, raw, false)
+		if err != nil {
+			return fanout, lifecycle, err
+		}
+		if kind == OpRelease && releaseApprovalsRequired() > 0 {
+			return fanout, lifecycle, requestError(http.StatusBadRequest, fmt.Sprintf(
+				"to_envs cannot include %q while production releases need approval; release it on its own", toEnv,
+			))
+		}
+		if slices.ContainsFunc(fanout.Targets, func(t PromotionFanoutTarget) bool { return t.Environment == toEnv }) {
+			return fanout, lifecycle, requestError(
+				http.StatusBadRequest, fmt.Sprintf("environment %q is listed more than once in to_envs", toEnv),
+			)
+		}
+		if len(fanout.Targets) == 0 {
+			lifecycle = transitionLifecycleContext{
+				project: project,
+				spec:    spec,
+				fromEnv: fromEnv,
+				toEnv:   toEnv,
+				stage:   stage,
+				kind:    kind,
+			}
+		}
+		fanout.FromEnv = fromEnv
+		fanout.Targets = append(fanout.Targets, PromotionFanoutTarget{
+			Environment: toEnv,
+			OpKind:      kind,
+			OpID:        "",
+			Status:      promotionFanoutTargetPending,
+			Error:       "",
+		})
+	}
+	return fanout, lifecycle, nil
+}
+
+// startPromotionFanout records the parent op and points the project at it.
+// Nothing is published: the targets' child ops are what reach the workers.
+func (a *API) startPromotionFanout(
+	ctx context.Context,
+	project Project,
+	fanout PromotionFanout,
+	execution OpExecution,
+	vulnOverride *VulnerabilityOverride,
+	freezeOverride *FreezeOverride,
+) (Operation, error) {
+	if err := a.readiness.admit(); err != nil {
+		return Operation{}, err
+	}
+	projectMu := a.projectStartLock(project.ID)
+	projectMu.Lock()
+	defer projectMu.Unlock()
+	if err := a.projectOperationConflict(ctx, project.ID, OpPromoteFanout); err != nil {
+		return Operation{}, err
+	}
+	if err := a.authorizeProjectChange(ctx, project, string(OpPromoteFanout)); err != nil {
+		return Operation{}, err
+	}
+
+	now := time.Now().UTC()
+	op := Operation{
+		ID:        newID(),
+		Kind:      OpPromoteFanout,
+		ProjectID: project.ID,
+		Delivery: DeliveryLifecycle{
+			Stage:           "",
+			Environment:     "",
+			FromEnv:         fanout.FromEnv,
+			ToEnv:           "",
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
+		},
+		Execution:             execution,
+		Requested:             now,
+		Finished:              time.Time{},
+		Status:                statusMessageQueued,
+		Error:                 "",
+		Steps:                 []OpStep{},
+		VulnerabilityOverride: vulnOverride,
+		FreezeOverride:        freezeOverride,
+		ApprovalID:            "",
+		Notes:                 nil,
+		SpecHash:              "",
+		ParentOpID:            "",
+		Rollout:               nil,
+		Fanout:                &fanout,
+		SpecChange:            nil,
+		Remediation:           nil,
+		RemediationOf:         "",
+		ScheduleID:            "",
+		CloneOf:               "",
+		CI:                    nil,
+		Upgrade:               nil,
+		Webhook:               nil,
+		SLABreached:           false,
+		SLABreaches:           nil,
+	}
+	if err := a.store.PutOp(ctx, op); err != nil {
+		return Operation{}, fmt.Errorf("persist op: %w", err)
+	}
+	a.setQueuedProjectStatus(ctx, op.ID, OpPromoteFanout, project.ID, project.Spec, now)
+	appLoggerForProcess().Source("api").Infof(
+		"queued op=%s kind=%s project=%s from=%s targets=%d",
+		op.ID, op.Kind, project.ID, fanout.FromEnv, len(fanout.Targets),
+	)
+	emitOpBootstrap(a.opEvents, op, "fan-out promotion accepted")
+	emitOpStatus(a.opEvents, op, "queued")
+	return op, nil
+}
+
+// runPromotionFanout starts every target, waits for all of them, and
+// finalizes the parent with the aggregate outcome.
+func (a *API) runPromotionFanout(ctx context.Context, parent Operation, spec ProjectSpec) {
+	for i := range parent.Fanout.Targets {
+		a.startPromotionFanoutTarget(ctx, parent, i, spec)
+	}
+	if !a.waitPromotionFanout(ctx, parent.ID) {
+		return
+	}
+
+	op, active := a.activePromotionFanout(ctx, parent.ID)
+	if !active {
+		return
+	}
+	outcome, failures := promotionFanoutOutcome(op.Fanout.Targets)
+	a.editPromotionFanout(ctx, parent.ID, func(op *Operation) {
+		op.Fanout.Outcome = outcome
+	})
+	status, errMsg := opStatusDone, ""
+	switch outcome {
+	case promotionFanoutPartial:
+		status, errMsg = opStatusError, "fan-out promotion partially failed: "+strings.Join(failures, "; ")
+	case promotionFanoutFailed:
+		status, errMsg = opStatusError, "fan-out promotion failed: "+strings.Join(failures, "; ")
+	}
+	if err := finalizeOp(ctx, a.store, parent.ID, parent.ProjectID, OpPromoteFanout, status, errMsg); err != nil {
+		appLoggerForProcess().Source("api").Errorf("finalize fan-out promotion op=%s: %v", parent.ID, err)
+	}
+}
+
+// startPromotionFanoutTarget queues the child op of one target. A target
+// that cannot be queued fails on its own; the others still start.
+func (a *API) startPromotionFanoutTarget(ctx context.Context, parent Operation, index int, spec ProjectSpec) {
+	if _, active := a.activePromotionFanout(ctx, parent.ID); !active {
+		return
+	}
+	target := parent.Fanout.Targets[index]
+	worker := promotionFanoutStepPrefix + target.Environment
+	if err := markOpStepStart(
+		ctx, a.store, parent.ID, worker, time.Now().UTC(),
+		"promote "+parent.Fanout.FromEnv+" to "+target.Environment,
+	); err != nil {
+		return
+	}
+	opts := transitionOpRunOptions(
+		parent.Fanout.FromEnv, target.Environment, transitionDeliveryStage(target.Environment),
+	).withExecution(parent.Execution)
+	opts.vulnOverride = parent.VulnerabilityOverride
+	opts.freezeOverride = parent.FreezeOverride
+	opts.parentOpID = parent.ID
+	child, err := a.enqueueOp(ctx, target.OpKind, parent.ProjectID, spec, opts)
+	if err != nil {
+		a.endPromotionFanoutTarget(ctx, parent.ID, index, opStatusError, err.Error())
+		return
+	}
+	a.editPromotionFanout(ctx, parent.ID, func(op *Operation) {
+		op.Fanout.Targets[index].OpID = child.ID
+		op.Fanout.Targets[index].Status = opStatusRunning
+	})
+}
+
+// waitPromotionFanout polls the running targets until each child op ends.
+// It reports false when the parent stopped first, after cancelling the
+// children still running.
+func (a *API) waitPromotionFanout(ctx context.Context, parentID string) bool {
+	ticker := time.NewTicker(varRolloutPollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(promotionFanoutTimeout)
+	for {
+		parent, active := a.activePromotionFanout(ctx, parentID)
+		if !active {
+			a.cancelPromotionFanoutChildren(ctx, parent)
+			return false
+		}
+		running := 0
+		for i, target := range parent.Fanout.Targets {
+			if target.Status != opStatusRunning {
+				continue
+			}
+			child, err := a.store.GetOp(ctx, target.OpID)
+			switch {
+			case err == nil && isOperationStatusTerminal(child.Status):
+				a.endPromotionFanoutTarget(ctx, parentID, i, child.Status, child.Error)
+			case time.Now().After(deadline):
+				if err == nil {
+					a.cancelVarRolloutChild(ctx, child)
+				}
+				a.endPromotionFanoutTarget(ctx, parentID, i, opStatusError, fmt.Sprintf(
+					"%s op %s did not finish within %s", target.OpKind, target.OpID, promotionFanoutTimeout,
+				))
+			default:
+				running++
+			}
+		}
+		if running == 0 {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// cancelPromotionFanoutChildren cancels the children of a stopped parent
+// and records them as cancelled on it.
+func (a *API) cancelPromotionFanoutChildren(ctx context.Context, parent Operation) {
+	if parent.Fanout == nil {
+		return
+	}
+	stopped := func(target PromotionFanoutTarget) bool {
+		return target.Status == opStatusRunning || target.Status == promotionFanoutTargetPending
+	}
+	changed := false
+	for _, target := range parent.Fanout.Targets {
+		if !stopped(target) {
+			continue
+		}
+		if child, err := a.store.GetOp(ctx, target.OpID); err == nil && !isOperationStatusTerminal(child.Status) {
+			a.cancelVarRolloutChild(ctx, child)
+		}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	_, _ = a.store.updateOp(ctx, parent.ID, func(op *Operation) bool {
+		if op.Fanout == nil {
+			return false
+		}
+		for i, target := range op.Fanout.Targets {
+			if stopped(target) {
+				op.Fanout.Targets[i].Status = opStatusCancelled
+			}
+		}
+		op.Fanout.Outcome, _ = promotionFanoutOutcome(op.Fanout.Targets)
+		return true
+	})
+}
+
+// promotionFanoutOutcome sums up finished targets and lists the failed
+// ones, as "env: error".
+func promotionFanoutOutcome(targets []PromotionFanoutTarget) (string, []string) {
+	failures := []string{}
+	for _, target := range targets {
+		if target.Status != opStatusDone {
+			failures = append(failures, target.Environment+": "+cmp.Or(target.Error, target.Status))
+		}
+	}
+	switch len(failures) {
+	case 0:
+		return promotionFanoutSucceeded, failures
+	case len(targets):
+		return promotionFanoutFailed, failures
+	default:
+		return promotionFanoutPartial, failures
+	}
+}
+
+func (a *API) endPromotionFanoutTarget(ctx context.Context, parentID string, index int, status, errText string) {
+	now := time.Now().UTC()
+	var step OpStep
+	stepIndex := 0
+	message := ""
+	op, ok := a.editPromotionFanout(ctx, parentID, func(op *Operation) {
+		target := &op.Fanout.Targets[index]
+		target.Status = status
+		target.Error = ""
+		message = "promoted to " + target.Environment
+		if status != opStatusDone {
+			target.Error = cmp.Or(errText, status)
+			message = "promotion to " + target.Environment + " " + status
+		}
+		// The step error is recorded without failing the parent, so the
+		// other targets keep running.
+		for i := len(op.Steps) - 1; i >= 0; i-- {
+			if op.Steps[i].Worker == promotionFanoutStepPrefix+target.Environment && op.Steps[i].EndedAt.IsZero() {
+				op.Steps[i].EndedAt = now
+				op.Steps[i].Message = message
+				op.Steps[i].Error = target.Error
+				step, stepIndex = op.Steps[i], i+1
+				break
+			}
+		}
+	})
+	if ok && stepIndex > 0 {
+		emitOpStepEnded(a.opEvents, op, step.Worker, stepIndex, message, step.Error, nil, step.StartedAt, now)
+	}
+}
+
+// activePromotionFanout reads the parent op and reports whether it can
+// still move; a cancel or an op resume after a restart ends it from outside.
+func (a *API) activePromotionFanout(ctx context.Context, parentID string) (Operation, bool) {
+	op, err := a.store.GetOp(ctx, parentID)
+	if err != nil || op.Fanout == nil {
+		return op, false
+	}
+	return op, !isOperationStatusTerminal(op.Status)
+}
+
+// editPromotionFanout rewrites the parent op while it is active, with the
+// same revision check as editVarRollout.
+func (a *API) editPromotionFanout(ctx context.Context, parentID string, edit func(op *Operation)) (Operation, bool) {
+	return editActiveParentOp(ctx, a.store, parentID, func(op Operation) bool { return op.Fanout != nil }, edit)
+}
@@ -0,0 +1,136 @@
+//nolint:testpackage,exhaustruct // Fan-out tests stand in for the promoter behind the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestAPI_PromotionFanoutRunsTargetsTogetherAndReportsPartialFailure(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-fanout"
+	spec := workerRuntimeSpec("fanout")
+	spec.Environments["staging-eu"] = EnvConfig{Vars: map[string]string{}}
+	spec.Environments["staging-us"] = EnvConfig{Vars: map[string]string{}}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-fanout-create", OpCreate, spec)
+
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	// The fake promoter only collects the child ops; the test ends them.
+	received := make(chan ProjectOpMsg, 4)
+	sub, err := fixture.nc.Subscribe(natsSubject(subjectPromotionStart), func(msg *nats.Msg) {
+		var opMsg ProjectOpMsg
+		if json.Unmarshal(msg.Data, &opMsg) == nil {
+			received <- opMsg
+		}
+	})
+	if err != nil {
+		t.Fatalf("subscribe promotion start: %v", err)
+	}
+	defer func() { _ = sub.Unsubscribe() }()
+
+	post := func(target, body string) (int, map[string]any) {
+		t.Helper()
+		resp, postErr := srv.Client().Post(srv.URL+target, "application/json", strings.NewReader(body))
+		if postErr != nil {
+			t.Fatalf("post %s: %v", target, postErr)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	fanoutBody := func(fields string) string {
+		return `{"project_id":"` + projectID + `","from_env":"dev",` + fields + `}`
+	}
+	for _, tc := range []struct{ query, fields string }{
+		{fields: `"to_envs":["staging-eu"]`},
+		{fields: `"to_envs":["staging-eu","staging-eu"]`},
+		{fields: `"to_envs":["staging-eu","qa"]`},
+		{fields: `"to_env":"qa","to_envs":["staging-eu","staging-us"]`},
+		{query: "?dry_run=true", fields: `"to_envs":["staging-eu","staging-us"]`},
+	} {
+		status, out := post("/api/events/promotion"+tc.query, fanoutBody(tc.fields))
+		if status != http.StatusBadRequest {
+			t.Fatalf("POST %s%s: expected 400, got %d %v", tc.query, tc.fields, status, out)
+		}
+	}
+
+	status, accepted := post("/api/events/promotion", fanoutBody(`"to_envs":["staging-eu","staging-us"]`))
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %v", status, accepted)
+	}
+	parentID, _ := accepted["op"].(map[string]any)["id"].(string)
+
+	// Both children are queued before either has finished.
+	children := map[string]ProjectOpMsg{}
+	for len(children) < 2 {
+		select {
+		case opMsg := <-received:
+			children[opMsg.ToEnv] = opMsg
+		case <-time.After(10 * time.Second):
+			t.Fatalf("expected both targets queued, got %v", children)
+		}
+	}
+	eu, us := children["staging-eu"], children["staging-us"]
+	if eu.Kind != OpPromote || eu.FromEnv != "dev" || us.Kind != OpPromote {
+		t.Fatalf("unexpected child ops: %+v", children)
+	}
+
+	// With one target done and the other running, the parent still holds
+	// the project.
+	_ = finalizeOp(ctx, fixture.store, eu.OpID, projectID, eu.Kind, opStatusDone, "")
+	waitForPromotionFanout(t, fixture.store, parentID, func(op Operation) bool {
+		return op.Fanout.Targets[0].Status == opStatusDone
+	})
+	status, conflict := post("/api/events/deployment", `{"project_id":"`+projectID+`"}`)
+	if status != http.StatusConflict || conflict["active_op"].(map[string]any)["id"] != parentID {
+		t.Fatalf("expected the fan-out to block other ops, got %d %v", status, conflict)
+	}
+
+	_ = finalizeOp(ctx, fixture.store, us.OpID, projectID, us.Kind, opStatusError, "render failed")
+	parent := waitForPromotionFanout(t, fixture.store, parentID, func(op Operation) bool {
+		return isOperationStatusTerminal(op.Status)
+	})
+	targets := parent.Fanout.Targets
+	if parent.Status != opStatusError || parent.Fanout.Outcome != promotionFanoutPartial ||
+		!strings.Contains(parent.Error, "staging-us: render failed") ||
+		targets[0].Status != opStatusDone || targets[0].OpID != eu.OpID ||
+		targets[1].Status != opStatusError || targets[1].OpID != us.OpID {
+		t.Fatalf("unexpected fan-out outcome: %s %q %+v", parent.Status, parent.Error, parent.Fanout)
+	}
+	child, err := fixture.store.GetOp(ctx, us.OpID)
+	if err != nil || child.ParentOpID != parentID {
+		t.Fatalf("expected a child of %s, got %+v (%v)", parentID, child, err)
+	}
+	project, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil || project.Status.LastOpID != parentID || project.Status.Phase != projectPhaseError {
+		t.Fatalf("expected the project to end on the parent in error, got %+v (%v)", project.Status, err)
+	}
+}
+
+func waitForPromotionFanout(t *testing.T, store *Store, opID string, done func(Operation) bool) Operation {
+	t.Helper()
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		op, err := store.GetOp(context.Background(), opID)
+		if err == nil && op.Fanout != nil && done(op) {
+			return op
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("fan-out promotion %s did not reach the expected state", opID)
+	return Operation{}
+}
@@ -0,0 +1,259 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Promotion plan: GET /api/projects/{id}/promotion-plan walks the image dev
+// renders today through every later environment in journey order, and runs
+// the promotion preview gates at each hop as if the hops before it had
+// succeeded. Nothing is queued; the plan answers "how far would this image
+// get, and which gate stops it" before anyone starts promoting.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	promotionPlanHopReady   = "ready"
+	promotionPlanHopBlocked = "blocked"
+)
+
+// PromotionPlanResponse is the simulated chain for the image dev renders.
+// BlockedAt is the target of the first blocked hop; Ready means every hop
+// would pass.
+type PromotionPlanResponse struct {
+	ProjectID string             `json:"project_id"`
+	Image     string             `json:"image"`
+	Chain     []string           `json:"chain"`
+	Ready     bool               `json:"ready"`
+	BlockedAt string             `json:"blocked_at,omitempty"`
+	Summary   string             `json:"summary"`
+	Hops      []PromotionPlanHop `json:"hops"`
+}
+
+// PromotionPlanHop is one from_env → to_env step of the plan, with the same
+// gates and blockers as POST /api/events/promotion/preview. A simulated hop
+// takes its source from the hop before it instead of what is deployed now.
+type PromotionPlanHop struct {
+	FromEnv       string                     `json:"from_env"`
+	ToEnv         string                     `json:"to_env"`
+	Action        string                     `json:"action"` // promote | release
+	Status        string                     `json:"status"` // ready | blocked
+	Simulated     bool                       `json:"simulated"`
+	SourceRelease *TransitionPreviewRelease  `json:"source_release,omitempty"`
+	TargetRelease *TransitionPreviewRelease  `json:"target_release,omitempty"`
+	ChangeSummary string                     `json:"change_summary"`
+	Gates         []TransitionPreviewGate    `json:"gates"`
+	Blockers      []TransitionPreviewBlocker `json:"blockers"`
+}
+
+func (a *API) handleProjectPromotionPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID, ok := projectIDFromSubresourcePath(w, r, "promotion-plan")
+	if !ok {
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	plan, err := a.buildPromotionPlan(r.Context(), project)
+	if err != nil {
+		writeAPIError(w, "failed to build promotion plan", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+func (a *API) buildPromotionPlan(ctx context.Context, project Project) (PromotionPlanResponse, error) {
+	spec := normalizeProjectSpec(project.Spec)
+	chain := journeyEnvironmentOrder(spec)
+	plan := PromotionPlanResponse{
+		ProjectID: project.ID,
+		Image:     "",
+		Chain:     chain,
+		Ready:     false,
+		BlockedAt: "",
+		Summary:   "",
+		Hops:      make([]PromotionPlanHop, 0, len(chain)),
+	}
+	var candidate vulnerabilityCheck
+	for i := 1; i < len(chain); i++ {
+		hop, details, err := a.previewPromotionPlanHop(ctx, project, spec, chain[i-1], chain[i], candidate, i > 1)
+		if err != nil {
+			return PromotionPlanResponse{}, err
+		}
+		if i == 1 {
+			plan.Image = strings.TrimSpace(details.sourceImage)
+			candidate = details.vulnerability
+		}
+		if hop.Status == promotionPlanHopBlocked && plan.BlockedAt == "" {
+			plan.BlockedAt = hop.ToEnv
+		}
+		plan.Hops = append(plan.Hops, hop)
+	}
+	plan.Ready = len(plan.Hops) > 0 && plan.BlockedAt == ""
+	plan.Summary = promotionPlanSummary(plan)
+	return plan, nil
+}
+
+// previewPromotionPlanHop runs the preview gates for one hop. The first hop
+// reads the source as deployed; a simulated hop assumes the previous hop
+// delivered candidate, so only candidate's image and budget are checked on
+// the source side.
+func (a *API) previewPromotionPlanHop(
+	ctx context.Context,
+	project Project,
+	spec ProjectSpec,
+	fromEnv string,
+	toEnv string,
+	candidate vulnerabilityCheck,
+	simulated bool,
+) (PromotionPlanHop, transitionPreviewDetails, error) {
+	blockersByCode := map[string]TransitionPreviewBlocker{}
+	blockerOrder := make([]string, 0, transitionPreviewBlockerCapacity)
+	stage := transitionDeliveryStage(toEnv)
+	kind := transitionOperationKind(stage)
+	if err := a.addActiveOperationPreviewBlocker(ctx, project.ID, kind, blockersByCode, &blockerOrder); err != nil {
+		return PromotionPlanHop{}, transitionPreviewDetails{}, err
+	}
+	addTargetUnavailablePreviewBlocker(spec, toEnv, blockersByCode, &blockerOrder)
+
+	var details transitionPreviewDetails
+	var err error
+	if simulated {
+		details, err = a.simulatedPromotionPlanDetails(ctx, project.ID, fromEnv, toEnv, kind, candidate)
+		if err == nil && details.sourceImage == "" {
+			addTransitionPreviewBlocker(blockersByCode, &blockerOrder, TransitionPreviewBlocker{
+				Code:       transitionBlockerSourceImage,
+				Message:    fmt.Sprintf("No image reaches %q in this plan.", fromEnv),
+				Why:        "The plan carries forward the image dev renders, and dev renders none.",
+				NextAction: "Deliver dev first so rendered manifests include an image.",
+			})
+		}
+		addVulnerabilityPreviewBlocker(details.vulnerability, blockersByCode, &blockerOrder)
+		if err == nil {
+			details.freeze, err = a.addFreezePreviewBlocker(ctx, project.ID, spec, toEnv, blockersByCode, &blockerOrder)
+		}
+		if err == nil {
+			details.approval, err = a.checkReleaseApproval(ctx, project.ID, kind, fromEnv, toEnv, details.sourceImage)
+		}
+	} else {
+		details, err = a.resolveTransitionPreviewDetails(
+			ctx, project, spec, fromEnv, toEnv, kind, blockersByCode, &blockerOrder)
+	}
+	if err != nil {
+		return PromotionPlanHop{}, transitionPreviewDetails{}, err
+	}
+
+	preview := PromotionPreviewResponse{
+		Action:        transitionActionFromStage(stage),
+		SourceRelease: details.sourceRelease,
+		TargetRelease: details.targetRelease,
+		ChangeSummary: "",
+		Gates:         []TransitionPreviewGate{},
+		Blockers:      orderedTransitionPreviewBlockers(blockersByCode, blockerOrder),
+		RolloutPlan:   nil,
+		TrafficSplit:  nil,
+	}
+	preview.ChangeSummary = transitionPreviewChangeSummary(preview, details)
+	preview.Gates = append(
+		transitionPreviewGates(blockersByCode, details.targetReleaseFound),
+		approvalPreviewGate(details.approval),
+		freezePreviewGate(details.freeze),
+		vulnerabilityPreviewGate(details.vulnerability),
+	)
+	if simulated {
+		markSimulatedSourceGates(preview.Gates, fromEnv)
+	}
+	status := promotionPlanHopReady
+	if len(preview.Blockers) > 0 {
+		status = promotionPlanHopBlocked
+	}
+	return PromotionPlanHop{
+		FromEnv:       fromEnv,
+		ToEnv:         toEnv,
+		Action:        preview.Action,
+		Status:        status,
+		Simulated:     simulated,
+		SourceRelease: preview.SourceRelease,
+		TargetRelease: preview.TargetRelease,
+		ChangeSummary: preview.ChangeSummary,
+		Gates:         preview.Gates,
+		Blockers:      preview.Blockers,
+	}, details, nil
+}
+
+func (a *API) simulatedPromotionPlanDetails(
+	ctx context.Context,
+	projectID string,
+	fromEnv string,
+	toEnv string,
+	kind OperationKind,
+	candidate vulnerabilityCheck,
+) (transitionPreviewDetails, error) {
+	details := transitionPreviewDetails{
+		resolvedFromEnv:    fromEnv,
+		resolvedToEnv:      toEnv,
+		kind:               kind,
+		sourceImage:        candidate.image,
+		targetImage:        "",
+		targetReleaseFound: false,
+		sourceRelease:      nil,
+		targetRelease:      nil,
+		vulnerability:      candidate,
+		freeze:             environmentFreezeStatus{},
+		approval:           releaseApprovalCheck{release: false, required: 0, approval: nil},
+	}
+	targetRelease, found, err := a.store.getProjectCurrentRelease(ctx, projectID, toEnv)
+	if err != nil {
+		return details, fmt.Errorf("failed to read target release: %w", err)
+	}
+	if found {
+		details.targetReleaseFound = true
+		details.targetRelease = transitionPreviewReleasePtr(targetRelease)
+	}
+	details.targetImage, err = readRenderedEnvImageTag(a.artifacts, projectID, toEnv)
+	if err != nil {
+		return details, fmt.Errorf("failed to read target rendered image: %w", err)
+	}
+	return details, nil
+}
+
+// markSimulatedSourceGates explains that a simulated hop's source gates pass
+// on the strength of the previous hop, not a delivery that has happened.
+func markSimulatedSourceGates(gates []TransitionPreviewGate, fromEnv string) {
+	for i := range gates {
+		switch gates[i].Code {
+		case transitionBlockerSourceDelivery, transitionBlockerSourceImage:
+			if gates[i].Status == previewGatePassed {
+				gates[i].Detail = fmt.Sprintf("Satisfied once the previous hop in this plan delivers %q.", fromEnv)
+			}
+		}
+	}
+}
+
+func promotionPlanSummary(plan PromotionPlanResponse) string {
+	route := strings.Join(plan.Chain, " → ")
+	switch {
+	case len(plan.Hops) == 0:
+		return "No environments after dev to promote into."
+	case plan.Ready:
+		return fmt.Sprintf("Image %q can be promoted through %s; every gate passes.", plan.Image, route)
+	}
+	codes := []string{}
+	for _, hop := range plan.Hops {
+		if hop.ToEnv != plan.BlockedAt {
+			continue
+		}
+		for _, blocker := range hop.Blockers {
+			codes = append(codes, blocker.Code)
+		}
+	}
+	return fmt.Sprintf("Promotion through %s stops at %s (%s).", route, plan.BlockedAt, strings.Join(codes, ", "))
+}
@@ -0,0 +1,81 @@
+//nolint:testpackage,exhaustruct // Promotion plan tests reuse the preview fixture and its internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getPromotionPlan(t *testing.T, srv *httptest.Server, projectID string) PromotionPlanResponse {
+	t.Helper()
+	resp, err := srv.Client().Get(srv.URL + "/api/projects/" + projectID + "/promotion-plan")
+	if err != nil {
+		t.Fatalf("get promotion plan: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var plan PromotionPlanResponse
+	if err = json.NewDecoder(resp.Body).Decode(&plan); err != nil {
+		t.Fatalf("decode promotion plan: %v", err)
+	}
+	return plan
+}
+
+func TestAPI_PromotionPlanSimulatesTheWholeChain(t *testing.T) {
+	t.Setenv(vulnBudgetEnv, "critical=0")
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+
+	image := "example.local/promotion-plan:v1"
+	writePreviewDeploymentImage(t, fixture.artifacts, fixture.projectID, "dev", image)
+	if _, err := fixture.api.store.PutRelease(context.Background(), ReleaseRecord{
+		ProjectID: fixture.projectID, Environment: "dev", OpID: "op-plan-source", OpKind: OpDeploy,
+		DeliveryStage: DeliveryStageDeploy, ToEnv: "dev", Image: image, CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("put source release: %v", err)
+	}
+
+	plan := getPromotionPlan(t, srv, fixture.projectID)
+	if !plan.Ready || plan.Image != image || strings.Join(plan.Chain, ",") != "dev,staging,prod" ||
+		len(plan.Hops) != 2 {
+		t.Fatalf("expected a ready dev → staging → prod plan, got %+v", plan)
+	}
+	staging, prod := plan.Hops[0], plan.Hops[1]
+	if staging.Simulated || staging.Action != string(OpPromote) || staging.SourceRelease == nil {
+		t.Fatalf("expected the first hop to read dev as deployed, got %+v", staging)
+	}
+	if !prod.Simulated || prod.FromEnv != "staging" || prod.Action != string(OpRelease) ||
+		prod.Status != promotionPlanHopReady {
+		t.Fatalf("expected a simulated, ready release hop into prod, got %+v", prod)
+	}
+	for _, gate := range prod.Gates {
+		if gate.Code == transitionBlockerSourceDelivery && !strings.Contains(gate.Detail, "previous hop") {
+			t.Fatalf("expected the prod source gate to lean on the staging hop, got %+v", gate)
+		}
+	}
+
+	report := `{"ArtifactName":"` + image + `","Results":[{"Vulnerabilities":[` +
+		`{"VulnerabilityID":"CVE-1","Severity":"CRITICAL"}]}]}`
+	if _, err := fixture.artifacts.WriteFile(fixture.projectID, vulnerabilityReportPath, []byte(report)); err != nil {
+		t.Fatalf("write scan report: %v", err)
+	}
+	plan = getPromotionPlan(t, srv, fixture.projectID)
+	if plan.Ready || plan.BlockedAt != "staging" || !strings.Contains(plan.Summary, transitionBlockerVulnBudget) {
+		t.Fatalf("expected the budget to stop the plan at staging, got %+v", plan)
+	}
+	for _, hop := range plan.Hops {
+		if hop.Status != promotionPlanHopBlocked || len(hop.Blockers) != 1 ||
+			hop.Blockers[0].Code != transitionBlockerVulnBudget {
+			t.Fatalf("expected every hop to report the budget blocker, got %+v", hop)
+		}
+	}
+}
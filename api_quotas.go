@@ -0,0 +1,518 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Project quotas and API rate limits. PAAS_PROJECT_QUOTAS caps how many ops
+// a project may have queued or running, how many it may start per hour, and
+// how many bytes its artifact tree may hold; PAAS_API_RATE_LIMIT caps the
+// requests each client token sends. Both answer 429 with a Retry-After and a
+// structured reason. GET /api/projects/{id}/quota shows a project's usage.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	quotaConcurrentOps = "concurrent_ops"
+	quotaOpsPerHour    = "ops_per_hour"
+	quotaArtifactBytes = "artifact_bytes"
+	quotaOpsWindow     = time.Hour
+	// quotaBusyRetryAfter and quotaArtifactRetryAfter are hints only: a
+	// project's ops finish, and its artifacts are pruned, on no fixed clock.
+	quotaBusyRetryAfter     = 30 * time.Second
+	quotaArtifactRetryAfter = 5 * time.Minute
+
+	rateLimitPerMinute = "per_minute"
+	rateLimitBurst     = "burst"
+	// rateLimitMaxClients bounds the buckets kept in memory. Past it, the
+	// buckets that have refilled are dropped; they hold nothing a new bucket
+	// would not.
+	rateLimitMaxClients = 10000
+)
+
+// projectQuotas are the per-project limits from PAAS_PROJECT_QUOTAS. Zero
+// leaves a limit off.
+type projectQuotas struct {
+	ConcurrentOps int
+	OpsPerHour    int
+	ArtifactBytes int64
+}
+
+// projectQuotaError refuses an op or upload that would take a project past
+// one of its quotas.
+type projectQuotaError struct {
+	ProjectID  string
+	Quota      string
+	Limit      int64
+	Used       int64
+	RetryAfter time.Duration
+}
+
+// projectOpUsage is what a project's recent ops count against its quotas.
+// WindowResetsAt is when the oldest op counted in Recent leaves the window.
+type projectOpUsage struct {
+	Active         int
+	Recent         int
+	WindowResetsAt time.Time
+}
+
+// projectQuotaUsage is one quota in GET /api/projects/{id}/quota. Exhausted
+// means the next op (or, for artifact_bytes, any op or upload) is refused.
+type projectQuotaUsage struct {
+	Name      string    `json:"name"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Exhausted bool      `json:"exhausted"`
+	ResetsAt  time.Time `json:"resets_at,omitzero"`
+}
+
+type projectQuotaResponse struct {
+	ProjectID string              `json:"project_id"`
+	Quotas    []projectQuotaUsage `json:"quotas"`
+}
+
+// apiRateLimit is PAAS_API_RATE_LIMIT: each client may send PerMinute
+// requests a minute on average, and up to Burst at once.
+type apiRateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+// apiRateLimiter keeps a token bucket per client.
+type apiRateLimiter struct {
+	limit   apiRateLimit
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func (e projectQuotaError) Error() string {
+	return fmt.Sprintf("project %s reached its %s quota of %d (%d in use)", e.ProjectID, e.Quota, e.Limit, e.Used)
+}
+
+func (q projectQuotas) opLimited() bool {
+	return q.ConcurrentOps > 0 || q.OpsPerHour > 0
+}
+
+// limitsFromEnv reads PAAS_PROJECT_QUOTAS and PAAS_API_RATE_LIMIT. The
+// limiter is nil when rate limiting is off.
+func limitsFromEnv() (projectQuotas, *apiRateLimiter, error) {
+	quotas, err := parseProjectQuotas(os.Getenv(projectQuotasEnv))
+	if err != nil {
+		return projectQuotas{}, nil, fmt.Errorf("%s: %w", projectQuotasEnv, err)
+	}
+	limit, err := parseAPIRateLimit(os.Getenv(apiRateLimitEnv))
+	if err != nil {
+		return projectQuotas{}, nil, fmt.Errorf("%s: %w", apiRateLimitEnv, err)
+	}
+	return quotas, newAPIRateLimiter(limit), nil
+}
+
+// parseProjectQuotas parses "concurrent_ops=2,ops_per_hour=30,artifact_bytes=5Gi".
+// ops_per_hour is capped at the ops one project listing returns, since that
+// listing is what the quota counts.
+func parseProjectQuotas(raw string) (projectQuotas, error) {
+	var quotas projectQuotas
+	err := parseLimitPairs(raw, func(name, value string) error {
+		switch name {
+		case quotaConcurrentOps, quotaOpsPerHour:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%s must be a non-negative integer, got %q", name, value)
+			}
+			if name == quotaConcurrentOps {
+				quotas.ConcurrentOps = n
+				return nil
+			}
+			if n > projectOpsMaxLimit {
+				return fmt.Errorf("%s must be at most %d", name, projectOpsMaxLimit)
+			}
+			quotas.OpsPerHour = n
+		case quotaArtifactBytes:
+			n, ok := parseMemoryBytes(value)
+			if !ok && value != "0" {
+				return fmt.Errorf("%s must be a byte size like 512Mi or 5G, got %q", name, value)
+			}
+			quotas.ArtifactBytes = n
+		default:
+			return fmt.Errorf("unknown quota %q (want %s, %s, or %s)",
+				name, quotaConcurrentOps, quotaOpsPerHour, quotaArtifactBytes)
+		}
+		return nil
+	})
+	return quotas, err
+}
+
+// parseAPIRateLimit parses "per_minute=120,burst=40". Burst defaults to a
+// minute's worth of requests.
+func parseAPIRateLimit(raw string) (apiRateLimit, error) {
+	var limit apiRateLimit
+	err := parseLimitPairs(raw, func(name, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s must be a non-negative integer, got %q", name, value)
+		}
+		switch name {
+		case rateLimitPerMinute:
+			limit.PerMinute = n
+		case rateLimitBurst:
+			limit.Burst = n
+		default:
+			return fmt.Errorf("unknown setting %q (want %s or %s)", name, rateLimitPerMinute, rateLimitBurst)
+		}
+		return nil
+	})
+	if err != nil {
+		return apiRateLimit{}, err
+	}
+	if limit.PerMinute == 0 && limit.Burst > 0 {
+		return apiRateLimit{}, fmt.Errorf("%s needs %s", rateLimitBurst, rateLimitPerMinute)
+	}
+	if limit.Burst == 0 {
+		limit.Burst = limit.PerMinute
+	}
+	return limit, nil
+}
+
+func parseLimitPairs(raw string, set func(name, value string) error) error {
+	for part := range strings.SplitSeq(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return fmt.Errorf("%q is not name=value", part)
+		}
+		if err := set(strings.TrimSpace(name), strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newAPIRateLimiter(limit apiRateLimit) *apiRateLimiter {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+	return &apiRateLimiter{limit: limit, mu: sync.Mutex{}, buckets: map[string]*rateBucket{}}
+}
+
+// take spends one of key's requests. When none is left it reports how long
+// until one is.
+func (l *apiRateLimiter) take(key string, now time.Time) (time.Duration, bool) {
+	return l.spend(key, now, 1)
+}
+
+// available reports whether key has a request left without spending it, and
+// when it has none, how long until it does.
+func (l *apiRateLimiter) available(key string, now time.Time) (time.Duration, bool) {
+	return l.spend(key, now, 0)
+}
+
+func (l *apiRateLimiter) spend(key string, now time.Time, cost float64) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	perSecond := float64(l.limit.PerMinute) / time.Minute.Seconds()
+	burst := float64(l.limit.Burst)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= rateLimitMaxClients {
+			l.pruneLocked(now, perSecond, burst)
+		}
+		bucket = &rateBucket{tokens: burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = min(burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*perSecond)
+	bucket.updated = now
+	if bucket.tokens >= 1 {
+		bucket.tokens -= cost
+		return 0, true
+	}
+	wait := time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	return wait, false
+}
+
+func (l *apiRateLimiter) pruneLocked(now time.Time, perSecond, burst float64) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*perSecond >= burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// withRateLimit spends a request from the caller's bucket for every /api/
+// request other than the health probes and the OpenAPI document. It sits
+// inside withAuth, so callers are told apart by token; unauthenticated
+// callers are keyed by address. Requests that fail authentication never get
+// here; authenticate limits those per address itself.
+func (a *API) withRateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.rateLimiter == nil || !rateLimitedPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		wait, ok := a.rateLimiter.take(rateLimitKey(r), time.Now())
+		if ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writeRateLimited(w, a.rateLimiter.limit, wait)
+	})
+}
+
+func writeRateLimited(w http.ResponseWriter, limit apiRateLimit, wait time.Duration) {
+	retryAfter := retryAfterSeconds(wait)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeErrorDetails(w, http.StatusTooManyRequests, errorCodeRateLimited, map[string]any{
+		"reason":              "rate limit exceeded; retry after " + strconv.Itoa(retryAfter) + "s",
+		"limit_per_minute":    limit.PerMinute,
+		"burst":               limit.Burst,
+		"retry_after_seconds": retryAfter,
+	})
+}
+
+func rateLimitedPath(path string) bool {
+	switch path {
+	case "/api/healthz", "/api/readyz", "/api/openapi.json":
+		return false
+	}
+	return strings.HasPrefix(path, "/api/")
+}
+
+func rateLimitKey(r *http.Request) string {
+	if principal, ok := requestPrincipal(r.Context()); ok {
+		if principal.TokenID != "" {
+			return "token:" + principal.TokenID
+		}
+		if principal.Name != "" {
+			return "principal:" + principal.Name
+		}
+	}
+	return "addr:" + rateLimitAddr(r)
+}
+
+// authFailureKey is the bucket the failed authentications from r's address
+// spend; it is kept apart from the address's own requests.
+func authFailureKey(r *http.Request) string {
+	return "auth-failure:" + rateLimitAddr(r)
+}
+
+func rateLimitAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// retryAfterSeconds rounds wait up to whole seconds, and to at least one.
+func retryAfterSeconds(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
+
+// projectQuotaConflict is the enqueueOp side of the project quotas. A child
+// op does not count the parent it runs under against concurrent_ops.
+// Deletes and cleanups free artifact space, so artifact_bytes does not
+// refuse them.
+func (a *API) projectQuotaConflict(ctx context.Context, projectID string, kind OperationKind, opts opRunOptions) error {
+	quotas := a.quotas
+	if a.store == nil || strings.TrimSpace(projectID) == "" {
+		return nil
+	}
+	if quotas.opLimited() {
+		now := time.Now().UTC()
+		usage, err := a.countProjectOps(ctx, projectID, opts.parentOpID, now)
+		if err != nil {
+			return fmt.Errorf("read project op usage: %w", err)
+		}
+		if quotas.ConcurrentOps > 0 && usage.Active >= quotas.ConcurrentOps {
+			return projectQuotaError{
+				ProjectID:  projectID,
+				Quota:      quotaConcurrentOps,
+				Limit:      int64(quotas.ConcurrentOps),
+				Used:       int64(usage.Active),
+				RetryAfter: quotaBusyRetryAfter,
+			}
+		}
+		if quotas.OpsPerHour > 0 && usage.Recent >= quotas.OpsPerHour {
+			return projectQuotaError{
+				ProjectID:  projectID,
+				Quota:      quotaOpsPerHour,
+				Limit:      int64(quotas.OpsPerHour),
+				Used:       int64(usage.Recent),
+				RetryAfter: usage.WindowResetsAt.Sub(now),
+			}
+		}
+	}
+	if kind == OpDelete || kind == OpCleanup {
+		return nil
+	}
+	return a.artifactQuotaConflict(projectID, 0)
+}
+
+// artifactQuotaConflict refuses adding bytes to projectID's artifacts when
+// the tree would exceed artifact_bytes, and anything at all once it is full.
+func (a *API) artifactQuotaConflict(projectID string, adding int64) error {
+	limit := a.quotas.ArtifactBytes
+	if limit <= 0 || a.artifacts == nil {
+		return nil
+	}
+	used, err := projectArtifactBytes(a.artifacts, projectID)
+	if err != nil {
+		return fmt.Errorf("measure project artifacts: %w", err)
+	}
+	if used >= limit || used+adding > limit {
+		return projectQuotaError{
+			ProjectID:  projectID,
+			Quota:      quotaArtifactBytes,
+			Limit:      limit,
+			Used:       used,
+			RetryAfter: quotaArtifactRetryAfter,
+		}
+	}
+	return nil
+}
+
+// countProjectOps counts projectID's queued and running ops other than
+// skipOpID, and the ops requested within quotaOpsWindow of now. It reads
+// the newest projectOpsMaxLimit ops, which covers any ops_per_hour quota.
+func (a *API) countProjectOps(
+	ctx context.Context,
+	projectID, skipOpID string,
+	now time.Time,
+) (projectOpUsage, error) {
+	page, err := a.store.listProjectOps(ctx, projectID, projectOpsListQuery{
+		Limit:  projectOpsMaxLimit,
+		Cursor: "",
+		Before: "",
+	})
+	if err != nil {
+		return projectOpUsage{}, err
+	}
+	usage := projectOpUsage{Active: 0, Recent: 0, WindowResetsAt: time.Time{}}
+	windowStart := now.Add(-quotaOpsWindow)
+	for _, op := range page.Ops {
+		if op.ID != skipOpID && isOperationStatusActive(op.Status) {
+			usage.Active++
+		}
+		if op.Requested.After(windowStart) {
+			usage.Recent++
+			// Ops are newest first, so the last one counted is the oldest.
+			usage.WindowResetsAt = op.Requested.Add(quotaOpsWindow)
+		}
+	}
+	return usage, nil
+}
+
+func projectArtifactBytes(artifacts ArtifactStore, projectID string) (int64, error) {
+	files, err := artifacts.StatFiles(projectID)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, file := range files {
+		total += file.Size
+	}
+	return total, nil
+}
+
+func writeProjectQuotaExceeded(w http.ResponseWriter, err error) bool {
+	var quotaErr projectQuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	retryAfter := retryAfterSeconds(quotaErr.RetryAfter)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeAPIErrorResponse(w, http.StatusTooManyRequests, apiErrorResponse{
+		Code:    errorCodeQuotaExceeded,
+		Message: quotaErr.Error(),
+		Field:   "",
+		Details: map[string]any{
+			"project_id":          quotaErr.ProjectID,
+			"quota":               quotaErr.Quota,
+			"limit":               quotaErr.Limit,
+			"used":                quotaErr.Used,
+			"retry_after_seconds": retryAfter,
+		},
+		OpID: "",
+	})
+	return true
+}
+
+// handleProjectQuota reports a project's usage against each configured
+// quota:
+//
+//	GET /api/projects/{id}/quota
+func (a *API) handleProjectQuota(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.store == nil {
+		writeAPIError(w, "quota data unavailable", http.StatusInternalServerError)
+		return
+	}
+	projectID, ok := projectIDFromSubresourcePath(w, r, "quota")
+	if !ok {
+		return
+	}
+	if _, found := a.getProjectOrWriteError(w, r, projectID); !found {
+		return
+	}
+	quotas, err := a.projectQuotaUsages(r.Context(), projectID)
+	if err != nil {
+		writeAPIError(w, "failed to read project quota usage", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, projectQuotaResponse{ProjectID: projectID, Quotas: quotas})
+}
+
+func (a *API) projectQuotaUsages(ctx context.Context, projectID string) ([]projectQuotaUsage, error) {
+	quotas := a.quotas
+	out := []projectQuotaUsage{}
+	if quotas.opLimited() {
+		usage, err := a.countProjectOps(ctx, projectID, "", time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		if quotas.ConcurrentOps > 0 {
+			out = append(out, newProjectQuotaUsage(quotaConcurrentOps, int64(quotas.ConcurrentOps),
+				int64(usage.Active), time.Time{}))
+		}
+		if quotas.OpsPerHour > 0 {
+			out = append(out, newProjectQuotaUsage(quotaOpsPerHour, int64(quotas.OpsPerHour),
+				int64(usage.Recent), usage.WindowResetsAt))
+		}
+	}
+	if quotas.ArtifactBytes > 0 && a.artifacts != nil {
+		used, err := projectArtifactBytes(a.artifacts, projectID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, newProjectQuotaUsage(quotaArtifactBytes, quotas.ArtifactBytes, used, time.Time{}))
+	}
+	return out, nil
+}
+
+func newProjectQuotaUsage(name string, limit, used int64, resetsAt time.Time) projectQuotaUsage {
+	return projectQuotaUsage{Name: name, Limit: limit, Used: used, Exhausted: used >= limit, ResetsAt: resetsAt}
+}
@@ -0,0 +1,220 @@
+//nolint:testpackage,exhaustruct // Quota tests drive the internal router against stored ops and artifacts.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAPI_ProjectQuotasRefuseOpsAndUploads(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-quota"
+	spec := workerRuntimeSpec("quota-app")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-quota", OpDeploy, spec)
+	artifacts := NewFSArtifacts(t.TempDir())
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	api.quotas = projectQuotas{ConcurrentOps: 1}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	call := func(method, path, contentType string, body []byte) (*http.Response, apiErrorResponse) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+"/api/projects/"+projectID+path,
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var out apiErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+	specBody, _ := json.Marshal(spec)
+	update := func() (*http.Response, apiErrorResponse) {
+		return call(http.MethodPut, "?force=true", "application/json", specBody)
+	}
+	quota := func() projectQuotaResponse {
+		t.Helper()
+		resp, err := srv.Client().Get(srv.URL + "/api/projects/" + projectID + "/quota")
+		if err != nil {
+			t.Fatalf("get quota: %v", err)
+		}
+		defer resp.Body.Close()
+		var out projectQuotaResponse
+		if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&out) != nil {
+			t.Fatalf("expected quota usage, got %d", resp.StatusCode)
+		}
+		return out
+	}
+
+	resp, refused := update()
+	if resp.StatusCode != http.StatusTooManyRequests || refused.Code != errorCodeQuotaExceeded ||
+		refused.Details["quota"] != quotaConcurrentOps || resp.Header.Get("Retry-After") != "30" {
+		t.Fatalf("expected concurrent_ops to refuse a second op, got %d %+v", resp.StatusCode, refused)
+	}
+	if got := quota().Quotas; len(got) != 1 || got[0].Used != 1 || !got[0].Exhausted {
+		t.Fatalf("expected one exhausted concurrent_ops quota, got %+v", got)
+	}
+
+	op, err := fixture.store.GetOp(ctx, "op-quota")
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	op.Status = opStatusDone
+	op.Requested = time.Now().UTC().Add(-10 * time.Minute)
+	if err = fixture.store.PutOp(ctx, op); err != nil {
+		t.Fatalf("put op: %v", err)
+	}
+	api.quotas = projectQuotas{ConcurrentOps: 1, OpsPerHour: 1, ArtifactBytes: 64}
+	resp, refused = update()
+	retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if resp.StatusCode != http.StatusTooManyRequests || refused.Details["quota"] != quotaOpsPerHour ||
+		retryAfter < 49*60 || retryAfter > 50*60 {
+		t.Fatalf("expected ops_per_hour to refuse until the op leaves the window, got %d %+v Retry-After=%d",
+			resp.StatusCode, refused, retryAfter)
+	}
+
+	junit := []byte(`<testsuite name="unit" tests="1"></testsuite>`)
+	resp, refused = call(http.MethodPost, "/artifacts/evidence/a.xml", "application/xml", junit)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected an upload within the artifact quota, got %d %+v", resp.StatusCode, refused)
+	}
+	resp, refused = call(http.MethodPost, "/artifacts/evidence/b.xml", "application/xml", junit)
+	if resp.StatusCode != http.StatusTooManyRequests || refused.Details["quota"] != quotaArtifactBytes {
+		t.Fatalf("expected artifact_bytes to refuse the second upload, got %d %+v", resp.StatusCode, refused)
+	}
+	usage := quota().Quotas
+	if len(usage) != 3 || usage[1].Name != quotaOpsPerHour || usage[1].ResetsAt.IsZero() ||
+		usage[2].Used != int64(len(junit)) || usage[2].Exhausted {
+		t.Fatalf("expected usage for all three quotas, got %+v", usage)
+	}
+}
+
+func TestAPI_RateLimitIsPerClientAndExemptsProbes(t *testing.T) {
+	t.Parallel()
+
+	api := &API{rateLimiter: newAPIRateLimiter(apiRateLimit{PerMinute: 60, Burst: 2})}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	for range 3 {
+		if resp := get("/api/healthz"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected probes to skip the rate limit, got %d", resp.StatusCode)
+		}
+	}
+	get("/api/openapi.json")
+	get("/api/no-such-route")
+	get("/api/no-such-route")
+	resp := get("/api/no-such-route")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "1" {
+		t.Fatalf("expected the third request past the burst to be limited, got %d Retry-After=%q",
+			resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	limiter := newAPIRateLimiter(apiRateLimit{PerMinute: 60, Burst: 1})
+	now := time.Now()
+	if _, ok := limiter.take("token:a", now); !ok {
+		t.Fatal("expected the first request to pass")
+	}
+	if wait, ok := limiter.take("token:a", now); ok || wait != time.Second {
+		t.Fatalf("expected a one second wait, got %s ok=%v", wait, ok)
+	}
+	if _, ok := limiter.take("token:b", now); !ok {
+		t.Fatal("expected another client to have its own bucket")
+	}
+	if _, ok := limiter.take("token:a", now.Add(time.Second)); !ok {
+		t.Fatal("expected the bucket to refill")
+	}
+}
+
+func TestAPI_RateLimitCountsFailedAuthenticationsPerAddress(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	api := &API{
+		store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir()),
+		rateLimiter: newAPIRateLimiter(apiRateLimit{PerMinute: 60, Burst: 2}),
+	}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	t.Setenv(apiAuthEnv, "true")
+	t.Setenv(operatorTokenEnv, "operator-secret")
+	get := func(token string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+"/api/projects", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("get projects: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := get("operator-secret"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected a good token to pass, got %d", resp.StatusCode)
+	}
+	for _, token := range []string{"paas_guess1", ""} {
+		if resp := get(token); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for token %q, got %d", token, resp.StatusCode)
+		}
+	}
+	resp := get("paas_guess2")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("expected failed authentications past the burst to be limited, got %d Retry-After=%q",
+			resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	// An address out of failures is refused even with a good token, until
+	// its failures refill.
+	if resp = get("operator-secret"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the address to stay limited, got %d", resp.StatusCode)
+	}
+	wait, _ := api.rateLimiter.available(authFailureKey(&http.Request{RemoteAddr: "127.0.0.1:1"}), time.Now())
+	time.Sleep(wait)
+	if resp = get("operator-secret"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected a good token to pass once the failures refilled, got %d", resp.StatusCode)
+	}
+}
+
+func TestParseProjectQuotasAndRateLimit(t *testing.T) {
+	t.Parallel()
+
+	quotas, err := parseProjectQuotas(" concurrent_ops=2, ops_per_hour=30,artifact_bytes=1Gi ")
+	if err != nil || quotas != (projectQuotas{ConcurrentOps: 2, OpsPerHour: 30, ArtifactBytes: 1 << 30}) {
+		t.Fatalf("unexpected quotas %+v err=%v", quotas, err)
+	}
+	for _, raw := range []string{"ops_per_hour=101", "concurrent_ops=-1", "artifact_bytes=lots", "builds=3", "x"} {
+		if _, err = parseProjectQuotas(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+	limit, err := parseAPIRateLimit("per_minute=120")
+	if err != nil || limit != (apiRateLimit{PerMinute: 120, Burst: 120}) {
+		t.Fatalf("expected burst to default to a minute's worth, got %+v err=%v", limit, err)
+	}
+	if _, err = parseAPIRateLimit("burst=5"); err == nil {
+		t.Fatal("expected burst without per_minute to be rejected")
+	}
+	if limiter := newAPIRateLimiter(apiRateLimit{}); limiter != nil {
+		t.Fatal("expected no limiter when rate limiting is off")
+	}
+}
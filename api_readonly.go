@@ -0,0 +1,196 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	readOnlyDefaultMessage = "the platform is in read-only mode for maintenance"
+	readOnlyMessageMax     = 512
+	readOnlyRetryAfter     = 60 * time.Second
+)
+
+// readOnlyState is the maintenance switch. It lives in KV so every API
+// replica, and the commit watcher, see the same setting.
+type readOnlyState struct {
+	Enabled bool      `json:"enabled"`
+	Message string    `json:"message,omitempty"`
+	Since   time.Time `json:"since,omitzero"`
+	By      string    `json:"by,omitempty"`
+}
+
+// readOnlyRequest is the body of POST /api/admin/readonly.
+type readOnlyRequest struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+}
+
+func (s *Store) getReadOnlyState(ctx context.Context) (readOnlyState, error) {
+	defer s.observe("getReadOnlyState", time.Now())
+	entry, err := s.kvOps.Get(ctx, kvReadOnlyKey)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return readOnlyState{}, nil
+		}
+		return readOnlyState{}, err
+	}
+	var state readOnlyState
+	if err = json.Unmarshal(entry.Value(), &state); err != nil {
+		return readOnlyState{}, err
+	}
+	return state, nil
+}
+
+func (s *Store) putReadOnlyState(ctx context.Context, state readOnlyState) error {
+	defer s.observe("putReadOnlyState", time.Now())
+	body, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = s.kvOps.Put(ctx, kvReadOnlyKey, body)
+	return err
+}
+
+// handleAdminReadOnly reads and flips the maintenance switch:
+//
+//	GET  /api/admin/readonly
+//	POST /api/admin/readonly {"enabled":true,"message":"backup until 02:00"}
+//
+// withAuth only lets admins through. Ops already queued or running are left
+// to finish.
+func (a *API) handleAdminReadOnly(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "read-only state unavailable", http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		state, err := a.store.getReadOnlyState(r.Context())
+		if err != nil {
+			writeAPIError(w, "failed to read read-only state", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	case http.MethodPost:
+		var req readOnlyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		message := strings.TrimSpace(req.Message)
+		if len(message) > readOnlyMessageMax {
+			writeAPIError(w, "message must be at most "+strconv.Itoa(readOnlyMessageMax)+" bytes",
+				http.StatusBadRequest)
+			return
+		}
+		state := readOnlyState{Enabled: req.Enabled, Message: "", Since: time.Time{}, By: ""}
+		if req.Enabled {
+			principal, _ := requestPrincipal(r.Context())
+			state.Message = message
+			state.Since = time.Now().UTC()
+			state.By = principal.Name
+		}
+		if err := a.store.putReadOnlyState(r.Context(), state); err != nil {
+			writeAPIError(w, "failed to write read-only state", http.StatusInternalServerError)
+			return
+		}
+		appLoggerForProcess().Source("api").Infof("read-only mode enabled=%t by=%q", state.Enabled, state.By)
+		writeJSON(w, http.StatusOK, state)
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// withReadOnly refuses mutations with 503 while read-only mode is on. Reads,
+// previews, spec validation, and the switch itself stay available.
+func (a *API) withReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.store == nil || !readOnlyGuarded(r.Method, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		state, err := a.store.getReadOnlyState(r.Context())
+		if err != nil {
+			writeAPIError(w, "failed to read read-only state", http.StatusInternalServerError)
+			return
+		}
+		if !state.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writeReadOnly(w, state)
+	})
+}
+
+// readOnlyGuarded reports whether read-only mode refuses a request: anything
+// under /api/ that apiRouteRole would not let a viewer send.
+func readOnlyGuarded(method, path string) bool {
+	if method == http.MethodGet || method == http.MethodHead || path == "/api/admin/readonly" ||
+		!strings.HasPrefix(path, "/api/") {
+		return false
+	}
+	need, _ := apiRouteRole(method, path)
+	return need != apiRoleViewer
+}
+
+// readOnlyError refuses an op while read-only mode is on, whatever started
+// it: a request, a remediation hook, a tag build's release, a preview build,
+// or a schedule. admitOp returns it.
+type readOnlyError struct {
+	State readOnlyState
+}
+
+func (e readOnlyError) Error() string {
+	if e.State.Message == "" {
+		return readOnlyDefaultMessage
+	}
+	return e.State.Message
+}
+
+// readOnlyConflict returns a readOnlyError while read-only mode is on.
+func (a *API) readOnlyConflict(ctx context.Context) error {
+	if a.store == nil {
+		return nil
+	}
+	state, err := a.store.getReadOnlyState(ctx)
+	if err != nil {
+		return fmt.Errorf("read read-only state: %w", err)
+	}
+	if !state.Enabled {
+		return nil
+	}
+	return readOnlyError{State: state}
+}
+
+func writeReadOnlyRefused(w http.ResponseWriter, err error) bool {
+	var readOnly readOnlyError
+	if !errors.As(err, &readOnly) {
+		return false
+	}
+	writeReadOnly(w, readOnly.State)
+	return true
+}
+
+func writeReadOnly(w http.ResponseWriter, state readOnlyState) {
+	message := state.Message
+	if message == "" {
+		message = readOnlyDefaultMessage
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(readOnlyRetryAfter.Seconds())))
+	writeErrorDetails(w, http.StatusServiceUnavailable, errorCodeReadOnly, map[string]any{
+		"accepted":  false,
+		"read_only": true,
+		"reason":    message,
+		"since":     state.Since,
+		"next_step": "retry once read-only mode is lifted; see GET /api/system",
+	})
+}
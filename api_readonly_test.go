@@ -0,0 +1,125 @@
+//nolint:testpackage,exhaustruct // Read-only tests drive the internal router against the internal store.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPI_ReadOnlyModeRefusesMutationsOnly(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-read-only"
+	spec := workerRuntimeSpec("read-only-app")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-read-only", OpCreate, spec)
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	call := func(method, path string, body any) (*http.Response, map[string]any) {
+		t.Helper()
+		reader := bytes.NewReader(nil)
+		if body != nil {
+			raw, _ := json.Marshal(body)
+			reader = bytes.NewReader(raw)
+		}
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, reader)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+
+	on := readOnlyRequest{Enabled: true, Message: "nightly backup"}
+	resp, state := call(http.MethodPost, "/api/admin/readonly", on)
+	if resp.StatusCode != http.StatusOK || state["enabled"] != true || state["message"] != "nightly backup" {
+		t.Fatalf("expected read-only mode on, got %d %v", resp.StatusCode, state)
+	}
+
+	resp, body := call(http.MethodPut, "/api/projects/"+projectID, spec)
+	if resp.StatusCode != http.StatusServiceUnavailable || body["reason"] != "nightly backup" ||
+		resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("expected an update to be refused with 503, got %d %v", resp.StatusCode, body)
+	}
+	if resp, _ = call(http.MethodPost, "/api/webhooks/source", SourceRepoWebhookEvent{
+		ProjectID: projectID, Branch: branchMain, Commit: "abc123",
+	}); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the source webhook to be refused with 503, got %d", resp.StatusCode)
+	}
+	if resp, _ = call(http.MethodGet, "/api/projects/"+projectID, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected reads to keep working, got %d", resp.StatusCode)
+	}
+	if resp, _ = call(http.MethodPost, "/api/projects/validate", spec); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected spec validation to keep working, got %d", resp.StatusCode)
+	}
+	resp, system := call(http.MethodGet, "/api/system", nil)
+	readOnly, _ := system["read_only"].(map[string]any)
+	if resp.StatusCode != http.StatusOK || readOnly["enabled"] != true {
+		t.Fatalf("expected /api/system to report read-only mode, got %v", system)
+	}
+
+	if resp, state = call(http.MethodPost, "/api/admin/readonly", readOnlyRequest{}); state["enabled"] != false {
+		t.Fatalf("expected read-only mode off, got %d %v", resp.StatusCode, state)
+	}
+	resp, _ = call(http.MethodPut, "/api/projects/"+projectID, spec)
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		t.Fatal("expected updates to be accepted again once read-only mode is off")
+	}
+	if need, _ := apiRouteRole(http.MethodGet, "/api/admin/readonly"); need != apiRoleAdmin {
+		t.Fatalf("expected the switch to need the admin role, got %q", need)
+	}
+}
+
+func TestAdmitOp_RefusesOpsFromEveryProducerWhileReadOnly(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-read-only-admit"
+	spec := workerRuntimeSpec("read-only-admit")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-read-only-admit", OpCreate, spec)
+	_ = finalizeOp(ctx, fixture.store, "op-read-only-admit", projectID, OpCreate, opStatusDone, "")
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+
+	if err := fixture.store.putReadOnlyState(ctx, readOnlyState{Enabled: true, Message: "backup"}); err != nil {
+		t.Fatalf("enable read-only: %v", err)
+	}
+	// Remediation hooks, tag releases, previews, and schedules all queue
+	// through enqueueOp rather than an HTTP route.
+	opts := emptyOpRunOptions()
+	opts.remediationOf = "op-failed"
+	_, err := api.enqueueOp(ctx, OpCI, projectID, spec, opts)
+	var readOnly readOnlyError
+	if !errors.As(err, &readOnly) || readOnly.State.Message != "backup" {
+		t.Fatalf("expected the op refused as read-only, got %v", err)
+	}
+	rec := httptest.NewRecorder()
+	if !writeAsyncOpError(rec, err) || rec.Code != http.StatusServiceUnavailable ||
+		!bytes.Contains(rec.Body.Bytes(), []byte(`"code":"read_only"`)) {
+		t.Fatalf("expected the read-only envelope, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// A child of a parent that was running before maintenance began is not
+	// refused by read-only mode.
+	child := emptyOpRunOptions()
+	child.parentOpID = "op-parent"
+	if _, _, err = api.admitOp(ctx, OpDeploy, projectID, spec, child); errors.As(err, &readOnly) {
+		t.Fatalf("expected a child op not to be refused as read-only, got %v", err)
+	}
+
+	if err = fixture.store.putReadOnlyState(ctx, readOnlyState{}); err != nil {
+		t.Fatalf("disable read-only: %v", err)
+	}
+	if _, err = api.enqueueOp(ctx, OpCI, projectID, spec, opts); err != nil {
+		t.Fatalf("expected the op queued once read-only mode is lifted, got %v", err)
+	}
+}
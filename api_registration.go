@@ -2,9 +2,9 @@ package platform
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,9 +14,23 @@ import (
 func (a *API) createProjectFromSpec(
 	ctx context.Context,
 	spec ProjectSpec,
+	opts opRunOptions,
 ) (Project, Operation, error) {
 	spec = normalizeProjectSpec(spec)
-	if err := validateProjectSpec(spec); err != nil {
+	if err := a.validateSpec(spec); err != nil {
+		return Project{}, Operation{}, err
+	}
+	// A clone copies its source repo rather than seeding it from the template.
+	if opts.cloneOf == "" {
+		if err := checkProjectTemplateExists(spec.Template); err != nil {
+			return Project{}, Operation{}, err
+		}
+	}
+
+	if err := a.orgQuotaConflict(ctx, opts.placement.Org, "", spec); err != nil {
+		return Project{}, Operation{}, err
+	}
+	if err := a.projectNeedsConflict(ctx, "", opts.placement.Org, spec); err != nil {
 		return Project{}, Operation{}, err
 	}
 
@@ -27,6 +41,7 @@ func (a *API) createProjectFromSpec(
 		CreatedAt: now,
 		UpdatedAt: now,
 		Spec:      spec,
+		SpecHash:  projectSpecHash(spec),
 		Status: ProjectStatus{
 			Phase:      "Reconciling",
 			UpdatedAt:  now,
@@ -34,13 +49,18 @@ func (a *API) createProjectFromSpec(
 			LastOpKind: "",
 			Message:    statusMessageQueued,
 		},
+		Ownership: ProjectOwnership{Owners: nil, Teams: nil, OnCall: "", Escalation: nil, UpdatedAt: time.Time{}},
+		Org:       opts.placement.Org,
+		Workspace: opts.placement.Workspace,
+		Revision:  0,
 	}
 	putErr := a.store.PutProject(ctx, p)
 	if putErr != nil {
 		return Project{}, Operation{}, errors.New("failed to persist project")
 	}
+	a.store.projectOrgs.put(projectID, p.Org)
 
-	op, err := a.enqueueOp(ctx, OpCreate, projectID, spec, emptyOpRunOptions())
+	op, err := a.enqueueOp(ctx, OpCreate, projectID, spec, opts)
 	if err != nil {
 		rollbackErr := a.store.DeleteProject(context.WithoutCancel(ctx), projectID)
 		return Project{}, Operation{}, withCreateRollbackResult(err, projectID, rollbackErr)
@@ -52,21 +72,44 @@ func (a *API) createProjectFromSpec(
 	return project, op, nil
 }
 
+// specUnchangedError reports an update that would not change anything: the
+// project is Ready and already runs a spec with the same spec_hash.
+type specUnchangedError struct {
+	project Project
+}
+
+func (e specUnchangedError) Error() string {
+	return "spec unchanged (spec_hash " + e.project.SpecHash + ")"
+}
+
 func (a *API) updateProjectFromSpec(
 	ctx context.Context,
 	projectID string,
 	spec ProjectSpec,
+	force bool,
 ) (Project, Operation, error) {
 	spec = normalizeProjectSpec(spec)
-	if err := validateProjectSpec(spec); err != nil {
+	if err := a.validateSpec(spec); err != nil {
 		return Project{}, Operation{}, err
 	}
 
-	if _, err := a.store.GetProject(ctx, projectID); err != nil {
+	current, _, err := a.store.getProjectRevision(ctx, projectID)
+	if err != nil {
+		return Project{}, Operation{}, err
+	}
+	if spec, err = inheritProjectTemplate(current.Spec, spec); err != nil {
+		return Project{}, Operation{}, err
+	}
+	if !force && specUnchanged(current, spec) {
+		return current, Operation{}, specUnchangedError{project: current}
+	}
+	if err = a.projectNeedsConflict(ctx, projectID, current.Org, spec); err != nil {
 		return Project{}, Operation{}, err
 	}
 
-	op, err := a.enqueueOp(ctx, OpUpdate, projectID, spec, emptyOpRunOptions())
+	opts := emptyOpRunOptions().withProjectRevision(current.Revision)
+	opts.specChange = planSpecChange(a.artifacts, current, spec)
+	op, err := a.enqueueOp(ctx, OpUpdate, projectID, spec, opts)
 	if err != nil {
 		return Project{}, Operation{}, err
 	}
@@ -77,26 +120,37 @@ func (a *API) updateProjectFromSpec(
 func (a *API) deleteProject(
 	ctx context.Context,
 	projectID string,
+	planID string,
+	impactAcknowledged bool,
 ) (Operation, error) {
 	if _, err := a.store.GetProject(ctx, projectID); err != nil {
 		return Operation{}, err
 	}
 
-	op, err := a.enqueueOp(ctx, OpDelete, projectID, zeroProjectSpec(), emptyOpRunOptions())
+	op, err := a.enqueueOp(ctx, OpDelete, projectID, zeroProjectSpec(), deleteOpRunOptions(planID, impactAcknowledged))
 	if err != nil {
 		return Operation{}, err
 	}
 	return op, nil
 }
 
+// validateSpec runs the built-in spec checks, then the operator's extension
+// schemas, which only the API knows about.
+func (a *API) validateSpec(spec ProjectSpec) error {
+	if err := validateProjectSpec(spec); err != nil {
+		return err
+	}
+	return a.specExtensions.validate(spec.Extensions)
+}
+
 func (a *API) handleRegistrationEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	evt, err := decodeRegistrationEvent(r)
 	if err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeBadRequest(w, err)
 		return
 	}
 	switch evt.Action {
@@ -105,15 +159,15 @@ func (a *API) handleRegistrationEvents(w http.ResponseWriter, r *http.Request) {
 	case "update":
 		a.handleRegistrationUpdate(w, r, evt.ProjectID, evt.Spec)
 	case "delete":
-		a.handleRegistrationDelete(w, r, evt.ProjectID)
+		a.handleRegistrationDelete(w, r, evt)
 	default:
-		http.Error(w, "action must be create, update, or delete", http.StatusBadRequest)
+		writeAPIError(w, "action must be create, update, or delete", http.StatusBadRequest)
 	}
 }
 
 func decodeRegistrationEvent(r *http.Request) (RegistrationEvent, error) {
 	var evt RegistrationEvent
-	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
+	if err := decodeSpecBody(r, &evt); err != nil {
 		return RegistrationEvent{}, err
 	}
 	evt.Action = strings.TrimSpace(strings.ToLower(evt.Action))
@@ -121,7 +175,13 @@ func decodeRegistrationEvent(r *http.Request) (RegistrationEvent, error) {
 }
 
 func (a *API) handleRegistrationCreate(w http.ResponseWriter, r *http.Request, spec ProjectSpec) {
-	project, op, err := a.createProjectFromSpec(r.Context(), spec)
+	opts := emptyOpRunOptions()
+	placement, ok := a.requestPlacementOrWriteError(w, r, opts.placement)
+	if !ok {
+		return
+	}
+	opts.placement = placement
+	project, op, err := a.createProjectFromSpec(r.Context(), spec, opts)
 	if err != nil {
 		writeRegistrationError(w, err)
 		return
@@ -130,6 +190,7 @@ func (a *API) handleRegistrationCreate(w http.ResponseWriter, r *http.Request, s
 		"accepted": true,
 		"project":  project,
 		"op":       op,
+		"lint":     lintProjectSpec(spec),
 	})
 }
 
@@ -141,10 +202,15 @@ func (a *API) handleRegistrationUpdate(
 ) {
 	projectID = strings.TrimSpace(projectID)
 	if projectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
+		return
+	}
+	project, op, err := a.updateProjectFromSpec(r.Context(), projectID, spec, forceUpdateRequested(r))
+	var unchanged specUnchangedError
+	if errors.As(err, &unchanged) {
+		writeSpecUnchanged(w, unchanged.project)
 		return
 	}
-	project, op, err := a.updateProjectFromSpec(r.Context(), projectID, spec)
 	if err != nil {
 		writeRegistrationError(w, err)
 		return
@@ -153,16 +219,20 @@ func (a *API) handleRegistrationUpdate(
 		"accepted": true,
 		"project":  project,
 		"op":       op,
+		"lint":     lintProjectSpec(spec),
 	})
 }
 
-func (a *API) handleRegistrationDelete(w http.ResponseWriter, r *http.Request, projectID string) {
-	projectID = strings.TrimSpace(projectID)
+func (a *API) handleRegistrationDelete(w http.ResponseWriter, r *http.Request, evt RegistrationEvent) {
+	projectID := strings.TrimSpace(evt.ProjectID)
 	if projectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
+		return
+	}
+	if !requireAPIRole(w, r, apiRoleAdmin) {
 		return
 	}
-	op, err := a.deleteProject(r.Context(), projectID)
+	op, err := a.deleteProject(r.Context(), projectID, evt.PlanID, evt.AcknowledgeImpact)
 	if err != nil {
 		writeRegistrationError(w, err)
 		return
@@ -175,23 +245,51 @@ func (a *API) handleRegistrationDelete(w http.ResponseWriter, r *http.Request, p
 	})
 }
 
+// specUnchanged reports whether updating project to spec can be skipped. Only
+// a Ready project is skipped, so re-sending the spec still retries a failure.
+func specUnchanged(project Project, spec ProjectSpec) bool {
+	return project.Status.Phase == projectPhaseReady && project.SpecHash == projectSpecHash(spec)
+}
+
+// forceUpdateRequested reports ?force=true, which enqueues an update even
+// when the spec is unchanged.
+func forceUpdateRequested(r *http.Request) bool {
+	force, err := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("force")))
+	return err == nil && force
+}
+
+// impactAcknowledged reports ?acknowledge_impact=true, which a delete needs
+// when its plan lists impact outside the project.
+func impactAcknowledged(r *http.Request) bool {
+	acknowledged, err := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("acknowledge_impact")))
+	return err == nil && acknowledged
+}
+
+func writeSpecUnchanged(w http.ResponseWriter, project Project) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"accepted":  false,
+		"unchanged": true,
+		"project":   project,
+	})
+}
+
 func writeRegistrationError(w http.ResponseWriter, err error) {
 	if writeAsyncOpError(w, err) {
 		return
 	}
 	switch {
 	case errors.Is(err, jetstream.ErrKeyNotFound):
-		http.Error(w, "not found", http.StatusNotFound)
-	case isValidationError(err):
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeAPIError(w, "not found", http.StatusNotFound)
+	case isSpecValidationError(err):
+		writeBadRequest(w, err)
 	default:
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-func isValidationError(err error) bool {
-	msg := err.Error()
-	return strings.Contains(msg, "must") || strings.Contains(msg, "invalid")
+func isSpecValidationError(err error) bool {
+	var fieldErr specFieldError
+	return errors.As(err, &fieldErr)
 }
 
 func normalizeBranchValue(v string) string {
@@ -0,0 +1,309 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Remediation worker: a singleton job that watches for failed ops and runs
+// the runbook hook (see config_runbook.go) matching their failure code. The
+// run is recorded on the failed op, every action it takes starts an op marked
+// remediation_of, and each step is appended to the project's remediation
+// audit log under the hook's name.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	remediationPollInterval   = 10 * time.Second
+	remediationLookback       = 30 * time.Minute
+	remediationCleanupTimeout = 5 * time.Minute
+	remediationWaitInterval   = 500 * time.Millisecond
+	remediationActorPrefix    = "runbook:"
+	remediationStatusSkipped  = "skipped"
+	remediationCacheRoot      = "build"
+)
+
+func startRemediationWorker(ctx context.Context, api *API, elector *leaderElector) bool {
+	if len(api.runbook.Hooks) == 0 {
+		return false
+	}
+	remediationLog := appLoggerForProcess().Source("remediation")
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		runRemediationWorker(jobCtx, api, remediationLog)
+	})
+	return true
+}
+
+func runRemediationWorker(ctx context.Context, api *API, remediationLog sourceLogger) {
+	ticker := time.NewTicker(remediationPollInterval)
+	defer ticker.Stop()
+	for {
+		if err := api.remediateFailedOps(ctx, time.Now().UTC(), remediationLog); err != nil && ctx.Err() == nil {
+			remediationLog.Warnf("remediation scan failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remediateFailedOps runs hooks for ops that failed within
+// remediationLookback of now and have no remediation recorded yet, oldest
+// first. Older failures predate the worker and are left to the operator.
+func (a *API) remediateFailedOps(ctx context.Context, now time.Time, remediationLog sourceLogger) error {
+	ops, err := a.store.listAllOps(ctx)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(ops, func(x, y Operation) int { return x.Finished.Compare(y.Finished) })
+	for _, op := range ops {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if op.Status != opStatusError || op.Remediation != nil || op.Execution.DryRun ||
+			now.Sub(op.Finished) > remediationLookback {
+			continue
+		}
+		if hook, ok := a.runbook.hookFor(op.Kind, opFailureCode(op)); ok {
+			a.remediateOp(ctx, op, hook, remediationLog)
+		}
+	}
+	return nil
+}
+
+func (a *API) remediateOp(ctx context.Context, op Operation, hook runbookHook, remediationLog sourceLogger) {
+	remediation := OpRemediation{
+		Hook:        hook.Name,
+		FailureCode: opFailureCode(op),
+		Actor:       remediationActorPrefix + hook.Name,
+		Status:      opStatusRunning,
+		Message:     "",
+		Actions:     nil,
+		StartedAt:   time.Now().UTC(),
+		FinishedAt:  time.Time{},
+	}
+	attempts, err := a.remediationAttempts(ctx, op)
+	if err == nil && attempts >= hook.MaxAttempts {
+		remediation.Status = remediationStatusSkipped
+		err = fmt.Errorf("already retried %d of %d time(s)", attempts, hook.MaxAttempts)
+	}
+	if err != nil {
+		remediation.Message = err.Error()
+		if remediation.Status == opStatusRunning {
+			remediation.Status = opStatusError
+		}
+		remediation.FinishedAt = time.Now().UTC()
+		a.recordRemediation(ctx, op, remediation, remediationLog)
+		return
+	}
+	if !a.recordRemediation(ctx, op, remediation, remediationLog) {
+		return
+	}
+
+	for _, action := range hook.Actions {
+		result := a.runRemediationAction(ctx, op, action)
+		remediation.Actions = append(remediation.Actions, result)
+		a.auditRemediationAction(op, remediation, result, remediationLog)
+		if result.Status != opStatusDone {
+			remediation.Status = opStatusError
+			remediation.Message = action + ": " + result.Message
+			break
+		}
+	}
+	if remediation.Status == opStatusRunning {
+		remediation.Status = opStatusDone
+	}
+	remediation.FinishedAt = time.Now().UTC()
+	a.recordRemediation(ctx, op, remediation, remediationLog)
+}
+
+// remediationAttempts counts the retries that led to op by following
+// remediation_of back to the op that first failed.
+func (a *API) remediationAttempts(ctx context.Context, op Operation) (int, error) {
+	attempts := 0
+	for hops := 0; op.RemediationOf != "" && hops <= maxRunbookMaxAttempts; hops++ {
+		source, err := a.store.GetOp(ctx, op.RemediationOf)
+		if err != nil {
+			return attempts, fmt.Errorf("read remediated op %s: %w", op.RemediationOf, err)
+		}
+		if op.Kind == source.Kind {
+			attempts++
+		}
+		op = source
+	}
+	return attempts, nil
+}
+
+// recordRemediation writes remediation onto the failed op. It reports false
+// when the op could not be updated, so nothing runs without a record.
+func (a *API) recordRemediation(
+	ctx context.Context,
+	op Operation,
+	remediation OpRemediation,
+	remediationLog sourceLogger,
+) bool {
+	_, err := a.store.updateOp(ctx, op.ID, func(current *Operation) bool {
+		current.Remediation = &remediation
+		return true
+	})
+	if err != nil {
+		remediationLog.Warnf("record remediation op=%s hook=%s: %v", op.ID, remediation.Hook, err)
+		return false
+	}
+	if remediation.Status == opStatusRunning {
+		remediationLog.Infof(
+			"remediating op=%s project=%s code=%s hook=%s", op.ID, op.ProjectID, remediation.FailureCode, remediation.Hook,
+		)
+	}
+	a.appendProjectAuditLine(op.ProjectID, "remediation", fmt.Sprintf(
+		"%s op=%s code=%s actor=%q status=%s message=%q",
+		time.Now().UTC().Format(time.RFC3339),
+		op.ID,
+		remediation.FailureCode,
+		remediation.Actor,
+		remediation.Status,
+		remediation.Message,
+	))
+	return true
+}
+
+func (a *API) auditRemediationAction(
+	op Operation,
+	remediation OpRemediation,
+	result RemediationAction,
+	remediationLog sourceLogger,
+) {
+	remediationLog.Infof(
+		"remediation op=%s hook=%s action=%s started_op=%s status=%s %s",
+		op.ID, remediation.Hook, result.Action, result.OpID, result.Status, result.Message,
+	)
+	a.appendProjectAuditLine(op.ProjectID, "remediation", fmt.Sprintf(
+		"%s op=%s code=%s actor=%q action=%s started_op=%s status=%s message=%q",
+		result.At.Format(time.RFC3339),
+		op.ID,
+		remediation.FailureCode,
+		remediation.Actor,
+		result.Action,
+		result.OpID,
+		result.Status,
+		result.Message,
+	))
+}
+
+func (a *API) runRemediationAction(ctx context.Context, op Operation, action string) RemediationAction {
+	result := RemediationAction{Action: action, OpID: "", Status: opStatusDone, Message: "", At: time.Time{}}
+	var (
+		started Operation
+		err     error
+	)
+	switch action {
+	case remediationClearBuildCache:
+		started, err = a.clearBuildCacheFor(ctx, op)
+		result.Message = "removed build outputs"
+	case remediationRetry:
+		started, err = a.retryFailedOp(ctx, op)
+		result.Message = "queued " + string(op.Kind) + " retry"
+	default:
+		err = fmt.Errorf("unknown action %q", action)
+	}
+	result.OpID = started.ID
+	result.At = time.Now().UTC()
+	if err != nil {
+		result.Status = opStatusError
+		result.Message = err.Error()
+	}
+	return result
+}
+
+// clearBuildCacheFor removes the project's build outputs with a cleanup op
+// and waits for it, so a retry after it starts from a clean build tree.
+func (a *API) clearBuildCacheFor(ctx context.Context, op Operation) (Operation, error) {
+	project, err := a.store.GetProject(ctx, op.ProjectID)
+	if err != nil {
+		return Operation{}, fmt.Errorf("read project: %w", err)
+	}
+	opts := cleanupOpRunOptions(remediationCacheRoot)
+	opts.remediationOf = op.ID
+	cleanup, err := a.enqueueOp(ctx, OpCleanup, project.ID, project.Spec, opts)
+	if err != nil {
+		return Operation{}, err
+	}
+	return cleanup, a.waitOpTerminal(ctx, cleanup.ID, remediationCleanupTimeout)
+}
+
+// retryFailedOp queues op again with the project's current spec. Rollbacks,
+// deletes, cleanups, and var rollouts are not retried: what they act on may
+// have changed since they failed.
+func (a *API) retryFailedOp(ctx context.Context, op Operation) (Operation, error) {
+	project, err := a.store.GetProject(ctx, op.ProjectID)
+	if err != nil {
+		return Operation{}, fmt.Errorf("read project: %w", err)
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	var opts opRunOptions
+	switch op.Kind {
+	case OpCreate, OpCI:
+		opts = emptyOpRunOptions()
+		opts.cloneOf = op.CloneOf
+		opts.specChange = op.SpecChange
+		if op.CI != nil {
+			// The retry builds the same push but does not release it again.
+			opts.ci = &CIBuild{
+				Ref: op.CI.Ref, Commit: op.CI.Commit, Release: "", ReleaseError: "",
+				Preview: op.CI.Preview, Environment: op.CI.Environment,
+			}
+		}
+	case OpUpdate:
+		opts = emptyOpRunOptions()
+		opts.specChange = op.SpecChange
+	case OpDeploy:
+		opts = deployOpRunOptions(op.Delivery.Environment)
+	case OpPromote, OpRelease:
+		check, checkErr := a.checkTransitionSourceBudget(project.ID, spec, op.Delivery.FromEnv)
+		if checkErr != nil {
+			return Operation{}, checkErr
+		}
+		if len(check.exceeded) > 0 {
+			return Operation{}, vulnerabilityBudgetError{ProjectID: project.ID, Check: check}
+		}
+		opts = transitionOpRunOptions(op.Delivery.FromEnv, op.Delivery.ToEnv, op.Delivery.Stage)
+	case OpDelete, OpRollback, OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade, OpWebhookRefresh:
+		return Operation{}, fmt.Errorf("%s ops are not retried", op.Kind)
+	default:
+		return Operation{}, fmt.Errorf("%s ops are not retried", op.Kind)
+	}
+	opts.execution = op.Execution
+	opts.remediationOf = op.ID
+	return a.enqueueOp(ctx, op.Kind, project.ID, spec, opts)
+}
+
+// waitOpTerminal polls opID until it ends, returning an error unless it ends
+// done.
+func (a *API) waitOpTerminal(ctx context.Context, opID string, timeout time.Duration) error {
+	ticker := time.NewTicker(remediationWaitInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(timeout)
+	for {
+		op, err := a.store.GetOp(ctx, opID)
+		if err != nil {
+			return fmt.Errorf("read op %s: %w", opID, err)
+		}
+		switch {
+		case op.Status == opStatusDone:
+			return nil
+		case isOperationStatusTerminal(op.Status):
+			return fmt.Errorf("%s op %s ended %s: %s", op.Kind, op.ID, op.Status, op.Error)
+		case time.Now().After(deadline):
+			return fmt.Errorf("%s op %s did not finish within %s", op.Kind, op.ID, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//nolint:testpackage,exhaustruct // Remediation tests seed failed ops through internal fixtures.
+package platform
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRunbookConfig(t *testing.T) {
+	runbook, err := parseRunbookConfig([]byte(`{"hooks": [
+		{"name": "build-timeout", "failure_code": "build_timeout", "actions": ["clear_build_cache", "retry"]}
+	]}`))
+	if err != nil || runbook.Hooks[0].MaxAttempts != 1 {
+		t.Fatalf("expected a valid hook defaulting to one attempt, got %+v (%v)", runbook, err)
+	}
+	if _, ok := runbook.hookFor(OpCI, "build_timeout"); !ok {
+		t.Fatal("expected the hook to match any kind when kinds is unset")
+	}
+	hook := func(fields string) string { return `{"hooks": [{"name": "x", ` + fields + `}]}` }
+	for raw, wantErr := range map[string]string{
+		hook(`"failure_code": "build_slow", "actions": ["retry"]`):                             "unknown failure_code",
+		hook(`"failure_code": "build_timeout", "actions": ["wipe"]`):                           "unknown action",
+		hook(`"failure_code": "build_timeout", "actions": []`):                                 "actions required",
+		hook(`"failure_code": "build_timeout", "actions": ["retry"], "max_attempts": 9`):       "max_attempts",
+		hook(`"failure_code": "op_failed", "actions": ["retry"], "when": "always"`):            "unknown field",
+		`{"hooks": [{"name": "Bad Name", "failure_code": "op_failed", "actions": ["retry"]}]}`: "name must",
+	} {
+		if _, err = parseRunbookConfig([]byte(raw)); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: expected %q, got %v", raw, wantErr, err)
+		}
+	}
+}
+
+func TestRemediation_RetriesKnownFailureOnce(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	api := fixture.api
+	api.runbook = runbookConfig{Hooks: []runbookHook{{
+		Name: "ci-build-timeout", FailureCode: "build_timeout", Actions: []string{remediationRetry}, MaxAttempts: 1,
+	}}}
+	log := appLoggerForProcess().Source("remediation")
+
+	failed := Operation{
+		ID:        "op-build-timeout",
+		Kind:      OpCI,
+		ProjectID: fixture.projectID,
+		Requested: time.Now().UTC(),
+		Finished:  time.Now().UTC(),
+		Status:    opStatusError,
+		Error:     "build: context deadline exceeded",
+		Steps: []OpStep{
+			{Worker: "registrar"},
+			{Worker: "imageBuilder", Error: "build: context deadline exceeded"},
+			{Worker: "manifestRenderer", Error: "skipped: upstream error"},
+		},
+	}
+	if code := opFailureCode(failed); code != "build_timeout" {
+		t.Fatalf("expected build_timeout, got %s", code)
+	}
+	if err := api.store.PutOp(ctx, failed); err != nil {
+		t.Fatalf("put failed op: %v", err)
+	}
+	if err := api.remediateFailedOps(ctx, time.Now().UTC(), log); err != nil {
+		t.Fatalf("remediate: %v", err)
+	}
+	remediated, _ := api.store.GetOp(ctx, failed.ID)
+	rem := remediated.Remediation
+	if rem == nil || rem.Status != opStatusDone || rem.Actor != "runbook:ci-build-timeout" || len(rem.Actions) != 1 {
+		t.Fatalf("expected a finished remediation record, got %+v", rem)
+	}
+	retry, err := api.store.GetOp(ctx, rem.Actions[0].OpID)
+	if err != nil || retry.Kind != OpCI || retry.RemediationOf != failed.ID {
+		t.Fatalf("expected a ci retry pointing at the failed op, got %+v (%v)", retry, err)
+	}
+
+	// The retry fails the same way; the hook has used its one attempt.
+	retry.Status, retry.Finished, retry.Steps = opStatusError, time.Now().UTC(), failed.Steps
+	if err = api.store.PutOp(ctx, retry); err != nil {
+		t.Fatalf("put retry op: %v", err)
+	}
+	if err = api.remediateFailedOps(ctx, time.Now().UTC(), log); err != nil {
+		t.Fatalf("remediate retry: %v", err)
+	}
+	retry, _ = api.store.GetOp(ctx, retry.ID)
+	if retry.Remediation == nil || retry.Remediation.Status != remediationStatusSkipped {
+		t.Fatalf("expected the second failure to be skipped, got %+v", retry.Remediation)
+	}
+
+	audit, err := os.ReadFile(api.projectAuditLogPath(fixture.projectID, "remediation"))
+	wantLine := `actor="runbook:ci-build-timeout" action=retry started_op=` + retry.ID
+	if err != nil || !strings.Contains(string(audit), wantLine) {
+		t.Fatalf("expected the retry in the remediation audit log, got %q (%v)", audit, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,7 +22,23 @@ type opRunOptions struct {
 	rollbackEnv       string
 	rollbackScope     RollbackScope
 	rollbackOverride  bool
+	vulnOverride      *VulnerabilityOverride
+	freezeOverride    *FreezeOverride // deliveries: lets the op into a frozen environment
+	approvalID        string          // release only: the approval it was granted under
 	delivery          DeliveryLifecycle
+	deletePlanID      string
+	deleteImpactAcked bool
+	artifactPrefix    string
+	execution         OpExecution
+	parentOpID        string
+	specChange        *SpecChange
+	remediationOf     string
+	scheduleID        string // ops the scheduler starts: the schedule that fired
+	runtimeTarget     string
+	cloneOf           string           // create only: project whose source repo is copied
+	ci                *CIBuild         // ci only: the push to build
+	projectRevision   uint64           // project revision the request was based on; 0 skips the check
+	placement         projectPlacement // create only: org and workspace the project joins
 }
 
 func emptyOpRunOptions() opRunOptions {
@@ -33,12 +50,32 @@ func emptyOpRunOptions() opRunOptions {
 		rollbackEnv:       "",
 		rollbackScope:     "",
 		rollbackOverride:  false,
+		vulnOverride:      nil,
+		freezeOverride:    nil,
+		approvalID:        "",
 		delivery: DeliveryLifecycle{
-			Stage:       "",
-			Environment: "",
-			FromEnv:     "",
-			ToEnv:       "",
+			Stage:           "",
+			Environment:     "",
+			FromEnv:         "",
+			ToEnv:           "",
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
 		},
+		deletePlanID:      "",
+		deleteImpactAcked: false,
+		artifactPrefix:    "",
+		execution:         OpExecution{DryRun: false, Trace: false},
+		parentOpID:        "",
+		specChange:        nil,
+		remediationOf:     "",
+		scheduleID:        "",
+		runtimeTarget:     "",
+		cloneOf:           "",
+		ci:                nil,
+		projectRevision:   0,
+		placement:         projectPlacement{Org: "", Workspace: ""},
 	}
 }
 
@@ -84,6 +121,65 @@ func (e projectOpConflictError) Error() string {
 	)
 }
 
+func deleteOpRunOptions(planID string, impactAcknowledged bool) opRunOptions {
+	opts := emptyOpRunOptions()
+	opts.deletePlanID = strings.TrimSpace(planID)
+	opts.deleteImpactAcked = impactAcknowledged
+	return opts
+}
+
+func cleanupOpRunOptions(prefix string) opRunOptions {
+	opts := emptyOpRunOptions()
+	opts.artifactPrefix = prefix
+	return opts
+}
+
+func runtimeUpgradeOpRunOptions(target string) opRunOptions {
+	opts := emptyOpRunOptions()
+	opts.runtimeTarget = target
+	return opts
+}
+
+// withExecution returns a copy of o that runs under the given execution
+// profile.
+func (o opRunOptions) withExecution(execution OpExecution) opRunOptions {
+	o.execution = execution
+	return o
+}
+
+// withProjectRevision returns a copy of o that is refused with a
+// projectRevisionConflictError when the project has been written since
+// revision.
+func (o opRunOptions) withProjectRevision(revision uint64) opRunOptions {
+	o.projectRevision = revision
+	return o
+}
+
+// opExecutionFromRequest reads the dry_run and trace query flags that select
+// how workers run the op a request starts.
+func opExecutionFromRequest(r *http.Request) (OpExecution, error) {
+	execution := OpExecution{DryRun: false, Trace: false}
+	query := r.URL.Query()
+	for _, flag := range []struct {
+		name string
+		dst  *bool
+	}{
+		{name: "dry_run", dst: &execution.DryRun},
+		{name: "trace", dst: &execution.Trace},
+	} {
+		raw := strings.TrimSpace(query.Get(flag.name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return execution, fmt.Errorf("%s must be true or false", flag.name)
+		}
+		*flag.dst = value
+	}
+	return execution, nil
+}
+
 func deployOpRunOptions(env string) opRunOptions {
 	return opRunOptions{
 		deployEnv:         env,
@@ -93,12 +189,32 @@ func deployOpRunOptions(env string) opRunOptions {
 		rollbackEnv:       "",
 		rollbackScope:     "",
 		rollbackOverride:  false,
+		vulnOverride:      nil,
+		freezeOverride:    nil,
+		approvalID:        "",
 		delivery: DeliveryLifecycle{
-			Stage:       DeliveryStageDeploy,
-			Environment: env,
-			FromEnv:     "",
-			ToEnv:       "",
+			Stage:           DeliveryStageDeploy,
+			Environment:     env,
+			FromEnv:         "",
+			ToEnv:           "",
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
 		},
+		deletePlanID:      "",
+		deleteImpactAcked: false,
+		artifactPrefix:    "",
+		execution:         OpExecution{DryRun: false, Trace: false},
+		parentOpID:        "",
+		specChange:        nil,
+		remediationOf:     "",
+		scheduleID:        "",
+		runtimeTarget:     "",
+		cloneOf:           "",
+		ci:                nil,
+		projectRevision:   0,
+		placement:         projectPlacement{Org: "", Workspace: ""},
 	}
 }
 
@@ -111,12 +227,32 @@ func transitionOpRunOptions(fromEnv, toEnv string, stage DeliveryStage) opRunOpt
 		rollbackEnv:       "",
 		rollbackScope:     "",
 		rollbackOverride:  false,
+		vulnOverride:      nil,
+		freezeOverride:    nil,
+		approvalID:        "",
 		delivery: DeliveryLifecycle{
-			Stage:       stage,
-			Environment: "",
-			FromEnv:     fromEnv,
-			ToEnv:       toEnv,
+			Stage:           stage,
+			Environment:     "",
+			FromEnv:         fromEnv,
+			ToEnv:           toEnv,
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
 		},
+		deletePlanID:      "",
+		deleteImpactAcked: false,
+		artifactPrefix:    "",
+		execution:         OpExecution{DryRun: false, Trace: false},
+		parentOpID:        "",
+		specChange:        nil,
+		remediationOf:     "",
+		scheduleID:        "",
+		runtimeTarget:     "",
+		cloneOf:           "",
+		ci:                nil,
+		projectRevision:   0,
+		placement:         projectPlacement{Org: "", Workspace: ""},
 	}
 }
 
@@ -135,12 +271,32 @@ func rollbackOpRunOptions(
 		rollbackEnv:       environment,
 		rollbackScope:     scope,
 		rollbackOverride:  override,
+		vulnOverride:      nil,
+		freezeOverride:    nil,
+		approvalID:        "",
 		delivery: DeliveryLifecycle{
-			Stage:       rollbackDeliveryStage(environment),
-			Environment: environment,
-			FromEnv:     environment,
-			ToEnv:       environment,
+			Stage:           rollbackDeliveryStage(environment),
+			Environment:     environment,
+			FromEnv:         environment,
+			ToEnv:           environment,
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
 		},
+		deletePlanID:      "",
+		deleteImpactAcked: false,
+		artifactPrefix:    "",
+		execution:         OpExecution{DryRun: false, Trace: false},
+		parentOpID:        "",
+		specChange:        nil,
+		remediationOf:     "",
+		scheduleID:        "",
+		runtimeTarget:     "",
+		cloneOf:           "",
+		ci:                nil,
+		projectRevision:   0,
+		placement:         projectPlacement{Org: "", Workspace: ""},
 	}
 }
 
@@ -151,13 +307,16 @@ func (a *API) enqueueOp(
 	spec ProjectSpec,
 	opts opRunOptions,
 ) (Operation, error) {
+	if err := a.readiness.admit(); err != nil {
+		return Operation{}, err
+	}
 	projectMu := a.projectStartLock(projectID)
 	projectMu.Lock()
 	defer projectMu.Unlock()
 
-	conflictErr := a.projectOperationConflict(ctx, projectID, kind)
-	if conflictErr != nil {
-		return Operation{}, conflictErr
+	freezeOverride, plan, admitErr := a.admitOp(ctx, kind, projectID, spec, opts)
+	if admitErr != nil {
+		return Operation{}, admitErr
 	}
 
 	apiLog := appLoggerForProcess().Source("api")
@@ -165,15 +324,34 @@ func (a *API) enqueueOp(
 	now := time.Now().UTC()
 
 	op := Operation{
-		ID:        opID,
-		Kind:      kind,
-		ProjectID: projectID,
-		Delivery:  opts.delivery,
-		Requested: now,
-		Finished:  time.Time{},
-		Status:    statusMessageQueued,
-		Error:     "",
-		Steps:     []OpStep{},
+		ID:                    opID,
+		Kind:                  kind,
+		ProjectID:             projectID,
+		Delivery:              opts.delivery,
+		Execution:             opts.execution,
+		Requested:             now,
+		Finished:              time.Time{},
+		Status:                statusMessageQueued,
+		Error:                 "",
+		Steps:                 []OpStep{},
+		VulnerabilityOverride: opts.vulnOverride,
+		FreezeOverride:        freezeOverride.recorded(),
+		ApprovalID:            opts.approvalID,
+		Notes:                 nil,
+		SpecHash:              opSpecHash(kind, spec),
+		ParentOpID:            opts.parentOpID,
+		Rollout:               nil,
+		Fanout:                nil,
+		SpecChange:            opts.specChange,
+		Remediation:           nil,
+		RemediationOf:         opts.remediationOf,
+		ScheduleID:            opts.scheduleID,
+		CloneOf:               opts.cloneOf,
+		CI:                    opts.ci,
+		Upgrade:               newRuntimeUpgrade(kind, spec, opts.runtimeTarget),
+		Webhook:               nil,
+		SLABreached:           false,
+		SLABreaches:           nil,
 	}
 	if err := a.store.PutOp(ctx, op); err != nil {
 		return Operation{}, fmt.Errorf("persist op: %w", err)
@@ -182,7 +360,10 @@ func (a *API) enqueueOp(
 
 	opMsg := newProjectOpMsg(opID, kind, projectID, spec, opts, now)
 	body, _ := json.Marshal(opMsg)
-	startSubject := startSubjectForOperation(kind)
+	// A project whose org cannot be read starts on the bare subject, which
+	// the workers consume all the same.
+	org, _ := a.store.projectOrg(ctx, projectID)
+	startSubject := workerSubjectFor(startSubjectForOperation(kind), org, projectID)
 
 	finalizeCtx := context.WithoutCancel(ctx)
 	if err := a.nc.Publish(startSubject, body); err != nil {
@@ -208,8 +389,14 @@ func (a *API) enqueueOp(
 			ProjectRolledBack: nil,
 		}
 	}
-	a.setQueuedProjectStatus(ctx, opID, kind, projectID, spec, now)
+	if !opts.execution.DryRun && opUpdatesProjectStatus(kind) {
+		a.setQueuedProjectStatus(ctx, opID, kind, projectID, spec, now)
+		if kind == OpDelete {
+			a.consumeDeletePlan(finalizeCtx, plan, opID)
+		}
+	}
 
+	a.auditFreezeOverride(op)
 	emitOpBootstrap(a.opEvents, op, "operation accepted and queued")
 	emitOpStatus(a.opEvents, op, "queued")
 
@@ -217,6 +404,64 @@ func (a *API) enqueueOp(
 	return op, nil
 }
 
+// admitOp runs the checks an op must pass before it is queued, in order:
+// read-only mode, the project's active op, access, the org quota, holds, the expected revision,
+// environment freezes, release approval, and a delete plan. It returns the freeze override to
+// record and the plan to consume once the op is queued.
+func (a *API) admitOp(
+	ctx context.Context,
+	kind OperationKind,
+	projectID string,
+	spec ProjectSpec,
+	opts opRunOptions,
+) (FreezeOverride, DeletePlan, error) {
+	// A child op belongs to a parent that was already running when read-only
+	// mode came on, and running ops are left to finish.
+	if opts.parentOpID == "" {
+		if readOnlyErr := a.readOnlyConflict(ctx); readOnlyErr != nil {
+			return FreezeOverride{}, DeletePlan{}, readOnlyErr
+		}
+	}
+	conflictErr := a.projectOperationConflict(ctx, projectID, kind)
+	if conflictErr != nil && !isActiveParentOpConflict(conflictErr, opts.parentOpID) {
+		return FreezeOverride{}, DeletePlan{}, conflictErr
+	}
+	if accessErr := a.projectAccessConflict(ctx, projectID, kind); accessErr != nil {
+		return FreezeOverride{}, DeletePlan{}, accessErr
+	}
+	if quotaErr := a.projectOrgQuotaConflict(ctx, projectID, kind, spec); quotaErr != nil {
+		return FreezeOverride{}, DeletePlan{}, quotaErr
+	}
+	if quotaErr := a.projectQuotaConflict(ctx, projectID, kind, opts); quotaErr != nil {
+		return FreezeOverride{}, DeletePlan{}, quotaErr
+	}
+	if holdErr := a.projectHoldConflict(ctx, projectID, kind, opts); holdErr != nil {
+		return FreezeOverride{}, DeletePlan{}, holdErr
+	}
+	if dependentsErr := a.projectDependentsConflict(ctx, projectID, kind); dependentsErr != nil {
+		return FreezeOverride{}, DeletePlan{}, dependentsErr
+	}
+	if revisionErr := a.checkProjectRevision(ctx, projectID, opts.projectRevision); revisionErr != nil {
+		return FreezeOverride{}, DeletePlan{}, revisionErr
+	}
+	freezeOverride, freezeErr := a.environmentFreezeGate(ctx, projectID, kind, spec, opts)
+	if freezeErr != nil {
+		return FreezeOverride{}, DeletePlan{}, freezeErr
+	}
+	if approvalErr := a.releaseApprovalGate(ctx, projectID, kind, opts); approvalErr != nil {
+		return FreezeOverride{}, DeletePlan{}, approvalErr
+	}
+	// A dry run changes nothing, so it neither needs nor spends a delete plan.
+	if opts.execution.DryRun {
+		return freezeOverride, DeletePlan{}, nil
+	}
+	plan, planErr := a.confirmDeletePlan(ctx, projectID, kind, opts.deletePlanID, opts.deleteImpactAcked)
+	if planErr != nil {
+		return FreezeOverride{}, DeletePlan{}, planErr
+	}
+	return freezeOverride, plan, nil
+}
+
 func (a *API) projectStartLock(projectID string) *sync.Mutex {
 	projectID = strings.TrimSpace(projectID)
 	if projectID == "" {
@@ -275,9 +520,30 @@ func (a *API) projectOperationConflict(
 	}
 }
 
+// isActiveParentOpConflict reports whether the op blocking a project is the
+// parent of the op being started, which is how a var rollout runs its
+// stages, or a sibling under the same parent, which is how a fan-out
+// promotion runs its targets side by side.
+func isActiveParentOpConflict(err error, parentOpID string) bool {
+	var conflictErr projectOpConflictError
+	if parentOpID == "" || !errors.As(err, &conflictErr) {
+		return false
+	}
+	return conflictErr.ActiveOp.ID == parentOpID || conflictErr.ActiveOp.ParentOpID == parentOpID
+}
+
 func isOperationStatusActive(status string) bool {
 	switch strings.ToLower(strings.TrimSpace(status)) {
-	case statusMessageQueued, opStatusRunning:
+	case statusMessageQueued, opStatusRunning, opStatusInterrupted:
+		return true
+	default:
+		return false
+	}
+}
+
+func isOperationStatusTerminal(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case opStatusDone, opStatusError, opStatusCancelled:
 		return true
 	default:
 		return false
@@ -289,11 +555,12 @@ func writeProjectOpConflict(w http.ResponseWriter, err error) bool {
 	if !errors.As(err, &conflictErr) {
 		return false
 	}
-	writeJSON(w, http.StatusConflict, map[string]any{
+	writeErrorDetails(w, http.StatusConflict, errorCodeOpConflict, map[string]any{
 		"accepted":       false,
 		"reason":         conflictErr.Error(),
 		"project_id":     conflictErr.ProjectID,
 		"requested_kind": conflictErr.RequestedKind,
+		"op_id":          conflictErr.ActiveOp.ID,
 		"active_op": map[string]any{
 			"id":     conflictErr.ActiveOp.ID,
 			"kind":   conflictErr.ActiveOp.Kind,
@@ -329,14 +596,47 @@ func writeOpEnqueueError(w http.ResponseWriter, err error) bool {
 	if payload["next_step"] == "" {
 		payload["next_step"] = enqueueRetryNextStep(opID, projectID)
 	}
-	writeJSON(w, http.StatusInternalServerError, payload)
+	writeErrorDetails(w, http.StatusInternalServerError, errorCodeEnqueueFailed, payload)
 	return true
 }
 
 func writeAsyncOpError(w http.ResponseWriter, err error) bool {
+	if writeReadOnlyRefused(w, err) {
+		return true
+	}
 	if writeProjectOpConflict(w, err) {
 		return true
 	}
+	if writeProjectAccessDenied(w, err) {
+		return true
+	}
+	if writeProjectHoldConflict(w, err) {
+		return true
+	}
+	if writeProjectHasDependents(w, err) {
+		return true
+	}
+	if writeEnvironmentFrozen(w, err) {
+		return true
+	}
+	if writeReleaseApprovalRequired(w, err) {
+		return true
+	}
+	if writeProjectRevisionConflict(w, err) {
+		return true
+	}
+	if writeDeletePlanError(w, err) {
+		return true
+	}
+	if writeWorkersNotReady(w, err) {
+		return true
+	}
+	if writeOrgQuotaExceeded(w, err) {
+		return true
+	}
+	if writeProjectQuotaExceeded(w, err) {
+		return true
+	}
 	return writeOpEnqueueError(w, err)
 }
 
@@ -396,25 +696,23 @@ func (a *API) setQueuedProjectStatus(
 	spec ProjectSpec,
 	now time.Time,
 ) {
-	project, err := a.store.GetProject(ctx, projectID)
-	if err != nil {
-		return
-	}
 	phase := "Reconciling"
 	if kind == OpDelete {
 		phase = projectPhaseDel
 	}
-	if kind != OpDelete {
-		project.Spec = spec
-	}
-	project.Status = ProjectStatus{
-		Phase:      phase,
-		UpdatedAt:  now,
-		LastOpID:   opID,
-		LastOpKind: string(kind),
-		Message:    queuedProjectMessage(kind),
-	}
-	_ = a.store.PutProject(ctx, project)
+	_, _ = a.store.updateProject(ctx, projectID, func(project *Project) bool {
+		if kind != OpDelete {
+			project.Spec = spec
+		}
+		project.Status = ProjectStatus{
+			Phase:      phase,
+			UpdatedAt:  now,
+			LastOpID:   opID,
+			LastOpKind: string(kind),
+			Message:    queuedProjectMessage(kind),
+		}
+		return true
+	})
 }
 
 func queuedProjectMessage(kind OperationKind) string {
@@ -435,6 +733,16 @@ func queuedProjectMessage(kind OperationKind) string {
 		return "queued release"
 	case OpRollback:
 		return "queued rollback"
+	case OpCleanup:
+		return "queued artifact cleanup"
+	case OpVarRollout:
+		return "queued var rollout"
+	case OpPromoteFanout:
+		return "queued fan-out promotion"
+	case OpRuntimeUpgrade:
+		return "queued runtime upgrade trial"
+	case OpWebhookRefresh:
+		return "queued webhook endpoint refresh"
 	default:
 		return statusMessageQueued
 	}
@@ -460,7 +768,14 @@ func newProjectOpMsg(
 		RollbackEnv:       opts.rollbackEnv,
 		RollbackScope:     opts.rollbackScope,
 		RollbackOverride:  opts.rollbackOverride,
+		ArtifactPrefix:    opts.artifactPrefix,
+		RuntimeTarget:     opts.runtimeTarget,
+		CloneOf:           opts.cloneOf,
+		CI:                opts.ci,
 		Delivery:          opts.delivery,
+		Execution:         opts.execution,
+		SpecChange:        opts.specChange,
+		ApprovalID:        opts.approvalID,
 		Err:               "",
 		At:                now,
 	}
@@ -469,22 +784,30 @@ func newProjectOpMsg(
 func startSubjectForOperation(kind OperationKind) string {
 	switch kind {
 	case OpCreate:
-		return subjectProjectOpStart
+		return natsSubject(subjectProjectOpStart)
 	case OpUpdate:
-		return subjectProjectOpStart
+		return natsSubject(subjectProjectOpStart)
 	case OpDelete:
-		return subjectProjectOpStart
+		return natsSubject(subjectProjectOpStart)
 	case OpCI:
-		return subjectBootstrapDone
+		return natsSubject(subjectBootstrapDone)
 	case OpDeploy:
-		return subjectDeploymentStart
+		return natsSubject(subjectDeploymentStart)
 	case OpPromote:
-		return subjectPromotionStart
+		return natsSubject(subjectPromotionStart)
 	case OpRelease:
-		return subjectPromotionStart
+		return natsSubject(subjectPromotionStart)
 	case OpRollback:
-		return subjectPromotionStart
+		return natsSubject(subjectPromotionStart)
+	case OpCleanup:
+		return natsSubject(subjectCleanupStart)
+	case OpRuntimeUpgrade:
+		return natsSubject(subjectUpgradeStart)
+	case OpVarRollout, OpPromoteFanout, OpWebhookRefresh:
+		// Var rollouts, fan-out promotions, and webhook refreshes run in
+		// the API; only the parents' child ops reach workers.
+		return ""
 	default:
-		return subjectProjectOpStart
+		return natsSubject(subjectProjectOpStart)
 	}
 }
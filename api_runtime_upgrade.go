@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type runtimeUpgradeRequest struct {
+	TargetRuntime string `json:"target_runtime"`
+}
+
+// handleProjectRuntimeUpgrade queues a runtime upgrade trial:
+//
+//	POST /api/projects/{id}/runtime-upgrade {"target_runtime":"go_1.27"}
+//
+// The trial works on a runtime-upgrade/<target> branch; the project's spec
+// and main are left for the team to change once the trial passes.
+func (a *API) handleProjectRuntimeUpgrade(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != projectRelPathPartsMin || parts[1] != "runtime-upgrade" {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	if projectID == "" {
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
+		return
+	}
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	var req runtimeUpgradeRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	target := strings.TrimSpace(req.TargetRuntime)
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	if err = validateRuntimeUpgradeTarget(spec, target); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	opts := runtimeUpgradeOpRunOptions(target).withExecution(execution)
+	op, err := a.enqueueOp(r.Context(), OpRuntimeUpgrade, projectID, spec, opts)
+	if err != nil {
+		if writeAsyncOpError(w, err) {
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"accepted":   true,
+		"op":         op,
+		"project_id": projectID,
+		"branch":     runtimeUpgradeBranch(target),
+	})
+}
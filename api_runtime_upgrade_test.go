@@ -0,0 +1,134 @@
+//nolint:testpackage,exhaustruct // Runtime upgrade tests drive enqueueOp and the worker against the internal store.
+package platform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestAPI_RuntimeUpgradeTrialsBranchWithoutTouchingMain(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	projectID := "project-runtime-upgrade"
+	now := time.Now().UTC()
+	spec := workerRuntimeSpec("upgrade-app")
+	if err := fixture.store.PutProject(context.Background(), Project{
+		ID:        projectID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Spec:      spec,
+		Status:    ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now},
+	}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	artifacts := NewFSArtifacts(t.TempDir())
+	repoDir := sourceRepoDir(artifacts, projectID)
+	if err := ensureLocalGitRepo(context.Background(), repoDir); err != nil {
+		t.Fatalf("init source repo: %v", err)
+	}
+	seed := map[string]string{
+		"README.md":  "# upgrade-app source\n\nRuntime: go_1.26\n",
+		"go.mod":     "module example.com/upgrade\n\ngo 1.26.1\n",
+		"Dockerfile": "FROM --platform=linux/amd64 golang:1.26-alpine AS build\nFROM alpine:3.20\n",
+	}
+	for name, body := range seed {
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte(body), fileModePrivate); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if _, err := gitCommitIfChanged(context.Background(), repoDir, "seed"); err != nil {
+		t.Fatalf("commit seed: %v", err)
+	}
+	mainBefore, err := gitRevParse(context.Background(), repoDir, branchMain)
+	if err != nil {
+		t.Fatalf("rev-parse main: %v", err)
+	}
+
+	api := &API{nc: fixture.nc, store: fixture.store, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	upgradeURL := srv.URL + "/api/projects/" + projectID + "/runtime-upgrade"
+	post := func(body string) int {
+		resp, postErr := http.Post(upgradeURL, "application/json", strings.NewReader(body))
+		if postErr != nil {
+			t.Fatalf("post runtime upgrade: %v", postErr)
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	for _, target := range []string{"", "go_1.26", "go_1.25", "node_22"} {
+		if status := post(`{"target_runtime":"` + target + `"}`); status != http.StatusBadRequest {
+			t.Fatalf("expected 400 for target %q, got %d", target, status)
+		}
+	}
+	if status := post(`{"target_runtime":"go_1.27"}`); status != http.StatusAccepted {
+		t.Fatalf("expected 202 for go_1.27, got %d", status)
+	}
+
+	page, err := fixture.store.listProjectOps(context.Background(), projectID, projectOpsListQuery{Limit: 1})
+	if err != nil || len(page.Ops) != 1 || page.Ops[0].Kind != OpRuntimeUpgrade {
+		t.Fatalf("expected queued runtime upgrade op, got %#v err=%v", page.Ops, err)
+	}
+	opID := page.Ops[0].ID
+	msg := newProjectOpMsg(opID, OpRuntimeUpgrade, projectID, spec, runtimeUpgradeOpRunOptions("go_1.27"), now)
+	mode := imageBuilderModeResolution{effectiveMode: imageBuilderModeArtifact}
+	if _, err = runtimeUpgradeWorkerAction(context.Background(), fixture.store, artifacts, msg, mode); err != nil {
+		t.Fatalf("run runtime upgrade: %v", err)
+	}
+
+	op, err := fixture.store.GetOp(context.Background(), opID)
+	if err != nil || op.Status != opStatusDone || op.Upgrade == nil {
+		t.Fatalf("expected finished upgrade op, got %#v err=%v", op, err)
+	}
+	if op.Upgrade.Branch != "runtime-upgrade/go_1.27" || op.Upgrade.Build != runtimeUpgradeBuildPassed ||
+		len(op.Upgrade.Changes) != len(seed) {
+		t.Fatalf("unexpected upgrade record: %#v", op.Upgrade)
+	}
+	mainAfter, err := gitRevParse(context.Background(), repoDir, branchMain)
+	if err != nil || mainAfter != mainBefore {
+		t.Fatalf("expected main to stay at %s, got %s err=%v", mainBefore, mainAfter, err)
+	}
+	worktreeReadme, err := os.ReadFile(filepath.Join(repoDir, "README.md"))
+	if err != nil || string(worktreeReadme) != seed["README.md"] {
+		t.Fatalf("expected worktree untouched, got %q err=%v", worktreeReadme, err)
+	}
+
+	repo, err := openLocalRepo(repoDir)
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	commit, err := repo.CommitObject(plumbing.NewHash(op.Upgrade.Commit))
+	if err != nil {
+		t.Fatalf("read upgrade commit: %v", err)
+	}
+	want := map[string]string{
+		"README.md":  "# upgrade-app source\n\nRuntime: go_1.27\n",
+		"go.mod":     "module example.com/upgrade\n\ngo 1.27\n",
+		"Dockerfile": "FROM --platform=linux/amd64 golang:1.27-alpine AS build\nFROM alpine:3.20\n",
+	}
+	for name, body := range want {
+		file, fileErr := commit.File(name)
+		if fileErr != nil {
+			t.Fatalf("read %s on branch: %v", name, fileErr)
+		}
+		if got, _ := file.Contents(); got != body {
+			t.Fatalf("expected %s on branch to be %q, got %q", name, body, got)
+		}
+	}
+	if _, err = artifacts.ReadFile(projectID, "upgrades/"+opID+"/report.json"); err != nil {
+		t.Fatalf("expected upgrade report: %v", err)
+	}
+	project, err := fixture.store.GetProject(context.Background(), projectID)
+	if err != nil || project.Spec.Runtime != "go_1.26" || project.Status.Phase != projectPhaseReady {
+		t.Fatalf("expected project spec and phase unchanged, got %#v err=%v", project, err)
+	}
+}
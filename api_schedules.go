@@ -0,0 +1,331 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Project op schedules: /api/projects/{id}/schedules[/{scheduleID}]. A
+// schedule names a cron expression and the op it queues; ops_scheduler.go
+// fires them.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	maxProjectSchedules   = 20
+	maxScheduleNameLength = 64
+	scheduleNotFound      = "schedule not found"
+	projectChangeSchedule = "schedule ops for"
+	scheduleItemPathParts = 3
+)
+
+type opScheduleRequest struct {
+	Name          string        `json:"name"`
+	Cron          string        `json:"cron"`
+	Timezone      string        `json:"timezone"`
+	Kind          OperationKind `json:"kind"`
+	Environment   string        `json:"environment"`
+	FromEnv       string        `json:"from_env"`
+	ToEnv         string        `json:"to_env"`
+	TargetRuntime string        `json:"target_runtime"`
+	Enabled       *bool         `json:"enabled"` // default true
+}
+
+type projectSchedulesResponse struct {
+	ProjectID string       `json:"project_id"`
+	Schedules []OpSchedule `json:"schedules"`
+}
+
+func (a *API) handleProjectSchedules(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "schedule data unavailable", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) > scheduleItemPathParts || parts[1] != "schedules" {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	if projectID == "" {
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	if len(parts) == projectRelPathPartsMin {
+		switch r.Method {
+		case http.MethodGet:
+			a.handleProjectScheduleList(w, r, projectID)
+		case http.MethodPost:
+			a.handleProjectScheduleSave(w, r, project, "")
+		default:
+			writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	scheduleID := strings.TrimSpace(parts[2])
+	switch r.Method {
+	case http.MethodGet:
+		schedules, err := a.store.getProjectSchedules(r.Context(), projectID)
+		if err != nil {
+			writeAPIError(w, "failed to read schedules", http.StatusInternalServerError)
+			return
+		}
+		i, found := schedules.find(scheduleID)
+		if !found {
+			writeAPIError(w, scheduleNotFound, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, schedules.Schedules[i])
+	case http.MethodPut:
+		a.handleProjectScheduleSave(w, r, project, scheduleID)
+	case http.MethodDelete:
+		a.handleProjectScheduleDelete(w, r, project, scheduleID)
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleProjectScheduleList(w http.ResponseWriter, r *http.Request, projectID string) {
+	schedules, err := a.store.getProjectSchedules(r.Context(), projectID)
+	if err != nil {
+		writeAPIError(w, "failed to read schedules", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, projectSchedulesResponse{ProjectID: projectID, Schedules: schedules.Schedules})
+}
+
+// handleProjectScheduleSave creates a schedule (empty scheduleID) or
+// replaces the settings of an existing one, keeping its last run.
+func (a *API) handleProjectScheduleSave(w http.ResponseWriter, r *http.Request, project Project, scheduleID string) {
+	if err := a.authorizeProjectChange(r.Context(), project, projectChangeSchedule); err != nil {
+		if writeProjectAccessDenied(w, err) {
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var req opScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	schedule, err := newOpSchedule(normalizeProjectSpec(project.Spec), req, now)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	schedule.ProjectID = project.ID
+	if principal, ok := requestPrincipal(r.Context()); ok {
+		schedule.CreatedBy = principal.Name
+	}
+	var saved OpSchedule
+	_, err = a.store.updateProjectSchedules(r.Context(), project.ID, func(schedules *projectSchedules) error {
+		var placeErr error
+		saved, placeErr = placeSchedule(schedules, schedule, scheduleID)
+		return placeErr
+	})
+	switch {
+	case errors.Is(err, jetstream.ErrKeyNotFound):
+		writeAPIError(w, scheduleNotFound, http.StatusNotFound)
+	case err != nil:
+		writeTransitionError(w, err)
+	case scheduleID == "":
+		writeJSON(w, http.StatusCreated, saved)
+	default:
+		writeJSON(w, http.StatusOK, saved)
+	}
+}
+
+// placeSchedule adds schedule to schedules, or puts it in place of the one
+// with scheduleID, keeping that one's identity and history.
+func placeSchedule(schedules *projectSchedules, schedule OpSchedule, scheduleID string) (OpSchedule, error) {
+	index := len(schedules.Schedules)
+	if scheduleID != "" {
+		i, found := schedules.find(scheduleID)
+		if !found {
+			return OpSchedule{}, jetstream.ErrKeyNotFound
+		}
+		existing := schedules.Schedules[i]
+		schedule.ID = existing.ID
+		schedule.CreatedBy = existing.CreatedBy
+		schedule.CreatedAt = existing.CreatedAt
+		schedule.LastRun = existing.LastRun
+		index = i
+	} else if len(schedules.Schedules) >= maxProjectSchedules {
+		return OpSchedule{}, requestError(
+			http.StatusBadRequest, fmt.Sprintf("a project has at most %d schedules", maxProjectSchedules),
+		)
+	}
+	for i, other := range schedules.Schedules {
+		if i != index && strings.EqualFold(other.Name, schedule.Name) {
+			return OpSchedule{}, requestError(
+				http.StatusConflict, fmt.Sprintf("schedule %q already exists (%s)", other.Name, other.ID),
+			)
+		}
+	}
+	if index == len(schedules.Schedules) {
+		schedules.Schedules = append(schedules.Schedules, schedule)
+	} else {
+		schedules.Schedules[index] = schedule
+	}
+	return schedule, nil
+}
+
+func (a *API) handleProjectScheduleDelete(
+	w http.ResponseWriter,
+	r *http.Request,
+	project Project,
+	scheduleID string,
+) {
+	if err := a.authorizeProjectChange(r.Context(), project, projectChangeSchedule); err != nil {
+		if writeProjectAccessDenied(w, err) {
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var removed OpSchedule
+	_, err := a.store.updateProjectSchedules(r.Context(), project.ID, func(schedules *projectSchedules) error {
+		i, found := schedules.find(scheduleID)
+		if !found {
+			return jetstream.ErrKeyNotFound
+		}
+		removed = schedules.Schedules[i]
+		schedules.Schedules = append(schedules.Schedules[:i], schedules.Schedules[i+1:]...)
+		return nil
+	})
+	switch {
+	case errors.Is(err, jetstream.ErrKeyNotFound):
+		writeAPIError(w, scheduleNotFound, http.StatusNotFound)
+	case err != nil:
+		writeAPIError(w, "failed to delete schedule", http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, scheduleDeletedResponse{Deleted: true, Schedule: removed})
+	}
+}
+
+// newOpSchedule validates req against spec and returns the schedule it
+// describes, with its first run after now. ProjectID is left to the caller.
+func newOpSchedule(spec ProjectSpec, req opScheduleRequest, now time.Time) (OpSchedule, error) {
+	schedule := OpSchedule{
+		ID:            newID(),
+		ProjectID:     "",
+		Name:          strings.TrimSpace(req.Name),
+		Cron:          strings.Join(strings.Fields(req.Cron), " "),
+		Timezone:      strings.TrimSpace(req.Timezone),
+		Kind:          OperationKind(strings.TrimSpace(string(req.Kind))),
+		Environment:   "",
+		FromEnv:       "",
+		ToEnv:         "",
+		TargetRuntime: "",
+		Enabled:       req.Enabled == nil || *req.Enabled,
+		CreatedBy:     "",
+		NextRunAt:     time.Time{},
+		LastRun:       nil,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if schedule.Name == "" || len(schedule.Name) > maxScheduleNameLength {
+		return OpSchedule{}, fmt.Errorf("name required, at most %d characters", maxScheduleNameLength)
+	}
+	if _, err := scheduleLocation(schedule.Timezone); err != nil {
+		return OpSchedule{}, err
+	}
+	switch schedule.Kind {
+	case OpCI:
+	case OpDeploy:
+		schedule.Environment = normalizeEnvironmentName(req.Environment)
+		if schedule.Environment == "" {
+			schedule.Environment = defaultDeployEnvironment
+		}
+	case OpPromote:
+		schedule.FromEnv = normalizeEnvironmentName(req.FromEnv)
+		schedule.ToEnv = normalizeEnvironmentName(req.ToEnv)
+	case OpRuntimeUpgrade:
+		schedule.TargetRuntime = strings.TrimSpace(req.TargetRuntime)
+	case OpCreate, OpUpdate, OpDelete, OpRelease, OpRollback, OpCleanup, OpVarRollout, OpPromoteFanout,
+		OpWebhookRefresh:
+		return OpSchedule{}, fmt.Errorf("kind %q cannot be scheduled; use ci, deploy, promote, or runtime-upgrade",
+			schedule.Kind)
+	default:
+		return OpSchedule{}, errors.New("kind must be ci, deploy, promote, or runtime-upgrade")
+	}
+	if _, err := scheduleOpRunOptions(spec, schedule, 0); err != nil {
+		return OpSchedule{}, err
+	}
+	if schedule.Enabled {
+		next, err := nextScheduleRun(schedule, now)
+		if err != nil {
+			return OpSchedule{}, err
+		}
+		schedule.NextRunAt = next
+	}
+	return schedule, nil
+}
+
+// scheduleOpRunOptions checks schedule's op against spec and returns the
+// options it is queued with. projectRevision is passed on to ci ops, which
+// rewrite the project.
+func scheduleOpRunOptions(spec ProjectSpec, schedule OpSchedule, projectRevision uint64) (opRunOptions, error) {
+	var opts opRunOptions
+	switch schedule.Kind {
+	case OpCI:
+		opts = emptyOpRunOptions().withProjectRevision(projectRevision)
+	case OpDeploy:
+		if schedule.Environment != defaultDeployEnvironment {
+			return opRunOptions{}, fmt.Errorf("deploy schedules deploy to %s only", defaultDeployEnvironment)
+		}
+		opts = deployOpRunOptions(schedule.Environment)
+	case OpPromote:
+		fromEnv, toEnv, stage, kind, err := resolveTransitionRequest(spec, schedule.FromEnv, schedule.ToEnv, false)
+		if err != nil {
+			return opRunOptions{}, err
+		}
+		if kind != OpPromote {
+			return opRunOptions{}, fmt.Errorf("to_env %q is production; releases are not scheduled", toEnv)
+		}
+		opts = transitionOpRunOptions(fromEnv, toEnv, stage)
+	case OpRuntimeUpgrade:
+		if err := validateRuntimeUpgradeTarget(spec, schedule.TargetRuntime); err != nil {
+			return opRunOptions{}, err
+		}
+		opts = runtimeUpgradeOpRunOptions(schedule.TargetRuntime)
+	case OpCreate, OpUpdate, OpDelete, OpRelease, OpRollback, OpCleanup, OpVarRollout, OpPromoteFanout,
+		OpWebhookRefresh:
+		return opRunOptions{}, fmt.Errorf("%s ops are not scheduled", schedule.Kind)
+	default:
+		return opRunOptions{}, fmt.Errorf("%s ops are not scheduled", schedule.Kind)
+	}
+	opts.scheduleID = schedule.ID
+	return opts, nil
+}
+
+// nextScheduleRun is the first time after after that schedule's cron
+// fires, in its timezone.
+func nextScheduleRun(schedule OpSchedule, after time.Time) (time.Time, error) {
+	cron, err := parseCronExpression(schedule.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := scheduleLocation(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next, ok := cron.next(after, loc)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cron %q never fires", schedule.Cron)
+	}
+	return next.UTC(), nil
+}
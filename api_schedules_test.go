@@ -0,0 +1,158 @@
+//nolint:testpackage,exhaustruct // Schedule tests reuse the internal promotion preview fixture.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_ProjectSchedulesQueueOpsWhenDue(t *testing.T) {
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	call := func(method, path string, body any) (int, map[string]any) {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	schedulesPath := "/api/projects/" + fixture.projectID + "/schedules"
+
+	for _, refused := range []map[string]any{
+		{"name": "nightly", "cron": "0 2 * *", "kind": "deploy"},
+		{"name": "nightly", "cron": "0 2 * * *", "kind": "rollback"},
+		{"name": "nightly", "cron": "0 2 * * *", "kind": "promote", "from_env": "staging", "to_env": "prod"},
+		{"name": "nightly", "cron": "0 2 * * *", "kind": "deploy", "environment": "staging"},
+		{"name": "nightly", "cron": "0 2 * * *", "kind": "ci", "timezone": "Mars/Olympus"},
+	} {
+		if code, out := call(http.MethodPost, schedulesPath, refused); code != http.StatusBadRequest {
+			t.Fatalf("expected %v refused, got %d %v", refused, code, out)
+		}
+	}
+	code, out := call(http.MethodPost, schedulesPath, map[string]any{
+		"name": "nightly", "cron": "0 2 * * *", "kind": "deploy",
+	})
+	if code != http.StatusCreated || out["environment"] != defaultDeployEnvironment || out["enabled"] != true {
+		t.Fatalf("expected the deploy schedule created, got %d %v", code, out)
+	}
+	scheduleID, _ := out["id"].(string)
+	next, _ := time.Parse(time.RFC3339, out["next_run_at"].(string))
+	if next.Hour() != 2 || next.Minute() != 0 || !next.After(time.Now()) {
+		t.Fatalf("expected the next run at the coming 02:00 UTC, got %v", out["next_run_at"])
+	}
+	if code, _ = call(http.MethodPost, schedulesPath, map[string]any{
+		"name": "Nightly", "cron": "@daily", "kind": "ci",
+	}); code != http.StatusConflict {
+		t.Fatalf("expected a second schedule with the same name refused, got %d", code)
+	}
+
+	schedulerLog := appLoggerForProcess().Source("scheduler")
+	if err := fixture.api.runDueSchedules(ctx, next.Add(-time.Minute), schedulerLog); err != nil {
+		t.Fatalf("run schedules before they are due: %v", err)
+	}
+	if _, out = call(http.MethodGet, schedulesPath+"/"+scheduleID, nil); out["last_run"] != nil {
+		t.Fatalf("expected nothing fired before the schedule is due, got %v", out)
+	}
+	if err := fixture.api.runDueSchedules(ctx, next, schedulerLog); err != nil {
+		t.Fatalf("run due schedules: %v", err)
+	}
+	schedules, err := fixture.api.store.getProjectSchedules(ctx, fixture.projectID)
+	if err != nil || len(schedules.Schedules) != 1 {
+		t.Fatalf("read schedules: %+v (%v)", schedules, err)
+	}
+	fired := schedules.Schedules[0]
+	if fired.LastRun == nil || fired.LastRun.Status != scheduleRunQueued ||
+		!fired.NextRunAt.Equal(next.Add(24*time.Hour)) {
+		t.Fatalf("expected the run queued and the next one a day later, got %+v", fired)
+	}
+	op, err := fixture.api.store.GetOp(ctx, fired.LastRun.OpID)
+	if err != nil || op.Kind != OpDeploy || op.ScheduleID != scheduleID || op.Delivery.Environment != "dev" {
+		t.Fatalf("expected a dev deploy op marked with the schedule, got %+v (%v)", op, err)
+	}
+
+	// The deploy is still queued, so the next firing finds the project busy.
+	if err = fixture.api.runDueSchedules(ctx, fired.NextRunAt, schedulerLog); err != nil {
+		t.Fatalf("run due schedules again: %v", err)
+	}
+	if _, out = call(http.MethodGet, schedulesPath+"/"+scheduleID, nil); out["last_run"].(map[string]any)["status"] !=
+		scheduleRunSkipped {
+		t.Fatalf("expected the run skipped while the project is busy, got %v", out)
+	}
+
+	code, out = call(http.MethodPut, schedulesPath+"/"+scheduleID, map[string]any{
+		"name": "nightly", "cron": "0 2 * * *", "kind": "deploy", "enabled": false,
+	})
+	if code != http.StatusOK || out["next_run_at"] != "0001-01-01T00:00:00Z" || out["last_run"] == nil {
+		t.Fatalf("expected the schedule disabled with its history kept, got %d %v", code, out)
+	}
+	if code, out = call(http.MethodDelete, schedulesPath+"/"+scheduleID, nil); code != http.StatusOK ||
+		out["deleted"] != true {
+		t.Fatalf("expected the schedule deleted, got %d %v", code, out)
+	}
+	if code, out = call(http.MethodGet, schedulesPath+"/"+scheduleID, nil); code != http.StatusNotFound ||
+		!strings.Contains(out["message"].(string), "schedule not found") {
+		t.Fatalf("expected 404 after delete, got %d %v", code, out)
+	}
+	if projects, listErr := fixture.api.store.listScheduledProjects(ctx); listErr != nil || len(projects) != 0 {
+		t.Fatalf("expected no scheduled projects left, got %v (%v)", projects, listErr)
+	}
+}
+
+func TestAPI_ProjectSchedulesSkipRunsWhileReadOnly(t *testing.T) {
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+
+	schedule := OpSchedule{
+		ID: "sched-1", ProjectID: fixture.projectID, Name: "nightly", Cron: "0 2 * * *", Timezone: "UTC",
+		Kind: OpDeploy, Environment: defaultDeployEnvironment, Enabled: true,
+		NextRunAt: time.Date(2026, 1, 2, 2, 0, 0, 0, time.UTC),
+	}
+	if _, err := fixture.api.store.updateProjectSchedules(ctx, fixture.projectID, func(s *projectSchedules) error {
+		s.Schedules = append(s.Schedules, schedule)
+		return nil
+	}); err != nil {
+		t.Fatalf("write schedule: %v", err)
+	}
+	if err := fixture.api.store.putReadOnlyState(ctx, readOnlyState{
+		Enabled: true, Message: "backup until 02:30", Since: time.Now().UTC(), By: "ops",
+	}); err != nil {
+		t.Fatalf("enable read-only mode: %v", err)
+	}
+
+	if err := fixture.api.runDueSchedules(ctx, schedule.NextRunAt, appLoggerForProcess().Source("scheduler")); err != nil {
+		t.Fatalf("run due schedules: %v", err)
+	}
+	schedules, err := fixture.api.store.getProjectSchedules(ctx, fixture.projectID)
+	if err != nil || len(schedules.Schedules) != 1 {
+		t.Fatalf("read schedules: %+v (%v)", schedules, err)
+	}
+	fired := schedules.Schedules[0]
+	if fired.LastRun == nil || fired.LastRun.Status != scheduleRunSkipped || fired.LastRun.OpID != "" ||
+		fired.LastRun.Message != "read-only mode is on: backup until 02:30" {
+		t.Fatalf("expected the run skipped for read-only mode, got %+v", fired.LastRun)
+	}
+	if !fired.NextRunAt.Equal(schedule.NextRunAt.Add(24 * time.Hour)) {
+		t.Fatalf("expected the next run a day later, got %v", fired.NextRunAt)
+	}
+	if ops, listErr := fixture.api.store.listAllOps(ctx); listErr != nil || len(ops) != 0 {
+		t.Fatalf("expected no op queued while read-only, got %d (%v)", len(ops), listErr)
+	}
+}
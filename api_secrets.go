@@ -0,0 +1,179 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	secretsPathParts     = 3
+	storedSecretsMask    = "********"
+	projectChangeSecrets = "change secrets of"
+)
+
+// storedSecretsRequest is the body of a secrets POST: values to set in the
+// environment named by the path. Names not listed are left alone.
+type storedSecretsRequest struct {
+	Secrets map[string]string `json:"secrets"`
+}
+
+// storedSecretView is one stored secret as the API shows it. Value is always
+// the mask, never the stored value or its length.
+type storedSecretView struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type storedSecretsResponse struct {
+	ProjectID   string             `json:"project_id"`
+	Environment string             `json:"environment"`
+	Secrets     []storedSecretView `json:"secrets"`
+}
+
+type storedSecretsDeletedResponse struct {
+	ProjectID   string   `json:"project_id"`
+	Environment string   `json:"environment"`
+	Removed     []string `json:"removed"`
+}
+
+// handleProjectSecrets serves /api/projects/{id}/secrets/{env}. Changes apply
+// on the next deploy, promotion, or rollback render of the environment.
+func (a *API) handleProjectSecrets(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != secretsPathParts || parts[1] != "secrets" {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	if a.store == nil {
+		writeAPIError(w, "secret data unavailable", http.StatusInternalServerError)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	envName := strings.TrimSpace(parts[2])
+	if projectID == "" || envName == "" {
+		writeAPIError(w, "bad project id or environment", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	if _, exists := spec.Environments[envName]; !exists {
+		writeAPIError(w, "environment not found", http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodPost || r.Method == http.MethodDelete {
+		if !a.authorizeProjectChangeOrWriteError(w, r, project, projectChangeSecrets) {
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		secrets, err := a.store.getStoredSecrets(r.Context(), projectID)
+		if err != nil {
+			writeAPIError(w, "failed to read secrets", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newStoredSecretsResponse(projectID, envName, secrets))
+	case http.MethodPost:
+		a.handleProjectSecretsPost(w, r, spec, projectID, envName)
+	case http.MethodDelete:
+		removed, err := a.store.deleteStoredSecrets(r.Context(), projectID, envName, r.URL.Query()["name"])
+		if err != nil {
+			writeAPIError(w, "failed to delete secrets", http.StatusInternalServerError)
+			return
+		}
+		if len(removed) == 0 {
+			writeAPIError(w, "secret not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, storedSecretsDeletedResponse{
+			ProjectID:   projectID,
+			Environment: envName,
+			Removed:     removed,
+		})
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleProjectSecretsPost(
+	w http.ResponseWriter,
+	r *http.Request,
+	spec ProjectSpec,
+	projectID, envName string,
+) {
+	var req storedSecretsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if err := validateStoredSecrets(spec, envName, req.Secrets); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	secrets, err := a.store.putStoredSecrets(r.Context(), projectID, envName, req.Secrets)
+	if err != nil {
+		var keyErr secretsKeyError
+		if errors.As(err, &keyErr) {
+			writeAPIError(w, keyErr.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeAPIError(w, "failed to save secrets", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, newStoredSecretsResponse(projectID, envName, secrets))
+}
+
+// validateStoredSecrets checks a secrets POST. A name the spec already sets
+// in the environment, as a var or an external secret, is refused, since the
+// spec entry would win at render time.
+func validateStoredSecrets(spec ProjectSpec, envName string, values map[string]string) error {
+	if len(values) == 0 {
+		return errors.New("secrets must set at least one value")
+	}
+	envCfg := spec.Environments[envName]
+	for name, value := range values {
+		if len(name) > 128 || !envVarNameRe.MatchString(name) {
+			return fmt.Errorf("invalid secret name %q", name)
+		}
+		if value == "" {
+			return fmt.Errorf("secret %q is empty", name)
+		}
+		if len(value) > storedSecretMaxBytes {
+			return fmt.Errorf("secret %q exceeds %d bytes", name, storedSecretMaxBytes)
+		}
+		if _, isVar := envCfg.Vars[name]; isVar {
+			return fmt.Errorf("%q is already a var of %q", name, envName)
+		}
+		if _, isSecret := envCfg.Secrets[name]; isSecret {
+			return fmt.Errorf("%q is already an external secret of %q", name, envName)
+		}
+	}
+	return nil
+}
+
+func newStoredSecretsResponse(projectID, envName string, secrets projectStoredSecrets) storedSecretsResponse {
+	out := storedSecretsResponse{
+		ProjectID:   projectID,
+		Environment: envName,
+		Secrets:     []storedSecretView{},
+	}
+	stored := secrets.Environments[envName]
+	for _, name := range sortedKeys(stored) {
+		out.Secrets = append(out.Secrets, storedSecretView{
+			Name:      name,
+			Value:     storedSecretsMask,
+			UpdatedAt: stored[name].UpdatedAt,
+		})
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+//nolint:testpackage,exhaustruct // Stored secret tests read the sealed KV record through the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_StoredSecretsAreSealedMaskedAndRendered(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-stored-secrets"
+	spec := workerRuntimeSpec("stored-secrets")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-stored-secrets-create", OpCreate, spec)
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	call := func(method, target, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+target, strings.NewReader(body))
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, target, err)
+		}
+		defer resp.Body.Close()
+		var raw json.RawMessage
+		_ = json.NewDecoder(resp.Body).Decode(&raw)
+		return resp.StatusCode, string(raw)
+	}
+	secretsPath := "/api/projects/" + projectID + "/secrets/dev"
+	body := `{"secrets":{"DB_PASSWORD":"hunter2"}}`
+
+	t.Setenv(secretsKeyEnv, "")
+	if status, _ := call(http.MethodPost, secretsPath, body); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without %s, got %d", secretsKeyEnv, status)
+	}
+	t.Setenv(secretsKeyEnv, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", storedSecretsKeyBytes))))
+	for _, bad := range []string{`{"secrets":{}}`, `{"secrets":{"LOG_LEVEL":"x"}}`, `{"secrets":{"bad name":"x"}}`} {
+		if status, _ := call(http.MethodPost, secretsPath, bad); status != http.StatusBadRequest {
+			t.Fatalf("POST %s: expected 400, got %d", bad, status)
+		}
+	}
+	if status, _ := call(http.MethodPost, "/api/projects/"+projectID+"/secrets/qa", body); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for an undeclared environment, got %d", status)
+	}
+
+	status, out := call(http.MethodPost, secretsPath, body)
+	if status != http.StatusOK || !strings.Contains(out, `"name":"DB_PASSWORD","value":"********"`) ||
+		strings.Contains(out, "hunter2") {
+		t.Fatalf("expected a masked listing, got %d %s", status, out)
+	}
+	if _, out = call(http.MethodGet, secretsPath, ""); strings.Contains(out, "hunter2") {
+		t.Fatalf("GET leaked the value: %s", out)
+	}
+	entry, err := fixture.store.kvSecrets.Get(ctx, projectSecretsKey(projectID))
+	if err != nil || strings.Contains(string(entry.Value()), "hunter2") {
+		t.Fatalf("expected the value sealed at rest, got %s (%v)", entry.Value(), err)
+	}
+
+	// A ciphertext moved under another name does not open.
+	secrets, _ := fixture.store.getStoredSecrets(ctx, projectID)
+	aead, _ := storedSecretsCipher()
+	if _, err = openStoredSecret(aead, projectID, "dev", "OTHER", secrets.Environments["dev"]["DB_PASSWORD"]); err == nil {
+		t.Fatal("expected a moved ciphertext to fail to open")
+	}
+
+	stored, _ := loadEnvStoredSecrets(ctx, fixture.store, projectID)
+	patch := renderDeploymentEnvPatch(spec, "dev", nil, stored["dev"])
+	if !strings.Contains(patch, "name: DB_PASSWORD\n          valueFrom:\n            secretKeyRef:\n"+
+		"              name: stored-secrets-secrets\n              key: \"DB_PASSWORD\"") {
+		t.Fatalf("expected a secretKeyRef for the stored secret, got:\n%s", patch)
+	}
+	msg := ProjectOpMsg{OpID: "op-stored-secrets-deploy", Kind: OpDeploy, ProjectID: projectID, At: time.Now().UTC()}
+	trace, err := newEnvManifestTrace(ctx, fixture.store, api.artifacts, msg, spec, "dev")
+	if err != nil || trace.secretsChecksum != secretsChecksum(map[string]string{"DB_PASSWORD": "hunter2"}) {
+		t.Fatalf("expected the stored value in the rollout checksum, got %q (%v)", trace.secretsChecksum, err)
+	}
+
+	if _, out = call(http.MethodGet, "/api/projects/"+projectID+"/overview", ""); !strings.Contains(out,
+		`"secrets_readiness":"ready"`) {
+		t.Fatalf("expected dev secrets ready, got %s", out)
+	}
+	t.Setenv(secretsKeyEnv, "")
+	if _, out = call(http.MethodGet, "/api/projects/"+projectID+"/overview", ""); !strings.Contains(out,
+		`"secrets_readiness":"locked"`) {
+		t.Fatalf("expected dev secrets locked without a key, got %s", out)
+	}
+
+	status, out = call(http.MethodDelete, secretsPath+"?name=DB_PASSWORD", "")
+	if status != http.StatusOK || !strings.Contains(out, `"removed":["DB_PASSWORD"]`) {
+		t.Fatalf("expected the secret removed, got %d %s", status, out)
+	}
+	if status, _ = call(http.MethodDelete, secretsPath, ""); status != http.StatusNotFound {
+		t.Fatalf("expected 404 once nothing is stored, got %d", status)
+	}
+}
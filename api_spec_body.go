@@ -0,0 +1,118 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec-carrying endpoints (project create/update and registration events)
+// take JSON by default and YAML when the request says so, so a project.yaml
+// can be posted as-is.
+
+const (
+	specBodyJSON = "json"
+	specBodyYAML = "yaml"
+)
+
+// specBodyFormat picks the decoder from Content-Type. Anything that is not
+// a YAML media type, including a missing header, is treated as JSON.
+func specBodyFormat(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return specBodyJSON
+	}
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return specBodyYAML
+	default:
+		return specBodyJSON
+	}
+}
+
+// decodeSpecBody decodes the request body into dst by content type. YAML is
+// turned into JSON first so dst's json tags (apiVersion, networkPolicies)
+// apply unchanged. Errors read "invalid json" or "invalid yaml: <detail>";
+// a value of the wrong type is a specFieldError naming its path.
+func decodeSpecBody(r *http.Request, dst any) error {
+	if specBodyFormat(r) == specBodyJSON {
+		if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Field != "" {
+				return specFieldError{Field: typeErr.Field, Message: "invalid json"}
+			}
+			return errors.New("invalid json")
+		}
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return errors.New("invalid yaml: failed to read body")
+	}
+	return decodeSpecYAML(body, dst)
+}
+
+// ParseProjectSpec decodes a project.yaml, or its JSON form, exactly as the
+// API decodes a YAML spec body, so a CLI reads files the way the server
+// would.
+func ParseProjectSpec(data []byte) (ProjectSpec, error) {
+	var spec ProjectSpec
+	if err := decodeSpecYAML(data, &spec); err != nil {
+		return ProjectSpec{}, err
+	}
+	return spec, nil
+}
+
+func decodeSpecYAML(body []byte, dst any) error {
+	var doc any
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("invalid yaml: %s", strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if doc == nil {
+		return errors.New("invalid yaml: empty document")
+	}
+	asJSON, err := json.Marshal(yamlToJSONValue(doc))
+	if err != nil {
+		return fmt.Errorf("invalid yaml: %w", err)
+	}
+	if err = json.Unmarshal(asJSON, dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return specFieldErrorf(typeErr.Field, "invalid yaml: %s must be a %s, got %s (quote the value)",
+				typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return fmt.Errorf("invalid yaml: %w", err)
+	}
+	return nil
+}
+
+// yamlToJSONValue rewrites maps with non-string keys (e.g. `8080: x`) to
+// string keys, which encoding/json requires.
+func yamlToJSONValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, item := range value {
+			value[k] = yamlToJSONValue(item)
+		}
+		return value
+	case map[any]any:
+		out := make(map[string]any, len(value))
+		for k, item := range value {
+			out[fmt.Sprint(k)] = yamlToJSONValue(item)
+		}
+		return out
+	case []any:
+		for i, item := range value {
+			value[i] = yamlToJSONValue(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
@@ -0,0 +1,84 @@
+//nolint:testpackage // Spec body tests call the unexported decoder and YAML renderer.
+package platform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newSpecBodyRequest(contentType, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/projects", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+func TestDecodeSpecBody_ProjectYAMLRoundTrips(t *testing.T) {
+	spec := normalizeProjectSpec(workerRuntimeSpec("yaml-app"))
+	spec.Vars = map[string]string{"LOG_LEVEL": "info"}
+	spec.Environments["dev"] = EnvConfig{Vars: map[string]string{"PORT": "8080"}}
+	spec = normalizeProjectSpec(spec)
+
+	var got ProjectSpec
+	req := newSpecBodyRequest("application/yaml; charset=utf-8", string(renderProjectConfigYAML(spec)))
+	if err := decodeSpecBody(req, &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got = normalizeProjectSpec(got); !reflect.DeepEqual(got, spec) {
+		t.Fatalf("round trip mismatch:\n got %#v\nwant %#v", got, spec)
+	}
+}
+
+func TestDecodeSpecBody_RegistrationEventYAML(t *testing.T) {
+	body := `action: update
+project_id: p1
+spec:
+  apiVersion: platform.example.com/v2
+  kind: App
+  name: yaml-app
+`
+	var evt RegistrationEvent
+	if err := decodeSpecBody(newSpecBodyRequest("text/yaml", body), &evt); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if evt.Action != "update" || evt.ProjectID != "p1" || evt.Spec.APIVersion != projectAPIVersion {
+		t.Fatalf("unexpected event: %#v", evt)
+	}
+}
+
+func TestDecodeSpecBody_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"", "name: not-json", "invalid json"},
+		{"application/json", "{", "invalid json"},
+		{"application/yaml", "name: [unclosed", "invalid yaml: line 1"},
+		{"application/yaml", "", "invalid yaml: empty document"},
+		{"application/yaml", "environments:\n  dev:\n    vars:\n      PORT: 8080\n", "must be a string, got number (quote the value)"},
+	} {
+		var spec ProjectSpec
+		err := decodeSpecBody(newSpecBodyRequest(tc.contentType, tc.body), &spec)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%q %q: expected error containing %q, got %v", tc.contentType, tc.body, tc.want, err)
+		}
+	}
+}
+
+func TestAPI_CreateProjectYAMLValidationUsesSameErrors(t *testing.T) {
+	api := &API{}
+	rec := httptest.NewRecorder()
+	body := "apiVersion: platform.example.com/v1\nkind: App\nname: yaml-app\nruntime: go_1.26\n"
+	api.handleProjects(rec, newSpecBodyRequest("application/yaml", body))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "apiVersion must be") {
+		t.Fatalf("expected spec validation message, got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,73 @@
+//nolint:testpackage,exhaustruct // Spec hash tests seed projects through the internal store.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSpecHash_IsCanonicalAndSkipsNoOpUpdates(t *testing.T) {
+	spec := workerRuntimeSpec("hashed")
+	reordered := ProjectSpec{
+		APIVersion: projectAPIVersion, Kind: projectKind, Name: "hashed", Runtime: "go_1.26",
+		Environments:    map[string]EnvConfig{"dev": {Vars: map[string]string{"LOG_LEVEL": "info"}}},
+		NetworkPolicies: NetworkPolicies{Ingress: networkPolicyInternal, Egress: networkPolicyInternal},
+	}
+	if projectSpecHash(spec) != projectSpecHash(reordered) {
+		t.Fatal("expected a spec and its unnormalized equivalent to hash the same")
+	}
+	changed := workerRuntimeSpec("hashed")
+	changed.Environments["dev"].Vars["LOG_LEVEL"] = "debug"
+	if projectSpecHash(spec) == projectSpecHash(changed) {
+		t.Fatal("expected a var change to change the hash")
+	}
+
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	projectID := "project-spec-hash"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-spec-hash-create", OpCreate, spec)
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	put := func(query string, body ProjectSpec) (int, opAcceptedResponse) {
+		t.Helper()
+		raw, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPut,
+			srv.URL+"/api/projects/"+projectID+query, bytes.NewReader(raw))
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("put project: %v", err)
+		}
+		defer resp.Body.Close()
+		var out opAcceptedResponse
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	status, out := put("", reordered)
+	if status != http.StatusOK || !out.Unchanged || out.Accepted || out.Op.ID != "" ||
+		out.Project.SpecHash != projectSpecHash(spec) {
+		t.Fatalf("expected an unchanged spec to be skipped, got %d %+v", status, out)
+	}
+	status, out = put("?force=true", spec)
+	if status != http.StatusAccepted || out.Op.SpecHash != projectSpecHash(spec) {
+		t.Fatalf("expected force to enqueue an update, got %d %+v", status, out)
+	}
+
+	// Nothing runs the forced update, so release the project before the next.
+	project, _ := fixture.store.GetProject(context.Background(), projectID)
+	project.Status.LastOpID = ""
+	if err := fixture.store.PutProject(context.Background(), project); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	status, out = put("", changed)
+	if status != http.StatusAccepted || out.Op.SpecHash != projectSpecHash(changed) ||
+		out.Project.SpecHash != projectSpecHash(changed) {
+		t.Fatalf("expected a changed spec to enqueue an update, got %d %+v", status, out)
+	}
+}
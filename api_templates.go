@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Template endpoints:
+//
+//	GET  /api/templates
+//	POST /api/projects/from-template/{name}
+//
+// The create body is a partial spec applied over the template's; it needs
+// at least a name.
+
+const projectFromTemplatePrefix = "/api/projects/from-template/"
+
+func (a *API) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, listProjectTemplates())
+}
+
+func (a *API) handleProjectFromTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, projectFromTemplatePrefix), "/")
+	if name == "" || strings.Contains(name, "/") {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	execution, err := opExecutionFromRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if execution.DryRun {
+		writeAPIError(w, "dry_run is not supported for project creation", http.StatusBadRequest)
+		return
+	}
+	tmpl, ok := lookupProjectTemplate(name)
+	if !ok {
+		writeAPIError(w, "template "+name+" not found", http.StatusNotFound)
+		return
+	}
+	spec, err := projectSpecFromTemplate(tmpl, func(spec *ProjectSpec) error {
+		return decodeSpecBody(r, spec)
+	})
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	a.createProjectAndRespond(w, r, spec, emptyOpRunOptions().withExecution(execution))
+}
@@ -0,0 +1,153 @@
+//nolint:testpackage,exhaustruct // Template tests seed repos through the unexported bootstrap helpers.
+package platform
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListProjectTemplatesMergesTheTemplatesDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile := func(rel, body string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	writeTemplateFile("python-api/template.json", `{"description":"FastAPI service","spec":{"runtime":"python_3.14"}}`)
+	writeTemplateFile("python-api/files/app.py.tmpl", "print('{{.Name}}')\n")
+	writeTemplateFile("static-site/template.json", `{"description":"In-house static site","spec":{"runtime":"static"}}`)
+	writeTemplateFile("broken/template.json", `{"description":"typo","spec":{"runtim":"go"}}`)
+	t.Setenv(templatesDirEnv, dir)
+
+	names := []string{}
+	byName := map[string]ProjectTemplate{}
+	for _, tmpl := range listProjectTemplates() {
+		names = append(names, tmpl.Name)
+		byName[tmpl.Name] = tmpl
+	}
+	if got := strings.Join(names, ","); got != "go-http,node-worker,python-api,static-site" {
+		t.Fatalf("unexpected catalog %s", got)
+	}
+	if tmpl := byName["python-api"]; tmpl.Source != projectTemplateSourceCustom ||
+		strings.Join(tmpl.Files, ",") != "app.py" {
+		t.Fatalf("unexpected custom template: %+v", tmpl)
+	}
+	if tmpl := byName["static-site"]; tmpl.Source != projectTemplateSourceCustom ||
+		tmpl.Description != "In-house static site" {
+		t.Fatalf("expected the templates dir to replace the built-in static-site, got %+v", tmpl)
+	}
+	if tmpl := byName["go-http"]; tmpl.Source != projectTemplateSourceBuiltin ||
+		strings.Join(tmpl.Files, ",") != "README.md,go.mod,main.go" {
+		t.Fatalf("unexpected built-in go-http: %+v", tmpl)
+	}
+}
+
+func TestSeedSourceRepoFromTemplateOnlyOnCreate(t *testing.T) {
+	t.Parallel()
+
+	projectDir := t.TempDir()
+	sourceDir := filepath.Join(projectDir, "repos", "source")
+	spec := normalizeProjectSpec(ProjectSpec{
+		Name:         "orders",
+		Runtime:      "go_1.26",
+		Template:     "go-http",
+		Exposure:     ExposureConfig{ContainerPort: 9090},
+		Environments: map[string]EnvConfig{"dev": {}},
+	})
+	msg := ProjectOpMsg{OpID: "op-template", Kind: OpCreate, ProjectID: "project-template"}
+	touched := []string{}
+	if err := seedSourceRepo(msg, spec, projectDir, sourceDir, &touched); err != nil {
+		t.Fatalf("seed source repo: %v", err)
+	}
+	for rel, want := range map[string]string{
+		"main.go":   `addr = ":9090"`,
+		"go.mod":    "module orders",
+		"README.md": "started from the `go-http` template",
+	} {
+		body, err := os.ReadFile(filepath.Join(sourceDir, rel))
+		if err != nil || !strings.Contains(string(body), want) {
+			t.Fatalf("expected %s to contain %q, got err=%v\n%s", rel, want, err, body)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(sourceDir, "main.go.tmpl")); err == nil {
+		t.Fatal("expected .tmpl files to be written without the suffix")
+	}
+
+	if err := os.Remove(filepath.Join(sourceDir, "main.go")); err != nil {
+		t.Fatalf("remove main.go: %v", err)
+	}
+	msg.Kind = OpUpdate
+	if err := seedSourceRepo(msg, spec, projectDir, sourceDir, &touched); err != nil {
+		t.Fatalf("reseed source repo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sourceDir, "main.go")); err == nil {
+		t.Fatal("expected an update to leave a templated repo's files alone")
+	}
+}
+
+func TestAPI_ProjectFromTemplatePrefillsTheSpec(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	post := func(path, body string) *http.Response {
+		t.Helper()
+		resp, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		return resp
+	}
+	resp := post("/api/projects/from-template/node-worker", `{"name":"jobs","environments":{"prod":{"replicas":2}}}`)
+	defer resp.Body.Close()
+	var created opAcceptedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+	spec := created.Project.Spec
+	if resp.StatusCode != http.StatusAccepted || spec.Template != "node-worker" || spec.Runtime != "node_22" ||
+		spec.Vars["WORK_INTERVAL_MS"] != "5000" || len(spec.Environments) != 2 ||
+		spec.Environments["prod"].Replicas != 2 || spec.NetworkPolicies.Ingress != "none" {
+		t.Fatalf("expected the template's spec under the request's, got %d %+v", resp.StatusCode, spec)
+	}
+
+	missing := post("/api/projects/from-template/cobol-batch", `{"name":"ledger"}`)
+	defer missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown template, got %d", missing.StatusCode)
+	}
+	unknown := post("/api/projects", `{"name":"ledger","runtime":"go_1.26","template":"cobol-batch",`+
+		`"environments":{"dev":{}}}`)
+	defer unknown.Body.Close()
+	var apiErr apiErrorResponse
+	err := json.NewDecoder(unknown.Body).Decode(&apiErr)
+	if err != nil || unknown.StatusCode != http.StatusBadRequest || apiErr.Field != "template" {
+		t.Fatalf("expected a 400 on template, got %d %+v (%v)", unknown.StatusCode, apiErr, err)
+	}
+
+	spec.Template = "go-http"
+	payload, _ := json.Marshal(spec)
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/api/projects/"+created.Project.ID,
+		strings.NewReader(string(payload)))
+	req.Header.Set("Content-Type", "application/json")
+	changed, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	defer changed.Body.Close()
+	if changed.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a template change to be refused, got %d", changed.StatusCode)
+	}
+}
@@ -0,0 +1,142 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// apiTokenRequest is the body of POST /api/tokens.
+type apiTokenRequest struct {
+	Name  string   `json:"name"`
+	Role  string   `json:"role"`
+	Teams []string `json:"teams,omitempty"`
+	Org   string   `json:"org,omitempty"`
+}
+
+// apiTokenView is a stored token as the API shows it, without its hash.
+type apiTokenView struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Teams     []string  `json:"teams,omitempty"`
+	Org       string    `json:"org,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiTokenListResponse struct {
+	Tokens []apiTokenView `json:"tokens"`
+}
+
+// apiTokenCreatedResponse carries the bearer value, which is only ever
+// returned here.
+type apiTokenCreatedResponse struct {
+	Token apiTokenView `json:"token"`
+	Value string       `json:"value"`
+}
+
+type apiTokenRevokedResponse struct {
+	ID      string `json:"id"`
+	Revoked bool   `json:"revoked"`
+}
+
+func newAPITokenView(token apiToken) apiTokenView {
+	return apiTokenView{
+		ID:        token.ID,
+		Name:      token.Name,
+		Role:      string(token.Role),
+		Teams:     token.Teams,
+		Org:       token.Org,
+		CreatedAt: token.CreatedAt,
+	}
+}
+
+// handleTokens serves /api/tokens and /api/tokens/{id}. withAuth only lets
+// admins through.
+func (a *API) handleTokens(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "token data unavailable", http.StatusInternalServerError)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tokens"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		tokens, err := a.store.getAPITokens(r.Context())
+		if err != nil {
+			writeAPIError(w, "failed to read tokens", http.StatusInternalServerError)
+			return
+		}
+		out := apiTokenListResponse{Tokens: []apiTokenView{}}
+		for _, token := range tokens.Tokens {
+			out.Tokens = append(out.Tokens, newAPITokenView(token))
+		}
+		slices.SortFunc(out.Tokens, func(x, y apiTokenView) int {
+			return x.CreatedAt.Compare(y.CreatedAt)
+		})
+		writeJSON(w, http.StatusOK, out)
+	case id == "" && r.Method == http.MethodPost:
+		a.handleTokenCreate(w, r)
+	case id != "" && !strings.Contains(id, "/") && r.Method == http.MethodDelete:
+		revoked, err := a.store.deleteAPIToken(r.Context(), id)
+		if err != nil {
+			writeAPIError(w, "failed to revoke token", http.StatusInternalServerError)
+			return
+		}
+		if !revoked {
+			writeAPIError(w, "token not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, apiTokenRevokedResponse{ID: id, Revoked: true})
+	case id != "" && strings.Contains(id, "/"):
+		writeAPIError(w, "not found", http.StatusNotFound)
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleTokenCreate(w http.ResponseWriter, r *http.Request) {
+	var req apiTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	role := apiRole(strings.TrimSpace(req.Role))
+	if name == "" || len(name) > apiTokenNameMax {
+		writeAPIError(w, fmt.Sprintf("name is required (at most %d characters)", apiTokenNameMax),
+			http.StatusBadRequest)
+		return
+	}
+	if role.rank() == 0 {
+		writeAPIError(w, "role must be admin, developer, or viewer", http.StatusBadRequest)
+		return
+	}
+	teams, err := normalizeTeams(req.Teams)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	org := strings.TrimSpace(req.Org)
+	if org != "" {
+		if _, err = a.store.getOrg(r.Context(), org); err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				writeAPIError(w, fmt.Sprintf("organization %q not found", org), http.StatusBadRequest)
+				return
+			}
+			writeAPIError(w, "failed to read organization", http.StatusInternalServerError)
+			return
+		}
+	}
+	token, value, err := a.store.createAPIToken(r.Context(), name, role, teams, org)
+	if err != nil {
+		writeAPIError(w, "failed to create token", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, apiTokenCreatedResponse{Token: newAPITokenView(token), Value: value})
+}
@@ -0,0 +1,246 @@
+package platform
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// The web client is generated from the OpenAPI document and checked in under
+// web/, so it ships in the embedded UI. TestAPIClient_GeneratedFilesUpToDate
+// fails when a Go type change leaves it stale; regenerate with:
+//
+//go:generate env UPDATE_GENERATED=1 go test -run ^TestAPIClient_GeneratedFilesUpToDate$ .
+
+const (
+	webAPIClientTypesPath = "web/api_client.d.ts"
+	webAPIClientPath      = "web/api_client.js"
+
+	generatedHeader = "// Code generated from /api/openapi.json by api_tsclient.go; DO NOT EDIT.\n"
+)
+
+var tsIdentifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+type tsClientOperation struct {
+	method string
+	path   string
+	op     openAPIOperation
+}
+
+// renderTSDeclarations emits one interface per component schema plus the
+// ApiClient interface implemented by web/api_client.js.
+func renderTSDeclarations(doc openAPIDocument) string {
+	var b strings.Builder
+	b.WriteString(generatedHeader)
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		schema := doc.Components.Schemas[name]
+		fmt.Fprintf(&b, "\ninterface %s %s\n", name, tsObjectType(schema, ""))
+	}
+	b.WriteString("\ninterface ApiClient {\n")
+	for _, entry := range tsClientOperations(doc) {
+		fmt.Fprintf(&b, "  /** %s (%s %s) */\n", entry.op.Summary, strings.ToUpper(entry.method), entry.path)
+		fmt.Fprintf(
+			&b,
+			"  %s(%s): Promise<%s>;\n",
+			entry.op.OperationID,
+			strings.Join(tsClientParams(entry.op, true), ", "),
+			tsType(entry.op.Responses[successResponseCode(entry.op)].Content["application/json"].Schema, "  "),
+		)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// renderJSClient emits the runtime side of ApiClient as a classic script so
+// the UI keeps loading without a bundler; requests go through requestAPI.
+func renderJSClient(doc openAPIDocument) string {
+	var b strings.Builder
+	b.WriteString(generatedHeader)
+	b.WriteString("// @ts-check\n")
+	b.WriteString("/// <reference path=\"./api_client.d.ts\" />\n\n")
+	b.WriteString("/** @type {ApiClient} */\n")
+	b.WriteString("const apiClient = {\n")
+	for _, entry := range tsClientOperations(doc) {
+		params := tsClientParams(entry.op, false)
+		args := []string{fmt.Sprintf("%q", strings.ToUpper(entry.method)), jsPathExpression(entry.path, entry.op)}
+		if entry.op.RequestBody != nil {
+			args = append(args, "body")
+		}
+		fmt.Fprintf(&b, "  %s(%s) {\n", entry.op.OperationID, strings.Join(params, ", "))
+		fmt.Fprintf(&b, "    return requestAPI(%s);\n", strings.Join(args, ", "))
+		b.WriteString("  },\n")
+	}
+	b.WriteString("};\n\n")
+	b.WriteString(`function apiClientQuery(query) {
+  if (!query) {
+    return "";
+  }
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(query)) {
+    if (value !== undefined && value !== null && value !== "") {
+      params.set(key, String(value));
+    }
+  }
+  const encoded = params.toString();
+  return encoded ? ` + "`?${encoded}`" + ` : "";
+}
+`)
+	return b.String()
+}
+
+func tsClientOperations(doc openAPIDocument) []tsClientOperation {
+	var out []tsClientOperation
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			out = append(out, tsClientOperation{method: method, path: path, op: op})
+		}
+	}
+	slices.SortFunc(out, func(a, b tsClientOperation) int {
+		return cmp.Compare(a.op.OperationID, b.op.OperationID)
+	})
+	return out
+}
+
+// tsClientParams lists path params in order, then the body, then an optional
+// query object. typed adds TypeScript annotations for the declaration file.
+func tsClientParams(op openAPIOperation, typed bool) []string {
+	var params, query []string
+	for _, param := range op.Parameters {
+		switch param.In {
+		case "path":
+			name := tsParamName(param.Name)
+			if typed {
+				name += ": string"
+			}
+			params = append(params, name)
+		case "query":
+			query = append(query, fmt.Sprintf("%s?: string | number", tsPropertyName(param.Name)))
+		}
+	}
+	if op.RequestBody != nil {
+		body := "body"
+		if typed {
+			body += ": " + tsType(op.RequestBody.Content["application/json"].Schema, "  ")
+		}
+		params = append(params, body)
+	}
+	if len(query) > 0 {
+		name := "query"
+		if typed {
+			name += "?: { " + strings.Join(query, "; ") + " }"
+		}
+		params = append(params, name)
+	}
+	return params
+}
+
+func jsPathExpression(path string, op openAPIOperation) string {
+	hasQuery := slices.ContainsFunc(op.Parameters, func(p openAPIParameter) bool { return p.In == "query" })
+	if !strings.Contains(path, "{") && !hasQuery {
+		return fmt.Sprintf("%q", path)
+	}
+	var expr strings.Builder
+	expr.WriteString("`")
+	for i, segment := range strings.Split(path, "/") {
+		if i > 0 {
+			expr.WriteString("/")
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			fmt.Fprintf(&expr, "${encodeURIComponent(%s)}", tsParamName(strings.Trim(segment, "{}")))
+			continue
+		}
+		expr.WriteString(segment)
+	}
+	if hasQuery {
+		expr.WriteString("${apiClientQuery(query)}")
+	}
+	expr.WriteString("`")
+	return expr.String()
+}
+
+func successResponseCode(op openAPIOperation) string {
+	codes := sortedKeys(op.Responses)
+	return codes[0]
+}
+
+func tsType(schema *jsonSchema, indent string) string {
+	out := tsBaseType(schema, indent)
+	if schema != nil && schema.Nullable {
+		out += " | null"
+	}
+	return out
+}
+
+func tsBaseType(schema *jsonSchema, indent string) string {
+	if schema == nil {
+		return "unknown"
+	}
+	if schema.Ref != "" {
+		return strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+	}
+	switch schema.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := tsType(schema.Items, indent)
+		if strings.Contains(item, " ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if schema.AdditionalProperties != nil {
+			return "Record<string, " + tsType(schema.AdditionalProperties, indent) + ">"
+		}
+		return tsObjectType(schema, indent)
+	default:
+		return "unknown"
+	}
+}
+
+func tsObjectType(schema *jsonSchema, indent string) string {
+	if len(schema.PropertyOrder) == 0 {
+		return "{}"
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range schema.PropertyOrder {
+		optional := "?"
+		if slices.Contains(schema.Required, name) {
+			optional = ""
+		}
+		fmt.Fprintf(
+			&b,
+			"%s  %s%s: %s;\n",
+			indent,
+			tsPropertyName(name),
+			optional,
+			tsType(schema.Properties[name], indent+"  "),
+		)
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func tsPropertyName(name string) string {
+	if tsIdentifierPattern.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// tsParamName turns a snake_case path param into a camelCase identifier.
+func tsParamName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
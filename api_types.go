@@ -18,6 +18,15 @@ type API struct {
 	opEvents  *opEventHub
 
 	opHeartbeatInterval time.Duration
+	readiness           *workerReadiness
+	workerQueues        *workerQueues
+	specExtensions      *specExtensionRegistry
+	runbook             runbookConfig
+	freezes             freezeConfig
+	quotas              projectQuotas
+	rateLimiter         *apiRateLimiter
+	downloadSlots       chan struct{}
+	config              runtimeConfig
 
 	runtimeVersion              string
 	runtimeHTTPAddr             string
@@ -25,8 +34,10 @@ type API struct {
 	runtimeBuilderMode          imageBuilderModeResolution
 	runtimeCommitWatcherEnabled bool
 	runtimeNATSEmbedded         bool
+	runtimeNATSURL              string
 	runtimeNATSStoreDir         string
 	runtimeNATSStoreEphemeral   bool
+	runtimeNATSAuth             embeddedNATSAuthMode
 
 	sourceTriggerMu     sync.Mutex
 	projectStartLocksMu sync.Mutex
@@ -44,23 +55,48 @@ func (a *API) routes() http.Handler {
 	mux.Handle("/", http.FileServer(http.FS(sub)))
 
 	// CRUD: projects
-	mux.HandleFunc("/api/projects", a.handleProjects)
-	mux.HandleFunc("/api/projects/", a.handleProjectByID)
-	mux.HandleFunc("/api/events/registration", a.handleRegistrationEvents)
-	mux.HandleFunc("/api/events/deployment", a.handleDeploymentEvents)
-	mux.HandleFunc("/api/events/promotion/preview", a.handlePromotionPreviewEvents)
-	mux.HandleFunc("/api/events/promotion", a.handlePromotionEvents)
-	mux.HandleFunc("/api/events/release", a.handleReleaseEvents)
-	mux.HandleFunc("/api/events/rollback/preview", a.handleRollbackPreviewEvents)
-	mux.HandleFunc("/api/events/rollback", a.handleRollbackEvents)
-	mux.HandleFunc("/api/webhooks/source", a.handleSourceRepoWebhook)
+	idempotent := a.withIdempotency
+	mux.HandleFunc("/api/projects", withBodyLimit(specBodyMaxBytes, idempotent(a.handleProjects)))
+	mux.HandleFunc("/api/projects/", withProjectBodyLimit(a.handleProjectByID))
+	mux.HandleFunc("/api/projects/validate", withBodyLimit(specBodyMaxBytes, a.handleProjectValidate))
+	mux.HandleFunc(projectFromTemplatePrefix, withBodyLimit(specBodyMaxBytes, a.handleProjectFromTemplate))
+	mux.HandleFunc("/api/templates", a.handleTemplates)
+	mux.HandleFunc("/api/capabilities", a.handleCapabilities)
+	mux.HandleFunc("/api/events/registration", withBodyLimit(specBodyMaxBytes, idempotent(a.handleRegistrationEvents)))
+	mux.HandleFunc("/api/events/deployment", withBodyLimit(eventBodyMaxBytes, idempotent(a.handleDeploymentEvents)))
+	mux.HandleFunc("/api/events/promotion/preview", withBodyLimit(eventBodyMaxBytes, a.handlePromotionPreviewEvents))
+	mux.HandleFunc("/api/events/promotion", withBodyLimit(eventBodyMaxBytes, idempotent(a.handlePromotionEvents)))
+	mux.HandleFunc("/api/events/release", withBodyLimit(eventBodyMaxBytes, idempotent(a.handleReleaseEvents)))
+	mux.HandleFunc(
+		"/api/events/release/cutover",
+		withBodyLimit(eventBodyMaxBytes, idempotent(a.handleReleaseCutoverEvents)),
+	)
+	mux.HandleFunc("/api/events/rollback/preview", withBodyLimit(eventBodyMaxBytes, a.handleRollbackPreviewEvents))
+	mux.HandleFunc("/api/events/rollback", withBodyLimit(eventBodyMaxBytes, a.handleRollbackEvents))
+	mux.HandleFunc("/api/webhooks/source", withBodyLimit(webhookBodyMaxBytes, a.handleSourceRepoWebhook))
 	mux.HandleFunc("/api/system", a.handleSystem)
+	mux.HandleFunc("/api/config", a.handleConfig)
+	mux.HandleFunc("/api/admin/readonly", withBodyLimit(eventBodyMaxBytes, a.handleAdminReadOnly))
 	mux.HandleFunc("/api/healthz", a.handleHealthz)
+	mux.HandleFunc("/api/readyz", a.handleReadyz)
+	mux.HandleFunc("/api/lookup", a.handleLookup)
+	mux.HandleFunc("/api/graph", a.handleGraph)
+	mux.HandleFunc("/api/metrics", a.handleMetrics)
+	mux.HandleFunc("/api/openapi.json", a.handleOpenAPI)
+	mux.HandleFunc("/api/tokens", withBodyLimit(eventBodyMaxBytes, a.handleTokens))
+	mux.HandleFunc("/api/tokens/", a.handleTokens)
+	mux.HandleFunc("/api/orgs", withBodyLimit(eventBodyMaxBytes, a.handleOrgs))
+	mux.HandleFunc("/api/orgs/", withBodyLimit(eventBodyMaxBytes, a.handleOrgs))
+	mux.HandleFunc("/api/views", withBodyLimit(eventBodyMaxBytes, a.handleViews))
+	mux.HandleFunc("/api/views/", withBodyLimit(eventBodyMaxBytes, a.handleViews))
 
-	// Ops: read
-	mux.HandleFunc("/api/ops/", a.handleOpByID)
+	// Ops: read and cancel
+	mux.HandleFunc("/api/ops", a.handleOps)
+	mux.HandleFunc("/api/ops/", withBodyLimit(eventBodyMaxBytes, a.handleOpByID))
+	mux.HandleFunc("/api/approvals", a.handleApprovals)
+	mux.HandleFunc("/api/approvals/", withBodyLimit(eventBodyMaxBytes, a.handleApprovals))
 
-	return a.withRequestLogging(mux)
+	return a.withRequestLogging(a.withAuth(a.withRateLimit(a.withReadOnly(withStoreCacheBypassHeader(mux)))))
 }
 
 type statusRecorder struct {
@@ -81,6 +117,12 @@ func (s *statusRecorder) Write(p []byte) (int, error) {
 	return s.ResponseWriter.Write(p)
 }
 
+// Unwrap lets http.ResponseController reach the connection to set
+// per-request deadlines.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
 func (s *statusRecorder) Flush() {
 	flusher, ok := s.ResponseWriter.(http.Flusher)
 	if !ok {
@@ -115,9 +157,11 @@ func (a *API) withRequestLogging(next http.Handler) http.Handler {
 }
 
 type RegistrationEvent struct {
-	Action    string      `json:"action"` // create|update|delete
-	ProjectID string      `json:"project_id,omitempty"`
-	Spec      ProjectSpec `json:"spec"`
+	Action            string      `json:"action"` // create|update|delete
+	ProjectID         string      `json:"project_id,omitempty"`
+	PlanID            string      `json:"plan_id,omitempty"`            // delete only; see /api/projects/{id}/delete-plan
+	AcknowledgeImpact bool        `json:"acknowledge_impact,omitempty"` // delete only; needed when the plan lists impact
+	Spec              ProjectSpec `json:"spec"`
 }
 
 type SourceRepoWebhookEvent struct {
@@ -126,23 +170,54 @@ type SourceRepoWebhookEvent struct {
 	Branch    string `json:"branch,omitempty"`
 	Ref       string `json:"ref,omitempty"` // e.g. refs/heads/main
 	Commit    string `json:"commit,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"` // the branch was deleted; tears down its preview
 }
 
 type DeploymentEvent struct {
-	ProjectID   string `json:"project_id"`
-	Environment string `json:"environment,omitempty"`
+	ProjectID      string                 `json:"project_id"`
+	Environment    string                 `json:"environment,omitempty"`
+	FreezeOverride *FreezeOverrideRequest `json:"freeze_override,omitempty"`
 }
 
 type PromotionEvent struct {
-	ProjectID string `json:"project_id"`
-	FromEnv   string `json:"from_env"`
-	ToEnv     string `json:"to_env"`
+	ProjectID             string                        `json:"project_id"`
+	FromEnv               string                        `json:"from_env"`
+	ToEnv                 string                        `json:"to_env"`
+	ToEnvs                []string                      `json:"to_envs,omitempty"`
+	VulnerabilityOverride *VulnerabilityOverrideRequest `json:"vulnerability_override,omitempty"`
+	FreezeOverride        *FreezeOverrideRequest        `json:"freeze_override,omitempty"`
+	// Strategy canary starts the image on Weight percent of to_env's
+	// replicas; see POST .../environments/{env}/canary/{promote|abort}.
+	// Strategy blue-green stages it on to_env's idle color.
+	Strategy DeliveryStrategy `json:"strategy,omitempty"`
+	Weight   int              `json:"weight,omitempty"`
 }
 
 type ReleaseEvent struct {
-	ProjectID string `json:"project_id"`
-	FromEnv   string `json:"from_env"`
-	ToEnv     string `json:"to_env,omitempty"`
+	ProjectID             string                        `json:"project_id"`
+	FromEnv               string                        `json:"from_env"`
+	ToEnv                 string                        `json:"to_env,omitempty"`
+	VulnerabilityOverride *VulnerabilityOverrideRequest `json:"vulnerability_override,omitempty"`
+	FreezeOverride        *FreezeOverrideRequest        `json:"freeze_override,omitempty"`
+	// Strategy blue-green stages the image on to_env's idle color; see
+	// POST /api/events/release/cutover. Strategy and Weight otherwise work
+	// as they do on a promotion.
+	Strategy DeliveryStrategy `json:"strategy,omitempty"`
+	Weight   int              `json:"weight,omitempty"`
+}
+
+// VulnerabilityOverrideRequest asks to promote an image over the
+// vulnerability budget. It is honored only with the operator token.
+type VulnerabilityOverrideRequest struct {
+	Justification string `json:"justification"`
+	By            string `json:"by,omitempty"`
+}
+
+// FreezeOverrideRequest asks to deliver into a frozen environment. Like a
+// vulnerability override it needs the operator token.
+type FreezeOverrideRequest struct {
+	Justification string `json:"justification"`
+	By            string `json:"by,omitempty"`
 }
 
 type RollbackEvent struct {
@@ -184,6 +259,8 @@ type PromotionPreviewResponse struct {
 	Gates         []TransitionPreviewGate    `json:"gates"`
 	Blockers      []TransitionPreviewBlocker `json:"blockers"`
 	RolloutPlan   []string                   `json:"rollout_plan"`
+	// TrafficSplit is a canary promotion's planned split of the target.
+	TrafficSplit *CanaryRollout `json:"traffic_split,omitempty"`
 }
 
 type ReleaseCompareResponse struct {
@@ -195,6 +272,12 @@ type ReleaseCompareResponse struct {
 	ImageDelta    ReleaseCompareDelta `json:"image_delta"`
 	ConfigDelta   ReleaseCompareDelta `json:"config_delta"`
 	RenderedDelta ReleaseCompareDelta `json:"rendered_delta"`
+	// SpecDelta compares the releases' spec_hash values. Releases recorded
+	// before spec hashing have none, and are reported as unchanged.
+	SpecDelta ReleaseCompareDelta `json:"spec_delta"`
+	// Notes are release notes drafted from the source commits between the
+	// two releases, with the image and config deltas above.
+	Notes *ReleaseNotes `json:"notes,omitempty"`
 }
 
 type ReleaseCompareDelta struct {
@@ -206,6 +289,29 @@ type ReleaseCompareDelta struct {
 	Updated []string `json:"updated,omitempty"`
 }
 
+// SpecValidationResponse is the body of POST /api/projects/validate. Spec
+// is the normalized spec; Artifacts are rendered only for a valid spec.
+type SpecValidationResponse struct {
+	Valid     bool                     `json:"valid"`
+	Spec      ProjectSpec              `json:"spec"`
+	SpecHash  string                   `json:"spec_hash,omitempty"`
+	Image     string                   `json:"image,omitempty"`
+	Errors    []SpecValidationIssue    `json:"errors"`
+	Warnings  []SpecValidationIssue    `json:"warnings"`
+	Lint      []SpecLintWarning        `json:"lint"`
+	Artifacts []SpecValidationArtifact `json:"artifacts"`
+}
+
+type SpecValidationIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type SpecValidationArtifact struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
 type RollbackPreviewResponse struct {
 	ProjectID      string                     `json:"project_id"`
 	Environment    string                     `json:"environment"`
@@ -0,0 +1,586 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Var rollout: one var change applied to a project's environments in order
+// (dev → staging → prod). The API runs the parent op itself; every stage
+// writes the change into that environment's vars and delivers it with a
+// child deploy/promote/release op, checks the rendered manifest picked it up,
+// and pauses before the next stage.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	varRolloutMaxPauseSeconds = 3600
+	varRolloutMaxKeys         = 100
+	varRolloutPollInterval    = 500 * time.Millisecond
+	varRolloutStageTimeout    = 30 * time.Minute
+	varRolloutStepPrefix      = "rollout."
+	varRolloutRenderedFile    = "rendered.yaml"
+	varRolloutHoldMessage     = "var rollout in progress"
+
+	varRolloutStagePending = "pending"
+	varRolloutStageSkipped = "skipped"
+	varRolloutHealthPassed = "passed"
+	varRolloutHealthFailed = "failed"
+)
+
+type varRolloutRequest struct {
+	Set           map[string]string `json:"set,omitempty"`
+	Unset         []string          `json:"unset,omitempty"`
+	Environments  []string          `json:"environments,omitempty"`
+	PauseSeconds  int               `json:"pause_seconds,omitempty"`
+	StopOnFailure *bool             `json:"stop_on_failure,omitempty"`
+}
+
+// renderedContainerEnv is the slice of a rendered Deployment the health
+// check reads.
+type renderedContainerEnv struct {
+	Kind string `yaml:"kind"`
+	Spec struct {
+		Template struct {
+			Spec struct {
+				Containers []struct {
+					Env []struct {
+						Name  string `yaml:"name"`
+						Value string `yaml:"value"`
+					} `yaml:"env"`
+				} `yaml:"containers"`
+			} `yaml:"spec"`
+		} `yaml:"template"`
+	} `yaml:"spec"`
+}
+
+func (a *API) handleProjectVarRollout(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != projectRelPathPartsMin || parts[1] != "var-rollout" {
+		writeAPIError(w, "not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projectID := strings.TrimSpace(parts[0])
+	if projectID == "" {
+		writeAPIError(w, "bad project id", http.StatusBadRequest)
+		return
+	}
+	var req varRolloutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	project, ok := a.getProjectOrWriteError(w, r, projectID)
+	if !ok {
+		return
+	}
+	rollout, err := a.newVarRollout(project.Spec, req)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	op, err := a.startVarRollout(r.Context(), project, rollout)
+	if err != nil {
+		if writeAsyncOpError(w, err) {
+			return
+		}
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	go a.runVarRollout(context.WithoutCancel(r.Context()), op)
+
+	project, _ = a.store.GetProject(r.Context(), project.ID)
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"accepted": true,
+		"project":  project,
+		"op":       op,
+	})
+}
+
+// newVarRollout validates req against spec and plans one stage per
+// environment. Without an explicit list every environment is rolled, in
+// delivery order.
+func (a *API) newVarRollout(spec ProjectSpec, req varRolloutRequest) (VarRollout, error) {
+	spec = normalizeProjectSpec(spec)
+	rollout := VarRollout{
+		Set:           maps.Clone(req.Set),
+		Unset:         make([]string, 0, len(req.Unset)),
+		PauseSeconds:  req.PauseSeconds,
+		StopOnFailure: req.StopOnFailure == nil || *req.StopOnFailure,
+		Stages:        []VarRolloutStage{},
+	}
+	if len(rollout.Set)+len(req.Unset) == 0 {
+		return rollout, errors.New("set or unset must name at least one var")
+	}
+	if len(rollout.Set)+len(req.Unset) > varRolloutMaxKeys {
+		return rollout, fmt.Errorf("at most %d vars per rollout", varRolloutMaxKeys)
+	}
+	if err := validateEnvironmentVars("set", "set", rollout.Set); err != nil {
+		return rollout, err
+	}
+	for _, key := range req.Unset {
+		key = strings.TrimSpace(key)
+		if !envVarNameRe.MatchString(key) {
+			return rollout, fmt.Errorf("invalid environment variable name %q in %q", key, "unset")
+		}
+		if _, ok := rollout.Set[key]; ok || slices.Contains(rollout.Unset, key) {
+			return rollout, fmt.Errorf("var %q is listed more than once", key)
+		}
+		rollout.Unset = append(rollout.Unset, key)
+	}
+	if rollout.PauseSeconds < 0 || rollout.PauseSeconds > varRolloutMaxPauseSeconds {
+		return rollout, fmt.Errorf("pause_seconds must be between 0 and %d", varRolloutMaxPauseSeconds)
+	}
+
+	envs, err := varRolloutEnvironments(spec, req.Environments)
+	if err != nil {
+		return rollout, err
+	}
+	order := journeyEnvironmentOrder(spec)
+	for i, env := range envs {
+		stage := VarRolloutStage{
+			Environment: env,
+			FromEnv:     "",
+			OpKind:      OpDeploy,
+			OpID:        "",
+			Status:      varRolloutStagePending,
+			Health:      "",
+			Error:       "",
+		}
+		if env != defaultDeployEnvironment {
+			stage.FromEnv = order[slices.Index(order, env)-1]
+			if i > 0 {
+				stage.FromEnv = envs[i-1]
+			}
+			stage.OpKind = transitionOperationKind(transitionDeliveryStage(env))
+		}
+		rollout.Stages = append(rollout.Stages, stage)
+		spec = withVarRolloutChange(spec, env, rollout)
+	}
+	if err = a.validateSpec(spec); err != nil {
+		return rollout, err
+	}
+	return rollout, nil
+}
+
+// varRolloutEnvironments resolves the requested environments, which must
+// be defined for the project, listed once each, and in delivery order.
+func varRolloutEnvironments(spec ProjectSpec, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return journeyEnvironmentOrder(spec), nil
+	}
+	envs := make([]string, 0, len(requested))
+	for _, raw := range requested {
+		env, ok := resolveProjectEnvironmentName(spec, raw)
+		if !ok {
+			return nil, fmt.Errorf("environment %q is not defined for project", raw)
+		}
+		if slices.Contains(envs, env) {
+			return nil, fmt.Errorf("environment %q is listed more than once", env)
+		}
+		if len(envs) > 0 && !compareJourneyEnvironment(envs[len(envs)-1], env) {
+			return nil, fmt.Errorf("environments must be in delivery order: %q cannot follow %q", env, envs[len(envs)-1])
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// withVarRolloutChange returns spec with the rollout's change written into
+// env's own vars. Unsetting removes only the environment's value, so a var
+// also in the shared block falls back to it.
+func withVarRolloutChange(spec ProjectSpec, env string, rollout VarRollout) ProjectSpec {
+	envs := maps.Clone(spec.Environments)
+	if envs == nil {
+		envs = map[string]EnvConfig{}
+	}
+	cfg := envs[env]
+	vars := maps.Clone(cfg.Vars)
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	maps.Copy(vars, rollout.Set)
+	for _, key := range rollout.Unset {
+		delete(vars, key)
+	}
+	cfg.Vars = vars
+	envs[env] = cfg
+	spec.Environments = envs
+	return normalizeProjectSpec(spec)
+}
+
+// startVarRollout records the parent op and points the project at it, which
+// keeps other ops out until the rollout ends. Nothing is published: the
+// stages' child ops are what reach the workers.
+func (a *API) startVarRollout(ctx context.Context, project Project, rollout VarRollout) (Operation, error) {
+	if err := a.readiness.admit(); err != nil {
+		return Operation{}, err
+	}
+	projectMu := a.projectStartLock(project.ID)
+	projectMu.Lock()
+	defer projectMu.Unlock()
+	if err := a.projectOperationConflict(ctx, project.ID, OpVarRollout); err != nil {
+		return Operation{}, err
+	}
+	if err := a.authorizeProjectChange(ctx, project, string(OpVarRollout)); err != nil {
+		return Operation{}, err
+	}
+
+	now := time.Now().UTC()
+	op := Operation{
+		ID:        newID(),
+		Kind:      OpVarRollout,
+		ProjectID: project.ID,
+		Delivery: DeliveryLifecycle{
+			Stage:           "",
+			Environment:     "",
+			FromEnv:         "",
+			ToEnv:           "",
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
+		},
+		Execution:             OpExecution{DryRun: false, Trace: false},
+		Requested:             now,
+		Finished:              time.Time{},
+		Status:                statusMessageQueued,
+		Error:                 "",
+		Steps:                 []OpStep{},
+		VulnerabilityOverride: nil,
+		FreezeOverride:        nil,
+		ApprovalID:            "",
+		Notes:                 nil,
+		SpecHash:              "",
+		ParentOpID:            "",
+		Rollout:               &rollout,
+		Fanout:                nil,
+		SpecChange:            nil,
+		Remediation:           nil,
+		RemediationOf:         "",
+		ScheduleID:            "",
+		CloneOf:               "",
+		CI:                    nil,
+		Upgrade:               nil,
+		Webhook:               nil,
+		SLABreached:           false,
+		SLABreaches:           nil,
+	}
+	if err := a.store.PutOp(ctx, op); err != nil {
+		return Operation{}, fmt.Errorf("persist op: %w", err)
+	}
+	a.setQueuedProjectStatus(ctx, op.ID, OpVarRollout, project.ID, project.Spec, now)
+	appLoggerForProcess().Source("api").Infof(
+		"queued op=%s kind=%s project=%s stages=%d", op.ID, op.Kind, project.ID, len(rollout.Stages),
+	)
+	emitOpBootstrap(a.opEvents, op, "var rollout accepted")
+	emitOpStatus(a.opEvents, op, "queued")
+	return op, nil
+}
+
+// runVarRollout drives the stages of parent until they are all done, one
+// fails under the stop-on-failure policy, or the rollout is cancelled.
+func (a *API) runVarRollout(ctx context.Context, parent Operation) {
+	apiLog := appLoggerForProcess().Source("api")
+	failures := []string{}
+	for i, stage := range parent.Rollout.Stages {
+		if i > 0 && !a.pauseVarRollout(ctx, parent.ID, time.Duration(parent.Rollout.PauseSeconds)*time.Second) {
+			return
+		}
+		err := a.runVarRolloutStage(ctx, parent, i)
+		if _, active := a.activeVarRollout(ctx, parent.ID); !active {
+			return
+		}
+		if err == nil {
+			continue
+		}
+		apiLog.Warnf("var rollout op=%s stage=%s failed: %v", parent.ID, stage.Environment, err)
+		failures = append(failures, stage.Environment+": "+err.Error())
+		if parent.Rollout.StopOnFailure {
+			a.skipVarRolloutStages(ctx, parent.ID, i+1)
+			break
+		}
+	}
+
+	status, errMsg := opStatusDone, ""
+	if len(failures) > 0 {
+		status, errMsg = opStatusError, "var rollout failed: "+strings.Join(failures, "; ")
+	}
+	if err := finalizeOp(ctx, a.store, parent.ID, parent.ProjectID, OpVarRollout, status, errMsg); err != nil {
+		apiLog.Errorf("finalize var rollout op=%s: %v", parent.ID, err)
+	}
+}
+
+// runVarRolloutStage delivers the change to one environment and checks it.
+// The stage's step on the parent op records the outcome.
+func (a *API) runVarRolloutStage(ctx context.Context, parent Operation, index int) error {
+	stage := parent.Rollout.Stages[index]
+	worker := varRolloutStepPrefix + stage.Environment
+	if err := markOpStepStart(
+		ctx, a.store, parent.ID, worker, time.Now().UTC(), "roll out var change to "+stage.Environment,
+	); err != nil {
+		return err
+	}
+
+	child, err := a.enqueueVarRolloutChild(ctx, parent, stage)
+	if err == nil {
+		a.editVarRollout(ctx, parent.ID, func(op *Operation) {
+			op.Rollout.Stages[index].OpID = child.ID
+			op.Rollout.Stages[index].Status = opStatusRunning
+		})
+		err = a.waitVarRolloutChild(ctx, parent.ID, child.ID)
+	}
+	health := ""
+	if err == nil {
+		health = varRolloutHealthPassed
+		if err = a.checkVarRolloutHealth(ctx, parent.ProjectID, stage.Environment, *parent.Rollout); err != nil {
+			health = varRolloutHealthFailed
+		}
+	}
+	a.endVarRolloutStage(ctx, parent.ID, index, health, err)
+	return err
+}
+
+func (a *API) enqueueVarRolloutChild(ctx context.Context, parent Operation, stage VarRolloutStage) (Operation, error) {
+	project, err := a.store.GetProject(ctx, parent.ProjectID)
+	if err != nil {
+		return Operation{}, fmt.Errorf("read project: %w", err)
+	}
+	spec := withVarRolloutChange(normalizeProjectSpec(project.Spec), stage.Environment, *parent.Rollout)
+	opts := deployOpRunOptions(stage.Environment)
+	if stage.OpKind != OpDeploy {
+		check, checkErr := a.checkTransitionSourceBudget(project.ID, spec, stage.FromEnv)
+		if checkErr != nil {
+			return Operation{}, checkErr
+		}
+		if len(check.exceeded) > 0 {
+			return Operation{}, vulnerabilityBudgetError{ProjectID: project.ID, Check: check}
+		}
+		opts = transitionOpRunOptions(stage.FromEnv, stage.Environment, transitionDeliveryStage(stage.Environment))
+	}
+	opts.parentOpID = parent.ID
+	return a.enqueueOp(ctx, stage.OpKind, project.ID, spec, opts)
+}
+
+// waitVarRolloutChild polls childID until it ends. Cancelling the rollout
+// cancels the child it is waiting on.
+func (a *API) waitVarRolloutChild(ctx context.Context, parentID, childID string) error {
+	ticker := time.NewTicker(varRolloutPollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(varRolloutStageTimeout)
+	for {
+		child, err := a.store.GetOp(ctx, childID)
+		if err != nil {
+			return fmt.Errorf("read child op %s: %w", childID, err)
+		}
+		switch {
+		case child.Status == opStatusDone:
+			return nil
+		case isOperationStatusTerminal(child.Status):
+			return fmt.Errorf("%s op %s ended %s: %s", child.Kind, child.ID, child.Status, child.Error)
+		}
+		if _, active := a.activeVarRollout(ctx, parentID); !active {
+			a.cancelVarRolloutChild(ctx, child)
+			return fmt.Errorf("var rollout %s stopped", parentID)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s op %s did not finish within %s", child.Kind, child.ID, varRolloutStageTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *API) cancelVarRolloutChild(ctx context.Context, child Operation) {
+	const reason = "parent var rollout stopped"
+	cancelled, err := cancelOp(ctx, a.store, child.ID, reason)
+	if err != nil || a.nc == nil {
+		return
+	}
+	_ = publishOpCancel(a.nc, cancelled, reason)
+}
+
+// checkVarRolloutHealth confirms a delivered stage is healthy: the project
+// is not in error and the environment's rendered Deployment runs every
+// changed var with the value its spec now resolves to.
+func (a *API) checkVarRolloutHealth(ctx context.Context, projectID, env string, rollout VarRollout) error {
+	project, err := a.store.GetProject(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("read project: %w", err)
+	}
+	if project.Status.Phase == projectPhaseError {
+		return fmt.Errorf("project is in error: %s", project.Status.Message)
+	}
+	raw, err := a.artifacts.ReadFile(projectID, path.Join("deploy", env, varRolloutRenderedFile))
+	if err != nil {
+		return fmt.Errorf("read rendered manifest for %s: %w", env, err)
+	}
+	running, err := renderedDeploymentEnv(raw)
+	if err != nil {
+		return fmt.Errorf("parse rendered manifest for %s: %w", env, err)
+	}
+	want := effectiveEnvironmentVars(normalizeProjectSpec(project.Spec), env)
+	for _, key := range slices.Concat(sortedKeys(rollout.Set), rollout.Unset) {
+		wantValue, wantSet := want[key]
+		gotValue, gotSet := running[key]
+		if wantSet != gotSet || wantValue != gotValue {
+			return fmt.Errorf("rendered manifest for %s does not carry the change to %s", env, key)
+		}
+	}
+	return nil
+}
+
+// renderedDeploymentEnv reads the env entries of the Deployment in a
+// rendered kustomize build.
+func renderedDeploymentEnv(raw []byte) (map[string]string, error) {
+	out := map[string]string{}
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	for {
+		var doc renderedContainerEnv
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if doc.Kind != "Deployment" {
+			continue
+		}
+		for _, container := range doc.Spec.Template.Spec.Containers {
+			for _, entry := range container.Env {
+				out[entry.Name] = entry.Value
+			}
+		}
+	}
+}
+
+// pauseVarRollout waits between stages and reports whether the rollout is
+// still active afterwards.
+func (a *API) pauseVarRollout(ctx context.Context, parentID string, pause time.Duration) bool {
+	deadline := time.Now().Add(pause)
+	for time.Now().Before(deadline) {
+		if _, active := a.activeVarRollout(ctx, parentID); !active {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(min(varRolloutPollInterval, time.Until(deadline))):
+		}
+	}
+	_, active := a.activeVarRollout(ctx, parentID)
+	return active
+}
+
+// activeVarRollout reads the parent op and reports whether it can still
+// move; a cancel or an op resume after a restart ends it from outside.
+func (a *API) activeVarRollout(ctx context.Context, parentID string) (Operation, bool) {
+	op, err := a.store.GetOp(ctx, parentID)
+	if err != nil || op.Rollout == nil {
+		return op, false
+	}
+	return op, !isOperationStatusTerminal(op.Status)
+}
+
+// editVarRollout rewrites the parent op while it is active. The write is
+// revision-checked, so a cancel that lands first is reread and stops it.
+func (a *API) editVarRollout(ctx context.Context, parentID string, edit func(op *Operation)) (Operation, bool) {
+	return editActiveParentOp(ctx, a.store, parentID, func(op Operation) bool { return op.Rollout != nil }, edit)
+}
+
+// editActiveParentOp applies edit to a parent op that is still active and
+// of its kind (is), and reports whether it was written.
+func editActiveParentOp(
+	ctx context.Context,
+	store *Store,
+	parentID string,
+	is func(op Operation) bool,
+	edit func(op *Operation),
+) (Operation, bool) {
+	active := false
+	op, err := store.updateOp(ctx, parentID, func(op *Operation) bool {
+		active = is(*op) && !isOperationStatusTerminal(op.Status)
+		if active {
+			edit(op)
+		}
+		return active
+	})
+	return op, err == nil && active
+}
+
+func (a *API) endVarRolloutStage(ctx context.Context, parentID string, index int, health string, stageErr error) {
+	now := time.Now().UTC()
+	errText, message := "", "var change healthy in "
+	var step OpStep
+	stepIndex := 0
+	op, ok := a.editVarRollout(ctx, parentID, func(op *Operation) {
+		stage := &op.Rollout.Stages[index]
+		stage.Status = opStatusDone
+		stage.Health = health
+		if stageErr != nil {
+			stage.Status = opStatusError
+			stage.Error = stageErr.Error()
+			errText, message = stage.Error, "var change failed in "
+		}
+		message += stage.Environment
+		// The step error is recorded without failing the op so a rollout
+		// that continues past failures keeps running.
+		for i := len(op.Steps) - 1; i >= 0; i-- {
+			if op.Steps[i].Worker == varRolloutStepPrefix+stage.Environment && op.Steps[i].EndedAt.IsZero() {
+				op.Steps[i].EndedAt = now
+				op.Steps[i].Message = message
+				op.Steps[i].Error = errText
+				step, stepIndex = op.Steps[i], i+1
+				break
+			}
+		}
+	})
+	if !ok {
+		return
+	}
+	if stepIndex > 0 {
+		emitOpStepEnded(a.opEvents, op, step.Worker, stepIndex, message, errText, nil, step.StartedAt, now)
+	}
+	if index+1 < len(op.Rollout.Stages) {
+		a.holdProjectForVarRollout(ctx, op)
+	}
+}
+
+func (a *API) skipVarRolloutStages(ctx context.Context, parentID string, from int) {
+	op, ok := a.editVarRollout(ctx, parentID, func(op *Operation) {
+		for i := from; i < len(op.Rollout.Stages); i++ {
+			op.Rollout.Stages[i].Status = varRolloutStageSkipped
+		}
+	})
+	if ok {
+		emitOpStatus(a.opEvents, op, "remaining stages skipped after a failure")
+	}
+}
+
+// holdProjectForVarRollout points the project back at the rollout once a
+// child op finishes, so nothing else starts during the pause.
+func (a *API) holdProjectForVarRollout(ctx context.Context, parent Operation) {
+	holdProjectForParentOp(ctx, a.store, parent, varRolloutHoldMessage)
+}
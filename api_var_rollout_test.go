@@ -0,0 +1,141 @@
+//nolint:testpackage,exhaustruct // Var rollout tests stand in for the workers behind the internal store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestAPI_VarRolloutStagesEnvironmentsAndStopsOnFailure(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-var-rollout"
+	spec := workerRuntimeSpec("var-rollout")
+	spec.Environments["staging"] = EnvConfig{Vars: map[string]string{"LOG_LEVEL": "warn"}}
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{}}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-var-rollout-create", OpCreate, spec)
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	// The fake worker renders what the op carries, except that staging
+	// comes out stale so its health check fails.
+	handle := func(msg *nats.Msg) {
+		var opMsg ProjectOpMsg
+		if err := json.Unmarshal(msg.Data, &opMsg); err != nil {
+			return
+		}
+		env := opMsg.DeployEnv + opMsg.ToEnv
+		vars := effectiveEnvironmentVars(opMsg.Spec, env)
+		if env == "staging" {
+			vars = effectiveEnvironmentVars(spec, env)
+		}
+		var rendered strings.Builder
+		rendered.WriteString("kind: Deployment\nspec:\n  template:\n    spec:\n      containers:\n")
+		rendered.WriteString("      - name: app\n        env:\n")
+		for _, key := range sortedKeys(vars) {
+			fmt.Fprintf(&rendered, "        - name: %s\n          value: %s\n", key, yamlQuoted(vars[key]))
+		}
+		_, _ = artifacts.WriteFile(opMsg.ProjectID, path.Join("deploy", env, "rendered.yaml"), []byte(rendered.String()))
+		_ = finalizeOp(ctx, fixture.store, opMsg.OpID, opMsg.ProjectID, opMsg.Kind, opStatusDone, "")
+	}
+	for _, subject := range []string{natsSubject(subjectDeploymentStart), natsSubject(subjectPromotionStart)} {
+		sub, err := fixture.nc.Subscribe(subject, handle)
+		if err != nil {
+			t.Fatalf("subscribe %s: %v", subject, err)
+		}
+		defer func() { _ = sub.Unsubscribe() }()
+	}
+
+	post := func(target, body string) (int, map[string]any) {
+		t.Helper()
+		resp, err := srv.Client().Post(srv.URL+target, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("post %s: %v", target, err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	rolloutPath := "/api/projects/" + projectID + "/var-rollout"
+	for _, body := range []string{
+		`{}`,
+		`{"set":{"LOG_LEVEL":"debug"},"unset":["LOG_LEVEL"]}`,
+		`{"set":{"LOG_LEVEL":"debug"},"environments":["prod","staging"]}`,
+		`{"set":{"LOG_LEVEL":"debug"},"environments":["qa"]}`,
+		`{"set":{"LOG_LEVEL":"debug"},"pause_seconds":7200}`,
+	} {
+		if status, _ := post(rolloutPath, body); status != http.StatusBadRequest {
+			t.Fatalf("POST %s: expected 400, got %d", body, status)
+		}
+	}
+
+	status, accepted := post(rolloutPath, `{"set":{"LOG_LEVEL":"debug"},"pause_seconds":1}`)
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %v", status, accepted)
+	}
+	parentID, _ := accepted["op"].(map[string]any)["id"].(string)
+
+	// During the pause after dev the rollout still holds the project.
+	waitForVarRollout(t, fixture.store, parentID, func(op Operation) bool {
+		return op.Rollout.Stages[0].Status == opStatusDone
+	})
+	status, conflict := post("/api/events/deployment", `{"project_id":"`+projectID+`"}`)
+	if status != http.StatusConflict || conflict["active_op"].(map[string]any)["id"] != parentID {
+		t.Fatalf("expected the rollout to block other ops, got %d %v", status, conflict)
+	}
+
+	parent := waitForVarRollout(t, fixture.store, parentID, func(op Operation) bool {
+		return isOperationStatusTerminal(op.Status)
+	})
+	stages := parent.Rollout.Stages
+	if parent.Status != opStatusError || !strings.Contains(parent.Error, "staging: rendered manifest") ||
+		stages[0].Health != varRolloutHealthPassed || stages[1].Health != varRolloutHealthFailed ||
+		stages[2].Status != varRolloutStageSkipped || stages[2].OpID != "" {
+		t.Fatalf("unexpected rollout outcome: %s %q %+v", parent.Status, parent.Error, stages)
+	}
+	if stages[1].OpKind != OpPromote || stages[1].FromEnv != "dev" || stages[2].OpKind != OpRelease {
+		t.Fatalf("unexpected stage plan: %+v", stages)
+	}
+	child, err := fixture.store.GetOp(ctx, stages[1].OpID)
+	if err != nil || child.ParentOpID != parentID || child.Delivery.ToEnv != "staging" {
+		t.Fatalf("expected a staging child of %s, got %+v (%v)", parentID, child, err)
+	}
+
+	project, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if project.Status.LastOpID != parentID || project.Spec.Environments["staging"].Vars["LOG_LEVEL"] != "debug" ||
+		len(project.Spec.Environments["prod"].Vars) != 0 {
+		t.Fatalf("expected the change written up to staging only, got %+v", project)
+	}
+}
+
+func waitForVarRollout(t *testing.T, store *Store, opID string, done func(Operation) bool) Operation {
+	t.Helper()
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		op, err := store.GetOp(context.Background(), opID)
+		if err == nil && op.Rollout != nil && done(op) {
+			return op
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("var rollout %s did not reach the expected state", opID)
+	return Operation{}
+}
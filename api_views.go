@@ -0,0 +1,420 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Saved views: named project filters with a sort order, stored server-side
+// under /api/views so the web UI and the Go client list the same projects
+// for "payments prod errors" without each keeping its own bookmarks. The
+// same filter fields are accepted as query params on GET /api/projects.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	viewNameMax        = 64
+	viewDescriptionMax = 256
+	viewSortDescending = "-"
+	viewProjectsSuffix = "/projects"
+)
+
+// viewRequest is the body of POST /api/views and PUT /api/views/{id}.
+type viewRequest struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Filter      ProjectFilter `json:"filter"`
+	Sort        string        `json:"sort,omitempty"`
+}
+
+type viewListResponse struct {
+	Views []ProjectView `json:"views"`
+}
+
+type viewProjectsResponse struct {
+	View     ProjectView `json:"view"`
+	Projects []Project   `json:"projects"`
+}
+
+type viewDeletedResponse struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// viewNameTakenError refuses a second view with the same name.
+type viewNameTakenError struct {
+	Name string
+}
+
+func (e viewNameTakenError) Error() string {
+	return fmt.Sprintf("a view named %q already exists", e.Name)
+}
+
+// projectSortKeys lists the fields a project list can be sorted by; a
+// leading "-" reverses the order.
+func projectSortKeys() []string {
+	return []string{"name", "created_at", "updated_at", "phase"}
+}
+
+func projectPhases() []string {
+	return []string{projectPhaseReady, journeyPhaseReconciling, projectPhaseDel, projectPhaseError}
+}
+
+// handleViews serves /api/views, /api/views/{id}, and
+// /api/views/{id}/projects.
+func (a *API) handleViews(w http.ResponseWriter, r *http.Request) {
+	if a.store == nil {
+		writeAPIError(w, "view data unavailable", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/views"), "/")
+	id, projects := strings.CutSuffix(rest, viewProjectsSuffix)
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		views, err := a.store.listProjectViews(r.Context())
+		if err != nil {
+			writeAPIError(w, "failed to list views", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, viewListResponse{Views: views})
+	case rest == "" && r.Method == http.MethodPost:
+		a.handleViewSave(w, r, "")
+	case id == "" || strings.Contains(id, "/"):
+		writeAPIError(w, "not found", http.StatusNotFound)
+	case projects && r.Method == http.MethodGet:
+		a.handleViewProjects(w, r, id)
+	case projects:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	case r.Method == http.MethodGet:
+		view, ok := a.readViewOr404(w, r, id)
+		if ok {
+			writeJSON(w, http.StatusOK, view)
+		}
+	case r.Method == http.MethodPut:
+		a.handleViewSave(w, r, id)
+	case r.Method == http.MethodDelete:
+		deleted, err := a.store.deleteProjectView(r.Context(), id)
+		if err != nil {
+			writeAPIError(w, "failed to delete view", http.StatusInternalServerError)
+			return
+		}
+		if !deleted {
+			writeAPIError(w, "view not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, viewDeletedResponse{ID: id, Deleted: true})
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleViewSave creates a view when id is empty and replaces view id
+// otherwise. Names are unique, ignoring case.
+func (a *API) handleViewSave(w http.ResponseWriter, r *http.Request, id string) {
+	var req viewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	view, err := newProjectView(req)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	status := http.StatusCreated
+	if id == "" {
+		view.ID = newID()
+		view.CreatedAt = view.UpdatedAt
+		if principal, ok := requestPrincipal(r.Context()); ok {
+			view.CreatedBy = principal.Name
+		}
+	} else {
+		existing, ok := a.readViewOr404(w, r, id)
+		if !ok {
+			return
+		}
+		view.ID, view.CreatedBy, view.CreatedAt = existing.ID, existing.CreatedBy, existing.CreatedAt
+		status = http.StatusOK
+	}
+	if err = a.checkViewNameFree(r, view); err != nil {
+		var taken viewNameTakenError
+		if errors.As(err, &taken) {
+			writeAPIError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeAPIError(w, "failed to list views", http.StatusInternalServerError)
+		return
+	}
+	if err = a.store.putProjectView(r.Context(), view); err != nil {
+		writeAPIError(w, "failed to save view", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, status, view)
+}
+
+func (a *API) handleViewProjects(w http.ResponseWriter, r *http.Request, id string) {
+	view, ok := a.readViewOr404(w, r, id)
+	if !ok {
+		return
+	}
+	projects, err := a.store.ListProjects(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to list projects", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewProjectsResponse{
+		View:     view,
+		Projects: filterAndSortProjects(projects, scopeProjectFilter(r.Context(), view.Filter), view.Sort),
+	})
+}
+
+func (a *API) readViewOr404(w http.ResponseWriter, r *http.Request, id string) (ProjectView, bool) {
+	view, err := a.store.getProjectView(r.Context(), id)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		writeAPIError(w, "view not found", http.StatusNotFound)
+		return ProjectView{}, false
+	}
+	if err != nil {
+		writeAPIError(w, "failed to read view", http.StatusInternalServerError)
+		return ProjectView{}, false
+	}
+	return view, true
+}
+
+func (a *API) checkViewNameFree(r *http.Request, view ProjectView) error {
+	views, err := a.store.listProjectViews(r.Context())
+	if err != nil {
+		return err
+	}
+	for _, other := range views {
+		if other.ID != view.ID && strings.EqualFold(other.Name, view.Name) {
+			return viewNameTakenError{Name: other.Name}
+		}
+	}
+	return nil
+}
+
+// newProjectView validates req into a view stamped with the current time.
+// The caller fills in the ID and creation fields.
+func newProjectView(req viewRequest) (ProjectView, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > viewNameMax {
+		return ProjectView{}, fmt.Errorf("name is required (at most %d characters)", viewNameMax)
+	}
+	description := strings.TrimSpace(req.Description)
+	if len(description) > viewDescriptionMax {
+		return ProjectView{}, fmt.Errorf("description must be at most %d characters", viewDescriptionMax)
+	}
+	filter, err := normalizeProjectFilter(req.Filter)
+	if err != nil {
+		return ProjectView{}, fmt.Errorf("filter: %w", err)
+	}
+	sortKey, err := normalizeProjectSort(req.Sort)
+	if err != nil {
+		return ProjectView{}, err
+	}
+	now := time.Now().UTC()
+	return ProjectView{
+		ID:          "",
+		Name:        name,
+		Description: description,
+		Filter:      filter,
+		Sort:        sortKey,
+		CreatedBy:   "",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// parseProjectListQuery reads the filter and sort params of GET
+// /api/projects.
+func parseProjectListQuery(values url.Values) (ProjectFilter, string, error) {
+	filter, err := normalizeProjectFilter(ProjectFilter{
+		Name:        values.Get("name"),
+		NamePrefix:  values.Get("name_prefix"),
+		Phase:       values.Get("phase"),
+		Team:        values.Get("team"),
+		Owner:       values.Get("owner"),
+		Environment: values.Get("environment"),
+		Runtime:     values.Get("runtime"),
+		Capability:  values.Get("capability"),
+		Org:         values.Get("org"),
+		Workspace:   values.Get("workspace"),
+	})
+	if err != nil {
+		return ProjectFilter{}, "", err
+	}
+	sortKey, err := normalizeProjectSort(values.Get("sort"))
+	if err != nil {
+		return ProjectFilter{}, "", err
+	}
+	return filter, sortKey, nil
+}
+
+func normalizeProjectFilter(filter ProjectFilter) (ProjectFilter, error) {
+	out := ProjectFilter{
+		Name:        strings.TrimSpace(filter.Name),
+		NamePrefix:  strings.TrimSpace(filter.NamePrefix),
+		Phase:       "",
+		Team:        strings.ToLower(strings.TrimSpace(filter.Team)),
+		Owner:       strings.TrimSpace(filter.Owner),
+		Environment: strings.TrimSpace(filter.Environment),
+		Runtime:     strings.TrimSpace(filter.Runtime),
+		Capability:  strings.TrimSpace(filter.Capability),
+		Org:         strings.TrimSpace(filter.Org),
+		Workspace:   strings.TrimSpace(filter.Workspace),
+	}
+	if raw := strings.TrimSpace(filter.Phase); raw != "" {
+		idx := slices.IndexFunc(projectPhases(), func(phase string) bool { return strings.EqualFold(phase, raw) })
+		if idx < 0 {
+			return ProjectFilter{}, fmt.Errorf("bad phase %q (one of %s)", raw, strings.Join(projectPhases(), ", "))
+		}
+		out.Phase = projectPhases()[idx]
+	}
+	return out, nil
+}
+
+func normalizeProjectSort(raw string) (string, error) {
+	sortKey := strings.ToLower(strings.TrimSpace(raw))
+	if sortKey == "" {
+		return "", nil
+	}
+	if !slices.Contains(projectSortKeys(), strings.TrimPrefix(sortKey, viewSortDescending)) {
+		return "", fmt.Errorf("bad sort %q (one of %s, optionally prefixed with -)",
+			raw, strings.Join(projectSortKeys(), ", "))
+	}
+	return sortKey, nil
+}
+
+// matches reports whether project passes every set field of f.
+func (f ProjectFilter) matches(project Project) bool {
+	if f.Name != "" {
+		name := strings.ToLower(f.Name)
+		if !strings.Contains(strings.ToLower(project.Spec.Name), name) &&
+			!strings.Contains(strings.ToLower(project.ID), name) {
+			return false
+		}
+	}
+	if f.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(project.Spec.Name), strings.ToLower(f.NamePrefix)) {
+		return false
+	}
+	if f.Phase != "" && !strings.EqualFold(project.Status.Phase, f.Phase) {
+		return false
+	}
+	if f.Team != "" && !slices.Contains(project.Ownership.Teams, f.Team) {
+		return false
+	}
+	owner := apiPrincipal{TokenID: "", Name: f.Owner, Role: "", Teams: nil, Org: ""}
+	if f.Owner != "" && !project.Ownership.admits(owner) {
+		return false
+	}
+	if f.Org != "" && project.Org != f.Org {
+		return false
+	}
+	if f.Workspace != "" && project.Workspace != f.Workspace {
+		return false
+	}
+	if f.Environment != "" {
+		if _, ok := project.Spec.Environments[f.Environment]; !ok {
+			return false
+		}
+	}
+	if f.Runtime != "" && project.Spec.Runtime != f.Runtime {
+		return false
+	}
+	return f.Capability == "" || slices.Contains(project.Spec.Capabilities, f.Capability)
+}
+
+// filterAndSortProjects returns the projects filter matches, in sortKey
+// order. Ties, and an empty sortKey, keep the creation order ListProjects
+// returns.
+func filterAndSortProjects(projects []Project, filter ProjectFilter, sortKey string) []Project {
+	out := []Project{}
+	for _, project := range projects {
+		if filter.matches(project) {
+			out = append(out, project)
+		}
+	}
+	field, descending := strings.CutPrefix(sortKey, viewSortDescending)
+	slices.SortStableFunc(out, func(x, y Project) int {
+		cmp := 0
+		switch field {
+		case "name":
+			cmp = strings.Compare(strings.ToLower(x.Spec.Name), strings.ToLower(y.Spec.Name))
+		case "created_at":
+			cmp = x.CreatedAt.Compare(y.CreatedAt)
+		case "updated_at":
+			cmp = x.UpdatedAt.Compare(y.UpdatedAt)
+		case "phase":
+			cmp = strings.Compare(x.Status.Phase, y.Status.Phase)
+		}
+		if descending {
+			return -cmp
+		}
+		return cmp
+	})
+	return out
+}
+
+// projectListPage is GET /api/projects when limit or cursor is set.
+type projectListPage struct {
+	Items      []Project `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// projectListCursorError reports a cursor that is not in the listing, e.g.
+// because the project was deleted or no longer matches the filter.
+type projectListCursorError struct {
+	cursor string
+}
+
+func (e projectListCursorError) Error() string {
+	return fmt.Sprintf("cursor %q is not in this listing; start again without cursor", e.cursor)
+}
+
+// projectListPaginated reports whether GET /api/projects asked for a page rather than
+// the whole list.
+func projectListPaginated(values url.Values) bool {
+	return values.Has("limit") || values.Has("cursor")
+}
+
+func parseProjectListLimitParam(raw string) (int, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return projectListDefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(trimmed)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("bad limit")
+	}
+	return min(limit, projectListMaxLimit), nil
+}
+
+// pageProjects returns up to limit projects following the one with ID
+// cursor, or from the start when cursor is empty. The cursor of the next
+// page is the last project's ID; it is empty on the last page.
+func pageProjects(projects []Project, cursor string, limit int) (projectListPage, error) {
+	start := 0
+	if cursor = strings.TrimSpace(cursor); cursor != "" {
+		idx := slices.IndexFunc(projects, func(project Project) bool { return project.ID == cursor })
+		if idx < 0 {
+			return projectListPage{}, projectListCursorError{cursor: cursor}
+		}
+		start = idx + 1
+	}
+	end := min(start+limit, len(projects))
+	page := projectListPage{Items: projects[start:end], NextCursor: ""}
+	if end < len(projects) {
+		page.NextCursor = projects[end-1].ID
+	}
+	return page, nil
+}
@@ -0,0 +1,173 @@
+//nolint:testpackage,exhaustruct // View tests seed projects through the internal store and router.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPI_SavedViewsFilterAndSortProjects(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	api := fixture.api
+
+	base, err := api.store.GetProject(ctx, fixture.projectID)
+	if err != nil {
+		t.Fatalf("read fixture project: %v", err)
+	}
+	seed := func(id, name, phase string, teams ...string) {
+		t.Helper()
+		project := base
+		project.ID, project.Spec.Name, project.Status.Phase = id, name, phase
+		project.Revision = 0
+		project.Ownership = ProjectOwnership{Teams: teams}
+		if err = api.store.PutProject(ctx, project); err != nil {
+			t.Fatalf("put %s: %v", id, err)
+		}
+	}
+	seed("pay-api", "pay-api", projectPhaseError, "payments")
+	seed("pay-worker", "pay-worker", projectPhaseError, "payments")
+	seed("pay-web", "pay-web", projectPhaseReady, "payments")
+	seed("search-api", "search-api", projectPhaseError, "search")
+
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	call := func(method, path string, body any, out any) (int, string) {
+		t.Helper()
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, bytes.NewReader(payload))
+		resp, callErr := srv.Client().Do(req)
+		if callErr != nil {
+			t.Fatalf("%s %s: %v", method, path, callErr)
+		}
+		defer resp.Body.Close()
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		if out != nil && resp.StatusCode < http.StatusMultipleChoices {
+			if err = json.Unmarshal(buf.Bytes(), out); err != nil {
+				t.Fatalf("decode %s %s: %v", method, path, err)
+			}
+		}
+		return resp.StatusCode, buf.String()
+	}
+	ids := func(projects []Project) string {
+		out := []string{}
+		for _, project := range projects {
+			out = append(out, project.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	body := viewRequest{
+		Name:   "Payments errors",
+		Filter: ProjectFilter{Team: "Payments", Phase: "error"},
+		Sort:   "-name",
+	}
+	var view ProjectView
+	if status, raw := call(http.MethodPost, "/api/views", body, &view); status != http.StatusCreated {
+		t.Fatalf("expected the view to be created, got %d %s", status, raw)
+	}
+	if view.Filter.Team != "payments" || view.Filter.Phase != projectPhaseError {
+		t.Fatalf("expected a normalized filter, got %+v", view.Filter)
+	}
+	if status, raw := call(http.MethodPost, "/api/views", body, nil); status != http.StatusConflict {
+		t.Fatalf("expected a duplicate name to conflict, got %d %s", status, raw)
+	}
+	body.Sort = "owner"
+	if status, raw := call(http.MethodPost, "/api/views", body, nil); status != http.StatusBadRequest ||
+		!strings.Contains(raw, "bad sort") {
+		t.Fatalf("expected an unknown sort to be refused, got %d %s", status, raw)
+	}
+
+	var listed viewProjectsResponse
+	call(http.MethodGet, "/api/views/"+view.ID+"/projects", nil, &listed)
+	if got := ids(listed.Projects); got != "pay-worker,pay-api" {
+		t.Fatalf("expected the payments errors by name descending, got %s", got)
+	}
+
+	var projects []Project
+	call(http.MethodGet, "/api/projects?phase=Error&name=API&sort=name", nil, &projects)
+	if got := ids(projects); got != "pay-api,search-api" {
+		t.Fatalf("expected query filters on the project list, got %s", got)
+	}
+	if status, raw := call(http.MethodGet, "/api/projects?phase=broken", nil, nil); status != http.StatusBadRequest {
+		t.Fatalf("expected an unknown phase to be refused, got %d %s", status, raw)
+	}
+
+	var deleted viewDeletedResponse
+	call(http.MethodDelete, "/api/views/"+view.ID, nil, &deleted)
+	if status, _ := call(http.MethodGet, "/api/views/"+view.ID, nil, nil); !deleted.Deleted ||
+		status != http.StatusNotFound {
+		t.Fatalf("expected the view to be gone, got %+v then %d", deleted, status)
+	}
+}
+
+func TestAPI_ProjectListPagesWithCursorAndNamePrefix(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	api := fixture.api
+
+	base, err := api.store.GetProject(ctx, fixture.projectID)
+	if err != nil {
+		t.Fatalf("read fixture project: %v", err)
+	}
+	for _, name := range []string{"pay-a", "pay-b", "pay-c", "pay-d", "PAY-e", "search"} {
+		project := base
+		project.ID, project.Spec.Name = name, name
+		project.Revision = 0
+		if err = api.store.PutProject(ctx, project); err != nil {
+			t.Fatalf("put %s: %v", name, err)
+		}
+	}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	get := func(query string) (projectListPage, int) {
+		t.Helper()
+		resp, getErr := srv.Client().Get(srv.URL + "/api/projects?" + query)
+		if getErr != nil {
+			t.Fatalf("list %s: %v", query, getErr)
+		}
+		defer resp.Body.Close()
+		var page projectListPage
+		if resp.StatusCode == http.StatusOK {
+			if err = json.NewDecoder(resp.Body).Decode(&page); err != nil {
+				t.Fatalf("decode %s: %v", query, err)
+			}
+		}
+		return page, resp.StatusCode
+	}
+
+	seen := []string{}
+	cursor := ""
+	for range 4 {
+		page, status := get("name_prefix=pay&sort=-name&limit=2&cursor=" + cursor)
+		if status != http.StatusOK {
+			t.Fatalf("expected a page, got %d", status)
+		}
+		for _, project := range page.Items {
+			seen = append(seen, project.ID)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if got := strings.Join(seen, ","); got != "PAY-e,pay-d,pay-c,pay-b,pay-a" {
+		t.Fatalf("expected every pay- project once, name descending, got %s", got)
+	}
+	if _, status := get("limit=0"); status != http.StatusBadRequest {
+		t.Fatalf("expected a bad limit refused, got %d", status)
+	}
+	if _, status := get("cursor=search&name_prefix=pay"); status != http.StatusBadRequest {
+		t.Fatalf("expected a cursor outside the listing refused, got %d", status)
+	}
+}
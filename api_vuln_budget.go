@@ -0,0 +1,349 @@
+package platform
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Vulnerability budget: promotions and releases of an image whose scan report
+// has more findings of a severity than PAAS_VULN_BUDGET allows are refused,
+// unless an operator overrides the gate with a recorded justification.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// vulnerabilityReportPath is where a scanner leaves its report for the
+	// project's latest image, in Trivy JSON format (trivy image --format json).
+	vulnerabilityReportPath       = "build/vulnerability-report.json"
+	defaultVulnerabilityBudget    = "critical=0"
+	vulnerabilityBudgetOff        = "off"
+	transitionBlockerVulnBudget   = "vulnerability_budget_exceeded"
+	vulnerabilitySeverityCritical = "critical"
+	vulnerabilitySeverityHigh     = "high"
+	vulnerabilitySeverityMedium   = "medium"
+	vulnerabilitySeverityLow      = "low"
+)
+
+// vulnerabilityBudget maps a severity to the most findings of it an image
+// may carry forward. Severities without an entry are unlimited.
+type vulnerabilityBudget map[string]int
+
+// vulnerabilityCheck is the budget verdict for the image a transition moves.
+type vulnerabilityCheck struct {
+	image    string
+	enforced bool
+	scanned  bool
+	summary  VulnerabilitySummary
+	exceeded []string
+}
+
+// vulnerabilityBudgetError refuses a transition whose image is over budget.
+type vulnerabilityBudgetError struct {
+	ProjectID string
+	Check     vulnerabilityCheck
+}
+
+type trivyReport struct {
+	ArtifactName string `json:"ArtifactName"`
+	Results      []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID string `json:"VulnerabilityID"`
+			Severity        string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+func (e vulnerabilityBudgetError) Error() string {
+	return fmt.Sprintf(
+		"image %s exceeds the vulnerability budget (%s)",
+		e.Check.image,
+		strings.Join(e.Check.exceeded, ", "),
+	)
+}
+
+func (b vulnerabilityBudget) exceeded(summary VulnerabilitySummary) []string {
+	out := []string{}
+	for _, severity := range vulnerabilitySeverities() {
+		limit, ok := b[severity]
+		if !ok {
+			continue
+		}
+		if found := summary.count(severity); found > limit {
+			out = append(out, fmt.Sprintf("%s: %d > %d", severity, found, limit))
+		}
+	}
+	return out
+}
+
+func (s VulnerabilitySummary) count(severity string) int {
+	switch severity {
+	case vulnerabilitySeverityCritical:
+		return s.Critical
+	case vulnerabilitySeverityHigh:
+		return s.High
+	case vulnerabilitySeverityMedium:
+		return s.Medium
+	case vulnerabilitySeverityLow:
+		return s.Low
+	default:
+		return s.Unknown
+	}
+}
+
+func vulnerabilitySeverities() []string {
+	return []string{
+		vulnerabilitySeverityCritical,
+		vulnerabilitySeverityHigh,
+		vulnerabilitySeverityMedium,
+		vulnerabilitySeverityLow,
+	}
+}
+
+func vulnerabilityBudgetFromEnv() vulnerabilityBudget {
+	return parseVulnerabilityBudget(os.Getenv(vulnBudgetEnv))
+}
+
+// parseVulnerabilityBudget reads "severity=max" pairs such as
+// "critical=0,high=5". "off" disables the gate; an invalid list falls back
+// to the default so a typo never opens it.
+func parseVulnerabilityBudget(raw string) vulnerabilityBudget {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == vulnerabilityBudgetOff {
+		return vulnerabilityBudget{}
+	}
+	if raw == "" {
+		raw = defaultVulnerabilityBudget
+	}
+	out := vulnerabilityBudget{}
+	for part := range strings.SplitSeq(raw, ",") {
+		severity, limitRaw, _ := strings.Cut(strings.TrimSpace(part), "=")
+		severity = strings.TrimSpace(severity)
+		limit, err := strconv.Atoi(strings.TrimSpace(limitRaw))
+		if err != nil || limit < 0 || !isVulnerabilitySeverity(severity) {
+			return parseVulnerabilityBudget(defaultVulnerabilityBudget)
+		}
+		out[severity] = limit
+	}
+	return out
+}
+
+func isVulnerabilitySeverity(severity string) bool {
+	for _, known := range vulnerabilitySeverities() {
+		if severity == known {
+			return true
+		}
+	}
+	return false
+}
+
+// readVulnerabilitySummary loads the project's scan report. found is false
+// when there is none, or when it scanned some other image than image.
+func readVulnerabilitySummary(
+	artifacts ArtifactStore,
+	projectID string,
+	image string,
+) (VulnerabilitySummary, bool, error) {
+	summary := VulnerabilitySummary{Image: image, Critical: 0, High: 0, Medium: 0, Low: 0, Unknown: 0}
+	if artifacts == nil {
+		return summary, false, nil
+	}
+	raw, err := artifacts.ReadFile(projectID, vulnerabilityReportPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return summary, false, nil
+		}
+		return summary, false, fmt.Errorf("read %s: %w", vulnerabilityReportPath, err)
+	}
+	var report trivyReport
+	if err = json.Unmarshal(raw, &report); err != nil {
+		return summary, false, fmt.Errorf("parse %s: %w", vulnerabilityReportPath, err)
+	}
+	if scanned := strings.TrimSpace(report.ArtifactName); scanned != "" && scanned != image {
+		return summary, false, nil
+	}
+	for _, result := range report.Results {
+		for _, vuln := range result.Vulnerabilities {
+			switch strings.ToLower(strings.TrimSpace(vuln.Severity)) {
+			case vulnerabilitySeverityCritical:
+				summary.Critical++
+			case vulnerabilitySeverityHigh:
+				summary.High++
+			case vulnerabilitySeverityMedium:
+				summary.Medium++
+			case vulnerabilitySeverityLow:
+				summary.Low++
+			default:
+				summary.Unknown++
+			}
+		}
+	}
+	return summary, true, nil
+}
+
+// checkVulnerabilityBudget holds image's scan report against the budget. An
+// image without a report passes; the preview gate warns about it instead.
+func (a *API) checkVulnerabilityBudget(projectID, image string) (vulnerabilityCheck, error) {
+	budget := vulnerabilityBudgetFromEnv()
+	check := vulnerabilityCheck{
+		image:    strings.TrimSpace(image),
+		enforced: len(budget) > 0,
+		scanned:  false,
+		summary:  VulnerabilitySummary{},
+		exceeded: nil,
+	}
+	if !check.enforced || check.image == "" {
+		return check, nil
+	}
+	summary, found, err := readVulnerabilitySummary(a.artifacts, projectID, check.image)
+	if err != nil {
+		return check, err
+	}
+	check.scanned = found
+	check.summary = summary
+	if found {
+		check.exceeded = budget.exceeded(summary)
+	}
+	return check, nil
+}
+
+// transitionVulnerabilityGate checks the image lifecycle would move. An
+// over-budget image needs an override, which is only honored with the
+// operator token and a justification; overridden reports whether the op
+// should record the returned override.
+func (a *API) transitionVulnerabilityGate(
+	r *http.Request,
+	lifecycle transitionLifecycleContext,
+	req *VulnerabilityOverrideRequest,
+) (VulnerabilityOverride, bool, error) {
+	var override VulnerabilityOverride
+	check, err := a.checkTransitionSourceBudget(lifecycle.project.ID, lifecycle.spec, lifecycle.fromEnv)
+	if err != nil || len(check.exceeded) == 0 {
+		return override, false, err
+	}
+	if req == nil {
+		return override, false, vulnerabilityBudgetError{ProjectID: lifecycle.project.ID, Check: check}
+	}
+	if !operatorRequest(r) {
+		return override, false, requestError(
+			http.StatusForbidden,
+			"vulnerability overrides require the operator token (Authorization: Bearer $"+operatorTokenEnv+")",
+		)
+	}
+	override.Justification = strings.TrimSpace(req.Justification)
+	if override.Justification == "" {
+		return override, false, requestError(http.StatusBadRequest, "vulnerability_override.justification is required")
+	}
+	override.By = strings.TrimSpace(req.By)
+	override.Exceeded = check.exceeded
+	override.Summary = check.summary
+	override.At = time.Now().UTC()
+	return override, true, nil
+}
+
+// checkTransitionSourceBudget checks the image a transition out of fromEnv
+// would ship against the project's budget.
+func (a *API) checkTransitionSourceBudget(
+	projectID string,
+	spec ProjectSpec,
+	fromEnv string,
+) (vulnerabilityCheck, error) {
+	image, err := a.transitionSourceImage(projectID, spec, fromEnv)
+	if err != nil {
+		return vulnerabilityCheck{}, err
+	}
+	return a.checkVulnerabilityBudget(projectID, image)
+}
+
+// operatorRequest reports whether r carries PAAS_OPERATOR_TOKEN as its bearer
+// token. Without a configured token no request is an operator's.
+func operatorRequest(r *http.Request) bool {
+	token := strings.TrimSpace(os.Getenv(operatorTokenEnv))
+	if token == "" {
+		return false
+	}
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(bearer)), []byte(token)) == 1
+}
+
+func writeVulnerabilityBudgetError(w http.ResponseWriter, err error) bool {
+	var budgetErr vulnerabilityBudgetError
+	if !errors.As(err, &budgetErr) {
+		return false
+	}
+	writeErrorDetails(w, http.StatusConflict, errorCodeVulnBudget, map[string]any{
+		"accepted":   false,
+		"reason":     budgetErr.Error(),
+		"project_id": budgetErr.ProjectID,
+		"image":      budgetErr.Check.image,
+		"summary":    budgetErr.Check.summary,
+		"exceeded":   budgetErr.Check.exceeded,
+		"next_step": "fix the findings and rebuild, or retry with vulnerability_override " +
+			"and the operator token",
+	})
+	return true
+}
+
+// auditVulnerabilityOverride logs an accepted override and appends it to the
+// project's override audit log next to the hold log.
+func (a *API) auditVulnerabilityOverride(op Operation) {
+	override := op.VulnerabilityOverride
+	if override == nil {
+		return
+	}
+	appLoggerForProcess().Source("api").Warnf(
+		"vulnerability budget overridden op=%s project=%s image=%s by=%q exceeded=%q justification=%q",
+		op.ID,
+		op.ProjectID,
+		override.Summary.Image,
+		override.By,
+		strings.Join(override.Exceeded, ", "),
+		override.Justification,
+	)
+	a.appendProjectAuditLine(op.ProjectID, "overrides", fmt.Sprintf(
+		"%s vulnerability override op=%s image=%s by=%q exceeded=%q justification=%q",
+		override.At.Format(time.RFC3339),
+		op.ID,
+		override.Summary.Image,
+		override.By,
+		strings.Join(override.Exceeded, ", "),
+		override.Justification,
+	))
+}
+
+func vulnerabilityPreviewGate(check vulnerabilityCheck) TransitionPreviewGate {
+	gate := TransitionPreviewGate{
+		Code:   transitionBlockerVulnBudget,
+		Title:  "Image is within the vulnerability budget",
+		Status: previewGatePassed,
+		Detail: "",
+	}
+	switch {
+	case check.image == "":
+		gate.Detail = "No source image to check yet."
+	case !check.enforced:
+		gate.Detail = "The vulnerability budget is disabled (" + vulnBudgetEnv + "=off)."
+	case !check.scanned:
+		gate.Status = previewGateWarning
+		gate.Detail = fmt.Sprintf("No scan report for %s; the budget cannot be checked.", check.image)
+	case len(check.exceeded) > 0:
+		gate.Status = previewGateBlocked
+		gate.Detail = "Over budget: " + strings.Join(check.exceeded, ", ") + "."
+	default:
+		gate.Detail = fmt.Sprintf(
+			"%d critical, %d high findings are within budget.",
+			check.summary.Critical,
+			check.summary.High,
+		)
+	}
+	return gate
+}
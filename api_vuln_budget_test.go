@@ -0,0 +1,135 @@
+//nolint:testpackage,exhaustruct // Vulnerability budget tests reuse the internal promotion preview fixture.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_PromotionVulnerabilityBudgetBlocksUntilOperatorOverrides(t *testing.T) {
+	t.Setenv(vulnBudgetEnv, "critical=0,high=1")
+	t.Setenv(operatorTokenEnv, "operator-secret")
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+
+	image := "example.local/vuln-budget:v1"
+	writePreviewDeploymentImage(t, fixture.artifacts, fixture.projectID, "dev", image)
+	if _, err := fixture.api.store.PutRelease(context.Background(), ReleaseRecord{
+		ProjectID: fixture.projectID, Environment: "dev", OpID: "op-vuln-source", OpKind: OpDeploy,
+		DeliveryStage: DeliveryStageDeploy, ToEnv: "dev", Image: image, CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("put source release: %v", err)
+	}
+	report := `{"ArtifactName":"` + image + `","Results":[{"Vulnerabilities":[` +
+		`{"VulnerabilityID":"CVE-1","Severity":"CRITICAL"},` +
+		`{"VulnerabilityID":"CVE-2","Severity":"HIGH"},{"VulnerabilityID":"CVE-3","Severity":"HIGH"},` +
+		`{"VulnerabilityID":"CVE-4","Severity":"LOW"}]}]}`
+	if _, err := fixture.artifacts.WriteFile(fixture.projectID, vulnerabilityReportPath, []byte(report)); err != nil {
+		t.Fatalf("write scan report: %v", err)
+	}
+
+	preview := requestPromotionPreviewForTest(t, fixture, map[string]any{
+		"project_id": fixture.projectID, "from_env": "dev", "to_env": "staging",
+	})
+	assertPromotionPreviewHasBlocker(t, preview, transitionBlockerVulnBudget)
+	gate := preview.Gates[len(preview.Gates)-1]
+	if gate.Code != transitionBlockerVulnBudget || gate.Status != previewGateBlocked ||
+		!strings.Contains(gate.Detail, "critical: 1 > 0") || !strings.Contains(gate.Detail, "high: 2 > 1") {
+		t.Fatalf("unexpected vulnerability gate: %#v", gate)
+	}
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	promote := func(token string, override map[string]any) (int, string) {
+		body := map[string]any{"project_id": fixture.projectID, "from_env": "dev", "to_env": "staging"}
+		if override != nil {
+			body["vulnerability_override"] = override
+		}
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(
+			context.Background(), http.MethodPost, srv.URL+"/api/events/promotion", bytes.NewReader(payload),
+		)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("promote: %v", err)
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(raw)
+	}
+
+	if code, raw := promote("", nil); code != http.StatusConflict || !strings.Contains(raw, `"exceeded"`) {
+		t.Fatalf("expected 409 over budget, got %d %s", code, raw)
+	}
+	justified := map[string]any{"justification": "CVE-1 is unreachable; fix ships Friday", "by": "oncall"}
+	if code, raw := promote("wrong-token", justified); code != http.StatusForbidden {
+		t.Fatalf("expected 403 without the operator token, got %d %s", code, raw)
+	}
+	if code, raw := promote("operator-secret", map[string]any{"by": "oncall"}); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a justification, got %d %s", code, raw)
+	}
+	code, raw := promote("operator-secret", justified)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202 with an operator override, got %d %s", code, raw)
+	}
+	var accepted struct {
+		Op Operation `json:"op"`
+	}
+	if err := json.Unmarshal([]byte(raw), &accepted); err != nil {
+		t.Fatalf("decode accepted: %v", err)
+	}
+	stored, err := fixture.api.store.GetOp(context.Background(), accepted.Op.ID)
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	recorded := stored.VulnerabilityOverride
+	if recorded == nil || recorded.By != "oncall" || recorded.Summary.Critical != 1 || len(recorded.Exceeded) != 2 {
+		t.Fatalf("expected the override recorded on the op, got %#v", recorded)
+	}
+	auditDir := filepath.Join(filepath.Dir(fixture.artifacts.ProjectDir(fixture.projectID)), "_audit")
+	logged, err := os.ReadFile(filepath.Join(auditDir, fixture.projectID+".overrides.log"))
+	if err != nil || !strings.Contains(string(logged), "fix ships Friday") || !strings.Contains(string(logged), stored.ID) {
+		t.Fatalf("expected the override in the audit log, got %q (%v)", logged, err)
+	}
+}
+
+func TestAPI_VulnerabilityBudgetIgnoresReportsForOtherImages(t *testing.T) {
+	artifacts := NewFSArtifacts(t.TempDir())
+	api := &API{artifacts: artifacts}
+	report := `{"ArtifactName":"example.local/app:v2","Results":[{"Vulnerabilities":[{"Severity":"CRITICAL"}]}]}`
+	if _, err := artifacts.WriteFile("project-vuln", vulnerabilityReportPath, []byte(report)); err != nil {
+		t.Fatalf("write scan report: %v", err)
+	}
+
+	check, err := api.checkVulnerabilityBudget("project-vuln", "example.local/app:v1")
+	if err != nil || check.scanned || len(check.exceeded) != 0 {
+		t.Fatalf("expected a report for another image to be ignored, got %#v (%v)", check, err)
+	}
+	if gate := vulnerabilityPreviewGate(check); gate.Status != previewGateWarning {
+		t.Fatalf("expected an unscanned image to warn, got %#v", gate)
+	}
+	check, err = api.checkVulnerabilityBudget("project-vuln", "example.local/app:v2")
+	if err != nil || len(check.exceeded) != 1 {
+		t.Fatalf("expected the default budget to refuse a critical finding, got %#v (%v)", check, err)
+	}
+
+	if budget := parseVulnerabilityBudget("critical=0,high=lots"); len(budget) != 1 || budget["critical"] != 0 {
+		t.Fatalf("expected an invalid budget to fall back to the default, got %#v", budget)
+	}
+	if budget := parseVulnerabilityBudget("OFF"); len(budget) != 0 {
+		t.Fatalf("expected off to disable the budget, got %#v", budget)
+	}
+}
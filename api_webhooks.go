@@ -29,6 +29,8 @@ type sourceRepoCICommitState struct {
 	LastSuccessfulCommit string                                    `json:"last_successful_commit,omitempty"`
 	PendingEnqueueCommit string                                    `json:"pending_enqueue_commit,omitempty"`
 	PendingByOpID        map[string]sourceRepoCICommitPendingState `json:"pending_by_op_id,omitempty"`
+	// Tags maps each tag CI has been queued for to the commit it pointed at.
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
 type sourceRepoWebhookResult struct {
@@ -42,30 +44,34 @@ type sourceRepoWebhookResult struct {
 
 func (a *API) handleSourceRepoWebhook(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var evt SourceRepoWebhookEvent
 	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
 		return
 	}
 	evt.ProjectID = strings.TrimSpace(evt.ProjectID)
 	if evt.ProjectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		writeAPIError(w, "project_id required", http.StatusBadRequest)
 		return
 	}
-	result, err := a.triggerSourceRepoCI(r.Context(), evt, "source.main.webhook")
+	ctx, ok := a.authorizeSourceWebhook(w, r, evt)
+	if !ok {
+		return
+	}
+	result, err := a.triggerSourceRepoCI(ctx, evt, "source.main.webhook")
 	if err != nil {
 		if writeAsyncOpError(w, err) {
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	if result.reason == "project not found" {
-		http.Error(w, result.reason, http.StatusNotFound)
+		writeAPIError(w, result.reason, http.StatusNotFound)
 		return
 	}
 	writeJSON(w, http.StatusAccepted, map[string]any{
@@ -83,75 +89,91 @@ func (a *API) triggerSourceRepoCI(
 	evt SourceRepoWebhookEvent,
 	trigger string,
 ) (sourceRepoWebhookResult, error) {
-	if evt.Repo != "" && strings.ToLower(strings.TrimSpace(evt.Repo)) != "source" {
+	commit := strings.TrimSpace(evt.Commit)
+	ignored := func(projectID, reason string) (sourceRepoWebhookResult, error) {
 		return sourceRepoWebhookResult{
 			accepted: false,
-			reason:   "ignored: only source repo webhooks trigger ci",
-			project:  evt.ProjectID,
+			reason:   reason,
+			project:  projectID,
 			op:       nil,
-			commit:   strings.TrimSpace(evt.Commit),
+			commit:   commit,
 			trigger:  trigger,
 		}, nil
 	}
-	if !isMainBranchWebhook(evt.Branch, evt.Ref) {
-		return sourceRepoWebhookResult{
-			accepted: false,
-			reason:   "ignored: only main branch triggers CI",
-			project:  evt.ProjectID,
-			op:       nil,
-			commit:   strings.TrimSpace(evt.Commit),
-			trigger:  trigger,
-		}, nil
+	if evt.Repo != "" && strings.ToLower(strings.TrimSpace(evt.Repo)) != "source" {
+		return ignored(evt.ProjectID, "ignored: only source repo webhooks trigger ci")
 	}
 
-	project, err := a.store.GetProject(ctx, evt.ProjectID)
+	project, _, err := a.store.getProjectRevision(ctx, evt.ProjectID)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
-			return sourceRepoWebhookResult{
-				accepted: false,
-				reason:   "project not found",
-				project:  evt.ProjectID,
-				op:       nil,
-				commit:   strings.TrimSpace(evt.Commit),
-				trigger:  trigger,
-			}, nil
+			return ignored(evt.ProjectID, "project not found")
 		}
 		return sourceRepoWebhookResult{}, err
 	}
+	policy := normalizeProjectSpec(project.Spec).CI
+	if evt.Deleted {
+		return a.triggerPreviewTeardown(ctx, project, evt, trigger)
+	}
+	if preview, ok := matchPreviewPush(policy, evt.Branch, evt.Ref); ok {
+		return a.triggerPreviewBuild(ctx, project, preview, commit, trigger)
+	}
+	push, reason := matchCIPush(policy, evt.Branch, evt.Ref)
+	if reason != "" {
+		return ignored(project.ID, reason)
+	}
 
 	a.sourceTriggerMu.Lock()
 	defer a.sourceTriggerMu.Unlock()
 
-	isNewCommit, markErr := a.markSourceCommitSeen(project.ID, evt.Commit)
-	if markErr != nil {
-		return sourceRepoWebhookResult{}, markErr
+	if !push.tag && !a.sourcePushChangesPaths(ctx, project.ID, commit, policy.Paths) {
+		return ignored(project.ID, sourceRepoWebhookPathsLabel)
 	}
-	if !isNewCommit {
+	claim, original, err := a.claimSourceCommit(ctx, project.ID, push, commit)
+	if err != nil || !claim.claimed {
 		return sourceRepoWebhookResult{
 			accepted: false,
 			reason:   sourceRepoWebhookCommitIgnoredLabel,
 			project:  project.ID,
-			op:       nil,
-			commit:   strings.TrimSpace(evt.Commit),
+			op:       original,
+			commit:   commit,
 			trigger:  trigger,
-		}, nil
+		}, err
+	}
+	var queued *Operation
+	defer func() { a.finishSourceCommitClaim(context.WithoutCancel(ctx), claim, queued) }()
+	isNewPush, markErr := a.markSourcePushSeen(project.ID, push, commit)
+	if markErr != nil {
+		return sourceRepoWebhookResult{}, markErr
+	}
+	if !isNewPush && push.tag {
+		return ignored(project.ID, sourceRepoWebhookTagLabel)
+	}
+	if !isNewPush {
+		return ignored(project.ID, sourceRepoWebhookCommitIgnoredLabel)
 	}
 
-	op, err := a.enqueueOp(ctx, OpCI, project.ID, project.Spec, emptyOpRunOptions())
+	// CI rewrites the project with the spec read here; a concurrent update
+	// must not be reverted by it.
+	opts := emptyOpRunOptions().withProjectRevision(project.Revision)
+	opts.ci = &CIBuild{Ref: push.ref.String(), Commit: commit, Release: "", ReleaseError: "", Preview: "", Environment: ""}
+	op, err := a.enqueueOp(ctx, OpCI, project.ID, project.Spec, opts)
 	if err != nil {
-		rollbackErr := a.rollbackSourceCommitPendingEnqueue(project.ID, evt.Commit)
+		rollbackErr := a.forgetSourcePush(project.ID, push, commit)
 		if rollbackErr != nil {
 			return sourceRepoWebhookResult{}, errors.Join(err, rollbackErr)
 		}
 		return sourceRepoWebhookResult{}, err
 	}
-	confirmErr := a.confirmSourceCommitPendingOp(project.ID, evt.Commit, op.ID)
-	if confirmErr != nil {
+	queued = &op
+	if push.tag {
+		go a.releaseAfterTagBuild(context.WithoutCancel(ctx), op)
+	} else if confirmErr := a.confirmSourceCommitPendingOp(project.ID, commit, op.ID); confirmErr != nil {
 		appLoggerForProcess().Source("api").Warnf(
 			"project=%s op=%s commit=%s persist ci pending state: %v",
 			project.ID,
 			op.ID,
-			shortID(strings.TrimSpace(evt.Commit)),
+			shortID(commit),
 			confirmErr,
 		)
 	}
@@ -160,11 +182,68 @@ func (a *API) triggerSourceRepoCI(
 		reason:   "",
 		project:  project.ID,
 		op:       &op,
-		commit:   strings.TrimSpace(evt.Commit),
+		commit:   commit,
 		trigger:  trigger,
 	}, nil
 }
 
+// sourcePushChangesPaths applies ci.paths to a branch push. A push that
+// cannot be checked builds, so a broken filter never hides a change.
+func (a *API) sourcePushChangesPaths(ctx context.Context, projectID, commit string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	state, err := readSourceRepoCICommitState(a.artifacts, projectID)
+	if err == nil {
+		var changed bool
+		changed, err = ciPushChangesPaths(ctx, sourceRepoDir(a.artifacts, projectID), commit,
+			state.LastSuccessfulCommit, paths)
+		if err == nil {
+			return changed
+		}
+	}
+	appLoggerForProcess().Source("api").Warnf(
+		"project=%s commit=%s check ci.paths, building anyway: %v", projectID, shortID(commit), err,
+	)
+	return true
+}
+
+// markSourcePushSeen records a push about to be queued: a branch commit as
+// pending, and a tag with the commit it points at.
+func (a *API) markSourcePushSeen(projectID string, push ciPush, commit string) (bool, error) {
+	if !push.tag {
+		return a.markSourceCommitSeen(projectID, commit)
+	}
+	if commit == "" {
+		return true, nil
+	}
+	state, err := readSourceRepoCICommitState(a.artifacts, projectID)
+	if err != nil {
+		return false, err
+	}
+	if state.Tags[push.name] == commit {
+		return false, nil
+	}
+	if state.Tags == nil {
+		state.Tags = map[string]string{}
+	}
+	state.Tags[push.name] = commit
+	return true, writeSourceRepoCICommitState(a.artifacts, projectID, state)
+}
+
+// forgetSourcePush undoes markSourcePushSeen for a push that was not queued.
+func (a *API) forgetSourcePush(projectID string, push ciPush, commit string) error {
+	if !push.tag {
+		return a.rollbackSourceCommitPendingEnqueue(projectID, commit)
+	}
+	state, err := readSourceRepoCICommitState(a.artifacts, projectID)
+	if err != nil || commit == "" || state.Tags[push.name] != commit {
+		return err
+	}
+	delete(state.Tags, push.name)
+	return writeSourceRepoCICommitState(a.artifacts, projectID, state)
+}
+
 func (a *API) markSourceCommitSeen(projectID, commit string) (bool, error) {
 	commit = strings.TrimSpace(commit)
 	if commit == "" {
@@ -312,6 +391,7 @@ func readSourceRepoCICommitLegacyState(
 		LastSuccessfulCommit: strings.TrimSpace(string(data)),
 		PendingEnqueueCommit: "",
 		PendingByOpID:        nil,
+		Tags:                 nil,
 	}, nil
 }
 
@@ -1,14 +1,16 @@
 package platform
 
 import (
+	"context"
 	"errors"
-	"io/fs"
+	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"sync"
 
 	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -18,22 +20,76 @@ import (
 type ArtifactStore interface {
 	ProjectDir(projectID string) string
 	EnsureProjectDir(projectID string) (string, error)
-	WriteFile(projectID, relPath string, data []byte) (string, error) // returns relative path
-	ListFiles(projectID string) ([]string, error)                     // returns relative paths
+	WriteFile(projectID, relPath string, data []byte) (string, error)  // returns relative path
+	AppendFile(projectID, relPath string, data []byte) (string, error) // creates the file if missing
+	ListFiles(projectID string) ([]string, error)                      // returns relative paths
+	StatFiles(projectID string) ([]ArtifactFileInfo, error)            // ListFiles with size and mtime
+	StatFile(projectID, relPath string) (ArtifactFileInfo, error)      // one file, read from disk
 	ReadFile(projectID, relPath string) ([]byte, error)
+	RemoveFiles(projectID, prefix string) ([]string, error) // returns removed relative paths
 	RemoveProject(projectID string) error
 }
 
+// FSArtifacts keeps each project's tree under root, unless the project is
+// placed on one of the named alternate roots (see artifacts_residency.go).
+// Projects of an organization sit one level down, under orgs/<org>/.
 type FSArtifacts struct {
-	root string
+	root       string
+	mu         sync.RWMutex
+	roots      map[string]string // alternate root name -> directory
+	placements map[string]string // project ID -> alternate root name
+	// projectOrg resolves the org whose directory holds a project's tree;
+	// nil keeps every tree at the top of its root.
+	projectOrg func(ctx context.Context, projectID string) (string, error)
+	index      *artifactIndex
 }
 
 func NewFSArtifacts(root string) *FSArtifacts {
-	return &FSArtifacts{root: root}
+	return &FSArtifacts{
+		root:       root,
+		mu:         sync.RWMutex{},
+		roots:      map[string]string{},
+		placements: map[string]string{},
+		projectOrg: nil,
+		index:      newArtifactIndex(),
+	}
 }
 
 func (a *FSArtifacts) ProjectDir(projectID string) string {
-	return filepath.Join(a.root, projectID)
+	root := a.projectRoot(projectID)
+	return filepath.Join(root, a.projectSubdir(root, projectID))
+}
+
+// projectSubdir is the project's directory relative to root:
+// orgs/<org>/<id> for a project of an organization, else <id>. The org comes
+// from the store, so a project another replica created resolves the same
+// way here. A project the store no longer has keeps <id>; when the store
+// cannot be read, a tree already under some org's directory is used.
+func (a *FSArtifacts) projectSubdir(root, projectID string) string {
+	if a.projectOrg == nil {
+		return projectID
+	}
+	org, err := a.projectOrg(context.Background(), projectID)
+	if err != nil {
+		if !errors.Is(err, jetstream.ErrKeyNotFound) {
+			appLoggerForProcess().Source("artifacts").Warnf("project=%s resolve org: %v", projectID, err)
+			org = existingArtifactOrg(root, projectID)
+		}
+	}
+	if org != "" {
+		return filepath.Join(artifactOrgsDir, org, projectID)
+	}
+	return projectID
+}
+
+// existingArtifactOrg returns the org whose directory under root already
+// holds projectID's tree, or "" when none does.
+func existingArtifactOrg(root, projectID string) string {
+	matches, err := filepath.Glob(filepath.Join(root, artifactOrgsDir, "*", projectID))
+	if err != nil || len(matches) != 1 {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(matches[0]))
 }
 
 func (a *FSArtifacts) EnsureProjectDir(projectID string) (string, error) {
@@ -64,57 +120,160 @@ func (a *FSArtifacts) WriteFile(projectID, relPath string, data []byte) (string,
 	if writeErr != nil {
 		return "", writeErr
 	}
+	a.index.recordWrite(projectID, dir, filepath.ToSlash(relPath))
 	return filepath.ToSlash(relPath), nil
 }
 
-func (a *FSArtifacts) ListFiles(projectID string) ([]string, error) {
-	root := a.ProjectDir(projectID)
-	var files []string
-	_, err := os.Stat(root)
+// AppendFile adds data to the end of relPath, creating it if missing. Each
+// call is one write to an O_APPEND file, so appends from several processes
+// interleave whole.
+func (a *FSArtifacts) AppendFile(projectID, relPath string, data []byte) (string, error) {
+	dir, err := a.EnsureProjectDir(projectID)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []string{}, nil
-		}
-		return nil, err
+		return "", err
 	}
-	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, _ error) error {
-		if d == nil {
-			return nil
-		}
-		if d.IsDir() {
-			if d.Name() == ".git" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		rel, relErr := filepath.Rel(root, p)
-		if relErr != nil {
-			return relErr
-		}
-		files = append(files, filepath.ToSlash(rel))
-		return nil
-	})
+	relPath = filepath.Clean(relPath)
+	if strings.HasPrefix(relPath, "..") || filepath.IsAbs(relPath) {
+		return "", errors.New("invalid relPath")
+	}
+	full := filepath.Join(dir, relPath)
+	// #nosec G703 -- relPath is cleaned, validated, and anchored to project dir.
+	if err = os.MkdirAll(filepath.Dir(full), dirModePrivateRead); err != nil {
+		return "", err
+	}
+	// #nosec G304,G703 -- full path is constrained by relPath guards above.
+	f, err := os.OpenFile(full, os.O_WRONLY|os.O_CREATE|os.O_APPEND, fileModePrivate)
+	if err != nil {
+		return "", err
+	}
+	_, writeErr := f.Write(data)
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		return "", writeErr
+	}
+	a.index.recordWrite(projectID, dir, filepath.ToSlash(relPath))
+	return filepath.ToSlash(relPath), nil
+}
+
+func (a *FSArtifacts) ListFiles(projectID string) ([]string, error) {
+	infos, err := a.StatFiles(projectID)
 	if err != nil {
 		return nil, err
 	}
-	sort.Strings(files)
+	files := make([]string, 0, len(infos))
+	for _, info := range infos {
+		files = append(files, info.Path)
+	}
 	return files, nil
 }
 
+// StatFiles lists the project's files, sorted by path, from the artifact
+// index (see artifacts_index.go). Like ListFiles it skips .git directories.
+func (a *FSArtifacts) StatFiles(projectID string) ([]ArtifactFileInfo, error) {
+	return a.index.list(projectID, a.ProjectDir(projectID))
+}
+
+// StatFile reports one file's size and mtime from disk rather than the
+// index, so a file rewritten in place outside the store is seen as it is.
+func (a *FSArtifacts) StatFile(projectID, relPath string) (ArtifactFileInfo, error) {
+	full, err := a.filePath(projectID, relPath)
+	if err != nil {
+		return ArtifactFileInfo{}, err
+	}
+	// #nosec G703 -- full path is constrained by filePath.
+	info, err := os.Stat(full)
+	if err != nil {
+		return ArtifactFileInfo{}, err
+	}
+	if info.IsDir() {
+		return ArtifactFileInfo{}, fmt.Errorf("%s is a directory: %w", relPath, os.ErrNotExist)
+	}
+	return ArtifactFileInfo{
+		Path:    filepath.ToSlash(filepath.Clean(relPath)),
+		Size:    info.Size(),
+		ModTime: info.ModTime().UTC(),
+	}, nil
+}
+
 func (a *FSArtifacts) ReadFile(projectID, relPath string) ([]byte, error) {
+	full, err := a.filePath(projectID, relPath)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G703 -- full path is constrained by filePath.
+	return os.ReadFile(full)
+}
+
+// filePath resolves relPath inside the project's tree, refusing paths that
+// would leave it.
+func (a *FSArtifacts) filePath(projectID, relPath string) (string, error) {
 	dir := a.ProjectDir(projectID)
 	relPath = filepath.Clean(relPath)
 	if strings.HasPrefix(relPath, "..") || filepath.IsAbs(relPath) {
-		return nil, errors.New("invalid relPath")
+		return "", errors.New("invalid relPath")
 	}
 	full, err := securejoin.SecureJoin(dir, relPath)
 	if err != nil {
-		return nil, errors.New("invalid relPath")
+		return "", errors.New("invalid relPath")
 	}
-	// #nosec G703 -- full path is constrained by relPath guards and securejoin above.
-	return os.ReadFile(full)
+	return full, nil
+}
+
+// RemoveFiles deletes the file at prefix, or every file below it, and then
+// any directories the removal left empty. Git metadata is never matched
+// because ListFiles skips .git directories.
+func (a *FSArtifacts) RemoveFiles(projectID, prefix string) ([]string, error) {
+	dir := a.ProjectDir(projectID)
+	prefix = filepath.ToSlash(filepath.Clean(prefix))
+	if prefix == "." || strings.HasPrefix(prefix, "..") || filepath.IsAbs(prefix) {
+		return nil, errors.New("invalid prefix")
+	}
+	files, err := a.ListFiles(projectID)
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, rel := range files {
+		if rel != prefix && !strings.HasPrefix(rel, prefix+"/") {
+			continue
+		}
+		full, joinErr := securejoin.SecureJoin(dir, rel)
+		if joinErr != nil {
+			return removed, errors.New("invalid relPath")
+		}
+		// #nosec G703 -- full path is constrained by securejoin above.
+		if rmErr := os.Remove(full); rmErr != nil && !os.IsNotExist(rmErr) {
+			return removed, rmErr
+		}
+		removed = append(removed, rel)
+	}
+	a.index.recordRemoval(projectID, dir, removed)
+	if root, joinErr := securejoin.SecureJoin(dir, prefix); joinErr == nil {
+		removeEmptyDirs(root)
+	}
+	return removed, nil
 }
 
 func (a *FSArtifacts) RemoveProject(projectID string) error {
-	return os.RemoveAll(a.ProjectDir(projectID))
+	a.index.drop(projectID)
+	if err := os.RemoveAll(a.ProjectDir(projectID)); err != nil {
+		return err
+	}
+	return a.placeProject(projectID, defaultArtifactRootName)
+}
+
+// removeEmptyDirs drops root and its subdirectories when they hold no files.
+func removeEmptyDirs(root string) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			removeEmptyDirs(filepath.Join(root, entry.Name()))
+		}
+	}
+	_ = os.Remove(root) // fails, as intended, while anything is left inside
 }
@@ -46,3 +46,34 @@ func TestStore_FSArtifactsListFilesSkipsGitDirectories(t *testing.T) {
 		t.Fatalf("unexpected file list: %#v", files)
 	}
 }
+
+func TestStore_FSArtifactsRemoveFilesScopedToPrefix(t *testing.T) {
+	artifacts := platform.NewFSArtifacts(t.TempDir())
+	projectID := "p1"
+	for _, rel := range []string{"build/image.txt", "build/logs/build.log", "buildkit.txt", "repos/source/main.go"} {
+		if _, err := artifacts.WriteFile(projectID, rel, []byte("x\n")); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	removed, err := artifacts.RemoveFiles(projectID, "build/")
+	if err != nil {
+		t.Fatalf("remove files: %v", err)
+	}
+	if len(removed) != 2 || removed[0] != "build/image.txt" || removed[1] != "build/logs/build.log" {
+		t.Fatalf("unexpected removed list: %#v", removed)
+	}
+	files, err := artifacts.ListFiles(projectID)
+	if err != nil {
+		t.Fatalf("list files: %v", err)
+	}
+	if len(files) != 2 || files[0] != "buildkit.txt" || files[1] != "repos/source/main.go" {
+		t.Fatalf("expected unrelated files to survive, got %#v", files)
+	}
+	if _, statErr := os.Stat(filepath.Join(artifacts.ProjectDir(projectID), "build")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected emptied build directory to be removed, stat err=%v", statErr)
+	}
+	if _, err = artifacts.RemoveFiles(projectID, "../other"); err == nil {
+		t.Fatal("expected traversal prefix to be rejected")
+	}
+}
@@ -0,0 +1,259 @@
+package platform
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Artifact index: listings come from an in-memory index per project instead of
+// a walk of the whole tree. WriteFile, RemoveFiles, and RemoveProject update it
+// as they go. Git, kustomize, and the bootstrap helpers write into project
+// trees directly, so each listing also stats every indexed directory and
+// re-reads only those whose mtime moved.
+////////////////////////////////////////////////////////////////////////////////
+
+// artifactIndexRacyWindow is how close to a directory's mtime it must have been
+// read for the read to be trusted. A change landing in the same mtime tick as
+// the read would otherwise go unseen.
+const artifactIndexRacyWindow = time.Second
+
+// ArtifactFileInfo is one artifact file with its size and modification time.
+// A file rewritten in place outside the artifact store keeps its old metadata
+// until the store writes it again or its directory changes.
+type ArtifactFileInfo struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+type artifactIndex struct {
+	mu       sync.Mutex
+	projects map[string]*projectArtifactIndex
+}
+
+// projectArtifactIndex holds one project's files and, for every directory
+// (keyed by relative path, "." for the root), the mtime it had when its
+// entries were last read.
+type projectArtifactIndex struct {
+	mu    sync.Mutex
+	root  string
+	files map[string]ArtifactFileInfo
+	dirs  map[string]dirReadMark
+}
+
+type dirReadMark struct {
+	modTime time.Time
+	readAt  time.Time
+}
+
+func newArtifactIndex() *artifactIndex {
+	return &artifactIndex{mu: sync.Mutex{}, projects: map[string]*projectArtifactIndex{}}
+}
+
+func newProjectArtifactIndex(root string) *projectArtifactIndex {
+	return &projectArtifactIndex{
+		mu:    sync.Mutex{},
+		root:  root,
+		files: map[string]ArtifactFileInfo{},
+		dirs:  map[string]dirReadMark{},
+	}
+}
+
+// project returns the index for projectID rooted at root. A project moved to
+// another artifact root starts over.
+func (x *artifactIndex) project(projectID, root string) *projectArtifactIndex {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	idx, ok := x.projects[projectID]
+	if !ok || idx.root != root {
+		idx = newProjectArtifactIndex(root)
+		x.projects[projectID] = idx
+	}
+	return idx
+}
+
+// loaded returns the index for projectID only if one was already built.
+func (x *artifactIndex) loaded(projectID, root string) *projectArtifactIndex {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	idx, ok := x.projects[projectID]
+	if !ok || idx.root != root {
+		return nil
+	}
+	return idx
+}
+
+func (x *artifactIndex) drop(projectID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.projects, projectID)
+}
+
+// list brings the index up to date with the tree and returns its files,
+// sorted by path. Concurrent listings of one project share a single refresh.
+func (x *artifactIndex) list(projectID, root string) ([]ArtifactFileInfo, error) {
+	if _, err := os.Stat(root); err != nil {
+		x.drop(projectID)
+		if os.IsNotExist(err) {
+			return []ArtifactFileInfo{}, nil
+		}
+		return nil, err
+	}
+	idx := x.project(projectID, root)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if err := idx.refresh(); err != nil {
+		x.drop(projectID)
+		return nil, err
+	}
+	out := make([]ArtifactFileInfo, 0, len(idx.files))
+	for _, info := range idx.files {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// recordWrite notes a file the store wrote, if the project is indexed. The
+// directory is re-read anyway when a new file changes its mtime; this keeps
+// the size and time of files overwritten in place current.
+func (x *artifactIndex) recordWrite(projectID, root, rel string) {
+	idx := x.loaded(projectID, root)
+	if idx == nil {
+		return
+	}
+	info, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if err != nil {
+		delete(idx.files, rel)
+		return
+	}
+	idx.files[rel] = ArtifactFileInfo{Path: rel, Size: info.Size(), ModTime: info.ModTime().UTC()}
+}
+
+// recordRemoval forgets files the store removed, if the project is indexed.
+func (x *artifactIndex) recordRemoval(projectID, root string, removed []string) {
+	idx := x.loaded(projectID, root)
+	if idx == nil {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, rel := range removed {
+		delete(idx.files, rel)
+	}
+}
+
+// refresh re-reads each directory whose mtime changed since it was read (all
+// of them on first use), along with any directories that appeared under it.
+func (idx *projectArtifactIndex) refresh() error {
+	if len(idx.dirs) == 0 {
+		return idx.readDir(".")
+	}
+	stale := []string{}
+	for dir, mark := range idx.dirs {
+		info, err := os.Stat(filepath.Join(idx.root, filepath.FromSlash(dir)))
+		racy := !mark.readAt.After(mark.modTime.Add(artifactIndexRacyWindow))
+		if err != nil || racy || !info.ModTime().Equal(mark.modTime) {
+			stale = append(stale, dir)
+		}
+	}
+	// Parents first, so a vanished subtree is dropped before its children
+	// would be looked at.
+	sort.Strings(stale)
+	for _, dir := range stale {
+		if _, ok := idx.dirs[dir]; !ok {
+			continue
+		}
+		if err := idx.readDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDir replaces dir's direct entries with what is on disk now. New
+// subdirectories are read in full; vanished ones are forgotten with
+// everything below them. The mtime is taken before reading, so a change
+// made during the read leaves the directory stale for next time.
+func (idx *projectArtifactIndex) readDir(dir string) error {
+	full := filepath.Join(idx.root, filepath.FromSlash(dir))
+	info, err := os.Stat(full)
+	if os.IsNotExist(err) {
+		idx.forget(dir)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	mark := dirReadMark{modTime: info.ModTime(), readAt: time.Now()}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	newDirs := []string{}
+	for _, entry := range entries {
+		rel := path.Join(dir, entry.Name())
+		seen[rel] = true
+		switch {
+		case entry.IsDir() && entry.Name() == ".git":
+			// Repo internals are never listed.
+		case entry.IsDir():
+			delete(idx.files, rel)
+			if _, known := idx.dirs[rel]; !known {
+				newDirs = append(newDirs, rel)
+			}
+		default:
+			if _, wasDir := idx.dirs[rel]; wasDir {
+				idx.forget(rel)
+			}
+			if fileInfo, infoErr := entry.Info(); infoErr == nil {
+				idx.files[rel] = ArtifactFileInfo{Path: rel, Size: fileInfo.Size(), ModTime: fileInfo.ModTime().UTC()}
+			}
+		}
+	}
+	for rel := range idx.files {
+		if path.Dir(rel) == dir && !seen[rel] {
+			delete(idx.files, rel)
+		}
+	}
+	for sub := range idx.dirs {
+		if sub != dir && path.Dir(sub) == dir && !seen[sub] {
+			idx.forget(sub)
+		}
+	}
+	idx.dirs[dir] = mark
+	for _, sub := range newDirs {
+		if err = idx.readDir(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forget drops dir and everything indexed below it.
+func (idx *projectArtifactIndex) forget(dir string) {
+	for rel := range idx.files {
+		if isUnderArtifactDir(rel, dir) {
+			delete(idx.files, rel)
+		}
+	}
+	for sub := range idx.dirs {
+		if sub == dir || isUnderArtifactDir(sub, dir) {
+			delete(idx.dirs, sub)
+		}
+	}
+}
+
+func isUnderArtifactDir(rel, dir string) bool {
+	return dir == "." || strings.HasPrefix(rel, dir+"/")
+}
@@ -0,0 +1,93 @@
+//nolint:testpackage,exhaustruct // Index tests compare the unexported index against a fresh walk of the tree.
+package platform
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestArtifactIndex_TracksStoreAndOutOfBandChanges(t *testing.T) {
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-artifact-index"
+	root := artifacts.ProjectDir(projectID)
+
+	assertMatchesWalk := func(step string) []ArtifactFileInfo {
+		t.Helper()
+		entries, err := artifacts.StatFiles(projectID)
+		if err != nil {
+			t.Fatalf("%s: stat files: %v", step, err)
+		}
+		want := []string{}
+		_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() {
+				rel, _ := filepath.Rel(root, p)
+				want = append(want, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		got, _ := artifacts.ListFiles(projectID)
+		if !slices.Equal(got, want) {
+			t.Fatalf("%s: index lists %v, tree holds %v", step, got, want)
+		}
+		return entries
+	}
+
+	if _, err := artifacts.WriteFile(projectID, "build/build.log", []byte("ok\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := artifacts.WriteFile(projectID, "deploy/dev/rendered.yaml", []byte("kind: Service\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	assertMatchesWalk("store writes")
+
+	// Writers that bypass the store: git, kustomize, and the bootstrap helpers.
+	mustWrite := func(rel, body string) {
+		t.Helper()
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0o750); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	mustWrite("repos/manifests/.git/HEAD", "ref: refs/heads/main\n")
+	mustWrite("repos/manifests/overlays/dev/kustomization.yaml", "resources: []\n")
+	mustWrite("build/extra.txt", "x\n")
+	assertMatchesWalk("out-of-band writes")
+
+	if err := os.RemoveAll(filepath.Join(root, "repos", "manifests", "overlays")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	assertMatchesWalk("out-of-band removal")
+
+	if _, err := artifacts.WriteFile(projectID, "build/build.log", []byte("a much longer log\n")); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	entries := assertMatchesWalk("in-place rewrite")
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Path, "repos/manifests/.git/") {
+			t.Fatalf("expected .git to be skipped, got %s", entry.Path)
+		}
+		if entry.Path == "build/build.log" && entry.Size != int64(len("a much longer log\n")) {
+			t.Fatalf("expected the rewritten size, got %+v", entry)
+		}
+	}
+
+	if err := artifacts.RemoveProject(projectID); err != nil {
+		t.Fatalf("remove project: %v", err)
+	}
+	if files, err := artifacts.ListFiles(projectID); err != nil || len(files) != 0 {
+		t.Fatalf("expected no files after removal, got %v (%v)", files, err)
+	}
+}
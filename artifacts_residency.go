@@ -0,0 +1,250 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Artifact residency: PAAS_ARTIFACT_ROOTS names alternate artifact roots
+// (e.g. a large disk), a KV placement pins a project to one of them, and
+// paasadmin move-artifacts moves a project's tree between roots.
+////////////////////////////////////////////////////////////////////////////////
+
+const defaultArtifactRootName = "default"
+
+// parseArtifactRoots reads "name=dir,name=dir". Names are lowercase
+// letters, digits, and dashes; "default" is reserved for the main root.
+func parseArtifactRoots(raw string) (map[string]string, error) {
+	roots := map[string]string{}
+	for pair := range strings.SplitSeq(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, dir, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		dir = strings.TrimSpace(dir)
+		if !ok || name == "" || dir == "" {
+			return nil, fmt.Errorf("%s: %q is not name=dir", artifactRootsEnv, strings.TrimSpace(pair))
+		}
+		if err := validateArtifactRootName(name); err != nil {
+			return nil, fmt.Errorf("%s: %w", artifactRootsEnv, err)
+		}
+		if name == defaultArtifactRootName {
+			return nil, fmt.Errorf("%s: root name %q is reserved for %s", artifactRootsEnv, name, artifactsRootEnv)
+		}
+		if _, dup := roots[name]; dup {
+			return nil, fmt.Errorf("%s: root %q is listed twice", artifactRootsEnv, name)
+		}
+		roots[name] = filepath.Clean(dir)
+	}
+	return roots, nil
+}
+
+func artifactRootsFromEnv() (map[string]string, error) {
+	return parseArtifactRoots(os.Getenv(artifactRootsEnv))
+}
+
+func validateArtifactRootName(name string) error {
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return fmt.Errorf("root name %q may only contain lowercase letters, digits, and dashes", name)
+		}
+	}
+	return nil
+}
+
+func (a *FSArtifacts) setRoots(roots map[string]string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.roots = maps.Clone(roots)
+}
+
+// alternateRoots returns a copy of the named alternate roots.
+func (a *FSArtifacts) alternateRoots() map[string]string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return maps.Clone(a.roots)
+}
+
+// rootDir resolves a root name; "" and "default" mean the main root.
+func (a *FSArtifacts) rootDir(name string) (string, bool) {
+	if name == "" || name == defaultArtifactRootName {
+		return a.root, true
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	dir, ok := a.roots[name]
+	return dir, ok
+}
+
+// placeProject points projectID at a named root; the default root clears
+// the placement. It does not move any files.
+func (a *FSArtifacts) placeProject(projectID, rootName string) error {
+	if _, ok := a.rootDir(rootName); !ok {
+		return fmt.Errorf("artifact root %q is not configured in %s", rootName, artifactRootsEnv)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if rootName == "" || rootName == defaultArtifactRootName {
+		delete(a.placements, projectID)
+		return nil
+	}
+	a.placements[projectID] = rootName
+	return nil
+}
+
+func (a *FSArtifacts) projectRootName(projectID string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if name, ok := a.placements[projectID]; ok {
+		return name
+	}
+	return defaultArtifactRootName
+}
+
+func (a *FSArtifacts) projectRoot(projectID string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if name, ok := a.placements[projectID]; ok {
+		if dir, known := a.roots[name]; known {
+			return dir
+		}
+	}
+	return a.root
+}
+
+// configureArtifactRoots creates the roots named in PAAS_ARTIFACT_ROOTS and
+// applies the stored placements, returning how many projects were placed.
+func configureArtifactRoots(ctx context.Context, store *Store, artifacts *FSArtifacts) (int, error) {
+	roots, err := artifactRootsFromEnv()
+	if err != nil {
+		return 0, err
+	}
+	for name, dir := range roots {
+		if err = os.MkdirAll(dir, dirModePrivateRead); err != nil {
+			return 0, fmt.Errorf("mkdir artifact root %s: %w", name, err)
+		}
+	}
+	artifacts.setRoots(roots)
+	return loadArtifactPlacements(ctx, store, artifacts)
+}
+
+// loadArtifactPlacements applies every stored placement to artifacts. A
+// placement naming a root that is not configured is an error: resolving the
+// project to the main root instead would hide its files and write new ones
+// in the wrong place.
+func loadArtifactPlacements(ctx context.Context, store *Store, artifacts *FSArtifacts) (int, error) {
+	placements, err := store.listArtifactPlacements(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, placement := range placements {
+		if err = artifacts.placeProject(placement.ProjectID, placement.Root); err != nil {
+			return 0, fmt.Errorf("project %s: %w", placement.ProjectID, err)
+		}
+	}
+	return len(placements), nil
+}
+
+// moveArtifactTree moves the directory from to to, which must not exist
+// yet, and returns how many files moved. A rename is tried first; across
+// filesystems the tree is copied and the source removed only after the
+// copy succeeded. A missing source moves nothing.
+func moveArtifactTree(from, to string) (int, error) {
+	files, err := countArtifactFiles(from)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if _, statErr := os.Lstat(to); statErr == nil {
+		return 0, fmt.Errorf("destination %s already exists", to)
+	}
+	if err = os.MkdirAll(filepath.Dir(to), dirModePrivateRead); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(from, to); err == nil {
+		return files, nil
+	}
+	if err = copyArtifactTree(from, to); err != nil {
+		_ = os.RemoveAll(to)
+		return 0, fmt.Errorf("copy %s to %s: %w", from, to, err)
+	}
+	return files, os.RemoveAll(from)
+}
+
+func countArtifactFiles(dir string) (int, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, err
+	}
+	count := 0
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !d.IsDir() {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// copyArtifactTree copies directories, regular files, and symlinks (git
+// repos may hold any of them), keeping permission bits.
+func copyArtifactTree(from, to string) error {
+	return filepath.WalkDir(from, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(from, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, linkErr := os.Readlink(p)
+			if linkErr != nil {
+				return linkErr
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyArtifactFile(p, target, info.Mode().Perm())
+		default:
+			return fmt.Errorf("%s: unsupported file type %s", p, info.Mode().Type())
+		}
+	})
+}
+
+func copyArtifactFile(from, to string, perm fs.FileMode) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,132 @@
+//nolint:testpackage // Residency tests exercise unexported root resolution.
+package platform
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestParseArtifactRoots(t *testing.T) {
+	roots, err := parseArtifactRoots(" bigdata=/mnt/big/ , eu-1=/srv/eu ,")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(roots) != 2 || roots["bigdata"] != "/mnt/big" || roots["eu-1"] != "/srv/eu" {
+		t.Fatalf("unexpected roots: %#v", roots)
+	}
+
+	for raw, want := range map[string]string{
+		"bigdata":                      "not name=dir",
+		"Big=/mnt":                     "lowercase",
+		"default=/mnt":                 "reserved",
+		"a=/one,a=/two":                "listed twice",
+		"bigdata=":                     "not name=dir",
+		"ok=/mnt,../escape=/elsewhere": "lowercase",
+	} {
+		if _, parseErr := parseArtifactRoots(raw); parseErr == nil || !strings.Contains(parseErr.Error(), want) {
+			t.Fatalf("parse %q: expected error containing %q, got %v", raw, want, parseErr)
+		}
+	}
+}
+
+func TestFSArtifacts_PlacedProjectResolvesToItsRoot(t *testing.T) {
+	mainRoot := t.TempDir()
+	bigRoot := t.TempDir()
+	artifacts := NewFSArtifacts(mainRoot)
+	artifacts.setRoots(map[string]string{"bigdata": bigRoot})
+
+	if err := artifacts.placeProject("p1", "missing"); err == nil {
+		t.Fatal("expected an unknown root to be rejected")
+	}
+	if err := artifacts.placeProject("p1", "bigdata"); err != nil {
+		t.Fatalf("place project: %v", err)
+	}
+	if _, err := artifacts.WriteFile("p1", "build/image.txt", []byte("img\n")); err != nil {
+		t.Fatalf("write placed file: %v", err)
+	}
+	if _, err := artifacts.WriteFile("p2", "build/image.txt", []byte("img\n")); err != nil {
+		t.Fatalf("write unplaced file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(bigRoot, "p1", "build", "image.txt")); err != nil {
+		t.Fatalf("expected p1 under the bigdata root: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(mainRoot, "p2", "build", "image.txt")); err != nil {
+		t.Fatalf("expected p2 under the main root: %v", err)
+	}
+
+	if err := artifacts.RemoveProject("p1"); err != nil {
+		t.Fatalf("remove project: %v", err)
+	}
+	if got := artifacts.projectRootName("p1"); got != defaultArtifactRootName {
+		t.Fatalf("expected removal to clear the placement, got %q", got)
+	}
+}
+
+func TestMoveArtifactTree_RefusesExistingDestination(t *testing.T) {
+	from := filepath.Join(t.TempDir(), "p1")
+	to := filepath.Join(t.TempDir(), "p1")
+	if err := os.MkdirAll(filepath.Join(from, "build"), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(from, "build", "image.txt"), []byte("img\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.MkdirAll(to, 0o750); err != nil {
+		t.Fatalf("mkdir destination: %v", err)
+	}
+	if _, err := moveArtifactTree(from, to); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected existing destination to be refused, got %v", err)
+	}
+	if err := os.Remove(to); err != nil {
+		t.Fatalf("remove destination: %v", err)
+	}
+
+	moved, err := moveArtifactTree(from, to)
+	if err != nil || moved != 1 {
+		t.Fatalf("move: moved=%d err=%v", moved, err)
+	}
+	if _, statErr := os.Stat(filepath.Join(to, "build", "image.txt")); statErr != nil {
+		t.Fatalf("expected file at destination: %v", statErr)
+	}
+	if _, statErr := os.Stat(from); !os.IsNotExist(statErr) {
+		t.Fatalf("expected source to be gone, got %v", statErr)
+	}
+}
+
+func TestFSArtifacts_ProjectDirResolvesTheOrgThroughTheStore(t *testing.T) {
+	root := t.TempDir()
+	artifacts := NewFSArtifacts(root)
+	var resolveErr error
+	artifacts.projectOrg = func(_ context.Context, projectID string) (string, error) {
+		if resolveErr != nil {
+			return "", resolveErr
+		}
+		if projectID == "p1" {
+			return "acme", nil
+		}
+		return "", jetstream.ErrKeyNotFound
+	}
+
+	inOrg := filepath.Join(root, artifactOrgsDir, "acme", "p1")
+	if got := artifacts.ProjectDir("p1"); got != inOrg {
+		t.Fatalf("expected %s, got %s", inOrg, got)
+	}
+	if got := artifacts.ProjectDir("gone"); got != filepath.Join(root, "gone") {
+		t.Fatalf("expected a project the store lacks at the top of the root, got %s", got)
+	}
+
+	// While the store cannot be read, a tree already under an org stays put.
+	if _, err := artifacts.EnsureProjectDir("p1"); err != nil {
+		t.Fatalf("ensure project dir: %v", err)
+	}
+	resolveErr = errors.New("nats: timeout")
+	if got := artifacts.ProjectDir("p1"); got != inOrg {
+		t.Fatalf("expected the existing org tree while the store is down, got %s", got)
+	}
+}
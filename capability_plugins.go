@@ -0,0 +1,681 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Capability plugins: every name a spec lists under capabilities is a plugin
+// from capabilityPlugins. A plugin checks its options (capabilityOptions.<name>
+// in the spec) and, for each environment, may add a manifest to the overlay,
+// such as a database StatefulSet, and env vars wiring the app to it. A
+// capability bound in an environment (store_bindings.go) is provided by the
+// binding there, so the plugin adds nothing to that environment.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	capabilityHTTP          = "http"
+	capabilityMetrics       = "metrics"
+	capabilityPostgres      = "postgres"
+	capabilityRedis         = "redis"
+	capabilityCron          = "cron"
+	capabilityObjectStorage = "object-storage"
+
+	// capabilityLabel marks every resource a plugin renders, so the app's
+	// own Deployment and Service can be told apart from them.
+	capabilityLabel            = "platform.example.com/capability"
+	capabilityManifestPrefix   = "capability-"
+	maxCapabilityOptionLength  = 256
+	defaultCapabilityStorage   = "1Gi"
+	defaultMetricsPort         = "9090"
+	defaultMetricsPath         = "/metrics"
+	postgresPort               = 5432
+	postgresUser               = "app"
+	redisPort                  = 6379
+	objectStoragePort          = 9000
+	objectStorageDevCredential = "minioadmin" // MinIO's own default root user and password
+)
+
+var (
+	imageTagRe      = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+	databaseNameRe  = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+	bucketNameRe    = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	metricsPathRe   = regexp.MustCompile(`^/[A-Za-z0-9._~/-]*$`)
+	errNoCapability = errors.New("capability has no plugin")
+)
+
+// CapabilityInfo describes one capability plugin for GET /api/capabilities.
+type CapabilityInfo struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Options     []CapabilityOption `json:"options,omitempty"`
+	Resources   []string           `json:"resources,omitempty"` // kinds added to each overlay
+	Env         []string           `json:"env,omitempty"`       // app env vars set
+}
+
+// CapabilityOption is one key a plugin accepts under capabilityOptions.
+// An empty Default with Required unset means the plugin derives the value
+// from the project.
+type CapabilityOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// capabilityPlugin is the behavior behind one capability name. validate
+// sees only the options the spec sets; render sees them with defaults
+// filled in.
+type capabilityPlugin interface {
+	info() CapabilityInfo
+	validate(options map[string]string) error
+	render(c capabilityContext) capabilityOutput
+}
+
+// capabilityContext is what a plugin renders from: the project, the
+// environment, and the name its resources take, <app>-<capability>.
+type capabilityContext struct {
+	spec    ProjectSpec
+	env     string
+	name    string
+	options map[string]string
+}
+
+// capabilityOutput is what a plugin adds to one environment. An empty
+// manifest adds no overlay file.
+type capabilityOutput struct {
+	capability string
+	manifest   string
+	env        map[string]string
+}
+
+func capabilityPlugins() map[string]capabilityPlugin {
+	return map[string]capabilityPlugin{
+		capabilityHTTP:          httpCapability{},
+		capabilityMetrics:       metricsCapability{},
+		capabilityPostgres:      postgresCapability{},
+		capabilityRedis:         redisCapability{},
+		capabilityCron:          cronCapability{},
+		capabilityObjectStorage: objectStorageCapability{},
+	}
+}
+
+func lookupCapabilityPlugin(name string) (capabilityPlugin, error) {
+	plugin, ok := capabilityPlugins()[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errNoCapability, name)
+	}
+	return plugin, nil
+}
+
+func listCapabilityPlugins() []CapabilityInfo {
+	plugins := capabilityPlugins()
+	out := make([]CapabilityInfo, 0, len(plugins))
+	for _, name := range sortedKeys(plugins) {
+		out = append(out, plugins[name].info())
+	}
+	return out
+}
+
+func (a *API) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, listCapabilityPlugins())
+}
+
+// validateCapabilities checks that every capability has a plugin and that
+// capabilityOptions only configures declared capabilities, with keys and
+// values their plugins accept.
+func validateCapabilities(spec ProjectSpec) error {
+	for i, capability := range spec.Capabilities {
+		field := fmt.Sprintf("capabilities[%d]", i)
+		if len(capability) > 64 || !capabilityRe.MatchString(capability) {
+			return specFieldErrorf(field, "invalid capability %q", capability)
+		}
+		plugin, err := lookupCapabilityPlugin(capability)
+		if err != nil {
+			return specFieldErrorf(field, "unknown capability %q; available: %s",
+				capability, strings.Join(sortedKeys(capabilityPlugins()), ", "))
+		}
+		err = validateCapabilityOptions("capabilityOptions."+capability, plugin, spec.CapabilityOptions[capability])
+		if err != nil {
+			return err
+		}
+	}
+	for _, capability := range sortedKeys(spec.CapabilityOptions) {
+		if !slices.Contains(spec.Capabilities, capability) {
+			return specFieldErrorf("capabilityOptions."+capability,
+				"capability %q is not declared in capabilities", capability)
+		}
+	}
+	return nil
+}
+
+func validateCapabilityOptions(field string, plugin capabilityPlugin, options map[string]string) error {
+	accepted := plugin.info().Options
+	names := make([]string, 0, len(accepted))
+	for _, option := range accepted {
+		names = append(names, option.Name)
+	}
+	for _, key := range sortedKeys(options) {
+		if !slices.Contains(names, key) {
+			if len(names) == 0 {
+				return specFieldErrorf(field+"."+key, "capability %q takes no options", plugin.info().Name)
+			}
+			return specFieldErrorf(field+"."+key, "unknown option %q; accepted: %s", key, strings.Join(names, ", "))
+		}
+		if len(options[key]) > maxCapabilityOptionLength {
+			return specFieldErrorf(field+"."+key, "%s must be at most %d characters", key, maxCapabilityOptionLength)
+		}
+	}
+	for _, option := range accepted {
+		if option.Required && options[option.Name] == "" {
+			return specFieldErrorf(field+"."+option.Name, "%s is required", option.Name)
+		}
+	}
+	if err := plugin.validate(options); err != nil {
+		var fieldErr specFieldError
+		if errors.As(err, &fieldErr) {
+			return specFieldError{Field: field + "." + fieldErr.Field, Message: fieldErr.Message}
+		}
+		return specFieldError{Field: field, Message: err.Error()}
+	}
+	return nil
+}
+
+// renderCapabilities runs the plugin of each capability spec declares for
+// env, in declared order, skipping the capabilities bindings provide.
+func renderCapabilities(spec ProjectSpec, env string, bindings []CapabilityBinding) []capabilityOutput {
+	spec = normalizeProjectSpec(spec)
+	out := []capabilityOutput{}
+	for _, capability := range spec.Capabilities {
+		if slices.ContainsFunc(bindings, func(b CapabilityBinding) bool { return b.Capability == capability }) {
+			continue
+		}
+		plugin, err := lookupCapabilityPlugin(capability)
+		if err != nil {
+			continue // stored before plugins existed; validation refuses it on the next write
+		}
+		rendered := plugin.render(capabilityContext{
+			spec:    spec,
+			env:     env,
+			name:    safeName(spec.Name) + "-" + safeName(capability),
+			options: capabilityOptionValues(spec, capability, plugin),
+		})
+		rendered.capability = capability
+		out = append(out, rendered)
+	}
+	return out
+}
+
+// capabilityOptionValues lays the options spec sets for capability over the
+// plugin's defaults.
+func capabilityOptionValues(spec ProjectSpec, capability string, plugin capabilityPlugin) map[string]string {
+	options := map[string]string{}
+	for _, option := range plugin.info().Options {
+		if option.Default != "" {
+			options[option.Name] = option.Default
+		}
+	}
+	for key, value := range spec.CapabilityOptions[capability] {
+		options[key] = value
+	}
+	return options
+}
+
+// capabilityAppEnv lays vars over the env vars the unbound capabilities
+// set, so the spec can override what a plugin wires in.
+func capabilityAppEnv(
+	spec ProjectSpec,
+	env string,
+	vars map[string]string,
+	bindings []CapabilityBinding,
+) map[string]string {
+	out := map[string]string{}
+	for _, rendered := range renderCapabilities(spec, env, bindings) {
+		for key, value := range rendered.env {
+			out[key] = value
+		}
+	}
+	for key, value := range vars {
+		out[key] = value
+	}
+	return out
+}
+
+func capabilityManifestFile(capability string) string {
+	return capabilityManifestPrefix + safeName(capability) + ".yaml"
+}
+
+// hasCapabilityResources reports whether a plugin in spec renders pods the
+// app talks to in its own namespace.
+func hasCapabilityResources(spec ProjectSpec, env string) bool {
+	return slices.ContainsFunc(renderCapabilities(spec, env, nil), func(rendered capabilityOutput) bool {
+		return rendered.manifest != ""
+	})
+}
+
+// writeOverlayCapabilities writes capability-<name>.yaml into each overlay
+// for every plugin that renders a manifest there, and removes the files of
+// the others.
+func writeOverlayCapabilities(
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	envs []string,
+	bindings envCapabilityBindings,
+) ([]string, error) {
+	written := []string{}
+	for _, env := range envs {
+		manifests := map[string]string{}
+		for _, rendered := range renderCapabilities(spec, env, bindings[env]) {
+			if rendered.manifest != "" {
+				manifests[capabilityManifestFile(rendered.capability)] = rendered.manifest
+			}
+		}
+		for _, capability := range sortedKeys(capabilityPlugins()) {
+			file := capabilityManifestFile(capability)
+			rel := path.Join(manifestsRepoOverlaysDir, env, file)
+			manifest, ok := manifests[file]
+			if !ok {
+				if _, err := artifacts.RemoveFiles(projectID, rel); err != nil {
+					return written, err
+				}
+				continue
+			}
+			artifactPath, err := artifacts.WriteFile(projectID, rel, []byte(manifest))
+			if err != nil {
+				return written, err
+			}
+			written = append(written, artifactPath)
+		}
+	}
+	return written, nil
+}
+
+// manifestCapability returns the capability label of a rendered manifest,
+// or "" for the app's own resources.
+func manifestCapability(manifest string) string {
+	for line := range strings.SplitSeq(manifest, "\n") {
+		if capability, ok := strings.CutPrefix(strings.TrimSpace(line), capabilityLabel+":"); ok {
+			return strings.TrimSpace(capability)
+		}
+	}
+	return ""
+}
+
+func writeCapabilityMetadata(b *strings.Builder, c capabilityContext, capability string) {
+	b.WriteString("metadata:\n")
+	fmt.Fprintf(b, "  name: %s\n", c.name)
+	b.WriteString("  labels:\n")
+	fmt.Fprintf(b, "    %s: %s\n", capabilityLabel, capability)
+}
+
+// capabilityStore is a single-replica StatefulSet with one claim, fronted
+// by a Service of the same name.
+type capabilityStore struct {
+	capability string
+	image      string
+	port       int
+	args       []string
+	env        map[string]string
+	mountPath  string
+	storage    string
+}
+
+func renderCapabilityStore(c capabilityContext, store capabilityStore) string {
+	var b strings.Builder
+	b.WriteString("apiVersion: v1\nkind: Service\n")
+	writeCapabilityMetadata(&b, c, store.capability)
+	b.WriteString("spec:\n  selector:\n")
+	fmt.Fprintf(&b, "    app: %s\n", c.name)
+	b.WriteString("  ports:\n")
+	fmt.Fprintf(&b, "  - name: %s\n    port: %d\n    targetPort: %d\n", safeName(store.capability), store.port, store.port)
+	b.WriteString("---\napiVersion: apps/v1\nkind: StatefulSet\n")
+	writeCapabilityMetadata(&b, c, store.capability)
+	b.WriteString("spec:\n")
+	fmt.Fprintf(&b, "  serviceName: %s\n", c.name)
+	b.WriteString("  replicas: 1\n  selector:\n    matchLabels:\n")
+	fmt.Fprintf(&b, "      app: %s\n", c.name)
+	b.WriteString("  template:\n    metadata:\n      labels:\n")
+	fmt.Fprintf(&b, "        app: %s\n", c.name)
+	fmt.Fprintf(&b, "        %s: %s\n", capabilityLabel, store.capability)
+	b.WriteString("    spec:\n      containers:\n")
+	fmt.Fprintf(&b, "      - name: %s\n", safeName(store.capability))
+	fmt.Fprintf(&b, "        image: %s\n", store.image)
+	if len(store.args) > 0 {
+		b.WriteString("        args:\n")
+		for _, arg := range store.args {
+			fmt.Fprintf(&b, "        - %s\n", yamlQuoted(arg))
+		}
+	}
+	fmt.Fprintf(&b, "        ports:\n        - containerPort: %d\n", store.port)
+	if len(store.env) > 0 {
+		b.WriteString("        env:\n")
+		for _, key := range sortedKeys(store.env) {
+			fmt.Fprintf(&b, "        - name: %s\n          value: %s\n", key, yamlQuoted(store.env[key]))
+		}
+	}
+	fmt.Fprintf(&b, "        volumeMounts:\n        - name: data\n          mountPath: %s\n", store.mountPath)
+	b.WriteString("  volumeClaimTemplates:\n  - metadata:\n      name: data\n")
+	b.WriteString("    spec:\n      accessModes: [\"ReadWriteOnce\"]\n")
+	fmt.Fprintf(&b, "      resources:\n        requests:\n          storage: %s\n", store.storage)
+	return b.String()
+}
+
+func validateImageTagOption(options map[string]string) error {
+	if version, ok := options["version"]; ok && !imageTagRe.MatchString(version) {
+		return specFieldErrorf("version", "version must be an image tag, got %q", version)
+	}
+	return nil
+}
+
+func validateStorageOption(options map[string]string) error {
+	if storage, ok := options["storage"]; ok && !memoryQuantityRe.MatchString(storage) {
+		return specFieldErrorf("storage", "storage must be a quantity such as %s, got %q",
+			defaultCapabilityStorage, storage)
+	}
+	return nil
+}
+
+func storageOption() CapabilityOption {
+	return CapabilityOption{
+		Name:        "storage",
+		Description: "Size of the volume claim.",
+		Default:     defaultCapabilityStorage,
+		Required:    false,
+	}
+}
+
+// httpCapability is the app serving HTTP on exposure.port; the base Service
+// and the optional Ingress already cover it.
+type httpCapability struct{}
+
+func (httpCapability) info() CapabilityInfo {
+	return CapabilityInfo{
+		Name:        capabilityHTTP,
+		Description: "The app serves HTTP on exposure.port, through the project Service and optional Ingress.",
+		Options:     nil,
+		Resources:   nil,
+		Env:         nil,
+	}
+}
+
+func (httpCapability) validate(map[string]string) error {
+	return nil
+}
+
+func (httpCapability) render(capabilityContext) capabilityOutput {
+	return capabilityOutput{capability: "", manifest: "", env: nil}
+}
+
+// metricsCapability exposes the app's metrics port through a second Service
+// carrying the prometheus.io scrape annotations.
+type metricsCapability struct{}
+
+func (metricsCapability) info() CapabilityInfo {
+	return CapabilityInfo{
+		Name:        capabilityMetrics,
+		Description: "Prometheus scraping of the app through an annotated metrics Service.",
+		Options: []CapabilityOption{
+			{Name: "port", Description: "Container port serving metrics.", Default: defaultMetricsPort, Required: false},
+			{Name: "path", Description: "HTTP path of the metrics.", Default: defaultMetricsPath, Required: false},
+		},
+		Resources: []string{"Service"},
+		Env:       []string{"METRICS_PORT", "METRICS_PATH"},
+	}
+}
+
+func (metricsCapability) validate(options map[string]string) error {
+	if raw, ok := options["port"]; ok {
+		if port, err := strconv.Atoi(raw); err != nil || port < 1 || port > maxNetworkPort {
+			return specFieldErrorf("port", "port must be between 1 and %d, got %q", maxNetworkPort, raw)
+		}
+	}
+	if metricsPath, ok := options["path"]; ok && !metricsPathRe.MatchString(metricsPath) {
+		return specFieldErrorf("path", "path must start with / and hold no spaces or query, got %q", metricsPath)
+	}
+	return nil
+}
+
+func (metricsCapability) render(c capabilityContext) capabilityOutput {
+	port, metricsPath := c.options["port"], c.options["path"]
+	var b strings.Builder
+	b.WriteString("apiVersion: v1\nkind: Service\n")
+	writeCapabilityMetadata(&b, c, capabilityMetrics)
+	b.WriteString("  annotations:\n")
+	b.WriteString("    prometheus.io/scrape: \"true\"\n")
+	fmt.Fprintf(&b, "    prometheus.io/port: %s\n", yamlQuoted(port))
+	fmt.Fprintf(&b, "    prometheus.io/path: %s\n", yamlQuoted(metricsPath))
+	b.WriteString("spec:\n  selector:\n")
+	fmt.Fprintf(&b, "    app: %s\n", safeName(c.spec.Name))
+	fmt.Fprintf(&b, "  ports:\n  - name: metrics\n    port: %s\n    targetPort: %s\n", port, port)
+	return capabilityOutput{
+		capability: "",
+		manifest:   b.String(),
+		env:        map[string]string{"METRICS_PORT": port, "METRICS_PATH": metricsPath},
+	}
+}
+
+// postgresCapability runs a single Postgres instance per environment. It
+// trusts connections from inside the namespace, so DATABASE_URL carries no
+// password; bind the capability for a managed database.
+type postgresCapability struct{}
+
+func (postgresCapability) info() CapabilityInfo {
+	return CapabilityInfo{
+		Name:        capabilityPostgres,
+		Description: "A Postgres StatefulSet per environment, wired to the app through DATABASE_URL.",
+		Options: []CapabilityOption{
+			{Name: "version", Description: "postgres image tag.", Default: "16", Required: false},
+			storageOption(),
+			{Name: "database", Description: "Database name; defaults to the project name.", Default: "", Required: false},
+		},
+		Resources: []string{"Service", "StatefulSet"},
+		Env:       []string{"DATABASE_URL"},
+	}
+}
+
+func (postgresCapability) validate(options map[string]string) error {
+	if err := validateImageTagOption(options); err != nil {
+		return err
+	}
+	if database, ok := options["database"]; ok && !databaseNameRe.MatchString(database) {
+		return specFieldErrorf("database", "database must match %s, got %q", databaseNameRe.String(), database)
+	}
+	return validateStorageOption(options)
+}
+
+// postgresDatabase is the database option, or the project name with
+// dashes turned into underscores.
+func postgresDatabase(spec ProjectSpec, options map[string]string) string {
+	if database := options["database"]; database != "" {
+		return database
+	}
+	return strings.ReplaceAll(safeName(spec.Name), "-", "_")
+}
+
+func (postgresCapability) render(c capabilityContext) capabilityOutput {
+	database := postgresDatabase(c.spec, c.options)
+	manifest := renderCapabilityStore(c, capabilityStore{
+		capability: capabilityPostgres,
+		image:      "postgres:" + c.options["version"],
+		port:       postgresPort,
+		args:       nil,
+		env: map[string]string{
+			"POSTGRES_DB":               database,
+			"POSTGRES_USER":             postgresUser,
+			"POSTGRES_HOST_AUTH_METHOD": "trust",
+			"PGDATA":                    "/var/lib/postgresql/data/pgdata",
+		},
+		mountPath: "/var/lib/postgresql/data",
+		storage:   c.options["storage"],
+	})
+	return capabilityOutput{
+		capability: "",
+		manifest:   manifest,
+		env: map[string]string{
+			"DATABASE_URL": fmt.Sprintf("postgres://%s@%s:%d/%s?sslmode=disable",
+				postgresUser, c.name, postgresPort, database),
+		},
+	}
+}
+
+// redisCapability runs a single append-only Redis per environment.
+type redisCapability struct{}
+
+func (redisCapability) info() CapabilityInfo {
+	return CapabilityInfo{
+		Name:        capabilityRedis,
+		Description: "A Redis StatefulSet per environment, wired to the app through REDIS_URL.",
+		Options: []CapabilityOption{
+			{Name: "version", Description: "redis image tag.", Default: "7", Required: false},
+			storageOption(),
+		},
+		Resources: []string{"Service", "StatefulSet"},
+		Env:       []string{"REDIS_URL"},
+	}
+}
+
+func (redisCapability) validate(options map[string]string) error {
+	if err := validateImageTagOption(options); err != nil {
+		return err
+	}
+	return validateStorageOption(options)
+}
+
+func (redisCapability) render(c capabilityContext) capabilityOutput {
+	manifest := renderCapabilityStore(c, capabilityStore{
+		capability: capabilityRedis,
+		image:      "redis:" + c.options["version"],
+		port:       redisPort,
+		args:       []string{"redis-server", "--appendonly", "yes"},
+		env:        nil,
+		mountPath:  "/data",
+		storage:    c.options["storage"],
+	})
+	return capabilityOutput{
+		capability: "",
+		manifest:   manifest,
+		env:        map[string]string{"REDIS_URL": fmt.Sprintf("redis://%s:%d/0", c.name, redisPort)},
+	}
+}
+
+// cronCapability runs a command from the app image on a schedule. The
+// overlay's image rewrite reaches the CronJob too, so it runs the release
+// the environment runs.
+type cronCapability struct{}
+
+func (cronCapability) info() CapabilityInfo {
+	return CapabilityInfo{
+		Name:        capabilityCron,
+		Description: "A CronJob running a shell command from the app image, with the environment's vars.",
+		Options: []CapabilityOption{
+			{Name: "schedule", Description: "Five-field cron expression or @hourly, @daily, @weekly, @monthly.",
+				Default: "", Required: true},
+			{Name: "command", Description: "Command run with /bin/sh -c.", Default: "", Required: true},
+		},
+		Resources: []string{"CronJob"},
+		Env:       nil,
+	}
+}
+
+func (cronCapability) validate(options map[string]string) error {
+	if schedule, ok := options["schedule"]; ok {
+		if _, err := parseCronExpression(schedule); err != nil {
+			return specFieldErrorf("schedule", "invalid schedule: %v", err)
+		}
+	}
+	if command, ok := options["command"]; ok && strings.TrimSpace(command) == "" {
+		return specFieldErrorf("command", "command must not be blank")
+	}
+	return nil
+}
+
+func (cronCapability) render(c capabilityContext) capabilityOutput {
+	var b strings.Builder
+	b.WriteString("apiVersion: batch/v1\nkind: CronJob\n")
+	writeCapabilityMetadata(&b, c, capabilityCron)
+	b.WriteString("spec:\n")
+	fmt.Fprintf(&b, "  schedule: %s\n", yamlQuoted(strings.TrimSpace(c.options["schedule"])))
+	b.WriteString("  concurrencyPolicy: Forbid\n")
+	b.WriteString("  jobTemplate:\n    spec:\n      template:\n        metadata:\n          labels:\n")
+	fmt.Fprintf(&b, "            app: %s\n", c.name)
+	fmt.Fprintf(&b, "            %s: %s\n", capabilityLabel, capabilityCron)
+	b.WriteString("        spec:\n          restartPolicy: OnFailure\n")
+	fmt.Fprintf(&b, "          serviceAccountName: %s\n", projectServiceAccountName(c.spec))
+	b.WriteString("          containers:\n          - name: cron\n            image: app-image\n")
+	b.WriteString("            command: [\"/bin/sh\", \"-c\"]\n")
+	fmt.Fprintf(&b, "            args: [%s]\n", yamlQuoted(c.options["command"]))
+	vars := environmentVarsFor(c.spec, c.env)
+	vars["PLATFORM_ENVIRONMENT"] = c.env
+	b.WriteString("            env:\n")
+	for _, key := range sortedKeys(vars) {
+		fmt.Fprintf(&b, "            - name: %s\n              value: %s\n", key, yamlQuoted(vars[key]))
+	}
+	return capabilityOutput{capability: "", manifest: b.String(), env: nil}
+}
+
+// objectStorageCapability runs a single-node MinIO per environment with its
+// default root credentials, which are handed to the app as AWS_* vars. The
+// app creates its bucket; bind the capability for real object storage.
+type objectStorageCapability struct{}
+
+func (objectStorageCapability) info() CapabilityInfo {
+	return CapabilityInfo{
+		Name:        capabilityObjectStorage,
+		Description: "An S3-compatible MinIO StatefulSet per environment, wired to the app through S3_* and AWS_* vars.",
+		Options: []CapabilityOption{
+			{Name: "bucket", Description: "Bucket name; defaults to the project name.", Default: "", Required: false},
+			{Name: "version", Description: "minio/minio image tag.", Default: "latest", Required: false},
+			storageOption(),
+		},
+		Resources: []string{"Service", "StatefulSet"},
+		Env:       []string{"S3_ENDPOINT", "S3_BUCKET", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"},
+	}
+}
+
+func (objectStorageCapability) validate(options map[string]string) error {
+	if bucket, ok := options["bucket"]; ok && !bucketNameRe.MatchString(bucket) {
+		return specFieldErrorf("bucket", "bucket must match %s, got %q", bucketNameRe.String(), bucket)
+	}
+	if err := validateImageTagOption(options); err != nil {
+		return err
+	}
+	return validateStorageOption(options)
+}
+
+func (objectStorageCapability) render(c capabilityContext) capabilityOutput {
+	bucket := c.options["bucket"]
+	if bucket == "" {
+		bucket = safeName(c.spec.Name)
+	}
+	manifest := renderCapabilityStore(c, capabilityStore{
+		capability: capabilityObjectStorage,
+		image:      "minio/minio:" + c.options["version"],
+		port:       objectStoragePort,
+		args:       []string{"server", "/data"},
+		env:        nil,
+		mountPath:  "/data",
+		storage:    c.options["storage"],
+	})
+	return capabilityOutput{
+		capability: "",
+		manifest:   manifest,
+		env: map[string]string{
+			"S3_ENDPOINT":           fmt.Sprintf("http://%s:%d", c.name, objectStoragePort),
+			"S3_BUCKET":             bucket,
+			"AWS_ACCESS_KEY_ID":     objectStorageDevCredential,
+			"AWS_SECRET_ACCESS_KEY": objectStorageDevCredential,
+		},
+	}
+}
@@ -0,0 +1,174 @@
+//nolint:testpackage,exhaustruct // Capability tests drive the unexported validators and overlay writers.
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCapabilitiesChecksPluginsAndOptions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		mutate  func(*ProjectSpec)
+		field   string
+		message string
+	}{
+		{
+			name:    "unknown capability",
+			mutate:  func(s *ProjectSpec) { s.Capabilities = []string{"http", "mongodb"} },
+			field:   "capabilities[1]",
+			message: "available: cron, http, metrics, object-storage, postgres, redis",
+		},
+		{
+			name: "unknown option",
+			mutate: func(s *ProjectSpec) {
+				s.Capabilities = []string{"postgres"}
+				s.CapabilityOptions = map[string]map[string]string{"postgres": {"size": "1Gi"}}
+			},
+			field:   "capabilityOptions.postgres.size",
+			message: "accepted: version, storage, database",
+		},
+		{
+			name: "invalid option value",
+			mutate: func(s *ProjectSpec) {
+				s.Capabilities = []string{"redis"}
+				s.CapabilityOptions = map[string]map[string]string{"redis": {"storage": "lots"}}
+			},
+			field:   "capabilityOptions.redis.storage",
+			message: "storage must be a quantity",
+		},
+		{
+			name:    "missing required option",
+			mutate:  func(s *ProjectSpec) { s.Capabilities = []string{"cron"} },
+			field:   "capabilityOptions.cron.schedule",
+			message: "schedule is required",
+		},
+		{
+			name: "options for an undeclared capability",
+			mutate: func(s *ProjectSpec) {
+				s.CapabilityOptions = map[string]map[string]string{"redis": {"version": "7"}}
+			},
+			field:   "capabilityOptions.redis",
+			message: "not declared in capabilities",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			spec := workerRuntimeSpec("orders")
+			tc.mutate(&spec)
+			var fieldErr specFieldError
+			if err := validateProjectSpec(normalizeProjectSpec(spec)); !errors.As(err, &fieldErr) ||
+				fieldErr.Field != tc.field || !strings.Contains(fieldErr.Message, tc.message) {
+				t.Fatalf("expected %s: %q, got %v", tc.field, tc.message, err)
+			}
+		})
+	}
+
+	spec := workerRuntimeSpec("orders")
+	spec.Capabilities = []string{"cron", "metrics"}
+	spec.CapabilityOptions = map[string]map[string]string{
+		"cron":    {"schedule": "*/15 * * * *", "command": "./orders sweep"},
+		"metrics": {"port": "9102"},
+	}
+	if err := validateProjectSpec(normalizeProjectSpec(spec)); err != nil {
+		t.Fatalf("expected configured plugins to validate, got %v", err)
+	}
+}
+
+func TestAPI_CapabilitiesListsPlugins(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer((&API{}).routes())
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/api/capabilities")
+	if err != nil {
+		t.Fatalf("get capabilities: %v", err)
+	}
+	defer resp.Body.Close()
+	var plugins []CapabilityInfo
+	if err = json.NewDecoder(resp.Body).Decode(&plugins); err != nil {
+		t.Fatalf("decode capabilities: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || len(plugins) != len(capabilityPlugins()) {
+		t.Fatalf("expected every plugin, got %d %+v", resp.StatusCode, plugins)
+	}
+	if plugins[4].Name != capabilityPostgres || plugins[4].Env[0] != "DATABASE_URL" {
+		t.Fatalf("expected postgres to set DATABASE_URL, got %+v", plugins[4])
+	}
+}
+
+func TestWriteKustomizeRepoFilesRendersCapabilityPlugins(t *testing.T) {
+	t.Parallel()
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-orders"
+	spec := workerRuntimeSpec("orders")
+	spec.Capabilities = []string{"http", "postgres", "redis"}
+	spec.CapabilityOptions = map[string]map[string]string{"postgres": {"version": "15", "storage": "5Gi"}}
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{"REDIS_URL": "redis://cache.internal:6379/0"}}
+	bindings := envCapabilityBindings{"prod": {{
+		ProjectID: projectID, Environment: "prod", Capability: capabilityPostgres,
+		Type: CapabilityBindingExternal, Env: map[string]string{"DATABASE_URL": "postgres://rds.internal/orders"},
+	}}}
+	if _, err := writeKustomizeRepoFiles(
+		artifacts, projectID, spec, map[string]string{}, bindings, nil, nil,
+	); err != nil {
+		t.Fatalf("write manifests: %v", err)
+	}
+
+	dev, err := renderEnvironmentManifestsFromRepo(artifacts, projectID, "dev", manifestTrace{})
+	if err != nil {
+		t.Fatalf("render dev: %v", err)
+	}
+	for _, want := range []string{
+		"kind: StatefulSet\nmetadata:\n  labels:\n    platform.example.com/capability: postgres\n",
+		"image: postgres:15\n",
+		"storage: 5Gi\n",
+		"image: redis:7\n",
+		"value: postgres://app@orders-postgres:5432/orders?sslmode=disable\n",
+		"value: redis://orders-redis:6379/0\n",
+	} {
+		if !strings.Contains(dev.rendered, want) {
+			t.Fatalf("expected dev to render %q, got:\n%s", want, dev.rendered)
+		}
+	}
+	if !strings.Contains(dev.service, "name: orders\n") || manifestCapability(dev.service) != "" {
+		t.Fatalf("expected the app Service to stay the served one, got:\n%s", dev.service)
+	}
+
+	prodOverlay := filepath.ToSlash(filepath.Join(manifestsRepoOverlaysDir, "prod"))
+	if _, err = artifacts.ReadFile(projectID, prodOverlay+"/"+capabilityManifestFile(capabilityPostgres)); err == nil {
+		t.Fatal("expected the bound postgres to render no StatefulSet in prod")
+	}
+	prod, err := renderEnvironmentManifestsFromRepo(artifacts, projectID, "prod", manifestTrace{})
+	if err != nil {
+		t.Fatalf("render prod: %v", err)
+	}
+	for _, want := range []string{
+		"value: postgres://rds.internal/orders\n",
+		"value: redis://cache.internal:6379/0\n",
+		"image: redis:7\n",
+	} {
+		if !strings.Contains(prod.rendered, want) {
+			t.Fatalf("expected prod to render %q, got:\n%s", want, prod.rendered)
+		}
+	}
+
+	spec.Capabilities = []string{"http"}
+	spec.CapabilityOptions = nil
+	if _, err = writeKustomizeRepoFiles(artifacts, projectID, spec, map[string]string{}, bindings, nil, nil); err != nil {
+		t.Fatalf("rewrite manifests: %v", err)
+	}
+	devOverlay := filepath.ToSlash(filepath.Join(manifestsRepoOverlaysDir, "dev"))
+	if _, err = artifacts.ReadFile(projectID, devOverlay+"/"+capabilityManifestFile(capabilityRedis)); err == nil {
+		t.Fatal("expected the redis manifest to be removed once the capability is dropped")
+	}
+}
@@ -0,0 +1,296 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CI policy: spec.ci decides which source pushes a webhook turns into a ci
+// op. A project without one builds main only. A matching branch builds the
+// pushed commit and deploys it to dev like main does; a matching tag does the
+// same and, once the build is done, releases dev to prod. Path filters drop
+// branch pushes that change nothing the project builds from.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	ciPolicyPatternsMax         = 32
+	ciPatternMaxLength          = 256
+	ciPathsDirSuffix            = "/**"
+	ciTagReleaseTimeout         = 30 * time.Minute
+	ciBuildContextRelPath       = "build/ci-source"
+	sourceRepoWebhookPathsLabel = "ignored: no changed file matches ci.paths"
+	sourceRepoWebhookTagLabel   = "ignored: tag already built at this commit"
+)
+
+// ciPush is the branch or tag a source webhook names.
+type ciPush struct {
+	ref  plumbing.ReferenceName
+	name string
+	tag  bool
+}
+
+func normalizeCIPolicy(policy CIPolicy) CIPolicy {
+	return CIPolicy{
+		Branches: normalizeCIPatterns(policy.Branches),
+		Tags:     normalizeCIPatterns(policy.Tags),
+		Paths:    normalizeCIPatterns(policy.Paths),
+		Previews: PreviewPolicy{
+			Enabled:  policy.Previews.Enabled,
+			Branches: normalizeCIPatterns(policy.Previews.Branches),
+			TTL:      strings.TrimSpace(policy.Previews.TTL),
+		},
+	}
+}
+
+func normalizeCIPatterns(patterns []string) []string {
+	var out []string
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" && !slices.Contains(out, pattern) {
+			out = append(out, pattern)
+		}
+	}
+	return out
+}
+
+func validateCIPolicy(policy CIPolicy) error {
+	lists := []struct {
+		field    string
+		patterns []string
+	}{
+		{field: "ci.branches", patterns: policy.Branches},
+		{field: "ci.tags", patterns: policy.Tags},
+		{field: "ci.paths", patterns: policy.Paths},
+		{field: "ci.previews.branches", patterns: policy.Previews.Branches},
+	}
+	for _, list := range lists {
+		if len(list.patterns) > ciPolicyPatternsMax {
+			return specFieldErrorf(list.field, "%s allows at most %d patterns", list.field, ciPolicyPatternsMax)
+		}
+		for _, pattern := range list.patterns {
+			if len(pattern) > ciPatternMaxLength {
+				return specFieldErrorf(list.field, "%s pattern exceeds %d characters", list.field, ciPatternMaxLength)
+			}
+			if _, err := path.Match(strings.TrimSuffix(pattern, ciPathsDirSuffix), ""); err != nil {
+				return specFieldErrorf(list.field, "%s pattern %q is malformed", list.field, pattern)
+			}
+		}
+	}
+	return validatePreviewTTL(policy.Previews.TTL)
+}
+
+// matchCIPush finds the push a webhook event names and checks it against
+// policy, returning why it is ignored when it does not build. Without
+// branches, main is matched the way it always was.
+func matchCIPush(policy CIPolicy, branch, ref string) (ciPush, string) {
+	ref = strings.TrimSpace(ref)
+	if tag, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		if !slices.ContainsFunc(policy.Tags, func(pattern string) bool { return matchCIPattern(pattern, tag) }) {
+			return ciPush{}, "ignored: tag " + tag + " does not match ci.tags"
+		}
+		return ciPush{ref: plumbing.NewTagReferenceName(tag), name: tag, tag: true}, ""
+	}
+	if len(policy.Branches) == 0 {
+		if !isMainBranchWebhook(branch, ref) {
+			return ciPush{}, "ignored: only main branch triggers CI"
+		}
+		return ciPush{ref: plumbing.NewBranchReferenceName(branchMain), name: branchMain, tag: false}, ""
+	}
+	for _, name := range []string{ciBranchName(branch), ciBranchName(ref)} {
+		if name == "" {
+			continue
+		}
+		if slices.ContainsFunc(policy.Branches, func(pattern string) bool { return matchCIPattern(pattern, name) }) {
+			return ciPush{ref: plumbing.NewBranchReferenceName(name), name: name, tag: false}, ""
+		}
+	}
+	return ciPush{}, "ignored: branch does not match ci.branches"
+}
+
+// ciBranchName strips refs/heads/ like normalizeBranchValue, but keeps the
+// case: policy patterns name branches exactly.
+func ciBranchName(v string) string {
+	v = strings.TrimSpace(v)
+	v = strings.TrimPrefix(v, "refs/heads/")
+	return strings.TrimPrefix(v, "heads/")
+}
+
+func matchCIPattern(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+func matchCIPathPattern(pattern, file string) bool {
+	if dir, ok := strings.CutSuffix(pattern, ciPathsDirSuffix); ok {
+		return file == dir || strings.HasPrefix(file, dir+"/")
+	}
+	return matchCIPattern(pattern, file)
+}
+
+// ciPushChangesPaths reports whether commit changes a file matching
+// patterns. It compares with since, the last commit CI built, when that is
+// an ancestor, and with the commit's first parent otherwise. A commit the
+// repo does not have yet, such as one pushed to a remote, counts as a change.
+func ciPushChangesPaths(ctx context.Context, sourceDir, commitHash, since string, patterns []string) (bool, error) {
+	if len(patterns) == 0 || commitHash == "" {
+		return true, nil
+	}
+	repo, err := openLocalRepo(sourceDir)
+	if err != nil {
+		return false, err
+	}
+	commit, err := repo.CommitObject(plumbing.NewHash(commitHash))
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read commit %s: %w", shortID(commitHash), err)
+	}
+	var base *object.Commit
+	if previous, prevErr := repo.CommitObject(plumbing.NewHash(since)); since != "" && prevErr == nil {
+		if ancestor, _ := previous.IsAncestor(commit); ancestor {
+			base = previous
+		}
+	}
+	if base == nil && commit.NumParents() > 0 {
+		if base, err = commit.Parent(0); err != nil {
+			return false, fmt.Errorf("read parent of %s: %w", shortID(commitHash), err)
+		}
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, fmt.Errorf("read tree of %s: %w", shortID(commitHash), err)
+	}
+	var baseTree *object.Tree
+	if base != nil {
+		if baseTree, err = base.Tree(); err != nil {
+			return false, fmt.Errorf("read tree of %s: %w", shortID(base.Hash.String()), err)
+		}
+	}
+	changes, err := object.DiffTreeWithOptions(ctx, baseTree, tree, nil)
+	if err != nil {
+		return false, fmt.Errorf("diff %s: %w", shortID(commitHash), err)
+	}
+	for _, change := range changes {
+		for _, file := range []string{change.From.Name, change.To.Name} {
+			if file != "" && slices.ContainsFunc(patterns, func(p string) bool { return matchCIPathPattern(p, file) }) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ciBuildContextDir is the directory a ci op builds from: the source repo
+// for main, and otherwise the pushed commit exported to build/ci-source, so
+// the worktree stays on main.
+func ciBuildContextDir(ctx context.Context, artifacts ArtifactStore, msg ProjectOpMsg) (string, error) {
+	sourceDir := sourceRepoDir(artifacts, msg.ProjectID)
+	ref := ciPushRef(msg)
+	if msg.Kind != OpCI || ref == plumbing.NewBranchReferenceName(branchMain) {
+		return sourceDir, nil
+	}
+	commit := msg.CI.Commit
+	if commit == "" {
+		var err error
+		if commit, err = resolveCIPushCommit(sourceDir, ref); err != nil {
+			return "", err
+		}
+	}
+	subStepDone := beginSubStep(ctx, "export "+ref.Short()+" at "+shortID(commit))
+	dst := filepath.Join(artifacts.ProjectDir(msg.ProjectID), filepath.FromSlash(ciBuildContextRelPath))
+	err := os.RemoveAll(dst)
+	if err == nil {
+		err = gitExportCommit(ctx, sourceDir, commit, dst)
+	}
+	subStepDone(err)
+	if err != nil {
+		return "", fmt.Errorf("export %s at %s: %w", ref, shortID(commit), err)
+	}
+	return dst, nil
+}
+
+// ciPushRef is the ref a ci op builds; ops queued without one build main.
+func ciPushRef(msg ProjectOpMsg) plumbing.ReferenceName {
+	if msg.CI == nil || msg.CI.Ref == "" {
+		return plumbing.NewBranchReferenceName(branchMain)
+	}
+	return plumbing.ReferenceName(msg.CI.Ref)
+}
+
+// resolveCIPushCommit finds the commit a ref points at when the webhook did
+// not say: a fetched remote branch first, then the local one.
+func resolveCIPushCommit(sourceDir string, ref plumbing.ReferenceName) (string, error) {
+	repo, err := openLocalRepo(sourceDir)
+	if err != nil {
+		return "", err
+	}
+	candidates := []plumbing.ReferenceName{ref}
+	if ref.IsBranch() {
+		candidates = []plumbing.ReferenceName{plumbing.NewRemoteReferenceName(gitRemoteName, ref.Short()), ref}
+	}
+	for _, name := range candidates {
+		if commit, resolveErr := repo.ResolveRevision(plumbing.Revision(name)); resolveErr == nil {
+			return commit.String(), nil
+		}
+	}
+	return "", fmt.Errorf("source repo has no %s", ref)
+}
+
+// releaseAfterTagBuild waits for a tag's ci op and, when it ends done,
+// releases dev, where the build was deployed, to prod. The release op, or
+// why there is none, is recorded on the ci op.
+func (a *API) releaseAfterTagBuild(ctx context.Context, ciOp Operation) {
+	apiLog := appLoggerForProcess().Source("api")
+	release, err := a.startTagRelease(ctx, ciOp)
+	_, putErr := a.store.updateOp(ctx, ciOp.ID, func(op *Operation) bool {
+		if op.CI == nil {
+			return false
+		}
+		if err != nil {
+			op.CI.ReleaseError = err.Error()
+		} else {
+			op.CI.Release = release.ID
+		}
+		return true
+	})
+	if putErr != nil {
+		apiLog.Warnf("project=%s op=%s record tag release: %v", ciOp.ProjectID, ciOp.ID, putErr)
+	}
+}
+
+func (a *API) startTagRelease(ctx context.Context, ciOp Operation) (Operation, error) {
+	if err := a.waitOpTerminal(ctx, ciOp.ID, ciTagReleaseTimeout); err != nil {
+		return Operation{}, fmt.Errorf("not released: %w", err)
+	}
+	project, err := a.store.GetProject(ctx, ciOp.ProjectID)
+	if err != nil {
+		return Operation{}, fmt.Errorf("read project: %w", err)
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	fromEnv, toEnv, stage, kind, err := resolveTransitionRequest(
+		spec, defaultDeployEnvironment, defaultReleaseEnvironment, true,
+	)
+	if err != nil {
+		return Operation{}, err
+	}
+	check, err := a.checkTransitionSourceBudget(project.ID, spec, fromEnv)
+	if err != nil {
+		return Operation{}, err
+	}
+	if len(check.exceeded) > 0 {
+		return Operation{}, vulnerabilityBudgetError{ProjectID: project.ID, Check: check}
+	}
+	opts := transitionOpRunOptions(fromEnv, toEnv, stage).withExecution(ciOp.Execution)
+	return a.enqueueOp(ctx, kind, project.ID, spec, opts)
+}
@@ -0,0 +1,223 @@
+//nolint:testpackage,exhaustruct // CI policy tests drive the unexported webhook trigger and image builder.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestMatchCIPush(t *testing.T) {
+	t.Parallel()
+
+	policy := CIPolicy{Branches: []string{"main", "release/*"}, Tags: []string{"v*"}}
+	cases := []struct {
+		name    string
+		policy  CIPolicy
+		branch  string
+		ref     string
+		wantRef string
+		wantTag bool
+	}{
+		{name: "default main", branch: "Main", wantRef: "refs/heads/main"},
+		{name: "default feature", ref: "refs/heads/feature/x"},
+		{name: "default tag", ref: "refs/tags/v1.0.0"},
+		{name: "release branch", policy: policy, ref: "refs/heads/release/1.2", wantRef: "refs/heads/release/1.2"},
+		{name: "nested release branch", policy: policy, ref: "refs/heads/release/1.2/hotfix"},
+		{name: "feature branch", policy: policy, branch: "feature/x"},
+		{name: "policy is case sensitive", policy: policy, branch: "Main"},
+		{name: "tag", policy: policy, ref: "refs/tags/v1.0.0", wantRef: "refs/tags/v1.0.0", wantTag: true},
+		{name: "other tag", policy: policy, ref: "refs/tags/nightly"},
+	}
+	for _, tc := range cases {
+		push, reason := matchCIPush(normalizeCIPolicy(tc.policy), tc.branch, tc.ref)
+		if tc.wantRef == "" {
+			if reason == "" {
+				t.Errorf("%s: expected the push to be ignored, got %+v", tc.name, push)
+			}
+			continue
+		}
+		if reason != "" || push.ref.String() != tc.wantRef || push.tag != tc.wantTag {
+			t.Errorf("%s: expected %s (tag %v), got %+v %q", tc.name, tc.wantRef, tc.wantTag, push, reason)
+		}
+	}
+
+	err := validateCIPolicy(CIPolicy{Paths: []string{"src/**", "cmd/[a-"}})
+	var fieldErr specFieldError
+	if !asSpecFieldError(err, &fieldErr) || fieldErr.Field != "ci.paths" {
+		t.Fatalf("expected a malformed pattern on ci.paths, got %v", err)
+	}
+}
+
+func TestCIPushChangesPaths(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "source")
+	if err := ensureLocalGitRepo(ctx, dir); err != nil {
+		t.Fatalf("init repo: %v", err)
+	}
+	commit := func(name string) string {
+		t.Helper()
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(target, []byte(name+time.Now().String()), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if _, err := gitCommitIfChanged(ctx, dir, "change "+name); err != nil {
+			t.Fatalf("commit %s: %v", name, err)
+		}
+		head, err := gitRevParse(ctx, dir, "HEAD")
+		if err != nil {
+			t.Fatalf("head: %v", err)
+		}
+		return head
+	}
+	paths := []string{"src/**", "go.mod"}
+	root := commit("README.md")
+	code := commit("src/app/main.go")
+	docs := commit("docs/guide.md")
+
+	for _, tc := range []struct {
+		name          string
+		commit, since string
+		want          bool
+	}{
+		{name: "root commit outside paths", commit: root, want: false},
+		{name: "code change", commit: code, want: true},
+		{name: "docs change", commit: docs, want: false},
+		{name: "docs change since the root", commit: docs, since: root, want: true},
+		{name: "since that is not an ancestor", commit: code, since: docs, want: true},
+		{name: "unknown commit", commit: strings.Repeat("ab", 20), want: true},
+	} {
+		got, err := ciPushChangesPaths(ctx, dir, tc.commit, tc.since, paths)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: expected %v, got %v (%v)", tc.name, tc.want, got, err)
+		}
+	}
+}
+
+func TestImageBuilderBuildsTheTaggedCommit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := normalizeProjectSpec(ProjectSpec{
+		Name:         "orders",
+		Runtime:      "go_1.26",
+		Environments: map[string]EnvConfig{"dev": {}},
+	})
+	createMsg := newProjectOpMsg("op-create", OpCreate, "project-ci", spec, emptyOpRunOptions(), time.Now().UTC())
+	if _, err := runRepoBootstrapCreateOrUpdate(ctx, artifacts, createMsg, spec); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	sourceDir := sourceRepoDir(artifacts, "project-ci")
+	writeAndCommit := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(sourceDir, "VERSION"), []byte(body), 0o600); err != nil {
+			t.Fatalf("write VERSION: %v", err)
+		}
+		if _, err := gitCommitIfChanged(ctx, sourceDir, "version "+body); err != nil {
+			t.Fatalf("commit VERSION: %v", err)
+		}
+	}
+	writeAndCommit("1.0.0")
+	repo, err := openLocalRepo(sourceDir)
+	if err != nil {
+		t.Fatalf("open source repo: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("head: %v", err)
+	}
+	if _, err = repo.CreateTag("v1.0.0", head.Hash(), nil); err != nil {
+		t.Fatalf("tag: %v", err)
+	}
+	writeAndCommit("1.1.0-dev")
+
+	opts := emptyOpRunOptions()
+	opts.ci = &CIBuild{Ref: plumbing.NewTagReferenceName("v1.0.0").String()}
+	ciMsg := newProjectOpMsg("op-ci", OpCI, "project-ci", spec, opts, time.Now().UTC())
+	if _, err = runImageBuilderBuild(ctx, artifacts, ciMsg, spec, "local/orders:ci"); err != nil {
+		t.Fatalf("ci build: %v", err)
+	}
+	exported := filepath.Join(artifacts.ProjectDir("project-ci"), filepath.FromSlash(ciBuildContextRelPath))
+	if body, readErr := os.ReadFile(filepath.Join(exported, "VERSION")); readErr != nil || string(body) != "1.0.0" {
+		t.Fatalf("expected the tagged commit in the build context, got %q (%v)", body, readErr)
+	}
+	if body, _ := os.ReadFile(filepath.Join(sourceDir, "VERSION")); string(body) != "1.1.0-dev" {
+		t.Fatalf("expected the source worktree left on main, got %q", body)
+	}
+}
+
+func TestAPI_SourceWebhookFollowsTheCIPolicy(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-ci-policy"
+	spec := workerRuntimeSpec("ci-policy")
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{}}
+	spec.CI = CIPolicy{Branches: []string{"main"}, Tags: []string{"v*"}}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-ci-policy-create", OpCreate, spec)
+
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	post := func(ref, commit string) map[string]any {
+		t.Helper()
+		body := `{"project_id":"` + projectID + `","repo":"source","ref":"` + ref + `","commit":"` + commit + `"}`
+		resp, err := http.Post(srv.URL+"/api/webhooks/source", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("post webhook: %v", err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d %v", resp.StatusCode, out)
+		}
+		return out
+	}
+
+	if out := post("refs/heads/feature/x", "c0ffee"); out["accepted"] != false ||
+		!strings.Contains(out["reason"].(string), "ci.branches") {
+		t.Fatalf("expected a feature branch to be ignored, got %v", out)
+	}
+	out := post("refs/tags/v1.0.0", "c0ffee")
+	if out["accepted"] != true {
+		t.Fatalf("expected the tag to build, got %v", out)
+	}
+	ciID, _ := out["op"].(map[string]any)["id"].(string)
+	ciOp, err := fixture.store.GetOp(ctx, ciID)
+	if err != nil || ciOp.Kind != OpCI || ciOp.CI == nil || ciOp.CI.Ref != "refs/tags/v1.0.0" {
+		t.Fatalf("expected a ci op for the tag, got %+v (%v)", ciOp, err)
+	}
+	if out = post("refs/tags/v1.0.0", "c0ffee"); out["reason"] != sourceRepoWebhookTagLabel {
+		t.Fatalf("expected a redelivered tag to be ignored, got %v", out)
+	}
+
+	_ = finalizeOp(ctx, fixture.store, ciID, projectID, OpCI, opStatusDone, "")
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		if ciOp, err = fixture.store.GetOp(ctx, ciID); err == nil && ciOp.CI.Release+ciOp.CI.ReleaseError != "" {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	release, err := fixture.store.GetOp(ctx, ciOp.CI.Release)
+	if err != nil || release.Kind != OpRelease ||
+		release.Delivery.FromEnv != "dev" || release.Delivery.ToEnv != "prod" {
+		t.Fatalf("expected a dev to prod release after the build, got %+v %+v (%v)", ciOp.CI, release, err)
+	}
+}
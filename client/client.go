@@ -0,0 +1,379 @@
+// Package client is a typed Go client for the platform HTTP API.
+//
+// It wraps the project, operation, artifact, release, promotion, and rollback
+// endpoints plus the per-operation SSE stream, and retries requests the
+// server rejected before doing any work (429/503) with exponential backoff,
+// except project quota refusals, which free up on the server's schedule.
+// Model types come from the platform package so the client tracks the
+// server's JSON shapes without a second copy.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+const (
+	defaultRetryAttempts  = 4
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+	defaultRequestTimeout = 30 * time.Second
+
+	errorBodyLimit = 64 << 10
+
+	// codeQuotaExceeded is a project quota refusal. It frees up on the
+	// project's schedule rather than the client's, so it is not retried.
+	codeQuotaExceeded = "quota_exceeded"
+)
+
+// RetryPolicy controls how failed requests are retried. Attempts counts the
+// first try; values below 1 disable retries.
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy is used when no WithRetry option is given.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:  defaultRetryAttempts,
+		BaseDelay: defaultRetryBaseDelay,
+		MaxDelay:  defaultRetryMaxDelay,
+	}
+}
+
+// delay returns the wait before retry number attempt (1-based), doubling
+// from BaseDelay and capped at MaxDelay.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// Client talks to one platform API server. It is safe for concurrent use.
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+	stream  *http.Client
+	retry   RetryPolicy
+	token   string
+
+	execution platform.OpExecution
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient replaces the client used for request/response calls.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.http = hc
+		}
+	}
+}
+
+// WithStreamHTTPClient replaces the client used for SSE streams. It should
+// not set an overall Timeout, since streams stay open for the op's lifetime.
+func WithStreamHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.stream = hc
+		}
+	}
+}
+
+// WithRetry replaces the retry policy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithToken sends token as the bearer credential on every request, which the
+// server requires when PAAS_API_AUTH is on.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = strings.TrimSpace(token)
+	}
+}
+
+// New returns a client for the API rooted at baseURL, e.g.
+// "http://127.0.0.1:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimRight(strings.TrimSpace(baseURL), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("base url %q must be http or https", baseURL)
+	}
+	c := &Client{
+		baseURL: parsed,
+		http:    &http.Client{Timeout: defaultRequestTimeout},
+		stream:  &http.Client{},
+		retry:   DefaultRetryPolicy(),
+		token:   "",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// WithExecution returns a copy of c whose op-starting calls (create, update,
+// delete, cleanup, deploy, promote, release, rollback) ask the server for the
+// given execution profile. The server rejects DryRun for CreateProject.
+func (c *Client) WithExecution(execution platform.OpExecution) *Client {
+	clone := *c
+	clone.execution = execution
+	return &clone
+}
+
+// opQuery adds the client's execution flags to an op-starting request.
+func (c *Client) opQuery(query url.Values) url.Values {
+	if !c.execution.DryRun && !c.execution.Trace {
+		return query
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	if c.execution.DryRun {
+		query.Set("dry_run", "true")
+	}
+	if c.execution.Trace {
+		query.Set("trace", "true")
+	}
+	return query
+}
+
+// Error is a non-2xx API response, decoded from the JSON error envelope.
+// Code is the machine-readable error code ("validation_failed",
+// "op_conflict", ...), Field the spec field a validation error names, and
+// OpID the op the error concerns. Body keeps the raw bytes for endpoints
+// whose errors carry more (conflicts, holds). RetryAfter is the response's
+// Retry-After, set on 429 and 503.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Field      string
+	OpID       string
+	NextStep   string
+	RetryAfter time.Duration
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("platform api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("platform api: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the API.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is a 409 from the API, such as an op
+// already in flight or an active compliance hold.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+func newError(resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyLimit))
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Code:       "",
+		Message:    strings.TrimSpace(string(body)),
+		Field:      "",
+		OpID:       "",
+		NextStep:   "",
+		RetryAfter: retryAfter(resp),
+		Body:       body,
+	}
+	var structured struct {
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Field    string `json:"field"`
+		OpID     string `json:"op_id"`
+		Reason   string `json:"reason"`
+		Error    string `json:"error"`
+		NextStep string `json:"next_step"`
+	}
+	if json.Unmarshal(body, &structured) == nil {
+		switch {
+		case structured.Reason != "":
+			apiErr.Message = structured.Reason
+		case structured.Message != "":
+			apiErr.Message = structured.Message
+		case structured.Error != "":
+			apiErr.Message = structured.Error
+		}
+		apiErr.Code = structured.Code
+		apiErr.Field = structured.Field
+		apiErr.OpID = structured.OpID
+		apiErr.NextStep = structured.NextStep
+	}
+	return apiErr
+}
+
+// endpoint joins an already-escaped API path and query onto the base URL.
+func (c *Client) endpoint(path string, query url.Values) string {
+	target := c.baseURL.String() + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target
+}
+
+// getJSON issues a GET and decodes the JSON response into out.
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out any) error {
+	return c.doJSON(ctx, http.MethodGet, path, query, nil, out)
+}
+
+// doJSON sends body as JSON (when non-nil) and decodes a 2xx response into
+// out (when non-nil).
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+	resp, err := c.do(ctx, method, c.endpoint(path, query), payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// do sends the request, retrying per the client's policy, and returns a 2xx
+// response whose body the caller must close.
+func (c *Client) do(ctx context.Context, method, target string, payload []byte) (*http.Response, error) {
+	attempts := max(c.retry.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, c.http, method, target, payload, nil)
+		if err == nil && resp.StatusCode < http.StatusMultipleChoices {
+			return resp, nil
+		}
+		var wait time.Duration
+		retry := attempt < attempts
+		if err != nil {
+			retry = retry && ctx.Err() == nil && method == http.MethodGet
+		} else {
+			apiErr := newError(resp)
+			resp.Body.Close()
+			retry = retry && retryableStatus(method, resp.StatusCode) && apiErr.Code != codeQuotaExceeded
+			wait = apiErr.RetryAfter
+			err = apiErr
+		}
+		if !retry {
+			return nil, err
+		}
+		if wait <= 0 {
+			wait = c.retry.delay(attempt)
+		}
+		if sleepErr := sleepCtx(ctx, wait); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) send(
+	ctx context.Context,
+	hc *http.Client,
+	method, target string,
+	payload []byte,
+	header http.Header,
+) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+// retryableStatus reports whether a response status is worth retrying. 429
+// and 503 mean the server turned the request away before acting on it, so
+// they are safe for any method; other gateway errors are retried only for
+// reads, since a mutation may already have been applied.
+func retryableStatus(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusInternalServerError:
+		return method == http.MethodGet
+	default:
+		return false
+	}
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After")))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
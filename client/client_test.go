@@ -0,0 +1,185 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+	"github.com/a2y-d5l/go-web-nats/client"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) *client.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := client.New(srv.URL, client.WithRetry(client.RetryPolicy{
+		Attempts:  3,
+		BaseDelay: time.Millisecond,
+		MaxDelay:  5 * time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+func TestClient_RetriesUnavailableMutation(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/events/deployment" {
+			http.NotFound(w, r)
+			return
+		}
+		var evt platform.DeploymentEvent
+		if err := json.NewDecoder(r.Body).Decode(&evt); err != nil || evt.ProjectID != "p1" {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"accepted":false,"reason":"workers not ready"}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"accepted":true,"op":{"id":"op-1","kind":"deploy"}}`))
+	}))
+
+	out, err := c.Deploy(context.Background(), platform.DeploymentEvent{ProjectID: "p1", Environment: "dev"})
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	if !out.Accepted || out.Op.ID != "op-1" || calls.Load() != 2 {
+		t.Fatalf("unexpected result %+v after %d calls", out, calls.Load())
+	}
+}
+
+func TestClient_DoesNotRetryFailedMutationAndDecodesError(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"accepted":false,"reason":"project has active holds","next_step":"lift holds"}`))
+	}))
+
+	_, err := c.DeleteProject(context.Background(), "p1", "plan-1", false)
+	if !client.IsConflict(err) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Message != "project has active holds" || apiErr.NextStep != "lift holds" {
+		t.Fatalf("unexpected error detail: %#v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected one call, got %d", calls.Load())
+	}
+}
+
+func TestClient_DoesNotRetryProjectQuotaRefusal(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "1740")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"code":"quota_exceeded","message":"project p1 reached its ops_per_hour quota"}`))
+	}))
+
+	_, err := c.GetProjectQuota(context.Background(), "p1")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "quota_exceeded" || apiErr.RetryAfter != 1740*time.Second {
+		t.Fatalf("unexpected error detail: %#v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected one call, got %d", calls.Load())
+	}
+}
+
+func TestClient_DecodesErrorEnvelopeField(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"validation_failed","message":"runtime is required","field":"runtime"}`))
+	}))
+
+	_, err := c.CreateProject(context.Background(), platform.ProjectSpec{Name: "app"})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "validation_failed" || apiErr.Field != "runtime" ||
+		apiErr.Message != "runtime is required" {
+		t.Fatalf("unexpected error detail: %#v", err)
+	}
+}
+
+func TestClient_GetRetriesServerErrorsThenReportsNotFound(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "failed to read op", http.StatusInternalServerError)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+
+	_, err := c.GetOp(context.Background(), "op-missing")
+	if !client.IsNotFound(err) || calls.Load() != 2 {
+		t.Fatalf("expected not found after one retry, got %v after %d calls", err, calls.Load())
+	}
+}
+
+func TestClient_WithTokenSendsBearer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer paas_test" {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := client.New(srv.URL, client.WithToken(" paas_test "))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	if _, err = c.GetOp(context.Background(), "op-missing"); !client.IsNotFound(err) {
+		t.Fatalf("expected the bearer token to be accepted, got %v", err)
+	}
+}
+
+func TestClient_StreamOpEventsResumesWithLastEventID(t *testing.T) {
+	var conns atomic.Int32
+	resumedFrom := make(chan string, 1)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ops/op-1/events" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		if conns.Add(1) == 1 {
+			fmt.Fprint(w, "event: op.bootstrap\ndata: {\"op_id\":\"op-1\",\"status\":\"running\"}\n\n")
+			fmt.Fprint(w, "id: 3\nevent: step.started\ndata: {\"op_id\":\"op-1\",\"sequence\":3}\n\n")
+			return
+		}
+		resumedFrom <- r.Header.Get("Last-Event-ID")
+		fmt.Fprint(w, "event: op.heartbeat\ndata: {\"op_id\":\"op-1\",\"sequence\":4}\n\n")
+		fmt.Fprint(w, "id: 4\nevent: op.completed\ndata: {\"op_id\":\"op-1\",\"sequence\":4,\"status\":\"done\"}\n\n")
+	}))
+
+	var names []string
+	err := c.StreamOpEvents(context.Background(), "op-1", "", func(evt client.OpEvent) error {
+		names = append(names, evt.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if got := <-resumedFrom; got != "3" {
+		t.Fatalf("expected resume from event 3, got %q", got)
+	}
+	want := []string{client.EventBootstrap, client.EventStepStarted, client.EventHeartbeat, client.EventCompleted}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Fatalf("unexpected events %v, want %v", names, want)
+	}
+}
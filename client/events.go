@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+// Op event names sent on /api/ops/{id}/events.
+const (
+	EventBootstrap     = "op.bootstrap"
+	EventStatus        = "op.status"
+	EventStepStarted   = "step.started"
+	EventStepHeartbeat = "step.heartbeat"
+	EventStepSubStep   = "step.substep"
+	EventStepEnded     = "step.ended"
+	EventStepArtifacts = "step.artifacts"
+	EventCompleted     = "op.completed"
+	EventFailed        = "op.failed"
+	EventCancelled     = "op.cancelled"
+	EventHeartbeat     = "op.heartbeat"
+
+	sseLineLimit = 1 << 20
+)
+
+// ErrStopStream can be returned from a StreamOpEvents callback to end the
+// stream early without an error.
+var ErrStopStream = errors.New("stop op event stream")
+
+// OpEventDelivery describes the environment transition an op event belongs
+// to.
+type OpEventDelivery struct {
+	Stage       platform.DeliveryStage `json:"stage,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	FromEnv     string                 `json:"from_env,omitempty"`
+	ToEnv       string                 `json:"to_env,omitempty"`
+}
+
+// OpEvent is one decoded SSE event for an operation.
+type OpEvent struct {
+	Name            string                 `json:"-"`
+	EventID         string                 `json:"event_id"`
+	Sequence        int64                  `json:"sequence"`
+	OpID            string                 `json:"op_id"`
+	ProjectID       string                 `json:"project_id"`
+	Kind            platform.OperationKind `json:"kind"`
+	Status          string                 `json:"status"`
+	At              time.Time              `json:"at"`
+	Worker          string                 `json:"worker,omitempty"`
+	StepIndex       int                    `json:"step_index,omitempty"`
+	TotalSteps      int                    `json:"total_steps,omitempty"`
+	ProgressPercent int                    `json:"progress_percent,omitempty"`
+	DurationMS      int64                  `json:"duration_ms,omitempty"`
+	Message         string                 `json:"message,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+	Artifacts       []string               `json:"artifacts,omitempty"`
+	Delivery        OpEventDelivery        `json:"delivery"`
+	Hint            string                 `json:"hint,omitempty"`
+	SubStep         *platform.OpSubStep    `json:"substep,omitempty"`
+
+	replayable bool // sent with an SSE id, so Last-Event-ID can resume after it
+}
+
+// Terminal reports whether the event means the op has finished. A bootstrap
+// snapshot of an already finished op counts, since no further events follow.
+func (e OpEvent) Terminal() bool {
+	switch e.Name {
+	case EventCompleted, EventFailed, EventCancelled:
+		return true
+	case EventBootstrap:
+		return e.Status == "done" || e.Status == "error" || e.Status == "cancelled"
+	default:
+		return false
+	}
+}
+
+// StreamOpEvents calls fn for every event of opID until the op finishes, fn
+// returns an error, or ctx ends. Dropped connections are resumed with
+// Last-Event-ID after a backoff, so fn sees each replayable event once.
+// Pass lastEventID to resume a previous subscription; "" starts from the
+// server's bootstrap snapshot.
+func (c *Client) StreamOpEvents(ctx context.Context, opID, lastEventID string, fn func(OpEvent) error) error {
+	failures := 0
+	for {
+		done, progressed, err := c.streamOpEventsOnce(ctx, opID, &lastEventID, fn)
+		switch {
+		case done:
+			return nil
+		case errors.Is(err, ErrStopStream):
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && !retryableStreamError(err):
+			return err
+		}
+		if progressed {
+			failures = 0
+		}
+		failures++
+		if c.retry.Attempts > 0 && failures >= c.retry.Attempts {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("op %s event stream: %w", opID, err)
+		}
+		if sleepErr := sleepCtx(ctx, c.retry.delay(failures)); sleepErr != nil {
+			return sleepErr
+		}
+	}
+}
+
+// streamOpEventsOnce reads one SSE connection. It reports whether a terminal
+// event was delivered and whether any event arrived before the stream ended.
+func (c *Client) streamOpEventsOnce(
+	ctx context.Context,
+	opID string,
+	lastEventID *string,
+	fn func(OpEvent) error,
+) (bool, bool, error) {
+	header := http.Header{}
+	header.Set("Accept", "text/event-stream")
+	if *lastEventID != "" {
+		header.Set("Last-Event-ID", *lastEventID)
+	}
+	target := c.endpoint("/api/ops/"+url.PathEscape(opID)+"/events", nil)
+	resp, err := c.send(ctx, c.stream, http.MethodGet, target, nil, header)
+	if err != nil {
+		return false, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, false, newError(resp)
+	}
+
+	progressed := false
+	reader := newSSEReader(resp.Body)
+	for {
+		evt, readErr := reader.next()
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				readErr = nil
+			}
+			return false, progressed, readErr
+		}
+		progressed = true
+		if evt.replayable {
+			*lastEventID = evt.EventID
+		}
+		if err = fn(evt); err != nil {
+			return false, progressed, err
+		}
+		if evt.Terminal() {
+			return true, progressed, nil
+		}
+	}
+}
+
+// retryableStreamError reports whether a failed stream attempt should be
+// resumed: transport errors and gateway/unavailable responses are, client
+// errors such as 404 are not.
+func retryableStreamError(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return retryableStatus(http.MethodGet, apiErr.StatusCode)
+	}
+	return true
+}
+
+// sseReader decodes the subset of text/event-stream the server emits:
+// optional "id:", then "event:" and a single "data:" line per event.
+type sseReader struct {
+	scanner *bufio.Scanner
+}
+
+func newSSEReader(r io.Reader) *sseReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), sseLineLimit)
+	return &sseReader{scanner: scanner}
+}
+
+func (r *sseReader) next() (OpEvent, error) {
+	var name, id, data string
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if data == "" {
+				continue
+			}
+			var evt OpEvent
+			if err := json.Unmarshal([]byte(data), &evt); err != nil {
+				return OpEvent{}, fmt.Errorf("decode %s event: %w", name, err)
+			}
+			evt.Name = name
+			if id != "" {
+				evt.EventID = id
+				evt.replayable = true
+			}
+			return evt, nil
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			name = value
+		case "id":
+			id = value
+		case "data":
+			data += value
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return OpEvent{}, err
+	}
+	return OpEvent{}, io.EOF
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+// OpSummary is one row of op history.
+type OpSummary struct {
+	ID                string                 `json:"id"`
+	ProjectID         string                 `json:"project_id"`
+	Kind              platform.OperationKind `json:"kind"`
+	Status            string                 `json:"status"`
+	Requested         time.Time              `json:"requested"`
+	Finished          time.Time              `json:"finished"`
+	Error             string                 `json:"error,omitempty"`
+	SummaryMessage    string                 `json:"summary_message,omitempty"`
+	LastEventSequence int64                  `json:"last_event_sequence"`
+	LastUpdateAt      time.Time              `json:"last_update_at"`
+	SLABreached       bool                   `json:"sla_breached,omitempty"`
+}
+
+// OpPage is one page of op history, newest first.
+type OpPage struct {
+	Items      []OpSummary `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// ListOpsOptions pages through project op history. Zero values use the
+// server defaults.
+type ListOpsOptions struct {
+	Limit  int
+	Cursor string
+	Before time.Time
+}
+
+// OpFilter narrows ListOps. Zero values match everything; Since is
+// inclusive and Until exclusive on the request time.
+type OpFilter struct {
+	ProjectID string
+	Kinds     []platform.OperationKind
+	Statuses  []string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Cursor    string
+}
+
+// ArtifactStat describes one artifact file without its content.
+type ArtifactStat struct {
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time"`
+	SHA256      string    `json:"sha256"`
+	ContentType string    `json:"content_type"`
+}
+
+// CancelOp stops a queued or running op and returns it as cancelled. Ops
+// that already finished, and delete ops, fail with a conflict.
+func (c *Client) CancelOp(ctx context.Context, opID, reason string) (platform.Operation, error) {
+	var out struct {
+		Op platform.Operation `json:"op"`
+	}
+	body := map[string]string{"reason": reason}
+	err := c.doJSON(ctx, http.MethodPost, "/api/ops/"+url.PathEscape(opID)+"/cancel", nil, body, &out)
+	return out.Op, err
+}
+
+// GetOp returns one operation with its steps.
+func (c *Client) GetOp(ctx context.Context, opID string) (platform.Operation, error) {
+	var op platform.Operation
+	err := c.getJSON(ctx, "/api/ops/"+url.PathEscape(opID), nil, &op)
+	return op, err
+}
+
+// ListOps returns one page of ops across all projects, newest first.
+func (c *Client) ListOps(ctx context.Context, filter OpFilter) (OpPage, error) {
+	query := url.Values{}
+	if filter.ProjectID != "" {
+		query.Set("project_id", filter.ProjectID)
+	}
+	if len(filter.Kinds) > 0 {
+		kinds := make([]string, 0, len(filter.Kinds))
+		for _, kind := range filter.Kinds {
+			kinds = append(kinds, string(kind))
+		}
+		query.Set("kind", strings.Join(kinds, ","))
+	}
+	if len(filter.Statuses) > 0 {
+		query.Set("status", strings.Join(filter.Statuses, ","))
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if !filter.Until.IsZero() {
+		query.Set("until", filter.Until.UTC().Format(time.RFC3339Nano))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Cursor != "" {
+		query.Set("cursor", filter.Cursor)
+	}
+	var page OpPage
+	err := c.getJSON(ctx, "/api/ops", query, &page)
+	return page, err
+}
+
+// ListProjectOps returns one page of a project's op history.
+func (c *Client) ListProjectOps(ctx context.Context, projectID string, opts ListOpsOptions) (OpPage, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	if !opts.Before.IsZero() {
+		query.Set("before", opts.Before.UTC().Format(time.RFC3339Nano))
+	}
+	var page OpPage
+	err := c.getJSON(ctx, projectPath(projectID, "ops"), query, &page)
+	return page, err
+}
+
+// ListArtifacts returns the project's artifact paths, relative to its
+// artifact root.
+func (c *Client) ListArtifacts(ctx context.Context, projectID string) ([]string, error) {
+	var out struct {
+		Files []string `json:"files"`
+	}
+	if err := c.getJSON(ctx, projectPath(projectID, "artifacts"), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Files, nil
+}
+
+// CleanupArtifacts enqueues a cleanup op that removes the project's artifacts
+// under prefix, such as "build/". Repos are never eligible, and release
+// evidence cannot be removed while a compliance hold is active.
+func (c *Client) CleanupArtifacts(ctx context.Context, projectID, prefix string) (Accepted, error) {
+	var out Accepted
+	query := url.Values{"prefix": {prefix}}
+	err := c.doJSON(ctx, http.MethodDelete, projectPath(projectID, "artifacts"), c.opQuery(query), nil, &out)
+	return out, err
+}
+
+// TrialRuntimeUpgrade enqueues a runtime-upgrade op that commits the
+// scaffolding for target (such as "go_1.27") to a runtime-upgrade/<target>
+// branch and trial-builds it; main and the project are left unchanged.
+func (c *Client) TrialRuntimeUpgrade(ctx context.Context, projectID, target string) (Accepted, error) {
+	var out Accepted
+	body := map[string]string{"target_runtime": target}
+	err := c.doJSON(ctx, http.MethodPost, projectPath(projectID, "runtime-upgrade"), c.opQuery(nil), body, &out)
+	return out, err
+}
+
+// ReadArtifact downloads one artifact file.
+func (c *Client) ReadArtifact(ctx context.Context, projectID, relPath string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.endpoint(artifactPath(projectID, relPath), nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// StatArtifact returns one artifact file's size, mtime, SHA-256 and content
+// type without downloading it.
+func (c *Client) StatArtifact(ctx context.Context, projectID, relPath string) (ArtifactStat, error) {
+	var out ArtifactStat
+	err := c.getJSON(ctx, artifactPath(projectID, relPath), url.Values{"stat": {"1"}}, &out)
+	return out, err
+}
+
+func artifactPath(projectID, relPath string) string {
+	segments := strings.Split(strings.Trim(relPath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return projectPath(projectID, append([]string{"artifacts"}, segments...)...)
+}
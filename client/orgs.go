@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+// OrgStatus is an organization with what it holds against its quota.
+type OrgStatus struct {
+	Org   platform.Organization `json:"org"`
+	Usage platform.OrgUsage     `json:"usage"`
+}
+
+// ListOrgs returns the organizations the token may see, ordered by ID.
+func (c *Client) ListOrgs(ctx context.Context) ([]OrgStatus, error) {
+	var out struct {
+		Orgs []OrgStatus `json:"orgs"`
+	}
+	if err := c.getJSON(ctx, "/api/orgs", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Orgs, nil
+}
+
+// GetOrg returns one organization and its usage.
+func (c *Client) GetOrg(ctx context.Context, orgID string) (OrgStatus, error) {
+	var out OrgStatus
+	err := c.getJSON(ctx, orgPath(orgID), nil, &out)
+	return out, err
+}
+
+// SaveOrg creates org, or replaces its name, workspaces, and quota when
+// replace is set. Only admins may change organizations.
+func (c *Client) SaveOrg(
+	ctx context.Context,
+	org platform.Organization,
+	replace bool,
+) (platform.Organization, error) {
+	body := map[string]any{
+		"id":         org.ID,
+		"name":       org.Name,
+		"workspaces": org.Workspaces,
+		"quota":      org.Quota,
+	}
+	var out platform.Organization
+	if !replace {
+		err := c.doJSON(ctx, http.MethodPost, "/api/orgs", nil, body, &out)
+		return out, err
+	}
+	err := c.doJSON(ctx, http.MethodPut, orgPath(org.ID), nil, body, &out)
+	return out, err
+}
+
+// DeleteOrg removes an organization that no longer holds projects.
+func (c *Client) DeleteOrg(ctx context.Context, orgID string) error {
+	return c.doJSON(ctx, http.MethodDelete, orgPath(orgID), nil, nil, nil)
+}
+
+// ListOrgProjects returns an organization's projects matching filter, in
+// sort order, as FilterProjects does.
+func (c *Client) ListOrgProjects(
+	ctx context.Context,
+	orgID string,
+	filter platform.ProjectFilter,
+	sort string,
+) ([]platform.Project, error) {
+	var out struct {
+		Projects []platform.Project `json:"projects"`
+	}
+	if err := c.getJSON(ctx, orgPath(orgID, "projects"), projectListQuery(filter, sort), &out); err != nil {
+		return nil, err
+	}
+	return out.Projects, nil
+}
+
+func orgPath(orgID string, sub ...string) string {
+	path := "/api/orgs/" + url.PathEscape(orgID)
+	for _, part := range sub {
+		path += "/" + part
+	}
+	return path
+}
@@ -0,0 +1,345 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+// Accepted is the 202 body returned by every endpoint that enqueues an op.
+// Project is empty for deletes, which report ProjectID instead.
+type Accepted struct {
+	Accepted  bool               `json:"accepted"`
+	Unchanged bool               `json:"unchanged,omitempty"`
+	Deleted   bool               `json:"deleted,omitempty"`
+	ProjectID string             `json:"project_id,omitempty"`
+	Project   platform.Project   `json:"project"`
+	Op        platform.Operation `json:"op"`
+}
+
+// ListProjects returns every registered project.
+func (c *Client) ListProjects(ctx context.Context) ([]platform.Project, error) {
+	var projects []platform.Project
+	if err := c.getJSON(ctx, "/api/projects", nil, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// GetProject returns one project by ID.
+func (c *Client) GetProject(ctx context.Context, projectID string) (platform.Project, error) {
+	var project platform.Project
+	err := c.getJSON(ctx, projectPath(projectID), nil, &project)
+	return project, err
+}
+
+// CreateProject registers a project and enqueues its create op.
+func (c *Client) CreateProject(ctx context.Context, spec platform.ProjectSpec) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/projects", c.opQuery(nil), spec, &out)
+	return out, err
+}
+
+// ListTemplates lists the project templates a project can be created from.
+func (c *Client) ListTemplates(ctx context.Context) ([]platform.ProjectTemplate, error) {
+	var out []platform.ProjectTemplate
+	err := c.getJSON(ctx, "/api/templates", nil, &out)
+	return out, err
+}
+
+// ListCapabilities lists the capability plugins a spec can declare, with
+// the options each takes under capabilityOptions.
+func (c *Client) ListCapabilities(ctx context.Context) ([]platform.CapabilityInfo, error) {
+	var out []platform.CapabilityInfo
+	err := c.getJSON(ctx, "/api/capabilities", nil, &out)
+	return out, err
+}
+
+// CreateProjectFromTemplate creates a project from the named template.
+// Overrides is a partial spec laid over the template's, and needs at least
+// a name; a full ProjectSpec would blank the fields the template fills.
+func (c *Client) CreateProjectFromTemplate(
+	ctx context.Context,
+	template string,
+	overrides map[string]any,
+) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/projects/from-template/"+url.PathEscape(template),
+		c.opQuery(nil), overrides, &out)
+	return out, err
+}
+
+// CloneProject creates a project with projectID's spec and a copy of its
+// source repo. Overrides is laid over the copied spec the same way, and must
+// at least give the clone a new name.
+func (c *Client) CloneProject(ctx context.Context, projectID string, overrides map[string]any) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPost, projectPath(projectID)+"/clone", c.opQuery(nil), overrides, &out)
+	return out, err
+}
+
+// UpdateProject replaces a project's spec and enqueues an update op. If the
+// project is Ready with the same spec_hash, no op is enqueued and the result
+// has Unchanged set instead.
+func (c *Client) UpdateProject(ctx context.Context, projectID string, spec platform.ProjectSpec) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPut, projectPath(projectID), c.opQuery(nil), spec, &out)
+	return out, err
+}
+
+// ValidateSpec checks spec as create and update would and returns the
+// artifacts it renders to, without storing anything. An invalid spec is not
+// an error; its problems are in the result's Errors.
+func (c *Client) ValidateSpec(ctx context.Context, spec platform.ProjectSpec) (platform.SpecValidationResponse, error) {
+	var out platform.SpecValidationResponse
+	err := c.doJSON(ctx, http.MethodPost, "/api/projects/validate", nil, spec, &out)
+	return out, err
+}
+
+// PlanDelete inventories what deleting the project would remove and makes
+// the returned plan the project's pending one.
+func (c *Client) PlanDelete(ctx context.Context, projectID string) (platform.DeletePlan, error) {
+	var plan platform.DeletePlan
+	err := c.doJSON(ctx, http.MethodPost, projectPath(projectID, "delete-plan"), nil, nil, &plan)
+	return plan, err
+}
+
+// DeleteProject applies the delete plan planID and enqueues a delete op. The
+// project is removed once the op finishes; a compliance hold or a stale,
+// expired, or missing plan makes this fail with a conflict. A plan whose
+// RequiresAcknowledgement is set needs acknowledgeImpact, after the caller
+// has reviewed its Impact.
+func (c *Client) DeleteProject(
+	ctx context.Context,
+	projectID, planID string,
+	acknowledgeImpact bool,
+) (Accepted, error) {
+	var out Accepted
+	query := url.Values{"plan_id": {planID}}
+	if acknowledgeImpact {
+		query.Set("acknowledge_impact", "true")
+	}
+	err := c.doJSON(ctx, http.MethodDelete, projectPath(projectID), c.opQuery(query), nil, &out)
+	return out, err
+}
+
+// EffectiveConfig is an environment's resolved vars: the project's shared
+// vars block overlaid with the environment's overrides.
+type EffectiveConfig struct {
+	ProjectID   string            `json:"project_id"`
+	Environment string            `json:"environment"`
+	Vars        map[string]string `json:"vars"`
+	Sources     map[string]string `json:"sources"`
+	Overridden  []string          `json:"overridden"`
+}
+
+// GetEffectiveConfig resolves the vars an environment deploys with.
+func (c *Client) GetEffectiveConfig(ctx context.Context, projectID, env string) (EffectiveConfig, error) {
+	var out EffectiveConfig
+	path := projectPath(projectID, "environments", url.PathEscape(env), "effective-config")
+	err := c.getJSON(ctx, path, nil, &out)
+	return out, err
+}
+
+// VarPatch is one change to an environment's vars; see PatchVars. Op is
+// add, update, or remove. Expected, on update and remove, is the value the
+// caller last read: the patch fails with a conflict if the var holds
+// anything else.
+type VarPatch struct {
+	Op       string  `json:"op"`
+	Name     string  `json:"name"`
+	Value    string  `json:"value,omitempty"`
+	Expected *string `json:"expected,omitempty"`
+}
+
+// VarsPatched is the result of PatchVars: the ci op that rolls the change
+// out, the environment it re-renders, and the environment's vars after the
+// patch. Unchanged is set, with no op, when the patch changed nothing.
+type VarsPatched struct {
+	Accepted          bool                `json:"accepted"`
+	Unchanged         bool                `json:"unchanged,omitempty"`
+	Project           platform.Project    `json:"project"`
+	Op                *platform.Operation `json:"op,omitempty"`
+	RenderEnvironment string              `json:"render_environment,omitempty"`
+	EffectiveConfig   EffectiveConfig     `json:"effective_config"`
+}
+
+// PatchVars applies patches to env's own vars, in order, and enqueues a ci
+// op that re-renders the environment with the image it runs.
+func (c *Client) PatchVars(ctx context.Context, projectID, env string, patches ...VarPatch) (VarsPatched, error) {
+	var out VarsPatched
+	body := map[string][]VarPatch{"operations": patches}
+	path := projectPath(projectID, "environments", url.PathEscape(env), "vars")
+	err := c.doJSON(ctx, http.MethodPatch, path, c.opQuery(nil), body, &out)
+	return out, err
+}
+
+// EnvironmentBindings is an environment's capability bindings plus the
+// declared capabilities it leaves unbound.
+type EnvironmentBindings struct {
+	ProjectID   string                       `json:"project_id"`
+	Environment string                       `json:"environment"`
+	Bindings    []platform.CapabilityBinding `json:"bindings"`
+	Unbound     []string                     `json:"unbound"`
+}
+
+// CapabilityBindingInput binds one capability; see PutBinding.
+type CapabilityBindingInput struct {
+	Type         platform.CapabilityBindingType   `json:"type"`
+	Image        string                           `json:"image,omitempty"`
+	ContainerEnv map[string]string                `json:"container_env,omitempty"`
+	Env          map[string]string                `json:"env,omitempty"`
+	SecretEnv    map[string]platform.SecretKeyRef `json:"secret_env,omitempty"`
+}
+
+// ListBindings returns how each capability is bound in env.
+func (c *Client) ListBindings(ctx context.Context, projectID, env string) (EnvironmentBindings, error) {
+	var out EnvironmentBindings
+	err := c.getJSON(ctx, projectPath(projectID, "environments", url.PathEscape(env), "bindings"), nil, &out)
+	return out, err
+}
+
+// PutBinding binds capability in env, replacing any earlier binding. It
+// takes effect on the environment's next deploy, promotion, or rollback.
+func (c *Client) PutBinding(
+	ctx context.Context,
+	projectID, env, capability string,
+	in CapabilityBindingInput,
+) (platform.CapabilityBinding, error) {
+	var out platform.CapabilityBinding
+	path := projectPath(projectID, "environments", url.PathEscape(env), "bindings", url.PathEscape(capability))
+	err := c.doJSON(ctx, http.MethodPut, path, nil, in, &out)
+	return out, err
+}
+
+// DeleteBinding removes capability's binding in env and returns it.
+func (c *Client) DeleteBinding(ctx context.Context, projectID, env, capability string) (platform.CapabilityBinding, error) {
+	var out struct {
+		Binding platform.CapabilityBinding `json:"binding"`
+	}
+	path := projectPath(projectID, "environments", url.PathEscape(env), "bindings", url.PathEscape(capability))
+	err := c.doJSON(ctx, http.MethodDelete, path, nil, nil, &out)
+	return out.Binding, err
+}
+
+// StoredSecret is one stored secret as the API lists it. Value is always a
+// fixed mask; stored values are never returned.
+type StoredSecret struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StoredSecrets is an environment's stored secrets.
+type StoredSecrets struct {
+	ProjectID   string         `json:"project_id"`
+	Environment string         `json:"environment"`
+	Secrets     []StoredSecret `json:"secrets"`
+}
+
+// ListSecrets returns the names of env's stored secrets, values masked.
+func (c *Client) ListSecrets(ctx context.Context, projectID, env string) (StoredSecrets, error) {
+	var out StoredSecrets
+	err := c.getJSON(ctx, projectPath(projectID, "secrets", url.PathEscape(env)), nil, &out)
+	return out, err
+}
+
+// PutSecrets encrypts and stores values in env, leaving its other stored
+// secrets alone. They take effect on the environment's next render.
+func (c *Client) PutSecrets(
+	ctx context.Context,
+	projectID, env string,
+	values map[string]string,
+) (StoredSecrets, error) {
+	var out StoredSecrets
+	body := map[string]map[string]string{"secrets": values}
+	err := c.doJSON(ctx, http.MethodPost, projectPath(projectID, "secrets", url.PathEscape(env)), nil, body, &out)
+	return out, err
+}
+
+// DeleteSecrets removes the named stored secrets from env, or all of them
+// when no names are given, and returns the names removed.
+func (c *Client) DeleteSecrets(ctx context.Context, projectID, env string, names ...string) ([]string, error) {
+	var out struct {
+		Removed []string `json:"removed"`
+	}
+	query := url.Values{}
+	for _, name := range names {
+		query.Add("name", name)
+	}
+	err := c.doJSON(ctx, http.MethodDelete, projectPath(projectID, "secrets", url.PathEscape(env)), query, nil, &out)
+	return out.Removed, err
+}
+
+// ProjectAtOp is a project reconstructed as it stood right after one op.
+// Spec is nil once KV history no longer reaches the op; Warnings explain any
+// part that could not be recovered.
+type ProjectAtOp struct {
+	ProjectID    string                `json:"project_id"`
+	Op           platform.Operation    `json:"op"`
+	At           time.Time             `json:"at"`
+	Spec         *platform.ProjectSpec `json:"spec,omitempty"`
+	SpecRevision uint64                `json:"spec_revision,omitempty"`
+	Environments []EnvironmentAtOp     `json:"environments"`
+	Warnings     []string              `json:"warnings"`
+}
+
+// EnvironmentAtOp is one environment's release, image, and rendered
+// manifests as of the op.
+type EnvironmentAtOp struct {
+	Environment string                  `json:"environment"`
+	Release     *platform.ReleaseRecord `json:"release,omitempty"`
+	Image       string                  `json:"image,omitempty"`
+	Rendered    string                  `json:"rendered,omitempty"`
+}
+
+// GetProjectAt reconstructs the project's spec and environments as they were
+// immediately after opID finished.
+func (c *Client) GetProjectAt(ctx context.Context, projectID, opID string) (ProjectAtOp, error) {
+	var out ProjectAtOp
+	err := c.getJSON(ctx, projectPath(projectID, "at"), url.Values{"op": {opID}}, &out)
+	return out, err
+}
+
+// ProjectQuota is a project's usage against each configured quota.
+type ProjectQuota struct {
+	ProjectID string       `json:"project_id"`
+	Quotas    []QuotaUsage `json:"quotas"`
+}
+
+// QuotaUsage is one quota: concurrent_ops, ops_per_hour, or artifact_bytes.
+// Exhausted means the next op is refused; ResetsAt is set for ops_per_hour.
+type QuotaUsage struct {
+	Name      string    `json:"name"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Exhausted bool      `json:"exhausted"`
+	ResetsAt  time.Time `json:"resets_at,omitzero"`
+}
+
+// GetProjectQuota returns the project's quota usage. Quotas the server does
+// not configure are left out.
+func (c *Client) GetProjectQuota(ctx context.Context, projectID string) (ProjectQuota, error) {
+	var out ProjectQuota
+	err := c.getJSON(ctx, projectPath(projectID, "quota"), nil, &out)
+	return out, err
+}
+
+// GetGraph returns the project dependency graph: a node per project and an
+// edge from each project to every project its spec needs.
+func (c *Client) GetGraph(ctx context.Context) (platform.ProjectGraph, error) {
+	var out platform.ProjectGraph
+	err := c.getJSON(ctx, "/api/graph", nil, &out)
+	return out, err
+}
+
+func projectPath(projectID string, sub ...string) string {
+	path := "/api/projects/" + url.PathEscape(projectID)
+	for _, part := range sub {
+		path += "/" + part
+	}
+	return path
+}
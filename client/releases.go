@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+// ReleasePage is one page of an environment's release timeline, newest first.
+type ReleasePage struct {
+	Items      []platform.ReleaseRecord `json:"items"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+// ReleaseDetail is a release record plus the compliance hold pinning it, if
+// any.
+type ReleaseDetail struct {
+	platform.ReleaseRecord
+
+	Hold *platform.ComplianceHold `json:"hold,omitempty"`
+}
+
+// ListReleasesOptions pages through one environment's releases. Environment
+// is required.
+type ListReleasesOptions struct {
+	Environment string
+	Limit       int
+	Cursor      string
+}
+
+// ListReleases returns one page of a project environment's releases.
+func (c *Client) ListReleases(ctx context.Context, projectID string, opts ListReleasesOptions) (ReleasePage, error) {
+	query := url.Values{}
+	query.Set("environment", opts.Environment)
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	var page ReleasePage
+	err := c.getJSON(ctx, projectPath(projectID, "releases"), query, &page)
+	return page, err
+}
+
+// GetRelease returns one release of a project.
+func (c *Client) GetRelease(ctx context.Context, projectID, releaseID string) (ReleaseDetail, error) {
+	var detail ReleaseDetail
+	err := c.getJSON(ctx, projectPath(projectID, "releases", url.PathEscape(releaseID)), nil, &detail)
+	return detail, err
+}
+
+// GetReleaseNotes returns a release's notes: its source commits and the
+// image and config changes since the environment's previous release.
+func (c *Client) GetReleaseNotes(ctx context.Context, projectID, releaseID string) (platform.ReleaseNotes, error) {
+	var notes platform.ReleaseNotes
+	err := c.getJSON(ctx, projectPath(projectID, "releases", url.PathEscape(releaseID), "notes"), nil, &notes)
+	return notes, err
+}
+
+// CompareReleases diffs image, config, and rendered manifests between two
+// releases of a project.
+func (c *Client) CompareReleases(
+	ctx context.Context,
+	projectID, fromID, toID string,
+) (platform.ReleaseCompareResponse, error) {
+	query := url.Values{}
+	query.Set("from", fromID)
+	query.Set("to", toID)
+	var out platform.ReleaseCompareResponse
+	err := c.getJSON(ctx, projectPath(projectID, "releases", "compare"), query, &out)
+	return out, err
+}
+
+// Deploy enqueues a deploy of the project's current build to dev.
+func (c *Client) Deploy(ctx context.Context, evt platform.DeploymentEvent) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/events/deployment", c.opQuery(nil), evt, &out)
+	return out, err
+}
+
+// PreviewPromotion evaluates the gates for promoting between environments
+// without enqueueing anything.
+func (c *Client) PreviewPromotion(
+	ctx context.Context,
+	evt platform.PromotionEvent,
+) (platform.PromotionPreviewResponse, error) {
+	var out platform.PromotionPreviewResponse
+	err := c.doJSON(ctx, http.MethodPost, "/api/events/promotion/preview", nil, evt, &out)
+	return out, err
+}
+
+// PlanPromotion simulates promoting dev's image through every environment of
+// the project and reports the gates at each hop.
+func (c *Client) PlanPromotion(ctx context.Context, projectID string) (platform.PromotionPlanResponse, error) {
+	var out platform.PromotionPlanResponse
+	err := c.getJSON(ctx, projectPath(projectID, "promotion-plan"), nil, &out)
+	return out, err
+}
+
+// Promote enqueues a promotion of the source environment's release.
+func (c *Client) Promote(ctx context.Context, evt platform.PromotionEvent) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/events/promotion", c.opQuery(nil), evt, &out)
+	return out, err
+}
+
+// GetCanary returns the canary a canary promotion started in env.
+func (c *Client) GetCanary(ctx context.Context, projectID, env string) (platform.CanaryRollout, error) {
+	var out platform.CanaryRollout
+	err := c.getJSON(ctx, projectPath(projectID, "environments", url.PathEscape(env), "canary"), nil, &out)
+	return out, err
+}
+
+// EndCanary enqueues the op that ends env's canary: action promote rolls
+// the canary image out to every replica, abort keeps the stable image.
+func (c *Client) EndCanary(
+	ctx context.Context,
+	projectID, env string,
+	action platform.CanaryAction,
+) (Accepted, error) {
+	var out Accepted
+	path := projectPath(projectID, "environments", url.PathEscape(env), "canary", string(action))
+	err := c.doJSON(ctx, http.MethodPost, path, c.opQuery(nil), nil, &out)
+	return out, err
+}
+
+// Release enqueues a release to production (or evt.ToEnv when set).
+func (c *Client) Release(ctx context.Context, evt platform.ReleaseEvent) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/events/release", c.opQuery(nil), evt, &out)
+	return out, err
+}
+
+// GetBlueGreen returns the colors of a blue/green environment.
+func (c *Client) GetBlueGreen(ctx context.Context, projectID, env string) (platform.BlueGreenRelease, error) {
+	var out platform.BlueGreenRelease
+	err := c.getJSON(ctx, projectPath(projectID, "environments", url.PathEscape(env), "blue-green"), nil, &out)
+	return out, err
+}
+
+// Cutover enqueues the op that switches a blue/green environment's traffic
+// to its staged color, or back to the previous one when evt.Rollback is set.
+func (c *Client) Cutover(ctx context.Context, evt platform.ReleaseCutoverEvent) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/events/release/cutover", c.opQuery(nil), evt, &out)
+	return out, err
+}
+
+// PreviewRollback evaluates a rollback to a previous release without
+// enqueueing anything.
+func (c *Client) PreviewRollback(
+	ctx context.Context,
+	evt platform.RollbackEvent,
+) (platform.RollbackPreviewResponse, error) {
+	var out platform.RollbackPreviewResponse
+	err := c.doJSON(ctx, http.MethodPost, "/api/events/rollback/preview", nil, evt, &out)
+	return out, err
+}
+
+// Rollback enqueues a rollback. When the preview is not ready the server
+// answers 400 and the returned *Error carries the preview in Body.
+func (c *Client) Rollback(ctx context.Context, evt platform.RollbackEvent) (Accepted, error) {
+	var out Accepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/events/rollback", c.opQuery(nil), evt, &out)
+	return out, err
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+// FilterProjects returns the projects matching filter, ordered by sort: one
+// of name, created_at, updated_at, or phase, with a leading "-" to reverse
+// it. An empty filter and sort return the same list as ListProjects.
+func (c *Client) FilterProjects(
+	ctx context.Context,
+	filter platform.ProjectFilter,
+	sort string,
+) ([]platform.Project, error) {
+	var projects []platform.Project
+	if err := c.getJSON(ctx, "/api/projects", projectListQuery(filter, sort), &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// ProjectPage is one page of projects. NextCursor is empty on the last page.
+type ProjectPage struct {
+	Items      []platform.Project `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// ListProjectsPage returns up to limit projects matching filter in sort
+// order, starting after cursor (a previous page's NextCursor; empty for
+// the first page). A limit of 0 uses the server default.
+func (c *Client) ListProjectsPage(
+	ctx context.Context,
+	filter platform.ProjectFilter,
+	sort string,
+	limit int,
+	cursor string,
+) (ProjectPage, error) {
+	query := projectListQuery(filter, sort)
+	// An empty limit still asks for a page, of the server's default size.
+	query.Set("limit", "")
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	var page ProjectPage
+	err := c.getJSON(ctx, "/api/projects", query, &page)
+	return page, err
+}
+
+func projectListQuery(filter platform.ProjectFilter, sort string) url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"name":        filter.Name,
+		"name_prefix": filter.NamePrefix,
+		"phase":       filter.Phase,
+		"team":        filter.Team,
+		"owner":       filter.Owner,
+		"environment": filter.Environment,
+		"runtime":     filter.Runtime,
+		"capability":  filter.Capability,
+		"org":         filter.Org,
+		"workspace":   filter.Workspace,
+		"sort":        sort,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query
+}
+
+// ListViews returns every saved view, ordered by name.
+func (c *Client) ListViews(ctx context.Context) ([]platform.ProjectView, error) {
+	var out struct {
+		Views []platform.ProjectView `json:"views"`
+	}
+	if err := c.getJSON(ctx, "/api/views", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Views, nil
+}
+
+// GetView returns one saved view by ID.
+func (c *Client) GetView(ctx context.Context, viewID string) (platform.ProjectView, error) {
+	var view platform.ProjectView
+	err := c.getJSON(ctx, viewPath(viewID), nil, &view)
+	return view, err
+}
+
+// SaveView creates view when its ID is empty and replaces the stored view
+// otherwise. Only Name, Description, Filter, and Sort are sent.
+func (c *Client) SaveView(ctx context.Context, view platform.ProjectView) (platform.ProjectView, error) {
+	body := map[string]any{
+		"name":        view.Name,
+		"description": view.Description,
+		"filter":      view.Filter,
+		"sort":        view.Sort,
+	}
+	var saved platform.ProjectView
+	if view.ID == "" {
+		err := c.doJSON(ctx, http.MethodPost, "/api/views", nil, body, &saved)
+		return saved, err
+	}
+	err := c.doJSON(ctx, http.MethodPut, viewPath(view.ID), nil, body, &saved)
+	return saved, err
+}
+
+// DeleteView removes a saved view.
+func (c *Client) DeleteView(ctx context.Context, viewID string) error {
+	return c.doJSON(ctx, http.MethodDelete, viewPath(viewID), nil, nil, nil)
+}
+
+// ViewProjects returns the projects a saved view currently selects, in the
+// view's sort order.
+func (c *Client) ViewProjects(ctx context.Context, viewID string) ([]platform.Project, error) {
+	var out struct {
+		Projects []platform.Project `json:"projects"`
+	}
+	if err := c.getJSON(ctx, viewPath(viewID, "projects"), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Projects, nil
+}
+
+func viewPath(viewID string, sub ...string) string {
+	path := "/api/views/" + url.PathEscape(viewID)
+	for _, part := range sub {
+		path += "/" + part
+	}
+	return path
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+func main() {
+	os.Exit(platform.RunAdmin(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+
+	"github.com/a2y-d5l/go-web-nats/ctl"
+)
+
+func main() {
+	os.Exit(ctl.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
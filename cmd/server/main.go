@@ -1,7 +1,17 @@
 package main
 
-import platform "github.com/a2y-d5l/go-web-nats"
+import (
+	"flag"
+	"os"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
 
 func main() {
+	selfTest := flag.Bool("self-test", false, "run a synthetic project through the pipeline in a temp dir and exit")
+	flag.Parse()
+	if *selfTest {
+		os.Exit(platform.RunSelfTest(os.Stdout))
+	}
 	platform.Run()
 }
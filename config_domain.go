@@ -18,4 +18,16 @@ const (
 	projectPhaseDel       = "Deleting"
 	statusMessageQueued   = "queued"
 	statusMessageDelQueue = "queued delete"
+
+	// Per-project namespace guardrails rendered into every overlay. The quota
+	// can be changed with PAAS_NAMESPACE_QUOTA; see workers_render_resources.go.
+	namespaceQuotaRequestsCPU          = "2"
+	namespaceQuotaRequestsMemory       = "2Gi"
+	namespaceQuotaLimitsCPU            = "4"
+	namespaceQuotaLimitsMemory         = "4Gi"
+	namespaceQuotaPods                 = 20
+	namespaceLimitDefaultRequestCPU    = "100m"
+	namespaceLimitDefaultRequestMemory = "128Mi"
+	namespaceLimitDefaultCPU           = "500m"
+	namespaceLimitDefaultMemory        = "512Mi"
 )
@@ -12,4 +12,8 @@ const (
 	dirModePrivateRead  os.FileMode = 0o750
 
 	projectRelPathPartsMin = 2
+
+	// artifactOrgsDir holds one directory per organization, each holding its
+	// projects' trees.
+	artifactOrgsDir = "orgs"
 )
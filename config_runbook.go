@@ -0,0 +1,177 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Runbook hooks: PAAS_RUNBOOK_FILE maps failure codes to remediation actions
+// for failure modes known to be flaky. A failed op's code is the pipeline
+// stage that failed plus whether it timed out, e.g. build_timeout or
+// deploy_failed.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	remediationClearBuildCache = "clear_build_cache"
+	remediationRetry           = "retry"
+
+	defaultRunbookMaxAttempts = 1
+	maxRunbookMaxAttempts     = 5
+	maxRunbookHooks           = 50
+
+	failureCodeTimeoutSuffix = "_timeout"
+	failureCodeFailedSuffix  = "_failed"
+)
+
+// runbookHook runs Actions, in order, on ops failing with FailureCode.
+// MaxAttempts caps how many retries one original op gets from the hook.
+type runbookHook struct {
+	Name        string          `json:"name"`
+	FailureCode string          `json:"failure_code"`
+	Kinds       []OperationKind `json:"kinds,omitempty"`
+	Actions     []string        `json:"actions"`
+	MaxAttempts int             `json:"max_attempts,omitempty"`
+}
+
+type runbookConfig struct {
+	Hooks []runbookHook `json:"hooks"`
+}
+
+func runbookFromEnv() (runbookConfig, error) {
+	path := strings.TrimSpace(os.Getenv(runbookFileEnv))
+	if path == "" {
+		return runbookConfig{Hooks: nil}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return runbookConfig{}, fmt.Errorf("%s: %w", runbookFileEnv, err)
+	}
+	runbook, err := parseRunbookConfig(raw)
+	if err != nil {
+		return runbookConfig{}, fmt.Errorf("%s %s: %w", runbookFileEnv, path, err)
+	}
+	return runbook, nil
+}
+
+func parseRunbookConfig(raw []byte) (runbookConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var runbook runbookConfig
+	if err := decoder.Decode(&runbook); err != nil {
+		return runbookConfig{}, err
+	}
+	if len(runbook.Hooks) > maxRunbookHooks {
+		return runbookConfig{}, fmt.Errorf("at most %d hooks", maxRunbookHooks)
+	}
+	names := map[string]bool{}
+	for i := range runbook.Hooks {
+		hook := &runbook.Hooks[i]
+		if err := hook.validate(); err != nil {
+			return runbookConfig{}, fmt.Errorf("hooks[%d]: %w", i, err)
+		}
+		if names[hook.Name] {
+			return runbookConfig{}, fmt.Errorf("hooks[%d]: duplicate name %q", i, hook.Name)
+		}
+		names[hook.Name] = true
+	}
+	return runbook, nil
+}
+
+func (h *runbookHook) validate() error {
+	h.Name = strings.TrimSpace(h.Name)
+	if !projectNameRe.MatchString(h.Name) {
+		return fmt.Errorf("name must match %s", projectNameRe.String())
+	}
+	if !slices.Contains(failureCodes(), h.FailureCode) {
+		return fmt.Errorf("unknown failure_code %q (one of %s)", h.FailureCode, strings.Join(failureCodes(), ", "))
+	}
+	for _, kind := range h.Kinds {
+		if !slices.Contains(allOperationKinds(), kind) {
+			return fmt.Errorf("unknown kind %q", kind)
+		}
+	}
+	if len(h.Actions) == 0 {
+		return errors.New("actions required")
+	}
+	for _, action := range h.Actions {
+		if action != remediationClearBuildCache && action != remediationRetry {
+			return fmt.Errorf("unknown action %q (one of %s, %s)", action, remediationClearBuildCache, remediationRetry)
+		}
+	}
+	if h.MaxAttempts == 0 {
+		h.MaxAttempts = defaultRunbookMaxAttempts
+	}
+	if h.MaxAttempts < 0 || h.MaxAttempts > maxRunbookMaxAttempts {
+		return fmt.Errorf("max_attempts must be 1-%d", maxRunbookMaxAttempts)
+	}
+	return nil
+}
+
+// hookFor returns the first hook matching op's kind and failure code.
+func (c runbookConfig) hookFor(kind OperationKind, code string) (runbookHook, bool) {
+	for _, hook := range c.Hooks {
+		if hook.FailureCode == code && (len(hook.Kinds) == 0 || slices.Contains(hook.Kinds, kind)) {
+			return hook, true
+		}
+	}
+	return runbookHook{}, false
+}
+
+// failureStages maps the pipeline workers to the stage a failure code names.
+// Failures no step owns use "op".
+func failureStages() map[string]string {
+	return map[string]string{
+		"registrar":        "registration",
+		"repoBootstrap":    "bootstrap",
+		"imageBuilder":     "build",
+		"manifestRenderer": "render",
+		"deployer":         "deploy",
+		"promoter":         "promotion",
+		"artifactCleaner":  "cleanup",
+		"runtimeUpgrader":  "upgrade",
+	}
+}
+
+func failureCodes() []string {
+	stages := []string{"op"}
+	for _, stage := range failureStages() {
+		stages = append(stages, stage)
+	}
+	slices.Sort(stages)
+	codes := []string{}
+	for _, stage := range stages {
+		codes = append(codes, stage+failureCodeFailedSuffix, stage+failureCodeTimeoutSuffix)
+	}
+	return codes
+}
+
+// opFailureCode classifies a failed op by its first failed step, the one the
+// later stages skipped over.
+func opFailureCode(op Operation) string {
+	stage, errText := "op", op.Error
+	for _, step := range op.Steps {
+		if step.Error == "" {
+			continue
+		}
+		if named, ok := failureStages()[step.Worker]; ok {
+			stage, errText = named, step.Error
+		}
+		break
+	}
+	if isTimeoutError(errText) {
+		return stage + failureCodeTimeoutSuffix
+	}
+	return stage + failureCodeFailedSuffix
+}
+
+func isTimeoutError(text string) bool {
+	text = strings.ToLower(text)
+	return strings.Contains(text, "deadline exceeded") || strings.Contains(text, "timed out") ||
+		strings.Contains(text, "timeout")
+}
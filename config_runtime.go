@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,15 +19,80 @@ import (
 type imageBuilderMode string
 
 const (
+	// Runtime config file and the overrides it shares with env.
+	configFileEnv      = "PAAS_CONFIG_FILE"
+	httpAddrEnv        = "PAAS_HTTP_ADDR"
+	gitTimeoutEnv      = "PAAS_GIT_TIMEOUT"
+	gitReadTimeoutEnv  = "PAAS_GIT_READ_TIMEOUT"
+	shutdownTimeoutEnv = "PAAS_SHUTDOWN_TIMEOUT"
+	opStaleTTLEnv      = "PAAS_OP_STALE_TTL"
+	opSLAQueuedEnv     = "PAAS_OP_SLA_QUEUED"
+	opSLARunningEnv    = "PAAS_OP_SLA_RUNNING"
+
 	// HTTP.
-	httpAddr = "127.0.0.1:8080"
+	defaultHTTPAddr = "127.0.0.1:8080"
 
 	// Where workers write artifacts.
-	artifactsRootEnv       = "PAAS_ARTIFACTS_ROOT"
-	legacyArtifactsRoot    = "./data/artifacts"
-	artifactsAppFolderName = "EmbeddedWebApp-HTTPAPI-BackendNATS"
-	imageBuilderModeEnv    = "PAAS_IMAGE_BUILDER_MODE"
-	natsStoreDirEnv        = "PAAS_NATS_STORE_DIR"
+	artifactsRootEnv             = "PAAS_ARTIFACTS_ROOT"
+	artifactRootsEnv             = "PAAS_ARTIFACT_ROOTS"
+	legacyArtifactsRoot          = "./data/artifacts"
+	artifactsAppFolderName       = "EmbeddedWebApp-HTTPAPI-BackendNATS"
+	imageBuilderModeEnv          = "PAAS_IMAGE_BUILDER_MODE"
+	natsStoreDirEnv              = "PAAS_NATS_STORE_DIR"
+	natsURLEnv                   = "PAAS_NATS_URL"
+	natsCredsEnv                 = "PAAS_NATS_CREDS"
+	natsTLSCAEnv                 = "PAAS_NATS_TLS_CA"
+	natsTLSCertEnv               = "PAAS_NATS_TLS_CERT"
+	natsTLSKeyEnv                = "PAAS_NATS_TLS_KEY"
+	natsEmbeddedAuthEnv          = "PAAS_NATS_EMBEDDED_AUTH"
+	subjectPrefixEnv             = "PAAS_SUBJECT_PREFIX"
+	namespaceProvisioningEnv     = "PAAS_NAMESPACE_PROVISIONING"
+	namespaceQuotaEnv            = "PAAS_NAMESPACE_QUOTA"
+	serviceAccountAnnotationsEnv = "PAAS_SERVICE_ACCOUNT_ANNOTATIONS"
+	storeSlowThresholdEnv        = "PAAS_STORE_SLOW_THRESHOLD"
+	workerOpCacheTTLEnv          = "PAAS_WORKER_OP_CACHE_TTL"
+	storeReadCacheEnv            = "PAAS_STORE_READ_CACHE"
+	opStepsMaxEnv                = "PAAS_OP_STEPS_MAX"
+	opValueMaxBytesEnv           = "PAAS_OP_MAX_BYTES"
+	kvProjectHistoryEnv          = "PAAS_KV_PROJECT_HISTORY"
+	kvOpsHistoryEnv              = "PAAS_KV_OPS_HISTORY"
+	readinessModeEnv             = "PAAS_READINESS_MODE"
+	specExtensionsFileEnv        = "PAAS_SPEC_EXTENSIONS_FILE"
+	templatesDirEnv              = "PAAS_TEMPLATES_DIR"
+	gitFileRemotesEnv            = "PAAS_GIT_FILE_REMOTES"
+	workerMaxDeliverEnv          = "PAAS_WORKER_MAX_DELIVER"
+	workerRetryBackoffEnv        = "PAAS_WORKER_RETRY_BACKOFF"
+	workerConcurrencyEnv         = "PAAS_WORKER_CONCURRENCY"
+	workerLimitsEnv              = "PAAS_WORKER_LIMITS"
+	vaultAddrEnv                 = "PAAS_VAULT_ADDR"
+	vaultTokenEnv                = "PAAS_VAULT_TOKEN"
+	vaultRoleIDEnv               = "PAAS_VAULT_ROLE_ID"
+	vaultSecretIDEnv             = "PAAS_VAULT_SECRET_ID"
+	vaultAppRoleMountEnv         = "PAAS_VAULT_APPROLE_MOUNT"
+	vaultNamespaceEnv            = "PAAS_VAULT_NAMESPACE"
+	sopsBinaryEnv                = "PAAS_SOPS_BIN"
+	vulnBudgetEnv                = "PAAS_VULN_BUDGET"
+	operatorTokenEnv             = "PAAS_OPERATOR_TOKEN"
+	apiAuthEnv                   = "PAAS_API_AUTH"
+	sourceWebhookSecretEnv       = "PAAS_SOURCE_WEBHOOK_SECRET"
+	packBinaryEnv                = "PAAS_PACK_BIN"
+	buildpacksBuilderEnv         = "PAAS_BUILDPACKS_BUILDER"
+	kubeApplyEnv                 = "PAAS_KUBE_APPLY"
+	kubectlBinaryEnv             = "PAAS_KUBECTL_BIN"
+	kubeContextEnv               = "PAAS_KUBE_CONTEXT"
+	kubeRolloutTimeoutEnv        = "PAAS_KUBE_ROLLOUT_TIMEOUT"
+	postgresProvisionerEnv       = "PAAS_POSTGRES_PROVISIONER"
+	dockerBinaryEnv              = "PAAS_DOCKER_BIN"
+	postgresHostEnv              = "PAAS_POSTGRES_HOST"
+	secretsKeyEnv                = "PAAS_SECRETS_KEY"
+	runbookFileEnv               = "PAAS_RUNBOOK_FILE"
+	freezeFileEnv                = "PAAS_FREEZE_FILE"
+	releaseApprovalsEnv          = "PAAS_RELEASE_APPROVALS"
+	artifactUploadPathsEnv       = "PAAS_ARTIFACT_UPLOAD_PATHS"
+	healthProbeIntervalEnv       = "PAAS_HEALTH_PROBE_INTERVAL"
+	healthProbePathEnv           = "PAAS_HEALTH_PROBE_PATH"
+	projectQuotasEnv             = "PAAS_PROJECT_QUOTAS"
+	apiRateLimitEnv              = "PAAS_API_RATE_LIMIT"
 
 	defaultNATSStoreDir       = "./data/nats"
 	natsStoreDirModeTemp      = "temp"
@@ -42,12 +108,33 @@ const (
 	defaultStartupWait        = 10 * time.Second
 	defaultShutdownWait       = 10 * time.Second
 	defaultReadHeaderWait     = 5 * time.Second
-	apiWaitTimeout            = 45 * time.Second
-	gitOpTimeout              = 20 * time.Second
-	gitReadTimeout            = 10 * time.Second
+	defaultReadWait           = 30 * time.Second
+	defaultWriteWait          = 60 * time.Second
+	defaultIdleWait           = 120 * time.Second
+	sseFrameWriteWait         = 30 * time.Second
+	defaultGitTimeout         = 20 * time.Second
+	defaultGitReadTimeout     = 10 * time.Second
+	defaultShutdownTimeout    = 30 * time.Second
+	defaultOpStaleTTL         = 30 * time.Minute
+	opReapInterval            = time.Minute
+	defaultOpSLAQueued        = 2 * time.Minute
+	defaultOpSLARunning       = 15 * time.Minute
+	opSLACheckInterval        = 15 * time.Second
 	commitWatcherPollInterval = 2 * time.Second
 	opEventsRetention         = 30 * time.Minute
 	opEventsHeartbeatInterval = 10 * time.Second
+	opStepHeartbeatInterval   = 10 * time.Second
+	defaultStoreSlowThreshold = 250 * time.Millisecond
+	defaultWorkerOpCacheTTL   = 2 * time.Second
+	opCompactionInterval      = 10 * time.Minute
+	leaderLeaseTTL            = 15 * time.Second
+	leaderLeaseRenewDivisor   = 3
+	deletePlanTTL             = 15 * time.Minute
+	opResumeGrace             = 30 * time.Second
+	secretResolveTimeout      = 10 * time.Second
+
+	workerReadyHeartbeatInterval = 5 * time.Second
+	workerReadyMissedHeartbeats  = 3
 
 	shortIDLength                      = 12
 	httpServerErrThreshold             = 500
@@ -63,14 +150,35 @@ const (
 	projectReleaseDefaultLimit         = 20
 	projectReleaseMaxLimit             = 100
 	projectReleaseHistoryCap           = 200
+	projectListDefaultLimit            = 50
+	projectListMaxLimit                = 500
+	defaultOpStepsMax                  = 64
+	defaultOpValueMaxBytes             = 256 * 1024
+
+	specBodyMaxBytes    = 1 << 20
+	eventBodyMaxBytes   = 64 * 1024
+	webhookBodyMaxBytes = 1 << 20
+
+	artifactUploadMaxBytes = 8 << 20
+
+	artifactDownloadSlots          = 8
+	artifactDownloadBaseWait       = 10 * time.Second
+	artifactDownloadMinBytesPerSec = 64 * 1024
+
+	workerDeliveryAckWait           = 15 * time.Second
+	workerDeliveryFetchWait         = 2 * time.Second
+	workerQueueKeepAlive            = 5 * time.Second
+	defaultWorkerDeliveryMaxDeliver = 5
+	defaultWorkerConcurrency        = 1
+	maxWorkerConcurrency            = 32
 
-	workerDeliveryAckWait    = 15 * time.Second
-	workerDeliveryFetchWait  = 2 * time.Second
-	workerDeliveryMaxDeliver = 5
+	idempotencyKeyTTL = 24 * time.Hour
 
 	workerDeliveryStreamMaxAge   = 24 * time.Hour
 	workerDeliveryStreamMaxMsgs  = int64(20000)
 	workerDeliveryStreamMaxBytes = int64(64 * 1024 * 1024)
+	opEventsJournalMaxAge        = 24 * time.Hour
+	opEventsJournalLoadTimeout   = 2 * time.Second
 
 	finalResultConsumerAckWait      = 15 * time.Second
 	finalResultConsumerFetchWait    = 2 * time.Second
@@ -81,14 +189,67 @@ const (
 	finalResultWaiterDeliveryCacheMax = 4096
 )
 
+// workerDeliveryMaxDeliver is how many times a pipeline message is delivered
+// before it is parked as poison and its op fails.
+func workerDeliveryMaxDeliver() int {
+	return positiveIntFromEnv(workerMaxDeliverEnv, defaultWorkerDeliveryMaxDeliver)
+}
+
+// workerConcurrency is how many pipeline partitions each worker's input is
+// split into (see workers_partitions.go), capped at maxWorkerConcurrency.
+func workerConcurrency() int {
+	return min(positiveIntFromEnv(workerConcurrencyEnv, defaultWorkerConcurrency), maxWorkerConcurrency)
+}
+
+// workerLimitsFromEnv reads PAAS_WORKER_LIMITS, comma-separated
+// "worker=max" caps on deliveries a worker type runs at once in this
+// process ("imageBuilder=2,*=4"); "*" covers workers not listed. Malformed
+// entries are ignored.
+func workerLimitsFromEnv() map[string]int {
+	limits := map[string]int{}
+	for entry := range strings.SplitSeq(os.Getenv(workerLimitsEnv), ",") {
+		name, raw, found := strings.Cut(entry, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !found || strings.TrimSpace(name) == "" || err != nil || limit <= 0 {
+			continue
+		}
+		limits[strings.TrimSpace(name)] = limit
+	}
+	return limits
+}
+
+// workerDeliveryRetryBackoff reads PAAS_WORKER_RETRY_BACKOFF, a
+// comma-separated list of durations ("1s,5s,30s"); a malformed list falls
+// back to the default. JetStream refuses more backoff steps than
+// deliveries, so the list is cut to max-deliver.
 func workerDeliveryRetryBackoff() []time.Duration {
-	return []time.Duration{
+	backoff := parseWorkerRetryBackoff(os.Getenv(workerRetryBackoffEnv))
+	if maxDeliver := workerDeliveryMaxDeliver(); len(backoff) > maxDeliver {
+		backoff = backoff[:maxDeliver]
+	}
+	return backoff
+}
+
+func parseWorkerRetryBackoff(raw string) []time.Duration {
+	defaults := []time.Duration{
 		1 * time.Second,
 		2 * time.Second,
 		5 * time.Second,
 		10 * time.Second,
 		20 * time.Second,
 	}
+	if strings.TrimSpace(raw) == "" {
+		return defaults
+	}
+	out := []time.Duration{}
+	for part := range strings.SplitSeq(raw, ",") {
+		delay, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || delay <= 0 {
+			return defaults
+		}
+		out = append(out, delay)
+	}
+	return out
 }
 
 func finalResultConsumerRetryBackoff() []time.Duration {
@@ -154,10 +315,12 @@ type artifactsRootResolution struct {
 	legacyRoot string
 }
 
-func resolveArtifactsRoot() artifactsRootResolution {
-	raw, exists := os.LookupEnv(artifactsRootEnv)
+// resolveArtifactsRoot applies the OS default when cfg leaves the artifacts
+// root unset.
+func resolveArtifactsRoot(cfg runtimeConfig) artifactsRootResolution {
 	homeDir, _ := os.UserHomeDir()
-	return resolveArtifactsRootRaw(runtime.GOOS, raw, exists, homeDir, os.Getenv("XDG_STATE_HOME"))
+	return resolveArtifactsRootRaw(runtime.GOOS, cfg.ArtifactsRoot, cfg.ArtifactsRoot != "", homeDir,
+		os.Getenv("XDG_STATE_HOME"))
 }
 
 func resolveArtifactsRootRaw(
@@ -1,10 +1,15 @@
-//nolint:testpackage // Runtime config tests validate unexported resolution helpers directly.
+//nolint:testpackage,exhaustruct // Runtime config tests validate unexported resolution helpers directly.
 package platform
 
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
 )
 
 func TestResolveNATSStoreDirRawDefaultsToPersistentDataDir(t *testing.T) {
@@ -167,3 +172,131 @@ func TestShouldLogLegacyArtifactsMigrationNotice(t *testing.T) {
 		}
 	})
 }
+
+func TestWorkerDeliveryRetryBackoffFromEnv(t *testing.T) {
+	t.Setenv(workerRetryBackoffEnv, "500ms, 3s,1m")
+	t.Setenv(workerMaxDeliverEnv, "2")
+
+	got := workerDeliveryRetryBackoff()
+	if len(got) != 2 || got[0] != 500*time.Millisecond || got[1] != 3*time.Second {
+		t.Fatalf("expected backoff cut to max-deliver [500ms 3s], got %v", got)
+	}
+	if workerDeliveryMaxDeliver() != 2 {
+		t.Fatalf("expected max deliver 2, got %d", workerDeliveryMaxDeliver())
+	}
+}
+
+func TestParseWorkerRetryBackoffFallsBackOnInvalidList(t *testing.T) {
+	t.Parallel()
+
+	defaults := parseWorkerRetryBackoff("")
+	for _, raw := range []string{"1s,soon", "1s,-2s", "1s,,2s"} {
+		got := parseWorkerRetryBackoff(raw)
+		if len(got) != len(defaults) || got[0] != defaults[0] {
+			t.Fatalf("expected defaults for %q, got %v", raw, got)
+		}
+	}
+}
+
+func TestExternalNATSEndpointFromEnvValidatesAndConnects(t *testing.T) {
+	t.Setenv(natsURLEnv, "")
+	if _, external, err := externalNATSEndpointFromEnv(); external || err != nil {
+		t.Fatalf("expected embedded mode without %s, got external=%v err=%v", natsURLEnv, external, err)
+	}
+
+	t.Setenv(natsURLEnv, "tls://nats-a:4222,tls://nats-b:4222")
+	t.Setenv(natsTLSCertEnv, filepath.Join(t.TempDir(), "client.crt"))
+	if _, _, err := externalNATSEndpointFromEnv(); err == nil || !strings.Contains(err.Error(), "set together") {
+		t.Fatalf("expected cert without key to be rejected, got %v", err)
+	}
+	t.Setenv(natsTLSCertEnv, "")
+	t.Setenv(natsCredsEnv, filepath.Join(t.TempDir(), "missing.creds"))
+	if _, _, err := externalNATSEndpointFromEnv(); err == nil || !strings.Contains(err.Error(), natsCredsEnv) {
+		t.Fatalf("expected a missing creds file to be rejected, got %v", err)
+	}
+	t.Setenv(natsCredsEnv, "")
+
+	var opts server.Options
+	opts.Host = "127.0.0.1"
+	opts.Port = -1
+	opts.NoLog = true
+	opts.NoSigs = true
+	opts.Username = "paas"
+	opts.Password = "s3cret"
+	ns, err := server.NewServer(&opts)
+	if err != nil {
+		t.Skipf("nats server unavailable: %v", err)
+	}
+	ns.Start()
+	defer ns.Shutdown()
+	if !ns.ReadyForConnections(defaultStartupWait) {
+		t.Skip("nats server not ready")
+	}
+	clusterURL := strings.Replace(ns.ClientURL(), "nats://", "nats://paas:s3cret@", 1)
+	t.Setenv(natsURLEnv, clusterURL)
+
+	endpoint, external, err := externalNATSEndpointFromEnv()
+	if err != nil || !external {
+		t.Fatalf("expected external endpoint, got external=%v err=%v", external, err)
+	}
+	if shown := endpoint.displayURL(); strings.Contains(shown, "s3cret") || !strings.Contains(shown, "paas:xxxxx@") {
+		t.Fatalf("expected the password masked, got %q", shown)
+	}
+	token := natsEndpoint{url: "nats://tok3n@nats-a:4222"}
+	if shown := token.displayURL(); strings.Contains(shown, "tok3n") {
+		t.Fatalf("expected the token masked, got %q", shown)
+	}
+	nc, err := endpoint.connect("external-test")
+	if err != nil {
+		t.Fatalf("connect external: %v", err)
+	}
+	nc.Close()
+
+	api := newRuntimeAPI(nil, nil, nil, nil, nil, imageBuilderModeResolution{}, defaultRuntimeConfig(),
+		runtimeNATS{endpoint: endpoint})
+	if api.runtimeNATSEmbedded || api.runtimeNATSURL != endpoint.displayURL() {
+		t.Fatalf("expected external runtime status, got embedded=%v url=%q", api.runtimeNATSEmbedded, api.runtimeNATSURL)
+	}
+}
+
+func TestEmbeddedNATSRequiresGeneratedCredentials(t *testing.T) {
+	for _, mode := range []embeddedNATSAuthMode{embeddedNATSAuthPassword, embeddedNATSAuthNKey} {
+		t.Setenv(natsEmbeddedAuthEnv, string(mode))
+		ns, endpoint, err := startNATSServerAt(t.TempDir(), false)
+		if err != nil {
+			t.Skipf("embedded nats unavailable: %v", err)
+		}
+		if anonymous, connErr := nats.Connect(ns.ClientURL()); connErr == nil {
+			anonymous.Close()
+			t.Errorf("%s: expected an anonymous client to be refused", mode)
+		}
+		nc, err := endpoint.connect("auth-test")
+		if err != nil {
+			t.Errorf("%s: expected the generated credentials to connect: %v", mode, err)
+		} else {
+			nc.Close()
+		}
+		ns.Shutdown()
+		ns.WaitForShutdown()
+
+		restarted, next, err := startNATSServerAt(t.TempDir(), false)
+		if err != nil {
+			t.Fatalf("%s: restart embedded nats: %v", mode, err)
+		}
+		if stale, connErr := nats.Connect(restarted.ClientURL(), endpoint.embeddedAuth.clientOptions()...); connErr == nil {
+			stale.Close()
+			t.Errorf("%s: expected credentials from the previous start to be refused", mode)
+		}
+		if next.embeddedAuth.mode != mode {
+			t.Errorf("expected mode %s, got %s", mode, next.embeddedAuth.mode)
+		}
+		restarted.Shutdown()
+		restarted.WaitForShutdown()
+	}
+
+	t.Setenv(natsEmbeddedAuthEnv, "open")
+	_, _, err := startNATSServerAt(t.TempDir(), false)
+	if err == nil || !strings.Contains(err.Error(), natsEmbeddedAuthEnv) {
+		t.Fatalf("expected an unknown auth mode to be rejected, got %v", err)
+	}
+}
@@ -1,32 +1,76 @@
 package platform
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var subjectPrefixTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 ////////////////////////////////////////////////////////////////////////////////
 // Subjects (register -> bootstrap -> build -> deploy chain) + process channels + KV buckets
+//
+// Names below are relative to the PAAS_SUBJECT_PREFIX namespace (default
+// "paas") so several platform instances can share one NATS cluster:
+// natsSubject, natsStreamName, and kvBucketName turn them into the names
+// used on the wire.
 ////////////////////////////////////////////////////////////////////////////////
 
 const (
+	defaultSubjectPrefix = "paas"
+
 	// Worker delivery stream.
-	streamWorkerPipeline = "PAAS_WORKER_PIPELINE"
+	streamWorkerPipeline = "WORKER_PIPELINE"
+
+	// Op event journal stream, one subject per op (suffixed with the op ID).
+	streamOpEvents        = "OP_EVENTS"
+	subjectOpEventsPrefix = "op.events."
 
 	// API publishes project operations here.
-	subjectProjectOpStart = "paas.project.op.start"
+	subjectProjectOpStart = "project.op.start"
 
 	// Worker pipeline chain.
-	subjectRegistrationDone = "paas.project.op.registration.done"
-	subjectBootstrapDone    = "paas.project.op.bootstrap.done"
-	subjectBuildDone        = "paas.project.op.build.done"
-	subjectDeployDone       = "paas.project.op.deploy.done"
+	subjectRegistrationDone = "project.op.registration.done"
+	subjectBootstrapDone    = "project.op.bootstrap.done"
+	subjectBuildDone        = "project.op.build.done"
+	subjectDeployDone       = "project.op.deploy.done"
 
 	// Standalone process subjects.
-	subjectDeploymentStart = "paas.project.process.deployment.start"
-	subjectDeploymentDone  = "paas.project.process.deployment.done"
-	subjectPromotionStart  = "paas.project.process.promotion.start"
-	subjectPromotionDone   = "paas.project.process.promotion.done"
-	subjectWorkerPoison    = "paas.worker.delivery.poison"
+	subjectDeploymentStart = "project.process.deployment.start"
+	subjectDeploymentDone  = "project.process.deployment.done"
+	subjectPromotionStart  = "project.process.promotion.start"
+	subjectPromotionDone   = "project.process.promotion.done"
+	subjectCleanupStart    = "project.process.cleanup.start"
+	subjectCleanupDone     = "project.process.cleanup.done"
+	subjectUpgradeStart    = "project.process.upgrade.start"
+	subjectUpgradeDone     = "project.process.upgrade.done"
+	subjectWorkerPoison    = "worker.delivery.poison"
+
+	// Core NATS (not streamed): worker readiness heartbeats.
+	subjectWorkerReady = "worker.ready"
+
+	// Core NATS (not streamed): per-op cancellation, suffixed with the op ID.
+	subjectOpCancelPrefix = "project.op.cancel."
 
 	// KV buckets.
-	kvBucketProjects = "paas_projects"
-	kvBucketOps      = "paas_ops"
+	kvBucketProjects = "projects"
+	kvBucketOps      = "ops"
+	kvBucketMeta     = "meta"
+	kvBucketLeases   = "leases"
+	kvBucketSecrets  = "secrets"
+	kvBucketOrgs     = "orgs"
+
+	// KV bucket whose entries expire after idempotencyKeyTTL.
+	kvBucketIdempotency = "idempotency"
+
+	// Meta keys: logical bucket name -> physical bucket after a migration.
+	kvActiveBucketKeyPrefix = "active_bucket/"
+
+	// Lease key for singleton background jobs.
+	leaderLeaseKey = "leader/background-jobs"
 
 	// Project keys in KV.
 	kvProjectKeyPrefix               = "project/"
@@ -35,4 +79,82 @@ const (
 	kvProjectOpsIndexKeyPrefix       = "project_ops/"
 	kvProjectReleaseIndexKeyPrefix   = "project_release_index/"
 	kvProjectReleaseCurrentKeyPrefix = "project_release_current/"
+	kvProjectHoldsKeyPrefix          = "project_holds/"
+	kvProjectFreezesKeyPrefix        = "project_freezes/"
+	kvProjectHealthKeyPrefix         = "project_health/"
+	kvProjectSchedulesKeyPrefix      = "project_schedules/"
+	kvReleaseApprovalKeyPrefix       = "release_approval/"
+	kvProjectDeletePlanKeyPrefix     = "project_delete_plan/"
+	kvProjectArtifactRootKeyPrefix   = "project_artifact_root/"
+	kvProjectBindingsKeyPrefix       = "project_bindings/"
+	kvProjectSecretsKeyPrefix        = "project_secrets/"
+	kvProjectPreviewsKeyPrefix       = "project_previews/"
+	kvOpNotesKeyPrefix               = "op_notes/"
+	kvViewKeyPrefix                  = "view/"
+
+	// Organization keys in the orgs bucket.
+	kvOrgKeyPrefix = "org/"
+
+	// Endpoint the source repos' webhook hooks were last pointed at.
+	kvWebhookEndpointKey = "webhook_endpoint"
+	// SLA breaches of every op that has one.
+	kvOpSLAKey = "op_sla"
+	// Maintenance switch that refuses API mutations.
+	kvReadOnlyKey = "read_only"
+
+	// API token record in the secrets bucket.
+	kvAPITokensKey = "api_tokens"
 )
+
+// subjectPrefix reads PAAS_SUBJECT_PREFIX: dot-separated tokens of letters,
+// digits, "-", and "_", like acme.paas. Invalid values use the default;
+// startup config validation refuses them first.
+func subjectPrefix() string {
+	prefix := strings.TrimSpace(os.Getenv(subjectPrefixEnv))
+	if validateSubjectPrefix(prefix) != nil {
+		return defaultSubjectPrefix
+	}
+	return prefix
+}
+
+func validateSubjectPrefix(prefix string) error {
+	if prefix == "" {
+		return errors.New("subject prefix is empty")
+	}
+	for token := range strings.SplitSeq(prefix, ".") {
+		if !subjectPrefixTokenPattern.MatchString(token) {
+			return fmt.Errorf("subject prefix %q: want dot-separated tokens of letters, digits, - and _", prefix)
+		}
+	}
+	return nil
+}
+
+// natsSubject places a subject under the instance's prefix.
+func natsSubject(name string) string {
+	return subjectPrefix() + "." + name
+}
+
+// natsStreamName names a stream for the instance: PAAS_WORKER_PIPELINE by
+// default, ACME_PAAS_WORKER_PIPELINE for acme.paas.
+func natsStreamName(name string) string {
+	return strings.ToUpper(subjectPrefixToken()) + "_" + name
+}
+
+// kvBucketName names a KV bucket for the instance: paas_projects by default.
+func kvBucketName(name string) string {
+	return strings.ToLower(subjectPrefixToken()) + "_" + name
+}
+
+// natsConsumerName namespaces a durable consumer name. The default prefix
+// keeps the unprefixed names existing installs already have delivery
+// state under.
+func natsConsumerName(name string) string {
+	if subjectPrefix() == defaultSubjectPrefix {
+		return name
+	}
+	return strings.ToLower(subjectPrefixToken()) + "_" + name
+}
+
+func subjectPrefixToken() string {
+	return strings.ReplaceAll(subjectPrefix(), ".", "_")
+}
@@ -31,7 +31,7 @@ func startPersistentNATSFixture(t *testing.T, storeDir string) *persistentNATSFi
 	t.Helper()
 	t.Setenv(natsStoreDirEnv, storeDir)
 
-	ns, natsURL, resolvedDir, isEphemeral, err := startEmbeddedNATS()
+	ns, endpoint, resolvedDir, isEphemeral, err := startEmbeddedNATS()
 	if err != nil {
 		t.Skipf("embedded nats is unavailable in this environment: %v", err)
 	}
@@ -46,7 +46,7 @@ func startPersistentNATSFixture(t *testing.T, storeDir string) *persistentNATSFi
 		t.Fatalf("expected nats store dir %q, got %q", storeDir, resolvedDir)
 	}
 
-	nc, err := nats.Connect(natsURL, nats.Name("control-plane-persistence-test"))
+	nc, err := endpoint.connect("control-plane-persistence-test")
 	if err != nil {
 		ns.Shutdown()
 		ns.WaitForShutdown()
@@ -284,7 +284,7 @@ func TestOpEventsBootstrapRebuildsSnapshotAfterRestartWithoutHistory(t *testing.
 		opEvents:             hub,
 		opHeartbeatInterval:  5 * time.Second,
 		runtimeVersion:       "",
-		runtimeHTTPAddr:      httpAddr,
+		runtimeHTTPAddr:      defaultHTTPAddr,
 		runtimeArtifactsRoot: "",
 		runtimeBuilderMode: imageBuilderModeResolution{
 			requestedMode:     imageBuilderModeBuildKit,
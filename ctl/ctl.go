@@ -0,0 +1,189 @@
+// Package ctl is paasctl, the command-line client for the platform HTTP API
+// (cmd/paasctl). It is a thin layer over package client, so requests and
+// responses use the server's own types and cannot drift from them.
+package ctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/a2y-d5l/go-web-nats/client"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+
+	serverEnv     = "PAASCTL_SERVER"
+	tokenEnv      = "PAASCTL_TOKEN"
+	defaultServer = "http://127.0.0.1:8080"
+
+	outputTable = "table"
+	outputJSON  = "json"
+
+	usage = `usage: paasctl [-server URL] [-token TOKEN] [-o table|json] <command> [flags]
+
+Talks to a running platform API (default ` + defaultServer + `, or
+` + serverEnv + `); -token defaults to ` + tokenEnv + `.
+
+commands:
+  project list                         list projects
+  project get ID                       show one project
+  project create -f FILE [-watch]      create a project from a project.yaml
+  project update ID -f FILE [-watch]   replace a project's spec
+  project delete ID [-acknowledge-impact] [-watch]
+                                       plan and apply a project delete
+  project quota ID                     show quota usage
+  op get ID                            show one op and its steps
+  op list -project ID [-limit N]       list a project's ops, newest first
+  op watch ID                          stream an op's events until it ends
+  op cancel ID [-reason TEXT]          cancel a queued or running op
+  promote -project ID -from ENV -to ENV [-strategy canary|blue-green]
+          [-weight N] [-watch]         promote the source env's release
+  rollback -project ID -env ENV -release ID [-scope SCOPE] [-preview]
+          [-watch]                     roll an environment back to a release
+
+Commands that start an op print it and return; -watch streams its events
+and exits non-zero if it fails.
+`
+)
+
+var errUsage = errors.New("usage")
+
+// cli is one invocation: the API client and where output goes.
+type cli struct {
+	api    *client.Client
+	output string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Run runs one paasctl command and returns the process exit code.
+func Run(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("paasctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { _, _ = io.WriteString(stderr, usage) }
+	server := global.String("server", envOr(serverEnv, defaultServer), "platform API base URL")
+	token := global.String("token", os.Getenv(tokenEnv), "API bearer token")
+	output := global.String("o", outputTable, "output format: table or json")
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+	if global.NArg() == 0 || (*output != outputTable && *output != outputJSON) {
+		global.Usage()
+		return exitUsage
+	}
+	api, err := client.New(*server, client.WithToken(*token))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "paasctl: %v\n", err)
+		return exitUsage
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := &cli{api: api, output: *output, stdout: stdout, stderr: stderr}
+	if err = c.run(ctx, global.Arg(0), global.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			if !errors.Is(err, flag.ErrHelp) {
+				_, _ = fmt.Fprintf(stderr, "paasctl: %v\n", err)
+			}
+			return exitUsage
+		}
+		c.printError(err)
+		return exitError
+	}
+	return exitOK
+}
+
+func (c *cli) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "project", "projects":
+		return c.runProject(ctx, args)
+	case "op", "ops":
+		return c.runOp(ctx, args)
+	case "promote":
+		return c.promote(ctx, args)
+	case "rollback":
+		return c.rollback(ctx, args)
+	default:
+		return fmt.Errorf("%w: unknown command %q (run paasctl -h)", errUsage, command)
+	}
+}
+
+// printError writes err, with the API's next step when it gave one.
+func (c *cli) printError(err error) {
+	_, _ = fmt.Fprintf(c.stderr, "paasctl: %v\n", err)
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		if apiErr.NextStep != "" {
+			_, _ = fmt.Fprintf(c.stderr, "next step: %s\n", apiErr.NextStep)
+		}
+		if apiErr.RetryAfter > 0 {
+			_, _ = fmt.Fprintf(c.stderr, "retry after: %s\n", apiErr.RetryAfter)
+		}
+	}
+}
+
+// printJSON writes v indented, for -o json.
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// newFlags returns a flag set for "paasctl name" that reports errors to
+// stderr.
+func (c *cli) newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("paasctl "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// parseArgs parses fs's flags wherever they appear among args, so
+// "op watch ID" and "project delete ID -watch" both work, and returns the
+// positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// oneArg returns the single positional argument a command takes.
+func oneArg(positional []string, what string) (string, error) {
+	if len(positional) != 1 || strings.TrimSpace(positional[0]) == "" {
+		return "", fmt.Errorf("%w: expected one %s", errUsage, what)
+	}
+	return strings.TrimSpace(positional[0]), nil
+}
+
+func requireFlag(value, name string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%w: -%s is required", errUsage, name)
+	}
+	return value, nil
+}
+
+func envOr(name, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,119 @@
+package ctl_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+	"github.com/a2y-d5l/go-web-nats/ctl"
+)
+
+func runCtl(t *testing.T, handler http.Handler, args ...string) (int, string, string) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	var stdout, stderr bytes.Buffer
+	code := ctl.Run(append([]string{"-server", srv.URL, "-token", "secret"}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_ProjectCreateSendsTheParsedSpec(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "project.yaml")
+	spec := "apiVersion: platform.example.com/v2\nkind: App\nname: billing-api\nruntime: go_1.26\n" +
+		"environments:\n  dev:\n    vars:\n      PORT: \"8080\"\n"
+	if err := os.WriteFile(specFile, []byte(spec), 0o600); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	var got platform.ProjectSpec
+	code, stdout, stderr := runCtl(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/projects" ||
+			r.Header.Get("Authorization") != "Bearer secret" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = fmt.Fprint(w, `{"accepted":true,"project":{"id":"p1"},`+
+			`"op":{"id":"op-1","kind":"create","status":"queued"}}`)
+	}), "project", "create", "-f", specFile)
+	if code != 0 {
+		t.Fatalf("expected success, got %d: %s", code, stderr)
+	}
+	if got.Name != "billing-api" || got.Environments["dev"].Vars["PORT"] != "8080" {
+		t.Fatalf("expected the YAML spec to be sent as JSON, got %+v", got)
+	}
+	if stdout != "op op-1 (create) queued for project p1\n" {
+		t.Fatalf("unexpected output %q", stdout)
+	}
+}
+
+func TestRun_OpWatchStreamsEventsAndFailsWithTheOp(t *testing.T) {
+	code, stdout, stderr := runCtl(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ops/op-1/events" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: op.bootstrap\ndata: {\"op_id\":\"op-1\",\"status\":\"running\"}\n\n")
+		_, _ = fmt.Fprint(w, "id: 2\nevent: step.started\ndata: {\"op_id\":\"op-1\",\"worker\":\"builder\"}\n\n")
+		_, _ = fmt.Fprint(w, "event: op.heartbeat\ndata: {\"op_id\":\"op-1\"}\n\n")
+		_, _ = fmt.Fprint(w, "id: 3\nevent: op.failed\ndata: {\"op_id\":\"op-1\",\"error\":\"build failed\"}\n\n")
+	}), "op", "watch", "op-1")
+	if code != 1 || !strings.Contains(stderr, "op op-1 failed: build failed") {
+		t.Fatalf("expected the failed op to fail the command, got %d %q", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "step.started  builder") ||
+		!strings.Contains(lines[2], "op.failed  error: build failed") {
+		t.Fatalf("expected one line per event without heartbeats, got %q", stdout)
+	}
+}
+
+func TestRun_PromoteBuildsTheEventAndChecksFlags(t *testing.T) {
+	var got platform.PromotionEvent
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/events/promotion" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusConflict)
+		_, _ = fmt.Fprint(w, `{"code":"op_conflict","reason":"project has an active op","next_step":"wait for op-0"}`)
+	})
+	code, _, stderr := runCtl(t, handler, "promote", "-project", "p1", "-from", "dev", "-to", "staging",
+		"-strategy", "canary", "-weight", "10")
+	if got.ProjectID != "p1" || got.FromEnv != "dev" || got.ToEnv != "staging" ||
+		got.Strategy != platform.DeliveryStrategyCanary || got.Weight != 10 {
+		t.Fatalf("unexpected promotion event %+v", got)
+	}
+	if code != 1 || !strings.Contains(stderr, "project has an active op") ||
+		!strings.Contains(stderr, "next step: wait for op-0") {
+		t.Fatalf("expected the API error and its next step, got %d %q", code, stderr)
+	}
+	if code, _, stderr = runCtl(t, handler, "promote", "-project", "p1", "-from", "dev"); code != 2 ||
+		!strings.Contains(stderr, "-to is required") {
+		t.Fatalf("expected a usage error without -to, got %d %q", code, stderr)
+	}
+}
+
+func TestRun_ProjectListAsJSON(t *testing.T) {
+	code, stdout, stderr := runCtl(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/projects" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprint(w, `[{"id":"p1","spec":{"name":"billing-api"}}]`)
+	}), "-o", "json", "project", "list")
+	var projects []platform.Project
+	if code != 0 || json.Unmarshal([]byte(stdout), &projects) != nil || len(projects) != 1 ||
+		projects[0].Spec.Name != "billing-api" {
+		t.Fatalf("expected the projects as JSON, got %d %q %q", code, stdout, stderr)
+	}
+}
@@ -0,0 +1,101 @@
+package ctl
+
+import (
+	"context"
+	"fmt"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+)
+
+func (c *cli) promote(ctx context.Context, args []string) error {
+	fs := c.newFlags("promote")
+	projectID := fs.String("project", "", "project to promote")
+	from := fs.String("from", "", "source environment")
+	to := fs.String("to", "", "target environment")
+	strategy := fs.String("strategy", "", "delivery strategy: canary or blue-green (default: replace)")
+	weight := fs.Int("weight", 0, "percent of replicas a canary starts on")
+	watch := fs.Bool("watch", false, "stream the op's events until it ends")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	evt := platform.PromotionEvent{
+		ProjectID:             *projectID,
+		FromEnv:               *from,
+		ToEnv:                 *to,
+		ToEnvs:                nil,
+		VulnerabilityOverride: nil,
+		FreezeOverride:        nil,
+		Strategy:              platform.DeliveryStrategy(*strategy),
+		Weight:                *weight,
+	}
+	for _, required := range []struct{ value, name string }{
+		{evt.ProjectID, "project"}, {evt.FromEnv, "from"}, {evt.ToEnv, "to"},
+	} {
+		if _, err := requireFlag(required.value, required.name); err != nil {
+			return err
+		}
+	}
+	accepted, err := c.api.Promote(ctx, evt)
+	if err != nil {
+		return err
+	}
+	return c.accepted(ctx, accepted, *watch)
+}
+
+// rollback enqueues a rollback, or with -preview prints what one would do.
+// A rollback the preview blocks fails with the blockers in the API error.
+func (c *cli) rollback(ctx context.Context, args []string) error {
+	fs := c.newFlags("rollback")
+	projectID := fs.String("project", "", "project to roll back")
+	env := fs.String("env", "", "environment to roll back")
+	releaseID := fs.String("release", "", "release to roll back to")
+	scope := fs.String("scope", string(platform.RollbackScopeCodeOnly),
+		"code_only, code_and_config, or full_state")
+	override := fs.Bool("override", false, "roll back past blockers an operator may override")
+	preview := fs.Bool("preview", false, "show the rollback preview instead of rolling back")
+	watch := fs.Bool("watch", false, "stream the op's events until it ends")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	evt := platform.RollbackEvent{
+		ProjectID:   *projectID,
+		Environment: *env,
+		ReleaseID:   *releaseID,
+		Scope:       platform.RollbackScope(*scope),
+		Override:    *override,
+	}
+	for _, required := range []struct{ value, name string }{
+		{evt.ProjectID, "project"}, {evt.Environment, "env"}, {evt.ReleaseID, "release"},
+	} {
+		if _, err := requireFlag(required.value, required.name); err != nil {
+			return err
+		}
+	}
+	if *preview {
+		return c.rollbackPreview(ctx, evt)
+	}
+	accepted, err := c.api.Rollback(ctx, evt)
+	if err != nil {
+		return err
+	}
+	return c.accepted(ctx, accepted, *watch)
+}
+
+func (c *cli) rollbackPreview(ctx context.Context, evt platform.RollbackEvent) error {
+	preview, err := c.api.PreviewRollback(ctx, evt)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.printJSON(preview)
+	}
+	_, _ = fmt.Fprintf(c.stdout, "rollback of %s/%s to %s: ready=%t\n",
+		evt.ProjectID, evt.Environment, evt.ReleaseID, preview.Ready)
+	for _, gate := range preview.Gates {
+		_, _ = fmt.Fprintf(c.stdout, "%s: %s %s\n", gate.Status, gate.Title, gate.Detail)
+	}
+	for _, blocker := range preview.Blockers {
+		_, _ = fmt.Fprintf(c.stdout, "blocked: %s; %s\n", blocker.Message, blocker.NextAction)
+	}
+	return nil
+}
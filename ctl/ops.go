@@ -0,0 +1,172 @@
+package ctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/a2y-d5l/go-web-nats/client"
+)
+
+// watchedEvent is one line of "op watch -o json": the event name, which
+// client.OpEvent leaves out of its JSON, and the event.
+type watchedEvent struct {
+	Event string `json:"event"`
+	client.OpEvent
+}
+
+func (c *cli) runOp(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: op needs get, list, watch, or cancel", errUsage)
+	}
+	fs := c.newFlags("op " + args[0])
+	projectID := fs.String("project", "", "project whose ops to list (list)")
+	limit := fs.Int("limit", 0, "most ops to list; 0 uses the server default (list)")
+	reason := fs.String("reason", "", "why the op is cancelled (cancel)")
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if args[0] == "list" {
+		id, flagErr := requireFlag(*projectID, "project")
+		if flagErr != nil {
+			return flagErr
+		}
+		return c.listOps(ctx, id, *limit)
+	}
+	opID, err := oneArg(positional, "op ID")
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "get":
+		return c.getOp(ctx, opID)
+	case "watch":
+		return c.watchOp(ctx, opID)
+	case "cancel":
+		op, cancelErr := c.api.CancelOp(ctx, opID, *reason)
+		if cancelErr != nil {
+			return cancelErr
+		}
+		if c.output == outputJSON {
+			return c.printJSON(op)
+		}
+		_, err = fmt.Fprintf(c.stdout, "op %s %s\n", op.ID, op.Status)
+		return err
+	default:
+		return fmt.Errorf("%w: unknown op command %q", errUsage, args[0])
+	}
+}
+
+func (c *cli) listOps(ctx context.Context, projectID string, limit int) error {
+	opts := client.ListOpsOptions{Limit: limit, Cursor: "", Before: time.Time{}}
+	page, err := c.api.ListProjectOps(ctx, projectID, opts)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.printJSON(page)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tKIND\tSTATUS\tREQUESTED\tSUMMARY")
+	for _, op := range page.Items {
+		summary := op.SummaryMessage
+		if op.Error != "" {
+			summary = op.Error
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			op.ID, op.Kind, op.Status, op.Requested.Format(time.RFC3339), summary)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) getOp(ctx context.Context, opID string) error {
+	op, err := c.api.GetOp(ctx, opID)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.printJSON(op)
+	}
+	_, _ = fmt.Fprintf(c.stdout, "op %s (%s) %s for project %s\n", op.ID, op.Kind, op.Status, op.ProjectID)
+	if op.Error != "" {
+		_, _ = fmt.Fprintf(c.stdout, "error: %s\n", op.Error)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "STEP\tSTARTED\tENDED\tMESSAGE")
+	for _, step := range op.Steps {
+		message := step.Message
+		if step.Error != "" {
+			message = step.Error
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			step.Worker, formatTime(step.StartedAt), formatTime(step.EndedAt), message)
+	}
+	return tw.Flush()
+}
+
+// watchOp prints opID's events as they arrive and fails when the op does.
+func (c *cli) watchOp(ctx context.Context, opID string) error {
+	var last client.OpEvent
+	err := c.api.StreamOpEvents(ctx, opID, "", func(evt client.OpEvent) error {
+		if evt.Name == client.EventHeartbeat {
+			return nil
+		}
+		last = evt
+		if c.output == outputJSON {
+			line, marshalErr := json.Marshal(watchedEvent{Event: evt.Name, OpEvent: evt})
+			if marshalErr != nil {
+				return marshalErr
+			}
+			_, writeErr := fmt.Fprintln(c.stdout, string(line))
+			return writeErr
+		}
+		_, writeErr := fmt.Fprintln(c.stdout, formatEvent(evt))
+		return writeErr
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case last.Name == client.EventFailed || (last.Name == client.EventBootstrap && last.Status == "error"):
+		return fmt.Errorf("op %s failed: %s", opID, last.Error)
+	case last.Name == client.EventCancelled || (last.Name == client.EventBootstrap && last.Status == "cancelled"):
+		return fmt.Errorf("op %s was cancelled", opID)
+	default:
+		return nil
+	}
+}
+
+// formatEvent renders one op event as a log line: time, event, worker, and
+// what happened.
+func formatEvent(evt client.OpEvent) string {
+	parts := []string{evt.At.Local().Format(time.TimeOnly), evt.Name}
+	if evt.Worker != "" {
+		parts = append(parts, evt.Worker)
+	}
+	if evt.ProgressPercent > 0 {
+		parts = append(parts, fmt.Sprintf("%d%%", evt.ProgressPercent))
+	}
+	switch {
+	case evt.Error != "":
+		parts = append(parts, "error: "+evt.Error)
+	case evt.Message != "":
+		parts = append(parts, evt.Message)
+	case evt.Name == client.EventBootstrap || evt.Name == client.EventStatus:
+		parts = append(parts, evt.Status)
+	}
+	if evt.Hint != "" {
+		parts = append(parts, "("+evt.Hint+")")
+	}
+	return strings.Join(parts, "  ")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
@@ -0,0 +1,232 @@
+package ctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	platform "github.com/a2y-d5l/go-web-nats"
+	"github.com/a2y-d5l/go-web-nats/client"
+)
+
+func (c *cli) runProject(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: project needs list, get, create, update, delete, or quota", errUsage)
+	}
+	fs := c.newFlags("project " + args[0])
+	file := fs.String("f", "", "project spec file, YAML or JSON, or - for stdin (create, update)")
+	watch := fs.Bool("watch", false, "stream the op's events until it ends")
+	acknowledge := fs.Bool("acknowledge-impact", false, "apply a delete plan that affects other projects (delete)")
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "list":
+		return c.listProjects(ctx)
+	case "get", "quota":
+		projectID, argErr := oneArg(positional, "project ID")
+		if argErr != nil {
+			return argErr
+		}
+		if args[0] == "quota" {
+			return c.projectQuota(ctx, projectID)
+		}
+		return c.getProject(ctx, projectID)
+	case "create":
+		return c.createProject(ctx, *file, *watch)
+	case "update":
+		projectID, argErr := oneArg(positional, "project ID")
+		if argErr != nil {
+			return argErr
+		}
+		return c.updateProject(ctx, projectID, *file, *watch)
+	case "delete":
+		projectID, argErr := oneArg(positional, "project ID")
+		if argErr != nil {
+			return argErr
+		}
+		return c.deleteProject(ctx, projectID, *acknowledge, *watch)
+	default:
+		return fmt.Errorf("%w: unknown project command %q", errUsage, args[0])
+	}
+}
+
+func (c *cli) listProjects(ctx context.Context) error {
+	projects, err := c.api.ListProjects(ctx)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.printJSON(projects)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tNAME\tPHASE\tLAST OP\tUPDATED")
+	for _, project := range projects {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			project.ID,
+			project.Spec.Name,
+			project.Status.Phase,
+			project.Status.LastOpID,
+			project.UpdatedAt.Format(time.RFC3339),
+		)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) getProject(ctx context.Context, projectID string) error {
+	project, err := c.api.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.printJSON(project)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "ID:\t%s\n", project.ID)
+	_, _ = fmt.Fprintf(tw, "Name:\t%s\n", project.Spec.Name)
+	_, _ = fmt.Fprintf(tw, "Runtime:\t%s\n", project.Spec.Runtime)
+	_, _ = fmt.Fprintf(tw, "Phase:\t%s\n", project.Status.Phase)
+	_, _ = fmt.Fprintf(tw, "Message:\t%s\n", project.Status.Message)
+	_, _ = fmt.Fprintf(tw, "Last op:\t%s %s\n", project.Status.LastOpKind, project.Status.LastOpID)
+	_, _ = fmt.Fprintf(tw, "Environments:\t%d\n", len(project.Spec.Environments))
+	_, _ = fmt.Fprintf(tw, "Updated:\t%s\n", project.UpdatedAt.Format(time.RFC3339))
+	return tw.Flush()
+}
+
+func (c *cli) projectQuota(ctx context.Context, projectID string) error {
+	quota, err := c.api.GetProjectQuota(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.printJSON(quota)
+	}
+	if len(quota.Quotas) == 0 {
+		_, err = fmt.Fprintln(c.stdout, "no quotas configured")
+		return err
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "QUOTA\tUSED\tLIMIT\tEXHAUSTED\tRESETS")
+	for _, usage := range quota.Quotas {
+		resets := ""
+		if !usage.ResetsAt.IsZero() {
+			resets = usage.ResetsAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%t\t%s\n", usage.Name, usage.Used, usage.Limit, usage.Exhausted, resets)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) createProject(ctx context.Context, file string, watch bool) error {
+	spec, err := readSpecFile(file)
+	if err != nil {
+		return err
+	}
+	accepted, err := c.api.CreateProject(ctx, spec)
+	if err != nil {
+		return err
+	}
+	return c.accepted(ctx, accepted, watch)
+}
+
+func (c *cli) updateProject(ctx context.Context, projectID, file string, watch bool) error {
+	spec, err := readSpecFile(file)
+	if err != nil {
+		return err
+	}
+	accepted, err := c.api.UpdateProject(ctx, projectID, spec)
+	if err != nil {
+		return err
+	}
+	return c.accepted(ctx, accepted, watch)
+}
+
+// deleteProject plans the delete and applies the plan straight away. A plan
+// with impact outside the project needs -acknowledge-impact, so the impact
+// is printed before the server refuses.
+func (c *cli) deleteProject(ctx context.Context, projectID string, acknowledge, watch bool) error {
+	plan, err := c.api.PlanDelete(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if plan.RequiresAcknowledgement && !acknowledge {
+		c.printDeleteImpact(plan.Impact)
+		return fmt.Errorf("delete of %s affects other projects; rerun with -acknowledge-impact", projectID)
+	}
+	accepted, err := c.api.DeleteProject(ctx, projectID, plan.ID, acknowledge)
+	if err != nil {
+		return err
+	}
+	return c.accepted(ctx, accepted, watch)
+}
+
+func (c *cli) printDeleteImpact(impact platform.DeleteImpact) {
+	for _, release := range impact.LiveReleases {
+		_, _ = fmt.Fprintf(c.stderr, "live release: %s\n", release)
+	}
+	for _, endpoint := range impact.Endpoints {
+		_, _ = fmt.Fprintf(c.stderr, "endpoint: %s\n", endpoint)
+	}
+	for _, dependent := range impact.Dependents {
+		_, _ = fmt.Fprintf(c.stderr, "dependent: %s (%s) uses %s via %s\n",
+			dependent.ProjectName, dependent.ProjectID, dependent.Endpoint, dependent.Reference)
+	}
+	for _, credential := range impact.Credentials {
+		_, _ = fmt.Fprintf(c.stderr, "credential: %s\n", credential)
+	}
+}
+
+// accepted prints the op a command started and, with watch, follows it.
+func (c *cli) accepted(ctx context.Context, accepted client.Accepted, watch bool) error {
+	if c.output == outputJSON {
+		if err := c.printJSON(accepted); err != nil || !watch || accepted.Op.ID == "" {
+			return err
+		}
+		return c.watchOp(ctx, accepted.Op.ID)
+	}
+	projectID := accepted.Project.ID
+	if projectID == "" {
+		projectID = accepted.ProjectID
+	}
+	switch {
+	case accepted.Unchanged:
+		_, _ = fmt.Fprintf(c.stdout, "project %s unchanged; no op started\n", projectID)
+		return nil
+	case accepted.Op.ID == "":
+		_, _ = fmt.Fprintf(c.stdout, "project %s: nothing queued\n", projectID)
+		return nil
+	}
+	_, _ = fmt.Fprintf(c.stdout, "op %s (%s) %s for project %s\n",
+		accepted.Op.ID, accepted.Op.Kind, accepted.Op.Status, projectID)
+	if !watch {
+		return nil
+	}
+	return c.watchOp(ctx, accepted.Op.ID)
+}
+
+func readSpecFile(path string) (platform.ProjectSpec, error) {
+	if path == "" {
+		return platform.ProjectSpec{}, fmt.Errorf("%w: -f is required", errUsage)
+	}
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return platform.ProjectSpec{}, fmt.Errorf("read spec: %w", err)
+	}
+	spec, err := platform.ParseProjectSpec(data)
+	if err != nil {
+		return platform.ProjectSpec{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
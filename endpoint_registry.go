@@ -0,0 +1,258 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Webhook endpoint registry: source repo hooks carry the API address they
+// were installed with. At startup the registry compares the current address
+// with the one it last recorded and, when they differ, repoints every
+// bootstrapped repo through a webhook-refresh op.
+////////////////////////////////////////////////////////////////////////////////
+
+const endpointRegistryWorker = "endpointRegistry"
+
+// webhookEndpointRecord is the endpoint every source repo's hooks were last
+// known to post to.
+type webhookEndpointRecord struct {
+	Endpoint  string    `json:"endpoint"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// webhookRefreshReport counts what one registry pass found and did.
+type webhookRefreshReport struct {
+	Endpoint  string
+	Previous  string
+	Scanned   int
+	Stale     int
+	Refreshed int
+	Deferred  int
+	Failed    int
+}
+
+func (s *Store) getWebhookEndpointRecord(ctx context.Context) (webhookEndpointRecord, bool, error) {
+	defer s.observe("getWebhookEndpointRecord", time.Now())
+	entry, err := s.kvOps.Get(ctx, kvWebhookEndpointKey)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return webhookEndpointRecord{}, false, nil
+		}
+		return webhookEndpointRecord{}, false, err
+	}
+	var record webhookEndpointRecord
+	if err = json.Unmarshal(entry.Value(), &record); err != nil {
+		return webhookEndpointRecord{}, false, err
+	}
+	return record, true, nil
+}
+
+func (s *Store) putWebhookEndpointRecord(ctx context.Context, endpoint string) error {
+	defer s.observe("putWebhookEndpointRecord", time.Now())
+	body, err := json.Marshal(webhookEndpointRecord{Endpoint: endpoint, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	_, err = s.kvOps.Put(ctx, kvWebhookEndpointKey, body)
+	return err
+}
+
+// startWebhookEndpointRegistry runs the registry as a singleton job so only
+// the lease holder rewrites repos.
+func startWebhookEndpointRegistry(ctx context.Context, api *API, elector *leaderElector) {
+	registryLog := appLoggerForProcess().Source(endpointRegistryWorker)
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		runWebhookEndpointRegistry(jobCtx, api, registryLog)
+	})
+}
+
+func runWebhookEndpointRegistry(ctx context.Context, api *API, registryLog sourceLogger) {
+	report, err := api.refreshWebhookEndpoints(ctx, sourceWebhookEndpoint(ctx), registryLog)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		registryLog.Warnf("webhook endpoint refresh failed: %v", err)
+	case report.Stale > 0:
+		registryLog.Infof(
+			"webhook endpoint %s: scanned=%d stale=%d refreshed=%d deferred=%d failed=%d",
+			report.Endpoint,
+			report.Scanned,
+			report.Stale,
+			report.Refreshed,
+			report.Deferred,
+			report.Failed,
+		)
+	}
+	<-ctx.Done()
+}
+
+// refreshWebhookEndpoints repoints each bootstrapped source repo whose
+// webhook.json names an endpoint other than endpoint. The record only moves
+// to endpoint once no repo is left behind: a repo busy with another op, or
+// one whose refresh failed, is tried again on the next startup.
+func (a *API) refreshWebhookEndpoints(
+	ctx context.Context,
+	endpoint string,
+	registryLog sourceLogger,
+) (webhookRefreshReport, error) {
+	report := webhookRefreshReport{Endpoint: endpoint}
+	record, found, err := a.store.getWebhookEndpointRecord(ctx)
+	if err != nil {
+		return report, fmt.Errorf("read webhook endpoint record: %w", err)
+	}
+	report.Previous = record.Endpoint
+	if found && record.Endpoint == endpoint {
+		return report, nil
+	}
+	projects, err := a.store.ListProjects(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, project := range projects {
+		report.Scanned++
+		recorded, bootstrapped, readErr := readSourceWebhookEndpoint(sourceRepoDir(a.artifacts, project.ID))
+		if readErr != nil {
+			registryLog.Warnf("project=%s read webhook endpoint: %v", project.ID, readErr)
+			report.Failed++
+			continue
+		}
+		if !bootstrapped || recorded == endpoint {
+			continue
+		}
+		report.Stale++
+		op, refreshErr := a.refreshProjectWebhook(ctx, project.ID, recorded, endpoint)
+		var conflict projectOpConflictError
+		switch {
+		case errors.As(refreshErr, &conflict):
+			registryLog.Infof("project=%s webhook refresh deferred behind op=%s", project.ID, conflict.ActiveOp.ID)
+			report.Deferred++
+		case refreshErr != nil:
+			registryLog.Warnf("project=%s op=%s webhook refresh failed: %v", project.ID, op.ID, refreshErr)
+			report.Failed++
+		default:
+			report.Refreshed++
+		}
+	}
+	if report.Deferred > 0 || report.Failed > 0 {
+		return report, nil
+	}
+	return report, a.store.putWebhookEndpointRecord(ctx, endpoint)
+}
+
+// refreshProjectWebhook records a webhook-refresh op and repoints the
+// project's source repo from one endpoint to the other. It holds the
+// project's start lock throughout, so no op can start on the repo meanwhile.
+func (a *API) refreshProjectWebhook(ctx context.Context, projectID, from, to string) (Operation, error) {
+	projectMu := a.projectStartLock(projectID)
+	projectMu.Lock()
+	defer projectMu.Unlock()
+	if err := a.projectOperationConflict(ctx, projectID, OpWebhookRefresh); err != nil {
+		return Operation{}, err
+	}
+
+	now := time.Now().UTC()
+	op := newWebhookRefreshOp(projectID, from, to, now)
+	if err := a.store.PutOp(ctx, op); err != nil {
+		return Operation{}, fmt.Errorf("persist op: %w", err)
+	}
+	emitOpBootstrap(a.opEvents, op, "webhook endpoint changed")
+	emitOpStatus(a.opEvents, op, "queued")
+	if err := markOpStepStart(ctx, a.store, op.ID, endpointRegistryWorker, now, "repointing source webhook"); err != nil {
+		return op, err
+	}
+
+	sourceDir := sourceRepoDir(a.artifacts, projectID)
+	commit, refreshErr := rewriteSourceWebhook(ctx, sourceDir, projectID, to, op.ID)
+	if refreshErr == nil && commit != "" {
+		refreshErr = recordWebhookRefreshCommit(ctx, a.store, op.ID, commit)
+	}
+	status, stepMsg, stepErr := opStatusDone, "source webhook now posts to "+to, ""
+	if refreshErr != nil {
+		status, stepMsg, stepErr = opStatusError, "", refreshErr.Error()
+	}
+	webhookMeta := relPath(a.artifacts.ProjectDir(projectID), sourceWebhookMetaPath(sourceDir))
+	if err := markOpStepEnd(
+		ctx, a.store, op.ID, endpointRegistryWorker, time.Now().UTC(), stepMsg, stepErr, []string{webhookMeta},
+	); err != nil {
+		return op, err
+	}
+	if err := finalizeOp(ctx, a.store, op.ID, projectID, OpWebhookRefresh, status, stepErr); err != nil {
+		return op, err
+	}
+	return op, refreshErr
+}
+
+func newWebhookRefreshOp(projectID, from, to string, now time.Time) Operation {
+	return Operation{
+		ID:        newID(),
+		Kind:      OpWebhookRefresh,
+		ProjectID: projectID,
+		Delivery: DeliveryLifecycle{
+			Stage:           "",
+			Environment:     "",
+			FromEnv:         "",
+			ToEnv:           "",
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
+		},
+		Execution:             OpExecution{DryRun: false, Trace: false},
+		Requested:             now,
+		Finished:              time.Time{},
+		Status:                statusMessageQueued,
+		Error:                 "",
+		Steps:                 []OpStep{},
+		VulnerabilityOverride: nil,
+		FreezeOverride:        nil,
+		ApprovalID:            "",
+		Notes:                 nil,
+		SpecHash:              "",
+		ParentOpID:            "",
+		Rollout:               nil,
+		Fanout:                nil,
+		SpecChange:            nil,
+		Remediation:           nil,
+		RemediationOf:         "",
+		ScheduleID:            "",
+		CloneOf:               "",
+		CI:                    nil,
+		Upgrade:               nil,
+		Webhook:               &WebhookRefresh{From: from, To: to, Commit: ""},
+		SLABreached:           false,
+		SLABreaches:           nil,
+	}
+}
+
+// rewriteSourceWebhook reinstalls the hooks and webhook.json for endpoint
+// and commits the change, returning the new commit when there was one.
+func rewriteSourceWebhook(ctx context.Context, sourceDir, projectID, endpoint, opID string) (string, error) {
+	if _, _, err := writeSourceWebhook(sourceDir, projectID, endpoint); err != nil {
+		return "", err
+	}
+	committed, err := gitCommitIfChanged(
+		ctx,
+		sourceDir,
+		fmt.Sprintf("platform-sync: refresh source webhook endpoint (%s)", shortID(opID)),
+	)
+	if err != nil || !committed {
+		return "", err
+	}
+	return gitRevParse(ctx, sourceDir, "HEAD")
+}
+
+func recordWebhookRefreshCommit(ctx context.Context, store *Store, opID, commit string) error {
+	_, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		if op.Webhook == nil {
+			return false
+		}
+		op.Webhook.Commit = commit
+		return true
+	})
+	return err
+}
@@ -0,0 +1,120 @@
+//nolint:testpackage,exhaustruct // Registry tests drive the internal store and source repos directly.
+package platform
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWebhookEndpointRegistry_RepointsStaleReposAsOps(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	artifacts := NewFSArtifacts(t.TempDir())
+	api := &API{nc: fixture.nc, store: fixture.store, artifacts: artifacts}
+	registryLog := appLoggerForProcess().Source("registry-test")
+	const (
+		oldEndpoint = "http://127.0.0.1:8080/api/webhooks/source"
+		newEndpoint = "http://127.0.0.1:9090/api/webhooks/source"
+	)
+
+	seedRepo := func(projectID, opID string) string {
+		t.Helper()
+		putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCreate, workerRuntimeSpec(projectID))
+		repoDir := sourceRepoDir(artifacts, projectID)
+		if err := ensureLocalGitRepo(ctx, repoDir); err != nil {
+			t.Fatalf("init source repo: %v", err)
+		}
+		if _, _, err := writeSourceWebhook(repoDir, projectID, oldEndpoint); err != nil {
+			t.Fatalf("write webhook: %v", err)
+		}
+		if _, err := gitCommitIfChanged(ctx, repoDir, "seed"); err != nil {
+			t.Fatalf("commit seed: %v", err)
+		}
+		return repoDir
+	}
+	idleRepo := seedRepo("project-registry-idle", "op-registry-idle")
+	busyRepo := seedRepo("project-registry-busy", "op-registry-busy")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-registry-new", "op-registry-new", OpCreate,
+		workerRuntimeSpec("registry-new"))
+	if _, err := fixture.store.updateProject(ctx, "project-registry-busy", func(project *Project) bool {
+		project.Status.LastOpID = "op-registry-busy"
+		return true
+	}); err != nil {
+		t.Fatalf("mark busy project: %v", err)
+	}
+
+	report, err := api.refreshWebhookEndpoints(ctx, newEndpoint, registryLog)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if report.Scanned != 3 || report.Stale != 2 || report.Refreshed != 1 || report.Deferred != 1 {
+		t.Fatalf("unexpected first pass: %#v", report)
+	}
+	if _, found, _ := fixture.store.getWebhookEndpointRecord(ctx); found {
+		t.Fatal("expected the record to wait for the deferred repo")
+	}
+	assertHooksPostTo(t, idleRepo, newEndpoint)
+	assertHooksPostTo(t, busyRepo, oldEndpoint)
+
+	page, err := fixture.store.listProjectOps(ctx, "project-registry-idle", projectOpsListQuery{Limit: 1})
+	if err != nil || len(page.Ops) != 1 || page.Ops[0].Kind != OpWebhookRefresh {
+		t.Fatalf("expected a webhook-refresh op, got %#v err=%v", page.Ops, err)
+	}
+	op, err := fixture.store.GetOp(ctx, page.Ops[0].ID)
+	if err != nil || op.Status != opStatusDone || op.Webhook == nil {
+		t.Fatalf("expected a finished refresh op, got %#v err=%v", op, err)
+	}
+	if op.Webhook.From != oldEndpoint || op.Webhook.To != newEndpoint || op.Webhook.Commit == "" {
+		t.Fatalf("unexpected refresh record: %#v", op.Webhook)
+	}
+	head, _ := gitRevParse(ctx, idleRepo, "HEAD")
+	if head != op.Webhook.Commit {
+		t.Fatalf("expected the refresh commit %s at HEAD, got %s", op.Webhook.Commit, head)
+	}
+	if project, _ := fixture.store.GetProject(ctx, "project-registry-idle"); project.Status.LastOpID != "" {
+		t.Fatalf("expected the refresh to stay off the project status, got %#v", project.Status)
+	}
+
+	busyOp, err := fixture.store.GetOp(ctx, "op-registry-busy")
+	if err != nil {
+		t.Fatalf("get busy op: %v", err)
+	}
+	busyOp.Status = opStatusDone
+	if err = fixture.store.PutOp(ctx, busyOp); err != nil {
+		t.Fatalf("finish busy op: %v", err)
+	}
+	if report, err = api.refreshWebhookEndpoints(ctx, newEndpoint, registryLog); err != nil {
+		t.Fatalf("second refresh: %v", err)
+	}
+	if report.Stale != 1 || report.Refreshed != 1 {
+		t.Fatalf("unexpected second pass: %#v", report)
+	}
+	assertHooksPostTo(t, busyRepo, newEndpoint)
+	record, found, err := fixture.store.getWebhookEndpointRecord(ctx)
+	if err != nil || !found || record.Endpoint != newEndpoint {
+		t.Fatalf("expected the record to move to %s, got %#v found=%v err=%v", newEndpoint, record, found, err)
+	}
+
+	if report, err = api.refreshWebhookEndpoints(ctx, newEndpoint, registryLog); err != nil || report.Scanned != 0 {
+		t.Fatalf("expected an unchanged endpoint to skip the scan, got %#v err=%v", report, err)
+	}
+}
+
+func assertHooksPostTo(t *testing.T, repoDir, endpoint string) {
+	t.Helper()
+	recorded, _, err := readSourceWebhookEndpoint(repoDir)
+	if err != nil || recorded != endpoint {
+		t.Fatalf("expected webhook.json in %s to name %s, got %q err=%v", repoDir, endpoint, recorded, err)
+	}
+	for _, hook := range []string{"post-commit", "post-merge"} {
+		script, readErr := os.ReadFile(filepath.Join(repoDir, ".git", "hooks", hook))
+		if readErr != nil || !strings.Contains(string(script), "-X "+http.MethodPost+" '"+endpoint+"'") {
+			t.Fatalf("expected %s to post to %s, err=%v", hook, endpoint, readErr)
+		}
+	}
+}
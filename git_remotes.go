@@ -0,0 +1,370 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Remote git repos: spec.repos can point the source and manifests repos at
+// external remotes. The repos under the project's artifacts stay the working
+// copies every worker reads and writes. Repo bootstrap (and a CI op, for the
+// source repo) fetches the remote's main into them, so a new project starts
+// as a clone; the workers that commit push main back. Local-only repos stay
+// the default.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	gitRemoteName            = "origin"
+	gitCredentialProviderEnv = "env"
+	gitDefaultRemoteUser     = "git"
+	remoteRepoURLMaxLength   = 2048
+)
+
+var gitCredentialEnvNameRe = regexp.MustCompile(`^PAAS_GIT_CREDENTIAL_[A-Z0-9_]+$`)
+
+// gitRemoteSession is a local repo with its origin set to a remote, and the
+// auth to reach it with.
+type gitRemoteSession struct {
+	repo *gogit.Repository
+	url  string
+	auth transport.AuthMethod
+}
+
+func normalizeRepoRemotes(in RepoRemotes) RepoRemotes {
+	for _, remote := range []*RemoteRepo{&in.Source, &in.Manifests} {
+		remote.URL = strings.TrimSpace(remote.URL)
+		remote.CredentialsRef = strings.TrimSpace(remote.CredentialsRef)
+	}
+	return in
+}
+
+func validateRepoRemotes(repos RepoRemotes) error {
+	if err := validateRemoteRepo("repos.source", repos.Source); err != nil {
+		return err
+	}
+	return validateRemoteRepo("repos.manifests", repos.Manifests)
+}
+
+func validateRemoteRepo(field string, remote RemoteRepo) error {
+	if remote.URL == "" {
+		if remote.CredentialsRef != "" {
+			return specFieldErrorf(field+".credentialsRef", "%s.credentialsRef needs a url", field)
+		}
+		return nil
+	}
+	if len(remote.URL) > remoteRepoURLMaxLength {
+		return specFieldErrorf(field+".url", "%s.url exceeds %d characters", field, remoteRepoURLMaxLength)
+	}
+	parsed, err := url.Parse(remote.URL)
+	if err != nil {
+		return specFieldErrorf(field+".url", "%s.url is not a url", field)
+	}
+	switch parsed.Scheme {
+	case "https", "http":
+		if parsed.Host == "" {
+			return specFieldErrorf(field+".url", "%s.url needs a host", field)
+		}
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			return specFieldErrorf(field+".url", "%s.url must not carry a password; use credentialsRef", field)
+		}
+	case "file":
+		if !gitFileRemotesEnabled() {
+			return specFieldErrorf(field+".url", "file:// remotes are off; the server enables them with %s=true",
+				gitFileRemotesEnv)
+		}
+		if parsed.Path == "" {
+			return specFieldErrorf(field+".url", "%s.url needs a path", field)
+		}
+	default:
+		return specFieldErrorf(field+".url", "%s.url must be an https://, http://, or file:// url", field)
+	}
+	if remote.CredentialsRef != "" {
+		if _, err = parseGitCredentialsRef(remote.CredentialsRef); err != nil {
+			return specFieldErrorf(field+".credentialsRef", "%s.credentialsRef: %v", field, err)
+		}
+	}
+	return nil
+}
+
+// gitFileRemotesEnabled reports whether specs may name file:// remotes. They
+// read paths on the worker host, so an operator has to opt in.
+func gitFileRemotesEnabled() bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(gitFileRemotesEnv)))
+	return err == nil && enabled
+}
+
+// parseGitCredentialsRef accepts vault://path#key, or env://NAME for a worker
+// variable named PAAS_GIT_CREDENTIAL_*. The prefix keeps a spec from sending
+// any other server setting to a remote of its choosing.
+func parseGitCredentialsRef(raw string) (secretRef, error) {
+	if name, ok := strings.CutPrefix(raw, gitCredentialProviderEnv+"://"); ok {
+		if !gitCredentialEnvNameRe.MatchString(name) {
+			return secretRef{}, fmt.Errorf("env reference %q must name a variable matching %s",
+				raw, gitCredentialEnvNameRe.String())
+		}
+		return secretRef{Provider: gitCredentialProviderEnv, Path: name, Key: ""}, nil
+	}
+	ref, err := parseSecretRef(raw)
+	if err != nil {
+		return secretRef{}, err
+	}
+	if ref.Provider != secretProviderVault {
+		return secretRef{}, fmt.Errorf("reference %q must be vault://path#key or env://NAME", raw)
+	}
+	return ref, nil
+}
+
+// resolveGitRemoteAuth builds HTTP basic auth from the remote's credentials:
+// the token is the password, and the user is the url's, or "git".
+func resolveGitRemoteAuth(ctx context.Context, remote RemoteRepo) (transport.AuthMethod, error) {
+	ref, err := parseGitCredentialsRef(remote.CredentialsRef)
+	if err != nil {
+		return nil, err
+	}
+	var token string
+	if ref.Provider == gitCredentialProviderEnv {
+		token = strings.TrimSpace(os.Getenv(ref.Path))
+		if token == "" {
+			return nil, fmt.Errorf("credentials variable %s is not set on the worker", ref.Path)
+		}
+	} else if token, err = vaultSecretProviderFromEnv().resolve(ctx, ref); err != nil {
+		return nil, fmt.Errorf("resolve git credentials from %s: %w", ref, err)
+	}
+	user := gitDefaultRemoteUser
+	if parsed, parseErr := url.Parse(remote.URL); parseErr == nil && parsed.User.Username() != "" {
+		user = parsed.User.Username()
+	}
+	return &githttp.BasicAuth{Username: user, Password: token}, nil
+}
+
+// openGitRemote opens the repo at dir and points its origin at remote.
+func openGitRemote(ctx context.Context, dir string, remote RemoteRepo) (gitRemoteSession, error) {
+	repo, err := openLocalRepo(dir)
+	if err != nil {
+		return gitRemoteSession{}, err
+	}
+	session := gitRemoteSession{repo: repo, url: remote.URL, auth: nil}
+	if remote.CredentialsRef != "" {
+		if session.auth, err = resolveGitRemoteAuth(ctx, remote); err != nil {
+			return gitRemoteSession{}, err
+		}
+	}
+	existing, err := repo.Remote(gitRemoteName)
+	switch {
+	case err == nil && len(existing.Config().URLs) == 1 && existing.Config().URLs[0] == remote.URL:
+		return session, nil
+	case err == nil:
+		if err = repo.DeleteRemote(gitRemoteName); err != nil {
+			return gitRemoteSession{}, fmt.Errorf("reset remote %s: %w", gitRemoteName, err)
+		}
+	case !errors.Is(err, gogit.ErrRemoteNotFound):
+		return gitRemoteSession{}, fmt.Errorf("read remote %s: %w", gitRemoteName, err)
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name:   gitRemoteName,
+		URLs:   []string{remote.URL},
+		Mirror: false,
+		Fetch:  nil,
+	})
+	if err != nil {
+		return gitRemoteSession{}, fmt.Errorf("add remote %s: %w", gitRemoteName, err)
+	}
+	return session, nil
+}
+
+// syncGitRemote fetches the remote's main into the repo at dir and moves the
+// local main to it. A local main that is ahead is kept for the next push; one
+// that has diverged is an error, as the platform never merges. An empty
+// remote, or one without main, is left for the first push to fill.
+func syncGitRemote(ctx context.Context, dir string, remote RemoteRepo) error {
+	if remote.URL == "" {
+		return nil
+	}
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitTimeout)
+	defer cancel()
+	session, err := openGitRemote(runCtx, dir, remote)
+	if err != nil {
+		return err
+	}
+	remoteMain := plumbing.NewRemoteReferenceName(gitRemoteName, branchMain)
+	fetched, err := fetchGitRemote(ctx, runCtx, dir, session, plumbing.NewBranchReferenceName(branchMain), remoteMain)
+	if err != nil || !fetched {
+		return err
+	}
+	ref, err := session.repo.Reference(remoteMain, true)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", remoteMain, err)
+	}
+	return fastForwardMain(session.repo, ref.Hash(), session.url)
+}
+
+// fetchGitRemoteRef fetches one branch or tag of the remote: a branch into
+// refs/remotes/origin/, a tag into refs/tags/. Nothing on the remote under
+// that name is an error.
+func fetchGitRemoteRef(ctx context.Context, dir string, remote RemoteRepo, ref plumbing.ReferenceName) error {
+	if remote.URL == "" {
+		return nil
+	}
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitTimeout)
+	defer cancel()
+	session, err := openGitRemote(runCtx, dir, remote)
+	if err != nil {
+		return err
+	}
+	dst := ref
+	if ref.IsBranch() {
+		dst = plumbing.NewRemoteReferenceName(gitRemoteName, ref.Short())
+	}
+	fetched, err := fetchGitRemote(ctx, runCtx, dir, session, ref, dst)
+	if err == nil && !fetched {
+		err = fmt.Errorf("%s has no %s", session.url, ref)
+	}
+	return err
+}
+
+// fetchGitRemote fetches src from the session's remote into dst. It reports
+// false, without an error, when the remote is empty or has no src.
+func fetchGitRemote(
+	ctx, runCtx context.Context,
+	dir string,
+	session gitRemoteSession,
+	src, dst plumbing.ReferenceName,
+) (bool, error) {
+	err := session.repo.FetchContext(runCtx, &gogit.FetchOptions{
+		RemoteName:      gitRemoteName,
+		RemoteURL:       "",
+		RefSpecs:        []config.RefSpec{config.RefSpec("+" + src + ":" + dst)},
+		Depth:           0,
+		Auth:            session.auth,
+		Progress:        nil,
+		Tags:            gogit.NoTags,
+		Force:           true,
+		InsecureSkipTLS: false,
+		ClientCert:      nil,
+		ClientKey:       nil,
+		CABundle:        nil,
+		ProxyOptions:    transport.ProxyOptions{URL: "", Username: "", Password: ""},
+		Prune:           false,
+	})
+	traceCommand(ctx, fmt.Sprintf("git -C repos/%s fetch %s %s", filepath.Base(dir), gitRemoteName, src.Short()),
+		"", err)
+	switch {
+	case err == nil, errors.Is(err, gogit.NoErrAlreadyUpToDate):
+		return true, nil
+	case errors.Is(err, transport.ErrEmptyRemoteRepository), errors.Is(err, gogit.NoMatchingRefSpecError{}):
+		return false, nil
+	default:
+		return false, fmt.Errorf("fetch %s from %s: %w", src.Short(), session.url, err)
+	}
+}
+
+func fastForwardMain(repo *gogit.Repository, target plumbing.Hash, remoteURL string) error {
+	head, err := repo.Head()
+	if err == nil {
+		if head.Hash() == target {
+			return nil
+		}
+		local, localErr := repo.CommitObject(head.Hash())
+		if localErr != nil {
+			return fmt.Errorf("read local %s: %w", branchMain, localErr)
+		}
+		fetched, fetchedErr := repo.CommitObject(target)
+		if fetchedErr != nil {
+			return fmt.Errorf("read fetched %s: %w", branchMain, fetchedErr)
+		}
+		if ahead, _ := fetched.IsAncestor(local); ahead {
+			return nil
+		}
+		if behind, _ := local.IsAncestor(fetched); !behind {
+			return fmt.Errorf("local %s and %s have diverged; reconcile the remote before retrying",
+				branchMain, remoteURL)
+		}
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return fmt.Errorf("read head: %w", err)
+	}
+	// An unborn main has no ref for the reset to move, so point it first.
+	branch := plumbing.NewHashReference(plumbing.NewBranchReferenceName(branchMain), target)
+	if err = repo.Storer.SetReference(branch); err != nil {
+		return fmt.Errorf("move %s to %s: %w", branchMain, target, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("worktree: %w", err)
+	}
+	if err = wt.Reset(&gogit.ResetOptions{Commit: target, Mode: gogit.HardReset, Files: nil}); err != nil {
+		return fmt.Errorf("move %s to %s: %w", branchMain, target, err)
+	}
+	return nil
+}
+
+// fetchCISourceRemote fast-forwards the source repo to its remote's main
+// before a CI build, and fetches the pushed branch or tag when that is not
+// main. CI ops start at the image builder, so repo bootstrap never gets to
+// fetch for them.
+func fetchCISourceRemote(ctx context.Context, artifacts ArtifactStore, msg ProjectOpMsg, spec ProjectSpec) error {
+	if spec.Repos.Source.URL == "" {
+		return nil
+	}
+	sourceDir := sourceRepoDir(artifacts, msg.ProjectID)
+	subStepDone := beginSubStep(ctx, "fetch source remote")
+	err := syncGitRemote(ctx, sourceDir, spec.Repos.Source)
+	if ref := ciPushRef(msg); err == nil && ref != plumbing.NewBranchReferenceName(branchMain) {
+		err = fetchGitRemoteRef(ctx, sourceDir, spec.Repos.Source, ref)
+	}
+	subStepDone(err)
+	return err
+}
+
+// pushGitRemote pushes the repo's main to its remote. A remote main that
+// moved on since the last fetch refuses the push.
+func pushGitRemote(ctx context.Context, dir string, remote RemoteRepo) error {
+	if remote.URL == "" {
+		return nil
+	}
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitTimeout)
+	defer cancel()
+	session, err := openGitRemote(runCtx, dir, remote)
+	if err != nil {
+		return err
+	}
+	branch := plumbing.NewBranchReferenceName(branchMain)
+	err = session.repo.PushContext(runCtx, &gogit.PushOptions{
+		RemoteName:        gitRemoteName,
+		RemoteURL:         "",
+		RefSpecs:          []config.RefSpec{config.RefSpec(branch + ":" + branch)},
+		Auth:              session.auth,
+		Progress:          nil,
+		Prune:             false,
+		Force:             false,
+		InsecureSkipTLS:   false,
+		ClientCert:        nil,
+		ClientKey:         nil,
+		CABundle:          nil,
+		RequireRemoteRefs: nil,
+		FollowTags:        false,
+		ForceWithLease:    nil,
+		Options:           nil,
+		Atomic:            false,
+		ProxyOptions:      transport.ProxyOptions{URL: "", Username: "", Password: ""},
+	})
+	traceCommand(ctx, fmt.Sprintf("git -C repos/%s push %s %s", filepath.Base(dir), gitRemoteName, branchMain),
+		"", err)
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("push %s to %s: %w", branchMain, session.url, err)
+	}
+	return nil
+}
@@ -3,16 +3,152 @@ package platform
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
-// Infrastructure: Embedded NATS + JetStream KV
+// Infrastructure: Embedded or external NATS + JetStream KV
 ////////////////////////////////////////////////////////////////////////////////
 
+// natsEndpoint is how runtime components reach NATS: the embedded server's
+// loopback URL and the credentials it generated, or an external cluster
+// together with the credentials and TLS material it requires.
+type natsEndpoint struct {
+	url          string
+	external     bool
+	embeddedAuth embeddedNATSCredentials
+	credsFile    string
+	tlsCA        string
+	tlsCert      string
+	tlsKey       string
+}
+
+// runtimeNATS is the server a process runs against. The store fields only
+// apply to the embedded server; an external cluster keeps its own storage.
+type runtimeNATS struct {
+	endpoint       natsEndpoint
+	storeDir       string
+	storeEphemeral bool
+}
+
+func embeddedNATSEndpoint(clientURL string, auth embeddedNATSCredentials) natsEndpoint {
+	return natsEndpoint{
+		url:          clientURL,
+		external:     false,
+		embeddedAuth: auth,
+		credsFile:    "",
+		tlsCA:        "",
+		tlsCert:      "",
+		tlsKey:       "",
+	}
+}
+
+// externalNATSEndpointFromEnv reads PAAS_NATS_URL and its credential and TLS
+// settings. ok is false when no URL is set, which selects the embedded server.
+func externalNATSEndpointFromEnv() (natsEndpoint, bool, error) {
+	clusterURL := strings.TrimSpace(os.Getenv(natsURLEnv))
+	if clusterURL == "" {
+		return embeddedNATSEndpoint("", embeddedNATSCredentials{}), false, nil
+	}
+	endpoint, err := externalNATSEndpoint(clusterURL)
+	return endpoint, true, err
+}
+
+// externalNATSEndpoint pairs clusterURL with the PAAS_NATS_CREDS and TLS
+// settings from the environment.
+func externalNATSEndpoint(clusterURL string) (natsEndpoint, error) {
+	endpoint := natsEndpoint{
+		url:          clusterURL,
+		external:     true,
+		embeddedAuth: embeddedNATSCredentials{},
+		credsFile:    strings.TrimSpace(os.Getenv(natsCredsEnv)),
+		tlsCA:        strings.TrimSpace(os.Getenv(natsTLSCAEnv)),
+		tlsCert:      strings.TrimSpace(os.Getenv(natsTLSCertEnv)),
+		tlsKey:       strings.TrimSpace(os.Getenv(natsTLSKeyEnv)),
+	}
+	return endpoint, endpoint.validate()
+}
+
+func (e natsEndpoint) validate() error {
+	if (e.tlsCert == "") != (e.tlsKey == "") {
+		return fmt.Errorf("%s and %s must be set together", natsTLSCertEnv, natsTLSKeyEnv)
+	}
+	for _, file := range []struct{ env, path string }{
+		{natsCredsEnv, e.credsFile},
+		{natsTLSCAEnv, e.tlsCA},
+		{natsTLSCertEnv, e.tlsCert},
+		{natsTLSKeyEnv, e.tlsKey},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			return fmt.Errorf("%s: %w", file.env, err)
+		}
+	}
+	return nil
+}
+
+func (e natsEndpoint) options(name string) []nats.Option {
+	opts := []nats.Option{nats.Name(name)}
+	if !e.external {
+		return append(opts, e.embeddedAuth.clientOptions()...)
+	}
+	// A remote cluster restarts and fails over on its own schedule, so keep
+	// reconnecting instead of giving up after the client's default attempts.
+	opts = append(opts, nats.MaxReconnects(-1))
+	if e.credsFile != "" {
+		opts = append(opts, nats.UserCredentials(e.credsFile))
+	}
+	if e.tlsCA != "" {
+		opts = append(opts, nats.RootCAs(e.tlsCA))
+	}
+	if e.tlsCert != "" {
+		opts = append(opts, nats.ClientCert(e.tlsCert, e.tlsKey))
+	}
+	return opts
+}
+
+func (e natsEndpoint) connect(name string) (*nats.Conn, error) {
+	return nats.Connect(e.url, e.options(name)...)
+}
+
+// displayURL is the URL with embedded passwords and tokens masked, for logs
+// and GET /api/system.
+func (e natsEndpoint) displayURL() string {
+	parts := strings.Split(e.url, ",")
+	for i, raw := range parts {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			parts[i] = "<unparseable>"
+			continue
+		}
+		if u.User != nil {
+			if _, hasPassword := u.User.Password(); !hasPassword {
+				u.User = url.User("xxxxx") // token auth
+			}
+		}
+		parts[i] = u.Redacted()
+	}
+	return strings.Join(parts, ",")
+}
+
+// externalURL is the display URL of an external cluster, or empty when the
+// server is embedded; only an external URL means anything to API clients.
+func (r runtimeNATS) externalURL() string {
+	if !r.endpoint.external {
+		return ""
+	}
+	return r.endpoint.displayURL()
+}
+
 func ensureKVBucket(
 	ctx context.Context,
 	js jetstream.JetStream,
@@ -40,21 +176,46 @@ func ensureKVBucket(
 	return nil
 }
 
-func startEmbeddedNATS() (*server.Server, string, string, bool, error) {
+func startEmbeddedNATS() (*server.Server, natsEndpoint, string, bool, error) {
 	storeCfg := resolveNATSStoreDir()
 	storeDir := storeCfg.storeDir
+	var endpoint natsEndpoint
 	var err error
 	if storeCfg.isEphemeral {
 		storeDir, err = os.MkdirTemp("", "nats-js-*")
 		if err != nil {
-			return nil, "", "", false, err
+			return nil, endpoint, "", false, err
 		}
 	} else {
 		err = os.MkdirAll(storeDir, dirModePrivateRead)
 		if err != nil {
-			return nil, "", "", false, err
+			return nil, endpoint, "", false, err
 		}
 	}
+	ns, endpoint, err := startNATSServerAt(storeDir, true)
+	if err != nil {
+		if storeCfg.isEphemeral {
+			_ = os.RemoveAll(storeDir)
+		}
+		return nil, endpoint, "", false, err
+	}
+	return ns, endpoint, storeDir, storeCfg.isEphemeral, nil
+}
+
+// startNATSServerAt runs a loopback-only JetStream server over storeDir that
+// only accepts the credentials generated for it here, and returns the
+// endpoint that carries them. paasadmin passes withLogs=false so its output
+// stays machine-readable.
+func startNATSServerAt(storeDir string, withLogs bool) (*server.Server, natsEndpoint, error) {
+	var endpoint natsEndpoint
+	mode, err := embeddedNATSAuthModeFromEnv()
+	if err != nil {
+		return nil, endpoint, err
+	}
+	auth, err := newEmbeddedNATSCredentials(mode)
+	if err != nil {
+		return nil, endpoint, err
+	}
 	var opts server.Options
 	opts.ServerName = "embedded-paas"
 	opts.Host = "127.0.0.1"
@@ -62,42 +223,46 @@ func startEmbeddedNATS() (*server.Server, string, string, bool, error) {
 	opts.JetStream = true
 	opts.StoreDir = storeDir
 	opts.NoSigs = true
+	opts.NoLog = !withLogs
+	if err = auth.apply(&opts); err != nil {
+		return nil, endpoint, err
+	}
 
 	ns, err := server.NewServer(&opts)
 	if err != nil {
-		if storeCfg.isEphemeral {
-			_ = os.RemoveAll(storeDir)
-		}
-		return nil, "", "", false, err
+		return nil, endpoint, err
+	}
+	if withLogs {
+		ns.ConfigureLogger()
 	}
-	ns.ConfigureLogger()
 	ns.Start()
 	if !ns.ReadyForConnections(defaultStartupWait) {
 		ns.Shutdown()
 		ns.WaitForShutdown()
-		if storeCfg.isEphemeral {
-			_ = os.RemoveAll(storeDir)
-		}
-		return nil, "", "", false, errors.New("nats not ready")
+		return nil, endpoint, errors.New("nats not ready")
 	}
-	return ns, ns.ClientURL(), storeDir, storeCfg.isEphemeral, nil
+	return ns, embeddedNATSEndpoint(ns.ClientURL(), auth), nil
 }
 
 func ensureWorkerDeliveryStream(ctx context.Context, js jetstream.JetStream) error {
 	var cfg jetstream.StreamConfig
-	cfg.Name = streamWorkerPipeline
-	cfg.Subjects = []string{
-		subjectProjectOpStart,
-		subjectRegistrationDone,
-		subjectBootstrapDone,
-		subjectBuildDone,
-		subjectDeployDone,
-		subjectDeploymentStart,
-		subjectDeploymentDone,
-		subjectPromotionStart,
-		subjectPromotionDone,
-		subjectWorkerPoison,
-	}
+	cfg.Name = natsStreamName(streamWorkerPipeline)
+	pipelineSubjects := workerStreamSubjects([]string{
+		natsSubject(subjectProjectOpStart),
+		natsSubject(subjectRegistrationDone),
+		natsSubject(subjectBootstrapDone),
+		natsSubject(subjectBuildDone),
+		natsSubject(subjectDeployDone),
+		natsSubject(subjectDeploymentStart),
+		natsSubject(subjectDeploymentDone),
+		natsSubject(subjectPromotionStart),
+		natsSubject(subjectPromotionDone),
+		natsSubject(subjectCleanupStart),
+		natsSubject(subjectCleanupDone),
+		natsSubject(subjectUpgradeStart),
+		natsSubject(subjectUpgradeDone),
+	})
+	cfg.Subjects = append(pipelineSubjects, natsSubject(subjectWorkerPoison), natsSubject(subjectWorkerPoison)+".org.*")
 	cfg.Retention = jetstream.LimitsPolicy
 	cfg.MaxMsgs = workerDeliveryStreamMaxMsgs
 	cfg.MaxBytes = workerDeliveryStreamMaxBytes
@@ -0,0 +1,115 @@
+package platform
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"golang.org/x/crypto/bcrypt"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Embedded NATS authentication: the embedded server only accepts the
+// credentials its own process generated at startup, so other local processes
+// cannot connect and publish into the pipeline subjects. Every start generates
+// new ones. PAAS_NATS_EMBEDDED_AUTH selects user/password (the default),
+// nkey, or none.
+////////////////////////////////////////////////////////////////////////////////
+
+type embeddedNATSAuthMode string
+
+const (
+	embeddedNATSAuthPassword embeddedNATSAuthMode = "password"
+	embeddedNATSAuthNKey     embeddedNATSAuthMode = "nkey"
+	embeddedNATSAuthNone     embeddedNATSAuthMode = "none"
+
+	embeddedNATSUser          = "paas"
+	embeddedNATSPasswordBytes = 32
+)
+
+// embeddedNATSCredentials is what a client of the embedded server presents.
+// They live only in process memory.
+type embeddedNATSCredentials struct {
+	mode     embeddedNATSAuthMode
+	user     string
+	password string
+	nkey     nkeys.KeyPair
+}
+
+func embeddedNATSAuthModeFromEnv() (embeddedNATSAuthMode, error) {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv(natsEmbeddedAuthEnv)))
+	switch mode := embeddedNATSAuthMode(raw); mode {
+	case "":
+		return embeddedNATSAuthPassword, nil
+	case embeddedNATSAuthPassword, embeddedNATSAuthNKey, embeddedNATSAuthNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%s must be password, nkey, or none, got %q", natsEmbeddedAuthEnv, raw)
+	}
+}
+
+// newEmbeddedNATSCredentials generates fresh credentials for mode.
+func newEmbeddedNATSCredentials(mode embeddedNATSAuthMode) (embeddedNATSCredentials, error) {
+	creds := embeddedNATSCredentials{mode: mode, user: "", password: "", nkey: nil}
+	switch mode {
+	case embeddedNATSAuthPassword:
+		buf := make([]byte, embeddedNATSPasswordBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return creds, fmt.Errorf("generate nats password: %w", err)
+		}
+		creds.user = embeddedNATSUser
+		creds.password = base64.RawURLEncoding.EncodeToString(buf)
+	case embeddedNATSAuthNKey:
+		kp, err := nkeys.CreateUser()
+		if err != nil {
+			return creds, fmt.Errorf("generate nats nkey: %w", err)
+		}
+		creds.nkey = kp
+	case embeddedNATSAuthNone:
+	}
+	return creds, nil
+}
+
+// apply makes the server require these credentials. The server only gets the
+// password's bcrypt hash; the password is random, so the minimum cost will do.
+func (c embeddedNATSCredentials) apply(opts *server.Options) error {
+	switch c.mode {
+	case embeddedNATSAuthPassword:
+		hash, err := bcrypt.GenerateFromPassword([]byte(c.password), bcrypt.MinCost)
+		if err != nil {
+			return fmt.Errorf("hash nats password: %w", err)
+		}
+		opts.Username = c.user
+		opts.Password = string(hash)
+	case embeddedNATSAuthNKey:
+		public, err := c.nkey.PublicKey()
+		if err != nil {
+			return fmt.Errorf("nats nkey public key: %w", err)
+		}
+		var user server.NkeyUser
+		user.Nkey = public
+		opts.Nkeys = []*server.NkeyUser{&user}
+	case embeddedNATSAuthNone:
+	}
+	return nil
+}
+
+func (c embeddedNATSCredentials) clientOptions() []nats.Option {
+	switch c.mode {
+	case embeddedNATSAuthPassword:
+		return []nats.Option{nats.UserInfo(c.user, c.password)}
+	case embeddedNATSAuthNKey:
+		public, err := c.nkey.PublicKey()
+		if err != nil {
+			return nil
+		}
+		return []nats.Option{nats.Nkey(public, c.nkey.Sign)}
+	case embeddedNATSAuthNone:
+	}
+	return nil
+}
@@ -36,6 +36,7 @@ func NewTestAPI(artifacts ArtifactStore) *API {
 		},
 		runtimeCommitWatcherEnabled: false,
 		runtimeNATSEmbedded:         false,
+		runtimeNATSURL:              "",
 		runtimeNATSStoreDir:         "",
 		runtimeNATSStoreEphemeral:   false,
 		sourceTriggerMu:             sync.Mutex{},
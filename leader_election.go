@@ -0,0 +1,167 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Leader election for singleton background jobs (JetStream KV lease)
+////////////////////////////////////////////////////////////////////////////////
+
+// leaderElector holds a lease key in a KV bucket whose max age equals the
+// lease TTL. The holder renews with a revision-checked update; if it stops
+// renewing, the key ages out and another replica's create succeeds.
+type leaderElector struct {
+	kv       jetstream.KeyValue
+	holderID string
+	ttl      time.Duration
+	log      sourceLogger
+
+	mu       sync.Mutex
+	revision uint64
+	lost     chan struct{} // non-nil while leading; closed on step-down
+}
+
+func newLeaderElector(ctx context.Context, js jetstream.JetStream, ttl time.Duration) (*leaderElector, error) {
+	var cfg jetstream.KeyValueConfig
+	cfg.Bucket = kvBucketName(kvBucketLeases)
+	cfg.History = 1
+	cfg.TTL = ttl
+	kv, err := js.CreateKeyValue(ctx, cfg)
+	if errors.Is(err, jetstream.ErrBucketExists) {
+		kv, err = js.KeyValue(ctx, kvBucketName(kvBucketLeases))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lease bucket: %w", err)
+	}
+	host, _ := os.Hostname()
+	return &leaderElector{
+		kv:       kv,
+		holderID: fmt.Sprintf("%s/%d/%s", host, os.Getpid(), newID()),
+		ttl:      ttl,
+		log:      appLoggerForProcess().Source("leader"),
+		mu:       sync.Mutex{},
+		revision: 0,
+		lost:     nil,
+	}, nil
+}
+
+// run campaigns for and renews the lease until ctx ends, then releases it so
+// a standby replica can take over without waiting for expiry.
+func (e *leaderElector) run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / leaderLeaseRenewDivisor)
+	defer ticker.Stop()
+	for {
+		e.tick(ctx)
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *leaderElector) tick(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lost != nil {
+		revision, err := e.kv.Update(ctx, leaderLeaseKey, []byte(e.holderID), e.revision)
+		if err != nil {
+			e.log.Warnf("lost leadership holder=%s: %v", e.holderID, err)
+			e.stepDownLocked()
+			return
+		}
+		e.revision = revision
+		return
+	}
+	revision, err := e.kv.Create(ctx, leaderLeaseKey, []byte(e.holderID))
+	if err != nil {
+		if !errors.Is(err, jetstream.ErrKeyExists) && ctx.Err() == nil {
+			e.log.Warnf("lease campaign failed: %v", err)
+		}
+		return
+	}
+	e.revision = revision
+	e.lost = make(chan struct{})
+	e.log.Infof("acquired leadership holder=%s", e.holderID)
+}
+
+func (e *leaderElector) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lost == nil {
+		return
+	}
+	releaseCtx, cancel := context.WithTimeout(context.Background(), e.ttl)
+	defer cancel()
+	_ = e.kv.Delete(releaseCtx, leaderLeaseKey, jetstream.LastRevision(e.revision))
+	e.stepDownLocked()
+	e.log.Infof("released leadership holder=%s", e.holderID)
+}
+
+func (e *leaderElector) stepDownLocked() {
+	if e.lost != nil {
+		close(e.lost)
+	}
+	e.lost = nil
+	e.revision = 0
+}
+
+// leadership returns a channel that is closed when this replica stops
+// leading, or false when it is not the leader.
+func (e *leaderElector) leadership() (<-chan struct{}, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lost == nil {
+		return nil, false
+	}
+	return e.lost, true
+}
+
+func (e *leaderElector) isLeader() bool {
+	_, ok := e.leadership()
+	return ok
+}
+
+// runSingletonJob runs job only while this replica holds the lease and
+// restarts it after failover back to this replica. A nil elector runs the
+// job directly, which is the single-replica behavior.
+func runSingletonJob(ctx context.Context, elector *leaderElector, job func(context.Context)) {
+	if elector == nil {
+		job(ctx)
+		return
+	}
+	ticker := time.NewTicker(elector.ttl / leaderLeaseRenewDivisor)
+	defer ticker.Stop()
+	for {
+		if lost, ok := elector.leadership(); ok {
+			runWhileLeading(ctx, lost, job)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runWhileLeading(ctx context.Context, lost <-chan struct{}, job func(context.Context)) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lost:
+			cancel()
+		case <-jobCtx.Done():
+		}
+	}()
+	job(jobCtx)
+}
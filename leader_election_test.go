@@ -0,0 +1,55 @@
+//nolint:testpackage // Leader election tests drive the unexported elector tick/release steps directly.
+package platform
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLeaderElection_SingleLeaderWithFailover(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	first, err := newLeaderElector(ctx, fixture.js, time.Second)
+	if err != nil {
+		t.Fatalf("first elector: %v", err)
+	}
+	second, err := newLeaderElector(ctx, fixture.js, time.Second)
+	if err != nil {
+		t.Fatalf("second elector: %v", err)
+	}
+
+	first.tick(ctx)
+	second.tick(ctx)
+	if !first.isLeader() || second.isLeader() {
+		t.Fatalf("expected only first elector to lead, first=%t second=%t", first.isLeader(), second.isLeader())
+	}
+	first.tick(ctx)
+	if !first.isLeader() {
+		t.Fatal("expected renewal to keep leadership")
+	}
+
+	loopCtx, stopLoop := context.WithCancel(ctx)
+	defer stopLoop()
+	jobStopped := make(chan struct{})
+	go runSingletonJob(loopCtx, first, func(jobCtx context.Context) {
+		<-jobCtx.Done()
+		close(jobStopped)
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	first.release()
+	select {
+	case <-jobStopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected singleton job to stop after leadership was released")
+	}
+
+	second.tick(ctx)
+	if !second.isLeader() {
+		t.Fatal("expected second elector to take over after release")
+	}
+	second.release()
+}
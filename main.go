@@ -3,6 +3,7 @@ package platform
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
@@ -18,16 +20,31 @@ import (
 // Run starts the local platform runtime (embedded NATS, workers, and HTTP API).
 func Run() {
 	mainLog := appLoggerForProcess().Source("main")
+	cfg, err := loadRuntimeConfig()
+	if err != nil {
+		mainLog.Fatalf("config: %v", err)
+	}
 	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stopSignals()
-	ctx, cancel := context.WithCancel(signalCtx)
+	// Workers outlive the signal until their in-flight steps finish, so the
+	// runtime context is cancelled by shutdownRuntime, not by the signal.
+	drain := newWorkerDrain()
+	queues := newWorkerQueues(workerLimitsFromEnv())
+	ctx, cancel := context.WithCancel(
+		withWorkerQueues(withWorkerDrain(withRuntimeConfig(context.Background(), cfg), drain), queues),
+	)
 	defer cancel()
+	signalled := make(chan time.Time, 1)
+	stopDrainOnSignal := context.AfterFunc(signalCtx, func() {
+		signalled <- time.Now()
+		drain.stop()
+	})
+	defer stopDrainOnSignal()
 
-	natsURL, jsDir, jsDirEphemeral, stopNATS := startRuntimeNATS(mainLog)
+	natsRuntime, stopNATS := startRuntimeNATS(mainLog)
 	defer stopNATS()
 
-	var err error
-	nc, err := nats.Connect(natsURL, nats.Name("api"))
+	nc, err := natsRuntime.endpoint.connect("api")
 	if err != nil {
 		mainLog.Fatalf("connect nats: %v", err)
 	}
@@ -41,38 +58,79 @@ func Run() {
 	if err != nil {
 		mainLog.Fatalf("jetstream: %v", err)
 	}
-	streamErr := ensureWorkerDeliveryStream(ctx, js)
-	if streamErr != nil {
-		mainLog.Fatalf("worker delivery stream: %v", streamErr)
-	}
-
-	store, err := newStore(ctx, js)
+	store, opEvents, err := openRuntimeStore(ctx, js)
 	if err != nil {
-		mainLog.Fatalf("store: %v", err)
+		mainLog.Fatalf("%v", err)
+	}
+	if storeReadCacheEnabledFromEnv() {
+		if cacheErr := store.enableReadCache(ctx); cacheErr != nil {
+			mainLog.Warnf("store read cache unavailable, reads go to KV: %v", cacheErr)
+		}
 	}
-	opEvents := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
-	store.setOpEvents(opEvents)
 	runProjectOpsHistoryBackfill(ctx, store, mainLog)
+	elector, err := newLeaderElector(ctx, js, leaderLeaseTTL)
+	if err != nil {
+		mainLog.Fatalf("leader election: %v", err)
+	}
+	go elector.run(ctx)
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		runOpStepsCompactor(jobCtx, store, appLoggerForProcess().Source("opCompactor"))
+	})
 
-	artifactsRoot := resolveArtifactsRoot()
+	artifactsRoot := resolveArtifactsRoot(cfg)
 	artifacts := NewFSArtifacts(artifactsRoot.root)
 	mkdirErr := os.MkdirAll(artifactsRoot.root, dirModePrivateRead)
 	if mkdirErr != nil {
 		mainLog.Fatalf("mkdir artifacts root: %v", mkdirErr)
 	}
-	builderMode := resolveEffectiveImageBuilderMode(ctx)
-
-	startErr := startPlatformWorkers(ctx, natsURL, artifacts, opEvents, builderMode)
-	if startErr != nil {
-		mainLog.Fatalf("start worker: %v", startErr)
+	placed, err := configureArtifactRoots(ctx, store, artifacts)
+	if err != nil {
+		mainLog.Fatalf("artifact roots: %v", err)
+	}
+	if placed > 0 {
+		mainLog.Infof("artifact roots: %d project(s) placed on alternate roots", placed)
+	}
+	artifacts.projectOrg = store.projectOrg
+	specExtensions, err := specExtensionsFromEnv()
+	if err != nil {
+		mainLog.Fatalf("spec extensions: %v", err)
+	}
+	runbook, err := runbookFromEnv()
+	if err != nil {
+		mainLog.Fatalf("runbook: %v", err)
+	}
+	freezes, err := freezeConfigFromEnv()
+	if err != nil {
+		mainLog.Fatalf("freeze windows: %v", err)
+	}
+	quotas, rateLimiter, err := limitsFromEnv()
+	if err != nil {
+		mainLog.Fatalf("limits: %v", err)
 	}
+	builderMode := resolveEffectiveImageBuilderMode(ctx)
 
-	waiters := newWaiterHub()
-	stopFinalResults, err := subscribeFinalResults(ctx, js, waiters, mainLog)
+	workers, err := startRuntimeWorkers(ctx, nc, js, runtimeWorkerConfig{
+		endpoint:      natsRuntime.endpoint,
+		artifacts:     artifacts,
+		opEvents:      opEvents,
+		builderMode:   builderMode,
+		readinessMode: readinessModeFromEnv(),
+		log:           mainLog,
+	})
 	if err != nil {
-		mainLog.Fatalf("subscribe final: %v", err)
+		mainLog.Fatalf("%v", err)
+	}
+	defer workers.stop()
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		runOpResume(jobCtx, js, store, artifacts, appLoggerForProcess().Source("opResume"))
+	})
+	if cfg.OpStaleTTL > 0 {
+		go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+			runOpReaper(jobCtx, store, artifacts, cfg.OpStaleTTL, appLoggerForProcess().Source("opReaper"))
+		})
 	}
-	defer stopFinalResults()
+	startOpSLAChecker(ctx, store, elector, opSLAThresholdsFromConfig(cfg))
+	startHealthChecker(ctx, store, artifacts, elector)
 
 	flushErr := nc.Flush()
 	if flushErr != nil {
@@ -81,36 +139,68 @@ func Run() {
 
 	api, watcherStarted := newRuntimeAPIWithWatcher(
 		ctx,
+		elector,
 		nc,
 		store,
 		artifacts,
-		waiters,
+		workers.waiters,
 		opEvents,
 		builderMode,
-		artifactsRoot.root,
-		jsDir,
-		jsDirEphemeral,
+		cfg,
+		natsRuntime,
 	)
-	srv := &http.Server{
-		Addr:              httpAddr,
-		Handler:           api.routes(),
-		ReadHeaderTimeout: defaultReadHeaderWait,
+	api.readiness = workers.readiness
+	api.workerQueues = queues
+	api.specExtensions = specExtensions
+	api.runbook = runbook
+	api.freezes = freezes
+	api.quotas = quotas
+	api.rateLimiter = rateLimiter
+	startWebhookEndpointRegistry(ctx, api, elector)
+	startOpScheduler(ctx, api, elector)
+	startPreviewReaper(ctx, api, elector)
+	if startRemediationWorker(ctx, api, elector) {
+		mainLog.Infof("runbook: %d remediation hook(s) enabled", len(runbook.Hooks))
 	}
+	srv := newHTTPServer(cfg.HTTPAddr, api.routes())
 
+	logRuntimeConfig(mainLog, cfg)
 	logRuntimeStartup(
 		mainLog,
-		natsURL,
-		jsDir,
-		jsDirEphemeral,
+		natsRuntime,
 		watcherStarted,
 		builderMode,
 		artifactsRoot,
+		cfg.HTTPAddr,
 	)
 
 	serveErr := serveHTTPUntilSignalOrExit(signalCtx, srv, mainLog)
 	if serveErr != nil {
 		mainLog.Fatalf("http server: %v", serveErr)
 	}
+	if signalCtx.Err() != nil {
+		shutdownRuntime(ctx, cancel, drain, store, nc, (<-signalled).Add(cfg.ShutdownTimeout), mainLog)
+	}
+}
+
+// shutdownRuntime runs once the HTTP server has stopped: it waits for the
+// workers' in-flight steps, stops the runtime context, and flushes NATS
+// before Run's deferred drains and the embedded server stop.
+func shutdownRuntime(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	drain *workerDrain,
+	store *Store,
+	nc *nats.Conn,
+	deadline time.Time,
+	mainLog sourceLogger,
+) {
+	drainWorkers(ctx, drain, cancel, store, deadline, mainLog)
+	cancel()
+	if err := nc.Flush(); err != nil {
+		mainLog.Warnf("Shutdown: nats flush error: %v", err)
+	}
+	mainLog.Infof("Shutdown complete")
 }
 
 func serveHTTPUntilSignalOrExit(signalCtx context.Context, srv *http.Server, mainLog sourceLogger) error {
@@ -152,11 +242,24 @@ func shutdownHTTPServer(signalCtx context.Context, srv *http.Server, mainLog sou
 	return shutdownErr
 }
 
-func startRuntimeNATS(mainLog sourceLogger) (string, string, bool, func()) {
-	ns, natsURL, jsDir, jsDirEphemeral, err := startEmbeddedNATS()
+// startRuntimeNATS starts the embedded server, unless PAAS_NATS_URL points
+// the runtime at an external cluster, in which case nothing is started and
+// API and workers on any host share that cluster's streams and buckets.
+func startRuntimeNATS(mainLog sourceLogger) (runtimeNATS, func()) {
+	endpoint, external, err := externalNATSEndpointFromEnv()
+	if err != nil {
+		mainLog.Fatalf("external nats: %v", err)
+	}
+	if external {
+		return runtimeNATS{endpoint: endpoint, storeDir: "", storeEphemeral: false}, func() {}
+	}
+	ns, endpoint, jsDir, jsDirEphemeral, err := startEmbeddedNATS()
 	if err != nil {
 		mainLog.Fatalf("start embedded nats: %v", err)
 	}
+	if endpoint.embeddedAuth.mode == embeddedNATSAuthNone {
+		mainLog.Warnf("embedded nats accepts any local client (%s=none)", natsEmbeddedAuthEnv)
+	}
 	cleanup := func() {
 		ns.Shutdown()
 		ns.WaitForShutdown()
@@ -164,24 +267,124 @@ func startRuntimeNATS(mainLog sourceLogger) (string, string, bool, func()) {
 			_ = os.RemoveAll(jsDir)
 		}
 	}
-	return natsURL, jsDir, jsDirEphemeral, cleanup
+	return runtimeNATS{endpoint: endpoint, storeDir: jsDir, storeEphemeral: jsDirEphemeral}, cleanup
 }
 
-func startPlatformWorkers(
+// openRuntimeStore creates the worker delivery stream and the KV store and
+// gives the store an op event hub journaled to JetStream. Run and the
+// self-test open the store through it.
+func openRuntimeStore(ctx context.Context, js jetstream.JetStream) (*Store, *opEventHub, error) {
+	if err := ensureWorkerDeliveryStream(ctx, js); err != nil {
+		return nil, nil, fmt.Errorf("worker delivery stream: %w", err)
+	}
+	store, err := newStore(ctx, js)
+	if err != nil {
+		return nil, nil, fmt.Errorf("store: %w", err)
+	}
+	opEvents := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
+	journal, err := ensureOpEventJournal(ctx, js)
+	if err != nil {
+		return nil, nil, fmt.Errorf("op event journal: %w", err)
+	}
+	journal.projectOrg = store.projectOrg
+	opEvents.setJournal(journal)
+	store.setOpEvents(opEvents)
+	return store, opEvents, nil
+}
+
+type runtimeWorkerConfig struct {
+	endpoint      natsEndpoint
+	artifacts     ArtifactStore
+	opEvents      *opEventHub
+	builderMode   imageBuilderModeResolution
+	readinessMode readinessMode
+	log           sourceLogger
+}
+
+// runtimeWorkers is what the API needs from the started workers: their
+// readiness and the waiters woken by their final results.
+type runtimeWorkers struct {
+	readiness *workerReadiness
+	waiters   *waiterHub
+	stops     []func()
+}
+
+// startRuntimeWorkers starts the platform workers and the subscriptions the
+// API reads them through: readiness heartbeats, final results, and the
+// result relay into op events. Run and the self-test start workers through
+// it; on error the subscriptions made so far are already stopped.
+func startRuntimeWorkers(
 	ctx context.Context,
-	natsURL string,
+	nc *nats.Conn,
+	js jetstream.JetStream,
+	cfg runtimeWorkerConfig,
+) (runtimeWorkers, error) {
+	workers := platformWorkers(cfg.endpoint, cfg.artifacts, cfg.opEvents, cfg.builderMode)
+	started := runtimeWorkers{
+		readiness: newWorkerReadiness(workerNames(workers), cfg.readinessMode),
+		waiters:   newWaiterHub(),
+		stops:     nil,
+	}
+	readySub, err := started.readiness.subscribe(nc)
+	if err != nil {
+		return started, fmt.Errorf("subscribe worker readiness: %w", err)
+	}
+	started.stops = append(started.stops, func() { _ = readySub.Unsubscribe() })
+	if err = startPlatformWorkers(ctx, workers); err != nil {
+		started.stop()
+		return started, fmt.Errorf("start worker: %w", err)
+	}
+	stopFinalResults, err := subscribeFinalResults(ctx, js, started.waiters, cfg.log)
+	if err != nil {
+		started.stop()
+		return started, fmt.Errorf("subscribe final: %w", err)
+	}
+	started.stops = append(started.stops, stopFinalResults)
+	stopResultRelay, err := subscribeWorkerResultRelay(nc, cfg.opEvents)
+	if err != nil {
+		started.stop()
+		return started, fmt.Errorf("subscribe worker result relay: %w", err)
+	}
+	started.stops = append(started.stops, stopResultRelay)
+	return started, nil
+}
+
+// stop ends the subscriptions in reverse order. The workers themselves stop
+// with the context they were started with.
+func (w *runtimeWorkers) stop() {
+	for i := len(w.stops) - 1; i >= 0; i-- {
+		w.stops[i]()
+	}
+	w.stops = nil
+}
+
+func platformWorkers(
+	endpoint natsEndpoint,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 	builderMode imageBuilderModeResolution,
-) error {
-	workers := []Worker{
-		NewRegistrationWorker(natsURL, artifacts, opEvents),
-		NewRepoBootstrapWorker(natsURL, artifacts, opEvents),
-		NewImageBuilderWorker(natsURL, artifacts, opEvents, builderMode),
-		NewManifestRendererWorker(natsURL, artifacts, opEvents),
-		NewDeploymentWorker(natsURL, artifacts, opEvents),
-		NewPromotionWorker(natsURL, artifacts, opEvents),
+) []Worker {
+	return []Worker{
+		NewRegistrationWorker(endpoint, artifacts, opEvents),
+		NewRepoBootstrapWorker(endpoint, artifacts, opEvents),
+		NewImageBuilderWorker(endpoint, artifacts, opEvents, builderMode),
+		NewManifestRendererWorker(endpoint, artifacts, opEvents),
+		NewDeploymentWorker(endpoint, artifacts, opEvents),
+		NewPromotionWorker(endpoint, artifacts, opEvents),
+		NewArtifactCleanupWorker(endpoint, artifacts, opEvents),
+		NewRuntimeUpgradeWorker(endpoint, artifacts, opEvents, builderMode),
 	}
+}
+
+func workerNames(workers []Worker) []string {
+	names := make([]string, 0, len(workers))
+	for _, worker := range workers {
+		names = append(names, worker.Name())
+	}
+	return names
+}
+
+func startPlatformWorkers(ctx context.Context, workers []Worker) error {
 	for _, worker := range workers {
 		if err := worker.Start(ctx); err != nil {
 			return err
@@ -197,9 +400,8 @@ func newRuntimeAPI(
 	waiters *waiterHub,
 	opEvents *opEventHub,
 	builderMode imageBuilderModeResolution,
-	artifactsRoot string,
-	natsStoreDir string,
-	natsStoreEphemeral bool,
+	cfg runtimeConfig,
+	natsRuntime runtimeNATS,
 ) *API {
 	return &API{
 		nc:                          nc,
@@ -208,14 +410,25 @@ func newRuntimeAPI(
 		waiters:                     waiters,
 		opEvents:                    opEvents,
 		opHeartbeatInterval:         opEventsHeartbeatInterval,
+		readiness:                   nil,
+		workerQueues:                nil,
+		specExtensions:              nil,
+		runbook:                     runbookConfig{Hooks: nil},
+		freezes:                     freezeConfig{Windows: nil},
+		quotas:                      projectQuotas{ConcurrentOps: 0, OpsPerHour: 0, ArtifactBytes: 0},
+		rateLimiter:                 nil,
+		downloadSlots:               make(chan struct{}, artifactDownloadSlots),
 		runtimeVersion:              runtimeBuildVersion(),
-		runtimeHTTPAddr:             httpAddr,
-		runtimeArtifactsRoot:        strings.TrimSpace(artifactsRoot),
+		config:                      cfg,
+		runtimeHTTPAddr:             cfg.HTTPAddr,
+		runtimeArtifactsRoot:        resolveArtifactsRoot(cfg).root,
 		runtimeBuilderMode:          builderMode,
 		runtimeCommitWatcherEnabled: false,
-		runtimeNATSEmbedded:         true,
-		runtimeNATSStoreDir:         strings.TrimSpace(natsStoreDir),
-		runtimeNATSStoreEphemeral:   natsStoreEphemeral,
+		runtimeNATSEmbedded:         !natsRuntime.endpoint.external,
+		runtimeNATSURL:              natsRuntime.externalURL(),
+		runtimeNATSStoreDir:         strings.TrimSpace(natsRuntime.storeDir),
+		runtimeNATSStoreEphemeral:   natsRuntime.storeEphemeral,
+		runtimeNATSAuth:             natsRuntime.endpoint.embeddedAuth.mode,
 		sourceTriggerMu:             sync.Mutex{},
 		projectStartLocksMu:         sync.Mutex{},
 		projectStartLocks:           map[string]*sync.Mutex{},
@@ -224,15 +437,15 @@ func newRuntimeAPI(
 
 func newRuntimeAPIWithWatcher(
 	ctx context.Context,
+	elector *leaderElector,
 	nc *nats.Conn,
 	store *Store,
 	artifacts ArtifactStore,
 	waiters *waiterHub,
 	opEvents *opEventHub,
 	builderMode imageBuilderModeResolution,
-	artifactsRoot string,
-	natsStoreDir string,
-	natsStoreEphemeral bool,
+	cfg runtimeConfig,
+	natsRuntime runtimeNATS,
 ) (*API, bool) {
 	api := newRuntimeAPI(
 		nc,
@@ -241,11 +454,10 @@ func newRuntimeAPIWithWatcher(
 		waiters,
 		opEvents,
 		builderMode,
-		artifactsRoot,
-		natsStoreDir,
-		natsStoreEphemeral,
+		cfg,
+		natsRuntime,
 	)
-	watcherStarted := startSourceCommitWatcher(ctx, api)
+	watcherStarted := startSourceCommitWatcher(ctx, api, elector)
 	api.runtimeCommitWatcherEnabled = watcherStarted
 	return api, watcherStarted
 }
@@ -260,18 +472,20 @@ func runtimeBuildVersion() string {
 
 func logRuntimeStartup(
 	mainLog sourceLogger,
-	natsURL string,
-	natsStoreDir string,
-	natsStoreEphemeral bool,
+	natsRuntime runtimeNATS,
 	watcherStarted bool,
 	builderMode imageBuilderModeResolution,
 	artifactsRoot artifactsRootResolution,
+	httpAddr string,
 ) {
-	mainLog.Infof("NATS: %s", natsURL)
-	if natsStoreEphemeral {
-		mainLog.Infof("NATS store dir: %s (ephemeral)", natsStoreDir)
-	} else {
-		mainLog.Infof("NATS store dir: %s (persistent)", natsStoreDir)
+	mainLog.Infof("NATS: %s", natsRuntime.endpoint.displayURL())
+	switch {
+	case natsRuntime.endpoint.external:
+		mainLog.Infof("NATS mode: external (%s ignored)", natsStoreDirEnv)
+	case natsRuntime.storeEphemeral:
+		mainLog.Infof("NATS store dir: %s (ephemeral)", natsRuntime.storeDir)
+	default:
+		mainLog.Infof("NATS store dir: %s (persistent)", natsRuntime.storeDir)
 	}
 	mainLog.Infof("Portal: http://%s", httpAddr)
 	mainLog.Infof("Artifacts root: %s", artifactsRoot.root)
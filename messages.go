@@ -18,7 +18,14 @@ type ProjectOpMsg struct {
 	RollbackEnv       string            `json:"rollback_env,omitempty"`
 	RollbackScope     RollbackScope     `json:"rollback_scope,omitempty"`
 	RollbackOverride  bool              `json:"rollback_override,omitempty"`
+	ArtifactPrefix    string            `json:"artifact_prefix,omitempty"` // cleanup only
+	RuntimeTarget     string            `json:"runtime_target,omitempty"`  // runtime-upgrade only
+	CloneOf           string            `json:"clone_of,omitempty"`        // create only
+	CI                *CIBuild          `json:"ci,omitempty"`              // ci only
 	Delivery          DeliveryLifecycle `json:"delivery,omitzero"`
+	Execution         OpExecution       `json:"execution,omitzero"`
+	SpecChange        *SpecChange       `json:"spec_change,omitempty"` // update only
+	ApprovalID        string            `json:"approval_id,omitempty"` // release only
 	Err               string            `json:"err,omitempty"`
 	At                time.Time         `json:"at"`
 }
@@ -36,6 +43,10 @@ type WorkerResultMsg struct {
 	RollbackScope     RollbackScope     `json:"rollback_scope,omitempty"`
 	RollbackOverride  bool              `json:"rollback_override,omitempty"`
 	Delivery          DeliveryLifecycle `json:"delivery,omitzero"`
+	Execution         OpExecution       `json:"execution,omitzero"`
+	SpecChange        *SpecChange       `json:"spec_change,omitempty"`
+	CloneOf           string            `json:"clone_of,omitempty"`
+	CI                *CIBuild          `json:"ci,omitempty"`
 	Worker            string            `json:"worker"`
 	Message           string            `json:"message,omitempty"`
 	Err               string            `json:"err,omitempty"`
@@ -43,6 +54,22 @@ type WorkerResultMsg struct {
 	At                time.Time         `json:"at"`
 }
 
+// OpCancelMsg asks whichever worker is running OpID to stop. The op is
+// already marked cancelled in KV when it is sent.
+type OpCancelMsg struct {
+	OpID      string    `json:"op_id"`
+	ProjectID string    `json:"project_id"`
+	Reason    string    `json:"reason,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// WorkerReadyMsg is a worker liveness heartbeat sent once its consumer is bound.
+type WorkerReadyMsg struct {
+	Worker   string    `json:"worker"`
+	Consumer string    `json:"consumer"`
+	At       time.Time `json:"at"`
+}
+
 type WorkerPoisonMsg struct {
 	Worker     string            `json:"worker"`
 	SubjectIn  string            `json:"subject_in"`
@@ -60,13 +87,36 @@ type WorkerPoisonMsg struct {
 
 func zeroProjectSpec() ProjectSpec {
 	return ProjectSpec{
-		APIVersion:      "",
-		Kind:            "",
-		Name:            "",
-		Runtime:         "",
-		Capabilities:    nil,
-		Environments:    nil,
-		NetworkPolicies: NetworkPolicies{Ingress: "", Egress: ""},
+		APIVersion:        "",
+		Kind:              "",
+		Name:              "",
+		Runtime:           "",
+		Template:          "",
+		Build:             BuildConfig{Strategy: "", Builder: ""},
+		Helm:              HelmConfig{Enabled: false},
+		Capabilities:      nil,
+		Needs:             nil,
+		Vars:              nil,
+		Environments:      nil,
+		NetworkPolicies:   NetworkPolicies{Ingress: "", Egress: ""},
+		Extensions:        nil,
+		CapabilityOptions: nil,
+		Repos: RepoRemotes{
+			Source:    RemoteRepo{URL: "", CredentialsRef: ""},
+			Manifests: RemoteRepo{URL: "", CredentialsRef: ""},
+		},
+		CI: CIPolicy{
+			Branches: nil, Tags: nil, Paths: nil,
+			Previews: PreviewPolicy{Enabled: false, Branches: nil, TTL: ""},
+		},
+		Exposure: ExposureConfig{
+			ServiceType: "", Port: 0, ContainerPort: 0,
+			Ingress: IngressConfig{Host: "", Path: "", TLS: false},
+		},
+		Resources: ResourceConfig{
+			Requests: ResourceAmounts{CPU: "", Memory: ""},
+			Limits:   ResourceAmounts{CPU: "", Memory: ""},
+		},
 	}
 }
 
@@ -84,15 +134,23 @@ func newWorkerResultMsg(message string) WorkerResultMsg {
 		RollbackScope:     "",
 		RollbackOverride:  false,
 		Delivery: DeliveryLifecycle{
-			Stage:       "",
-			Environment: "",
-			FromEnv:     "",
-			ToEnv:       "",
+			Stage:           "",
+			Environment:     "",
+			FromEnv:         "",
+			ToEnv:           "",
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
 		},
-		Worker:    "",
-		Message:   message,
-		Err:       "",
-		Artifacts: nil,
-		At:        time.Time{},
+		Execution:  OpExecution{DryRun: false, Trace: false},
+		SpecChange: nil,
+		CloneOf:    "",
+		CI:         nil,
+		Worker:     "",
+		Message:    message,
+		Err:        "",
+		Artifacts:  nil,
+		At:         time.Time{},
 	}
 }
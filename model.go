@@ -1,8 +1,8 @@
 package platform
 
 import (
-	"errors"
 	"fmt"
+	"maps"
 	"regexp"
 	"strings"
 	"time"
@@ -14,6 +14,33 @@ import (
 
 type EnvConfig struct {
 	Vars map[string]string `json:"vars"`
+	// Secrets maps env var names to secret references (vault://path#key or
+	// sops://file#key). Only the references are stored; see secrets_providers.go.
+	Secrets map[string]string `json:"secrets,omitempty"`
+	// Replicas is the environment's pod count; 0 runs one.
+	Replicas int `json:"replicas,omitempty"`
+	// Autoscaling hands the pod count to a HorizontalPodAutoscaler instead;
+	// see workers_render_autoscaling.go.
+	Autoscaling AutoscalingConfig `json:"autoscaling,omitzero"`
+	// Freeze lists windows in which nothing new is delivered into the
+	// environment; see api_freeze.go.
+	Freeze []FreezeWindow `json:"freeze,omitempty"`
+}
+
+// FreezeWindow freezes an environment from Start until End.
+type FreezeWindow struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// AutoscalingConfig scales an environment between MinReplicas and
+// MaxReplicas on average CPU utilization, as a percentage of the requested
+// CPU. Zero MinReplicas and TargetCPUUtilization take the defaults.
+type AutoscalingConfig struct {
+	MinReplicas          int `json:"minReplicas,omitempty"`
+	MaxReplicas          int `json:"maxReplicas"`
+	TargetCPUUtilization int `json:"targetCPUUtilization,omitempty"`
 }
 
 type NetworkPolicies struct {
@@ -21,14 +48,115 @@ type NetworkPolicies struct {
 	Egress  string `json:"egress"`
 }
 
+// BuildConfig selects how imageBuilder turns the source repo into an image.
+// An empty Strategy builds from the generated Dockerfile.
+type BuildConfig struct {
+	Strategy string `json:"strategy,omitempty"` // dockerfile | buildpacks
+	// Builder overrides the CNB builder image for buildpacks builds.
+	Builder string `json:"builder,omitempty"`
+}
+
+// HelmConfig asks the manifest renderer for a Helm chart of the project
+// next to its kustomize manifests (see workers_render_helm.go).
+type HelmConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// RepoRemotes points the source and manifests repos at external git remotes
+// (see git_remotes.go). A repo without a URL stays local-only.
+type RepoRemotes struct {
+	Source    RemoteRepo `json:"source,omitzero"`
+	Manifests RemoteRepo `json:"manifests,omitzero"`
+}
+
+// RemoteRepo is one external remote. CredentialsRef says where its token is
+// kept: vault://path#key, or env://NAME for a PAAS_GIT_CREDENTIAL_ variable
+// of the workers.
+type RemoteRepo struct {
+	URL            string `json:"url,omitempty"`
+	CredentialsRef string `json:"credentialsRef,omitempty"`
+}
+
+// CIPolicy says which pushes to the source repo run CI (see ci_policy.go).
+// Patterns use path.Match syntax. Without Branches only main builds.
+type CIPolicy struct {
+	// Branches build and deploy to dev, like a push to main.
+	Branches []string `json:"branches,omitempty"`
+	// Tags build, deploy to dev, and then release to prod.
+	Tags []string `json:"tags,omitempty"`
+	// Paths, when set, skip branch pushes that change no matching file;
+	// a trailing /** matches a whole directory.
+	Paths []string `json:"paths,omitempty"`
+	// Previews turns pushes to other branches into preview environments.
+	Previews PreviewPolicy `json:"previews,omitzero"`
+}
+
+// PreviewPolicy says which branch pushes get a preview environment (see
+// previews.go) and how long one lives after its last push.
+type PreviewPolicy struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Branches limits previews to matching branches; empty means every
+	// branch that is not main and not in CIPolicy.Branches.
+	Branches []string `json:"branches,omitempty"`
+	// TTL is a Go duration; empty means 72h.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// ExposureConfig is how the app is reached: the Service in front of it and,
+// when Ingress.Host is set, an Ingress in every environment. Zero fields take
+// the defaults in workers_render_exposure.go.
+type ExposureConfig struct {
+	ServiceType   string        `json:"serviceType,omitempty"` // ClusterIP | NodePort | LoadBalancer
+	Port          int           `json:"port,omitempty"`
+	ContainerPort int           `json:"containerPort,omitempty"`
+	Ingress       IngressConfig `json:"ingress,omitzero"`
+}
+
+// ResourceConfig sets the app container's CPU and memory. Amounts left out
+// take the namespace LimitRange defaults; see workers_render_resources.go.
+type ResourceConfig struct {
+	Requests ResourceAmounts `json:"requests,omitzero"`
+	Limits   ResourceAmounts `json:"limits,omitzero"`
+}
+
+// ResourceAmounts holds Kubernetes quantities, such as 250m CPU or 512Mi of
+// memory.
+type ResourceAmounts struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// IngressConfig routes Host and Path to the Service. A {env} in Host is
+// replaced by the environment name, so each environment gets its own host.
+type IngressConfig struct {
+	Host string `json:"host,omitempty"`
+	Path string `json:"path,omitempty"`
+	TLS  bool   `json:"tls,omitempty"`
+}
+
 type ProjectSpec struct {
 	APIVersion      string               `json:"apiVersion"`
 	Kind            string               `json:"kind"`
 	Name            string               `json:"name"`
 	Runtime         string               `json:"runtime"`
+	Template        string               `json:"template,omitempty"` // starter the source repo was seeded from
+	Repos           RepoRemotes          `json:"repos,omitzero"`
+	CI              CIPolicy             `json:"ci,omitzero"`
+	Build           BuildConfig          `json:"build,omitzero"`
+	Helm            HelmConfig           `json:"helm,omitzero"`
+	Exposure        ExposureConfig       `json:"exposure,omitzero"`
+	Resources       ResourceConfig       `json:"resources,omitzero"`
 	Capabilities    []string             `json:"capabilities,omitempty"`
+	Needs           []string             `json:"needs,omitempty"`
+	Vars            map[string]string    `json:"vars,omitempty"` // inherited by every environment
 	Environments    map[string]EnvConfig `json:"environments"`
 	NetworkPolicies NetworkPolicies      `json:"networkPolicies"`
+	// Extensions holds operator-defined metadata under x- prefixed keys;
+	// see spec_extensions.go.
+	Extensions map[string]any `json:"extensions,omitempty"`
+	// CapabilityOptions configures declared capabilities by name; see
+	// capability_plugins.go for the keys each plugin takes.
+	CapabilityOptions map[string]map[string]string `json:"capabilityOptions,omitempty"`
 }
 
 type ProjectStatus struct {
@@ -39,12 +167,89 @@ type ProjectStatus struct {
 	Message    string    `json:"message,omitempty"`
 }
 
+// ProjectOwner is one person or team answerable for a project. Name is
+// required along with at least one of Email or Chat.
+type ProjectOwner struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Chat  string `json:"chat,omitempty"` // chat handle or channel, e.g. @sam or #payments
+}
+
+// ProjectOwnership says who to contact about a project. It lives outside the
+// spec and is edited through /api/projects/{id}/ownership without an op.
+// With API auth on, a project that names owners or teams only accepts
+// changes from tokens named after an owner or carrying one of the teams.
+type ProjectOwnership struct {
+	Owners     []ProjectOwner `json:"owners,omitempty"`
+	Teams      []string       `json:"teams,omitempty"`
+	OnCall     string         `json:"on_call,omitempty"`    // rotation or pager handle
+	Escalation []string       `json:"escalation,omitempty"` // contacts to try next, in order
+	UpdatedAt  time.Time      `json:"updated_at,omitzero"`
+}
+
 type Project struct {
-	ID        string        `json:"id"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
-	Spec      ProjectSpec   `json:"spec"`
-	Status    ProjectStatus `json:"status"`
+	ID        string           `json:"id"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Spec      ProjectSpec      `json:"spec"`
+	SpecHash  string           `json:"spec_hash,omitempty"` // projectSpecHash(Spec), refreshed on every write
+	Status    ProjectStatus    `json:"status"`
+	Ownership ProjectOwnership `json:"ownership,omitzero"`
+	// Org and Workspace place the project in an organization; both are set
+	// at creation and never change. See api_orgs.go.
+	Org       string `json:"org,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	// Revision is the KV revision the record was read at; PutProject only
+	// writes over that revision, and creates the record when it is zero.
+	Revision uint64 `json:"-"`
+}
+
+// ProjectFilter selects projects for GET /api/projects and saved views.
+// Empty fields match every project.
+type ProjectFilter struct {
+	Name        string `json:"name,omitempty"`        // substring of the spec name or project ID
+	NamePrefix  string `json:"name_prefix,omitempty"` // start of the spec name
+	Phase       string `json:"phase,omitempty"`
+	Team        string `json:"team,omitempty"`
+	Owner       string `json:"owner,omitempty"` // owner name or email
+	Environment string `json:"environment,omitempty"`
+	Runtime     string `json:"runtime,omitempty"`
+	Capability  string `json:"capability,omitempty"`
+	Org         string `json:"org,omitempty"`
+	Workspace   string `json:"workspace,omitempty"`
+}
+
+// ProjectView is a saved filter and sort order, stored server-side so every
+// client lists the same projects for it.
+type ProjectView struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Filter      ProjectFilter `json:"filter"`
+	Sort        string        `json:"sort,omitempty"`
+	CreatedBy   string        `json:"created_by,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// Organization groups projects so several teams can share one instance.
+// Its ID prefixes the artifact directories and op event subjects of its
+// projects, and org-scoped API tokens only see its projects.
+type Organization struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name,omitempty"`
+	Workspaces []string  `json:"workspaces,omitempty"` // a project may join one of these
+	Quota      OrgQuota  `json:"quota,omitzero"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Revision is the KV revision the record was read at.
+	Revision uint64 `json:"-"`
+}
+
+// OrgQuota caps an organization's projects. Zero means no limit.
+type OrgQuota struct {
+	MaxProjects     int `json:"max_projects,omitempty"`
+	MaxEnvironments int `json:"max_environments,omitempty"` // summed over the org's projects
 }
 
 type OperationKind string
@@ -58,8 +263,28 @@ const (
 	OpPromote  OperationKind = "promote"
 	OpRelease  OperationKind = "release"
 	OpRollback OperationKind = "rollback"
+	OpCleanup  OperationKind = "cleanup"
+	// OpVarRollout is a parent op: the API applies a var change one
+	// environment at a time, each through a child deploy/promote/release op.
+	OpVarRollout OperationKind = "var-rollout"
+	// OpPromoteFanout is a parent op: the API promotes one environment to
+	// several targets at once, each through a child promote/release op.
+	OpPromoteFanout OperationKind = "promote-fanout"
+	// OpRuntimeUpgrade moves a branch of the source repo to a newer runtime
+	// and trial-builds it; main and the project spec stay as they are.
+	OpRuntimeUpgrade OperationKind = "runtime-upgrade"
+	// OpWebhookRefresh repoints a source repo's webhook hooks after the API
+	// address changed. The API runs it at startup; no worker is involved.
+	OpWebhookRefresh OperationKind = "webhook-refresh"
 )
 
+func allOperationKinds() []OperationKind {
+	return []OperationKind{
+		OpCreate, OpUpdate, OpDelete, OpCI, OpDeploy, OpPromote, OpRelease, OpRollback, OpCleanup, OpVarRollout,
+		OpPromoteFanout, OpRuntimeUpgrade, OpWebhookRefresh,
+	}
+}
+
 type RollbackScope string
 
 const (
@@ -76,11 +301,50 @@ const (
 	DeliveryStageRelease DeliveryStage = "release"
 )
 
+// DeliveryStrategy is how a transition rolls an image out to its target.
+// The empty strategy replaces the target's Deployment in one step.
+type DeliveryStrategy string
+
+const (
+	DeliveryStrategyCanary    DeliveryStrategy = "canary"
+	DeliveryStrategyBlueGreen DeliveryStrategy = "blue-green"
+)
+
+// CanaryAction ends a running canary: promote rolls its image out to the
+// whole environment, abort returns the environment to its stable image.
+type CanaryAction string
+
+const (
+	CanaryActionPromote CanaryAction = "promote"
+	CanaryActionAbort   CanaryAction = "abort"
+)
+
+// BlueGreenAction moves a blue/green environment's traffic: cutover to the
+// color a release staged, rollback back to the color it replaced.
+type BlueGreenAction string
+
+const (
+	BlueGreenActionCutover  BlueGreenAction = "cutover"
+	BlueGreenActionRollback BlueGreenAction = "rollback"
+)
+
 type DeliveryLifecycle struct {
 	Stage       DeliveryStage `json:"stage,omitempty"`
 	Environment string        `json:"environment,omitempty"`
 	FromEnv     string        `json:"from_env,omitempty"`
 	ToEnv       string        `json:"to_env,omitempty"`
+
+	Strategy     DeliveryStrategy `json:"strategy,omitempty"`
+	CanaryWeight int              `json:"canary_weight,omitempty"` // canary start: requested percent of replicas
+	CanaryAction CanaryAction     `json:"canary_action,omitempty"` // set on the op that ends a canary
+	// BlueGreenAction is set on the op that moves a blue/green environment's traffic.
+	BlueGreenAction BlueGreenAction `json:"blue_green_action,omitempty"`
+}
+
+// followUp reports whether d ends a canary or moves blue/green traffic,
+// rolling out only an image an earlier op already delivered.
+func (d DeliveryLifecycle) followUp() bool {
+	return d.CanaryAction != "" || d.BlueGreenAction != ""
 }
 
 type OpStep struct {
@@ -90,6 +354,43 @@ type OpStep struct {
 	Message   string    `json:"message,omitempty"`
 	Error     string    `json:"error,omitempty"`
 	Artifacts []string  `json:"artifacts,omitempty"` // relative paths
+	Compacted int       `json:"compacted,omitempty"` // >0 marks a summary of that many folded steps
+
+	HeartbeatAt time.Time   `json:"heartbeat_at,omitzero"`
+	Progress    string      `json:"progress,omitempty"`
+	Percent     int         `json:"percent,omitempty"` // 0 = unknown
+	SubSteps    []OpSubStep `json:"substeps,omitempty"`
+
+	Plan []string `json:"plan,omitempty"` // dry-run ops: changes the step would have made
+
+	Attempt       uint64          `json:"attempt,omitempty"` // delivery attempt now running; 0 = first
+	PriorAttempts []OpStepAttempt `json:"prior_attempts,omitempty"`
+}
+
+// OpStepAttempt is an earlier, interrupted run of a step. Artifacts are the
+// per-attempt copies of the files it wrote (build/attempt-1/...).
+type OpStepAttempt struct {
+	Attempt   uint64    `json:"attempt"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Artifacts []string  `json:"artifacts,omitempty"`
+}
+
+// OpSubStep is a named phase a worker reported inside its step, such as
+// "resolve base image" within imageBuilder. EndedAt is zero while it runs.
+type OpSubStep struct {
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitzero"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// OpExecution selects how workers run an op. DryRun computes and reports the
+// intended changes without applying them; Trace attaches timed sub-steps and
+// command transcripts as a per-worker artifact.
+type OpExecution struct {
+	DryRun bool `json:"dry_run,omitempty"`
+	Trace  bool `json:"trace,omitempty"`
 }
 
 type Operation struct {
@@ -97,11 +398,228 @@ type Operation struct {
 	Kind      OperationKind     `json:"kind"`
 	ProjectID string            `json:"project_id"`
 	Delivery  DeliveryLifecycle `json:"delivery,omitzero"`
+	Execution OpExecution       `json:"execution,omitzero"`
 	Requested time.Time         `json:"requested"`
 	Finished  time.Time         `json:"finished"`
 	Status    string            `json:"status"` // queued|running|done|error
 	Error     string            `json:"error,omitempty"`
 	Steps     []OpStep          `json:"steps"`
+	// VulnerabilityOverride is set when an operator promoted an image over
+	// the vulnerability budget.
+	VulnerabilityOverride *VulnerabilityOverride `json:"vulnerability_override,omitempty"`
+	// FreezeOverride is set when an operator delivered into a frozen
+	// environment. A fan-out parent keeps the override its targets start
+	// with; each target records the freezes it got past.
+	FreezeOverride *FreezeOverride `json:"freeze_override,omitempty"`
+	// ApprovalID names the approval a production release was queued under,
+	// when PAAS_RELEASE_APPROVALS requires one.
+	ApprovalID string `json:"approval_id,omitempty"`
+	// Notes are kept under their own key so workers rewriting the op cannot
+	// drop them; they are filled in only when GET /api/ops/{id} serves the op.
+	Notes []OpNote `json:"notes,omitempty"`
+	// SpecHash is the spec_hash of the spec the op was queued with. Deletes
+	// and var rollouts (whose children carry their own) leave it empty.
+	SpecHash string `json:"spec_hash,omitempty"`
+	// ParentOpID is set on the child ops a var rollout or fan-out
+	// promotion starts.
+	ParentOpID string `json:"parent_op_id,omitempty"`
+	// Rollout is the plan and per-stage progress of a var-rollout op.
+	Rollout *VarRollout `json:"rollout,omitempty"`
+	// Fanout is the targets and per-target outcome of a promote-fanout op.
+	Fanout *PromotionFanout `json:"fanout,omitempty"`
+	// SpecChange is how an update differs from the spec it replaced and
+	// which pipeline stages it runs; see spec_change.go.
+	SpecChange *SpecChange `json:"spec_change,omitempty"`
+	// Remediation is what a runbook hook did after this op failed.
+	Remediation *OpRemediation `json:"remediation,omitempty"`
+	// RemediationOf is set on ops a runbook hook started: the failed op
+	// they remediate.
+	RemediationOf string `json:"remediation_of,omitempty"`
+	// ScheduleID is set on ops the scheduler started: the schedule that
+	// fired.
+	ScheduleID string `json:"schedule_id,omitempty"`
+	// CloneOf is set on the create op of a cloned project: the project its
+	// source repo was copied from.
+	CloneOf string `json:"clone_of,omitempty"`
+	// CI is the push a ci op builds, when the webhook named one.
+	CI *CIBuild `json:"ci,omitempty"`
+	// Upgrade is the target and trial outcome of a runtime-upgrade op.
+	Upgrade *RuntimeUpgrade `json:"upgrade,omitempty"`
+	// Webhook is the endpoint move a webhook-refresh op applied.
+	Webhook *WebhookRefresh `json:"webhook,omitempty"`
+	// SLABreached and SLABreaches are kept apart from the op record, like
+	// Notes, and filled in when the op is served; see ops_sla.go.
+	SLABreached bool          `json:"sla_breached,omitempty"`
+	SLABreaches []OpSLABreach `json:"sla_breaches,omitempty"`
+}
+
+// OpSLABreach records an op that stayed in State past its SLA: Elapsed is
+// how long it had been there when the checker noticed, At.
+type OpSLABreach struct {
+	State     string    `json:"state"` // queued|running
+	Threshold string    `json:"threshold"`
+	Elapsed   string    `json:"elapsed"`
+	At        time.Time `json:"at"`
+}
+
+// CIBuild is the ref and commit a ci op builds. For a tag, Release is the
+// release op started once the build was done, or ReleaseError why none was.
+type CIBuild struct {
+	Ref          string `json:"ref"` // refs/heads/<branch> or refs/tags/<tag>
+	Commit       string `json:"commit,omitempty"`
+	Release      string `json:"release,omitempty"`
+	ReleaseError string `json:"release_error,omitempty"`
+	// Preview names the preview environment a branch build renders; empty
+	// for builds that deploy to dev.
+	Preview string `json:"preview,omitempty"`
+	// Environment is set on the ci op a vars patch queues: the environment
+	// it re-renders with the image that environment runs. Empty is dev.
+	Environment string `json:"environment,omitempty"`
+}
+
+// Preview is a branch's preview environment: its ci ops render the branch
+// as environment Environment under Path, until the branch is deleted or
+// ExpiresAt passes (see previews.go).
+type Preview struct {
+	Name        string    `json:"name"`
+	Branch      string    `json:"branch"`
+	Environment string    `json:"environment"`
+	Path        string    `json:"path"`
+	Status      string    `json:"status"` // building | ready | failed | tearing_down
+	Commit      string    `json:"commit,omitempty"`
+	Image       string    `json:"image,omitempty"` // last rendered
+	OpID        string    `json:"op_id"`           // the latest build or teardown
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// WebhookRefresh records a webhook-refresh op moving a source repo's hooks
+// from the endpoint they were installed with to the current one.
+type WebhookRefresh struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Commit string `json:"commit,omitempty"`
+}
+
+// RuntimeUpgrade records a runtime upgrade trial: the branch the source
+// repo's scaffolding was moved to To on, and how its trial build went.
+type RuntimeUpgrade struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Branch   string   `json:"branch"`
+	Commit   string   `json:"commit,omitempty"`
+	Changes  []string `json:"changes,omitempty"`
+	ImageTag string   `json:"image_tag,omitempty"`
+	Build    string   `json:"build,omitempty"`  // passed|failed
+	Report   string   `json:"report,omitempty"` // artifact path of the report
+}
+
+// OpRemediation records a runbook hook run against a failed op. Actor names
+// the hook, so every action it took can be traced back to its config.
+type OpRemediation struct {
+	Hook        string              `json:"hook"`
+	FailureCode string              `json:"failure_code"`
+	Actor       string              `json:"actor"`
+	Status      string              `json:"status"` // running|done|error|skipped
+	Message     string              `json:"message,omitempty"`
+	Actions     []RemediationAction `json:"actions,omitempty"`
+	StartedAt   time.Time           `json:"started_at"`
+	FinishedAt  time.Time           `json:"finished_at,omitzero"`
+}
+
+// RemediationAction is one step of a hook run, with the op it started.
+type RemediationAction struct {
+	Action  string    `json:"action"`
+	OpID    string    `json:"op_id,omitempty"`
+	Status  string    `json:"status"`
+	Message string    `json:"message,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// SpecChangeClass names one kind of difference between two specs.
+type SpecChangeClass string
+
+const (
+	// SpecChangeVars covers shared or per-environment vars and secret
+	// references, and environments added or removed.
+	SpecChangeVars         SpecChangeClass = "vars"
+	SpecChangeCapabilities SpecChangeClass = "capabilities"
+	SpecChangeRuntime      SpecChangeClass = "runtime"
+	SpecChangeName         SpecChangeClass = "name"
+	SpecChangeBuild        SpecChangeClass = "build"
+	// SpecChangeRepos covers the remotes of the source and manifests repos.
+	SpecChangeRepos SpecChangeClass = "repos"
+	// SpecChangeCI covers the CI policy, which only the webhook reads.
+	SpecChangeCI SpecChangeClass = "ci"
+	// SpecChangeFreeze covers environment freeze windows, which only the
+	// enqueue gate reads.
+	SpecChangeFreeze SpecChangeClass = "freeze"
+	// SpecChangeManifest covers network policies, needs, extensions, and the
+	// apiVersion/kind header, which only reach the rendered manifests.
+	SpecChangeManifest SpecChangeClass = "manifest"
+)
+
+// SpecChange records the classification of an update and the stages it
+// runs. A stage in Skipped passes the op along without doing its work; when
+// imageBuilder is skipped, Image is the earlier build the render reuses.
+type SpecChange struct {
+	Classes []SpecChangeClass `json:"classes"`
+	Stages  []string          `json:"stages"`
+	Skipped []string          `json:"skipped,omitempty"`
+	Image   string            `json:"image,omitempty"`
+}
+
+// VarRollout is a var change applied to environments in order. Each stage
+// runs a child op, then a health check, then waits PauseSeconds before the
+// next one.
+type VarRollout struct {
+	Set           map[string]string `json:"set,omitempty"`
+	Unset         []string          `json:"unset,omitempty"`
+	PauseSeconds  int               `json:"pause_seconds"`
+	StopOnFailure bool              `json:"stop_on_failure"`
+	Stages        []VarRolloutStage `json:"stages"`
+}
+
+// VarRolloutStage is one environment of a var rollout. Status is pending,
+// running, done, error, or skipped.
+type VarRolloutStage struct {
+	Environment string        `json:"environment"`
+	FromEnv     string        `json:"from_env,omitempty"`
+	OpKind      OperationKind `json:"op_kind"`
+	OpID        string        `json:"op_id,omitempty"`
+	Status      string        `json:"status"`
+	Health      string        `json:"health,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// PromotionFanout is one source environment promoted to several targets in
+// parallel. Outcome is empty while targets run, then succeeded, partial
+// (some targets failed), or failed.
+type PromotionFanout struct {
+	FromEnv string                  `json:"from_env"`
+	Outcome string                  `json:"outcome,omitempty"`
+	Targets []PromotionFanoutTarget `json:"targets"`
+}
+
+// PromotionFanoutTarget is one target of a fan-out promotion. Status is
+// pending, running, done, error, or cancelled.
+type PromotionFanoutTarget struct {
+	Environment string        `json:"environment"`
+	OpKind      OperationKind `json:"op_kind"`
+	OpID        string        `json:"op_id,omitempty"`
+	Status      string        `json:"status"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// OpNote is a comment attached to an operation after the fact, such as why
+// it failed and what was done about it.
+type OpNote struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type ReleaseRecord struct {
@@ -119,7 +637,244 @@ type ReleaseRecord struct {
 	RollbackSafe          *bool         `json:"rollback_safe,omitempty"`
 	RollbackSourceRelease string        `json:"rollback_source_release,omitempty"`
 	RollbackScope         RollbackScope `json:"rollback_scope,omitempty"`
+	SourceCommit          string        `json:"source_commit,omitempty"`
+	SpecHash              string        `json:"spec_hash,omitempty"`
 	CreatedAt             time.Time     `json:"created_at"`
+	// Notes is the changelog drafted from the source commits since the
+	// environment's previous release; operators can edit its text.
+	Notes *ReleaseNotes `json:"notes,omitempty"`
+	// Evidence lists the uploaded evidence files (see api_artifact_upload.go)
+	// the project held when the release was recorded.
+	Evidence []ArtifactFileInfo `json:"evidence,omitempty"`
+}
+
+// ReleaseNotes lists the source commits a release shipped, grouped by
+// conventional-commit type when the subjects use it, and how its image and
+// config vars differ from the previous release. Text starts as a markdown
+// rendering of Sections and is what an edit replaces.
+type ReleaseNotes struct {
+	FromCommit string                `json:"from_commit,omitempty"`
+	ToCommit   string                `json:"to_commit"`
+	Sections   []ReleaseNotesSection `json:"sections"`
+	Truncated  bool                  `json:"truncated,omitempty"`
+	Text       string                `json:"text"`
+	EditedBy   string                `json:"edited_by,omitempty"`
+	EditedAt   time.Time             `json:"edited_at,omitzero"`
+
+	PreviousReleaseID string `json:"previous_release_id,omitempty"`
+	// Image and Config compare the release with the previous one. Config is
+	// nil when the previous release's config was not kept.
+	Image  *ReleaseCompareDelta `json:"image,omitempty"`
+	Config *ReleaseCompareDelta `json:"config,omitempty"`
+	// Path is the notes artifact, a markdown document of the whole notes.
+	Path string `json:"path,omitempty"`
+}
+
+// ReleaseNotesSection is one group of commits. Type is the
+// conventional-commit type, or "other" for subjects that do not use one.
+type ReleaseNotesSection struct {
+	Type    string              `json:"type"`
+	Title   string              `json:"title"`
+	Commits []ReleaseNoteCommit `json:"commits"`
+}
+
+// ReleaseNoteCommit is one commit in a release's notes.
+type ReleaseNoteCommit struct {
+	Hash     string `json:"hash"`
+	Scope    string `json:"scope,omitempty"`
+	Subject  string `json:"subject"`
+	Breaking bool   `json:"breaking,omitempty"`
+}
+
+// VulnerabilitySummary counts the findings of an image's scan report by
+// severity.
+type VulnerabilitySummary struct {
+	Image    string `json:"image"`
+	Critical int    `json:"critical"`
+	High     int    `json:"high"`
+	Medium   int    `json:"medium"`
+	Low      int    `json:"low"`
+	Unknown  int    `json:"unknown"`
+}
+
+// VulnerabilityOverride records who let an image past the vulnerability
+// budget, why, and what the scan showed at the time.
+type VulnerabilityOverride struct {
+	Justification string               `json:"justification"`
+	By            string               `json:"by,omitempty"`
+	Exceeded      []string             `json:"exceeded"`
+	Summary       VulnerabilitySummary `json:"summary"`
+	At            time.Time            `json:"at"`
+}
+
+// EnvironmentFreeze is one freeze in force on an environment: a window from
+// the project spec or PAAS_FREEZE_FILE, or a manual freeze, which has no
+// Until and lasts until it is lifted.
+type EnvironmentFreeze struct {
+	Source string    `json:"source"` // project | global | manual
+	Name   string    `json:"name,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	By     string    `json:"by,omitempty"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until,omitzero"`
+}
+
+// FreezeOverride records who delivered into a frozen environment, and the
+// freezes that were in force at the time.
+type FreezeOverride struct {
+	Environment   string              `json:"environment"`
+	Justification string              `json:"justification"`
+	By            string              `json:"by,omitempty"`
+	Freezes       []EnvironmentFreeze `json:"freezes"`
+	At            time.Time           `json:"at"`
+}
+
+// ReleaseApproval is a request to release an image to production, held
+// until enough people approve it or anyone rejects it.
+type ReleaseApproval struct {
+	ID          string             `json:"id"`
+	ProjectID   string             `json:"project_id"`
+	FromEnv     string             `json:"from_env"`
+	ToEnv       string             `json:"to_env"`
+	Image       string             `json:"image"`
+	Required    int                `json:"required"`
+	Status      string             `json:"status"` // pending|approved|rejected|superseded
+	RequestedBy string             `json:"requested_by,omitempty"`
+	Decisions   []ApprovalDecision `json:"decisions"`
+	// The overrides the release was requested with, applied when the
+	// approved release is queued.
+	VulnerabilityOverride *VulnerabilityOverride `json:"vulnerability_override,omitempty"`
+	FreezeOverride        *FreezeOverride        `json:"freeze_override,omitempty"`
+	// OpID is the release op last queued under the approval.
+	OpID      string    `json:"op_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ApprovalDecision is one person's approval or rejection of a release.
+type ApprovalDecision struct {
+	By       string    `json:"by"`
+	Decision string    `json:"decision"` // approve|reject
+	Comment  string    `json:"comment,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// OpSchedule queues an op for a project whenever Cron fires; see
+// ops_scheduler.go. Environment, FromEnv/ToEnv, and TargetRuntime are set for
+// the kinds that take them.
+type OpSchedule struct {
+	ID            string        `json:"id"`
+	ProjectID     string        `json:"project_id"`
+	Name          string        `json:"name"`
+	Cron          string        `json:"cron"`
+	Timezone      string        `json:"timezone,omitempty"` // IANA name; empty is UTC
+	Kind          OperationKind `json:"kind"`               // ci|deploy|promote|runtime-upgrade
+	Environment   string        `json:"environment,omitempty"`
+	FromEnv       string        `json:"from_env,omitempty"`
+	ToEnv         string        `json:"to_env,omitempty"`
+	TargetRuntime string        `json:"target_runtime,omitempty"`
+	Enabled       bool          `json:"enabled"`
+	CreatedBy     string        `json:"created_by,omitempty"`
+	// NextRunAt is zero while the schedule is disabled.
+	NextRunAt time.Time    `json:"next_run_at"`
+	LastRun   *ScheduleRun `json:"last_run,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// ScheduleRun is what happened the last time a schedule fired.
+type ScheduleRun struct {
+	At      time.Time `json:"at"`
+	Status  string    `json:"status"` // queued|skipped|error
+	OpID    string    `json:"op_id,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+// ComplianceHold blocks deletion and retention of a project's artifacts and
+// KV records until it is lifted.
+type ComplianceHold struct {
+	ProjectID string    `json:"project_id"`
+	ReleaseID string    `json:"release_id,omitempty"`
+	Reason    string    `json:"reason"`
+	PlacedBy  string    `json:"placed_by,omitempty"`
+	PlacedAt  time.Time `json:"placed_at"`
+}
+
+// CapabilityBindingType says how an environment provides a capability.
+type CapabilityBindingType string
+
+const (
+	// The platform runs the capability as a container next to the app.
+	CapabilityBindingContainer CapabilityBindingType = "container"
+	// An operator runs it elsewhere and hands over connection details.
+	CapabilityBindingExternal CapabilityBindingType = "external"
+	// The platform provisioned it outside the cluster and stored its
+	// connection details as stored secrets; see workers_action_postgres.go.
+	CapabilityBindingManaged CapabilityBindingType = "managed"
+)
+
+// CapabilityBinding resolves one declared capability in one environment,
+// e.g. postgres as a container in dev and as an operator-provided
+// connection string in prod. Env lands in the app container as plain vars;
+// SecretEnv vars read from Secrets the operator manages, so credentials
+// never pass through KV.
+type CapabilityBinding struct {
+	ProjectID    string                  `json:"project_id"`
+	Environment  string                  `json:"environment"`
+	Capability   string                  `json:"capability"`
+	Type         CapabilityBindingType   `json:"type"`
+	Image        string                  `json:"image,omitempty"`         // container only
+	ContainerEnv map[string]string       `json:"container_env,omitempty"` // container only
+	Env          map[string]string       `json:"env,omitempty"`
+	SecretEnv    map[string]SecretKeyRef `json:"secret_env,omitempty"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// SecretKeyRef names one key of a Secret in the environment's namespace.
+type SecretKeyRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// DeletePlan lists what deleting a project will remove. A delete is only
+// applied with the ID of an unexpired plan whose fingerprint still matches
+// the project's current state.
+type DeletePlan struct {
+	ID               string    `json:"id"`
+	ProjectID        string    `json:"project_id"`
+	CreatedAt        time.Time `json:"created_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	Fingerprint      string    `json:"fingerprint"`
+	Repos            []string  `json:"repos"`
+	Images           []string  `json:"images"`
+	Releases         []string  `json:"releases"`
+	ClusterResources []string  `json:"cluster_resources"`
+	ArtifactFiles    int       `json:"artifact_files"`
+	BlockedBy        string    `json:"blocked_by,omitempty"`
+	// Impact is what the delete reaches outside the project's own files.
+	// When it is not empty the delete must also acknowledge it.
+	Impact                  DeleteImpact `json:"impact"`
+	RequiresAcknowledgement bool         `json:"requires_acknowledgement"`
+}
+
+// DeleteImpact lists what breaks or goes away for others when a project is
+// deleted: deployed releases, the service endpoints they serve, other
+// projects configured to call those endpoints, and credentials held for it.
+type DeleteImpact struct {
+	LiveReleases []string          `json:"live_releases"` // <env>/<release id>
+	Endpoints    []string          `json:"endpoints"`     // in-cluster service DNS names
+	Dependents   []DeleteDependent `json:"dependents"`
+	Credentials  []string          `json:"credentials"` // secret <env>/<name> | binding <env>/<capability>
+}
+
+// DeleteDependent is another project whose config names one of the
+// deleted project's endpoints.
+type DeleteDependent struct {
+	ProjectID   string `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	Environment string `json:"environment,omitempty"` // empty for the shared vars block
+	Reference   string `json:"reference"`             // var, or binding <capability> env var
+	Endpoint    string `json:"endpoint"`
 }
 
 var (
@@ -129,6 +884,9 @@ var (
 	envNameRe      = regexp.MustCompile(`^[a-z][a-z0-9_\-]*[a-z0-9]$`)
 	envVarNameRe   = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
 	networkValueRe = regexp.MustCompile(`^(internal|none)$`)
+	extensionKeyRe = regexp.MustCompile(`^x-[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	secretKeyRe    = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+	chatHandleRe   = regexp.MustCompile(`^[@#]?[A-Za-z0-9][A-Za-z0-9._\-/:]*$`)
 )
 
 func normalizeProjectSpec(in ProjectSpec) ProjectSpec {
@@ -144,6 +902,15 @@ func normalizeProjectSpec(in ProjectSpec) ProjectSpec {
 
 	spec.Name = strings.TrimSpace(spec.Name)
 	spec.Runtime = strings.TrimSpace(spec.Runtime)
+	spec.Template = strings.TrimSpace(spec.Template)
+	spec.Repos = normalizeRepoRemotes(spec.Repos)
+	spec.CI = normalizeCIPolicy(spec.CI)
+	spec.Build.Strategy = strings.TrimSpace(spec.Build.Strategy)
+	spec.Build.Builder = strings.TrimSpace(spec.Build.Builder)
+	spec.Exposure.ServiceType = strings.TrimSpace(spec.Exposure.ServiceType)
+	spec.Exposure.Ingress.Host = strings.ToLower(strings.TrimSpace(spec.Exposure.Ingress.Host))
+	spec.Exposure.Ingress.Path = strings.TrimSpace(spec.Exposure.Ingress.Path)
+	spec.Resources = normalizeResourceConfig(spec.Resources)
 
 	spec.NetworkPolicies.Ingress = strings.TrimSpace(spec.NetworkPolicies.Ingress)
 	spec.NetworkPolicies.Egress = strings.TrimSpace(spec.NetworkPolicies.Egress)
@@ -168,79 +935,161 @@ func normalizeProjectSpec(in ProjectSpec) ProjectSpec {
 		caps = append(caps, c)
 	}
 	spec.Capabilities = caps
+	if len(spec.CapabilityOptions) == 0 {
+		spec.CapabilityOptions = nil
+	}
+	spec.Needs = normalizeProjectNeeds(spec.Needs)
 
-	if spec.Environments == nil {
-		spec.Environments = map[string]EnvConfig{}
+	if len(spec.Vars) == 0 {
+		spec.Vars = nil
+	}
+	if len(spec.Extensions) == 0 {
+		spec.Extensions = nil
 	}
+	envs := make(map[string]EnvConfig, len(spec.Environments))
 	for envName, envCfg := range spec.Environments {
-		if envCfg.Vars == nil {
-			envCfg.Vars = map[string]string{}
-		}
-		spec.Environments[envName] = envCfg
+		envCfg.Vars = environmentOverrides(spec.Vars, envCfg.Vars)
+		envCfg.Secrets = normalizeSecretRefs(envCfg.Secrets)
+		envCfg.Freeze = normalizeFreezeWindows(envCfg.Freeze)
+		envs[envName] = envCfg
 	}
+	spec.Environments = envs
 	return spec
 }
 
+// environmentOverrides keeps only the env vars that differ from the shared
+// block, so a stored environment lists its overrides and picks up later
+// shared changes for everything else.
+func environmentOverrides(shared, vars map[string]string) map[string]string {
+	out := make(map[string]string, len(vars))
+	for key, value := range vars {
+		if inherited, ok := shared[key]; ok && inherited == value {
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// effectiveEnvironmentVars resolves the vars an environment runs with: the
+// shared block overlaid with the environment's own overrides.
+func effectiveEnvironmentVars(spec ProjectSpec, envName string) map[string]string {
+	out := make(map[string]string, len(spec.Vars)+len(spec.Environments[envName].Vars))
+	maps.Copy(out, spec.Vars)
+	maps.Copy(out, spec.Environments[envName].Vars)
+	return out
+}
+
+// specFieldError is a validation failure of one spec field, named by its
+// JSON path (environments.dev.vars.PORT). Its message already names the
+// field, so Error returns the message alone.
+type specFieldError struct {
+	Field   string
+	Message string
+}
+
+func (e specFieldError) Error() string {
+	return e.Message
+}
+
+func specFieldErrorf(field, format string, args ...any) error {
+	return specFieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
 func validateProjectSpec(spec ProjectSpec) error {
 	if err := validateProjectCore(spec); err != nil {
 		return err
 	}
-	if err := validateCapabilities(spec.Capabilities); err != nil {
+	if err := validateBuildConfig(spec); err != nil {
+		return err
+	}
+	if err := validateCapabilities(spec); err != nil {
+		return err
+	}
+	if err := validateProjectNeeds(spec); err != nil {
+		return err
+	}
+	if err := validateEnvironmentVars("vars", "vars", spec.Vars); err != nil {
 		return err
 	}
 	if err := validateEnvironments(spec.Environments); err != nil {
 		return err
 	}
-	return validateNetworkPolicies(spec.NetworkPolicies)
+	if err := validateExposure(spec.Exposure); err != nil {
+		return err
+	}
+	if err := validateResources(spec); err != nil {
+		return err
+	}
+	if err := validateNetworkPolicies(spec.NetworkPolicies); err != nil {
+		return err
+	}
+	if err := validateRepoRemotes(spec.Repos); err != nil {
+		return err
+	}
+	if err := validateCIPolicy(spec.CI); err != nil {
+		return err
+	}
+	return validateExtensionKeys(spec.Extensions)
 }
 
 func validateProjectCore(spec ProjectSpec) error {
 	if spec.APIVersion != projectAPIVersion {
-		return fmt.Errorf("apiVersion must be %q", projectAPIVersion)
+		return specFieldErrorf("apiVersion", "apiVersion must be %q", projectAPIVersion)
 	}
 	if spec.Kind != projectKind {
-		return fmt.Errorf("kind must be %q", projectKind)
+		return specFieldErrorf("kind", "kind must be %q", projectKind)
 	}
 	if len(spec.Name) < 1 || len(spec.Name) > 63 || !projectNameRe.MatchString(spec.Name) {
-		return fmt.Errorf("name must match %s", projectNameRe.String())
+		return specFieldErrorf("name", "name must match %s", projectNameRe.String())
 	}
 	if len(spec.Runtime) < 1 || len(spec.Runtime) > 128 || !runtimeRe.MatchString(spec.Runtime) {
-		return fmt.Errorf("runtime must match %s", runtimeRe.String())
+		return specFieldErrorf("runtime", "runtime must match %s", runtimeRe.String())
 	}
-	return nil
-}
-
-func validateCapabilities(capabilities []string) error {
-	for _, capability := range capabilities {
-		if len(capability) > 64 || !capabilityRe.MatchString(capability) {
-			return fmt.Errorf("invalid capability %q", capability)
-		}
-	}
-	return nil
+	return validateProjectTemplateName(spec.Template)
 }
 
 func validateEnvironments(envs map[string]EnvConfig) error {
 	if len(envs) < 1 {
-		return errors.New("environments must include at least one environment")
+		return specFieldErrorf("environments", "environments must include at least one environment")
 	}
 	for envName, envCfg := range envs {
 		if len(envName) > 32 || !envNameRe.MatchString(envName) {
-			return fmt.Errorf("invalid environment name %q", envName)
+			return specFieldErrorf("environments."+envName, "invalid environment name %q", envName)
+		}
+		if err := validateEnvironmentVars(envName, "environments."+envName+".vars", envCfg.Vars); err != nil {
+			return err
+		}
+		if err := validateEnvironmentSecrets(envName, envCfg); err != nil {
+			return err
 		}
-		if err := validateEnvironmentVars(envName, envCfg.Vars); err != nil {
+		if err := validateFreezeWindows("environments."+envName+".freeze", envCfg.Freeze); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func validateEnvironmentVars(envName string, vars map[string]string) error {
+// validateEnvironmentVars checks a block of env vars; scope names the block
+// in messages and field is its JSON path.
+func validateEnvironmentVars(scope, field string, vars map[string]string) error {
 	for key, value := range vars {
 		if len(key) > 128 || !envVarNameRe.MatchString(key) {
-			return fmt.Errorf("invalid environment variable name %q in %q", key, envName)
+			return specFieldErrorf(field+"."+key, "invalid environment variable name %q in %q", key, scope)
 		}
 		if len(value) > maxEnvVarValueLength {
-			return fmt.Errorf("env var %q in %q exceeds max length", key, envName)
+			return specFieldErrorf(field+"."+key, "env var %q in %q exceeds max length", key, scope)
+		}
+	}
+	return nil
+}
+
+// validateExtensionKeys checks key shape only; values are checked against
+// the operator's schemas by specExtensionRegistry.
+func validateExtensionKeys(extensions map[string]any) error {
+	for key := range extensions {
+		if len(key) > 63 || !extensionKeyRe.MatchString(key) {
+			return specFieldErrorf("extensions."+key, "extension key %q must match %s", key, extensionKeyRe.String())
 		}
 	}
 	return nil
@@ -248,10 +1097,10 @@ func validateEnvironmentVars(envName string, vars map[string]string) error {
 
 func validateNetworkPolicies(policies NetworkPolicies) error {
 	if !networkValueRe.MatchString(policies.Ingress) {
-		return errors.New("networkPolicies.ingress must be internal or none")
+		return specFieldErrorf("networkPolicies.ingress", "networkPolicies.ingress must be internal or none")
 	}
 	if !networkValueRe.MatchString(policies.Egress) {
-		return errors.New("networkPolicies.egress must be internal or none")
+		return specFieldErrorf("networkPolicies.egress", "networkPolicies.egress must be internal or none")
 	}
 	return nil
 }
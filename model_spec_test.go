@@ -72,3 +72,18 @@ func TestModel_RenderProjectConfigYAML(t *testing.T) {
 		t.Fatalf("missing networkPolicies in yaml: %s", out)
 	}
 }
+
+func TestModel_ValidateProjectSpecRejectsBadSharedVar(t *testing.T) {
+	spec := platform.NormalizeProjectSpecForTest(platform.ProjectSpec{
+		Name:    "hello",
+		Runtime: "go_1.26",
+		Vars:    map[string]string{"log-level": "info"},
+		Environments: map[string]platform.EnvConfig{
+			"dev": {Vars: map[string]string{}},
+		},
+	})
+	err := platform.ValidateProjectSpecForTest(spec)
+	if err == nil || !strings.Contains(err.Error(), `"vars"`) {
+		t.Fatalf("expected shared vars validation error, got %v", err)
+	}
+}
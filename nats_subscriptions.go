@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -27,14 +28,16 @@ func subscribeFinalResults(
 	waiters *waiterHub,
 	log sourceLogger,
 ) (func(), error) {
-	subjects := finalResultSubjects()
+	// A durable consumer per partition keeps the original per-subject
+	// consumers, and their start times, as they were.
+	subjects := workerPartitionSubjects(finalResultSubjects())
 	consumers := make([]jetstream.Consumer, 0, len(subjects))
 	for _, subject := range subjects {
 		err := ensureFinalResultConsumer(ctx, js, subject)
 		if err != nil {
 			return nil, err
 		}
-		consumer, err := js.Consumer(ctx, streamWorkerPipeline, finalResultConsumerName(subject))
+		consumer, err := js.Consumer(ctx, natsStreamName(streamWorkerPipeline), finalResultConsumerName(subject))
 		if err != nil {
 			return nil, err
 		}
@@ -61,9 +64,11 @@ func subscribeFinalResults(
 
 func finalResultSubjects() []string {
 	return []string{
-		subjectDeployDone,
-		subjectDeploymentDone,
-		subjectPromotionDone,
+		natsSubject(subjectDeployDone),
+		natsSubject(subjectDeploymentDone),
+		natsSubject(subjectPromotionDone),
+		natsSubject(subjectCleanupDone),
+		natsSubject(subjectUpgradeDone),
 	}
 }
 
@@ -73,9 +78,18 @@ func ensureFinalResultConsumer(
 	subject string,
 ) error {
 	consumerName := finalResultConsumerName(subject)
-	_, err := js.Consumer(ctx, streamWorkerPipeline, consumerName)
+	existing, err := js.Consumer(ctx, natsStreamName(streamWorkerPipeline), consumerName)
 	if err == nil {
-		return nil
+		// Consumers from before org subjects filter the bare subject only;
+		// widening the filter in place keeps their delivery state.
+		cfg := existing.CachedInfo().Config
+		if slices.Equal(cfg.FilterSubjects, workerFilterSubjects(subject)) {
+			return nil
+		}
+		cfg.FilterSubject = ""
+		cfg.FilterSubjects = workerFilterSubjects(subject)
+		_, err = js.UpdateConsumer(ctx, natsStreamName(streamWorkerPipeline), cfg)
+		return err
 	}
 	if !errors.Is(err, jetstream.ErrConsumerNotFound) {
 		return err
@@ -93,10 +107,10 @@ func ensureFinalResultConsumer(
 	cfg.AckWait = finalResultConsumerAckWait
 	cfg.MaxDeliver = finalResultConsumerMaxDeliver
 	cfg.BackOff = finalResultConsumerRetryBackoff()
-	cfg.FilterSubject = subject
+	cfg.FilterSubjects = workerFilterSubjects(subject)
 	cfg.ReplayPolicy = jetstream.ReplayInstantPolicy
 	cfg.MaxAckPending = 1
-	_, err = js.CreateConsumer(ctx, streamWorkerPipeline, cfg)
+	_, err = js.CreateConsumer(ctx, natsStreamName(streamWorkerPipeline), cfg)
 	return err
 }
 
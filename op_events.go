@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,13 +16,22 @@ const (
 	opEventArtifacts = "step.artifacts"
 	opEventCompleted = "op.completed"
 	opEventFailed    = "op.failed"
+	opEventCancelled = "op.cancelled"
 	opEventHeartbeat = "op.heartbeat"
-
-	opStatusRunning = "running"
-	opStatusDone    = "done"
-	opStatusError   = "error"
-	opMessageFailed = "operation failed"
-	opMessageDone   = "operation completed"
+	opEventStepBeat  = "step.heartbeat"
+	opEventSubStep   = "step.substep"
+	opEventNote      = "op.note"
+	opEventSLA       = "op.sla_breached"
+
+	opStatusRunning   = "running"
+	opStatusDone      = "done"
+	opStatusError     = "error"
+	opStatusCancelled = "cancelled"
+	// Cut short by a shutdown; not terminal, the op resumes on restart.
+	opStatusInterrupted = "interrupted"
+	opMessageFailed     = "operation failed"
+	opMessageDone       = "operation completed"
+	opMessageCancel     = "operation cancelled"
 
 	opEventSubscriberBuffer = 32
 	opTotalStepsFullChain   = 4
@@ -57,6 +67,9 @@ type opEventPayload struct {
 	Artifacts       []string        `json:"artifacts,omitempty"`
 	Delivery        opEventDelivery `json:"delivery"`
 	Hint            string          `json:"hint,omitempty"`
+	SubStep         *OpSubStep      `json:"substep,omitempty"`
+	Note            *OpNote         `json:"note,omitempty"`
+	SLABreach       *OpSLABreach    `json:"sla_breach,omitempty"`
 }
 
 type opEventRecord struct {
@@ -77,6 +90,10 @@ type opEventHub struct {
 	terminalTTL  time.Duration
 	nextSubID    uint64
 	streams      map[string]*opEventStream
+	projects     map[string]*projectEventStream
+	// journal, when set, keeps op histories across restarts; see
+	// op_events_journal.go.
+	journal *opEventJournal
 }
 
 func newOpEventHub(historyLimit int, terminalTTL time.Duration) *opEventHub {
@@ -92,9 +109,20 @@ func newOpEventHub(historyLimit int, terminalTTL time.Duration) *opEventHub {
 		terminalTTL:  terminalTTL,
 		nextSubID:    0,
 		streams:      map[string]*opEventStream{},
+		projects:     map[string]*projectEventStream{},
+		journal:      nil,
 	}
 }
 
+// setJournal makes the hub journal its events and restore op histories it
+// does not hold. Call it before the hub is used.
+func (h *opEventHub) setJournal(journal *opEventJournal) {
+	if h == nil {
+		return
+	}
+	h.journal = journal
+}
+
 func (h *opEventHub) publish(eventName string, payload opEventPayload) {
 	if h == nil || strings.TrimSpace(payload.OpID) == "" {
 		return
@@ -104,6 +132,10 @@ func (h *opEventHub) publish(eventName string, payload opEventPayload) {
 	if payload.At.IsZero() {
 		payload.At = now
 	}
+	// An op's first event is its bootstrap, so there is nothing to restore.
+	if eventName != opEventBootstrap {
+		h.restore(payload.OpID)
+	}
 
 	h.mu.Lock()
 	h.cleanupLocked(now)
@@ -117,10 +149,7 @@ func (h *opEventHub) publish(eventName string, payload opEventPayload) {
 	if len(stream.records) > h.historyLimit {
 		stream.records = append([]opEventRecord(nil), stream.records[len(stream.records)-h.historyLimit:]...)
 	}
-	if payload.Status == opStatusDone ||
-		payload.Status == opStatusError ||
-		eventName == opEventCompleted ||
-		eventName == opEventFailed {
+	if isOpEventTerminal(eventName, payload.Status) {
 		stream.terminalAt = now
 	}
 
@@ -129,6 +158,7 @@ func (h *opEventHub) publish(eventName string, payload opEventPayload) {
 		subs = append(subs, sub)
 	}
 	h.mu.Unlock()
+	h.journal.append(record)
 
 	for _, sub := range subs {
 		select {
@@ -136,6 +166,7 @@ func (h *opEventHub) publish(eventName string, payload opEventPayload) {
 		default:
 		}
 	}
+	emitProjectOpEvent(h, eventName, payload)
 }
 
 func (h *opEventHub) subscribe(
@@ -148,6 +179,7 @@ func (h *opEventHub) subscribe(
 
 	opID = strings.TrimSpace(opID)
 	now := time.Now().UTC()
+	h.restore(opID)
 
 	h.mu.Lock()
 	h.cleanupLocked(now)
@@ -158,7 +190,7 @@ func (h *opEventHub) subscribe(
 	subID := h.nextSubID
 	stream.subscribers[subID] = ch
 
-	replay, needsBootstrap := computeOpEventReplay(stream.records, lastEventID)
+	replay, needsBootstrap := computeEventReplay(stream.records, opEventSequence, lastEventID)
 
 	h.mu.Unlock()
 
@@ -222,19 +254,28 @@ func (h *opEventHub) cleanupLocked(now time.Time) {
 		}
 		delete(h.streams, opID)
 	}
+	h.cleanupProjectsLocked(now)
 }
 
-func opEventRange(records []opEventRecord) (int64, int64) {
-	if len(records) == 0 {
-		return 0, 0
-	}
-	return records[0].Payload.Sequence, records[len(records)-1].Payload.Sequence
+func isOpEventTerminal(eventName, status string) bool {
+	return isOperationStatusTerminal(status) ||
+		eventName == opEventCompleted ||
+		eventName == opEventFailed ||
+		eventName == opEventCancelled
 }
 
-func computeOpEventReplay(
-	records []opEventRecord,
+func opEventSequence(record opEventRecord) int64 {
+	return record.Payload.Sequence
+}
+
+// computeEventReplay returns the records after lastEventID, or asks for a
+// bootstrap when the ID is missing or outside the retained window. It serves
+// both per-op and per-project streams.
+func computeEventReplay[R any](
+	records []R,
+	sequence func(R) int64,
 	lastEventID string,
-) ([]opEventRecord, bool) {
+) ([]R, bool) {
 	lastEventID = strings.TrimSpace(lastEventID)
 	if lastEventID == "" {
 		return nil, true
@@ -244,17 +285,17 @@ func computeOpEventReplay(
 	if !ok {
 		return nil, true
 	}
-	oldest, newest := opEventRange(records)
-	if oldest == 0 && newest == 0 {
+	if len(records) == 0 {
 		return nil, true
 	}
+	oldest, newest := sequence(records[0]), sequence(records[len(records)-1])
 	if lastSeq < oldest-1 || lastSeq > newest {
 		return nil, true
 	}
 
-	replay := make([]opEventRecord, 0, len(records))
+	replay := make([]R, 0, len(records))
 	for _, record := range records {
-		if record.Payload.Sequence > lastSeq {
+		if sequence(record) > lastSeq {
 			replay = append(replay, record)
 		}
 	}
@@ -292,7 +333,10 @@ func newOpEventBase(op Operation) opEventPayload {
 			FromEnv:     op.Delivery.FromEnv,
 			ToEnv:       op.Delivery.ToEnv,
 		},
-		Hint: "",
+		Hint:      "",
+		SubStep:   nil,
+		Note:      nil,
+		SLABreach: nil,
 	}
 }
 
@@ -336,6 +380,14 @@ func newOpBootstrapSnapshot(op Operation) opEventPayload {
 		if payload.Message == "" {
 			payload.Message = opMessageFailed
 		}
+	case opStatusCancelled:
+		if payload.Message == "" {
+			payload.Message = opMessageCancel
+		}
+	case opStatusInterrupted:
+		if payload.Message == "" {
+			payload.Message = "operation " + opInterruptedMessage
+		}
 	}
 	return payload
 }
@@ -388,6 +440,64 @@ func emitOpStepStarted(h *opEventHub, op Operation, worker string, stepIndex int
 	h.publish(opEventStarted, payload)
 }
 
+func emitOpStepHeartbeat(h *opEventHub, op Operation, step OpStep, stepIndex int) {
+	if h == nil {
+		return
+	}
+	payload := newOpEventBase(op)
+	payload.Worker = strings.TrimSpace(step.Worker)
+	payload.StepIndex = stepIndex
+	payload.Message = step.Progress
+	if payload.Message == "" {
+		payload.Message = "step still running"
+	}
+	if step.Percent > 0 {
+		payload.ProgressPercent = step.Percent
+	}
+	if !step.StartedAt.IsZero() && step.HeartbeatAt.After(step.StartedAt) {
+		payload.DurationMS = step.HeartbeatAt.Sub(step.StartedAt).Milliseconds()
+	}
+	h.publish(opEventStepBeat, payload)
+}
+
+// emitOpSubStep reports a sub-step boundary. A starting sub-step has a zero
+// ended_at; an ending one carries its own duration_ms and error.
+func emitOpSubStep(h *opEventHub, op Operation, worker string, stepIndex int, subStep OpSubStep) {
+	if h == nil {
+		return
+	}
+	payload := newOpEventBase(op)
+	payload.Worker = strings.TrimSpace(worker)
+	payload.StepIndex = stepIndex
+	payload.Message = subStep.Name
+	payload.Error = subStep.Error
+	if !subStep.EndedAt.IsZero() {
+		payload.DurationMS = subStep.EndedAt.Sub(subStep.StartedAt).Milliseconds()
+	}
+	payload.SubStep = &subStep
+	h.publish(opEventSubStep, payload)
+}
+
+func emitOpNote(h *opEventHub, op Operation, note OpNote) {
+	if h == nil {
+		return
+	}
+	payload := newOpEventBase(op)
+	payload.Message = note.Text
+	payload.Note = &note
+	h.publish(opEventNote, payload)
+}
+
+func emitOpSLABreached(h *opEventHub, op Operation, breach OpSLABreach) {
+	if h == nil {
+		return
+	}
+	payload := newOpEventBase(op)
+	payload.Message = fmt.Sprintf(opSLABreachedMessage, breach.State, breach.Elapsed, breach.Threshold)
+	payload.SLABreach = &breach
+	h.publish(opEventSLA, payload)
+}
+
 func emitOpStepEnded(
 	h *opEventHub,
 	op Operation,
@@ -445,6 +555,11 @@ func emitOpTerminal(h *opEventHub, op Operation) {
 	if payload.Status == opStatusDone {
 		payload.Message = opMessageDone
 		h.publish(opEventCompleted, payload)
+		return
+	}
+	if payload.Status == opStatusCancelled {
+		payload.Message = opMessageCancel
+		h.publish(opEventCancelled, payload)
 	}
 }
 
@@ -484,10 +599,14 @@ func opTotalSteps(kind OperationKind) int {
 		return opTotalStepsFullChain
 	case OpCI:
 		return opTotalStepsCIChain
-	case OpDeploy:
+	case OpDeploy, OpCleanup, OpRuntimeUpgrade, OpWebhookRefresh:
 		return opTotalStepsSingle
 	case OpPromote, OpRelease, OpRollback:
 		return opTotalStepsTransition
+	case OpVarRollout, OpPromoteFanout:
+		// One step per stage or target; opProgressPercent counts them from
+		// the op.
+		return 0
 	default:
 		return 0
 	}
@@ -495,6 +614,12 @@ func opTotalSteps(kind OperationKind) int {
 
 func opProgressPercent(op Operation) int {
 	total := opTotalSteps(op.Kind)
+	if op.Rollout != nil {
+		total = len(op.Rollout.Stages)
+	}
+	if op.Fanout != nil {
+		total = len(op.Fanout.Targets)
+	}
 	if total <= 0 {
 		if op.Status == opStatusDone {
 			return opProgressMax
@@ -0,0 +1,188 @@
+package platform
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Op event journal: every event the hub publishes is also appended to the
+// OP_EVENTS stream under the op's own subject, keeping the same
+// opEventsHistoryLimit window per op for opEventsJournalMaxAge. An op the
+// hub holds nothing for, because the process restarted or the op's history
+// aged out of memory, is read back from the journal on first use, so a
+// client's Last-Event-ID keeps resuming where it left off and new events
+// continue its sequence. Events of an organization's projects carry the
+// org in their subject, op.events.<org>.<op>, so a consumer can be limited
+// to one org.
+////////////////////////////////////////////////////////////////////////////////
+
+// opEventJournalEntry is one journaled event; the SSE event name travels
+// with its payload.
+type opEventJournalEntry struct {
+	Name    string         `json:"name"`
+	Payload opEventPayload `json:"payload"`
+}
+
+type opEventJournal struct {
+	js jetstream.JetStream
+	// projectOrg resolves the org whose subjects a project's events go to;
+	// nil journals every event without one.
+	projectOrg func(ctx context.Context, projectID string) (string, error)
+}
+
+func ensureOpEventJournal(ctx context.Context, js jetstream.JetStream) (*opEventJournal, error) {
+	var cfg jetstream.StreamConfig
+	cfg.Name = natsStreamName(streamOpEvents)
+	cfg.Subjects = []string{natsSubject(subjectOpEventsPrefix) + ">"}
+	cfg.Retention = jetstream.LimitsPolicy
+	cfg.MaxMsgsPerSubject = opEventsHistoryLimit
+	cfg.MaxAge = opEventsJournalMaxAge
+	cfg.Discard = jetstream.DiscardOld
+	cfg.Storage = jetstream.FileStorage
+	cfg.Replicas = 1
+	if _, err := js.CreateOrUpdateStream(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return &opEventJournal{js: js, projectOrg: nil}, nil
+}
+
+// opEventJournalSubject returns the op's subject within org ("" for none),
+// or "" for an ID that is not a single subject token and so is never
+// journaled. An org that is not one token journals as no org.
+func opEventJournalSubject(org, opID string) string {
+	if !subjectPrefixTokenPattern.MatchString(opID) {
+		return ""
+	}
+	if org != "" && subjectPrefixTokenPattern.MatchString(org) {
+		return natsSubject(subjectOpEventsPrefix) + org + "." + opID
+	}
+	return natsSubject(subjectOpEventsPrefix) + opID
+}
+
+// append journals record without waiting for the server's ack; a lost
+// append only shortens what a later restore can replay. An org that cannot
+// be resolved journals the event without one, where load still finds it.
+func (j *opEventJournal) append(record opEventRecord) {
+	if j == nil {
+		return
+	}
+	org := ""
+	if j.projectOrg != nil && record.Payload.ProjectID != "" {
+		org, _ = j.projectOrg(context.Background(), record.Payload.ProjectID)
+	}
+	subject := opEventJournalSubject(org, record.Payload.OpID)
+	if subject == "" {
+		return
+	}
+	body, err := json.Marshal(opEventJournalEntry{Name: record.Name, Payload: record.Payload})
+	if err != nil {
+		return
+	}
+	if _, err = j.js.PublishAsync(subject, body); err != nil {
+		appLoggerForProcess().Source("opEvents").Warnf("op=%s journal event: %v", record.Payload.OpID, err)
+	}
+}
+
+// load reads opID's journaled events, under any org or none, back in
+// sequence order. Should two processes have numbered the same op's events,
+// the first record seen for each sequence wins.
+func (j *opEventJournal) load(ctx context.Context, opID string) ([]opEventRecord, error) {
+	subject := opEventJournalSubject("", opID)
+	if j == nil || subject == "" {
+		return nil, nil
+	}
+	orgSubject := natsSubject(subjectOpEventsPrefix) + "*." + opID
+	ctx, cancel := context.WithTimeout(ctx, opEventsJournalLoadTimeout)
+	defer cancel()
+	stream, err := j.js.Stream(ctx, natsStreamName(streamOpEvents))
+	if err != nil {
+		return nil, err
+	}
+	var stored uint64
+	for _, filter := range []string{subject, orgSubject} {
+		info, infoErr := stream.Info(ctx, jetstream.WithSubjectFilter(filter))
+		if infoErr != nil {
+			return nil, infoErr
+		}
+		for _, count := range info.State.Subjects {
+			stored += count
+		}
+	}
+	pending := min(stored, uint64(opEventsHistoryLimit))
+	if pending == 0 {
+		return nil, nil
+	}
+
+	var cfg jetstream.OrderedConsumerConfig
+	cfg.FilterSubjects = []string{subject, orgSubject}
+	cfg.DeliverPolicy = jetstream.DeliverAllPolicy
+	consumer, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := consumer.Fetch(int(pending), jetstream.FetchMaxWait(opEventsJournalLoadTimeout))
+	if err != nil {
+		return nil, err
+	}
+	records := make([]opEventRecord, 0, pending)
+	for msg := range batch.Messages() {
+		var entry opEventJournalEntry
+		if json.Unmarshal(msg.Data(), &entry) == nil && entry.Payload.Sequence > 0 {
+			records = append(records, opEventRecord{Name: entry.Name, Payload: entry.Payload})
+		}
+	}
+	if fetchErr := batch.Error(); fetchErr != nil && !errors.Is(fetchErr, context.DeadlineExceeded) {
+		return nil, fetchErr
+	}
+	slices.SortStableFunc(records, func(a, b opEventRecord) int {
+		return cmp.Compare(a.Payload.Sequence, b.Payload.Sequence)
+	})
+	return slices.CompactFunc(records, func(a, b opEventRecord) bool {
+		return a.Payload.Sequence == b.Payload.Sequence
+	}), nil
+}
+
+// restore fills in opID's history from the journal unless the hub already
+// holds it. The journal is read outside the hub's lock.
+func (h *opEventHub) restore(opID string) {
+	if h == nil || h.journal == nil {
+		return
+	}
+	h.mu.Lock()
+	_, held := h.streams[opID]
+	h.mu.Unlock()
+	if held {
+		return
+	}
+	records, err := h.journal.load(context.Background(), opID)
+	if err != nil {
+		appLoggerForProcess().Source("opEvents").Warnf("op=%s restore events: %v", opID, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, held = h.streams[opID]; held {
+		return
+	}
+	stream := h.streamForLocked(opID)
+	if len(records) == 0 {
+		return
+	}
+	if len(records) > h.historyLimit {
+		records = records[len(records)-h.historyLimit:]
+	}
+	stream.records = records
+	last := records[len(records)-1]
+	stream.nextSequence = last.Payload.Sequence
+	if isOpEventTerminal(last.Name, last.Payload.Status) {
+		// Counted from now, so the restored history stays for a reconnect.
+		stream.terminalAt = time.Now().UTC()
+	}
+}
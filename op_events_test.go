@@ -1,7 +1,8 @@
-//nolint:testpackage // Event-hub tests validate unexported replay and retention behavior.
+//nolint:testpackage,exhaustruct // Event-hub tests validate unexported replay and retention behavior.
 package platform
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -97,6 +98,118 @@ func TestOpEventHubTerminalTTLPrune(t *testing.T) {
 	}
 }
 
+func TestOpEventHubRestoresHistoryFromJournal(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	journal, err := ensureOpEventJournal(context.Background(), fixture.js)
+	if err != nil {
+		t.Fatalf("ensure journal: %v", err)
+	}
+
+	before := newOpEventHub(8, time.Minute)
+	before.setJournal(journal)
+	base := newTestOpEventPayload("op-journal", "project-journal", OpDeploy, opStatusRunning)
+	before.publish(opEventBootstrap, base)
+	before.publish(opEventStatus, base)
+	before.publish(opEventStarted, base)
+	select {
+	case <-fixture.js.PublishAsyncComplete():
+	case <-time.After(5 * time.Second):
+		t.Fatal("journal appends not acked")
+	}
+
+	// A new hub stands in for the restarted process.
+	after := newOpEventHub(8, time.Minute)
+	after.setJournal(journal)
+	replay, _, needsBootstrap, unsubscribe := after.subscribe("op-journal", "1")
+	defer unsubscribe()
+	if needsBootstrap || len(replay) != 2 || replay[0].Name != opEventStatus || replay[1].Payload.Sequence != 3 {
+		t.Fatalf("expected events 2 and 3 replayed after the restart, got %+v (bootstrap=%v)", replay, needsBootstrap)
+	}
+	after.publish(opEventEnded, base)
+	if got := after.latestSequence("op-journal"); got != 4 {
+		t.Fatalf("expected new events to continue the journaled sequence, got %d", got)
+	}
+}
+
+func TestOpEventHubMultiplexesProjectEvents(t *testing.T) {
+	hub := newOpEventHub(8, time.Minute)
+	_, live, needsBootstrap, unsubscribe := hub.subscribeProject("project-5", "")
+	defer unsubscribe()
+	if !needsBootstrap {
+		t.Fatal("expected bootstrap for a fresh project subscription")
+	}
+
+	hub.publish(opEventStatus, newTestOpEventPayload("op-a", "project-5", OpDeploy, opStatusRunning))
+	hub.publish(opEventStatus, newTestOpEventPayload("op-other", "project-6", OpDeploy, opStatusRunning))
+	hub.publish(opEventCompleted, newTestOpEventPayload("op-b", "project-5", OpCI, opStatusDone))
+	emitProjectRelease(hub, ReleaseRecord{ID: "rel-1", ProjectID: "project-5", CreatedAt: time.Now().UTC()})
+
+	want := []struct {
+		name string
+		opID string
+	}{
+		{opEventStatus, "op-a"},
+		{opEventCompleted, "op-b"},
+		{projectEventRelease, ""},
+	}
+	for i, expected := range want {
+		record := <-live
+		if record.Name != expected.name || record.Payload.Type != expected.name {
+			t.Fatalf("event %d: expected %s, got name=%s type=%s", i, expected.name, record.Name, record.Payload.Type)
+		}
+		if record.Payload.Sequence != int64(i+1) {
+			t.Fatalf("event %d: expected project sequence %d, got %d", i, i+1, record.Payload.Sequence)
+		}
+		if expected.opID != "" && (record.Payload.Op == nil || record.Payload.Op.OpID != expected.opID) {
+			t.Fatalf("event %d: expected op %s, got %+v", i, expected.opID, record.Payload.Op)
+		}
+	}
+	select {
+	case record := <-live:
+		t.Fatalf("unexpected event from another project: %+v", record)
+	default:
+	}
+
+	replay, _, needsBootstrap, unsubscribeReplay := hub.subscribeProject("project-5", "1")
+	defer unsubscribeReplay()
+	if needsBootstrap || len(replay) != 2 || replay[1].Payload.Release == nil {
+		t.Fatalf("expected replay of events 2-3 ending with the release, got bootstrap=%t replay=%+v", needsBootstrap, replay)
+	}
+}
+
+func TestRelayWorkerResultFillsInStepsFromOtherReplicas(t *testing.T) {
+	hub := newOpEventHub(16, time.Minute)
+	_, live, _, unsubscribe := hub.subscribeProject("project-7", "")
+	defer unsubscribe()
+
+	op := Operation{ID: "op-r", Kind: OpCreate, ProjectID: "project-7", Status: opStatusRunning}
+	emitOpStepEnded(hub, op, "registrar", 1, "registered", "", nil, time.Time{}, time.Time{})
+	<-live
+
+	local := WorkerResultMsg{OpID: "op-r", Kind: OpCreate, ProjectID: "project-7", Worker: "registrar", Message: "registered"}
+	relayWorkerResult(hub, local)
+	skipped := WorkerResultMsg{OpID: "op-r", Kind: OpCreate, ProjectID: "project-7", Worker: "imageBuilder",
+		Message: workerSkippedMessage, Err: "upstream failed"}
+	relayWorkerResult(hub, skipped)
+	remote := WorkerResultMsg{OpID: "op-r", Kind: OpCreate, ProjectID: "project-7", Worker: "repoBootstrap",
+		Err: "git push failed"}
+	relayWorkerResult(hub, remote)
+
+	record := <-live
+	if record.Name != opEventEnded || record.Payload.Op == nil || record.Payload.Op.Worker != "repoBootstrap" {
+		t.Fatalf("expected relayed repoBootstrap step.ended, got %+v", record)
+	}
+	if record.Payload.Op.Status != opStatusError || record.Payload.Op.Error != "git push failed" {
+		t.Fatalf("expected relayed failure, got %+v", record.Payload.Op)
+	}
+	select {
+	case extra := <-live:
+		t.Fatalf("expected local and skipped results to be dropped, got %+v", extra)
+	default:
+	}
+}
+
 func TestNewOpBootstrapSnapshotReconstructsLatestStepFromStoredOp(t *testing.T) {
 	t.Parallel()
 
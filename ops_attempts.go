@@ -0,0 +1,149 @@
+package platform
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Step attempts: a redelivered step reruns its action, which writes the same
+// artifact paths the interrupted attempt did. Before the rerun starts, the
+// files the previous attempt wrote are copied to <dir>/attempt-N/... and
+// listed on the step's prior_attempts. The unnumbered paths always hold the
+// latest attempt's output, which is what later workers and the UI read.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	attemptArtifactDirPrefix = "attempt-"
+	// Git working trees carry their own history and are not copied, and op
+	// logs already give each attempt its own section.
+	attemptArtifactSkipPrefix = "repos/"
+	// File mtimes come from a coarser clock than step timestamps, and some
+	// filesystems only keep whole seconds.
+	attemptModTimeSlack = 2 * time.Second
+)
+
+// attemptArtifactPath nests rel under attempt-N inside its top-level
+// directory: build/image.txt becomes build/attempt-2/image.txt.
+func attemptArtifactPath(rel string, attempt uint64) string {
+	name := attemptArtifactDirPrefix + strconv.FormatUint(attempt, 10)
+	dir, rest, ok := strings.Cut(rel, "/")
+	if !ok {
+		return name + "/" + rel
+	}
+	return dir + "/" + name + "/" + rest
+}
+
+func isAttemptArtifactPath(rel string) bool {
+	for part := range strings.SplitSeq(rel, "/") {
+		if number, ok := strings.CutPrefix(part, attemptArtifactDirPrefix); ok {
+			if _, err := strconv.ParseUint(number, 10, 64); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// preserveStepAttempt runs before delivery attempt of workerName's step. When
+// an earlier attempt left the step open, the files written since it started
+// are copied aside and the attempt is recorded on the step. Files another op
+// wrote to the same project in that window are copied too.
+func preserveStepAttempt(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	opMsg ProjectOpMsg,
+	workerName string,
+	attempt uint64,
+) error {
+	if attempt < 2 || opMsg.Execution.DryRun {
+		return nil
+	}
+	op, err := store.GetOp(ctx, opMsg.OpID)
+	if err != nil {
+		return err
+	}
+	if openWorkerStep(&op, workerName) == nil {
+		return nil
+	}
+	previous, since := lastWorkerAttempt(op, workerName)
+	if previous >= attempt {
+		return nil
+	}
+	files, err := artifacts.StatFiles(opMsg.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	saved := []string{}
+	for _, file := range files {
+		if file.ModTime.Before(since.Add(-attemptModTimeSlack)) || strings.HasPrefix(file.Path, attemptArtifactSkipPrefix) ||
+			strings.HasPrefix(file.Path, opLogArtifactRoot+"/") || isAttemptArtifactPath(file.Path) {
+			continue
+		}
+		data, readErr := artifacts.ReadFile(opMsg.ProjectID, file.Path)
+		if readErr != nil {
+			return readErr
+		}
+		copied, writeErr := artifacts.WriteFile(opMsg.ProjectID, attemptArtifactPath(file.Path, previous), data)
+		if writeErr != nil {
+			return writeErr
+		}
+		saved = append(saved, copied)
+	}
+	endedAt := time.Now().UTC()
+	_, err = store.updateOp(ctx, opMsg.OpID, func(op *Operation) bool {
+		step := openWorkerStep(op, workerName)
+		if step == nil {
+			return false
+		}
+		if recorded, _ := lastWorkerAttempt(*op, workerName); recorded >= attempt {
+			return false
+		}
+		step.PriorAttempts = append(step.PriorAttempts, OpStepAttempt{
+			Attempt:   previous,
+			StartedAt: since,
+			EndedAt:   endedAt,
+			Artifacts: saved,
+		})
+		step.Attempt = attempt
+		return true
+	})
+	return err
+}
+
+// openWorkerStep returns the newest unfinished step of workerName (or one
+// of its stages, like promoter.render), or nil.
+func openWorkerStep(op *Operation, workerName string) *OpStep {
+	for i := len(op.Steps) - 1; i >= 0; i-- {
+		if workerStepMatchesDelivery(op.Steps[i].Worker, workerName) && op.Steps[i].EndedAt.IsZero() {
+			return &op.Steps[i]
+		}
+	}
+	return nil
+}
+
+// lastWorkerAttempt returns the number and start time of workerName's most
+// recent attempt on op. A retry starts when its predecessor is preserved;
+// the first attempt starts with the worker's first step.
+func lastWorkerAttempt(op Operation, workerName string) (uint64, time.Time) {
+	attempt := uint64(1)
+	var started time.Time
+	for _, step := range op.Steps {
+		if !workerStepMatchesDelivery(step.Worker, workerName) {
+			continue
+		}
+		if started.IsZero() || step.StartedAt.Before(started) {
+			started = step.StartedAt
+		}
+		for _, prior := range step.PriorAttempts {
+			if prior.Attempt >= attempt {
+				attempt, started = prior.Attempt+1, prior.EndedAt
+			}
+		}
+	}
+	return attempt, started
+}
@@ -0,0 +1,91 @@
+//nolint:testpackage,exhaustruct // Attempt tests redeliver through the internal worker loop and artifact store.
+package platform
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWorkers_RetryPreservesPriorAttemptArtifacts(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+
+	spec := workerRuntimeSpec("attempts")
+	opID, projectID := "op-attempts", "project-attempts"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCI, spec)
+	artifacts := NewFSArtifacts(t.TempDir())
+	if _, err := artifacts.WriteFile(projectID, "deploy/dev/app.yaml", []byte("from an earlier op\n")); err != nil {
+		t.Fatalf("seed artifact: %v", err)
+	}
+	earlier := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(artifacts.ProjectDir(projectID), "deploy", "dev", "app.yaml"),
+		earlier, earlier); err != nil {
+		t.Fatalf("age seeded artifact: %v", err)
+	}
+
+	// Attempt 1 starts its step and writes its log, then the process dies.
+	_ = markOpStepStart(ctx, fixture.store, opID, "imageBuilder", time.Now().UTC(), "build image")
+	if _, err := artifacts.WriteFile(projectID, "build/log.txt", []byte("attempt 1 failed\n")); err != nil {
+		t.Fatalf("write attempt 1 log: %v", err)
+	}
+
+	deliver := func(attempt uint64, log string) {
+		t.Helper()
+		action := func(
+			ctx context.Context, store *Store, artifacts ArtifactStore, msg ProjectOpMsg,
+		) (WorkerResultMsg, error) {
+			_ = markOpStepStart(ctx, store, msg.OpID, "imageBuilder", time.Now().UTC(), "build image")
+			path, err := artifacts.WriteFile(msg.ProjectID, "build/log.txt", []byte(log))
+			if err != nil {
+				return WorkerResultMsg{}, err
+			}
+			if attempt < 3 {
+				// Leave the step open, as a process that died mid-step would.
+				return WorkerResultMsg{}, errors.New("worker process died")
+			}
+			_ = markOpStepEnd(ctx, store, msg.OpID, "imageBuilder", time.Now().UTC(), "built", "", []string{path})
+			return newWorkerResultMsg("built"), nil
+		}
+		handleWorkerDelivery(ctx, fixture.store, artifacts, "imageBuilder",
+			natsSubject(subjectBootstrapDone), natsSubject(subjectBuildDone),
+			action, fixture.js, workerPayload(t, opID, OpCI, projectID, spec), attempt,
+			appLoggerForProcess().Source("attempts-test"), publishWorkerResult, publishWorkerPoison)
+	}
+	deliver(2, "attempt 2 failed\n")
+	deliver(3, "attempt 3 built\n")
+
+	for path, want := range map[string]string{
+		"build/log.txt":           "attempt 3 built\n",
+		"build/attempt-1/log.txt": "attempt 1 failed\n",
+		"build/attempt-2/log.txt": "attempt 2 failed\n",
+	} {
+		if got, err := artifacts.ReadFile(projectID, path); err != nil || string(got) != want {
+			t.Fatalf("expected %s to hold %q, got %q (%v)", path, want, got, err)
+		}
+	}
+	files, _ := artifacts.ListFiles(projectID)
+	if slices.Contains(files, "deploy/attempt-1/dev/app.yaml") {
+		t.Fatalf("expected files older than the step to stay put, got %v", files)
+	}
+
+	op, err := fixture.store.GetOp(ctx, opID)
+	if err != nil || len(op.Steps) != 1 {
+		t.Fatalf("expected one imageBuilder step, got %+v (%v)", op.Steps, err)
+	}
+	step := op.Steps[0]
+	if step.Attempt != 3 || len(step.PriorAttempts) != 2 {
+		t.Fatalf("expected attempt 3 with two prior attempts, got %d %+v", step.Attempt, step.PriorAttempts)
+	}
+	for i, prior := range step.PriorAttempts {
+		want := attemptArtifactPath("build/log.txt", uint64(i+1))
+		if prior.Attempt != uint64(i+1) || !slices.Equal(prior.Artifacts, []string{want}) {
+			t.Fatalf("expected attempt %d to list %s, got %+v", i+1, want, prior)
+		}
+	}
+}
@@ -16,35 +16,57 @@ func markOpStepStart(
 	startedAt time.Time,
 	msg string,
 ) error {
-	op, err := store.GetOp(ctx, opID)
+	var prevStatus string
+	resumed := false
+	op, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		for i := len(op.Steps) - 1; i >= 0; i-- {
+			if op.Steps[i].Worker == worker && op.Steps[i].EndedAt.IsZero() {
+				resumed = true
+				return false
+			}
+		}
+		resumed = false
+		prevStatus = op.Status
+		if op.Status != opStatusCancelled {
+			op.Status = opStatusRunning
+		}
+		op.Steps = append(op.Steps, newOpStep(worker, startedAt, msg))
+		return true
+	})
 	if err != nil {
 		return err
 	}
-	for i := len(op.Steps) - 1; i >= 0; i-- {
-		if op.Steps[i].Worker == worker && op.Steps[i].EndedAt.IsZero() {
-			return nil
-		}
+	if resumed {
+		return resumeInterruptedOp(ctx, store, op)
+	}
+
+	if prevStatus != op.Status {
+		emitOpStatus(store.opEvents, op, "operation started")
 	}
-	prevStatus := op.Status
-	op.Status = opStatusRunning
-	op.Steps = append(op.Steps, OpStep{
+	emitOpStepStarted(store.opEvents, op, worker, len(op.Steps), msg)
+	return nil
+}
+
+func newOpStep(worker string, startedAt time.Time, msg string) OpStep {
+	return OpStep{
 		Worker:    worker,
 		StartedAt: startedAt,
 		EndedAt:   time.Time{},
 		Message:   msg,
 		Error:     "",
 		Artifacts: nil,
-	})
-	putErr := store.PutOp(ctx, op)
-	if putErr != nil {
-		return putErr
-	}
+		Compacted: 0,
 
-	if prevStatus != op.Status {
-		emitOpStatus(store.opEvents, op, "operation started")
+		HeartbeatAt: time.Time{},
+		Progress:    "",
+		Percent:     0,
+		SubSteps:    nil,
+
+		Plan: nil,
+
+		Attempt:       0,
+		PriorAttempts: nil,
 	}
-	emitOpStepStarted(store.opEvents, op, worker, len(op.Steps), msg)
-	return nil
 }
 
 func markOpStepEnd(
@@ -55,36 +77,36 @@ func markOpStepEnd(
 	message, stepErr string,
 	artifacts []string,
 ) error {
-	op, err := store.GetOp(ctx, opID)
-	if err != nil {
-		return err
-	}
-	prevStatus := op.Status
-	prevError := op.Error
+	var prevStatus, prevError string
 	stepIndex := 0
 	var stepStartedAt time.Time
-	// Find last step for worker that doesn't have EndedAt set.
-	for i := len(op.Steps) - 1; i >= 0; i-- {
-		if op.Steps[i].Worker == worker && op.Steps[i].EndedAt.IsZero() {
-			op.Steps[i].EndedAt = endedAt
-			if message != "" {
-				op.Steps[i].Message = message
+	op, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		prevStatus, prevError = op.Status, op.Error
+		stepIndex, stepStartedAt = 0, time.Time{}
+		// Find last step for worker that doesn't have EndedAt set.
+		for i := len(op.Steps) - 1; i >= 0; i-- {
+			if op.Steps[i].Worker == worker && op.Steps[i].EndedAt.IsZero() {
+				op.Steps[i].EndedAt = endedAt
+				if message != "" {
+					op.Steps[i].Message = message
+				}
+				op.Steps[i].Error = stepErr
+				op.Steps[i].Artifacts = artifacts
+				stepIndex = i + 1
+				stepStartedAt = op.Steps[i].StartedAt
+				break
 			}
-			op.Steps[i].Error = stepErr
-			op.Steps[i].Artifacts = artifacts
-			stepIndex = i + 1
-			stepStartedAt = op.Steps[i].StartedAt
-			break
 		}
-	}
-	if stepErr != "" {
-		op.Status = opStatusError
-		op.Error = stepErr
-		op.Finished = time.Now().UTC()
-	}
-	putErr := store.PutOp(ctx, op)
-	if putErr != nil {
-		return putErr
+		// A cancelled op keeps its status; the step still records why it stopped.
+		if stepErr != "" && op.Status != opStatusCancelled {
+			op.Status = opStatusError
+			op.Error = stepErr
+			op.Finished = time.Now().UTC()
+		}
+		return true
+	})
+	if err != nil {
+		return err
 	}
 
 	stateChanged := prevStatus != op.Status || prevError != op.Error
@@ -117,32 +139,79 @@ func finalizeOp(
 	kind OperationKind,
 	status, errMsg string,
 ) error {
-	op, err := store.GetOp(ctx, opID)
-	if err != nil {
+	var prevStatus, prevError string
+	skipped := false
+	// The update is revision-checked: a cancel written after this op was
+	// read makes it reread, see the cancel, and leave it in place.
+	op, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		if op.Status == opStatusCancelled && status != opStatusCancelled {
+			skipped = true
+			return false
+		}
+		skipped = false
+		prevStatus, prevError = op.Status, op.Error
+		op.Status = status
+		op.Error = errMsg
+		op.Finished = time.Now().UTC()
+		return true
+	})
+	if err != nil || skipped {
 		return err
 	}
-	prevStatus := op.Status
-	prevError := op.Error
-	op.Status = status
-	op.Error = errMsg
-	op.Finished = time.Now().UTC()
-	putErr := store.PutOp(ctx, op)
-	if putErr != nil {
-		return putErr
-	}
 
 	stateChanged := prevStatus != op.Status || prevError != op.Error
 	if stateChanged {
 		emitOpStatus(store.opEvents, op, "operation status updated")
 	}
-	if stateChanged && (status == opStatusDone || status == opStatusError) {
+	if stateChanged && isOperationStatusTerminal(status) {
 		emitOpTerminal(store.opEvents, op)
 	}
 
+	if op.Execution.DryRun || !opUpdatesProjectStatus(kind) {
+		return nil
+	}
+	// A child op ending while its parent runs hands the project back to the
+	// parent, which reports the outcome once every child has ended.
+	if parent, err := store.GetOp(ctx, op.ParentOpID); op.ParentOpID != "" && err == nil &&
+		isOperationStatusActive(parent.Status) {
+		holdProjectForParentOp(ctx, store, parent, parentOpHoldMessage(parent.Kind))
+		return nil
+	}
 	finalizeProjectStatusBestEffort(ctx, store, opID, projectID, kind, status, errMsg)
 	return nil
 }
 
+func parentOpHoldMessage(kind OperationKind) string {
+	if kind == OpPromoteFanout {
+		return promotionFanoutHoldMessage
+	}
+	return varRolloutHoldMessage
+}
+
+// holdProjectForParentOp points the project at a running parent op so no
+// other op starts between its children.
+func holdProjectForParentOp(ctx context.Context, store *Store, parent Operation, message string) {
+	_, _ = store.updateProject(ctx, parent.ProjectID, func(project *Project) bool {
+		if project.Status.Phase == projectPhaseError {
+			return false
+		}
+		project.Status.Phase = journeyPhaseReconciling
+		project.Status.UpdatedAt = time.Now().UTC()
+		project.Status.LastOpID = parent.ID
+		project.Status.LastOpKind = string(parent.Kind)
+		project.Status.Message = message
+		return true
+	})
+}
+
+// opUpdatesProjectStatus reports whether kind's progress shows on the
+// project. A runtime upgrade trial says nothing about what the project
+// runs, so a failed trial build must not put the project in Error; nor
+// does repointing its webhook hooks.
+func opUpdatesProjectStatus(kind OperationKind) bool {
+	return kind != OpRuntimeUpgrade && kind != OpWebhookRefresh
+}
+
 func finalizeProjectStatusBestEffort(
 	ctx context.Context,
 	store *Store,
@@ -152,26 +221,46 @@ func finalizeProjectStatusBestEffort(
 	status string,
 	errMsg string,
 ) {
-	p, err := store.GetProject(ctx, projectID)
-	if err != nil {
-		return
-	}
-
-	switch {
-	case kind == OpDelete && status == opStatusRunning:
-		p.Status.Phase = projectPhaseDel
-	case status == opStatusError:
-		p.Status.Phase = projectPhaseError
-		p.Status.Message = errMsg
-	case status == opStatusDone:
-		if kind != OpDelete {
+	_, _ = store.updateProject(ctx, projectID, func(p *Project) bool {
+		switch {
+		case kind == OpDelete && status == opStatusRunning:
+			p.Status.Phase = projectPhaseDel
+		case status == opStatusError:
+			p.Status.Phase = projectPhaseError
+			p.Status.Message = errMsg
+		case status == opStatusDone:
+			if kind != OpDelete {
+				p.Status.Phase = projectPhaseReady
+				p.Status.Message = "ready"
+			}
+		case status == opStatusCancelled:
 			p.Status.Phase = projectPhaseReady
-			p.Status.Message = "ready"
+			p.Status.Message = string(kind) + " " + opMessageCancel
 		}
-	}
 
-	p.Status.UpdatedAt = time.Now().UTC()
-	p.Status.LastOpID = opID
-	p.Status.LastOpKind = string(kind)
-	_ = store.PutProject(ctx, p)
+		p.Status.UpdatedAt = time.Now().UTC()
+		p.Status.LastOpID = opID
+		p.Status.LastOpKind = string(kind)
+		return true
+	})
+}
+
+// amendLastOpStep applies edit to the newest step recorded for worker (or one
+// of its sub-steps), for details that are only known once the step closed.
+func amendLastOpStep(
+	ctx context.Context,
+	store *Store,
+	opID, worker string,
+	edit func(step *OpStep),
+) error {
+	_, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		for i := len(op.Steps) - 1; i >= 0; i-- {
+			if workerStepMatchesDelivery(op.Steps[i].Worker, worker) {
+				edit(&op.Steps[i])
+				return true
+			}
+		}
+		return false
+	})
+	return err
 }
@@ -0,0 +1,164 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Operation cancellation: the API marks the op cancelled in KV, then tells
+// workers on the op's cancel subject. A worker running the op cancels its
+// delivery context; a worker that picks the op up later sees the KV status
+// and passes it down the chain without running.
+////////////////////////////////////////////////////////////////////////////////
+
+// opCancelledError is the cause of a delivery context cancelled on request.
+type opCancelledError struct {
+	OpID   string
+	Reason string
+}
+
+func (e opCancelledError) Error() string {
+	if e.Reason == "" {
+		return opMessageCancel
+	}
+	return opMessageCancel + ": " + e.Reason
+}
+
+// cancelOp marks opID cancelled. Open steps are closed with the
+// cancellation, and finalizing the op frees its project for the next one.
+func cancelOp(ctx context.Context, store *Store, opID, reason string) (Operation, error) {
+	cause := opCancelledError{OpID: opID, Reason: reason}
+	op, err := store.GetOp(ctx, opID)
+	if err != nil {
+		return Operation{}, err
+	}
+	if err = closeOpenOpSteps(ctx, store, opID, cause.Error()); err != nil {
+		return Operation{}, err
+	}
+	if err = finalizeOp(ctx, store, op.ID, op.ProjectID, op.Kind, opStatusCancelled, cause.Error()); err != nil {
+		return Operation{}, err
+	}
+	return store.GetOp(ctx, opID)
+}
+
+// closeOpenOpSteps ends every unfinished step (and sub-step) of opID with
+// errText. The op's status is left to the caller.
+func closeOpenOpSteps(ctx context.Context, store *Store, opID, errText string) error {
+	now := time.Now().UTC()
+	var closed []int
+	op, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		closed = []int{}
+		for i := range op.Steps {
+			step := &op.Steps[i]
+			if !step.EndedAt.IsZero() {
+				continue
+			}
+			step.EndedAt = now
+			step.Error = errText
+			for j := range step.SubSteps {
+				if step.SubSteps[j].EndedAt.IsZero() {
+					step.SubSteps[j].EndedAt = now
+					step.SubSteps[j].Error = errText
+				}
+			}
+			closed = append(closed, i)
+		}
+		return len(closed) > 0
+	})
+	if err != nil || len(closed) == 0 {
+		return err
+	}
+	for _, i := range closed {
+		step := op.Steps[i]
+		emitOpStepEnded(store.opEvents, op, step.Worker, i+1, step.Message, errText, step.Artifacts, step.StartedAt, now)
+	}
+	return nil
+}
+
+func opCancelSubject(opID string) string {
+	return natsSubject(subjectOpCancelPrefix + strings.TrimSpace(opID))
+}
+
+func publishOpCancel(nc *nats.Conn, op Operation, reason string) error {
+	body, err := json.Marshal(OpCancelMsg{
+		OpID:      op.ID,
+		ProjectID: op.ProjectID,
+		Reason:    reason,
+		At:        time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return nc.Publish(opCancelSubject(op.ID), body)
+}
+
+// watchOpCancellation derives a context that is cancelled, with an
+// opCancelledError cause, once opID is cancelled. The KV status is checked
+// after subscribing so a cancel sent just before the delivery started is not
+// missed. The returned stop func must be called when the delivery ends.
+func watchOpCancellation(
+	ctx context.Context,
+	nc *nats.Conn,
+	store *Store,
+	opID string,
+) (context.Context, func()) {
+	watchCtx, cancel := context.WithCancelCause(ctx)
+	var sub *nats.Subscription
+	if nc != nil {
+		sub, _ = nc.Subscribe(opCancelSubject(opID), func(m *nats.Msg) {
+			var msg OpCancelMsg
+			_ = json.Unmarshal(m.Data, &msg)
+			store.forgetOp(opID)
+			cancel(opCancelledError{OpID: opID, Reason: msg.Reason})
+		})
+	}
+	if op, cancelled := opCancelledInStore(ctx, store, opID); cancelled {
+		cancel(opCancelledErrorFor(op))
+	}
+	return watchCtx, func() {
+		if sub != nil {
+			_ = sub.Unsubscribe()
+		}
+		cancel(nil)
+	}
+}
+
+func opCancelledInStore(ctx context.Context, store *Store, opID string) (Operation, bool) {
+	if store == nil {
+		return Operation{}, false
+	}
+	op, err := store.GetOp(ctx, opID)
+	if err != nil {
+		return Operation{}, false
+	}
+	return op, op.Status == opStatusCancelled
+}
+
+// opCancelledErrorFor recovers the cancellation of an op already marked
+// cancelled; its Error holds the text of the original opCancelledError.
+func opCancelledErrorFor(op Operation) opCancelledError {
+	reason := strings.TrimPrefix(strings.TrimPrefix(op.Error, opMessageCancel), ": ")
+	return opCancelledError{OpID: op.ID, Reason: reason}
+}
+
+// opCancelCause returns the cancellation behind ctx, or nil while the op may
+// keep running. Long steps call it between sub-steps.
+func opCancelCause(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return context.Cause(ctx)
+}
+
+// cancelledWorkerResult is what a worker publishes for a cancelled op so the
+// rest of the chain skips it and final-result waiters wake up.
+func cancelledWorkerResult(opMsg ProjectOpMsg, workerName string, cause error) WorkerResultMsg {
+	res := skipWorkerResult(opMsg, workerName)
+	res.Err = cause.Error()
+	return res
+}
@@ -0,0 +1,194 @@
+package platform
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Step heartbeats: a running worker refreshes its open step on an interval so
+// a slow step is distinguishable from a hung one. Workers can also mark named
+// sub-steps, which are written to the open step as they start and end.
+////////////////////////////////////////////////////////////////////////////////
+
+const opSubStepsMax = 32
+
+type stepProgressKey struct{}
+
+// stepProgress is the latest progress a worker action reported for its step.
+// writeMu serializes the op writes made on its behalf: heartbeats and
+// sub-step boundaries both read-modify-write the op, and an unserialized
+// heartbeat could put back a sub-step list older than the one just written.
+type stepProgress struct {
+	mu      sync.Mutex
+	message string
+	percent int
+
+	writeMu sync.Mutex
+	store   *Store
+	opID    string
+}
+
+func withStepProgress(ctx context.Context, store *Store, opID string) (context.Context, *stepProgress) {
+	progress := &stepProgress{
+		mu:      sync.Mutex{},
+		message: "",
+		percent: 0,
+		writeMu: sync.Mutex{},
+		store:   store,
+		opID:    opID,
+	}
+	return context.WithValue(ctx, stepProgressKey{}, progress), progress
+}
+
+// reportStepProgress records progress for the next heartbeat. percent is
+// clamped to 0..100, where 0 means unknown. It is a no-op outside a worker
+// delivery. Traced deliveries also log each report as a trace entry.
+func reportStepProgress(ctx context.Context, message string, percent int) {
+	traceProgress(ctx, strings.TrimSpace(message))
+	progress, ok := ctx.Value(stepProgressKey{}).(*stepProgress)
+	if !ok {
+		return
+	}
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	progress.message = strings.TrimSpace(message)
+	progress.percent = min(max(percent, 0), opProgressMax)
+}
+
+// beginSubStep opens a named sub-step of the running step; call the returned
+// func with the sub-step's error (or nil) when it finishes. Both ends are
+// written to the op and streamed as step.substep. A step keeps its first
+// opSubStepsMax sub-steps; traced deliveries record every one regardless.
+func beginSubStep(ctx context.Context, name string) func(error) {
+	traceDone := traceSubStep(ctx, name)
+	logOpLine(ctx, "--> "+name)
+	progress, ok := ctx.Value(stepProgressKey{}).(*stepProgress)
+	if !ok || progress.store == nil {
+		return traceDone
+	}
+	name = strings.TrimSpace(name)
+	startedAt := time.Now().UTC()
+	_ = progress.markSubStep(ctx, OpSubStep{Name: name, StartedAt: startedAt, EndedAt: time.Time{}, Error: ""})
+	return func(err error) {
+		traceDone(err)
+		ended := OpSubStep{Name: name, StartedAt: startedAt, EndedAt: time.Now().UTC(), Error: ""}
+		if err != nil {
+			ended.Error = err.Error()
+		}
+		_ = progress.markSubStep(ctx, ended)
+	}
+}
+
+func (p *stepProgress) snapshot() (string, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.message, p.percent
+}
+
+// markSubStep appends a starting sub-step to the open step, or closes the
+// matching running one when subStep has an end time.
+func (p *stepProgress) markSubStep(ctx context.Context, subStep OpSubStep) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	recorded := false
+	op, step, stepIndex, err := updateOpenStep(ctx, p.store, p.opID, func(step *OpStep) {
+		if subStep.EndedAt.IsZero() {
+			if len(step.SubSteps) < opSubStepsMax {
+				step.SubSteps = append(step.SubSteps, subStep)
+				recorded = true
+			}
+			return
+		}
+		for i := len(step.SubSteps) - 1; i >= 0; i-- {
+			open := &step.SubSteps[i]
+			if open.Name == subStep.Name && open.EndedAt.IsZero() {
+				open.EndedAt = subStep.EndedAt
+				open.Error = subStep.Error
+				recorded = true
+				return
+			}
+		}
+	})
+	if err != nil || !recorded {
+		return err
+	}
+	emitOpSubStep(p.store.opEvents, op, step.Worker, stepIndex, subStep)
+	return nil
+}
+
+// runStepHeartbeats refreshes the op's open step until ctx is cancelled.
+func runStepHeartbeats(ctx context.Context, progress *stepProgress) {
+	ticker := time.NewTicker(opStepHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		message, percent := progress.snapshot()
+		progress.writeMu.Lock()
+		_ = markOpStepHeartbeat(ctx, progress.store, progress.opID, time.Now().UTC(), message, percent)
+		progress.writeMu.Unlock()
+	}
+}
+
+func markOpStepHeartbeat(
+	ctx context.Context,
+	store *Store,
+	opID string,
+	at time.Time,
+	message string,
+	percent int,
+) error {
+	op, step, stepIndex, err := updateOpenStep(ctx, store, opID, func(step *OpStep) {
+		step.HeartbeatAt = at
+		if message != "" {
+			step.Progress = message
+		}
+		if percent > 0 {
+			step.Percent = percent
+		}
+	})
+	if err != nil || stepIndex == 0 {
+		return err
+	}
+	emitOpStepHeartbeat(store.opEvents, op, step, stepIndex)
+	return nil
+}
+
+// updateOpenStep applies update to the op's newest unfinished step and saves
+// the op. stepIndex is 1-based and 0 when no step is open, in which case
+// nothing is written.
+func updateOpenStep(
+	ctx context.Context,
+	store *Store,
+	opID string,
+	update func(step *OpStep),
+) (Operation, OpStep, int, error) {
+	stepIndex := 0
+	op, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		stepIndex = 0
+		for i := len(op.Steps) - 1; i >= 0; i-- {
+			if op.Steps[i].EndedAt.IsZero() {
+				stepIndex = i + 1
+				break
+			}
+		}
+		if stepIndex == 0 {
+			return false
+		}
+		update(&op.Steps[stepIndex-1])
+		return true
+	})
+	if err != nil {
+		return Operation{}, OpStep{}, 0, err
+	}
+	if stepIndex == 0 {
+		return op, OpStep{}, 0, nil
+	}
+	return op, op.Steps[stepIndex-1], stepIndex, nil
+}
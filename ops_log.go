@@ -0,0 +1,254 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Op logs: every worker delivery appends the commands its git and build steps
+// ran, with what they printed, to logs/<op>.log in the project's artifacts.
+// Build output is appended while the build runs, so GET /api/ops/{id}/logs
+// can follow a step that has not ended yet.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	opLogArtifactRoot   = "logs"
+	opLogMaxBytes       = 4 << 20
+	opLogFollowInterval = 500 * time.Millisecond
+)
+
+type opLogKey struct{}
+
+// opLog appends to one op's log. streaming is set while a command's output
+// is being written as it runs, so the result logged when the command ends
+// does not repeat it.
+type opLog struct {
+	mu        sync.Mutex
+	artifacts ArtifactStore
+	projectID string
+	relPath   string
+	size      int64
+	truncated bool
+	streaming bool
+}
+
+func opLogRelPath(opID string) string {
+	return opLogArtifactRoot + "/" + opID + ".log"
+}
+
+// withOpLog opens the delivery's section of the op log. A real delete has
+// none: it removes the artifact tree the log would be written to.
+func withOpLog(
+	ctx context.Context,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	workerName string,
+	attempt uint64,
+) context.Context {
+	if artifacts == nil || msg.ProjectID == "" || (msg.Kind == OpDelete && !msg.Execution.DryRun) {
+		return ctx
+	}
+	log := &opLog{
+		mu:        sync.Mutex{},
+		artifacts: artifacts,
+		projectID: msg.ProjectID,
+		relPath:   opLogRelPath(msg.OpID),
+		size:      0,
+		truncated: false,
+		streaming: false,
+	}
+	full := filepath.Join(artifacts.ProjectDir(msg.ProjectID), filepath.FromSlash(log.relPath))
+	if info, err := os.Stat(full); err == nil {
+		log.size = info.Size()
+	}
+	log.append(fmt.Appendf(nil, "==> %s %s attempt %d\n", time.Now().UTC().Format(time.RFC3339), workerName, attempt))
+	return context.WithValue(ctx, opLogKey{}, log)
+}
+
+func opLogFromContext(ctx context.Context) (*opLog, bool) {
+	log, ok := ctx.Value(opLogKey{}).(*opLog)
+	return log, ok
+}
+
+// opLogStream logs command as started and returns a writer for its output.
+// Outside a worker delivery the output is discarded.
+func opLogStream(ctx context.Context, command string) io.Writer {
+	log, ok := opLogFromContext(ctx)
+	if !ok {
+		return io.Discard
+	}
+	log.append([]byte("$ " + command + "\n"))
+	log.mu.Lock()
+	log.streaming = true
+	log.mu.Unlock()
+	return log
+}
+
+// logOpCommand appends a finished command: its line and transcript, or only
+// its error when opLogStream already wrote the output.
+func logOpCommand(ctx context.Context, command, transcript string, err error) {
+	log, ok := opLogFromContext(ctx)
+	if !ok {
+		return
+	}
+	log.mu.Lock()
+	streamed := log.streaming
+	log.streaming = false
+	log.mu.Unlock()
+	var entry strings.Builder
+	if !streamed {
+		entry.WriteString("$ " + strings.TrimSpace(command) + "\n")
+		if transcript = strings.TrimSpace(transcript); transcript != "" {
+			entry.WriteString(transcript + "\n")
+		}
+	}
+	if err != nil {
+		entry.WriteString("error: " + err.Error() + "\n")
+	}
+	log.append([]byte(entry.String()))
+}
+
+func logOpLine(ctx context.Context, line string) {
+	if log, ok := opLogFromContext(ctx); ok && strings.TrimSpace(line) != "" {
+		log.append([]byte(strings.TrimSpace(line) + "\n"))
+	}
+}
+
+// Write appends streamed command output. A log that cannot be written never
+// fails the command, so Write always reports success.
+func (l *opLog) Write(p []byte) (int, error) {
+	l.append(p)
+	return len(p), nil
+}
+
+// append writes p to the log, replacing whatever would take it past
+// opLogMaxBytes with one truncation notice.
+func (l *opLog) append(p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.truncated || len(p) == 0 {
+		return
+	}
+	if l.size+int64(len(p)) > opLogMaxBytes {
+		l.truncated = true
+		p = fmt.Appendf(nil, "\n[log truncated at %d bytes]\n", opLogMaxBytes)
+	}
+	if _, err := l.artifacts.AppendFile(l.projectID, l.relPath, p); err == nil {
+		l.size += int64(len(p))
+	}
+}
+
+// handleOpLogs serves an op's log as text, from ?offset= bytes in. With
+// ?follow=1 the response stays open and gets what the log gains until the op
+// ends.
+func (a *API) handleOpLogs(w http.ResponseWriter, r *http.Request, opID string) {
+	if a.store == nil || a.artifacts == nil {
+		writeAPIError(w, "operation logs unavailable", http.StatusInternalServerError)
+		return
+	}
+	op, err := a.store.GetOp(r.Context(), opID)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			writeAPIError(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeAPIError(w, "failed to read op", http.StatusInternalServerError)
+		return
+	}
+	follow, offset, msg := parseOpLogsQuery(r)
+	if msg != "" {
+		writeAPIError(w, msg, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if !follow {
+		chunk, _ := readOpLogFrom(a.artifacts, op, offset)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(chunk)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	a.followOpLog(r.Context(), w, flusher, op, offset)
+}
+
+func parseOpLogsQuery(r *http.Request) (bool, int64, string) {
+	query := r.URL.Query()
+	follow := false
+	if raw := strings.TrimSpace(query.Get("follow")); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, 0, "follow must be true or false"
+		}
+		follow = value
+	}
+	var offset int64
+	if raw := strings.TrimSpace(query.Get("offset")); raw != "" {
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || value < 0 {
+			return false, 0, "offset must be a non-negative byte count"
+		}
+		offset = value
+	}
+	return follow, offset, ""
+}
+
+// followOpLog writes what the log gains until the op has ended and the rest
+// of its log is written, or the client goes away. The op is read before the
+// log, so output written just before the op ended is not missed.
+func (a *API) followOpLog(ctx context.Context, w io.Writer, flusher http.Flusher, op Operation, offset int64) {
+	ticker := time.NewTicker(opLogFollowInterval)
+	defer ticker.Stop()
+	for {
+		ended := isOperationStatusTerminal(op.Status)
+		chunk, next := readOpLogFrom(a.artifacts, op, offset)
+		if len(chunk) > 0 {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			flusher.Flush()
+			offset = next
+		}
+		if ended {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if latest, err := a.store.GetOp(ctx, op.ID); err == nil {
+			op = latest
+		}
+	}
+}
+
+// readOpLogFrom returns the op's log from offset on and the offset after it.
+// An op that has logged nothing yet has an empty log.
+func readOpLogFrom(artifacts ArtifactStore, op Operation, offset int64) ([]byte, int64) {
+	data, err := artifacts.ReadFile(op.ProjectID, opLogRelPath(op.ID))
+	if err != nil || offset >= int64(len(data)) {
+		return nil, offset
+	}
+	return data[offset:], int64(len(data))
+}
@@ -0,0 +1,141 @@
+//nolint:testpackage,exhaustruct // Op log tests drive the unexported log context and image builder.
+package platform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpLog_RecordsCommandsAndStreamsBuildOutput(t *testing.T) {
+	fakePack := filepath.Join(t.TempDir(), "pack")
+	script := "#!/bin/sh\necho '===> DETECTING'\necho 'paketo-buildpacks/go-build 4.1.2'\n" +
+		"echo 'ERROR: failed to export' >&2\nexit 1\n"
+	if err := os.WriteFile(fakePack, []byte(script), 0o700); err != nil {
+		t.Fatalf("write fake pack: %v", err)
+	}
+	t.Setenv(packBinaryEnv, fakePack)
+	t.Setenv(imageBuilderModeEnv, string(imageBuilderModeArtifact))
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := normalizeProjectSpec(ProjectSpec{
+		Name: "log-app", Runtime: "go_1.26",
+		Build:        BuildConfig{Strategy: buildStrategyBuildpacks},
+		Environments: map[string]EnvConfig{"dev": {}},
+	})
+	msg := ProjectOpMsg{OpID: "op-log", Kind: OpCI, ProjectID: "project-log", Spec: spec}
+	ctx := withOpLog(context.Background(), artifacts, msg, "imageBuilder", 2)
+	if _, err := runImageBuilderBuild(ctx, artifacts, msg, spec, "local/log-app:tag"); err == nil {
+		t.Fatal("expected the fake pack build to fail")
+	}
+	repoDir := filepath.Join(t.TempDir(), "source")
+	if err := ensureLocalGitRepo(ctx, repoDir); err != nil {
+		t.Fatalf("init repo: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package main\n"), 0o600); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if _, err := gitCommitIfChanged(ctx, repoDir, "add main"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	raw, err := artifacts.ReadFile(msg.ProjectID, opLogRelPath(msg.OpID))
+	if err != nil {
+		t.Fatalf("read op log: %v", err)
+	}
+	log := string(raw)
+	for _, want := range []string{
+		"imageBuilder attempt 2\n",
+		"--> build image with buildpacks\n",
+		"$ " + fakePack + " build local/log-app:tag --path ",
+		"===> DETECTING\npaketo-buildpacks/go-build 4.1.2\nERROR: failed to export\n" +
+			"error: pack build local/log-app:tag",
+		`$ git -C repos/source commit -m "add main"`,
+	} {
+		if !strings.Contains(log, want) {
+			t.Fatalf("expected %q in the op log:\n%s", want, log)
+		}
+	}
+	if strings.Count(log, "===> DETECTING") != 1 {
+		t.Fatalf("expected streamed build output logged once:\n%s", log)
+	}
+
+	deleteMsg := ProjectOpMsg{OpID: "op-log-delete", Kind: OpDelete, ProjectID: "project-log"}
+	if _, ok := opLogFromContext(withOpLog(context.Background(), artifacts, deleteMsg, "registrar", 1)); ok {
+		t.Fatal("expected no op log for a delete that removes the artifact tree")
+	}
+	full, ok := opLogFromContext(ctx)
+	if !ok {
+		t.Fatal("expected the op log in the delivery context")
+	}
+	full.size = opLogMaxBytes - 4
+	logOpLine(ctx, "past the limit")
+	logOpLine(ctx, "dropped")
+	raw, _ = artifacts.ReadFile(msg.ProjectID, opLogRelPath(msg.OpID))
+	if !strings.HasSuffix(string(raw), "[log truncated at 4194304 bytes]\n") {
+		t.Fatalf("expected one truncation notice at the end, got %q", raw[len(raw)-64:])
+	}
+}
+
+func TestAPI_OpLogsFollowUntilTheOpEnds(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID, opID := "project-op-logs", "op-op-logs"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCI, workerRuntimeSpec("op-logs"))
+	artifacts := NewFSArtifacts(t.TempDir())
+	if _, err := artifacts.AppendFile(projectID, opLogRelPath(opID), []byte("==> imageBuilder\n")); err != nil {
+		t.Fatalf("seed log: %v", err)
+	}
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	get := func(query string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/api/ops/" + opID + "/logs" + query)
+		if err != nil {
+			t.Fatalf("get logs: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := get(""); status != http.StatusOK || body != "==> imageBuilder\n" {
+		t.Fatalf("expected the log so far, got %d %q", status, body)
+	}
+	if status, body := get("?offset=4"); status != http.StatusOK || body != "imageBuilder\n" {
+		t.Fatalf("expected the log from the offset, got %d %q", status, body)
+	}
+	if status, _ := get("?follow=maybe"); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed follow flag, got %d", status)
+	}
+
+	go func() {
+		time.Sleep(2 * opLogFollowInterval)
+		_, _ = artifacts.AppendFile(projectID, opLogRelPath(opID), []byte("$ pack build\nStep 1/2\n"))
+		time.Sleep(2 * opLogFollowInterval)
+		_, _ = artifacts.AppendFile(projectID, opLogRelPath(opID), []byte("Successfully built\n"))
+		_ = finalizeOp(ctx, fixture.store, opID, projectID, OpCI, opStatusDone, "")
+	}()
+	status, body := get("?follow=1")
+	if status != http.StatusOK || body != "==> imageBuilder\n$ pack build\nStep 1/2\nSuccessfully built\n" {
+		t.Fatalf("expected the followed log to end with the op, got %d %q", status, body)
+	}
+
+	resp, err := http.Get(srv.URL + "/api/ops/op-missing/logs")
+	if err != nil {
+		t.Fatalf("get missing op logs: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown op, got %d", resp.StatusCode)
+	}
+}
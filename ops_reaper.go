@@ -0,0 +1,150 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Op reaper: the leader fails ops that stay queued or running with no sign
+// of life (step start, end, or heartbeat, or a child op's for a parent op)
+// for longer than op_stale_ttl. A worker that crashed mid-step otherwise
+// leaves its op active, and the project refuses every later operation as a
+// conflict.
+////////////////////////////////////////////////////////////////////////////////
+
+const opStaleReasonPrefix = "stale:"
+
+type opReapReport struct {
+	ScannedOps int
+	ReapedOps  int
+}
+
+// runOpReaper reaps stale ops every opReapInterval while this replica
+// holds the background-jobs lease.
+func runOpReaper(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	ttl time.Duration,
+	reaperLog sourceLogger,
+) {
+	ticker := time.NewTicker(opReapInterval)
+	defer ticker.Stop()
+	for {
+		report, err := reapStaleOps(ctx, store, artifacts, time.Now().UTC(), ttl, reaperLog)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			reaperLog.Warnf("op reaper failed: %v", err)
+		case report.ReapedOps > 0:
+			reaperLog.Infof("op reaper: scanned_ops=%d reaped_ops=%d", report.ScannedOps, report.ReapedOps)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reapStaleOps fails every queued or running op last alive before now-ttl
+// (see opLivenessAt). Interrupted ops are left alone; they resume on
+// restart.
+func reapStaleOps(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	now time.Time,
+	ttl time.Duration,
+	reaperLog sourceLogger,
+) (opReapReport, error) {
+	var report opReapReport
+	ops, err := store.listAllOps(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, op := range ops {
+		status := strings.TrimSpace(op.Status)
+		if status != statusMessageQueued && status != opStatusRunning {
+			continue
+		}
+		report.ScannedOps++
+		lastUpdate := opLivenessAt(ctx, store, op)
+		if !lastUpdate.Before(now.Add(-ttl)) {
+			continue
+		}
+		reason := staleOpReason(status, now.Sub(lastUpdate).Truncate(time.Second))
+		reaperLog.Warnf("op=%s kind=%s project=%s: %s", op.ID, op.Kind, op.ProjectID, reason)
+		if err = closeOpenOpSteps(ctx, store, op.ID, reason); err != nil {
+			return report, fmt.Errorf("close steps of op %s: %w", op.ID, err)
+		}
+		var opMsg ProjectOpMsg
+		opMsg.OpID = op.ID
+		markWorkerDeliveryFailure(ctx, store, artifacts, opMsg, reason, reaperLog)
+		report.ReapedOps++
+	}
+	return report, nil
+}
+
+// opLivenessAt is op's most recent sign of life. A parent op (a var rollout
+// or promotion fan-out) writes nothing to its own record while a child runs
+// or while it waits out the pause between stages, so its children's
+// updates count too, and a rollout between stages stays alive until its
+// pause is over.
+func opLivenessAt(ctx context.Context, store *Store, op Operation) time.Time {
+	last := opLastUpdateAt(op)
+	for _, childID := range parentOpChildIDs(op) {
+		if child, err := store.GetOp(ctx, childID); err == nil && opLastUpdateAt(child).After(last) {
+			last = opLastUpdateAt(child)
+		}
+	}
+	if op.Rollout != nil && op.Rollout.PauseSeconds > 0 && len(op.Steps) > 0 {
+		if ended := op.Steps[len(op.Steps)-1].EndedAt; !ended.IsZero() {
+			resumesAt := ended.UTC().Add(time.Duration(op.Rollout.PauseSeconds) * time.Second)
+			if resumesAt.After(last) {
+				last = resumesAt
+			}
+		}
+	}
+	return last
+}
+
+// parentOpChildIDs lists the child ops a parent op has started so far.
+func parentOpChildIDs(op Operation) []string {
+	ids := []string{}
+	if op.Rollout != nil {
+		for _, stage := range op.Rollout.Stages {
+			if stage.OpID != "" {
+				ids = append(ids, stage.OpID)
+			}
+		}
+	}
+	if op.Fanout != nil {
+		for _, target := range op.Fanout.Targets {
+			if target.OpID != "" {
+				ids = append(ids, target.OpID)
+			}
+		}
+	}
+	return ids
+}
+
+func staleOpReason(status string, idle time.Duration) string {
+	return fmt.Sprintf("%s op was %s with no progress for %s; its worker likely stopped. Re-run the operation",
+		opStaleReasonPrefix, status, idle)
+}
+
+// opReapedInStore reports whether the reaper failed opID, so a message for
+// it that turns up later is passed down the chain instead of run.
+func opReapedInStore(ctx context.Context, store *Store, opID string) (Operation, bool) {
+	if store == nil {
+		return Operation{}, false
+	}
+	op, err := store.GetOp(ctx, opID)
+	if err != nil {
+		return Operation{}, false
+	}
+	return op, op.Status == opStatusError && strings.HasPrefix(op.Error, opStaleReasonPrefix)
+}
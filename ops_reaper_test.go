@@ -0,0 +1,146 @@
+//nolint:testpackage,exhaustruct // Reaper tests seed ops in KV and call the internal reaper pass directly.
+package platform
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpReaper_FailsStaleOpsAndReleasesTheProject(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	artifacts := NewFSArtifacts(t.TempDir())
+	log := appLoggerForProcess().Source("reaper-test")
+	now := time.Now().UTC()
+
+	spec := workerRuntimeSpec("reaper")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-stale", "op-stale", OpCI, spec)
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-busy", "op-busy", OpCI, spec)
+	seed := func(opID string, stepAt time.Time) {
+		t.Helper()
+		op, err := fixture.store.GetOp(ctx, opID)
+		if err != nil {
+			t.Fatalf("get op: %v", err)
+		}
+		op.Status = opStatusRunning
+		op.Requested = now.Add(-2 * time.Hour)
+		op.Steps = []OpStep{{Worker: "imageBuilder", StartedAt: stepAt, Message: "build image"}}
+		if err = fixture.store.PutOp(ctx, op); err != nil {
+			t.Fatalf("put op: %v", err)
+		}
+		finalizeProjectStatusBestEffort(ctx, fixture.store, opID, op.ProjectID, op.Kind, opStatusRunning, "")
+	}
+	seed("op-stale", now.Add(-time.Hour))
+	// Started long ago, but its worker is still heartbeating.
+	seed("op-busy", now.Add(-time.Hour))
+	if err := amendLastOpStep(ctx, fixture.store, "op-busy", "imageBuilder", func(step *OpStep) {
+		step.HeartbeatAt = now.Add(-time.Minute)
+	}); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+
+	api := &API{store: fixture.store}
+	if api.projectOperationConflict(ctx, "project-stale", OpDeploy) == nil {
+		t.Fatal("expected the stale op to block the project before reaping")
+	}
+	report, err := reapStaleOps(ctx, fixture.store, artifacts, now, 30*time.Minute, log)
+	if err != nil || report.ReapedOps != 1 || report.ScannedOps != 2 {
+		t.Fatalf("expected one of two ops reaped, got %+v (%v)", report, err)
+	}
+
+	op, _ := fixture.store.GetOp(ctx, "op-stale")
+	if op.Status != opStatusError || !strings.HasPrefix(op.Error, opStaleReasonPrefix) ||
+		op.Steps[0].EndedAt.IsZero() || op.Steps[0].Error != op.Error {
+		t.Fatalf("expected the stale op failed with its step closed, got %+v", op)
+	}
+	if conflict := api.projectOperationConflict(ctx, "project-stale", OpDeploy); conflict != nil {
+		t.Fatalf("expected the project free after reaping, got %v", conflict)
+	}
+	if busy, _ := fixture.store.GetOp(ctx, "op-busy"); busy.Status != opStatusRunning {
+		t.Fatalf("expected a heartbeating op left running, got %s", busy.Status)
+	}
+
+	// A message for the reaped op that turns up later is not run.
+	notRun := func(context.Context, *Store, ArtifactStore, ProjectOpMsg) (WorkerResultMsg, error) {
+		t.Fatal("worker ran an op the reaper already failed")
+		return newWorkerResultMsg(""), nil
+	}
+	data := workerPayload(t, "op-stale", OpCI, "project-stale", spec)
+	decision := handleWorkerDelivery(ctx, fixture.store, artifacts,
+		"imageBuilder", natsSubject(subjectBootstrapDone), natsSubject(subjectBuildDone),
+		notRun, fixture.js, data, 1, log, publishWorkerResult, publishWorkerPoison)
+	if decision.action != workerDeliveryAck {
+		t.Fatalf("expected the late delivery skipped and acked, got %+v", decision)
+	}
+}
+
+func TestOpReaper_CountsParentOpPausesAndChildren(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	artifacts := NewFSArtifacts(t.TempDir())
+	log := appLoggerForProcess().Source("reaper-test")
+	now := time.Now().UTC()
+	ttl := 30 * time.Minute
+
+	spec := workerRuntimeSpec("reaper-parents")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-rollout", "op-rollout", OpVarRollout, spec)
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-fanout", "op-fanout", OpPromoteFanout, spec)
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-fanout", "op-fanout-child", OpPromote, spec)
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-paused-out", "op-paused-out", OpVarRollout, spec)
+	seed := func(opID string, edit func(op *Operation)) {
+		t.Helper()
+		op, err := fixture.store.GetOp(ctx, opID)
+		if err != nil {
+			t.Fatalf("get op: %v", err)
+		}
+		op.Status = opStatusRunning
+		op.Requested = now.Add(-3 * time.Hour)
+		edit(&op)
+		if err = fixture.store.PutOp(ctx, op); err != nil {
+			t.Fatalf("put op: %v", err)
+		}
+	}
+	// Stage dev ended 40 minutes ago; the hour-long pause before prod is
+	// longer than the TTL and still running.
+	pausing := func(endedAt time.Time) func(op *Operation) {
+		return func(op *Operation) {
+			op.Rollout = &VarRollout{PauseSeconds: varRolloutMaxPauseSeconds, Stages: []VarRolloutStage{
+				{Environment: "dev", OpKind: OpDeploy, Status: opStatusDone},
+				{Environment: "prod", OpKind: OpPromote, Status: promotionFanoutTargetPending},
+			}}
+			op.Steps = []OpStep{{
+				Worker: varRolloutStepPrefix + "dev", StartedAt: endedAt.Add(-time.Minute), EndedAt: endedAt,
+			}}
+		}
+	}
+	seed("op-rollout", pausing(now.Add(-40*time.Minute)))
+	seed("op-paused-out", pausing(now.Add(-2*time.Hour)))
+	// The fan-out's own step started an hour ago; its child is heartbeating.
+	seed("op-fanout", func(op *Operation) {
+		op.Fanout = &PromotionFanout{FromEnv: "dev", Targets: []PromotionFanoutTarget{
+			{Environment: "staging", OpKind: OpPromote, OpID: "op-fanout-child", Status: opStatusRunning},
+		}}
+		op.Steps = []OpStep{{Worker: "fanout", StartedAt: now.Add(-time.Hour)}}
+	})
+	seed("op-fanout-child", func(op *Operation) {
+		op.ParentOpID = "op-fanout"
+		op.Steps = []OpStep{{Worker: "promoter", StartedAt: now.Add(-time.Hour), HeartbeatAt: now.Add(-time.Minute)}}
+	})
+
+	report, err := reapStaleOps(ctx, fixture.store, artifacts, now, ttl, log)
+	if err != nil || report.ReapedOps != 1 {
+		t.Fatalf("expected only the rollout past its pause reaped, got %+v (%v)", report, err)
+	}
+	for _, opID := range []string{"op-rollout", "op-fanout", "op-fanout-child"} {
+		if op, _ := fixture.store.GetOp(ctx, opID); op.Status != opStatusRunning {
+			t.Errorf("expected %s left running, got %s (%s)", opID, op.Status, op.Error)
+		}
+	}
+	if op, _ := fixture.store.GetOp(ctx, "op-paused-out"); op.Status != opStatusError {
+		t.Fatalf("expected a rollout quiet past its pause and the TTL reaped, got %s", op.Status)
+	}
+}
@@ -0,0 +1,169 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Op scheduler: a singleton job that queues the ops of due project schedules
+// (see api_schedules.go) through enqueueOp, so they pass the same conflict,
+// freeze, and read-only checks as ops an API call starts. Runs missed while no
+// replica led are made up once, not once per missed time.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	schedulerPollInterval = 30 * time.Second
+	scheduleRunQueued     = "queued"
+	scheduleRunSkipped    = "skipped"
+	scheduleRunError      = "error"
+)
+
+func startOpScheduler(ctx context.Context, api *API, elector *leaderElector) {
+	schedulerLog := appLoggerForProcess().Source("scheduler")
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		runOpScheduler(jobCtx, api, schedulerLog)
+	})
+}
+
+func runOpScheduler(ctx context.Context, api *API, schedulerLog sourceLogger) {
+	ticker := time.NewTicker(schedulerPollInterval)
+	defer ticker.Stop()
+	for {
+		if err := api.runDueSchedules(ctx, time.Now().UTC(), schedulerLog); err != nil && ctx.Err() == nil {
+			schedulerLog.Warnf("schedule scan failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDueSchedules fires every enabled schedule whose next run is at or
+// before now.
+func (a *API) runDueSchedules(ctx context.Context, now time.Time, schedulerLog sourceLogger) error {
+	projectIDs, err := a.store.listScheduledProjects(ctx)
+	if err != nil {
+		return err
+	}
+	for _, projectID := range projectIDs {
+		schedules, readErr := a.store.getProjectSchedules(ctx, projectID)
+		if readErr != nil {
+			schedulerLog.Warnf("read schedules project=%s: %v", projectID, readErr)
+			continue
+		}
+		for _, schedule := range schedules.Schedules {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if scheduleDue(schedule, now) {
+				a.fireSchedule(ctx, schedule, now, schedulerLog)
+			}
+		}
+	}
+	return nil
+}
+
+func scheduleDue(schedule OpSchedule, now time.Time) bool {
+	return schedule.Enabled && !schedule.NextRunAt.IsZero() && !schedule.NextRunAt.After(now)
+}
+
+// fireSchedule first moves the schedule's next run past now, so a replica
+// that takes over leadership mid-run does not fire it again, then queues its
+// op and records how that went.
+func (a *API) fireSchedule(ctx context.Context, schedule OpSchedule, now time.Time, schedulerLog sourceLogger) {
+	claimed := false
+	_, err := a.store.updateProjectSchedules(ctx, schedule.ProjectID, func(schedules *projectSchedules) error {
+		i, found := schedules.find(schedule.ID)
+		claimed = found && scheduleDue(schedules.Schedules[i], now) &&
+			schedules.Schedules[i].NextRunAt.Equal(schedule.NextRunAt)
+		if !claimed {
+			return nil
+		}
+		// A cron that no longer parses leaves the schedule idle; its last
+		// run says why.
+		next, _ := nextScheduleRun(schedules.Schedules[i], now)
+		schedules.Schedules[i].NextRunAt = next
+		return nil
+	})
+	if err != nil || !claimed {
+		if err != nil {
+			schedulerLog.Warnf("claim schedule=%s project=%s: %v", schedule.ID, schedule.ProjectID, err)
+		}
+		return
+	}
+
+	run := a.runSchedule(ctx, schedule, now)
+	schedulerLog.Infof(
+		"schedule=%s project=%s kind=%s status=%s op=%s %s",
+		schedule.ID, schedule.ProjectID, schedule.Kind, run.Status, run.OpID, run.Message,
+	)
+	_, err = a.store.updateProjectSchedules(ctx, schedule.ProjectID, func(schedules *projectSchedules) error {
+		if i, found := schedules.find(schedule.ID); found {
+			schedules.Schedules[i].LastRun = &run
+		}
+		return nil
+	})
+	if err != nil {
+		schedulerLog.Warnf("record schedule=%s run: %v", schedule.ID, err)
+	}
+}
+
+// runSchedule queues schedule's op against the project as it is now. A
+// project busy with another op, a frozen environment, or read-only mode skips
+// the run rather than failing it.
+func (a *API) runSchedule(ctx context.Context, schedule OpSchedule, now time.Time) ScheduleRun {
+	run := ScheduleRun{At: now, Status: scheduleRunQueued, OpID: "", Message: ""}
+	op, err := a.enqueueScheduledOp(ctx, schedule)
+	var (
+		conflictErr projectOpConflictError
+		frozenErr   environmentFrozenError
+		readOnlyErr readOnlyError
+	)
+	switch {
+	case err == nil:
+		run.OpID = op.ID
+	case errors.As(err, &readOnlyErr):
+		run.Status = scheduleRunSkipped
+		run.Message = "read-only mode is on"
+		if readOnlyErr.State.Message != "" {
+			run.Message += ": " + readOnlyErr.State.Message
+		}
+	case errors.As(err, &conflictErr), errors.As(err, &frozenErr):
+		run.Status = scheduleRunSkipped
+		run.Message = err.Error()
+	default:
+		run.Status = scheduleRunError
+		run.Message = err.Error()
+	}
+	if _, cronErr := nextScheduleRun(schedule, now); cronErr != nil && run.Message == "" {
+		run.Message = "schedule stopped: " + cronErr.Error()
+	}
+	return run
+}
+
+func (a *API) enqueueScheduledOp(ctx context.Context, schedule OpSchedule) (Operation, error) {
+	project, _, err := a.store.getProjectRevision(ctx, schedule.ProjectID)
+	if err != nil {
+		return Operation{}, fmt.Errorf("read project: %w", err)
+	}
+	spec := normalizeProjectSpec(project.Spec)
+	opts, err := scheduleOpRunOptions(spec, schedule, project.Revision)
+	if err != nil {
+		return Operation{}, err
+	}
+	if schedule.Kind == OpPromote {
+		check, checkErr := a.checkTransitionSourceBudget(project.ID, spec, opts.fromEnv)
+		if checkErr != nil {
+			return Operation{}, checkErr
+		}
+		if len(check.exceeded) > 0 {
+			return Operation{}, vulnerabilityBudgetError{ProjectID: project.ID, Check: check}
+		}
+	}
+	return a.enqueueOp(ctx, schedule.Kind, project.ID, spec, opts)
+}
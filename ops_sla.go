@@ -0,0 +1,274 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Op SLAs: the leader checks queued and running ops against op_sla_queued
+// and op_sla_running. A breach is recorded once per op and state, emits
+// op.sla_breached, and shows on the op as sla_breached. Breaches live in one
+// record of their own because workers rewrite op records from a cached copy
+// and would drop a flag written into them.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	opSLAStateQueued  = "queued"
+	opSLAStateRunning = "running"
+	// opSLARecordMax bounds the record; the ops that breached longest ago
+	// are dropped first.
+	opSLARecordMax       = 500
+	opSLAWriteAttempts   = 5
+	opSLABreachedMessage = "%s for %s, over the %s SLA"
+)
+
+// opSLAThresholds are the configured SLAs; 0 turns one off.
+type opSLAThresholds struct {
+	Queued  time.Duration
+	Running time.Duration
+}
+
+type opSLARecord struct {
+	Ops       map[string][]OpSLABreach `json:"ops"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+type opSLAReport struct {
+	ScannedOps int
+	Breached   int
+}
+
+func (t opSLAThresholds) enabled() bool {
+	return t.Queued > 0 || t.Running > 0
+}
+
+func opSLAThresholdsFromConfig(cfg runtimeConfig) opSLAThresholds {
+	return opSLAThresholds{Queued: cfg.OpSLAQueued, Running: cfg.OpSLARunning}
+}
+
+// getOpSLABreaches returns every recorded breach by op ID, and the
+// record's revision, which is 0 while no op has breached.
+func (s *Store) getOpSLABreaches(ctx context.Context) (map[string][]OpSLABreach, kvRevision, error) {
+	defer s.observe("getOpSLABreaches", time.Now())
+	record, rev, err := s.readOpSLARecord(ctx)
+	return record.Ops, rev, err
+}
+
+// addOpSLABreaches records breaches (op ID to new breaches) and returns
+// the ones that were not recorded yet. Writes are revision-checked.
+func (s *Store) addOpSLABreaches(
+	ctx context.Context,
+	breaches map[string][]OpSLABreach,
+) (map[string][]OpSLABreach, error) {
+	defer s.observe("addOpSLABreaches", time.Now())
+	var err error
+	for range opSLAWriteAttempts {
+		var record opSLARecord
+		var rev kvRevision
+		if record, rev, err = s.readOpSLARecord(ctx); err != nil {
+			return nil, err
+		}
+		added := map[string][]OpSLABreach{}
+		for opID, opBreaches := range breaches {
+			for _, breach := range opBreaches {
+				if opSLABreachRecorded(record.Ops[opID], breach.State) {
+					continue
+				}
+				record.Ops[opID] = append(record.Ops[opID], breach)
+				added[opID] = append(added[opID], breach)
+			}
+		}
+		if len(added) == 0 {
+			return added, nil
+		}
+		pruneOpSLARecord(&record)
+		record.UpdatedAt = time.Now().UTC()
+		body, marshalErr := json.Marshal(record)
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+		if rev.Revision == 0 {
+			_, err = s.kvOps.Create(ctx, kvOpSLAKey, body)
+		} else {
+			_, err = s.kvOps.Update(ctx, kvOpSLAKey, body, rev.Revision)
+		}
+		if err == nil {
+			return added, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("op SLA record kept changing: %w", err)
+}
+
+func (s *Store) readOpSLARecord(ctx context.Context) (opSLARecord, kvRevision, error) {
+	record := opSLARecord{Ops: map[string][]OpSLABreach{}, UpdatedAt: time.Time{}}
+	entry, err := s.kvOps.Get(ctx, kvOpSLAKey)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return record, kvRevision{Key: kvOpSLAKey, Revision: 0, Modified: time.Time{}}, nil
+		}
+		return record, kvRevision{}, err
+	}
+	if err = json.Unmarshal(entry.Value(), &record); err != nil {
+		return record, kvRevision{}, err
+	}
+	if record.Ops == nil {
+		record.Ops = map[string][]OpSLABreach{}
+	}
+	return record, entryRevision(entry), nil
+}
+
+func opSLABreachRecorded(breaches []OpSLABreach, state string) bool {
+	return slices.ContainsFunc(breaches, func(breach OpSLABreach) bool { return breach.State == state })
+}
+
+func pruneOpSLARecord(record *opSLARecord) {
+	if len(record.Ops) <= opSLARecordMax {
+		return
+	}
+	ids := sortedKeys(record.Ops)
+	slices.SortStableFunc(ids, func(a, b string) int {
+		return record.Ops[a][0].At.Compare(record.Ops[b][0].At)
+	})
+	for _, opID := range ids[:len(ids)-opSLARecordMax] {
+		delete(record.Ops, opID)
+	}
+}
+
+// withOpSLA sets op's SLA fields from breaches.
+func withOpSLA(op Operation, breaches map[string][]OpSLABreach) Operation {
+	op.SLABreaches = breaches[op.ID]
+	op.SLABreached = len(op.SLABreaches) > 0
+	return op
+}
+
+// startOpSLAChecker runs the checker as a singleton job unless both SLAs
+// are off.
+func startOpSLAChecker(ctx context.Context, store *Store, elector *leaderElector, thresholds opSLAThresholds) {
+	if !thresholds.enabled() {
+		return
+	}
+	slaLog := appLoggerForProcess().Source("opSLA")
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		runOpSLAChecker(jobCtx, store, thresholds, slaLog)
+	})
+}
+
+// runOpSLAChecker checks op SLAs every opSLACheckInterval while this
+// replica leads.
+func runOpSLAChecker(ctx context.Context, store *Store, thresholds opSLAThresholds, slaLog sourceLogger) {
+	ticker := time.NewTicker(opSLACheckInterval)
+	defer ticker.Stop()
+	for {
+		report, err := checkOpSLAs(ctx, store, time.Now().UTC(), thresholds, slaLog)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slaLog.Warnf("op SLA check failed: %v", err)
+		case report.Breached > 0:
+			slaLog.Infof("op SLA check: scanned_ops=%d breached=%d", report.ScannedOps, report.Breached)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkOpSLAs records a breach for every queued or running op that has
+// been in that state longer than its SLA, once per op and state.
+func checkOpSLAs(
+	ctx context.Context,
+	store *Store,
+	now time.Time,
+	thresholds opSLAThresholds,
+	slaLog sourceLogger,
+) (opSLAReport, error) {
+	var report opSLAReport
+	ops, err := store.listAllOps(ctx)
+	if err != nil {
+		return report, err
+	}
+	byID := map[string]Operation{}
+	found := map[string][]OpSLABreach{}
+	for _, op := range ops {
+		if isOperationStatusActive(op.Status) {
+			report.ScannedOps++
+		}
+		breach, breached := opSLABreachAt(op, now, thresholds)
+		if !breached {
+			continue
+		}
+		byID[op.ID] = op
+		found[op.ID] = []OpSLABreach{breach}
+	}
+	if len(found) == 0 {
+		return report, nil
+	}
+	added, err := store.addOpSLABreaches(ctx, found)
+	if err != nil {
+		return report, err
+	}
+	for _, opID := range sortedKeys(added) {
+		op := byID[opID]
+		for _, breach := range added[opID] {
+			slaLog.Warnf("op=%s kind=%s project=%s: "+opSLABreachedMessage,
+				op.ID, op.Kind, op.ProjectID, breach.State, breach.Elapsed, breach.Threshold)
+			emitOpSLABreached(store.opEvents, op, breach)
+			report.Breached++
+		}
+	}
+	return report, nil
+}
+
+// opSLABreachAt reports whether op is past the SLA of the state it is in.
+// A var rollout's parent runs through its stages' pauses, and a fan-out
+// parent waits on its targets, so only the child ops are held to the
+// running SLA.
+func opSLABreachAt(op Operation, now time.Time, thresholds opSLAThresholds) (OpSLABreach, bool) {
+	var state string
+	var since time.Time
+	var threshold time.Duration
+	switch strings.TrimSpace(op.Status) {
+	case statusMessageQueued:
+		state, since, threshold = opSLAStateQueued, op.Requested, thresholds.Queued
+	case opStatusRunning:
+		if op.Kind == OpVarRollout || op.Kind == OpPromoteFanout {
+			return OpSLABreach{}, false
+		}
+		state, since, threshold = opSLAStateRunning, opRunningSince(op), thresholds.Running
+	default:
+		return OpSLABreach{}, false
+	}
+	elapsed := now.Sub(since)
+	if threshold <= 0 || since.IsZero() || elapsed <= threshold {
+		return OpSLABreach{}, false
+	}
+	return OpSLABreach{
+		State:     state,
+		Threshold: threshold.String(),
+		Elapsed:   elapsed.Truncate(time.Second).String(),
+		At:        now,
+	}, true
+}
+
+// opRunningSince is when op's first step started, or its request time when
+// no step has yet.
+func opRunningSince(op Operation) time.Time {
+	for _, step := range op.Steps {
+		if !step.StartedAt.IsZero() {
+			return step.StartedAt
+		}
+	}
+	return op.Requested
+}
@@ -0,0 +1,105 @@
+//nolint:testpackage,exhaustruct // SLA tests seed ops in KV and call the internal checker pass directly.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOpSLAChecker_FlagsOverdueOpsOnce(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	hub := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
+	fixture.store.setOpEvents(hub)
+	slaLog := appLoggerForProcess().Source("sla-test")
+	now := time.Now().UTC()
+	thresholds := opSLAThresholds{Queued: 2 * time.Minute, Running: 15 * time.Minute}
+
+	spec := workerRuntimeSpec("sla")
+	seed := func(opID, status string, requested, stepAt time.Time) {
+		t.Helper()
+		putWorkerRuntimeProjectAndOp(t, fixture.store, "project-"+opID, opID, OpCI, spec)
+		op, err := fixture.store.GetOp(ctx, opID)
+		if err != nil {
+			t.Fatalf("get op: %v", err)
+		}
+		op.Status = status
+		op.Requested = requested
+		if !stepAt.IsZero() {
+			op.Steps = []OpStep{{Worker: "imageBuilder", StartedAt: stepAt, Message: "build image"}}
+		}
+		if err = fixture.store.PutOp(ctx, op); err != nil {
+			t.Fatalf("put op: %v", err)
+		}
+	}
+	seed("op-sla-queued", statusMessageQueued, now.Add(-5*time.Minute), time.Time{})
+	seed("op-sla-fresh", statusMessageQueued, now.Add(-time.Minute), time.Time{})
+	// Requested long ago, but running only since its step started.
+	seed("op-sla-running", opStatusRunning, now.Add(-time.Hour), now.Add(-10*time.Minute))
+	seed("op-sla-done", opStatusDone, now.Add(-time.Hour), now.Add(-time.Hour))
+	_, events, _, unsubscribe := hub.subscribe("op-sla-queued", "")
+	defer unsubscribe()
+
+	report, err := checkOpSLAs(ctx, fixture.store, now, thresholds, slaLog)
+	if err != nil || report.ScannedOps != 3 || report.Breached != 1 {
+		t.Fatalf("expected one of three active ops breached, got %+v (%v)", report, err)
+	}
+	select {
+	case record := <-events:
+		if record.Name != opEventSLA || record.Payload.SLABreach == nil ||
+			record.Payload.SLABreach.State != opSLAStateQueued {
+			t.Fatalf("expected an op.sla_breached event for the queued SLA, got %+v", record)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an op.sla_breached event")
+	}
+	if report, err = checkOpSLAs(ctx, fixture.store, now.Add(time.Minute), thresholds, slaLog); err != nil ||
+		report.Breached != 0 {
+		t.Fatalf("expected a breach to be recorded once, got %+v (%v)", report, err)
+	}
+	// With the queued SLA off, only the running op is past its SLA.
+	thresholds.Queued = 0
+	if report, err = checkOpSLAs(ctx, fixture.store, now.Add(6*time.Minute), thresholds, slaLog); err != nil ||
+		report.Breached != 1 {
+		t.Fatalf("expected the running op to breach once past its SLA, got %+v (%v)", report, err)
+	}
+
+	srv := httptest.NewServer((&API{store: fixture.store, nc: fixture.nc, opEvents: hub}).routes())
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/api/ops/op-sla-running")
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	var op Operation
+	err = json.NewDecoder(resp.Body).Decode(&op)
+	resp.Body.Close()
+	if err != nil || !op.SLABreached || len(op.SLABreaches) != 1 || op.SLABreaches[0].State != opSLAStateRunning ||
+		op.SLABreaches[0].Threshold != "15m0s" {
+		t.Fatalf("expected the op to carry its running breach, got %+v (%v)", op.SLABreaches, err)
+	}
+
+	resp, err = srv.Client().Get(srv.URL + "/api/ops?kind=ci")
+	if err != nil {
+		t.Fatalf("list ops: %v", err)
+	}
+	var list projectOpsListResponse
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode ops list: %v", err)
+	}
+	flagged := map[string]bool{}
+	for _, item := range list.Items {
+		flagged[item.ID] = item.SLABreached
+	}
+	want := map[string]bool{"op-sla-queued": true, "op-sla-running": true, "op-sla-fresh": false, "op-sla-done": false}
+	for opID, breached := range want {
+		if flagged[opID] != breached {
+			t.Fatalf("expected %s sla_breached=%v in the ops list, got %v", opID, breached, flagged)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Execution traces: with OpExecution.Trace set, a worker collects timed
+// sub-steps and command transcripts while it runs and the loop stores them as
+// one artifact per worker.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	opTraceKindSubStep  = "substep"
+	opTraceKindProgress = "progress"
+	opTraceKindCommand  = "command"
+
+	opTraceArtifactRoot    = "traces"
+	opTraceTranscriptLimit = 16 * 1024
+)
+
+type opTraceKey struct{}
+
+type opTraceEntry struct {
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	At         time.Time `json:"at"`
+	OffsetMS   int64     `json:"offset_ms"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+	Transcript string    `json:"transcript,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// opTrace collects entries for one worker delivery.
+type opTrace struct {
+	mu      sync.Mutex
+	started time.Time
+	entries []opTraceEntry
+}
+
+type opTraceArtifact struct {
+	OpID      string         `json:"op_id"`
+	Kind      OperationKind  `json:"kind"`
+	Worker    string         `json:"worker"`
+	DryRun    bool           `json:"dry_run,omitempty"`
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   time.Time      `json:"ended_at"`
+	Entries   []opTraceEntry `json:"entries"`
+}
+
+func withOpTrace(ctx context.Context) (context.Context, *opTrace) {
+	trace := &opTrace{mu: sync.Mutex{}, started: time.Now().UTC(), entries: nil}
+	return context.WithValue(ctx, opTraceKey{}, trace), trace
+}
+
+func opTraceFromContext(ctx context.Context) (*opTrace, bool) {
+	trace, ok := ctx.Value(opTraceKey{}).(*opTrace)
+	return trace, ok
+}
+
+// traceSubStep opens a timed sub-step; call the returned func with the
+// sub-step's error (or nil) when it finishes. Outside a traced delivery both
+// calls are no-ops.
+func traceSubStep(ctx context.Context, name string) func(error) {
+	trace, ok := opTraceFromContext(ctx)
+	if !ok {
+		return func(error) {}
+	}
+	startedAt := time.Now().UTC()
+	return func(err error) {
+		entry := trace.newEntry(opTraceKindSubStep, name, startedAt)
+		entry.DurationMS = time.Since(startedAt).Milliseconds()
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		trace.add(entry)
+	}
+}
+
+// traceCommand records what an external or library command was asked to do
+// and what it answered, truncated to opTraceTranscriptLimit. The op log gets
+// the same, in full, on every delivery.
+func traceCommand(ctx context.Context, command string, transcript string, err error) {
+	logOpCommand(ctx, command, transcript, err)
+	trace, ok := opTraceFromContext(ctx)
+	if !ok {
+		return
+	}
+	entry := trace.newEntry(opTraceKindCommand, command, time.Now().UTC())
+	entry.Transcript = truncatedPayload([]byte(strings.TrimSpace(transcript)), opTraceTranscriptLimit)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	trace.add(entry)
+}
+
+func traceProgress(ctx context.Context, message string) {
+	trace, ok := opTraceFromContext(ctx)
+	if !ok || message == "" {
+		return
+	}
+	trace.add(trace.newEntry(opTraceKindProgress, message, time.Now().UTC()))
+}
+
+func (t *opTrace) newEntry(kind, name string, at time.Time) opTraceEntry {
+	return opTraceEntry{
+		Kind:       kind,
+		Name:       strings.TrimSpace(name),
+		At:         at,
+		OffsetMS:   at.Sub(t.started).Milliseconds(),
+		DurationMS: 0,
+		Transcript: "",
+		Error:      "",
+	}
+}
+
+func (t *opTrace) add(entry opTraceEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, entry)
+}
+
+func (t *opTrace) artifact(msg ProjectOpMsg, workerName string) opTraceArtifact {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return opTraceArtifact{
+		OpID:      msg.OpID,
+		Kind:      msg.Kind,
+		Worker:    workerName,
+		DryRun:    msg.Execution.DryRun,
+		StartedAt: t.started,
+		EndedAt:   time.Now().UTC(),
+		Entries:   append([]opTraceEntry{}, t.entries...),
+	}
+}
+
+// writeOpTraceArtifact stores the trace under traces/<op>/<worker>.json. A
+// real delete removes the project's artifact tree, so its traces go to
+// _audit beside the other delete records instead and no path is returned.
+func writeOpTraceArtifact(
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	workerName string,
+	trace *opTrace,
+) (string, error) {
+	body, err := json.MarshalIndent(trace.artifact(msg, workerName), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if msg.Kind == OpDelete && !msg.Execution.DryRun {
+		auditDir := filepath.Join(filepath.Dir(artifacts.ProjectDir(msg.ProjectID)), "_audit")
+		if err = os.MkdirAll(auditDir, dirModePrivateRead); err != nil {
+			return "", err
+		}
+		name := fmt.Sprintf("%s.%s.%s.trace.json", msg.ProjectID, msg.OpID, workerName)
+		return "", os.WriteFile(filepath.Join(auditDir, name), body, fileModePrivate)
+	}
+	relPath := fmt.Sprintf("%s/%s/%s.json", opTraceArtifactRoot, msg.OpID, workerName)
+	return artifacts.WriteFile(msg.ProjectID, relPath, body)
+}
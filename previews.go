@@ -0,0 +1,479 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Preview environments: with spec.ci.previews.enabled, a push to a branch
+// that is not main and not in ci.branches queues a ci op that builds the
+// branch and renders it as environment preview-<name> under
+// deploy/preview/<name>/, where name is the branch made DNS-safe. Renders
+// stay out of the manifests repo. A preview lives for ci.previews.ttl after
+// its last push; deleting the branch or the TTL passing queues a cleanup op
+// that removes deploy/preview/<name>/, and the record goes once it is done.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	previewArtifactRoot   = "deploy/preview"
+	previewRecordFile     = "preview.json"
+	previewEnvPrefix      = "preview-"
+	previewNameMaxLength  = 40
+	previewDefaultTTL     = 72 * time.Hour
+	previewMinTTL         = time.Hour
+	previewMaxTTL         = 30 * 24 * time.Hour
+	previewsPerProjectMax = 10
+	previewReapInterval   = time.Minute
+
+	previewStatusBuilding    = "building"
+	previewStatusReady       = "ready"
+	previewStatusFailed      = "failed"
+	previewStatusTearingDown = "tearing_down"
+)
+
+func validatePreviewTTL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl < previewMinTTL || ttl > previewMaxTTL {
+		return specFieldErrorf("ci.previews.ttl", "ci.previews.ttl must be a duration from %s to %s",
+			previewMinTTL, previewMaxTTL)
+	}
+	return nil
+}
+
+func previewTTL(policy PreviewPolicy) time.Duration {
+	if ttl, err := time.ParseDuration(policy.TTL); err == nil && ttl > 0 {
+		return ttl
+	}
+	return previewDefaultTTL
+}
+
+// previewName makes branch usable in an environment and namespace name:
+// lower case, with every run of other characters turned into one dash.
+func previewName(branch string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(branch) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := b.String()
+	if len(name) > previewNameMaxLength {
+		name = name[:previewNameMaxLength]
+	}
+	return strings.Trim(name, "-")
+}
+
+func previewEnvironment(name string) string {
+	return previewEnvPrefix + name
+}
+
+func previewArtifactDir(name string) string {
+	return previewArtifactRoot + "/" + name
+}
+
+// previewNameFromArtifactPrefix reports which preview a cleanup prefix
+// removes whole, if any.
+func previewNameFromArtifactPrefix(prefix string) (string, bool) {
+	name, ok := strings.CutPrefix(path.Clean(prefix), previewArtifactRoot+"/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
+
+func isPreviewOp(msg ProjectOpMsg) bool {
+	return msg.Kind == OpCI && msg.CI != nil && msg.CI.Preview != ""
+}
+
+// matchPreviewPush reports whether a webhook event names a branch push that
+// gets a preview. Main and ci.branches keep building the way they do.
+func matchPreviewPush(policy CIPolicy, branch, ref string) (ciPush, bool) {
+	if !policy.Previews.Enabled || strings.HasPrefix(strings.TrimSpace(ref), "refs/tags/") {
+		return ciPush{}, false
+	}
+	name := ciBranchName(branch)
+	if name == "" {
+		name = ciBranchName(ref)
+	}
+	if name == "" || name == branchMain ||
+		slices.ContainsFunc(policy.Branches, func(pattern string) bool { return matchCIPattern(pattern, name) }) {
+		return ciPush{}, false
+	}
+	if len(policy.Previews.Branches) > 0 &&
+		!slices.ContainsFunc(policy.Previews.Branches, func(pattern string) bool { return matchCIPattern(pattern, name) }) {
+		return ciPush{}, false
+	}
+	return ciPush{ref: plumbing.NewBranchReferenceName(name), name: name, tag: false}, true
+}
+
+// triggerPreviewBuild queues a ci op that builds push into its preview and
+// records the preview as building.
+func (a *API) triggerPreviewBuild(
+	ctx context.Context,
+	project Project,
+	push ciPush,
+	commit, trigger string,
+) (sourceRepoWebhookResult, error) {
+	result := sourceRepoWebhookResult{
+		accepted: false, reason: "", project: project.ID, op: nil, commit: commit, trigger: trigger,
+	}
+	name := previewName(push.name)
+	if name == "" {
+		result.reason = "ignored: branch " + push.name + " cannot name a preview"
+		return result, nil
+	}
+
+	a.sourceTriggerMu.Lock()
+	defer a.sourceTriggerMu.Unlock()
+
+	previews, err := a.store.getProjectPreviews(ctx, project.ID)
+	if err != nil {
+		return sourceRepoWebhookResult{}, err
+	}
+	i, found := previews.find(name)
+	switch {
+	case found && previews.Previews[i].Branch != push.name:
+		result.reason = fmt.Sprintf("ignored: preview %s belongs to branch %s", name, previews.Previews[i].Branch)
+		return result, nil
+	case found && commit != "" && previews.Previews[i].Commit == commit &&
+		a.previewStatus(ctx, previews.Previews[i]).Status != previewStatusFailed:
+		result.reason = sourceRepoWebhookCommitIgnoredLabel
+		return result, nil
+	case !found && len(previews.Previews) >= previewsPerProjectMax:
+		result.reason = fmt.Sprintf("ignored: project has %d previews; delete a preview branch first", previewsPerProjectMax)
+		return result, nil
+	}
+
+	opts := emptyOpRunOptions().withProjectRevision(project.Revision)
+	opts.ci = &CIBuild{
+		Ref: push.ref.String(), Commit: commit, Release: "", ReleaseError: "", Preview: name, Environment: "",
+	}
+	op, err := a.enqueueOp(ctx, OpCI, project.ID, project.Spec, opts)
+	if err != nil {
+		return sourceRepoWebhookResult{}, err
+	}
+	now := time.Now().UTC()
+	ttl := previewTTL(normalizeProjectSpec(project.Spec).CI.Previews)
+	if _, err = a.store.updateProjectPreviews(ctx, project.ID, func(p *projectPreviews) error {
+		preview := Preview{
+			Name:        name,
+			Branch:      push.name,
+			Environment: previewEnvironment(name),
+			Path:        previewArtifactDir(name),
+			Status:      previewStatusBuilding,
+			Commit:      commit,
+			Image:       "",
+			OpID:        op.ID,
+			Error:       "",
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+		if j, ok := p.find(name); ok {
+			preview.Image = p.Previews[j].Image
+			preview.CreatedAt = p.Previews[j].CreatedAt
+		}
+		p.put(preview)
+		return nil
+	}); err != nil {
+		appLoggerForProcess().Source("api").Warnf("project=%s op=%s record preview %s: %v", project.ID, op.ID, name, err)
+	}
+	result.accepted = true
+	result.op = &op
+	return result, nil
+}
+
+// triggerPreviewTeardown tears down the preview of a deleted branch. The
+// preview is marked expired first, so the reaper retries a teardown that
+// cannot be queued now.
+func (a *API) triggerPreviewTeardown(
+	ctx context.Context,
+	project Project,
+	evt SourceRepoWebhookEvent,
+	trigger string,
+) (sourceRepoWebhookResult, error) {
+	result := sourceRepoWebhookResult{
+		accepted: false, reason: "", project: project.ID, op: nil, commit: strings.TrimSpace(evt.Commit), trigger: trigger,
+	}
+	branch := ciBranchName(evt.Branch)
+	if branch == "" {
+		branch = ciBranchName(evt.Ref)
+	}
+	var preview Preview
+	found := false
+	if _, err := a.store.updateProjectPreviews(ctx, project.ID, func(p *projectPreviews) error {
+		i, ok := p.find(previewName(branch))
+		if !ok || p.Previews[i].Branch != branch {
+			return errPreviewNotFound
+		}
+		p.Previews[i].ExpiresAt = time.Now().UTC()
+		preview, found = p.Previews[i], true
+		return nil
+	}); err != nil && !errors.Is(err, errPreviewNotFound) {
+		return sourceRepoWebhookResult{}, err
+	}
+	if !found {
+		result.reason = "ignored: deleted branch has no preview"
+		return result, nil
+	}
+	op, err := a.teardownPreview(ctx, project, preview)
+	if err != nil {
+		return sourceRepoWebhookResult{}, err
+	}
+	result.accepted = true
+	result.op = &op
+	return result, nil
+}
+
+var errPreviewNotFound = errors.New("preview not found")
+
+// teardownPreview queues the cleanup op that removes preview's renders.
+func (a *API) teardownPreview(ctx context.Context, project Project, preview Preview) (Operation, error) {
+	op, err := a.enqueueOp(ctx, OpCleanup, project.ID, project.Spec, cleanupOpRunOptions(preview.Path))
+	if err != nil {
+		return Operation{}, err
+	}
+	_, err = a.store.updateProjectPreviews(ctx, project.ID, func(p *projectPreviews) error {
+		i, ok := p.find(preview.Name)
+		if !ok {
+			return errPreviewNotFound
+		}
+		p.Previews[i].Status = previewStatusTearingDown
+		p.Previews[i].OpID = op.ID
+		p.Previews[i].Error = ""
+		p.Previews[i].UpdatedAt = time.Now().UTC()
+		return nil
+	})
+	if errors.Is(err, errPreviewNotFound) {
+		err = nil
+	}
+	return op, err
+}
+
+// previewStatus reports a building or tearing-down preview whose op failed
+// as failed, with the op's error.
+func (a *API) previewStatus(ctx context.Context, preview Preview) Preview {
+	if preview.Status != previewStatusBuilding && preview.Status != previewStatusTearingDown {
+		return preview
+	}
+	op, err := a.store.GetOp(ctx, preview.OpID)
+	if err != nil || (op.Status != opStatusError && op.Status != opStatusCancelled) {
+		return preview
+	}
+	if preview.Status == previewStatusTearingDown {
+		preview.Error = "teardown: " + op.Error
+	} else {
+		preview.Error = op.Error
+	}
+	preview.Status = previewStatusFailed
+	return preview
+}
+
+// listPreviews returns the project's previews with their current status.
+func (a *API) listPreviews(ctx context.Context, projectID string) ([]Preview, error) {
+	previews, err := a.store.getProjectPreviews(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Preview, 0, len(previews.Previews))
+	for _, preview := range previews.Previews {
+		out = append(out, a.previewStatus(ctx, preview))
+	}
+	return out, nil
+}
+
+// previewRenderSpec is spec with env configured like dev. Previews get no
+// Ingress, whose host belongs to the project's own environments.
+func previewRenderSpec(spec ProjectSpec, env string) ProjectSpec {
+	spec = normalizeProjectSpec(spec)
+	spec.Environments = map[string]EnvConfig{env: spec.Environments[defaultDeployEnvironment]}
+	spec.Exposure.Ingress = IngressConfig{Host: "", Path: "", TLS: false}
+	return spec
+}
+
+// runPreviewRender renders a preview build in a scratch manifests tree, so
+// neither the manifests repo nor dev changes, and writes the result under
+// deploy/preview/<name>/. Capability bindings and stored secrets are not
+// carried over: capabilities render in the preview's own namespace.
+func runPreviewRender(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	spec ProjectSpec,
+	imageTag string,
+) (repoBootstrapOutcome, error) {
+	name := msg.CI.Preview
+	env := previewEnvironment(name)
+	dir := previewArtifactDir(name)
+	if previewName(name) != name || !isValidEnvironmentName(env) {
+		return repoBootstrapOutcome{}, fmt.Errorf("invalid preview name %q", name)
+	}
+	scratchDir, err := os.MkdirTemp("", "paas-preview-")
+	if err != nil {
+		return repoBootstrapOutcome{}, err
+	}
+	defer func() { _ = os.RemoveAll(scratchDir) }()
+	scratch := NewFSArtifacts(scratchDir)
+	previewSpec := previewRenderSpec(spec, env)
+
+	subStepDone := beginSubStep(ctx, "write "+env+" overlay")
+	_, err = writeKustomizeRepoFiles(scratch, msg.ProjectID, previewSpec, map[string]string{env: imageTag}, nil, nil, nil)
+	subStepDone(err)
+	if err != nil {
+		return repoBootstrapOutcome{}, err
+	}
+	trace, err := newEnvManifestTrace(ctx, store, artifacts, msg, previewSpec, env)
+	if err != nil {
+		return repoBootstrapOutcome{}, err
+	}
+	subStepDone = beginSubStep(ctx, "render "+env+" manifests")
+	rendered, err := renderEnvironmentManifestsFromRepo(scratch, msg.ProjectID, env, trace)
+	subStepDone(err)
+	if err != nil {
+		return repoBootstrapOutcome{}, err
+	}
+	written, err := writeRenderedEnvArtifacts(artifacts, msg.ProjectID, dir, rendered)
+	if err != nil {
+		return repoBootstrapOutcome{message: "", artifacts: written}, err
+	}
+
+	now := time.Now().UTC()
+	preview := Preview{
+		Name:        name,
+		Branch:      plumbing.ReferenceName(msg.CI.Ref).Short(),
+		Environment: env,
+		Path:        dir,
+		Status:      previewStatusReady,
+		Commit:      msg.CI.Commit,
+		Image:       imageTag,
+		OpID:        msg.OpID,
+		Error:       "",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(previewTTL(normalizeProjectSpec(spec).CI.Previews)),
+	}
+	if store != nil {
+		if _, err = store.updateProjectPreviews(ctx, msg.ProjectID, func(p *projectPreviews) error {
+			if i, ok := p.find(name); ok {
+				preview.CreatedAt = p.Previews[i].CreatedAt
+			}
+			p.put(preview)
+			return nil
+		}); err != nil {
+			return repoBootstrapOutcome{message: "", artifacts: written}, err
+		}
+	}
+	raw, err := json.MarshalIndent(preview, "", "  ")
+	if err != nil {
+		return repoBootstrapOutcome{message: "", artifacts: written}, err
+	}
+	recordPath, err := artifacts.WriteFile(msg.ProjectID, dir+"/"+previewRecordFile, append(raw, '\n'))
+	if err != nil {
+		return repoBootstrapOutcome{message: "", artifacts: written}, err
+	}
+	return repoBootstrapOutcome{
+		message: fmt.Sprintf("rendered preview %s for branch %s until %s",
+			env, preview.Branch, preview.ExpiresAt.Format(time.RFC3339)),
+		artifacts: uniqueSorted(append(written, recordPath)),
+	}, nil
+}
+
+// forgetTornDownPreview drops the record of a preview a cleanup op removed,
+// unless a newer build of the branch was queued since.
+func forgetTornDownPreview(ctx context.Context, store *Store, msg ProjectOpMsg) {
+	name, ok := previewNameFromArtifactPrefix(msg.ArtifactPrefix)
+	if !ok || store == nil {
+		return
+	}
+	_, err := store.updateProjectPreviews(ctx, msg.ProjectID, func(p *projectPreviews) error {
+		if i, found := p.find(name); found && p.Previews[i].OpID == msg.OpID {
+			p.remove(name)
+		}
+		return nil
+	})
+	if err != nil {
+		appLoggerForProcess().Source("previews").Warnf(
+			"project=%s op=%s forget preview %s: %v", msg.ProjectID, msg.OpID, name, err,
+		)
+	}
+}
+
+func startPreviewReaper(ctx context.Context, api *API, elector *leaderElector) {
+	previewLog := appLoggerForProcess().Source("previews")
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		ticker := time.NewTicker(previewReapInterval)
+		defer ticker.Stop()
+		for {
+			if err := api.expirePreviews(jobCtx, time.Now().UTC(), previewLog); err != nil && jobCtx.Err() == nil {
+				previewLog.Warnf("preview scan failed: %v", err)
+			}
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// expirePreviews tears down every preview whose TTL has passed, and retries
+// teardowns that failed. A preview whose project is busy waits for the next
+// pass.
+func (a *API) expirePreviews(ctx context.Context, now time.Time, previewLog sourceLogger) error {
+	projectIDs, err := a.store.listPreviewProjects(ctx)
+	if err != nil {
+		return err
+	}
+	for _, projectID := range projectIDs {
+		previews, readErr := a.listPreviews(ctx, projectID)
+		if readErr != nil {
+			previewLog.Warnf("read previews project=%s: %v", projectID, readErr)
+			continue
+		}
+		project, readErr := a.store.GetProject(ctx, projectID)
+		if readErr != nil {
+			if !errors.Is(readErr, jetstream.ErrKeyNotFound) {
+				previewLog.Warnf("read project=%s: %v", projectID, readErr)
+			}
+			continue
+		}
+		for _, preview := range previews {
+			if ctx.Err() != nil {
+				return nil
+			}
+			failedTeardown := preview.Status == previewStatusFailed && strings.HasPrefix(preview.Error, "teardown: ")
+			if preview.Status == previewStatusTearingDown || (!failedTeardown && preview.ExpiresAt.After(now)) {
+				continue
+			}
+			op, teardownErr := a.teardownPreview(ctx, project, preview)
+			if teardownErr != nil {
+				previewLog.Infof("project=%s preview=%s teardown not queued: %v", projectID, preview.Name, teardownErr)
+				continue
+			}
+			previewLog.Infof("project=%s preview=%s expired; queued teardown op=%s", projectID, preview.Name, op.ID)
+		}
+	}
+	return nil
+}
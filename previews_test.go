@@ -0,0 +1,192 @@
+//nolint:testpackage,exhaustruct // Preview tests drive the unexported webhook, render, and reaper paths.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviewNamesAndPolicy(t *testing.T) {
+	t.Parallel()
+
+	for branch, want := range map[string]string{
+		"feature/Login":  "feature-login",
+		"fix--crash__42": "fix-crash-42",
+		"/-/":            "",
+		"x" + strings.Repeat("y", previewNameMaxLength): "x" + strings.Repeat("y", previewNameMaxLength-1),
+	} {
+		if got := previewName(branch); got != want {
+			t.Errorf("previewName(%q): expected %q, got %q", branch, want, got)
+		}
+	}
+
+	policy := CIPolicy{Branches: []string{"release/*"}, Previews: PreviewPolicy{Enabled: true, Branches: nil, TTL: ""}}
+	for _, tc := range []struct {
+		ref  string
+		want bool
+	}{
+		{ref: "refs/heads/feature/login", want: true},
+		{ref: "refs/heads/main", want: false},
+		{ref: "refs/heads/release/1.0", want: false},
+		{ref: "refs/tags/v1.0.0", want: false},
+	} {
+		if _, got := matchPreviewPush(policy, "", tc.ref); got != tc.want {
+			t.Errorf("matchPreviewPush(%q): expected %v", tc.ref, tc.want)
+		}
+	}
+	policy.Previews.Branches = []string{"feature/*"}
+	if _, ok := matchPreviewPush(policy, "", "refs/heads/spike"); ok {
+		t.Fatal("expected ci.previews.branches to limit which branches get previews")
+	}
+	policy.Previews.Enabled = false
+	if _, ok := matchPreviewPush(policy, "", "refs/heads/feature/login"); ok {
+		t.Fatal("expected no previews while disabled")
+	}
+
+	for ttl, valid := range map[string]bool{"": true, "2h": true, "30m": false, "1000h": false, "soon": false} {
+		if err := validatePreviewTTL(ttl); (err == nil) != valid {
+			t.Errorf("validatePreviewTTL(%q): expected valid=%v, got %v", ttl, valid, err)
+		}
+	}
+	if got := previewTTL(PreviewPolicy{TTL: "6h"}); got != 6*time.Hour {
+		t.Fatalf("expected a 6h ttl, got %s", got)
+	}
+}
+
+func TestAPI_SourceWebhookBuildsAndTearsDownPreviews(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-previews"
+	spec := workerRuntimeSpec("previews")
+	spec.CI = CIPolicy{Previews: PreviewPolicy{Enabled: true, TTL: "2h"}}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-previews-create", OpCreate, spec)
+
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+	post := func(body string) map[string]any {
+		t.Helper()
+		resp, err := http.Post(srv.URL+"/api/webhooks/source", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("post webhook: %v", err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d %v", resp.StatusCode, out)
+		}
+		return out
+	}
+	push := `{"project_id":"` + projectID + `","repo":"source","ref":"refs/heads/feature/login","commit":"c0ffee"}`
+
+	out := post(push)
+	if out["accepted"] != true {
+		t.Fatalf("expected the branch push to build a preview, got %v", out)
+	}
+	ciID, _ := out["op"].(map[string]any)["id"].(string)
+	ciOp, err := fixture.store.GetOp(ctx, ciID)
+	if err != nil || ciOp.Kind != OpCI || ciOp.CI == nil || ciOp.CI.Preview != "feature-login" {
+		t.Fatalf("expected a preview ci op, got %+v (%v)", ciOp, err)
+	}
+	previews, err := api.listPreviews(ctx, projectID)
+	if err != nil || len(previews) != 1 || previews[0].Status != previewStatusBuilding ||
+		previews[0].Environment != "preview-feature-login" || previews[0].Path != "deploy/preview/feature-login" {
+		t.Fatalf("expected a building preview, got %+v (%v)", previews, err)
+	}
+	if out = post(push); out["reason"] != sourceRepoWebhookCommitIgnoredLabel {
+		t.Fatalf("expected a redelivered push to be ignored, got %v", out)
+	}
+	_ = finalizeOp(ctx, fixture.store, ciID, projectID, OpCI, opStatusDone, "")
+
+	deleted := `{"project_id":"` + projectID + `","repo":"source","ref":"refs/heads/%s","deleted":true}`
+	if out = post(fmt.Sprintf(deleted, "spike")); out["accepted"] != false {
+		t.Fatalf("expected a deleted branch without a preview to be ignored, got %v", out)
+	}
+	out = post(fmt.Sprintf(deleted, "feature/login"))
+	if out["accepted"] != true {
+		t.Fatalf("expected the deleted branch to tear its preview down, got %v", out)
+	}
+	cleanupID, _ := out["op"].(map[string]any)["id"].(string)
+	cleanupOp, err := fixture.store.GetOp(ctx, cleanupID)
+	if err != nil || cleanupOp.Kind != OpCleanup {
+		t.Fatalf("expected a cleanup op for the preview, got %+v (%v)", cleanupOp, err)
+	}
+	if previews, _ = api.listPreviews(ctx, projectID); len(previews) != 1 ||
+		previews[0].Status != previewStatusTearingDown || previews[0].OpID != cleanupID {
+		t.Fatalf("expected the preview to be tearing down, got %+v", previews)
+	}
+
+	forgetTornDownPreview(ctx, fixture.store, ProjectOpMsg{
+		OpID: cleanupID, Kind: OpCleanup, ProjectID: projectID, ArtifactPrefix: "deploy/preview/feature-login",
+	})
+	if previews, _ = api.listPreviews(ctx, projectID); len(previews) != 0 {
+		t.Fatalf("expected the torn down preview to be forgotten, got %+v", previews)
+	}
+}
+
+func TestPreviewRenderAndExpiry(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	store := fixture.store
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-preview-render"
+	spec := workerRuntimeSpec("orders")
+	spec.CI = CIPolicy{Previews: PreviewPolicy{Enabled: true, TTL: "1h"}}
+	putWorkerRuntimeProjectAndOp(t, store, projectID, "op-preview-render-create", OpCreate, spec)
+
+	opts := emptyOpRunOptions()
+	opts.ci = &CIBuild{Ref: "refs/heads/feature/login", Commit: "c0ffee", Preview: "feature-login"}
+	msg := newProjectOpMsg("op-preview", OpCI, projectID, spec, opts, time.Now().UTC())
+	outcome, err := runPreviewRender(ctx, store, artifacts, msg, spec, "local/orders:preview")
+	if err != nil ||
+		!strings.HasPrefix(outcome.message, "rendered preview preview-feature-login for branch feature/login until ") {
+		t.Fatalf("render preview: %+v (%v)", outcome, err)
+	}
+	rendered, err := artifacts.ReadFile(projectID, "deploy/preview/feature-login/rendered.yaml")
+	if err != nil || !strings.Contains(string(rendered), "namespace: orders-preview-feature-login") ||
+		!strings.Contains(string(rendered), "image: local/orders:preview") {
+		t.Fatalf("expected the preview rendered in its own namespace, got:\n%s (%v)", rendered, err)
+	}
+	if _, err = artifacts.ReadFile(projectID, "deploy/preview/feature-login/"+previewRecordFile); err != nil {
+		t.Fatalf("expected the preview record beside the render: %v", err)
+	}
+	if _, err = artifacts.ReadFile(projectID, "deploy/dev/rendered.yaml"); err == nil {
+		t.Fatal("expected dev to be left alone by a preview render")
+	}
+
+	api := &API{store: store, nc: fixture.nc, artifacts: artifacts}
+	previews, err := api.listPreviews(ctx, projectID)
+	if err != nil || len(previews) != 1 || previews[0].Status != previewStatusReady ||
+		previews[0].Image != "local/orders:preview" {
+		t.Fatalf("expected a ready preview, got %+v (%v)", previews, err)
+	}
+	previewLog := appLoggerForProcess().Source("previews")
+	if err = api.expirePreviews(ctx, time.Now().UTC(), previewLog); err != nil {
+		t.Fatalf("expire previews: %v", err)
+	}
+	if previews, _ = api.listPreviews(ctx, projectID); previews[0].Status != previewStatusReady {
+		t.Fatalf("expected a live preview to be kept, got %+v", previews)
+	}
+	if err = api.expirePreviews(ctx, time.Now().UTC().Add(2*time.Hour), previewLog); err != nil {
+		t.Fatalf("expire previews: %v", err)
+	}
+	previews, _ = api.listPreviews(ctx, projectID)
+	if len(previews) != 1 || previews[0].Status != previewStatusTearingDown {
+		t.Fatalf("expected the expired preview to be torn down, got %+v", previews)
+	}
+	cleanupOp, err := store.GetOp(ctx, previews[0].OpID)
+	if err != nil || cleanupOp.Kind != OpCleanup {
+		t.Fatalf("expected a cleanup op for the expired preview, got %+v (%v)", cleanupOp, err)
+	}
+}
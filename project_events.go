@@ -0,0 +1,274 @@
+package platform
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Project event streams: every op event, project status change, and release
+// record for one project, multiplexed onto a single ordered sequence so a
+// project page can follow all of its ops over one connection.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	projectEventBootstrap = "project.bootstrap"
+	projectEventStatus    = "project.status"
+	projectEventOwnership = "project.ownership"
+	projectEventDeleted   = "project.deleted"
+	projectEventRelease   = "release.created"
+	projectEventHeartbeat = "project.heartbeat"
+
+	projectEventsBootstrapOps = 20
+)
+
+// projectEventPayload wraps one project event. Type repeats the SSE event
+// name so clients reading the JSON alone can discriminate; exactly one of
+// Op, Release, Status, or Ownership is set for live events, and the
+// bootstrap carries Status plus recent op snapshots in Ops. Status events
+// also carry Ownership when the project has any, so whoever is told about a
+// failure knows who to contact.
+type projectEventPayload struct {
+	EventID   string            `json:"event_id"`
+	Sequence  int64             `json:"sequence"`
+	Type      string            `json:"type"`
+	ProjectID string            `json:"project_id"`
+	At        time.Time         `json:"at"`
+	Op        *opEventPayload   `json:"op,omitempty"`
+	Release   *ReleaseRecord    `json:"release,omitempty"`
+	Status    *ProjectStatus    `json:"status,omitempty"`
+	Ownership *ProjectOwnership `json:"ownership,omitempty"`
+	Ops       []opEventPayload  `json:"ops,omitempty"`
+}
+
+type projectEventRecord struct {
+	Name    string
+	Payload projectEventPayload
+}
+
+type projectEventStream struct {
+	records      []projectEventRecord
+	subscribers  map[uint64]chan projectEventRecord
+	nextSequence int64
+	lastAt       time.Time
+}
+
+func newProjectEventPayload(projectID, eventName string) projectEventPayload {
+	return projectEventPayload{
+		EventID:   "",
+		Sequence:  0,
+		Type:      eventName,
+		ProjectID: strings.TrimSpace(projectID),
+		At:        time.Now().UTC(),
+		Op:        nil,
+		Release:   nil,
+		Status:    nil,
+		Ownership: nil,
+		Ops:       nil,
+	}
+}
+
+// publishProject appends payload to its project's stream under a fresh
+// project sequence and fans it out to that project's subscribers.
+func (h *opEventHub) publishProject(eventName string, payload projectEventPayload) {
+	if h == nil || payload.ProjectID == "" {
+		return
+	}
+
+	now := time.Now().UTC()
+	if payload.At.IsZero() {
+		payload.At = now
+	}
+	payload.Type = eventName
+
+	h.mu.Lock()
+	h.cleanupLocked(now)
+	stream := h.projectStreamForLocked(payload.ProjectID)
+	stream.nextSequence++
+	stream.lastAt = now
+	payload.Sequence = stream.nextSequence
+	payload.EventID = strconv.FormatInt(stream.nextSequence, 10)
+
+	record := projectEventRecord{Name: eventName, Payload: payload}
+	stream.records = append(stream.records, record)
+	if len(stream.records) > h.historyLimit {
+		stream.records = append([]projectEventRecord(nil), stream.records[len(stream.records)-h.historyLimit:]...)
+	}
+
+	subs := make([]chan projectEventRecord, 0, len(stream.subscribers))
+	for _, sub := range stream.subscribers {
+		subs = append(subs, sub)
+	}
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub <- record:
+		default:
+		}
+	}
+}
+
+func (h *opEventHub) subscribeProject(
+	projectID string,
+	lastEventID string,
+) ([]projectEventRecord, <-chan projectEventRecord, bool, func()) {
+	if h == nil {
+		return nil, nil, true, func() {}
+	}
+
+	projectID = strings.TrimSpace(projectID)
+	now := time.Now().UTC()
+
+	h.mu.Lock()
+	h.cleanupLocked(now)
+	stream := h.projectStreamForLocked(projectID)
+
+	ch := make(chan projectEventRecord, opEventSubscriberBuffer)
+	h.nextSubID++
+	subID := h.nextSubID
+	stream.subscribers[subID] = ch
+
+	replay, needsBootstrap := computeEventReplay(stream.records, projectEventSequence, lastEventID)
+
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		streamState, ok := h.projects[projectID]
+		if !ok {
+			return
+		}
+		sub, ok := streamState.subscribers[subID]
+		if !ok {
+			return
+		}
+		delete(streamState.subscribers, subID)
+		close(sub)
+		streamState.lastAt = time.Now().UTC()
+	}
+
+	return replay, ch, needsBootstrap, unsubscribe
+}
+
+func (h *opEventHub) latestProjectSequence(projectID string) int64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stream, ok := h.projects[strings.TrimSpace(projectID)]
+	if !ok {
+		return 0
+	}
+	return stream.nextSequence
+}
+
+func (h *opEventHub) projectStreamForLocked(projectID string) *projectEventStream {
+	stream, ok := h.projects[projectID]
+	if ok {
+		return stream
+	}
+	stream = &projectEventStream{
+		records:      []projectEventRecord{},
+		subscribers:  map[uint64]chan projectEventRecord{},
+		nextSequence: 0,
+		lastAt:       time.Now().UTC(),
+	}
+	h.projects[projectID] = stream
+	return stream
+}
+
+// cleanupProjectsLocked drops idle project streams. Projects never reach a
+// terminal state, so a stream expires once it has had no subscribers and no
+// events for the retention window.
+func (h *opEventHub) cleanupProjectsLocked(now time.Time) {
+	for projectID, stream := range h.projects {
+		if len(stream.subscribers) > 0 {
+			continue
+		}
+		if now.Sub(stream.lastAt) < h.terminalTTL {
+			continue
+		}
+		delete(h.projects, projectID)
+	}
+}
+
+func projectEventSequence(record projectEventRecord) int64 {
+	return record.Payload.Sequence
+}
+
+func newProjectBootstrapSnapshot(project Project, ops []Operation) projectEventPayload {
+	payload := newProjectEventPayload(project.ID, projectEventBootstrap)
+	status := project.Status
+	payload.Status = &status
+	payload.Ops = make([]opEventPayload, 0, len(ops))
+	for _, op := range ops {
+		payload.Ops = append(payload.Ops, newOpBootstrapSnapshot(op))
+	}
+	return payload
+}
+
+func emitProjectOpEvent(h *opEventHub, eventName string, op opEventPayload) {
+	if h == nil {
+		return
+	}
+	payload := newProjectEventPayload(op.ProjectID, eventName)
+	payload.At = op.At
+	payload.Op = &op
+	h.publishProject(eventName, payload)
+}
+
+func emitProjectStatus(h *opEventHub, project Project) {
+	if h == nil {
+		return
+	}
+	payload := newProjectEventPayload(project.ID, projectEventStatus)
+	status := project.Status
+	payload.Status = &status
+	if !project.Ownership.UpdatedAt.IsZero() {
+		ownership := project.Ownership
+		payload.Ownership = &ownership
+	}
+	h.publishProject(projectEventStatus, payload)
+}
+
+func emitProjectOwnership(h *opEventHub, project Project) {
+	if h == nil {
+		return
+	}
+	payload := newProjectEventPayload(project.ID, projectEventOwnership)
+	ownership := project.Ownership
+	payload.Ownership = &ownership
+	h.publishProject(projectEventOwnership, payload)
+}
+
+func emitProjectDeleted(h *opEventHub, projectID string) {
+	if h == nil {
+		return
+	}
+	h.publishProject(projectEventDeleted, newProjectEventPayload(projectID, projectEventDeleted))
+}
+
+func emitProjectRelease(h *opEventHub, release ReleaseRecord) {
+	if h == nil {
+		return
+	}
+	payload := newProjectEventPayload(release.ProjectID, projectEventRelease)
+	payload.At = release.CreatedAt
+	payload.Release = &release
+	h.publishProject(projectEventRelease, payload)
+}
+
+func newProjectHeartbeatPayload(projectID string, sequence int64) projectEventPayload {
+	payload := newProjectEventPayload(projectID, projectEventHeartbeat)
+	if sequence < 0 {
+		sequence = 0
+	}
+	payload.EventID = strconv.FormatInt(sequence, 10)
+	payload.Sequence = sequence
+	return payload
+}
@@ -0,0 +1,123 @@
+package platform
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Worker results published on NATS reach every replica, but step events
+// are only emitted by the replica whose worker ran the step. The relay turns
+// results this process did not emit into step.ended events, so op and
+// project streams served here also show steps that ran elsewhere.
+
+func workerResultSubjects() []string {
+	return []string{
+		natsSubject(subjectRegistrationDone),
+		natsSubject(subjectBootstrapDone),
+		natsSubject(subjectBuildDone),
+		natsSubject(subjectDeployDone),
+		natsSubject(subjectDeploymentDone),
+		natsSubject(subjectPromotionDone),
+		natsSubject(subjectCleanupDone),
+		natsSubject(subjectUpgradeDone),
+	}
+}
+
+// subscribeWorkerResultRelay listens with plain (non-durable) subscriptions:
+// a relay only serves live streams, so results missed while this process
+// was down do not need replaying.
+func subscribeWorkerResultRelay(nc *nats.Conn, hub *opEventHub) (func(), error) {
+	subjects := workerStreamSubjects(workerResultSubjects())
+	subs := make([]*nats.Subscription, 0, len(subjects))
+	stop := func() {
+		for _, sub := range subs {
+			_ = sub.Unsubscribe()
+		}
+	}
+	for _, subject := range subjects {
+		sub, err := nc.Subscribe(subject, func(msg *nats.Msg) {
+			var res WorkerResultMsg
+			if json.Unmarshal(msg.Data, &res) != nil {
+				return
+			}
+			relayWorkerResult(hub, res)
+		})
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return stop, nil
+}
+
+// relayWorkerResult publishes res as step.ended unless this hub already
+// emitted that step's end, which is the case whenever the worker ran here.
+// Upstream-error skips are dropped: no step ran, so none ended.
+func relayWorkerResult(hub *opEventHub, res WorkerResultMsg) {
+	if hub == nil || strings.TrimSpace(res.OpID) == "" || strings.TrimSpace(res.Worker) == "" {
+		return
+	}
+	if res.Message == workerSkippedMessage {
+		return
+	}
+	if hub.hasStepEnded(res.OpID, res.Worker) {
+		return
+	}
+	status := opStatusRunning
+	errText := strings.TrimSpace(res.Err)
+	hint := ""
+	if errText != "" {
+		status = opStatusError
+		hint = opFailureHint(errText)
+	}
+	at := res.At.UTC()
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	hub.publish(opEventEnded, opEventPayload{
+		EventID:         "",
+		Sequence:        0,
+		OpID:            res.OpID,
+		ProjectID:       res.ProjectID,
+		Kind:            res.Kind,
+		Status:          status,
+		At:              at,
+		Worker:          strings.TrimSpace(res.Worker),
+		StepIndex:       0,
+		TotalSteps:      opTotalSteps(res.Kind),
+		ProgressPercent: 0,
+		DurationMS:      0,
+		Message:         strings.TrimSpace(res.Message),
+		Error:           errText,
+		Artifacts:       boundedOpEventArtifacts(res.Artifacts),
+		Delivery: opEventDelivery{
+			Stage:       res.Delivery.Stage,
+			Environment: res.Delivery.Environment,
+			FromEnv:     res.Delivery.FromEnv,
+			ToEnv:       res.Delivery.ToEnv,
+		},
+		Hint:      hint,
+		SubStep:   nil,
+		Note:      nil,
+		SLABreach: nil,
+	})
+}
+
+func (h *opEventHub) hasStepEnded(opID, worker string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stream, ok := h.streams[strings.TrimSpace(opID)]
+	if !ok {
+		return false
+	}
+	for _, record := range stream.records {
+		if record.Name == opEventEnded && workerStepMatchesDelivery(record.Payload.Worker, worker) {
+			return true
+		}
+	}
+	return false
+}
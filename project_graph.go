@@ -0,0 +1,391 @@
+package platform
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Project dependencies: spec.needs names the projects an app calls. Needs
+// resolve by name among the projects of the same org, must exist, and may
+// not form a cycle. A project others need cannot be deleted or renamed.
+// Each environment's overlay of an app with needs or dependents gets a
+// NetworkPolicy admitting traffic between them, and GET /api/graph returns
+// the dependency DAG.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	overlayNetworkPolicyFile = "networkpolicy.yaml"
+	maxProjectNeeds          = 32
+)
+
+// ProjectGraph is the dependency DAG GET /api/graph returns. An edge runs
+// from a project to one it needs, by project ID.
+type ProjectGraph struct {
+	Nodes []ProjectGraphNode `json:"nodes"`
+	Edges []ProjectGraphEdge `json:"edges"`
+}
+
+type ProjectGraphNode struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Phase string   `json:"phase"`
+	Needs []string `json:"needs,omitempty"`
+}
+
+type ProjectGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// projectDependentsError refuses to delete or rename a project other
+// projects need.
+type projectDependentsError struct {
+	ProjectID  string
+	Name       string
+	Dependents []string
+}
+
+func (e projectDependentsError) Error() string {
+	return fmt.Sprintf("project %s is needed by %s; remove it from their needs first",
+		e.Name, strings.Join(e.Dependents, ", "))
+}
+
+func normalizeProjectNeeds(needs []string) []string {
+	var out []string
+	for _, need := range needs {
+		need = strings.TrimSpace(need)
+		if need == "" || slices.Contains(out, need) {
+			continue
+		}
+		out = append(out, need)
+	}
+	return out
+}
+
+// validateProjectNeeds checks the shape of spec.needs. Whether the named
+// projects exist, and whether they form a cycle, depends on the store and
+// is checked by projectNeedsConflict.
+func validateProjectNeeds(spec ProjectSpec) error {
+	if len(spec.Needs) > maxProjectNeeds {
+		return specFieldErrorf("needs", "needs lists %d projects; at most %d are allowed",
+			len(spec.Needs), maxProjectNeeds)
+	}
+	for i, need := range spec.Needs {
+		field := fmt.Sprintf("needs[%d]", i)
+		if len(need) > 63 || !projectNameRe.MatchString(need) {
+			return specFieldErrorf(field, "needs[%d] must be a project name matching %s", i, projectNameRe.String())
+		}
+		if need == spec.Name {
+			return specFieldErrorf(field, "project %s cannot need itself", need)
+		}
+	}
+	return nil
+}
+
+// projectNeedsConflict checks spec's needs against the other projects of
+// org: each must name one, and none may lead back to spec. projectID is
+// empty on create. An update that renames a project others need is
+// refused as well.
+func (a *API) projectNeedsConflict(ctx context.Context, projectID, org string, spec ProjectSpec) error {
+	projects, err := a.store.ListProjects(ctx)
+	if err != nil {
+		return fmt.Errorf("list projects: %w", err)
+	}
+	projects = orgProjects(projects, org)
+	for i, need := range spec.Needs {
+		if !slices.ContainsFunc(projects, func(p Project) bool { return p.ID != projectID && p.Spec.Name == need }) {
+			return specFieldErrorf(fmt.Sprintf("needs[%d]", i), "needs[%d]: no project named %s", i, need)
+		}
+	}
+	if cycle := projectDependencyCycle(projects, projectID, spec); len(cycle) > 0 {
+		return specFieldErrorf("needs", "needs form a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	idx := slices.IndexFunc(projects, func(p Project) bool { return p.ID == projectID })
+	if idx < 0 || projects[idx].Spec.Name == spec.Name {
+		return nil
+	}
+	if dependents := projectDependents(projects, projects[idx]); len(dependents) > 0 {
+		return specFieldErrorf("name", "%s", projectDependentsError{
+			ProjectID:  projectID,
+			Name:       projects[idx].Spec.Name,
+			Dependents: projectNames(dependents),
+		}.Error())
+	}
+	return nil
+}
+
+// projectDependencyCycle returns a path of project names from spec back to
+// itself, following needs with spec in place of projectID's stored spec,
+// or nil when there is none.
+func projectDependencyCycle(projects []Project, projectID string, spec ProjectSpec) []string {
+	needs := map[string][]string{}
+	for _, project := range projects {
+		if project.ID != projectID {
+			needs[project.Spec.Name] = append(needs[project.Spec.Name], project.Spec.Needs...)
+		}
+	}
+	needs[spec.Name] = append(needs[spec.Name], spec.Needs...)
+
+	visited := map[string]bool{}
+	var walk func(name string, trail []string) []string
+	walk = func(name string, trail []string) []string {
+		trail = append(trail, name)
+		for _, next := range needs[name] {
+			if next == spec.Name {
+				return append(trail, next)
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			if cycle := walk(next, trail); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return walk(spec.Name, nil)
+}
+
+// projectDependentsConflict refuses to delete a project others need.
+func (a *API) projectDependentsConflict(ctx context.Context, projectID string, kind OperationKind) error {
+	if kind != OpDelete || a.store == nil {
+		return nil
+	}
+	project, err := a.store.GetProject(ctx, projectID)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read project: %w", err)
+	}
+	dependents, err := a.dependentProjects(ctx, project)
+	if err != nil || len(dependents) == 0 {
+		return err
+	}
+	return projectDependentsError{
+		ProjectID:  projectID,
+		Name:       project.Spec.Name,
+		Dependents: projectNames(dependents),
+	}
+}
+
+func writeProjectHasDependents(w http.ResponseWriter, err error) bool {
+	var dependentsErr projectDependentsError
+	if !errors.As(err, &dependentsErr) {
+		return false
+	}
+	writeErrorDetails(w, http.StatusConflict, errorCodeHasDependents, map[string]any{
+		"accepted":   false,
+		"reason":     dependentsErr.Error(),
+		"project_id": dependentsErr.ProjectID,
+		"dependents": dependentsErr.Dependents,
+		"next_step":  "remove " + dependentsErr.Name + " from the needs of its dependents, then retry",
+	})
+	return true
+}
+
+// dependentProjects lists the projects of project's org that need it.
+func (a *API) dependentProjects(ctx context.Context, project Project) ([]Project, error) {
+	projects, err := a.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	return projectDependents(orgProjects(projects, project.Org), project), nil
+}
+
+// loadProjectDependents names the projects that need projectID, for the
+// render to admit in its NetworkPolicy.
+func loadProjectDependents(ctx context.Context, store *Store, projectID string) ([]string, error) {
+	if store == nil {
+		return nil, nil
+	}
+	project, err := store.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("read project: %w", err)
+	}
+	projects, err := store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	return projectNames(projectDependents(orgProjects(projects, project.Org), project)), nil
+}
+
+func projectDependents(projects []Project, project Project) []Project {
+	var dependents []Project
+	for _, other := range projects {
+		if other.ID != project.ID && slices.Contains(other.Spec.Needs, project.Spec.Name) {
+			dependents = append(dependents, other)
+		}
+	}
+	return dependents
+}
+
+func orgProjects(projects []Project, org string) []Project {
+	return slices.DeleteFunc(slices.Clone(projects), func(p Project) bool { return p.Org != org })
+}
+
+// projectNames returns the sorted, distinct names of projects.
+func projectNames(projects []Project) []string {
+	names := make([]string, 0, len(projects))
+	for _, project := range projects {
+		names = append(names, project.Spec.Name)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+func (a *API) handleGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	projects, err := a.store.ListProjects(r.Context())
+	if err != nil {
+		writeAPIError(w, "failed to list projects", http.StatusInternalServerError)
+		return
+	}
+	if org := principalOrg(r.Context()); org != "" {
+		projects = orgProjects(projects, org)
+	}
+	writeJSON(w, http.StatusOK, buildProjectGraph(projects))
+}
+
+// buildProjectGraph links each project to the projects of its org its needs
+// name. Nodes are sorted by name, then ID; edges by source, then target.
+func buildProjectGraph(projects []Project) ProjectGraph {
+	projects = slices.Clone(projects)
+	slices.SortFunc(projects, func(a, b Project) int {
+		return cmp.Or(strings.Compare(a.Spec.Name, b.Spec.Name), strings.Compare(a.ID, b.ID))
+	})
+	graph := ProjectGraph{Nodes: []ProjectGraphNode{}, Edges: []ProjectGraphEdge{}}
+	for _, project := range projects {
+		graph.Nodes = append(graph.Nodes, ProjectGraphNode{
+			ID:    project.ID,
+			Name:  project.Spec.Name,
+			Phase: project.Status.Phase,
+			Needs: project.Spec.Needs,
+		})
+		for _, need := range project.Spec.Needs {
+			for _, other := range projects {
+				if other.ID != project.ID && other.Org == project.Org && other.Spec.Name == need {
+					graph.Edges = append(graph.Edges, ProjectGraphEdge{From: project.ID, To: other.ID})
+				}
+			}
+		}
+	}
+	return graph
+}
+
+// hasDependencyPolicy reports whether the overlays get a NetworkPolicy.
+func hasDependencyPolicy(spec ProjectSpec, dependents []string) bool {
+	return len(spec.Needs) > 0 || len(dependents) > 0
+}
+
+// renderNetworkPolicyManifest renders env's NetworkPolicy for an app with
+// needs or dependents, matching peers by app label in their env namespace.
+// A policy that selects a pod isolates it, so an internal ingress or egress
+// setting keeps admitting the whole cluster; with none, only the peers, and
+// DNS and the pods of capability plugins for egress, get through.
+func renderNetworkPolicyManifest(spec ProjectSpec, env string, dependents []string) string {
+	spec = normalizeProjectSpec(spec)
+	name := safeName(spec.Name)
+	var b strings.Builder
+	b.WriteString("apiVersion: networking.k8s.io/v1\n")
+	b.WriteString("kind: NetworkPolicy\n")
+	b.WriteString("metadata:\n")
+	fmt.Fprintf(&b, "  name: %s-dependencies\n", name)
+	b.WriteString("  labels:\n")
+	fmt.Fprintf(&b, "    app: %s\n", name)
+	b.WriteString("spec:\n")
+	b.WriteString("  podSelector:\n")
+	b.WriteString("    matchLabels:\n")
+	fmt.Fprintf(&b, "      app: %s\n", name)
+	b.WriteString("  policyTypes:\n")
+	if len(dependents) > 0 {
+		b.WriteString("  - Ingress\n")
+	}
+	if len(spec.Needs) > 0 {
+		b.WriteString("  - Egress\n")
+	}
+	if len(dependents) > 0 {
+		b.WriteString("  ingress:\n")
+		if spec.NetworkPolicies.Ingress == networkPolicyInternal {
+			b.WriteString("  - from:\n    - namespaceSelector: {}\n")
+		}
+		writeNetworkPolicyPeers(&b, "from", env, dependents)
+	}
+	if len(spec.Needs) > 0 {
+		b.WriteString("  egress:\n")
+		if spec.NetworkPolicies.Egress == networkPolicyInternal {
+			b.WriteString("  - to:\n    - namespaceSelector: {}\n")
+		} else {
+			b.WriteString("  - to:\n")
+			b.WriteString("    - namespaceSelector:\n")
+			b.WriteString("        matchLabels:\n")
+			b.WriteString("          kubernetes.io/metadata.name: kube-system\n")
+			b.WriteString("    ports:\n")
+			b.WriteString("    - protocol: UDP\n      port: 53\n")
+			b.WriteString("    - protocol: TCP\n      port: 53\n")
+			if hasCapabilityResources(spec, env) {
+				b.WriteString("  - to:\n")
+				b.WriteString("    - podSelector:\n")
+				b.WriteString("        matchExpressions:\n")
+				fmt.Fprintf(&b, "        - key: %s\n", capabilityLabel)
+				b.WriteString("          operator: Exists\n")
+			}
+		}
+		writeNetworkPolicyPeers(&b, "to", env, spec.Needs)
+	}
+	return b.String()
+}
+
+func writeNetworkPolicyPeers(b *strings.Builder, direction, env string, peers []string) {
+	fmt.Fprintf(b, "  - %s:\n", direction)
+	for _, peer := range peers {
+		b.WriteString("    - namespaceSelector:\n")
+		b.WriteString("        matchLabels:\n")
+		fmt.Fprintf(b, "          kubernetes.io/metadata.name: %s\n", projectNamespaceFor(peer, env))
+		b.WriteString("      podSelector:\n")
+		b.WriteString("        matchLabels:\n")
+		fmt.Fprintf(b, "          app: %s\n", safeName(peer))
+	}
+}
+
+// writeOverlayNetworkPolicies writes networkpolicy.yaml into each overlay
+// when the project has needs or dependents, and removes it otherwise.
+func writeOverlayNetworkPolicies(
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	envs []string,
+	dependents []string,
+) ([]string, error) {
+	spec = normalizeProjectSpec(spec)
+	written := []string{}
+	for _, env := range envs {
+		rel := path.Join(manifestsRepoOverlaysDir, env, overlayNetworkPolicyFile)
+		if !hasDependencyPolicy(spec, dependents) {
+			if _, err := artifacts.RemoveFiles(projectID, rel); err != nil {
+				return written, err
+			}
+			continue
+		}
+		manifest := renderNetworkPolicyManifest(spec, env, dependents)
+		artifactPath, err := artifacts.WriteFile(projectID, rel, []byte(manifest))
+		if err != nil {
+			return written, err
+		}
+		written = append(written, artifactPath)
+	}
+	return written, nil
+}
@@ -0,0 +1,138 @@
+//nolint:testpackage,exhaustruct // Dependency tests drive the internal router and the unexported overlay writers.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_ProjectNeedsAreCheckedAndBlockDelete(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	billing := workerRuntimeSpec("billing-api")
+	ledger := workerRuntimeSpec("ledger")
+	ledger.Needs = []string{"billing-api"}
+	for id, spec := range map[string]ProjectSpec{"p-billing": billing, "p-ledger": ledger} {
+		if err := fixture.store.PutProject(ctx, Project{
+			ID: id, CreatedAt: now, UpdatedAt: now, Spec: spec, SpecHash: projectSpecHash(spec),
+			Status: ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now},
+		}); err != nil {
+			t.Fatalf("put project %s: %v", id, err)
+		}
+	}
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	call := func(method, path string, body any) (*http.Response, apiErrorResponse) {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var out apiErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+
+	cyclic := billing
+	cyclic.Needs = []string{"ledger"}
+	resp, refused := call(http.MethodPut, "/api/projects/p-billing", cyclic)
+	if resp.StatusCode != http.StatusBadRequest || refused.Field != "needs" ||
+		!strings.Contains(refused.Message, "billing-api -> ledger -> billing-api") {
+		t.Fatalf("expected the cycle to be refused, got %d %+v", resp.StatusCode, refused)
+	}
+	missing := ledger
+	missing.Needs = []string{"billing-api", "payments"}
+	resp, refused = call(http.MethodPut, "/api/projects/p-ledger", missing)
+	if resp.StatusCode != http.StatusBadRequest || refused.Field != "needs[1]" {
+		t.Fatalf("expected an unknown need to be refused, got %d %+v", resp.StatusCode, refused)
+	}
+	renamed := billing
+	renamed.Name = "billing"
+	resp, refused = call(http.MethodPut, "/api/projects/p-billing", renamed)
+	if resp.StatusCode != http.StatusBadRequest || refused.Field != "name" {
+		t.Fatalf("expected renaming a needed project to be refused, got %d %+v", resp.StatusCode, refused)
+	}
+
+	graphResp, err := srv.Client().Get(srv.URL + "/api/graph")
+	if err != nil {
+		t.Fatalf("get graph: %v", err)
+	}
+	defer graphResp.Body.Close()
+	var graph ProjectGraph
+	if err = json.NewDecoder(graphResp.Body).Decode(&graph); err != nil {
+		t.Fatalf("decode graph: %v", err)
+	}
+	if len(graph.Nodes) != 2 || graph.Nodes[0].Name != "billing-api" || len(graph.Edges) != 1 ||
+		graph.Edges[0] != (ProjectGraphEdge{From: "p-ledger", To: "p-billing"}) {
+		t.Fatalf("expected ledger -> billing-api, got %+v", graph)
+	}
+
+	resp, refused = call(http.MethodDelete, "/api/projects/p-billing", nil)
+	if resp.StatusCode != http.StatusConflict || refused.Code != errorCodeHasDependents ||
+		!strings.Contains(refused.Message, "needed by ledger") {
+		t.Fatalf("expected the delete to be blocked by ledger, got %d %+v", resp.StatusCode, refused)
+	}
+	plan, err := api.buildDeletePlan(ctx, Project{ID: "p-billing", Spec: billing})
+	if err != nil || !strings.Contains(plan.BlockedBy, "needed by ledger") {
+		t.Fatalf("expected the plan to be blocked by ledger, got %q (%v)", plan.BlockedBy, err)
+	}
+}
+
+func TestWriteKustomizeRepoFilesRendersDependencyNetworkPolicy(t *testing.T) {
+	t.Parallel()
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-ledger"
+	spec := workerRuntimeSpec("ledger")
+	spec.Needs = []string{"billing-api"}
+	spec.NetworkPolicies.Egress = "none"
+	if _, err := writeKustomizeRepoFiles(
+		artifacts, projectID, spec, map[string]string{}, nil, nil, []string{"reports"},
+	); err != nil {
+		t.Fatalf("write manifests: %v", err)
+	}
+	overlay := filepath.ToSlash(filepath.Join(manifestsRepoOverlaysDir, "dev"))
+	kustomization, err := artifacts.ReadFile(projectID, overlay+"/"+manifestFileKustomization)
+	if err != nil || !strings.Contains(string(kustomization), "  - "+overlayNetworkPolicyFile+"\n") {
+		t.Fatalf("expected the overlay to list the NetworkPolicy, got %q (%v)", kustomization, err)
+	}
+	policy, err := artifacts.ReadFile(projectID, overlay+"/"+overlayNetworkPolicyFile)
+	if err != nil {
+		t.Fatalf("read NetworkPolicy: %v", err)
+	}
+	for _, want := range []string{
+		"  - Ingress\n  - Egress\n",
+		"  ingress:\n  - from:\n    - namespaceSelector: {}\n",
+		"kubernetes.io/metadata.name: reports-dev\n      podSelector:\n        matchLabels:\n          app: reports\n",
+		"kubernetes.io/metadata.name: kube-system\n    ports:\n",
+		"kubernetes.io/metadata.name: billing-api-dev\n      podSelector:\n" +
+			"        matchLabels:\n          app: billing-api\n",
+	} {
+		if !strings.Contains(string(policy), want) {
+			t.Fatalf("expected the NetworkPolicy to contain %q, got:\n%s", want, policy)
+		}
+	}
+
+	spec.Needs = nil
+	if _, err = writeKustomizeRepoFiles(artifacts, projectID, spec, map[string]string{}, nil, nil, nil); err != nil {
+		t.Fatalf("rewrite manifests: %v", err)
+	}
+	if _, err = artifacts.ReadFile(projectID, overlay+"/"+overlayNetworkPolicyFile); err == nil {
+		t.Fatal("expected the NetworkPolicy to be removed once the project has no needs or dependents")
+	}
+}
@@ -0,0 +1,318 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Project health: with PAAS_KUBE_APPLY on, the leader probes every
+// environment whose manifests were applied to the local cluster (its
+// deploy/<env>/kube-apply.json says applied) every PAAS_HEALTH_PROBE_INTERVAL.
+// A probe is a GET of PAAS_HEALTH_PROBE_PATH on the app's Service through
+// the cluster API server's service proxy, so no port-forward is needed. Each
+// environment keeps its last healthProbeWindow results, from which its
+// availability is computed; the overview reports a fresh result instead of
+// inferring health from the project phase. Kubernetes is the only deploy
+// target the platform runs workloads on, so it is the only one probed.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	healthStatusHealthy = "healthy"
+	healthStatusFailing = "failing"
+	healthTargetKube    = "kubernetes"
+
+	defaultHealthProbeInterval = 30 * time.Second
+	defaultHealthProbePath     = "/healthz"
+	healthProbeTimeout         = 5 * time.Second
+	healthProbeWindow          = 20
+	// A result older than this many intervals no longer counts as current.
+	healthProbeStaleIntervals = 3
+	healthProbeMessageMax     = 256
+	percentScale              = 100
+)
+
+// HealthProbeResult is one probe of an environment.
+type HealthProbeResult struct {
+	At    time.Time `json:"at"`
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+}
+
+// EnvironmentHealth is what the probes of one environment found.
+type EnvironmentHealth struct {
+	Environment         string              `json:"environment"`
+	Target              string              `json:"target"`
+	Status              string              `json:"status"`
+	CheckedAt           time.Time           `json:"checked_at"`
+	LastHealthyAt       *time.Time          `json:"last_healthy_at,omitempty"`
+	ConsecutiveFailures int                 `json:"consecutive_failures"`
+	AvailabilityPercent float64             `json:"availability_percent"`
+	Message             string              `json:"message,omitempty"`
+	Probes              []HealthProbeResult `json:"probes"`
+	// Current is set on read: the last probe is recent enough to stand for
+	// the environment now.
+	Current bool `json:"current"`
+}
+
+// healthProbeTarget is what a probe needs to reach one environment.
+type healthProbeTarget struct {
+	ProjectID   string
+	Environment string
+	Namespace   string
+	Service     string
+	Path        string
+}
+
+// healthProbeFunc probes one target; nil means the app answered 2xx.
+type healthProbeFunc func(ctx context.Context, target healthProbeTarget) error
+
+type healthProbeReport struct {
+	Probed  int
+	Failing int
+}
+
+// healthProbeInterval reads PAAS_HEALTH_PROBE_INTERVAL; 0 turns probing
+// off and a malformed value keeps the default.
+func healthProbeInterval() time.Duration {
+	raw := strings.TrimSpace(os.Getenv(healthProbeIntervalEnv))
+	if raw == "" {
+		return defaultHealthProbeInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval < 0 {
+		return defaultHealthProbeInterval
+	}
+	return interval
+}
+
+func healthProbePath() string {
+	probePath := strings.TrimSpace(os.Getenv(healthProbePathEnv))
+	if probePath == "" {
+		return defaultHealthProbePath
+	}
+	return "/" + strings.TrimLeft(probePath, "/")
+}
+
+// startHealthChecker runs the checker as a singleton job when rendered
+// manifests are applied to a cluster and probing is not turned off.
+func startHealthChecker(ctx context.Context, store *Store, artifacts ArtifactStore, elector *leaderElector) {
+	interval := healthProbeInterval()
+	if !kubeApplyEnabled() || interval == 0 {
+		return
+	}
+	applier, err := kubectlApplierFromEnv()
+	healthLog := appLoggerForProcess().Source("health")
+	if err != nil {
+		healthLog.Warnf("health probes off: %v", err)
+		return
+	}
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		runHealthChecker(jobCtx, store, artifacts, interval, applier.probeHealth, healthLog)
+	})
+}
+
+// runHealthChecker probes every interval while this replica leads.
+func runHealthChecker(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	interval time.Duration,
+	probe healthProbeFunc,
+	healthLog sourceLogger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := checkProjectHealth(ctx, store, artifacts, probe)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			healthLog.Warnf("health check failed: %v", err)
+		case report.Failing > 0:
+			healthLog.Infof("health check: probed=%d failing=%d", report.Probed, report.Failing)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkProjectHealth probes each applied environment of every project once
+// and records the results.
+func checkProjectHealth(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	probe healthProbeFunc,
+) (healthProbeReport, error) {
+	var report healthProbeReport
+	projects, err := store.ListProjects(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, project := range projects {
+		targets := healthProbeTargets(artifacts, project)
+		if len(targets) == 0 {
+			continue
+		}
+		results := map[string]HealthProbeResult{}
+		for _, target := range targets {
+			probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+			probeErr := probe(probeCtx, target)
+			cancel()
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			result := HealthProbeResult{At: time.Now().UTC(), OK: probeErr == nil, Error: ""}
+			if probeErr != nil {
+				result.Error = truncateHealthMessage(probeErr.Error())
+				report.Failing++
+			}
+			results[target.Environment] = result
+			report.Probed++
+		}
+		if err = store.recordProjectHealth(ctx, project.ID, results); err != nil {
+			return report, fmt.Errorf("record health of %s: %w", project.ID, err)
+		}
+	}
+	return report, nil
+}
+
+// healthProbeTargets lists project's environments whose last kube apply
+// succeeded.
+func healthProbeTargets(artifacts ArtifactStore, project Project) []healthProbeTarget {
+	spec := normalizeProjectSpec(project.Spec)
+	targets := []healthProbeTarget{}
+	for _, env := range desiredManifestEnvironments(spec) {
+		raw, err := artifacts.ReadFile(project.ID, path.Join("deploy", env, kubeApplyReportFile))
+		if err != nil {
+			continue
+		}
+		var applied kubeApplyReport
+		if json.Unmarshal(raw, &applied) != nil || applied.Status != kubeApplyStatusApplied {
+			continue
+		}
+		targets = append(targets, healthProbeTarget{
+			ProjectID:   project.ID,
+			Environment: env,
+			Namespace:   applied.Namespace,
+			Service:     safeName(spec.Name),
+			Path:        healthProbePath(),
+		})
+	}
+	return targets
+}
+
+// probeHealth GETs the target's path through the API server's proxy to the
+// Service's http port; kubectl fails unless the app answers 2xx.
+func (k kubectlApplier) probeHealth(ctx context.Context, target healthProbeTarget) error {
+	proxyPath := fmt.Sprintf(
+		"/api/v1/namespaces/%s/services/%s:http/proxy%s",
+		target.Namespace,
+		target.Service,
+		target.Path,
+	)
+	_, err := k.run(ctx, nil, "get", "--raw", proxyPath)
+	return err
+}
+
+// withHealthProbe adds result to health and recomputes what follows from
+// its window of probes.
+func withHealthProbe(health EnvironmentHealth, env string, result HealthProbeResult) EnvironmentHealth {
+	health.Environment = env
+	health.Target = healthTargetKube
+	health.CheckedAt = result.At
+	health.Probes = append(health.Probes, result)
+	if len(health.Probes) > healthProbeWindow {
+		health.Probes = health.Probes[len(health.Probes)-healthProbeWindow:]
+	}
+	if result.OK {
+		at := result.At
+		health.Status = healthStatusHealthy
+		health.LastHealthyAt = &at
+		health.ConsecutiveFailures = 0
+		health.Message = ""
+	} else {
+		health.Status = healthStatusFailing
+		health.ConsecutiveFailures++
+		health.Message = result.Error
+	}
+	passed := 0
+	for _, probe := range health.Probes {
+		if probe.OK {
+			passed++
+		}
+	}
+	health.AvailabilityPercent = float64(passed*percentScale) / float64(len(health.Probes))
+	return health
+}
+
+// withCurrent sets health.Current as of now.
+func (health EnvironmentHealth) withCurrent(now time.Time) EnvironmentHealth {
+	interval := healthProbeInterval()
+	health.Current = interval > 0 && now.Sub(health.CheckedAt) <= healthProbeStaleIntervals*interval
+	return health
+}
+
+func truncateHealthMessage(message string) string {
+	message = strings.TrimSpace(message)
+	if len(message) > healthProbeMessageMax {
+		return message[:healthProbeMessageMax] + "..."
+	}
+	return message
+}
+
+// projectHealthResponse is GET /api/projects/{id}/health.
+type projectHealthResponse struct {
+	ProjectID string `json:"project_id"`
+	// Probing is whether this replica's configuration probes at all.
+	Probing      bool                `json:"probing"`
+	Interval     string              `json:"interval,omitempty"`
+	Environments []EnvironmentHealth `json:"environments"`
+}
+
+func (a *API) handleProjectHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.store == nil {
+		writeAPIError(w, "health data unavailable", http.StatusInternalServerError)
+		return
+	}
+	projectID, ok := projectIDFromSubresourcePath(w, r, "health")
+	if !ok {
+		return
+	}
+	if _, found := a.getProjectOrWriteError(w, r, projectID); !found {
+		return
+	}
+	health, err := a.store.getProjectHealth(r.Context(), projectID)
+	if err != nil {
+		writeAPIError(w, "failed to read project health", http.StatusInternalServerError)
+		return
+	}
+	interval := healthProbeInterval()
+	response := projectHealthResponse{
+		ProjectID:    projectID,
+		Probing:      kubeApplyEnabled() && interval > 0,
+		Interval:     "",
+		Environments: make([]EnvironmentHealth, 0, len(health.Environments)),
+	}
+	if response.Probing {
+		response.Interval = interval.String()
+	}
+	now := time.Now().UTC()
+	for _, env := range sortedKeys(health.Environments) {
+		response.Environments = append(response.Environments, health.Environments[env].withCurrent(now))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,72 @@
+//nolint:testpackage,exhaustruct // Health tests reuse the internal promotion preview fixture.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckProjectHealth_RecordsAvailabilityOfAppliedEnvironments(t *testing.T) {
+	fixture := newPromotionPreviewFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	applied, _ := json.Marshal(kubeApplyReport{Namespace: "apps-staging", Status: kubeApplyStatusApplied})
+	reportPath := "deploy/staging/" + kubeApplyReportFile
+	if _, err := fixture.artifacts.WriteFile(fixture.projectID, reportPath, applied); err != nil {
+		t.Fatalf("write kube apply report: %v", err)
+	}
+	probes := []healthProbeTarget{}
+	failNext := false
+	probe := func(_ context.Context, target healthProbeTarget) error {
+		probes = append(probes, target)
+		if failNext {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	store := fixture.api.store
+	if _, err := checkProjectHealth(ctx, store, fixture.artifacts, probe); err != nil {
+		t.Fatalf("first health check: %v", err)
+	}
+	failNext = true
+	report, err := checkProjectHealth(ctx, store, fixture.artifacts, probe)
+	if err != nil || report.Probed != 1 || report.Failing != 1 {
+		t.Fatalf("expected one failing probe, got %+v (%v)", report, err)
+	}
+	if len(probes) != 2 || probes[0].Namespace != "apps-staging" || probes[0].Path != defaultHealthProbePath {
+		t.Fatalf("expected only staging probed through its namespace, got %+v", probes)
+	}
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/projects/"+fixture.projectID+"/health", nil)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("get project health: %v", err)
+	}
+	defer resp.Body.Close()
+	var body projectHealthResponse
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected health, got %d (%v)", resp.StatusCode, err)
+	}
+	if len(body.Environments) != 1 {
+		t.Fatalf("expected staging health only, got %+v", body.Environments)
+	}
+	staging := body.Environments[0]
+	if staging.Environment != "staging" || staging.Status != healthStatusFailing || !staging.Current ||
+		staging.AvailabilityPercent != 50 || staging.ConsecutiveFailures != 1 || staging.LastHealthyAt == nil {
+		t.Fatalf("expected staging failing at 50%% availability, got %+v", staging)
+	}
+
+	overview := fetchProjectOverviewForTest(t, srv.Client(), srv.URL, fixture.projectID)
+	for _, env := range overview.Overview.Environments {
+		if env.Name == "staging" && env.HealthStatus != overviewHealthFailing {
+			t.Fatalf("expected the overview to report the failing probe, got %q", env.HealthStatus)
+		}
+	}
+}
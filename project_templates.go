@@ -0,0 +1,280 @@
+package platform
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Project templates: curated starters a project can be created from. Each
+// template is a directory holding template.json (a description and the
+// spec fields it pre-fills) and files/, the tree its source repo is seeded
+// with instead of the generic hello-world main.go. The built-in templates
+// ship under templates/; PAAS_TEMPLATES_DIR adds more, or replaces a
+// built-in of the same name.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	projectTemplateManifest = "template.json"
+	projectTemplateFilesDir = "files"
+	projectTemplateSuffix   = ".tmpl"
+
+	projectTemplateSourceBuiltin = "builtin"
+	projectTemplateSourceCustom  = "custom"
+)
+
+//go:embed templates
+var builtinTemplatesFS embed.FS
+
+// ProjectTemplate is one entry of GET /api/templates. Spec holds only the
+// fields the template pre-fills; the request fills in the rest.
+type ProjectTemplate struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Source      string      `json:"source"` // builtin | custom
+	Spec        ProjectSpec `json:"spec"`
+	Files       []string    `json:"files"` // seeded paths, .tmpl suffixes removed
+
+	fsys fs.FS // the template directory
+}
+
+// projectTemplateManifestFile is template.json.
+type projectTemplateManifestFile struct {
+	Description string          `json:"description"`
+	Spec        json.RawMessage `json:"spec"`
+}
+
+// projectTemplateData is what .tmpl files are rendered with.
+type projectTemplateData struct {
+	Name     string
+	SafeName string
+	Runtime  string
+	Port     int
+}
+
+// loadProjectTemplates reads the built-in templates, then those under
+// PAAS_TEMPLATES_DIR. A template that does not load is logged and left out
+// rather than taking the whole catalog down with it.
+func loadProjectTemplates() map[string]ProjectTemplate {
+	logger := appLoggerForProcess().Source("templates")
+	catalog := map[string]ProjectTemplate{}
+	builtin, err := fs.Sub(builtinTemplatesFS, "templates")
+	if err != nil {
+		logger.Warnf("built-in templates unavailable: %v", err)
+	} else {
+		addProjectTemplates(catalog, builtin, projectTemplateSourceBuiltin)
+	}
+	if dir := strings.TrimSpace(os.Getenv(templatesDirEnv)); dir != "" {
+		addProjectTemplates(catalog, os.DirFS(dir), projectTemplateSourceCustom)
+	}
+	return catalog
+}
+
+func addProjectTemplates(catalog map[string]ProjectTemplate, root fs.FS, source string) {
+	logger := appLoggerForProcess().Source("templates")
+	entries, err := fs.ReadDir(root, ".")
+	if err != nil {
+		logger.Warnf("read %s templates: %v", source, err)
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		tmpl, loadErr := loadProjectTemplate(root, entry.Name(), source)
+		if loadErr != nil {
+			logger.Warnf("skip %s template %q: %v", source, entry.Name(), loadErr)
+			continue
+		}
+		catalog[tmpl.Name] = tmpl
+	}
+}
+
+func loadProjectTemplate(root fs.FS, name, source string) (ProjectTemplate, error) {
+	if len(name) > 63 || !projectNameRe.MatchString(name) {
+		return ProjectTemplate{}, fmt.Errorf("name must match %s", projectNameRe.String())
+	}
+	dir, err := fs.Sub(root, name)
+	if err != nil {
+		return ProjectTemplate{}, err
+	}
+	raw, err := fs.ReadFile(dir, projectTemplateManifest)
+	if err != nil {
+		return ProjectTemplate{}, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var manifest projectTemplateManifestFile
+	if err = decoder.Decode(&manifest); err != nil {
+		return ProjectTemplate{}, fmt.Errorf("%s: %w", projectTemplateManifest, err)
+	}
+	tmpl := ProjectTemplate{
+		Name:        name,
+		Description: strings.TrimSpace(manifest.Description),
+		Source:      source,
+		Spec:        zeroProjectSpec(),
+		Files:       []string{},
+		fsys:        dir,
+	}
+	if len(manifest.Spec) > 0 {
+		decoder = json.NewDecoder(bytes.NewReader(manifest.Spec))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(&tmpl.Spec); err != nil {
+			return ProjectTemplate{}, fmt.Errorf("%s spec: %w", projectTemplateManifest, err)
+		}
+	}
+	err = walkProjectTemplateFiles(dir, func(rel string, _ []byte) error {
+		tmpl.Files = append(tmpl.Files, strings.TrimSuffix(rel, projectTemplateSuffix))
+		return nil
+	})
+	if err != nil {
+		return ProjectTemplate{}, err
+	}
+	sort.Strings(tmpl.Files)
+	return tmpl, nil
+}
+
+// walkProjectTemplateFiles calls fn with each regular file under files/,
+// by its slash-separated path below it. Symlinks and other special files
+// are skipped, so a template can only seed what it contains.
+func walkProjectTemplateFiles(dir fs.FS, fn func(rel string, body []byte) error) error {
+	return fs.WalkDir(dir, projectTemplateFilesDir, func(p string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == projectTemplateFilesDir {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		body, err := fs.ReadFile(dir, p)
+		if err != nil {
+			return err
+		}
+		return fn(strings.TrimPrefix(p, projectTemplateFilesDir+"/"), body)
+	})
+}
+
+func lookupProjectTemplate(name string) (ProjectTemplate, bool) {
+	tmpl, ok := loadProjectTemplates()[strings.TrimSpace(name)]
+	return tmpl, ok
+}
+
+// listProjectTemplates returns the catalog sorted by name.
+func listProjectTemplates() []ProjectTemplate {
+	catalog := loadProjectTemplates()
+	out := make([]ProjectTemplate, 0, len(catalog))
+	for _, name := range sortedKeys(catalog) {
+		out = append(out, catalog[name])
+	}
+	return out
+}
+
+// validateProjectTemplateName checks spec.template's form; whether it names
+// a template is only checked when a project is created, so removing a
+// template never breaks updates of the projects made from it.
+func validateProjectTemplateName(name string) error {
+	if name == "" || (len(name) <= 63 && projectNameRe.MatchString(name)) {
+		return nil
+	}
+	return specFieldErrorf("template", "template must match %s", projectNameRe.String())
+}
+
+func checkProjectTemplateExists(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := lookupProjectTemplate(name); !ok {
+		return specFieldErrorf("template", "template %q is not in the catalog (GET /api/templates)", name)
+	}
+	return nil
+}
+
+// inheritProjectTemplate keeps the template of a project whose update does
+// not name one. A project's template cannot change: its files were seeded
+// when it was created.
+func inheritProjectTemplate(current, next ProjectSpec) (ProjectSpec, error) {
+	if next.Template == "" {
+		next.Template = current.Template
+	}
+	if next.Template != current.Template {
+		return next, specFieldErrorf("template",
+			"template is fixed once the project is created (was %q)", current.Template)
+	}
+	return next, nil
+}
+
+// projectSpecFromTemplate pre-fills a spec from tmpl, then applies the
+// request body on top: fields the body sets win, and maps such as
+// environments and vars are merged key by key.
+func projectSpecFromTemplate(tmpl ProjectTemplate, decode func(*ProjectSpec) error) (ProjectSpec, error) {
+	spec := tmpl.Spec
+	spec.Vars = maps.Clone(tmpl.Spec.Vars)
+	spec.Environments = make(map[string]EnvConfig, len(tmpl.Spec.Environments))
+	for env, cfg := range tmpl.Spec.Environments {
+		cfg.Vars = maps.Clone(cfg.Vars)
+		spec.Environments[env] = cfg
+	}
+	spec.Capabilities = append([]string(nil), tmpl.Spec.Capabilities...)
+	if err := decode(&spec); err != nil {
+		return ProjectSpec{}, err
+	}
+	spec.Template = tmpl.Name
+	return normalizeProjectSpec(spec), nil
+}
+
+// seedSourceRepoFromTemplate writes the template's files into a new source
+// repo. Files ending in .tmpl are rendered with the project's name, safe
+// name, runtime, and container port, and lose the suffix.
+func seedSourceRepoFromTemplate(spec ProjectSpec, projectDir, sourceDir string, touched *[]string) error {
+	tmpl, ok := lookupProjectTemplate(spec.Template)
+	if !ok {
+		return fmt.Errorf("template %q is not in the catalog", spec.Template)
+	}
+	data := projectTemplateData{
+		Name:     spec.Name,
+		SafeName: safeName(spec.Name),
+		Runtime:  spec.Runtime,
+		Port:     spec.Exposure.containerPort(),
+	}
+	return walkProjectTemplateFiles(tmpl.fsys, func(rel string, body []byte) error {
+		if strings.HasSuffix(rel, projectTemplateSuffix) {
+			rendered, err := renderProjectTemplateFile(rel, body, data)
+			if err != nil {
+				return err
+			}
+			rel, body = strings.TrimSuffix(rel, projectTemplateSuffix), rendered
+		}
+		target := filepath.Join(sourceDir, filepath.FromSlash(path.Clean(rel)))
+		created, err := writeFileIfMissing(target, body)
+		if err != nil {
+			return err
+		}
+		recordTouched(projectDir, touched, target, created)
+		return nil
+	})
+}
+
+func renderProjectTemplateFile(rel string, body []byte, data projectTemplateData) ([]byte, error) {
+	parsed, err := template.New(rel).Option("missingkey=error").Parse(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("template file %s: %w", rel, err)
+	}
+	var out bytes.Buffer
+	if err = parsed.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("template file %s: %w", rel, err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,392 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Blue/green releases: a release with strategy blue-green rolls its image out
+// to the environment's idle color while the Service keeps sending traffic to
+// the active one. Blue is the app's own Deployment and green is <app>-green;
+// each pod carries its color as its track label, and a patch adds the active
+// color to the Service selector. The first blue/green release keeps the
+// environment's image on blue and stages green. A cutover op switches the
+// selector to the staged color, and a rollback op switches it back to the
+// color the cutover replaced, which still runs its image until the next
+// release restages it. The state lives in overlays/<env>/bluegreen.json in
+// the manifests repo, and once there the environment only takes blue/green
+// releases.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	blueColor                = "blue"
+	greenColor               = "green"
+	blueGreenMarkerFile      = "bluegreen.json"
+	greenDeploymentFile      = "green-deployment.yaml"
+	greenPatchFile           = "green-patch.yaml"
+	bluePatchFile            = "blue-patch.yaml"
+	serviceSelectorPatchFile = "service-patch.yaml"
+)
+
+// BlueGreenRelease is a blue/green environment: the image each color runs
+// and the color the Service selects.
+type BlueGreenRelease struct {
+	Environment string `json:"environment"`
+	FromEnv     string `json:"from_env"`
+	ActiveColor string `json:"active_color"`
+	BlueImage   string `json:"blue_image"`
+	GreenImage  string `json:"green_image"`
+	// StagedColor is the idle color a release rolled out to and no cutover
+	// has switched to yet.
+	StagedColor string `json:"staged_color,omitempty"`
+	// PreviousColor is the color the last cutover switched away from; a
+	// rollback switches back to it.
+	PreviousColor string    `json:"previous_color,omitempty"`
+	OpID          string    `json:"op_id,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at,omitzero"`
+}
+
+func (b BlueGreenRelease) image(color string) string {
+	if color == greenColor {
+		return b.GreenImage
+	}
+	return b.BlueImage
+}
+
+func (b *BlueGreenRelease) setImage(color, image string) {
+	if color == greenColor {
+		b.GreenImage = image
+		return
+	}
+	b.BlueImage = image
+}
+
+func otherColor(color string) string {
+	if color == greenColor {
+		return blueColor
+	}
+	return greenColor
+}
+
+func blueGreenMarkerPath(env string) string {
+	return path.Join(manifestsRepoOverlaysDir, env, blueGreenMarkerFile)
+}
+
+// readBlueGreenRelease returns env's blue/green state, if it has one.
+func readBlueGreenRelease(artifacts ArtifactStore, projectID, env string) (BlueGreenRelease, bool, error) {
+	raw, err := artifacts.ReadFile(projectID, blueGreenMarkerPath(env))
+	if errors.Is(err, os.ErrNotExist) {
+		return BlueGreenRelease{}, false, nil
+	}
+	if err != nil {
+		return BlueGreenRelease{}, false, err
+	}
+	var release BlueGreenRelease
+	if err = json.Unmarshal(raw, &release); err != nil {
+		return BlueGreenRelease{}, false, fmt.Errorf("read %s blue/green state: %w", env, err)
+	}
+	return release, true, nil
+}
+
+// ensureNoBlueGreen refuses a delivery into a blue/green environment that
+// would replace its image without going through the idle color.
+func ensureNoBlueGreen(artifacts ArtifactStore, projectID, env string) error {
+	release, active, err := readBlueGreenRelease(artifacts, projectID, env)
+	if err != nil || !active {
+		return err
+	}
+	return fmt.Errorf(
+		"%s releases blue/green (%s serves %s); release into it with strategy blue-green",
+		env, release.ActiveColor, release.image(release.ActiveColor),
+	)
+}
+
+// ensurePlainDelivery refuses a delivery that replaces env's image outright
+// while a canary runs there or env releases blue/green.
+func ensurePlainDelivery(artifacts ArtifactStore, projectID, env string) error {
+	if err := ensureNoCanary(artifacts, projectID, env); err != nil {
+		return err
+	}
+	return ensureNoBlueGreen(artifacts, projectID, env)
+}
+
+// envRollouts are the canaries and blue/green releases in a project's
+// environments, which every render of an environment keeps.
+type envRollouts struct {
+	canaries   map[string]CanaryRollout
+	blueGreens map[string]BlueGreenRelease
+}
+
+func loadEnvRollouts(artifacts ArtifactStore, projectID string, envs []string) (envRollouts, error) {
+	rollouts := envRollouts{canaries: map[string]CanaryRollout{}, blueGreens: map[string]BlueGreenRelease{}}
+	for _, env := range envs {
+		canary, running, err := readCanaryRollout(artifacts, projectID, env)
+		if err != nil {
+			return envRollouts{}, err
+		}
+		if running {
+			rollouts.canaries[env] = canary
+		}
+		release, active, err := readBlueGreenRelease(artifacts, projectID, env)
+		if err != nil {
+			return envRollouts{}, err
+		}
+		if active {
+			rollouts.blueGreens[env] = release
+		}
+	}
+	return rollouts, nil
+}
+
+// strategy is the strategy whose files env's overlay carries, if any.
+func (r envRollouts) strategy(env string) DeliveryStrategy {
+	if _, ok := r.canaries[env]; ok {
+		return DeliveryStrategyCanary
+	}
+	if _, ok := r.blueGreens[env]; ok {
+		return DeliveryStrategyBlueGreen
+	}
+	return ""
+}
+
+// kustomizeImage is the image the overlay gives the app's own Deployment:
+// the environment's image, or blue's in a blue/green environment.
+func (r envRollouts) kustomizeImage(env, envImage string) string {
+	if release, ok := r.blueGreens[env]; ok {
+		return release.BlueImage
+	}
+	return envImage
+}
+
+// writeOverlayBlueGreens writes the green Deployment and the color patches
+// into the overlay of each blue/green environment, and removes them from
+// the others.
+func writeOverlayBlueGreens(
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	envs []string,
+	releases map[string]BlueGreenRelease,
+	bindings envCapabilityBindings,
+	storedSecrets envStoredSecrets,
+) ([]string, error) {
+	spec = normalizeProjectSpec(spec)
+	app := safeName(spec.Name)
+	written := []string{}
+	for _, env := range envs {
+		overlayDir := path.Join(manifestsRepoOverlaysDir, env)
+		release, active := releases[env]
+		if !active {
+			stale := []string{greenDeploymentFile, greenPatchFile, bluePatchFile, serviceSelectorPatchFile}
+			for _, file := range stale {
+				if _, err := artifacts.RemoveFiles(projectID, path.Join(overlayDir, file)); err != nil {
+					return written, err
+				}
+			}
+			continue
+		}
+		// Green matches blue's replicas; the HPA only scales blue.
+		greenReplicas := 0
+		if cfg := spec.Environments[env]; cfg.Autoscaling.enabled() {
+			greenReplicas = cfg.Autoscaling.minReplicas()
+		}
+		files := map[string]string{
+			greenDeploymentFile: renderTrackDeploymentManifest(spec, greenColor, release.GreenImage),
+			greenPatchFile: renderDeploymentPatch(
+				spec, env, app+"-"+greenColor, greenReplicas, bindings[env], storedSecrets[env],
+			),
+			bluePatchFile:            renderBluePodLabelPatch(app),
+			serviceSelectorPatchFile: renderServiceSelectorPatch(app, release.ActiveColor),
+		}
+		for _, file := range sortedKeys(files) {
+			artifactPath, err := artifacts.WriteFile(projectID, path.Join(overlayDir, file), []byte(files[file]))
+			if err != nil {
+				return written, err
+			}
+			written = append(written, artifactPath)
+		}
+	}
+	return written, nil
+}
+
+// renderBluePodLabelPatch labels the app's own Deployment's pods blue. Its
+// selector stays as it is, since a Deployment's selector cannot change.
+func renderBluePodLabelPatch(app string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: apps/v1\n")
+	fmt.Fprintf(&b, "kind: Deployment\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", app)
+	fmt.Fprintf(&b, "spec:\n")
+	fmt.Fprintf(&b, "  template:\n")
+	fmt.Fprintf(&b, "    metadata:\n")
+	fmt.Fprintf(&b, "      labels:\n")
+	fmt.Fprintf(&b, "        %s: %s\n", deploymentTrackLabel, blueColor)
+	return b.String()
+}
+
+// renderServiceSelectorPatch narrows the Service to the pods of color.
+func renderServiceSelectorPatch(app, color string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: v1\n")
+	fmt.Fprintf(&b, "kind: Service\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", app)
+	fmt.Fprintf(&b, "spec:\n")
+	fmt.Fprintf(&b, "  selector:\n")
+	fmt.Fprintf(&b, "    %s: %s\n", deploymentTrackLabel, color)
+	return b.String()
+}
+
+// planBlueGreenTransition settles what a transition does to a blue/green
+// environment. A release stages its image on the idle color, a cutover or
+// rollback switches the active color, and any other delivery is refused.
+func planBlueGreenTransition(artifacts ArtifactStore, msg ProjectOpMsg, state *promotionExecutionState) error {
+	toEnv := state.resolvedToEnv
+	if msg.Delivery.Strategy != DeliveryStrategyBlueGreen {
+		return ensureNoBlueGreen(artifacts, msg.ProjectID, toEnv)
+	}
+	release, active, err := readBlueGreenRelease(artifacts, msg.ProjectID, toEnv)
+	if err != nil {
+		return err
+	}
+	switch msg.Delivery.BlueGreenAction {
+	case BlueGreenActionCutover:
+		if !active || release.StagedColor == "" {
+			return fmt.Errorf("%s has no staged blue/green release to cut over to", toEnv)
+		}
+		release.PreviousColor, release.ActiveColor = release.ActiveColor, release.StagedColor
+		release.StagedColor = ""
+	case BlueGreenActionRollback:
+		if !active || release.PreviousColor == "" {
+			return fmt.Errorf("%s has no earlier color to roll back to", toEnv)
+		}
+		release.ActiveColor, release.PreviousColor = release.PreviousColor, ""
+		release.StagedColor = ""
+	default:
+		if release, err = stageBlueGreenRelease(artifacts, msg, state, release, active); err != nil {
+			return err
+		}
+	}
+	release.OpID = msg.OpID
+	release.UpdatedAt = time.Now().UTC()
+	state.blueGreen = release
+	if msg.Delivery.BlueGreenAction != "" {
+		state.sourceImage = release.image(release.ActiveColor)
+	}
+	return nil
+}
+
+// stageBlueGreenRelease puts the promoted image on release's idle color. An
+// environment's first blue/green release starts it on blue with the image
+// it runs now.
+func stageBlueGreenRelease(
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	state *promotionExecutionState,
+	release BlueGreenRelease,
+	active bool,
+) (BlueGreenRelease, error) {
+	toEnv := state.resolvedToEnv
+	if !active {
+		current, err := resolvePromotionSourceImage(artifacts, msg.ProjectID, toEnv, state.imageByEnv)
+		if err != nil {
+			return release, err
+		}
+		release = BlueGreenRelease{
+			Environment:   toEnv,
+			FromEnv:       "",
+			ActiveColor:   blueColor,
+			BlueImage:     current,
+			GreenImage:    "",
+			StagedColor:   "",
+			PreviousColor: "",
+			OpID:          "",
+			UpdatedAt:     time.Time{},
+		}
+	}
+	if serving := release.image(release.ActiveColor); serving == state.sourceImage {
+		return release, fmt.Errorf("%s already serves %s from %s", toEnv, serving, release.ActiveColor)
+	}
+	idle := otherColor(release.ActiveColor)
+	release.setImage(idle, state.sourceImage)
+	release.FromEnv = state.resolvedFromEnv
+	release.StagedColor = idle
+	// The idle color no longer runs what the last cutover replaced.
+	release.PreviousColor = ""
+	return release, nil
+}
+
+// stageDeliveryMarker records the target's canary or blue/green state
+// before its overlays are rendered and returns the image the environment
+// serves once the op is done.
+func stageDeliveryMarker(artifacts ArtifactStore, msg ProjectOpMsg, state *promotionExecutionState) (string, error) {
+	if msg.Delivery.Strategy != DeliveryStrategyBlueGreen {
+		return stageCanaryMarker(artifacts, msg, state)
+	}
+	raw, err := json.MarshalIndent(state.blueGreen, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if _, err = artifacts.WriteFile(msg.ProjectID, blueGreenMarkerPath(state.resolvedToEnv), raw); err != nil {
+		return "", err
+	}
+	return state.blueGreen.image(state.blueGreen.ActiveColor), nil
+}
+
+// writeBlueGreenArtifacts copies each color's rendered Deployment and the
+// blue/green state next to the environment's rendered manifests and the
+// transition's.
+func writeBlueGreenArtifacts(
+	artifacts ArtifactStore,
+	projectID string,
+	env string,
+	transitionDir string,
+	release BlueGreenRelease,
+) ([]string, error) {
+	rendered, err := artifacts.ReadFile(projectID, path.Join("deploy", env, "rendered.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	marker, err := json.MarshalIndent(release, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{blueGreenMarkerFile: string(marker) + "\n"}
+	for _, manifest := range splitManifestDocs(string(rendered)) {
+		if color := manifestTrack(manifest); manifestKind(manifest) == "Deployment" && color != "" {
+			files["deployment-"+color+".yaml"] = normalizeManifestOutput(manifest)
+		}
+	}
+	written := []string{}
+	for _, dir := range []string{path.Join("deploy", env), transitionDir} {
+		for _, file := range sortedKeys(files) {
+			artifactPath, writeErr := artifacts.WriteFile(projectID, path.Join(dir, file), []byte(files[file]))
+			if writeErr != nil {
+				return written, writeErr
+			}
+			written = append(written, artifactPath)
+		}
+	}
+	return written, nil
+}
+
+// blueGreenOutcomeMessage describes a blue/green release that staged its
+// image. It moved no traffic, so it records no release; a cutover or
+// rollback does.
+func blueGreenOutcomeMessage(delivery DeliveryLifecycle, release BlueGreenRelease) (string, bool) {
+	if delivery.Strategy != DeliveryStrategyBlueGreen || delivery.BlueGreenAction != "" {
+		return "", false
+	}
+	return fmt.Sprintf(
+		"staged %s on %s in %s; %s still serves %s until POST /api/events/release/cutover",
+		release.image(release.StagedColor), release.StagedColor, release.Environment,
+		release.ActiveColor, release.image(release.ActiveColor),
+	), true
+}
@@ -0,0 +1,241 @@
+//nolint:testpackage,exhaustruct // Blue/green tests drive the internal release worker and API against one store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestWorkers_BlueGreenReleaseStagesCutsOverAndRollsBack(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	const projectID = "project-bluegreen"
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := workerRuntimeSpec("bluegreen")
+	spec.Environments["staging"] = EnvConfig{Vars: map[string]string{}}
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{}, Replicas: 2}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-bluegreen-create", OpCreate, spec)
+	seedCanaryEnvironment(t, artifacts, projectID, spec, "staging", "local/bluegreen:new")
+	seedCanaryEnvironment(t, artifacts, projectID, spec, "prod", "local/bluegreen:old")
+
+	runRelease := func(opID string, delivery DeliveryLifecycle) error {
+		t.Helper()
+		putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpRelease, spec)
+		delivery.Stage = DeliveryStageRelease
+		delivery.FromEnv = "staging"
+		delivery.ToEnv = "prod"
+		_, err := promotionWorkerAction(ctx, fixture.store, artifacts, ProjectOpMsg{
+			OpID:      opID,
+			Kind:      OpRelease,
+			ProjectID: projectID,
+			Spec:      spec,
+			FromEnv:   "staging",
+			ToEnv:     "prod",
+			Delivery:  delivery,
+			At:        time.Now().UTC(),
+		})
+		return err
+	}
+	readFile := func(rel string) string {
+		t.Helper()
+		raw, err := artifacts.ReadFile(projectID, rel)
+		if err != nil {
+			t.Fatalf("read %s: %v", rel, err)
+		}
+		return string(raw)
+	}
+	releaseCount := func() int {
+		t.Helper()
+		page, err := fixture.store.listProjectReleases(ctx, projectID, "prod", projectReleaseListQuery{Limit: 5})
+		if err != nil {
+			t.Fatalf("list prod releases: %v", err)
+		}
+		return len(page.Items)
+	}
+
+	if err := runRelease("op-bluegreen-stage", DeliveryLifecycle{Strategy: DeliveryStrategyBlueGreen}); err != nil {
+		t.Fatalf("stage blue/green release: %v", err)
+	}
+	release, active, err := readBlueGreenRelease(artifacts, projectID, "prod")
+	if err != nil || !active {
+		t.Fatalf("expected a prod blue/green marker, got %v, %v", active, err)
+	}
+	if release.ActiveColor != blueColor || release.StagedColor != greenColor ||
+		release.BlueImage != "local/bluegreen:old" || release.GreenImage != "local/bluegreen:new" {
+		t.Fatalf("unexpected staged release: %+v", release)
+	}
+	if image, _ := readRenderedEnvImageTag(artifacts, projectID, "prod"); image != "local/bluegreen:old" {
+		t.Fatalf("expected prod to keep serving local/bluegreen:old, got %q", image)
+	}
+	if selector := readFile(manifestsRepoOverlaysDir + "/prod/" + serviceSelectorPatchFile); !strings.Contains(
+		selector, deploymentTrackLabel+": blue") {
+		t.Fatalf("expected the Service to select blue:\n%s", selector)
+	}
+	for _, rel := range []string{
+		"deploy/prod/deployment-green.yaml",
+		"releases/staging-to-prod/deployment-green.yaml",
+	} {
+		if green := readFile(rel); !strings.Contains(green, "image: local/bluegreen:new") {
+			t.Fatalf("expected %s to run local/bluegreen:new:\n%s", rel, green)
+		}
+	}
+	if blue := readFile("deploy/prod/deployment-blue.yaml"); !strings.Contains(blue, "image: local/bluegreen:old") {
+		t.Fatalf("expected the blue Deployment to run local/bluegreen:old:\n%s", blue)
+	}
+	if releaseCount() != 0 {
+		t.Fatal("expected staging a blue/green release to record no release")
+	}
+
+	if err = runRelease("op-bluegreen-plain", DeliveryLifecycle{}); err == nil ||
+		!strings.Contains(err.Error(), "strategy blue-green") {
+		t.Fatalf("expected a plain release into prod to be refused, got %v", err)
+	}
+	if err = runRelease("op-bluegreen-early-rollback", DeliveryLifecycle{
+		Strategy:        DeliveryStrategyBlueGreen,
+		BlueGreenAction: BlueGreenActionRollback,
+	}); err == nil {
+		t.Fatal("expected a rollback before any cutover to be refused")
+	}
+
+	err = runRelease("op-bluegreen-cutover", DeliveryLifecycle{
+		Strategy:        DeliveryStrategyBlueGreen,
+		BlueGreenAction: BlueGreenActionCutover,
+	})
+	if err != nil {
+		t.Fatalf("cut over: %v", err)
+	}
+	if release, _, _ = readBlueGreenRelease(artifacts, projectID, "prod"); release.ActiveColor != greenColor ||
+		release.PreviousColor != blueColor || release.StagedColor != "" {
+		t.Fatalf("expected green to be active after cutover, got %+v", release)
+	}
+	if selector := readFile(manifestsRepoOverlaysDir + "/prod/" + serviceSelectorPatchFile); !strings.Contains(
+		selector, deploymentTrackLabel+": green") {
+		t.Fatalf("expected the Service to select green:\n%s", selector)
+	}
+	if image, _ := readRenderedEnvImageTag(artifacts, projectID, "prod"); image != "local/bluegreen:new" {
+		t.Fatalf("expected prod to serve local/bluegreen:new after cutover, got %q", image)
+	}
+	if releaseCount() != 1 {
+		t.Fatal("expected the cutover to record a prod release")
+	}
+
+	err = runRelease("op-bluegreen-rollback", DeliveryLifecycle{
+		Strategy:        DeliveryStrategyBlueGreen,
+		BlueGreenAction: BlueGreenActionRollback,
+	})
+	if err != nil {
+		t.Fatalf("roll back: %v", err)
+	}
+	if release, _, _ = readBlueGreenRelease(artifacts, projectID, "prod"); release.ActiveColor != blueColor ||
+		release.PreviousColor != "" {
+		t.Fatalf("expected blue to be active after rollback, got %+v", release)
+	}
+	if image, _ := readRenderedEnvImageTag(artifacts, projectID, "prod"); image != "local/bluegreen:old" {
+		t.Fatalf("expected prod to serve local/bluegreen:old after rollback, got %q", image)
+	}
+	if releaseCount() != 2 {
+		t.Fatal("expected the rollback to record a prod release")
+	}
+}
+
+func TestAPI_BlueGreenCutoverOps(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	const projectID = "project-bluegreen-api"
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := workerRuntimeSpec("bluegreen-api")
+	spec.Environments["staging"] = EnvConfig{Vars: map[string]string{}}
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{}}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-bluegreen-api-create", OpCreate, spec)
+	seedCanaryEnvironment(t, artifacts, projectID, spec, "staging", "local/bluegreen-api:new")
+	seedCanaryEnvironment(t, artifacts, projectID, spec, "prod", "local/bluegreen-api:old")
+
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	received := make(chan ProjectOpMsg, 2)
+	sub, err := fixture.nc.Subscribe(natsSubject(subjectPromotionStart), func(msg *nats.Msg) {
+		var opMsg ProjectOpMsg
+		if json.Unmarshal(msg.Data, &opMsg) == nil {
+			received <- opMsg
+		}
+	})
+	if err != nil {
+		t.Fatalf("subscribe promotion start: %v", err)
+	}
+	defer func() { _ = sub.Unsubscribe() }()
+
+	post := func(target, body string) (int, []byte) {
+		t.Helper()
+		req, reqErr := http.NewRequestWithContext(
+			context.Background(), http.MethodPost, srv.URL+target, strings.NewReader(body),
+		)
+		if reqErr != nil {
+			t.Fatalf("build POST %s: %v", target, reqErr)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, doErr := srv.Client().Do(req)
+		if doErr != nil {
+			t.Fatalf("POST %s: %v", target, doErr)
+		}
+		defer resp.Body.Close()
+		var raw json.RawMessage
+		_ = json.NewDecoder(resp.Body).Decode(&raw)
+		return resp.StatusCode, raw
+	}
+	cutoverBody := `{"project_id":"` + projectID + `"}`
+	rollbackBody := `{"project_id":"` + projectID + `","rollback":true}`
+
+	if status, raw := post("/api/events/release/cutover", cutoverBody); status != http.StatusConflict {
+		t.Fatalf("expected 409 cutting over a plain environment, got %d %s", status, raw)
+	}
+	status, raw := post("/api/events/release", `{"project_id":"`+projectID+`","strategy":"blue-green","weight":10}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected a blue-green weight to be rejected, got %d %s", status, raw)
+	}
+
+	release := BlueGreenRelease{
+		Environment: "prod",
+		FromEnv:     "staging",
+		ActiveColor: blueColor,
+		BlueImage:   "local/bluegreen-api:old",
+		GreenImage:  "local/bluegreen-api:new",
+		StagedColor: greenColor,
+	}
+	marker, _ := json.Marshal(release)
+	if _, err = artifacts.WriteFile(projectID, blueGreenMarkerPath("prod"), marker); err != nil {
+		t.Fatalf("write blue/green marker: %v", err)
+	}
+
+	plainBody := `{"project_id":"` + projectID + `","from_env":"staging"}`
+	if status, raw = post("/api/events/release", plainBody); status != http.StatusConflict {
+		t.Fatalf("expected 409 for a plain release into blue/green prod, got %d %s", status, raw)
+	}
+	if status, raw = post("/api/events/release/cutover", rollbackBody); status != http.StatusConflict {
+		t.Fatalf("expected 409 rolling back before any cutover, got %d %s", status, raw)
+	}
+	if status, raw = post("/api/events/release/cutover", cutoverBody); status != http.StatusAccepted {
+		t.Fatalf("expected 202 cutting over, got %d %s", status, raw)
+	}
+	select {
+	case msg := <-received:
+		if msg.Kind != OpRelease || msg.FromEnv != "staging" || msg.ToEnv != "prod" ||
+			msg.Delivery.Strategy != DeliveryStrategyBlueGreen ||
+			msg.Delivery.BlueGreenAction != BlueGreenActionCutover {
+			t.Fatalf("unexpected cutover op message: %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the cutover op")
+	}
+}
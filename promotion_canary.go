@@ -0,0 +1,279 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Canary promotions: a promotion with strategy canary leaves the target's
+// Deployment on its stable image and adds <app>-canary beside it, running the
+// promoted image. Both carry the app label the Service selects, so traffic
+// splits by replica count: the canary gets the requested weight of the
+// environment's replicas, rounded to whole pods. The running canary is
+// recorded in overlays/<env>/canary.json in the manifests repo, and every
+// later render of the environment keeps it until a follow-up op promotes the
+// canary to the whole environment or aborts it. Until then other deliveries
+// into the environment are refused.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	canaryTrack          = "canary"
+	deploymentTrackLabel = "track"
+	canaryMarkerFile     = "canary.json"
+	canaryDeploymentFile = "canary-deployment.yaml"
+	canaryPatchFile      = "canary-patch.yaml"
+	canaryWeightMin      = 1
+	canaryWeightMax      = 99
+)
+
+// CanaryRollout is a canary running in an environment, or a preview's plan
+// for one.
+type CanaryRollout struct {
+	Environment     string `json:"environment"`
+	FromEnv         string `json:"from_env"`
+	StableImage     string `json:"stable_image"`
+	CanaryImage     string `json:"canary_image"`
+	RequestedWeight int    `json:"requested_weight"`
+	// Weight is the canary's actual share of replicas, and so of traffic.
+	Weight         int `json:"weight"`
+	StableReplicas int `json:"stable_replicas"`
+	CanaryReplicas int `json:"canary_replicas"`
+	// Autoscaled means the HPA owns the stable count; the split is planned
+	// at its minReplicas.
+	Autoscaled bool      `json:"autoscaled,omitempty"`
+	OpID       string    `json:"op_id,omitempty"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+}
+
+func validateCanaryWeight(weight int) error {
+	if weight < canaryWeightMin || weight > canaryWeightMax {
+		return fmt.Errorf("canary weight must be between %d and %d percent (got %d)",
+			canaryWeightMin, canaryWeightMax, weight)
+	}
+	return nil
+}
+
+// planCanaryRollout splits env's replicas for a canary at weight percent.
+// Each side keeps at least one pod, so a single-replica environment runs
+// one of each.
+func planCanaryRollout(spec ProjectSpec, env, fromEnv, stableImage, canaryImage string, weight int) CanaryRollout {
+	cfg := normalizeProjectSpec(spec).Environments[env]
+	total := cfg.replicas()
+	if cfg.Autoscaling.enabled() {
+		total = cfg.Autoscaling.minReplicas()
+	}
+	canaryReplicas := max(1, (total*weight+percentScale/2)/percentScale)
+	stableReplicas := max(1, total-canaryReplicas)
+	pods := stableReplicas + canaryReplicas
+	return CanaryRollout{
+		Environment:     env,
+		FromEnv:         fromEnv,
+		StableImage:     stableImage,
+		CanaryImage:     canaryImage,
+		RequestedWeight: weight,
+		Weight:          (canaryReplicas*percentScale + pods/2) / pods,
+		StableReplicas:  stableReplicas,
+		CanaryReplicas:  canaryReplicas,
+		Autoscaled:      cfg.Autoscaling.enabled(),
+		OpID:            "",
+		StartedAt:       time.Time{},
+	}
+}
+
+// stablePatchReplicas is the count the stable Deployment's patch sets; 0
+// leaves it to the environment, which for an autoscaled one is the HPA.
+func (c CanaryRollout) stablePatchReplicas() int {
+	if c.Autoscaled {
+		return 0
+	}
+	return c.StableReplicas
+}
+
+func canaryMarkerPath(env string) string {
+	return path.Join(manifestsRepoOverlaysDir, env, canaryMarkerFile)
+}
+
+// readCanaryRollout returns the canary running in env, if any.
+func readCanaryRollout(artifacts ArtifactStore, projectID, env string) (CanaryRollout, bool, error) {
+	raw, err := artifacts.ReadFile(projectID, canaryMarkerPath(env))
+	if errors.Is(err, os.ErrNotExist) {
+		return CanaryRollout{}, false, nil
+	}
+	if err != nil {
+		return CanaryRollout{}, false, err
+	}
+	var rollout CanaryRollout
+	if err = json.Unmarshal(raw, &rollout); err != nil {
+		return CanaryRollout{}, false, fmt.Errorf("read %s canary: %w", env, err)
+	}
+	return rollout, true, nil
+}
+
+// ensureNoCanary refuses a delivery into env while a canary runs there.
+func ensureNoCanary(artifacts ArtifactStore, projectID, env string) error {
+	rollout, running, err := readCanaryRollout(artifacts, projectID, env)
+	if err != nil || !running {
+		return err
+	}
+	return fmt.Errorf(
+		"%s runs canary %s at %d%%; promote or abort it first",
+		env, rollout.CanaryImage, rollout.Weight,
+	)
+}
+
+// writeOverlayCanaries writes the canary Deployment and its patch into the
+// overlay of each environment running a canary, and removes them from the
+// others.
+func writeOverlayCanaries(
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	envs []string,
+	canaries map[string]CanaryRollout,
+	bindings envCapabilityBindings,
+	storedSecrets envStoredSecrets,
+) ([]string, error) {
+	spec = normalizeProjectSpec(spec)
+	written := []string{}
+	for _, env := range envs {
+		deploymentPath := path.Join(manifestsRepoOverlaysDir, env, canaryDeploymentFile)
+		patchPath := path.Join(manifestsRepoOverlaysDir, env, canaryPatchFile)
+		rollout, running := canaries[env]
+		if !running {
+			for _, rel := range []string{deploymentPath, patchPath} {
+				if _, err := artifacts.RemoveFiles(projectID, rel); err != nil {
+					return written, err
+				}
+			}
+			continue
+		}
+		files := map[string]string{
+			deploymentPath: renderTrackDeploymentManifest(spec, canaryTrack, rollout.CanaryImage),
+			patchPath: renderDeploymentPatch(
+				spec, env, safeName(spec.Name)+"-"+canaryTrack,
+				rollout.CanaryReplicas, bindings[env], storedSecrets[env],
+			),
+		}
+		for _, rel := range sortedKeys(files) {
+			artifactPath, err := artifacts.WriteFile(projectID, rel, []byte(files[rel]))
+			if err != nil {
+				return written, err
+			}
+			written = append(written, artifactPath)
+		}
+	}
+	return written, nil
+}
+
+// planCanaryTransition settles what a promotion does about canaries. A
+// canary start plans the split against the target's current image; a
+// follow-up switches the image to roll out to the canary's or back to the
+// stable one; any other promotion is refused while a canary runs.
+func planCanaryTransition(artifacts ArtifactStore, msg ProjectOpMsg, state *promotionExecutionState) error {
+	toEnv := state.resolvedToEnv
+	if msg.Delivery.Strategy != DeliveryStrategyCanary {
+		return ensureNoCanary(artifacts, msg.ProjectID, toEnv)
+	}
+	running, active, err := readCanaryRollout(artifacts, msg.ProjectID, toEnv)
+	if err != nil {
+		return err
+	}
+	switch msg.Delivery.CanaryAction {
+	case CanaryActionPromote, CanaryActionAbort:
+		if !active {
+			return fmt.Errorf("%s has no canary running", toEnv)
+		}
+		state.canary = running
+		state.sourceImage = running.StableImage
+		if msg.Delivery.CanaryAction == CanaryActionPromote {
+			state.sourceImage = running.CanaryImage
+		}
+		return nil
+	}
+	if active {
+		return ensureNoCanary(artifacts, msg.ProjectID, toEnv)
+	}
+	if err = validateCanaryWeight(msg.Delivery.CanaryWeight); err != nil {
+		return err
+	}
+	stableImage, err := resolvePromotionSourceImage(artifacts, msg.ProjectID, toEnv, state.imageByEnv)
+	if err != nil {
+		return err
+	}
+	if stableImage == state.sourceImage {
+		return fmt.Errorf("%s already runs %s; there is nothing to canary", toEnv, stableImage)
+	}
+	state.canary = planCanaryRollout(
+		state.spec, toEnv, state.resolvedFromEnv, stableImage, state.sourceImage, msg.Delivery.CanaryWeight,
+	)
+	state.canary.OpID = msg.OpID
+	state.canary.StartedAt = time.Now().UTC()
+	return nil
+}
+
+// stageCanaryMarker records or clears the target's canary before its
+// overlays are rendered and returns the image the stable Deployment runs.
+func stageCanaryMarker(artifacts ArtifactStore, msg ProjectOpMsg, state *promotionExecutionState) (string, error) {
+	if msg.Delivery.Strategy != DeliveryStrategyCanary {
+		return state.sourceImage, nil
+	}
+	if msg.Delivery.CanaryAction != "" {
+		_, err := artifacts.RemoveFiles(msg.ProjectID, canaryMarkerPath(state.resolvedToEnv))
+		return state.sourceImage, err
+	}
+	raw, err := json.MarshalIndent(state.canary, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if _, err = artifacts.WriteFile(msg.ProjectID, canaryMarkerPath(state.resolvedToEnv), raw); err != nil {
+		return "", err
+	}
+	return state.canary.StableImage, nil
+}
+
+// canaryOutcomeMessage describes a canary op's result. A canary start or
+// abort leaves the environment's release as it was, so it records none.
+func canaryOutcomeMessage(delivery DeliveryLifecycle, rollout CanaryRollout) (string, bool) {
+	if delivery.Strategy != DeliveryStrategyCanary {
+		return "", false
+	}
+	switch delivery.CanaryAction {
+	case CanaryActionPromote:
+		return "", false
+	case CanaryActionAbort:
+		return fmt.Sprintf(
+			"aborted canary %s in %s; all replicas run %s",
+			rollout.CanaryImage, rollout.Environment, rollout.StableImage,
+		), true
+	}
+	return fmt.Sprintf(
+		"started canary %s in %s at %d%% (%d canary, %d stable replicas)",
+		rollout.CanaryImage, rollout.Environment, rollout.Weight, rollout.CanaryReplicas, rollout.StableReplicas,
+	), true
+}
+
+// deleteCanary removes the canary Deployment once an op ended the canary;
+// applying manifests without it does not.
+func (k kubectlApplier) deleteCanary(ctx context.Context, spec ProjectSpec, report *kubeApplyReport) error {
+	subStepDone := beginSubStep(ctx, "delete canary")
+	out, err := k.run(
+		ctx,
+		nil,
+		"delete", "deployment", safeName(spec.Name)+"-"+canaryTrack,
+		"-n", report.Namespace,
+		"--ignore-not-found", "-o", "name",
+	)
+	subStepDone(err)
+	if err != nil {
+		return err
+	}
+	report.Removed = strings.Fields(out)
+	return nil
+}
@@ -0,0 +1,296 @@
+//nolint:testpackage,exhaustruct // Canary tests drive the internal promotion worker and API against one store.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestPlanCanaryRollout_SplitsReplicasByWeight(t *testing.T) {
+	spec := workerRuntimeSpec("canary-plan")
+	for _, tc := range []struct {
+		replicas, weight, canary, stable, actual int
+	}{
+		{replicas: 1, weight: 10, canary: 1, stable: 1, actual: 50},
+		{replicas: 4, weight: 25, canary: 1, stable: 3, actual: 25},
+		{replicas: 10, weight: 33, canary: 3, stable: 7, actual: 30},
+		{replicas: 3, weight: 99, canary: 3, stable: 1, actual: 75},
+	} {
+		spec.Environments["staging"] = EnvConfig{Vars: map[string]string{}, Replicas: tc.replicas}
+		plan := planCanaryRollout(spec, "staging", "dev", "app:stable", "app:canary", tc.weight)
+		if plan.CanaryReplicas != tc.canary || plan.StableReplicas != tc.stable || plan.Weight != tc.actual {
+			t.Fatalf(
+				"replicas %d at %d%%: expected %d canary, %d stable, %d%%; got %+v",
+				tc.replicas, tc.weight, tc.canary, tc.stable, tc.actual, plan,
+			)
+		}
+	}
+	if err := validateCanaryWeight(0); err == nil {
+		t.Fatal("expected weight 0 to be rejected")
+	}
+	if err := validateCanaryWeight(100); err == nil {
+		t.Fatal("expected weight 100 to be rejected")
+	}
+}
+
+func TestPromotionStrategy_ValidatesStrategy(t *testing.T) {
+	for _, evt := range []PromotionEvent{
+		{Strategy: DeliveryStrategyCanary, Weight: 0},
+		{Strategy: DeliveryStrategyCanary, Weight: 100},
+		{Strategy: DeliveryStrategyCanary, Weight: 20, ToEnvs: []string{"staging"}},
+		{Strategy: DeliveryStrategyBlueGreen, Weight: 20},
+		{Strategy: DeliveryStrategyBlueGreen, ToEnvs: []string{"staging"}},
+		{Strategy: "rolling"},
+		{Weight: 20},
+	} {
+		if _, err := promotionStrategy(evt); err == nil {
+			t.Fatalf("expected %+v to be rejected", evt)
+		}
+	}
+	strategy, err := promotionStrategy(PromotionEvent{Strategy: DeliveryStrategyCanary, Weight: 20})
+	if err != nil || strategy.weight != 20 {
+		t.Fatalf("expected canary weight 20, got %+v, %v", strategy, err)
+	}
+	if _, err = promotionStrategy(PromotionEvent{Strategy: DeliveryStrategyBlueGreen}); err != nil {
+		t.Fatalf("expected blue-green to be accepted: %v", err)
+	}
+}
+
+func TestWorkers_CanaryPromotionStartsThenPromotes(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	const projectID = "project-canary"
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := workerRuntimeSpec("canary")
+	spec.Environments["staging"] = EnvConfig{Vars: map[string]string{}, Replicas: 4}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-canary-create", OpCreate, spec)
+	seedCanaryEnvironment(t, artifacts, projectID, spec, "dev", "local/canary:new")
+	seedCanaryEnvironment(t, artifacts, projectID, spec, "staging", "local/canary:old")
+
+	runPromotion := func(opID string, delivery DeliveryLifecycle) error {
+		t.Helper()
+		putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpPromote, spec)
+		delivery.Stage = DeliveryStagePromote
+		delivery.FromEnv = "dev"
+		delivery.ToEnv = "staging"
+		_, err := promotionWorkerAction(ctx, fixture.store, artifacts, ProjectOpMsg{
+			OpID:      opID,
+			Kind:      OpPromote,
+			ProjectID: projectID,
+			Spec:      spec,
+			FromEnv:   "dev",
+			ToEnv:     "staging",
+			Delivery:  delivery,
+			At:        time.Now().UTC(),
+		})
+		return err
+	}
+
+	err := runPromotion("op-canary-start", DeliveryLifecycle{Strategy: DeliveryStrategyCanary, CanaryWeight: 25})
+	if err != nil {
+		t.Fatalf("start canary: %v", err)
+	}
+	rollout, running, err := readCanaryRollout(artifacts, projectID, "staging")
+	if err != nil || !running {
+		t.Fatalf("expected a staging canary marker, got %v, %v", running, err)
+	}
+	if rollout.StableImage != "local/canary:old" || rollout.CanaryImage != "local/canary:new" ||
+		rollout.CanaryReplicas != 1 || rollout.StableReplicas != 3 || rollout.OpID != "op-canary-start" {
+		t.Fatalf("unexpected canary rollout: %+v", rollout)
+	}
+	if image, _ := readRenderedEnvImageTag(artifacts, projectID, "staging"); image != "local/canary:old" {
+		t.Fatalf("expected stable staging deployment to keep local/canary:old, got %q", image)
+	}
+	rendered, err := artifacts.ReadFile(projectID, "promotions/dev-to-staging/rendered.yaml")
+	if err != nil {
+		t.Fatalf("read canary rendered manifests: %v", err)
+	}
+	for _, want := range []string{"name: canary-canary", "image: local/canary:new", "image: local/canary:old"} {
+		if !strings.Contains(string(rendered), want) {
+			t.Fatalf("expected rendered manifests to contain %q:\n%s", want, rendered)
+		}
+	}
+	page, err := fixture.store.listProjectReleases(ctx, projectID, "staging", projectReleaseListQuery{Limit: 5})
+	if err != nil {
+		t.Fatalf("list staging releases: %v", err)
+	}
+	if len(page.Items) != 0 {
+		t.Fatalf("expected a canary start to record no release, got %d", len(page.Items))
+	}
+
+	if err = runPromotion("op-canary-blocked", DeliveryLifecycle{}); err == nil ||
+		!strings.Contains(err.Error(), "promote or abort it first") {
+		t.Fatalf("expected a plain promotion to be refused while the canary runs, got %v", err)
+	}
+
+	err = runPromotion("op-canary-promote", DeliveryLifecycle{
+		Strategy:     DeliveryStrategyCanary,
+		CanaryAction: CanaryActionPromote,
+	})
+	if err != nil {
+		t.Fatalf("promote canary: %v", err)
+	}
+	if _, running, _ = readCanaryRollout(artifacts, projectID, "staging"); running {
+		t.Fatal("expected promote to clear the staging canary marker")
+	}
+	for _, rel := range []string{"canary-deployment.yaml", "canary-patch.yaml"} {
+		if _, readErr := artifacts.ReadFile(projectID, manifestsRepoOverlaysDir+"/staging/"+rel); readErr == nil {
+			t.Fatalf("expected promote to remove overlays/staging/%s", rel)
+		}
+	}
+	if image, _ := readRenderedEnvImageTag(artifacts, projectID, "staging"); image != "local/canary:new" {
+		t.Fatalf("expected staging to run local/canary:new after promote, got %q", image)
+	}
+	page, err = fixture.store.listProjectReleases(ctx, projectID, "staging", projectReleaseListQuery{Limit: 5})
+	if err != nil {
+		t.Fatalf("list staging releases after promote: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].OpID != "op-canary-promote" {
+		t.Fatalf("expected the promote op to record the staging release, got %+v", page.Items)
+	}
+}
+
+func TestAPI_CanaryPreviewAndFollowUpOps(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	const projectID = "project-canary-api"
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := workerRuntimeSpec("canary-api")
+	spec.Environments["staging"] = EnvConfig{Vars: map[string]string{}, Replicas: 4}
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-canary-api-create", OpCreate, spec)
+	seedCanaryEnvironment(t, artifacts, projectID, spec, "dev", "local/canary-api:new")
+	seedCanaryEnvironment(t, artifacts, projectID, spec, "staging", "local/canary-api:old")
+
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	received := make(chan ProjectOpMsg, 2)
+	sub, err := fixture.nc.Subscribe(natsSubject(subjectPromotionStart), func(msg *nats.Msg) {
+		var opMsg ProjectOpMsg
+		if json.Unmarshal(msg.Data, &opMsg) == nil {
+			received <- opMsg
+		}
+	})
+	if err != nil {
+		t.Fatalf("subscribe promotion start: %v", err)
+	}
+	defer func() { _ = sub.Unsubscribe() }()
+
+	send := func(method, target, body string) (int, []byte) {
+		t.Helper()
+		req, reqErr := http.NewRequestWithContext(context.Background(), method, srv.URL+target, strings.NewReader(body))
+		if reqErr != nil {
+			t.Fatalf("build %s %s: %v", method, target, reqErr)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, doErr := srv.Client().Do(req)
+		if doErr != nil {
+			t.Fatalf("%s %s: %v", method, target, doErr)
+		}
+		defer resp.Body.Close()
+		var raw json.RawMessage
+		_ = json.NewDecoder(resp.Body).Decode(&raw)
+		return resp.StatusCode, raw
+	}
+	plainBody := `{"project_id":"` + projectID + `","from_env":"dev","to_env":"staging"}`
+	canaryBody := strings.TrimSuffix(plainBody, "}") + `,"strategy":"canary","weight":50}`
+	canaryPath := "/api/projects/" + projectID + "/environments/staging/canary"
+
+	status, raw := send(http.MethodPost, "/api/events/promotion/preview", canaryBody)
+	if status != http.StatusOK {
+		t.Fatalf("preview canary: expected 200, got %d %s", status, raw)
+	}
+	var preview PromotionPreviewResponse
+	if err = json.Unmarshal(raw, &preview); err != nil {
+		t.Fatalf("decode preview: %v", err)
+	}
+	split := preview.TrafficSplit
+	if split == nil || split.CanaryReplicas != 2 || split.StableReplicas != 2 ||
+		split.CanaryImage != "local/canary-api:new" || split.StableImage != "local/canary-api:old" {
+		t.Fatalf("expected a 2/2 traffic split from old to new, got %+v", split)
+	}
+
+	status, raw = send(http.MethodPost, "/api/events/promotion", strings.Replace(canaryBody, "50", "100", 1))
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected weight 100 to be rejected, got %d %s", status, raw)
+	}
+	if status, raw = send(http.MethodGet, canaryPath, ""); status != http.StatusNotFound {
+		t.Fatalf("expected 404 with no canary running, got %d %s", status, raw)
+	}
+	if status, raw = send(http.MethodPost, canaryPath+"/promote", ""); status != http.StatusConflict {
+		t.Fatalf("expected 409 promoting a missing canary, got %d %s", status, raw)
+	}
+
+	rollout := planCanaryRollout(spec, "staging", "dev", "local/canary-api:old", "local/canary-api:new", 50)
+	marker, _ := json.Marshal(rollout)
+	if _, err = artifacts.WriteFile(projectID, canaryMarkerPath("staging"), marker); err != nil {
+		t.Fatalf("write canary marker: %v", err)
+	}
+
+	if status, raw = send(http.MethodGet, canaryPath, ""); status != http.StatusOK ||
+		!strings.Contains(string(raw), `"canary_image":"local/canary-api:new"`) {
+		t.Fatalf("expected the running canary, got %d %s", status, raw)
+	}
+	status, raw = send(http.MethodPost, "/api/events/promotion", plainBody)
+	if status != http.StatusConflict {
+		t.Fatalf("expected 409 promoting into a running canary, got %d %s", status, raw)
+	}
+	status, raw = send(http.MethodPost, "/api/events/promotion/preview", canaryBody)
+	if status != http.StatusOK || !strings.Contains(string(raw), transitionBlockerCanaryRunning) {
+		t.Fatalf("expected preview to report %s, got %d %s", transitionBlockerCanaryRunning, status, raw)
+	}
+	if status, raw = send(http.MethodPost, canaryPath+"/pause", ""); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown canary action, got %d %s", status, raw)
+	}
+
+	if status, raw = send(http.MethodPost, canaryPath+"/abort", ""); status != http.StatusAccepted {
+		t.Fatalf("expected 202 aborting the canary, got %d %s", status, raw)
+	}
+	select {
+	case msg := <-received:
+		if msg.Kind != OpPromote || msg.FromEnv != "dev" || msg.ToEnv != "staging" ||
+			msg.Delivery.Strategy != DeliveryStrategyCanary || msg.Delivery.CanaryAction != CanaryActionAbort {
+			t.Fatalf("unexpected abort op message: %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the abort op")
+	}
+}
+
+func seedCanaryEnvironment(
+	t *testing.T,
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	env string,
+	image string,
+) {
+	t.Helper()
+	msg := ProjectOpMsg{
+		OpID:      "op-canary-seed-" + env,
+		Kind:      OpCreate,
+		ProjectID: projectID,
+		Spec:      spec,
+		DeployEnv: env,
+		Delivery: DeliveryLifecycle{
+			Stage:       DeliveryStageDeploy,
+			Environment: env,
+		},
+		At: time.Now().UTC(),
+	}
+	_, err := runManifestApplyForEnvironment(context.Background(), nil, artifacts, msg, spec, image, env)
+	if err != nil {
+		t.Fatalf("seed %s deployment artifacts: %v", env, err)
+	}
+}
@@ -0,0 +1,486 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var conventionalCommitPattern = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+////////////////////////////////////////////////////////////////////////////////
+// Release notes: when a release record is written, the source commits since
+// the environment's previous release are drafted into a changelog on the
+// record, next to the image and config var changes since that release. The
+// notes are also written as a markdown artifact, release-notes/<id>/notes.md,
+// beside a copy of the release's Deployment that the next release's config
+// diff reads. PUT /api/projects/{id}/releases/{release_id}/notes replaces the
+// text; the commit list and deltas stay as drafted.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	releaseNotesArtifactDir   = "release-notes"
+	releaseNotesArtifactFile  = "notes.md"
+	releaseNotesFormatMD      = "markdown"
+	releaseNotesMaxCommits    = 200
+	releaseNotesMaxTextLength = 20000
+	releaseNotesWriteAttempts = 5
+	releaseNotesHashLength    = 12
+	releaseNotesOtherType     = "other"
+)
+
+// releaseNotesRequest is the body of PUT .../releases/{release_id}/notes.
+type releaseNotesRequest struct {
+	Text string `json:"text"`
+}
+
+// releaseNotesSectionOrder lists the sections notes show, in order, with
+// their titles. Commits of any other type land in "other".
+func releaseNotesSectionOrder() []ReleaseNotesSection {
+	titles := [][2]string{
+		{"feat", "Features"},
+		{"fix", "Bug Fixes"},
+		{"perf", "Performance"},
+		{"refactor", "Refactoring"},
+		{"revert", "Reverts"},
+		{"docs", "Documentation"},
+		{"test", "Tests"},
+		{"build", "Build"},
+		{"ci", "CI"},
+		{"chore", "Chores"},
+		{releaseNotesOtherType, "Other Changes"},
+	}
+	sections := make([]ReleaseNotesSection, 0, len(titles))
+	for _, title := range titles {
+		sections = append(sections, ReleaseNotesSection{Type: title[0], Title: title[1], Commits: nil})
+	}
+	return sections
+}
+
+func releaseNotesArtifactPath(releaseID string) string {
+	return path.Join(releaseNotesArtifactDir, releaseID, releaseNotesArtifactFile)
+}
+
+func releaseConfigSnapshotPath(releaseID string) string {
+	return path.Join(releaseNotesArtifactDir, releaseID, manifestFileDeployment)
+}
+
+// draftReleaseNotes builds the notes for release from the project's source
+// repo and the environment's previous release. A rewrite of a release that
+// already has notes (a redelivered step) keeps them, edits included.
+// Rollbacks ship old code and get none.
+func draftReleaseNotes(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	release ReleaseRecord,
+) (*ReleaseNotes, error) {
+	if release.OpKind == OpRollback || store == nil || artifacts == nil {
+		return nil, nil //nolint:nilnil // No notes is the normal answer here, not a failure.
+	}
+	previous, found, err := store.getProjectCurrentRelease(ctx, release.ProjectID, release.Environment)
+	if err != nil {
+		return nil, err
+	}
+	if found && previous.ID == release.ID {
+		return previous.Notes, nil
+	}
+	notes := &ReleaseNotes{
+		FromCommit:        previous.SourceCommit,
+		ToCommit:          release.SourceCommit,
+		Sections:          []ReleaseNotesSection{},
+		Truncated:         false,
+		Text:              "",
+		EditedBy:          "",
+		EditedAt:          time.Time{},
+		PreviousReleaseID: previous.ID,
+		Image:             releaseImageDelta(previous.Image, release.Image),
+		Config:            nil,
+		Path:              releaseNotesArtifactPath(release.ID),
+	}
+	if notes.Config, err = releaseConfigDelta(artifacts, previous, found, release); err != nil {
+		return nil, err
+	}
+	if err = addReleaseNoteCommits(ctx, artifacts, release.ProjectID, notes); err != nil {
+		return nil, err
+	}
+	notes.Text = renderReleaseNotes(notes)
+	return notes, nil
+}
+
+// addReleaseNoteCommits fills notes' sections with the source commits after
+// FromCommit up to ToCommit. Without a ToCommit there are none to list.
+func addReleaseNoteCommits(ctx context.Context, artifacts ArtifactStore, projectID string, notes *ReleaseNotes) error {
+	if notes.ToCommit == "" {
+		return nil
+	}
+	commits, truncated, err := gitCommitsSince(
+		ctx, sourceRepoDir(artifacts, projectID), notes.ToCommit, notes.FromCommit, releaseNotesMaxCommits,
+	)
+	if err != nil {
+		return err
+	}
+	entries := make([]releaseNoteEntry, 0, len(commits))
+	for _, commit := range commits {
+		entries = append(entries, parseReleaseNoteCommit(commit.Hash.String(), commit.Message))
+	}
+	notes.Sections = groupReleaseNotes(entries)
+	notes.Truncated = truncated
+	return nil
+}
+
+func releaseImageDelta(from, to string) *ReleaseCompareDelta {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	return &ReleaseCompareDelta{Changed: from != to, From: from, To: to, Added: nil, Removed: nil, Updated: nil}
+}
+
+// releaseConfigDelta diffs the config vars of release's Deployment against
+// the previous release's kept copy. An environment's first release adds
+// every var.
+func releaseConfigDelta(
+	artifacts ArtifactStore,
+	previous ReleaseRecord,
+	found bool,
+	release ReleaseRecord,
+) (*ReleaseCompareDelta, error) {
+	fromVars := map[string]string{}
+	if found {
+		raw, err := artifacts.ReadFile(release.ProjectID, releaseConfigSnapshotPath(previous.ID))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil //nolint:nilnil // The previous config is unknown, so there is no delta to report.
+		}
+		if err != nil {
+			return nil, err
+		}
+		fromVars = parseDeploymentEnvVars(raw)
+	}
+	raw, err := releaseDeploymentSnapshot(artifacts, release.ProjectID, release)
+	if err != nil {
+		return nil, err
+	}
+	toVars := parseDeploymentEnvVars(raw)
+	added, removed, updated := diffStringMap(fromVars, toVars)
+	return &ReleaseCompareDelta{
+		Changed: len(added) > 0 || len(removed) > 0 || len(updated) > 0,
+		From:    stableConfigFingerprint(fromVars),
+		To:      stableConfigFingerprint(toVars),
+		Added:   added,
+		Removed: removed,
+		Updated: updated,
+	}, nil
+}
+
+// keepReleaseConfigSnapshot copies the Deployment release rendered next to
+// its notes, where later deliveries into the same place cannot overwrite it.
+func keepReleaseConfigSnapshot(artifacts ArtifactStore, release ReleaseRecord) error {
+	raw, err := releaseDeploymentSnapshot(artifacts, release.ProjectID, release)
+	if err != nil || len(raw) == 0 {
+		return err
+	}
+	_, err = artifacts.WriteFile(release.ProjectID, releaseConfigSnapshotPath(release.ID), raw)
+	return err
+}
+
+// writeReleaseNotesArtifact writes release's notes as a markdown document.
+func writeReleaseNotesArtifact(artifacts ArtifactStore, release ReleaseRecord) error {
+	if artifacts == nil || release.Notes == nil || release.Notes.Path == "" {
+		return nil
+	}
+	_, err := artifacts.WriteFile(release.ProjectID, release.Notes.Path, []byte(renderReleaseNotesDocument(release)))
+	return err
+}
+
+// releaseNoteEntry is a parsed commit before it is placed in a section.
+type releaseNoteEntry struct {
+	kind   string
+	commit ReleaseNoteCommit
+}
+
+// parseReleaseNoteCommit reads a commit message's subject as a
+// conventional commit ("feat(api)!: add x"); other subjects are kept whole
+// under "other".
+func parseReleaseNoteCommit(hash, message string) releaseNoteEntry {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	subject = strings.TrimSpace(subject)
+	entry := releaseNoteEntry{
+		kind: releaseNotesOtherType,
+		commit: ReleaseNoteCommit{
+			Hash:     hash[:min(len(hash), releaseNotesHashLength)],
+			Scope:    "",
+			Subject:  subject,
+			Breaking: strings.Contains(body, "BREAKING CHANGE:"),
+		},
+	}
+	match := conventionalCommitPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return entry
+	}
+	entry.kind = strings.ToLower(match[1])
+	entry.commit.Scope = strings.TrimSpace(match[2])
+	entry.commit.Subject = strings.TrimSpace(match[4])
+	entry.commit.Breaking = entry.commit.Breaking || match[3] == "!"
+	return entry
+}
+
+// groupReleaseNotes places entries in sections, keeping commit order and
+// dropping empty sections. With no conventional subjects at all there is
+// a single "Changes" section.
+func groupReleaseNotes(entries []releaseNoteEntry) []ReleaseNotesSection {
+	sections := releaseNotesSectionOrder()
+	index := map[string]int{}
+	for i, section := range sections {
+		index[section.Type] = i
+	}
+	conventional := false
+	for _, entry := range entries {
+		i, known := index[entry.kind]
+		if !known {
+			i = index[releaseNotesOtherType]
+		}
+		conventional = conventional || entry.kind != releaseNotesOtherType
+		sections[i].Commits = append(sections[i].Commits, entry.commit)
+	}
+	out := []ReleaseNotesSection{}
+	for _, section := range sections {
+		if len(section.Commits) > 0 {
+			out = append(out, section)
+		}
+	}
+	if !conventional && len(out) == 1 {
+		out[0].Title = "Changes"
+	}
+	return out
+}
+
+func renderReleaseNotes(notes *ReleaseNotes) string {
+	if notes.ToCommit == "" {
+		return "No source commit was recorded for this release.\n"
+	}
+	if len(notes.Sections) == 0 {
+		return "No source changes since the previous release.\n"
+	}
+	var b strings.Builder
+	for i, section := range notes.Sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n\n", section.Title)
+		for _, commit := range section.Commits {
+			b.WriteString("- ")
+			if commit.Breaking {
+				b.WriteString("**BREAKING** ")
+			}
+			if commit.Scope != "" {
+				fmt.Fprintf(&b, "**%s:** ", commit.Scope)
+			}
+			fmt.Fprintf(&b, "%s (%s)\n", commit.Subject, commit.Hash)
+		}
+	}
+	if notes.Truncated {
+		fmt.Fprintf(&b, "\nOnly the latest %d commits are listed.\n", releaseNotesMaxCommits)
+	}
+	return b.String()
+}
+
+// renderReleaseNotesDocument renders release's notes artifact: what it
+// delivered and what changed since the previous release, then the text.
+func renderReleaseNotesDocument(release ReleaseRecord) string {
+	notes := release.Notes
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Release %s (%s)\n\n", release.ID, release.Environment)
+	fmt.Fprintf(&b, "- Op: %s `%s`\n", release.OpKind, release.OpID)
+	if release.FromEnv != "" {
+		fmt.Fprintf(&b, "- From: %s\n", release.FromEnv)
+	}
+	if notes.PreviousReleaseID != "" {
+		fmt.Fprintf(&b, "- Previous release: %s\n", notes.PreviousReleaseID)
+	} else {
+		fmt.Fprintf(&b, "- Previous release: none\n")
+	}
+	switch {
+	case notes.Image == nil:
+	case notes.Image.Changed:
+		fmt.Fprintf(&b, "- Image: `%s` -> `%s`\n", notes.Image.From, notes.Image.To)
+	default:
+		fmt.Fprintf(&b, "- Image: `%s` (unchanged)\n", notes.Image.To)
+	}
+	b.WriteString("- Config vars: " + describeConfigDelta(notes.Config) + "\n")
+	if notes.ToCommit != "" {
+		fmt.Fprintf(&b, "- Source: `%s`\n", shortCommitRange(notes.FromCommit, notes.ToCommit))
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.TrimSpace(notes.Text))
+	b.WriteString("\n")
+	return b.String()
+}
+
+func describeConfigDelta(delta *ReleaseCompareDelta) string {
+	if delta == nil {
+		return "not compared (the previous release's config was not kept)"
+	}
+	if !delta.Changed {
+		return "unchanged"
+	}
+	parts := []string{}
+	for _, change := range []struct {
+		verb  string
+		names []string
+	}{{"added", delta.Added}, {"removed", delta.Removed}, {"updated", delta.Updated}} {
+		if len(change.names) > 0 {
+			parts = append(parts, change.verb+" "+strings.Join(change.names, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func shortCommitRange(from, to string) string {
+	to = to[:min(len(to), releaseNotesHashLength)]
+	if from == "" {
+		return to
+	}
+	return from[:min(len(from), releaseNotesHashLength)] + ".." + to
+}
+
+// updateReleaseNotesText replaces the text of a release's notes. Writes are
+// revision-checked so a concurrent edit is not lost silently.
+func (s *Store) updateReleaseNotesText(ctx context.Context, releaseID, text, editedBy string) (ReleaseRecord, error) {
+	defer s.observe("updateReleaseNotesText", time.Now())
+	var err error
+	for range releaseNotesWriteAttempts {
+		release, rev, readErr := s.readRelease(ctx, releaseID)
+		if readErr != nil {
+			return ReleaseRecord{}, readErr
+		}
+		if release.Notes == nil {
+			release.Notes = &ReleaseNotes{
+				FromCommit:        "",
+				ToCommit:          release.SourceCommit,
+				Sections:          []ReleaseNotesSection{},
+				Truncated:         false,
+				Text:              "",
+				EditedBy:          "",
+				EditedAt:          time.Time{},
+				PreviousReleaseID: "",
+				Image:             nil,
+				Config:            nil,
+				Path:              releaseNotesArtifactPath(release.ID),
+			}
+		}
+		release.Notes.Text = text
+		release.Notes.EditedBy = editedBy
+		release.Notes.EditedAt = time.Now().UTC()
+		body, marshalErr := json.Marshal(release)
+		if marshalErr != nil {
+			return ReleaseRecord{}, marshalErr
+		}
+		_, err = s.kvOps.Update(ctx, kvReleaseKeyPrefix+release.ID, body, rev.Revision)
+		if err == nil {
+			return release, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return ReleaseRecord{}, err
+		}
+	}
+	return ReleaseRecord{}, fmt.Errorf("release %s kept changing: %w", releaseID, err)
+}
+
+// handleProjectReleaseNotes serves a release's notes, as JSON or with
+// ?format=markdown as their artifact's document, and PUT replaces their
+// text. Releases without drafted notes (rollbacks) can be given some.
+func (a *API) handleProjectReleaseNotes(w http.ResponseWriter, r *http.Request, projectID, releaseID string) {
+	switch r.Method {
+	case http.MethodGet:
+		a.getProjectReleaseNotes(w, r, projectID, releaseID)
+	case http.MethodPut:
+		a.putProjectReleaseNotes(w, r, projectID, releaseID)
+	default:
+		writeAPIError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) getProjectReleaseNotes(w http.ResponseWriter, r *http.Request, projectID, releaseID string) {
+	release, ok := a.readProjectRelease(w, r, projectID, releaseID)
+	if !ok {
+		return
+	}
+	if release.Notes == nil {
+		writeAPIError(w, "release has no notes", http.StatusNotFound)
+		return
+	}
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		writeJSON(w, http.StatusOK, release.Notes)
+	case releaseNotesFormatMD:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, renderReleaseNotesDocument(release))
+	default:
+		writeAPIError(w, "format must be json or markdown", http.StatusBadRequest)
+	}
+}
+
+// readProjectRelease reads a release of the project, writing the error
+// response when it cannot.
+func (a *API) readProjectRelease(
+	w http.ResponseWriter,
+	r *http.Request,
+	projectID, releaseID string,
+) (ReleaseRecord, bool) {
+	release, err := a.store.GetRelease(r.Context(), releaseID)
+	if err != nil || release.ProjectID != strings.TrimSpace(projectID) {
+		if err == nil || errors.Is(err, jetstream.ErrKeyNotFound) {
+			writeAPIError(w, "not found", http.StatusNotFound)
+			return ReleaseRecord{}, false
+		}
+		writeAPIError(w, "failed to read release", http.StatusInternalServerError)
+		return ReleaseRecord{}, false
+	}
+	return release, true
+}
+
+func (a *API) putProjectReleaseNotes(w http.ResponseWriter, r *http.Request, projectID, releaseID string) {
+	var req releaseNotesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if utf8.RuneCountInString(text) > releaseNotesMaxTextLength {
+		writeAPIError(w, "text is too long", http.StatusBadRequest)
+		return
+	}
+	release, ok := a.readProjectRelease(w, r, projectID, releaseID)
+	if !ok {
+		return
+	}
+	editedBy := ""
+	if principal, found := requestPrincipal(r.Context()); found {
+		editedBy = principal.Name
+	}
+	release, err := a.store.updateReleaseNotesText(r.Context(), release.ID, text, editedBy)
+	if err != nil {
+		writeAPIError(w, "failed to save release notes", http.StatusInternalServerError)
+		return
+	}
+	if err = writeReleaseNotesArtifact(a.artifacts, release); err != nil {
+		writeAPIError(w, "failed to write release notes artifact", http.StatusInternalServerError)
+		return
+	}
+	detail, err := a.releaseDetailWithHold(r.Context(), release)
+	if err != nil {
+		writeAPIError(w, "failed to read release holds", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, detail)
+}
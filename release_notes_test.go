@@ -0,0 +1,257 @@
+//nolint:testpackage,exhaustruct // Release notes tests commit to a source repo and call internal persistence.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReleaseNotes_DraftedFromCommitsSincePreviousReleaseAndEditable(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	store, artifacts := fixture.api.store, fixture.api.artifacts
+
+	dir := sourceRepoDir(artifacts, fixture.projectID)
+	if err := ensureLocalGitRepo(ctx, dir); err != nil {
+		t.Fatalf("init source repo: %v", err)
+	}
+	commit := func(message string) string {
+		t.Helper()
+		file := filepath.Join(dir, "CHANGES")
+		previous, _ := os.ReadFile(file)
+		if err := os.WriteFile(file, append(previous, message+"\n"...), 0o600); err != nil {
+			t.Fatalf("write source file: %v", err)
+		}
+		if _, err := gitCommitIfChanged(ctx, dir, message); err != nil {
+			t.Fatalf("commit %q: %v", message, err)
+		}
+		hash, err := gitRevParse(ctx, dir, "HEAD")
+		if err != nil {
+			t.Fatalf("rev-parse: %v", err)
+		}
+		return hash
+	}
+	release := func(opID string, kind OperationKind, sourceCommit string) ReleaseRecord {
+		t.Helper()
+		record := ReleaseRecord{
+			ID:           releaseIDForOp(opID),
+			ProjectID:    fixture.projectID,
+			Environment:  "prod",
+			OpID:         opID,
+			OpKind:       kind,
+			ToEnv:        "prod",
+			SourceCommit: sourceCommit,
+		}
+		if err := persistReleaseRecord(ctx, store, artifacts, record); err != nil {
+			t.Fatalf("persist release %s: %v", opID, err)
+		}
+		stored, err := store.GetRelease(ctx, record.ID)
+		if err != nil {
+			t.Fatalf("get release %s: %v", opID, err)
+		}
+		return stored
+	}
+
+	base := commit("chore: scaffold service")
+	first := release("op-notes-1", OpRelease, base)
+	if first.Notes == nil || len(first.Notes.Sections) != 1 || first.Notes.Sections[0].Title != "Chores" {
+		t.Fatalf("expected the first release to list the whole history, got %+v", first.Notes)
+	}
+
+	commit("feat(api): add health endpoint")
+	commit("Update README")
+	commit("fix!: drop the v1 route")
+	head := commit("fix: handle an empty body")
+	second := release("op-notes-2", OpRelease, head)
+	notes := second.Notes
+	if notes == nil || notes.FromCommit != base || notes.ToCommit != head || notes.Truncated {
+		t.Fatalf("expected notes covering base..head, got %+v", notes)
+	}
+	titles := []string{}
+	for _, section := range notes.Sections {
+		titles = append(titles, fmt.Sprintf("%s:%d", section.Title, len(section.Commits)))
+	}
+	if strings.Join(titles, ",") != "Features:1,Bug Fixes:2,Other Changes:1" {
+		t.Fatalf("unexpected sections %v", titles)
+	}
+	if fixes := notes.Sections[1].Commits; fixes[0].Subject != "handle an empty body" || !fixes[1].Breaking {
+		t.Fatalf("expected newest-first fixes with the breaking one flagged, got %+v", fixes)
+	}
+	if !strings.Contains(notes.Text, "## Features\n\n- **api:** add health endpoint (") ||
+		!strings.Contains(notes.Text, "- **BREAKING** drop the v1 route (") {
+		t.Fatalf("unexpected draft text:\n%s", notes.Text)
+	}
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	url := fmt.Sprintf("%s/api/projects/%s/releases/%s/notes", srv.URL, fixture.projectID, second.ID)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, url, strings.NewReader(`{"text":"Health checks."}`))
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("put notes: %v", err)
+	}
+	defer resp.Body.Close()
+	var edited releaseDetailResponse
+	if err = json.NewDecoder(resp.Body).Decode(&edited); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with the release, got %d (%v)", resp.StatusCode, err)
+	}
+	if edited.Notes.Text != "Health checks." || edited.Notes.EditedAt.IsZero() || len(edited.Notes.Sections) != 3 {
+		t.Fatalf("expected edited text with sections kept, got %+v", edited.Notes)
+	}
+
+	// A redelivered step rewrites the release without losing the edit.
+	if again := release("op-notes-2", OpRelease, head); again.Notes.Text != "Health checks." {
+		t.Fatalf("expected the edit kept on rewrite, got %q", again.Notes.Text)
+	}
+	if rollback := release("op-notes-3", OpRollback, base); rollback.Notes != nil {
+		t.Fatalf("expected no notes on a rollback, got %+v", rollback.Notes)
+	}
+
+	missing, _ := http.NewRequestWithContext(ctx, http.MethodPut,
+		fmt.Sprintf("%s/api/projects/%s/releases/release-missing/notes", srv.URL, fixture.projectID),
+		strings.NewReader(`{"text":"x"}`))
+	missingResp, err := srv.Client().Do(missing)
+	if err != nil {
+		t.Fatalf("put missing notes: %v", err)
+	}
+	defer missingResp.Body.Close()
+	if missingResp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing release, got %d", missingResp.StatusCode)
+	}
+}
+
+func TestReleaseNotes_NonConventionalHistoryIsOneSection(t *testing.T) {
+	entries := []releaseNoteEntry{
+		parseReleaseNoteCommit("aaaaaaaaaaaaaaaa", "Add login page\n\nBREAKING CHANGE: sessions reset"),
+		parseReleaseNoteCommit("bbbbbbbbbbbbbbbb", "Tidy up"),
+	}
+	sections := groupReleaseNotes(entries)
+	if len(sections) != 1 || sections[0].Title != "Changes" || !sections[0].Commits[0].Breaking ||
+		sections[0].Commits[0].Hash != "aaaaaaaaaaaa" {
+		t.Fatalf("expected one Changes section, got %+v", sections)
+	}
+}
+
+func TestReleaseNotes_CarryImageAndConfigDeltasAsAnArtifact(t *testing.T) {
+	fixture := newProjectReleaseAPIFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	store, artifacts := fixture.api.store, fixture.api.artifacts
+
+	const configPath = "releases/staging-to-prod/deployment.yaml"
+	deployment := func(image string, vars ...string) string {
+		lines := []string{
+			"apiVersion: apps/v1", "kind: Deployment", "metadata:", "  name: app", "spec:", "  template:",
+			"    spec:", "      containers:", "        - name: app", "          image: " + image, "          env:",
+		}
+		for i := 0; i < len(vars); i += 2 {
+			lines = append(lines, "            - name: "+vars[i], "              value: "+vars[i+1])
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+	release := func(opID, image, config string) ReleaseRecord {
+		t.Helper()
+		if _, err := artifacts.WriteFile(fixture.projectID, configPath, []byte(config)); err != nil {
+			t.Fatalf("write deployment: %v", err)
+		}
+		record := ReleaseRecord{
+			ID:          releaseIDForOp(opID),
+			ProjectID:   fixture.projectID,
+			Environment: "prod",
+			OpID:        opID,
+			OpKind:      OpRelease,
+			FromEnv:     "staging",
+			ToEnv:       "prod",
+			Image:       image,
+			ConfigPath:  configPath,
+		}
+		if err := persistReleaseRecord(ctx, store, artifacts, record); err != nil {
+			t.Fatalf("persist release %s: %v", opID, err)
+		}
+		stored, err := store.GetRelease(ctx, record.ID)
+		if err != nil {
+			t.Fatalf("get release %s: %v", opID, err)
+		}
+		return stored
+	}
+
+	first := release("op-delta-1", "local/app:1", deployment("local/app:1", "LOG_LEVEL", "info", "OLD", "x"))
+	if first.Notes == nil || first.Notes.Config == nil ||
+		strings.Join(first.Notes.Config.Added, ",") != "LOG_LEVEL,OLD" {
+		t.Fatalf("expected the first release to add every var, got %+v", first.Notes)
+	}
+	// The second release overwrites the shared config path; the first
+	// release's kept copy is what the diff reads.
+	second := release("op-delta-2", "local/app:2", deployment("local/app:2", "LOG_LEVEL", "warn", "NEW", "y"))
+	notes := second.Notes
+	if notes == nil || notes.PreviousReleaseID != first.ID || notes.Image == nil || !notes.Image.Changed ||
+		notes.Image.From != "local/app:1" || notes.Image.To != "local/app:2" {
+		t.Fatalf("expected an image delta from the first release, got %+v", notes)
+	}
+	config := notes.Config
+	if config == nil || strings.Join(config.Added, ",") != "NEW" || strings.Join(config.Removed, ",") != "OLD" ||
+		strings.Join(config.Updated, ",") != "LOG_LEVEL" {
+		t.Fatalf("unexpected config delta %+v", config)
+	}
+	doc, err := artifacts.ReadFile(fixture.projectID, releaseNotesArtifactPath(second.ID))
+	if err != nil {
+		t.Fatalf("read notes artifact: %v", err)
+	}
+	for _, want := range []string{
+		"# Release " + second.ID + " (prod)",
+		"- Image: `local/app:1` -> `local/app:2`",
+		"- Config vars: added NEW; removed OLD; updated LOG_LEVEL",
+		"No source commit was recorded for this release.",
+	} {
+		if !strings.Contains(string(doc), want) {
+			t.Fatalf("expected the notes artifact to contain %q:\n%s", want, doc)
+		}
+	}
+
+	srv := httptest.NewServer(fixture.api.routes())
+	defer srv.Close()
+	get := func(target string) (*http.Response, []byte) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+target, nil)
+		resp, getErr := srv.Client().Do(req)
+		if getErr != nil {
+			t.Fatalf("GET %s: %v", target, getErr)
+		}
+		defer resp.Body.Close()
+		var body strings.Builder
+		_, _ = io.Copy(&body, resp.Body)
+		return resp, []byte(body.String())
+	}
+	notesPath := fmt.Sprintf("/api/projects/%s/releases/%s/notes", fixture.projectID, second.ID)
+	resp, body := get(notesPath)
+	var served ReleaseNotes
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &served) != nil || served.Path != notes.Path {
+		t.Fatalf("expected the notes as JSON, got %d %s", resp.StatusCode, body)
+	}
+	resp, body = get(notesPath + "?format=markdown")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/markdown") ||
+		string(body) != string(doc) {
+		t.Fatalf("expected the notes document, got %d %s", resp.StatusCode, body)
+	}
+	if resp, body = get(notesPath + "?format=pdf"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d %s", resp.StatusCode, body)
+	}
+
+	resp, body = get(fmt.Sprintf(
+		"/api/projects/%s/releases/compare?from=%s&to=%s", fixture.projectID, first.ID, second.ID,
+	))
+	var compared ReleaseCompareResponse
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &compared) != nil || compared.Notes == nil ||
+		compared.Notes.Config == nil || strings.Join(compared.Notes.Config.Added, ",") != "NEW" {
+		t.Fatalf("expected compare to carry notes with the config delta, got %d %s", resp.StatusCode, body)
+	}
+}
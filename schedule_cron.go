@@ -0,0 +1,206 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Cron expressions for op schedules: five fields (minute hour day-of-month
+// month day-of-week) built from *, lists, ranges, and steps, or one of the
+// @hourly, @daily, @weekly, and @monthly shorthands. Month and day names are
+// not accepted. As in classic cron, a day matches when either day field
+// does, unless one of them is *.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	cronFieldCount    = 5
+	cronMinuteMax     = 59
+	cronHourMax       = 23
+	cronDayOfMonthMax = 31
+	cronMonthMax      = 12
+	// cronDayOfWeekMax accepts 7 as Sunday, like 0.
+	cronDayOfWeekMax = 7
+	// cronSearchHorizon bounds the search for the next run; an expression
+	// with no run in it (say, 30 February) never runs.
+	cronSearchHorizon = 5 * 366 * 24 * time.Hour
+	cronRangeParts    = 2
+)
+
+// cronSchedule holds one bit per allowed value of each field.
+type cronSchedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	anyDay     bool // day-of-month starts with *, as in * or */2
+	anyWeekday bool // day-of-week starts with *
+}
+
+func parseCronExpression(raw string) (cronSchedule, error) {
+	expr := strings.TrimSpace(raw)
+	if strings.HasPrefix(expr, "@") {
+		expanded, ok := cronShorthand(expr)
+		if !ok {
+			return cronSchedule{}, fmt.Errorf("unknown cron shorthand %q", expr)
+		}
+		expr = expanded
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != cronFieldCount {
+		return cronSchedule{}, fmt.Errorf(
+			"cron %q must have %d fields: minute hour day-of-month month day-of-week", raw, cronFieldCount,
+		)
+	}
+	var (
+		schedule cronSchedule
+		err      error
+	)
+	if schedule.minute, err = parseCronField(fields[0], "minute", 0, cronMinuteMax); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.hour, err = parseCronField(fields[1], "hour", 0, cronHourMax); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.dayOfMonth, err = parseCronField(fields[2], "day-of-month", 1, cronDayOfMonthMax); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.month, err = parseCronField(fields[3], "month", 1, cronMonthMax); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.dayOfWeek, err = parseCronField(fields[4], "day-of-week", 0, cronDayOfWeekMax); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.dayOfWeek&(1<<cronDayOfWeekMax) != 0 {
+		schedule.dayOfWeek |= 1
+	}
+	// As in Vixie cron, a day field starting with *, such as */2, counts as
+	// unrestricted: a day must then match both fields rather than either.
+	schedule.anyDay = strings.HasPrefix(fields[2], "*")
+	schedule.anyWeekday = strings.HasPrefix(fields[4], "*")
+	return schedule, nil
+}
+
+func cronShorthand(expr string) (string, bool) {
+	switch expr {
+	case "@hourly":
+		return "0 * * * *", true
+	case "@daily", "@midnight":
+		return "0 0 * * *", true
+	case "@weekly":
+		return "0 0 * * 0", true
+	case "@monthly":
+		return "0 0 1 * *", true
+	default:
+		return "", false
+	}
+}
+
+// parseCronField turns one comma-separated field into a bit set over
+// [lo, hi].
+func parseCronField(field, name string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(field, ",") {
+		first, last, step, err := parseCronRange(part, lo, hi)
+		if err != nil {
+			return 0, fmt.Errorf("cron %s %q: %w", name, field, err)
+		}
+		for value := first; value <= last; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+func parseCronRange(part string, lo, hi int) (int, int, int, error) {
+	span, stepRaw, stepped := strings.Cut(part, "/")
+	step := 1
+	if stepped {
+		parsed, err := strconv.Atoi(stepRaw)
+		if err != nil || parsed < 1 {
+			return 0, 0, 0, fmt.Errorf("step %q must be a positive number", stepRaw)
+		}
+		step = parsed
+	}
+	if span == "*" {
+		return lo, hi, step, nil
+	}
+	bounds := strings.SplitN(span, "-", cronRangeParts)
+	first, err := parseCronValue(bounds[0], lo, hi)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	last := first
+	switch {
+	case len(bounds) == cronRangeParts:
+		if last, err = parseCronValue(bounds[1], lo, hi); err != nil {
+			return 0, 0, 0, err
+		}
+		if last < first {
+			return 0, 0, 0, fmt.Errorf("range %q runs backwards", span)
+		}
+	case stepped:
+		// "5/15" means from 5 to the end of the field, every 15.
+		last = hi
+	}
+	return first, last, step, nil
+}
+
+func parseCronValue(raw string, lo, hi int) (int, error) {
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", raw)
+	}
+	if value < lo || value > hi {
+		return 0, fmt.Errorf("%d is outside %d-%d", value, lo, hi)
+	}
+	return value, nil
+}
+
+func (c cronSchedule) matchesDay(t time.Time) bool {
+	dayOK := c.dayOfMonth&(1<<t.Day()) != 0
+	weekdayOK := c.dayOfWeek&(1<<int(t.Weekday())) != 0
+	if c.anyDay || c.anyWeekday {
+		return dayOK && weekdayOK
+	}
+	return dayOK || weekdayOK
+}
+
+// next returns the first minute after after that the schedule matches, in
+// loc's wall clock, or false when there is none within cronSearchHorizon.
+func (c cronSchedule) next(after time.Time, loc *time.Location) (time.Time, bool) {
+	t := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchHorizon)
+	for !t.After(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// scheduleLocation resolves a schedule's timezone; empty means UTC.
+func scheduleLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.New("timezone must be an IANA zone name such as Europe/Berlin")
+	}
+	return loc, nil
+}
@@ -0,0 +1,61 @@
+//nolint:testpackage // Cron tests exercise the unexported parser.
+package platform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	// Friday 10:07 UTC.
+	from := time.Date(2026, time.October, 16, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"*/15 * * * *", time.UTC, time.Date(2026, time.October, 16, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.UTC, time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)},
+		{"@weekly", time.UTC, time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.UTC, time.Date(2026, time.October, 18, 3, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.UTC, time.Date(2026, time.October, 16, 10, 25, 0, 0, time.UTC)},
+		// Either day field matches: the 1st of the month or any Tuesday.
+		{"0 0 1 * 2", time.UTC, time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1,7 *", time.UTC, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		// A stepped * still leaves the day to the other field: Mondays only,
+		// and the 1st only when it falls on a Sunday, Tuesday, Thursday, or
+		// Saturday.
+		{"0 9 */1 * 1", time.UTC, time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 9 1 * */2", time.UTC, time.Date(2026, time.November, 1, 9, 0, 0, 0, time.UTC)},
+		// 02:30 at UTC+5:30 is 21:00 UTC the day before.
+		{"30 2 * * *", time.FixedZone("IST", 5*3600+30*60), time.Date(2026, time.October, 16, 21, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		cron, err := parseCronExpression(tc.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.expr, err)
+		}
+		got, ok := cron.next(from, tc.loc)
+		if !ok || !got.Equal(tc.want) {
+			t.Errorf("%q next after %s = %s (%t), want %s", tc.expr, from, got.UTC(), ok, tc.want)
+		}
+	}
+
+	never, err := parseCronExpression("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("parse 30 February: %v", err)
+	}
+	if next, ok := never.next(from, time.UTC); ok {
+		t.Fatalf("expected 30 February never to fire, got %s", next)
+	}
+	for _, expr := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *",
+		"@yearly", "* * * * 8",
+	} {
+		if _, err = parseCronExpression(expr); err == nil {
+			t.Errorf("expected %q to be refused", expr)
+		}
+	}
+}
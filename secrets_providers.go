@@ -0,0 +1,357 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// External secrets: a spec names where each secret lives (Vault or a SOPS file
+// in the manifests repo) and the value is fetched when an environment is
+// rendered. Values are hashed into the rendered pod template and dropped; they
+// are never written to KV, the manifests repo, or artifacts.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	secretProviderVault = "vault"
+	secretProviderSOPS  = "sops"
+
+	secretRefMaxLength          = 512
+	projectSecretNameSuffix     = "-secrets"
+	secretAnnotationChecksum    = "platform.example.com/secrets-checksum"
+	defaultVaultAppRoleMount    = "approle"
+	defaultSOPSBinary           = "sops"
+	vaultResponseMaxBytes       = 1 << 20
+	secretProviderErrorMaxBytes = 512
+)
+
+// secretRef is a parsed reference: provider "vault" reads Path from the Vault
+// HTTP API (e.g. secret/data/app for KV v2), provider "sops" decrypts Path
+// relative to the project's manifests repo. Key selects one field.
+type secretRef struct {
+	Provider string
+	Path     string
+	Key      string
+}
+
+func (r secretRef) String() string {
+	return r.Provider + "://" + r.Path + "#" + r.Key
+}
+
+// secretProvider fetches the value behind one reference. Errors must not
+// carry the value.
+type secretProvider interface {
+	resolve(ctx context.Context, ref secretRef) (string, error)
+}
+
+// secretResolver routes references to the provider for their scheme. It is
+// built per render, so provider credentials are read from the environment
+// each time and rotate without a restart.
+type secretResolver struct {
+	providers map[string]secretProvider
+}
+
+type vaultSecretProvider struct {
+	addr         string
+	namespace    string
+	token        string
+	roleID       string
+	secretID     string
+	appRoleMount string
+	client       *http.Client
+	loginFailed  error
+}
+
+type sopsSecretProvider struct {
+	binary  string
+	repoDir string
+}
+
+func parseSecretRef(raw string) (secretRef, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > secretRefMaxLength {
+		return secretRef{}, fmt.Errorf("secret reference exceeds %d characters", secretRefMaxLength)
+	}
+	provider, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return secretRef{}, fmt.Errorf("secret reference %q must look like vault://path#key or sops://file#key", raw)
+	}
+	refPath, key, ok := strings.Cut(rest, "#")
+	if !ok || key == "" || !secretKeyRe.MatchString(key) {
+		return secretRef{}, fmt.Errorf("secret reference %q needs a #key naming one field", raw)
+	}
+	ref := secretRef{Provider: provider, Path: strings.Trim(refPath, "/"), Key: key}
+	if ref.Path == "" || path.Clean(ref.Path) != ref.Path || strings.ContainsAny(ref.Path, " \t\r\n?") {
+		return secretRef{}, fmt.Errorf("secret reference %q has an invalid path", raw)
+	}
+	for part := range strings.SplitSeq(ref.Path, "/") {
+		if part == ".." || part == "." {
+			return secretRef{}, fmt.Errorf("secret reference %q has an invalid path", raw)
+		}
+	}
+	if provider != secretProviderVault && provider != secretProviderSOPS {
+		return secretRef{}, fmt.Errorf("secret reference %q: unknown provider %q", raw, provider)
+	}
+	return ref, nil
+}
+
+func normalizeSecretRefs(refs map[string]string) map[string]string {
+	if len(refs) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(refs))
+	for name, ref := range refs {
+		out[name] = strings.TrimSpace(ref)
+	}
+	return out
+}
+
+// validateEnvironmentSecrets checks an environment's secret references. A
+// name cannot be both a plain var and a secret in the same environment.
+func validateEnvironmentSecrets(envName string, envCfg EnvConfig) error {
+	for name, raw := range envCfg.Secrets {
+		field := "environments." + envName + ".secrets." + name
+		if len(name) > 128 || !envVarNameRe.MatchString(name) {
+			return specFieldErrorf(field, "invalid secret name %q in %q", name, envName)
+		}
+		if _, plain := envCfg.Vars[name]; plain {
+			return specFieldErrorf(field, "%q is set in both vars and secrets of %q", name, envName)
+		}
+		if _, err := parseSecretRef(raw); err != nil {
+			return specFieldErrorf(field, "secret %q in %q: %v", name, envName, err)
+		}
+	}
+	return nil
+}
+
+func projectSecretName(spec ProjectSpec) string {
+	return safeName(spec.Name) + projectSecretNameSuffix
+}
+
+// environmentSecretKeyRefs points each of the environment's secret vars, and
+// each stored secret the spec does not shadow, at its key in the project
+// Secret; the Secret is keyed by var name.
+func environmentSecretKeyRefs(spec ProjectSpec, envName string, storedSecrets []string) map[string]SecretKeyRef {
+	refs := map[string]SecretKeyRef{}
+	for name := range spec.Environments[envName].Secrets {
+		refs[name] = SecretKeyRef{Name: projectSecretName(spec), Key: name}
+	}
+	for _, name := range activeStoredSecretNames(spec, envName, storedSecrets) {
+		refs[name] = SecretKeyRef{Name: projectSecretName(spec), Key: name}
+	}
+	return refs
+}
+
+func newSecretResolver(manifestsDir string) secretResolver {
+	return secretResolver{providers: map[string]secretProvider{
+		secretProviderVault: vaultSecretProviderFromEnv(),
+		secretProviderSOPS:  sopsSecretProviderFromEnv(manifestsDir),
+	}}
+}
+
+// resolveEnvironmentSecrets fetches every secret of envName, keyed by var
+// name. The first failure stops the render; its error names the reference
+// but never a value.
+func (r secretResolver) resolveEnvironmentSecrets(
+	ctx context.Context,
+	spec ProjectSpec,
+	envName string,
+) (map[string]string, error) {
+	secrets := spec.Environments[envName].Secrets
+	values := make(map[string]string, len(secrets))
+	for _, name := range sortedKeys(secrets) {
+		ref, err := parseSecretRef(secrets[name])
+		if err != nil {
+			return nil, err
+		}
+		provider, ok := r.providers[ref.Provider]
+		if !ok {
+			return nil, fmt.Errorf("secret %q: no %s provider configured", name, ref.Provider)
+		}
+		value, err := provider.resolve(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("resolve secret %q from %s: %w", name, ref, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// secretsChecksum hashes resolved values so a rotated secret changes the
+// pod template and rolls the workload, without the value itself showing up.
+func secretsChecksum(values map[string]string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	hash := sha256.New()
+	for _, name := range sortedKeys(values) {
+		_, _ = fmt.Fprintf(hash, "%s=%d:%s\n", name, len(values[name]), values[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func vaultSecretProviderFromEnv() *vaultSecretProvider {
+	mount := strings.Trim(strings.TrimSpace(os.Getenv(vaultAppRoleMountEnv)), "/")
+	if mount == "" {
+		mount = defaultVaultAppRoleMount
+	}
+	return &vaultSecretProvider{
+		addr:         strings.TrimRight(strings.TrimSpace(os.Getenv(vaultAddrEnv)), "/"),
+		namespace:    strings.TrimSpace(os.Getenv(vaultNamespaceEnv)),
+		token:        strings.TrimSpace(os.Getenv(vaultTokenEnv)),
+		roleID:       strings.TrimSpace(os.Getenv(vaultRoleIDEnv)),
+		secretID:     strings.TrimSpace(os.Getenv(vaultSecretIDEnv)),
+		appRoleMount: mount,
+		client:       &http.Client{Timeout: secretResolveTimeout},
+		loginFailed:  nil,
+	}
+}
+
+func (v *vaultSecretProvider) resolve(ctx context.Context, ref secretRef) (string, error) {
+	if v.addr == "" {
+		return "", fmt.Errorf("%s is not set", vaultAddrEnv)
+	}
+	token, err := v.clientToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err = v.do(ctx, http.MethodGet, "/v1/"+ref.Path, token, nil, &body); err != nil {
+		return "", err
+	}
+	fields := body.Data
+	// KV v2 nests the secret under data.data next to data.metadata.
+	if nested, ok := fields["data"].(map[string]any); ok {
+		if _, versioned := fields["metadata"]; versioned {
+			fields = nested
+		}
+	}
+	value, ok := fields[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found", ref.Key)
+	}
+	return secretFieldString(value)
+}
+
+// clientToken returns the static token, or logs in with AppRole once per
+// resolver and reuses the issued token for the rest of the render.
+func (v *vaultSecretProvider) clientToken(ctx context.Context) (string, error) {
+	if v.token != "" {
+		return v.token, nil
+	}
+	if v.loginFailed != nil {
+		return "", v.loginFailed
+	}
+	if v.roleID == "" || v.secretID == "" {
+		return "", fmt.Errorf("set %s, or %s and %s", vaultTokenEnv, vaultRoleIDEnv, vaultSecretIDEnv)
+	}
+	login, err := json.Marshal(map[string]string{"role_id": v.roleID, "secret_id": v.secretID})
+	if err != nil {
+		return "", err
+	}
+	var body struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	err = v.do(ctx, http.MethodPost, "/v1/auth/"+v.appRoleMount+"/login", "", login, &body)
+	if err == nil && body.Auth.ClientToken == "" {
+		err = errors.New("approle login returned no token")
+	}
+	if err != nil {
+		v.loginFailed = fmt.Errorf("vault approle login: %w", err)
+		return "", v.loginFailed
+	}
+	v.token = body.Auth.ClientToken
+	return v.token, nil
+}
+
+func (v *vaultSecretProvider) do(ctx context.Context, method, apiPath, token string, payload []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, v.addr+apiPath, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// Vault error bodies list messages, not secret data.
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, secretProviderErrorMaxBytes))
+		return fmt.Errorf("vault %s %s: %s %s", method, apiPath, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, vaultResponseMaxBytes)).Decode(out)
+}
+
+func secretFieldString(value any) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.New("secret field is not a string")
+	}
+	return string(encoded), nil
+}
+
+func sopsSecretProviderFromEnv(repoDir string) *sopsSecretProvider {
+	binary := strings.TrimSpace(os.Getenv(sopsBinaryEnv))
+	if binary == "" {
+		binary = defaultSOPSBinary
+	}
+	return &sopsSecretProvider{binary: binary, repoDir: repoDir}
+}
+
+// resolve runs `sops --decrypt --extract` on the file, so sops reads its own
+// key configuration (age, PGP, or cloud KMS) from the server's environment.
+func (s *sopsSecretProvider) resolve(ctx context.Context, ref secretRef) (string, error) {
+	file, err := securejoin.SecureJoin(s.repoDir, filepath.FromSlash(ref.Path))
+	if err != nil {
+		return "", errors.New("invalid sops file path")
+	}
+	if _, err = os.Stat(file); err != nil {
+		return "", fmt.Errorf("sops file %s: %w", ref.Path, errors.Unwrap(err))
+	}
+	ctx, cancel := context.WithTimeout(ctx, secretResolveTimeout)
+	defer cancel()
+	extract := fmt.Sprintf(`["%s"]`, ref.Key)
+	// #nosec G204 -- the binary is operator configuration; the file is confined to the repo and the key is validated.
+	cmd := exec.CommandContext(ctx, s.binary, "--decrypt", "--extract", extract, file)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		detail := strings.TrimSpace(stderr.String())
+		if len(detail) > secretProviderErrorMaxBytes {
+			detail = detail[:secretProviderErrorMaxBytes]
+		}
+		return "", fmt.Errorf("sops decrypt %s: %w: %s", ref.Path, err, detail)
+	}
+	return strings.TrimSuffix(stdout.String(), "\n"), nil
+}
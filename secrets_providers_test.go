@@ -0,0 +1,192 @@
+//nolint:testpackage,exhaustruct // Secret provider tests drive unexported resolvers and render helpers.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecrets_ParseRefRejectsTraversalAndUnknownProviders(t *testing.T) {
+	t.Parallel()
+
+	ref, err := parseSecretRef(" vault://secret/data/app/db#password ")
+	if err != nil || ref != (secretRef{Provider: "vault", Path: "secret/data/app/db", Key: "password"}) {
+		t.Fatalf("unexpected vault ref %+v (%v)", ref, err)
+	}
+	for _, raw := range []string{
+		"secret/data/app#password",
+		"vault://secret/data/app",
+		"vault://secret/data/app#",
+		"sops://../outside.yaml#key",
+		"sops://secrets/./dev.yaml#key",
+		"sops://secrets//dev.yaml#key",
+		"file:///etc/passwd#root",
+		"vault://secret/data/app#bad key",
+	} {
+		if _, err = parseSecretRef(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+
+	spec := normalizeProjectSpec(ProjectSpec{
+		APIVersion: projectAPIVersion, Kind: projectKind, Name: "secrets-spec", Runtime: "go_1.26",
+		Environments: map[string]EnvConfig{"dev": {
+			Vars:    map[string]string{"DB_PASSWORD": "plain"},
+			Secrets: map[string]string{"DB_PASSWORD": "vault://secret/data/app#password"},
+		}},
+	})
+	if err = validateProjectSpec(spec); err == nil || !strings.Contains(err.Error(), "both vars and secrets") {
+		t.Fatalf("expected var/secret overlap rejected, got %v", err)
+	}
+}
+
+func TestSecrets_VaultResolvesKVv1AndKVv2WithAppRole(t *testing.T) {
+	logins := 0
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/auth/approle/login":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["role_id"] != "role" || body["secret_id"] != "secret-id" {
+				http.Error(w, `{"errors":["invalid role or secret ID"]}`, http.StatusBadRequest)
+				return
+			}
+			logins++
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"issued"}}`))
+		case r.Header.Get("X-Vault-Token") != "issued" || r.Header.Get("X-Vault-Namespace") != "team-a":
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		case r.URL.Path == "/v1/secret/data/app":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"s3cret","port":5432},"metadata":{"version":3}}}`))
+		case r.URL.Path == "/v1/kv/app":
+			_, _ = w.Write([]byte(`{"data":{"api_key":"k-123"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer vault.Close()
+	t.Setenv(vaultAddrEnv, vault.URL)
+	t.Setenv(vaultTokenEnv, "")
+	t.Setenv(vaultRoleIDEnv, "role")
+	t.Setenv(vaultSecretIDEnv, "secret-id")
+	t.Setenv(vaultNamespaceEnv, "team-a")
+
+	spec := normalizeProjectSpec(ProjectSpec{
+		APIVersion: projectAPIVersion, Kind: projectKind, Name: "secrets-vault", Runtime: "go_1.26",
+		Environments: map[string]EnvConfig{"prod": {Secrets: map[string]string{
+			"DB_PASSWORD": "vault://secret/data/app#password",
+			"DB_PORT":     "vault://secret/data/app#port",
+			"API_KEY":     "vault://kv/app#api_key",
+		}}},
+	})
+	values, err := newSecretResolver(t.TempDir()).resolveEnvironmentSecrets(context.Background(), spec, "prod")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if values["DB_PASSWORD"] != "s3cret" || values["DB_PORT"] != "5432" || values["API_KEY"] != "k-123" {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+	if logins != 1 {
+		t.Fatalf("expected one approle login per resolver, got %d", logins)
+	}
+
+	spec.Environments["prod"] = EnvConfig{Secrets: map[string]string{"MISSING": "vault://secret/data/app#nope"}}
+	_, err = newSecretResolver(t.TempDir()).resolveEnvironmentSecrets(context.Background(), spec, "prod")
+	if err == nil || !strings.Contains(err.Error(), `key "nope" not found`) || strings.Contains(err.Error(), "s3cret") {
+		t.Fatalf("expected missing key error without values, got %v", err)
+	}
+}
+
+func TestSecrets_DeployStampsChecksumWithoutPersistingValues(t *testing.T) {
+	sopsLog := filepath.Join(t.TempDir(), "sops.log")
+	fakeSOPS := filepath.Join(t.TempDir(), "sops")
+	script := "#!/bin/sh\necho \"$@\" >> " + sopsLog + "\n" +
+		"case \"$3\" in\n" +
+		"'[\"db_password\"]') printf 'hunter2-%s' \"$SECRET_VERSION\";;\n" +
+		"*) echo 'key not found' >&2; exit 1;;\n" +
+		"esac\n"
+	if err := os.WriteFile(fakeSOPS, []byte(script), 0o700); err != nil {
+		t.Fatalf("write fake sops: %v", err)
+	}
+	t.Setenv(sopsBinaryEnv, fakeSOPS)
+	t.Setenv("SECRET_VERSION", "1")
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-secrets-deploy"
+	encrypted := []byte("db_password: ENC[...]\n")
+	if _, err := artifacts.WriteFile(projectID, "repos/manifests/secrets/dev.enc.yaml", encrypted); err != nil {
+		t.Fatalf("write sops file: %v", err)
+	}
+	spec := normalizeProjectSpec(ProjectSpec{
+		APIVersion: projectAPIVersion, Kind: projectKind, Name: "secrets-app", Runtime: "go_1.26",
+		Vars: map[string]string{"DB_PASSWORD": "shared-default"},
+		Environments: map[string]EnvConfig{"dev": {
+			Vars:    map[string]string{"LOG_LEVEL": "debug"},
+			Secrets: map[string]string{"DB_PASSWORD": "sops://secrets/dev.enc.yaml#db_password"},
+		}},
+		NetworkPolicies: NetworkPolicies{Ingress: networkPolicyInternal, Egress: networkPolicyInternal},
+	})
+	deploy := func() (string, error) {
+		msg := ProjectOpMsg{
+			OpID: "op-secrets-" + os.Getenv("SECRET_VERSION"), Kind: OpDeploy, ProjectID: projectID, At: time.Now().UTC(),
+		}
+		_, err := runManifestApplyForEnvironment(
+			context.Background(), nil, artifacts, msg, spec, "local/secrets-app:v1", "dev",
+		)
+		if err != nil {
+			return "", err
+		}
+		raw, err := artifacts.ReadFile(projectID, "deploy/dev/deployment.yaml")
+		return string(raw), err
+	}
+
+	first, err := deploy()
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	checksum := secretsChecksum(map[string]string{"DB_PASSWORD": "hunter2-1"})
+	wants := []string{"name: secrets-app-secrets", "key: DB_PASSWORD", secretAnnotationChecksum + ": " + checksum}
+	for _, want := range wants {
+		if !strings.Contains(first, want) {
+			t.Fatalf("expected %q in rendered deployment:\n%s", want, first)
+		}
+	}
+	if strings.Contains(first, "shared-default") {
+		t.Fatalf("expected the secret to replace the shared var:\n%s", first)
+	}
+	files, err := artifacts.ListFiles(projectID)
+	if err != nil {
+		t.Fatalf("list artifacts: %v", err)
+	}
+	for _, file := range files {
+		raw, _ := artifacts.ReadFile(projectID, file)
+		if strings.Contains(string(raw), "hunter2") {
+			t.Fatalf("secret value persisted in %s", file)
+		}
+	}
+	logged, _ := os.ReadFile(sopsLog)
+	wantFile := filepath.Join(manifestsRepoDir(artifacts, projectID), "secrets", "dev.enc.yaml")
+	if !strings.Contains(string(logged), `--decrypt --extract ["db_password"] `+wantFile) {
+		t.Fatalf("unexpected sops invocation: %q", logged)
+	}
+
+	t.Setenv("SECRET_VERSION", "2")
+	rotated, err := deploy()
+	if err != nil {
+		t.Fatalf("redeploy: %v", err)
+	}
+	if strings.Contains(rotated, checksum) {
+		t.Fatal("expected a rotated secret to change the pod template checksum")
+	}
+
+	spec.Environments["dev"] = EnvConfig{Secrets: map[string]string{"DB_PASSWORD": "sops://secrets/dev.enc.yaml#other"}}
+	if _, err = deploy(); err == nil || !strings.Contains(err.Error(), "key not found") {
+		t.Fatalf("expected deploy to fail on an unresolvable secret, got %v", err)
+	}
+}
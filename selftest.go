@@ -0,0 +1,462 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Self-test (server --self-test): boot a throwaway runtime in a temp dir, push
+// one synthetic project through create, deploy, and delete over the HTTP API,
+// and report what was verified.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	selfTestTimeout      = 3 * time.Minute
+	selfTestReadyTimeout = 30 * time.Second
+	selfTestPollInterval = 200 * time.Millisecond
+	selfTestProjectName  = "paas-self-test"
+
+	selfTestErrorBodyLimit = 4 * 1024
+	selfTestExitPassed     = 0
+	selfTestExitFailed     = 1
+)
+
+// selfTestProbeNames are the checks a passing self-test reports, in order.
+var selfTestProbeNames = []string{
+	"boot runtime",
+	"create project",
+	"project record",
+	"create artifacts",
+	"deploy to " + defaultDeployEnvironment,
+	"release record",
+	"delete project",
+	"delete cleanup",
+}
+
+type selfTestCheck struct {
+	name   string
+	detail string
+	err    error
+}
+
+type selfTestReport struct {
+	checks []selfTestCheck
+}
+
+// selfTestRuntime is the full platform, opened and started through the same
+// openRuntimeStore and startRuntimeWorkers as Run, except that everything
+// lives under one temp dir and the image builder is forced to the artifact
+// backend so no daemon is needed.
+type selfTestRuntime struct {
+	baseURL   string
+	store     *Store
+	artifacts ArtifactStore
+	closers   []func()
+}
+
+// RunSelfTest runs the self-test and writes a pass/fail report to stdout.
+// It returns the process exit code: 0 when every check passed.
+func RunSelfTest(stdout io.Writer) int {
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+
+	report := &selfTestReport{checks: nil}
+	runtime, err := startSelfTestRuntime(ctx)
+	if runtime != nil {
+		defer runtime.close()
+	}
+	if report.record("boot runtime", "embedded NATS, KV store, workers, and HTTP API are up", err) {
+		runSelfTestPipeline(ctx, runtime, report)
+	}
+	if report.write(stdout) {
+		return selfTestExitPassed
+	}
+	return selfTestExitFailed
+}
+
+func runSelfTestPipeline(ctx context.Context, rt *selfTestRuntime, report *selfTestReport) {
+	spec := normalizeProjectSpec(ProjectSpec{
+		APIVersion:   projectAPIVersion,
+		Kind:         projectKind,
+		Name:         selfTestProjectName,
+		Runtime:      "go_1.26",
+		Template:     "",
+		Build:        BuildConfig{Strategy: buildStrategyDockerfile, Builder: ""},
+		Helm:         HelmConfig{Enabled: false},
+		Capabilities: []string{"http"},
+		Needs:        nil,
+		Vars:         nil,
+		Environments: map[string]EnvConfig{
+			defaultDeployEnvironment: {
+				Vars:        map[string]string{"LOG_LEVEL": "info"},
+				Secrets:     nil,
+				Replicas:    0,
+				Autoscaling: AutoscalingConfig{MinReplicas: 0, MaxReplicas: 0, TargetCPUUtilization: 0},
+				Freeze:      nil,
+			},
+		},
+		NetworkPolicies: NetworkPolicies{
+			Ingress: networkPolicyInternal,
+			Egress:  networkPolicyInternal,
+		},
+		Extensions:        nil,
+		CapabilityOptions: nil,
+		Repos: RepoRemotes{
+			Source:    RemoteRepo{URL: "", CredentialsRef: ""},
+			Manifests: RemoteRepo{URL: "", CredentialsRef: ""},
+		},
+		CI: CIPolicy{
+			Branches: nil, Tags: nil, Paths: nil,
+			Previews: PreviewPolicy{Enabled: false, Branches: nil, TTL: ""},
+		},
+		Exposure: ExposureConfig{
+			ServiceType: "", Port: 0, ContainerPort: 0,
+			Ingress: IngressConfig{Host: "", Path: "", TLS: false},
+		},
+		Resources: ResourceConfig{
+			Requests: ResourceAmounts{CPU: "", Memory: ""},
+			Limits:   ResourceAmounts{CPU: "", Memory: ""},
+		},
+	})
+
+	var created struct {
+		Project Project   `json:"project"`
+		Op      Operation `json:"op"`
+	}
+	err := rt.call(ctx, http.MethodPost, "/api/projects", spec, &created)
+	if err == nil {
+		created.Op, err = rt.waitForOp(ctx, created.Op.ID)
+	}
+	if !report.record("create project", opCheckDetail(created.Op), err) {
+		return
+	}
+	projectID := created.Project.ID
+
+	report.record("project record", "phase Ready, last op is the create", rt.checkProject(ctx, projectID, created.Op.ID))
+	createArtifacts := []string{
+		"registration/project.yaml",
+		"registration/registration.json",
+		imageBuildTagPath,
+		"deploy/" + defaultDeployEnvironment + "/rendered.yaml",
+		"deploy/" + defaultDeployEnvironment + "/deployment.yaml",
+	}
+	report.record(
+		"create artifacts",
+		strings.Join(createArtifacts, ", "),
+		rt.checkArtifacts(projectID, createArtifacts),
+	)
+
+	var deployed struct {
+		Op Operation `json:"op"`
+	}
+	err = rt.call(ctx, http.MethodPost, "/api/events/deployment", DeploymentEvent{
+		ProjectID:   projectID,
+		Environment: defaultDeployEnvironment,
+	}, &deployed)
+	if err == nil {
+		deployed.Op, err = rt.waitForOp(ctx, deployed.Op.ID)
+	}
+	if !report.record("deploy to "+defaultDeployEnvironment, opCheckDetail(deployed.Op), err) {
+		return
+	}
+	report.record(
+		"release record",
+		"current "+defaultDeployEnvironment+" release points at the deploy op and built image",
+		rt.checkRelease(ctx, projectID, deployed.Op.ID),
+	)
+
+	var plan DeletePlan
+	err = rt.call(ctx, http.MethodPost, "/api/projects/"+projectID+"/delete-plan", nil, &plan)
+	var deleted struct {
+		Op Operation `json:"op"`
+	}
+	if err == nil {
+		// The deployed release is the plan's impact; the self-test owns it.
+		target := "/api/projects/" + projectID + "?plan_id=" + plan.ID + "&acknowledge_impact=true"
+		err = rt.call(ctx, http.MethodDelete, target, nil, &deleted)
+	}
+	if err == nil {
+		deleted.Op, err = rt.waitForOp(ctx, deleted.Op.ID)
+	}
+	if !report.record("delete project", opCheckDetail(deleted.Op), err) {
+		return
+	}
+	report.record("delete cleanup", "project record and artifact tree are gone", rt.checkDeleted(ctx, projectID))
+}
+
+func startSelfTestRuntime(ctx context.Context) (*selfTestRuntime, error) {
+	root, err := os.MkdirTemp("", "paas-self-test-*")
+	if err != nil {
+		return nil, err
+	}
+	rt := &selfTestRuntime{
+		baseURL:   "",
+		store:     nil,
+		artifacts: nil,
+		closers:   []func(){func() { _ = os.RemoveAll(root) }},
+	}
+
+	natsDir := filepath.Join(root, "nats")
+	if err = os.MkdirAll(natsDir, dirModePrivateRead); err != nil {
+		return rt, err
+	}
+	ns, endpoint, err := startNATSServerAt(natsDir, false)
+	if err != nil {
+		return rt, fmt.Errorf("start embedded nats: %w", err)
+	}
+	rt.closers = append(rt.closers, func() {
+		ns.Shutdown()
+		ns.WaitForShutdown()
+	})
+	nc, err := endpoint.connect("self-test")
+	if err != nil {
+		return rt, fmt.Errorf("connect nats: %w", err)
+	}
+	rt.closers = append(rt.closers, nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return rt, fmt.Errorf("jetstream: %w", err)
+	}
+	store, opEvents, err := openRuntimeStore(ctx, js)
+	if err != nil {
+		return rt, err
+	}
+	rt.store = store
+
+	artifactsRoot := filepath.Join(root, "data")
+	if err = os.MkdirAll(artifactsRoot, dirModePrivateRead); err != nil {
+		return rt, err
+	}
+	artifacts := NewFSArtifacts(artifactsRoot)
+	artifacts.projectOrg = store.projectOrg
+	rt.artifacts = artifacts
+	builderMode := imageBuilderModeResolution{
+		requestedMode:     imageBuilderModeArtifact,
+		requestedExplicit: true,
+		effectiveMode:     imageBuilderModeArtifact,
+		requestedWarning:  "",
+		fallbackReason:    "",
+		policyError:       "",
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	rt.closers = append(rt.closers, stopWorkers)
+	workers, err := startRuntimeWorkers(workerCtx, nc, js, runtimeWorkerConfig{
+		endpoint:      endpoint,
+		artifacts:     artifacts,
+		opEvents:      opEvents,
+		builderMode:   builderMode,
+		readinessMode: readinessModeReject,
+		log:           appLoggerForProcess().Source("self-test"),
+	})
+	if err != nil {
+		return rt, err
+	}
+	rt.closers = append(rt.closers, workers.stop)
+	if err = waitForSelfTestWorkers(ctx, workers.readiness); err != nil {
+		return rt, err
+	}
+
+	cfg := defaultRuntimeConfig()
+	cfg.ArtifactsRoot = artifactsRoot
+	api := newRuntimeAPI(nc, store, artifacts, workers.waiters, opEvents, builderMode, cfg, runtimeNATS{
+		endpoint:       endpoint,
+		storeDir:       natsDir,
+		storeEphemeral: true,
+	})
+	api.readiness = workers.readiness
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", "127.0.0.1:0")
+	if err != nil {
+		return rt, fmt.Errorf("listen: %w", err)
+	}
+	srv := newHTTPServer("", api.routes())
+	go func() { _ = srv.Serve(listener) }()
+	rt.closers = append(rt.closers, func() { _ = srv.Close() })
+	rt.baseURL = "http://" + listener.Addr().String()
+	return rt, nil
+}
+
+func waitForSelfTestWorkers(ctx context.Context, readiness *workerReadiness) error {
+	deadline := time.Now().Add(selfTestReadyTimeout)
+	for {
+		status := readiness.status()
+		if status.Ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("workers not ready after %s: %s", selfTestReadyTimeout, strings.Join(status.Missing, ", "))
+		}
+		if err := sleepSelfTest(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+func (rt *selfTestRuntime) close() {
+	for i := len(rt.closers) - 1; i >= 0; i-- {
+		rt.closers[i]()
+	}
+}
+
+// call sends a JSON request to the self-test API and decodes a 2xx response
+// into out; any other status is returned as an error carrying the body.
+func (rt *selfTestRuntime) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rt.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, selfTestErrorBodyLimit))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (rt *selfTestRuntime) waitForOp(ctx context.Context, opID string) (Operation, error) {
+	for {
+		var op Operation
+		if err := rt.call(ctx, http.MethodGet, "/api/ops/"+opID, nil, &op); err != nil {
+			return op, err
+		}
+		switch op.Status {
+		case opStatusDone:
+			return op, nil
+		case opStatusError, opStatusCancelled:
+			return op, fmt.Errorf("op %s %s: %s", op.ID, op.Status, op.Error)
+		}
+		if err := sleepSelfTest(ctx); err != nil {
+			return op, fmt.Errorf("op %s still %s: %w", opID, op.Status, err)
+		}
+	}
+}
+
+func (rt *selfTestRuntime) checkProject(ctx context.Context, projectID, opID string) error {
+	project, err := rt.store.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if project.Status.Phase != projectPhaseReady || project.Status.LastOpID != opID {
+		return fmt.Errorf("unexpected status: phase=%s last_op=%s", project.Status.Phase, project.Status.LastOpID)
+	}
+	return nil
+}
+
+func (rt *selfTestRuntime) checkArtifacts(projectID string, paths []string) error {
+	missing := []string{}
+	for _, rel := range paths {
+		if _, err := rt.artifacts.ReadFile(projectID, rel); err != nil {
+			missing = append(missing, rel)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (rt *selfTestRuntime) checkRelease(ctx context.Context, projectID, opID string) error {
+	page, err := rt.store.listProjectReleases(ctx, projectID, defaultDeployEnvironment, projectReleaseListQuery{
+		Limit:  1,
+		Cursor: "",
+	})
+	if err != nil {
+		return err
+	}
+	if len(page.Items) == 0 {
+		return errors.New("no release recorded")
+	}
+	release := page.Items[0]
+	image, err := rt.artifacts.ReadFile(projectID, imageBuildTagPath)
+	if err != nil {
+		return err
+	}
+	if release.OpID != opID || release.Image != strings.TrimSpace(string(image)) {
+		return fmt.Errorf("release %s has op=%s image=%s", release.ID, release.OpID, release.Image)
+	}
+	return nil
+}
+
+func (rt *selfTestRuntime) checkDeleted(ctx context.Context, projectID string) error {
+	if _, err := rt.store.GetProject(ctx, projectID); !errors.Is(err, jetstream.ErrKeyNotFound) {
+		return fmt.Errorf("project record still readable (err=%v)", err)
+	}
+	if _, err := os.Stat(rt.artifacts.ProjectDir(projectID)); !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("artifact tree still present (err=%v)", err)
+	}
+	return nil
+}
+
+func sleepSelfTest(ctx context.Context) error {
+	timer := time.NewTimer(selfTestPollInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func opCheckDetail(op Operation) string {
+	if op.ID == "" {
+		return "op was not accepted"
+	}
+	return fmt.Sprintf("op %s %s with %d step(s)", op.ID, op.Status, len(op.Steps))
+}
+
+// record adds a check and reports whether it passed.
+func (r *selfTestReport) record(name, detail string, err error) bool {
+	r.checks = append(r.checks, selfTestCheck{name: name, detail: detail, err: err})
+	return err == nil
+}
+
+// write prints one line per check and a summary, and reports whether every
+// check passed.
+func (r *selfTestReport) write(w io.Writer) bool {
+	failed := 0
+	_, _ = fmt.Fprintln(w, "self-test report:")
+	for _, check := range r.checks {
+		if check.err != nil {
+			failed++
+			_, _ = fmt.Fprintf(w, "  FAIL  %-22s %v\n", check.name, check.err)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  PASS  %-22s %s\n", check.name, check.detail)
+	}
+	if failed > 0 {
+		_, _ = fmt.Fprintf(w, "self-test FAILED: %d of %d check(s) failed\n", failed, len(r.checks))
+		return false
+	}
+	_, _ = fmt.Fprintf(w, "self-test passed: %d check(s)\n", len(r.checks))
+	return true
+}
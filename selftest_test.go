@@ -0,0 +1,92 @@
+//nolint:testpackage // The self-test wires the unexported runtime the same way Run does.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunSelfTest_PassesOnAFreshRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("self-test boots the full runtime")
+	}
+	var out bytes.Buffer
+	if code := RunSelfTest(&out); code != selfTestExitPassed {
+		t.Fatalf("expected exit %d, got %d:\n%s", selfTestExitPassed, code, out.String())
+	}
+	if !strings.Contains(out.String(), "self-test passed") || strings.Contains(out.String(), "FAIL") {
+		t.Fatalf("unexpected report:\n%s", out.String())
+	}
+	for _, probe := range selfTestProbeNames {
+		if !strings.Contains(out.String(), fmt.Sprintf("PASS  %-22s", probe)) {
+			t.Errorf("expected probe %q to run and pass:\n%s", probe, out.String())
+		}
+	}
+}
+
+// hidingArtifacts hides one artifact from the self-test's checks while the
+// workers, which hold their own artifact store, still write it.
+type hidingArtifacts struct {
+	ArtifactStore
+
+	hidden string
+}
+
+func (a hidingArtifacts) ReadFile(projectID, relPath string) ([]byte, error) {
+	if relPath == a.hidden {
+		return nil, os.ErrNotExist
+	}
+	return a.ArtifactStore.ReadFile(projectID, relPath)
+}
+
+func TestRunSelfTest_ReportsAFailingProbe(t *testing.T) {
+	if testing.Short() {
+		t.Skip("self-test boots the full runtime")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+	rt, err := startSelfTestRuntime(ctx)
+	if rt != nil {
+		defer rt.close()
+	}
+	if err != nil {
+		t.Fatalf("start runtime: %v", err)
+	}
+	hidden := "deploy/" + defaultDeployEnvironment + "/deployment.yaml"
+	rt.artifacts = hidingArtifacts{ArtifactStore: rt.artifacts, hidden: hidden}
+
+	report := &selfTestReport{checks: nil}
+	runSelfTestPipeline(ctx, rt, report)
+	var out bytes.Buffer
+	if report.write(&out) {
+		t.Fatalf("expected the report to fail:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "FAIL  create artifacts") || !strings.Contains(out.String(), "missing "+hidden) {
+		t.Fatalf("expected the artifacts probe to fail on %s:\n%s", hidden, out.String())
+	}
+	// A failed check that does not stop the pipeline leaves the later
+	// probes running.
+	for _, probe := range selfTestProbeNames[1:] {
+		if !strings.Contains(out.String(), probe) {
+			t.Errorf("expected probe %q to run after the failure:\n%s", probe, out.String())
+		}
+	}
+}
+
+func TestSelfTestReport_FailsWhenAnyCheckFails(t *testing.T) {
+	report := &selfTestReport{checks: nil}
+	report.record("first", "ok", nil)
+	report.record("second", "", errors.New("boom"))
+	var out bytes.Buffer
+	if report.write(&out) {
+		t.Fatal("expected report with a failed check to fail")
+	}
+	if !strings.Contains(out.String(), "FAIL  second") || !strings.Contains(out.String(), "1 of 2 check(s) failed") {
+		t.Fatalf("unexpected report:\n%s", out.String())
+	}
+}
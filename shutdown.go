@@ -0,0 +1,224 @@
+package platform
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Shutdown: on SIGINT/SIGTERM the HTTP server stops accepting requests and
+// workers stop taking deliveries, while steps already running get until
+// shutdown_timeout to finish. A step still running at the deadline has its
+// context cancelled; its op is marked "interrupted" and its message left
+// un-acked, so the step reruns (as a new attempt) when the platform restarts.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// How long cancelled steps get to unwind and nack after the deadline.
+	shutdownInterruptGrace = 5 * time.Second
+	opInterruptedMessage   = "interrupted by a platform shutdown; resumes when the platform restarts"
+)
+
+type workerDrainKey struct{}
+
+// workerDrain counts the deliveries workers are handling and which ops
+// their actions are running, so Run can wait for them before it exits.
+type workerDrain struct {
+	mu       sync.Mutex
+	stopping bool
+	active   int
+	running  map[string]string // op ID -> worker
+	stopped  chan struct{}
+	idle     chan struct{}
+}
+
+func newWorkerDrain() *workerDrain {
+	return &workerDrain{
+		mu:       sync.Mutex{},
+		stopping: false,
+		active:   0,
+		running:  map[string]string{},
+		stopped:  make(chan struct{}),
+		idle:     make(chan struct{}),
+	}
+}
+
+// begin reserves a slot for a delivery a worker just fetched. It returns
+// false once shutdown started; the worker then hands the message back.
+func (d *workerDrain) begin() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopping {
+		return false
+	}
+	d.active++
+	return true
+}
+
+func (d *workerDrain) end() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.stopping && d.active == 0 {
+		close(d.idle)
+	}
+}
+
+// track records that workerName's action is running for opID until the
+// returned func is called.
+func (d *workerDrain) track(opID, workerName string) func() {
+	if d == nil {
+		return func() {}
+	}
+	d.mu.Lock()
+	d.running[opID] = workerName
+	d.mu.Unlock()
+	return func() {
+		d.mu.Lock()
+		delete(d.running, opID)
+		d.mu.Unlock()
+	}
+}
+
+// stop makes workers stop taking deliveries. The returned channel closes
+// once the deliveries already in hand are finished.
+func (d *workerDrain) stop() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.stopping {
+		d.stopping = true
+		close(d.stopped)
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	return d.idle
+}
+
+// stopRequested returns a channel closed when shutdown starts; nil (never
+// ready) without a drain.
+func (d *workerDrain) stopRequested() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+	return d.stopped
+}
+
+// interrupted reports whether ctx ended because shutdown ran out of time,
+// as opposed to the op being cancelled or the action failing.
+func (d *workerDrain) interrupted(ctx context.Context) bool {
+	if d == nil || ctx.Err() == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stopping
+}
+
+func (d *workerDrain) runningOps() map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return maps.Clone(d.running)
+}
+
+func withWorkerDrain(ctx context.Context, drain *workerDrain) context.Context {
+	return context.WithValue(ctx, workerDrainKey{}, drain)
+}
+
+// workerDrainFromContext returns Run's drain, or nil for workers started
+// without one (tests), which then stop only when their context ends.
+func workerDrainFromContext(ctx context.Context) *workerDrain {
+	drain, _ := ctx.Value(workerDrainKey{}).(*workerDrain)
+	return drain
+}
+
+// drainWorkers waits until deadline for in-flight deliveries, then cancels
+// the workers' context and marks the ops of steps that still have not
+// returned as interrupted.
+func drainWorkers(
+	ctx context.Context,
+	drain *workerDrain,
+	cancelWorkers context.CancelFunc,
+	store *Store,
+	deadline time.Time,
+	mainLog sourceLogger,
+) {
+	idle := drain.stop()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-idle:
+		mainLog.Infof("Shutdown: worker steps finished")
+		return
+	case <-timer.C:
+	}
+
+	running := drain.runningOps()
+	mainLog.Warnf("Shutdown: deadline reached with %d step(s) running; interrupting them", len(running))
+	cancelWorkers()
+	select {
+	case <-idle:
+		return
+	case <-time.After(shutdownInterruptGrace):
+	}
+	for _, opID := range slices.Sorted(maps.Keys(drain.runningOps())) {
+		if err := markOpInterrupted(context.WithoutCancel(ctx), store, opID); err != nil {
+			mainLog.Warnf("Shutdown: mark op=%s interrupted failed: %v", opID, err)
+		}
+	}
+}
+
+// markOpInterrupted flags an op whose step was cut short by shutdown. Its
+// open step is left open: the redelivered message reruns it on restart.
+func markOpInterrupted(ctx context.Context, store *Store, opID string) error {
+	interrupted := false
+	op, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		interrupted = !isOperationStatusTerminal(op.Status) && op.Status != opStatusInterrupted
+		if !interrupted {
+			return false
+		}
+		op.Status = opStatusInterrupted
+		for i := range op.Steps {
+			if op.Steps[i].EndedAt.IsZero() {
+				op.Steps[i].Progress = opInterruptedMessage
+			}
+		}
+		return true
+	})
+	if err != nil || !interrupted {
+		return err
+	}
+	emitOpStatus(store.opEvents, op, "operation "+opInterruptedMessage)
+	return nil
+}
+
+// resumeInterruptedOp puts an interrupted op back to running when its step
+// starts again.
+func resumeInterruptedOp(ctx context.Context, store *Store, op Operation) error {
+	if strings.TrimSpace(op.Status) != opStatusInterrupted {
+		return nil
+	}
+	resumed := false
+	op, err := store.updateOp(ctx, op.ID, func(op *Operation) bool {
+		resumed = strings.TrimSpace(op.Status) == opStatusInterrupted
+		if resumed {
+			op.Status = opStatusRunning
+		}
+		return resumed
+	})
+	if err != nil || !resumed {
+		return err
+	}
+	emitOpStatus(store.opEvents, op, "operation resumed after a restart")
+	return nil
+}
@@ -0,0 +1,78 @@
+//nolint:testpackage,exhaustruct // Shutdown tests drive worker deliveries through the internal drain.
+package platform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestShutdown_DeadlineInterruptsRunningStepAndRestartResumesIt(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	spec := workerRuntimeSpec("shutdown")
+	opID, projectID := "op-shutdown", "project-shutdown"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCreate, spec)
+	data := workerPayload(t, opID, OpCreate, projectID, spec)
+	artifacts := NewFSArtifacts(t.TempDir())
+	log := appLoggerForProcess().Source("shutdown-test")
+	published := 0
+	resultPublisher := func(context.Context, jetstream.JetStream, string, WorkerResultMsg) error {
+		published++
+		return nil
+	}
+
+	drain := newWorkerDrain()
+	ctx, cancel := context.WithCancel(withWorkerDrain(context.Background(), drain))
+	defer cancel()
+	started := make(chan struct{})
+	blockUntilStopped := func(
+		ctx context.Context, store *Store, _ ArtifactStore, msg ProjectOpMsg,
+	) (WorkerResultMsg, error) {
+		_ = markOpStepStart(ctx, store, msg.OpID, "registrar", time.Now().UTC(), "register app configuration")
+		close(started)
+		<-ctx.Done()
+		return newWorkerResultMsg(""), ctx.Err()
+	}
+	if !drain.begin() {
+		t.Fatal("expected deliveries accepted before shutdown")
+	}
+	decided := make(chan workerDeliveryDecision, 1)
+	go func() {
+		defer drain.end()
+		decided <- handleWorkerDelivery(ctx, fixture.store, artifacts,
+			"registrar", natsSubject(subjectProjectOpStart), natsSubject(subjectRegistrationDone),
+			blockUntilStopped, fixture.js, data, 1, log, resultPublisher, publishWorkerPoison)
+	}()
+	<-started
+
+	drainWorkers(ctx, drain, cancel, fixture.store, time.Now().Add(50*time.Millisecond), log)
+	if drain.begin() {
+		t.Fatal("expected no new deliveries once shutdown started")
+	}
+	if decision := <-decided; decision.action != workerDeliveryRetry || published != 0 {
+		t.Fatalf("expected the message handed back without a result, got %+v (%d published)", decision, published)
+	}
+	op, err := fixture.store.GetOp(context.Background(), opID)
+	if err != nil || op.Status != opStatusInterrupted || len(op.Steps) != 1 || !op.Steps[0].EndedAt.IsZero() {
+		t.Fatalf("expected an interrupted op with its step open, got %+v (%v)", op, err)
+	}
+	if !isOperationStatusActive(op.Status) || isOperationStatusTerminal(op.Status) {
+		t.Fatalf("expected interrupted ops to stay active, got %s", op.Status)
+	}
+
+	// After a restart the redelivered message reruns the step.
+	decision := handleWorkerDelivery(context.Background(), fixture.store, artifacts,
+		"registrar", natsSubject(subjectProjectOpStart), natsSubject(subjectRegistrationDone),
+		workerRuntimeActionSuccess, fixture.js, data, 2, log, resultPublisher, publishWorkerPoison)
+	if decision.action != workerDeliveryAck || published != 1 {
+		t.Fatalf("expected the rerun to publish its result, got %+v (%d published)", decision, published)
+	}
+	op, err = fixture.store.GetOp(context.Background(), opID)
+	if err != nil || op.Status == opStatusInterrupted || op.Steps[0].Attempt != 2 {
+		t.Fatalf("expected the op resumed as attempt 2, got %+v (%v)", op, err)
+	}
+}
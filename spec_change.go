@@ -0,0 +1,215 @@
+package platform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Spec change classification: an update of a Ready project is compared with
+// the spec it replaces, and the pipeline stages the difference cannot reach
+// are skipped. registrar and manifestRenderer always run; repoBootstrap and
+// imageBuilder run only for the classes that feed them.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	stageRegistrar        = "registrar"
+	stageRepoBootstrap    = "repoBootstrap"
+	stageImageBuilder     = "imageBuilder"
+	stageManifestRenderer = "manifestRenderer"
+)
+
+// specChangeStageClasses lists, per optional stage, the classes that
+// require it. Stages not listed here always run.
+func specChangeStageClasses(stage string) []SpecChangeClass {
+	switch stage {
+	case stageRepoBootstrap:
+		// The seeded READMEs and sample main.go carry the name, and a new
+		// remote has to be fetched.
+		return []SpecChangeClass{SpecChangeName, SpecChangeRepos}
+	case stageImageBuilder:
+		return []SpecChangeClass{SpecChangeName, SpecChangeRuntime, SpecChangeBuild, SpecChangeRepos}
+	default:
+		return nil
+	}
+}
+
+// classifySpecChange lists how next differs from current, in a fixed order.
+// Equal specs give no classes.
+func classifySpecChange(current, next ProjectSpec) []SpecChangeClass {
+	current = normalizeProjectSpec(current)
+	next = normalizeProjectSpec(next)
+	classes := []SpecChangeClass{}
+	if !sameSpecVars(current, next) {
+		classes = append(classes, SpecChangeVars)
+	}
+	if !sameCapabilities(current.Capabilities, next.Capabilities) ||
+		!bytes.Equal(mustCompactJSON(current.CapabilityOptions), mustCompactJSON(next.CapabilityOptions)) {
+		classes = append(classes, SpecChangeCapabilities)
+	}
+	if current.Runtime != next.Runtime {
+		classes = append(classes, SpecChangeRuntime)
+	}
+	if current.Name != next.Name {
+		classes = append(classes, SpecChangeName)
+	}
+	if current.Build != next.Build {
+		classes = append(classes, SpecChangeBuild)
+	}
+	if current.Repos != next.Repos {
+		classes = append(classes, SpecChangeRepos)
+	}
+	if !slices.Equal(current.CI.Branches, next.CI.Branches) || !slices.Equal(current.CI.Tags, next.CI.Tags) ||
+		!slices.Equal(current.CI.Paths, next.CI.Paths) || !samePreviewPolicy(current.CI.Previews, next.CI.Previews) {
+		classes = append(classes, SpecChangeCI)
+	}
+	if !sameFreezeWindows(current, next) {
+		classes = append(classes, SpecChangeFreeze)
+	}
+	if current.APIVersion != next.APIVersion ||
+		current.Kind != next.Kind ||
+		current.NetworkPolicies != next.NetworkPolicies ||
+		!slices.Equal(current.Needs, next.Needs) ||
+		current.Helm != next.Helm ||
+		current.Exposure != next.Exposure ||
+		current.Resources != next.Resources ||
+		!sameEnvironmentScaling(current, next) ||
+		!bytes.Equal(mustCompactJSON(current.Extensions), mustCompactJSON(next.Extensions)) {
+		classes = append(classes, SpecChangeManifest)
+	}
+	return classes
+}
+
+func sameSpecVars(current, next ProjectSpec) bool {
+	if !maps.Equal(current.Vars, next.Vars) || len(current.Environments) != len(next.Environments) {
+		return false
+	}
+	for name, cfg := range current.Environments {
+		other, ok := next.Environments[name]
+		if !ok || !maps.Equal(cfg.Vars, other.Vars) || !maps.Equal(cfg.Secrets, other.Secrets) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameEnvironmentScaling(current, next ProjectSpec) bool {
+	for name, cfg := range current.Environments {
+		other := next.Environments[name]
+		if cfg.replicas() != other.replicas() || cfg.Autoscaling != other.Autoscaling {
+			return false
+		}
+	}
+	return true
+}
+
+func sameFreezeWindows(current, next ProjectSpec) bool {
+	for _, pair := range [][2]map[string]EnvConfig{
+		{current.Environments, next.Environments},
+		{next.Environments, current.Environments},
+	} {
+		for name, cfg := range pair[0] {
+			if !slices.Equal(cfg.Freeze, pair[1][name].Freeze) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sameCapabilities(current, next []string) bool {
+	current = slices.Clone(current)
+	next = slices.Clone(next)
+	slices.Sort(current)
+	slices.Sort(next)
+	return slices.Equal(current, next)
+}
+
+// planSpecChange classifies an update of project to spec and picks the
+// stages it runs. It returns nil, so every stage runs, unless the project is
+// Ready: after a failed op the skipped stages may never have finished. A
+// stage is kept when what it would have left behind is missing, and a
+// forced update with no difference runs everything.
+func planSpecChange(artifacts ArtifactStore, project Project, spec ProjectSpec) *SpecChange {
+	if project.Status.Phase != projectPhaseReady {
+		return nil
+	}
+	change := &SpecChange{
+		Classes: classifySpecChange(project.Spec, spec),
+		Stages:  []string{},
+		Skipped: nil,
+		Image:   "",
+	}
+	for _, stage := range []string{stageRegistrar, stageRepoBootstrap, stageImageBuilder, stageManifestRenderer} {
+		if len(change.Classes) == 0 || !canSkipSpecChangeStage(artifacts, project.ID, stage, change) {
+			change.Stages = append(change.Stages, stage)
+			continue
+		}
+		change.Skipped = append(change.Skipped, stage)
+	}
+	return change
+}
+
+func canSkipSpecChangeStage(artifacts ArtifactStore, projectID, stage string, change *SpecChange) bool {
+	required := specChangeStageClasses(stage)
+	if required == nil || slices.ContainsFunc(change.Classes, func(c SpecChangeClass) bool {
+		return slices.Contains(required, c)
+	}) {
+		return false
+	}
+	switch stage {
+	case stageRepoBootstrap:
+		for _, dir := range []string{sourceRepoDir(artifacts, projectID), manifestsRepoDir(artifacts, projectID)} {
+			if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+				return false
+			}
+		}
+		return true
+	case stageImageBuilder:
+		image, err := readBuildImageTagForDeployment(artifacts, projectID)
+		if err != nil {
+			return false
+		}
+		change.Image = image
+		return true
+	default:
+		return false
+	}
+}
+
+// specChangeSkipOutcome is the outcome a worker records for a stage the op's
+// spec change plan skips; ok is false when the stage should run. Updates
+// and the ci ops of a vars patch carry a plan.
+func specChangeSkipOutcome(msg ProjectOpMsg, stage, label string) (repoBootstrapOutcome, bool) {
+	if (msg.Kind != OpUpdate && msg.Kind != OpCI) || msg.SpecChange == nil ||
+		!slices.Contains(msg.SpecChange.Skipped, stage) {
+		return newRepoBootstrapOutcome(), false
+	}
+	classes := make([]string, 0, len(msg.SpecChange.Classes))
+	for _, class := range msg.SpecChange.Classes {
+		classes = append(classes, string(class))
+	}
+	return repoBootstrapOutcome{
+		message:   fmt.Sprintf("%s skipped: spec change is %s only", label, strings.Join(classes, ", ")),
+		artifacts: nil,
+	}, true
+}
+
+// updateImageTag is the image a create, update, or CI op renders with: the
+// one imageBuilder builds for the op, or the image an update or vars patch
+// reuses when its spec change skipped imageBuilder.
+func updateImageTag(msg ProjectOpMsg, spec ProjectSpec) string {
+	if _, skipped := specChangeSkipOutcome(msg, stageImageBuilder, ""); skipped && msg.SpecChange.Image != "" {
+		return msg.SpecChange.Image
+	}
+	return fmt.Sprintf("local/%s:%s", safeName(spec.Name), shortID(msg.OpID))
+}
+
+func samePreviewPolicy(current, next PreviewPolicy) bool {
+	return current.Enabled == next.Enabled && current.TTL == next.TTL && slices.Equal(current.Branches, next.Branches)
+}
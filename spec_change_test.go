@@ -0,0 +1,137 @@
+//nolint:testpackage,exhaustruct // Spec change tests drive the internal planner and worker actions.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassifySpecChange(t *testing.T) {
+	base := workerRuntimeSpec("classified")
+	cases := []struct {
+		name   string
+		mutate func(*ProjectSpec)
+		want   []SpecChangeClass
+	}{
+		{name: "unchanged", mutate: func(*ProjectSpec) {}, want: []SpecChangeClass{}},
+		{
+			name:   "env var",
+			mutate: func(s *ProjectSpec) { s.Environments["dev"] = EnvConfig{Vars: map[string]string{"LOG_LEVEL": "debug"}} },
+			want:   []SpecChangeClass{SpecChangeVars},
+		},
+		{
+			name:   "environment added",
+			mutate: func(s *ProjectSpec) { s.Environments["staging"] = EnvConfig{} },
+			want:   []SpecChangeClass{SpecChangeVars},
+		},
+		{
+			name:   "capabilities",
+			mutate: func(s *ProjectSpec) { s.Capabilities = []string{"postgres"} },
+			want:   []SpecChangeClass{SpecChangeCapabilities},
+		},
+		{
+			name: "capability options",
+			mutate: func(s *ProjectSpec) {
+				s.CapabilityOptions = map[string]map[string]string{"metrics": {"port": "9102"}}
+			},
+			want: []SpecChangeClass{SpecChangeCapabilities},
+		},
+		{
+			name: "runtime and name",
+			mutate: func(s *ProjectSpec) {
+				s.Runtime = "node_22"
+				s.Name = "renamed"
+			},
+			want: []SpecChangeClass{SpecChangeRuntime, SpecChangeName},
+		},
+		{
+			name:   "network policy",
+			mutate: func(s *ProjectSpec) { s.NetworkPolicies.Egress = "none" },
+			want:   []SpecChangeClass{SpecChangeManifest},
+		},
+	}
+	for _, tc := range cases {
+		next := workerRuntimeSpec("classified")
+		tc.mutate(&next)
+		if got := classifySpecChange(base, next); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: expected classes %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestSpecChange_VarsOnlyUpdateSkipsBootstrapAndBuild(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+	projectID := "project-spec-change"
+	spec := workerRuntimeSpec("classified")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-spec-change-create", OpCreate, spec)
+	artifacts := NewFSArtifacts(t.TempDir())
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: artifacts}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	project, _ := fixture.store.GetProject(ctx, projectID)
+	next := workerRuntimeSpec("classified")
+	next.Environments["dev"] = EnvConfig{Vars: map[string]string{"LOG_LEVEL": "debug"}}
+	if change := planSpecChange(artifacts, project, next); len(change.Skipped) != 0 ||
+		len(change.Stages) != 4 {
+		t.Fatalf("expected every stage to run before the repos and an image exist, got %+v", change)
+	}
+
+	for _, dir := range []string{sourceRepoDir(artifacts, projectID), manifestsRepoDir(artifacts, projectID)} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatalf("create repo dir: %v", err)
+		}
+	}
+	if _, err := artifacts.WriteFile(projectID, imageBuildTagPath, []byte("local/classified:previous\n")); err != nil {
+		t.Fatalf("write image tag: %v", err)
+	}
+	raw, _ := json.Marshal(next)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL+"/api/projects/"+projectID, bytes.NewReader(raw))
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	defer resp.Body.Close()
+	var out opAcceptedResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	change := out.Op.SpecChange
+	if resp.StatusCode != http.StatusAccepted || change == nil ||
+		!slices.Equal(change.Classes, []SpecChangeClass{SpecChangeVars}) ||
+		!slices.Equal(change.Stages, []string{stageRegistrar, stageManifestRenderer}) ||
+		!slices.Equal(change.Skipped, []string{stageRepoBootstrap, stageImageBuilder}) ||
+		change.Image != "local/classified:previous" {
+		t.Fatalf("expected a vars-only plan reusing the previous image, got %d %+v", resp.StatusCode, change)
+	}
+
+	msg := newProjectOpMsg(out.Op.ID, OpUpdate, projectID, next, emptyOpRunOptions(), time.Now().UTC())
+	msg.SpecChange = change
+	res, err := repoBootstrapWorkerAction(ctx, fixture.store, artifacts, msg)
+	if err != nil || !strings.Contains(res.Message, "repo bootstrap skipped: spec change is vars only") ||
+		len(res.Artifacts) != 0 {
+		t.Fatalf("expected repo bootstrap to be skipped, got %+v %v", res, err)
+	}
+	if image := updateImageTag(msg, next); image != "local/classified:previous" {
+		t.Fatalf("expected the render to reuse the previous image, got %q", image)
+	}
+
+	runtimeChange := workerRuntimeSpec("classified")
+	runtimeChange.Runtime = "node_22"
+	change = planSpecChange(artifacts, project, runtimeChange)
+	if !slices.Equal(change.Skipped, []string{stageRepoBootstrap}) {
+		t.Fatalf("expected a runtime change to rebuild the image, got %+v", change)
+	}
+	project.Status.Phase = projectPhaseError
+	if change = planSpecChange(artifacts, project, next); change != nil {
+		t.Fatalf("expected no plan for a project that is not ready, got %+v", change)
+	}
+}
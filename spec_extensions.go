@@ -0,0 +1,252 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Spec extensions: operators register a JSON sub-schema per x- prefixed key
+// in PAAS_SPEC_EXTENSIONS_FILE; ProjectSpec.Extensions values are checked
+// against it on the API, carried to workers unchanged, and rendered as
+// Deployment annotations.
+////////////////////////////////////////////////////////////////////////////////
+
+const extensionAnnotationPrefix = "extensions.platform.example.com/"
+
+// extensionSchema is the JSON Schema subset an extension may use. Unknown
+// keywords are rejected when the file loads rather than silently ignored.
+type extensionSchema struct {
+	Title                string                      `json:"title,omitempty"`
+	Description          string                      `json:"description,omitempty"`
+	Type                 string                      `json:"type,omitempty"`
+	Enum                 []any                       `json:"enum,omitempty"`
+	Pattern              string                      `json:"pattern,omitempty"`
+	MinLength            *int                        `json:"minLength,omitempty"`
+	MaxLength            *int                        `json:"maxLength,omitempty"`
+	Minimum              *float64                    `json:"minimum,omitempty"`
+	Maximum              *float64                    `json:"maximum,omitempty"`
+	Properties           map[string]*extensionSchema `json:"properties,omitempty"`
+	Required             []string                    `json:"required,omitempty"`
+	AdditionalProperties *bool                       `json:"additionalProperties,omitempty"`
+	Items                *extensionSchema            `json:"items,omitempty"`
+
+	patternRe *regexp.Regexp
+}
+
+// specExtensionRegistry maps each registered extension key to its schema.
+// A nil registry has no extensions, so any extension in a spec is refused.
+type specExtensionRegistry struct {
+	schemas map[string]*extensionSchema
+}
+
+func specExtensionsFromEnv() (*specExtensionRegistry, error) {
+	path := strings.TrimSpace(os.Getenv(specExtensionsFileEnv))
+	if path == "" {
+		return &specExtensionRegistry{schemas: map[string]*extensionSchema{}}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", specExtensionsFileEnv, err)
+	}
+	registry, err := parseSpecExtensionRegistry(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", specExtensionsFileEnv, path, err)
+	}
+	return registry, nil
+}
+
+// parseSpecExtensionRegistry reads {"x-key": <schema>, ...}.
+func parseSpecExtensionRegistry(raw []byte) (*specExtensionRegistry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var schemas map[string]*extensionSchema
+	if err := decoder.Decode(&schemas); err != nil {
+		return nil, err
+	}
+	for key, schema := range schemas {
+		if err := validateExtensionKeys(map[string]any{key: nil}); err != nil {
+			return nil, err
+		}
+		if schema == nil {
+			return nil, fmt.Errorf("%s: schema is empty", key)
+		}
+		if err := schema.compile(key); err != nil {
+			return nil, err
+		}
+	}
+	return &specExtensionRegistry{schemas: schemas}, nil
+}
+
+func (s *extensionSchema) compile(path string) error {
+	switch s.Type {
+	case "", "string", "number", "integer", "boolean", "object", "array", "null":
+	default:
+		return fmt.Errorf("%s: unsupported type %q", path, s.Type)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: pattern: %w", path, err)
+		}
+		s.patternRe = re
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s.%s: schema is empty", path, name)
+		}
+		if err := property.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// keys lists the registered extension keys in order.
+func (r *specExtensionRegistry) keys() []string {
+	if r == nil {
+		return []string{}
+	}
+	return sortedKeys(r.schemas)
+}
+
+// validate checks every extension against its registered schema. Errors are
+// specFieldErrors naming the value's path, like validateProjectSpec's.
+func (r *specExtensionRegistry) validate(extensions map[string]any) error {
+	for _, key := range sortedKeys(extensions) {
+		var schema *extensionSchema
+		if r != nil {
+			schema = r.schemas[key]
+		}
+		if schema == nil {
+			return specFieldErrorf("extensions."+key, "extensions.%s must be registered by the operator in %s",
+				key, specExtensionsFileEnv)
+		}
+		if err := schema.check("extensions."+key, extensions[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *extensionSchema) check(path string, value any) error {
+	if err := s.checkType(path, value); err != nil {
+		return err
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(v any) bool { return reflect.DeepEqual(v, value) }) {
+		return specFieldErrorf(path, "%s must be one of %s", path, string(mustCompactJSON(s.Enum)))
+	}
+	switch v := value.(type) {
+	case string:
+		return s.checkString(path, v)
+	case float64:
+		return s.checkNumber(path, v)
+	case map[string]any:
+		return s.checkObject(path, v)
+	case []any:
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range v {
+			if err := s.Items.check(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *extensionSchema) checkType(path string, value any) error {
+	ok := true
+	switch s.Type {
+	case "":
+	case "string":
+		_, ok = value.(string)
+	case "number":
+		_, ok = value.(float64)
+	case "integer":
+		n, isNumber := value.(float64)
+		ok = isNumber && n == math.Trunc(n)
+	case "boolean":
+		_, ok = value.(bool)
+	case "object":
+		_, ok = value.(map[string]any)
+	case "array":
+		_, ok = value.([]any)
+	case "null":
+		ok = value == nil
+	}
+	if !ok {
+		return specFieldErrorf(path, "%s must be of type %s", path, s.Type)
+	}
+	return nil
+}
+
+func (s *extensionSchema) checkString(path, value string) error {
+	length := len([]rune(value))
+	if s.MinLength != nil && length < *s.MinLength {
+		return specFieldErrorf(path, "%s must be at least %d characters", path, *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return specFieldErrorf(path, "%s must be at most %d characters", path, *s.MaxLength)
+	}
+	if s.patternRe != nil && !s.patternRe.MatchString(value) {
+		return specFieldErrorf(path, "%s must match %s", path, s.Pattern)
+	}
+	return nil
+}
+
+func (s *extensionSchema) checkNumber(path string, value float64) error {
+	if s.Minimum != nil && value < *s.Minimum {
+		return specFieldErrorf(path, "%s must be >= %v", path, *s.Minimum)
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		return specFieldErrorf(path, "%s must be <= %v", path, *s.Maximum)
+	}
+	return nil
+}
+
+func (s *extensionSchema) checkObject(path string, value map[string]any) error {
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			return specFieldErrorf(path+"."+name, "%s.%s must be set", path, name)
+		}
+	}
+	for _, name := range sortedKeys(value) {
+		property, known := s.Properties[name]
+		if !known {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return specFieldErrorf(path+"."+name, "%s.%s must not be set (not in the schema)", path, name)
+			}
+			continue
+		}
+		if err := property.check(path+"."+name, value[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extensionAnnotationValue renders strings as-is and anything else as
+// compact JSON, so simple tags read naturally on the Deployment.
+func extensionAnnotationValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return string(mustCompactJSON(value))
+}
+
+func mustCompactJSON(v any) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
@@ -0,0 +1,111 @@
+//nolint:testpackage // Extension tests exercise the unexported registry and renderers.
+package platform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testExtensionSchemas = `{
+  "x-cost-center": {"type": "string", "pattern": "^CC-[0-9]{4}$"},
+  "x-service-tier": {"type": "string", "enum": ["gold", "silver"]},
+  "x-owner": {
+    "type": "object",
+    "required": ["team"],
+    "additionalProperties": false,
+    "properties": {
+      "team": {"type": "string", "minLength": 2},
+      "pager": {"type": "integer", "minimum": 1}
+    }
+  }
+}`
+
+func TestSpecExtensionRegistry_Validate(t *testing.T) {
+	registry, err := parseSpecExtensionRegistry([]byte(testExtensionSchemas))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := strings.Join(registry.keys(), ","); got != "x-cost-center,x-owner,x-service-tier" {
+		t.Fatalf("unexpected keys %q", got)
+	}
+
+	valid := map[string]any{
+		"x-cost-center":  "CC-1234",
+		"x-service-tier": "gold",
+		"x-owner":        map[string]any{"team": "data", "pager": float64(3)},
+	}
+	if err = registry.validate(valid); err != nil {
+		t.Fatalf("expected valid extensions, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		extensions map[string]any
+		want       string
+	}{
+		{map[string]any{"x-cost-center": "1234"}, "extensions.x-cost-center must match"},
+		{map[string]any{"x-cost-center": float64(1234)}, "extensions.x-cost-center must be of type string"},
+		{map[string]any{"x-service-tier": "bronze"}, `must be one of ["gold","silver"]`},
+		{map[string]any{"x-owner": map[string]any{}}, "extensions.x-owner.team must be set"},
+		{map[string]any{"x-owner": map[string]any{"team": "data", "pager": 1.5}}, "x-owner.pager must be of type integer"},
+		{map[string]any{"x-owner": map[string]any{"team": "data", "slack": "#data"}}, "x-owner.slack must not be set"},
+		{map[string]any{"x-unknown": "v"}, "extensions.x-unknown must be registered"},
+	} {
+		if err = registry.validate(tc.extensions); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%v: expected error containing %q, got %v", tc.extensions, tc.want, err)
+		}
+	}
+}
+
+func TestParseSpecExtensionRegistry_RejectsUnsupportedSchemas(t *testing.T) {
+	for raw, want := range map[string]string{
+		`{"cost-center": {"type": "string"}}`:            "must match",
+		`{"x-tier": {"oneOf": []}}`:                      "unknown field",
+		`{"x-tier": {"type": "date"}}`:                   "unsupported type",
+		`{"x-tier": {"type": "string", "pattern": "("}}`: "pattern",
+	} {
+		if _, err := parseSpecExtensionRegistry([]byte(raw)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected error containing %q, got %v", raw, want, err)
+		}
+	}
+}
+
+func TestRenderDeploymentManifest_AddsExtensionAnnotations(t *testing.T) {
+	spec := workerRuntimeSpec("ext-app")
+	spec.Extensions = map[string]any{
+		"x-cost-center": "CC-1234",
+		"x-owner":       map[string]any{"team": "data"},
+	}
+	manifest := renderDeploymentManifest(spec, "example/ext-app:abc")
+	for _, want := range []string{
+		`extensions.platform.example.com/x-cost-center: "CC-1234"`,
+		`extensions.platform.example.com/x-owner: "{\"team\":\"data\"}"`,
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Fatalf("manifest missing %q:\n%s", want, manifest)
+		}
+	}
+
+	var decoded ProjectSpec
+	req := httptest.NewRequest(http.MethodPost, "/api/projects", strings.NewReader(string(renderProjectConfigYAML(spec))))
+	req.Header.Set("Content-Type", "application/yaml")
+	if err := decodeSpecBody(req, &decoded); err != nil {
+		t.Fatalf("decode rendered project.yaml: %v", err)
+	}
+	if decoded.Extensions["x-cost-center"] != "CC-1234" {
+		t.Fatalf("expected extensions to survive project.yaml, got %#v", decoded.Extensions)
+	}
+}
+
+func TestAPI_CreateProjectRejectsUnregisteredExtension(t *testing.T) {
+	api := &API{}
+	body := `{"apiVersion":"platform.example.com/v2","kind":"App","name":"ext-app","runtime":"go_1.26",` +
+		`"environments":{"dev":{"vars":{}}},"networkPolicies":{"ingress":"internal","egress":"internal"},` +
+		`"extensions":{"x-cost-center":"CC-1234"}}`
+	rec := httptest.NewRecorder()
+	api.handleProjects(rec, httptest.NewRequest(http.MethodPost, "/api/projects", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "must be registered") {
+		t.Fatalf("expected 400 for unregistered extension, got %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,92 @@
+package platform
+
+import (
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Spec lint: best-practice checks on a spec that already passed validation.
+// Findings come back on create, update, spec validation, and the journey, and
+// never block a write.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	specLintSingleReplicaProduction = "single-replica-production"
+	specLintExposedWithoutProbes    = "exposed-without-probes"
+	specLintFileLikeEnvValue        = "file-like-env-value"
+
+	// Values at least this long that span lines are most likely pasted files.
+	specLintFileLikeValueBytes = 512
+	// Production needs a second replica to stay up through a pod restart.
+	specLintMinProductionReplicas = 2
+)
+
+// SpecLintWarning is one lint finding. Rule is a stable identifier; Field is
+// the JSON path the finding is about.
+type SpecLintWarning struct {
+	Rule    string `json:"rule"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// lintProjectSpec returns the findings for spec, or an empty list. The order
+// is fixed, so the same spec always lints the same way.
+func lintProjectSpec(spec ProjectSpec) []SpecLintWarning {
+	spec = normalizeProjectSpec(spec)
+	warnings := []SpecLintWarning{}
+	for _, env := range sortedKeys(spec.Environments) {
+		if isProductionEnvironment(env) && spec.Environments[env].floorReplicas() < specLintMinProductionReplicas {
+			warnings = append(warnings, SpecLintWarning{
+				Rule:    specLintSingleReplicaProduction,
+				Field:   "environments." + env,
+				Message: env + " runs a single replica, so a pod restart or node drain takes it down",
+			})
+		}
+	}
+	if field, exposed := specExposedOutsideCluster(spec.Exposure); exposed {
+		warnings = append(warnings, SpecLintWarning{
+			Rule:    specLintExposedWithoutProbes,
+			Field:   field,
+			Message: "outside traffic reaches the app, but no health probes keep it off pods that are not ready",
+		})
+	}
+	warnings = append(warnings, lintFileLikeVars("vars", spec.Vars)...)
+	for _, env := range sortedKeys(spec.Environments) {
+		warnings = append(warnings, lintFileLikeVars("environments."+env+".vars", spec.Environments[env].Vars)...)
+	}
+	return warnings
+}
+
+// specExposedOutsideCluster reports whether exposure lets traffic in from
+// outside the cluster, and the field that does it.
+func specExposedOutsideCluster(exposure ExposureConfig) (string, bool) {
+	if exposure.Ingress.Host != "" {
+		return "exposure.ingress.host", true
+	}
+	if exposure.serviceType() != serviceTypeClusterIP {
+		return "exposure.serviceType", true
+	}
+	return "", false
+}
+
+func lintFileLikeVars(field string, vars map[string]string) []SpecLintWarning {
+	warnings := []SpecLintWarning{}
+	for _, key := range sortedKeys(vars) {
+		value := vars[key]
+		message := ""
+		switch {
+		case strings.Contains(value, "-----BEGIN "):
+			message = key + " holds PEM data; store keys and certificates as secrets"
+		case len(value) >= specLintFileLikeValueBytes && strings.Contains(value, "\n"):
+			message = key + " looks like a file; mount it or store it as a secret instead of an env var"
+		default:
+			continue
+		}
+		warnings = append(warnings, SpecLintWarning{
+			Rule:    specLintFileLikeEnvValue,
+			Field:   field + "." + key,
+			Message: message,
+		})
+	}
+	return warnings
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"slices"
 	"sort"
 	"strings"
@@ -19,7 +20,21 @@ import (
 type Store struct {
 	kvProjects jetstream.KeyValue
 	kvOps      jetstream.KeyValue
+	kvSecrets  jetstream.KeyValue
+	kvOrgs     jetstream.KeyValue
 	opEvents   *opEventHub
+	metrics    *storeMetrics
+	opLimits   opCompactionLimits
+	opCache    *opReadCache // worker stores only; see store_op_cache.go
+	// projectWatch and opWatch are the server store's read cache; see
+	// store_read_cache.go.
+	projectWatch *kvWatchCache
+	opWatch      *kvWatchCache
+	// kvIdempotency entries expire on their own; see store_idempotency.go.
+	kvIdempotency jetstream.KeyValue
+	// projectOrgs remembers which org each project belongs to; see
+	// store_orgs.go.
+	projectOrgs *projectOrgCache
 }
 
 type projectOpsIndex struct {
@@ -48,6 +63,27 @@ type projectOpsListPage struct {
 	NextCursor string
 }
 
+// opsListQuery filters the cross-project op listing. Empty fields match
+// everything; Since is inclusive and Until exclusive on Requested.
+type opsListQuery struct {
+	ProjectID string
+	Kinds     []OperationKind
+	Statuses  []string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Cursor    string
+}
+
+// opsCursorError reports a cursor naming an op that no longer exists.
+type opsCursorError struct {
+	cursor string
+}
+
+func (e opsCursorError) Error() string {
+	return fmt.Sprintf("bad cursor: op %q not found", e.cursor)
+}
+
 type projectReleaseListQuery struct {
 	Limit  int
 	Cursor string
@@ -71,20 +107,57 @@ type projectOpsBackfillReport struct {
 }
 
 func newStore(ctx context.Context, js jetstream.JetStream) (*Store, error) {
+	var metaKV jetstream.KeyValue
+	err := ensureKVBucket(ctx, js, kvBucketName(kvBucketMeta), 1, &metaKV)
+	if err != nil {
+		return nil, err
+	}
+	cfg := runtimeConfigFromContext(ctx)
 	var projectsKV jetstream.KeyValue
-	err := ensureKVBucket(ctx, js, kvBucketProjects, defaultKVProjectHistory, &projectsKV)
+	projectHistory := kvHistoryLimit(cfg.KVProjectHistory)
+	migration, migrated, err := openStoreBucket(
+		ctx, js, metaKV, kvBucketName(kvBucketProjects), projectHistory, &projectsKV,
+	)
 	if err != nil {
 		return nil, err
 	}
+	if migrated {
+		logKVBucketMigration(migration)
+	}
 	var opsKV jetstream.KeyValue
-	err = ensureKVBucket(ctx, js, kvBucketOps, defaultKVOpsHistory, &opsKV)
+	opsHistory := kvHistoryLimit(cfg.KVOpsHistory)
+	migration, migrated, err = openStoreBucket(ctx, js, metaKV, kvBucketName(kvBucketOps), opsHistory, &opsKV)
+	if err != nil {
+		return nil, err
+	}
+	if migrated {
+		logKVBucketMigration(migration)
+	}
+	var secretsKV jetstream.KeyValue
+	if err = ensureKVBucket(ctx, js, kvBucketName(kvBucketSecrets), 1, &secretsKV); err != nil {
+		return nil, err
+	}
+	var orgsKV jetstream.KeyValue
+	if err = ensureKVBucket(ctx, js, kvBucketName(kvBucketOrgs), 1, &orgsKV); err != nil {
+		return nil, err
+	}
+	idempotencyKV, err := ensureIdempotencyBucket(ctx, js)
 	if err != nil {
 		return nil, err
 	}
 	return &Store{
-		kvProjects: projectsKV,
-		kvOps:      opsKV,
-		opEvents:   nil,
+		kvProjects:    projectsKV,
+		kvOps:         opsKV,
+		kvSecrets:     secretsKV,
+		kvOrgs:        orgsKV,
+		projectOrgs:   newProjectOrgCache(),
+		kvIdempotency: idempotencyKV,
+		opEvents:      nil,
+		metrics:       newStoreMetrics(storeSlowThresholdFromEnv()),
+		opLimits:      opCompactionLimitsFromEnv(),
+		opCache:       nil,
+		projectWatch:  nil,
+		opWatch:       nil,
 	}, nil
 }
 
@@ -95,34 +168,91 @@ func (s *Store) setOpEvents(hub *opEventHub) {
 	s.opEvents = hub
 }
 
+// PutProject writes p over the revision it was read at, or creates it when
+// p.Revision is zero. A project that changed since (or already exists)
+// fails with projectRevisionConflictError instead of being overwritten.
 func (s *Store) PutProject(ctx context.Context, p Project) error {
-	p.UpdatedAt = time.Now().UTC()
-	b, err := json.Marshal(p)
-	if err != nil {
-		return err
-	}
-	_, err = s.kvProjects.Put(ctx, kvProjectKeyPrefix+p.ID, b)
+	_, err := s.putProject(ctx, p)
 	return err
 }
 
-func (s *Store) GetProject(ctx context.Context, projectID string) (Project, error) {
-	e, err := s.kvProjects.Get(ctx, kvProjectKeyPrefix+projectID)
+func (s *Store) putProject(ctx context.Context, p Project) (Project, error) {
+	defer s.observe("PutProject", time.Now())
+	p.UpdatedAt = time.Now().UTC()
+	p.SpecHash = projectSpecHash(p.Spec)
+	b, err := json.Marshal(p)
 	if err != nil {
 		return Project{}, err
 	}
-	var p Project
-	unmarshalErr := json.Unmarshal(e.Value(), &p)
-	if unmarshalErr != nil {
-		return Project{}, unmarshalErr
+	key := kvProjectKeyPrefix + p.ID
+	var revision uint64
+	if p.Revision == 0 {
+		revision, err = s.kvProjects.Create(ctx, key, b)
+	} else {
+		revision, err = s.kvProjects.Update(ctx, key, b, p.Revision)
+	}
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return Project{}, s.projectRevisionConflict(ctx, p.ID, p.Revision)
 	}
+	if err != nil {
+		return Project{}, err
+	}
+	p.Revision = revision
+	s.projectWatch.put(p.ID, b, revision)
+	emitProjectStatus(s.opEvents, p)
 	return p, nil
 }
 
+// updateProject applies edit to the stored project and writes it back,
+// rereading and reapplying edit when another write lands in between. An
+// edit that returns false leaves the project unwritten.
+func (s *Store) updateProject(ctx context.Context, projectID string, edit func(*Project) bool) (Project, error) {
+	var err error
+	for range projectWriteAttempts {
+		var project Project
+		if project, _, err = s.readProject(ctx, projectID); err != nil {
+			return Project{}, err
+		}
+		if !edit(&project) {
+			return project, nil
+		}
+		if project, err = s.putProject(ctx, project); err == nil {
+			return project, nil
+		}
+		var conflict projectRevisionConflictError
+		if !errors.As(err, &conflict) {
+			return Project{}, err
+		}
+	}
+	return Project{}, fmt.Errorf("project %s kept changing: %w", projectID, err)
+}
+
+func (s *Store) GetProject(ctx context.Context, projectID string) (Project, error) {
+	if p, ok := s.watchedProject(ctx, projectID); ok {
+		s.observeCached("GetProject")
+		return p, nil
+	}
+	defer s.observe("GetProject", time.Now())
+	p, _, err := s.readProject(ctx, projectID)
+	return p, err
+}
+
 func (s *Store) DeleteProject(ctx context.Context, projectID string) error {
-	return s.kvProjects.Delete(ctx, kvProjectKeyPrefix+projectID)
+	defer s.observe("DeleteProject", time.Now())
+	if err := s.kvProjects.Delete(ctx, kvProjectKeyPrefix+projectID); err != nil {
+		return err
+	}
+	s.projectWatch.markDeleted(projectID)
+	emitProjectDeleted(s.opEvents, projectID)
+	return nil
 }
 
 func (s *Store) ListProjects(ctx context.Context) ([]Project, error) {
+	if projects, ok := s.watchedProjects(ctx); ok {
+		s.observeCached("ListProjects")
+		return projects, nil
+	}
+	defer s.observe("ListProjects", time.Now())
 	keys, err := s.kvProjects.Keys(ctx)
 	if err != nil {
 		// Some KV backends can return ErrNoKeys if empty; treat as empty.
@@ -149,18 +279,77 @@ func (s *Store) ListProjects(ctx context.Context) ([]Project, error) {
 }
 
 func (s *Store) PutOp(ctx context.Context, op Operation) error {
+	defer s.observe("PutOp", time.Now())
+	return s.writeOp(ctx, op, 0)
+}
+
+// updateOp applies edit to opID's record and writes it back with a revision
+// check, rereading from KV and reapplying edit when another writer (a
+// cancel, the reaper, a worker on another replica) got there first. The
+// first attempt may start from this store's cached record; a stale cache
+// only costs a retry. An edit that returns false leaves the op unwritten.
+func (s *Store) updateOp(ctx context.Context, opID string, edit func(*Operation) bool) (Operation, error) {
+	defer s.observe("updateOp", time.Now())
+	var err error
+	for attempt := range opWriteAttempts {
+		op, revision, cached := s.cachedOp(opID)
+		if !cached || attempt > 0 {
+			var rev kvRevision
+			if op, rev, err = s.readOp(ctx, opID); err != nil {
+				return Operation{}, err
+			}
+			revision = rev.Revision
+		}
+		if !edit(&op) {
+			return op, nil
+		}
+		if err = s.writeOp(ctx, op, revision); err == nil {
+			return op, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return Operation{}, err
+		}
+		s.forgetOp(opID)
+	}
+	return Operation{}, fmt.Errorf("op %s kept changing: %w", opID, err)
+}
+
+// writeOp stores op, revision-checked against expected unless it is 0, and
+// refreshes this store's caches and the project's ops index.
+func (s *Store) writeOp(ctx context.Context, op Operation, expected uint64) error {
+	op.Notes = nil                              // stored under kvOpNotesKeyPrefix
+	op.SLABreached, op.SLABreaches = false, nil // stored under kvOpSLAKey
 	b, err := json.Marshal(op)
 	if err != nil {
 		return err
 	}
-	_, err = s.kvOps.Put(ctx, kvOpKeyPrefix+op.ID, b)
+	if b, err = s.boundOpSize(op, b); err != nil {
+		return err
+	}
+	var revision uint64
+	if expected == 0 {
+		revision, err = s.kvOps.Put(ctx, kvOpKeyPrefix+op.ID, b)
+	} else {
+		revision, err = s.kvOps.Update(ctx, kvOpKeyPrefix+op.ID, b, expected)
+	}
 	if err != nil {
 		return err
 	}
-	return s.recordProjectOp(ctx, op.ProjectID, op.ID)
+	s.opWatch.put(op.ID, b, revision)
+	if s.opIndexed(op.ID) {
+		s.rememberOp(op.ID, b, revision, true)
+		return nil
+	}
+	s.rememberOp(op.ID, b, revision, false)
+	if err = s.recordProjectOp(ctx, op.ProjectID, op.ID); err != nil {
+		return err
+	}
+	s.rememberOp(op.ID, b, revision, true)
+	return nil
 }
 
 func (s *Store) PutRelease(ctx context.Context, release ReleaseRecord) (ReleaseRecord, error) {
+	defer s.observe("PutRelease", time.Now())
 	release = normalizeReleaseRecord(release)
 	if strings.TrimSpace(release.ProjectID) == "" {
 		return ReleaseRecord{}, errors.New("project_id required")
@@ -193,32 +382,28 @@ func (s *Store) PutRelease(ctx context.Context, release ReleaseRecord) (ReleaseR
 	if err = s.writeProjectReleaseCurrent(ctx, release.ProjectID, release.Environment, release.ID); err != nil {
 		return ReleaseRecord{}, err
 	}
+	emitProjectRelease(s.opEvents, release)
 	return release, nil
 }
 
 func (s *Store) GetRelease(ctx context.Context, releaseID string) (ReleaseRecord, error) {
-	entry, err := s.kvOps.Get(ctx, kvReleaseKeyPrefix+strings.TrimSpace(releaseID))
-	if err != nil {
-		return ReleaseRecord{}, err
-	}
-	var release ReleaseRecord
-	if unmarshalErr := json.Unmarshal(entry.Value(), &release); unmarshalErr != nil {
-		return ReleaseRecord{}, unmarshalErr
-	}
-	return normalizeReleaseRecord(release), nil
+	defer s.observe("GetRelease", time.Now())
+	release, _, err := s.readRelease(ctx, releaseID)
+	return release, err
 }
 
 func (s *Store) GetOp(ctx context.Context, opID string) (Operation, error) {
-	e, err := s.kvOps.Get(ctx, kvOpKeyPrefix+opID)
-	if err != nil {
-		return Operation{}, err
+	if op, _, ok := s.cachedOp(opID); ok {
+		s.observeCached("GetOp")
+		return op, nil
 	}
-	var op Operation
-	unmarshalErr := json.Unmarshal(e.Value(), &op)
-	if unmarshalErr != nil {
-		return Operation{}, unmarshalErr
+	if op, ok := s.watchedOp(ctx, opID); ok {
+		s.observeCached("GetOp")
+		return op, nil
 	}
-	return op, nil
+	defer s.observe("GetOp", time.Now())
+	op, _, err := s.readOp(ctx, opID)
+	return op, err
 }
 
 func (s *Store) listProjectOps(
@@ -226,6 +411,7 @@ func (s *Store) listProjectOps(
 	projectID string,
 	query projectOpsListQuery,
 ) (projectOpsListPage, error) {
+	defer s.observe("listProjectOps", time.Now())
 	projectID = strings.TrimSpace(projectID)
 	if projectID == "" {
 		return projectOpsListPage{Ops: []Operation{}, NextCursor: ""}, nil
@@ -254,12 +440,83 @@ func (s *Store) listProjectOps(
 	)
 }
 
+// listOps pages through every op in the ops bucket, newest first. The
+// cursor is the last op ID of the previous page; paging continues from that
+// op's position, so ops written since then do not shift later pages.
+func (s *Store) listOps(ctx context.Context, query opsListQuery) (projectOpsListPage, error) {
+	defer s.observe("listOps", time.Now())
+	limit := normalizeProjectOpsLimit(query.Limit)
+	var after *Operation
+	if cursor := strings.TrimSpace(query.Cursor); cursor != "" {
+		cursorOp, err := s.GetOp(ctx, cursor)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				return projectOpsListPage{}, opsCursorError{cursor: cursor}
+			}
+			return projectOpsListPage{}, err
+		}
+		after = &cursorOp
+	}
+
+	ops, err := s.listAllOps(ctx)
+	if err != nil {
+		return projectOpsListPage{}, err
+	}
+	slices.SortFunc(ops, compareOpsNewestFirst)
+
+	items := make([]Operation, 0, limit+1)
+	for _, op := range ops {
+		if after != nil && compareOpsNewestFirst(op, *after) <= 0 {
+			continue
+		}
+		if !query.matches(op) {
+			continue
+		}
+		items = append(items, op)
+		if len(items) > limit {
+			break
+		}
+	}
+	nextCursor := ""
+	if len(items) > limit {
+		items = items[:limit]
+		nextCursor = items[len(items)-1].ID
+	}
+	return projectOpsListPage{Ops: items, NextCursor: nextCursor}, nil
+}
+
+func (q opsListQuery) matches(op Operation) bool {
+	if q.ProjectID != "" && strings.TrimSpace(op.ProjectID) != q.ProjectID {
+		return false
+	}
+	if len(q.Kinds) > 0 && !slices.Contains(q.Kinds, op.Kind) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, op.Status) {
+		return false
+	}
+	if !q.Since.IsZero() && op.Requested.Before(q.Since) {
+		return false
+	}
+	return q.Until.IsZero() || op.Requested.Before(q.Until)
+}
+
+// compareOpsNewestFirst orders by request time descending, breaking ties
+// on ID so the order (and therefore the cursor) is total.
+func compareOpsNewestFirst(a, b Operation) int {
+	if c := b.Requested.Compare(a.Requested); c != 0 {
+		return c
+	}
+	return strings.Compare(b.ID, a.ID)
+}
+
 func (s *Store) listProjectReleases(
 	ctx context.Context,
 	projectID string,
 	environment string,
 	query projectReleaseListQuery,
 ) (projectReleaseListPage, error) {
+	defer s.observe("listProjectReleases", time.Now())
 	projectID = strings.TrimSpace(projectID)
 	environment = normalizeEnvironmentName(environment)
 	if projectID == "" || environment == "" {
@@ -316,6 +573,7 @@ func (s *Store) backfillProjectOpsIndex(
 	ctx context.Context,
 	maxScan int,
 ) (projectOpsBackfillReport, error) {
+	defer s.observe("backfillProjectOpsIndex", time.Now())
 	var report projectOpsBackfillReport
 
 	opsByProject, err := s.scanProjectOpsForBackfill(
@@ -340,6 +598,7 @@ func (s *Store) scanProjectOpsForBackfill(
 	scanLimit int,
 	report *projectOpsBackfillReport,
 ) (map[string][]Operation, error) {
+	defer s.observe("scanProjectOpsForBackfill", time.Now())
 	keys, err := s.kvOps.Keys(ctx)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrNoKeysFound) {
@@ -375,6 +634,7 @@ func (s *Store) readBackfillOp(
 	key string,
 	report *projectOpsBackfillReport,
 ) (Operation, bool) {
+	defer s.observe("readBackfillOp", time.Now())
 	entry, err := s.kvOps.Get(ctx, key)
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrKeyDeleted) {
@@ -405,6 +665,7 @@ func (s *Store) rebuildProjectOpsIndexes(
 	opsByProject map[string][]Operation,
 	report *projectOpsBackfillReport,
 ) error {
+	defer s.observe("rebuildProjectOpsIndexes", time.Now())
 	projectIDs := make([]string, 0, len(opsByProject))
 	for projectID := range opsByProject {
 		projectIDs = append(projectIDs, projectID)
@@ -490,6 +751,7 @@ func (s *Store) collectProjectOpsPage(
 	limit int,
 	beforeAt time.Time,
 ) (projectOpsListPage, error) {
+	defer s.observe("collectProjectOpsPage", time.Now())
 	items := make([]Operation, 0, limit+1)
 	for _, opID := range opIDs {
 		op, getErr := s.GetOp(ctx, opID)
@@ -632,6 +894,7 @@ func normalizeReleaseRecord(in ReleaseRecord) ReleaseRecord {
 	release.RenderedPath = strings.Trim(strings.TrimSpace(release.RenderedPath), "/")
 	release.ConfigPath = strings.Trim(strings.TrimSpace(release.ConfigPath), "/")
 	release.RollbackSourceRelease = strings.TrimSpace(release.RollbackSourceRelease)
+	release.SourceCommit = strings.TrimSpace(release.SourceCommit)
 	release.RollbackScope = RollbackScope(strings.TrimSpace(string(release.RollbackScope)))
 	if release.Environment == "" && release.ToEnv != "" {
 		release.Environment = release.ToEnv
@@ -645,7 +908,8 @@ func normalizeReleaseRecord(in ReleaseRecord) ReleaseRecord {
 			release.DeliveryStage = DeliveryStageRelease
 		case OpPromote:
 			release.DeliveryStage = DeliveryStagePromote
-		case OpDeploy, OpCreate, OpUpdate, OpDelete, OpCI:
+		case OpDeploy, OpCreate, OpUpdate, OpDelete, OpCI, OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade,
+			OpWebhookRefresh:
 			release.DeliveryStage = DeliveryStageDeploy
 		case OpRollback:
 			release.DeliveryStage = rollbackDeliveryStage(release.Environment)
@@ -696,6 +960,7 @@ func indexStartFromCursor(ids []string, cursor string) int {
 }
 
 func (s *Store) recordProjectOp(ctx context.Context, projectID, opID string) error {
+	defer s.observe("recordProjectOp", time.Now())
 	projectID = strings.TrimSpace(projectID)
 	opID = strings.TrimSpace(opID)
 	if projectID == "" || opID == "" {
@@ -721,6 +986,7 @@ func (s *Store) recordProjectOp(ctx context.Context, projectID, opID string) err
 }
 
 func (s *Store) recordProjectRelease(ctx context.Context, projectID, environment, releaseID string) error {
+	defer s.observe("recordProjectRelease", time.Now())
 	projectID = strings.TrimSpace(projectID)
 	environment = normalizeEnvironmentName(environment)
 	releaseID = strings.TrimSpace(releaseID)
@@ -739,13 +1005,33 @@ func (s *Store) recordProjectRelease(ctx context.Context, projectID, environment
 
 	index.IDs = append([]string{releaseID}, index.IDs...)
 	if len(index.IDs) > projectReleaseHistoryCap {
-		index.IDs = append([]string(nil), index.IDs[:projectReleaseHistoryCap]...)
+		holds, holdsErr := s.getProjectHolds(ctx, projectID)
+		if holdsErr != nil {
+			return holdsErr
+		}
+		index.IDs = trimReleaseIndexKeepingHeld(index.IDs, holds)
 	}
 	index.UpdatedAt = time.Now().UTC()
 	return s.writeProjectReleaseIndex(ctx, projectID, environment, index)
 }
 
+// trimReleaseIndexKeepingHeld applies the history cap but never drops a
+// release that is under a compliance hold.
+func trimReleaseIndexKeepingHeld(ids []string, holds projectHolds) []string {
+	if holds.Project != nil {
+		return ids
+	}
+	kept := append([]string(nil), ids[:projectReleaseHistoryCap]...)
+	for _, id := range ids[projectReleaseHistoryCap:] {
+		if _, held := holds.Releases[id]; held {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
 func (s *Store) readProjectOpsIndex(ctx context.Context, projectID string) (projectOpsIndex, error) {
+	defer s.observe("readProjectOpsIndex", time.Now())
 	entry, err := s.kvOps.Get(ctx, projectOpsIndexKey(projectID))
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
@@ -767,6 +1053,7 @@ func (s *Store) readProjectOpsIndex(ctx context.Context, projectID string) (proj
 }
 
 func (s *Store) writeProjectOpsIndex(ctx context.Context, projectID string, index projectOpsIndex) error {
+	defer s.observe("writeProjectOpsIndex", time.Now())
 	body, err := json.Marshal(index)
 	if err != nil {
 		return err
@@ -780,6 +1067,7 @@ func (s *Store) readProjectReleaseIndex(
 	projectID string,
 	environment string,
 ) (projectReleaseIndex, error) {
+	defer s.observe("readProjectReleaseIndex", time.Now())
 	entry, err := s.kvOps.Get(ctx, projectReleaseIndexKey(projectID, environment))
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
@@ -806,6 +1094,7 @@ func (s *Store) writeProjectReleaseIndex(
 	environment string,
 	index projectReleaseIndex,
 ) error {
+	defer s.observe("writeProjectReleaseIndex", time.Now())
 	body, err := json.Marshal(index)
 	if err != nil {
 		return err
@@ -819,6 +1108,7 @@ func (s *Store) readProjectReleaseCurrent(
 	projectID string,
 	environment string,
 ) (projectReleaseCurrent, bool, error) {
+	defer s.observe("readProjectReleaseCurrent", time.Now())
 	entry, err := s.kvOps.Get(ctx, projectReleaseCurrentKey(projectID, environment))
 	if err != nil {
 		if errors.Is(err, jetstream.ErrKeyNotFound) {
@@ -843,6 +1133,7 @@ func (s *Store) writeProjectReleaseCurrent(
 	environment string,
 	releaseID string,
 ) error {
+	defer s.observe("writeProjectReleaseCurrent", time.Now())
 	current := projectReleaseCurrent{
 		ID:        strings.TrimSpace(releaseID),
 		UpdatedAt: time.Now().UTC(),
@@ -860,6 +1151,7 @@ func (s *Store) getProjectCurrentRelease(
 	projectID string,
 	environment string,
 ) (ReleaseRecord, bool, error) {
+	defer s.observe("getProjectCurrentRelease", time.Now())
 	current, ok, err := s.readProjectReleaseCurrent(ctx, projectID, environment)
 	if err != nil || !ok {
 		return ReleaseRecord{}, false, err
@@ -0,0 +1,511 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// storeRepairFinding is one dangling reference found by repairStore. Fix
+// describes what was (or, in a dry run, would be) done about it.
+type storeRepairFinding struct {
+	Key     string `json:"key"`
+	Problem string `json:"problem"`
+	Fix     string `json:"fix"`
+}
+
+// storeExport is a full dump of the records paasadmin can inspect.
+type storeExport struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Projects   []Project       `json:"projects"`
+	Ops        []Operation     `json:"ops"`
+	Releases   []ReleaseRecord `json:"releases"`
+}
+
+func (s *Store) opsBucketKeys(ctx context.Context) ([]string, error) {
+	keys, err := s.kvOps.Keys(ctx)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrNoKeysFound) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// listAllOps reads every op record, skipping ones that no longer decode.
+func (s *Store) listAllOps(ctx context.Context) ([]Operation, error) {
+	if ops, ok := s.watchedOps(ctx); ok {
+		s.observeCached("listAllOps")
+		return ops, nil
+	}
+	defer s.observe("listAllOps", time.Now())
+	keys, err := s.opsBucketKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []Operation{}
+	for _, key := range keys {
+		opID, ok := strings.CutPrefix(key, kvOpKeyPrefix)
+		if !ok {
+			continue
+		}
+		op, getErr := s.GetOp(ctx, opID)
+		if getErr != nil {
+			continue
+		}
+		out = append(out, op)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Requested.Before(out[j].Requested) })
+	return out, nil
+}
+
+// listAllReleases reads every release record, including ones no index
+// points at anymore.
+func (s *Store) listAllReleases(ctx context.Context) ([]ReleaseRecord, error) {
+	defer s.observe("listAllReleases", time.Now())
+	keys, err := s.opsBucketKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []ReleaseRecord{}
+	for _, key := range keys {
+		releaseID, ok := strings.CutPrefix(key, kvReleaseKeyPrefix)
+		if !ok {
+			continue
+		}
+		release, getErr := s.GetRelease(ctx, releaseID)
+		if getErr != nil {
+			continue
+		}
+		out = append(out, release)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *Store) exportAll(ctx context.Context) (storeExport, error) {
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		return storeExport{}, fmt.Errorf("list projects: %w", err)
+	}
+	if projects == nil {
+		projects = []Project{}
+	}
+	ops, err := s.listAllOps(ctx)
+	if err != nil {
+		return storeExport{}, fmt.Errorf("list ops: %w", err)
+	}
+	releases, err := s.listAllReleases(ctx)
+	if err != nil {
+		return storeExport{}, fmt.Errorf("list releases: %w", err)
+	}
+	return storeExport{
+		ExportedAt: time.Now().UTC(),
+		Projects:   projects,
+		Ops:        ops,
+		Releases:   releases,
+	}, nil
+}
+
+// repairStore finds index entries and pointers that reference records which
+// no longer exist, and unfinished ops whose project is gone. Indexes of
+// deleted projects are history, not damage, and are left alone. Nothing is
+// written unless apply is set; compliance holds are only ever reported.
+func (s *Store) repairStore(ctx context.Context, apply bool) ([]storeRepairFinding, error) {
+	defer s.observe("repairStore", time.Now())
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	known := map[string]struct{}{}
+	for _, project := range projects {
+		known[project.ID] = struct{}{}
+	}
+	keys, err := s.opsBucketKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []storeRepairFinding{}
+	for _, key := range keys {
+		finding, found, checkErr := s.checkStoreKey(ctx, key, known, apply)
+		if checkErr != nil {
+			return findings, fmt.Errorf("%s: %w", key, checkErr)
+		}
+		if found {
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+func (s *Store) checkStoreKey(
+	ctx context.Context,
+	key string,
+	known map[string]struct{},
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	switch {
+	case strings.HasPrefix(key, kvOpKeyPrefix):
+		return s.checkOpRecord(ctx, key, known, apply)
+	case strings.HasPrefix(key, kvProjectOpsIndexKeyPrefix):
+		return s.checkProjectOpsIndex(ctx, key, strings.TrimPrefix(key, kvProjectOpsIndexKeyPrefix), apply)
+	case strings.HasPrefix(key, kvProjectReleaseIndexKeyPrefix):
+		return s.checkProjectReleaseIndex(ctx, key, apply)
+	case strings.HasPrefix(key, kvProjectReleaseCurrentKeyPrefix):
+		return s.checkProjectReleaseCurrent(ctx, key, apply)
+	case strings.HasPrefix(key, kvProjectDeletePlanKeyPrefix):
+		return s.checkProjectDeletePlan(ctx, key, known, apply)
+	case strings.HasPrefix(key, kvProjectHoldsKeyPrefix):
+		projectID := strings.TrimPrefix(key, kvProjectHoldsKeyPrefix)
+		if _, ok := known[projectID]; ok {
+			return storeRepairFinding{}, false, nil
+		}
+		return storeRepairFinding{
+			Key:     key,
+			Problem: fmt.Sprintf("compliance holds for missing project %s", projectID),
+			Fix:     "left in place; review and remove by hand",
+		}, true, nil
+	case strings.HasPrefix(key, kvProjectFreezesKeyPrefix):
+		return s.checkProjectFreezes(ctx, key, known, apply)
+	case strings.HasPrefix(key, kvProjectSchedulesKeyPrefix):
+		return s.checkProjectSchedules(ctx, key, known, apply)
+	case strings.HasPrefix(key, kvProjectHealthKeyPrefix):
+		return s.checkProjectHealthRecord(ctx, key, known, apply)
+	case strings.HasPrefix(key, kvProjectBindingsKeyPrefix):
+		projectID := strings.TrimPrefix(key, kvProjectBindingsKeyPrefix)
+		if _, ok := known[projectID]; ok {
+			return storeRepairFinding{}, false, nil
+		}
+		finding := storeRepairFinding{
+			Key:     key,
+			Problem: fmt.Sprintf("capability bindings for missing project %s", projectID),
+			Fix:     "delete bindings",
+		}
+		if apply {
+			if err := s.deleteProjectCapabilityBindings(ctx, projectID); err != nil {
+				return finding, false, err
+			}
+		}
+		return finding, true, nil
+	case strings.HasPrefix(key, kvProjectPreviewsKeyPrefix):
+		projectID := strings.TrimPrefix(key, kvProjectPreviewsKeyPrefix)
+		if _, ok := known[projectID]; ok {
+			return storeRepairFinding{}, false, nil
+		}
+		finding := storeRepairFinding{
+			Key:     key,
+			Problem: fmt.Sprintf("preview environments for missing project %s", projectID),
+			Fix:     "delete previews",
+		}
+		if apply {
+			if err := s.deleteProjectPreviews(ctx, projectID); err != nil {
+				return finding, false, err
+			}
+		}
+		return finding, true, nil
+	case strings.HasPrefix(key, kvProjectArtifactRootKeyPrefix):
+		projectID := strings.TrimPrefix(key, kvProjectArtifactRootKeyPrefix)
+		if _, ok := known[projectID]; ok {
+			return storeRepairFinding{}, false, nil
+		}
+		finding := storeRepairFinding{
+			Key:     key,
+			Problem: fmt.Sprintf("artifact root placement for missing project %s", projectID),
+			Fix:     "delete placement",
+		}
+		if apply {
+			if err := s.deleteArtifactPlacement(ctx, projectID); err != nil {
+				return finding, false, err
+			}
+		}
+		return finding, true, nil
+	default:
+		return storeRepairFinding{}, false, nil
+	}
+}
+
+func (s *Store) checkOpRecord(
+	ctx context.Context,
+	key string,
+	known map[string]struct{},
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	op, err := s.GetOp(ctx, strings.TrimPrefix(key, kvOpKeyPrefix))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return storeRepairFinding{}, false, nil
+		}
+		return storeRepairFinding{
+			Key:     key,
+			Problem: fmt.Sprintf("unreadable op record: %v", err),
+			Fix:     "left in place; export and inspect by hand",
+		}, true, nil
+	}
+	if _, ok := known[op.ProjectID]; ok || !op.Finished.IsZero() {
+		return storeRepairFinding{}, false, nil
+	}
+	finding := storeRepairFinding{
+		Key:     key,
+		Problem: fmt.Sprintf("%s op still %s but project %s is gone", op.Kind, op.Status, op.ProjectID),
+		Fix:     "mark op as error",
+	}
+	if apply {
+		op.Status = opStatusError
+		op.Error = "abandoned: project no longer exists (repaired by paasadmin)"
+		op.Finished = time.Now().UTC()
+		if err = s.PutOp(ctx, op); err != nil {
+			return finding, false, err
+		}
+	}
+	return finding, true, nil
+}
+
+func (s *Store) checkProjectDeletePlan(
+	ctx context.Context,
+	key string,
+	known map[string]struct{},
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	projectID := strings.TrimPrefix(key, kvProjectDeletePlanKeyPrefix)
+	if _, ok := known[projectID]; ok {
+		return storeRepairFinding{}, false, nil
+	}
+	finding := storeRepairFinding{
+		Key:     key,
+		Problem: fmt.Sprintf("pending delete plan for missing project %s", projectID),
+		Fix:     "delete plan",
+	}
+	if apply {
+		if err := s.deleteDeletePlan(ctx, projectID); err != nil {
+			return finding, false, err
+		}
+	}
+	return finding, true, nil
+}
+
+func (s *Store) checkProjectFreezes(
+	ctx context.Context,
+	key string,
+	known map[string]struct{},
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	projectID := strings.TrimPrefix(key, kvProjectFreezesKeyPrefix)
+	if _, ok := known[projectID]; ok {
+		return storeRepairFinding{}, false, nil
+	}
+	finding := storeRepairFinding{
+		Key:     key,
+		Problem: fmt.Sprintf("environment freezes for missing project %s", projectID),
+		Fix:     "delete freezes",
+	}
+	if apply {
+		if err := s.deleteProjectFreezes(ctx, projectID); err != nil {
+			return finding, false, err
+		}
+	}
+	return finding, true, nil
+}
+
+func (s *Store) checkProjectSchedules(
+	ctx context.Context,
+	key string,
+	known map[string]struct{},
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	projectID := strings.TrimPrefix(key, kvProjectSchedulesKeyPrefix)
+	if _, ok := known[projectID]; ok {
+		return storeRepairFinding{}, false, nil
+	}
+	finding := storeRepairFinding{
+		Key:     key,
+		Problem: fmt.Sprintf("op schedules for missing project %s", projectID),
+		Fix:     "delete schedules",
+	}
+	if apply {
+		if err := s.deleteProjectSchedules(ctx, projectID); err != nil {
+			return finding, false, err
+		}
+	}
+	return finding, true, nil
+}
+
+func (s *Store) checkProjectHealthRecord(
+	ctx context.Context,
+	key string,
+	known map[string]struct{},
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	projectID := strings.TrimPrefix(key, kvProjectHealthKeyPrefix)
+	if _, ok := known[projectID]; ok {
+		return storeRepairFinding{}, false, nil
+	}
+	finding := storeRepairFinding{
+		Key:     key,
+		Problem: fmt.Sprintf("health probes for missing project %s", projectID),
+		Fix:     "delete health record",
+	}
+	if apply {
+		if err := s.deleteProjectHealth(ctx, projectID); err != nil {
+			return finding, false, err
+		}
+	}
+	return finding, true, nil
+}
+
+func (s *Store) checkProjectOpsIndex(
+	ctx context.Context,
+	key string,
+	projectID string,
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	index, err := s.readProjectOpsIndex(ctx, projectID)
+	if err != nil {
+		return storeRepairFinding{}, false, err
+	}
+	kept, dropped, err := s.keepExisting(ctx, index.IDs, kvOpKeyPrefix)
+	if err != nil || dropped == 0 {
+		return storeRepairFinding{}, false, err
+	}
+	finding := storeRepairFinding{
+		Key:     key,
+		Problem: fmt.Sprintf("%d op id(s) point at missing ops", dropped),
+		Fix:     "drop missing ids from index",
+	}
+	if apply {
+		index.IDs = kept
+		index.UpdatedAt = time.Now().UTC()
+		if err = s.writeProjectOpsIndex(ctx, projectID, index); err != nil {
+			return finding, false, err
+		}
+	}
+	return finding, true, nil
+}
+
+func (s *Store) checkProjectReleaseIndex(
+	ctx context.Context,
+	key string,
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	index, err := s.readReleaseIndexKey(ctx, key)
+	if err != nil {
+		return storeRepairFinding{}, false, err
+	}
+	kept, dropped, err := s.keepExisting(ctx, index.IDs, kvReleaseKeyPrefix)
+	if err != nil || dropped == 0 {
+		return storeRepairFinding{}, false, err
+	}
+	finding := storeRepairFinding{
+		Key:     key,
+		Problem: fmt.Sprintf("%d release id(s) point at missing releases", dropped),
+		Fix:     "drop missing ids from index",
+	}
+	if apply {
+		index.IDs = kept
+		index.UpdatedAt = time.Now().UTC()
+		body, marshalErr := json.Marshal(index)
+		if marshalErr != nil {
+			return finding, false, marshalErr
+		}
+		if _, err = s.kvOps.Put(ctx, key, body); err != nil {
+			return finding, false, err
+		}
+	}
+	return finding, true, nil
+}
+
+// checkProjectReleaseCurrent repoints a current-release pointer whose
+// release is gone at the newest release still in the environment's index.
+func (s *Store) checkProjectReleaseCurrent(
+	ctx context.Context,
+	key string,
+	apply bool,
+) (storeRepairFinding, bool, error) {
+	entry, err := s.kvOps.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return storeRepairFinding{}, false, nil
+		}
+		return storeRepairFinding{}, false, err
+	}
+	var current projectReleaseCurrent
+	if err = json.Unmarshal(entry.Value(), &current); err != nil {
+		return storeRepairFinding{}, false, err
+	}
+	if current.ID = strings.TrimSpace(current.ID); current.ID != "" {
+		_, dropped, existsErr := s.keepExisting(ctx, []string{current.ID}, kvReleaseKeyPrefix)
+		if existsErr != nil || dropped == 0 {
+			return storeRepairFinding{}, false, existsErr
+		}
+	}
+
+	index, err := s.readReleaseIndexKey(ctx, kvProjectReleaseIndexKeyPrefix+
+		strings.TrimPrefix(key, kvProjectReleaseCurrentKeyPrefix))
+	if err != nil {
+		return storeRepairFinding{}, false, err
+	}
+	kept, _, err := s.keepExisting(ctx, index.IDs, kvReleaseKeyPrefix)
+	if err != nil {
+		return storeRepairFinding{}, false, err
+	}
+	finding := storeRepairFinding{
+		Key:     key,
+		Problem: fmt.Sprintf("current release %s is missing", current.ID),
+		Fix:     "delete pointer",
+	}
+	if len(kept) > 0 {
+		finding.Fix = "repoint to release " + kept[0]
+	}
+	if !apply {
+		return finding, true, nil
+	}
+	if len(kept) == 0 {
+		return finding, true, s.kvOps.Delete(ctx, key)
+	}
+	body, err := json.Marshal(projectReleaseCurrent{ID: kept[0], UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return finding, false, err
+	}
+	_, err = s.kvOps.Put(ctx, key, body)
+	return finding, true, err
+}
+
+func (s *Store) readReleaseIndexKey(ctx context.Context, key string) (projectReleaseIndex, error) {
+	index := projectReleaseIndex{IDs: []string{}, UpdatedAt: time.Time{}}
+	entry, err := s.kvOps.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return index, nil
+		}
+		return index, err
+	}
+	if err = json.Unmarshal(entry.Value(), &index); err != nil {
+		return index, err
+	}
+	return index, nil
+}
+
+// keepExisting returns ids whose prefix+id key still exists, in order, and
+// how many were dropped.
+func (s *Store) keepExisting(ctx context.Context, ids []string, prefix string) ([]string, int, error) {
+	kept := make([]string, 0, len(ids))
+	for _, id := range ids {
+		_, err := s.kvOps.Get(ctx, prefix+id)
+		switch {
+		case err == nil:
+			kept = append(kept, id)
+		case errors.Is(err, jetstream.ErrKeyNotFound):
+		default:
+			return nil, 0, err
+		}
+	}
+	return kept, len(ids) - len(kept), nil
+}
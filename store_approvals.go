@@ -0,0 +1,138 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	releaseApprovalWriteAttempts = 5
+	// maxReleaseApprovalsKept is how many approvals a project keeps; the
+	// oldest decided ones go first.
+	maxReleaseApprovalsKept = 50
+)
+
+func releaseApprovalKey(id string) string {
+	return kvReleaseApprovalKeyPrefix + strings.TrimSpace(id)
+}
+
+func (s *Store) getReleaseApproval(ctx context.Context, id string) (ReleaseApproval, error) {
+	defer s.observe("getReleaseApproval", time.Now())
+	approval, _, err := s.readReleaseApproval(ctx, id)
+	return approval, err
+}
+
+func (s *Store) readReleaseApproval(ctx context.Context, id string) (ReleaseApproval, uint64, error) {
+	entry, err := s.kvOps.Get(ctx, releaseApprovalKey(id))
+	if err != nil {
+		return ReleaseApproval{}, 0, err
+	}
+	var approval ReleaseApproval
+	if err = json.Unmarshal(entry.Value(), &approval); err != nil {
+		return ReleaseApproval{}, 0, err
+	}
+	if approval.Decisions == nil {
+		approval.Decisions = []ApprovalDecision{}
+	}
+	return approval, entry.Revision(), nil
+}
+
+// listReleaseApprovals returns approvals newest first, only projectID's when
+// it is set.
+func (s *Store) listReleaseApprovals(ctx context.Context, projectID string) ([]ReleaseApproval, error) {
+	defer s.observe("listReleaseApprovals", time.Now())
+	keys, err := s.opsBucketKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []ReleaseApproval{}
+	for _, key := range keys {
+		id, ok := strings.CutPrefix(key, kvReleaseApprovalKeyPrefix)
+		if !ok {
+			continue
+		}
+		approval, _, readErr := s.readReleaseApproval(ctx, id)
+		if errors.Is(readErr, jetstream.ErrKeyNotFound) {
+			continue
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+		if projectID == "" || approval.ProjectID == projectID {
+			out = append(out, approval)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// createReleaseApproval stores a new approval, dropping the project's oldest
+// decided approvals beyond maxReleaseApprovalsKept.
+func (s *Store) createReleaseApproval(ctx context.Context, approval ReleaseApproval) error {
+	defer s.observe("createReleaseApproval", time.Now())
+	body, err := json.Marshal(approval)
+	if err != nil {
+		return err
+	}
+	if _, err = s.kvOps.Create(ctx, releaseApprovalKey(approval.ID), body); err != nil {
+		return err
+	}
+	existing, err := s.listReleaseApprovals(ctx, approval.ProjectID)
+	if err != nil || len(existing) <= maxReleaseApprovalsKept {
+		return err
+	}
+	kept := 0
+	for _, old := range existing {
+		kept++
+		if kept <= maxReleaseApprovalsKept || old.Status == approvalStatusPending {
+			continue
+		}
+		if err = s.kvOps.Delete(ctx, releaseApprovalKey(old.ID)); err != nil &&
+			!errors.Is(err, jetstream.ErrKeyNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateReleaseApproval applies change to the approval id and writes it
+// back. Writes are revision-checked, so two decisions posted at once are
+// each applied to the other's result; an error from change is returned
+// as-is and nothing is written.
+func (s *Store) updateReleaseApproval(
+	ctx context.Context,
+	id string,
+	change func(*ReleaseApproval) error,
+) (ReleaseApproval, error) {
+	defer s.observe("updateReleaseApproval", time.Now())
+	var err error
+	for range releaseApprovalWriteAttempts {
+		approval, revision, readErr := s.readReleaseApproval(ctx, id)
+		if readErr != nil {
+			return ReleaseApproval{}, readErr
+		}
+		if err = change(&approval); err != nil {
+			return ReleaseApproval{}, err
+		}
+		approval.UpdatedAt = time.Now().UTC()
+		body, marshalErr := json.Marshal(approval)
+		if marshalErr != nil {
+			return ReleaseApproval{}, marshalErr
+		}
+		_, err = s.kvOps.Update(ctx, releaseApprovalKey(id), body, revision)
+		if err == nil {
+			return approval, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return ReleaseApproval{}, err
+		}
+	}
+	return ReleaseApproval{}, fmt.Errorf("approval %s kept changing: %w", id, err)
+}
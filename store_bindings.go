@@ -0,0 +1,152 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// projectCapabilityBindings is the per-project binding record, keyed by
+// environment and then capability.
+type projectCapabilityBindings struct {
+	Environments map[string]map[string]CapabilityBinding `json:"environments"`
+	UpdatedAt    time.Time                               `json:"updated_at"`
+}
+
+// envCapabilityBindings is what rendering needs: each environment's
+// bindings, sorted by capability.
+type envCapabilityBindings map[string][]CapabilityBinding
+
+func emptyProjectCapabilityBindings() projectCapabilityBindings {
+	return projectCapabilityBindings{
+		Environments: map[string]map[string]CapabilityBinding{},
+		UpdatedAt:    time.Time{},
+	}
+}
+
+func (b projectCapabilityBindings) forEnvironment(env string) []CapabilityBinding {
+	bound := b.Environments[env]
+	out := make([]CapabilityBinding, 0, len(bound))
+	for _, capability := range sortedKeys(bound) {
+		out = append(out, bound[capability])
+	}
+	return out
+}
+
+func (b projectCapabilityBindings) byEnvironment() envCapabilityBindings {
+	out := envCapabilityBindings{}
+	for env := range b.Environments {
+		out[env] = b.forEnvironment(env)
+	}
+	return out
+}
+
+func projectBindingsKey(projectID string) string {
+	return kvProjectBindingsKeyPrefix + strings.TrimSpace(projectID)
+}
+
+func (s *Store) getCapabilityBindings(ctx context.Context, projectID string) (projectCapabilityBindings, error) {
+	defer s.observe("getCapabilityBindings", time.Now())
+	entry, err := s.kvOps.Get(ctx, projectBindingsKey(projectID))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return emptyProjectCapabilityBindings(), nil
+		}
+		return projectCapabilityBindings{}, err
+	}
+	bindings := emptyProjectCapabilityBindings()
+	if err = json.Unmarshal(entry.Value(), &bindings); err != nil {
+		return projectCapabilityBindings{}, err
+	}
+	if bindings.Environments == nil {
+		bindings.Environments = map[string]map[string]CapabilityBinding{}
+	}
+	return bindings, nil
+}
+
+// putCapabilityBinding stores binding, replacing any earlier binding of the
+// same capability in the same environment.
+func (s *Store) putCapabilityBinding(ctx context.Context, binding CapabilityBinding) (CapabilityBinding, error) {
+	defer s.observe("putCapabilityBinding", time.Now())
+	bindings, err := s.getCapabilityBindings(ctx, binding.ProjectID)
+	if err != nil {
+		return CapabilityBinding{}, err
+	}
+	binding.UpdatedAt = time.Now().UTC()
+	if bindings.Environments[binding.Environment] == nil {
+		bindings.Environments[binding.Environment] = map[string]CapabilityBinding{}
+	}
+	bindings.Environments[binding.Environment][binding.Capability] = binding
+	if err = s.writeCapabilityBindings(ctx, binding.ProjectID, bindings); err != nil {
+		return CapabilityBinding{}, err
+	}
+	return binding, nil
+}
+
+// deleteCapabilityBinding removes one binding and returns it, or false when
+// the capability was not bound in that environment.
+func (s *Store) deleteCapabilityBinding(
+	ctx context.Context,
+	projectID, env, capability string,
+) (CapabilityBinding, bool, error) {
+	defer s.observe("deleteCapabilityBinding", time.Now())
+	bindings, err := s.getCapabilityBindings(ctx, projectID)
+	if err != nil {
+		return CapabilityBinding{}, false, err
+	}
+	removed, ok := bindings.Environments[env][capability]
+	if !ok {
+		return CapabilityBinding{}, false, nil
+	}
+	delete(bindings.Environments[env], capability)
+	if len(bindings.Environments[env]) == 0 {
+		delete(bindings.Environments, env)
+	}
+	if err = s.writeCapabilityBindings(ctx, projectID, bindings); err != nil {
+		return CapabilityBinding{}, false, err
+	}
+	return removed, true, nil
+}
+
+func (s *Store) deleteProjectCapabilityBindings(ctx context.Context, projectID string) error {
+	defer s.observe("deleteProjectCapabilityBindings", time.Now())
+	err := s.kvOps.Delete(ctx, projectBindingsKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) writeCapabilityBindings(
+	ctx context.Context,
+	projectID string,
+	bindings projectCapabilityBindings,
+) error {
+	if len(bindings.Environments) == 0 {
+		return s.deleteProjectCapabilityBindings(ctx, projectID)
+	}
+	bindings.UpdatedAt = time.Now().UTC()
+	body, err := json.Marshal(bindings)
+	if err != nil {
+		return err
+	}
+	_, err = s.kvOps.Put(ctx, projectBindingsKey(projectID), body)
+	return err
+}
+
+// loadEnvCapabilityBindings reads the bindings a render should apply. Without
+// a store (offline renders) nothing is bound.
+func loadEnvCapabilityBindings(ctx context.Context, store *Store, projectID string) (envCapabilityBindings, error) {
+	if store == nil {
+		return envCapabilityBindings{}, nil
+	}
+	bindings, err := store.getCapabilityBindings(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return bindings.byEnvironment(), nil
+}
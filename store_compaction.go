@@ -0,0 +1,216 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// opCompactionLimits bounds how large a persisted Operation may grow.
+// maxSteps triggers background compaction of finished ops; maxBytes is
+// enforced on every write so a single KV value never exceeds it because of
+// its step history.
+type opCompactionLimits struct {
+	maxSteps int
+	maxBytes int
+}
+
+type opCompactionReport struct {
+	ScannedOps   int
+	CompactedOps int
+	FoldedSteps  int
+}
+
+func opCompactionLimitsFromEnv() opCompactionLimits {
+	return opCompactionLimits{
+		maxSteps: positiveIntFromEnv(opStepsMaxEnv, defaultOpStepsMax),
+		maxBytes: positiveIntFromEnv(opValueMaxBytesEnv, defaultOpValueMaxBytes),
+	}
+}
+
+func positiveIntFromEnv(name string, fallback int) int {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// compactOpSteps folds older intermediate steps into one summary step per
+// worker. Open steps, the latest step of every worker (its terminal result),
+// and the keepRecent most recent steps are kept verbatim. Summary steps keep
+// the first start and last end time of what they fold, and existing
+// summaries are merged rather than nested.
+func compactOpSteps(steps []OpStep, keepRecent int) []OpStep {
+	keep := make([]bool, len(steps))
+	lastByWorker := map[string]int{}
+	for i, step := range steps {
+		lastByWorker[step.Worker] = i
+		if step.EndedAt.IsZero() || i >= len(steps)-keepRecent {
+			keep[i] = true
+		}
+	}
+	for _, i := range lastByWorker {
+		keep[i] = true
+	}
+
+	summaries := map[string]*OpStep{}
+	workers := make([]string, 0)
+	kept := make([]OpStep, 0, len(steps))
+	for i, step := range steps {
+		if keep[i] {
+			kept = append(kept, step)
+			continue
+		}
+		folded := max(step.Compacted, 1)
+		summary, ok := summaries[step.Worker]
+		if !ok {
+			summaries[step.Worker] = &OpStep{
+				Worker:    step.Worker,
+				StartedAt: step.StartedAt,
+				EndedAt:   step.EndedAt,
+				Message:   "",
+				Error:     "",
+				Artifacts: nil,
+				Compacted: folded,
+
+				HeartbeatAt: time.Time{},
+				Progress:    "",
+				Percent:     0,
+				SubSteps:    nil,
+
+				Plan: nil,
+
+				Attempt:       0,
+				PriorAttempts: nil,
+			}
+			workers = append(workers, step.Worker)
+			continue
+		}
+		if step.StartedAt.Before(summary.StartedAt) {
+			summary.StartedAt = step.StartedAt
+		}
+		if step.EndedAt.After(summary.EndedAt) {
+			summary.EndedAt = step.EndedAt
+		}
+		summary.Compacted += folded
+	}
+	if len(workers) == 0 {
+		return steps
+	}
+
+	out := make([]OpStep, 0, len(workers)+len(kept))
+	for _, worker := range workers {
+		summary := summaries[worker]
+		summary.Message = fmt.Sprintf("%d earlier steps compacted", summary.Compacted)
+		out = append(out, *summary)
+	}
+	slices.SortStableFunc(out, func(x, y OpStep) int {
+		return x.StartedAt.Compare(y.StartedAt)
+	})
+	return append(out, kept...)
+}
+
+// boundOpSize compacts the step history when the encoded op exceeds the
+// configured byte limit. Only folded history is dropped; if the op is still
+// too large the oversized value is written and a warning is logged.
+func (s *Store) boundOpSize(op Operation, body []byte) ([]byte, error) {
+	if s.opLimits.maxBytes <= 0 || len(body) <= s.opLimits.maxBytes {
+		return body, nil
+	}
+	op.Steps = compactOpSteps(op.Steps, 0)
+	compacted, err := json.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+	if len(compacted) > s.opLimits.maxBytes {
+		appLoggerForProcess().Source("store").Warnf(
+			"op=%s still exceeds %d bytes after step compaction (%d bytes)",
+			op.ID,
+			s.opLimits.maxBytes,
+			len(compacted),
+		)
+	}
+	return compacted, nil
+}
+
+// compactFinishedOps walks each project's op history and compacts finished
+// ops whose step count exceeds the limit. Active ops are left alone so
+// workers keep stable step positions while they run.
+func (s *Store) compactFinishedOps(ctx context.Context) (opCompactionReport, error) {
+	defer s.observe("compactFinishedOps", time.Now())
+	report := opCompactionReport{}
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, project := range projects {
+		index, indexErr := s.readProjectOpsIndex(ctx, project.ID)
+		if indexErr != nil {
+			return report, indexErr
+		}
+		for _, opID := range index.IDs {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			report.ScannedOps++
+			op, getErr := s.GetOp(ctx, opID)
+			if getErr != nil || isOperationStatusActive(op.Status) || len(op.Steps) <= s.opLimits.maxSteps {
+				continue
+			}
+			folded := 0
+			_, putErr := s.updateOp(ctx, opID, func(op *Operation) bool {
+				if isOperationStatusActive(op.Status) {
+					folded = 0
+					return false
+				}
+				before := len(op.Steps)
+				op.Steps = compactOpSteps(op.Steps, s.opLimits.maxSteps/2)
+				folded = before - len(op.Steps)
+				return folded > 0
+			})
+			if putErr != nil {
+				return report, putErr
+			}
+			if folded == 0 {
+				continue
+			}
+			report.CompactedOps++
+			report.FoldedSteps += folded
+		}
+	}
+	return report, nil
+}
+
+func runOpStepsCompactor(ctx context.Context, store *Store, compactorLog sourceLogger) {
+	ticker := time.NewTicker(opCompactionInterval)
+	defer ticker.Stop()
+	for {
+		report, err := store.compactFinishedOps(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			compactorLog.Warnf("op step compaction failed: %v", err)
+		case report.CompactedOps > 0:
+			compactorLog.Infof(
+				"op step compaction: scanned_ops=%d compacted_ops=%d folded_steps=%d",
+				report.ScannedOps,
+				report.CompactedOps,
+				report.FoldedSteps,
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,115 @@
+//nolint:testpackage,exhaustruct // Compaction tests drive unexported helpers with concise step fixtures.
+package platform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func retryingSteps(base time.Time, attempts int) []OpStep {
+	steps := make([]OpStep, 0, attempts+2)
+	steps = append(steps, OpStep{
+		Worker:    "registrar",
+		StartedAt: base,
+		EndedAt:   base.Add(time.Second),
+		Message:   "registered",
+	})
+	for i := range attempts {
+		start := base.Add(time.Duration(i+2) * time.Second)
+		steps = append(steps, OpStep{
+			Worker:    "imageBuilder",
+			StartedAt: start,
+			EndedAt:   start.Add(time.Second),
+			Error:     fmt.Sprintf("attempt %d failed", i+1),
+		})
+	}
+	final := base.Add(time.Duration(attempts+2) * time.Second)
+	steps = append(steps, OpStep{
+		Worker:    "imageBuilder",
+		StartedAt: final,
+		EndedAt:   final.Add(time.Second),
+		Message:   "image built",
+	})
+	return steps
+}
+
+func TestStore_CompactOpStepsKeepsTerminalResults(t *testing.T) {
+	base := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	steps := retryingSteps(base, 10)
+
+	compacted := compactOpSteps(steps, 2)
+	if len(compacted) != 4 {
+		t.Fatalf("expected summary + registrar + 2 recent steps, got %d: %#v", len(compacted), compacted)
+	}
+	summary := compacted[0]
+	if summary.Worker != "imageBuilder" || summary.Compacted != 9 {
+		t.Fatalf("expected imageBuilder summary of 9 steps, got %#v", summary)
+	}
+	if !summary.StartedAt.Equal(steps[1].StartedAt) || !summary.EndedAt.Equal(steps[9].EndedAt) {
+		t.Fatalf("expected summary to span folded timings, got %s..%s", summary.StartedAt, summary.EndedAt)
+	}
+	if compacted[1].Message != "registered" || compacted[3].Message != "image built" {
+		t.Fatalf("expected terminal worker results preserved, got %#v", compacted)
+	}
+
+	again := compactOpSteps(append(compacted, retryingSteps(base.Add(time.Hour), 3)[1:]...), 0)
+	if again[0].Compacted != 9+2+3 {
+		t.Fatalf("expected prior summary merged into new one, got %#v", again[0])
+	}
+}
+
+func TestStore_CompactFinishedOpsAndByteBound(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	store := fixture.store
+	store.opLimits = opCompactionLimits{maxSteps: 4, maxBytes: 4096}
+	projectID := "project-compaction"
+	if err := store.PutProject(ctx, Project{ID: projectID}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+
+	base := time.Now().UTC()
+	done := Operation{ID: "op-compact-done", Kind: OpCI, ProjectID: projectID, Status: opStatusDone, Steps: retryingSteps(base, 6)}
+	running := Operation{ID: "op-compact-running", Kind: OpCI, ProjectID: projectID, Status: opStatusRunning, Steps: retryingSteps(base, 6)}
+	for _, op := range []Operation{done, running} {
+		if err := store.PutOp(ctx, op); err != nil {
+			t.Fatalf("put op %s: %v", op.ID, err)
+		}
+	}
+
+	report, err := store.compactFinishedOps(ctx)
+	if err != nil {
+		t.Fatalf("compact ops: %v", err)
+	}
+	if report.CompactedOps != 1 {
+		t.Fatalf("expected only the finished op compacted, got %#v", report)
+	}
+	gotDone, _ := store.GetOp(ctx, done.ID)
+	if len(gotDone.Steps) >= len(done.Steps) || gotDone.Steps[len(gotDone.Steps)-1].Message != "image built" {
+		t.Fatalf("expected compacted finished op with terminal step kept, got %#v", gotDone.Steps)
+	}
+	gotRunning, _ := store.GetOp(ctx, running.ID)
+	if len(gotRunning.Steps) != len(running.Steps) {
+		t.Fatalf("expected active op untouched, got %d steps", len(gotRunning.Steps))
+	}
+
+	large := Operation{ID: "op-compact-large", Kind: OpCI, ProjectID: projectID, Status: opStatusRunning, Steps: retryingSteps(base, 40)}
+	for i := range large.Steps {
+		large.Steps[i].Message = strings.Repeat("x", 200)
+	}
+	if err = store.PutOp(ctx, large); err != nil {
+		t.Fatalf("put large op: %v", err)
+	}
+	entry, err := store.kvOps.Get(ctx, kvOpKeyPrefix+large.ID)
+	if err != nil {
+		t.Fatalf("read large op: %v", err)
+	}
+	if len(entry.Value()) > store.opLimits.maxBytes {
+		t.Fatalf("expected stored op under %d bytes, got %d", store.opLimits.maxBytes, len(entry.Value()))
+	}
+}
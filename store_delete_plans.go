@@ -0,0 +1,52 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// putDeletePlan stores plan as the project's only pending delete plan,
+// replacing any earlier one.
+func (s *Store) putDeletePlan(ctx context.Context, plan DeletePlan) error {
+	defer s.observe("putDeletePlan", time.Now())
+	body, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	_, err = s.kvOps.Put(ctx, projectDeletePlanKey(plan.ProjectID), body)
+	return err
+}
+
+func (s *Store) getDeletePlan(ctx context.Context, projectID string) (DeletePlan, bool, error) {
+	defer s.observe("getDeletePlan", time.Now())
+	entry, err := s.kvOps.Get(ctx, projectDeletePlanKey(projectID))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return DeletePlan{}, false, nil
+		}
+		return DeletePlan{}, false, err
+	}
+	var plan DeletePlan
+	if err = json.Unmarshal(entry.Value(), &plan); err != nil {
+		return DeletePlan{}, false, err
+	}
+	return plan, true, nil
+}
+
+func (s *Store) deleteDeletePlan(ctx context.Context, projectID string) error {
+	defer s.observe("deleteDeletePlan", time.Now())
+	err := s.kvOps.Delete(ctx, projectDeletePlanKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func projectDeletePlanKey(projectID string) string {
+	return kvProjectDeletePlanKeyPrefix + strings.TrimSpace(projectID)
+}
@@ -0,0 +1,74 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// projectHealth is the per-project record of environment probes, keyed by
+// environment. Only the leader's health checker writes it.
+type projectHealth struct {
+	Environments map[string]EnvironmentHealth `json:"environments,omitempty"`
+	UpdatedAt    time.Time                    `json:"updated_at"`
+}
+
+func (s *Store) getProjectHealth(ctx context.Context, projectID string) (projectHealth, error) {
+	defer s.observe("getProjectHealth", time.Now())
+	health := projectHealth{Environments: map[string]EnvironmentHealth{}, UpdatedAt: time.Time{}}
+	entry, err := s.kvOps.Get(ctx, projectHealthKey(projectID))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return health, nil
+		}
+		return projectHealth{}, err
+	}
+	if err = json.Unmarshal(entry.Value(), &health); err != nil {
+		return projectHealth{}, err
+	}
+	if health.Environments == nil {
+		health.Environments = map[string]EnvironmentHealth{}
+	}
+	return health, nil
+}
+
+// recordProjectHealth adds one probe result per environment. Environments
+// no longer probed, such as one whose last apply failed, are dropped.
+func (s *Store) recordProjectHealth(
+	ctx context.Context,
+	projectID string,
+	results map[string]HealthProbeResult,
+) error {
+	defer s.observe("recordProjectHealth", time.Now())
+	health, err := s.getProjectHealth(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	environments := make(map[string]EnvironmentHealth, len(results))
+	for env, result := range results {
+		environments[env] = withHealthProbe(health.Environments[env], env, result)
+	}
+	body, err := json.Marshal(projectHealth{Environments: environments, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	_, err = s.kvOps.Put(ctx, projectHealthKey(projectID), body)
+	return err
+}
+
+func (s *Store) deleteProjectHealth(ctx context.Context, projectID string) error {
+	defer s.observe("deleteProjectHealth", time.Now())
+	err := s.kvOps.Delete(ctx, projectHealthKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func projectHealthKey(projectID string) string {
+	return kvProjectHealthKeyPrefix + strings.TrimSpace(projectID)
+}
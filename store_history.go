@@ -0,0 +1,62 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// projectRevision is one historical value of a project record.
+type projectRevision struct {
+	Project  Project
+	Revision uint64
+	Created  time.Time
+}
+
+// projectRevisionAfterOp returns the project record as it stood right after
+// opID: the last revision that op wrote, or failing that the last revision
+// written by cutoff. ok is false when KV history no longer reaches back that
+// far (it keeps PAAS_KV_PROJECT_HISTORY revisions).
+func (s *Store) projectRevisionAfterOp(
+	ctx context.Context,
+	projectID string,
+	opID string,
+	cutoff time.Time,
+) (projectRevision, bool, error) {
+	defer s.observe("projectRevisionAfterOp", time.Now())
+	entries, err := s.kvProjects.History(ctx, kvProjectKeyPrefix+projectID)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return projectRevision{}, false, nil
+		}
+		return projectRevision{}, false, err
+	}
+	var byOp, byTime projectRevision
+	for _, entry := range entries {
+		if entry.Operation() != jetstream.KeyValuePut {
+			continue
+		}
+		var project Project
+		if json.Unmarshal(entry.Value(), &project) != nil {
+			continue
+		}
+		rev := projectRevision{Project: project, Revision: entry.Revision(), Created: entry.Created().UTC()}
+		if project.Status.LastOpID == opID {
+			byOp = rev
+		}
+		if !rev.Created.After(cutoff) {
+			byTime = rev
+		}
+	}
+	switch {
+	case byOp.Revision != 0:
+		return byOp, true, nil
+	case byTime.Revision != 0:
+		return byTime, true, nil
+	default:
+		return projectRevision{}, false, nil
+	}
+}
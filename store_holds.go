@@ -0,0 +1,145 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// projectHolds is the per-project compliance hold record. A project-level
+// hold covers every release; release holds pin individual releases.
+type projectHolds struct {
+	Project   *ComplianceHold           `json:"project,omitempty"`
+	Releases  map[string]ComplianceHold `json:"releases,omitempty"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+}
+
+func (h projectHolds) active() bool {
+	return h.Project != nil || len(h.Releases) > 0
+}
+
+func (h projectHolds) releaseHold(releaseID string) (ComplianceHold, bool) {
+	if hold, ok := h.Releases[strings.TrimSpace(releaseID)]; ok {
+		return hold, true
+	}
+	if h.Project != nil {
+		return *h.Project, true
+	}
+	return ComplianceHold{}, false
+}
+
+func emptyProjectHolds() projectHolds {
+	return projectHolds{
+		Project:   nil,
+		Releases:  map[string]ComplianceHold{},
+		UpdatedAt: time.Time{},
+	}
+}
+
+func (s *Store) getProjectHolds(ctx context.Context, projectID string) (projectHolds, error) {
+	defer s.observe("getProjectHolds", time.Now())
+	entry, err := s.kvOps.Get(ctx, projectHoldsKey(projectID))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return emptyProjectHolds(), nil
+		}
+		return projectHolds{}, err
+	}
+	holds := emptyProjectHolds()
+	if err = json.Unmarshal(entry.Value(), &holds); err != nil {
+		return projectHolds{}, err
+	}
+	if holds.Releases == nil {
+		holds.Releases = map[string]ComplianceHold{}
+	}
+	return holds, nil
+}
+
+func (s *Store) writeProjectHolds(ctx context.Context, projectID string, holds projectHolds) error {
+	defer s.observe("writeProjectHolds", time.Now())
+	if !holds.active() {
+		err := s.kvOps.Delete(ctx, projectHoldsKey(projectID))
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+	holds.UpdatedAt = time.Now().UTC()
+	body, err := json.Marshal(holds)
+	if err != nil {
+		return err
+	}
+	_, err = s.kvOps.Put(ctx, projectHoldsKey(projectID), body)
+	return err
+}
+
+// PlaceHold records a hold on a project (empty ReleaseID) or on a single
+// release. Re-placing an existing hold replaces its metadata.
+func (s *Store) PlaceHold(ctx context.Context, hold ComplianceHold) (ComplianceHold, error) {
+	defer s.observe("PlaceHold", time.Now())
+	hold.ProjectID = strings.TrimSpace(hold.ProjectID)
+	hold.ReleaseID = strings.TrimSpace(hold.ReleaseID)
+	hold.Reason = strings.TrimSpace(hold.Reason)
+	hold.PlacedBy = strings.TrimSpace(hold.PlacedBy)
+	if hold.ProjectID == "" {
+		return ComplianceHold{}, errors.New("project_id required")
+	}
+	if hold.Reason == "" {
+		return ComplianceHold{}, errors.New("reason required")
+	}
+	if hold.PlacedAt.IsZero() {
+		hold.PlacedAt = time.Now().UTC()
+	}
+	holds, err := s.getProjectHolds(ctx, hold.ProjectID)
+	if err != nil {
+		return ComplianceHold{}, err
+	}
+	if hold.ReleaseID == "" {
+		placed := hold
+		holds.Project = &placed
+	} else {
+		holds.Releases[hold.ReleaseID] = hold
+	}
+	if err = s.writeProjectHolds(ctx, hold.ProjectID, holds); err != nil {
+		return ComplianceHold{}, err
+	}
+	return hold, nil
+}
+
+// LiftHold removes a project or release hold and returns what was lifted.
+func (s *Store) LiftHold(ctx context.Context, projectID, releaseID string) (ComplianceHold, bool, error) {
+	defer s.observe("LiftHold", time.Now())
+	projectID = strings.TrimSpace(projectID)
+	releaseID = strings.TrimSpace(releaseID)
+	holds, err := s.getProjectHolds(ctx, projectID)
+	if err != nil {
+		return ComplianceHold{}, false, err
+	}
+	var lifted ComplianceHold
+	switch {
+	case releaseID == "" && holds.Project != nil:
+		lifted = *holds.Project
+		holds.Project = nil
+	case releaseID != "":
+		hold, ok := holds.Releases[releaseID]
+		if !ok {
+			return ComplianceHold{}, false, nil
+		}
+		lifted = hold
+		delete(holds.Releases, releaseID)
+	default:
+		return ComplianceHold{}, false, nil
+	}
+	if err = s.writeProjectHolds(ctx, projectID, holds); err != nil {
+		return ComplianceHold{}, false, err
+	}
+	return lifted, true, nil
+}
+
+func projectHoldsKey(projectID string) string {
+	return kvProjectHoldsKeyPrefix + strings.TrimSpace(projectID)
+}
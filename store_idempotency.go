@@ -0,0 +1,135 @@
+package platform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	idempotencyClaimAttempts = 3
+	// A claim this old that never got an answer belongs to a request whose
+	// replica went away; the next request with the key takes it over.
+	idempotencyClaimAbandonedAfter = 10 * time.Minute
+)
+
+// idempotencyRecord is what an idempotency key maps to: the request that
+// claimed it and, once that request was answered, its response and op.
+// Status stays 0 while the first request is still running.
+type idempotencyRecord struct {
+	RequestHash string          `json:"request_hash"`
+	Status      int             `json:"status,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	OpID        string          `json:"op_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// ensureIdempotencyBucket opens the bucket behind idempotency keys. The
+// bucket TTL expires keys, so nothing has to clean them up.
+func ensureIdempotencyBucket(ctx context.Context, js jetstream.JetStream) (jetstream.KeyValue, error) {
+	var cfg jetstream.KeyValueConfig
+	cfg.Bucket = kvBucketName(kvBucketIdempotency)
+	cfg.History = 1
+	cfg.TTL = idempotencyKeyTTL
+	kv, err := js.CreateKeyValue(ctx, cfg)
+	if errors.Is(err, jetstream.ErrBucketExists) {
+		kv, err = js.KeyValue(ctx, cfg.Bucket)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("idempotency bucket: %w", err)
+	}
+	return kv, nil
+}
+
+// idempotencyStoreKey hashes parts into a KV key, so client-chosen keys
+// never have to be valid key tokens.
+func idempotencyStoreKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// claimIdempotencyKey records key as taken by the request hashed as
+// requestHash. When another request got there first it returns that
+// request's record and false.
+func (s *Store) claimIdempotencyKey(
+	ctx context.Context,
+	key, requestHash string,
+) (idempotencyRecord, uint64, bool, error) {
+	defer s.observe("claimIdempotencyKey", time.Now())
+	record := idempotencyRecord{
+		RequestHash: requestHash,
+		Status:      0,
+		Body:        nil,
+		OpID:        "",
+		CreatedAt:   time.Now().UTC(),
+	}
+	body, err := json.Marshal(record)
+	if err != nil {
+		return idempotencyRecord{}, 0, false, err
+	}
+	for range idempotencyClaimAttempts {
+		revision, createErr := s.kvIdempotency.Create(ctx, key, body)
+		if createErr == nil {
+			return record, revision, true, nil
+		}
+		if !errors.Is(createErr, jetstream.ErrKeyExists) {
+			return idempotencyRecord{}, 0, false, createErr
+		}
+		entry, getErr := s.kvIdempotency.Get(ctx, key)
+		if errors.Is(getErr, jetstream.ErrKeyNotFound) {
+			// Released between the two calls; claim it again.
+			continue
+		}
+		if getErr != nil {
+			return idempotencyRecord{}, 0, false, getErr
+		}
+		var existing idempotencyRecord
+		if err = json.Unmarshal(entry.Value(), &existing); err != nil {
+			return idempotencyRecord{}, 0, false, err
+		}
+		if existing.Status != 0 || time.Since(existing.CreatedAt) < idempotencyClaimAbandonedAfter {
+			return existing, entry.Revision(), false, nil
+		}
+		revision, updateErr := s.kvIdempotency.Update(ctx, key, body, entry.Revision())
+		if updateErr == nil {
+			return record, revision, true, nil
+		}
+		if !errors.Is(updateErr, jetstream.ErrKeyExists) {
+			return idempotencyRecord{}, 0, false, updateErr
+		}
+	}
+	return idempotencyRecord{}, 0, false, fmt.Errorf("idempotency key %s kept changing", key)
+}
+
+// completeIdempotencyKey stores the answer to the request that claimed key
+// at revision.
+func (s *Store) completeIdempotencyKey(
+	ctx context.Context,
+	key string,
+	revision uint64,
+	record idempotencyRecord,
+) (uint64, error) {
+	defer s.observe("completeIdempotencyKey", time.Now())
+	body, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+	return s.kvIdempotency.Update(ctx, key, body, revision)
+}
+
+// releaseIdempotencyKey frees key so the next request with it runs anew.
+func (s *Store) releaseIdempotencyKey(ctx context.Context, key string, revision uint64) error {
+	defer s.observe("releaseIdempotencyKey", time.Now())
+	err := s.kvIdempotency.Delete(ctx, key, jetstream.LastRevision(revision))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
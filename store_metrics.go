@@ -0,0 +1,115 @@
+package platform
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// storeMethodStats is the running tally for one Store method.
+type storeMethodStats struct {
+	Calls         int64   `json:"calls"`
+	SlowCalls     int64   `json:"slow_calls"`
+	TotalMillis   float64 `json:"total_ms"`
+	AverageMillis float64 `json:"avg_ms"`
+	MaxMillis     float64 `json:"max_ms"`
+}
+
+type storeMetricsSnapshot struct {
+	SlowThreshold string                      `json:"slow_threshold"`
+	Methods       map[string]storeMethodStats `json:"methods"`
+}
+
+// storeMetrics times Store methods and logs calls slower than the threshold.
+// Nested Store calls are counted separately, so a method that fans out (for
+// example ListProjects -> GetProject) shows up as a call count hotspot.
+type storeMetrics struct {
+	mu            sync.Mutex
+	slowThreshold time.Duration
+	methods       map[string]*storeMethodStats
+}
+
+func newStoreMetrics(slowThreshold time.Duration) *storeMetrics {
+	return &storeMetrics{
+		mu:            sync.Mutex{},
+		slowThreshold: slowThreshold,
+		methods:       map[string]*storeMethodStats{},
+	}
+}
+
+// storeSlowThresholdFromEnv reads PAAS_STORE_SLOW_THRESHOLD as a Go duration.
+// "0" or "off" disables slow-call logging; invalid values use the default.
+func storeSlowThresholdFromEnv() time.Duration {
+	raw := strings.TrimSpace(strings.ToLower(os.Getenv(storeSlowThresholdEnv)))
+	switch raw {
+	case "":
+		return defaultStoreSlowThreshold
+	case "0", "off":
+		return 0
+	}
+	threshold, err := time.ParseDuration(raw)
+	if err != nil || threshold < 0 {
+		return defaultStoreSlowThreshold
+	}
+	return threshold
+}
+
+// observe is deferred at the top of instrumented Store methods.
+func (s *Store) observe(method string, started time.Time) {
+	if s == nil || s.metrics == nil {
+		return
+	}
+	s.metrics.record(method, time.Since(started))
+}
+
+func (m *storeMetrics) record(method string, elapsed time.Duration) {
+	millis := float64(elapsed.Microseconds()) / float64(time.Millisecond/time.Microsecond)
+	slow := m.slowThreshold > 0 && elapsed >= m.slowThreshold
+
+	m.mu.Lock()
+	stats, ok := m.methods[method]
+	if !ok {
+		stats = &storeMethodStats{}
+		m.methods[method] = stats
+	}
+	stats.Calls++
+	stats.TotalMillis += millis
+	stats.MaxMillis = max(stats.MaxMillis, millis)
+	if slow {
+		stats.SlowCalls++
+	}
+	m.mu.Unlock()
+
+	if slow {
+		appLoggerForProcess().Source("store").Warnf(
+			"slow store call method=%s elapsed=%s threshold=%s",
+			method,
+			elapsed.Round(time.Microsecond),
+			m.slowThreshold,
+		)
+	}
+}
+
+func (m *storeMetrics) snapshot() storeMetricsSnapshot {
+	out := storeMetricsSnapshot{
+		SlowThreshold: "off",
+		Methods:       map[string]storeMethodStats{},
+	}
+	if m == nil {
+		return out
+	}
+	if m.slowThreshold > 0 {
+		out.SlowThreshold = m.slowThreshold.String()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for method, stats := range m.methods {
+		copied := *stats
+		if copied.Calls > 0 {
+			copied.AverageMillis = copied.TotalMillis / float64(copied.Calls)
+		}
+		out.Methods[method] = copied
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+//nolint:testpackage // Store metrics tests read the unexported per-method counters.
+package platform
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStore_MetricsCountNestedCallsPerMethod(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	for _, id := range []string{"project-metrics-a", "project-metrics-b"} {
+		if err := fixture.store.PutProject(ctx, Project{ID: id, CreatedAt: time.Now().UTC()}); err != nil {
+			t.Fatalf("put project %s: %v", id, err)
+		}
+	}
+	before := fixture.store.metrics.snapshot().Methods["GetProject"].Calls
+
+	if _, err := fixture.store.ListProjects(ctx); err != nil {
+		t.Fatalf("list projects: %v", err)
+	}
+
+	snapshot := fixture.store.metrics.snapshot()
+	if got := snapshot.Methods["ListProjects"].Calls; got != 1 {
+		t.Fatalf("expected 1 ListProjects call, got %d", got)
+	}
+	if got := snapshot.Methods["GetProject"].Calls - before; got < 2 {
+		t.Fatalf("expected ListProjects to fan out to >=2 GetProject calls, got %d", got)
+	}
+}
+
+func TestStore_MetricsSlowThreshold(t *testing.T) {
+	metrics := newStoreMetrics(time.Millisecond)
+	metrics.record("GetOp", 5*time.Millisecond)
+	metrics.record("GetOp", 100*time.Microsecond)
+
+	stats := metrics.snapshot().Methods["GetOp"]
+	if stats.Calls != 2 || stats.SlowCalls != 1 {
+		t.Fatalf("expected 2 calls with 1 slow, got %#v", stats)
+	}
+	if stats.MaxMillis != 5 {
+		t.Fatalf("expected max 5ms, got %v", stats.MaxMillis)
+	}
+
+	t.Setenv(storeSlowThresholdEnv, "off")
+	if got := storeSlowThresholdFromEnv(); got != 0 {
+		t.Fatalf("expected off to disable threshold, got %s", got)
+	}
+	t.Setenv(storeSlowThresholdEnv, "not-a-duration")
+	if got := storeSlowThresholdFromEnv(); got != defaultStoreSlowThreshold {
+		t.Fatalf("expected default threshold for invalid value, got %s", got)
+	}
+}
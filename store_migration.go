@@ -0,0 +1,163 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// kvBucketMigration describes one completed bucket switch.
+type kvBucketMigration struct {
+	Logical     string
+	From        string
+	To          string
+	FromHistory int64
+	ToHistory   uint8
+	Keys        int
+	Revisions   int
+}
+
+// openStoreBucket resolves the physical bucket currently backing a logical
+// store bucket and migrates it when its history setting differs from the
+// target. It runs before the store serves traffic, so the copy never races
+// live writes; the switch itself is a single write to the meta bucket.
+func openStoreBucket(
+	ctx context.Context,
+	js jetstream.JetStream,
+	meta jetstream.KeyValue,
+	logical string,
+	history uint8,
+	out *jetstream.KeyValue,
+) (kvBucketMigration, bool, error) {
+	physical, err := activeBucketName(ctx, meta, logical)
+	if err != nil {
+		return kvBucketMigration{}, false, err
+	}
+	var current jetstream.KeyValue
+	if err = ensureKVBucket(ctx, js, physical, history, &current); err != nil {
+		return kvBucketMigration{}, false, err
+	}
+	status, err := current.Status(ctx)
+	if err != nil {
+		return kvBucketMigration{}, false, fmt.Errorf("read %s bucket status: %w", physical, err)
+	}
+	if status.History() == int64(history) {
+		*out = current
+		return kvBucketMigration{}, false, nil
+	}
+	migration, err := migrateKVBucket(ctx, js, meta, logical, current, history)
+	if err != nil {
+		return kvBucketMigration{}, false, fmt.Errorf("migrate %s bucket to history %d: %w", logical, history, err)
+	}
+	migration.FromHistory = status.History()
+	*out, err = js.KeyValue(ctx, migration.To)
+	if err != nil {
+		return kvBucketMigration{}, false, err
+	}
+	return migration, true, nil
+}
+
+func activeBucketName(ctx context.Context, meta jetstream.KeyValue, logical string) (string, error) {
+	entry, err := meta.Get(ctx, kvActiveBucketKeyPrefix+logical)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return logical, nil
+		}
+		return "", fmt.Errorf("read active bucket for %s: %w", logical, err)
+	}
+	name := strings.TrimSpace(string(entry.Value()))
+	if name == "" {
+		return logical, nil
+	}
+	return name, nil
+}
+
+// migrateKVBucket copies every live key into a new bucket created with the
+// target history. Each key's retained revisions are replayed in order, so
+// history survives up to the target limit; revision numbers themselves are
+// assigned by the new bucket. The old bucket is left in place for rollback.
+func migrateKVBucket(
+	ctx context.Context,
+	js jetstream.JetStream,
+	meta jetstream.KeyValue,
+	logical string,
+	source jetstream.KeyValue,
+	history uint8,
+) (kvBucketMigration, error) {
+	target := fmt.Sprintf("%s_v%d", logical, time.Now().UTC().UnixNano())
+	var dest jetstream.KeyValue
+	if err := ensureKVBucket(ctx, js, target, history, &dest); err != nil {
+		return kvBucketMigration{}, err
+	}
+	migration := kvBucketMigration{
+		Logical:     logical,
+		From:        source.Bucket(),
+		To:          target,
+		FromHistory: 0,
+		ToHistory:   history,
+		Keys:        0,
+		Revisions:   0,
+	}
+	keys, err := source.Keys(ctx)
+	if err != nil && !errors.Is(err, jetstream.ErrNoKeysFound) {
+		return kvBucketMigration{}, fmt.Errorf("list keys: %w", err)
+	}
+	for _, key := range keys {
+		revisions, copyErr := copyKVKeyHistory(ctx, source, dest, key)
+		if copyErr != nil {
+			return kvBucketMigration{}, copyErr
+		}
+		migration.Keys++
+		migration.Revisions += revisions
+	}
+	if _, err = meta.Put(ctx, kvActiveBucketKeyPrefix+logical, []byte(target)); err != nil {
+		return kvBucketMigration{}, fmt.Errorf("switch active bucket: %w", err)
+	}
+	return migration, nil
+}
+
+func copyKVKeyHistory(ctx context.Context, source, dest jetstream.KeyValue, key string) (int, error) {
+	entries, err := source.History(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("read history for %s: %w", key, err)
+	}
+	for _, entry := range entries {
+		switch entry.Operation() {
+		case jetstream.KeyValuePut:
+			_, err = dest.Put(ctx, key, entry.Value())
+		case jetstream.KeyValueDelete:
+			err = dest.Delete(ctx, key)
+		case jetstream.KeyValuePurge:
+			err = dest.Purge(ctx, key)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("copy %s revision %d: %w", key, entry.Revision(), err)
+		}
+	}
+	latest, err := dest.Get(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("verify %s: %w", key, err)
+	}
+	if !bytes.Equal(latest.Value(), entries[len(entries)-1].Value()) {
+		return 0, fmt.Errorf("verify %s: copied value differs from source", key)
+	}
+	return len(entries), nil
+}
+
+func logKVBucketMigration(migration kvBucketMigration) {
+	appLoggerForProcess().Source("store").Infof(
+		"migrated KV bucket %s: %s (history=%d) -> %s (history=%d), keys=%d revisions=%d; previous bucket kept",
+		migration.Logical,
+		migration.From,
+		migration.FromHistory,
+		migration.To,
+		migration.ToHistory,
+		migration.Keys,
+		migration.Revisions,
+	)
+}
@@ -0,0 +1,62 @@
+//nolint:testpackage,exhaustruct // Migration tests reopen the store against the fixture's JetStream context.
+package platform
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStore_MigratesBucketWhenHistorySettingChanges(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	projectID := "project-migration"
+	for i := range 3 {
+		project := Project{ID: projectID, CreatedAt: time.Now().UTC()}
+		project.Spec.Name = "migration-" + strconv.Itoa(i)
+		if err := overwriteProjectForTest(ctx, fixture.store, project); err != nil {
+			t.Fatalf("put project revision %d: %v", i, err)
+		}
+	}
+
+	t.Setenv(kvProjectHistoryEnv, "2")
+	migrated, err := newStore(ctx, fixture.js)
+	if err != nil {
+		t.Fatalf("reopen store with new history: %v", err)
+	}
+	if migrated.kvProjects.Bucket() == kvBucketName(kvBucketProjects) {
+		t.Fatalf("expected projects bucket to switch away from %s", kvBucketName(kvBucketProjects))
+	}
+	status, err := migrated.kvProjects.Status(ctx)
+	if err != nil {
+		t.Fatalf("read migrated status: %v", err)
+	}
+	if status.History() != 2 {
+		t.Fatalf("expected migrated history 2, got %d", status.History())
+	}
+	project, err := migrated.GetProject(ctx, projectID)
+	if err != nil {
+		t.Fatalf("read migrated project: %v", err)
+	}
+	if project.Spec.Name != "migration-2" {
+		t.Fatalf("expected latest revision after migration, got %q", project.Spec.Name)
+	}
+	history, err := migrated.kvProjects.History(ctx, kvProjectKeyPrefix+projectID)
+	if err != nil {
+		t.Fatalf("read migrated history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 retained revisions, got %d", len(history))
+	}
+
+	reopened, err := newStore(ctx, fixture.js)
+	if err != nil {
+		t.Fatalf("reopen migrated store: %v", err)
+	}
+	if reopened.kvProjects.Bucket() != migrated.kvProjects.Bucket() {
+		t.Fatalf("expected reopen to reuse %s, got %s", migrated.kvProjects.Bucket(), reopened.kvProjects.Bucket())
+	}
+}
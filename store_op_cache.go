@@ -0,0 +1,131 @@
+package platform
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Worker op cache: a worker reads and rewrites its op several times per
+// delivery (delivery check, cancel check, step start, heartbeats, step end).
+// Worker stores keep the last op record they read or wrote for a short TTL,
+// so only the first read of a delivery goes to KV. Entries are refreshed by
+// the store's own PutOp, dropped when a delivery starts or a cancel arrives,
+// and never shared between workers. Each entry keeps its KV revision, so
+// updateOp can start from it and still lose cleanly to a newer write.
+////////////////////////////////////////////////////////////////////////////////
+
+const workerOpCacheMaxEntries = 256
+
+type opCacheEntry struct {
+	raw []byte
+	// revision is the KV revision raw was read or written at, so an update
+	// starting from the cached record can be revision-checked.
+	revision  uint64
+	fetchedAt time.Time
+	// indexed is set once this store has recorded the op in its project's
+	// ops index, so later writes can skip the index round trips.
+	indexed bool
+}
+
+type opReadCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]opCacheEntry
+}
+
+// workerOpCacheTTLFromEnv reads PAAS_WORKER_OP_CACHE_TTL as a Go duration.
+// "0" or "off" disables the cache; invalid values use the default.
+func workerOpCacheTTLFromEnv() time.Duration {
+	raw := strings.TrimSpace(strings.ToLower(os.Getenv(workerOpCacheTTLEnv)))
+	switch raw {
+	case "":
+		return defaultWorkerOpCacheTTL
+	case "0", "off":
+		return 0
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl < 0 {
+		return defaultWorkerOpCacheTTL
+	}
+	return ttl
+}
+
+// enableOpCache turns on op read caching for this store. Only worker stores
+// enable it; the API always reads through to KV.
+func (s *Store) enableOpCache(ttl time.Duration) {
+	if s == nil || ttl <= 0 {
+		return
+	}
+	s.opCache = &opReadCache{mu: sync.Mutex{}, ttl: ttl, entries: map[string]opCacheEntry{}}
+}
+
+// forgetOp drops opID from the cache so the next read goes to KV.
+func (s *Store) forgetOp(opID string) {
+	if s == nil || s.opCache == nil {
+		return
+	}
+	s.opCache.mu.Lock()
+	defer s.opCache.mu.Unlock()
+	delete(s.opCache.entries, opID)
+}
+
+// cachedOp decodes a fresh cache entry for opID and returns it with the
+// revision it was cached at. The record is decoded on every hit so callers
+// can edit the op without touching the cache.
+func (s *Store) cachedOp(opID string) (Operation, uint64, bool) {
+	if s == nil || s.opCache == nil {
+		return Operation{}, 0, false
+	}
+	s.opCache.mu.Lock()
+	entry, ok := s.opCache.entries[opID]
+	s.opCache.mu.Unlock()
+	if !ok || time.Since(entry.fetchedAt) > s.opCache.ttl {
+		return Operation{}, 0, false
+	}
+	var op Operation
+	if err := json.Unmarshal(entry.raw, &op); err != nil {
+		return Operation{}, 0, false
+	}
+	return op, entry.revision, true
+}
+
+// opIndexed reports whether this store already recorded opID in its
+// project's ops index. Unlike the record itself this does not go stale: the
+// index only drops an op when it is trimmed or repaired.
+func (s *Store) opIndexed(opID string) bool {
+	if s == nil || s.opCache == nil {
+		return false
+	}
+	s.opCache.mu.Lock()
+	defer s.opCache.mu.Unlock()
+	entry, ok := s.opCache.entries[opID]
+	return ok && entry.indexed
+}
+
+// rememberOp caches raw, stored at revision, as opID's current record.
+// Expired entries are swept once the cache grows past
+// workerOpCacheMaxEntries.
+func (s *Store) rememberOp(opID string, raw []byte, revision uint64, indexed bool) {
+	if s == nil || s.opCache == nil {
+		return
+	}
+	c := s.opCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= workerOpCacheMaxEntries {
+		for id, entry := range c.entries {
+			if now.Sub(entry.fetchedAt) > c.ttl {
+				delete(c.entries, id)
+			}
+		}
+	}
+	if !indexed {
+		indexed = c.entries[opID].indexed
+	}
+	c.entries[opID] = opCacheEntry{raw: raw, revision: revision, fetchedAt: now, indexed: indexed}
+}
@@ -0,0 +1,105 @@
+//nolint:testpackage,exhaustruct // Op cache tests compare a worker store's cached reads with direct KV writes.
+package platform
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_WorkerOpCacheServesRereadsUntilForgotten(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+
+	worker, err := newStore(ctx, fixture.js)
+	if err != nil {
+		t.Fatalf("worker store: %v", err)
+	}
+	worker.enableOpCache(time.Minute)
+
+	op := Operation{
+		ID:        "op-cache",
+		Kind:      OpCI,
+		ProjectID: "project-cache",
+		Requested: time.Now().UTC(),
+		Status:    opStatusRunning,
+		Steps:     []OpStep{{Worker: "imageBuilder", Message: "building"}},
+	}
+	for range 3 {
+		if err = worker.PutOp(ctx, op); err != nil {
+			t.Fatalf("put op: %v", err)
+		}
+	}
+	if got := worker.metrics.snapshot().Methods["recordProjectOp"].Calls; got != 1 {
+		t.Fatalf("expected the ops index to be written once, got %d", got)
+	}
+
+	// Another process cancels the op; the worker keeps its own copy until told.
+	cancelled := op
+	cancelled.Status = opStatusCancelled
+	if err = fixture.store.PutOp(ctx, cancelled); err != nil {
+		t.Fatalf("put cancelled op: %v", err)
+	}
+	cached, err := worker.GetOp(ctx, op.ID)
+	if err != nil || cached.Status != opStatusRunning {
+		t.Fatalf("expected the cached running op, got %+v (%v)", cached, err)
+	}
+	cached.Steps[0].Message = "edited by caller"
+	if again, _ := worker.GetOp(ctx, op.ID); again.Steps[0].Message != "building" {
+		t.Fatalf("expected caller edits not to reach the cache, got %q", again.Steps[0].Message)
+	}
+	if got := worker.metrics.snapshot().Methods["GetOpCached"].Calls; got != 2 {
+		t.Fatalf("expected 2 cached reads, got %d", got)
+	}
+
+	worker.forgetOp(op.ID)
+	fresh, err := worker.GetOp(ctx, op.ID)
+	if err != nil || fresh.Status != opStatusCancelled {
+		t.Fatalf("expected a forgotten op to be read from KV, got %+v (%v)", fresh, err)
+	}
+}
+
+func TestFinalizeOp_KeepsACancelTheWorkerCacheHasNotSeen(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := context.Background()
+
+	worker, err := newStore(ctx, fixture.js)
+	if err != nil {
+		t.Fatalf("worker store: %v", err)
+	}
+	worker.enableOpCache(time.Minute)
+	op := Operation{
+		ID:        "op-cache-cancel",
+		Kind:      OpCI,
+		ProjectID: "project-cache-cancel",
+		Requested: time.Now().UTC(),
+		Status:    opStatusRunning,
+		Steps:     []OpStep{{Worker: "imageBuilder", Message: "building"}},
+	}
+	if err = worker.PutOp(ctx, op); err != nil {
+		t.Fatalf("put op: %v", err)
+	}
+
+	// The API cancels the op before the cancel message reaches the worker,
+	// whose cache still holds it running.
+	if _, err = cancelOp(ctx, fixture.store, op.ID, "stop"); err != nil {
+		t.Fatalf("cancel op: %v", err)
+	}
+	if cached, _ := worker.GetOp(ctx, op.ID); cached.Status != opStatusRunning {
+		t.Fatalf("expected the worker to still hold the running op, got %s", cached.Status)
+	}
+	if err = markOpStepEnd(ctx, worker, op.ID, "imageBuilder", time.Now().UTC(), "built", "", nil); err != nil {
+		t.Fatalf("end step: %v", err)
+	}
+	if err = finalizeOp(ctx, worker, op.ID, op.ProjectID, op.Kind, opStatusDone, ""); err != nil {
+		t.Fatalf("finalize op: %v", err)
+	}
+
+	stored, _, err := fixture.store.readOp(ctx, op.ID)
+	if err != nil || stored.Status != opStatusCancelled || !strings.HasPrefix(stored.Error, opMessageCancel) {
+		t.Fatalf("expected the cancel to survive the worker's finalize, got %+v (%v)", stored, err)
+	}
+}
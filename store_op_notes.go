@@ -0,0 +1,93 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const opNotesWriteAttempts = 5
+
+// opNotesRecord holds every note on one op, oldest first.
+type opNotesRecord struct {
+	OpID      string    `json:"op_id"`
+	ProjectID string    `json:"project_id"`
+	Notes     []OpNote  `json:"notes"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// opNotesFullError refuses a note once an op has maxOpNotes of them.
+type opNotesFullError struct{}
+
+func (opNotesFullError) Error() string {
+	return fmt.Sprintf("operation already has %d notes", maxOpNotes)
+}
+
+func opNotesKey(opID string) string {
+	return kvOpNotesKeyPrefix + strings.TrimSpace(opID)
+}
+
+// getOpNotes returns opID's notes and the revision of their key, which is 0
+// while the op has none.
+func (s *Store) getOpNotes(ctx context.Context, opID string) ([]OpNote, kvRevision, error) {
+	defer s.observe("getOpNotes", time.Now())
+	record, rev, err := s.readOpNotes(ctx, opID)
+	return record.Notes, rev, err
+}
+
+// addOpNote appends note to op's notes. Writes are revision-checked so two
+// notes posted at once both land.
+func (s *Store) addOpNote(ctx context.Context, op Operation, note OpNote) error {
+	defer s.observe("addOpNote", time.Now())
+	var err error
+	for range opNotesWriteAttempts {
+		var record opNotesRecord
+		var rev kvRevision
+		if record, rev, err = s.readOpNotes(ctx, op.ID); err != nil {
+			return err
+		}
+		if len(record.Notes) >= maxOpNotes {
+			return opNotesFullError{}
+		}
+		record.OpID = op.ID
+		record.ProjectID = op.ProjectID
+		record.Notes = append(record.Notes, note)
+		record.UpdatedAt = note.CreatedAt
+		body, marshalErr := json.Marshal(record)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		if rev.Revision == 0 {
+			_, err = s.kvOps.Create(ctx, opNotesKey(op.ID), body)
+		} else {
+			_, err = s.kvOps.Update(ctx, opNotesKey(op.ID), body, rev.Revision)
+		}
+		if err == nil || !errors.Is(err, jetstream.ErrKeyExists) {
+			return err
+		}
+	}
+	return fmt.Errorf("notes for op %s kept changing: %w", op.ID, err)
+}
+
+func (s *Store) readOpNotes(ctx context.Context, opID string) (opNotesRecord, kvRevision, error) {
+	record := opNotesRecord{OpID: opID, ProjectID: "", Notes: []OpNote{}, UpdatedAt: time.Time{}}
+	entry, err := s.kvOps.Get(ctx, opNotesKey(opID))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return record, kvRevision{Key: opNotesKey(opID), Revision: 0, Modified: time.Time{}}, nil
+		}
+		return record, kvRevision{}, err
+	}
+	if err = json.Unmarshal(entry.Value(), &record); err != nil {
+		return record, kvRevision{}, err
+	}
+	if record.Notes == nil {
+		record.Notes = []OpNote{}
+	}
+	return record, entryRevision(entry), nil
+}
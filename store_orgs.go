@@ -0,0 +1,155 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Organizations live in their own bucket (paas_orgs by default), one key
+// each under kvOrgKeyPrefix.
+
+// orgExistsError refuses a second organization with the same ID.
+type orgExistsError struct {
+	ID string
+}
+
+func (e orgExistsError) Error() string {
+	return fmt.Sprintf("organization %q already exists", e.ID)
+}
+
+func orgKey(id string) string {
+	return kvOrgKeyPrefix + strings.TrimSpace(id)
+}
+
+// getOrg reads one organization; a missing one is jetstream.ErrKeyNotFound.
+func (s *Store) getOrg(ctx context.Context, id string) (Organization, error) {
+	defer s.observe("getOrg", time.Now())
+	entry, err := s.kvOrgs.Get(ctx, orgKey(id))
+	if err != nil {
+		return Organization{}, err
+	}
+	var org Organization
+	if err = json.Unmarshal(entry.Value(), &org); err != nil {
+		return Organization{}, err
+	}
+	org.Revision = entry.Revision()
+	return org, nil
+}
+
+// listOrgs returns every organization ordered by ID.
+func (s *Store) listOrgs(ctx context.Context) ([]Organization, error) {
+	defer s.observe("listOrgs", time.Now())
+	keys, err := s.kvOrgs.Keys(ctx)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrNoKeysFound) {
+			return []Organization{}, nil
+		}
+		return nil, err
+	}
+	out := []Organization{}
+	for _, key := range keys {
+		id, ok := strings.CutPrefix(key, kvOrgKeyPrefix)
+		if !ok {
+			continue
+		}
+		org, getErr := s.getOrg(ctx, id)
+		if getErr != nil {
+			// best-effort listing, as in ListProjects
+			continue
+		}
+		out = append(out, org)
+	}
+	slices.SortFunc(out, func(x, y Organization) int { return strings.Compare(x.ID, y.ID) })
+	return out, nil
+}
+
+// putOrg creates org when its Revision is zero, failing with orgExistsError
+// if the ID is taken, and otherwise writes over that revision, failing with
+// jetstream.ErrKeyExists when the org changed since.
+func (s *Store) putOrg(ctx context.Context, org Organization) (Organization, error) {
+	defer s.observe("putOrg", time.Now())
+	org.UpdatedAt = time.Now().UTC()
+	body, err := json.Marshal(org)
+	if err != nil {
+		return Organization{}, err
+	}
+	var revision uint64
+	if org.Revision == 0 {
+		revision, err = s.kvOrgs.Create(ctx, orgKey(org.ID), body)
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return Organization{}, orgExistsError{ID: org.ID}
+		}
+	} else {
+		revision, err = s.kvOrgs.Update(ctx, orgKey(org.ID), body, org.Revision)
+	}
+	if err != nil {
+		return Organization{}, err
+	}
+	org.Revision = revision
+	return org, nil
+}
+
+// deleteOrg removes an organization by ID and reports whether it existed.
+func (s *Store) deleteOrg(ctx context.Context, id string) (bool, error) {
+	defer s.observe("deleteOrg", time.Now())
+	if _, err := s.kvOrgs.Get(ctx, orgKey(id)); err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, s.kvOrgs.Delete(ctx, orgKey(id))
+}
+
+// projectOrgCache remembers each project's org. A project's org is fixed
+// when it is created, so entries never go stale; only projects that were
+// found are cached.
+type projectOrgCache struct {
+	mu   sync.RWMutex
+	orgs map[string]string
+}
+
+func newProjectOrgCache() *projectOrgCache {
+	return &projectOrgCache{mu: sync.RWMutex{}, orgs: map[string]string{}}
+}
+
+func (c *projectOrgCache) get(projectID string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	org, ok := c.orgs[projectID]
+	return org, ok
+}
+
+func (c *projectOrgCache) put(projectID, org string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.orgs[projectID] = org
+}
+
+// projectOrg returns the org projectID belongs to, "" for one outside any
+// org. A missing project is jetstream.ErrKeyNotFound.
+func (s *Store) projectOrg(ctx context.Context, projectID string) (string, error) {
+	if org, ok := s.projectOrgs.get(projectID); ok {
+		return org, nil
+	}
+	project, err := s.GetProject(ctx, projectID)
+	if err != nil {
+		return "", err
+	}
+	s.projectOrgs.put(projectID, project.Org)
+	return project.Org, nil
+}
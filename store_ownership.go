@@ -0,0 +1,46 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// setProjectOwnership replaces the ownership on a project record. The write
+// is revision-checked and retried, so a status write landing in between is
+// kept rather than overwritten.
+func (s *Store) setProjectOwnership(
+	ctx context.Context,
+	projectID string,
+	ownership ProjectOwnership,
+) (Project, error) {
+	defer s.observe("setProjectOwnership", time.Now())
+	var err error
+	for range projectWriteAttempts {
+		var project Project
+		var rev kvRevision
+		if project, rev, err = s.readProject(ctx, projectID); err != nil {
+			return Project{}, err
+		}
+		project.Ownership = ownership
+		body, marshalErr := json.Marshal(project)
+		if marshalErr != nil {
+			return Project{}, marshalErr
+		}
+		var revision uint64
+		revision, err = s.kvProjects.Update(ctx, kvProjectKeyPrefix+projectID, body, rev.Revision)
+		if err == nil {
+			s.projectWatch.put(projectID, body, revision)
+			emitProjectOwnership(s.opEvents, project)
+			return project, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return Project{}, err
+		}
+	}
+	return Project{}, fmt.Errorf("project %s kept changing: %w", projectID, err)
+}
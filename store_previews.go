@@ -0,0 +1,156 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const projectPreviewsWriteAttempts = 5
+
+// projectPreviews is the per-project record of preview environments, in the
+// order they were created.
+type projectPreviews struct {
+	Previews  []Preview `json:"previews"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (p projectPreviews) find(name string) (int, bool) {
+	for i, preview := range p.Previews {
+		if preview.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// put replaces the preview with preview's name, or appends it.
+func (p *projectPreviews) put(preview Preview) {
+	if i, ok := p.find(preview.Name); ok {
+		p.Previews[i] = preview
+		return
+	}
+	p.Previews = append(p.Previews, preview)
+}
+
+func (p *projectPreviews) remove(name string) {
+	p.Previews = slices.DeleteFunc(p.Previews, func(preview Preview) bool { return preview.Name == name })
+}
+
+func projectPreviewsKey(projectID string) string {
+	return kvProjectPreviewsKeyPrefix + strings.TrimSpace(projectID)
+}
+
+func (s *Store) getProjectPreviews(ctx context.Context, projectID string) (projectPreviews, error) {
+	defer s.observe("getProjectPreviews", time.Now())
+	previews, _, err := s.readProjectPreviews(ctx, projectID)
+	return previews, err
+}
+
+// readProjectPreviews returns the project's previews and the revision they
+// were read at; 0 when the project has none.
+func (s *Store) readProjectPreviews(ctx context.Context, projectID string) (projectPreviews, uint64, error) {
+	empty := projectPreviews{Previews: []Preview{}, UpdatedAt: time.Time{}}
+	entry, err := s.kvOps.Get(ctx, projectPreviewsKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return empty, 0, nil
+	}
+	if err != nil {
+		return projectPreviews{}, 0, err
+	}
+	previews := empty
+	if err = json.Unmarshal(entry.Value(), &previews); err != nil {
+		return projectPreviews{}, 0, err
+	}
+	if previews.Previews == nil {
+		previews.Previews = []Preview{}
+	}
+	return previews, entry.Revision(), nil
+}
+
+// updateProjectPreviews applies change to the project's previews and writes
+// them back with a revision check, re-reading and re-applying change when
+// another writer (the webhook, a worker, or the reaper) got there first. An
+// error from change is returned as-is and nothing is written.
+func (s *Store) updateProjectPreviews(
+	ctx context.Context,
+	projectID string,
+	change func(*projectPreviews) error,
+) (projectPreviews, error) {
+	defer s.observe("updateProjectPreviews", time.Now())
+	var err error
+	for range projectPreviewsWriteAttempts {
+		previews, revision, readErr := s.readProjectPreviews(ctx, projectID)
+		if readErr != nil {
+			return projectPreviews{}, readErr
+		}
+		if err = change(&previews); err != nil {
+			return projectPreviews{}, err
+		}
+		err = s.writeProjectPreviews(ctx, projectID, previews, revision)
+		if err == nil {
+			return previews, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return projectPreviews{}, err
+		}
+	}
+	return projectPreviews{}, fmt.Errorf("previews of project %s kept changing: %w", projectID, err)
+}
+
+func (s *Store) writeProjectPreviews(
+	ctx context.Context,
+	projectID string,
+	previews projectPreviews,
+	revision uint64,
+) error {
+	key := projectPreviewsKey(projectID)
+	if len(previews.Previews) == 0 {
+		if revision == 0 {
+			return nil
+		}
+		return s.kvOps.Delete(ctx, key, jetstream.LastRevision(revision))
+	}
+	previews.UpdatedAt = time.Now().UTC()
+	body, err := json.Marshal(previews)
+	if err != nil {
+		return err
+	}
+	if revision == 0 {
+		_, err = s.kvOps.Create(ctx, key, body)
+		return err
+	}
+	_, err = s.kvOps.Update(ctx, key, body, revision)
+	return err
+}
+
+// listPreviewProjects returns the IDs of projects that have previews.
+func (s *Store) listPreviewProjects(ctx context.Context) ([]string, error) {
+	defer s.observe("listPreviewProjects", time.Now())
+	keys, err := s.opsBucketKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	projectIDs := []string{}
+	for _, key := range keys {
+		if projectID, ok := strings.CutPrefix(key, kvProjectPreviewsKeyPrefix); ok {
+			projectIDs = append(projectIDs, projectID)
+		}
+	}
+	return projectIDs, nil
+}
+
+func (s *Store) deleteProjectPreviews(ctx context.Context, projectID string) error {
+	defer s.observe("deleteProjectPreviews", time.Now())
+	err := s.kvOps.Delete(ctx, projectPreviewsKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,301 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Read cache: the API's hot paths (project list, overview, journey, op
+// lists) read the same project and op records many times per request. The
+// server's store follows a KV watch on each bucket and keeps the latest
+// project and op records in memory, so those reads are a map lookup.
+//
+// Consistency: a write made through the store is in its cache before the
+// write call returns, so an API process always reads its own writes. Writes
+// from other processes (workers, other replicas) arrive with the watch,
+// usually within milliseconds. Records the cache does not hold, a watch
+// that has not caught up or has stopped, and contexts marked with
+// withStoreCacheBypass all read KV directly.
+////////////////////////////////////////////////////////////////////////////////
+
+// kvWatchPendingDelete marks a record this store deleted whose delete the
+// watch has not delivered yet; older puts still in flight must not bring
+// it back.
+const kvWatchPendingDelete = math.MaxUint64
+
+type kvWatchEntry struct {
+	raw      []byte
+	revision uint64
+	deleted  bool
+}
+
+// kvWatchCache holds the latest stored value of every key under prefix,
+// keyed by the ID after the prefix. Values stay encoded and are decoded on
+// each hit, so callers can edit what they read.
+type kvWatchCache struct {
+	prefix  string
+	mu      sync.RWMutex
+	synced  bool
+	entries map[string]kvWatchEntry
+}
+
+func newKVWatchCache(prefix string) *kvWatchCache {
+	return &kvWatchCache{prefix: prefix, mu: sync.RWMutex{}, synced: false, entries: map[string]kvWatchEntry{}}
+}
+
+// put records raw at revision unless the cache already holds a newer
+// write; a local write can land before the watch delivers older ones.
+func (c *kvWatchCache) put(id string, raw []byte, revision uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current, ok := c.entries[id]; ok && current.revision > revision {
+		return
+	}
+	c.entries[id] = kvWatchEntry{raw: raw, revision: revision, deleted: false}
+}
+
+// markDeleted hides id until the watch delivers its delete.
+func (c *kvWatchCache) markDeleted(id string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[id] = kvWatchEntry{raw: nil, revision: kvWatchPendingDelete, deleted: true}
+}
+
+// get returns id's stored value, or false when the cache cannot answer:
+// it is not following the bucket, or it does not hold id.
+func (c *kvWatchCache) get(id string) (kvWatchEntry, bool) {
+	if c == nil {
+		return kvWatchEntry{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[id]
+	if !c.synced || !ok || entry.deleted {
+		return kvWatchEntry{}, false
+	}
+	return entry, true
+}
+
+// values returns every stored value, or false while the cache is not
+// following the bucket.
+func (c *kvWatchCache) values() ([]kvWatchEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.synced {
+		return nil, false
+	}
+	out := make([]kvWatchEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		if !entry.deleted {
+			out = append(out, entry)
+		}
+	}
+	return out, true
+}
+
+func (c *kvWatchCache) setSynced(synced bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.synced = synced
+}
+
+// apply folds one watch entry into the cache. Other keys share the bucket
+// and are ignored.
+func (c *kvWatchCache) apply(entry jetstream.KeyValueEntry) {
+	id, ok := strings.CutPrefix(entry.Key(), c.prefix)
+	if !ok {
+		return
+	}
+	switch entry.Operation() {
+	case jetstream.KeyValuePut:
+		c.put(id, entry.Value(), entry.Revision())
+	case jetstream.KeyValueDelete, jetstream.KeyValuePurge:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if current, found := c.entries[id]; found && !current.deleted && current.revision > entry.Revision() {
+			return
+		}
+		delete(c.entries, id)
+	}
+}
+
+// follow keeps the cache in step with kv until ctx ends; the cache then
+// stops answering.
+func (c *kvWatchCache) follow(ctx context.Context, kv jetstream.KeyValue) error {
+	watcher, err := kv.WatchAll(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer c.setSynced(false)
+		defer func() { _ = watcher.Stop() }()
+		for entry := range watcher.Updates() {
+			if entry == nil {
+				// The initial values are in; updates follow.
+				c.setSynced(true)
+				continue
+			}
+			c.apply(entry)
+		}
+	}()
+	return nil
+}
+
+// storeReadCacheEnabledFromEnv reads PAAS_STORE_READ_CACHE; "off", "0" or
+// "false" keep every read on KV.
+func storeReadCacheEnabledFromEnv() bool {
+	switch strings.TrimSpace(strings.ToLower(os.Getenv(storeReadCacheEnv))) {
+	case "off", "0", "false":
+		return false
+	}
+	return true
+}
+
+// enableReadCache starts following the project and op records. Only the
+// server's store enables it; worker stores keep their short-lived op cache.
+func (s *Store) enableReadCache(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	projects := newKVWatchCache(kvProjectKeyPrefix)
+	if err := projects.follow(ctx, s.kvProjects); err != nil {
+		return err
+	}
+	ops := newKVWatchCache(kvOpKeyPrefix)
+	if err := ops.follow(ctx, s.kvOps); err != nil {
+		return err
+	}
+	s.projectWatch = projects
+	s.opWatch = ops
+	return nil
+}
+
+type storeCacheBypassKey struct{}
+
+// withStoreCacheBypass marks ctx so Store reads go to KV, for callers that
+// need writes other processes made a moment ago.
+func withStoreCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, storeCacheBypassKey{}, true)
+}
+
+func storeCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(storeCacheBypassKey{}).(bool)
+	return bypass
+}
+
+// withStoreCacheBypassHeader bypasses the read cache for requests that ask
+// for a fresh answer with Cache-Control: no-cache (or no-store).
+func withStoreCacheBypassHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		control := strings.ToLower(r.Header.Get("Cache-Control"))
+		if strings.Contains(control, "no-cache") || strings.Contains(control, "no-store") {
+			r = r.WithContext(withStoreCacheBypass(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Store) readCacheUsable(ctx context.Context, cache *kvWatchCache) bool {
+	return s != nil && cache != nil && !storeCacheBypassed(ctx)
+}
+
+// watchedProject serves GetProject from the read cache.
+func (s *Store) watchedProject(ctx context.Context, projectID string) (Project, bool) {
+	if !s.readCacheUsable(ctx, s.projectWatch) {
+		return Project{}, false
+	}
+	entry, ok := s.projectWatch.get(projectID)
+	if !ok {
+		return Project{}, false
+	}
+	project, err := decodeStoredProject(entry.raw)
+	project.Revision = entry.revision
+	return project, err == nil
+}
+
+// watchedProjects serves ListProjects from the read cache, oldest first.
+func (s *Store) watchedProjects(ctx context.Context) ([]Project, bool) {
+	if !s.readCacheUsable(ctx, s.projectWatch) {
+		return nil, false
+	}
+	values, ok := s.projectWatch.values()
+	if !ok {
+		return nil, false
+	}
+	out := make([]Project, 0, len(values))
+	for _, entry := range values {
+		if project, err := decodeStoredProject(entry.raw); err == nil {
+			project.Revision = entry.revision
+			out = append(out, project)
+		}
+	}
+	slices.SortFunc(out, func(x, y Project) int {
+		if c := x.CreatedAt.Compare(y.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(x.ID, y.ID)
+	})
+	return out, true
+}
+
+// watchedOp serves GetOp from the read cache.
+func (s *Store) watchedOp(ctx context.Context, opID string) (Operation, bool) {
+	if !s.readCacheUsable(ctx, s.opWatch) {
+		return Operation{}, false
+	}
+	entry, ok := s.opWatch.get(opID)
+	if !ok {
+		return Operation{}, false
+	}
+	var op Operation
+	return op, json.Unmarshal(entry.raw, &op) == nil
+}
+
+// watchedOps serves listAllOps from the read cache, oldest request first.
+func (s *Store) watchedOps(ctx context.Context) ([]Operation, bool) {
+	if !s.readCacheUsable(ctx, s.opWatch) {
+		return nil, false
+	}
+	values, ok := s.opWatch.values()
+	if !ok {
+		return nil, false
+	}
+	out := make([]Operation, 0, len(values))
+	for _, entry := range values {
+		var op Operation
+		if json.Unmarshal(entry.raw, &op) == nil {
+			out = append(out, op)
+		}
+	}
+	slices.SortFunc(out, func(x, y Operation) int {
+		if c := x.Requested.Compare(y.Requested); c != 0 {
+			return c
+		}
+		return strings.Compare(x.ID, y.ID)
+	})
+	return out, true
+}
+
+// observeCached counts a read the cache answered.
+func (s *Store) observeCached(method string) {
+	s.observe(method+"Cached", time.Now())
+}
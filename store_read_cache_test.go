@@ -0,0 +1,139 @@
+//nolint:testpackage,exhaustruct // Read cache tests watch KV writes made through a second store.
+package platform
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_ProjectListCacheFollowsWritesFromOtherStores(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, err := newStore(ctx, fixture.js)
+	if err != nil {
+		t.Fatalf("server store: %v", err)
+	}
+	now := time.Now().UTC()
+	put := func(store *Store, id string, created time.Time) {
+		t.Helper()
+		err := store.PutProject(ctx, Project{ID: id, CreatedAt: created, Spec: workerRuntimeSpec(id)})
+		if err != nil {
+			t.Fatalf("put %s: %v", id, err)
+		}
+	}
+	put(fixture.store, "project-before", now.Add(-time.Hour))
+	if err = server.enableReadCache(ctx); err != nil {
+		t.Fatalf("enable cache: %v", err)
+	}
+	listed := func() string {
+		t.Helper()
+		projects, listErr := server.ListProjects(ctx)
+		if listErr != nil {
+			t.Fatalf("list projects: %v", listErr)
+		}
+		ids := []string{}
+		for _, project := range projects {
+			ids = append(ids, project.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for listed() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected projects %q, got %q", want, listed())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("project-before")
+	readsKV := server.metrics.snapshot().Methods["ListProjects"].Calls
+
+	// Writes through this store show up at once; other replicas' via the watch.
+	put(server, "project-local", now)
+	if got := listed(); got != "project-before,project-local" {
+		t.Fatalf("expected a local write listed right away, got %q", got)
+	}
+	put(fixture.store, "project-remote", now.Add(time.Minute))
+	waitFor("project-before,project-local,project-remote")
+	if err = fixture.store.DeleteProject(ctx, "project-before"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	waitFor("project-local,project-remote")
+	if got := server.metrics.snapshot().Methods["ListProjects"].Calls; got != readsKV {
+		t.Fatalf("expected every listing served from the cache, got %d KV listings", got)
+	}
+
+	// Once the watch stops, listing reads KV again.
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := server.projectWatch.values(); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the cache to stop answering after its watch ended")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStore_ReadCacheServesOpsAndHonorsBypass(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx := t.Context()
+
+	server, err := newStore(ctx, fixture.js)
+	if err != nil {
+		t.Fatalf("server store: %v", err)
+	}
+	if err = server.enableReadCache(ctx); err != nil {
+		t.Fatalf("enable cache: %v", err)
+	}
+	op := Operation{ID: "op-cached", Kind: OpDeploy, ProjectID: "project-1", Status: "queued", Requested: time.Now().UTC()}
+	if err = fixture.store.PutOp(ctx, op); err != nil {
+		t.Fatalf("put op: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := server.watchedOp(ctx, op.ID); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a worker's op write to reach the cache")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The server's own write is read back at once, without a KV read.
+	op.Status = "running"
+	if err = server.PutOp(ctx, op); err != nil {
+		t.Fatalf("put op: %v", err)
+	}
+	kvReads := server.metrics.snapshot().Methods["GetOp"].Calls
+	got, err := server.GetOp(ctx, op.ID)
+	if err != nil || got.Status != "running" {
+		t.Fatalf("expected own write read back, got %+v (%v)", got, err)
+	}
+	if calls := server.metrics.snapshot().Methods["GetOp"].Calls; calls != kvReads {
+		t.Fatalf("expected the read served from the cache, got %d KV reads", calls-kvReads)
+	}
+	ops, err := server.listAllOps(ctx)
+	if err != nil || len(ops) != 1 || ops[0].Status != "running" {
+		t.Fatalf("expected cached op listing, got %+v (%v)", ops, err)
+	}
+
+	// A bypassing read goes to KV.
+	if _, err = server.GetOp(withStoreCacheBypass(ctx), op.ID); err != nil {
+		t.Fatalf("bypass read: %v", err)
+	}
+	if calls := server.metrics.snapshot().Methods["GetOp"].Calls; calls != kvReads+1 {
+		t.Fatalf("expected one KV read for the bypassing call, got %d", calls-kvReads)
+	}
+}
@@ -0,0 +1,91 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// artifactPlacement pins a project's artifact tree to a named root from
+// PAAS_ARTIFACT_ROOTS. Projects without one live under the main root.
+type artifactPlacement struct {
+	ProjectID string    `json:"project_id"`
+	Root      string    `json:"root"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func artifactPlacementKey(projectID string) string {
+	return kvProjectArtifactRootKeyPrefix + strings.TrimSpace(projectID)
+}
+
+// getArtifactPlacement reports the project's placement, or false when it
+// lives under the main root.
+func (s *Store) getArtifactPlacement(ctx context.Context, projectID string) (artifactPlacement, bool, error) {
+	defer s.observe("getArtifactPlacement", time.Now())
+	entry, err := s.kvOps.Get(ctx, artifactPlacementKey(projectID))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return artifactPlacement{}, false, nil
+		}
+		return artifactPlacement{}, false, err
+	}
+	var placement artifactPlacement
+	if err = json.Unmarshal(entry.Value(), &placement); err != nil {
+		return artifactPlacement{}, false, err
+	}
+	return placement, true, nil
+}
+
+// putArtifactPlacement records placement; the default root deletes the
+// record instead, since that is where unplaced projects live.
+func (s *Store) putArtifactPlacement(ctx context.Context, placement artifactPlacement) error {
+	defer s.observe("putArtifactPlacement", time.Now())
+	if placement.Root == "" || placement.Root == defaultArtifactRootName {
+		return s.deleteArtifactPlacement(ctx, placement.ProjectID)
+	}
+	placement.UpdatedAt = time.Now().UTC()
+	body, err := json.Marshal(placement)
+	if err != nil {
+		return err
+	}
+	_, err = s.kvOps.Put(ctx, artifactPlacementKey(placement.ProjectID), body)
+	return err
+}
+
+func (s *Store) deleteArtifactPlacement(ctx context.Context, projectID string) error {
+	defer s.observe("deleteArtifactPlacement", time.Now())
+	err := s.kvOps.Delete(ctx, artifactPlacementKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) listArtifactPlacements(ctx context.Context) ([]artifactPlacement, error) {
+	defer s.observe("listArtifactPlacements", time.Now())
+	keys, err := s.opsBucketKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []artifactPlacement{}
+	for _, key := range keys {
+		projectID, ok := strings.CutPrefix(key, kvProjectArtifactRootKeyPrefix)
+		if !ok {
+			continue
+		}
+		placement, found, getErr := s.getArtifactPlacement(ctx, projectID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		if found {
+			out = append(out, placement)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProjectID < out[j].ProjectID })
+	return out, nil
+}
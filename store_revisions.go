@@ -0,0 +1,150 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// kvRevision identifies one stored version of a key: the KV revision (the
+// stream sequence of its last write) and when that write happened. A key
+// that does not exist has revision 0.
+type kvRevision struct {
+	Key      string
+	Revision uint64
+	Modified time.Time
+}
+
+// projectWriteAttempts and opWriteAttempts bound the read-modify-write
+// retries of a project or op record under concurrent writers.
+const (
+	projectWriteAttempts = 5
+	opWriteAttempts      = 5
+)
+
+// projectRevisionConflictError reports a project write that lost a race:
+// the record changed after it was read, or a create found it already there.
+// Current is the stored project, when it could be read.
+type projectRevisionConflictError struct {
+	ProjectID string
+	Create    bool
+	Expected  uint64 // zero when the caller only held an ETag
+	Current   Project
+}
+
+func (e projectRevisionConflictError) Error() string {
+	switch {
+	case e.Create:
+		return fmt.Sprintf("project %s already exists", e.ProjectID)
+	case e.Expected != 0:
+		return fmt.Sprintf(
+			"project %s changed since revision %d (now at %d)",
+			e.ProjectID,
+			e.Expected,
+			e.Current.Revision,
+		)
+	default:
+		return fmt.Sprintf("project %s has changed (now at revision %d)", e.ProjectID, e.Current.Revision)
+	}
+}
+
+func (s *Store) projectRevisionConflict(ctx context.Context, projectID string, expected uint64) error {
+	current, _, _ := s.readProject(ctx, projectID)
+	return projectRevisionConflictError{
+		ProjectID: projectID,
+		Create:    expected == 0,
+		Expected:  expected,
+		Current:   current,
+	}
+}
+
+func entryRevision(entry jetstream.KeyValueEntry) kvRevision {
+	return kvRevision{
+		Key:      entry.Key(),
+		Revision: entry.Revision(),
+		Modified: entry.Created().UTC(),
+	}
+}
+
+func (s *Store) getProjectRevision(ctx context.Context, projectID string) (Project, kvRevision, error) {
+	defer s.observe("getProjectRevision", time.Now())
+	return s.readProject(ctx, projectID)
+}
+
+func (s *Store) getOpRevision(ctx context.Context, opID string) (Operation, kvRevision, error) {
+	defer s.observe("getOpRevision", time.Now())
+	return s.readOp(ctx, opID)
+}
+
+func (s *Store) getReleaseRevision(ctx context.Context, releaseID string) (ReleaseRecord, kvRevision, error) {
+	defer s.observe("getReleaseRevision", time.Now())
+	return s.readRelease(ctx, releaseID)
+}
+
+// opsKeyRevision reports the current revision of an ops-bucket key without
+// decoding it. Used for index and holds keys that shape a response.
+func (s *Store) opsKeyRevision(ctx context.Context, key string) (kvRevision, error) {
+	defer s.observe("opsKeyRevision", time.Now())
+	entry, err := s.kvOps.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return kvRevision{Key: key, Revision: 0, Modified: time.Time{}}, nil
+		}
+		return kvRevision{}, err
+	}
+	return entryRevision(entry), nil
+}
+
+func (s *Store) readProject(ctx context.Context, projectID string) (Project, kvRevision, error) {
+	entry, err := s.kvProjects.Get(ctx, kvProjectKeyPrefix+projectID)
+	if err != nil {
+		return Project{}, kvRevision{}, err
+	}
+	p, err := decodeStoredProject(entry.Value())
+	if err != nil {
+		return Project{}, kvRevision{}, err
+	}
+	p.Revision = entry.Revision()
+	return p, entryRevision(entry), nil
+}
+
+func decodeStoredProject(raw []byte) (Project, error) {
+	var p Project
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return Project{}, err
+	}
+	if p.SpecHash == "" {
+		p.SpecHash = projectSpecHash(p.Spec) // written before spec_hash existed
+	}
+	return p, nil
+}
+
+func (s *Store) readOp(ctx context.Context, opID string) (Operation, kvRevision, error) {
+	entry, err := s.kvOps.Get(ctx, kvOpKeyPrefix+opID)
+	if err != nil {
+		return Operation{}, kvRevision{}, err
+	}
+	var op Operation
+	if err = json.Unmarshal(entry.Value(), &op); err != nil {
+		return Operation{}, kvRevision{}, err
+	}
+	s.rememberOp(opID, entry.Value(), entry.Revision(), false)
+	return op, entryRevision(entry), nil
+}
+
+func (s *Store) readRelease(ctx context.Context, releaseID string) (ReleaseRecord, kvRevision, error) {
+	entry, err := s.kvOps.Get(ctx, kvReleaseKeyPrefix+strings.TrimSpace(releaseID))
+	if err != nil {
+		return ReleaseRecord{}, kvRevision{}, err
+	}
+	var release ReleaseRecord
+	if err = json.Unmarshal(entry.Value(), &release); err != nil {
+		return ReleaseRecord{}, kvRevision{}, err
+	}
+	return normalizeReleaseRecord(release), entryRevision(entry), nil
+}
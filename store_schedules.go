@@ -0,0 +1,142 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const projectSchedulesWriteAttempts = 5
+
+// projectSchedules is the per-project record of op schedules, in the order
+// they were created.
+type projectSchedules struct {
+	Schedules []OpSchedule `json:"schedules"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+func (p projectSchedules) find(id string) (int, bool) {
+	for i, schedule := range p.Schedules {
+		if schedule.ID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func projectSchedulesKey(projectID string) string {
+	return kvProjectSchedulesKeyPrefix + strings.TrimSpace(projectID)
+}
+
+func (s *Store) getProjectSchedules(ctx context.Context, projectID string) (projectSchedules, error) {
+	defer s.observe("getProjectSchedules", time.Now())
+	schedules, _, err := s.readProjectSchedules(ctx, projectID)
+	return schedules, err
+}
+
+// readProjectSchedules returns the project's schedules and the revision they
+// were read at; 0 when the project has none.
+func (s *Store) readProjectSchedules(ctx context.Context, projectID string) (projectSchedules, uint64, error) {
+	empty := projectSchedules{Schedules: []OpSchedule{}, UpdatedAt: time.Time{}}
+	entry, err := s.kvOps.Get(ctx, projectSchedulesKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return empty, 0, nil
+	}
+	if err != nil {
+		return projectSchedules{}, 0, err
+	}
+	schedules := empty
+	if err = json.Unmarshal(entry.Value(), &schedules); err != nil {
+		return projectSchedules{}, 0, err
+	}
+	if schedules.Schedules == nil {
+		schedules.Schedules = []OpSchedule{}
+	}
+	return schedules, entry.Revision(), nil
+}
+
+// updateProjectSchedules applies change to the project's schedules and
+// writes them back with a revision check, re-reading and re-applying change
+// when another writer (an API replica or the scheduler) got there first. An
+// error from change is returned as-is and nothing is written.
+func (s *Store) updateProjectSchedules(
+	ctx context.Context,
+	projectID string,
+	change func(*projectSchedules) error,
+) (projectSchedules, error) {
+	defer s.observe("updateProjectSchedules", time.Now())
+	var err error
+	for range projectSchedulesWriteAttempts {
+		schedules, revision, readErr := s.readProjectSchedules(ctx, projectID)
+		if readErr != nil {
+			return projectSchedules{}, readErr
+		}
+		if err = change(&schedules); err != nil {
+			return projectSchedules{}, err
+		}
+		err = s.writeProjectSchedules(ctx, projectID, schedules, revision)
+		if err == nil {
+			return schedules, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return projectSchedules{}, err
+		}
+	}
+	return projectSchedules{}, fmt.Errorf("schedules of project %s kept changing: %w", projectID, err)
+}
+
+func (s *Store) writeProjectSchedules(
+	ctx context.Context,
+	projectID string,
+	schedules projectSchedules,
+	revision uint64,
+) error {
+	key := projectSchedulesKey(projectID)
+	if len(schedules.Schedules) == 0 {
+		if revision == 0 {
+			return nil
+		}
+		return s.kvOps.Delete(ctx, key, jetstream.LastRevision(revision))
+	}
+	schedules.UpdatedAt = time.Now().UTC()
+	body, err := json.Marshal(schedules)
+	if err != nil {
+		return err
+	}
+	if revision == 0 {
+		_, err = s.kvOps.Create(ctx, key, body)
+		return err
+	}
+	_, err = s.kvOps.Update(ctx, key, body, revision)
+	return err
+}
+
+// listScheduledProjects returns the IDs of projects that have schedules.
+func (s *Store) listScheduledProjects(ctx context.Context) ([]string, error) {
+	defer s.observe("listScheduledProjects", time.Now())
+	keys, err := s.opsBucketKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	projectIDs := []string{}
+	for _, key := range keys {
+		if projectID, ok := strings.CutPrefix(key, kvProjectSchedulesKeyPrefix); ok {
+			projectIDs = append(projectIDs, projectID)
+		}
+	}
+	return projectIDs, nil
+}
+
+func (s *Store) deleteProjectSchedules(ctx context.Context, projectID string) error {
+	defer s.observe("deleteProjectSchedules", time.Now())
+	err := s.kvOps.Delete(ctx, projectSchedulesKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,339 @@
+package platform
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Stored secrets: per-environment values kept in the paas_secrets bucket,
+// each sealed with AES-256-GCM under PAAS_SECRETS_KEY. Renders reference them
+// by name through the project Secret; the values are only opened to compute
+// the rollout checksum and to hand the Secret to kubectl.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	storedSecretsKeyBytes = 32
+	storedSecretMaxBytes  = 64 << 10
+)
+
+// projectStoredSecrets is the per-project record, keyed by environment and
+// then secret name.
+type projectStoredSecrets struct {
+	Environments map[string]map[string]sealedSecret `json:"environments"`
+	UpdatedAt    time.Time                          `json:"updated_at"`
+}
+
+// sealedSecret is one encrypted value: base64 of the GCM nonce followed by
+// the sealed bytes. The project, environment, and name are bound in as
+// additional data, so a ciphertext copied to another key does not open.
+type sealedSecret struct {
+	Ciphertext string    `json:"ciphertext"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// envStoredSecrets is what rendering needs: each environment's stored
+// secret names, sorted.
+type envStoredSecrets map[string][]string
+
+// secretsKeyError reports a missing or malformed PAAS_SECRETS_KEY. Stored
+// values can be listed by name without it but not written or opened.
+type secretsKeyError struct {
+	reason string
+}
+
+func (e secretsKeyError) Error() string {
+	return fmt.Sprintf("stored secrets unavailable: %s", e.reason)
+}
+
+func emptyProjectStoredSecrets() projectStoredSecrets {
+	return projectStoredSecrets{
+		Environments: map[string]map[string]sealedSecret{},
+		UpdatedAt:    time.Time{},
+	}
+}
+
+func (s projectStoredSecrets) names() envStoredSecrets {
+	out := envStoredSecrets{}
+	for env, values := range s.Environments {
+		out[env] = sortedKeys(values)
+	}
+	return out
+}
+
+func projectSecretsKey(projectID string) string {
+	return kvProjectSecretsKeyPrefix + strings.TrimSpace(projectID)
+}
+
+// storedSecretsCipher builds the AEAD from PAAS_SECRETS_KEY, which must be
+// 32 bytes, base64 encoded. It is read on every use like the provider
+// credentials, but a changed key cannot open values sealed under the old one.
+func storedSecretsCipher() (cipher.AEAD, error) {
+	raw := strings.TrimSpace(os.Getenv(secretsKeyEnv))
+	if raw == "" {
+		return nil, secretsKeyError{reason: secretsKeyEnv + " is not set"}
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(key) != storedSecretsKeyBytes {
+		return nil, secretsKeyError{
+			reason: fmt.Sprintf("%s must be %d bytes, base64 encoded", secretsKeyEnv, storedSecretsKeyBytes),
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func storedSecretAAD(projectID, env, name string) []byte {
+	return []byte(projectID + "\x00" + env + "\x00" + name)
+}
+
+func sealStoredSecret(aead cipher.AEAD, projectID, env, name, value string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), storedSecretAAD(projectID, env, name))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openStoredSecret decrypts one value. Its error never carries the value.
+func openStoredSecret(aead cipher.AEAD, projectID, env, name string, sealed sealedSecret) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed.Ciphertext)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return "", fmt.Errorf("stored secret %q in %q is malformed", name, env)
+	}
+	nonce, body := raw[:aead.NonceSize()], raw[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, body, storedSecretAAD(projectID, env, name))
+	if err != nil {
+		return "", fmt.Errorf("stored secret %q in %q does not open with the configured %s", name, env, secretsKeyEnv)
+	}
+	return string(plain), nil
+}
+
+func (s *Store) getStoredSecrets(ctx context.Context, projectID string) (projectStoredSecrets, error) {
+	defer s.observe("getStoredSecrets", time.Now())
+	entry, err := s.kvSecrets.Get(ctx, projectSecretsKey(projectID))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return emptyProjectStoredSecrets(), nil
+		}
+		return projectStoredSecrets{}, err
+	}
+	secrets := emptyProjectStoredSecrets()
+	if err = json.Unmarshal(entry.Value(), &secrets); err != nil {
+		return projectStoredSecrets{}, err
+	}
+	if secrets.Environments == nil {
+		secrets.Environments = map[string]map[string]sealedSecret{}
+	}
+	return secrets, nil
+}
+
+// putStoredSecrets seals values and stores them in env, replacing earlier
+// values of the same names and keeping the rest.
+func (s *Store) putStoredSecrets(
+	ctx context.Context,
+	projectID, env string,
+	values map[string]string,
+) (projectStoredSecrets, error) {
+	defer s.observe("putStoredSecrets", time.Now())
+	aead, err := storedSecretsCipher()
+	if err != nil {
+		return projectStoredSecrets{}, err
+	}
+	secrets, err := s.getStoredSecrets(ctx, projectID)
+	if err != nil {
+		return projectStoredSecrets{}, err
+	}
+	if secrets.Environments[env] == nil {
+		secrets.Environments[env] = map[string]sealedSecret{}
+	}
+	now := time.Now().UTC()
+	for _, name := range sortedKeys(values) {
+		sealed, sealErr := sealStoredSecret(aead, projectID, env, name, values[name])
+		if sealErr != nil {
+			return projectStoredSecrets{}, sealErr
+		}
+		secrets.Environments[env][name] = sealedSecret{Ciphertext: sealed, UpdatedAt: now}
+	}
+	if err = s.writeStoredSecrets(ctx, projectID, secrets); err != nil {
+		return projectStoredSecrets{}, err
+	}
+	return secrets, nil
+}
+
+// deleteStoredSecrets removes the named secrets from env, or all of env's
+// secrets when names is empty, and returns the names it removed.
+func (s *Store) deleteStoredSecrets(ctx context.Context, projectID, env string, names []string) ([]string, error) {
+	defer s.observe("deleteStoredSecrets", time.Now())
+	secrets, err := s.getStoredSecrets(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		names = sortedKeys(secrets.Environments[env])
+	}
+	removed := []string{}
+	for _, name := range names {
+		if _, ok := secrets.Environments[env][name]; ok {
+			delete(secrets.Environments[env], name)
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+	if len(secrets.Environments[env]) == 0 {
+		delete(secrets.Environments, env)
+	}
+	if err = s.writeStoredSecrets(ctx, projectID, secrets); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+func (s *Store) deleteProjectStoredSecrets(ctx context.Context, projectID string) error {
+	defer s.observe("deleteProjectStoredSecrets", time.Now())
+	err := s.kvSecrets.Delete(ctx, projectSecretsKey(projectID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) writeStoredSecrets(ctx context.Context, projectID string, secrets projectStoredSecrets) error {
+	if len(secrets.Environments) == 0 {
+		return s.deleteProjectStoredSecrets(ctx, projectID)
+	}
+	secrets.UpdatedAt = time.Now().UTC()
+	body, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	_, err = s.kvSecrets.Put(ctx, projectSecretsKey(projectID), body)
+	return err
+}
+
+// loadEnvStoredSecrets reads the stored secret names a render should
+// reference. Without a store (offline renders) there are none.
+func loadEnvStoredSecrets(ctx context.Context, store *Store, projectID string) (envStoredSecrets, error) {
+	if store == nil {
+		return envStoredSecrets{}, nil
+	}
+	secrets, err := store.getStoredSecrets(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.names(), nil
+}
+
+// openEnvStoredSecrets decrypts the stored secrets of env that the spec does
+// not shadow, keyed by name.
+func openEnvStoredSecrets(
+	ctx context.Context,
+	store *Store,
+	projectID string,
+	spec ProjectSpec,
+	env string,
+) (map[string]string, error) {
+	values := map[string]string{}
+	if store == nil {
+		return values, nil
+	}
+	secrets, err := store.getStoredSecrets(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	names := activeStoredSecretNames(spec, env, sortedKeys(secrets.Environments[env]))
+	if len(names) == 0 {
+		return values, nil
+	}
+	aead, err := storedSecretsCipher()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		value, openErr := openStoredSecret(aead, projectID, env, name, secrets.Environments[env][name])
+		if openErr != nil {
+			return nil, openErr
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// activeStoredSecretNames drops stored names the spec sets itself in env,
+// as a var or as an external secret; the spec wins, and the stored value
+// applies again if the spec entry goes away.
+func activeStoredSecretNames(spec ProjectSpec, env string, names []string) []string {
+	envCfg := spec.Environments[env]
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, isVar := envCfg.Vars[name]; isVar {
+			continue
+		}
+		if _, isSecret := envCfg.Secrets[name]; isSecret {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// environmentHasSecrets reports whether a render of env has any secret to
+// resolve, from the spec or the store.
+func environmentHasSecrets(
+	ctx context.Context,
+	store *Store,
+	projectID string,
+	spec ProjectSpec,
+	env string,
+) (bool, error) {
+	if len(spec.Environments[env].Secrets) > 0 {
+		return true, nil
+	}
+	stored, err := loadEnvStoredSecrets(ctx, store, projectID)
+	if err != nil {
+		return false, err
+	}
+	return len(activeStoredSecretNames(spec, env, stored[env])) > 0, nil
+}
+
+// resolveRenderSecrets returns every secret value env renders with, keyed by
+// var name: the stored secrets the spec does not shadow, plus the spec's
+// external references.
+func resolveRenderSecrets(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	env string,
+) (map[string]string, error) {
+	values, err := openEnvStoredSecrets(ctx, store, projectID, spec, env)
+	if err != nil {
+		return nil, err
+	}
+	external, err := newSecretResolver(manifestsRepoDir(artifacts, projectID)).
+		resolveEnvironmentSecrets(ctx, spec, env)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(values, external)
+	return values, nil
+}
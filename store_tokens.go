@@ -0,0 +1,215 @@
+package platform
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// API tokens: bearer credentials with a role, kept in the paas_secrets bucket
+// as one record. Only the SHA-256 of each token is stored; the token itself
+// is shown once, when it is created.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	apiTokenPrefix     = "paas_"
+	apiTokenRandomSize = 32
+	apiTokenNameMax    = 64
+	// apiTokensWriteAttempts bounds the retries of a token write that keeps
+	// losing the revision check to other creates and revokes.
+	apiTokensWriteAttempts = 5
+)
+
+// apiRole is what a token may do. Each role can do everything the ones
+// below it can.
+type apiRole string
+
+const (
+	apiRoleViewer    apiRole = "viewer"
+	apiRoleDeveloper apiRole = "developer"
+	apiRoleAdmin     apiRole = "admin"
+)
+
+// rank orders the roles from viewer up; an unknown role ranks 0.
+func (r apiRole) rank() int {
+	return slices.Index([]apiRole{apiRoleViewer, apiRoleDeveloper, apiRoleAdmin}, r) + 1
+}
+
+// allows reports whether r covers a request that needs role need.
+func (r apiRole) allows(need apiRole) bool {
+	return r.rank() > 0 && r.rank() >= need.rank()
+}
+
+// apiToken is one stored token. Hash is the hex SHA-256 of the bearer value.
+// Teams lets the token change projects whose ownership names one of them;
+// Org confines it to that organization's projects.
+type apiToken struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      apiRole   `json:"role"`
+	Teams     []string  `json:"teams,omitempty"`
+	Org       string    `json:"org,omitempty"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiTokenSet struct {
+	Tokens    map[string]apiToken `json:"tokens"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+func emptyAPITokenSet() apiTokenSet {
+	return apiTokenSet{
+		Tokens:    map[string]apiToken{},
+		UpdatedAt: time.Time{},
+	}
+}
+
+func hashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(raw)))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAPITokenValue() (string, error) {
+	buf := make([]byte, apiTokenRandomSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiTokenPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *Store) getAPITokens(ctx context.Context) (apiTokenSet, error) {
+	defer s.observe("getAPITokens", time.Now())
+	tokens, _, err := s.readAPITokens(ctx)
+	return tokens, err
+}
+
+// readAPITokens returns the token set and the revision it was read at; 0
+// when no token was ever created.
+func (s *Store) readAPITokens(ctx context.Context) (apiTokenSet, uint64, error) {
+	entry, err := s.kvSecrets.Get(ctx, kvAPITokensKey)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return emptyAPITokenSet(), 0, nil
+		}
+		return apiTokenSet{}, 0, err
+	}
+	tokens := emptyAPITokenSet()
+	if err = json.Unmarshal(entry.Value(), &tokens); err != nil {
+		return apiTokenSet{}, 0, err
+	}
+	if tokens.Tokens == nil {
+		tokens.Tokens = map[string]apiToken{}
+	}
+	return tokens, entry.Revision(), nil
+}
+
+// createAPIToken stores a new token and returns it with its bearer value,
+// which is not kept and cannot be read back.
+func (s *Store) createAPIToken(
+	ctx context.Context,
+	name string,
+	role apiRole,
+	teams []string,
+	org string,
+) (apiToken, string, error) {
+	defer s.observe("createAPIToken", time.Now())
+	value, err := newAPITokenValue()
+	if err != nil {
+		return apiToken{}, "", err
+	}
+	token := apiToken{
+		ID:        newID(),
+		Name:      name,
+		Role:      role,
+		Teams:     teams,
+		Org:       org,
+		Hash:      hashAPIToken(value),
+		CreatedAt: time.Now().UTC(),
+	}
+	err = s.updateAPITokens(ctx, func(tokens *apiTokenSet) bool {
+		tokens.Tokens[token.ID] = token
+		return true
+	})
+	if err != nil {
+		return apiToken{}, "", err
+	}
+	return token, value, nil
+}
+
+// deleteAPIToken revokes a token by ID and reports whether it existed.
+func (s *Store) deleteAPIToken(ctx context.Context, id string) (bool, error) {
+	defer s.observe("deleteAPIToken", time.Now())
+	existed := false
+	err := s.updateAPITokens(ctx, func(tokens *apiTokenSet) bool {
+		_, existed = tokens.Tokens[id]
+		delete(tokens.Tokens, id)
+		return existed
+	})
+	return existed, err
+}
+
+// lookupAPIToken finds the stored token whose hash matches raw.
+func (s *Store) lookupAPIToken(ctx context.Context, raw string) (apiToken, bool, error) {
+	tokens, err := s.getAPITokens(ctx)
+	if err != nil {
+		return apiToken{}, false, fmt.Errorf("read api tokens: %w", err)
+	}
+	hash := []byte(hashAPIToken(raw))
+	for _, token := range tokens.Tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(token.Hash)) == 1 {
+			return token, true, nil
+		}
+	}
+	return apiToken{}, false, nil
+}
+
+// updateAPITokens applies change to the token set and writes it back with a
+// revision check, re-reading and re-applying change when another create or
+// revoke got there first, so neither can write back a set the other has
+// already changed. A change that returns false leaves the set unwritten.
+func (s *Store) updateAPITokens(ctx context.Context, change func(*apiTokenSet) bool) error {
+	var err error
+	for range apiTokensWriteAttempts {
+		tokens, revision, readErr := s.readAPITokens(ctx)
+		if readErr != nil {
+			return readErr
+		}
+		if !change(&tokens) {
+			return nil
+		}
+		if err = s.writeAPITokens(ctx, tokens, revision); err == nil {
+			return nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return err
+		}
+	}
+	return fmt.Errorf("api tokens kept changing: %w", err)
+}
+
+func (s *Store) writeAPITokens(ctx context.Context, tokens apiTokenSet, revision uint64) error {
+	tokens.UpdatedAt = time.Now().UTC()
+	body, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	if revision == 0 {
+		_, err = s.kvSecrets.Create(ctx, kvAPITokensKey, body)
+		return err
+	}
+	_, err = s.kvSecrets.Update(ctx, kvAPITokensKey, body, revision)
+	return err
+}
@@ -0,0 +1,82 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Saved project views live next to the projects, one key each under
+// kvViewKeyPrefix.
+
+func viewKey(id string) string {
+	return kvViewKeyPrefix + strings.TrimSpace(id)
+}
+
+func (s *Store) getProjectView(ctx context.Context, id string) (ProjectView, error) {
+	defer s.observe("getProjectView", time.Now())
+	entry, err := s.kvProjects.Get(ctx, viewKey(id))
+	if err != nil {
+		return ProjectView{}, err
+	}
+	var view ProjectView
+	if err = json.Unmarshal(entry.Value(), &view); err != nil {
+		return ProjectView{}, err
+	}
+	return view, nil
+}
+
+// listProjectViews returns every saved view ordered by name.
+func (s *Store) listProjectViews(ctx context.Context) ([]ProjectView, error) {
+	defer s.observe("listProjectViews", time.Now())
+	keys, err := s.kvProjects.Keys(ctx)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrNoKeysFound) {
+			return []ProjectView{}, nil
+		}
+		return nil, err
+	}
+	out := []ProjectView{}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, kvViewKeyPrefix) {
+			continue
+		}
+		view, getErr := s.getProjectView(ctx, strings.TrimPrefix(key, kvViewKeyPrefix))
+		if getErr != nil {
+			// best-effort listing, as in ListProjects
+			continue
+		}
+		out = append(out, view)
+	}
+	slices.SortFunc(out, func(x, y ProjectView) int {
+		return strings.Compare(strings.ToLower(x.Name), strings.ToLower(y.Name))
+	})
+	return out, nil
+}
+
+func (s *Store) putProjectView(ctx context.Context, view ProjectView) error {
+	defer s.observe("putProjectView", time.Now())
+	b, err := json.Marshal(view)
+	if err != nil {
+		return err
+	}
+	_, err = s.kvProjects.Put(ctx, viewKey(view.ID), b)
+	return err
+}
+
+// deleteProjectView removes a view by ID and reports whether it existed.
+func (s *Store) deleteProjectView(ctx context.Context, id string) (bool, error) {
+	defer s.observe("deleteProjectView", time.Now())
+	if _, err := s.kvProjects.Get(ctx, viewKey(id)); err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, s.kvProjects.Delete(ctx, viewKey(id))
+}
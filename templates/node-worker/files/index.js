@@ -0,0 +1,28 @@
+const intervalMs = Number(process.env.WORK_INTERVAL_MS || 5000);
+
+let stopping = false;
+
+async function work() {
+  // Replace with the job this worker exists for.
+  console.log(`tick ${new Date().toISOString()}`);
+}
+
+async function loop() {
+  while (!stopping) {
+    try {
+      await work();
+    } catch (err) {
+      console.error("work failed", err);
+    }
+    await new Promise((resolve) => setTimeout(resolve, intervalMs));
+  }
+}
+
+for (const signal of ["SIGTERM", "SIGINT"]) {
+  process.on(signal, () => {
+    console.log(`${signal} received, finishing the current job`);
+    stopping = true;
+  });
+}
+
+loop().then(() => process.exit(0));
@@ -0,0 +1,1715 @@
+// Code generated from /api/openapi.json by api_tsclient.go; DO NOT EDIT.
+
+interface ApiTokenCreatedResponse {
+  token: ApiTokenView;
+  value: string;
+}
+
+interface ApiTokenListResponse {
+  tokens: ApiTokenView[];
+}
+
+interface ApiTokenRequest {
+  name: string;
+  role: string;
+  teams?: string[];
+  org?: string;
+}
+
+interface ApiTokenRevokedResponse {
+  id: string;
+  revoked: boolean;
+}
+
+interface ApiTokenView {
+  id: string;
+  name: string;
+  role: string;
+  teams?: string[];
+  org?: string;
+  created_at: string;
+}
+
+interface ApprovalDecision {
+  by: string;
+  decision: string;
+  comment?: string;
+  at: string;
+}
+
+interface ApprovalDecisionRequest {
+  comment: string;
+  by: string;
+}
+
+interface ApprovalDecisionResponse {
+  approval: ReleaseApproval;
+  op?: Operation | null;
+  release_error?: string;
+}
+
+interface ArtifactCleanupAcceptedResponse {
+  accepted: boolean;
+  op: Operation;
+  project_id: string;
+  prefix: string;
+}
+
+interface ArtifactFileInfo {
+  path: string;
+  size: number;
+  mod_time: string;
+}
+
+interface ArtifactListResponse {
+  files: string[];
+  entries: ArtifactFileInfo[];
+}
+
+interface ArtifactStatResponse {
+  path: string;
+  size: number;
+  mod_time?: string;
+  sha256: string;
+  content_type: string;
+}
+
+interface AutoscalingConfig {
+  minReplicas?: number;
+  maxReplicas: number;
+  targetCPUUtilization?: number;
+}
+
+interface BindingDeletedResponse {
+  deleted: boolean;
+  binding: CapabilityBinding;
+}
+
+interface BlueGreenRelease {
+  environment: string;
+  from_env: string;
+  active_color: string;
+  blue_image: string;
+  green_image: string;
+  staged_color?: string;
+  previous_color?: string;
+  op_id?: string;
+  updated_at?: string;
+}
+
+interface BuildConfig {
+  strategy?: string;
+  builder?: string;
+}
+
+interface CIBuild {
+  ref: string;
+  commit?: string;
+  release?: string;
+  release_error?: string;
+  preview?: string;
+  environment?: string;
+}
+
+interface CIPolicy {
+  branches?: string[];
+  tags?: string[];
+  paths?: string[];
+  previews?: PreviewPolicy;
+}
+
+interface CanaryActionRequest {
+  freeze_override?: FreezeOverrideRequest | null;
+}
+
+interface CanaryRollout {
+  environment: string;
+  from_env: string;
+  stable_image: string;
+  canary_image: string;
+  requested_weight: number;
+  weight: number;
+  stable_replicas: number;
+  canary_replicas: number;
+  autoscaled?: boolean;
+  op_id?: string;
+  started_at?: string;
+}
+
+interface CapabilityBinding {
+  project_id: string;
+  environment: string;
+  capability: string;
+  type: string;
+  image?: string;
+  container_env?: Record<string, string>;
+  env?: Record<string, string>;
+  secret_env?: Record<string, SecretKeyRef>;
+  updated_at: string;
+}
+
+interface CapabilityBindingRequest {
+  type: string;
+  image?: string;
+  container_env?: Record<string, string>;
+  env?: Record<string, string>;
+  secret_env?: Record<string, SecretKeyRef>;
+}
+
+interface CapabilityInfo {
+  name: string;
+  description: string;
+  options?: CapabilityOption[];
+  resources?: string[];
+  env?: string[];
+}
+
+interface CapabilityOption {
+  name: string;
+  description: string;
+  default?: string;
+  required?: boolean;
+}
+
+interface ComplianceAudit {
+  ops: ComplianceAuditOp[];
+  logs: Record<string, string[]>;
+}
+
+interface ComplianceAuditOp {
+  id: string;
+  kind: string;
+  status: string;
+  requested: string;
+  finished: string;
+  error?: string;
+  vulnerability_override?: boolean;
+  freeze_override?: boolean;
+}
+
+interface ComplianceEnvironment {
+  environment: string;
+  image: string;
+  digest?: string;
+  release?: ReleaseRecord | null;
+  scan?: VulnerabilitySummary | null;
+  budget_exceeded?: string[];
+}
+
+interface ComplianceHold {
+  project_id: string;
+  release_id?: string;
+  reason: string;
+  placed_by?: string;
+  placed_at: string;
+}
+
+interface ComplianceProductionRelease {
+  release: ReleaseRecord;
+  op_status?: string;
+  vulnerability_override?: VulnerabilityOverride | null;
+  freeze_override?: FreezeOverride | null;
+  approval?: ReleaseApproval | null;
+  notes: OpNote[];
+}
+
+interface ComplianceReport {
+  project_id: string;
+  name: string;
+  spec_hash: string;
+  generated_at: string;
+  ownership: ProjectOwnership;
+  environments: ComplianceEnvironment[];
+  last_production_release?: ComplianceProductionRelease | null;
+  violations: ComplianceViolation[];
+  holds: ProjectHoldsResponse;
+  audit: ComplianceAudit;
+}
+
+interface ComplianceViolation {
+  kind: string;
+  environment: string;
+  image?: string;
+  detail: string;
+}
+
+interface DeleteDependent {
+  project_id: string;
+  project_name: string;
+  environment?: string;
+  reference: string;
+  endpoint: string;
+}
+
+interface DeleteImpact {
+  live_releases: string[];
+  endpoints: string[];
+  dependents: DeleteDependent[];
+  credentials: string[];
+}
+
+interface DeletePlan {
+  id: string;
+  project_id: string;
+  created_at: string;
+  expires_at: string;
+  fingerprint: string;
+  repos: string[];
+  images: string[];
+  releases: string[];
+  cluster_resources: string[];
+  artifact_files: number;
+  blocked_by?: string;
+  impact: DeleteImpact;
+  requires_acknowledgement: boolean;
+}
+
+interface DeliveryLifecycle {
+  stage?: string;
+  environment?: string;
+  from_env?: string;
+  to_env?: string;
+  strategy?: string;
+  canary_weight?: number;
+  canary_action?: string;
+  blue_green_action?: string;
+}
+
+interface DeploymentEvent {
+  project_id: string;
+  environment?: string;
+  freeze_override?: FreezeOverrideRequest | null;
+}
+
+interface EnvConfig {
+  vars: Record<string, string>;
+  secrets?: Record<string, string>;
+  replicas?: number;
+  autoscaling?: AutoscalingConfig;
+  freeze?: FreezeWindow[];
+}
+
+interface EnvVarPatchOperation {
+  op: string;
+  name: string;
+  value?: string;
+  expected?: string | null;
+}
+
+interface EnvVarsPatchRequest {
+  operations: EnvVarPatchOperation[];
+}
+
+interface EnvVarsPatchResponse {
+  accepted: boolean;
+  unchanged?: boolean;
+  project: Project;
+  op?: Operation | null;
+  render_environment?: string;
+  effective_config: EnvironmentEffectiveConfigResponse;
+}
+
+interface EnvironmentBindingsResponse {
+  project_id: string;
+  environment: string;
+  bindings: CapabilityBinding[];
+  unbound: string[];
+}
+
+interface EnvironmentEffectiveConfigResponse {
+  project_id: string;
+  environment: string;
+  vars: Record<string, string>;
+  sources: Record<string, string>;
+  overridden: string[];
+}
+
+interface EnvironmentFreeze {
+  source: string;
+  name?: string;
+  reason?: string;
+  by?: string;
+  since: string;
+  until?: string;
+}
+
+interface EnvironmentFreezeResponse {
+  project_id: string;
+  environment: string;
+  frozen: boolean;
+  freezes: EnvironmentFreeze[];
+  unfreeze_at?: string;
+  windows: EnvironmentFreeze[];
+}
+
+interface EnvironmentHealth {
+  environment: string;
+  target: string;
+  status: string;
+  checked_at: string;
+  last_healthy_at?: string | null;
+  consecutive_failures: number;
+  availability_percent: number;
+  message?: string;
+  probes: HealthProbeResult[];
+  current: boolean;
+}
+
+interface EnvironmentScaling {
+  autoscaling: boolean;
+  replicas: number;
+  max_replicas?: number;
+  target_cpu_utilization?: number;
+}
+
+interface ExposureConfig {
+  serviceType?: string;
+  port?: number;
+  containerPort?: number;
+  ingress?: IngressConfig;
+}
+
+interface FreezeOverride {
+  environment: string;
+  justification: string;
+  by?: string;
+  freezes: EnvironmentFreeze[];
+  at: string;
+}
+
+interface FreezeOverrideRequest {
+  justification: string;
+  by?: string;
+}
+
+interface FreezeWindow {
+  start: string;
+  end: string;
+  reason?: string;
+}
+
+interface HealthProbeResult {
+  at: string;
+  ok: boolean;
+  error?: string;
+}
+
+interface HealthzResponse {
+  ok: boolean;
+  time: string;
+}
+
+interface HelmConfig {
+  enabled?: boolean;
+}
+
+interface HoldLiftedResponse {
+  lifted: boolean;
+  hold: ComplianceHold;
+}
+
+interface IngressConfig {
+  host?: string;
+  path?: string;
+  tls?: boolean;
+}
+
+interface LookupMatch {
+  project_id: string;
+  project_name: string;
+  environment: string;
+  release_id: string;
+  op_id: string;
+  op_kind: string;
+  image?: string;
+  source_commit?: string;
+  current: boolean;
+  created_at: string;
+}
+
+interface LookupResponse {
+  image?: string;
+  commit?: string;
+  matches: LookupMatch[];
+}
+
+interface ManualFreezeRequest {
+  reason: string;
+  by: string;
+}
+
+interface MetricsResponse {
+  store: StoreMetricsSnapshot;
+  workers: Record<string, WorkerQueueStats>;
+  time: string;
+}
+
+interface NetworkPolicies {
+  ingress: string;
+  egress: string;
+}
+
+interface OpAcceptedResponse {
+  accepted: boolean;
+  unchanged?: boolean;
+  project: Project;
+  op: Operation;
+  approval?: ReleaseApproval | null;
+  lint?: SpecLintWarning[];
+}
+
+interface OpCancelRequest {
+  reason?: string;
+}
+
+interface OpCancelResponse {
+  cancelled: boolean;
+  op: Operation;
+}
+
+interface OpExecution {
+  dry_run?: boolean;
+  trace?: boolean;
+}
+
+interface OpNote {
+  id: string;
+  author: string;
+  text: string;
+  created_at: string;
+}
+
+interface OpNoteCreatedResponse {
+  op_id: string;
+  note: OpNote;
+}
+
+interface OpNoteRequest {
+  author: string;
+  text: string;
+}
+
+interface OpNotesResponse {
+  op_id: string;
+  notes: OpNote[];
+}
+
+interface OpRemediation {
+  hook: string;
+  failure_code: string;
+  actor: string;
+  status: string;
+  message?: string;
+  actions?: RemediationAction[];
+  started_at: string;
+  finished_at?: string;
+}
+
+interface OpSLABreach {
+  state: string;
+  threshold: string;
+  elapsed: string;
+  at: string;
+}
+
+interface OpSchedule {
+  id: string;
+  project_id: string;
+  name: string;
+  cron: string;
+  timezone?: string;
+  kind: string;
+  environment?: string;
+  from_env?: string;
+  to_env?: string;
+  target_runtime?: string;
+  enabled: boolean;
+  created_by?: string;
+  next_run_at: string;
+  last_run?: ScheduleRun | null;
+  created_at: string;
+  updated_at: string;
+}
+
+interface OpScheduleRequest {
+  name: string;
+  cron: string;
+  timezone: string;
+  kind: string;
+  environment: string;
+  from_env: string;
+  to_env: string;
+  target_runtime: string;
+  enabled: boolean | null;
+}
+
+interface OpStep {
+  worker: string;
+  started_at: string;
+  ended_at: string;
+  message?: string;
+  error?: string;
+  artifacts?: string[];
+  compacted?: number;
+  heartbeat_at?: string;
+  progress?: string;
+  percent?: number;
+  substeps?: OpSubStep[];
+  plan?: string[];
+  attempt?: number;
+  prior_attempts?: OpStepAttempt[];
+}
+
+interface OpStepAttempt {
+  attempt: number;
+  started_at: string;
+  ended_at: string;
+  artifacts?: string[];
+}
+
+interface OpSubStep {
+  name: string;
+  started_at: string;
+  ended_at?: string;
+  error?: string;
+}
+
+interface Operation {
+  id: string;
+  kind: string;
+  project_id: string;
+  delivery?: DeliveryLifecycle;
+  execution?: OpExecution;
+  requested: string;
+  finished: string;
+  status: string;
+  error?: string;
+  steps: OpStep[];
+  vulnerability_override?: VulnerabilityOverride | null;
+  freeze_override?: FreezeOverride | null;
+  approval_id?: string;
+  notes?: OpNote[];
+  spec_hash?: string;
+  parent_op_id?: string;
+  rollout?: VarRollout | null;
+  fanout?: PromotionFanout | null;
+  spec_change?: SpecChange | null;
+  remediation?: OpRemediation | null;
+  remediation_of?: string;
+  schedule_id?: string;
+  clone_of?: string;
+  ci?: CIBuild | null;
+  upgrade?: RuntimeUpgrade | null;
+  webhook?: WebhookRefresh | null;
+  sla_breached?: boolean;
+  sla_breaches?: OpSLABreach[];
+}
+
+interface OrgDeletedResponse {
+  id: string;
+  deleted: boolean;
+}
+
+interface OrgListResponse {
+  orgs: OrgResponse[];
+}
+
+interface OrgProjectsResponse {
+  org: Organization;
+  projects: Project[];
+}
+
+interface OrgQuota {
+  max_projects?: number;
+  max_environments?: number;
+}
+
+interface OrgRequest {
+  id?: string;
+  name?: string;
+  workspaces?: string[];
+  quota?: OrgQuota;
+}
+
+interface OrgResponse {
+  org: Organization;
+  usage: OrgUsage;
+}
+
+interface OrgUsage {
+  projects: number;
+  environments: number;
+}
+
+interface Organization {
+  id: string;
+  name?: string;
+  workspaces?: string[];
+  quota?: OrgQuota;
+  created_at: string;
+  updated_at: string;
+}
+
+interface PlaceHoldRequest {
+  release_id: string;
+  reason: string;
+  placed_by: string;
+}
+
+interface Preview {
+  name: string;
+  branch: string;
+  environment: string;
+  path: string;
+  status: string;
+  commit?: string;
+  image?: string;
+  op_id: string;
+  error?: string;
+  created_at: string;
+  updated_at: string;
+  expires_at: string;
+}
+
+interface PreviewPolicy {
+  enabled?: boolean;
+  branches?: string[];
+  ttl?: string;
+}
+
+interface Project {
+  id: string;
+  created_at: string;
+  updated_at: string;
+  spec: ProjectSpec;
+  spec_hash?: string;
+  status: ProjectStatus;
+  ownership?: ProjectOwnership;
+  org?: string;
+  workspace?: string;
+}
+
+interface ProjectAtOpEnvState {
+  environment: string;
+  release?: ReleaseRecord | null;
+  image?: string;
+  rendered?: string;
+}
+
+interface ProjectAtOpResponse {
+  project_id: string;
+  op: Operation;
+  at: string;
+  spec?: ProjectSpec | null;
+  spec_revision?: number;
+  environments: ProjectAtOpEnvState[];
+  warnings: string[];
+}
+
+interface ProjectDeleteAcceptedResponse {
+  accepted: boolean;
+  deleted: boolean;
+  project_id: string;
+  op: Operation;
+}
+
+interface ProjectFilter {
+  name?: string;
+  name_prefix?: string;
+  phase?: string;
+  team?: string;
+  owner?: string;
+  environment?: string;
+  runtime?: string;
+  capability?: string;
+  org?: string;
+  workspace?: string;
+}
+
+interface ProjectGraph {
+  nodes: ProjectGraphNode[];
+  edges: ProjectGraphEdge[];
+}
+
+interface ProjectGraphEdge {
+  from: string;
+  to: string;
+}
+
+interface ProjectGraphNode {
+  id: string;
+  name: string;
+  phase: string;
+  needs?: string[];
+}
+
+interface ProjectHealthResponse {
+  project_id: string;
+  probing: boolean;
+  interval?: string;
+  environments: EnvironmentHealth[];
+}
+
+interface ProjectHoldsResponse {
+  project_id: string;
+  project?: ComplianceHold | null;
+  releases: ComplianceHold[];
+}
+
+interface ProjectJourney {
+  summary: string;
+  milestones: ProjectJourneyMilestone[];
+  environments: ProjectJourneyEnv[];
+  previews: Preview[];
+  next_action: ProjectJourneyNextAction;
+  artifact_stats: ProjectJourneyArtifactStat;
+  evidence: string[];
+  lint: SpecLintWarning[];
+  recent_operation?: Operation | null;
+  last_update_time: string;
+}
+
+interface ProjectJourneyArtifactStat {
+  total: number;
+  build: number;
+  deploy: number;
+  promotion: number;
+  release: number;
+  repository: number;
+  registration: number;
+  evidence: number;
+  other: number;
+}
+
+interface ProjectJourneyEnv {
+  name: string;
+  state: string;
+  image?: string;
+  image_source?: string;
+  delivery_type?: string;
+  delivery_path?: string;
+  detail: string;
+  scaling: EnvironmentScaling;
+}
+
+interface ProjectJourneyMilestone {
+  id: string;
+  title: string;
+  status: string;
+  detail: string;
+}
+
+interface ProjectJourneyNextAction {
+  kind: string;
+  label: string;
+  detail: string;
+  environment?: string;
+  from_env?: string;
+  to_env?: string;
+}
+
+interface ProjectJourneyResponse {
+  project: Project;
+  journey: ProjectJourney;
+}
+
+interface ProjectOpsListItem {
+  id: string;
+  project_id: string;
+  kind: string;
+  status: string;
+  requested: string;
+  finished: string;
+  error?: string;
+  summary_message?: string;
+  last_event_sequence: number;
+  last_update_at: string;
+  sla_breached?: boolean;
+}
+
+interface ProjectOpsListResponse {
+  items: ProjectOpsListItem[];
+  next_cursor?: string;
+}
+
+interface ProjectOverview {
+  summary: string;
+  ownership: ProjectOwnership;
+  environments: ProjectOverviewEnv[];
+  previews: Preview[];
+}
+
+interface ProjectOverviewEnv {
+  name: string;
+  health_status: string;
+  delivery_state: string;
+  running_image?: string;
+  delivery_type: string;
+  delivery_path?: string;
+  config_readiness: string;
+  secrets_readiness: string;
+  url: string;
+  url_scope: string;
+  scaling: EnvironmentScaling;
+  last_delivery_at?: string | null;
+}
+
+interface ProjectOverviewResponse {
+  project: Project;
+  overview: ProjectOverview;
+}
+
+interface ProjectOwner {
+  name: string;
+  email?: string;
+  chat?: string;
+}
+
+interface ProjectOwnership {
+  owners?: ProjectOwner[];
+  teams?: string[];
+  on_call?: string;
+  escalation?: string[];
+  updated_at?: string;
+}
+
+interface ProjectOwnershipRequest {
+  owners: ProjectOwner[];
+  teams: string[];
+  on_call: string;
+  escalation: string[];
+}
+
+interface ProjectOwnershipResponse {
+  project_id: string;
+  ownership: ProjectOwnership;
+}
+
+interface ProjectQuotaResponse {
+  project_id: string;
+  quotas: ProjectQuotaUsage[];
+}
+
+interface ProjectQuotaUsage {
+  name: string;
+  limit: number;
+  used: number;
+  exhausted: boolean;
+  resets_at?: string;
+}
+
+interface ProjectReleaseListResponse {
+  items: ReleaseRecord[];
+  next_cursor?: string;
+}
+
+interface ProjectRevisionSnapshot {
+  project_id: string;
+  token: string;
+  project: number;
+  latest_op: ProjectRevisionSnapshotOp;
+  releases: Record<string, number>;
+}
+
+interface ProjectRevisionSnapshotOp {
+  id?: string;
+  revision: number;
+}
+
+interface ProjectSchedulesResponse {
+  project_id: string;
+  schedules: OpSchedule[];
+}
+
+interface ProjectSpec {
+  apiVersion: string;
+  kind: string;
+  name: string;
+  runtime: string;
+  template?: string;
+  repos?: RepoRemotes;
+  ci?: CIPolicy;
+  build?: BuildConfig;
+  helm?: HelmConfig;
+  exposure?: ExposureConfig;
+  resources?: ResourceConfig;
+  capabilities?: string[];
+  needs?: string[];
+  vars?: Record<string, string>;
+  environments: Record<string, EnvConfig>;
+  networkPolicies: NetworkPolicies;
+  extensions?: Record<string, unknown>;
+  capabilityOptions?: Record<string, Record<string, string>>;
+}
+
+interface ProjectStatus {
+  phase: string;
+  updated_at: string;
+  last_op_id: string;
+  last_op_kind: string;
+  message?: string;
+}
+
+interface ProjectTemplate {
+  name: string;
+  description: string;
+  source: string;
+  spec: ProjectSpec;
+  files: string[];
+}
+
+interface ProjectView {
+  id: string;
+  name: string;
+  description?: string;
+  filter: ProjectFilter;
+  sort?: string;
+  created_by?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+interface PromotionEvent {
+  project_id: string;
+  from_env: string;
+  to_env: string;
+  to_envs?: string[];
+  vulnerability_override?: VulnerabilityOverrideRequest | null;
+  freeze_override?: FreezeOverrideRequest | null;
+  strategy?: string;
+  weight?: number;
+}
+
+interface PromotionFanout {
+  from_env: string;
+  outcome?: string;
+  targets: PromotionFanoutTarget[];
+}
+
+interface PromotionFanoutTarget {
+  environment: string;
+  op_kind: string;
+  op_id?: string;
+  status: string;
+  error?: string;
+}
+
+interface PromotionPlanHop {
+  from_env: string;
+  to_env: string;
+  action: string;
+  status: string;
+  simulated: boolean;
+  source_release?: TransitionPreviewRelease | null;
+  target_release?: TransitionPreviewRelease | null;
+  change_summary: string;
+  gates: TransitionPreviewGate[];
+  blockers: TransitionPreviewBlocker[];
+}
+
+interface PromotionPlanResponse {
+  project_id: string;
+  image: string;
+  chain: string[];
+  ready: boolean;
+  blocked_at?: string;
+  summary: string;
+  hops: PromotionPlanHop[];
+}
+
+interface PromotionPreviewResponse {
+  action: string;
+  source_release?: TransitionPreviewRelease | null;
+  target_release?: TransitionPreviewRelease | null;
+  change_summary: string;
+  gates: TransitionPreviewGate[];
+  blockers: TransitionPreviewBlocker[];
+  rollout_plan: string[];
+  traffic_split?: CanaryRollout | null;
+}
+
+interface ReadOnlyRequest {
+  enabled: boolean;
+  message?: string;
+}
+
+interface ReadOnlyState {
+  enabled: boolean;
+  message?: string;
+  since?: string;
+  by?: string;
+}
+
+interface RegistrationEvent {
+  action: string;
+  project_id?: string;
+  plan_id?: string;
+  acknowledge_impact?: boolean;
+  spec: ProjectSpec;
+}
+
+interface ReleaseApproval {
+  id: string;
+  project_id: string;
+  from_env: string;
+  to_env: string;
+  image: string;
+  required: number;
+  status: string;
+  requested_by?: string;
+  decisions: ApprovalDecision[];
+  vulnerability_override?: VulnerabilityOverride | null;
+  freeze_override?: FreezeOverride | null;
+  op_id?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+interface ReleaseCompareDelta {
+  changed: boolean;
+  from?: string;
+  to?: string;
+  added?: string[];
+  removed?: string[];
+  updated?: string[];
+}
+
+interface ReleaseCompareResponse {
+  from_id: string;
+  to_id: string;
+  from_release?: ReleaseRecord | null;
+  to_release?: ReleaseRecord | null;
+  summary: string;
+  image_delta: ReleaseCompareDelta;
+  config_delta: ReleaseCompareDelta;
+  rendered_delta: ReleaseCompareDelta;
+  spec_delta: ReleaseCompareDelta;
+  notes?: ReleaseNotes | null;
+}
+
+interface ReleaseCutoverEvent {
+  project_id: string;
+  env?: string;
+  rollback?: boolean;
+  freeze_override?: FreezeOverrideRequest | null;
+}
+
+interface ReleaseDetailResponse {
+  id: string;
+  project_id: string;
+  environment: string;
+  op_id: string;
+  op_kind: string;
+  delivery_stage: string;
+  from_env?: string;
+  to_env?: string;
+  image?: string;
+  rendered_path?: string;
+  config_path?: string;
+  rollback_safe?: boolean | null;
+  rollback_source_release?: string;
+  rollback_scope?: string;
+  source_commit?: string;
+  spec_hash?: string;
+  created_at: string;
+  notes?: ReleaseNotes | null;
+  evidence?: ArtifactFileInfo[];
+  hold?: ComplianceHold | null;
+}
+
+interface ReleaseEvent {
+  project_id: string;
+  from_env: string;
+  to_env?: string;
+  vulnerability_override?: VulnerabilityOverrideRequest | null;
+  freeze_override?: FreezeOverrideRequest | null;
+  strategy?: string;
+  weight?: number;
+}
+
+interface ReleaseNoteCommit {
+  hash: string;
+  scope?: string;
+  subject: string;
+  breaking?: boolean;
+}
+
+interface ReleaseNotes {
+  from_commit?: string;
+  to_commit: string;
+  sections: ReleaseNotesSection[];
+  truncated?: boolean;
+  text: string;
+  edited_by?: string;
+  edited_at?: string;
+  previous_release_id?: string;
+  image?: ReleaseCompareDelta | null;
+  config?: ReleaseCompareDelta | null;
+  path?: string;
+}
+
+interface ReleaseNotesRequest {
+  text: string;
+}
+
+interface ReleaseNotesSection {
+  type: string;
+  title: string;
+  commits: ReleaseNoteCommit[];
+}
+
+interface ReleaseRecord {
+  id: string;
+  project_id: string;
+  environment: string;
+  op_id: string;
+  op_kind: string;
+  delivery_stage: string;
+  from_env?: string;
+  to_env?: string;
+  image?: string;
+  rendered_path?: string;
+  config_path?: string;
+  rollback_safe?: boolean | null;
+  rollback_source_release?: string;
+  rollback_scope?: string;
+  source_commit?: string;
+  spec_hash?: string;
+  created_at: string;
+  notes?: ReleaseNotes | null;
+  evidence?: ArtifactFileInfo[];
+}
+
+interface RemediationAction {
+  action: string;
+  op_id?: string;
+  status: string;
+  message?: string;
+  at: string;
+}
+
+interface RemoteRepo {
+  url?: string;
+  credentialsRef?: string;
+}
+
+interface RepoRemotes {
+  source?: RemoteRepo;
+  manifests?: RemoteRepo;
+}
+
+interface ResourceAmounts {
+  cpu?: string;
+  memory?: string;
+}
+
+interface ResourceConfig {
+  requests?: ResourceAmounts;
+  limits?: ResourceAmounts;
+}
+
+interface RollbackEvent {
+  project_id: string;
+  environment: string;
+  release_id: string;
+  scope: string;
+  override?: boolean;
+}
+
+interface RollbackPreviewResponse {
+  project_id: string;
+  environment: string;
+  release_id: string;
+  scope: string;
+  override?: boolean;
+  ready: boolean;
+  source_release?: TransitionPreviewRelease | null;
+  current_release?: TransitionPreviewRelease | null;
+  compare?: ReleaseCompareResponse | null;
+  gates: TransitionPreviewGate[];
+  blockers: TransitionPreviewBlocker[];
+}
+
+interface RuntimeConfigResponse {
+  file?: string;
+  http_addr: string;
+  artifacts_root: string;
+  kv_project_history: number;
+  kv_ops_history: number;
+  git_timeout: string;
+  git_read_timeout: string;
+  shutdown_timeout: string;
+  op_stale_ttl: string;
+  op_sla_queued: string;
+  op_sla_running: string;
+  subject_prefix: string;
+  env: Record<string, string>;
+}
+
+interface RuntimeUpgrade {
+  from: string;
+  to: string;
+  branch: string;
+  commit?: string;
+  changes?: string[];
+  image_tag?: string;
+  build?: string;
+  report?: string;
+}
+
+interface RuntimeUpgradeAcceptedResponse {
+  accepted: boolean;
+  op: Operation;
+  project_id: string;
+  branch: string;
+}
+
+interface RuntimeUpgradeRequest {
+  target_runtime: string;
+}
+
+interface ScheduleDeletedResponse {
+  deleted: boolean;
+  schedule: OpSchedule;
+}
+
+interface ScheduleRun {
+  at: string;
+  status: string;
+  op_id?: string;
+  message?: string;
+}
+
+interface SecretKeyRef {
+  name: string;
+  key: string;
+}
+
+interface SourceRepoWebhookEvent {
+  project_id: string;
+  repo?: string;
+  branch?: string;
+  ref?: string;
+  commit?: string;
+  deleted?: boolean;
+}
+
+interface SourceWebhookResponse {
+  accepted: boolean;
+  reason: string;
+  trigger: string;
+  project: string;
+  op: Operation | null;
+  commit: string;
+}
+
+interface SpecChange {
+  classes: string[];
+  stages: string[];
+  skipped?: string[];
+  image?: string;
+}
+
+interface SpecLintWarning {
+  rule: string;
+  field: string;
+  message: string;
+}
+
+interface SpecValidationArtifact {
+  path: string;
+  content: string;
+}
+
+interface SpecValidationIssue {
+  field: string;
+  message: string;
+}
+
+interface SpecValidationResponse {
+  valid: boolean;
+  spec: ProjectSpec;
+  spec_hash?: string;
+  image?: string;
+  errors: SpecValidationIssue[];
+  warnings: SpecValidationIssue[];
+  lint: SpecLintWarning[];
+  artifacts: SpecValidationArtifact[];
+}
+
+interface StoreMethodStats {
+  calls: number;
+  slow_calls: number;
+  total_ms: number;
+  avg_ms: number;
+  max_ms: number;
+}
+
+interface StoreMetricsSnapshot {
+  slow_threshold: string;
+  methods: Record<string, StoreMethodStats>;
+}
+
+interface StoredSecretView {
+  name: string;
+  value: string;
+  updated_at: string;
+}
+
+interface StoredSecretsDeletedResponse {
+  project_id: string;
+  environment: string;
+  removed: string[];
+}
+
+interface StoredSecretsRequest {
+  secrets: Record<string, string>;
+}
+
+interface StoredSecretsResponse {
+  project_id: string;
+  environment: string;
+  secrets: StoredSecretView[];
+}
+
+interface SystemStatusNATSSummary {
+  embedded: boolean;
+  url?: string;
+  store_dir?: string;
+  store_dir_mode: string;
+  auth?: string;
+}
+
+interface SystemStatusRealtimeSummary {
+  sse_enabled: boolean;
+  sse_replay_window: number;
+  sse_heartbeat_interval: string;
+}
+
+interface SystemStatusResponse {
+  version?: string;
+  http_addr: string;
+  artifacts_root: string;
+  artifact_roots?: Record<string, string>;
+  builder_mode_requested: string;
+  builder_mode_effective: string;
+  builder_mode_reason?: string;
+  commit_watcher_enabled: boolean;
+  nats: SystemStatusNATSSummary;
+  realtime: SystemStatusRealtimeSummary;
+  read_only: ReadOnlyState;
+  time: string;
+}
+
+interface TransitionPreviewBlocker {
+  code: string;
+  message: string;
+  why: string;
+  next_action: string;
+}
+
+interface TransitionPreviewGate {
+  code: string;
+  title: string;
+  status: string;
+  detail?: string;
+}
+
+interface TransitionPreviewRelease {
+  id: string;
+  environment: string;
+  image?: string;
+  op_kind?: string;
+  delivery_stage?: string;
+  created_at: string;
+}
+
+interface VarRollout {
+  set?: Record<string, string>;
+  unset?: string[];
+  pause_seconds: number;
+  stop_on_failure: boolean;
+  stages: VarRolloutStage[];
+}
+
+interface VarRolloutRequest {
+  set?: Record<string, string>;
+  unset?: string[];
+  environments?: string[];
+  pause_seconds?: number;
+  stop_on_failure?: boolean | null;
+}
+
+interface VarRolloutStage {
+  environment: string;
+  from_env?: string;
+  op_kind: string;
+  op_id?: string;
+  status: string;
+  health?: string;
+  error?: string;
+}
+
+interface ViewDeletedResponse {
+  id: string;
+  deleted: boolean;
+}
+
+interface ViewListResponse {
+  views: ProjectView[];
+}
+
+interface ViewProjectsResponse {
+  view: ProjectView;
+  projects: Project[];
+}
+
+interface ViewRequest {
+  name: string;
+  description?: string;
+  filter: ProjectFilter;
+  sort?: string;
+}
+
+interface VulnerabilityOverride {
+  justification: string;
+  by?: string;
+  exceeded: string[];
+  summary: VulnerabilitySummary;
+  at: string;
+}
+
+interface VulnerabilityOverrideRequest {
+  justification: string;
+  by?: string;
+}
+
+interface VulnerabilitySummary {
+  image: string;
+  critical: number;
+  high: number;
+  medium: number;
+  low: number;
+  unknown: number;
+}
+
+interface WebhookRefresh {
+  from: string;
+  to: string;
+  commit?: string;
+}
+
+interface WorkerQueueStats {
+  limit: number;
+  running: number;
+  waiting: number;
+  pending: number;
+}
+
+interface WorkerReadinessStatus {
+  ready: boolean;
+  mode: string;
+  missing: string[];
+  workers: Record<string, string>;
+}
+
+interface ApiClient {
+  /** Remove the canary and keep the stable image (POST /api/projects/{id}/environments/{env}/canary/abort) */
+  abortCanary(id: string, env: string, body: CanaryActionRequest, query?: { dry_run?: string | number; trace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Add a note to an operation (POST /api/ops/{id}/notes) */
+  addOpNote(id: string, body: OpNoteRequest): Promise<OpNoteCreatedResponse>;
+  /** Approve a release; the last approval needed queues it (POST /api/approvals/{id}/approve) */
+  approveRelease(id: string, body: ApprovalDecisionRequest): Promise<ApprovalDecisionResponse>;
+  /** Cancel a queued or running operation (POST /api/ops/{id}/cancel) */
+  cancelOp(id: string, body: OpCancelRequest): Promise<OpCancelResponse>;
+  /** Remove artifacts under a prefix (DELETE /api/projects/{id}/artifacts) */
+  cleanupProjectArtifacts(id: string, query?: { prefix?: string | number; dry_run?: string | number; trace?: string | number }): Promise<ArtifactCleanupAcceptedResponse>;
+  /** Create a project from another's spec and source repo (POST /api/projects/{id}/clone) */
+  cloneProject(id: string, body: ProjectSpec, query?: { trace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Compare two releases (GET /api/projects/{id}/releases/compare) */
+  compareProjectReleases(id: string, query?: { from?: string | number; to?: string | number }): Promise<ReleaseCompareResponse>;
+  /** Create an organization (POST /api/orgs) */
+  createOrg(body: OrgRequest): Promise<Organization>;
+  /** Create a project (POST /api/projects) */
+  createProject(body: ProjectSpec, query?: { trace?: string | number; org?: string | number; workspace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Plan a project delete (POST /api/projects/{id}/delete-plan) */
+  createProjectDeletePlan(id: string): Promise<DeletePlan>;
+  /** Create a project from a template (POST /api/projects/from-template/{name}) */
+  createProjectFromTemplate(name: string, body: ProjectSpec, query?: { trace?: string | number; org?: string | number; workspace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Run an op on a cron schedule (POST /api/projects/{id}/schedules) */
+  createProjectSchedule(id: string, body: OpScheduleRequest): Promise<OpSchedule>;
+  /** Create an API token (its value is shown once) (POST /api/tokens) */
+  createToken(body: ApiTokenRequest): Promise<ApiTokenCreatedResponse>;
+  /** Save a project view (POST /api/views) */
+  createView(body: ViewRequest): Promise<ProjectView>;
+  /** Remove a capability binding (DELETE /api/projects/{id}/environments/{env}/bindings/{capability}) */
+  deleteEnvironmentBinding(id: string, env: string, capability: string): Promise<BindingDeletedResponse>;
+  /** Delete an organization without projects (DELETE /api/orgs/{id}) */
+  deleteOrg(id: string): Promise<OrgDeletedResponse>;
+  /** Delete a project (applies a delete plan) (DELETE /api/projects/{id}) */
+  deleteProject(id: string, query?: { plan_id?: string | number; acknowledge_impact?: string | number; dry_run?: string | number; trace?: string | number }): Promise<ProjectDeleteAcceptedResponse>;
+  /** Remove an op schedule (DELETE /api/projects/{id}/schedules/{scheduleID}) */
+  deleteProjectSchedule(id: string, scheduleID: string): Promise<ScheduleDeletedResponse>;
+  /** Remove stored secrets (DELETE /api/projects/{id}/secrets/{env}) */
+  deleteProjectSecrets(id: string, env: string, query?: { name?: string | number }): Promise<StoredSecretsDeletedResponse>;
+  /** Delete a saved project view (DELETE /api/views/{id}) */
+  deleteView(id: string): Promise<ViewDeletedResponse>;
+  /** Place a manual freeze (PUT /api/projects/{id}/environments/{env}/freeze) */
+  freezeEnvironment(id: string, env: string, body: ManualFreezeRequest): Promise<EnvironmentFreezeResponse>;
+  /** Get a release approval (GET /api/approvals/{id}) */
+  getApproval(id: string): Promise<ReleaseApproval>;
+  /** Resolved runtime config, secrets redacted (GET /api/config) */
+  getConfig(): Promise<RuntimeConfigResponse>;
+  /** Get a capability binding (GET /api/projects/{id}/environments/{env}/bindings/{capability}) */
+  getEnvironmentBinding(id: string, env: string, capability: string): Promise<CapabilityBinding>;
+  /** A blue/green environment's colors (GET /api/projects/{id}/environments/{env}/blue-green) */
+  getEnvironmentBlueGreen(id: string, env: string): Promise<BlueGreenRelease>;
+  /** The canary running in an environment (GET /api/projects/{id}/environments/{env}/canary) */
+  getEnvironmentCanary(id: string, env: string): Promise<CanaryRollout>;
+  /** Resolved environment vars (GET /api/projects/{id}/environments/{env}/effective-config) */
+  getEnvironmentEffectiveConfig(id: string, env: string): Promise<EnvironmentEffectiveConfigResponse>;
+  /** Freezes in force or scheduled (GET /api/projects/{id}/environments/{env}/freeze) */
+  getEnvironmentFreeze(id: string, env: string): Promise<EnvironmentFreezeResponse>;
+  /** Project dependency graph (GET /api/graph) */
+  getGraph(): Promise<ProjectGraph>;
+  /** Liveness probe (GET /api/healthz) */
+  getHealthz(): Promise<HealthzResponse>;
+  /** In-process counters (GET /api/metrics) */
+  getMetrics(): Promise<MetricsResponse>;
+  /** Get an operation (GET /api/ops/{id}) */
+  getOp(id: string): Promise<Operation>;
+  /** Get an organization and its usage (GET /api/orgs/{id}) */
+  getOrg(id: string): Promise<OrgResponse>;
+  /** Get a project (GET /api/projects/{id}) */
+  getProject(id: string): Promise<Project>;
+  /** Project state right after an op (GET /api/projects/{id}/at) */
+  getProjectAtOp(id: string, query?: { op?: string | number }): Promise<ProjectAtOpResponse>;
+  /** Project compliance report (GET /api/projects/{id}/compliance) */
+  getProjectCompliance(id: string): Promise<ComplianceReport>;
+  /** Pending delete plan (GET /api/projects/{id}/delete-plan) */
+  getProjectDeletePlan(id: string): Promise<DeletePlan>;
+  /** Probed health and availability per environment (GET /api/projects/{id}/health) */
+  getProjectHealth(id: string): Promise<ProjectHealthResponse>;
+  /** Project journey read model (GET /api/projects/{id}/journey) */
+  getProjectJourney(id: string): Promise<ProjectJourneyResponse>;
+  /** Project overview read model (GET /api/projects/{id}/overview) */
+  getProjectOverview(id: string): Promise<ProjectOverviewResponse>;
+  /** Get project ownership (GET /api/projects/{id}/ownership) */
+  getProjectOwnership(id: string): Promise<ProjectOwnershipResponse>;
+  /** Simulate promoting dev's image through every environment (GET /api/projects/{id}/promotion-plan) */
+  getProjectPromotionPlan(id: string): Promise<PromotionPlanResponse>;
+  /** Project quota usage (GET /api/projects/{id}/quota) */
+  getProjectQuota(id: string): Promise<ProjectQuotaResponse>;
+  /** Get a release (GET /api/projects/{id}/releases/{release_id}) */
+  getProjectRelease(id: string, releaseId: string): Promise<ReleaseDetailResponse>;
+  /** A release's notes (format=markdown for the notes document) (GET /api/projects/{id}/releases/{release_id}/notes) */
+  getProjectReleaseNotes(id: string, releaseId: string, query?: { format?: string | number }): Promise<ReleaseNotes>;
+  /** KV revisions behind the project's views, for staleness checks (GET /api/projects/{id}/revision) */
+  getProjectRevision(id: string): Promise<ProjectRevisionSnapshot>;
+  /** Get an op schedule and its last run (GET /api/projects/{id}/schedules/{scheduleID}) */
+  getProjectSchedule(id: string, scheduleID: string): Promise<OpSchedule>;
+  /** Read-only maintenance mode (GET /api/admin/readonly) */
+  getReadOnly(): Promise<ReadOnlyState>;
+  /** Worker readiness probe (GET /api/readyz) */
+  getReadyz(): Promise<WorkerReadinessStatus>;
+  /** Runtime capability and transport status (GET /api/system) */
+  getSystem(): Promise<SystemStatusResponse>;
+  /** Get a saved project view (GET /api/views/{id}) */
+  getView(id: string): Promise<ProjectView>;
+  /** Lift a compliance hold (DELETE /api/projects/{id}/holds) */
+  liftProjectHold(id: string, query?: { release_id?: string | number; lifted_by?: string | number }): Promise<HoldLiftedResponse>;
+  /** List release approvals (GET /api/approvals) */
+  listApprovals(query?: { project_id?: string | number; status?: string | number }): Promise<ReleaseApproval[]>;
+  /** List capability plugins (GET /api/capabilities) */
+  listCapabilities(): Promise<CapabilityInfo[]>;
+  /** List capability bindings (GET /api/projects/{id}/environments/{env}/bindings) */
+  listEnvironmentBindings(id: string, env: string): Promise<EnvironmentBindingsResponse>;
+  /** List notes on an operation (GET /api/ops/{id}/notes) */
+  listOpNotes(id: string): Promise<OpNotesResponse>;
+  /** List operations across projects (GET /api/ops) */
+  listOps(query?: { project_id?: string | number; kind?: string | number; status?: string | number; since?: string | number; until?: string | number; limit?: string | number; cursor?: string | number }): Promise<ProjectOpsListResponse>;
+  /** List an organization's projects (GET /api/orgs/{id}/projects) */
+  listOrgProjects(id: string, query?: { name?: string | number; name_prefix?: string | number; phase?: string | number; team?: string | number; owner?: string | number; environment?: string | number; runtime?: string | number; capability?: string | number; workspace?: string | number; sort?: string | number }): Promise<OrgProjectsResponse>;
+  /** List organizations with their usage (GET /api/orgs) */
+  listOrgs(): Promise<OrgListResponse>;
+  /** List artifact files (GET /api/projects/{id}/artifacts) */
+  listProjectArtifacts(id: string): Promise<ArtifactListResponse>;
+  /** List compliance holds (GET /api/projects/{id}/holds) */
+  listProjectHolds(id: string): Promise<ProjectHoldsResponse>;
+  /** Project operation history (GET /api/projects/{id}/ops) */
+  listProjectOps(id: string, query?: { limit?: string | number; cursor?: string | number; before?: string | number }): Promise<ProjectOpsListResponse>;
+  /** Environment release timeline (GET /api/projects/{id}/releases) */
+  listProjectReleases(id: string, query?: { environment?: string | number; limit?: string | number; cursor?: string | number }): Promise<ProjectReleaseListResponse>;
+  /** List op schedules (GET /api/projects/{id}/schedules) */
+  listProjectSchedules(id: string): Promise<ProjectSchedulesResponse>;
+  /** List stored secrets, values masked (GET /api/projects/{id}/secrets/{env}) */
+  listProjectSecrets(id: string, env: string): Promise<StoredSecretsResponse>;
+  /** List projects (GET /api/projects) */
+  listProjects(query?: { name?: string | number; name_prefix?: string | number; phase?: string | number; team?: string | number; owner?: string | number; environment?: string | number; runtime?: string | number; capability?: string | number; org?: string | number; workspace?: string | number; sort?: string | number; limit?: string | number; cursor?: string | number }): Promise<Project[]>;
+  /** List project templates (GET /api/templates) */
+  listTemplates(): Promise<ProjectTemplate[]>;
+  /** List API tokens (GET /api/tokens) */
+  listTokens(): Promise<ApiTokenListResponse>;
+  /** Projects a saved view selects (GET /api/views/{id}/projects) */
+  listViewProjects(id: string): Promise<ViewProjectsResponse>;
+  /** List saved project views (GET /api/views) */
+  listViews(): Promise<ViewListResponse>;
+  /** Find releases by image or commit (GET /api/lookup) */
+  lookup(query?: { image?: string | number; commit?: string | number }): Promise<LookupResponse>;
+  /** Add, update, or remove environment vars and re-render (PATCH /api/projects/{id}/environments/{env}/vars) */
+  patchEnvironmentVars(id: string, env: string, body: EnvVarsPatchRequest, query?: { dry_run?: string | number; trace?: string | number }): Promise<EnvVarsPatchResponse>;
+  /** Place a compliance hold (POST /api/projects/{id}/holds) */
+  placeProjectHold(id: string, body: PlaceHoldRequest): Promise<ComplianceHold>;
+  /** Deploy to dev (POST /api/events/deployment) */
+  postDeploymentEvent(body: DeploymentEvent, query?: { dry_run?: string | number; trace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Promote between environments (POST /api/events/promotion) */
+  postPromotionEvent(body: PromotionEvent, query?: { dry_run?: string | number; trace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Registration event (POST /api/events/registration) */
+  postRegistrationEvent(body: RegistrationEvent, query?: { org?: string | number; workspace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Switch blue/green traffic to the staged color, or back (POST /api/events/release/cutover) */
+  postReleaseCutoverEvent(body: ReleaseCutoverEvent, query?: { dry_run?: string | number; trace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Release to production (POST /api/events/release) */
+  postReleaseEvent(body: ReleaseEvent, query?: { dry_run?: string | number; trace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Roll back to a release (POST /api/events/rollback) */
+  postRollbackEvent(body: RollbackEvent, query?: { dry_run?: string | number; trace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Source repo webhook (POST /api/webhooks/source) */
+  postSourceWebhook(body: SourceRepoWebhookEvent): Promise<SourceWebhookResponse>;
+  /** Preview a promotion (POST /api/events/promotion/preview) */
+  previewPromotion(body: PromotionEvent): Promise<PromotionPreviewResponse>;
+  /** Preview a rollback (POST /api/events/rollback/preview) */
+  previewRollback(body: RollbackEvent): Promise<RollbackPreviewResponse>;
+  /** Roll the canary image out to the whole environment (POST /api/projects/{id}/environments/{env}/canary/promote) */
+  promoteCanary(id: string, env: string, body: CanaryActionRequest, query?: { dry_run?: string | number; trace?: string | number }): Promise<OpAcceptedResponse>;
+  /** Bind a capability in an environment (PUT /api/projects/{id}/environments/{env}/bindings/{capability}) */
+  putEnvironmentBinding(id: string, env: string, capability: string, body: CapabilityBindingRequest): Promise<CapabilityBinding>;
+  /** Store encrypted secret values (POST /api/projects/{id}/secrets/{env}) */
+  putProjectSecrets(id: string, env: string, body: StoredSecretsRequest): Promise<StoredSecretsResponse>;
+  /** Reject a release (POST /api/approvals/{id}/reject) */
+  rejectRelease(id: string, body: ApprovalDecisionRequest): Promise<ApprovalDecisionResponse>;
+  /** Revoke an API token (DELETE /api/tokens/{id}) */
+  revokeToken(id: string): Promise<ApiTokenRevokedResponse>;
+  /** Replace project ownership (PUT /api/projects/{id}/ownership) */
+  setProjectOwnership(id: string, body: ProjectOwnershipRequest): Promise<ProjectOwnershipResponse>;
+  /** Turn read-only maintenance mode on or off (POST /api/admin/readonly) */
+  setReadOnly(body: ReadOnlyRequest): Promise<ReadOnlyState>;
+  /** Trial a runtime upgrade on a branch (POST /api/projects/{id}/runtime-upgrade) */
+  startRuntimeUpgrade(id: string, body: RuntimeUpgradeRequest, query?: { dry_run?: string | number; trace?: string | number }): Promise<RuntimeUpgradeAcceptedResponse>;
+  /** Roll a var change out to environments in order (POST /api/projects/{id}/var-rollout) */
+  startVarRollout(id: string, body: VarRolloutRequest): Promise<OpAcceptedResponse>;
+  /** An artifact's size, mtime, sha256 and content type (requires stat=1) (GET /api/projects/{id}/artifacts/{path}) */
+  statProjectArtifact(id: string, path: string, query?: { stat?: string | number }): Promise<ArtifactStatResponse>;
+  /** Lift the manual freeze (DELETE /api/projects/{id}/environments/{env}/freeze) */
+  unfreezeEnvironment(id: string, env: string, query?: { lifted_by?: string | number }): Promise<EnvironmentFreezeResponse>;
+  /** Replace an organization's workspaces and quota (PUT /api/orgs/{id}) */
+  updateOrg(id: string, body: OrgRequest): Promise<Organization>;
+  /** Replace a project spec (PUT /api/projects/{id}) */
+  updateProject(id: string, body: ProjectSpec, query?: { dry_run?: string | number; trace?: string | number; force?: string | number }): Promise<OpAcceptedResponse>;
+  /** Replace a release's notes text (PUT /api/projects/{id}/releases/{release_id}/notes) */
+  updateProjectReleaseNotes(id: string, releaseId: string, body: ReleaseNotesRequest): Promise<ReleaseDetailResponse>;
+  /** Replace an op schedule's settings (PUT /api/projects/{id}/schedules/{scheduleID}) */
+  updateProjectSchedule(id: string, scheduleID: string, body: OpScheduleRequest): Promise<OpSchedule>;
+  /** Replace a saved project view (PUT /api/views/{id}) */
+  updateView(id: string, body: ViewRequest): Promise<ProjectView>;
+  /** Validate a spec and render its artifacts without storing anything (POST /api/projects/validate) */
+  validateProjectSpec(body: ProjectSpec): Promise<SpecValidationResponse>;
+}
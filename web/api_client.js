@@ -0,0 +1,318 @@
+// Code generated from /api/openapi.json by api_tsclient.go; DO NOT EDIT.
+// @ts-check
+/// <reference path="./api_client.d.ts" />
+
+/** @type {ApiClient} */
+const apiClient = {
+  abortCanary(id, env, body, query) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/canary/abort${apiClientQuery(query)}`, body);
+  },
+  addOpNote(id, body) {
+    return requestAPI("POST", `/api/ops/${encodeURIComponent(id)}/notes`, body);
+  },
+  approveRelease(id, body) {
+    return requestAPI("POST", `/api/approvals/${encodeURIComponent(id)}/approve`, body);
+  },
+  cancelOp(id, body) {
+    return requestAPI("POST", `/api/ops/${encodeURIComponent(id)}/cancel`, body);
+  },
+  cleanupProjectArtifacts(id, query) {
+    return requestAPI("DELETE", `/api/projects/${encodeURIComponent(id)}/artifacts${apiClientQuery(query)}`);
+  },
+  cloneProject(id, body, query) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/clone${apiClientQuery(query)}`, body);
+  },
+  compareProjectReleases(id, query) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/releases/compare${apiClientQuery(query)}`);
+  },
+  createOrg(body) {
+    return requestAPI("POST", "/api/orgs", body);
+  },
+  createProject(body, query) {
+    return requestAPI("POST", `/api/projects${apiClientQuery(query)}`, body);
+  },
+  createProjectDeletePlan(id) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/delete-plan`);
+  },
+  createProjectFromTemplate(name, body, query) {
+    return requestAPI("POST", `/api/projects/from-template/${encodeURIComponent(name)}${apiClientQuery(query)}`, body);
+  },
+  createProjectSchedule(id, body) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/schedules`, body);
+  },
+  createToken(body) {
+    return requestAPI("POST", "/api/tokens", body);
+  },
+  createView(body) {
+    return requestAPI("POST", "/api/views", body);
+  },
+  deleteEnvironmentBinding(id, env, capability) {
+    return requestAPI("DELETE", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/bindings/${encodeURIComponent(capability)}`);
+  },
+  deleteOrg(id) {
+    return requestAPI("DELETE", `/api/orgs/${encodeURIComponent(id)}`);
+  },
+  deleteProject(id, query) {
+    return requestAPI("DELETE", `/api/projects/${encodeURIComponent(id)}${apiClientQuery(query)}`);
+  },
+  deleteProjectSchedule(id, scheduleID) {
+    return requestAPI("DELETE", `/api/projects/${encodeURIComponent(id)}/schedules/${encodeURIComponent(scheduleID)}`);
+  },
+  deleteProjectSecrets(id, env, query) {
+    return requestAPI("DELETE", `/api/projects/${encodeURIComponent(id)}/secrets/${encodeURIComponent(env)}${apiClientQuery(query)}`);
+  },
+  deleteView(id) {
+    return requestAPI("DELETE", `/api/views/${encodeURIComponent(id)}`);
+  },
+  freezeEnvironment(id, env, body) {
+    return requestAPI("PUT", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/freeze`, body);
+  },
+  getApproval(id) {
+    return requestAPI("GET", `/api/approvals/${encodeURIComponent(id)}`);
+  },
+  getConfig() {
+    return requestAPI("GET", "/api/config");
+  },
+  getEnvironmentBinding(id, env, capability) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/bindings/${encodeURIComponent(capability)}`);
+  },
+  getEnvironmentBlueGreen(id, env) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/blue-green`);
+  },
+  getEnvironmentCanary(id, env) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/canary`);
+  },
+  getEnvironmentEffectiveConfig(id, env) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/effective-config`);
+  },
+  getEnvironmentFreeze(id, env) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/freeze`);
+  },
+  getGraph() {
+    return requestAPI("GET", "/api/graph");
+  },
+  getHealthz() {
+    return requestAPI("GET", "/api/healthz");
+  },
+  getMetrics() {
+    return requestAPI("GET", "/api/metrics");
+  },
+  getOp(id) {
+    return requestAPI("GET", `/api/ops/${encodeURIComponent(id)}`);
+  },
+  getOrg(id) {
+    return requestAPI("GET", `/api/orgs/${encodeURIComponent(id)}`);
+  },
+  getProject(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}`);
+  },
+  getProjectAtOp(id, query) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/at${apiClientQuery(query)}`);
+  },
+  getProjectCompliance(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/compliance`);
+  },
+  getProjectDeletePlan(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/delete-plan`);
+  },
+  getProjectHealth(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/health`);
+  },
+  getProjectJourney(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/journey`);
+  },
+  getProjectOverview(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/overview`);
+  },
+  getProjectOwnership(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/ownership`);
+  },
+  getProjectPromotionPlan(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/promotion-plan`);
+  },
+  getProjectQuota(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/quota`);
+  },
+  getProjectRelease(id, releaseId) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/releases/${encodeURIComponent(releaseId)}`);
+  },
+  getProjectReleaseNotes(id, releaseId, query) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/releases/${encodeURIComponent(releaseId)}/notes${apiClientQuery(query)}`);
+  },
+  getProjectRevision(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/revision`);
+  },
+  getProjectSchedule(id, scheduleID) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/schedules/${encodeURIComponent(scheduleID)}`);
+  },
+  getReadOnly() {
+    return requestAPI("GET", "/api/admin/readonly");
+  },
+  getReadyz() {
+    return requestAPI("GET", "/api/readyz");
+  },
+  getSystem() {
+    return requestAPI("GET", "/api/system");
+  },
+  getView(id) {
+    return requestAPI("GET", `/api/views/${encodeURIComponent(id)}`);
+  },
+  liftProjectHold(id, query) {
+    return requestAPI("DELETE", `/api/projects/${encodeURIComponent(id)}/holds${apiClientQuery(query)}`);
+  },
+  listApprovals(query) {
+    return requestAPI("GET", `/api/approvals${apiClientQuery(query)}`);
+  },
+  listCapabilities() {
+    return requestAPI("GET", "/api/capabilities");
+  },
+  listEnvironmentBindings(id, env) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/bindings`);
+  },
+  listOpNotes(id) {
+    return requestAPI("GET", `/api/ops/${encodeURIComponent(id)}/notes`);
+  },
+  listOps(query) {
+    return requestAPI("GET", `/api/ops${apiClientQuery(query)}`);
+  },
+  listOrgProjects(id, query) {
+    return requestAPI("GET", `/api/orgs/${encodeURIComponent(id)}/projects${apiClientQuery(query)}`);
+  },
+  listOrgs() {
+    return requestAPI("GET", "/api/orgs");
+  },
+  listProjectArtifacts(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/artifacts`);
+  },
+  listProjectHolds(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/holds`);
+  },
+  listProjectOps(id, query) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/ops${apiClientQuery(query)}`);
+  },
+  listProjectReleases(id, query) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/releases${apiClientQuery(query)}`);
+  },
+  listProjectSchedules(id) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/schedules`);
+  },
+  listProjectSecrets(id, env) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/secrets/${encodeURIComponent(env)}`);
+  },
+  listProjects(query) {
+    return requestAPI("GET", `/api/projects${apiClientQuery(query)}`);
+  },
+  listTemplates() {
+    return requestAPI("GET", "/api/templates");
+  },
+  listTokens() {
+    return requestAPI("GET", "/api/tokens");
+  },
+  listViewProjects(id) {
+    return requestAPI("GET", `/api/views/${encodeURIComponent(id)}/projects`);
+  },
+  listViews() {
+    return requestAPI("GET", "/api/views");
+  },
+  lookup(query) {
+    return requestAPI("GET", `/api/lookup${apiClientQuery(query)}`);
+  },
+  patchEnvironmentVars(id, env, body, query) {
+    return requestAPI("PATCH", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/vars${apiClientQuery(query)}`, body);
+  },
+  placeProjectHold(id, body) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/holds`, body);
+  },
+  postDeploymentEvent(body, query) {
+    return requestAPI("POST", `/api/events/deployment${apiClientQuery(query)}`, body);
+  },
+  postPromotionEvent(body, query) {
+    return requestAPI("POST", `/api/events/promotion${apiClientQuery(query)}`, body);
+  },
+  postRegistrationEvent(body, query) {
+    return requestAPI("POST", `/api/events/registration${apiClientQuery(query)}`, body);
+  },
+  postReleaseCutoverEvent(body, query) {
+    return requestAPI("POST", `/api/events/release/cutover${apiClientQuery(query)}`, body);
+  },
+  postReleaseEvent(body, query) {
+    return requestAPI("POST", `/api/events/release${apiClientQuery(query)}`, body);
+  },
+  postRollbackEvent(body, query) {
+    return requestAPI("POST", `/api/events/rollback${apiClientQuery(query)}`, body);
+  },
+  postSourceWebhook(body) {
+    return requestAPI("POST", "/api/webhooks/source", body);
+  },
+  previewPromotion(body) {
+    return requestAPI("POST", "/api/events/promotion/preview", body);
+  },
+  previewRollback(body) {
+    return requestAPI("POST", "/api/events/rollback/preview", body);
+  },
+  promoteCanary(id, env, body, query) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/canary/promote${apiClientQuery(query)}`, body);
+  },
+  putEnvironmentBinding(id, env, capability, body) {
+    return requestAPI("PUT", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/bindings/${encodeURIComponent(capability)}`, body);
+  },
+  putProjectSecrets(id, env, body) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/secrets/${encodeURIComponent(env)}`, body);
+  },
+  rejectRelease(id, body) {
+    return requestAPI("POST", `/api/approvals/${encodeURIComponent(id)}/reject`, body);
+  },
+  revokeToken(id) {
+    return requestAPI("DELETE", `/api/tokens/${encodeURIComponent(id)}`);
+  },
+  setProjectOwnership(id, body) {
+    return requestAPI("PUT", `/api/projects/${encodeURIComponent(id)}/ownership`, body);
+  },
+  setReadOnly(body) {
+    return requestAPI("POST", "/api/admin/readonly", body);
+  },
+  startRuntimeUpgrade(id, body, query) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/runtime-upgrade${apiClientQuery(query)}`, body);
+  },
+  startVarRollout(id, body) {
+    return requestAPI("POST", `/api/projects/${encodeURIComponent(id)}/var-rollout`, body);
+  },
+  statProjectArtifact(id, path, query) {
+    return requestAPI("GET", `/api/projects/${encodeURIComponent(id)}/artifacts/${encodeURIComponent(path)}${apiClientQuery(query)}`);
+  },
+  unfreezeEnvironment(id, env, query) {
+    return requestAPI("DELETE", `/api/projects/${encodeURIComponent(id)}/environments/${encodeURIComponent(env)}/freeze${apiClientQuery(query)}`);
+  },
+  updateOrg(id, body) {
+    return requestAPI("PUT", `/api/orgs/${encodeURIComponent(id)}`, body);
+  },
+  updateProject(id, body, query) {
+    return requestAPI("PUT", `/api/projects/${encodeURIComponent(id)}${apiClientQuery(query)}`, body);
+  },
+  updateProjectReleaseNotes(id, releaseId, body) {
+    return requestAPI("PUT", `/api/projects/${encodeURIComponent(id)}/releases/${encodeURIComponent(releaseId)}/notes`, body);
+  },
+  updateProjectSchedule(id, scheduleID, body) {
+    return requestAPI("PUT", `/api/projects/${encodeURIComponent(id)}/schedules/${encodeURIComponent(scheduleID)}`, body);
+  },
+  updateView(id, body) {
+    return requestAPI("PUT", `/api/views/${encodeURIComponent(id)}`, body);
+  },
+  validateProjectSpec(body) {
+    return requestAPI("POST", "/api/projects/validate", body);
+  },
+};
+
+function apiClientQuery(query) {
+  if (!query) {
+    return "";
+  }
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(query)) {
+    if (value !== undefined && value !== null && value !== "") {
+      params.set(key, String(value));
+    }
+  }
+  const encoded = params.toString();
+  return encoded ? `?${encoded}` : "";
+}
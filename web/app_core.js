@@ -48,7 +48,9 @@ const dom = {
     createAPIVersion: document.getElementById("createAPIVersion"),
     createKind: document.getElementById("createKind"),
     createName: document.getElementById("createName"),
+    createTemplate: document.getElementById("createTemplate"),
     createRuntime: document.getElementById("createRuntime"),
+    createBuildStrategy: document.getElementById("createBuildStrategy"),
     createCapabilities: document.getElementById("createCapabilities"),
     createIngress: document.getElementById("createIngress"),
     createEgress: document.getElementById("createEgress"),
@@ -57,6 +59,7 @@ const dom = {
     updateKind: document.getElementById("updateKind"),
     updateName: document.getElementById("updateName"),
     updateRuntime: document.getElementById("updateRuntime"),
+    updateBuildStrategy: document.getElementById("updateBuildStrategy"),
     updateCapabilities: document.getElementById("updateCapabilities"),
     updateIngress: document.getElementById("updateIngress"),
     updateEgress: document.getElementById("updateEgress"),
@@ -66,6 +69,7 @@ const dom = {
     webhookRef: document.getElementById("webhookRef"),
     webhookCommit: document.getElementById("webhookCommit"),
 
+    savedView: document.getElementById("savedView"),
     projectSearch: document.getElementById("projectSearch"),
     phaseFilter: document.getElementById("phaseFilter"),
     projectSort: document.getElementById("projectSort"),
@@ -115,6 +119,7 @@ const dom = {
 
     deleteModalTarget: document.getElementById("deleteModalTarget"),
     deleteConfirmHint: document.getElementById("deleteConfirmHint"),
+    deletePlanSummary: document.getElementById("deletePlanSummary"),
     promotionModalTitle: document.getElementById("promotionModalTitle"),
     promotionSummary: document.getElementById("promotionSummary"),
     promotionPreviewStatus: document.getElementById("promotionPreviewStatus"),
@@ -124,6 +129,7 @@ const dom = {
     rollbackConfirmHint: document.getElementById("rollbackConfirmHint"),
 
     opRaw: document.getElementById("lastOp"),
+    opLog: document.getElementById("opLog"),
     opTransportStatus: document.getElementById("opTransportStatus"),
   },
   containers: {
@@ -217,6 +223,9 @@ const workerOrderByKind = {
   promote: ["promoter.plan", "promoter.render", "promoter.commit", "promoter.finalize"],
   release: ["promoter.plan", "promoter.render", "promoter.commit", "promoter.finalize"],
   rollback: ["promoter.plan", "promoter.render", "promoter.commit", "promoter.finalize"],
+  cleanup: ["artifactCleaner"],
+  "runtime-upgrade": ["runtimeUpgrader"],
+  "webhook-refresh": ["endpointRegistry"],
 };
 
 const workerLabelByName = {
@@ -230,6 +239,10 @@ const workerLabelByName = {
   "promoter.render": "Render target manifests",
   "promoter.commit": "Commit manifests to repo",
   "promoter.finalize": "Persist release record",
+  artifactCleaner: "Remove stored outputs",
+  runtimeUpgrader: "Trial build on upgrade branch",
+  endpointRegistry: "Repoint source webhook",
+  kubeApplier: "Apply to local cluster",
 };
 
 const operationLabelByKind = {
@@ -241,6 +254,11 @@ const operationLabelByKind = {
   promote: "Promote environment",
   release: "Release to production",
   rollback: "Rollback environment",
+  cleanup: "Clean up outputs",
+  "var-rollout": "Roll out var change",
+  "promote-fanout": "Promote to several environments",
+  "runtime-upgrade": "Trial runtime upgrade",
+  "webhook-refresh": "Refresh webhook endpoint",
 };
 
 const nextActionKindToTone = {
@@ -254,8 +272,10 @@ const nextActionKindToTone = {
 
 const state = {
   projects: [],
+  views: [],
   selectedProjectID: "",
   filters: {
+    view: "",
     search: "",
     phase: "all",
     sort: "updated_desc",
@@ -305,12 +325,14 @@ const state = {
     activeOpID: "",
     payload: null,
     eventSource: null,
+    logAbort: null,
     usingPolling: false,
     timer: null,
     token: 0,
     failureCount: 0,
     sseFailureCount: 0,
     terminalHandledOpID: "",
+    noteDraft: { author: "", text: "" },
     history: [],
     historyLoading: false,
     historyLoadingMore: false,
@@ -352,6 +374,15 @@ const state = {
     previewError: "",
     blockers: [],
   },
+  templates: {
+    items: [],
+    loaded: false,
+  },
+  deletePlan: {
+    plan: null,
+    loading: false,
+    error: "",
+  },
   ui: {
     modal: "none",
     workspaceOpen: false,
@@ -755,6 +786,7 @@ function buildCreateSpec() {
     kind: "App",
     name: dom.inputs.createName.value.trim(),
     runtime: dom.inputs.createRuntime.value.trim(),
+    build: { strategy: dom.inputs.createBuildStrategy.value },
     capabilities: parseCapabilities(dom.inputs.createCapabilities.value),
     environments: collectEnvironments("create", "Create environments"),
     networkPolicies: {
@@ -770,15 +802,27 @@ function buildUpdateSpec() {
     kind: "App",
     name: dom.inputs.updateName.value.trim(),
     runtime: dom.inputs.updateRuntime.value.trim(),
+    build: buildUpdateBuildConfig(),
     capabilities: parseCapabilities(dom.inputs.updateCapabilities.value),
+    // The editor only covers per-environment overrides; keep the shared block.
+    vars: { ...(getSelectedProject()?.spec?.vars || {}) },
     environments: collectEnvironments("update", "Update environments"),
     networkPolicies: {
       ingress: dom.inputs.updateIngress.value,
       egress: dom.inputs.updateEgress.value,
     },
+    // Operator-defined extensions have no editor; carry them through.
+    extensions: { ...(getSelectedProject()?.spec?.extensions || {}) },
   };
 }
 
+// The builder override has no editor; keep it while buildpacks stays selected.
+function buildUpdateBuildConfig() {
+  const strategy = dom.inputs.updateBuildStrategy.value;
+  const builder = getSelectedProject()?.spec?.build?.builder || "";
+  return strategy === "buildpacks" && builder ? { strategy, builder } : { strategy };
+}
+
 function generatedWebhookCommitHint() {
   const now = Date.now().toString(16);
   const random = Math.floor(Math.random() * 0xffffff)
@@ -809,18 +853,45 @@ function setCreateDefaults() {
   dom.inputs.createAPIVersion.value = "platform.example.com/v2";
   dom.inputs.createKind.value = "App";
   dom.inputs.createName.value = "";
+  dom.inputs.createTemplate.value = "";
   ensureRuntimeOption(dom.inputs.createRuntime, "go_1.26");
+  dom.inputs.createBuildStrategy.value = "dockerfile";
   dom.inputs.createCapabilities.value = "";
   dom.inputs.createIngress.value = "internal";
   dom.inputs.createEgress.value = "internal";
   setEnvironmentsInEditor("create", defaultEnvironments);
 }
 
+function populateTemplateSelect(templates) {
+  const selectEl = dom.inputs.createTemplate;
+  const selected = selectEl.value;
+  selectEl.replaceChildren(selectEl.options[0] || new Option("None (hello-world main.go)", ""));
+  for (const tmpl of templates) {
+    const label = tmpl.description ? `${tmpl.name} - ${tmpl.description}` : tmpl.name;
+    selectEl.appendChild(new Option(label, tmpl.name));
+  }
+  selectEl.value = templates.some((tmpl) => tmpl.name === selected) ? selected : "";
+}
+
+// applyCreateTemplate copies the fields a template pre-fills into the create
+// form so the user sees what they start from; the server merges the rest.
+function applyCreateTemplate(name) {
+  const tmpl = state.templates.items.find((item) => item.name === name);
+  if (!tmpl) return;
+  const spec = tmpl.spec || {};
+  if (spec.runtime) ensureRuntimeOption(dom.inputs.createRuntime, spec.runtime);
+  if (spec.build?.strategy) dom.inputs.createBuildStrategy.value = spec.build.strategy;
+  dom.inputs.createCapabilities.value = (spec.capabilities || []).join(",");
+  if (spec.networkPolicies?.ingress) dom.inputs.createIngress.value = spec.networkPolicies.ingress;
+  if (spec.networkPolicies?.egress) dom.inputs.createEgress.value = spec.networkPolicies.egress;
+}
+
 function setUpdateDefaults() {
   dom.inputs.updateAPIVersion.value = "platform.example.com/v2";
   dom.inputs.updateKind.value = "App";
   dom.inputs.updateName.value = "";
   ensureRuntimeOption(dom.inputs.updateRuntime, "go_1.26");
+  dom.inputs.updateBuildStrategy.value = "dockerfile";
   dom.inputs.updateCapabilities.value = "";
   dom.inputs.updateIngress.value = "internal";
   dom.inputs.updateEgress.value = "internal";
@@ -833,6 +904,7 @@ function syncUpdateForm(project) {
   dom.inputs.updateKind.value = spec.kind || "App";
   dom.inputs.updateName.value = spec.name || "";
   ensureRuntimeOption(dom.inputs.updateRuntime, spec.runtime || "go_1.26");
+  dom.inputs.updateBuildStrategy.value = spec.build?.strategy || "dockerfile";
   dom.inputs.updateCapabilities.value = Array.isArray(spec.capabilities) ? spec.capabilities.join(",") : "";
   dom.inputs.updateIngress.value = spec.networkPolicies?.ingress || "internal";
   dom.inputs.updateEgress.value = spec.networkPolicies?.egress || "internal";
@@ -882,8 +954,8 @@ function transitionVerb(action) {
   return action === "release" ? "release" : "promotion";
 }
 
-function transitionEndpoint(action) {
-  return action === "release" ? "/api/events/release" : "/api/events/promotion";
+function transitionRequest(action, body) {
+  return action === "release" ? apiClient.postReleaseEvent(body) : apiClient.postPromotionEvent(body);
 }
 
 function operationLabel(kind) {
@@ -891,7 +963,11 @@ function operationLabel(kind) {
 }
 
 function workerLabel(name) {
-  return workerLabelByName[String(name || "").trim()] || String(name || "step");
+  const key = String(name || "").trim();
+  if (key.startsWith("rollout.")) {
+    return `Roll out to ${key.slice("rollout.".length)}`;
+  }
+  return workerLabelByName[key] || String(name || "step");
 }
 
 function currentOverview() {
@@ -1067,6 +1143,10 @@ function projectMatchesSearch(project, term) {
   return haystack.includes(term.toLowerCase());
 }
 
+function getActiveView() {
+  return state.views.find((view) => view.id === state.filters.view) || null;
+}
+
 function getVisibleProjects() {
   const term = state.filters.search.trim().toLowerCase();
   const phase = state.filters.phase;
@@ -1076,6 +1156,9 @@ function getVisibleProjects() {
     return projectMatchesSearch(project, term);
   });
 
+  // A saved view with its own sort arrives in that order from the server.
+  if (getActiveView()?.sort) return filtered;
+
   const sortKey = state.filters.sort;
   filtered.sort((a, b) => {
     if (sortKey === "name_asc") {
@@ -3,6 +3,11 @@ function artifactUrl(projectID, path) {
   return `/api/projects/${encodeURIComponent(projectID)}/artifacts/${encodeURIComponent(path).replaceAll("%2F", "/")}`;
 }
 
+// artifactViewUrl opens the artifact in the browser rather than downloading it.
+function artifactViewUrl(projectID, path) {
+  return `${artifactUrl(projectID, path)}?inline=1`;
+}
+
 function artifactKind(path) {
   if (path.startsWith("build/")) return "build";
   if (path.startsWith("deploy/")) return "deploy";
@@ -144,7 +149,7 @@ async function loadOverview({ silent = false } = {}) {
   renderEnvironmentMatrix();
 
   try {
-    const response = await requestAPI("GET", `/api/projects/${encodeURIComponent(project.id)}/overview`);
+    const response = await apiClient.getProjectOverview(project.id);
     state.overview.data = response?.overview && typeof response.overview === "object" ? response.overview : null;
     renderJourneyPanel();
     renderEnvironmentMatrix();
@@ -170,10 +175,17 @@ async function loadOverview({ silent = false } = {}) {
   }
 }
 
+// With PAAS_API_AUTH on, the UI sends the token saved in this browser with
+// localStorage.setItem("paas.apiToken", "<token>").
+function apiAuthHeaders() {
+  const token = window.localStorage?.getItem("paas.apiToken") || "";
+  return token ? { authorization: `Bearer ${token}` } : {};
+}
+
 async function requestAPI(method, url, body) {
   const options = {
     method,
-    headers: {},
+    headers: apiAuthHeaders(),
   };
 
   if (body !== undefined) {
@@ -234,7 +246,7 @@ async function loadSystemStatus({ silent = false } = {}) {
   renderSystemStrip();
 
   try {
-    const response = await requestAPI("GET", "/api/system");
+    const response = await apiClient.getSystem();
     state.system.data = response && typeof response === "object" ? response : null;
     renderSystemStrip();
     if (!silent) {
@@ -278,7 +290,7 @@ async function loadJourney({ silent = false } = {}) {
   renderJourneyPanel();
 
   try {
-    const response = await requestAPI("GET", `/api/projects/${encodeURIComponent(project.id)}/journey`);
+    const response = await apiClient.getProjectJourney(project.id);
     state.journey.data = response?.journey || null;
     renderJourneyPanel();
     renderSystemStrip();
@@ -365,17 +377,11 @@ async function loadReleaseTimeline({ silent = false, append = false } = {}) {
   renderReleaseTimelinePanel();
 
   try {
-    const query = new URLSearchParams();
-    query.set("environment", environment);
-    query.set("limit", String(operationHistoryPageLimit));
-    if (append) {
-      query.set("cursor", String(state.releaseTimeline.nextCursor || "").trim());
-    }
-
-    const response = await requestAPI(
-      "GET",
-      `/api/projects/${encodeURIComponent(project.id)}/releases?${query.toString()}`
-    );
+    const response = await apiClient.listProjectReleases(project.id, {
+      environment,
+      limit: operationHistoryPageLimit,
+      cursor: append ? String(state.releaseTimeline.nextCursor || "").trim() : "",
+    });
     if (getSelectedProject()?.id !== project.id) {
       return;
     }
@@ -446,7 +452,7 @@ async function readArtifactText(path) {
   }
 
   try {
-    const response = await fetch(artifactUrl(project.id, path));
+    const response = await fetch(artifactUrl(project.id, path), { headers: apiAuthHeaders() });
     if (!response.ok) {
       state.artifacts.textCache[path] = "";
       return "";
@@ -599,6 +605,9 @@ function snapshotFromOverviewEnvironment(environment) {
     deliveryPath,
     configReadiness: String(environment?.config_readiness || "unknown"),
     secretsReadiness: String(environment?.secrets_readiness || "unknown"),
+    url: String(environment?.url || "").trim(),
+    urlScope: String(environment?.url_scope || "").trim(),
+    scaling: environment?.scaling || null,
     lastDeliveryAt: String(environment?.last_delivery_at || "").trim(),
   };
 }
@@ -641,6 +650,12 @@ function createEnvironmentSnapshotCard(snapshot) {
   if (snapshot.secretsReadiness) {
     meta.appendChild(makeSignalChip(`secrets ${snapshot.secretsReadiness}`, "signal-chip-runtime"));
   }
+  if (snapshot.scaling?.autoscaling) {
+    const { replicas, max_replicas: maxReplicas, target_cpu_utilization: targetCPU } = snapshot.scaling;
+    meta.appendChild(makeSignalChip(`autoscale ${replicas}-${maxReplicas} at ${targetCPU}% cpu`, "signal-chip-runtime"));
+  } else if (snapshot.scaling) {
+    meta.appendChild(makeSignalChip(`replicas ${snapshot.scaling.replicas}`, "signal-chip-runtime"));
+  }
   if (hasRealTimestamp(snapshot.lastDeliveryAt)) {
     meta.appendChild(makeSignalChip(`updated ${toLocalTime(snapshot.lastDeliveryAt)}`, "signal-chip-updated"));
   }
@@ -659,12 +674,22 @@ function createEnvironmentSnapshotCard(snapshot) {
     if (state.artifacts.loaded && !state.artifacts.files.includes(path)) return;
 
     const anchor = makeElem("a", "link-chip", label);
-    anchor.href = artifactUrl(getSelectedProject().id, path);
+    anchor.href = artifactViewUrl(getSelectedProject().id, path);
     anchor.target = "_blank";
     anchor.rel = "noopener";
     links.appendChild(anchor);
   };
 
+  if (snapshot.url && snapshot.urlScope === "ingress") {
+    const anchor = makeElem("a", "link-chip", "open app");
+    anchor.href = snapshot.url;
+    anchor.target = "_blank";
+    anchor.rel = "noopener";
+    links.appendChild(anchor);
+  } else if (snapshot.url) {
+    meta.appendChild(makeSignalChip(`in-cluster ${snapshot.url}`, "signal-chip-id"));
+  }
+
   maybeLink(snapshot.deployRenderedPath, "rendered config");
   maybeLink(snapshot.deployDeploymentPath, "deployment");
   maybeLink(snapshot.deployServicePath, "service");
@@ -58,6 +58,10 @@ function bindEvents() {
     openModal("create");
   });
 
+  dom.inputs.createTemplate.addEventListener("change", () => {
+    applyCreateTemplate(dom.inputs.createTemplate.value);
+  });
+
   dom.buttons.openUpdateModal.addEventListener("click", () => {
     openModal("update");
   });
@@ -216,6 +220,15 @@ function bindEvents() {
     setStatus(`Update form keys cleaned: ${changed}`, "success");
   });
 
+  dom.inputs.savedView.addEventListener("change", async () => {
+    state.filters.view = dom.inputs.savedView.value;
+    try {
+      await refreshProjects({ silent: true, preserveSelection: true });
+    } catch (error) {
+      setStatus(`Saved view unavailable: ${error.message}`, statusToneFromError(error), { toast: true });
+    }
+  });
+
   dom.inputs.projectSearch.addEventListener("input", () => {
     state.filters.search = dom.inputs.projectSearch.value;
     renderProjectsList();
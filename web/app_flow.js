@@ -10,13 +10,14 @@ function openModal(modalName) {
 
   if (modalName === "create") {
     setCreateDefaults();
+    void loadProjectTemplates();
   } else if (modalName === "update") {
     syncUpdateForm(project);
   } else if (modalName === "delete") {
     const appName = project?.spec?.name || project?.id || "";
     dom.text.deleteModalTarget.textContent = `Target app: ${appName}`;
     dom.inputs.deleteConfirm.value = "";
-    syncDeleteConfirmationState();
+    void loadDeletePlan(project);
   } else if (modalName === "rollback") {
     syncRollbackConfirmationState();
   }
@@ -126,6 +127,13 @@ function renderRollbackPreviewDetails(project, releaseRecord, preview) {
         `Rendered manifest changed ${compare.rendered_delta?.changed ? "yes" : "no"}`
       )
     );
+    compareList.appendChild(
+      makeElem(
+        "li",
+        "promotion-preview-item",
+        `Project spec changed ${compare.spec_delta?.changed ? "yes" : "no"}`
+      )
+    );
     dom.containers.rollbackCompare.appendChild(compareList);
   }
 
@@ -228,7 +236,7 @@ async function loadRollbackPreview({ silent = false } = {}) {
   }
 
   try {
-    const preview = await requestAPI("POST", "/api/events/rollback/preview", {
+    const preview = await apiClient.previewRollback({
       project_id: project.id,
       environment: state.rollback.environment,
       release_id: state.rollback.releaseID,
@@ -389,7 +397,7 @@ async function openPromotionConfirmation() {
 
   let preview;
   try {
-    preview = await requestAPI("POST", "/api/events/promotion/preview", {
+    preview = await apiClient.previewPromotion({
       project_id: project.id,
       from_env: fromEnv,
       to_env: toEnv,
@@ -443,6 +451,8 @@ function stopOperationMonitor({ clearPayload = false } = {}) {
     state.operation.eventSource = null;
   }
 
+  stopOperationLog();
+
   state.operation.token += 1;
   state.operation.failureCount = 0;
   state.operation.sseFailureCount = 0;
@@ -452,6 +462,45 @@ function stopOperationMonitor({ clearPayload = false } = {}) {
 
   if (clearPayload) {
     state.operation.payload = null;
+    if (dom.text.opLog) dom.text.opLog.textContent = "";
+  }
+}
+
+function stopOperationLog() {
+  if (!state.operation.logAbort) return;
+  state.operation.logAbort.abort();
+  state.operation.logAbort = null;
+}
+
+// followOperationLog streams the op's build and git output into the log
+// panel. The server ends the response once the op has finished, so a
+// finished op just shows its whole log.
+async function followOperationLog(opID) {
+  stopOperationLog();
+  const output = dom.text.opLog;
+  if (!output) return;
+  output.textContent = "";
+  const controller = new AbortController();
+  state.operation.logAbort = controller;
+  try {
+    const response = await fetch(`/api/ops/${encodeURIComponent(opID)}/logs?follow=1`, {
+      headers: apiAuthHeaders(),
+      signal: controller.signal,
+    });
+    if (!response.ok || !response.body) return;
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    for (;;) {
+      const { done, value } = await reader.read();
+      if (done) break;
+      const pinned = output.scrollTop + output.clientHeight >= output.scrollHeight - 8;
+      output.textContent += decoder.decode(value, { stream: true });
+      if (pinned) output.scrollTop = output.scrollHeight;
+    }
+  } catch (_error) {
+    // Aborted for another op, or dropped; the log artifact keeps the rest.
+  } finally {
+    if (state.operation.logAbort === controller) state.operation.logAbort = null;
   }
 }
 
@@ -464,13 +513,8 @@ function resetOperationHistory() {
   state.operation.historyNextCursor = "";
 }
 
-function operationHistoryEndpoint(projectID, cursor = "") {
-  const params = new URLSearchParams();
-  params.set("limit", String(operationHistoryPageLimit));
-  if (cursor) {
-    params.set("cursor", String(cursor));
-  }
-  return `/api/projects/${encodeURIComponent(projectID)}/ops?${params.toString()}`;
+function operationHistoryQuery(cursor = "") {
+  return { limit: operationHistoryPageLimit, cursor: cursor ? String(cursor) : "" };
 }
 
 async function loadOperationHistory({ silent = false } = {}) {
@@ -488,7 +532,7 @@ async function loadOperationHistory({ silent = false } = {}) {
   renderOperationPanel();
 
   try {
-    const response = await requestAPI("GET", operationHistoryEndpoint(projectID));
+    const response = await apiClient.listProjectOps(projectID, operationHistoryQuery());
     if (getSelectedProject()?.id !== projectID) {
       return;
     }
@@ -545,7 +589,7 @@ async function loadMoreOperationHistory({ silent = false } = {}) {
   renderOperationPanel();
 
   try {
-    const response = await requestAPI("GET", operationHistoryEndpoint(projectID, cursor));
+    const response = await apiClient.listProjectOps(projectID, operationHistoryQuery(cursor));
     if (getSelectedProject()?.id !== projectID) {
       return;
     }
@@ -594,12 +638,20 @@ function closeWorkspace() {
 
 async function refreshProjects({ silent = false, preserveSelection = true } = {}) {
   const previousSelection = preserveSelection ? state.selectedProjectID : "";
+  const saved = await apiClient.listViews();
+  state.views = Array.isArray(saved?.views) ? saved.views : [];
+  if (!getActiveView()) {
+    state.filters.view = "";
+  }
   const [projects] = await Promise.all([
-    requestAPI("GET", "/api/projects"),
+    state.filters.view
+      ? apiClient.listViewProjects(state.filters.view).then((out) => out.projects)
+      : apiClient.listProjects(),
     loadSystemStatus({ silent: true }),
   ]);
 
   state.projects = Array.isArray(projects) ? projects : [];
+  renderSavedViews();
 
   if (previousSelection && !state.projects.some((project) => project.id === previousSelection)) {
     state.selectedProjectID = "";
@@ -668,6 +720,7 @@ async function startOperationMonitor(opID, { announce = true } = {}) {
   stopOperationMonitor({ clearPayload: false });
   state.operation.activeOpID = opID;
   const token = state.operation.token;
+  void followOperationLog(opID);
 
   const closeOperationEventSource = () => {
     if (!state.operation.eventSource) return;
@@ -685,7 +738,7 @@ async function startOperationMonitor(opID, { announce = true } = {}) {
     }
 
     if (announce) {
-      const tone = op.status === "done" ? "success" : "error";
+      const tone = op.status === "done" ? "success" : op.status === "cancelled" ? "warning" : "error";
       setStatus(`${operationLabel(op.kind)} finished with status ${op.status}.`, tone, { toast: true });
     }
 
@@ -707,7 +760,7 @@ async function startOperationMonitor(opID, { announce = true } = {}) {
   };
 
   const fetchLatestOp = async () => {
-    const op = await requestAPI("GET", `/api/ops/${encodeURIComponent(opID)}`);
+    const op = await apiClient.getOp(opID);
     if (token !== state.operation.token) return null;
 
     state.operation.payload = op;
@@ -729,11 +782,22 @@ async function startOperationMonitor(opID, { announce = true } = {}) {
     closeOperationEventSource();
     renderOperationPanel();
 
+    // Each tick reads the project's revision snapshot first and refetches
+    // the op only when its KV record moved since the last fetch.
+    let fetchedOpRevision = 0;
     const poll = async () => {
       if (token !== state.operation.token) return;
 
       try {
-        const op = await fetchLatestOp();
+        const projectID = state.operation.payload?.project_id || "";
+        const snapshot = projectID ? await apiClient.getProjectRevision(projectID) : null;
+        const opRevision = snapshot?.latest_op?.id === opID ? Number(snapshot.latest_op.revision || 0) : 0;
+        if (token !== state.operation.token) return;
+        let op = state.operation.payload;
+        if (!opRevision || opRevision !== fetchedOpRevision) {
+          op = await fetchLatestOp();
+          fetchedOpRevision = opRevision;
+        }
         if (token !== state.operation.token || !op) return;
         if (isTerminalOperationStatus(op.status)) {
           state.operation.timer = null;
@@ -761,7 +825,12 @@ async function startOperationMonitor(opID, { announce = true } = {}) {
       return;
     }
 
-    const source = new EventSource(`/api/ops/${encodeURIComponent(opID)}/events`);
+    // One project stream carries every op, status, and release event for the
+    // app; fall back to the per-op stream when the op has no project.
+    const projectID = state.operation.payload?.project_id || "";
+    const source = projectID
+      ? new EventSource(`/api/projects/${encodeURIComponent(projectID)}/events`)
+      : new EventSource(`/api/ops/${encodeURIComponent(opID)}/events`);
     state.operation.eventSource = source;
     state.operation.usingPolling = false;
     renderOperationPanel();
@@ -770,19 +839,43 @@ async function startOperationMonitor(opID, { announce = true } = {}) {
       "op.bootstrap",
       "op.status",
       "step.started",
+      "step.substep",
       "step.ended",
       "step.artifacts",
       "op.completed",
       "op.failed",
+      "op.cancelled",
+      "op.note",
+      "op.sla_breached",
       "op.heartbeat",
+      "project.bootstrap",
+      "project.status",
+      "project.heartbeat",
+      "release.created",
     ];
 
+    const eventOpID = (event) => {
+      if (!projectID) return opID;
+      try {
+        return JSON.parse(event.data)?.op?.op_id || "";
+      } catch (_error) {
+        return "";
+      }
+    };
+
     const onEvent = (event) => {
       if (token !== state.operation.token) return;
 
       state.operation.sseFailureCount = 0;
 
-      if (event.type === "op.heartbeat") {
+      if (event.type === "op.heartbeat" || event.type === "project.heartbeat") {
+        return;
+      }
+      if (event.type === "release.created") {
+        void loadReleaseTimeline({ silent: true }).catch(() => {});
+        return;
+      }
+      if (event.type.startsWith("project.") || eventOpID(event) !== opID) {
         return;
       }
 
@@ -792,7 +885,7 @@ async function startOperationMonitor(opID, { announce = true } = {}) {
         setStatus(`Activity stream warning: ${error.message}`, "warning");
       });
 
-      if (event.type === "op.completed" || event.type === "op.failed") {
+      if (event.type === "op.completed" || event.type === "op.failed" || event.type === "op.cancelled") {
         closeOperationEventSource();
       }
     };
@@ -839,7 +932,7 @@ async function loadArtifacts({ silent = false } = {}) {
   renderArtifactsPanel();
 
   try {
-    const response = await requestAPI("GET", `/api/projects/${encodeURIComponent(project.id)}/artifacts`);
+    const response = await apiClient.listProjectArtifacts(project.id);
     const files = Array.isArray(response.files) ? response.files : [];
 
     state.artifacts.loaded = true;
@@ -907,10 +1000,16 @@ async function previewArtifact(path) {
   state.artifacts.previewIsBinary = false;
   renderArtifactsPanel();
 
-  const response = await fetch(artifactUrl(project.id, path));
+  const response = await fetch(artifactUrl(project.id, path), { headers: apiAuthHeaders() });
   if (!response.ok) {
     const text = await response.text();
-    throw new Error(`Preview failed (${response.status}): ${text}`);
+    let message = text;
+    try {
+      message = JSON.parse(text).message || text;
+    } catch (_error) {
+      // Not an error envelope; show the body as-is.
+    }
+    throw new Error(`Preview failed (${response.status}): ${message}`);
   }
 
   const buffer = await response.arrayBuffer();
@@ -999,16 +1098,31 @@ function primeAcceptedOperation(op) {
   renderActionPanels();
 }
 
+async function loadProjectTemplates() {
+  if (state.templates.loaded) return;
+  try {
+    const templates = await apiClient.listTemplates();
+    state.templates.items = Array.isArray(templates) ? templates : [];
+    state.templates.loaded = true;
+    populateTemplateSelect(state.templates.items);
+  } catch (error) {
+    setStatus(`Starter templates unavailable: ${error.message}`, "warning");
+  }
+}
+
 async function handleCreateSubmit(event) {
   event.preventDefault();
   setStatus("Creating app...", "info");
 
   try {
     const spec = buildCreateSpec();
-    const response = await requestAPI("POST", "/api/events/registration", {
-      action: "create",
-      spec,
-    });
+    const templateName = dom.inputs.createTemplate.value;
+    const response = templateName
+      ? await apiClient.createProjectFromTemplate(templateName, spec)
+      : await apiClient.postRegistrationEvent({
+          action: "create",
+          spec,
+        });
 
     await refreshProjects({ silent: true, preserveSelection: true });
 
@@ -1041,7 +1155,7 @@ async function handleUpdateSubmit(event) {
 
   try {
     const spec = buildUpdateSpec();
-    const response = await requestAPI("POST", "/api/events/registration", {
+    const response = await apiClient.postRegistrationEvent({
       action: "update",
       project_id: project.id,
       spec,
@@ -1078,7 +1192,7 @@ async function handleWebhookSubmit(event) {
 
   try {
     const payload = buildWebhookPayload(project.id, { generateCommit: true });
-    const response = await requestAPI("POST", "/api/webhooks/source", payload);
+    const response = await apiClient.postSourceWebhook(payload);
 
     if (!response.accepted) {
       setStatus(`Build trigger ignored: ${response.reason || "not accepted"}`, "warning", { toast: true });
@@ -1113,7 +1227,7 @@ async function handleDeployDevClick() {
   setStatus(`Delivering dev environment for ${project.spec?.name || project.id}...`, "info");
 
   try {
-    const response = await requestAPI("POST", "/api/events/deployment", {
+    const response = await apiClient.postDeploymentEvent({
       project_id: project.id,
       environment: "dev",
     });
@@ -1181,7 +1295,7 @@ async function handlePromotionConfirmSubmit(event) {
   setStatus(`${actionLabel} ${fromEnv} to ${toEnv}...`, "warning");
 
   try {
-    const response = await requestAPI("POST", transitionEndpoint(action), {
+    const response = await transitionRequest(action, {
       project_id: project.id,
       from_env: fromEnv,
       to_env: toEnv,
@@ -1231,7 +1345,7 @@ async function handleRollbackConfirmSubmit(event) {
   );
 
   try {
-    const response = await requestAPI("POST", "/api/events/rollback", {
+    const response = await apiClient.postRollbackEvent({
       project_id: project.id,
       environment: state.rollback.environment,
       release_id: state.rollback.releaseID,
@@ -1265,6 +1379,20 @@ async function handleRollbackConfirmSubmit(event) {
   }
 }
 
+async function loadDeletePlan(project) {
+  state.deletePlan = { plan: null, loading: true, error: "" };
+  syncDeleteConfirmationState();
+  try {
+    const plan = await apiClient.createProjectDeletePlan(project.id);
+    if (getSelectedProject()?.id === project.id) {
+      state.deletePlan = { plan, loading: false, error: "" };
+    }
+  } catch (error) {
+    state.deletePlan = { plan: null, loading: false, error: statusMessageFromError(error) };
+  }
+  syncDeleteConfirmationState();
+}
+
 async function handleDeleteConfirmSubmit(event) {
   event.preventDefault();
 
@@ -1285,9 +1413,11 @@ async function handleDeleteConfirmSubmit(event) {
   setStatus("Deleting app...", "warning");
 
   try {
-    const response = await requestAPI("POST", "/api/events/registration", {
+    const response = await apiClient.postRegistrationEvent({
       action: "delete",
       project_id: project.id,
+      plan_id: state.deletePlan.plan?.id || "",
+      acknowledge_impact: Boolean(state.deletePlan.plan?.requires_acknowledgement),
     });
 
     if (response.op?.id) {
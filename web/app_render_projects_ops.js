@@ -5,6 +5,20 @@ function makeSignalChip(text, className = "") {
   return makeElem("span", classes.join(" "), text);
 }
 
+function renderSavedViews() {
+  const select = dom.inputs.savedView;
+  const options = [new Option("All apps", "")];
+  for (const view of state.views) {
+    const option = new Option(view.name, view.id);
+    option.title = view.description || "";
+    options.push(option);
+  }
+  select.replaceChildren(...options);
+  select.value = state.filters.view;
+  select.disabled = !state.views.length;
+  dom.inputs.projectSort.disabled = Boolean(getActiveView()?.sort);
+}
+
 function renderProjectsList() {
   const selected = getSelectedProject();
   const visible = getVisibleProjects();
@@ -126,13 +140,44 @@ function renderSelectionPanel() {
 
   dom.text.selected.append(row1, row2, row3, row4);
 
+  const ownership = project.ownership || {};
+  const owners = Array.isArray(ownership.owners) ? ownership.owners : [];
+  const teams = Array.isArray(ownership.teams) ? ownership.teams : [];
+  if (owners.length || teams.length || ownership.on_call) {
+    const contacts = makeElem("div", "project-signals");
+    for (const owner of owners) {
+      const contact = owner.chat || owner.email || "";
+      contacts.appendChild(makeSignalChip(`owner ${owner.name}${contact ? ` (${contact})` : ""}`, "signal-chip-owner"));
+    }
+    for (const team of teams) {
+      contacts.appendChild(makeSignalChip(`team ${team}`, "signal-chip-owner"));
+    }
+    if (ownership.on_call) {
+      contacts.appendChild(makeSignalChip(`on call ${ownership.on_call}`, "signal-chip-owner"));
+    }
+    dom.text.selected.appendChild(contacts);
+  }
+
   if (state.ui.modal === "delete") {
     syncDeleteConfirmationState();
   }
 }
 
-function workerOrderForKind(kind) {
-  return workerOrderByKind[String(kind || "")] || [];
+function workerOrderForOperation(op) {
+  if (op?.kind === "var-rollout") {
+    // Each rollout stage is a step named after its environment.
+    const stages = Array.isArray(op.rollout?.stages) ? op.rollout.stages : [];
+    return stages.map((stage) => `rollout.${stage.environment}`);
+  }
+  if (op?.kind === "promote-fanout") {
+    // Each fan-out target is a step named after its environment.
+    const targets = Array.isArray(op.fanout?.targets) ? op.fanout.targets : [];
+    return targets.map((target) => `fanout.${target.environment}`);
+  }
+  const order = workerOrderByKind[String(op?.kind || "")] || [];
+  // The kube apply step only runs when the server has it switched on.
+  if (stepForWorker(op, "kubeApplier")) return [...order, "kubeApplier"];
+  return order;
 }
 
 function stepForWorker(op, workerName) {
@@ -152,7 +197,7 @@ function stepState(step) {
 }
 
 function isTerminalOperationStatus(status) {
-  return status === "done" || status === "error";
+  return status === "done" || status === "error" || status === "cancelled";
 }
 
 function normalizeHistorySequence(value) {
@@ -178,6 +223,7 @@ function operationHistorySummaryFromPayload(op) {
     message: summaryMessage,
     last_event_sequence: normalizeHistorySequence(op.last_event_sequence),
     last_update_at: op.last_update_at || op.finished || op.requested,
+    sla_breached: Boolean(op.sla_breached),
   };
 }
 
@@ -287,7 +333,7 @@ function renderOperationProgress(op) {
     return;
   }
 
-  const order = workerOrderForKind(op.kind);
+  const order = workerOrderForOperation(op);
 
   let doneCount = 0;
   for (const workerName of order) {
@@ -311,6 +357,24 @@ function renderOperationProgress(op) {
     ),
     makeBadge(op.status || "unknown", op.status || "unknown")
   );
+  if (op.sla_breached) {
+    head.append(makeBadge("SLA breached", "warning"));
+  }
+  if (!isTerminalOperationStatus(op.status) && op.kind !== "delete") {
+    const cancelButton = makeElem("button", "btn btn-subtle", "Cancel");
+    cancelButton.type = "button";
+    cancelButton.addEventListener("click", async () => {
+      cancelButton.disabled = true;
+      try {
+        await apiClient.cancelOp(op.id, {});
+        setStatus(`${operationLabel(op.kind)} cancelled.`, "warning", { toast: true });
+      } catch (error) {
+        cancelButton.disabled = false;
+        setStatus(`Cancel failed: ${error.message}`, "error", { toast: true });
+      }
+    });
+    head.appendChild(cancelButton);
+  }
 
   const track = makeElem("div", "progress-track");
   const fill = makeElem("span", "progress-fill");
@@ -358,7 +422,7 @@ function renderOperationTimeline(op) {
     return;
   }
 
-  const order = workerOrderForKind(op.kind);
+  const order = workerOrderForOperation(op);
 
   if (!order.length) {
     renderEmptyState(dom.containers.opTimeline, "No known worker path for this operation.");
@@ -381,12 +445,24 @@ function renderOperationTimeline(op) {
       bits.push(`started ${toLocalTime(step.started_at)}`);
       bits.push(`ended ${toLocalTime(step.ended_at)}`);
       bits.push(`duration ${duration(step.started_at, step.ended_at)}`);
+      if (step.attempt > 1) bits.push(`attempt ${step.attempt}`);
       if (step.message) bits.push(step.message);
       if (step.error) bits.push(`error ${step.error}`);
     }
 
     row.append(head, makeElem("p", "timeline-step-meta", bits.join(" • ")));
 
+    if (step && Array.isArray(step.substeps) && step.substeps.length) {
+      const list = makeElem("ol", "timeline-substeps");
+      for (const subStep of step.substeps) {
+        const subState = subStep.error ? "error" : hasRealTimestamp(subStep.ended_at) ? "done" : "running";
+        const timing = subState === "running" ? "running" : duration(subStep.started_at, subStep.ended_at);
+        const text = [subStep.name, timing, subStep.error ? `error ${subStep.error}` : ""].filter(Boolean).join(" • ");
+        list.appendChild(makeElem("li", `timeline-substep timeline-substep--${subState}`, text));
+      }
+      row.appendChild(list);
+    }
+
     if (step && Array.isArray(step.artifacts) && step.artifacts.length) {
       const artifactPreview = step.artifacts.slice(0, 4).join(", ");
       row.appendChild(
@@ -398,8 +474,82 @@ function renderOperationTimeline(op) {
       );
     }
 
+    // Interrupted attempts keep their outputs under attempt-N directories.
+    if (step && Array.isArray(step.prior_attempts)) {
+      for (const prior of step.prior_attempts) {
+        const saved = Array.isArray(prior.artifacts) ? prior.artifacts : [];
+        const outputs = saved.length ? saved.join(", ") : "no outputs";
+        row.appendChild(
+          makeElem(
+            "p",
+            "timeline-step-artifacts",
+            `attempt ${prior.attempt} interrupted after ${duration(prior.started_at, prior.ended_at)}: ${outputs}`
+          )
+        );
+      }
+    }
+
     dom.containers.opTimeline.appendChild(row);
   }
+
+  renderOperationNotes(op);
+}
+
+// Notes stay editable after an op finishes so its timeline can double as an
+// incident record; drafts live in state because SSE updates re-render.
+function renderOperationNotes(op) {
+  const section = makeElem("section", "timeline-notes");
+  section.appendChild(makeElem("p", "timeline-step-title", "Notes"));
+
+  const notes = Array.isArray(op.notes) ? op.notes : [];
+  if (notes.length) {
+    const list = makeElem("ol", "timeline-note-list");
+    for (const note of notes) {
+      const item = makeElem("li", "timeline-note");
+      item.append(
+        makeElem("p", "timeline-step-meta", `${note.author} • ${toLocalTime(note.created_at)}`),
+        makeElem("p", "timeline-note-text", note.text)
+      );
+      list.appendChild(item);
+    }
+    section.appendChild(list);
+  }
+
+  const draft = state.operation.noteDraft;
+  const form = makeElem("div", "timeline-note-form");
+  const authorInput = makeElem("input");
+  authorInput.placeholder = "Your name";
+  authorInput.value = draft.author;
+  authorInput.addEventListener("input", () => {
+    draft.author = authorInput.value;
+  });
+  const textInput = makeElem("input");
+  textInput.placeholder = "e.g. failed due to flaky registry, retried";
+  textInput.value = draft.text;
+  textInput.addEventListener("input", () => {
+    draft.text = textInput.value;
+  });
+  const addButton = makeElem("button", "btn btn-subtle", "Add note");
+  addButton.type = "button";
+  addButton.addEventListener("click", async () => {
+    addButton.disabled = true;
+    try {
+      await apiClient.addOpNote(op.id, { author: draft.author, text: draft.text });
+      draft.text = "";
+      const latest = await apiClient.getOp(op.id);
+      if (state.operation.payload?.id === latest.id) {
+        state.operation.payload = latest;
+      }
+      renderOperationPanel();
+    } catch (error) {
+      addButton.disabled = false;
+      setStatus(`Note failed: ${error.message}`, "error", { toast: true });
+    }
+  });
+  form.append(authorInput, textInput, addButton);
+  section.appendChild(form);
+
+  dom.containers.opTimeline.appendChild(section);
 }
 
 function renderOperationHistory() {
@@ -440,6 +590,9 @@ function renderOperationHistory() {
       makeElem("strong", "", `${operationLabel(item.kind)} • ${String(item.id || "").slice(0, 8)}`),
       makeBadge(item.status || "unknown", item.status || "unknown")
     );
+    if (item.sla_breached) {
+      head.append(makeBadge("SLA breached", "warning"));
+    }
 
     const meta = makeElem(
       "p",
@@ -658,6 +811,15 @@ function renderOperationTransportStatus(op) {
   }
 
   const terminal = isTerminalOperationStatus(op.status);
+  if (op.status === "interrupted") {
+    setPanelInlineStatus(
+      dom.text.opTransportStatus,
+      "Operation interrupted by a platform shutdown. It resumes when the platform restarts.",
+      "warning"
+    );
+    return;
+  }
+
   if (state.operation.usingPolling && !terminal) {
     setPanelInlineStatus(
       dom.text.opTransportStatus,
@@ -690,6 +852,11 @@ function renderOperationTransportStatus(op) {
     return;
   }
 
+  if (op.status === "cancelled") {
+    setPanelInlineStatus(dom.text.opTransportStatus, "Operation cancelled. Steps that finished are kept.", "warning");
+    return;
+  }
+
   setPanelInlineStatus(
     dom.text.opTransportStatus,
     "Operation failed. Review hints and retry.",
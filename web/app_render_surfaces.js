@@ -89,6 +89,7 @@ function renderJourneyPanel() {
 
   milestoneContainer.replaceChildren();
   dom.containers.journeyNextAction.replaceChildren();
+  dom.text.journeyStatusLine.title = "";
 
   if (!project) {
     dom.text.journeyStatusLine.textContent = "Pick an app to load milestones.";
@@ -128,7 +129,12 @@ function renderJourneyPanel() {
     return;
   }
 
-  dom.text.journeyStatusLine.textContent = journey.summary || "Journey status is available.";
+  const summary = journey.summary || "Journey status is available.";
+  const lint = Array.isArray(journey.lint) ? journey.lint : [];
+  dom.text.journeyStatusLine.textContent = lint.length
+    ? `${summary} Spec lint: ${lint.length} warning${lint.length === 1 ? "" : "s"}.`
+    : summary;
+  dom.text.journeyStatusLine.title = lint.map((warning) => `${warning.field}: ${warning.message}`).join("\n");
 
   const nextAction = journey.next_action || { kind: "none", label: "No suggested action", detail: "" };
   renderJourneyNextActionCard(nextAction);
@@ -258,7 +264,7 @@ function renderArtifactQuickLinks() {
 
   for (const linkInfo of links.slice(0, 10)) {
     const anchor = makeElem("a", "link-chip", linkInfo.label);
-    anchor.href = artifactUrl(project.id, linkInfo.path);
+    anchor.href = artifactViewUrl(project.id, linkInfo.path);
     anchor.target = "_blank";
     anchor.rel = "noopener";
     container.appendChild(anchor);
@@ -311,7 +317,7 @@ function renderArtifactsPanel() {
       if (path === state.artifacts.selectedPath) row.classList.add("selected");
 
       const link = makeElem("a", "artifact-link");
-      link.href = artifactUrl(project.id, path);
+      link.href = artifactViewUrl(project.id, path);
       link.target = "_blank";
       link.rel = "noopener";
 
@@ -414,6 +420,10 @@ function renderSystemStrip() {
   if (serverTime) {
     healthMeta.push(`updated ${toLocalTime(serverTime)}`);
   }
+  const readOnly = system.read_only || {};
+  if (readOnly.enabled) {
+    healthMeta.push(`read-only: ${String(readOnly.message || "maintenance").trim()}`);
+  }
   if (state.system.error) {
     healthMeta.push("last refresh failed");
   }
@@ -437,14 +447,51 @@ function syncDeleteConfirmationState() {
   const project = getSelectedProject();
   const expected = (project?.spec?.name || project?.id || "").trim();
   const typed = String(dom.inputs.deleteConfirm.value || "").trim();
-  const valid = Boolean(expected) && typed === expected;
+  const plan = state.deletePlan.plan;
+  const valid = Boolean(expected) && typed === expected && Boolean(plan) && !plan.blocked_by;
 
   dom.buttons.deleteConfirm.disabled = !valid;
+  dom.text.deletePlanSummary.textContent = describeDeletePlan();
+  if (plan?.blocked_by) {
+    dom.text.deleteConfirmHint.textContent = "Delete locked until the blocker is cleared.";
+    return;
+  }
   dom.text.deleteConfirmHint.textContent = expected
     ? `Type "${expected}" to enable delete.`
     : "Select app first.";
 }
 
+function describeDeletePlan() {
+  const { plan, loading, error } = state.deletePlan;
+  if (loading) return "Building delete plan...";
+  if (error) return `Delete plan unavailable: ${error}`;
+  if (!plan) return "";
+  const removes = [
+    `${plan.repos.length} repo(s)`,
+    `${plan.images.length} image(s)`,
+    `${plan.releases.length} release record(s)`,
+    `${plan.cluster_resources.length} cluster resource(s)`,
+    `${plan.artifact_files} output file(s)`,
+  ];
+  let summary = `Plan ${plan.id.slice(0, 8)} removes ${removes.join(", ")}.`;
+  if (plan.requires_acknowledgement) {
+    summary += ` ${describeDeleteImpact(plan.impact)} Deleting acknowledges this.`;
+  }
+  return plan.blocked_by ? `${summary} Blocked: ${plan.blocked_by}` : summary;
+}
+
+function describeDeleteImpact(impact) {
+  const parts = [];
+  if (impact.live_releases.length) parts.push(`takes down ${impact.live_releases.join(", ")}`);
+  if (impact.endpoints.length) parts.push(`removes endpoint(s) ${impact.endpoints.join(", ")}`);
+  if (impact.dependents.length) {
+    const names = [...new Set(impact.dependents.map((dep) => dep.project_name || dep.project_id))];
+    parts.push(`breaks ${names.join(", ")}, which call it`);
+  }
+  if (impact.credentials.length) parts.push(`drops ${impact.credentials.length} stored credential(s)`);
+  return `Impact: ${parts.join("; ")}.`;
+}
+
 function syncPromotionConfirmationState() {
   const expected = state.promotion.confirmationPhrase;
   const typed = String(dom.inputs.promotionConfirmInput.value || "").trim();
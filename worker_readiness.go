@@ -0,0 +1,170 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Worker readiness: workers heartbeat once their consumer is bound; the API
+// refuses (or holds in JetStream) new ops until every required worker is live.
+////////////////////////////////////////////////////////////////////////////////
+
+type readinessMode string
+
+const (
+	readinessModeReject readinessMode = "reject"
+	readinessModeQueue  readinessMode = "queue"
+)
+
+type workerReadinessStatus struct {
+	Ready   bool                 `json:"ready"`
+	Mode    readinessMode        `json:"mode"`
+	Missing []string             `json:"missing"`
+	Workers map[string]time.Time `json:"workers"`
+}
+
+type workerNotReadyError struct {
+	status workerReadinessStatus
+}
+
+func (e workerNotReadyError) Error() string {
+	return fmt.Sprintf("workers not ready: %s", strings.Join(e.status.Missing, ", "))
+}
+
+type workerReadiness struct {
+	mu       sync.Mutex
+	required []string
+	lastSeen map[string]time.Time
+	ttl      time.Duration
+	mode     readinessMode
+}
+
+func newWorkerReadiness(required []string, mode readinessMode) *workerReadiness {
+	return &workerReadiness{
+		mu:       sync.Mutex{},
+		required: slices.Clone(required),
+		lastSeen: map[string]time.Time{},
+		ttl:      workerReadyHeartbeatInterval * workerReadyMissedHeartbeats,
+		mode:     mode,
+	}
+}
+
+// readinessModeFromEnv reads PAAS_READINESS_MODE. "reject" (default) answers
+// 503 until workers are ready; "queue" accepts ops and leaves them in the
+// durable worker stream for delivery once consumers bind.
+func readinessModeFromEnv() readinessMode {
+	switch readinessMode(strings.TrimSpace(strings.ToLower(os.Getenv(readinessModeEnv)))) {
+	case readinessModeQueue:
+		return readinessModeQueue
+	case readinessModeReject:
+		return readinessModeReject
+	default:
+		return readinessModeReject
+	}
+}
+
+func (r *workerReadiness) subscribe(nc *nats.Conn) (*nats.Subscription, error) {
+	return nc.Subscribe(natsSubject(subjectWorkerReady), func(msg *nats.Msg) {
+		var ready WorkerReadyMsg
+		if err := json.Unmarshal(msg.Data, &ready); err != nil {
+			return
+		}
+		r.observe(ready.Worker, ready.At)
+	})
+}
+
+func (r *workerReadiness) observe(worker string, at time.Time) {
+	worker = strings.TrimSpace(worker)
+	if worker == "" {
+		return
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastSeen[worker] = at
+}
+
+func (r *workerReadiness) status() workerReadinessStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	out := workerReadinessStatus{
+		Ready:   true,
+		Mode:    r.mode,
+		Missing: []string{},
+		Workers: map[string]time.Time{},
+	}
+	for _, worker := range r.required {
+		seen, ok := r.lastSeen[worker]
+		if ok {
+			out.Workers[worker] = seen
+		}
+		if !ok || now.Sub(seen) > r.ttl {
+			out.Ready = false
+			out.Missing = append(out.Missing, worker)
+		}
+	}
+	return out
+}
+
+// admit reports whether a new op may be enqueued. A nil tracker means no
+// gating (tests and embedded callers that start workers synchronously).
+func (r *workerReadiness) admit() error {
+	if r == nil {
+		return nil
+	}
+	status := r.status()
+	if status.Ready || status.Mode == readinessModeQueue {
+		return nil
+	}
+	return workerNotReadyError{status: status}
+}
+
+// publishWorkerReadyHeartbeats announces that workerName's consumer is bound,
+// then repeats on an interval so the API notices a worker that goes away.
+func publishWorkerReadyHeartbeats(ctx context.Context, nc *nats.Conn, workerName, consumer string) {
+	ticker := time.NewTicker(workerReadyHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		body, _ := json.Marshal(WorkerReadyMsg{
+			Worker:   workerName,
+			Consumer: consumer,
+			At:       time.Now().UTC(),
+		})
+		_ = nc.Publish(natsSubject(subjectWorkerReady), body)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeWorkersNotReady(w http.ResponseWriter, err error) bool {
+	var notReady workerNotReadyError
+	if !errors.As(err, &notReady) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(workerReadyHeartbeatInterval.Seconds())))
+	writeErrorDetails(w, http.StatusServiceUnavailable, errorCodeWorkersNotReady, map[string]any{
+		"accepted":  false,
+		"reason":    notReady.Error(),
+		"missing":   notReady.status.Missing,
+		"next_step": "retry after workers finish starting; see /api/readyz",
+	})
+	return true
+}
@@ -0,0 +1,90 @@
+//nolint:testpackage,exhaustruct // Readiness tests inspect the unexported tracker and API wiring.
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWorkerReadiness_GatesUntilAllWorkersHeartbeat(t *testing.T) {
+	readiness := newWorkerReadiness([]string{"registrar", "imageBuilder"}, readinessModeReject)
+	api := &API{readiness: readiness}
+	srv := httptest.NewServer(http.HandlerFunc(api.handleReadyz))
+	defer srv.Close()
+
+	readiness.observe("registrar", time.Now().UTC())
+	var notReady workerNotReadyError
+	if err := readiness.admit(); !errors.As(err, &notReady) {
+		t.Fatalf("expected not-ready error, got %v", err)
+	}
+	if len(notReady.status.Missing) != 1 || notReady.status.Missing[0] != "imageBuilder" {
+		t.Fatalf("expected imageBuilder missing, got %#v", notReady.status.Missing)
+	}
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("readyz: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before warm-up, got %d", resp.StatusCode)
+	}
+
+	readiness.observe("imageBuilder", time.Now().UTC())
+	if err = readiness.admit(); err != nil {
+		t.Fatalf("expected admit after all heartbeats, got %v", err)
+	}
+	resp, err = srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("readyz: %v", err)
+	}
+	var status workerReadinessStatus
+	decodeErr := json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if decodeErr != nil || resp.StatusCode != http.StatusOK || !status.Ready {
+		t.Fatalf("expected ready 200, got %d %#v (%v)", resp.StatusCode, status, decodeErr)
+	}
+
+	readiness.observe("registrar", time.Now().UTC().Add(-readiness.ttl-time.Second))
+	if readiness.status().Ready {
+		t.Fatal("expected stale heartbeat to mark worker missing")
+	}
+}
+
+func TestWorkerReadiness_QueueModeAdmitsWhileWarmingUp(t *testing.T) {
+	readiness := newWorkerReadiness([]string{"registrar"}, readinessModeQueue)
+	if err := readiness.admit(); err != nil {
+		t.Fatalf("expected queue mode to admit, got %v", err)
+	}
+	if readiness.status().Ready {
+		t.Fatal("expected status to remain not ready in queue mode")
+	}
+}
+
+func TestWorkers_PublishReadyHeartbeatAfterConsumerBinds(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	readiness := newWorkerReadiness([]string{"registrar"}, readinessModeReject)
+	sub, err := readiness.subscribe(fixture.nc)
+	if err != nil {
+		t.Fatalf("subscribe readiness: %v", err)
+	}
+	defer func() { _ = sub.Unsubscribe() }()
+
+	ctx := t.Context()
+	worker := NewRegistrationWorker(fixture.endpoint, NewFSArtifacts(t.TempDir()), nil)
+	if err = worker.Start(ctx); err != nil {
+		t.Fatalf("start worker: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !readiness.status().Ready {
+		if time.Now().After(deadline) {
+			t.Fatal("expected registrar readiness heartbeat")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -41,7 +41,11 @@ func repoBootstrapWorkerAction(
 
 	switch msg.Kind {
 	case OpCreate, OpUpdate:
-		outcome, err = runRepoBootstrapCreateOrUpdate(ctx, artifacts, msg, spec)
+		if skipped, ok := specChangeSkipOutcome(msg, stageRepoBootstrap, "repo bootstrap"); ok {
+			outcome = skipped
+		} else {
+			outcome, err = runRepoBootstrapCreateOrUpdate(ctx, artifacts, msg, spec)
+		}
 	case OpDelete:
 		outcome, err = runRepoBootstrapDelete(artifacts, msg.ProjectID)
 	case OpCI:
@@ -49,6 +53,8 @@ func repoBootstrapWorkerAction(
 			message:   "repo bootstrap skipped for ci operation",
 			artifacts: nil,
 		}
+	case OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade, OpWebhookRefresh:
+		err = fmt.Errorf("repo bootstrap does not handle %s operations", msg.Kind)
 	case OpDeploy, OpPromote, OpRelease, OpRollback:
 		outcome = repoBootstrapOutcome{
 			message:   "repo bootstrap skipped for deployment/promotion/release/rollback operation",
@@ -110,21 +116,42 @@ func runRepoBootstrapCreateOrUpdate(
 	msg ProjectOpMsg,
 	spec ProjectSpec,
 ) (repoBootstrapOutcome, error) {
+	subStepDone := beginSubStep(ctx, "ensure local git repos")
 	projectDir, sourceDir, manifestsDir, err := ensureBootstrapRepos(ctx, artifacts, msg.ProjectID)
+	subStepDone(err)
 	if err != nil {
 		return repoBootstrapOutcome{}, err
 	}
+	if spec.Repos.Source.URL != "" || spec.Repos.Manifests.URL != "" {
+		subStepDone = beginSubStep(ctx, "fetch remote repos")
+		err = syncGitRemote(ctx, sourceDir, spec.Repos.Source)
+		if err == nil {
+			err = syncGitRemote(ctx, manifestsDir, spec.Repos.Manifests)
+		}
+		subStepDone(err)
+		if err != nil {
+			return repoBootstrapOutcome{}, err
+		}
+	}
 
 	touched := make([]string, 0, touchedArtifactsCap)
-	err = seedSourceRepo(msg, spec, projectDir, sourceDir, &touched)
-	if err != nil {
-		return repoBootstrapOutcome{message: "", artifacts: touched}, err
+	subStepDone = beginSubStep(ctx, "seed repo files")
+	if msg.Kind == OpCreate && msg.CloneOf != "" {
+		err = seedSourceRepoFromClone(ctx, artifacts, msg.CloneOf, projectDir, sourceDir, &touched)
+	}
+	if err == nil {
+		err = seedSourceRepo(msg, spec, projectDir, sourceDir, &touched)
+	}
+	if err == nil {
+		err = seedManifestsRepo(msg, spec, projectDir, manifestsDir, &touched)
 	}
-	err = seedManifestsRepo(msg, spec, projectDir, manifestsDir, &touched)
+	subStepDone(err)
 	if err != nil {
 		return repoBootstrapOutcome{message: "", artifacts: touched}, err
 	}
+	subStepDone = beginSubStep(ctx, "commit bootstrap seeds")
 	err = commitBootstrapSeeds(ctx, msg, sourceDir, manifestsDir)
+	subStepDone(err)
 	if err != nil {
 		return repoBootstrapOutcome{message: "", artifacts: touched}, err
 	}
@@ -133,6 +160,9 @@ func runRepoBootstrapCreateOrUpdate(
 	if err != nil {
 		return repoBootstrapOutcome{message: "", artifacts: touched}, err
 	}
+	if err = pushBootstrapRemotes(ctx, spec, sourceDir, manifestsDir); err != nil {
+		return repoBootstrapOutcome{message: "", artifacts: touched}, err
+	}
 	err = writeBootstrapSummary(ctx, msg, projectDir, sourceDir, manifestsDir, webhookURL, &touched)
 	if err != nil {
 		return repoBootstrapOutcome{message: "", artifacts: touched}, err
@@ -3,6 +3,7 @@ package platform
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 )
 
@@ -34,6 +35,14 @@ func seedSourceRepo(
 	projectDir, sourceDir string,
 	touched *[]string,
 ) error {
+	// A template seeds its files once, when the project is created; later
+	// ops leave the repo to its owners. A clone already has its files.
+	cloned := msg.CloneOf != ""
+	if spec.Template != "" && msg.Kind == OpCreate && !cloned {
+		if err := seedSourceRepoFromTemplate(spec, projectDir, sourceDir, touched); err != nil {
+			return err
+		}
+	}
 	sourceReadme := filepath.Join(sourceDir, "README.md")
 	sourceReadmeBody := fmt.Appendf(nil, "# %s source\n\nRuntime: %s\n", spec.Name, spec.Runtime)
 	readmeCreated, err := writeFileIfMissing(
@@ -45,18 +54,11 @@ func seedSourceRepo(
 	}
 	recordTouched(projectDir, touched, sourceReadme, readmeCreated)
 
-	sourceMain := filepath.Join(sourceDir, "main.go")
-	sourceMainBody := fmt.Appendf(nil, `package main
-
-import "fmt"
-
-func main() { fmt.Println("hello from %s") }
-`, spec.Name)
-	mainCreated, err := writeFileIfMissing(sourceMain, sourceMainBody)
-	if err != nil {
-		return err
+	if spec.Template == "" && !cloned {
+		if err = seedSourceMain(spec, projectDir, sourceDir, touched); err != nil {
+			return err
+		}
 	}
-	recordTouched(projectDir, touched, sourceMain, mainCreated)
 
 	sourceRepoMeta := filepath.Join(sourceDir, ".paas", "repo.json")
 	metaUpdated, err := upsertFile(sourceRepoMeta, mustJSON(map[string]any{
@@ -72,6 +74,50 @@ func main() { fmt.Println("hello from %s") }
 	return nil
 }
 
+// seedSourceRepoFromClone writes the files at the HEAD of cloneOf's source
+// repo into a new project's repo, which then commits them as its own first
+// commit: the clone starts without the original's history. The original's
+// .paas metadata is dropped; bootstrap writes the clone's own.
+func seedSourceRepoFromClone(
+	ctx context.Context,
+	artifacts ArtifactStore,
+	cloneOf string,
+	projectDir, sourceDir string,
+	touched *[]string,
+) error {
+	originDir := sourceRepoDir(artifacts, cloneOf)
+	head, err := gitRevParse(ctx, originDir, "HEAD")
+	if err != nil {
+		return fmt.Errorf("read source repo of %s: %w", cloneOf, err)
+	}
+	if err = gitExportCommit(ctx, originDir, head, sourceDir); err != nil {
+		return fmt.Errorf("copy source repo of %s: %w", cloneOf, err)
+	}
+	if err = os.RemoveAll(filepath.Join(sourceDir, ".paas")); err != nil {
+		return err
+	}
+	recordTouched(projectDir, touched, sourceDir, true)
+	return nil
+}
+
+// seedSourceMain writes the generic hello-world main.go of a project made
+// without a template.
+func seedSourceMain(spec ProjectSpec, projectDir, sourceDir string, touched *[]string) error {
+	sourceMain := filepath.Join(sourceDir, "main.go")
+	sourceMainBody := fmt.Appendf(nil, `package main
+
+import "fmt"
+
+func main() { fmt.Println("hello from %s") }
+`, spec.Name)
+	mainCreated, err := writeFileIfMissing(sourceMain, sourceMainBody)
+	if err != nil {
+		return err
+	}
+	recordTouched(projectDir, touched, sourceMain, mainCreated)
+	return nil
+}
+
 func seedManifestsRepo(
 	msg ProjectOpMsg,
 	spec ProjectSpec,
@@ -129,24 +175,28 @@ func commitBootstrapSeeds(
 	return manifestsCommitErr
 }
 
+// pushBootstrapRemotes pushes the bootstrap commits of repos with a remote.
+func pushBootstrapRemotes(ctx context.Context, spec ProjectSpec, sourceDir, manifestsDir string) error {
+	if spec.Repos.Source.URL == "" && spec.Repos.Manifests.URL == "" {
+		return nil
+	}
+	subStepDone := beginSubStep(ctx, "push remote repos")
+	err := pushGitRemote(ctx, sourceDir, spec.Repos.Source)
+	if err == nil {
+		err = pushGitRemote(ctx, manifestsDir, spec.Repos.Manifests)
+	}
+	subStepDone(err)
+	return err
+}
+
 func configureSourceWebhook(
 	ctx context.Context,
 	msg ProjectOpMsg,
 	projectDir, sourceDir string,
 	touched *[]string,
 ) (string, error) {
-	webhookURL := sourceWebhookEndpoint()
-	if err := installSourceWebhookHooks(sourceDir, msg.ProjectID, webhookURL); err != nil {
-		return "", err
-	}
-	webhookMeta := filepath.Join(sourceDir, ".paas", "webhook.json")
-	updated, err := upsertFile(webhookMeta, mustJSON(map[string]any{
-		"project_id": msg.ProjectID,
-		"repo":       "source",
-		"branch":     branchMain,
-		"endpoint":   webhookURL,
-		"hooks":      []string{"post-commit", "post-merge"},
-	}))
+	webhookURL := sourceWebhookEndpoint(ctx)
+	webhookMeta, updated, err := writeSourceWebhook(sourceDir, msg.ProjectID, webhookURL)
 	if err != nil {
 		return "", err
 	}
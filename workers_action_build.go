@@ -17,6 +17,9 @@ const (
 	buildKitMetadataPath     = "build/buildkit-metadata.json"
 	buildKitLogPath          = "build/buildkit.log"
 	buildKitArtifactsCount   = 3
+
+	imageBuildProgressBuilding  = 10
+	imageBuildProgressArtifacts = 90
 )
 
 func imageBuilderWorkerActionWithMode(
@@ -45,9 +48,15 @@ func imageBuilderWorkerActionWithMode(
 
 	switch msg.Kind {
 	case OpCreate, OpUpdate, OpCI:
-		outcome, err = runImageBuilderBuildWithMode(ctx, artifacts, msg, spec, imageTag, modeResolution)
+		if skipped, ok := specChangeSkipOutcome(msg, stageImageBuilder, "image build"); ok {
+			outcome = skipped
+		} else {
+			outcome, err = runImageBuilderBuildWithMode(ctx, artifacts, msg, spec, imageTag, modeResolution)
+		}
 	case OpDelete:
 		outcome, err = runImageBuilderDelete(artifacts, msg.ProjectID, msg.OpID)
+	case OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade, OpWebhookRefresh:
+		err = fmt.Errorf("image builder does not handle %s operations", msg.Kind)
 	case OpDeploy, OpPromote, OpRelease, OpRollback:
 		outcome = repoBootstrapOutcome{
 			message:   "image build skipped for deployment/promotion/release/rollback operation",
@@ -121,8 +130,36 @@ func runImageBuilderBuildWithMode(
 	imageTag string,
 	modeResolution imageBuilderModeResolution,
 ) (repoBootstrapOutcome, error) {
+	if msg.Kind == OpCI {
+		if err := fetchCISourceRemote(ctx, artifacts, msg, spec); err != nil {
+			return newRepoBootstrapOutcome(), err
+		}
+	}
+	contextDir, err := ciBuildContextDir(ctx, artifacts, msg)
+	if err != nil {
+		return newRepoBootstrapOutcome(), err
+	}
+	if spec.Build.strategy() == buildStrategyBuildpacks {
+		// pack builds against the local Docker daemon, so the BuildKit mode
+		// and its policy do not apply.
+		req := imageBuildRequest{
+			OpID:              msg.OpID,
+			ProjectID:         msg.ProjectID,
+			Spec:              spec,
+			ImageTag:          imageTag,
+			ContextDir:        contextDir,
+			DockerfileBody:    nil,
+			DockerfileRelPath: "",
+		}
+		return runImageBuilderBuildWithBackend(
+			ctx, artifacts, msg, modeResolution, buildpacksBackendFor(spec), req, nil,
+		)
+	}
+
+	subStepDone := beginSubStep(ctx, "render Dockerfile")
 	dockerfileBody := renderImageBuilderDockerfile(spec)
 	dockerfilePath, err := artifacts.WriteFile(msg.ProjectID, imageBuildDockerfilePath, dockerfileBody)
+	subStepDone(err)
 	if err != nil {
 		return newRepoBootstrapOutcome(), err
 	}
@@ -144,7 +181,7 @@ func runImageBuilderBuildWithMode(
 		ProjectID:         msg.ProjectID,
 		Spec:              spec,
 		ImageTag:          imageTag,
-		ContextDir:        sourceRepoDir(artifacts, msg.ProjectID),
+		ContextDir:        contextDir,
 		DockerfileBody:    dockerfileBody,
 		DockerfileRelPath: imageBuildDockerfilePath,
 	}
@@ -188,8 +225,14 @@ func runImageBuilderBuildWithBackend(
 	buildCtx, cancel := context.WithTimeout(ctx, buildOpTimeout)
 	defer cancel()
 
+	reportStepProgress(ctx, fmt.Sprintf("building %s with %s backend", req.ImageTag, backend.name()), imageBuildProgressBuilding)
+	subStepDone := beginSubStep(ctx, "build image with "+backend.name())
 	result, backendErr := backend.build(buildCtx, req)
-	buildKitArtifacts, writeBuildKitErr := maybeWriteBuildKitArtifacts(
+	subStepDone(backendErr)
+	traceCommand(ctx, fmt.Sprintf("%s build -t %s", backend.name(), req.ImageTag), result.logs, backendErr)
+	reportStepProgress(ctx, "writing build artifacts", imageBuildProgressArtifacts)
+	subStepDone = beginSubStep(ctx, "export build artifacts")
+	buildKitArtifacts, writeBuildKitErr := writeBackendBuildArtifacts(
 		artifacts,
 		msg,
 		modeResolution,
@@ -198,6 +241,7 @@ func runImageBuilderBuildWithBackend(
 		result,
 		backendErr,
 	)
+	subStepDone(writeBuildKitErr)
 	outcome.artifacts = append(outcome.artifacts, buildKitArtifacts...)
 	if writeBuildKitErr != nil {
 		if backendErr != nil {
@@ -208,7 +252,11 @@ func runImageBuilderBuildWithBackend(
 	if backendErr != nil {
 		return outcome, backendErr
 	}
+	if err := opCancelCause(ctx); err != nil {
+		return outcome, err
+	}
 
+	subStepDone = beginSubStep(ctx, "publish image")
 	publishPath, err := writeImagePublishArtifacts(
 		artifacts,
 		msg,
@@ -218,10 +266,12 @@ func runImageBuilderBuildWithBackend(
 		backend,
 	)
 	if err != nil {
+		subStepDone(err)
 		return outcome, err
 	}
 	outcome.artifacts = append(outcome.artifacts, publishPath)
 	imagePath, err := artifacts.WriteFile(msg.ProjectID, imageBuildTagPath, []byte(req.ImageTag+"\n"))
+	subStepDone(err)
 	if err != nil {
 		return outcome, err
 	}
@@ -236,6 +286,23 @@ func runImageBuilderBuildWithBackend(
 	return outcome, nil
 }
 
+// writeBackendBuildArtifacts writes the records a backend leaves behind on
+// success and failure alike.
+func writeBackendBuildArtifacts(
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	modeResolution imageBuilderModeResolution,
+	backend imageBuilderBackend,
+	req imageBuildRequest,
+	result imageBuildResult,
+	backendErr error,
+) ([]string, error) {
+	if pack, ok := backend.(buildpacksImageBuilderBackend); ok {
+		return writeBuildpacksArtifacts(artifacts, msg, pack, req, result, backendErr)
+	}
+	return maybeWriteBuildKitArtifacts(artifacts, msg, modeResolution, backend, req, result, backendErr)
+}
+
 func maybeWriteBuildKitArtifacts(
 	artifacts ArtifactStore,
 	msg ProjectOpMsg,
@@ -309,6 +376,7 @@ func writeImagePublishArtifacts(
 		"project_id":      msg.ProjectID,
 		"image":           imageTag,
 		"runtime":         spec.Runtime,
+		"build_strategy":  spec.Build.strategy(),
 		"published_at":    time.Now().UTC().Format(time.RFC3339),
 		"daemon_target":   "local",
 		"builder_backend": backend.name(),
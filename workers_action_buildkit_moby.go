@@ -5,6 +5,7 @@ package platform
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -103,7 +104,15 @@ func (buildKitImageBuilderBackend) build(
 			},
 		},
 	}
-	solveResp, err := buildkitClient.Solve(ctx, nil, solveOpt, nil)
+	statusCh := make(chan *client.SolveStatus)
+	statusDone := make(chan struct{})
+	go func() {
+		defer close(statusDone)
+		writeBuildKitStatus(opLogStream(ctx, "buildkit solve "+req.ImageTag), statusCh)
+	}()
+	// Solve closes statusCh when it returns.
+	solveResp, err := buildkitClient.Solve(ctx, nil, solveOpt, statusCh)
+	<-statusDone
 	if err != nil {
 		return imageBuildResult{
 			message: "buildkit image build failed",
@@ -131,6 +140,24 @@ func (buildKitImageBuilderBackend) build(
 	}, nil
 }
 
+// writeBuildKitStatus writes each vertex's name as it starts, and whatever its
+// steps print, the way `docker build --progress=plain` does.
+func writeBuildKitStatus(w io.Writer, statusCh <-chan *client.SolveStatus) {
+	started := map[string]bool{}
+	for status := range statusCh {
+		for _, vertex := range status.Vertexes {
+			if vertex.Started == nil || started[vertex.Digest.String()] {
+				continue
+			}
+			started[vertex.Digest.String()] = true
+			_, _ = fmt.Fprintf(w, "#%d %s\n", len(started), vertex.Name)
+		}
+		for _, log := range status.Logs {
+			_, _ = w.Write(log.Data)
+		}
+	}
+}
+
 func buildkitAddress() string {
 	raw := strings.TrimSpace(os.Getenv(buildkitAddressEnv))
 	if raw == "" {
@@ -0,0 +1,282 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Buildpacks build strategy: instead of the generated Dockerfile, imageBuilder
+// runs `pack build` on the source repo and the builder's buildpacks work out
+// how to build it. The project runtime picks the language and its version.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	buildStrategyDockerfile = "dockerfile"
+	buildStrategyBuildpacks = "buildpacks"
+
+	defaultPackBinary          = "pack"
+	defaultBuildpacksBuilder   = "paketobuildpacks/builder-jammy-base"
+	buildpacksPlanPath         = "build/buildpacks-plan.json"
+	buildpacksLogPath          = "build/buildpacks.log"
+	buildpacksArtifactsCount   = 2
+	maxBuildpacksBuilderLength = 512
+	maxBuildpacksLogBytes      = 1 << 20
+)
+
+// buildpackRef is one buildpack the detect phase chose.
+type buildpackRef struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// buildpacksPlan is the record of a buildpacks build, written whether or not
+// the build succeeded.
+type buildpacksPlan struct {
+	ProjectID      string            `json:"project_id"`
+	OpID           string            `json:"op_id"`
+	Builder        string            `json:"builder"`
+	Runtime        string            `json:"runtime"`
+	Language       string            `json:"language"`
+	BuildEnv       map[string]string `json:"build_env,omitempty"`
+	Buildpacks     []buildpackRef    `json:"buildpacks"`
+	Image          string            `json:"image"`
+	ImageReference string            `json:"image_reference,omitempty"`
+	Status         string            `json:"status"`
+	Failure        string            `json:"failure,omitempty"`
+	CompletedAt    time.Time         `json:"completed_at"`
+}
+
+type buildpacksImageBuilderBackend struct {
+	binary  string
+	builder string
+}
+
+func (c BuildConfig) strategy() string {
+	if c.Strategy == "" {
+		return buildStrategyDockerfile
+	}
+	return c.Strategy
+}
+
+func validateBuildConfig(spec ProjectSpec) error {
+	switch spec.Build.Strategy {
+	case "", buildStrategyDockerfile:
+		if spec.Build.Builder != "" {
+			return specFieldErrorf("build.builder", "build.builder applies only to the %s strategy",
+				buildStrategyBuildpacks)
+		}
+		return nil
+	case buildStrategyBuildpacks:
+	default:
+		return specFieldErrorf("build.strategy", "build.strategy must be %q or %q",
+			buildStrategyDockerfile, buildStrategyBuildpacks)
+	}
+	if _, _, ok := buildpacksRuntime(spec.Runtime); !ok {
+		return specFieldErrorf("build.strategy",
+			"build.strategy %s needs a go, node, or python runtime, not %q", buildStrategyBuildpacks, spec.Runtime,
+		)
+	}
+	builder := spec.Build.Builder
+	if len(builder) > maxBuildpacksBuilderLength || strings.ContainsAny(builder, " \t\r\n") ||
+		strings.HasPrefix(builder, "-") {
+		return specFieldErrorf("build.builder", "invalid build.builder %q", builder)
+	}
+	return nil
+}
+
+// buildpacksRuntime splits a runtime such as go_1.26 or node-22 into the
+// language buildpacks detect and its version, which is empty when the runtime
+// names none.
+func buildpacksRuntime(runtime string) (string, string, bool) {
+	name, version := runtime, ""
+	if i := strings.IndexAny(runtime, "_-"); i >= 0 {
+		name, version = runtime[:i], runtime[i+1:]
+	}
+	if version != "" && (version[0] < '0' || version[0] > '9') {
+		version = ""
+	}
+	switch name {
+	case "go", "golang":
+		return "go", version, true
+	case "node", "nodejs":
+		return "node", version, true
+	case "python":
+		return "python", version, true
+	default:
+		return "", "", false
+	}
+}
+
+// buildpacksBuildEnv pins the language version through the variable the
+// Paketo buildpacks read; without a version the builder's default is used.
+func buildpacksBuildEnv(runtime string) map[string]string {
+	language, version, ok := buildpacksRuntime(runtime)
+	if !ok || version == "" {
+		return map[string]string{}
+	}
+	name := map[string]string{
+		"go":     "BP_GO_VERSION",
+		"node":   "BP_NODE_VERSION",
+		"python": "BP_CPYTHON_VERSION",
+	}[language]
+	return map[string]string{name: version + ".*"}
+}
+
+func buildpacksBackendFor(spec ProjectSpec) buildpacksImageBuilderBackend {
+	binary := strings.TrimSpace(os.Getenv(packBinaryEnv))
+	if binary == "" {
+		binary = defaultPackBinary
+	}
+	builder := spec.Build.Builder
+	if builder == "" {
+		builder = strings.TrimSpace(os.Getenv(buildpacksBuilderEnv))
+	}
+	if builder == "" {
+		builder = defaultBuildpacksBuilder
+	}
+	return buildpacksImageBuilderBackend{binary: binary, builder: builder}
+}
+
+func (buildpacksImageBuilderBackend) name() string {
+	return buildStrategyBuildpacks
+}
+
+func (b buildpacksImageBuilderBackend) args(req imageBuildRequest) []string {
+	args := []string{"build", req.ImageTag, "--path", req.ContextDir, "--builder", b.builder}
+	env := buildpacksBuildEnv(req.Spec.Runtime)
+	for _, key := range sortedKeys(env) {
+		args = append(args, "--env", key+"="+env[key])
+	}
+	return args
+}
+
+func (b buildpacksImageBuilderBackend) build(
+	ctx context.Context,
+	req imageBuildRequest,
+) (imageBuildResult, error) {
+	if err := ensureContextAlive(ctx); err != nil {
+		return imageBuildResult{}, err
+	}
+	args := b.args(req)
+	// #nosec G204 -- the binary is operator configuration; the builder is validated and the rest are platform values.
+	cmd := exec.CommandContext(ctx, b.binary, args...)
+	var output bytes.Buffer
+	stream := io.MultiWriter(&output, opLogStream(ctx, b.binary+" "+strings.Join(args, " ")))
+	cmd.Stdout = stream
+	cmd.Stderr = stream
+	runErr := cmd.Run()
+
+	logs := output.String()
+	if len(logs) > maxBuildpacksLogBytes {
+		logs = logs[len(logs)-maxBuildpacksLogBytes:]
+	}
+	metadata := map[string]any{
+		"strategy":       buildStrategyBuildpacks,
+		"builder":        b.builder,
+		"command":        b.binary + " " + strings.Join(args, " "),
+		"build_executed": runErr == nil,
+	}
+	if runErr != nil {
+		buildErr := fmt.Errorf("pack build %s: %w", req.ImageTag, runErr)
+		return imageBuildResult{
+			message:  "buildpacks image build failed",
+			summary:  buildErr.Error(),
+			metadata: metadata,
+			logs:     logs,
+		}, buildErr
+	}
+	return imageBuildResult{
+		message:  "container image built with buildpacks and published to local daemon",
+		summary:  "pack build completed with builder " + b.builder,
+		metadata: metadata,
+		logs:     logs,
+	}, nil
+}
+
+// parseBuildpacksOutput reads the buildpacks chosen in pack's DETECTING phase
+// and the image digest from its output. Lifecycle lines may carry a
+// "[detector] " style prefix, depending on whether the builder is trusted.
+func parseBuildpacksOutput(logs string) ([]buildpackRef, string) {
+	refs := []buildpackRef{}
+	digest := ""
+	phase := ""
+	for line := range strings.Lines(logs) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			if _, rest, ok := strings.Cut(line, "] "); ok {
+				line = strings.TrimSpace(rest)
+			}
+		}
+		if rest, ok := strings.CutPrefix(line, "===> "); ok {
+			phase = strings.TrimSpace(rest)
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "*** Images ("); ok {
+			digest = strings.TrimSuffix(rest, "):")
+			continue
+		}
+		fields := strings.Fields(line)
+		if phase == "DETECTING" && len(fields) == 2 && strings.Contains(fields[0], "/") {
+			refs = append(refs, buildpackRef{ID: fields[0], Version: fields[1]})
+		}
+	}
+	return refs, digest
+}
+
+func writeBuildpacksArtifacts(
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	backend buildpacksImageBuilderBackend,
+	req imageBuildRequest,
+	result imageBuildResult,
+	backendErr error,
+) ([]string, error) {
+	language, _, _ := buildpacksRuntime(req.Spec.Runtime)
+	refs, digest := parseBuildpacksOutput(result.logs)
+	plan := buildpacksPlan{
+		ProjectID:      msg.ProjectID,
+		OpID:           msg.OpID,
+		Builder:        backend.builder,
+		Runtime:        req.Spec.Runtime,
+		Language:       language,
+		BuildEnv:       buildpacksBuildEnv(req.Spec.Runtime),
+		Buildpacks:     refs,
+		Image:          req.ImageTag,
+		ImageReference: "",
+		Status:         "ok",
+		Failure:        "",
+		CompletedAt:    time.Now().UTC(),
+	}
+	switch {
+	case backendErr != nil:
+		plan.Status = "failed"
+		plan.Failure = backendErr.Error()
+	case digest != "":
+		plan.ImageReference = req.ImageTag + "@" + digest
+	default:
+		plan.ImageReference = req.ImageTag
+	}
+	logBody := strings.TrimSpace(result.logs)
+	if logBody == "" {
+		logBody = "(no pack output)"
+	}
+
+	written := make([]string, 0, buildpacksArtifactsCount)
+	planPath, err := artifacts.WriteFile(msg.ProjectID, buildpacksPlanPath, mustJSON(plan))
+	if err != nil {
+		return written, err
+	}
+	written = append(written, planPath)
+	logPath, err := artifacts.WriteFile(msg.ProjectID, buildpacksLogPath, []byte(logBody+"\n"))
+	if err != nil {
+		return written, err
+	}
+	return append(written, logPath), nil
+}
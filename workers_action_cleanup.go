@@ -0,0 +1,137 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// artifactCleanupRoots are the artifact trees a scoped cleanup may touch.
+// Repos and registration records are never eligible: they back the project
+// itself rather than disposable outputs.
+func artifactCleanupRoots() []string {
+	return []string{
+		"build", "deploy", "promotions", "releases", runtimeUpgradeArtifactRoot, opTraceArtifactRoot,
+		evidenceArtifactRoot,
+	}
+}
+
+// normalizeArtifactCleanupPrefix cleans a cleanup prefix and rejects anything
+// outside artifactCleanupRoots, including traversal attempts.
+func normalizeArtifactCleanupPrefix(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("prefix required")
+	}
+	if strings.HasPrefix(raw, "/") || slices.Contains(strings.Split(raw, "/"), "..") {
+		return "", errors.New("prefix must be a relative artifact path")
+	}
+	prefix := path.Clean(raw)
+	root, _, _ := strings.Cut(prefix, "/")
+	if !slices.Contains(artifactCleanupRoots(), root) {
+		return "", fmt.Errorf("prefix must start with one of %s/", strings.Join(artifactCleanupRoots(), "/, "))
+	}
+	return prefix, nil
+}
+
+// artifactCleanupTouchesReleaseEvidence reports whether the prefix can remove
+// snapshots that releases point at, which compliance holds protect. Preview
+// renders are never released.
+func artifactCleanupTouchesReleaseEvidence(prefix string) bool {
+	if prefix == previewArtifactRoot || strings.HasPrefix(prefix, previewArtifactRoot+"/") {
+		return false
+	}
+	root, _, _ := strings.Cut(prefix, "/")
+	return root != "build" && root != runtimeUpgradeArtifactRoot && root != opTraceArtifactRoot
+}
+
+func artifactCleanupWorkerAction(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+) (WorkerResultMsg, error) {
+	res := newWorkerResultMsg("artifact cleanup worker starting")
+	_ = markOpStepStart(
+		ctx,
+		store,
+		msg.OpID,
+		"artifactCleaner",
+		time.Now().UTC(),
+		fmt.Sprintf("remove artifacts under %s", msg.ArtifactPrefix),
+	)
+
+	removed, err := runArtifactCleanup(ctx, artifacts, msg)
+	if err != nil {
+		_ = markOpStepEnd(ctx, store, msg.OpID, "artifactCleaner", time.Now().UTC(), "", err.Error(), nil)
+		_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "error", err.Error())
+		return res, err
+	}
+
+	res.Message = fmt.Sprintf("removed %d artifact file(s) under %s", len(removed), msg.ArtifactPrefix)
+	forgetTornDownPreview(ctx, store, msg)
+	_ = markOpStepEnd(ctx, store, msg.OpID, "artifactCleaner", time.Now().UTC(), res.Message, "", nil)
+	_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "done", "")
+	return res, nil
+}
+
+func runArtifactCleanup(ctx context.Context, artifacts ArtifactStore, msg ProjectOpMsg) ([]string, error) {
+	if msg.Kind != OpCleanup {
+		return nil, fmt.Errorf("artifact cleanup worker only handles %s operations", OpCleanup)
+	}
+	// Re-validate: the message may have been queued by another API replica.
+	prefix, err := normalizeArtifactCleanupPrefix(msg.ArtifactPrefix)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := artifacts.RemoveFiles(msg.ProjectID, prefix)
+	traceCommand(ctx, "remove "+prefix, strings.Join(removed, "\n"), err)
+	writeArtifactCleanupAudit(artifacts, msg, prefix, removed, err)
+	return removed, err
+}
+
+// writeArtifactCleanupAudit appends to _audit beside the project directories
+// so the record of what was removed outlives the files themselves.
+func writeArtifactCleanupAudit(
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	prefix string,
+	removed []string,
+	cleanupErr error,
+) {
+	auditDir := filepath.Join(filepath.Dir(artifacts.ProjectDir(msg.ProjectID)), "_audit")
+	if err := os.MkdirAll(auditDir, dirModePrivateRead); err != nil {
+		return
+	}
+	f, err := os.OpenFile(
+		filepath.Join(auditDir, fmt.Sprintf("%s.cleanup.log", msg.ProjectID)),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		fileModePrivate,
+	)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	outcome := "ok"
+	if cleanupErr != nil {
+		outcome = fmt.Sprintf("error=%q", cleanupErr.Error())
+	}
+	_, _ = fmt.Fprintf(
+		f,
+		"%s op=%s prefix=%s removed=%d %s\n",
+		time.Now().UTC().Format(time.RFC3339),
+		msg.OpID,
+		prefix,
+		len(removed),
+		outcome,
+	)
+	for _, rel := range removed {
+		_, _ = fmt.Fprintf(f, "  - %s\n", rel)
+	}
+}
@@ -26,8 +26,18 @@ func manifestRendererWorkerAction(
 	msg ProjectOpMsg,
 ) (WorkerResultMsg, error) {
 	workerLog := appLoggerForProcess().Source("manifestRenderer")
-	stepStart := time.Now().UTC()
 	res := newWorkerResultMsg("manifest renderer worker starting")
+	spec := normalizeProjectSpec(msg.Spec)
+	var provisioned []string
+	if (msg.Kind == OpCreate || msg.Kind == OpUpdate || msg.Kind == OpCI) && !isPreviewOp(msg) {
+		provision, provisionErr := runPostgresProvisionStep(ctx, store, artifacts, msg, spec)
+		provisioned = provision.artifacts
+		if provisionErr != nil {
+			res.Artifacts = provisioned
+			return res, failManifestRendererOp(ctx, store, artifacts, msg, provisionErr)
+		}
+	}
+	stepStart := time.Now().UTC()
 	_ = markOpStepStart(
 		ctx,
 		store,
@@ -37,13 +47,17 @@ func manifestRendererWorkerAction(
 		"render and deploy dev manifests from kustomize overlays",
 	)
 
-	spec := normalizeProjectSpec(msg.Spec)
-	imageTag := fmt.Sprintf("local/%s:%s", safeName(spec.Name), shortID(msg.OpID))
+	imageTag := updateImageTag(msg, spec)
+	targetEnv := renderTargetEnvironment(msg)
 	outcome := newRepoBootstrapOutcome()
 	var err error
 
 	switch msg.Kind {
 	case OpCreate, OpUpdate, OpCI:
+		if isPreviewOp(msg) {
+			outcome, err = runPreviewRender(ctx, store, artifacts, msg, spec, imageTag)
+			break
+		}
 		outcome, err = runManifestApplyForEnvironment(
 			ctx,
 			store,
@@ -51,11 +65,12 @@ func manifestRendererWorkerAction(
 			msg,
 			spec,
 			imageTag,
-			defaultDeployEnvironment,
+			targetEnv,
 		)
 	case OpDelete:
 		outcome, err = runManifestRendererDelete(ctx, store, artifacts, msg)
-	case OpDeploy, OpPromote, OpRelease, OpRollback:
+	case OpDeploy, OpPromote, OpRelease, OpRollback, OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade,
+		OpWebhookRefresh:
 		err = fmt.Errorf("manifest renderer does not handle %s operations", msg.Kind)
 	default:
 		err = fmt.Errorf("unknown op kind: %s", msg.Kind)
@@ -71,23 +86,11 @@ func manifestRendererWorkerAction(
 			err.Error(),
 			outcome.artifacts,
 		)
-		_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "error", err.Error())
-		if msg.Kind == OpCI {
-			stateErr := finalizeSourceCommitPendingOp(artifacts, msg.ProjectID, msg.OpID, false)
-			if stateErr != nil {
-				workerLog.Warnf(
-					"project=%s op=%s persist failed ci pending state: %v",
-					msg.ProjectID,
-					msg.OpID,
-					stateErr,
-				)
-			}
-		}
-		return res, err
+		return res, failManifestRendererOp(ctx, store, artifacts, msg, err)
 	}
 
 	res.Message = outcome.message
-	res.Artifacts = outcome.artifacts
+	res.Artifacts = append(provisioned, outcome.artifacts...)
 	_ = markOpStepEnd(
 		ctx,
 		store,
@@ -98,6 +101,13 @@ func manifestRendererWorkerAction(
 		"",
 		res.Artifacts,
 	)
+	if msg.Kind != OpDelete && !isPreviewOp(msg) {
+		applyOutcome, applyErr := runKubeApplyStep(ctx, store, artifacts, msg, spec, targetEnv)
+		res.Artifacts = append(res.Artifacts, applyOutcome.artifacts...)
+		if applyErr != nil {
+			return res, failManifestRendererOp(ctx, store, artifacts, msg, applyErr)
+		}
+	}
 	_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "done", "")
 	if msg.Kind == OpCI {
 		stateErr := finalizeSourceCommitPendingOp(artifacts, msg.ProjectID, msg.OpID, true)
@@ -113,6 +123,39 @@ func manifestRendererWorkerAction(
 	return res, nil
 }
 
+// renderTargetEnvironment is the environment a create, update, or ci op
+// renders: dev, unless a vars patch's ci op names another.
+func renderTargetEnvironment(msg ProjectOpMsg) string {
+	if msg.Kind == OpCI && msg.CI != nil && msg.CI.Environment != "" {
+		return msg.CI.Environment
+	}
+	return defaultDeployEnvironment
+}
+
+// failManifestRendererOp finalizes a failed manifestRenderer op; a failed CI
+// op also clears its pending source commit.
+func failManifestRendererOp(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	err error,
+) error {
+	_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "error", err.Error())
+	if msg.Kind == OpCI {
+		stateErr := finalizeSourceCommitPendingOp(artifacts, msg.ProjectID, msg.OpID, false)
+		if stateErr != nil {
+			appLoggerForProcess().Source("manifestRenderer").Warnf(
+				"project=%s op=%s persist failed ci pending state: %v",
+				msg.ProjectID,
+				msg.OpID,
+				stateErr,
+			)
+		}
+	}
+	return err
+}
+
 func deploymentWorkerAction(
 	ctx context.Context,
 	store *Store,
@@ -190,6 +233,12 @@ func deploymentWorkerAction(
 		"",
 		res.Artifacts,
 	)
+	applyOutcome, err := runKubeApplyStep(ctx, store, artifacts, msg, spec, targetEnv)
+	res.Artifacts = append(res.Artifacts, applyOutcome.artifacts...)
+	if err != nil {
+		_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "error", err.Error())
+		return res, err
+	}
 	_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "done", "")
 	return res, nil
 }
@@ -234,8 +283,9 @@ func persistDeployReleaseRecord(
 	return persistReleaseRecord(
 		ctx,
 		store,
+		artifacts,
 		ReleaseRecord{
-			ID:                    "",
+			ID:                    releaseIDForOp(msg.OpID),
 			ProjectID:             msg.ProjectID,
 			Environment:           targetEnv,
 			OpID:                  msg.OpID,
@@ -249,7 +299,11 @@ func persistDeployReleaseRecord(
 			RollbackSafe:          rollbackSafeDefaultPtr(),
 			RollbackSourceRelease: "",
 			RollbackScope:         "",
+			SourceCommit:          readRenderedEnvSourceCommit(artifacts, msg.ProjectID, targetEnv),
+			SpecHash:              projectSpecHash(msg.Spec),
 			CreatedAt:             time.Now().UTC(),
+			Notes:                 nil,
+			Evidence:              nil,
 		},
 	)
 }
@@ -270,6 +324,9 @@ func runManifestApplyForEnvironment(
 	if !isValidEnvironmentName(targetEnv) {
 		return repoBootstrapOutcome{}, fmt.Errorf("invalid deployment environment %q", targetEnv)
 	}
+	if err := ensurePlainDelivery(artifacts, msg.ProjectID, targetEnv); err != nil {
+		return repoBootstrapOutcome{}, err
+	}
 
 	imageByEnv, err := loadManifestImageTags(artifacts, msg.ProjectID, spec)
 	if err != nil {
@@ -277,11 +334,22 @@ func runManifestApplyForEnvironment(
 	}
 	imageByEnv[targetEnv] = strings.TrimSpace(imageTag)
 
-	kustomizeArtifacts, err := writeKustomizeRepoFiles(artifacts, msg.ProjectID, spec, imageByEnv)
+	subStepDone := beginSubStep(ctx, "write kustomize overlays")
+	kustomizeArtifacts, err := writeStoredKustomizeRepoFiles(ctx, store, artifacts, msg.ProjectID, spec, imageByEnv)
+	subStepDone(err)
+	if err == nil {
+		err = opCancelCause(ctx)
+	}
+	if err != nil {
+		return repoBootstrapOutcome{message: "", artifacts: kustomizeArtifacts}, err
+	}
+	trace, err := newEnvManifestTrace(ctx, store, artifacts, msg, spec, targetEnv)
 	if err != nil {
 		return repoBootstrapOutcome{message: "", artifacts: kustomizeArtifacts}, err
 	}
-	rendered, err := renderEnvironmentManifestsFromRepo(artifacts, msg.ProjectID, targetEnv)
+	subStepDone = beginSubStep(ctx, "render "+targetEnv+" manifests")
+	rendered, err := renderEnvironmentManifestsFromRepo(artifacts, msg.ProjectID, targetEnv, trace)
+	subStepDone(err)
 	if err != nil {
 		return repoBootstrapOutcome{message: "", artifacts: kustomizeArtifacts}, err
 	}
@@ -311,6 +379,9 @@ func runManifestApplyForEnvironment(
 		manifestsDir,
 		fmt.Sprintf("platform-sync: deploy %s manifests (%s)", targetEnv, shortID(msg.OpID)),
 	)
+	if commitErr == nil {
+		commitErr = pushGitRemote(ctx, manifestsDir, spec.Repos.Manifests)
+	}
 	if commitErr != nil {
 		return repoBootstrapOutcome{
 			message:   "",
@@ -327,11 +398,39 @@ func runManifestApplyForEnvironment(
 	}, nil
 }
 
+// writeStoredKustomizeRepoFiles writes the manifests repo files with the
+// bindings, stored secrets, and dependents the store holds for projectID.
+func writeStoredKustomizeRepoFiles(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	imageByEnv map[string]string,
+) ([]string, error) {
+	bindings, err := loadEnvCapabilityBindings(ctx, store, projectID)
+	if err != nil {
+		return nil, err
+	}
+	storedSecrets, err := loadEnvStoredSecrets(ctx, store, projectID)
+	if err != nil {
+		return nil, err
+	}
+	dependents, err := loadProjectDependents(ctx, store, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return writeKustomizeRepoFiles(artifacts, projectID, spec, imageByEnv, bindings, storedSecrets, dependents)
+}
+
 func writeKustomizeRepoFiles(
 	artifacts ArtifactStore,
 	projectID string,
 	spec ProjectSpec,
 	imageByEnv map[string]string,
+	bindings envCapabilityBindings,
+	storedSecrets envStoredSecrets,
+	dependents []string,
 ) ([]string, error) {
 	spec = normalizeProjectSpec(spec)
 	files := []struct {
@@ -346,6 +445,10 @@ func writeKustomizeRepoFiles(
 			path: filepath.ToSlash(filepath.Join(manifestsRepoBaseDir, manifestFileService)),
 			data: renderServiceManifest(spec),
 		},
+		{
+			path: filepath.ToSlash(filepath.Join(manifestsRepoBaseDir, manifestFileServiceAccount)),
+			data: renderServiceAccountManifest(spec),
+		},
 		{
 			path: filepath.ToSlash(filepath.Join(manifestsRepoBaseDir, manifestFileKustomization)),
 			data: renderBaseKustomizationManifest(),
@@ -357,35 +460,13 @@ func writeKustomizeRepoFiles(
 	}
 
 	envs := desiredManifestEnvironments(spec)
+	rollouts, err := loadEnvRollouts(artifacts, projectID, envs)
+	if err != nil {
+		return nil, err
+	}
 	for _, env := range envs {
-		envImage := strings.TrimSpace(imageByEnv[env])
-		if envImage == "" {
-			envImage = defaultManifestImage(spec)
-		}
-		overlayDir := filepath.ToSlash(filepath.Join(manifestsRepoOverlaysDir, env))
 		files = append(files,
-			struct {
-				path string
-				data string
-			}{
-				path: filepath.ToSlash(filepath.Join(overlayDir, manifestFileKustomization)),
-				data: renderOverlayKustomizationManifest(envImage),
-			},
-			struct {
-				path string
-				data string
-			}{
-				path: filepath.ToSlash(filepath.Join(overlayDir, overlayDeploymentPatchFile)),
-				data: renderDeploymentEnvPatch(spec, env),
-			},
-			struct {
-				path string
-				data string
-			}{
-				path: filepath.ToSlash(filepath.Join(overlayDir, overlayImageMarkerFile)),
-				data: envImage + "\n",
-			},
-		)
+			overlayRepoFiles(spec, env, imageByEnv[env], rollouts, bindings, storedSecrets, dependents)...)
 	}
 
 	written := make([]string, 0, len(files))
@@ -396,9 +477,95 @@ func writeKustomizeRepoFiles(
 		}
 		written = append(written, artifactPath)
 	}
+	ingressArtifacts, err := writeOverlayIngresses(artifacts, projectID, spec, envs)
+	written = append(written, ingressArtifacts...)
+	if err != nil {
+		return written, err
+	}
+	hpaArtifacts, err := writeOverlayHPAs(artifacts, projectID, spec, envs)
+	written = append(written, hpaArtifacts...)
+	if err != nil {
+		return written, err
+	}
+	policyArtifacts, err := writeOverlayNetworkPolicies(artifacts, projectID, spec, envs, dependents)
+	written = append(written, policyArtifacts...)
+	if err != nil {
+		return written, err
+	}
+	capabilityArtifacts, err := writeOverlayCapabilities(artifacts, projectID, spec, envs, bindings)
+	written = append(written, capabilityArtifacts...)
+	if err != nil {
+		return written, err
+	}
+	canaryArtifacts, err := writeOverlayCanaries(
+		artifacts, projectID, spec, envs, rollouts.canaries, bindings, storedSecrets,
+	)
+	written = append(written, canaryArtifacts...)
+	if err != nil {
+		return written, err
+	}
+	colorArtifacts, err := writeOverlayBlueGreens(
+		artifacts, projectID, spec, envs, rollouts.blueGreens, bindings, storedSecrets,
+	)
+	written = append(written, colorArtifacts...)
+	if err != nil {
+		return written, err
+	}
+	chartArtifacts, err := writeHelmChart(artifacts, projectID, spec, imageByEnv, bindings, storedSecrets)
+	written = append(written, chartArtifacts...)
+	if err != nil {
+		return written, err
+	}
 	return uniqueSorted(written), nil
 }
 
+// overlayRepoFiles renders env's overlay: its kustomization, namespace,
+// deployment patch, and image marker.
+func overlayRepoFiles(
+	spec ProjectSpec,
+	env string,
+	envImage string,
+	rollouts envRollouts,
+	bindings envCapabilityBindings,
+	storedSecrets envStoredSecrets,
+	dependents []string,
+) []struct {
+	path string
+	data string
+} {
+	envImage = strings.TrimSpace(envImage)
+	if envImage == "" {
+		envImage = defaultManifestImage(spec)
+	}
+	canary := rollouts.canaries[env]
+	overlayDir := filepath.ToSlash(filepath.Join(manifestsRepoOverlaysDir, env))
+	return []struct {
+		path string
+		data string
+	}{
+		{
+			path: filepath.ToSlash(filepath.Join(overlayDir, manifestFileKustomization)),
+			data: renderOverlayKustomizationManifest(
+				spec, env, rollouts.kustomizeImage(env, envImage), rollouts.strategy(env), bindings[env], dependents,
+			),
+		},
+		{
+			path: filepath.ToSlash(filepath.Join(overlayDir, manifestFileNamespace)),
+			data: renderNamespaceManifest(spec, env),
+		},
+		{
+			path: filepath.ToSlash(filepath.Join(overlayDir, overlayDeploymentPatchFile)),
+			data: renderDeploymentPatch(
+				spec, env, safeName(spec.Name), canary.stablePatchReplicas(), bindings[env], storedSecrets[env],
+			),
+		},
+		{
+			path: filepath.ToSlash(filepath.Join(overlayDir, overlayImageMarkerFile)),
+			data: envImage + "\n",
+		},
+	}
+}
+
 func renderRootKustomizationManifest(defaultEnv string) string {
 	return fmt.Sprintf(`apiVersion: kustomize.config.k8s.io/v1beta1
 kind: Kustomization
@@ -463,6 +630,7 @@ func renderEnvironmentManifestsFromRepo(
 	artifacts ArtifactStore,
 	projectID string,
 	env string,
+	trace manifestTrace,
 ) (renderedProjectManifests, error) {
 	env = normalizeEnvironmentName(env)
 	overlayPath := filepath.Join(manifestsRepoDir(artifacts, projectID), "overlays", env)
@@ -470,6 +638,10 @@ func renderEnvironmentManifestsFromRepo(
 	if err != nil {
 		return renderedProjectManifests{}, err
 	}
+	rendered, err = stampManifestTrace(rendered, trace)
+	if err != nil {
+		return renderedProjectManifests{}, err
+	}
 	deployment, service, err := splitRenderedManifests(rendered)
 	if err != nil {
 		return renderedProjectManifests{}, err
@@ -567,27 +739,45 @@ func updateProjectReadyState(
 	if store == nil {
 		return
 	}
-	project, getErr := store.GetProject(ctx, msg.ProjectID)
-	if getErr != nil {
-		return
-	}
-	project.Spec = spec
-	project.Status = ProjectStatus{
-		Phase:      projectPhaseReady,
-		UpdatedAt:  time.Now().UTC(),
-		LastOpID:   msg.OpID,
-		LastOpKind: string(msg.Kind),
-		Message:    "ready",
-	}
-	_ = store.PutProject(ctx, project)
+	_, _ = store.updateProject(ctx, msg.ProjectID, func(project *Project) bool {
+		project.Spec = spec
+		project.Status = ProjectStatus{
+			Phase:      projectPhaseReady,
+			UpdatedAt:  time.Now().UTC(),
+			LastOpID:   msg.OpID,
+			LastOpKind: string(msg.Kind),
+			Message:    "ready",
+		}
+		return true
+	})
 }
 
-func persistReleaseRecord(ctx context.Context, store *Store, release ReleaseRecord) error {
+// persistReleaseRecord writes release with drafted notes and their
+// artifact. Notes are best effort: a source repo that cannot be read leaves
+// the release without them.
+func persistReleaseRecord(ctx context.Context, store *Store, artifacts ArtifactStore, release ReleaseRecord) error {
 	if store == nil {
 		return nil
 	}
-	_, err := store.PutRelease(ctx, release)
-	return err
+	logger := appLoggerForProcess().Source("releases")
+	if artifacts != nil {
+		if err := keepReleaseConfigSnapshot(artifacts, release); err != nil {
+			logger.Warnf("release=%s: keep config snapshot failed: %v", release.ID, err)
+		}
+	}
+	notes, err := draftReleaseNotes(ctx, store, artifacts, release)
+	if err != nil {
+		logger.Warnf("release=%s: draft notes failed: %v", release.ID, err)
+	}
+	release.Notes = notes
+	release.Evidence = artifactEvidence(artifacts, release.ProjectID)
+	if _, err = store.PutRelease(ctx, release); err != nil {
+		return err
+	}
+	if err = writeReleaseNotesArtifact(artifacts, release); err != nil {
+		logger.Warnf("release=%s: write notes artifact failed: %v", release.ID, err)
+	}
+	return nil
 }
 
 func rollbackSafeDefaultPtr() *bool {
@@ -601,20 +791,81 @@ func runManifestRendererDelete(
 	artifacts ArtifactStore,
 	msg ProjectOpMsg,
 ) (repoBootstrapOutcome, error) {
+	if store != nil {
+		holds, err := store.getProjectHolds(ctx, msg.ProjectID)
+		if err != nil {
+			return repoBootstrapOutcome{}, fmt.Errorf("read compliance holds: %w", err)
+		}
+		if holds.active() {
+			return repoBootstrapOutcome{}, errors.New("project is under compliance hold; delete refused")
+		}
+	}
 	writeDeleteAudit(artifacts, msg.ProjectID, msg.OpID)
+	namespaces := writeNamespaceTeardown(artifacts, msg.ProjectID, deleteTeardownSpec(ctx, store, msg))
+	deprovisioned, err := deprovisionManagedPostgres(ctx, msg.ProjectID)
+	if err != nil {
+		return repoBootstrapOutcome{}, fmt.Errorf("deprovision managed postgres: %w", err)
+	}
 	removeErr := artifacts.RemoveProject(msg.ProjectID)
 	if removeErr != nil {
 		return repoBootstrapOutcome{}, removeErr
 	}
 	if store != nil {
 		_ = store.DeleteProject(ctx, msg.ProjectID)
+		_ = store.deleteArtifactPlacement(ctx, msg.ProjectID)
+		_ = store.deleteProjectCapabilityBindings(ctx, msg.ProjectID)
+		_ = store.deleteProjectStoredSecrets(ctx, msg.ProjectID)
+		_ = store.deleteProjectFreezes(ctx, msg.ProjectID)
+		_ = store.deleteProjectSchedules(ctx, msg.ProjectID)
+		_ = store.deleteProjectPreviews(ctx, msg.ProjectID)
+		_ = store.deleteProjectHealth(ctx, msg.ProjectID)
+	}
+	message := "project deleted and artifacts cleaned"
+	if len(namespaces) > 0 {
+		message = fmt.Sprintf(
+			"project deleted, artifacts cleaned, namespace teardown recorded (%s)",
+			strings.Join(namespaces, ", "),
+		)
+	}
+	if len(deprovisioned) > 0 {
+		message += fmt.Sprintf("; removed managed postgres (%s)", strings.Join(deprovisioned, ", "))
 	}
 	return repoBootstrapOutcome{
-		message:   "project deleted and artifacts cleaned",
+		message:   message,
 		artifacts: []string{},
 	}, nil
 }
 
+// deleteTeardownSpec prefers the persisted spec because delete ops are
+// enqueued with a zero spec.
+func deleteTeardownSpec(ctx context.Context, store *Store, msg ProjectOpMsg) ProjectSpec {
+	if store != nil {
+		if project, err := store.GetProject(ctx, msg.ProjectID); err == nil {
+			return project.Spec
+		}
+	}
+	return msg.Spec
+}
+
+func writeNamespaceTeardown(artifacts ArtifactStore, projectID string, spec ProjectSpec) []string {
+	if strings.TrimSpace(spec.Name) == "" {
+		return nil
+	}
+	auditDir := filepath.Join(filepath.Dir(artifacts.ProjectDir(projectID)), "_audit")
+	if err := os.MkdirAll(auditDir, dirModePrivateRead); err != nil {
+		return nil
+	}
+	writeErr := os.WriteFile(
+		filepath.Join(auditDir, fmt.Sprintf("%s.namespaces.yaml", projectID)),
+		[]byte(renderNamespaceTeardownManifest(spec)),
+		fileModePrivate,
+	)
+	if writeErr != nil {
+		return nil
+	}
+	return projectNamespaces(spec)
+}
+
 func writeDeleteAudit(artifacts ArtifactStore, projectID, opID string) {
 	auditDir := filepath.Join(filepath.Dir(artifacts.ProjectDir(projectID)), "_audit")
 	_ = os.MkdirAll(auditDir, dirModePrivateRead)
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
@@ -63,11 +66,16 @@ func checkoutMainBranch(repo *gogit.Repository) error {
 			plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branchMain)),
 		)
 	}
+	branchRef := plumbing.NewBranchReferenceName(branchMain)
+	// Already on main: a forced checkout would throw away the files the
+	// caller just wrote into the worktree for the next commit.
+	if head, headErr := repo.Head(); headErr == nil && head.Name() == branchRef {
+		return nil
+	}
 	wt, err := repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("worktree: %w", err)
 	}
-	branchRef := plumbing.NewBranchReferenceName(branchMain)
 	createErr := wt.Checkout(&gogit.CheckoutOptions{
 		Hash:                      plumbing.Hash{},
 		Branch:                    branchRef,
@@ -105,7 +113,7 @@ func repoHasCommits(repo *gogit.Repository) (bool, error) {
 }
 
 func gitCommitIfChanged(ctx context.Context, dir, message string) (bool, error) {
-	runCtx, cancel := context.WithTimeout(ctx, gitOpTimeout)
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitTimeout)
 	defer cancel()
 	if err := ensureContextAlive(runCtx); err != nil {
 		return false, err
@@ -126,11 +134,13 @@ func gitCommitIfChanged(ctx context.Context, dir, message string) (bool, error)
 	if err != nil {
 		return false, fmt.Errorf("worktree status: %w", err)
 	}
+	command := fmt.Sprintf("git -C repos/%s commit -m %q", filepath.Base(dir), message)
 	if status.IsClean() {
+		traceCommand(ctx, command, "nothing to commit, working tree clean", nil)
 		return false, nil
 	}
 	signature := gitCommitSignature()
-	_, err = wt.Commit(message, &gogit.CommitOptions{
+	hash, err := wt.Commit(message, &gogit.CommitOptions{
 		All:               false,
 		AllowEmptyCommits: false,
 		Author:            &signature,
@@ -140,6 +150,7 @@ func gitCommitIfChanged(ctx context.Context, dir, message string) (bool, error)
 		Signer:            nil,
 		Amend:             false,
 	})
+	traceCommand(ctx, command, fmt.Sprintf("%s\n[%s] %s", status.String(), hash.String(), message), err)
 	if err != nil {
 		return false, fmt.Errorf("commit: %w", err)
 	}
@@ -151,7 +162,7 @@ func gitCommitIfChanged(ctx context.Context, dir, message string) (bool, error)
 }
 
 func gitRevParse(ctx context.Context, dir, ref string) (string, error) {
-	runCtx, cancel := context.WithTimeout(ctx, gitReadTimeout)
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitReadTimeout)
 	defer cancel()
 	if err := ensureContextAlive(runCtx); err != nil {
 		return "", err
@@ -172,7 +183,7 @@ func gitRevParse(ctx context.Context, dir, ref string) (string, error) {
 }
 
 func gitHeadDetails(ctx context.Context, dir string) (string, string, string, error) {
-	runCtx, cancel := context.WithTimeout(ctx, gitReadTimeout)
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitReadTimeout)
 	defer cancel()
 	if err := ensureContextAlive(runCtx); err != nil {
 		return "", "", "", err
@@ -198,11 +209,52 @@ func gitHeadDetails(ctx context.Context, dir string) (string, string, string, er
 	return branch, commitHash, subject, nil
 }
 
+// gitCommitsSince lists the non-merge commits reachable from to, newest
+// first, stopping before from or after limit commits. truncated reports
+// that the limit cut the list short.
+func gitCommitsSince(ctx context.Context, dir, to, from string, limit int) ([]*object.Commit, bool, error) {
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitReadTimeout)
+	defer cancel()
+	repo, err := openLocalRepo(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	head, err := repo.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return nil, false, fmt.Errorf("resolve revision %s: %w", to, err)
+	}
+	iter, err := repo.Log(&gogit.LogOptions{From: *head})
+	if err != nil {
+		return nil, false, fmt.Errorf("read log from %s: %w", to, err)
+	}
+	defer iter.Close()
+	commits := []*object.Commit{}
+	for {
+		if err = ensureContextAlive(runCtx); err != nil {
+			return nil, false, err
+		}
+		commit, nextErr := iter.Next()
+		switch {
+		case errors.Is(nextErr, io.EOF):
+			return commits, false, nil
+		case nextErr != nil:
+			return nil, false, fmt.Errorf("read log from %s: %w", to, nextErr)
+		case from != "" && commit.Hash.String() == from:
+			return commits, false, nil
+		case len(commits) == limit:
+			return commits, true, nil
+		case commit.NumParents() > 1:
+			continue
+		}
+		commits = append(commits, commit)
+	}
+}
+
 func ensureLocalGitRepo(ctx context.Context, dir string) error {
 	if err := os.MkdirAll(dir, dirModePrivateRead); err != nil {
 		return err
 	}
-	runCtx, cancel := context.WithTimeout(ctx, gitOpTimeout)
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitTimeout)
 	defer cancel()
 	if err := ensureContextAlive(runCtx); err != nil {
 		return err
@@ -235,3 +287,169 @@ func ensureLocalGitRepo(ctx context.Context, dir string) error {
 	}
 	return nil
 }
+
+// gitCommitToBranch commits edits of main's root-level files as one commit
+// on branch, writing objects and the branch ref only: the worktree, index,
+// and main are left alone. edit sees each of names main has and returns the
+// new contents and whether they changed. An existing branch is reset. The
+// commit is made even when nothing changed, so the branch always exists.
+func gitCommitToBranch(
+	ctx context.Context,
+	dir, branch, message string,
+	names []string,
+	edit func(name string, body []byte) ([]byte, bool),
+) (string, []string, error) {
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitTimeout)
+	defer cancel()
+	if err := ensureContextAlive(runCtx); err != nil {
+		return "", nil, err
+	}
+	repo, err := openLocalRepo(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	mainRef, err := repo.Reference(plumbing.NewBranchReferenceName(branchMain), true)
+	if err != nil {
+		return "", nil, fmt.Errorf("read %s: %w", branchMain, err)
+	}
+	base, err := repo.CommitObject(mainRef.Hash())
+	if err != nil {
+		return "", nil, fmt.Errorf("read commit object: %w", err)
+	}
+	tree, err := base.Tree()
+	if err != nil {
+		return "", nil, fmt.Errorf("read tree: %w", err)
+	}
+	entries := slices.Clone(tree.Entries)
+	changed := []string{}
+	for i, entry := range entries {
+		if !slices.Contains(names, entry.Name) || !entry.Mode.IsFile() || entry.Mode == filemode.Symlink {
+			continue
+		}
+		body, readErr := gitBlobContents(repo, entry.Hash)
+		if readErr != nil {
+			return "", nil, fmt.Errorf("read %s: %w", entry.Name, readErr)
+		}
+		updated, ok := edit(entry.Name, body)
+		if !ok {
+			continue
+		}
+		if entries[i].Hash, err = storeGitObject(repo, plumbing.BlobObject, func(o plumbing.EncodedObject) error {
+			w, writerErr := o.Writer()
+			if writerErr != nil {
+				return writerErr
+			}
+			if _, writeErr := w.Write(updated); writeErr != nil {
+				return errors.Join(writeErr, w.Close())
+			}
+			return w.Close()
+		}); err != nil {
+			return "", nil, fmt.Errorf("write %s: %w", entry.Name, err)
+		}
+		changed = append(changed, entry.Name)
+	}
+	newTree := object.Tree{Entries: entries, Hash: plumbing.ZeroHash}
+	treeHash, err := storeGitObject(repo, plumbing.TreeObject, newTree.Encode)
+	if err != nil {
+		return "", nil, fmt.Errorf("write tree: %w", err)
+	}
+	signature := gitCommitSignature()
+	commit := object.Commit{
+		Hash:         plumbing.ZeroHash,
+		Author:       signature,
+		Committer:    signature,
+		MergeTag:     "",
+		PGPSignature: "",
+		Message:      message,
+		TreeHash:     treeHash,
+		ParentHashes: []plumbing.Hash{base.Hash},
+		Encoding:     "",
+		ExtraHeaders: nil,
+	}
+	commitHash, err := storeGitObject(repo, plumbing.CommitObject, commit.Encode)
+	if err != nil {
+		return "", nil, fmt.Errorf("write commit: %w", err)
+	}
+	err = repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), commitHash))
+	traceCommand(
+		ctx,
+		fmt.Sprintf("git -C repos/%s branch -f %s %s", filepath.Base(dir), branch, branchMain),
+		fmt.Sprintf("[%s %s] %s\n%d file(s) changed", branch, commitHash.String(), message, len(changed)),
+		err,
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("set branch %s: %w", branch, err)
+	}
+	return commitHash.String(), changed, nil
+}
+
+func gitBlobContents(repo *gogit.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func storeGitObject(
+	repo *gogit.Repository,
+	kind plumbing.ObjectType,
+	encode func(plumbing.EncodedObject) error,
+) (plumbing.Hash, error) {
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(kind)
+	if err := encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+// gitExportCommit writes the files of commit into dst, for work that must
+// not see (or disturb) the repo's worktree. Symlinks and submodules are
+// skipped.
+func gitExportCommit(ctx context.Context, dir, commitHash, dst string) error {
+	runCtx, cancel := context.WithTimeout(ctx, runtimeConfigFromContext(ctx).GitTimeout)
+	defer cancel()
+	repo, err := openLocalRepo(dir)
+	if err != nil {
+		return err
+	}
+	commit, err := repo.CommitObject(plumbing.NewHash(commitHash))
+	if err != nil {
+		return fmt.Errorf("read commit object: %w", err)
+	}
+	files, err := commit.Files()
+	if err != nil {
+		return fmt.Errorf("list files: %w", err)
+	}
+	defer files.Close()
+	return files.ForEach(func(file *object.File) error {
+		if aliveErr := ensureContextAlive(runCtx); aliveErr != nil {
+			return aliveErr
+		}
+		if !file.Mode.IsFile() || file.Mode == filemode.Symlink {
+			return nil
+		}
+		if !filepath.IsLocal(file.Name) {
+			return fmt.Errorf("refusing to export %q outside the target", file.Name)
+		}
+		body, readErr := file.Contents()
+		if readErr != nil {
+			return fmt.Errorf("read %s: %w", file.Name, readErr)
+		}
+		target := filepath.Join(dst, filepath.FromSlash(file.Name))
+		if mkdirErr := os.MkdirAll(filepath.Dir(target), dirModePrivateRead); mkdirErr != nil {
+			return mkdirErr
+		}
+		mode := fileModePrivate
+		if file.Mode == filemode.Executable {
+			mode = fileModeExecPrivate
+		}
+		return os.WriteFile(target, []byte(body), mode)
+	})
+}
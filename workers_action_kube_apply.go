@@ -0,0 +1,338 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Kubernetes apply: with PAAS_KUBE_APPLY on, every op that renders an
+// environment ends with a kubeApplier step that applies deploy/<env>/rendered.yaml
+// to a local cluster (kind, k3d, minikube) with kubectl and waits for its
+// Deployments to roll out. The manifests repo stays the record of what was
+// rendered; this step only mirrors it into a cluster for local testing.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	kubeApplyStepWorker = "kubeApplier"
+
+	defaultKubectlBinary      = "kubectl"
+	defaultKubeRolloutTimeout = 2 * time.Minute
+	kubeApplyReportFile       = "kube-apply.json"
+	maxKubectlReasonBytes     = 1024
+
+	kubeApplyStatusApplied  = "applied"
+	kubeApplyStatusFailed   = "failed"
+	kubeRolloutStatusReady  = "complete"
+	kubeRolloutStatusFailed = "failed"
+)
+
+// kubeRolloutStatus is the outcome of `kubectl rollout status` for one
+// Deployment.
+type kubeRolloutStatus struct {
+	Resource string `json:"resource"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+}
+
+// kubeApplyReport is written to deploy/<env>/kube-apply.json whether or not
+// the apply succeeded. It names the Secret it applied but never its values.
+type kubeApplyReport struct {
+	ProjectID   string              `json:"project_id"`
+	OpID        string              `json:"op_id"`
+	Environment string              `json:"environment"`
+	Context     string              `json:"context"`
+	Namespace   string              `json:"namespace"`
+	Resources   []string            `json:"resources"`
+	Secret      string              `json:"secret,omitempty"`
+	Rollouts    []kubeRolloutStatus `json:"rollouts"`
+	Removed     []string            `json:"removed,omitempty"` // e.g. the canary Deployment an op ended
+	Status      string              `json:"status"`
+	Failure     string              `json:"failure,omitempty"`
+	CompletedAt time.Time           `json:"completed_at"`
+}
+
+type kubectlApplier struct {
+	binary  string
+	context string
+	timeout time.Duration
+}
+
+// kubeApplyEnabled reports whether rendered manifests are applied to a
+// cluster. It is off unless PAAS_KUBE_APPLY parses as true.
+func kubeApplyEnabled() bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(kubeApplyEnv)))
+	return err == nil && enabled
+}
+
+// isLocalKubeContext accepts the context names kind, k3d, and minikube create
+// (plus the desktop distributions). Anything else could be a shared cluster.
+func isLocalKubeContext(name string) bool {
+	switch name {
+	case "minikube", "docker-desktop", "rancher-desktop", "orbstack":
+		return true
+	}
+	return strings.HasPrefix(name, "kind-") || strings.HasPrefix(name, "k3d-")
+}
+
+func kubectlApplierFromEnv() (kubectlApplier, error) {
+	applier := kubectlApplier{
+		binary:  strings.TrimSpace(os.Getenv(kubectlBinaryEnv)),
+		context: strings.TrimSpace(os.Getenv(kubeContextEnv)),
+		timeout: defaultKubeRolloutTimeout,
+	}
+	if applier.binary == "" {
+		applier.binary = defaultKubectlBinary
+	}
+	if applier.context == "" {
+		return applier, fmt.Errorf("%s must name a local kubernetes context", kubeContextEnv)
+	}
+	if !isLocalKubeContext(applier.context) {
+		return applier, fmt.Errorf("%s %q is not a local kind, k3d, or minikube context", kubeContextEnv, applier.context)
+	}
+	if raw := strings.TrimSpace(os.Getenv(kubeRolloutTimeoutEnv)); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return applier, fmt.Errorf("invalid %s %q", kubeRolloutTimeoutEnv, raw)
+		}
+		applier.timeout = timeout
+	}
+	return applier, nil
+}
+
+// runKubeApplyStep applies env's rendered manifests as its own op step. It
+// records no step when kube apply is off.
+func runKubeApplyStep(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	spec ProjectSpec,
+	env string,
+) (promotionStageOutcome, error) {
+	if !kubeApplyEnabled() {
+		return promotionStageOutcome{}, nil
+	}
+	return runPromotionStage(
+		ctx,
+		store,
+		msg.OpID,
+		kubeApplyStepWorker,
+		"apply "+env+" manifests to local kubernetes cluster",
+		func() (promotionStageOutcome, error) {
+			return applyRenderedManifests(ctx, store, artifacts, msg, spec, env)
+		},
+	)
+}
+
+func applyRenderedManifests(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	spec ProjectSpec,
+	env string,
+) (promotionStageOutcome, error) {
+	report := kubeApplyReport{
+		ProjectID:   msg.ProjectID,
+		OpID:        msg.OpID,
+		Environment: env,
+		Context:     "",
+		Namespace:   projectNamespace(spec, env),
+		Resources:   []string{},
+		Secret:      "",
+		Rollouts:    []kubeRolloutStatus{},
+		Removed:     nil,
+		Status:      kubeApplyStatusApplied,
+		Failure:     "",
+		CompletedAt: time.Time{},
+	}
+	applier, err := kubectlApplierFromEnv()
+	report.Context = applier.context
+	if err == nil {
+		err = applier.apply(ctx, store, artifacts, spec, &report)
+	}
+	if err == nil && msg.Delivery.CanaryAction != "" {
+		err = applier.deleteCanary(ctx, spec, &report)
+	}
+	if err != nil {
+		report.Status = kubeApplyStatusFailed
+		report.Failure = err.Error()
+	}
+	report.CompletedAt = time.Now().UTC()
+
+	outcome := promotionStageOutcome{message: "", artifacts: nil}
+	raw, marshalErr := json.MarshalIndent(report, "", "  ")
+	if marshalErr != nil {
+		return outcome, errors.Join(err, marshalErr)
+	}
+	reportPath, writeErr := artifacts.WriteFile(msg.ProjectID, path.Join("deploy", env, kubeApplyReportFile), raw)
+	if writeErr != nil {
+		return outcome, errors.Join(err, writeErr)
+	}
+	outcome.artifacts = []string{reportPath}
+	if err != nil {
+		return outcome, err
+	}
+	outcome.message = fmt.Sprintf(
+		"applied %d resource(s) to %s; %d deployment(s) rolled out",
+		len(report.Resources),
+		report.Context,
+		len(report.Rollouts),
+	)
+	return outcome, nil
+}
+
+// apply runs the rendered manifests, then the project Secret, then waits on
+// each Deployment. The Secret goes second so its namespace already exists.
+func (k kubectlApplier) apply(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	spec ProjectSpec,
+	report *kubeApplyReport,
+) error {
+	rendered, err := artifacts.ReadFile(report.ProjectID, path.Join("deploy", report.Environment, "rendered.yaml"))
+	if err != nil {
+		return fmt.Errorf("read rendered %s manifests: %w", report.Environment, err)
+	}
+	subStepDone := beginSubStep(ctx, "kubectl apply")
+	out, err := k.run(ctx, rendered, "apply", "-f", "-", "-o", "name")
+	subStepDone(err)
+	if err != nil {
+		return err
+	}
+	report.Resources = strings.Fields(out)
+
+	if err = k.applySecret(ctx, store, artifacts, spec, report); err != nil {
+		return err
+	}
+
+	subStepDone = beginSubStep(ctx, "rollout status")
+	defer func() { subStepDone(err) }()
+	for _, resource := range report.Resources {
+		kind, _, _ := strings.Cut(resource, "/")
+		if kind != "deployment" && kind != "deployment.apps" {
+			continue
+		}
+		status := kubeRolloutStatus{Resource: resource, Status: kubeRolloutStatusReady, Message: ""}
+		out, err = k.run(
+			ctx,
+			nil,
+			"rollout", "status", resource,
+			"-n", report.Namespace,
+			"--timeout", k.timeout.String(),
+		)
+		status.Message = lastLine(out)
+		if err != nil {
+			status.Status = kubeRolloutStatusFailed
+			status.Message = err.Error()
+		}
+		report.Rollouts = append(report.Rollouts, status)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySecret creates the Secret the rendered secretKeyRefs point at. The
+// resolved values only ever travel to kubectl over stdin.
+func (k kubectlApplier) applySecret(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	spec ProjectSpec,
+	report *kubeApplyReport,
+) error {
+	hasSecrets, err := environmentHasSecrets(ctx, store, report.ProjectID, spec, report.Environment)
+	if err != nil || !hasSecrets {
+		return err
+	}
+	subStepDone := beginSubStep(ctx, "apply secret")
+	values, err := resolveRenderSecrets(ctx, store, artifacts, report.ProjectID, spec, report.Environment)
+	if err == nil {
+		data := make(map[string]string, len(values))
+		for name, value := range values {
+			data[name] = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		var manifest []byte
+		manifest, err = json.Marshal(map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "Opaque",
+			"metadata": map[string]any{
+				"name":      projectSecretName(spec),
+				"namespace": report.Namespace,
+				"labels":    map[string]string{"app": safeName(spec.Name)},
+			},
+			"data": data,
+		})
+		if err == nil {
+			_, err = k.run(ctx, manifest, "apply", "-f", "-", "-o", "name")
+		}
+	}
+	subStepDone(err)
+	if err != nil {
+		return err
+	}
+	report.Secret = projectSecretName(spec)
+	return nil
+}
+
+// run calls kubectl against the configured context. A failure carries the
+// first part of kubectl's stderr so the op shows why.
+func (k kubectlApplier) run(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	if err := ensureContextAlive(ctx); err != nil {
+		return "", err
+	}
+	verb := args[0]
+	if verb == "rollout" && len(args) > 2 {
+		verb = strings.Join(args[:3], " ")
+	}
+	args = append([]string{"--context", k.context}, args...)
+	// #nosec G204 -- the binary is operator configuration and the context is checked to be a local cluster.
+	cmd := exec.CommandContext(ctx, k.binary, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		reason := strings.TrimSpace(stderr.String())
+		if reason == "" {
+			reason = err.Error()
+		}
+		if len(reason) > maxKubectlReasonBytes {
+			reason = reason[:maxKubectlReasonBytes] + "..."
+		}
+		return stdout.String(), fmt.Errorf("kubectl %s: %s", verb, reason)
+	}
+	return stdout.String(), nil
+}
+
+// planKubeApply adds the apply step to a dry-run plan when it would run.
+func planKubeApply(plan []string, env string) []string {
+	if !kubeApplyEnabled() {
+		return plan
+	}
+	return append(plan, fmt.Sprintf(
+		"apply deploy/%s/rendered.yaml to kubernetes context %s and wait for rollout",
+		env,
+		strings.TrimSpace(os.Getenv(kubeContextEnv)),
+	))
+}
+
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
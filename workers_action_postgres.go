@@ -0,0 +1,486 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Managed Postgres: with PAAS_POSTGRES_PROVISIONER=docker, create, update,
+// and ci ops run a postgresProvisioner step before the manifests render. For
+// every environment whose postgres capability an operator has not bound, it
+// keeps a Postgres container running on the local Docker daemon, seals a
+// DATABASE_URL with the container's generated password into the project's
+// stored secrets, and records a managed binding, so the overlay renders a
+// secretKeyRef in place of the plugin's StatefulSet. Containers of
+// environments that no longer want one are removed, and delete ops remove
+// them all.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	postgresProvisionStepWorker = "postgresProvisioner"
+	postgresProvisionerDocker   = "docker"
+
+	defaultDockerBinary      = "docker"
+	defaultPostgresHost      = "host.docker.internal"
+	postgresReportFile       = "provision/postgres.json"
+	postgresURLSecret        = "DATABASE_URL"
+	postgresPasswordBytes    = 24
+	postgresProjectLabel     = "platform.example.com/project"
+	postgresEnvironmentLabel = "platform.example.com/environment"
+	maxDockerReasonBytes     = 1024
+
+	postgresStatusCreated = "created"
+	postgresStatusStarted = "started"
+	postgresStatusRunning = "running"
+)
+
+// postgresInstance is one environment's container in provision/postgres.json.
+// It never carries the password.
+type postgresInstance struct {
+	Environment string `json:"environment"`
+	Container   string `json:"container"`
+	Image       string `json:"image"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	Database    string `json:"database"`
+	User        string `json:"user"`
+	Status      string `json:"status"` // created | started | running
+}
+
+// postgresReport is written to provision/postgres.json whether or not the
+// step succeeded.
+type postgresReport struct {
+	ProjectID   string             `json:"project_id"`
+	OpID        string             `json:"op_id"`
+	Instances   []postgresInstance `json:"instances"`
+	Removed     []string           `json:"removed,omitempty"` // containers of environments that no longer want one
+	Failure     string             `json:"failure,omitempty"`
+	CompletedAt time.Time          `json:"completed_at"`
+}
+
+// dockerPostgres runs the managed instances with the docker CLI. host is
+// where the app reaches their published ports.
+type dockerPostgres struct {
+	binary string
+	host   string
+}
+
+// postgresProvisionerEnabled reports whether PAAS_POSTGRES_PROVISIONER is
+// set; dockerPostgresFromEnv refuses values other than docker.
+func postgresProvisionerEnabled() bool {
+	mode := strings.TrimSpace(os.Getenv(postgresProvisionerEnv))
+	return mode != "" && mode != "none"
+}
+
+func dockerPostgresFromEnv() (dockerPostgres, error) {
+	docker := dockerPostgres{
+		binary: strings.TrimSpace(os.Getenv(dockerBinaryEnv)),
+		host:   strings.TrimSpace(os.Getenv(postgresHostEnv)),
+	}
+	if docker.binary == "" {
+		docker.binary = defaultDockerBinary
+	}
+	if docker.host == "" {
+		docker.host = defaultPostgresHost
+	}
+	if mode := strings.TrimSpace(os.Getenv(postgresProvisionerEnv)); mode != postgresProvisionerDocker {
+		return docker, fmt.Errorf("%s %q is not supported; use %q", postgresProvisionerEnv, mode, postgresProvisionerDocker)
+	}
+	return docker, nil
+}
+
+func managedPostgresContainer(projectID, env string) string {
+	return "paas-" + safeName(projectID) + "-" + env + "-postgres"
+}
+
+// managedPostgresEnvironments lists the rendered environments of spec whose
+// postgres the platform provisions: all of them, unless the capability is
+// not declared or an operator bound it there.
+func managedPostgresEnvironments(spec ProjectSpec, bindings projectCapabilityBindings) []string {
+	if !slices.Contains(spec.Capabilities, capabilityPostgres) {
+		return nil
+	}
+	envs := []string{}
+	for _, env := range desiredManifestEnvironments(spec) {
+		binding, bound := bindings.Environments[env][capabilityPostgres]
+		if bound && binding.Type != CapabilityBindingManaged {
+			continue
+		}
+		envs = append(envs, env)
+	}
+	return envs
+}
+
+// runPostgresProvisionStep provisions managed Postgres as its own op step.
+// It records no step when the provisioner is off.
+func runPostgresProvisionStep(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	spec ProjectSpec,
+) (promotionStageOutcome, error) {
+	if !postgresProvisionerEnabled() {
+		return promotionStageOutcome{}, nil
+	}
+	return runPromotionStage(
+		ctx,
+		store,
+		msg.OpID,
+		postgresProvisionStepWorker,
+		"provision managed postgres on local docker",
+		func() (promotionStageOutcome, error) {
+			return provisionManagedPostgres(ctx, store, artifacts, msg, spec)
+		},
+	)
+}
+
+func provisionManagedPostgres(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	spec ProjectSpec,
+) (promotionStageOutcome, error) {
+	report := postgresReport{
+		ProjectID:   msg.ProjectID,
+		OpID:        msg.OpID,
+		Instances:   []postgresInstance{},
+		Removed:     nil,
+		Failure:     "",
+		CompletedAt: time.Time{},
+	}
+	err := reconcileManagedPostgres(ctx, store, msg.ProjectID, spec, &report)
+	if err != nil {
+		report.Failure = err.Error()
+	}
+	report.CompletedAt = time.Now().UTC()
+
+	outcome := promotionStageOutcome{message: "", artifacts: nil}
+	raw, marshalErr := json.MarshalIndent(report, "", "  ")
+	if marshalErr != nil {
+		return outcome, errors.Join(err, marshalErr)
+	}
+	reportPath, writeErr := artifacts.WriteFile(msg.ProjectID, postgresReportFile, raw)
+	if writeErr != nil {
+		return outcome, errors.Join(err, writeErr)
+	}
+	outcome.artifacts = []string{reportPath}
+	if err != nil {
+		return outcome, err
+	}
+	envs := make([]string, 0, len(report.Instances))
+	for _, instance := range report.Instances {
+		envs = append(envs, instance.Environment)
+	}
+	switch {
+	case len(envs) > 0:
+		outcome.message = "managed postgres running for " + strings.Join(envs, ", ")
+	case len(report.Removed) > 0:
+		outcome.message = fmt.Sprintf("removed %d managed postgres container(s)", len(report.Removed))
+	default:
+		outcome.message = "no environment needs managed postgres"
+	}
+	return outcome, nil
+}
+
+// reconcileManagedPostgres brings the project's containers, DATABASE_URL
+// secrets, and managed bindings in line with the environments that want
+// them, then removes what the others left behind.
+func reconcileManagedPostgres(
+	ctx context.Context,
+	store *Store,
+	projectID string,
+	spec ProjectSpec,
+	report *postgresReport,
+) error {
+	if store == nil {
+		return errors.New("managed postgres needs the control plane store")
+	}
+	docker, err := dockerPostgresFromEnv()
+	if err != nil {
+		return err
+	}
+	bindings, err := store.getCapabilityBindings(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	envs := managedPostgresEnvironments(spec, bindings)
+	for _, env := range envs {
+		instance, ensureErr := docker.ensure(ctx, store, projectID, spec, env)
+		if ensureErr != nil {
+			return fmt.Errorf("%s: %w", env, ensureErr)
+		}
+		report.Instances = append(report.Instances, instance)
+		if bindings.Environments[env][capabilityPostgres].Image == instance.Image {
+			continue
+		}
+		if _, err = store.putCapabilityBinding(ctx, CapabilityBinding{
+			ProjectID:    projectID,
+			Environment:  env,
+			Capability:   capabilityPostgres,
+			Type:         CapabilityBindingManaged,
+			Image:        instance.Image,
+			ContainerEnv: nil,
+			Env:          nil,
+			SecretEnv:    nil,
+			UpdatedAt:    time.Time{},
+		}); err != nil {
+			return err
+		}
+	}
+	for env, bound := range bindings.Environments {
+		if bound[capabilityPostgres].Type != CapabilityBindingManaged || slices.Contains(envs, env) {
+			continue
+		}
+		if _, _, err = store.deleteCapabilityBinding(ctx, projectID, env, capabilityPostgres); err != nil {
+			return err
+		}
+		if _, err = store.deleteStoredSecrets(ctx, projectID, env, []string{postgresURLSecret}); err != nil {
+			return err
+		}
+	}
+	containers, err := docker.projectContainers(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if slices.ContainsFunc(report.Instances, func(i postgresInstance) bool { return i.Container == container }) {
+			continue
+		}
+		if err = docker.remove(ctx, container); err != nil {
+			return err
+		}
+		report.Removed = append(report.Removed, container)
+		// An operator binding replaced the container; its URL must not
+		// outlive it.
+		for env := range spec.Environments {
+			if managedPostgresContainer(projectID, env) != container {
+				continue
+			}
+			if _, err = store.deleteStoredSecrets(ctx, projectID, env, []string{postgresURLSecret}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ensure keeps env's container running and its DATABASE_URL stored. A
+// container is replaced when its image changed or the stored credentials
+// are gone, which drops its data.
+func (d dockerPostgres) ensure(
+	ctx context.Context,
+	store *Store,
+	projectID string,
+	spec ProjectSpec,
+	env string,
+) (postgresInstance, error) {
+	options := capabilityOptionValues(spec, capabilityPostgres, postgresCapability{})
+	instance := postgresInstance{
+		Environment: env,
+		Container:   managedPostgresContainer(projectID, env),
+		Image:       "postgres:" + options["version"],
+		Host:        d.host,
+		Port:        0,
+		Database:    postgresDatabase(spec, options),
+		User:        postgresUser,
+		Status:      postgresStatusRunning,
+	}
+	storedURL, err := openStoredSecretValue(ctx, store, projectID, env, postgresURLSecret)
+	if err != nil {
+		return instance, err
+	}
+	password := ""
+	if parsed, parseErr := url.Parse(storedURL); parseErr == nil && parsed.User != nil {
+		password, _ = parsed.User.Password()
+	}
+	state, image, err := d.state(ctx, instance.Container)
+	if err != nil {
+		return instance, err
+	}
+	switch {
+	case state == "" || password == "" || image != instance.Image:
+		if state != "" {
+			if err = d.remove(ctx, instance.Container); err != nil {
+				return instance, err
+			}
+		}
+		if password, err = newPostgresPassword(); err != nil {
+			return instance, err
+		}
+		err = d.create(ctx, projectID, instance, password)
+		instance.Status = postgresStatusCreated
+	case state != postgresStatusRunning:
+		_, err = d.run(ctx, nil, "start", instance.Container)
+		instance.Status = postgresStatusStarted
+	}
+	if err != nil {
+		return instance, err
+	}
+	if instance.Port, err = d.port(ctx, instance.Container); err != nil {
+		return instance, err
+	}
+	databaseURL := (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(instance.User, password),
+		Host:     net.JoinHostPort(instance.Host, strconv.Itoa(instance.Port)),
+		Path:     "/" + instance.Database,
+		RawQuery: "sslmode=disable",
+	}).String()
+	if databaseURL != storedURL {
+		_, err = store.putStoredSecrets(ctx, projectID, env, map[string]string{postgresURLSecret: databaseURL})
+	}
+	return instance, err
+}
+
+// state returns the container's state (running, exited, ...) and image, or
+// "" when there is no such container.
+func (d dockerPostgres) state(ctx context.Context, container string) (string, string, error) {
+	out, err := d.run(ctx, nil, "ps", "--all", "--filter", "name=^/"+container+"$", "--format", "{{.State}} {{.Image}}")
+	if err != nil {
+		return "", "", err
+	}
+	state, image, _ := strings.Cut(strings.TrimSpace(out), " ")
+	return state, image, nil
+}
+
+// create starts the container with the password passed through the docker
+// CLI's environment, so it never shows in a process listing.
+func (d dockerPostgres) create(ctx context.Context, projectID string, instance postgresInstance, password string) error {
+	_, err := d.run(ctx, []string{"POSTGRES_PASSWORD=" + password},
+		"run", "--detach",
+		"--name", instance.Container,
+		"--label", postgresProjectLabel+"="+projectID,
+		"--label", postgresEnvironmentLabel+"="+instance.Environment,
+		"--env", "POSTGRES_USER="+instance.User,
+		"--env", "POSTGRES_DB="+instance.Database,
+		"--env", "POSTGRES_PASSWORD",
+		"--publish", strconv.Itoa(postgresPort),
+		instance.Image,
+	)
+	return err
+}
+
+// port returns the host port docker published the container's 5432 on.
+func (d dockerPostgres) port(ctx context.Context, container string) (int, error) {
+	out, err := d.run(ctx, nil, "port", container, strconv.Itoa(postgresPort)+"/tcp")
+	if err != nil {
+		return 0, err
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	_, rawPort, err := net.SplitHostPort(strings.TrimSpace(first))
+	if err != nil {
+		return 0, fmt.Errorf("docker port %s: unexpected output %q", container, first)
+	}
+	return strconv.Atoi(rawPort)
+}
+
+func (d dockerPostgres) remove(ctx context.Context, container string) error {
+	_, err := d.run(ctx, nil, "rm", "--force", "--volumes", container)
+	return err
+}
+
+// projectContainers lists every managed container labeled for projectID.
+func (d dockerPostgres) projectContainers(ctx context.Context, projectID string) ([]string, error) {
+	out, err := d.run(ctx, nil,
+		"ps", "--all", "--filter", "label="+postgresProjectLabel+"="+projectID, "--format", "{{.Names}}",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// run calls the docker CLI with env added to its environment. A failure
+// carries the first part of docker's stderr so the op shows why.
+func (d dockerPostgres) run(ctx context.Context, env []string, args ...string) (string, error) {
+	if err := ensureContextAlive(ctx); err != nil {
+		return "", err
+	}
+	// #nosec G204 -- the binary is operator configuration and the arguments are built here.
+	cmd := exec.CommandContext(ctx, d.binary, args...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		reason := strings.TrimSpace(stderr.String())
+		if reason == "" {
+			reason = err.Error()
+		}
+		if len(reason) > maxDockerReasonBytes {
+			reason = reason[:maxDockerReasonBytes] + "..."
+		}
+		return stdout.String(), fmt.Errorf("docker %s: %s", args[0], reason)
+	}
+	return stdout.String(), nil
+}
+
+// deprovisionManagedPostgres removes every managed container of projectID;
+// a delete op runs it before the project's records go.
+func deprovisionManagedPostgres(ctx context.Context, projectID string) ([]string, error) {
+	if !postgresProvisionerEnabled() {
+		return nil, nil
+	}
+	docker, err := dockerPostgresFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := docker.projectContainers(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		if err = docker.remove(ctx, container); err != nil {
+			return nil, err
+		}
+	}
+	return containers, nil
+}
+
+// planPostgresProvision lists what the provisioner would do for a dry run.
+func planPostgresProvision(spec ProjectSpec) []string {
+	if !postgresProvisionerEnabled() || !slices.Contains(spec.Capabilities, capabilityPostgres) {
+		return nil
+	}
+	return []string{"provision managed postgres on local docker and store DATABASE_URL, unless bound"}
+}
+
+func openStoredSecretValue(ctx context.Context, store *Store, projectID, env, name string) (string, error) {
+	secrets, err := store.getStoredSecrets(ctx, projectID)
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := secrets.Environments[env][name]
+	if !ok {
+		return "", nil
+	}
+	aead, err := storedSecretsCipher()
+	if err != nil {
+		return "", err
+	}
+	return openStoredSecret(aead, projectID, env, name, sealed)
+}
+
+func newPostgresPassword() (string, error) {
+	raw := make([]byte, postgresPasswordBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -63,7 +63,12 @@ type promotionExecutionState struct {
 	transition      envTransitionDescriptor
 	imageByEnv      map[string]string
 	sourceImage     string
+	bindings        envCapabilityBindings
+	storedSecrets   envStoredSecrets
+	dependents      []string
 	outcome         repoBootstrapOutcome
+	canary          CanaryRollout
+	blueGreen       BlueGreenRelease
 }
 
 type rollbackExecutionState struct {
@@ -73,6 +78,9 @@ type rollbackExecutionState struct {
 	sourceRelease ReleaseRecord
 	sourceImage   string
 	configVars    map[string]string
+	bindings      envCapabilityBindings
+	storedSecrets envStoredSecrets
+	dependents    []string
 	rendered      renderedProjectManifests
 	rollbackDir   string
 	artifactSets  transitionArtifactSets
@@ -92,6 +100,7 @@ func runRollbackLifecycleStages(
 		sourceRelease: zeroReleaseRecord(),
 		sourceImage:   "",
 		configVars:    map[string]string{},
+		bindings:      envCapabilityBindings{},
 		rendered:      zeroRenderedProjectManifests(),
 		rollbackDir:   "",
 		artifactSets:  newTransitionArtifactSets(),
@@ -119,7 +128,7 @@ func runRollbackLifecycleStages(
 		promotionStepRender,
 		"render rollback manifests for target environment",
 		func() (promotionStageOutcome, error) {
-			return runRollbackRenderStage(artifacts, msg, state)
+			return runRollbackRenderStage(ctx, store, artifacts, msg, state)
 		},
 	)
 	if err != nil {
@@ -140,7 +149,7 @@ func runRollbackLifecycleStages(
 		return stageOutcome, err
 	}
 
-	return runPromotionStage(
+	stageOutcome, err = runPromotionStage(
 		ctx,
 		store,
 		msg.OpID,
@@ -150,6 +159,12 @@ func runRollbackLifecycleStages(
 			return runRollbackFinalizeStage(ctx, store, artifacts, msg, state)
 		},
 	)
+	if err != nil {
+		return stageOutcome, err
+	}
+	applyOutcome, err := runKubeApplyStep(ctx, store, artifacts, msg, state.spec, state.targetEnv)
+	stageOutcome.artifacts = append(stageOutcome.artifacts, applyOutcome.artifacts...)
+	return stageOutcome, err
 }
 
 func runRollbackPlanStage(
@@ -162,6 +177,9 @@ func runRollbackPlanStage(
 	if err := applyRollbackPlanRequest(msg, state); err != nil {
 		return promotionStageOutcome{}, err
 	}
+	if err := ensurePlainDelivery(artifacts, msg.ProjectID, state.targetEnv); err != nil {
+		return promotionStageOutcome{}, err
+	}
 	release, err := loadRollbackPlanSourceRelease(ctx, store, msg, state.targetEnv)
 	if err != nil {
 		return promotionStageOutcome{}, err
@@ -171,6 +189,18 @@ func runRollbackPlanStage(
 	if err != nil {
 		return promotionStageOutcome{}, err
 	}
+	state.bindings, err = loadEnvCapabilityBindings(ctx, store, msg.ProjectID)
+	if err != nil {
+		return promotionStageOutcome{}, err
+	}
+	state.storedSecrets, err = loadEnvStoredSecrets(ctx, store, msg.ProjectID)
+	if err != nil {
+		return promotionStageOutcome{}, err
+	}
+	state.dependents, err = loadProjectDependents(ctx, store, msg.ProjectID)
+	if err != nil {
+		return promotionStageOutcome{}, err
+	}
 
 	return promotionStageOutcome{
 		message: fmt.Sprintf(
@@ -330,7 +360,11 @@ func zeroReleaseRecord() ReleaseRecord {
 		RollbackSafe:          nil,
 		RollbackSourceRelease: "",
 		RollbackScope:         "",
+		SourceCommit:          "",
+		SpecHash:              "",
 		CreatedAt:             time.Time{},
+		Notes:                 nil,
+		Evidence:              nil,
 	}
 }
 
@@ -344,6 +378,8 @@ func zeroRenderedProjectManifests() renderedProjectManifests {
 }
 
 func runRollbackRenderStage(
+	ctx context.Context,
+	store *Store,
 	artifacts ArtifactStore,
 	msg ProjectOpMsg,
 	state *rollbackExecutionState,
@@ -356,7 +392,11 @@ func runRollbackRenderStage(
 	if state.scope == RollbackScopeFullState {
 		sets, err = renderRollbackFullStateArtifacts(artifacts, msg, state)
 	} else {
-		sets, err = renderRollbackFromCurrentSpecArtifacts(artifacts, msg, state)
+		var trace manifestTrace
+		trace, err = newEnvManifestTrace(ctx, store, artifacts, msg, state.spec, state.targetEnv)
+		if err == nil {
+			sets, err = renderRollbackFromCurrentSpecArtifacts(artifacts, msg, state, trace)
+		}
 	}
 	state.artifactSets = sets
 	state.outcome.artifacts = sets.allArtifacts()
@@ -388,6 +428,7 @@ func runRollbackCommitStage(
 		ctx,
 		artifacts,
 		msg.ProjectID,
+		msg.Spec.Repos.Manifests,
 		state.targetEnv,
 		msg.OpID,
 		state.scope,
@@ -428,8 +469,9 @@ func runRollbackFinalizeStage(
 	if err = persistReleaseRecord(
 		ctx,
 		store,
+		artifacts,
 		ReleaseRecord{
-			ID:                    "",
+			ID:                    releaseIDForOp(msg.OpID),
 			ProjectID:             msg.ProjectID,
 			Environment:           state.targetEnv,
 			OpID:                  msg.OpID,
@@ -443,7 +485,11 @@ func runRollbackFinalizeStage(
 			RollbackSafe:          rollbackSafeDefaultPtr(),
 			RollbackSourceRelease: state.sourceRelease.ID,
 			RollbackScope:         state.scope,
+			SourceCommit:          readRenderedEnvSourceCommit(artifacts, msg.ProjectID, state.targetEnv),
+			SpecHash:              projectSpecHash(state.spec),
 			CreatedAt:             time.Now().UTC(),
+			Notes:                 nil,
+			Evidence:              nil,
 		},
 	); err != nil {
 		return promotionStageOutcome{
@@ -465,6 +511,7 @@ func renderRollbackFromCurrentSpecArtifacts(
 	artifacts ArtifactStore,
 	msg ProjectOpMsg,
 	state *rollbackExecutionState,
+	trace manifestTrace,
 ) (transitionArtifactSets, error) {
 	sets := newTransitionArtifactSets()
 	imageByEnv, err := loadManifestImageTags(artifacts, msg.ProjectID, state.spec)
@@ -472,21 +519,32 @@ func renderRollbackFromCurrentSpecArtifacts(
 		return sets, err
 	}
 	imageByEnv[state.targetEnv] = state.sourceImage
-	sets.kustomizeArtifacts, err = writeKustomizeRepoFiles(artifacts, msg.ProjectID, state.spec, imageByEnv)
+	sets.kustomizeArtifacts, err = writeKustomizeRepoFiles(
+		artifacts,
+		msg.ProjectID,
+		state.spec,
+		imageByEnv,
+		state.bindings,
+		state.storedSecrets,
+		state.dependents,
+	)
 	if err != nil {
 		return sets, err
 	}
 	overlayArtifacts, err := forceOverlayImageForEnvironment(
 		artifacts,
 		msg.ProjectID,
+		state.spec,
 		state.targetEnv,
 		state.sourceImage,
+		state.bindings[state.targetEnv],
+		state.dependents,
 	)
 	sets.kustomizeArtifacts = append(sets.kustomizeArtifacts, overlayArtifacts...)
 	if err != nil {
 		return sets, err
 	}
-	rendered, err := renderEnvironmentManifestsFromRepo(artifacts, msg.ProjectID, state.targetEnv)
+	rendered, err := renderEnvironmentManifestsFromRepo(artifacts, msg.ProjectID, state.targetEnv, trace)
 	if err != nil {
 		return sets, err
 	}
@@ -522,15 +580,26 @@ func renderRollbackFullStateArtifacts(
 		return sets, err
 	}
 	imageByEnv[state.targetEnv] = state.sourceImage
-	sets.kustomizeArtifacts, err = writeKustomizeRepoFiles(artifacts, msg.ProjectID, state.spec, imageByEnv)
+	sets.kustomizeArtifacts, err = writeKustomizeRepoFiles(
+		artifacts,
+		msg.ProjectID,
+		state.spec,
+		imageByEnv,
+		state.bindings,
+		state.storedSecrets,
+		state.dependents,
+	)
 	if err != nil {
 		return sets, err
 	}
 	overlayArtifacts, err := forceOverlayImageForEnvironment(
 		artifacts,
 		msg.ProjectID,
+		state.spec,
 		state.targetEnv,
 		state.sourceImage,
+		state.bindings[state.targetEnv],
+		state.dependents,
 	)
 	sets.kustomizeArtifacts = append(sets.kustomizeArtifacts, overlayArtifacts...)
 	if err != nil {
@@ -648,13 +717,14 @@ func applyRollbackConfigToSpec(spec ProjectSpec, env string, vars map[string]str
 	maps.Copy(cfgVars, vars)
 	cfg.Vars = cfgVars
 	spec.Environments[env] = cfg
-	return spec
+	return normalizeProjectSpec(spec)
 }
 
 func commitRollbackManifestsRepo(
 	ctx context.Context,
 	artifacts ArtifactStore,
 	projectID string,
+	remote RemoteRepo,
 	targetEnv string,
 	opID string,
 	scope RollbackScope,
@@ -675,7 +745,10 @@ func commitRollbackManifestsRepo(
 			shortID(opID),
 		),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return pushGitRemote(ctx, manifestsDir, remote)
 }
 
 func runPromotionLifecycleStages(
@@ -695,7 +768,7 @@ func runPromotionLifecycleStages(
 		promotionStepPlan,
 		"validate promotion/release request and source image",
 		func() (promotionStageOutcome, error) {
-			return runPromotionPlanStage(artifacts, msg, state)
+			return runPromotionPlanStage(ctx, store, artifacts, msg, state)
 		},
 	)
 	if err != nil {
@@ -709,7 +782,7 @@ func runPromotionLifecycleStages(
 		promotionStepRender,
 		"render transition manifests for target environment",
 		func() (promotionStageOutcome, error) {
-			return runPromotionRenderStage(artifacts, msg, state)
+			return runPromotionRenderStage(ctx, store, artifacts, msg, state)
 		},
 	)
 	if err != nil {
@@ -730,7 +803,7 @@ func runPromotionLifecycleStages(
 		return stageOutcome, err
 	}
 
-	return runPromotionStage(
+	stageOutcome, err = runPromotionStage(
 		ctx,
 		store,
 		msg.OpID,
@@ -740,9 +813,17 @@ func runPromotionLifecycleStages(
 			return runPromotionFinalizeStage(ctx, store, artifacts, msg, state)
 		},
 	)
+	if err != nil {
+		return stageOutcome, err
+	}
+	applyOutcome, err := runKubeApplyStep(ctx, store, artifacts, msg, state.spec, state.resolvedToEnv)
+	stageOutcome.artifacts = append(stageOutcome.artifacts, applyOutcome.artifacts...)
+	return stageOutcome, err
 }
 
 func runPromotionPlanStage(
+	ctx context.Context,
+	store *Store,
 	artifacts ArtifactStore,
 	msg ProjectOpMsg,
 	state *promotionExecutionState,
@@ -763,6 +844,18 @@ func runPromotionPlanStage(
 	if err != nil {
 		return promotionStageOutcome{}, err
 	}
+	state.bindings, err = loadEnvCapabilityBindings(ctx, store, msg.ProjectID)
+	if err != nil {
+		return promotionStageOutcome{}, err
+	}
+	state.storedSecrets, err = loadEnvStoredSecrets(ctx, store, msg.ProjectID)
+	if err != nil {
+		return promotionStageOutcome{}, err
+	}
+	state.dependents, err = loadProjectDependents(ctx, store, msg.ProjectID)
+	if err != nil {
+		return promotionStageOutcome{}, err
+	}
 	state.sourceImage, err = resolvePromotionSourceImage(
 		artifacts,
 		msg.ProjectID,
@@ -778,6 +871,15 @@ func runPromotionPlanStage(
 			state.resolvedFromEnv,
 		)
 	}
+	if err = planCanaryTransition(artifacts, msg, state); err != nil {
+		return promotionStageOutcome{}, err
+	}
+	if err = planBlueGreenTransition(artifacts, msg, state); err != nil {
+		return promotionStageOutcome{}, err
+	}
+	if err = verifyReleaseApproval(ctx, store, msg, state.resolvedToEnv, state.sourceImage); err != nil {
+		return promotionStageOutcome{}, err
+	}
 	return promotionStageOutcome{
 		message: fmt.Sprintf(
 			"planned %s transition from %s to %s",
@@ -790,22 +892,50 @@ func runPromotionPlanStage(
 }
 
 func runPromotionRenderStage(
+	ctx context.Context,
+	store *Store,
 	artifacts ArtifactStore,
 	msg ProjectOpMsg,
 	state *promotionExecutionState,
 ) (promotionStageOutcome, error) {
-	state.imageByEnv[state.resolvedToEnv] = state.sourceImage
+	servedImage, err := stageDeliveryMarker(artifacts, msg, state)
+	if err != nil {
+		return promotionStageOutcome{message: "", artifacts: nil}, err
+	}
+	state.imageByEnv[state.resolvedToEnv] = servedImage
+	trace, err := newEnvManifestTrace(ctx, store, artifacts, msg, state.spec, state.resolvedToEnv)
+	if err != nil {
+		return promotionStageOutcome{message: "", artifacts: nil}, err
+	}
 	artifactSets, err := renderTransitionManifests(
 		artifacts,
 		msg.ProjectID,
 		state.spec,
 		state.imageByEnv,
+		state.bindings,
+		state.storedSecrets,
+		state.dependents,
 		state.resolvedToEnv,
-		state.sourceImage,
+		servedImage,
 		state.transition,
 		state.resolvedFromEnv,
+		trace,
 	)
 	state.outcome.artifacts = artifactSets.allArtifacts()
+	if err == nil && msg.Delivery.Strategy == DeliveryStrategyBlueGreen {
+		var colorArtifacts []string
+		colorArtifacts, err = writeBlueGreenArtifacts(
+			artifacts,
+			msg.ProjectID,
+			state.resolvedToEnv,
+			filepath.ToSlash(filepath.Join(
+				state.transition.artifactDir,
+				fmt.Sprintf("%s-to-%s", state.resolvedFromEnv, state.resolvedToEnv),
+			)),
+			state.blueGreen,
+		)
+		state.outcome.artifacts = uniqueSorted(append(state.outcome.artifacts, colorArtifacts...))
+	}
 	if err != nil {
 		return promotionStageOutcome{
 			message:   "",
@@ -834,6 +964,7 @@ func runPromotionCommitStage(
 		ctx,
 		artifacts,
 		msg.ProjectID,
+		msg.Spec.Repos.Manifests,
 		state.transition,
 		state.resolvedFromEnv,
 		state.resolvedToEnv,
@@ -862,6 +993,14 @@ func runPromotionFinalizeStage(
 	msg ProjectOpMsg,
 	state *promotionExecutionState,
 ) (promotionStageOutcome, error) {
+	if message, ok := canaryOutcomeMessage(msg.Delivery, state.canary); ok {
+		state.outcome.message = message
+		return promotionStageOutcome(state.outcome), nil
+	}
+	if message, ok := blueGreenOutcomeMessage(msg.Delivery, state.blueGreen); ok {
+		state.outcome.message = message
+		return promotionStageOutcome(state.outcome), nil
+	}
 	err := persistTransitionReleaseRecord(
 		ctx,
 		store,
@@ -899,10 +1038,15 @@ func runPromotionStage(
 	startMessage string,
 	run func() (promotionStageOutcome, error),
 ) (promotionStageOutcome, error) {
+	if err := opCancelCause(ctx); err != nil {
+		return promotionStageOutcome{}, err
+	}
 	startedAt := time.Now().UTC()
 	_ = markOpStepStart(ctx, store, opID, worker, startedAt, startMessage)
 
+	traceDone := traceSubStep(ctx, worker)
 	outcome, err := run()
+	traceDone(err)
 	endedAt := time.Now().UTC()
 	if err != nil {
 		_ = markOpStepEnd(
@@ -958,8 +1102,9 @@ func persistTransitionReleaseRecord(
 	return persistReleaseRecord(
 		ctx,
 		store,
+		artifacts,
 		ReleaseRecord{
-			ID:            "",
+			ID:            releaseIDForOp(msg.OpID),
 			ProjectID:     msg.ProjectID,
 			Environment:   toEnv,
 			OpID:          msg.OpID,
@@ -985,7 +1130,11 @@ func persistTransitionReleaseRecord(
 			RollbackSafe:          rollbackSafeDefaultPtr(),
 			RollbackSourceRelease: "",
 			RollbackScope:         "",
+			SourceCommit:          readRenderedEnvSourceCommit(artifacts, msg.ProjectID, toEnv),
+			SpecHash:              projectSpecHash(msg.Spec),
 			CreatedAt:             time.Now().UTC(),
+			Notes:                 nil,
+			Evidence:              nil,
 		},
 	)
 }
@@ -1050,16 +1199,36 @@ func runManifestPromotionForEnvironments(
 		return repoBootstrapOutcome{}, fmt.Errorf("no promoted image found for source environment %q", fromEnv)
 	}
 	imageByEnv[toEnv] = sourceImage
+	bindings, err := loadEnvCapabilityBindings(ctx, store, msg.ProjectID)
+	if err != nil {
+		return repoBootstrapOutcome{}, err
+	}
+	storedSecrets, err := loadEnvStoredSecrets(ctx, store, msg.ProjectID)
+	if err != nil {
+		return repoBootstrapOutcome{}, err
+	}
+	dependents, err := loadProjectDependents(ctx, store, msg.ProjectID)
+	if err != nil {
+		return repoBootstrapOutcome{}, err
+	}
+	trace, err := newEnvManifestTrace(ctx, store, artifacts, msg, spec, toEnv)
+	if err != nil {
+		return repoBootstrapOutcome{}, err
+	}
 
 	artifactSets, err := renderTransitionManifests(
 		artifacts,
 		msg.ProjectID,
 		spec,
 		imageByEnv,
+		bindings,
+		storedSecrets,
+		dependents,
 		toEnv,
 		sourceImage,
 		transition,
 		fromEnv,
+		trace,
 	)
 	if err != nil {
 		return repoBootstrapOutcome{
@@ -1072,6 +1241,7 @@ func runManifestPromotionForEnvironments(
 		ctx,
 		artifacts,
 		msg.ProjectID,
+		spec.Repos.Manifests,
 		transition,
 		fromEnv,
 		toEnv,
@@ -1118,24 +1288,38 @@ func renderTransitionManifests(
 	projectID string,
 	spec ProjectSpec,
 	imageByEnv map[string]string,
+	bindings envCapabilityBindings,
+	storedSecrets envStoredSecrets,
+	dependents []string,
 	toEnv string,
 	sourceImage string,
 	transition envTransitionDescriptor,
 	fromEnv string,
+	trace manifestTrace,
 ) (transitionArtifactSets, error) {
 	sets := newTransitionArtifactSets()
 
-	kustomizeArtifacts, err := writeKustomizeRepoFiles(artifacts, projectID, spec, imageByEnv)
+	kustomizeArtifacts, err := writeKustomizeRepoFiles(
+		artifacts, projectID, spec, imageByEnv, bindings, storedSecrets, dependents,
+	)
 	sets.kustomizeArtifacts = kustomizeArtifacts
 	if err != nil {
 		return sets, err
 	}
-	overlayArtifacts, err := forceOverlayImageForEnvironment(artifacts, projectID, toEnv, sourceImage)
+	overlayArtifacts, err := forceOverlayImageForEnvironment(
+		artifacts,
+		projectID,
+		spec,
+		toEnv,
+		sourceImage,
+		bindings[toEnv],
+		dependents,
+	)
 	sets.kustomizeArtifacts = append(sets.kustomizeArtifacts, overlayArtifacts...)
 	if err != nil {
 		return sets, err
 	}
-	rendered, err := renderEnvironmentManifestsFromRepo(artifacts, projectID, toEnv)
+	rendered, err := renderEnvironmentManifestsFromRepo(artifacts, projectID, toEnv, trace)
 	if err != nil {
 		return sets, err
 	}
@@ -1175,6 +1359,33 @@ func renderTransitionManifests(
 	return sets, nil
 }
 
+// verifyReleaseApproval refuses a production release that was not granted
+// the approvals PAAS_RELEASE_APPROVALS asks for, or was granted them for a
+// different image than the source environment now runs.
+func verifyReleaseApproval(ctx context.Context, store *Store, msg ProjectOpMsg, toEnv, image string) error {
+	// A follow-up rolls out no image the canary or staged color was not approved for.
+	if msg.Kind != OpRelease || msg.Execution.DryRun || releaseApprovalsRequired() == 0 || msg.Delivery.followUp() {
+		return nil
+	}
+	if msg.ApprovalID == "" {
+		return fmt.Errorf("release to %s has no approval; %s requires one", toEnv, releaseApprovalsEnv)
+	}
+	approval, err := store.getReleaseApproval(ctx, msg.ApprovalID)
+	if err != nil {
+		return fmt.Errorf("read release approval %s: %w", msg.ApprovalID, err)
+	}
+	switch {
+	case approval.ProjectID != msg.ProjectID || approval.ToEnv != toEnv:
+		return fmt.Errorf("approval %s is for %s in project %s", approval.ID, approval.ToEnv, approval.ProjectID)
+	case !approval.granted():
+		return fmt.Errorf("approval %s is %s with %d of %d approvals",
+			approval.ID, approval.Status, approval.approvals(), approval.Required)
+	case approval.Image != image:
+		return fmt.Errorf("approval %s is for image %s, but the source runs %s", approval.ID, approval.Image, image)
+	}
+	return nil
+}
+
 func resolvePromotionSourceImage(
 	artifacts ArtifactStore,
 	projectID string,
@@ -1195,6 +1406,7 @@ func commitEnvironmentTransitionManifestsRepo(
 	ctx context.Context,
 	artifacts ArtifactStore,
 	projectID string,
+	remote RemoteRepo,
 	transition envTransitionDescriptor,
 	fromEnv string,
 	toEnv string,
@@ -1215,15 +1427,25 @@ func commitEnvironmentTransitionManifestsRepo(
 			shortID(opID),
 		),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return pushGitRemote(ctx, manifestsDir, remote)
 }
 
 func forceOverlayImageForEnvironment(
 	artifacts ArtifactStore,
 	projectID string,
+	spec ProjectSpec,
 	env string,
 	image string,
+	bindings []CapabilityBinding,
+	dependents []string,
 ) ([]string, error) {
+	rollouts, err := loadEnvRollouts(artifacts, projectID, []string{env})
+	if err != nil {
+		return nil, err
+	}
 	overlayDir := filepath.ToSlash(filepath.Join(manifestsRepoOverlaysDir, env))
 	files := []struct {
 		path string
@@ -1231,7 +1453,9 @@ func forceOverlayImageForEnvironment(
 	}{
 		{
 			path: filepath.ToSlash(filepath.Join(overlayDir, manifestFileKustomization)),
-			data: renderOverlayKustomizationManifest(image),
+			data: renderOverlayKustomizationManifest(
+				spec, env, rollouts.kustomizeImage(env, image), rollouts.strategy(env), bindings, dependents,
+			),
 		},
 		{
 			path: filepath.ToSlash(filepath.Join(overlayDir, overlayImageMarkerFile)),
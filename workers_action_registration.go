@@ -30,6 +30,8 @@ func registrationWorkerAction(
 			message:   "registration skipped for ci operation",
 			artifacts: nil,
 		}
+	case OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade, OpWebhookRefresh:
+		err = fmt.Errorf("registration worker does not handle %s operations", msg.Kind)
 	case OpDeploy, OpPromote, OpRelease, OpRollback:
 		outcome = repoBootstrapOutcome{
 			message:   "registration skipped for deployment/promotion/release/rollback operation",
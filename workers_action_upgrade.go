@@ -0,0 +1,455 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runtime upgrade trials: a runtime-upgrade op commits the scaffolding a newer
+// runtime needs to a runtime-upgrade/<target> branch of the source repo and
+// trial-builds that branch with the target runtime. main, the worktree, the
+// project spec, and the deployed image stay as they were; the outcome is
+// recorded on the op and under upgrades/<op_id>/.
+
+const (
+	runtimeUpgradeArtifactRoot   = "upgrades"
+	runtimeUpgradeBranchPrefix   = "runtime-upgrade/"
+	runtimeUpgradeBuildPassed    = "passed"
+	runtimeUpgradeBuildFailed    = "failed"
+	runtimeUpgradeProgressBuild  = 30
+	runtimeUpgradeProgressReport = 90
+)
+
+// runtimeUpgradeScaffoldFiles are the repo-root files that pin a runtime
+// version and are rewritten on the upgrade branch.
+func runtimeUpgradeScaffoldFiles() []string {
+	return []string{"README.md", "go.mod", "Dockerfile", ".tool-versions", ".nvmrc", ".python-version"}
+}
+
+func runtimeUpgradeBranch(target string) string {
+	return runtimeUpgradeBranchPrefix + target
+}
+
+// newRuntimeUpgrade seeds the upgrade record of a runtime-upgrade op; other
+// kinds carry none.
+func newRuntimeUpgrade(kind OperationKind, spec ProjectSpec, target string) *RuntimeUpgrade {
+	if kind != OpRuntimeUpgrade {
+		return nil
+	}
+	return &RuntimeUpgrade{
+		From:     spec.Runtime,
+		To:       target,
+		Branch:   runtimeUpgradeBranch(target),
+		Commit:   "",
+		Changes:  nil,
+		ImageTag: "",
+		Build:    "",
+		Report:   "",
+	}
+}
+
+// splitRuntimeVersion splits go_1.26 or node-22 into its language and
+// version; the version is empty when the runtime names none.
+func splitRuntimeVersion(runtime string) (string, string) {
+	i := strings.IndexAny(runtime, "_-")
+	if i < 0 {
+		return runtime, ""
+	}
+	return runtime[:i], runtime[i+1:]
+}
+
+// compareRuntimeVersions orders dotted numeric versions, treating missing
+// components as zero. ok is false when either version is not numeric.
+func compareRuntimeVersions(a, b string) (int, bool) {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		x, y := 0, 0
+		var err error
+		if i < len(as) {
+			if x, err = strconv.Atoi(as[i]); err != nil {
+				return 0, false
+			}
+		}
+		if i < len(bs) {
+			if y, err = strconv.Atoi(bs[i]); err != nil {
+				return 0, false
+			}
+		}
+		if x != y {
+			if x < y {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// validateRuntimeUpgradeTarget accepts a newer version of the project's
+// current language only; switching languages is a rewrite, not an upgrade.
+func validateRuntimeUpgradeTarget(spec ProjectSpec, target string) error {
+	if target == "" {
+		return errors.New("target_runtime required")
+	}
+	targetSpec := spec
+	targetSpec.Runtime = target
+	if err := validateProjectSpec(targetSpec); err != nil {
+		return fmt.Errorf("target_runtime: %w", err)
+	}
+	fromLang, fromVersion := splitRuntimeVersion(spec.Runtime)
+	toLang, toVersion := splitRuntimeVersion(target)
+	if fromLang != toLang {
+		return fmt.Errorf("target_runtime must keep the %s language of runtime %s", fromLang, spec.Runtime)
+	}
+	if fromVersion == "" || toVersion == "" {
+		return fmt.Errorf("runtime %s and target_runtime %s must both name a version", spec.Runtime, target)
+	}
+	if order, ok := compareRuntimeVersions(toVersion, fromVersion); !ok || order <= 0 {
+		return fmt.Errorf("target_runtime %s is not a newer numeric version than %s", target, spec.Runtime)
+	}
+	return nil
+}
+
+// runtimeImageNames are the base image names whose tags track a language's
+// version in Dockerfile FROM lines.
+func runtimeImageNames(language string) []string {
+	switch language {
+	case "go", "golang":
+		return []string{"golang", "go"}
+	case "node", "nodejs":
+		return []string{"node"}
+	default:
+		return []string{language}
+	}
+}
+
+// runtimeToolNames are the names .tool-versions uses for a language.
+func runtimeToolNames(language string) []string {
+	switch language {
+	case "go", "golang":
+		return []string{"golang", "go"}
+	case "node", "nodejs":
+		return []string{"nodejs", "node"}
+	default:
+		return []string{language}
+	}
+}
+
+// replaceVersionPrefix swaps a leading from version, including any finer
+// components (1.26.3 for 1.26), for to. 1.260 does not match 1.26.
+func replaceVersionPrefix(s, from, to string) (string, bool) {
+	rest, ok := strings.CutPrefix(s, from)
+	if !ok || (rest != "" && rest[0] >= '0' && rest[0] <= '9') {
+		return s, false
+	}
+	for len(rest) > 1 && rest[0] == '.' && rest[1] >= '0' && rest[1] <= '9' {
+		end := 1
+		for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+			end++
+		}
+		rest = rest[end:]
+	}
+	return to + rest, true
+}
+
+// rewriteRuntimeScaffold returns body with from's version pins moved to to,
+// and whether anything changed.
+func rewriteRuntimeScaffold(name string, body []byte, from, to string) ([]byte, bool) {
+	language, fromVersion := splitRuntimeVersion(from)
+	_, toVersion := splitRuntimeVersion(to)
+	lines := strings.SplitAfter(string(body), "\n")
+	changed := false
+	for i, line := range lines {
+		content := strings.TrimRight(line, "\r\n")
+		updated := content
+		switch name {
+		case "README.md":
+			updated = strings.ReplaceAll(content, "Runtime: "+from, "Runtime: "+to)
+		case "go.mod":
+			if version, ok := strings.CutPrefix(content, "go "); ok && (language == "go" || language == "golang") {
+				if next, replaced := replaceVersionPrefix(strings.TrimSpace(version), fromVersion, toVersion); replaced {
+					updated = "go " + next
+				}
+			}
+		case "Dockerfile":
+			updated = rewriteDockerfileFrom(content, runtimeImageNames(language), fromVersion, toVersion)
+		case ".tool-versions":
+			fields := strings.Fields(content)
+			if len(fields) == 2 && slices.Contains(runtimeToolNames(language), fields[0]) {
+				if next, replaced := replaceVersionPrefix(fields[1], fromVersion, toVersion); replaced {
+					updated = fields[0] + " " + next
+				}
+			}
+		case ".nvmrc", ".python-version":
+			version, hasV := strings.CutPrefix(content, "v")
+			if next, replaced := replaceVersionPrefix(version, fromVersion, toVersion); replaced {
+				updated = next
+				if hasV {
+					updated = "v" + next
+				}
+			}
+		}
+		if updated != content {
+			lines[i] = updated + line[len(content):]
+			changed = true
+		}
+	}
+	if !changed {
+		return body, false
+	}
+	return []byte(strings.Join(lines, "")), true
+}
+
+// rewriteDockerfileFrom retags a FROM line whose base image is one of images
+// and whose tag starts with the from version. Flags and the stage name are
+// kept.
+func rewriteDockerfileFrom(line string, images []string, fromVersion, toVersion string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+		return line
+	}
+	for i := 1; i < len(fields); i++ {
+		if strings.HasPrefix(fields[i], "--") {
+			continue
+		}
+		ref := fields[i]
+		colon := strings.LastIndex(ref, ":")
+		if colon < 0 || strings.Contains(ref[colon:], "/") {
+			return line
+		}
+		if !slices.Contains(images, path.Base(ref[:colon])) {
+			return line
+		}
+		tag, replaced := replaceVersionPrefix(ref[colon+1:], fromVersion, toVersion)
+		if !replaced {
+			return line
+		}
+		fields[i] = ref[:colon+1] + tag
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		return indent + strings.Join(fields, " ")
+	}
+	return line
+}
+
+func runtimeUpgradeWorkerAction(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	modeResolution imageBuilderModeResolution,
+) (WorkerResultMsg, error) {
+	res := newWorkerResultMsg("runtime upgrade worker starting")
+	spec := normalizeProjectSpec(msg.Spec)
+	upgrade := newRuntimeUpgrade(OpRuntimeUpgrade, spec, msg.RuntimeTarget)
+	_ = markOpStepStart(
+		ctx,
+		store,
+		msg.OpID,
+		"runtimeUpgrader",
+		time.Now().UTC(),
+		fmt.Sprintf("trial %s -> %s on branch %s", upgrade.From, upgrade.To, upgrade.Branch),
+	)
+
+	err := runRuntimeUpgrade(ctx, artifacts, msg, modeResolution, upgrade)
+	res.Artifacts = runtimeUpgradeArtifactPaths(artifacts, msg.ProjectID, upgrade)
+	if recordErr := recordRuntimeUpgrade(ctx, store, msg.OpID, upgrade); recordErr != nil && err == nil {
+		err = recordErr
+	}
+	if err != nil {
+		_ = markOpStepEnd(ctx, store, msg.OpID, "runtimeUpgrader", time.Now().UTC(), "", err.Error(), res.Artifacts)
+		_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "error", err.Error())
+		return res, err
+	}
+
+	res.Message = fmt.Sprintf("trial build of %s on %s passed", upgrade.To, upgrade.Branch)
+	_ = markOpStepEnd(ctx, store, msg.OpID, "runtimeUpgrader", time.Now().UTC(), res.Message, "", res.Artifacts)
+	_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, "done", "")
+	return res, nil
+}
+
+// runRuntimeUpgrade fills in upgrade as it goes, so a failed trial still
+// reports the branch and the build it attempted.
+func runRuntimeUpgrade(
+	ctx context.Context,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	modeResolution imageBuilderModeResolution,
+	upgrade *RuntimeUpgrade,
+) error {
+	if msg.Kind != OpRuntimeUpgrade {
+		return fmt.Errorf("runtime upgrade worker only handles %s operations", OpRuntimeUpgrade)
+	}
+	spec := normalizeProjectSpec(msg.Spec)
+	// Re-validate: the message may have been queued by another API replica.
+	if err := validateRuntimeUpgradeTarget(spec, msg.RuntimeTarget); err != nil {
+		return err
+	}
+	repoDir := sourceRepoDir(artifacts, msg.ProjectID)
+
+	subStepDone := beginSubStep(ctx, "commit upgrade branch")
+	commit, changes, err := gitCommitToBranch(
+		ctx,
+		repoDir,
+		upgrade.Branch,
+		fmt.Sprintf("platform: upgrade runtime %s -> %s", upgrade.From, upgrade.To),
+		runtimeUpgradeScaffoldFiles(),
+		func(name string, body []byte) ([]byte, bool) {
+			return rewriteRuntimeScaffold(name, body, upgrade.From, upgrade.To)
+		},
+	)
+	subStepDone(err)
+	if err != nil {
+		return err
+	}
+	upgrade.Commit, upgrade.Changes = commit, changes
+
+	buildErr := runRuntimeUpgradeBuild(ctx, artifacts, msg, modeResolution, upgrade)
+	upgrade.Build = runtimeUpgradeBuildPassed
+	if buildErr != nil {
+		upgrade.Build = runtimeUpgradeBuildFailed
+	}
+	reportStepProgress(ctx, "writing upgrade report", runtimeUpgradeProgressReport)
+	reportPath, err := artifacts.WriteFile(
+		msg.ProjectID,
+		runtimeUpgradeArtifactPath(msg.OpID, "report.json"),
+		mustJSON(runtimeUpgradeReport(upgrade, buildErr)),
+	)
+	if err == nil {
+		upgrade.Report = reportPath
+	}
+	return errors.Join(buildErr, err)
+}
+
+// runRuntimeUpgradeBuild builds the branch commit with the target runtime
+// from an exported copy, through the backend the image builder would pick.
+// The image is tagged for the trial only and never published.
+func runRuntimeUpgradeBuild(
+	ctx context.Context,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	modeResolution imageBuilderModeResolution,
+	upgrade *RuntimeUpgrade,
+) error {
+	contextDir, err := os.MkdirTemp("", "paas-upgrade-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(contextDir)
+	subStepDone := beginSubStep(ctx, "export upgrade branch")
+	err = gitExportCommit(ctx, sourceRepoDir(artifacts, msg.ProjectID), upgrade.Commit, contextDir)
+	subStepDone(err)
+	if err != nil {
+		return err
+	}
+
+	spec := normalizeProjectSpec(msg.Spec)
+	spec.Runtime = upgrade.To
+	upgrade.ImageTag = fmt.Sprintf("local/%s:upgrade-%s", safeName(spec.Name), shortID(msg.OpID))
+	req := imageBuildRequest{
+		OpID:              msg.OpID,
+		ProjectID:         msg.ProjectID,
+		Spec:              spec,
+		ImageTag:          upgrade.ImageTag,
+		ContextDir:        contextDir,
+		DockerfileBody:    nil,
+		DockerfileRelPath: "",
+	}
+	var backend imageBuilderBackend = artifactImageBuilderBackend{}
+	switch {
+	case spec.Build.strategy() == buildStrategyBuildpacks:
+		backend = buildpacksBackendFor(spec)
+	case modeResolution.policyError != "":
+		return errors.New(modeResolution.policyError)
+	case modeResolution.effectiveMode == imageBuilderModeBuildKit:
+		backend = buildKitImageBuilderBackend{}
+	}
+	if spec.Build.strategy() != buildStrategyBuildpacks {
+		// The branch's own Dockerfile carries the upgraded base image; the
+		// platform's rendered one stands in when the repo has none.
+		req.DockerfileBody, err = os.ReadFile(filepath.Join(contextDir, "Dockerfile"))
+		if err != nil {
+			req.DockerfileBody = renderImageBuilderDockerfile(spec)
+		}
+		req.DockerfileRelPath = runtimeUpgradeArtifactPath(msg.OpID, "Dockerfile")
+		if _, err = artifacts.WriteFile(msg.ProjectID, req.DockerfileRelPath, req.DockerfileBody); err != nil {
+			return err
+		}
+	}
+
+	buildCtx, cancel := context.WithTimeout(ctx, buildOpTimeout)
+	defer cancel()
+	reportStepProgress(
+		ctx,
+		fmt.Sprintf("trial building %s with %s backend", req.ImageTag, backend.name()),
+		runtimeUpgradeProgressBuild,
+	)
+	subStepDone = beginSubStep(ctx, "trial build with "+backend.name())
+	result, buildErr := backend.build(buildCtx, req)
+	subStepDone(buildErr)
+	traceCommand(ctx, fmt.Sprintf("%s build -t %s", backend.name(), req.ImageTag), result.logs, buildErr)
+	_, logErr := artifacts.WriteFile(
+		msg.ProjectID,
+		runtimeUpgradeArtifactPath(msg.OpID, "build.log"),
+		[]byte(result.logs),
+	)
+	return errors.Join(buildErr, logErr)
+}
+
+func runtimeUpgradeArtifactPath(opID, name string) string {
+	return path.Join(runtimeUpgradeArtifactRoot, opID, name)
+}
+
+// runtimeUpgradeArtifactPaths lists the upgrade artifacts the trial got as
+// far as writing.
+func runtimeUpgradeArtifactPaths(artifacts ArtifactStore, projectID string, upgrade *RuntimeUpgrade) []string {
+	out := []string{}
+	if upgrade.Report == "" {
+		return out
+	}
+	reportDir := path.Dir(upgrade.Report)
+	for _, name := range []string{"Dockerfile", "build.log", "report.json"} {
+		rel := path.Join(reportDir, name)
+		if _, err := os.Stat(filepath.Join(artifacts.ProjectDir(projectID), filepath.FromSlash(rel))); err == nil {
+			out = append(out, rel)
+		}
+	}
+	return out
+}
+
+func runtimeUpgradeReport(upgrade *RuntimeUpgrade, buildErr error) map[string]any {
+	report := map[string]any{
+		"from":      upgrade.From,
+		"to":        upgrade.To,
+		"branch":    upgrade.Branch,
+		"commit":    upgrade.Commit,
+		"changes":   upgrade.Changes,
+		"image_tag": upgrade.ImageTag,
+		"build":     upgrade.Build,
+		"merged":    false,
+	}
+	if buildErr != nil {
+		report["error"] = buildErr.Error()
+	}
+	return report
+}
+
+// recordRuntimeUpgrade stores the trial's outcome on the op.
+func recordRuntimeUpgrade(ctx context.Context, store *Store, opID string, upgrade *RuntimeUpgrade) error {
+	if store == nil {
+		return nil
+	}
+	recorded := *upgrade
+	_, err := store.updateOp(ctx, opID, func(op *Operation) bool {
+		op.Upgrade = &recorded
+		return true
+	})
+	return err
+}
@@ -2,6 +2,7 @@ package platform
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -11,16 +12,16 @@ import (
 	"time"
 )
 
-func localAPIBaseURL() string {
+func localAPIBaseURL(ctx context.Context) string {
 	base := strings.TrimSpace(os.Getenv("PAAS_LOCAL_API_BASE_URL"))
 	if base == "" {
-		base = "http://" + httpAddr
+		base = "http://" + runtimeConfigFromContext(ctx).HTTPAddr
 	}
 	return strings.TrimRight(base, "/")
 }
 
-func sourceWebhookEndpoint() string {
-	return localAPIBaseURL() + "/api/webhooks/source"
+func sourceWebhookEndpoint(ctx context.Context) string {
+	return localAPIBaseURL(ctx) + "/api/webhooks/source"
 }
 
 func commitWatcherEnabled() bool {
@@ -35,12 +36,16 @@ func commitWatcherEnabled() bool {
 	return enabled
 }
 
-func startSourceCommitWatcher(ctx context.Context, api *API) bool {
+// startSourceCommitWatcher runs the watcher as a singleton job so only the
+// lease-holding replica polls source repos.
+func startSourceCommitWatcher(ctx context.Context, api *API, elector *leaderElector) bool {
 	if !commitWatcherEnabled() {
 		return false
 	}
 	watcherLog := appLoggerForProcess().Source("sourceWatcher")
-	go runSourceCommitWatcher(ctx, api, watcherLog)
+	go runSingletonJob(ctx, elector, func(jobCtx context.Context) {
+		runSourceCommitWatcher(jobCtx, api, watcherLog)
+	})
 	return true
 }
 
@@ -64,6 +69,13 @@ func scanSourceRepos(
 	watcherLog sourceLogger,
 	lastSeenCommit map[string]string,
 ) {
+	// New commits wait out read-only mode; they are still unseen afterwards.
+	if readOnly, err := api.store.getReadOnlyState(ctx); err != nil || readOnly.Enabled {
+		if err != nil {
+			watcherLog.Warnf("read read-only state: %v", err)
+		}
+		return
+	}
 	projects, err := api.store.ListProjects(ctx)
 	if err != nil {
 		watcherLog.Warnf("list projects: %v", err)
@@ -145,7 +157,7 @@ func shouldRecordWatcherSeenCommit(result sourceRepoWebhookResult) bool {
 	if result.accepted {
 		return true
 	}
-	return result.reason == sourceRepoWebhookCommitIgnoredLabel
+	return result.reason == sourceRepoWebhookCommitIgnoredLabel || result.reason == sourceRepoWebhookPathsLabel
 }
 
 func sourceRepoDir(artifacts ArtifactStore, projectID string) string {
@@ -208,3 +220,46 @@ func installSourceWebhookHooks(repoDir, projectID, endpoint string) error {
 	}
 	return nil
 }
+
+func sourceWebhookMetaPath(repoDir string) string {
+	return filepath.Join(repoDir, ".paas", "webhook.json")
+}
+
+// writeSourceWebhook points the repo's hooks and its .paas/webhook.json at
+// endpoint. It returns the webhook.json path and whether that file changed.
+func writeSourceWebhook(repoDir, projectID, endpoint string) (string, bool, error) {
+	if err := installSourceWebhookHooks(repoDir, projectID, endpoint); err != nil {
+		return "", false, err
+	}
+	webhookMeta := sourceWebhookMetaPath(repoDir)
+	updated, err := upsertFile(webhookMeta, mustJSON(map[string]any{
+		"project_id": projectID,
+		"repo":       "source",
+		"branch":     branchMain,
+		"endpoint":   endpoint,
+		"hooks":      []string{"post-commit", "post-merge"},
+	}))
+	if err != nil {
+		return "", false, err
+	}
+	return webhookMeta, updated, nil
+}
+
+// readSourceWebhookEndpoint reports the endpoint the repo's webhook.json
+// records, or false when the repo was never bootstrapped.
+func readSourceWebhookEndpoint(repoDir string) (string, bool, error) {
+	raw, err := os.ReadFile(sourceWebhookMetaPath(repoDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	var meta struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err = json.Unmarshal(raw, &meta); err != nil {
+		return "", false, fmt.Errorf("decode %s: %w", sourceWebhookMetaPath(repoDir), err)
+	}
+	return strings.TrimSpace(meta.Endpoint), true, nil
+}
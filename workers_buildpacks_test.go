@@ -0,0 +1,124 @@
+//nolint:testpackage,exhaustruct // Buildpacks tests drive the unexported builder with a fake pack binary.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildpacks_SpecValidationAndRuntimeMapping(t *testing.T) {
+	t.Parallel()
+
+	base := normalizeProjectSpec(ProjectSpec{
+		APIVersion: projectAPIVersion, Kind: projectKind, Name: "bp-spec", Runtime: "node_22",
+		Environments: map[string]EnvConfig{"dev": {}},
+	})
+	for _, tc := range []struct {
+		runtime string
+		build   BuildConfig
+		wantErr string
+	}{
+		{runtime: "node_22", build: BuildConfig{Strategy: " buildpacks "}},
+		{runtime: "python_3.14", build: BuildConfig{Strategy: "buildpacks", Builder: "gcr.io/buildpacks/builder:v1"}},
+		{runtime: "go_1.26", build: BuildConfig{Strategy: "dockerfile"}},
+		{runtime: "ruby_3.3", build: BuildConfig{Strategy: "buildpacks"}, wantErr: "go, node, or python runtime"},
+		{runtime: "go_1.26", build: BuildConfig{Strategy: "nixpacks"}, wantErr: "build.strategy must be"},
+		{runtime: "go_1.26", build: BuildConfig{Builder: "paketobuildpacks/builder"}, wantErr: "only to the buildpacks"},
+		{runtime: "go_1.26", build: BuildConfig{Strategy: "buildpacks", Builder: "--publish"}, wantErr: "invalid build.builder"},
+	} {
+		spec := base
+		spec.Runtime = tc.runtime
+		spec.Build = tc.build
+		err := validateProjectSpec(normalizeProjectSpec(spec))
+		if tc.wantErr == "" && err != nil {
+			t.Fatalf("%s %+v: unexpected error %v", tc.runtime, tc.build, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Fatalf("%s %+v: expected %q, got %v", tc.runtime, tc.build, tc.wantErr, err)
+		}
+	}
+
+	if env := buildpacksBuildEnv("python_3.14"); env["BP_CPYTHON_VERSION"] != "3.14.*" || len(env) != 1 {
+		t.Fatalf("unexpected python build env: %#v", env)
+	}
+	if env := buildpacksBuildEnv("go"); len(env) != 0 {
+		t.Fatalf("expected no version pin for a bare runtime, got %#v", env)
+	}
+}
+
+func TestBuildpacks_ImageBuilderRunsPackAndRecordsPlan(t *testing.T) {
+	packLog := filepath.Join(t.TempDir(), "pack.log")
+	fakePack := filepath.Join(t.TempDir(), "pack")
+	script := "#!/bin/sh\necho \"$@\" >> " + packLog + "\n" +
+		"if [ -n \"$PACK_FAIL\" ]; then echo 'ERROR: No buildpack groups passed detection.' >&2; exit 1; fi\n" +
+		"cat <<'EOF'\n" +
+		"===> ANALYZING\n" +
+		"Image with name \"local/bp-app:tag\" not found\n" +
+		"===> DETECTING\n" +
+		"[detector] paketo-buildpacks/ca-certificates 3.8.1\n" +
+		"[detector] paketo-buildpacks/go-dist        2.6.3\n" +
+		"[detector] paketo-buildpacks/go-build       4.1.2\n" +
+		"===> EXPORTING\n" +
+		"*** Images (sha256:4f7ab2c9):\n" +
+		"      local/bp-app:tag\n" +
+		"Successfully built image 'local/bp-app:tag'\n" +
+		"EOF\n"
+	if err := os.WriteFile(fakePack, []byte(script), 0o700); err != nil {
+		t.Fatalf("write fake pack: %v", err)
+	}
+	t.Setenv(packBinaryEnv, fakePack)
+	t.Setenv(buildpacksBuilderEnv, "example.com/builders/base:1")
+	t.Setenv(imageBuilderModeEnv, string(imageBuilderModeArtifact))
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := normalizeProjectSpec(ProjectSpec{
+		APIVersion: projectAPIVersion, Kind: projectKind, Name: "bp-app", Runtime: "go_1.26",
+		Build:        BuildConfig{Strategy: buildStrategyBuildpacks},
+		Environments: map[string]EnvConfig{"dev": {}},
+	})
+	msg := ProjectOpMsg{OpID: "op-buildpacks", Kind: OpCI, ProjectID: "project-buildpacks", Spec: spec}
+
+	outcome, err := runImageBuilderBuild(context.Background(), artifacts, msg, spec, "local/bp-app:tag")
+	if err != nil {
+		t.Fatalf("buildpacks build: %v", err)
+	}
+	for _, want := range []string{buildpacksPlanPath, buildpacksLogPath, imageBuildTagPath, imageBuildPublishPath} {
+		if !slices.Contains(outcome.artifacts, want) {
+			t.Fatalf("expected %s in %v", want, outcome.artifacts)
+		}
+	}
+	if slices.Contains(outcome.artifacts, imageBuildDockerfilePath) {
+		t.Fatalf("buildpacks builds should not render a Dockerfile: %v", outcome.artifacts)
+	}
+	invoked, _ := os.ReadFile(packLog)
+	wantArgs := "build local/bp-app:tag --path " + sourceRepoDir(artifacts, msg.ProjectID) +
+		" --builder example.com/builders/base:1 --env BP_GO_VERSION=1.26.*"
+	if strings.TrimSpace(string(invoked)) != wantArgs {
+		t.Fatalf("unexpected pack invocation:\n got %s\nwant %s", invoked, wantArgs)
+	}
+
+	var plan buildpacksPlan
+	raw, _ := artifacts.ReadFile(msg.ProjectID, buildpacksPlanPath)
+	if err = json.Unmarshal(raw, &plan); err != nil {
+		t.Fatalf("decode plan: %v", err)
+	}
+	if plan.Status != "ok" || plan.Language != "go" || plan.ImageReference != "local/bp-app:tag@sha256:4f7ab2c9" ||
+		len(plan.Buildpacks) != 3 || plan.Buildpacks[1] != (buildpackRef{ID: "paketo-buildpacks/go-dist", Version: "2.6.3"}) {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	t.Setenv("PACK_FAIL", "1")
+	outcome, err = runImageBuilderBuild(context.Background(), artifacts, msg, spec, "local/bp-app:tag")
+	if err == nil || !strings.Contains(err.Error(), "pack build local/bp-app:tag") {
+		t.Fatalf("expected pack failure, got %v", err)
+	}
+	raw, _ = artifacts.ReadFile(msg.ProjectID, buildpacksLogPath)
+	if !slices.Contains(outcome.artifacts, buildpacksLogPath) || !strings.Contains(string(raw), "passed detection") {
+		t.Fatalf("expected the pack log kept on failure, got %v:\n%s", outcome.artifacts, raw)
+	}
+}
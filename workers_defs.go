@@ -3,12 +3,13 @@ package platform
 import "context"
 
 type Worker interface {
+	Name() string
 	Start(ctx context.Context) error
 }
 
 type WorkerBase struct {
 	name       string
-	natsURL    string
+	endpoint   natsEndpoint
 	subjectIn  string
 	subjectOut string
 	artifacts  ArtifactStore
@@ -16,13 +17,15 @@ type WorkerBase struct {
 }
 
 func newWorkerBase(
-	name, natsURL, subjectIn, subjectOut string,
+	name string,
+	endpoint natsEndpoint,
+	subjectIn, subjectOut string,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 ) WorkerBase {
 	return WorkerBase{
 		name:       name,
-		natsURL:    natsURL,
+		endpoint:   endpoint,
 		subjectIn:  subjectIn,
 		subjectOut: subjectOut,
 		artifacts:  artifacts,
@@ -30,6 +33,10 @@ func newWorkerBase(
 	}
 }
 
+func (w WorkerBase) Name() string {
+	return w.name
+}
+
 type (
 	RegistrationWorker  struct{ WorkerBase }
 	RepoBootstrapWorker struct{ WorkerBase }
@@ -41,19 +48,25 @@ type (
 	ManifestRendererWorker struct{ WorkerBase }
 	DeploymentWorker       struct{ WorkerBase }
 	PromotionWorker        struct{ WorkerBase }
+	ArtifactCleanupWorker  struct{ WorkerBase }
+	RuntimeUpgradeWorker   struct {
+		WorkerBase
+
+		modeResolution imageBuilderModeResolution
+	}
 )
 
 func NewRegistrationWorker(
-	natsURL string,
+	endpoint natsEndpoint,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 ) *RegistrationWorker {
 	return &RegistrationWorker{
 		WorkerBase: newWorkerBase(
 			"registrar",
-			natsURL,
-			subjectProjectOpStart,
-			subjectRegistrationDone,
+			endpoint,
+			natsSubject(subjectProjectOpStart),
+			natsSubject(subjectRegistrationDone),
 			artifacts,
 			opEvents,
 		),
@@ -61,16 +74,16 @@ func NewRegistrationWorker(
 }
 
 func NewRepoBootstrapWorker(
-	natsURL string,
+	endpoint natsEndpoint,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 ) *RepoBootstrapWorker {
 	return &RepoBootstrapWorker{
 		WorkerBase: newWorkerBase(
 			"repoBootstrap",
-			natsURL,
-			subjectRegistrationDone,
-			subjectBootstrapDone,
+			endpoint,
+			natsSubject(subjectRegistrationDone),
+			natsSubject(subjectBootstrapDone),
 			artifacts,
 			opEvents,
 		),
@@ -78,7 +91,7 @@ func NewRepoBootstrapWorker(
 }
 
 func NewImageBuilderWorker(
-	natsURL string,
+	endpoint natsEndpoint,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 	modeResolution imageBuilderModeResolution,
@@ -86,9 +99,9 @@ func NewImageBuilderWorker(
 	return &ImageBuilderWorker{
 		WorkerBase: newWorkerBase(
 			"imageBuilder",
-			natsURL,
-			subjectBootstrapDone,
-			subjectBuildDone,
+			endpoint,
+			natsSubject(subjectBootstrapDone),
+			natsSubject(subjectBuildDone),
 			artifacts,
 			opEvents,
 		),
@@ -97,16 +110,16 @@ func NewImageBuilderWorker(
 }
 
 func NewManifestRendererWorker(
-	natsURL string,
+	endpoint natsEndpoint,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 ) *ManifestRendererWorker {
 	return &ManifestRendererWorker{
 		WorkerBase: newWorkerBase(
 			"manifestRenderer",
-			natsURL,
-			subjectBuildDone,
-			subjectDeployDone,
+			endpoint,
+			natsSubject(subjectBuildDone),
+			natsSubject(subjectDeployDone),
 			artifacts,
 			opEvents,
 		),
@@ -114,16 +127,16 @@ func NewManifestRendererWorker(
 }
 
 func NewDeploymentWorker(
-	natsURL string,
+	endpoint natsEndpoint,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 ) *DeploymentWorker {
 	return &DeploymentWorker{
 		WorkerBase: newWorkerBase(
 			"deployer",
-			natsURL,
-			subjectDeploymentStart,
-			subjectDeploymentDone,
+			endpoint,
+			natsSubject(subjectDeploymentStart),
+			natsSubject(subjectDeploymentDone),
 			artifacts,
 			opEvents,
 		),
@@ -131,27 +144,63 @@ func NewDeploymentWorker(
 }
 
 func NewPromotionWorker(
-	natsURL string,
+	endpoint natsEndpoint,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 ) *PromotionWorker {
 	return &PromotionWorker{
 		WorkerBase: newWorkerBase(
 			"promoter",
-			natsURL,
-			subjectPromotionStart,
-			subjectPromotionDone,
+			endpoint,
+			natsSubject(subjectPromotionStart),
+			natsSubject(subjectPromotionDone),
 			artifacts,
 			opEvents,
 		),
 	}
 }
 
+func NewArtifactCleanupWorker(
+	endpoint natsEndpoint,
+	artifacts ArtifactStore,
+	opEvents *opEventHub,
+) *ArtifactCleanupWorker {
+	return &ArtifactCleanupWorker{
+		WorkerBase: newWorkerBase(
+			"artifactCleaner",
+			endpoint,
+			natsSubject(subjectCleanupStart),
+			natsSubject(subjectCleanupDone),
+			artifacts,
+			opEvents,
+		),
+	}
+}
+
+func NewRuntimeUpgradeWorker(
+	endpoint natsEndpoint,
+	artifacts ArtifactStore,
+	opEvents *opEventHub,
+	modeResolution imageBuilderModeResolution,
+) *RuntimeUpgradeWorker {
+	return &RuntimeUpgradeWorker{
+		WorkerBase: newWorkerBase(
+			"runtimeUpgrader",
+			endpoint,
+			natsSubject(subjectUpgradeStart),
+			natsSubject(subjectUpgradeDone),
+			artifacts,
+			opEvents,
+		),
+		modeResolution: modeResolution,
+	}
+}
+
 func (w *RegistrationWorker) Start(ctx context.Context) error {
 	return startWorker(
 		ctx,
 		w.name,
-		w.natsURL,
+		w.endpoint,
 		w.subjectIn,
 		w.subjectOut,
 		w.artifacts,
@@ -164,7 +213,7 @@ func (w *RepoBootstrapWorker) Start(ctx context.Context) error {
 	return startWorker(
 		ctx,
 		w.name,
-		w.natsURL,
+		w.endpoint,
 		w.subjectIn,
 		w.subjectOut,
 		w.artifacts,
@@ -177,7 +226,7 @@ func (w *ImageBuilderWorker) Start(ctx context.Context) error {
 	return startWorker(
 		ctx,
 		w.name,
-		w.natsURL,
+		w.endpoint,
 		w.subjectIn,
 		w.subjectOut,
 		w.artifacts,
@@ -203,7 +252,7 @@ func (w *ManifestRendererWorker) Start(ctx context.Context) error {
 	return startWorker(
 		ctx,
 		w.name,
-		w.natsURL,
+		w.endpoint,
 		w.subjectIn,
 		w.subjectOut,
 		w.artifacts,
@@ -216,7 +265,7 @@ func (w *DeploymentWorker) Start(ctx context.Context) error {
 	return startWorker(
 		ctx,
 		w.name,
-		w.natsURL,
+		w.endpoint,
 		w.subjectIn,
 		w.subjectOut,
 		w.artifacts,
@@ -229,7 +278,7 @@ func (w *PromotionWorker) Start(ctx context.Context) error {
 	return startWorker(
 		ctx,
 		w.name,
-		w.natsURL,
+		w.endpoint,
 		w.subjectIn,
 		w.subjectOut,
 		w.artifacts,
@@ -238,4 +287,37 @@ func (w *PromotionWorker) Start(ctx context.Context) error {
 	)
 }
 
+func (w *ArtifactCleanupWorker) Start(ctx context.Context) error {
+	return startWorker(
+		ctx,
+		w.name,
+		w.endpoint,
+		w.subjectIn,
+		w.subjectOut,
+		w.artifacts,
+		w.opEvents,
+		artifactCleanupWorkerAction,
+	)
+}
+
+func (w *RuntimeUpgradeWorker) Start(ctx context.Context) error {
+	return startWorker(
+		ctx,
+		w.name,
+		w.endpoint,
+		w.subjectIn,
+		w.subjectOut,
+		w.artifacts,
+		w.opEvents,
+		func(
+			actionCtx context.Context,
+			store *Store,
+			artifacts ArtifactStore,
+			msg ProjectOpMsg,
+		) (WorkerResultMsg, error) {
+			return runtimeUpgradeWorkerAction(actionCtx, store, artifacts, msg, w.modeResolution)
+		},
+	)
+}
+
 type workerFn func(ctx context.Context, store *Store, artifacts ArtifactStore, msg ProjectOpMsg) (WorkerResultMsg, error)
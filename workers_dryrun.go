@@ -0,0 +1,335 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Dry-run execution: each worker runs the same validation it would before
+// acting, then records the changes it would have made in its step's Plan
+// instead of making them.
+////////////////////////////////////////////////////////////////////////////////
+
+func dryRunWorkerAction(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	workerName string,
+	finalStage bool,
+	msg ProjectOpMsg,
+) (WorkerResultMsg, error) {
+	res := newWorkerResultMsg("dry run starting")
+	_ = markOpStepStart(
+		ctx,
+		store,
+		msg.OpID,
+		workerName,
+		time.Now().UTC(),
+		fmt.Sprintf("dry run: plan %s changes for %s", workerName, msg.Kind),
+	)
+
+	done := traceSubStep(ctx, "plan "+workerName)
+	plan, err := planWorkerChanges(ctx, store, artifacts, workerName, msg)
+	done(err)
+	if err != nil {
+		_ = markOpStepEnd(ctx, store, msg.OpID, workerName, time.Now().UTC(), "", err.Error(), nil)
+		return res, err
+	}
+
+	res.Message = fmt.Sprintf("dry run: %d change(s) planned, none applied", len(plan))
+	_ = markOpStepEnd(ctx, store, msg.OpID, workerName, time.Now().UTC(), res.Message, "", nil)
+	_ = amendLastOpStep(ctx, store, msg.OpID, workerName, func(step *OpStep) {
+		step.Plan = plan
+	})
+	if finalStage {
+		_ = finalizeOp(ctx, store, msg.OpID, msg.ProjectID, msg.Kind, opStatusDone, "")
+	}
+	return res, nil
+}
+
+func planWorkerChanges(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	workerName string,
+	msg ProjectOpMsg,
+) ([]string, error) {
+	switch workerName {
+	case "registrar":
+		return planRegistration(msg)
+	case "repoBootstrap":
+		return planRepoBootstrap(artifacts, msg)
+	case "imageBuilder":
+		return planImageBuild(msg)
+	case "manifestRenderer":
+		return planManifestRender(ctx, store, artifacts, msg)
+	case "deployer":
+		return planDeployment(artifacts, msg)
+	case "promoter":
+		return planPromotion(ctx, store, artifacts, msg)
+	case "artifactCleaner":
+		return planArtifactCleanup(artifacts, msg)
+	case "runtimeUpgrader":
+		return planRuntimeUpgrade(artifacts, msg)
+	default:
+		return nil, fmt.Errorf("worker %s has no dry-run planner", workerName)
+	}
+}
+
+func planRegistration(msg ProjectOpMsg) ([]string, error) {
+	switch msg.Kind {
+	case OpCreate, OpUpdate:
+		if err := validateProjectSpec(normalizeProjectSpec(msg.Spec)); err != nil {
+			return nil, err
+		}
+		return []string{
+			"write registration/project.yaml",
+			"write registration/registration.json",
+		}, nil
+	case OpDelete:
+		return []string{"write registration/deregister.txt"}, nil
+	case OpCI, OpDeploy, OpPromote, OpRelease, OpRollback, OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade,
+		OpWebhookRefresh:
+		return nil, fmt.Errorf("registration worker does not handle %s operations", msg.Kind)
+	default:
+		return nil, fmt.Errorf("unknown op kind: %s", msg.Kind)
+	}
+}
+
+func planRepoBootstrap(artifacts ArtifactStore, msg ProjectOpMsg) ([]string, error) {
+	switch msg.Kind {
+	case OpCreate, OpUpdate:
+		plan := []string{}
+		if _, skipped := specChangeSkipOutcome(msg, stageRepoBootstrap, ""); skipped {
+			return plan, nil
+		}
+		for _, repo := range []struct{ name, dir string }{
+			{"source", sourceRepoDir(artifacts, msg.ProjectID)},
+			{"manifests", manifestsRepoDir(artifacts, msg.ProjectID)},
+		} {
+			if _, err := os.Stat(repo.dir); errors.Is(err, os.ErrNotExist) {
+				plan = append(plan, fmt.Sprintf("initialize %s repo at repos/%s", repo.name, repo.name))
+			}
+			plan = append(plan, fmt.Sprintf("seed and commit %s repo files (platform-sync)", repo.name))
+		}
+		return append(plan, "install source repo webhook hook"), nil
+	case OpDelete:
+		return []string{"write repos/teardown-plan.txt"}, nil
+	case OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade, OpWebhookRefresh:
+		return nil, fmt.Errorf("repo bootstrap does not handle %s operations", msg.Kind)
+	case OpCI, OpDeploy, OpPromote, OpRelease, OpRollback:
+		return []string{}, nil
+	default:
+		return nil, fmt.Errorf("unknown op kind: %s", msg.Kind)
+	}
+}
+
+func planImageBuild(msg ProjectOpMsg) ([]string, error) {
+	spec := normalizeProjectSpec(msg.Spec)
+	switch msg.Kind {
+	case OpCreate, OpUpdate, OpCI:
+		if _, skipped := specChangeSkipOutcome(msg, stageImageBuilder, ""); skipped {
+			return []string{}, nil
+		}
+		imageTag := fmt.Sprintf("local/%s:%s", safeName(spec.Name), shortID(msg.OpID))
+		return []string{
+			"build image " + imageTag,
+			"write " + imageBuildTagPath + " = " + imageTag,
+		}, nil
+	case OpDelete:
+		return []string{"write build/image-prune.txt"}, nil
+	case OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade, OpWebhookRefresh:
+		return nil, fmt.Errorf("image builder does not handle %s operations", msg.Kind)
+	case OpDeploy, OpPromote, OpRelease, OpRollback:
+		return []string{}, nil
+	default:
+		return nil, fmt.Errorf("unknown op kind: %s", msg.Kind)
+	}
+}
+
+func planManifestRender(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+) ([]string, error) {
+	switch msg.Kind {
+	case OpCreate, OpUpdate, OpCI:
+		spec := normalizeProjectSpec(msg.Spec)
+		imageTag := updateImageTag(msg, spec)
+		if isPreviewOp(msg) {
+			return []string{fmt.Sprintf("render preview %s with image %s under %s",
+				previewEnvironment(msg.CI.Preview), imageTag, previewArtifactDir(msg.CI.Preview))}, nil
+		}
+		targetEnv := renderTargetEnvironment(msg)
+		return planKubeApply(append(planPostgresProvision(spec),
+			fmt.Sprintf("write kustomize overlays for %s", strings.Join(desiredManifestEnvironments(spec), ", ")),
+			fmt.Sprintf("render deploy/%s manifests with image %s", targetEnv, imageTag),
+			fmt.Sprintf("commit manifests repo: deploy %s manifests", targetEnv),
+		), targetEnv), nil
+	case OpDelete:
+		if store != nil {
+			holds, err := store.getProjectHolds(ctx, msg.ProjectID)
+			if err != nil {
+				return nil, fmt.Errorf("read compliance holds: %w", err)
+			}
+			if holds.active() {
+				return nil, errors.New("project is under compliance hold; delete refused")
+			}
+		}
+		files, err := artifacts.ListFiles(msg.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		plan := []string{fmt.Sprintf("remove project artifact tree (%d file(s))", len(files))}
+		if namespaces := projectNamespaces(deleteTeardownSpec(ctx, store, msg)); len(namespaces) > 0 {
+			plan = append(plan, "record namespace teardown for "+strings.Join(namespaces, ", "))
+		}
+		if postgresProvisionerEnabled() {
+			plan = append(plan, "remove managed postgres containers")
+		}
+		return append(plan, "delete project record"), nil
+	case OpDeploy, OpPromote, OpRelease, OpRollback, OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade,
+		OpWebhookRefresh:
+		return nil, fmt.Errorf("manifest renderer does not handle %s operations", msg.Kind)
+	default:
+		return nil, fmt.Errorf("unknown op kind: %s", msg.Kind)
+	}
+}
+
+func planDeployment(artifacts ArtifactStore, msg ProjectOpMsg) ([]string, error) {
+	if msg.Kind != OpDeploy {
+		return nil, fmt.Errorf("deployment worker only handles %s operations", OpDeploy)
+	}
+	targetEnv := resolveDeployEnvironment(msg.DeployEnv)
+	if targetEnv != defaultDeployEnvironment {
+		return nil, fmt.Errorf(
+			"deployment environment %q not supported; use promotion/release for higher environments",
+			targetEnv,
+		)
+	}
+	imageTag, err := readBuildImageTagForDeployment(artifacts, msg.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return planKubeApply([]string{
+		fmt.Sprintf("render deploy/%s manifests with image %s", targetEnv, imageTag),
+		fmt.Sprintf("commit manifests repo: deploy %s manifests", targetEnv),
+		fmt.Sprintf("record release %s for %s", releaseIDForOp(msg.OpID), targetEnv),
+	}, targetEnv), nil
+}
+
+func planPromotion(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+) ([]string, error) {
+	spec := normalizeProjectSpec(msg.Spec)
+	switch msg.Kind {
+	case OpPromote, OpRelease:
+		fromEnv, toEnv, err := validatePromotionRequestEnvironments(spec, msg)
+		if err != nil {
+			return nil, err
+		}
+		transition := transitionDescriptorForRequest(msg.Kind, msg.Delivery, toEnv)
+		imageByEnv, err := loadManifestImageTags(artifacts, msg.ProjectID, spec)
+		if err != nil {
+			return nil, err
+		}
+		sourceImage, err := resolvePromotionSourceImage(artifacts, msg.ProjectID, fromEnv, imageByEnv)
+		if err != nil {
+			return nil, err
+		}
+		if sourceImage == "" {
+			return nil, fmt.Errorf("no promoted image found for source environment %q", fromEnv)
+		}
+		return planKubeApply([]string{
+			fmt.Sprintf("%s image %s from %s to %s", transition.commitVerb, sourceImage, fromEnv, toEnv),
+			fmt.Sprintf("write %s/%s-to-%s manifests", transition.artifactDir, fromEnv, toEnv),
+			fmt.Sprintf("commit manifests repo: %s %s", transition.commitVerb, toEnv),
+			fmt.Sprintf("record release %s for %s", releaseIDForOp(msg.OpID), toEnv),
+		}, toEnv), nil
+	case OpRollback:
+		release, err := store.GetRelease(ctx, msg.RollbackReleaseID)
+		if err != nil {
+			return nil, fmt.Errorf("read rollback release %s: %w", msg.RollbackReleaseID, err)
+		}
+		return planKubeApply([]string{
+			fmt.Sprintf(
+				"roll %s back to release %s (image %s, scope %s)",
+				msg.RollbackEnv,
+				release.ID,
+				release.Image,
+				msg.RollbackScope,
+			),
+			"commit manifests repo: rollback " + msg.RollbackEnv,
+			fmt.Sprintf("record release %s for %s", releaseIDForOp(msg.OpID), msg.RollbackEnv),
+		}, msg.RollbackEnv), nil
+	case OpCreate, OpUpdate, OpDelete, OpCI, OpDeploy, OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade,
+		OpWebhookRefresh:
+		return nil, fmt.Errorf(
+			"promotion worker only handles %s, %s, and %s operations",
+			OpPromote,
+			OpRelease,
+			OpRollback,
+		)
+	default:
+		return nil, fmt.Errorf("unknown op kind: %s", msg.Kind)
+	}
+}
+
+func planArtifactCleanup(artifacts ArtifactStore, msg ProjectOpMsg) ([]string, error) {
+	if msg.Kind != OpCleanup {
+		return nil, fmt.Errorf("artifact cleanup worker only handles %s operations", OpCleanup)
+	}
+	prefix, err := normalizeArtifactCleanupPrefix(msg.ArtifactPrefix)
+	if err != nil {
+		return nil, err
+	}
+	files, err := artifacts.ListFiles(msg.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	plan := []string{}
+	for _, rel := range files {
+		if rel == prefix || strings.HasPrefix(rel, prefix+"/") {
+			plan = append(plan, "remove "+rel)
+		}
+	}
+	slices.Sort(plan)
+	return plan, nil
+}
+
+func planRuntimeUpgrade(artifacts ArtifactStore, msg ProjectOpMsg) ([]string, error) {
+	if msg.Kind != OpRuntimeUpgrade {
+		return nil, fmt.Errorf("runtime upgrade worker only handles %s operations", OpRuntimeUpgrade)
+	}
+	spec := normalizeProjectSpec(msg.Spec)
+	if err := validateRuntimeUpgradeTarget(spec, msg.RuntimeTarget); err != nil {
+		return nil, err
+	}
+	upgrade := newRuntimeUpgrade(msg.Kind, spec, msg.RuntimeTarget)
+	plan := []string{}
+	for _, name := range runtimeUpgradeScaffoldFiles() {
+		body, err := os.ReadFile(filepath.Join(sourceRepoDir(artifacts, msg.ProjectID), name))
+		if err != nil {
+			continue
+		}
+		if _, changed := rewriteRuntimeScaffold(name, body, upgrade.From, upgrade.To); changed {
+			plan = append(plan, fmt.Sprintf("update repos/source/%s on branch %s", name, upgrade.Branch))
+		}
+	}
+	plan = append(plan,
+		fmt.Sprintf("trial build %s as local/%s:upgrade-%s", upgrade.To, safeName(spec.Name), shortID(msg.OpID)),
+		"write "+runtimeUpgradeArtifactPath(msg.OpID, "report.json"),
+	)
+	return plan, nil
+}
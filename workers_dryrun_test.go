@@ -0,0 +1,110 @@
+//nolint:testpackage,exhaustruct // Dry-run tests drive the unexported worker delivery loop directly.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWorkers_DryRunPlansWithoutSideEffectsAndAttachesTrace(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	ctx := context.Background()
+	spec := workerRuntimeSpec("worker-dry-run")
+	opID := "op-worker-dry-run-1"
+	projectID := "project-worker-dry-run-1"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCleanup, spec)
+	execution := OpExecution{DryRun: true, Trace: true}
+	queued, err := fixture.store.GetOp(ctx, opID)
+	if err != nil {
+		t.Fatalf("get queued op: %v", err)
+	}
+	queued.Execution = execution // enqueueOp records the profile on the op
+	if err = fixture.store.PutOp(ctx, queued); err != nil {
+		t.Fatalf("put queued op: %v", err)
+	}
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	for _, rel := range []string{"build/image.txt", "deploy/dev/deployment.yaml"} {
+		if _, err = artifacts.WriteFile(projectID, rel, []byte("x\n")); err != nil {
+			t.Fatalf("seed %s: %v", rel, err)
+		}
+	}
+
+	var msg ProjectOpMsg
+	if err = json.Unmarshal(workerPayload(t, opID, OpCleanup, projectID, spec), &msg); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	msg.ArtifactPrefix = "build"
+	msg.Execution = execution
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("encode payload: %v", err)
+	}
+
+	realAction := func(context.Context, *Store, ArtifactStore, ProjectOpMsg) (WorkerResultMsg, error) {
+		t.Fatal("dry run must not invoke the worker's real action")
+		return WorkerResultMsg{}, nil
+	}
+	decision := handleWorkerDelivery(
+		ctx,
+		fixture.store,
+		artifacts,
+		"artifactCleaner",
+		natsSubject(subjectCleanupStart),
+		natsSubject(subjectCleanupDone),
+		realAction,
+		fixture.js,
+		data,
+		1,
+		appLoggerForProcess().Source("workers-test"),
+		publishWorkerResult,
+		publishWorkerPoison,
+	)
+	if decision.action != workerDeliveryAck {
+		t.Fatalf("expected ack, got %d", decision.action)
+	}
+
+	if _, err = artifacts.ReadFile(projectID, "build/image.txt"); err != nil {
+		t.Fatalf("dry run removed build/image.txt: %v", err)
+	}
+	op, err := fixture.store.GetOp(ctx, opID)
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	if op.Status != opStatusDone || len(op.Steps) != 1 {
+		t.Fatalf("expected a done op with one step, got status=%s steps=%d", op.Status, len(op.Steps))
+	}
+	step := op.Steps[0]
+	if !slices.Equal(step.Plan, []string{"remove build/image.txt"}) {
+		t.Fatalf("unexpected plan: %v", step.Plan)
+	}
+	tracePath := "traces/" + opID + "/artifactCleaner.json"
+	if !slices.Contains(step.Artifacts, tracePath) {
+		t.Fatalf("expected step artifacts to link %s, got %v", tracePath, step.Artifacts)
+	}
+	raw, err := artifacts.ReadFile(projectID, tracePath)
+	if err != nil {
+		t.Fatalf("read trace: %v", err)
+	}
+	var trace opTraceArtifact
+	if err = json.Unmarshal(raw, &trace); err != nil {
+		t.Fatalf("decode trace: %v", err)
+	}
+	if !trace.DryRun || len(trace.Entries) == 0 ||
+		!strings.HasPrefix(trace.Entries[0].Name, "plan artifactCleaner") {
+		t.Fatalf("unexpected trace: %+v", trace)
+	}
+
+	project, err := fixture.store.GetProject(ctx, projectID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if project.Status.LastOpID != "" || project.Status.Phase != projectPhaseReady {
+		t.Fatalf("dry run must not touch project status, got %+v", project.Status)
+	}
+}
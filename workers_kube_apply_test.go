@@ -0,0 +1,124 @@
+//nolint:testpackage,exhaustruct // Kube apply tests drive the unexported applier with a fake kubectl binary.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKubeApply_AppliesRenderedManifestsAndRecordsRollout(t *testing.T) {
+	dir := t.TempDir()
+	kubectlLog := filepath.Join(dir, "kubectl.log")
+	secretInput := filepath.Join(dir, "secret.json")
+	fakeKubectl := filepath.Join(dir, "kubectl")
+	script := "#!/bin/sh\necho \"$@\" >> " + kubectlLog + "\n" +
+		"case \"$3\" in\n" +
+		"apply) input=$(cat)\n" +
+		"  case \"$input\" in\n" +
+		"  *'\"kind\":\"Secret\"'*) echo \"$input\" > " + secretInput + "; echo secret/kube-app-secrets;;\n" +
+		"  *) printf 'namespace/kube-app-dev\\ndeployment.apps/kube-app\\nservice/kube-app\\n';;\n" +
+		"  esac;;\n" +
+		"rollout) if [ -n \"$KUBE_FAIL\" ]; then\n" +
+		"  echo 'error: deployment \"kube-app\" exceeded its progress deadline' >&2; exit 1; fi\n" +
+		"  echo 'deployment \"kube-app\" successfully rolled out';;\n" +
+		"esac\n"
+	if err := os.WriteFile(fakeKubectl, []byte(script), 0o700); err != nil {
+		t.Fatalf("write fake kubectl: %v", err)
+	}
+	fakeSOPS := filepath.Join(dir, "sops")
+	if err := os.WriteFile(fakeSOPS, []byte("#!/bin/sh\nprintf 'hunter2'\n"), 0o700); err != nil {
+		t.Fatalf("write fake sops: %v", err)
+	}
+	t.Setenv(sopsBinaryEnv, fakeSOPS)
+	t.Setenv(kubectlBinaryEnv, fakeKubectl)
+	t.Setenv(kubeRolloutTimeoutEnv, "30s")
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-kube-apply"
+	encrypted := []byte("db: ENC[...]\n")
+	if _, err := artifacts.WriteFile(projectID, "repos/manifests/secrets/dev.enc.yaml", encrypted); err != nil {
+		t.Fatalf("write sops file: %v", err)
+	}
+	spec := normalizeProjectSpec(ProjectSpec{
+		APIVersion: projectAPIVersion, Kind: projectKind, Name: "kube-app", Runtime: "go_1.26",
+		Environments: map[string]EnvConfig{"dev": {
+			Secrets: map[string]string{"DB_PASSWORD": "sops://secrets/dev.enc.yaml#db"},
+		}},
+		NetworkPolicies: NetworkPolicies{Ingress: networkPolicyInternal, Egress: networkPolicyInternal},
+	})
+	msg := ProjectOpMsg{OpID: "op-kube-apply", Kind: OpDeploy, ProjectID: projectID, At: time.Now().UTC()}
+	ctx := context.Background()
+
+	if outcome, err := runKubeApplyStep(ctx, nil, artifacts, msg, spec, "dev"); err != nil || len(outcome.artifacts) != 0 {
+		t.Fatalf("expected no apply step while disabled, got %+v (%v)", outcome, err)
+	}
+	t.Setenv(kubeApplyEnv, "true")
+	t.Setenv(kubeContextEnv, "shared-prod")
+	if _, err := applyRenderedManifests(ctx, nil, artifacts, msg, spec, "dev"); err == nil ||
+		!strings.Contains(err.Error(), "not a local kind, k3d, or minikube context") {
+		t.Fatalf("expected a non-local context to be refused, got %v", err)
+	}
+
+	t.Setenv(kubeContextEnv, "kind-paas")
+	if _, err := runManifestApplyForEnvironment(ctx, nil, artifacts, msg, spec, "local/kube-app:v1", "dev"); err != nil {
+		t.Fatalf("render dev manifests: %v", err)
+	}
+	outcome, err := applyRenderedManifests(ctx, nil, artifacts, msg, spec, "dev")
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	report := readKubeApplyReport(t, artifacts, projectID)
+	if report.Status != kubeApplyStatusApplied || len(report.Resources) != 3 || report.Secret != "kube-app-secrets" ||
+		len(report.Rollouts) != 1 || report.Rollouts[0].Status != kubeRolloutStatusReady ||
+		!strings.Contains(report.Rollouts[0].Message, "successfully rolled out") {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(outcome.artifacts) != 1 || !strings.HasSuffix(outcome.artifacts[0], "deploy/dev/"+kubeApplyReportFile) {
+		t.Fatalf("expected the report as the step artifact, got %v", outcome.artifacts)
+	}
+	invoked, _ := os.ReadFile(kubectlLog)
+	if !strings.Contains(string(invoked), "--context kind-paas apply -f - -o name") ||
+		!strings.Contains(string(invoked), "rollout status deployment.apps/kube-app -n kube-app-dev --timeout 30s") {
+		t.Fatalf("unexpected kubectl invocations:\n%s", invoked)
+	}
+	secret, _ := os.ReadFile(secretInput)
+	if !strings.Contains(string(secret), `"DB_PASSWORD":"aHVudGVyMg=="`) {
+		t.Fatalf("expected the secret value on kubectl stdin, got %s", secret)
+	}
+	files, _ := artifacts.ListFiles(projectID)
+	for _, file := range files {
+		raw, _ := artifacts.ReadFile(projectID, file)
+		if strings.Contains(string(raw), "hunter2") || strings.Contains(string(raw), "aHVudGVyMg==") {
+			t.Fatalf("secret value leaked into artifact %s", file)
+		}
+	}
+
+	t.Setenv("KUBE_FAIL", "1")
+	_, err = applyRenderedManifests(ctx, nil, artifacts, msg, spec, "dev")
+	if err == nil || !strings.Contains(err.Error(), "kubectl rollout status deployment.apps/kube-app: error: deployment") {
+		t.Fatalf("expected the rollout failure reason, got %v", err)
+	}
+	report = readKubeApplyReport(t, artifacts, projectID)
+	if report.Status != kubeApplyStatusFailed || report.Rollouts[0].Status != kubeRolloutStatusFailed ||
+		!strings.Contains(report.Failure, "progress deadline") {
+		t.Fatalf("expected a failed report, got %+v", report)
+	}
+}
+
+func readKubeApplyReport(t *testing.T, artifacts ArtifactStore, projectID string) kubeApplyReport {
+	t.Helper()
+	var report kubeApplyReport
+	raw, err := artifacts.ReadFile(projectID, "deploy/dev/"+kubeApplyReportFile)
+	if err == nil {
+		err = json.Unmarshal(raw, &report)
+	}
+	if err != nil {
+		t.Fatalf("read kube apply report: %v", err)
+	}
+	return report
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -42,7 +44,9 @@ type workerPoisonPublishFn func(
 
 func startWorker(
 	ctx context.Context,
-	workerName, natsURL, inSubj, outSubj string,
+	workerName string,
+	endpoint natsEndpoint,
+	inSubj, outSubj string,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 	fn workerFn,
@@ -51,7 +55,7 @@ func startWorker(
 	go runWorkerLoop(
 		ctx,
 		workerName,
-		natsURL,
+		endpoint,
 		inSubj,
 		outSubj,
 		artifacts,
@@ -65,13 +69,15 @@ func startWorker(
 
 func runWorkerLoop(
 	ctx context.Context,
-	workerName, natsURL, inSubj, outSubj string,
+	workerName string,
+	endpoint natsEndpoint,
+	inSubj, outSubj string,
 	artifacts ArtifactStore,
 	opEvents *opEventHub,
 	fn workerFn,
 	workerLog sourceLogger,
 ) {
-	nc, err := nats.Connect(natsURL, nats.Name(workerName))
+	nc, err := endpoint.connect(workerName)
 	if err != nil {
 		workerLog.Errorf("connect error: %v", err)
 		return
@@ -93,6 +99,7 @@ func runWorkerLoop(
 		return
 	}
 	store.setOpEvents(opEvents)
+	store.enableOpCache(workerOpCacheTTLFromEnv())
 
 	streamErr := ensureWorkerDeliveryStream(ctx, js)
 	if streamErr != nil {
@@ -100,39 +107,49 @@ func runWorkerLoop(
 		return
 	}
 
-	consumerName := workerConsumerName(workerName)
-	var consumerCfg jetstream.ConsumerConfig
-	consumerCfg.Name = consumerName
-	consumerCfg.Durable = consumerName
-	consumerCfg.Description = fmt.Sprintf("worker %s consumer for %s", workerName, inSubj)
-	consumerCfg.DeliverPolicy = jetstream.DeliverAllPolicy
-	consumerCfg.AckPolicy = jetstream.AckExplicitPolicy
-	consumerCfg.AckWait = workerDeliveryAckWait
-	consumerCfg.MaxDeliver = workerDeliveryMaxDeliver
-	consumerCfg.BackOff = workerDeliveryRetryBackoff()
-	consumerCfg.FilterSubject = inSubj
-	consumerCfg.ReplayPolicy = jetstream.ReplayInstantPolicy
-	consumerCfg.MaxAckPending = 1
-
-	consumer, err := js.CreateOrUpdateConsumer(ctx, streamWorkerPipeline, consumerCfg)
-	if err != nil {
-		workerLog.Errorf("consumer setup error: %v", err)
-		return
+	partitions := workerConcurrency()
+	if drainErr := drainStaleWorkerPartitions(ctx, js, workerName, inSubj, partitions, workerLog); drainErr != nil {
+		// The stale partitions keep their messages; the next start retries.
+		workerLog.Warnf("stale partition drain error: %v", drainErr)
 	}
-
-	workerLog.Infof("ready: subscribe=%s publish=%s", inSubj, outSubj)
-	consumeWorkerMessages(
-		ctx,
-		store,
-		consumer,
-		artifacts,
-		workerName,
-		inSubj,
-		outSubj,
-		fn,
-		js,
-		workerLog,
-	)
+	queue := workerQueuesFromContext(ctx).queue(workerName)
+	consumers := make([]jetstream.Consumer, 0, partitions)
+	for partition := range partitions {
+		consumer, consumerErr := js.CreateOrUpdateConsumer(
+			ctx,
+			natsStreamName(streamWorkerPipeline),
+			workerConsumerConfig(workerName, inSubj, partition),
+		)
+		if consumerErr != nil {
+			workerLog.Errorf("consumer setup error partition=%d: %v", partition, consumerErr)
+			return
+		}
+		consumers = append(consumers, consumer)
+		queue.addConsumer(consumer)
+	}
+
+	workerLog.Infof("ready: subscribe=%s publish=%s partitions=%d", inSubj, outSubj, partitions)
+	go publishWorkerReadyHeartbeats(ctx, nc, workerName, workerConsumerName(workerName))
+	var wg sync.WaitGroup
+	for partition, consumer := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			consumeWorkerMessages(
+				ctx,
+				store,
+				consumer,
+				artifacts,
+				workerName,
+				workerPartitionSubject(inSubj, partition),
+				outSubj,
+				fn,
+				js,
+				workerLog,
+			)
+		}()
+	}
+	wg.Wait()
 }
 
 func consumeWorkerMessages(
@@ -145,10 +162,14 @@ func consumeWorkerMessages(
 	js jetstream.JetStream,
 	workerLog sourceLogger,
 ) {
+	drain := workerDrainFromContext(ctx)
+	queue := workerQueuesFromContext(ctx).queue(workerName)
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-drain.stopRequested():
+			return
 		default:
 		}
 
@@ -166,13 +187,28 @@ func consumeWorkerMessages(
 			continue
 		}
 
+		if !queue.acquire(ctx, msg, drain.stopRequested()) {
+			// Shutdown started while the message waited for a slot.
+			if err := msg.Nak(); err != nil {
+				workerLog.Warnf("worker message nack failed: %v", err)
+			}
+			return
+		}
+		if !drain.begin() {
+			// Shutdown started while the fetch was waiting; hand it back.
+			queue.release()
+			if err := msg.Nak(); err != nil {
+				workerLog.Warnf("worker message nack failed: %v", err)
+			}
+			return
+		}
 		attempt := workerDeliveryAttempt(msg)
 		decision := handleWorkerDelivery(
 			ctx,
 			store,
 			artifacts,
 			workerName,
-			inSubj,
+			msg.Subject(),
 			outSubj,
 			fn,
 			js,
@@ -183,6 +219,8 @@ func consumeWorkerMessages(
 			publishWorkerPoison,
 		)
 		applyWorkerDeliveryDecision(msg, decision, workerLog)
+		drain.end()
+		queue.release()
 	}
 }
 
@@ -191,7 +229,30 @@ func workerConsumerName(workerName string) string {
 	if sanitized == "" {
 		sanitized = "worker"
 	}
-	return "worker_" + strings.ReplaceAll(sanitized, "-", "_")
+	return natsConsumerName("worker_" + strings.ReplaceAll(sanitized, "-", "_"))
+}
+
+// workerConsumerConfig is the durable consumer a worker binds to for one
+// partition of inSubj. Updating it in place on start lets max-deliver and
+// backoff changes take effect without losing the consumer's delivery state.
+// One message in flight per partition keeps each project's messages in
+// order.
+func workerConsumerConfig(workerName, inSubj string, partition int) jetstream.ConsumerConfig {
+	consumerName := workerPartitionConsumerName(workerName, partition)
+	filterSubject := workerPartitionSubject(inSubj, partition)
+	var consumerCfg jetstream.ConsumerConfig
+	consumerCfg.Name = consumerName
+	consumerCfg.Durable = consumerName
+	consumerCfg.Description = fmt.Sprintf("worker %s consumer for %s", workerName, filterSubject)
+	consumerCfg.DeliverPolicy = jetstream.DeliverAllPolicy
+	consumerCfg.AckPolicy = jetstream.AckExplicitPolicy
+	consumerCfg.AckWait = workerDeliveryAckWait
+	consumerCfg.MaxDeliver = workerDeliveryMaxDeliver()
+	consumerCfg.BackOff = workerDeliveryRetryBackoff()
+	consumerCfg.FilterSubjects = workerFilterSubjects(filterSubject)
+	consumerCfg.ReplayPolicy = jetstream.ReplayInstantPolicy
+	consumerCfg.MaxAckPending = 1
+	return consumerCfg
 }
 
 func workerDeliveryAttempt(msg jetstream.Msg) uint64 {
@@ -235,6 +296,11 @@ func handleWorkerDelivery(
 		return workerTerminateDecision()
 	}
 
+	// The next worker takes the project on the same partition and org.
+	outSubj = workerSubjectFor(outSubj, workerSubjectOrg(inSubj), opMsg.ProjectID)
+	// Each delivery starts from the stored op; the cache only serves the
+	// delivery's own re-reads.
+	store.forgetOp(opMsg.OpID)
 	preDecision, handled := handleWorkerPreExecution(
 		ctx,
 		store,
@@ -343,9 +409,8 @@ func handleWorkerPreExecution(
 		)
 		return workerAckDecision(), true
 	}
-	if opMsg.Err != "" {
-		workerLog.Warnf("skip op=%s due to upstream error: %s", opMsg.OpID, opMsg.Err)
-		publishErr := resultPublisher(ctx, js, outSubj, skipWorkerResult(opMsg, workerName))
+	if skipRes, skip := workerSkipResult(ctx, store, opMsg, workerName, workerLog); skip {
+		publishErr := resultPublisher(ctx, js, outSubj, skipRes)
 		if publishErr != nil {
 			return workerRetryOrPoison(
 				ctx,
@@ -358,7 +423,7 @@ func handleWorkerPreExecution(
 				&opMsg,
 				attempt,
 				rawPayload,
-				fmt.Sprintf("publish upstream-skip result: %v", publishErr),
+				fmt.Sprintf("publish skip result: %v", publishErr),
 				workerLog,
 				resultPublisher,
 				poisonPublisher,
@@ -369,6 +434,33 @@ func handleWorkerPreExecution(
 	return workerDeliveryDecision{}, false
 }
 
+// workerSkipResult reports whether the delivery should pass straight down the
+// chain without running: an upstream worker failed, or the op was cancelled
+// or reaped as stale.
+func workerSkipResult(
+	ctx context.Context,
+	store *Store,
+	opMsg ProjectOpMsg,
+	workerName string,
+	workerLog sourceLogger,
+) (WorkerResultMsg, bool) {
+	if opMsg.Err != "" {
+		workerLog.Warnf("skip op=%s due to upstream error: %s", opMsg.OpID, opMsg.Err)
+		return skipWorkerResult(opMsg, workerName), true
+	}
+	if op, cancelled := opCancelledInStore(ctx, store, opMsg.OpID); cancelled {
+		workerLog.Infof("skip op=%s worker=%s: operation was cancelled", opMsg.OpID, workerName)
+		return cancelledWorkerResult(opMsg, workerName, opCancelledErrorFor(op)), true
+	}
+	if op, reaped := opReapedInStore(ctx, store, opMsg.OpID); reaped {
+		workerLog.Warnf("skip op=%s worker=%s: operation was failed as stale", opMsg.OpID, workerName)
+		res := skipWorkerResult(opMsg, workerName)
+		res.Err = op.Error
+		return res, true
+	}
+	return WorkerResultMsg{}, false
+}
+
 func executeWorkerAndPublish(
 	ctx context.Context,
 	store *Store,
@@ -383,8 +475,61 @@ func executeWorkerAndPublish(
 	resultPublisher workerResultPublishFn,
 	poisonPublisher workerPoisonPublishFn,
 ) workerDeliveryDecision {
-	workerLog.Infof("start op=%s kind=%s project=%s", opMsg.OpID, opMsg.Kind, opMsg.ProjectID)
-	res, workerErr := fn(ctx, store, artifacts, opMsg)
+	workerLog.Infof(
+		"start op=%s kind=%s project=%s dry_run=%t trace=%t",
+		opMsg.OpID,
+		opMsg.Kind,
+		opMsg.ProjectID,
+		opMsg.Execution.DryRun,
+		opMsg.Execution.Trace,
+	)
+	if err := preserveStepAttempt(ctx, store, artifacts, opMsg, workerName, attempt); err != nil {
+		workerLog.Warnf("preserve prior attempt op=%s worker=%s attempt=%d failed: %v", opMsg.OpID, workerName, attempt, err)
+	}
+	var nc *nats.Conn
+	if js != nil {
+		nc = js.Conn()
+	}
+	actionCtx, stopCancelWatch := watchOpCancellation(ctx, nc, store, opMsg.OpID)
+	actionCtx, progress := withStepProgress(actionCtx, store, opMsg.OpID)
+	actionCtx = withOpLog(actionCtx, artifacts, opMsg, workerName, attempt)
+	var trace *opTrace
+	if opMsg.Execution.Trace {
+		actionCtx, trace = withOpTrace(actionCtx)
+	}
+	heartbeatCtx, stopHeartbeats := context.WithCancel(ctx)
+	go runStepHeartbeats(heartbeatCtx, progress)
+	drain := workerDrainFromContext(ctx)
+	untrack := drain.track(opMsg.OpID, workerName)
+	var (
+		res       WorkerResultMsg
+		workerErr error
+	)
+	if opMsg.Execution.DryRun {
+		finalStage := slices.Contains(finalResultSubjects(), workerBaseSubject(outSubj))
+		res, workerErr = dryRunWorkerAction(actionCtx, store, artifacts, workerName, finalStage, opMsg)
+	} else {
+		res, workerErr = fn(actionCtx, store, artifacts, opMsg)
+	}
+	stopHeartbeats()
+	untrack()
+	if drain.interrupted(ctx) {
+		stopCancelWatch()
+		return interruptWorkerDelivery(ctx, store, workerName, opMsg, workerErr, workerLog)
+	}
+	if cause := opCancelCause(actionCtx); cause != nil {
+		var cancelled opCancelledError
+		if errors.As(cause, &cancelled) {
+			workerErr = cancelled
+			res = cancelledWorkerResult(opMsg, workerName, cancelled)
+			// The action's own bookkeeping ran on the cancelled context.
+			_ = closeOpenOpSteps(ctx, store, opMsg.OpID, cancelled.Error())
+		}
+	}
+	stopCancelWatch()
+	if trace != nil {
+		res.Artifacts = attachWorkerTrace(ctx, store, artifacts, workerName, opMsg, trace, res.Artifacts, workerLog)
+	}
 	if workerErr != nil {
 		res.Err = workerErr.Error()
 		workerLog.Errorf("op=%s failed: %v", opMsg.OpID, workerErr)
@@ -413,6 +558,50 @@ func executeWorkerAndPublish(
 	return workerAckDecision()
 }
 
+// interruptWorkerDelivery handles a step that shutdown cut short: the op
+// is marked interrupted and the message handed back without a result, so
+// the step reruns after restart instead of failing the op.
+func interruptWorkerDelivery(
+	ctx context.Context,
+	store *Store,
+	workerName string,
+	opMsg ProjectOpMsg,
+	workerErr error,
+	workerLog sourceLogger,
+) workerDeliveryDecision {
+	workerLog.Warnf("op=%s worker=%s interrupted by shutdown: %v", opMsg.OpID, workerName, workerErr)
+	if err := markOpInterrupted(context.WithoutCancel(ctx), store, opMsg.OpID); err != nil {
+		workerLog.Warnf("mark op=%s interrupted failed: %v", opMsg.OpID, err)
+	}
+	return workerRetryDecision(0)
+}
+
+// attachWorkerTrace stores the delivery's trace and links it from the
+// worker's last step. A trace that cannot be written is logged, never fatal.
+func attachWorkerTrace(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	workerName string,
+	opMsg ProjectOpMsg,
+	trace *opTrace,
+	resultArtifacts []string,
+	workerLog sourceLogger,
+) []string {
+	tracePath, err := writeOpTraceArtifact(artifacts, opMsg, workerName, trace)
+	if err != nil {
+		workerLog.Warnf("write trace op=%s worker=%s failed: %v", opMsg.OpID, workerName, err)
+		return resultArtifacts
+	}
+	if tracePath == "" {
+		return resultArtifacts
+	}
+	_ = amendLastOpStep(ctx, store, opMsg.OpID, workerName, func(step *OpStep) {
+		step.Artifacts = append(step.Artifacts, tracePath)
+	})
+	return append(resultArtifacts, tracePath)
+}
+
 func completedWorkerResultForDelivery(
 	ctx context.Context,
 	store *Store,
@@ -467,14 +656,14 @@ func workerRetryOrPoison(
 	resultPublisher workerResultPublishFn,
 	poisonPublisher workerPoisonPublishFn,
 ) workerDeliveryDecision {
-	if attempt < uint64(workerDeliveryMaxDeliver) {
+	if attempt < uint64(workerDeliveryMaxDeliver()) {
 		delay := workerRetryDelay(attempt)
 		workerLog.Warnf(
 			"delivery retry op=%s worker=%s attempt=%d/%d delay=%s reason=%s",
 			opIDFromMessage(opMsg),
 			workerName,
 			attempt,
-			workerDeliveryMaxDeliver,
+			workerDeliveryMaxDeliver(),
 			delay,
 			reason,
 		)
@@ -536,13 +725,17 @@ func storeWorkerPoison(
 		Kind:       "",
 		ProjectID:  "",
 		Delivery: DeliveryLifecycle{
-			Stage:       "",
-			Environment: "",
-			FromEnv:     "",
-			ToEnv:       "",
+			Stage:           "",
+			Environment:     "",
+			FromEnv:         "",
+			ToEnv:           "",
+			Strategy:        "",
+			CanaryWeight:    0,
+			CanaryAction:    "",
+			BlueGreenAction: "",
 		},
 		Attempt:    attempt,
-		MaxDeliver: workerDeliveryMaxDeliver,
+		MaxDeliver: workerDeliveryMaxDeliver(),
 		Reason:     reason,
 		RawPayload: truncatedPayload(rawPayload, workerPoisonPayloadLimit),
 		StoredAt:   time.Now().UTC(),
@@ -577,7 +770,7 @@ func markWorkerDeliveryFailure(
 		workerLog.Warnf("read op for poison finalize op=%s failed: %v", opMsg.OpID, err)
 		return
 	}
-	if op.Status == opStatusDone || op.Status == opStatusError {
+	if isOperationStatusTerminal(op.Status) {
 		return
 	}
 	finalizeErr := finalizeOp(
@@ -15,22 +15,23 @@ import (
 )
 
 type workerDeliveryFixture struct {
-	nc    *nats.Conn
-	js    jetstream.JetStream
-	store *Store
-	close func()
+	endpoint natsEndpoint
+	nc       *nats.Conn
+	js       jetstream.JetStream
+	store    *Store
+	close    func()
 }
 
 func newWorkerDeliveryFixture(t *testing.T) *workerDeliveryFixture {
 	t.Helper()
 	t.Setenv(natsStoreDirEnv, natsStoreDirModeTemp)
 
-	ns, natsURL, nsDir, nsDirTmp, err := startEmbeddedNATS()
+	ns, endpoint, nsDir, nsDirTmp, err := startEmbeddedNATS()
 	if err != nil {
 		t.Skipf("embedded nats unavailable: %v", err)
 	}
 
-	nc, err := nats.Connect(natsURL, nats.Name("workers-runtime-test"))
+	nc, err := endpoint.connect("workers-runtime-test")
 	if err != nil {
 		ns.Shutdown()
 		ns.WaitForShutdown()
@@ -74,9 +75,10 @@ func newWorkerDeliveryFixture(t *testing.T) *workerDeliveryFixture {
 	}
 
 	return &workerDeliveryFixture{
-		nc:    nc,
-		js:    js,
-		store: store,
+		endpoint: endpoint,
+		nc:       nc,
+		js:       js,
+		store:    store,
 		close: func() {
 			_ = nc.Drain()
 			ns.Shutdown()
@@ -111,6 +113,15 @@ func workerRuntimeSpec(name string) ProjectSpec {
 	return normalizeProjectSpec(spec)
 }
 
+// overwriteProjectForTest stores p whether or not the project exists, the
+// way fixtures seed successive project states.
+func overwriteProjectForTest(ctx context.Context, store *Store, p Project) error {
+	if current, _, err := store.readProject(ctx, p.ID); err == nil {
+		p.Revision = current.Revision
+	}
+	return store.PutProject(ctx, p)
+}
+
 func putWorkerRuntimeProjectAndOp(
 	t *testing.T,
 	store *Store,
@@ -134,7 +145,7 @@ func putWorkerRuntimeProjectAndOp(
 			Message:    "ready",
 		},
 	}
-	if err := store.PutProject(context.Background(), project); err != nil {
+	if err := overwriteProjectForTest(context.Background(), store, project); err != nil {
 		t.Fatalf("put project: %v", err)
 	}
 	op := Operation{
@@ -262,8 +273,8 @@ func TestWorkers_JetStreamRetryAvoidsDuplicateStepMutation(t *testing.T) {
 		fixture.store,
 		NewFSArtifacts(t.TempDir()),
 		"registrar",
-		subjectProjectOpStart,
-		subjectRegistrationDone,
+		natsSubject(subjectProjectOpStart),
+		natsSubject(subjectRegistrationDone),
 		workerRuntimeActionSuccess,
 		fixture.js,
 		data,
@@ -281,8 +292,8 @@ func TestWorkers_JetStreamRetryAvoidsDuplicateStepMutation(t *testing.T) {
 		fixture.store,
 		NewFSArtifacts(t.TempDir()),
 		"registrar",
-		subjectProjectOpStart,
-		subjectRegistrationDone,
+		natsSubject(subjectProjectOpStart),
+		natsSubject(subjectRegistrationDone),
 		workerRuntimeActionSuccess,
 		fixture.js,
 		data,
@@ -342,12 +353,12 @@ func TestWorkers_JetStreamPoisonMarksOpErrorAfterRetryExhaustion(t *testing.T) {
 		fixture.store,
 		NewFSArtifacts(t.TempDir()),
 		"registrar",
-		subjectProjectOpStart,
-		subjectRegistrationDone,
+		natsSubject(subjectProjectOpStart),
+		natsSubject(subjectRegistrationDone),
 		workerRuntimeActionSuccess,
 		fixture.js,
 		data,
-		uint64(workerDeliveryMaxDeliver),
+		uint64(workerDeliveryMaxDeliver()),
 		log,
 		resultPublisher,
 		publishWorkerPoison,
@@ -367,11 +378,11 @@ func TestWorkers_JetStreamPoisonMarksOpErrorAfterRetryExhaustion(t *testing.T) {
 		t.Fatalf("expected poison error message, got %q", op.Error)
 	}
 
-	stream, err := fixture.js.Stream(context.Background(), streamWorkerPipeline)
+	stream, err := fixture.js.Stream(context.Background(), natsStreamName(streamWorkerPipeline))
 	if err != nil {
 		t.Fatalf("get worker stream: %v", err)
 	}
-	info, err := stream.Info(context.Background(), jetstream.WithSubjectFilter(subjectWorkerPoison))
+	info, err := stream.Info(context.Background(), jetstream.WithSubjectFilter(natsSubject(subjectWorkerPoison)))
 	if err != nil {
 		t.Fatalf("stream info for poison subject: %v", err)
 	}
@@ -611,7 +622,7 @@ func TestWorkers_FinalWaiterReceivesJetStreamPublishedFinalResult(t *testing.T)
 
 	res := finalWaiterResult(opID)
 
-	publishErr := publishWorkerResult(context.Background(), fixture.js, subjectDeploymentDone, res)
+	publishErr := publishWorkerResult(context.Background(), fixture.js, natsSubject(subjectDeploymentDone), res)
 	if publishErr != nil {
 		t.Fatalf("publish final result with jetstream: %v", publishErr)
 	}
@@ -654,7 +665,7 @@ func TestWorkers_FinalWaiterRecoversAfterConsumerRestart(t *testing.T) {
 	stop()
 
 	res := finalWaiterResult(opID)
-	publishErr := publishWorkerResult(context.Background(), fixture.js, subjectDeploymentDone, res)
+	publishErr := publishWorkerResult(context.Background(), fixture.js, natsSubject(subjectDeploymentDone), res)
 	if publishErr != nil {
 		t.Fatalf("publish final result while consumer stopped: %v", publishErr)
 	}
@@ -701,7 +712,7 @@ func TestWorkers_FinalWaiterSuppressesDuplicateReplayByOpID(t *testing.T) {
 	defer waiters.unregister(opID)
 
 	res := finalWaiterResult(opID)
-	publishErr := publishWorkerResult(context.Background(), fixture.js, subjectDeploymentDone, res)
+	publishErr := publishWorkerResult(context.Background(), fixture.js, natsSubject(subjectDeploymentDone), res)
 	if publishErr != nil {
 		t.Fatalf("publish first final result: %v", publishErr)
 	}
@@ -719,7 +730,7 @@ func TestWorkers_FinalWaiterSuppressesDuplicateReplayByOpID(t *testing.T) {
 	if err != nil {
 		t.Fatalf("marshal duplicate payload: %v", err)
 	}
-	_, publishRawErr := fixture.js.Publish(context.Background(), subjectDeploymentDone, body)
+	_, publishRawErr := fixture.js.Publish(context.Background(), natsSubject(subjectDeploymentDone), body)
 	if publishRawErr != nil {
 		t.Fatalf("publish duplicate payload: %v", publishRawErr)
 	}
@@ -751,7 +762,7 @@ func TestWorkers_FinalWaiterNoRegistrationPathDoesNotBlockLaterDelivery(t *testi
 	publishErr := publishWorkerResult(
 		context.Background(),
 		fixture.js,
-		subjectDeploymentDone,
+		natsSubject(subjectDeploymentDone),
 		finalWaiterResult(noWaiterOpID),
 	)
 	if publishErr != nil {
@@ -765,7 +776,7 @@ func TestWorkers_FinalWaiterNoRegistrationPathDoesNotBlockLaterDelivery(t *testi
 	publishSecondErr := publishWorkerResult(
 		context.Background(),
 		fixture.js,
-		subjectDeploymentDone,
+		natsSubject(subjectDeploymentDone),
 		finalWaiterResult(waitedOpID),
 	)
 	if publishSecondErr != nil {
@@ -808,3 +819,193 @@ func finalWaiterResult(opID string) WorkerResultMsg {
 	res.Artifacts = []string{"deploy/dev/rendered.yaml"}
 	return res
 }
+
+func TestWorkers_StepHeartbeatRefreshesOpenStepAndEmitsEvent(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	hub := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
+	fixture.store.setOpEvents(hub)
+
+	spec := workerRuntimeSpec("worker-heartbeat")
+	opID := "op-worker-heartbeat-1"
+	projectID := "project-worker-heartbeat-1"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCreate, spec)
+
+	started := time.Now().UTC().Add(-time.Minute)
+	if err := markOpStepStart(context.Background(), fixture.store, opID, "imageBuilder", started, "build image"); err != nil {
+		t.Fatalf("mark step start: %v", err)
+	}
+
+	ctx, progress := withStepProgress(context.Background(), fixture.store, opID)
+	reportStepProgress(ctx, "building image", 40)
+	message, percent := progress.snapshot()
+	beat := started.Add(30 * time.Second)
+	if err := markOpStepHeartbeat(ctx, fixture.store, opID, beat, message, percent); err != nil {
+		t.Fatalf("mark step heartbeat: %v", err)
+	}
+
+	op, err := fixture.store.GetOp(context.Background(), opID)
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	step := op.Steps[len(op.Steps)-1]
+	if !step.HeartbeatAt.Equal(beat) || step.Progress != "building image" || step.Percent != 40 {
+		t.Fatalf("unexpected step after heartbeat: %+v", step)
+	}
+	if got := opLastUpdateAt(op); !got.Equal(beat) {
+		t.Fatalf("expected last update at heartbeat %s, got %s", beat, got)
+	}
+
+	hub.mu.Lock()
+	records := append([]opEventRecord(nil), hub.streams[opID].records...)
+	hub.mu.Unlock()
+	last := records[len(records)-1]
+	if last.Name != opEventStepBeat || last.Payload.Worker != "imageBuilder" ||
+		last.Payload.ProgressPercent != 40 || last.Payload.DurationMS != 30000 {
+		t.Fatalf("unexpected heartbeat event: %s %+v", last.Name, last.Payload)
+	}
+}
+
+func TestWorkers_SubStepsRecordedOnOpenStepAndStreamed(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	hub := newOpEventHub(opEventsHistoryLimit, opEventsRetention)
+	fixture.store.setOpEvents(hub)
+
+	spec := workerRuntimeSpec("worker-substeps")
+	opID := "op-worker-substeps-1"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, "project-worker-substeps-1", opID, OpCreate, spec)
+	if err := markOpStepStart(context.Background(), fixture.store, opID, "imageBuilder", time.Now().UTC(), "build image"); err != nil {
+		t.Fatalf("mark step start: %v", err)
+	}
+
+	ctx, _ := withStepProgress(context.Background(), fixture.store, opID)
+	resolveDone := beginSubStep(ctx, "resolve base image")
+	resolveDone(nil)
+	buildDone := beginSubStep(ctx, "build layers")
+
+	op, err := fixture.store.GetOp(context.Background(), opID)
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	subSteps := op.Steps[len(op.Steps)-1].SubSteps
+	if len(subSteps) != 2 || subSteps[0].Name != "resolve base image" || subSteps[0].EndedAt.IsZero() ||
+		subSteps[1].Name != "build layers" || !subSteps[1].EndedAt.IsZero() {
+		t.Fatalf("expected one finished and one running sub-step, got %+v", subSteps)
+	}
+
+	buildDone(errors.New("layer cache corrupt"))
+	op, err = fixture.store.GetOp(context.Background(), opID)
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	if got := op.Steps[len(op.Steps)-1].SubSteps[1]; got.EndedAt.IsZero() || got.Error != "layer cache corrupt" {
+		t.Fatalf("expected failed sub-step closed with its error, got %+v", got)
+	}
+
+	hub.mu.Lock()
+	records := append([]opEventRecord(nil), hub.streams[opID].records...)
+	hub.mu.Unlock()
+	var subStepEvents []opEventPayload
+	for _, record := range records {
+		if record.Name == opEventSubStep {
+			subStepEvents = append(subStepEvents, record.Payload)
+		}
+	}
+	if len(subStepEvents) != 4 {
+		t.Fatalf("expected start and end events for both sub-steps, got %d", len(subStepEvents))
+	}
+	last := subStepEvents[len(subStepEvents)-1]
+	if last.Worker != "imageBuilder" || last.StepIndex != 1 || last.SubStep == nil ||
+		last.SubStep.Name != "build layers" || last.Error != "layer cache corrupt" {
+		t.Fatalf("unexpected sub-step end event: %+v", last)
+	}
+}
+
+func TestWorkers_CancelStopsRunningActionAndSkipsLaterWorkers(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	spec := workerRuntimeSpec("worker-cancel")
+	opID := "op-worker-cancel-1"
+	projectID := "project-worker-cancel-1"
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCreate, spec)
+
+	var published []WorkerResultMsg
+	resultPublisher := func(_ context.Context, _ jetstream.JetStream, _ string, res WorkerResultMsg) error {
+		published = append(published, res)
+		return nil
+	}
+	log := appLoggerForProcess().Source("workers-test")
+	data := workerPayload(t, opID, OpCreate, projectID, spec)
+
+	started := make(chan struct{})
+	blockUntilCancelled := func(ctx context.Context, store *Store, _ ArtifactStore, msg ProjectOpMsg) (WorkerResultMsg, error) {
+		_ = markOpStepStart(ctx, store, msg.OpID, "registrar", time.Now().UTC(), "register app configuration")
+		close(started)
+		<-ctx.Done()
+		return newWorkerResultMsg(""), ctx.Err()
+	}
+	decided := make(chan workerDeliveryDecision, 1)
+	go func() {
+		decided <- handleWorkerDelivery(
+			context.Background(), fixture.store, NewFSArtifacts(t.TempDir()),
+			"registrar", natsSubject(subjectProjectOpStart), natsSubject(subjectRegistrationDone),
+			blockUntilCancelled, fixture.js, data, 1, log, resultPublisher, publishWorkerPoison,
+		)
+	}()
+	<-started
+
+	cancelled, err := cancelOp(context.Background(), fixture.store, opID, "superseded")
+	if err != nil {
+		t.Fatalf("cancel op: %v", err)
+	}
+	if err = publishOpCancel(fixture.nc, cancelled, "superseded"); err != nil {
+		t.Fatalf("publish cancel: %v", err)
+	}
+	select {
+	case decision := <-decided:
+		if decision.action != workerDeliveryAck {
+			t.Fatalf("expected cancelled delivery acked, got %d", decision.action)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("running action did not observe cancellation")
+	}
+
+	notRun := func(context.Context, *Store, ArtifactStore, ProjectOpMsg) (WorkerResultMsg, error) {
+		t.Fatal("worker ran an op that was already cancelled")
+		return newWorkerResultMsg(""), nil
+	}
+	decision := handleWorkerDelivery(
+		context.Background(), fixture.store, NewFSArtifacts(t.TempDir()),
+		"repoBootstrap", natsSubject(subjectRegistrationDone), natsSubject(subjectBootstrapDone),
+		notRun, fixture.js, data, 1, log, resultPublisher, publishWorkerPoison,
+	)
+	if decision.action != workerDeliveryAck {
+		t.Fatalf("expected skip to ack, got %d", decision.action)
+	}
+
+	if len(published) != 2 {
+		t.Fatalf("expected one result per worker, got %d", len(published))
+	}
+	for _, res := range published {
+		if res.Err != "operation cancelled: superseded" {
+			t.Fatalf("expected cancellation passed down the chain, got %q from %s", res.Err, res.Worker)
+		}
+	}
+	op, err := fixture.store.GetOp(context.Background(), opID)
+	if err != nil {
+		t.Fatalf("get op: %v", err)
+	}
+	if op.Status != opStatusCancelled || len(op.Steps) != 1 || op.Steps[0].EndedAt.IsZero() ||
+		op.Steps[0].Error != "operation cancelled: superseded" {
+		t.Fatalf("expected cancelled op with its step closed, got %+v", op)
+	}
+	var api API
+	api.store = fixture.store
+	if conflict := api.projectOperationConflict(context.Background(), projectID, OpUpdate); conflict != nil {
+		t.Fatalf("expected project free for the next op, got %v", conflict)
+	}
+}
@@ -85,6 +85,14 @@ func TestWorkers_RenderKustomizedProjectManifests(t *testing.T) {
 	if !strings.Contains(rendered, "kind: Deployment") || !strings.Contains(rendered, "kind: Service") {
 		t.Fatalf("combined rendered manifest missing resources: %s", rendered)
 	}
+	if !strings.Contains(deployment, "serviceAccountName: svc") {
+		t.Fatalf("rendered deployment must use the project service account: %s", deployment)
+	}
+	for _, kind := range []string{"kind: ServiceAccount", "kind: Role", "kind: RoleBinding"} {
+		if !strings.Contains(rendered, kind) {
+			t.Fatalf("combined rendered manifest missing %q: %s", kind, rendered)
+		}
+	}
 }
 
 func TestWorkers_ManifestApplyWritesKustomizeTreeAndDevRenderOnly(t *testing.T) {
@@ -171,6 +179,67 @@ func TestWorkers_ManifestApplyWritesKustomizeTreeAndDevRenderOnly(t *testing.T)
 	}
 }
 
+func TestWorkers_ManifestApplyScopesResourcesToProjectNamespace(t *testing.T) {
+	artifacts := platform.NewFSArtifacts(t.TempDir())
+	spec := platform.ProjectSpec{
+		APIVersion: platform.ProjectAPIVersionForTest,
+		Kind:       platform.ProjectKindForTest,
+		Name:       "svc",
+		Runtime:    "go_1.26",
+		Environments: map[string]platform.EnvConfig{
+			"dev": {Vars: map[string]string{"LOG_LEVEL": "debug"}},
+		},
+		NetworkPolicies: platform.NetworkPolicies{
+			Ingress: "internal",
+			Egress:  "internal",
+		},
+	}
+	msg := platform.ProjectOpMsg{
+		OpID:      "op-namespace",
+		Kind:      platform.OpCreate,
+		ProjectID: "proj-namespace",
+		Spec:      spec,
+		At:        time.Now().UTC(),
+	}
+
+	_, touched, err := platform.RunManifestApplyForTest(
+		context.Background(),
+		artifacts,
+		msg,
+		spec,
+		"local/svc:ns12345",
+		"dev",
+	)
+	if err != nil {
+		t.Fatalf("run manifest apply: %v", err)
+	}
+	if !slices.Contains(touched, "repos/manifests/overlays/dev/namespace.yaml") {
+		t.Fatalf("expected namespace overlay artifact, got %v", touched)
+	}
+	rendered, readErr := artifacts.ReadFile(msg.ProjectID, "deploy/dev/rendered.yaml")
+	if readErr != nil {
+		t.Fatalf("read dev rendered manifest: %v", readErr)
+	}
+	text := string(rendered)
+	for _, want := range []string{
+		"kind: Namespace",
+		"kind: ResourceQuota",
+		"kind: LimitRange",
+		"namespace: svc-dev",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("rendered manifest missing %q: %s", want, text)
+		}
+	}
+	deployment, readErr := artifacts.ReadFile(msg.ProjectID, "deploy/dev/deployment.yaml")
+	if readErr != nil {
+		t.Fatalf("read dev deployment: %v", readErr)
+	}
+	if !strings.Contains(string(deployment), "namespace: svc-dev") {
+		t.Fatalf("deployment must target project namespace: %s", string(deployment))
+	}
+}
+
 func TestWorkers_ManifestPromotionRendersHigherEnvOnlyDuringPromotion(t *testing.T) {
 	artifacts := platform.NewFSArtifacts(t.TempDir())
 	spec := platform.ProjectSpec{
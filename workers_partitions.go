@@ -0,0 +1,225 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Worker partitions: each worker input subject is split into
+// PAAS_WORKER_CONCURRENCY partitions keyed by project ID. Partition 0 keeps
+// the bare subject and the original durable consumer; partition N publishes
+// on "<subject>.N" and has a consumer of its own. Every partition consumer
+// still takes one message at a time, so a project's messages are handled in
+// order by one worker copy at a time while different projects run side by
+// side on any replica. Publishers and consumers must agree on the count, so
+// every replica sharing a subject prefix needs the same setting. When the
+// count is lowered, a worker moves what is still waiting on the partitions
+// it no longer consumes onto the ones it does before it starts.
+//
+// A project in an org publishes on "<subject>.org.<org>", or
+// "<subject>.N.org.<org>" for partition N, so a NATS consumer can follow one
+// org. The worker pool still serves every org: each partition consumer
+// filters both the bare partition subject and its org form.
+////////////////////////////////////////////////////////////////////////////////
+
+// workerPartitionFor maps projectID onto one of partitions.
+func workerPartitionFor(projectID string, partitions int) int {
+	if partitions <= 1 {
+		return 0
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(strings.TrimSpace(projectID)))
+	return int(hash.Sum32() % uint32(partitions)) // #nosec G115 -- partitions is capped at maxWorkerConcurrency
+}
+
+func workerPartitionSubject(subject string, partition int) string {
+	if partition <= 0 {
+		return subject
+	}
+	return subject + "." + strconv.Itoa(partition)
+}
+
+// workerSubjectFor is the partition of subject that carries projectID's
+// messages, under org when the project has one.
+func workerSubjectFor(subject, org, projectID string) string {
+	return workerOrgSubject(workerPartitionSubject(subject, workerPartitionFor(projectID, workerConcurrency())), org)
+}
+
+// workerOrgSubject puts subject under org. An empty or unusable org leaves
+// it bare, where every worker consumer still finds it.
+func workerOrgSubject(subject, org string) string {
+	if org == "" || !orgIDPattern.MatchString(org) {
+		return subject
+	}
+	return subject + ".org." + org
+}
+
+// workerSubjectOrg is the org a worker subject was published under, or ""
+// for a bare one.
+func workerSubjectOrg(subject string) string {
+	tokens := strings.Split(subject, ".")
+	if len(tokens) < 3 || tokens[len(tokens)-2] != "org" {
+		return ""
+	}
+	return tokens[len(tokens)-1]
+}
+
+// workerSubjectWithoutOrg strips the org from a worker subject, leaving its
+// partition subject.
+func workerSubjectWithoutOrg(subject string) string {
+	org := workerSubjectOrg(subject)
+	if org == "" {
+		return subject
+	}
+	return strings.TrimSuffix(subject, ".org."+org)
+}
+
+// workerFilterSubjects are the consumer filters for one partition subject:
+// the bare subject and every org's form of it.
+func workerFilterSubjects(partitionSubject string) []string {
+	return []string{partitionSubject, partitionSubject + ".org.*"}
+}
+
+// workerPartitionSubjects expands each subject into all of its partitions.
+func workerPartitionSubjects(subjects []string) []string {
+	partitions := workerConcurrency()
+	out := make([]string, 0, len(subjects)*partitions)
+	for _, subject := range subjects {
+		for partition := range partitions {
+			out = append(out, workerPartitionSubject(subject, partition))
+		}
+	}
+	return out
+}
+
+// workerStreamSubjects lets the stream hold subject and any partition of it,
+// whatever the count the publishing replica was configured with, bare or
+// under an org.
+func workerStreamSubjects(subjects []string) []string {
+	out := make([]string, 0, len(subjects)*4)
+	for _, subject := range subjects {
+		out = append(out, subject, subject+".*", subject+".org.*", subject+".*.org.*")
+	}
+	return out
+}
+
+// workerBaseSubject strips the org and the partition from a stream subject.
+// Base subjects never end in a number, so a numeric last token is a
+// partition.
+func workerBaseSubject(subject string) string {
+	subject = workerSubjectWithoutOrg(subject)
+	idx := strings.LastIndex(subject, ".")
+	if idx < 0 {
+		return subject
+	}
+	if partition, err := strconv.Atoi(subject[idx+1:]); err != nil || partition <= 0 {
+		return subject
+	}
+	return subject[:idx]
+}
+
+func workerPartitionConsumerName(workerName string, partition int) string {
+	if partition <= 0 {
+		return workerConsumerName(workerName)
+	}
+	return workerConsumerName(fmt.Sprintf("%s_p%d", workerName, partition))
+}
+
+// drainStaleWorkerPartitions moves the messages still waiting on partitions
+// of inSubj at or above partitions, left by a run with a higher
+// PAAS_WORKER_CONCURRENCY, onto the partition each project maps to now and
+// deletes the emptied consumers. A consumer whose message is still in flight
+// on another replica is kept, to be drained on a later start.
+func drainStaleWorkerPartitions(
+	ctx context.Context,
+	js jetstream.JetStream,
+	workerName, inSubj string,
+	partitions int,
+	workerLog sourceLogger,
+) error {
+	stream := natsStreamName(streamWorkerPipeline)
+	for partition := partitions; partition < maxWorkerConcurrency; partition++ {
+		consumerName := workerPartitionConsumerName(workerName, partition)
+		consumer, err := js.Consumer(ctx, stream, consumerName)
+		if errors.Is(err, jetstream.ErrConsumerNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		moved, err := rebindWorkerPartitionMessages(ctx, js, consumer, inSubj)
+		if err != nil {
+			return fmt.Errorf("drain partition %d: %w", partition, err)
+		}
+		info, err := consumer.Info(ctx)
+		if err != nil {
+			return err
+		}
+		if info.NumPending > 0 || info.NumAckPending > 0 {
+			workerLog.Warnf("partition %d of %s still has %d pending and %d in-flight messages; keeping its consumer",
+				partition, inSubj, info.NumPending, info.NumAckPending)
+			continue
+		}
+		if err = js.DeleteConsumer(ctx, stream, consumerName); err != nil &&
+			!errors.Is(err, jetstream.ErrConsumerNotFound) {
+			return err
+		}
+		workerLog.Infof("drained partition %d of %s: moved=%d partitions=%d", partition, inSubj, moved, partitions)
+	}
+	return nil
+}
+
+// rebindWorkerPartitionMessages republishes each message consumer has
+// pending on the partition, and org, its project maps to now, and acks the
+// original. The stream sequence dedupes a republish repeated after a crash.
+func rebindWorkerPartitionMessages(
+	ctx context.Context,
+	js jetstream.JetStream,
+	consumer jetstream.Consumer,
+	inSubj string,
+) (int, error) {
+	moved := 0
+	for {
+		info, err := consumer.Info(ctx)
+		if err != nil {
+			return moved, err
+		}
+		if info.NumPending == 0 {
+			return moved, nil
+		}
+		msg, err := consumer.Next(jetstream.FetchMaxWait(workerDeliveryFetchWait))
+		if err != nil {
+			if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				return moved, nil
+			}
+			return moved, err
+		}
+		meta, err := msg.Metadata()
+		if err != nil {
+			return moved, err
+		}
+		var carrier struct {
+			ProjectID string `json:"project_id"`
+		}
+		_ = json.Unmarshal(msg.Data(), &carrier)
+		subject := workerSubjectFor(inSubj, workerSubjectOrg(msg.Subject()), carrier.ProjectID)
+		msgID := fmt.Sprintf("rebind/%s/%d", info.Name, meta.Sequence.Stream)
+		if _, err = js.Publish(ctx, subject, msg.Data(), jetstream.WithMsgID(msgID)); err != nil {
+			_ = msg.Nak()
+			return moved, err
+		}
+		if err = msg.DoubleAck(ctx); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+}
@@ -0,0 +1,219 @@
+//nolint:testpackage // Partition tests drive the unexported worker loop and consumer wiring.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestWorkers_PartitionsRunProjectsSideBySide(t *testing.T) {
+	t.Setenv(workerConcurrencyEnv, "4")
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startSubj, doneSubj := natsSubject(subjectProjectOpStart), natsSubject(subjectRegistrationDone)
+	projects := []string{}
+	for i := 0; len(projects) < 2; i++ {
+		projectID := fmt.Sprintf("project-partition-%d", i)
+		if len(projects) == 0 || workerSubjectFor(startSubj, "", projectID) != workerSubjectFor(startSubj, "", projects[0]) {
+			projects = append(projects, projectID)
+		}
+	}
+
+	// Each action holds until the other one has started, which only
+	// happens when the two partitions are consumed at the same time.
+	var started sync.WaitGroup
+	started.Add(len(projects))
+	bothRunning := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(bothRunning)
+	}()
+	action := func(
+		ctx context.Context, store *Store, artifacts ArtifactStore, msg ProjectOpMsg,
+	) (WorkerResultMsg, error) {
+		started.Done()
+		select {
+		case <-bothRunning:
+		case <-time.After(5 * time.Second):
+			return newWorkerResultMsg(""), errors.New("the other partition never started")
+		}
+		return workerRuntimeActionSuccess(ctx, store, artifacts, msg)
+	}
+
+	results := map[string]*nats.Subscription{}
+	for _, projectID := range projects {
+		if base := workerBaseSubject(workerSubjectFor(startSubj, "", projectID)); base != startSubj {
+			t.Fatalf("expected %s to map back to %s", base, startSubj)
+		}
+		sub, err := fixture.nc.SubscribeSync(workerSubjectFor(doneSubj, "", projectID))
+		if err != nil {
+			t.Fatalf("subscribe results: %v", err)
+		}
+		results[projectID] = sub
+	}
+	if err := startWorker(
+		ctx, "registrar", fixture.endpoint, startSubj, doneSubj, NewFSArtifacts(t.TempDir()), nil, action,
+	); err != nil {
+		t.Fatalf("start worker: %v", err)
+	}
+	for i, projectID := range projects {
+		spec := workerRuntimeSpec(fmt.Sprintf("partition-%d", i))
+		opID := "op-" + projectID
+		putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCreate, spec)
+		payload := workerPayload(t, opID, OpCreate, projectID, spec)
+		if _, err := fixture.js.Publish(ctx, workerSubjectFor(startSubj, "", projectID), payload); err != nil {
+			t.Fatalf("publish op: %v", err)
+		}
+	}
+
+	for projectID, sub := range results {
+		msg, err := sub.NextMsg(10 * time.Second)
+		if err != nil {
+			t.Fatalf("wait for %s result: %v", projectID, err)
+		}
+		var res WorkerResultMsg
+		if err = json.Unmarshal(msg.Data, &res); err != nil || res.ProjectID != projectID || res.Err != "" {
+			t.Fatalf("expected %s registered, got %+v (%v)", projectID, res, err)
+		}
+	}
+	floors, err := workerAckFloors(ctx, fixture.js)
+	if err != nil || len(floors) != 4 {
+		t.Fatalf("expected an ack floor for each registrar partition, got %v (%v)", floors, err)
+	}
+}
+
+func TestWorkers_OrgSubjectsKeepTheirOrgThroughThePipeline(t *testing.T) {
+	t.Setenv(workerConcurrencyEnv, "2")
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startSubj, doneSubj := natsSubject(subjectProjectOpStart), natsSubject(subjectRegistrationDone)
+	projectID := "project-org-subjects"
+	orgStart := workerSubjectFor(startSubj, "acme", projectID)
+	if workerSubjectOrg(orgStart) != "acme" || workerBaseSubject(orgStart) != startSubj ||
+		workerSubjectWithoutOrg(orgStart) != workerSubjectFor(startSubj, "", projectID) {
+		t.Fatalf("expected %s to name org acme on a partition of %s", orgStart, startSubj)
+	}
+
+	// A consumer left by a release without org subjects filters the bare
+	// partition subject only; the worker widens it in place.
+	if err := ensureWorkerDeliveryStream(ctx, fixture.js); err != nil {
+		t.Fatalf("ensure stream: %v", err)
+	}
+	for partition := range workerConcurrency() {
+		legacy := workerConsumerConfig("registrar", startSubj, partition)
+		legacy.FilterSubjects = nil
+		legacy.FilterSubject = workerPartitionSubject(startSubj, partition)
+		if _, err := fixture.js.CreateConsumer(ctx, natsStreamName(streamWorkerPipeline), legacy); err != nil {
+			t.Fatalf("create legacy consumer: %v", err)
+		}
+	}
+	results, err := fixture.nc.SubscribeSync(workerSubjectFor(doneSubj, "acme", projectID))
+	if err != nil {
+		t.Fatalf("subscribe results: %v", err)
+	}
+	if err = startWorker(
+		ctx, "registrar", fixture.endpoint, startSubj, doneSubj, NewFSArtifacts(t.TempDir()), nil,
+		workerRuntimeActionSuccess,
+	); err != nil {
+		t.Fatalf("start worker: %v", err)
+	}
+	spec := workerRuntimeSpec("org-subjects")
+	putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, "op-org-subjects", OpCreate, spec)
+	payload := workerPayload(t, "op-org-subjects", OpCreate, projectID, spec)
+	if _, err = fixture.js.Publish(ctx, orgStart, payload); err != nil {
+		t.Fatalf("publish op: %v", err)
+	}
+	msg, err := results.NextMsg(10 * time.Second)
+	if err != nil {
+		t.Fatalf("expected the result on the org's subject: %v", err)
+	}
+	var res WorkerResultMsg
+	if err = json.Unmarshal(msg.Data, &res); err != nil || res.ProjectID != projectID || res.Err != "" {
+		t.Fatalf("expected %s registered, got %+v (%v)", projectID, res, err)
+	}
+}
+
+func TestWorkers_LoweredConcurrencyDrainsTheDroppedPartitions(t *testing.T) {
+	t.Setenv(workerConcurrencyEnv, "4")
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startSubj, doneSubj := natsSubject(subjectProjectOpStart), natsSubject(subjectRegistrationDone)
+	if err := ensureWorkerDeliveryStream(ctx, fixture.js); err != nil {
+		t.Fatalf("ensure stream: %v", err)
+	}
+	for partition := range workerConcurrency() {
+		cfg := workerConsumerConfig("registrar", startSubj, partition)
+		if _, err := fixture.js.CreateConsumer(ctx, natsStreamName(streamWorkerPipeline), cfg); err != nil {
+			t.Fatalf("create consumer: %v", err)
+		}
+	}
+	// Two projects left waiting on partitions a lower count drops, one of
+	// them in an org.
+	orgs := []string{"", "acme"}
+	projects := []string{}
+	for i := 0; len(projects) < len(orgs); i++ {
+		projectID := fmt.Sprintf("project-stale-partition-%d", i)
+		if workerPartitionFor(projectID, workerConcurrency()) >= 2 {
+			projects = append(projects, projectID)
+		}
+	}
+	for i, projectID := range projects {
+		spec := workerRuntimeSpec(fmt.Sprintf("stale-partition-%d", i))
+		opID := "op-" + projectID
+		putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCreate, spec)
+		payload := workerPayload(t, opID, OpCreate, projectID, spec)
+		if _, err := fixture.js.Publish(ctx, workerSubjectFor(startSubj, orgs[i], projectID), payload); err != nil {
+			t.Fatalf("publish op: %v", err)
+		}
+	}
+
+	t.Setenv(workerConcurrencyEnv, "2")
+	results := map[string]*nats.Subscription{}
+	for i, projectID := range projects {
+		sub, err := fixture.nc.SubscribeSync(workerSubjectFor(doneSubj, orgs[i], projectID))
+		if err != nil {
+			t.Fatalf("subscribe results: %v", err)
+		}
+		results[projectID] = sub
+	}
+	if err := startWorker(
+		ctx, "registrar", fixture.endpoint, startSubj, doneSubj, NewFSArtifacts(t.TempDir()), nil,
+		workerRuntimeActionSuccess,
+	); err != nil {
+		t.Fatalf("start worker: %v", err)
+	}
+	for projectID, sub := range results {
+		msg, err := sub.NextMsg(10 * time.Second)
+		if err != nil {
+			t.Fatalf("expected %s handled after the partition count was lowered: %v", projectID, err)
+		}
+		var res WorkerResultMsg
+		if err = json.Unmarshal(msg.Data, &res); err != nil || res.ProjectID != projectID || res.Err != "" {
+			t.Fatalf("expected %s registered, got %+v (%v)", projectID, res, err)
+		}
+	}
+	for partition := 2; partition < 4; partition++ {
+		_, err := fixture.js.Consumer(ctx, natsStreamName(streamWorkerPipeline),
+			workerPartitionConsumerName("registrar", partition))
+		if !errors.Is(err, jetstream.ErrConsumerNotFound) {
+			t.Fatalf("expected the partition %d consumer deleted once drained, got %v", partition, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+//nolint:testpackage,exhaustruct // Provisioner tests drive the unexported step with a fake docker binary.
+package platform
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostgresProvisioner_ManagesContainersSecretsAndBindings(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+
+	dir := t.TempDir()
+	containers := filepath.Join(dir, "containers")
+	if err := os.Mkdir(containers, 0o700); err != nil {
+		t.Fatalf("mkdir containers: %v", err)
+	}
+	dockerLog := filepath.Join(dir, "docker.log")
+	fakeDocker := filepath.Join(dir, "docker")
+	script := "#!/bin/sh\necho \"$@\" >> " + dockerLog + "\n" +
+		"state=" + containers + "\n" +
+		"case \"$1\" in\n" +
+		"ps) case \"$4\" in\n" +
+		"  name=*) n=${4#name=^/}; n=${n%?}; if [ -f \"$state/$n\" ]; then cat \"$state/$n\"; fi;;\n" +
+		"  label=*) ls \"$state\";;\n" +
+		"  esac;;\n" +
+		"run) if [ -z \"$POSTGRES_PASSWORD\" ]; then echo 'no password' >&2; exit 1; fi\n" +
+		"  for last; do :; done; echo \"running $last\" > \"$state/$4\";;\n" +
+		"start) sed -i 's/^exited/running/' \"$state/$2\";;\n" +
+		"port) printf '0.0.0.0:55432\\n[::]:55432\\n';;\n" +
+		"rm) rm -f \"$state/$4\";;\n" +
+		"esac\n"
+	if err := os.WriteFile(fakeDocker, []byte(script), 0o700); err != nil {
+		t.Fatalf("write fake docker: %v", err)
+	}
+	t.Setenv(dockerBinaryEnv, fakeDocker)
+	t.Setenv(secretsKeyEnv, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", storedSecretsKeyBytes))))
+
+	ctx := context.Background()
+	store := fixture.store
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-orders"
+	spec := workerRuntimeSpec("orders")
+	spec.Capabilities = []string{"http", "postgres"}
+	spec.CapabilityOptions = map[string]map[string]string{"postgres": {"version": "15"}}
+	spec.Environments["prod"] = EnvConfig{}
+	now := time.Now().UTC()
+	if err := store.PutProject(ctx, Project{
+		ID: projectID, CreatedAt: now, UpdatedAt: now, Spec: spec,
+		Status: ProjectStatus{Phase: projectPhaseReady, UpdatedAt: now},
+	}); err != nil {
+		t.Fatalf("put project: %v", err)
+	}
+	msg := ProjectOpMsg{OpID: "op-provision", Kind: OpCreate, ProjectID: projectID, At: time.Now().UTC()}
+	if _, err := store.putCapabilityBinding(ctx, CapabilityBinding{
+		ProjectID: projectID, Environment: "prod", Capability: capabilityPostgres,
+		Type: CapabilityBindingExternal, Env: map[string]string{"DATABASE_URL": "postgres://rds.internal/orders"},
+	}); err != nil {
+		t.Fatalf("bind prod: %v", err)
+	}
+
+	if outcome, err := runPostgresProvisionStep(ctx, store, artifacts, msg, spec); err != nil || len(outcome.artifacts) != 0 {
+		t.Fatalf("expected no provision step while disabled, got %+v (%v)", outcome, err)
+	}
+	t.Setenv(postgresProvisionerEnv, "podman")
+	if _, err := runPostgresProvisionStep(ctx, store, artifacts, msg, spec); err == nil ||
+		!strings.Contains(err.Error(), "is not supported") {
+		t.Fatalf("expected an unknown provisioner to be refused, got %v", err)
+	}
+
+	t.Setenv(postgresProvisionerEnv, postgresProvisionerDocker)
+	outcome, err := runPostgresProvisionStep(ctx, store, artifacts, msg, spec)
+	if err != nil || outcome.message != "managed postgres running for dev" {
+		t.Fatalf("provision: %+v (%v)", outcome, err)
+	}
+	devContainer := managedPostgresContainer(projectID, "dev")
+	report := readPostgresReport(t, artifacts, projectID)
+	if len(report.Instances) != 1 || report.Instances[0].Container != devContainer ||
+		report.Instances[0].Status != postgresStatusCreated || report.Instances[0].Image != "postgres:15" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	databaseURL, err := openStoredSecretValue(ctx, store, projectID, "dev", postgresURLSecret)
+	if err != nil || !strings.HasPrefix(databaseURL, "postgres://app:") ||
+		!strings.HasSuffix(databaseURL, "@host.docker.internal:55432/orders?sslmode=disable") {
+		t.Fatalf("expected a stored DATABASE_URL, got %q (%v)", databaseURL, err)
+	}
+	password := strings.TrimSuffix(strings.TrimPrefix(databaseURL, "postgres://app:"),
+		"@host.docker.internal:55432/orders?sslmode=disable")
+	if raw, _ := os.ReadFile(dockerLog); strings.Contains(string(raw), password) {
+		t.Fatalf("expected the password to stay off docker's command line:\n%s", raw)
+	}
+	bindings, err := store.getCapabilityBindings(ctx, projectID)
+	if err != nil || bindings.Environments["dev"][capabilityPostgres].Type != CapabilityBindingManaged ||
+		bindings.Environments["prod"][capabilityPostgres].Type != CapabilityBindingExternal {
+		t.Fatalf("expected a managed dev binding beside the external prod one, got %+v (%v)", bindings, err)
+	}
+
+	if _, err = runManifestApplyForEnvironment(ctx, store, artifacts, msg, spec, "local/orders:v1", "dev"); err != nil {
+		t.Fatalf("render dev: %v", err)
+	}
+	dev, err := renderEnvironmentManifestsFromRepo(artifacts, projectID, "dev", manifestTrace{})
+	if err != nil {
+		t.Fatalf("read dev: %v", err)
+	}
+	if strings.Contains(dev.rendered, "kind: StatefulSet") ||
+		!strings.Contains(dev.rendered, "- name: DATABASE_URL\n          valueFrom:\n            secretKeyRef:\n") {
+		t.Fatalf("expected DATABASE_URL from the project secret and no in-cluster postgres, got:\n%s", dev.rendered)
+	}
+
+	if err = os.WriteFile(filepath.Join(containers, devContainer), []byte("exited postgres:15\n"), 0o600); err != nil {
+		t.Fatalf("stop container: %v", err)
+	}
+	if _, err = runPostgresProvisionStep(ctx, store, artifacts, msg, spec); err != nil {
+		t.Fatalf("reprovision: %v", err)
+	}
+	if report = readPostgresReport(t, artifacts, projectID); report.Instances[0].Status != postgresStatusStarted {
+		t.Fatalf("expected the stopped container to be started, got %+v", report)
+	}
+	if again, _ := openStoredSecretValue(ctx, store, projectID, "dev", postgresURLSecret); again != databaseURL {
+		t.Fatalf("expected the credentials to survive a restart, got %q", again)
+	}
+
+	spec.Capabilities = []string{"http"}
+	spec.CapabilityOptions = nil
+	if outcome, err = runPostgresProvisionStep(ctx, store, artifacts, msg, spec); err != nil ||
+		outcome.message != "removed 1 managed postgres container(s)" {
+		t.Fatalf("expected the dropped capability to deprovision, got %+v (%v)", outcome, err)
+	}
+	bindings, _ = store.getCapabilityBindings(ctx, projectID)
+	if _, bound := bindings.Environments["dev"][capabilityPostgres]; bound {
+		t.Fatalf("expected the managed binding to be removed, got %+v", bindings)
+	}
+	if stored, _ := openStoredSecretValue(ctx, store, projectID, "dev", postgresURLSecret); stored != "" {
+		t.Fatalf("expected the stored DATABASE_URL to be removed, got %q", stored)
+	}
+
+	spec.Capabilities = []string{"http", "postgres"}
+	if _, err = runPostgresProvisionStep(ctx, store, artifacts, msg, spec); err != nil {
+		t.Fatalf("provision again: %v", err)
+	}
+	deleted, err := runManifestRendererDelete(ctx, store, artifacts, ProjectOpMsg{
+		OpID: "op-delete", Kind: OpDelete, ProjectID: projectID, At: time.Now().UTC(),
+	})
+	if err != nil || !strings.Contains(deleted.message, "removed managed postgres ("+devContainer+")") {
+		t.Fatalf("expected delete to deprovision, got %+v (%v)", deleted, err)
+	}
+	if left, _ := os.ReadDir(containers); len(left) != 0 {
+		t.Fatalf("expected no containers left, got %v", left)
+	}
+}
+
+func readPostgresReport(t *testing.T, artifacts ArtifactStore, projectID string) postgresReport {
+	t.Helper()
+	raw, err := artifacts.ReadFile(projectID, postgresReportFile)
+	if err != nil {
+		t.Fatalf("read postgres report: %v", err)
+	}
+	var report postgresReport
+	if err = json.Unmarshal(raw, &report); err != nil {
+		t.Fatalf("decode postgres report: %v", err)
+	}
+	return report
+}
@@ -0,0 +1,188 @@
+package platform
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Worker queues: PAAS_WORKER_LIMITS caps how many deliveries of each worker
+// type this process runs at once. A partition loop that fetched a message
+// while its worker is at the cap holds the message, telling JetStream it is
+// still in progress so it is neither redelivered nor counted as an attempt,
+// until a slot frees up. Messages behind it stay in the stream. Per project
+// the limit is always one: partitions (workers_partitions.go) hand a
+// project's messages out one at a time.
+////////////////////////////////////////////////////////////////////////////////
+
+// workerLimitDefaultKey sets the cap for workers not named in
+// PAAS_WORKER_LIMITS.
+const workerLimitDefaultKey = "*"
+
+type workerQueueStats struct {
+	// Limit is 0 when the worker runs one delivery per partition.
+	Limit   int    `json:"limit"`
+	Running int64  `json:"running"`
+	Waiting int64  `json:"waiting"`
+	Pending uint64 `json:"pending"`
+}
+
+type workerQueue struct {
+	limit   int
+	slots   chan struct{}
+	running atomic.Int64
+	waiting atomic.Int64
+
+	mu        sync.Mutex
+	consumers []jetstream.Consumer
+}
+
+// workerQueues holds this process's queue for each worker type, created on
+// the worker's first use.
+type workerQueues struct {
+	limits map[string]int
+
+	mu     sync.Mutex
+	queues map[string]*workerQueue
+}
+
+type workerQueuesKey struct{}
+
+func newWorkerQueues(limits map[string]int) *workerQueues {
+	return &workerQueues{limits: limits, mu: sync.Mutex{}, queues: map[string]*workerQueue{}}
+}
+
+func withWorkerQueues(ctx context.Context, queues *workerQueues) context.Context {
+	return context.WithValue(ctx, workerQueuesKey{}, queues)
+}
+
+// workerQueuesFromContext returns Run's queues, or nil for workers started
+// without them (tests), which then run one delivery per partition.
+func workerQueuesFromContext(ctx context.Context) *workerQueues {
+	queues, _ := ctx.Value(workerQueuesKey{}).(*workerQueues)
+	return queues
+}
+
+func (q *workerQueues) queue(workerName string) *workerQueue {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if queue, ok := q.queues[workerName]; ok {
+		return queue
+	}
+	limit, ok := q.limits[workerName]
+	if !ok {
+		limit = q.limits[workerLimitDefaultKey]
+	}
+	queue := &workerQueue{
+		limit:     limit,
+		slots:     nil,
+		running:   atomic.Int64{},
+		waiting:   atomic.Int64{},
+		mu:        sync.Mutex{},
+		consumers: nil,
+	}
+	if limit > 0 {
+		queue.slots = make(chan struct{}, limit)
+	}
+	q.queues[workerName] = queue
+	return queue
+}
+
+// snapshot reports each worker's queue; Pending is what its consumers have
+// not delivered yet, across every replica.
+func (q *workerQueues) snapshot(ctx context.Context) map[string]workerQueueStats {
+	out := map[string]workerQueueStats{}
+	if q == nil {
+		return out
+	}
+	q.mu.Lock()
+	queues := maps.Clone(q.queues)
+	q.mu.Unlock()
+	for workerName, queue := range queues {
+		out[workerName] = queue.stats(ctx)
+	}
+	return out
+}
+
+func (w *workerQueue) addConsumer(consumer jetstream.Consumer) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.consumers = append(w.consumers, consumer)
+}
+
+func (w *workerQueue) stats(ctx context.Context) workerQueueStats {
+	w.mu.Lock()
+	consumers := slices.Clone(w.consumers)
+	w.mu.Unlock()
+	stats := workerQueueStats{
+		Limit:   w.limit,
+		Running: w.running.Load(),
+		Waiting: w.waiting.Load(),
+		Pending: 0,
+	}
+	for _, consumer := range consumers {
+		if info, err := consumer.Info(ctx); err == nil {
+			stats.Pending += info.NumPending
+		}
+	}
+	return stats
+}
+
+// acquire takes a slot for msg, keeping msg in progress while it waits. It
+// returns false when stop closes or ctx ends first; msg is then still
+// un-acked and the caller hands it back.
+func (w *workerQueue) acquire(ctx context.Context, msg jetstream.Msg, stop <-chan struct{}) bool {
+	if w == nil {
+		return true
+	}
+	if w.slots == nil {
+		w.running.Add(1)
+		return true
+	}
+	select {
+	case w.slots <- struct{}{}:
+		w.running.Add(1)
+		return true
+	default:
+	}
+
+	w.waiting.Add(1)
+	defer w.waiting.Add(-1)
+	keepAlive := time.NewTicker(workerQueueKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case w.slots <- struct{}{}:
+			w.running.Add(1)
+			return true
+		case <-keepAlive.C:
+			_ = msg.InProgress()
+		case <-stop:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (w *workerQueue) release() {
+	if w == nil {
+		return
+	}
+	w.running.Add(-1)
+	if w.slots != nil {
+		<-w.slots
+	}
+}
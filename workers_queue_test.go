@@ -0,0 +1,89 @@
+//nolint:testpackage // Queue tests drive the unexported worker loop and queue counters.
+package platform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWorkers_QueueLimitHoldsDeliveriesUntilASlotFrees(t *testing.T) {
+	t.Setenv(workerConcurrencyEnv, "4")
+	t.Setenv(workerLimitsEnv, "registrar=1, *=3, imageBuilder=x")
+	limits := workerLimitsFromEnv()
+	if len(limits) != 2 || limits["registrar"] != 1 || limits[workerLimitDefaultKey] != 3 {
+		t.Fatalf("expected registrar and default limits parsed, got %v", limits)
+	}
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	queues := newWorkerQueues(limits)
+	ctx, cancel := context.WithCancel(withWorkerQueues(context.Background(), queues))
+	defer cancel()
+
+	startSubj, doneSubj := natsSubject(subjectProjectOpStart), natsSubject(subjectRegistrationDone)
+	projects := []string{}
+	for i := 0; len(projects) < 2; i++ {
+		projectID := fmt.Sprintf("project-queue-%d", i)
+		if len(projects) == 0 || workerSubjectFor(startSubj, "", projectID) != workerSubjectFor(startSubj, "", projects[0]) {
+			projects = append(projects, projectID)
+		}
+	}
+	entered := make(chan string, len(projects))
+	proceed := make(chan struct{})
+	action := func(
+		ctx context.Context, store *Store, artifacts ArtifactStore, msg ProjectOpMsg,
+	) (WorkerResultMsg, error) {
+		entered <- msg.ProjectID
+		<-proceed
+		return workerRuntimeActionSuccess(ctx, store, artifacts, msg)
+	}
+	if err := startWorker(
+		ctx, "registrar", fixture.endpoint, startSubj, doneSubj, NewFSArtifacts(t.TempDir()), nil, action,
+	); err != nil {
+		t.Fatalf("start worker: %v", err)
+	}
+	for i, projectID := range projects {
+		spec := workerRuntimeSpec(fmt.Sprintf("queue-%d", i))
+		opID := "op-" + projectID
+		putWorkerRuntimeProjectAndOp(t, fixture.store, projectID, opID, OpCreate, spec)
+		payload := workerPayload(t, opID, OpCreate, projectID, spec)
+		if _, err := fixture.js.Publish(ctx, workerSubjectFor(startSubj, "", projectID), payload); err != nil {
+			t.Fatalf("publish op: %v", err)
+		}
+	}
+
+	select {
+	case <-entered:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no delivery started")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	stats := queues.snapshot(ctx)["registrar"]
+	for stats.Waiting != 1 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		stats = queues.snapshot(ctx)["registrar"]
+	}
+	if stats.Limit != 1 || stats.Running != 1 || stats.Waiting != 1 {
+		t.Fatalf("expected one delivery running and one waiting, got %+v", stats)
+	}
+	select {
+	case projectID := <-entered:
+		t.Fatalf("expected %s held while registrar is at its limit", projectID)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(proceed)
+	select {
+	case <-entered:
+	case <-time.After(10 * time.Second):
+		t.Fatal("held delivery did not start once the slot freed")
+	}
+	for stats.Running != 0 && time.Now().Before(deadline.Add(5*time.Second)) {
+		time.Sleep(20 * time.Millisecond)
+		stats = queues.snapshot(ctx)["registrar"]
+	}
+	if stats.Running != 0 || stats.Waiting != 0 || stats.Pending != 0 {
+		t.Fatalf("expected the queue drained, got %+v", stats)
+	}
+}
@@ -3,7 +3,6 @@ package platform
 import (
 	"errors"
 	"fmt"
-	"maps"
 	"os"
 	"path/filepath"
 	"slices"
@@ -60,10 +59,39 @@ func renderProjectConfigYAML(spec ProjectSpec) []byte {
 			fmt.Fprintf(&b, "  - %s\n", c)
 		}
 	}
+	if capabilities := sortedKeys(spec.CapabilityOptions); len(capabilities) > 0 {
+		b.WriteString("capabilityOptions:\n")
+		for _, c := range capabilities {
+			options := spec.CapabilityOptions[c]
+			if len(options) == 0 {
+				fmt.Fprintf(&b, "  %s: {}\n", c)
+				continue
+			}
+			fmt.Fprintf(&b, "  %s:\n", c)
+			for _, k := range sortedKeys(options) {
+				fmt.Fprintf(&b, "    %s: %s\n", k, yamlQuoted(options[k]))
+			}
+		}
+	}
+	if keys := sortedKeys(spec.Vars); len(keys) > 0 {
+		b.WriteString("vars:\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "  %s: %s\n", k, yamlQuoted(spec.Vars[k]))
+		}
+	}
 	b.WriteString("environments:\n")
 	for _, env := range sortedKeys(spec.Environments) {
 		cfg := spec.Environments[env]
 		fmt.Fprintf(&b, "  %s:\n", env)
+		if cfg.Replicas > 0 {
+			fmt.Fprintf(&b, "    replicas: %d\n", cfg.Replicas)
+		}
+		if autoscaling := cfg.Autoscaling; autoscaling.enabled() {
+			b.WriteString("    autoscaling:\n")
+			fmt.Fprintf(&b, "      minReplicas: %d\n", autoscaling.minReplicas())
+			fmt.Fprintf(&b, "      maxReplicas: %d\n", autoscaling.MaxReplicas)
+			fmt.Fprintf(&b, "      targetCPUUtilization: %d\n", autoscaling.targetCPUUtilization())
+		}
 		b.WriteString("    vars:\n")
 		keys := sortedKeys(cfg.Vars)
 		if len(keys) == 0 {
@@ -73,41 +101,105 @@ func renderProjectConfigYAML(spec ProjectSpec) []byte {
 			fmt.Fprintf(&b, "      %s: %s\n", k, yamlQuoted(cfg.Vars[k]))
 		}
 	}
+	if spec.Build != (BuildConfig{}) {
+		b.WriteString("build:\n")
+		if spec.Build.Strategy != "" {
+			fmt.Fprintf(&b, "  strategy: %s\n", spec.Build.Strategy)
+		}
+		if spec.Build.Builder != "" {
+			fmt.Fprintf(&b, "  builder: %s\n", yamlQuoted(spec.Build.Builder))
+		}
+	}
+	if spec.Helm.Enabled {
+		b.WriteString("helm:\n  enabled: true\n")
+	}
+	writeExposureYAML(&b, spec.Exposure)
+	writeContainerResources(&b, "", spec.Resources)
 	b.WriteString("networkPolicies:\n")
 	fmt.Fprintf(&b, "  ingress: %s\n", spec.NetworkPolicies.Ingress)
 	fmt.Fprintf(&b, "  egress: %s\n", spec.NetworkPolicies.Egress)
+	if keys := sortedKeys(spec.Extensions); len(keys) > 0 {
+		// JSON is valid YAML flow syntax, so values keep their exact shape.
+		b.WriteString("extensions:\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "  %s: %s\n", k, mustCompactJSON(spec.Extensions[k]))
+		}
+	}
 	return []byte(b.String())
 }
 
+func writeExposureYAML(b *strings.Builder, exposure ExposureConfig) {
+	if exposure == (ExposureConfig{}) {
+		return
+	}
+	b.WriteString("exposure:\n")
+	if exposure.ServiceType != "" {
+		fmt.Fprintf(b, "  serviceType: %s\n", exposure.ServiceType)
+	}
+	if exposure.Port != 0 {
+		fmt.Fprintf(b, "  port: %d\n", exposure.Port)
+	}
+	if exposure.ContainerPort != 0 {
+		fmt.Fprintf(b, "  containerPort: %d\n", exposure.ContainerPort)
+	}
+	if exposure.Ingress.Host == "" {
+		return
+	}
+	b.WriteString("  ingress:\n")
+	fmt.Fprintf(b, "    host: %s\n", yamlQuoted(exposure.Ingress.Host))
+	if exposure.Ingress.Path != "" {
+		fmt.Fprintf(b, "    path: %s\n", yamlQuoted(exposure.Ingress.Path))
+	}
+	if exposure.Ingress.TLS {
+		b.WriteString("    tls: true\n")
+	}
+}
+
+// writeExtensionAnnotations adds one pod template annotation per spec
+// extension, indented to sit under an annotations: key.
+func writeExtensionAnnotations(b *strings.Builder, extensions map[string]any) {
+	for _, k := range sortedKeys(extensions) {
+		fmt.Fprintf(b, "        %s%s: %s\n", extensionAnnotationPrefix, k,
+			yamlQuoted(extensionAnnotationValue(extensions[k])))
+	}
+}
+
 func preferredEnvironment(spec ProjectSpec) (string, map[string]string) {
 	spec = normalizeProjectSpec(spec)
-	if env, ok := spec.Environments["dev"]; ok {
-		return "dev", env.Vars
+	if _, ok := spec.Environments["dev"]; ok {
+		return "dev", effectiveEnvironmentVars(spec, "dev")
 	}
 	names := sortedKeys(spec.Environments)
 	if len(names) == 0 {
-		return "default", map[string]string{}
+		return "default", effectiveEnvironmentVars(spec, "")
 	}
 	first := names[0]
-	return first, spec.Environments[first].Vars
+	return first, effectiveEnvironmentVars(spec, first)
 }
 
 func environmentVarsFor(spec ProjectSpec, envName string) map[string]string {
 	spec = normalizeProjectSpec(spec)
-	if env, ok := spec.Environments[envName]; ok {
-		return mapsClone(env.Vars)
+	if _, ok := spec.Environments[envName]; ok {
+		return effectiveEnvironmentVars(spec, envName)
 	}
 	_, vars := preferredEnvironment(spec)
-	return mapsClone(vars)
+	return vars
 }
 
-func mapsClone(m map[string]string) map[string]string {
-	return maps.Clone(m)
+func renderBaseDeploymentManifest(spec ProjectSpec) string {
+	return renderTrackDeploymentManifest(spec, "", "app-image")
 }
 
-func renderBaseDeploymentManifest(spec ProjectSpec) string {
+// renderTrackDeploymentManifest renders the app Deployment. A track, such
+// as canary, names a second Deployment whose pods carry the same app label,
+// so the Service sends them a share of the traffic.
+func renderTrackDeploymentManifest(spec ProjectSpec, track string, image string) string {
 	spec = normalizeProjectSpec(spec)
-	name := safeName(spec.Name)
+	app := safeName(spec.Name)
+	name := app
+	if track != "" {
+		name = app + "-" + track
+	}
 	var b strings.Builder
 	fmt.Fprintf(&b, "apiVersion: apps/v1\n")
 	fmt.Fprintf(&b, "kind: Deployment\n")
@@ -117,34 +209,80 @@ func renderBaseDeploymentManifest(spec ProjectSpec) string {
 	fmt.Fprintf(&b, "  replicas: 1\n")
 	fmt.Fprintf(&b, "  selector:\n")
 	fmt.Fprintf(&b, "    matchLabels:\n")
-	fmt.Fprintf(&b, "      app: %s\n", name)
+	fmt.Fprintf(&b, "      app: %s\n", app)
+	writeTrackLabel(&b, "      ", track)
 	fmt.Fprintf(&b, "  template:\n")
 	fmt.Fprintf(&b, "    metadata:\n")
 	fmt.Fprintf(&b, "      labels:\n")
-	fmt.Fprintf(&b, "        app: %s\n", name)
+	fmt.Fprintf(&b, "        app: %s\n", app)
+	writeTrackLabel(&b, "        ", track)
 	fmt.Fprintf(&b, "      annotations:\n")
 	fmt.Fprintf(&b, "        platform.example.com/ingress: %s\n", spec.NetworkPolicies.Ingress)
 	fmt.Fprintf(&b, "        platform.example.com/egress: %s\n", spec.NetworkPolicies.Egress)
+	writeExtensionAnnotations(&b, spec.Extensions)
 	fmt.Fprintf(&b, "    spec:\n")
+	fmt.Fprintf(&b, "      serviceAccountName: %s\n", projectServiceAccountName(spec))
 	fmt.Fprintf(&b, "      containers:\n")
 	fmt.Fprintf(&b, "      - name: app\n")
-	fmt.Fprintf(&b, "        image: app-image\n")
+	fmt.Fprintf(&b, "        image: %s\n", image)
 	fmt.Fprintf(&b, "        imagePullPolicy: IfNotPresent\n")
 	fmt.Fprintf(&b, "        ports:\n")
-	fmt.Fprintf(&b, "        - containerPort: 8080\n")
+	fmt.Fprintf(&b, "        - containerPort: %d\n", spec.Exposure.containerPort())
+	writeContainerResources(&b, "        ", spec.Resources)
 	return b.String()
 }
 
-func renderDeploymentEnvPatch(spec ProjectSpec, envName string) string {
+func writeTrackLabel(b *strings.Builder, indent string, track string) {
+	if track != "" {
+		fmt.Fprintf(b, "%s%s: %s\n", indent, deploymentTrackLabel, track)
+	}
+}
+
+// renderDeploymentEnvPatch renders an environment overlay's deployment
+// patch: the environment's vars plus whatever its capability plugins and
+// bindings add.
+func renderDeploymentEnvPatch(
+	spec ProjectSpec,
+	envName string,
+	bindings []CapabilityBinding,
+	storedSecrets []string,
+) string {
+	return renderDeploymentPatch(spec, envName, safeName(spec.Name), 0, bindings, storedSecrets)
+}
+
+// renderDeploymentPatch patches the named Deployment for envName. A
+// positive replicas replaces the environment's own count, as a canary's
+// split of it does.
+func renderDeploymentPatch(
+	spec ProjectSpec,
+	envName string,
+	name string,
+	replicas int,
+	bindings []CapabilityBinding,
+	storedSecrets []string,
+) string {
 	spec = normalizeProjectSpec(spec)
-	vars := environmentVarsFor(spec, envName)
-	name := safeName(spec.Name)
+	bindings = activeCapabilityBindings(spec, bindings)
+	vars, secretVars := boundAppEnv(
+		capabilityAppEnv(spec, envName, environmentVarsFor(spec, envName), bindings),
+		environmentSecretKeyRefs(spec, envName, storedSecrets),
+		bindings,
+	)
 	var b strings.Builder
 	fmt.Fprintf(&b, "apiVersion: apps/v1\n")
 	fmt.Fprintf(&b, "kind: Deployment\n")
 	fmt.Fprintf(&b, "metadata:\n")
 	fmt.Fprintf(&b, "  name: %s\n", name)
 	fmt.Fprintf(&b, "spec:\n")
+	switch envCfg := spec.Environments[envName]; {
+	case replicas > 0:
+		fmt.Fprintf(&b, "  replicas: %d\n", replicas)
+	case envCfg.Autoscaling.enabled():
+		// Dropping the field leaves the count to the HPA on every apply.
+		fmt.Fprintf(&b, "  replicas: null\n")
+	case envCfg.Replicas > 0:
+		fmt.Fprintf(&b, "  replicas: %d\n", envCfg.Replicas)
+	}
 	fmt.Fprintf(&b, "  template:\n")
 	fmt.Fprintf(&b, "    metadata:\n")
 	fmt.Fprintf(&b, "      annotations:\n")
@@ -154,21 +292,29 @@ func renderDeploymentEnvPatch(spec ProjectSpec, envName string) string {
 	fmt.Fprintf(&b, "      - name: app\n")
 	keys := sortedKeys(vars)
 	fmt.Fprintf(&b, "        env:\n")
-	if len(keys) == 0 {
+	if len(keys) == 0 && len(secretVars) == 0 {
 		fmt.Fprintf(&b, "        - name: PLATFORM_ENVIRONMENT\n")
 		fmt.Fprintf(&b, "          value: %s\n", yamlQuoted(envName))
-		return b.String()
 	}
 	for _, k := range keys {
 		fmt.Fprintf(&b, "        - name: %s\n", k)
 		fmt.Fprintf(&b, "          value: %s\n", yamlQuoted(vars[k]))
 	}
+	for _, k := range sortedKeys(secretVars) {
+		fmt.Fprintf(&b, "        - name: %s\n", k)
+		fmt.Fprintf(&b, "          valueFrom:\n")
+		fmt.Fprintf(&b, "            secretKeyRef:\n")
+		fmt.Fprintf(&b, "              name: %s\n", secretVars[k].Name)
+		fmt.Fprintf(&b, "              key: %s\n", yamlQuoted(secretVars[k].Key))
+	}
+	writeCapabilityContainers(&b, bindings)
 	return b.String()
 }
 
 func renderDeploymentManifest(spec ProjectSpec, image string) string {
 	spec = normalizeProjectSpec(spec)
 	envName, vars := preferredEnvironment(spec)
+	vars = capabilityAppEnv(spec, envName, vars, nil)
 	name := safeName(spec.Name)
 	var b strings.Builder
 	fmt.Fprintf(&b, "apiVersion: apps/v1\n")
@@ -176,7 +322,9 @@ func renderDeploymentManifest(spec ProjectSpec, image string) string {
 	fmt.Fprintf(&b, "metadata:\n")
 	fmt.Fprintf(&b, "  name: %s\n", name)
 	fmt.Fprintf(&b, "spec:\n")
-	fmt.Fprintf(&b, "  replicas: 1\n")
+	if !spec.Environments[envName].Autoscaling.enabled() {
+		fmt.Fprintf(&b, "  replicas: %d\n", spec.Environments[envName].replicas())
+	}
 	fmt.Fprintf(&b, "  selector:\n")
 	fmt.Fprintf(&b, "    matchLabels:\n")
 	fmt.Fprintf(&b, "      app: %s\n", name)
@@ -188,13 +336,16 @@ func renderDeploymentManifest(spec ProjectSpec, image string) string {
 	fmt.Fprintf(&b, "        platform.example.com/environment: %s\n", envName)
 	fmt.Fprintf(&b, "        platform.example.com/ingress: %s\n", spec.NetworkPolicies.Ingress)
 	fmt.Fprintf(&b, "        platform.example.com/egress: %s\n", spec.NetworkPolicies.Egress)
+	writeExtensionAnnotations(&b, spec.Extensions)
 	fmt.Fprintf(&b, "    spec:\n")
+	fmt.Fprintf(&b, "      serviceAccountName: %s\n", projectServiceAccountName(spec))
 	fmt.Fprintf(&b, "      containers:\n")
 	fmt.Fprintf(&b, "      - name: app\n")
 	fmt.Fprintf(&b, "        image: %s\n", image)
 	fmt.Fprintf(&b, "        imagePullPolicy: IfNotPresent\n")
 	fmt.Fprintf(&b, "        ports:\n")
-	fmt.Fprintf(&b, "        - containerPort: 8080\n")
+	fmt.Fprintf(&b, "        - containerPort: %d\n", spec.Exposure.containerPort())
+	writeContainerResources(&b, "        ", spec.Resources)
 	keys := sortedKeys(vars)
 	if len(keys) > 0 {
 		fmt.Fprintf(&b, "        env:\n")
@@ -214,13 +365,14 @@ kind: Service
 metadata:
   name: %s
 spec:
+  type: %s
   selector:
     app: %s
   ports:
   - name: http
-    port: 80
-    targetPort: 8080
-`, name, name)
+    port: %d
+    targetPort: %d
+`, name, spec.Exposure.serviceType(), name, spec.Exposure.port(), spec.Exposure.containerPort())
 }
 
 func renderKustomizedProjectManifests(
@@ -229,8 +381,10 @@ func renderKustomizedProjectManifests(
 ) (renderedProjectManifests, error) {
 	deployment := renderDeploymentManifest(spec, image)
 	service := renderServiceManifest(spec)
-	kustomization := renderKustomizationManifest()
-	renderedManifest, err := runKustomizeBuild(deployment, service, kustomization)
+	serviceAccount := renderServiceAccountManifest(spec)
+	envName, _ := preferredEnvironment(spec)
+	kustomization := renderKustomizationManifest(projectNamespace(spec, envName))
+	renderedManifest, err := runKustomizeBuild(deployment, service, serviceAccount, kustomization)
 	if err != nil {
 		return renderedProjectManifests{}, err
 	}
@@ -246,8 +400,8 @@ func renderKustomizedProjectManifests(
 	}, nil
 }
 
-func renderKustomizationManifest() string {
-	return renderBaseKustomizationManifest()
+func renderKustomizationManifest(namespace string) string {
+	return fmt.Sprintf("namespace: %s\n%s", namespace, renderBaseKustomizationManifest())
 }
 
 func renderBaseKustomizationManifest() string {
@@ -256,22 +410,69 @@ kind: Kustomization
 resources:
   - deployment.yaml
   - service.yaml
+  - serviceaccount.yaml
 `
 }
 
-func renderOverlayKustomizationManifest(image string) string {
+// renderOverlayKustomizationManifest renders env's overlay. The strategy
+// env runs adds its files: the canary Deployment and its patch, or the
+// green Deployment and the patches that color both Deployments and point
+// the Service at the active one. dependents are the projects that need this
+// one, which with its own needs decide whether the overlay has a
+// NetworkPolicy.
+func renderOverlayKustomizationManifest(
+	spec ProjectSpec,
+	env string,
+	image string,
+	strategy DeliveryStrategy,
+	bindings []CapabilityBinding,
+	dependents []string,
+) string {
 	name, tag := splitImageRef(image)
-	return fmt.Sprintf(`apiVersion: kustomize.config.k8s.io/v1beta1
-kind: Kustomization
-resources:
-  - ../../base
-patches:
-  - path: deployment-patch.yaml
-images:
+	var b strings.Builder
+	b.WriteString("apiVersion: kustomize.config.k8s.io/v1beta1\n")
+	b.WriteString("kind: Kustomization\n")
+	fmt.Fprintf(&b, "namespace: %s\n", projectNamespace(spec, env))
+	b.WriteString("resources:\n")
+	b.WriteString("  - ../../base\n")
+	if namespaceProvisioningEnabled() {
+		fmt.Fprintf(&b, "  - %s\n", manifestFileNamespace)
+	}
+	if normalizeProjectSpec(spec).Exposure.Ingress.Host != "" {
+		fmt.Fprintf(&b, "  - %s\n", overlayIngressFile)
+	}
+	if spec.Environments[env].Autoscaling.enabled() {
+		fmt.Fprintf(&b, "  - %s\n", overlayHPAFile)
+	}
+	if hasDependencyPolicy(normalizeProjectSpec(spec), dependents) {
+		fmt.Fprintf(&b, "  - %s\n", overlayNetworkPolicyFile)
+	}
+	for _, rendered := range renderCapabilities(spec, env, bindings) {
+		if rendered.manifest != "" {
+			fmt.Fprintf(&b, "  - %s\n", capabilityManifestFile(rendered.capability))
+		}
+	}
+	switch strategy {
+	case DeliveryStrategyCanary:
+		fmt.Fprintf(&b, "  - %s\n", canaryDeploymentFile)
+	case DeliveryStrategyBlueGreen:
+		fmt.Fprintf(&b, "  - %s\n", greenDeploymentFile)
+	}
+	b.WriteString("patches:\n  - path: deployment-patch.yaml\n")
+	switch strategy {
+	case DeliveryStrategyCanary:
+		fmt.Fprintf(&b, "  - path: %s\n", canaryPatchFile)
+	case DeliveryStrategyBlueGreen:
+		for _, patch := range []string{greenPatchFile, bluePatchFile, serviceSelectorPatchFile} {
+			fmt.Fprintf(&b, "  - path: %s\n", patch)
+		}
+	}
+	fmt.Fprintf(&b, `images:
   - name: app-image
     newName: %s
     newTag: %s
 `, name, tag)
+	return b.String()
 }
 
 func splitImageRef(image string) (string, string) {
@@ -298,6 +499,7 @@ func splitImageRef(image string) (string, string) {
 func runKustomizeBuild(
 	deployment,
 	service,
+	serviceAccount,
 	kustomization string,
 ) ([]byte, error) {
 	tempDir, err := os.MkdirTemp("", "platform-kustomize-")
@@ -314,6 +516,7 @@ func runKustomizeBuild(
 	}{
 		{name: manifestFileDeployment, data: deployment},
 		{name: manifestFileService, data: service},
+		{name: manifestFileServiceAccount, data: serviceAccount},
 		{name: manifestFileKustomization, data: kustomization},
 	}
 	for _, manifestFile := range manifestFiles {
@@ -342,32 +545,55 @@ func runKustomizeBuildAtPath(dir string) ([]byte, error) {
 	return renderedManifest, nil
 }
 
+// splitRenderedManifests returns the Service and the Deployment it sends
+// traffic to. Deployments on other tracks, such as a canary or an idle
+// blue/green color, and the resources of capability plugins stay in the
+// rendered manifests only.
 func splitRenderedManifests(renderedManifest []byte) (string, string, error) {
-	deployment := ""
+	docs := slices.DeleteFunc(splitManifestDocs(string(renderedManifest)), func(manifest string) bool {
+		return manifestCapability(manifest) != ""
+	})
 	service := ""
-	for _, manifest := range splitManifestDocs(string(renderedManifest)) {
-		switch manifestKind(manifest) {
-		case "Deployment":
-			if deployment != "" {
-				return "", "", errors.New("rendered manifests contain multiple deployments")
-			}
-			deployment = normalizeManifestOutput(manifest)
-		case "Service":
-			if service != "" {
-				return "", "", errors.New("rendered manifests contain multiple services")
-			}
-			service = normalizeManifestOutput(manifest)
+	for _, manifest := range docs {
+		if manifestKind(manifest) != "Service" {
+			continue
 		}
-	}
-	if deployment == "" {
-		return "", "", errors.New("rendered manifests missing deployment")
+		if service != "" {
+			return "", "", errors.New("rendered manifests contain multiple services")
+		}
+		service = normalizeManifestOutput(manifest)
 	}
 	if service == "" {
 		return "", "", errors.New("rendered manifests missing service")
 	}
+	served := manifestTrack(service)
+	deployment := ""
+	for _, manifest := range docs {
+		if manifestKind(manifest) != "Deployment" || manifestTrack(manifest) != served {
+			continue
+		}
+		if deployment != "" {
+			return "", "", errors.New("rendered manifests contain multiple deployments")
+		}
+		deployment = normalizeManifestOutput(manifest)
+	}
+	if deployment == "" {
+		return "", "", errors.New("rendered manifests missing deployment")
+	}
 	return deployment, service, nil
 }
 
+// manifestTrack returns the first track label in a rendered manifest: the
+// track of a Deployment's pods, or of the pods a Service selects.
+func manifestTrack(manifest string) string {
+	for line := range strings.SplitSeq(manifest, "\n") {
+		if track, ok := strings.CutPrefix(strings.TrimSpace(line), deploymentTrackLabel+":"); ok {
+			return strings.TrimSpace(track)
+		}
+	}
+	return ""
+}
+
 func manifestKind(manifest string) string {
 	for line := range strings.SplitSeq(manifest, "\n") {
 		trimmed := strings.TrimSpace(line)
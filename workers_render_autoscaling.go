@@ -0,0 +1,156 @@
+package platform
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Autoscaling: environments.<env>.autoscaling adds a HorizontalPodAutoscaler
+// to that environment's overlay and leaves the replica count to it. The
+// namespace quota is checked against maxReplicas, and the journey and
+// overview report each environment's scaling.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	defaultAutoscalingMinReplicas = 1
+	defaultAutoscalingTargetCPU   = 80
+	maxAutoscalingTargetCPU       = 100
+	overlayHPAFile                = "hpa.yaml"
+)
+
+func (c AutoscalingConfig) enabled() bool {
+	return c != (AutoscalingConfig{MinReplicas: 0, MaxReplicas: 0, TargetCPUUtilization: 0})
+}
+
+func (c AutoscalingConfig) minReplicas() int {
+	return cmp.Or(c.MinReplicas, defaultAutoscalingMinReplicas)
+}
+
+func (c AutoscalingConfig) targetCPUUtilization() int {
+	return cmp.Or(c.TargetCPUUtilization, defaultAutoscalingTargetCPU)
+}
+
+// floorReplicas is the fewest pods the environment runs.
+func (c EnvConfig) floorReplicas() int {
+	if c.Autoscaling.enabled() {
+		return c.Autoscaling.minReplicas()
+	}
+	return c.replicas()
+}
+
+// peakReplicas is the most pods the environment may run, which is what the
+// namespace quota has to hold.
+func (c EnvConfig) peakReplicas() int {
+	if c.Autoscaling.enabled() {
+		return c.Autoscaling.MaxReplicas
+	}
+	return c.replicas()
+}
+
+func validateAutoscaling(env string, cfg EnvConfig) error {
+	autoscaling := cfg.Autoscaling
+	if !autoscaling.enabled() {
+		return nil
+	}
+	field := "environments." + env + ".autoscaling"
+	if cfg.Replicas != 0 {
+		return specFieldErrorf("environments."+env+".replicas",
+			"environments.%s.replicas cannot be set with autoscaling; set %s.minReplicas instead", env, field)
+	}
+	if autoscaling.MinReplicas < 0 {
+		return specFieldErrorf(field+".minReplicas", "%s.minReplicas must not be negative", field)
+	}
+	if autoscaling.MaxReplicas < autoscaling.minReplicas() {
+		return specFieldErrorf(field+".maxReplicas", "%s.maxReplicas (%d) must be at least minReplicas (%d)",
+			field, autoscaling.MaxReplicas, autoscaling.minReplicas())
+	}
+	if autoscaling.TargetCPUUtilization < 0 || autoscaling.TargetCPUUtilization > maxAutoscalingTargetCPU {
+		return specFieldErrorf(field+".targetCPUUtilization", "%s.targetCPUUtilization must be between 1 and %d",
+			field, maxAutoscalingTargetCPU)
+	}
+	return nil
+}
+
+// renderHPAManifest renders env's HorizontalPodAutoscaler. Utilization is
+// measured against the container's CPU request, which the namespace
+// LimitRange fills in when the spec sets none.
+func renderHPAManifest(spec ProjectSpec, env string) string {
+	spec = normalizeProjectSpec(spec)
+	name := safeName(spec.Name)
+	autoscaling := spec.Environments[env].Autoscaling
+	var b strings.Builder
+	b.WriteString("apiVersion: autoscaling/v2\n")
+	b.WriteString("kind: HorizontalPodAutoscaler\n")
+	b.WriteString("metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	b.WriteString("  labels:\n")
+	fmt.Fprintf(&b, "    app: %s\n", name)
+	b.WriteString("spec:\n")
+	b.WriteString("  scaleTargetRef:\n")
+	b.WriteString("    apiVersion: apps/v1\n")
+	b.WriteString("    kind: Deployment\n")
+	fmt.Fprintf(&b, "    name: %s\n", name)
+	fmt.Fprintf(&b, "  minReplicas: %d\n", autoscaling.minReplicas())
+	fmt.Fprintf(&b, "  maxReplicas: %d\n", autoscaling.MaxReplicas)
+	b.WriteString("  metrics:\n")
+	b.WriteString("  - type: Resource\n")
+	b.WriteString("    resource:\n")
+	b.WriteString("      name: cpu\n")
+	b.WriteString("      target:\n")
+	b.WriteString("        type: Utilization\n")
+	fmt.Fprintf(&b, "        averageUtilization: %d\n", autoscaling.targetCPUUtilization())
+	return b.String()
+}
+
+// writeOverlayHPAs writes hpa.yaml into the overlay of each autoscaled
+// environment, and removes it from the others.
+func writeOverlayHPAs(artifacts ArtifactStore, projectID string, spec ProjectSpec, envs []string) ([]string, error) {
+	spec = normalizeProjectSpec(spec)
+	written := []string{}
+	for _, env := range envs {
+		rel := path.Join(manifestsRepoOverlaysDir, env, overlayHPAFile)
+		if !spec.Environments[env].Autoscaling.enabled() {
+			if _, err := artifacts.RemoveFiles(projectID, rel); err != nil {
+				return written, err
+			}
+			continue
+		}
+		artifactPath, err := artifacts.WriteFile(projectID, rel, []byte(renderHPAManifest(spec, env)))
+		if err != nil {
+			return written, err
+		}
+		written = append(written, artifactPath)
+	}
+	return written, nil
+}
+
+// environmentScaling is how an environment's pod count is set, as the
+// journey and overview report it. Replicas is the fixed count, or the
+// autoscaling floor.
+type environmentScaling struct {
+	Autoscaling          bool `json:"autoscaling"`
+	Replicas             int  `json:"replicas"`
+	MaxReplicas          int  `json:"max_replicas,omitempty"`
+	TargetCPUUtilization int  `json:"target_cpu_utilization,omitempty"`
+}
+
+func environmentScalingFor(spec ProjectSpec, env string) environmentScaling {
+	cfg := normalizeProjectSpec(spec).Environments[env]
+	if !cfg.Autoscaling.enabled() {
+		return environmentScaling{
+			Autoscaling:          false,
+			Replicas:             cfg.replicas(),
+			MaxReplicas:          0,
+			TargetCPUUtilization: 0,
+		}
+	}
+	return environmentScaling{
+		Autoscaling:          true,
+		Replicas:             cfg.Autoscaling.minReplicas(),
+		MaxReplicas:          cfg.Autoscaling.MaxReplicas,
+		TargetCPUUtilization: cfg.Autoscaling.targetCPUUtilization(),
+	}
+}
@@ -0,0 +1,104 @@
+//nolint:testpackage,exhaustruct // Autoscaling tests render overlays through the unexported writers.
+package platform
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateAutoscalingRejectsBadSettings(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		env   EnvConfig
+		field string
+	}{
+		{
+			env:   EnvConfig{Replicas: 2, Autoscaling: AutoscalingConfig{MaxReplicas: 4}},
+			field: "environments.prod.replicas",
+		},
+		{
+			env:   EnvConfig{Autoscaling: AutoscalingConfig{MinReplicas: 3}},
+			field: "environments.prod.autoscaling.maxReplicas",
+		},
+		{
+			env:   EnvConfig{Autoscaling: AutoscalingConfig{MaxReplicas: 4, TargetCPUUtilization: 150}},
+			field: "environments.prod.autoscaling.targetCPUUtilization",
+		},
+		// The quota has to hold the ceiling, not the floor.
+		{
+			env:   EnvConfig{Autoscaling: AutoscalingConfig{MinReplicas: 2, MaxReplicas: 12}},
+			field: "environments.prod.autoscaling.maxReplicas",
+		},
+	} {
+		spec := workerRuntimeSpec("scaled")
+		spec.Environments["prod"] = tc.env
+		err := validateResources(spec)
+		var fieldErr specFieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != tc.field {
+			t.Fatalf("validateResources(%+v): expected an error on %s, got %v", tc.env, tc.field, err)
+		}
+	}
+}
+
+func TestWriteKustomizeRepoFilesRendersHPA(t *testing.T) {
+	t.Parallel()
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-autoscaling"
+	spec := workerRuntimeSpec("scaled")
+	spec.Environments["prod"] = EnvConfig{
+		Vars:        map[string]string{"LOG_LEVEL": "warn"},
+		Autoscaling: AutoscalingConfig{MinReplicas: 2, MaxReplicas: 6, TargetCPUUtilization: 70},
+	}
+	if err := validateProjectSpec(spec); err != nil {
+		t.Fatalf("expected the spec to be valid: %v", err)
+	}
+	if _, err := writeKustomizeRepoFiles(artifacts, projectID, spec, map[string]string{}, nil, nil, nil); err != nil {
+		t.Fatalf("write manifests: %v", err)
+	}
+	prodOverlay := filepath.Join(artifacts.ProjectDir(projectID), manifestsRepoOverlaysDir, "prod")
+	rendered, err := runKustomizeBuildAtPath(prodOverlay)
+	if err != nil {
+		t.Fatalf("build prod overlay: %v", err)
+	}
+	for _, want := range []string{
+		"kind: HorizontalPodAutoscaler",
+		"namespace: scaled-prod",
+		"minReplicas: 2",
+		"maxReplicas: 6",
+		"averageUtilization: 70",
+	} {
+		if !strings.Contains(string(rendered), want) {
+			t.Fatalf("prod overlay missing %q:\n%s", want, rendered)
+		}
+	}
+	if strings.Contains(string(rendered), "\n  replicas:") {
+		t.Fatalf("an autoscaled Deployment must leave replicas to the HPA:\n%s", rendered)
+	}
+	dev, err := runKustomizeBuildAtPath(filepath.Join(artifacts.ProjectDir(projectID), manifestsRepoOverlaysDir, "dev"))
+	if err != nil || strings.Contains(string(dev), "HorizontalPodAutoscaler") {
+		t.Fatalf("expected dev to build without an HPA, got err=%v\n%s", err, dev)
+	}
+	scaling := environmentScalingFor(spec, "prod")
+	if !scaling.Autoscaling || scaling.Replicas != 2 || scaling.MaxReplicas != 6 || scaling.TargetCPUUtilization != 70 {
+		t.Fatalf("unexpected prod scaling: %+v", scaling)
+	}
+	if got := specLintRules(lintProjectSpec(spec)); got != "" {
+		t.Fatalf("expected an autoscaling floor of two to satisfy the lint, got %s", got)
+	}
+
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{"LOG_LEVEL": "warn"}}
+	if _, err = writeKustomizeRepoFiles(artifacts, projectID, spec, map[string]string{}, nil, nil, nil); err != nil {
+		t.Fatalf("write manifests without autoscaling: %v", err)
+	}
+	if _, err = artifacts.ReadFile(projectID, "repos/manifests/overlays/prod/hpa.yaml"); err == nil {
+		t.Fatal("expected the overlay HPA to be removed once autoscaling is cleared")
+	}
+	rendered, err = runKustomizeBuildAtPath(prodOverlay)
+	if err != nil || !strings.Contains(string(rendered), "replicas: 1") {
+		t.Fatalf("expected prod back on one replica, got err=%v\n%s", err, rendered)
+	}
+}
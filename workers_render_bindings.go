@@ -0,0 +1,131 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const (
+	capabilityContainerPrefix = "capability-"
+	maxBindingImageLength     = 512
+	maxSecretNameLength       = 253
+)
+
+// validateCapabilityBinding checks binding against the project it binds
+// into: the environment and capability must both be declared by spec.
+func validateCapabilityBinding(spec ProjectSpec, binding CapabilityBinding) error {
+	if _, ok := spec.Environments[binding.Environment]; !ok {
+		return fmt.Errorf("environment %q is not defined for project", binding.Environment)
+	}
+	if !slices.Contains(spec.Capabilities, binding.Capability) {
+		return fmt.Errorf("capability %q is not declared by the project spec", binding.Capability)
+	}
+	switch binding.Type {
+	case CapabilityBindingContainer:
+		if binding.Image == "" {
+			return errors.New("container bindings require image")
+		}
+		if len(binding.Image) > maxBindingImageLength || strings.ContainsAny(binding.Image, " \t\r\n") {
+			return fmt.Errorf("invalid image %q", binding.Image)
+		}
+	case CapabilityBindingExternal:
+		if binding.Image != "" || len(binding.ContainerEnv) > 0 {
+			return errors.New("image and container_env apply only to container bindings")
+		}
+	default:
+		return fmt.Errorf("type must be %q or %q", CapabilityBindingContainer, CapabilityBindingExternal)
+	}
+	if err := validateEnvironmentVars("env", "env", binding.Env); err != nil {
+		return err
+	}
+	if err := validateEnvironmentVars("container_env", "container_env", binding.ContainerEnv); err != nil {
+		return err
+	}
+	for name, ref := range binding.SecretEnv {
+		if len(name) > 128 || !envVarNameRe.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q in %q", name, "secret_env")
+		}
+		if _, plain := binding.Env[name]; plain {
+			return fmt.Errorf("%q is set in both env and secret_env", name)
+		}
+		if len(ref.Name) > maxSecretNameLength || !projectNameRe.MatchString(ref.Name) {
+			return fmt.Errorf("secret_env %q: invalid secret name %q", name, ref.Name)
+		}
+		if ref.Key == "" || !secretKeyRe.MatchString(ref.Key) {
+			return fmt.Errorf("secret_env %q: invalid secret key %q", name, ref.Key)
+		}
+	}
+	return nil
+}
+
+// activeCapabilityBindings drops bindings for capabilities the spec no
+// longer declares; they stay stored and apply again if it is re-added.
+func activeCapabilityBindings(spec ProjectSpec, bindings []CapabilityBinding) []CapabilityBinding {
+	out := make([]CapabilityBinding, 0, len(bindings))
+	for _, binding := range bindings {
+		if slices.Contains(spec.Capabilities, binding.Capability) {
+			out = append(out, binding)
+		}
+	}
+	return out
+}
+
+// boundAppEnv overlays the environment's vars and spec secrets with each
+// binding's vars, in capability order. A spec secret wins over a shared var
+// of the same name and a binding's var wins over both; secret-backed vars
+// are returned apart so they render as secretKeyRefs.
+func boundAppEnv(
+	vars map[string]string,
+	secrets map[string]SecretKeyRef,
+	bindings []CapabilityBinding,
+) (map[string]string, map[string]SecretKeyRef) {
+	plain := maps.Clone(vars)
+	if plain == nil {
+		plain = map[string]string{}
+	}
+	secret := map[string]SecretKeyRef{}
+	for name, ref := range secrets {
+		delete(plain, name)
+		secret[name] = ref
+	}
+	for _, binding := range bindings {
+		for name, value := range binding.Env {
+			delete(secret, name)
+			plain[name] = value
+		}
+		for name, ref := range binding.SecretEnv {
+			delete(plain, name)
+			secret[name] = ref
+		}
+	}
+	return plain, secret
+}
+
+func capabilityContainerName(capability string) string {
+	return capabilityContainerPrefix + safeName(capability)
+}
+
+// writeCapabilityContainers adds one container per container binding to a
+// containers: list; kustomize merges containers by name, so they land next
+// to the app container.
+func writeCapabilityContainers(b *strings.Builder, bindings []CapabilityBinding) {
+	for _, binding := range bindings {
+		if binding.Type != CapabilityBindingContainer {
+			continue
+		}
+		fmt.Fprintf(b, "      - name: %s\n", capabilityContainerName(binding.Capability))
+		fmt.Fprintf(b, "        image: %s\n", binding.Image)
+		keys := sortedKeys(binding.ContainerEnv)
+		if len(keys) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "        env:\n")
+		for _, k := range keys {
+			fmt.Fprintf(b, "        - name: %s\n", k)
+			fmt.Fprintf(b, "          value: %s\n", yamlQuoted(binding.ContainerEnv[k]))
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package platform
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Exposure: spec.exposure sets the Service type and ports, and an ingress host
+// adds an Ingress to every environment overlay. Left out, the app keeps the
+// ClusterIP Service on port 80 in front of container port 8080. The overview
+// reports the URL each environment answers on.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	serviceTypeClusterIP    = "ClusterIP"
+	serviceTypeNodePort     = "NodePort"
+	serviceTypeLoadBalancer = "LoadBalancer"
+
+	defaultServicePort    = 80
+	defaultContainerPort  = 8080
+	maxNetworkPort        = 65535
+	defaultIngressPath    = "/"
+	maxIngressHostLength  = 253
+	maxIngressPathLength  = 256
+	ingressEnvPlaceholder = "{env}"
+	overlayIngressFile    = "ingress.yaml"
+
+	exposureURLScopeIngress = "ingress"
+	exposureURLScopeCluster = "cluster"
+)
+
+var ingressHostRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)+$`)
+
+func (c ExposureConfig) serviceType() string {
+	return cmp.Or(c.ServiceType, serviceTypeClusterIP)
+}
+
+func (c ExposureConfig) port() int {
+	return cmp.Or(c.Port, defaultServicePort)
+}
+
+func (c ExposureConfig) containerPort() int {
+	return cmp.Or(c.ContainerPort, defaultContainerPort)
+}
+
+func (c IngressConfig) path() string {
+	return cmp.Or(c.Path, defaultIngressPath)
+}
+
+// hostFor is the ingress host of env, or "" when the spec sets none.
+func (c IngressConfig) hostFor(env string) string {
+	return strings.ReplaceAll(c.Host, ingressEnvPlaceholder, strings.Trim(safeName(env), "-"))
+}
+
+func validateExposure(exposure ExposureConfig) error {
+	switch exposure.ServiceType {
+	case "", serviceTypeClusterIP, serviceTypeNodePort, serviceTypeLoadBalancer:
+	default:
+		return specFieldErrorf("exposure.serviceType", "exposure.serviceType must be %s, %s, or %s",
+			serviceTypeClusterIP, serviceTypeNodePort, serviceTypeLoadBalancer)
+	}
+	if exposure.Port < 0 || exposure.Port > maxNetworkPort {
+		return specFieldErrorf("exposure.port", "exposure.port must be between 1 and %d", maxNetworkPort)
+	}
+	if exposure.ContainerPort < 0 || exposure.ContainerPort > maxNetworkPort {
+		return specFieldErrorf("exposure.containerPort", "exposure.containerPort must be between 1 and %d",
+			maxNetworkPort)
+	}
+	ingress := exposure.Ingress
+	if ingress.Host == "" {
+		if ingress.Path != "" || ingress.TLS {
+			return specFieldErrorf("exposure.ingress.host", "exposure.ingress.path and tls need a host")
+		}
+		return nil
+	}
+	probe := strings.ReplaceAll(ingress.Host, ingressEnvPlaceholder, "env")
+	if len(probe) > maxIngressHostLength || !ingressHostRe.MatchString(probe) {
+		return specFieldErrorf("exposure.ingress.host",
+			"exposure.ingress.host %q must be a DNS name, optionally with %s", ingress.Host, ingressEnvPlaceholder)
+	}
+	if ingress.Path != "" && (!strings.HasPrefix(ingress.Path, "/") || len(ingress.Path) > maxIngressPathLength ||
+		strings.ContainsAny(ingress.Path, " \t\r\n?#")) {
+		return specFieldErrorf("exposure.ingress.path", "exposure.ingress.path %q must be a URL path", ingress.Path)
+	}
+	return nil
+}
+
+func ingressTLSSecretName(spec ProjectSpec) string {
+	return safeName(spec.Name) + "-tls"
+}
+
+// renderIngressManifest renders env's Ingress. TLS expects a certificate in
+// the <name>-tls Secret, which the platform does not issue.
+func renderIngressManifest(spec ProjectSpec, env string) string {
+	spec = normalizeProjectSpec(spec)
+	name := safeName(spec.Name)
+	ingress := spec.Exposure.Ingress
+	host := ingress.hostFor(env)
+	var b strings.Builder
+	b.WriteString("apiVersion: networking.k8s.io/v1\n")
+	b.WriteString("kind: Ingress\n")
+	b.WriteString("metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	b.WriteString("  labels:\n")
+	fmt.Fprintf(&b, "    app: %s\n", name)
+	b.WriteString("spec:\n")
+	if ingress.TLS {
+		b.WriteString("  tls:\n")
+		b.WriteString("  - hosts:\n")
+		fmt.Fprintf(&b, "    - %s\n", host)
+		fmt.Fprintf(&b, "    secretName: %s\n", ingressTLSSecretName(spec))
+	}
+	b.WriteString("  rules:\n")
+	fmt.Fprintf(&b, "  - host: %s\n", host)
+	b.WriteString("    http:\n")
+	b.WriteString("      paths:\n")
+	fmt.Fprintf(&b, "      - path: %s\n", yamlQuoted(ingress.path()))
+	b.WriteString("        pathType: Prefix\n")
+	b.WriteString("        backend:\n")
+	b.WriteString("          service:\n")
+	fmt.Fprintf(&b, "            name: %s\n", name)
+	b.WriteString("            port:\n")
+	b.WriteString("              name: http\n")
+	return b.String()
+}
+
+// writeOverlayIngresses writes ingress.yaml into each environment overlay
+// when the spec has an ingress host, and removes earlier ones when not.
+func writeOverlayIngresses(
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	envs []string,
+) ([]string, error) {
+	spec = normalizeProjectSpec(spec)
+	written := []string{}
+	for _, env := range envs {
+		rel := path.Join(manifestsRepoOverlaysDir, env, overlayIngressFile)
+		if spec.Exposure.Ingress.Host == "" {
+			if _, err := artifacts.RemoveFiles(projectID, rel); err != nil {
+				return written, err
+			}
+			continue
+		}
+		artifactPath, err := artifacts.WriteFile(projectID, rel, []byte(renderIngressManifest(spec, env)))
+		if err != nil {
+			return written, err
+		}
+		written = append(written, artifactPath)
+	}
+	return written, nil
+}
+
+// environmentURL is where env's app answers and how far that reaches: the
+// ingress URL when the spec sets a host, otherwise the Service's in-cluster
+// address. NodePort and LoadBalancer addresses are only known to the cluster.
+func environmentURL(spec ProjectSpec, env string) (string, string) {
+	spec = normalizeProjectSpec(spec)
+	exposure := spec.Exposure
+	if exposure.Ingress.Host != "" {
+		scheme := "http"
+		if exposure.Ingress.TLS {
+			scheme = "https"
+		}
+		return scheme + "://" + exposure.Ingress.hostFor(env) + exposure.Ingress.path(), exposureURLScopeIngress
+	}
+	host := safeName(spec.Name) + "." + projectNamespace(spec, env) + ".svc.cluster.local"
+	if port := exposure.port(); port != defaultServicePort {
+		host = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	return "http://" + host + "/", exposureURLScopeCluster
+}
@@ -0,0 +1,96 @@
+//nolint:testpackage,exhaustruct // Exposure tests render overlays through the unexported writers.
+package platform
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateExposureRejectsBadSettings(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		exposure ExposureConfig
+		field    string
+	}{
+		{exposure: ExposureConfig{ServiceType: "ExternalName"}, field: "exposure.serviceType"},
+		{exposure: ExposureConfig{Port: 70000}, field: "exposure.port"},
+		{exposure: ExposureConfig{ContainerPort: -1}, field: "exposure.containerPort"},
+		{exposure: ExposureConfig{Ingress: IngressConfig{TLS: true}}, field: "exposure.ingress.host"},
+		{exposure: ExposureConfig{Ingress: IngressConfig{Host: "localhost"}}, field: "exposure.ingress.host"},
+		{exposure: ExposureConfig{Ingress: IngressConfig{Host: "*.example.com"}}, field: "exposure.ingress.host"},
+		{
+			exposure: ExposureConfig{Ingress: IngressConfig{Host: "app.example.com", Path: "api"}},
+			field:    "exposure.ingress.path",
+		},
+	} {
+		err := validateExposure(tc.exposure)
+		var fieldErr specFieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != tc.field {
+			t.Fatalf("validateExposure(%+v): expected an error on %s, got %v", tc.exposure, tc.field, err)
+		}
+	}
+	valid := ExposureConfig{
+		ServiceType: serviceTypeLoadBalancer, Port: 443, ContainerPort: 3000,
+		Ingress: IngressConfig{Host: "{env}.shop.example.com", Path: "/api", TLS: true},
+	}
+	if err := validateExposure(valid); err != nil {
+		t.Fatalf("expected %+v to be valid, got %v", valid, err)
+	}
+}
+
+func TestWriteKustomizeRepoFilesRendersExposure(t *testing.T) {
+	t.Parallel()
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-exposure"
+	spec := workerRuntimeSpec("shop")
+	spec.Environments["staging"] = EnvConfig{Vars: map[string]string{"LOG_LEVEL": "warn"}}
+	spec.Exposure = ExposureConfig{
+		ServiceType: serviceTypeNodePort, Port: 8081, ContainerPort: 3000,
+		Ingress: IngressConfig{Host: "{env}.shop.example.com", TLS: true},
+	}
+	if _, err := writeKustomizeRepoFiles(artifacts, projectID, spec, map[string]string{}, nil, nil, nil); err != nil {
+		t.Fatalf("write manifests: %v", err)
+	}
+	overlay := filepath.Join(artifacts.ProjectDir(projectID), manifestsRepoOverlaysDir, "staging")
+	rendered, err := runKustomizeBuildAtPath(overlay)
+	if err != nil {
+		t.Fatalf("build staging overlay: %v", err)
+	}
+	for _, want := range []string{
+		"type: NodePort",
+		"port: 8081",
+		"targetPort: 3000",
+		"containerPort: 3000",
+		"kind: Ingress",
+		"namespace: shop-staging",
+		"- host: staging.shop.example.com",
+		"secretName: shop-tls",
+	} {
+		if !strings.Contains(string(rendered), want) {
+			t.Fatalf("staging overlay missing %q:\n%s", want, rendered)
+		}
+	}
+	if url, scope := environmentURL(spec, "staging"); url != "https://staging.shop.example.com/" ||
+		scope != exposureURLScopeIngress {
+		t.Fatalf("unexpected staging url %q (%s)", url, scope)
+	}
+
+	spec.Exposure.Ingress = IngressConfig{}
+	if _, err = writeKustomizeRepoFiles(artifacts, projectID, spec, map[string]string{}, nil, nil, nil); err != nil {
+		t.Fatalf("write manifests without ingress: %v", err)
+	}
+	if _, err = artifacts.ReadFile(projectID, "repos/manifests/overlays/staging/ingress.yaml"); err == nil {
+		t.Fatal("expected the overlay ingress to be removed once the host is cleared")
+	}
+	if rendered, err = runKustomizeBuildAtPath(overlay); err != nil || strings.Contains(string(rendered), "Ingress") {
+		t.Fatalf("expected the overlay to build without an ingress, got err=%v\n%s", err, rendered)
+	}
+	if url, scope := environmentURL(spec, "staging"); url != "http://shop.shop-staging.svc.cluster.local:8081/" ||
+		scope != exposureURLScopeCluster {
+		t.Fatalf("unexpected in-cluster staging url %q (%s)", url, scope)
+	}
+}
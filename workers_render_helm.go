@@ -0,0 +1,350 @@
+package platform
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Helm chart output: a project with helm.enabled also gets a chart, written
+// to deploy/chart/ and committed to the manifests repo under chart/. The
+// templates are the same for every project; values.yaml carries the spec,
+// with one entry per environment under environments, so
+//
+//	helm install my-app deploy/chart --set environment=staging
+//
+// installs what the staging overlay renders, minus capability sidecars and
+// the resources capability plugins add; the app still gets their env vars.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	helmChartArtifactDir = "deploy/chart"
+	helmChartRepoDir     = "repos/manifests/chart"
+	helmChartVersion     = "0.1.0"
+)
+
+const helmDeploymentTemplate = `{{- $env := required ` +
+	`(printf "environments.%s is not in values.yaml" .Values.environment) ` +
+	`(index .Values.environments .Values.environment) }}
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Values.name }}
+  labels:
+    app: {{ .Values.name }}
+spec:
+  {{- if not $env.autoscaling }}
+  replicas: {{ $env.replicas }}
+  {{- end }}
+  selector:
+    matchLabels:
+      app: {{ .Values.name }}
+  template:
+    metadata:
+      labels:
+        app: {{ .Values.name }}
+      annotations:
+        platform.example.com/environment: {{ .Values.environment | quote }}
+        {{- range $key, $value := .Values.podAnnotations }}
+        {{ $key }}: {{ $value | quote }}
+        {{- end }}
+    spec:
+      serviceAccountName: {{ .Values.serviceAccount.name }}
+      containers:
+      - name: app
+        image: "{{ $env.image.repository }}:{{ $env.image.tag }}"
+        imagePullPolicy: {{ .Values.image.pullPolicy }}
+        ports:
+        - containerPort: {{ .Values.containerPort }}
+        {{- with .Values.resources }}
+        resources:
+          {{- range $kind, $amounts := . }}
+          {{ $kind }}:
+            {{- range $name, $amount := $amounts }}
+            {{ $name }}: {{ $amount | quote }}
+            {{- end }}
+          {{- end }}
+        {{- end }}
+        env:
+        {{- if not (or $env.env $env.secretEnv) }}
+        - name: PLATFORM_ENVIRONMENT
+          value: {{ .Values.environment | quote }}
+        {{- end }}
+        {{- range $name, $value := $env.env }}
+        - name: {{ $name }}
+          value: {{ $value | quote }}
+        {{- end }}
+        {{- range $name, $ref := $env.secretEnv }}
+        - name: {{ $name }}
+          valueFrom:
+            secretKeyRef:
+              name: {{ $ref.name }}
+              key: {{ $ref.key | quote }}
+        {{- end }}
+`
+
+const helmServiceTemplate = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Values.name }}
+spec:
+  type: {{ .Values.service.type }}
+  selector:
+    app: {{ .Values.name }}
+  ports:
+  - name: http
+    port: {{ .Values.service.port }}
+    targetPort: {{ .Values.containerPort }}
+`
+
+const helmIngressTemplate = `{{- $env := index .Values.environments .Values.environment }}
+{{- if and $env $env.ingressHost }}
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ .Values.name }}
+  labels:
+    app: {{ .Values.name }}
+spec:
+  {{- if .Values.ingress.tls }}
+  tls:
+  - hosts:
+    - {{ $env.ingressHost }}
+    secretName: {{ .Values.ingress.tlsSecretName }}
+  {{- end }}
+  rules:
+  - host: {{ $env.ingressHost }}
+    http:
+      paths:
+      - path: {{ .Values.ingress.path | quote }}
+        pathType: Prefix
+        backend:
+          service:
+            name: {{ .Values.name }}
+            port:
+              name: http
+{{- end }}
+`
+
+const helmHPATemplate = `{{- $env := index .Values.environments .Values.environment }}
+{{- if and $env $env.autoscaling }}
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{ .Values.name }}
+  labels:
+    app: {{ .Values.name }}
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{ .Values.name }}
+  minReplicas: {{ $env.autoscaling.minReplicas }}
+  maxReplicas: {{ $env.autoscaling.maxReplicas }}
+  metrics:
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: Utilization
+        averageUtilization: {{ $env.autoscaling.targetCPUUtilization }}
+{{- end }}
+`
+
+const helmServiceAccountTemplate = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{ .Values.serviceAccount.name }}
+  {{- with .Values.serviceAccount.annotations }}
+  annotations:
+    {{- range $key, $value := . }}
+    {{ $key }}: {{ $value | quote }}
+    {{- end }}
+  {{- end }}
+`
+
+// helmChartFiles renders the chart, keyed by path inside the chart.
+func helmChartFiles(
+	spec ProjectSpec,
+	imageByEnv map[string]string,
+	bindings envCapabilityBindings,
+	storedSecrets envStoredSecrets,
+) map[string]string {
+	spec = normalizeProjectSpec(spec)
+	defaultImage := helmEnvImage(spec, imageByEnv, defaultDeployEnvironment)
+	return map[string]string{
+		"Chart.yaml":                    renderHelmChartYAML(spec, defaultImage),
+		"values.yaml":                   renderHelmValues(spec, imageByEnv, bindings, storedSecrets),
+		"templates/deployment.yaml":     helmDeploymentTemplate,
+		"templates/service.yaml":        helmServiceTemplate,
+		"templates/ingress.yaml":        helmIngressTemplate,
+		"templates/hpa.yaml":            helmHPATemplate,
+		"templates/serviceaccount.yaml": helmServiceAccountTemplate,
+		"templates/NOTES.txt":           renderHelmNotes(spec),
+	}
+}
+
+func renderHelmChartYAML(spec ProjectSpec, image string) string {
+	_, tag := splitImageRef(image)
+	var b strings.Builder
+	b.WriteString("apiVersion: v2\n")
+	fmt.Fprintf(&b, "name: %s\n", safeName(spec.Name))
+	fmt.Fprintf(&b, "description: %s\n", yamlQuoted(spec.Name+", rendered by the platform from its project spec"))
+	b.WriteString("type: application\n")
+	fmt.Fprintf(&b, "version: %s\n", helmChartVersion)
+	fmt.Fprintf(&b, "appVersion: %s\n", yamlQuoted(tag))
+	return b.String()
+}
+
+// renderHelmValues maps the spec into values: shared settings at the top,
+// and each environment's image, ingress host, plain vars, and secret-backed
+// vars (after capability bindings) under environments.<env>.
+func renderHelmValues(
+	spec ProjectSpec,
+	imageByEnv map[string]string,
+	bindings envCapabilityBindings,
+	storedSecrets envStoredSecrets,
+) string {
+	name := safeName(spec.Name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Rendered by the platform from the %s project spec; edits are overwritten.\n", name)
+	fmt.Fprintf(&b, "# Pick an environment at install time: --set environment=<name>.\n")
+	fmt.Fprintf(&b, "name: %s\n", name)
+	fmt.Fprintf(&b, "environment: %s\n", defaultDeployEnvironment)
+	fmt.Fprintf(&b, "containerPort: %d\n", spec.Exposure.containerPort())
+	if spec.Resources == (ResourceConfig{}) {
+		b.WriteString("resources: {}\n")
+	} else {
+		writeContainerResources(&b, "", spec.Resources)
+	}
+	b.WriteString("image:\n  pullPolicy: IfNotPresent\n")
+	fmt.Fprintf(&b, "service:\n  type: %s\n  port: %d\n", spec.Exposure.serviceType(), spec.Exposure.port())
+	b.WriteString("ingress:\n")
+	fmt.Fprintf(&b, "  path: %s\n", yamlQuoted(spec.Exposure.Ingress.path()))
+	fmt.Fprintf(&b, "  tls: %t\n", spec.Exposure.Ingress.TLS)
+	fmt.Fprintf(&b, "  tlsSecretName: %s\n", ingressTLSSecretName(spec))
+	b.WriteString("serviceAccount:\n")
+	fmt.Fprintf(&b, "  name: %s\n", projectServiceAccountName(spec))
+	writeHelmStringMap(&b, "  ", "annotations", serviceAccountAnnotationsFromEnv(spec.Name))
+	podAnnotations := map[string]string{
+		"platform.example.com/ingress": spec.NetworkPolicies.Ingress,
+		"platform.example.com/egress":  spec.NetworkPolicies.Egress,
+	}
+	for key, value := range spec.Extensions {
+		podAnnotations[extensionAnnotationPrefix+key] = extensionAnnotationValue(value)
+	}
+	writeHelmStringMap(&b, "", "podAnnotations", podAnnotations)
+	b.WriteString("environments:\n")
+	for _, env := range desiredManifestEnvironments(spec) {
+		image := helmEnvImage(spec, imageByEnv, env)
+		writeHelmEnvironmentValues(&b, spec, env, image, bindings[env], storedSecrets[env])
+	}
+	return b.String()
+}
+
+// writeHelmEnvironmentValues writes environments.<env>: the values the
+// environment's overlay patch renders from.
+func writeHelmEnvironmentValues(
+	b *strings.Builder,
+	spec ProjectSpec,
+	env, image string,
+	bindings []CapabilityBinding,
+	storedSecrets []string,
+) {
+	bindings = activeCapabilityBindings(spec, bindings)
+	vars, secretVars := boundAppEnv(
+		capabilityAppEnv(spec, env, environmentVarsFor(spec, env), bindings),
+		environmentSecretKeyRefs(spec, env, storedSecrets),
+		bindings,
+	)
+	repository, tag := splitImageRef(image)
+	fmt.Fprintf(b, "  %s:\n", env)
+	if autoscaling := spec.Environments[env].Autoscaling; autoscaling.enabled() {
+		b.WriteString("    autoscaling:\n")
+		fmt.Fprintf(b, "      minReplicas: %d\n", autoscaling.minReplicas())
+		fmt.Fprintf(b, "      maxReplicas: %d\n", autoscaling.MaxReplicas)
+		fmt.Fprintf(b, "      targetCPUUtilization: %d\n", autoscaling.targetCPUUtilization())
+	} else {
+		fmt.Fprintf(b, "    replicas: %d\n", spec.Environments[env].replicas())
+	}
+	b.WriteString("    image:\n")
+	fmt.Fprintf(b, "      repository: %s\n", yamlQuoted(repository))
+	fmt.Fprintf(b, "      tag: %s\n", yamlQuoted(tag))
+	if host := spec.Exposure.Ingress.hostFor(env); host != "" {
+		fmt.Fprintf(b, "    ingressHost: %s\n", yamlQuoted(host))
+	}
+	writeHelmStringMap(b, "    ", "env", vars)
+	if len(secretVars) == 0 {
+		b.WriteString("    secretEnv: {}\n")
+		return
+	}
+	b.WriteString("    secretEnv:\n")
+	for _, key := range sortedKeys(secretVars) {
+		fmt.Fprintf(b, "      %s:\n", key)
+		fmt.Fprintf(b, "        name: %s\n", yamlQuoted(secretVars[key].Name))
+		fmt.Fprintf(b, "        key: %s\n", yamlQuoted(secretVars[key].Key))
+	}
+}
+
+func renderHelmNotes(spec ProjectSpec) string {
+	envs := desiredManifestEnvironments(spec)
+	return fmt.Sprintf(
+		"%s is installed for the {{ .Values.environment }} environment.\n"+
+			"Environments in this chart: %s.\n",
+		safeName(spec.Name), strings.Join(envs, ", "),
+	)
+}
+
+// writeHelmStringMap writes key: followed by m as quoted string pairs, or
+// key: {} when m is empty.
+func writeHelmStringMap(b *strings.Builder, indent, key string, m map[string]string) {
+	if len(m) == 0 {
+		fmt.Fprintf(b, "%s%s: {}\n", indent, key)
+		return
+	}
+	fmt.Fprintf(b, "%s%s:\n", indent, key)
+	for _, k := range sortedKeys(m) {
+		fmt.Fprintf(b, "%s  %s: %s\n", indent, yamlQuoted(k), yamlQuoted(m[k]))
+	}
+}
+
+func helmEnvImage(spec ProjectSpec, imageByEnv map[string]string, env string) string {
+	if image := strings.TrimSpace(imageByEnv[env]); image != "" {
+		return image
+	}
+	return defaultManifestImage(spec)
+}
+
+// writeHelmChart writes the chart under both chart directories when the
+// spec asks for one, and removes any earlier chart when it does not.
+func writeHelmChart(
+	artifacts ArtifactStore,
+	projectID string,
+	spec ProjectSpec,
+	imageByEnv map[string]string,
+	bindings envCapabilityBindings,
+	storedSecrets envStoredSecrets,
+) ([]string, error) {
+	dirs := []string{helmChartArtifactDir, helmChartRepoDir}
+	if !normalizeProjectSpec(spec).Helm.Enabled {
+		for _, dir := range dirs {
+			if _, err := artifacts.RemoveFiles(projectID, dir); err != nil {
+				return nil, err
+			}
+		}
+		return []string{}, nil
+	}
+	files := helmChartFiles(spec, imageByEnv, bindings, storedSecrets)
+	written := make([]string, 0, len(dirs)*len(files))
+	for _, dir := range dirs {
+		for _, rel := range sortedKeys(files) {
+			artifactPath, err := artifacts.WriteFile(projectID, path.Join(dir, rel), []byte(files[rel]))
+			if err != nil {
+				return written, err
+			}
+			written = append(written, artifactPath)
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,150 @@
+//nolint:testpackage,exhaustruct // Chart tests execute the unexported templates against rendered values.
+package platform
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// executeHelmTemplate runs a chart template the way helm would for the
+// functions the chart uses.
+func executeHelmTemplate(t *testing.T, name, text string, values map[string]any) string {
+	t.Helper()
+	funcs := template.FuncMap{
+		"quote": func(v any) string { return fmt.Sprintf("%q", fmt.Sprint(v)) },
+		"required": func(msg string, v any) (any, error) {
+			if v == nil {
+				return nil, fmt.Errorf("%s", msg)
+			}
+			return v, nil
+		},
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		t.Fatalf("parse %s: %v", name, err)
+	}
+	var out strings.Builder
+	if err = tmpl.Execute(&out, map[string]any{"Values": values}); err != nil {
+		t.Fatalf("execute %s: %v", name, err)
+	}
+	var doc map[string]any
+	if err = yaml.Unmarshal([]byte(out.String()), &doc); err != nil {
+		t.Fatalf("%s renders invalid yaml: %v\n%s", name, err, out.String())
+	}
+	return out.String()
+}
+
+func TestHelmChartValuesRenderEachEnvironment(t *testing.T) {
+	t.Parallel()
+
+	spec := workerRuntimeSpec("chart-app")
+	spec.Helm.Enabled = true
+	spec.Exposure.Ingress.Host = "{env}.chart.example.com"
+	spec.Environments["staging"] = EnvConfig{
+		Vars:     map[string]string{"LOG_LEVEL": "warn"},
+		Secrets:  map[string]string{"DB_PASSWORD": "vault://apps/chart#db"},
+		Replicas: 2,
+	}
+	spec.Resources.Limits = ResourceAmounts{CPU: "250m", Memory: "256Mi"}
+	imageByEnv := map[string]string{"staging": "registry.local/chart-app:abc123"}
+	files := helmChartFiles(spec, imageByEnv, nil, nil)
+
+	var values map[string]any
+	if err := yaml.Unmarshal([]byte(files["values.yaml"]), &values); err != nil {
+		t.Fatalf("values.yaml is invalid yaml: %v\n%s", err, files["values.yaml"])
+	}
+	values["environment"] = "staging"
+	deployment := executeHelmTemplate(t, "deployment", files["templates/deployment.yaml"], values)
+	for _, want := range []string{
+		`image: "registry.local/chart-app:abc123"`,
+		"- name: LOG_LEVEL\n          value: \"warn\"",
+		"- name: DB_PASSWORD",
+		"name: " + projectSecretName(spec),
+		"replicas: 2",
+		"limits:\n            cpu: \"250m\"",
+	} {
+		if !strings.Contains(deployment, want) {
+			t.Fatalf("staging deployment missing %q:\n%s", want, deployment)
+		}
+	}
+	ingress := executeHelmTemplate(t, "ingress", files["templates/ingress.yaml"], values)
+	if !strings.Contains(ingress, "- host: staging.chart.example.com") {
+		t.Fatalf("staging ingress must use the staging host:\n%s", ingress)
+	}
+	if strings.Contains(deployment, "PLATFORM_ENVIRONMENT") {
+		t.Fatalf("PLATFORM_ENVIRONMENT is only the fallback for an environment without vars:\n%s", deployment)
+	}
+
+	values["environment"] = "dev"
+	deployment = executeHelmTemplate(t, "deployment", files["templates/deployment.yaml"], values)
+	if !strings.Contains(deployment, `value: "info"`) || strings.Contains(deployment, "DB_PASSWORD") {
+		t.Fatalf("dev deployment must carry only dev vars:\n%s", deployment)
+	}
+	if service := executeHelmTemplate(t, "service", files["templates/service.yaml"], values); !strings.Contains(
+		service, "type: ClusterIP",
+	) {
+		t.Fatalf("service must default to ClusterIP:\n%s", service)
+	}
+	if hpa := executeHelmTemplate(t, "hpa", files["templates/hpa.yaml"], values); strings.TrimSpace(hpa) != "" {
+		t.Fatalf("dev does not autoscale, so it must render no HPA:\n%s", hpa)
+	}
+	executeHelmTemplate(t, "serviceaccount", files["templates/serviceaccount.yaml"], values)
+}
+
+func TestHelmChartRendersAutoscaledEnvironments(t *testing.T) {
+	t.Parallel()
+
+	spec := workerRuntimeSpec("chart-scaled")
+	spec.Helm.Enabled = true
+	spec.Environments["prod"] = EnvConfig{Autoscaling: AutoscalingConfig{MinReplicas: 2, MaxReplicas: 5}}
+	files := helmChartFiles(spec, nil, nil, nil)
+	var values map[string]any
+	if err := yaml.Unmarshal([]byte(files["values.yaml"]), &values); err != nil {
+		t.Fatalf("values.yaml is invalid yaml: %v\n%s", err, files["values.yaml"])
+	}
+	values["environment"] = "prod"
+	hpa := executeHelmTemplate(t, "hpa", files["templates/hpa.yaml"], values)
+	for _, want := range []string{"minReplicas: 2", "maxReplicas: 5", "averageUtilization: 80"} {
+		if !strings.Contains(hpa, want) {
+			t.Fatalf("prod hpa missing %q:\n%s", want, hpa)
+		}
+	}
+	if deployment := executeHelmTemplate(t, "deployment", files["templates/deployment.yaml"], values); strings.Contains(
+		deployment, "replicas:",
+	) {
+		t.Fatalf("an autoscaled deployment must leave replicas to the HPA:\n%s", deployment)
+	}
+}
+
+func TestWriteHelmChartFollowsTheSpecSwitch(t *testing.T) {
+	t.Parallel()
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	spec := workerRuntimeSpec("chart-toggle")
+	spec.Helm.Enabled = true
+	written, err := writeKustomizeRepoFiles(artifacts, "project-chart", spec, map[string]string{}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("write manifests: %v", err)
+	}
+	for _, want := range []string{"deploy/chart/Chart.yaml", "repos/manifests/chart/values.yaml"} {
+		if !strings.Contains(strings.Join(written, "\n"), want) {
+			t.Fatalf("expected %s among written artifacts, got %v", want, written)
+		}
+	}
+
+	spec.Helm.Enabled = false
+	if _, err = writeKustomizeRepoFiles(
+		artifacts, "project-chart", spec, map[string]string{}, nil, nil, nil,
+	); err != nil {
+		t.Fatalf("write manifests without chart: %v", err)
+	}
+	for _, gone := range []string{"deploy/chart/Chart.yaml", "repos/manifests/chart/Chart.yaml"} {
+		if _, readErr := artifacts.ReadFile("project-chart", gone); readErr == nil {
+			t.Fatalf("expected %s to be removed once helm is disabled", gone)
+		}
+	}
+}
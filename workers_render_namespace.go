@@ -0,0 +1,117 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	manifestFileNamespace  = "namespace.yaml"
+	namespaceNameMaxLength = 63
+)
+
+// namespaceProvisioningEnabled reports whether overlays should own the
+// Namespace, ResourceQuota, and LimitRange objects. Clusters with
+// pre-provisioned namespaces can turn this off; workloads still target the
+// per-project namespace either way.
+func namespaceProvisioningEnabled() bool {
+	raw := strings.TrimSpace(strings.ToLower(os.Getenv(namespaceProvisioningEnv)))
+	if raw == "" {
+		return true
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		return true
+	}
+	return enabled
+}
+
+func projectNamespace(spec ProjectSpec, env string) string {
+	return projectNamespaceFor(strings.TrimSpace(spec.Name), env)
+}
+
+// projectNamespaceFor is projectNamespace for the project named name, for
+// rendering references to other projects' namespaces.
+func projectNamespaceFor(name, env string) string {
+	env = normalizeEnvironmentName(env)
+	if env == "" {
+		env = defaultDeployEnvironment
+	}
+	suffix := "-" + strings.Trim(safeName(env), "-")
+	name = strings.Trim(safeName(name), "-")
+	if len(name)+len(suffix) > namespaceNameMaxLength {
+		name = strings.TrimRight(name[:namespaceNameMaxLength-len(suffix)], "-")
+	}
+	return name + suffix
+}
+
+func projectNamespaces(spec ProjectSpec) []string {
+	envs := desiredManifestEnvironments(spec)
+	out := make([]string, 0, len(envs))
+	for _, env := range envs {
+		out = append(out, projectNamespace(spec, env))
+	}
+	return out
+}
+
+func renderNamespaceManifest(spec ProjectSpec, env string) string {
+	spec = normalizeProjectSpec(spec)
+	env = resolveDeployEnvironment(env)
+	namespace := projectNamespace(spec, env)
+	app := safeName(spec.Name)
+	quota := namespaceQuotaFromEnv()
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: v1\n")
+	fmt.Fprintf(&b, "kind: Namespace\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", namespace)
+	fmt.Fprintf(&b, "  labels:\n")
+	fmt.Fprintf(&b, "    app: %s\n", app)
+	fmt.Fprintf(&b, "    platform.example.com/environment: %s\n", env)
+	fmt.Fprintf(&b, "    platform.example.com/managed-by: platform\n")
+	fmt.Fprintf(&b, "---\n")
+	fmt.Fprintf(&b, "apiVersion: v1\n")
+	fmt.Fprintf(&b, "kind: ResourceQuota\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s-quota\n", app)
+	fmt.Fprintf(&b, "spec:\n")
+	fmt.Fprintf(&b, "  hard:\n")
+	fmt.Fprintf(&b, "    requests.cpu: %s\n", yamlQuoted(quota.RequestsCPU))
+	fmt.Fprintf(&b, "    requests.memory: %s\n", quota.RequestsMemory)
+	fmt.Fprintf(&b, "    limits.cpu: %s\n", yamlQuoted(quota.LimitsCPU))
+	fmt.Fprintf(&b, "    limits.memory: %s\n", quota.LimitsMemory)
+	fmt.Fprintf(&b, "    pods: %s\n", yamlQuoted(strconv.Itoa(quota.Pods)))
+	fmt.Fprintf(&b, "---\n")
+	fmt.Fprintf(&b, "apiVersion: v1\n")
+	fmt.Fprintf(&b, "kind: LimitRange\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s-limits\n", app)
+	fmt.Fprintf(&b, "spec:\n")
+	fmt.Fprintf(&b, "  limits:\n")
+	fmt.Fprintf(&b, "  - type: Container\n")
+	fmt.Fprintf(&b, "    defaultRequest:\n")
+	fmt.Fprintf(&b, "      cpu: %s\n", namespaceLimitDefaultRequestCPU)
+	fmt.Fprintf(&b, "      memory: %s\n", namespaceLimitDefaultRequestMemory)
+	fmt.Fprintf(&b, "    default:\n")
+	fmt.Fprintf(&b, "      cpu: %s\n", namespaceLimitDefaultCPU)
+	fmt.Fprintf(&b, "      memory: %s\n", namespaceLimitDefaultMemory)
+	return b.String()
+}
+
+// renderNamespaceTeardownManifest lists the namespaces a delete leaves behind
+// so an operator (or an applying deployer) can remove them with one command.
+func renderNamespaceTeardownManifest(spec ProjectSpec) string {
+	var b strings.Builder
+	for i, namespace := range projectNamespaces(spec) {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		fmt.Fprintf(&b, "apiVersion: v1\n")
+		fmt.Fprintf(&b, "kind: Namespace\n")
+		fmt.Fprintf(&b, "metadata:\n")
+		fmt.Fprintf(&b, "  name: %s\n", namespace)
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	manifestFileServiceAccount = "serviceaccount.yaml"
+	serviceAccountProjectToken = "{project}"
+)
+
+func projectServiceAccountName(spec ProjectSpec) string {
+	spec = normalizeProjectSpec(spec)
+	return safeName(spec.Name)
+}
+
+// serviceAccountAnnotationsFromEnv parses PAAS_SERVICE_ACCOUNT_ANNOTATIONS as
+// comma-separated key=value pairs. A literal {project} in a value is replaced
+// with the project name so one setting can map every workload to its own
+// cloud identity (for example an IRSA role ARN).
+func serviceAccountAnnotationsFromEnv(projectName string) map[string]string {
+	return parseServiceAccountAnnotations(os.Getenv(serviceAccountAnnotationsEnv), projectName)
+}
+
+func parseServiceAccountAnnotations(raw string, projectName string) map[string]string {
+	out := map[string]string{}
+	for pair := range strings.SplitSeq(raw, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		value = strings.ReplaceAll(strings.TrimSpace(value), serviceAccountProjectToken, projectName)
+		out[key] = value
+	}
+	return out
+}
+
+// renderServiceAccountManifest renders the workload identity: a dedicated
+// ServiceAccount with API token automount disabled, and a Role that only
+// grants read access to the project's own ConfigMaps.
+func renderServiceAccountManifest(spec ProjectSpec) string {
+	spec = normalizeProjectSpec(spec)
+	name := projectServiceAccountName(spec)
+	annotations := serviceAccountAnnotationsFromEnv(name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: v1\n")
+	fmt.Fprintf(&b, "kind: ServiceAccount\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	fmt.Fprintf(&b, "  labels:\n")
+	fmt.Fprintf(&b, "    app: %s\n", name)
+	if len(annotations) > 0 {
+		fmt.Fprintf(&b, "  annotations:\n")
+		for _, key := range sortedKeys(annotations) {
+			fmt.Fprintf(&b, "    %s: %s\n", key, yamlQuoted(annotations[key]))
+		}
+	}
+	fmt.Fprintf(&b, "automountServiceAccountToken: false\n")
+	fmt.Fprintf(&b, "---\n")
+	fmt.Fprintf(&b, "apiVersion: rbac.authorization.k8s.io/v1\n")
+	fmt.Fprintf(&b, "kind: Role\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	fmt.Fprintf(&b, "rules:\n")
+	fmt.Fprintf(&b, "- apiGroups: [\"\"]\n")
+	fmt.Fprintf(&b, "  resources: [\"configmaps\"]\n")
+	fmt.Fprintf(&b, "  verbs: [\"get\", \"list\", \"watch\"]\n")
+	fmt.Fprintf(&b, "---\n")
+	fmt.Fprintf(&b, "apiVersion: rbac.authorization.k8s.io/v1\n")
+	fmt.Fprintf(&b, "kind: RoleBinding\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	fmt.Fprintf(&b, "roleRef:\n")
+	fmt.Fprintf(&b, "  apiGroup: rbac.authorization.k8s.io\n")
+	fmt.Fprintf(&b, "  kind: Role\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	fmt.Fprintf(&b, "subjects:\n")
+	fmt.Fprintf(&b, "- kind: ServiceAccount\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	return b.String()
+}
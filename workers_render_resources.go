@@ -0,0 +1,330 @@
+package platform
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Resources: spec.resources sets the app container's CPU and memory, and
+// environments.<env>.replicas its pod count. Both are checked against the
+// per-environment namespace quota before anything is stored, so a spec the
+// cluster would refuse to schedule is a 400 instead of a stuck rollout.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	defaultReplicas = 1
+
+	resourceQuotaRequestsCPU    = "requests.cpu"
+	resourceQuotaRequestsMemory = "requests.memory"
+	resourceQuotaLimitsCPU      = "limits.cpu"
+	resourceQuotaLimitsMemory   = "limits.memory"
+	resourceQuotaPods           = "pods"
+
+	milliCPUPerCore = 1000
+	kibibyte        = 1 << 10
+	mebibyte        = 1 << 20
+	gibibyte        = 1 << 30
+	tebibyte        = 1 << 40
+	kilobyte        = 1e3
+	megabyte        = 1e6
+	gigabyte        = 1e9
+	terabyte        = 1e12
+)
+
+var (
+	cpuQuantityRe    = regexp.MustCompile(`^(?:([0-9]+)m|([0-9]+)(?:\.([0-9]{1,3}))?)$`)
+	memoryQuantityRe = regexp.MustCompile(`^([0-9]+)(Ki|Mi|Gi|Ti|k|M|G|T)?$`)
+)
+
+// replicas is the environment's pod count.
+func (c EnvConfig) replicas() int {
+	if c.Replicas > 0 {
+		return c.Replicas
+	}
+	return defaultReplicas
+}
+
+func normalizeResourceConfig(in ResourceConfig) ResourceConfig {
+	return ResourceConfig{
+		Requests: ResourceAmounts{
+			CPU:    strings.TrimSpace(in.Requests.CPU),
+			Memory: strings.TrimSpace(in.Requests.Memory),
+		},
+		Limits: ResourceAmounts{
+			CPU:    strings.TrimSpace(in.Limits.CPU),
+			Memory: strings.TrimSpace(in.Limits.Memory),
+		},
+	}
+}
+
+// namespaceQuota is the ResourceQuota rendered into every environment's
+// namespace, and the ceiling each environment's replicas times the app
+// container's resources must fit under.
+type namespaceQuota struct {
+	RequestsCPU    string
+	RequestsMemory string
+	LimitsCPU      string
+	LimitsMemory   string
+	Pods           int
+}
+
+func defaultNamespaceQuota() namespaceQuota {
+	return namespaceQuota{
+		RequestsCPU:    namespaceQuotaRequestsCPU,
+		RequestsMemory: namespaceQuotaRequestsMemory,
+		LimitsCPU:      namespaceQuotaLimitsCPU,
+		LimitsMemory:   namespaceQuotaLimitsMemory,
+		Pods:           namespaceQuotaPods,
+	}
+}
+
+func namespaceQuotaFromEnv() namespaceQuota {
+	return parseNamespaceQuota(os.Getenv(namespaceQuotaEnv))
+}
+
+// parseNamespaceQuota reads "key=amount" pairs keyed like ResourceQuota's
+// hard limits, such as "limits.cpu=8,pods=40". Keys left out keep their
+// defaults; an invalid list falls back to the defaults entirely, so a typo
+// never lifts the quota.
+func parseNamespaceQuota(raw string) namespaceQuota {
+	quota := defaultNamespaceQuota()
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return quota
+	}
+	for part := range strings.SplitSeq(raw, ",") {
+		key, amount, _ := strings.Cut(strings.TrimSpace(part), "=")
+		amount = strings.TrimSpace(amount)
+		var ok bool
+		switch strings.TrimSpace(key) {
+		case resourceQuotaRequestsCPU:
+			quota.RequestsCPU, ok = amount, validCPUQuantity(amount)
+		case resourceQuotaRequestsMemory:
+			quota.RequestsMemory, ok = amount, validMemoryQuantity(amount)
+		case resourceQuotaLimitsCPU:
+			quota.LimitsCPU, ok = amount, validCPUQuantity(amount)
+		case resourceQuotaLimitsMemory:
+			quota.LimitsMemory, ok = amount, validMemoryQuantity(amount)
+		case resourceQuotaPods:
+			pods, err := strconv.Atoi(amount)
+			quota.Pods, ok = pods, err == nil && pods > 0
+		}
+		if !ok {
+			return defaultNamespaceQuota()
+		}
+	}
+	return quota
+}
+
+// parseCPUMilli reads a CPU quantity ("250m", "1", "1.5") as millicores.
+func parseCPUMilli(raw string) (int64, bool) {
+	m := cpuQuantityRe.FindStringSubmatch(raw)
+	if m == nil {
+		return 0, false
+	}
+	if m[1] != "" {
+		milli, err := strconv.ParseInt(m[1], 10, 64)
+		return milli, err == nil
+	}
+	cores, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil || cores > math.MaxInt64/milliCPUPerCore {
+		return 0, false
+	}
+	fraction := int64(0)
+	if m[3] != "" {
+		fraction, _ = strconv.ParseInt((m[3] + "00")[:3], 10, 64)
+	}
+	return cores*milliCPUPerCore + fraction, true
+}
+
+// parseMemoryBytes reads a memory quantity ("512Mi", "1G", "1048576") as
+// bytes.
+func parseMemoryBytes(raw string) (int64, bool) {
+	m := memoryQuantityRe.FindStringSubmatch(raw)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	multiplier := map[string]int64{
+		"": 1, "k": kilobyte, "M": megabyte, "G": gigabyte, "T": terabyte,
+		"Ki": kibibyte, "Mi": mebibyte, "Gi": gibibyte, "Ti": tebibyte,
+	}[m[2]]
+	if n > math.MaxInt64/multiplier {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+func validCPUQuantity(raw string) bool {
+	milli, ok := parseCPUMilli(raw)
+	return ok && milli > 0
+}
+
+func validMemoryQuantity(raw string) bool {
+	n, ok := parseMemoryBytes(raw)
+	return ok && n > 0
+}
+
+// containerResource is one resource of the app container as the cluster
+// will see it: what the spec sets, or the LimitRange default. Without a
+// request of its own, a container with a limit requests that limit.
+type containerResource struct {
+	name            string // cpu | memory
+	request, limit  string
+	defaultRequest  string
+	defaultLimit    string
+	quotaRequest    string
+	quotaLimit      string
+	parse           func(string) (int64, bool)
+	quantityExample string
+}
+
+func (c containerResource) effective() (string, string) {
+	limit := c.limit
+	if limit == "" {
+		limit = c.defaultLimit
+	}
+	request := c.request
+	switch {
+	case request != "":
+	case c.limit != "":
+		request = c.limit
+	default:
+		request = c.defaultRequest
+	}
+	return request, limit
+}
+
+func appContainerResources(resources ResourceConfig, quota namespaceQuota) []containerResource {
+	return []containerResource{
+		{
+			name:            "cpu",
+			request:         resources.Requests.CPU,
+			limit:           resources.Limits.CPU,
+			defaultRequest:  namespaceLimitDefaultRequestCPU,
+			defaultLimit:    namespaceLimitDefaultCPU,
+			quotaRequest:    quota.RequestsCPU,
+			quotaLimit:      quota.LimitsCPU,
+			parse:           parseCPUMilli,
+			quantityExample: "250m or 1.5",
+		},
+		{
+			name:            "memory",
+			request:         resources.Requests.Memory,
+			limit:           resources.Limits.Memory,
+			defaultRequest:  namespaceLimitDefaultRequestMemory,
+			defaultLimit:    namespaceLimitDefaultMemory,
+			quotaRequest:    quota.RequestsMemory,
+			quotaLimit:      quota.LimitsMemory,
+			parse:           parseMemoryBytes,
+			quantityExample: "512Mi or 1Gi",
+		},
+	}
+}
+
+// validateResources checks spec.resources and each environment's replicas
+// or autoscaling, then that every environment fits the namespace quota at
+// its peak.
+func validateResources(spec ProjectSpec) error {
+	quota := namespaceQuotaFromEnv()
+	resources := appContainerResources(normalizeResourceConfig(spec.Resources), quota)
+	for _, res := range resources {
+		for _, field := range []struct{ kind, value string }{{"requests", res.request}, {"limits", res.limit}} {
+			if n, ok := res.parse(field.value); field.value != "" && (!ok || n <= 0) {
+				path := "resources." + field.kind + "." + res.name
+				return specFieldErrorf(path, "%s %q must be a %s quantity such as %s",
+					path, field.value, res.name, res.quantityExample)
+			}
+		}
+		request, limit := res.effective()
+		requestN, _ := res.parse(request)
+		limitN, _ := res.parse(limit)
+		if requestN > limitN && res.limit == "" {
+			return specFieldErrorf("resources.requests."+res.name,
+				"resources.requests.%s (%s) is above the namespace default %s limit (%s); set resources.limits.%s too",
+				res.name, request, res.name, limit, res.name)
+		}
+		if requestN > limitN {
+			return specFieldErrorf("resources.requests."+res.name,
+				"resources.requests.%s (%s) is above resources.limits.%s (%s)", res.name, request, res.name, limit)
+		}
+	}
+	for _, env := range sortedKeys(spec.Environments) {
+		if err := validateAutoscaling(env, spec.Environments[env]); err != nil {
+			return err
+		}
+		if err := validateEnvironmentQuota(env, spec.Environments[env], resources, quota); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateEnvironmentQuota(
+	env string,
+	cfg EnvConfig,
+	resources []containerResource,
+	quota namespaceQuota,
+) error {
+	field := "environments." + env + ".replicas"
+	if cfg.Replicas < 0 {
+		return specFieldErrorf(field, "%s must not be negative", field)
+	}
+	replicas := cfg.peakReplicas()
+	if cfg.Autoscaling.enabled() {
+		field = "environments." + env + ".autoscaling.maxReplicas"
+	}
+	if replicas > quota.Pods {
+		return specFieldErrorf(field, "%s (%d) is above the namespace quota of %d pods (%s)",
+			field, replicas, quota.Pods, namespaceQuotaEnv)
+	}
+	for _, res := range resources {
+		request, limit := res.effective()
+		for _, check := range []struct{ kind, amount, ceiling string }{
+			{"requests", request, res.quotaRequest},
+			{"limits", limit, res.quotaLimit},
+		} {
+			amount, _ := res.parse(check.amount)
+			ceiling, _ := res.parse(check.ceiling)
+			if amount > 0 && int64(replicas) > ceiling/amount {
+				return specFieldErrorf(field,
+					"%s: %d replicas at %s %s each exceed the namespace quota of %s %s (%s.%s in %s)",
+					field, replicas, check.amount, res.name, check.ceiling, res.name,
+					check.kind, res.name, namespaceQuotaEnv)
+			}
+		}
+	}
+	return nil
+}
+
+// writeContainerResources writes the app container's resources block at the
+// indent of its other keys, or nothing when the spec sets none.
+func writeContainerResources(b *strings.Builder, indent string, resources ResourceConfig) {
+	if resources == (ResourceConfig{}) {
+		return
+	}
+	fmt.Fprintf(b, "%sresources:\n", indent)
+	for _, group := range []struct {
+		kind    string
+		amounts ResourceAmounts
+	}{{"requests", resources.Requests}, {"limits", resources.Limits}} {
+		if group.amounts == (ResourceAmounts{}) {
+			continue
+		}
+		fmt.Fprintf(b, "%s  %s:\n", indent, group.kind)
+		if group.amounts.CPU != "" {
+			fmt.Fprintf(b, "%s    cpu: %s\n", indent, yamlQuoted(group.amounts.CPU))
+		}
+		if group.amounts.Memory != "" {
+			fmt.Fprintf(b, "%s    memory: %s\n", indent, yamlQuoted(group.amounts.Memory))
+		}
+	}
+}
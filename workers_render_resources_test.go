@@ -0,0 +1,134 @@
+//nolint:testpackage,exhaustruct // Resource tests check the unexported quota math and render overlays directly.
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateResourcesAgainstNamespaceQuota(t *testing.T) {
+	spec := workerRuntimeSpec("sized")
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{}, Replicas: 9}
+	for _, tc := range []struct {
+		resources ResourceConfig
+		replicas  int
+		field     string
+	}{
+		{resources: ResourceConfig{Requests: ResourceAmounts{CPU: "lots"}}, field: "resources.requests.cpu"},
+		{resources: ResourceConfig{Limits: ResourceAmounts{Memory: "1GB"}}, field: "resources.limits.memory"},
+		{resources: ResourceConfig{Requests: ResourceAmounts{CPU: "1"}}, field: "resources.requests.cpu"},
+		{
+			resources: ResourceConfig{
+				Requests: ResourceAmounts{Memory: "1Gi"},
+				Limits:   ResourceAmounts{Memory: "512Mi"},
+			},
+			field: "resources.requests.memory",
+		},
+		{replicas: -1, field: "environments.prod.replicas"},
+		// Nine replicas at the default 500m CPU limit need 4.5 of a 4 CPU quota.
+		{replicas: 9, field: "environments.prod.replicas"},
+		{
+			resources: ResourceConfig{Limits: ResourceAmounts{CPU: "100m", Memory: "64Mi"}},
+			replicas:  21,
+			field:     "environments.prod.replicas",
+		},
+	} {
+		spec.Resources = tc.resources
+		spec.Environments["prod"] = EnvConfig{Vars: map[string]string{}, Replicas: tc.replicas}
+		err := validateResources(spec)
+		var fieldErr specFieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != tc.field {
+			t.Fatalf("validateResources(%+v, %d replicas): expected an error on %s, got %v",
+				tc.resources, tc.replicas, tc.field, err)
+		}
+	}
+
+	spec.Resources = ResourceConfig{
+		Requests: ResourceAmounts{CPU: "100m", Memory: "128Mi"},
+		Limits:   ResourceAmounts{CPU: "0.5", Memory: "512Mi"},
+	}
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{}, Replicas: 8}
+	if err := validateResources(spec); err != nil {
+		t.Fatalf("expected 8 replicas to fit the default quota, got %v", err)
+	}
+
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{}, Replicas: 12}
+	err := validateResources(spec)
+	if err == nil || !strings.Contains(err.Error(), "limits.cpu") {
+		t.Fatalf("expected 12 replicas to exceed limits.cpu, got %v", err)
+	}
+	t.Setenv(namespaceQuotaEnv, "limits.cpu=8,limits.memory=8Gi")
+	if err = validateResources(spec); err != nil {
+		t.Fatalf("expected a raised quota to admit 12 replicas, got %v", err)
+	}
+	if manifest := renderNamespaceManifest(spec, "prod"); !strings.Contains(manifest, `limits.cpu: "8"`) {
+		t.Fatalf("expected the namespace quota to follow %s:\n%s", namespaceQuotaEnv, manifest)
+	}
+	t.Setenv(namespaceQuotaEnv, "limits.cpu=8,limits.gpu=1")
+	if err = validateResources(spec); err == nil {
+		t.Fatal("expected an unreadable quota to fall back to the defaults")
+	}
+}
+
+func TestWriteKustomizeRepoFilesRendersResourcesAndReplicas(t *testing.T) {
+	t.Parallel()
+
+	artifacts := NewFSArtifacts(t.TempDir())
+	projectID := "project-resources"
+	spec := workerRuntimeSpec("sized")
+	spec.Environments["prod"] = EnvConfig{Vars: map[string]string{"LOG_LEVEL": "warn"}, Replicas: 3}
+	spec.Resources = ResourceConfig{
+		Requests: ResourceAmounts{CPU: "250m", Memory: "256Mi"},
+		Limits:   ResourceAmounts{Memory: "512Mi"},
+	}
+	if _, err := writeKustomizeRepoFiles(artifacts, projectID, spec, map[string]string{}, nil, nil, nil); err != nil {
+		t.Fatalf("write manifests: %v", err)
+	}
+	rendered, err := runKustomizeBuildAtPath(
+		filepath.Join(artifacts.ProjectDir(projectID), manifestsRepoOverlaysDir, "prod"))
+	if err != nil {
+		t.Fatalf("build prod overlay: %v", err)
+	}
+	for _, want := range []string{"replicas: 3", "cpu: 250m", "memory: 256Mi", "memory: 512Mi"} {
+		if !strings.Contains(string(rendered), want) {
+			t.Fatalf("prod overlay missing %q:\n%s", want, rendered)
+		}
+	}
+	rendered, err = runKustomizeBuildAtPath(
+		filepath.Join(artifacts.ProjectDir(projectID), manifestsRepoOverlaysDir, "dev"))
+	if err != nil || !strings.Contains(string(rendered), "replicas: 1") {
+		t.Fatalf("expected dev to keep one replica, got err=%v\n%s", err, rendered)
+	}
+	if got := specLintRules(lintProjectSpec(spec)); got != "" {
+		t.Fatalf("expected three prod replicas to satisfy the lint, got %s", got)
+	}
+}
+
+func TestAPI_ProjectCreateRejectsSpecOverQuota(t *testing.T) {
+	fixture := newWorkerDeliveryFixture(t)
+	defer fixture.Close()
+	api := &API{store: fixture.store, nc: fixture.nc, artifacts: NewFSArtifacts(t.TempDir())}
+	srv := httptest.NewServer(api.routes())
+	defer srv.Close()
+
+	body := `{"name":"over-quota","runtime":"go_1.26","environments":{"prod":{"replicas":4}},` +
+		`"resources":{"limits":{"cpu":"2","memory":"1Gi"}}}`
+	resp, err := http.Post(srv.URL+"/api/projects", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	defer resp.Body.Close()
+	var out apiErrorResponse
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || out.Field != "environments.prod.replicas" ||
+		!strings.Contains(out.Message, "requests.cpu in "+namespaceQuotaEnv) {
+		t.Fatalf("expected a 400 naming the replicas and the quota, got %d %+v", resp.StatusCode, out)
+	}
+}
@@ -0,0 +1,76 @@
+//nolint:testpackage // Render tests validate unexported manifest helpers directly.
+package platform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseServiceAccountAnnotations(t *testing.T) {
+	t.Parallel()
+
+	got := parseServiceAccountAnnotations(
+		" eks.amazonaws.com/role-arn = arn:aws:iam::123:role/{project} ,broken, =skip,team=payments",
+		"svc",
+	)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 annotations, got %#v", got)
+	}
+	if got["eks.amazonaws.com/role-arn"] != "arn:aws:iam::123:role/svc" {
+		t.Fatalf("unexpected role annotation: %#v", got)
+	}
+	if got["team"] != "payments" {
+		t.Fatalf("unexpected team annotation: %#v", got)
+	}
+}
+
+func TestProjectNamespaceTruncatesToLabelLength(t *testing.T) {
+	t.Parallel()
+
+	spec := ProjectSpec{
+		Name:         "a-very-long-project-name-that-keeps-going-and-going-past-limits",
+		Environments: map[string]EnvConfig{},
+	}
+	got := projectNamespace(spec, "staging")
+	if len(got) > namespaceNameMaxLength {
+		t.Fatalf("namespace %q exceeds %d chars", got, namespaceNameMaxLength)
+	}
+	if got[len(got)-len("-staging"):] != "-staging" {
+		t.Fatalf("namespace %q must keep environment suffix", got)
+	}
+}
+
+func TestStampManifestTraceAnnotatesEveryDocument(t *testing.T) {
+	t.Parallel()
+
+	rendered := []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\n---\n" +
+		"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: svc\n")
+	trace := manifestTrace{
+		opID:            "op-1",
+		releaseID:       releaseIDForOp("op-1"),
+		sourceCommit:    "abc123",
+		specHash:        "hash",
+		platformVersion: "",
+	}
+	stamped, err := stampManifestTrace(rendered, trace)
+	if err != nil {
+		t.Fatalf("stamp manifest trace: %v", err)
+	}
+	for _, doc := range splitManifestDocs(string(stamped)) {
+		for _, want := range []string{
+			traceAnnotationOpID + ": 'op-1'",
+			traceAnnotationReleaseID + ": '" + trace.releaseID + "'",
+			traceAnnotationSourceCommit + ": 'abc123'",
+		} {
+			if !strings.Contains(doc, want) {
+				t.Fatalf("document missing %q:\n%s", want, doc)
+			}
+		}
+		if strings.Contains(doc, traceAnnotationPlatformVersion) {
+			t.Fatalf("empty trace values must not be stamped:\n%s", doc)
+		}
+	}
+	if releaseIDForOp("op-1") != trace.releaseID || releaseIDForOp("op-2") == trace.releaseID {
+		t.Fatal("release IDs must be stable per op and distinct across ops")
+	}
+}
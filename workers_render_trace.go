@@ -0,0 +1,199 @@
+package platform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	traceAnnotationOpID            = "platform.example.com/op-id"
+	traceAnnotationReleaseID       = "platform.example.com/release-id"
+	traceAnnotationSourceCommit    = "platform.example.com/source-commit"
+	traceAnnotationSpecHash        = "platform.example.com/spec-hash"
+	traceAnnotationPlatformVersion = "platform.example.com/platform-version"
+)
+
+// manifestTrace carries the provenance stamped onto every rendered object so
+// a workload found in a cluster can be traced back to the op that shipped it.
+// secretsChecksum goes on the Deployment's pod template instead, so that a
+// rotated secret rolls the pods.
+type manifestTrace struct {
+	opID            string
+	releaseID       string
+	sourceCommit    string
+	specHash        string
+	platformVersion string
+	secretsChecksum string
+}
+
+// releaseIDForOp derives the release record ID from the op that produces it.
+// Each deploy/promote/release/rollback op persists exactly one release, so
+// the ID is known before rendering and stays stable across redeliveries.
+func releaseIDForOp(opID string) string {
+	sum := sha256.Sum256([]byte("release:" + opID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// projectSpecHash is a project's spec_hash: SHA-256 over the JSON of the
+// normalized spec. encoding/json writes struct fields in order and map keys
+// sorted, so equal specs hash equally wherever they are stored. The same value
+// is stamped on rendered objects as the spec-hash annotation.
+func projectSpecHash(spec ProjectSpec) string {
+	body, err := json.Marshal(normalizeProjectSpec(spec))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// opSpecHash is the spec_hash recorded on an op of kind. Deletes carry an
+// empty spec, so they record none.
+func opSpecHash(kind OperationKind, spec ProjectSpec) string {
+	if kind == OpDelete {
+		return ""
+	}
+	return projectSpecHash(spec)
+}
+
+func opProducesRelease(kind OperationKind) bool {
+	switch kind {
+	case OpDeploy, OpPromote, OpRelease, OpRollback:
+		return true
+	case OpCreate, OpUpdate, OpDelete, OpCI, OpCleanup, OpVarRollout, OpPromoteFanout, OpRuntimeUpgrade,
+		OpWebhookRefresh:
+		return false
+	default:
+		return false
+	}
+}
+
+func newManifestTrace(
+	ctx context.Context,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	spec ProjectSpec,
+) manifestTrace {
+	trace := manifestTrace{
+		opID:            msg.OpID,
+		releaseID:       "",
+		sourceCommit:    "",
+		specHash:        projectSpecHash(spec),
+		platformVersion: runtimeBuildVersion(),
+		secretsChecksum: "",
+	}
+	if opProducesRelease(msg.Kind) {
+		trace.releaseID = releaseIDForOp(msg.OpID)
+	}
+	// A ci op for another branch or a tag built its pushed commit, not HEAD.
+	if msg.CI != nil && msg.CI.Commit != "" {
+		trace.sourceCommit = msg.CI.Commit
+	} else if artifacts != nil {
+		if commit, err := gitRevParse(ctx, sourceRepoDir(artifacts, msg.ProjectID), "HEAD"); err == nil {
+			trace.sourceCommit = commit
+		}
+	}
+	return trace
+}
+
+// newEnvManifestTrace is newManifestTrace for a render of envName. The
+// environment's external and stored secrets are resolved here, and only their
+// checksum is kept, so a render fails early when a secret cannot be fetched
+// or opened.
+func newEnvManifestTrace(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	msg ProjectOpMsg,
+	spec ProjectSpec,
+	envName string,
+) (manifestTrace, error) {
+	trace := newManifestTrace(ctx, artifacts, msg, spec)
+	spec = normalizeProjectSpec(spec)
+	hasSecrets, err := environmentHasSecrets(ctx, store, msg.ProjectID, spec, envName)
+	if err != nil || !hasSecrets {
+		return trace, err
+	}
+	subStepDone := beginSubStep(ctx, "resolve "+envName+" secrets")
+	values, err := resolveRenderSecrets(ctx, store, artifacts, msg.ProjectID, spec, envName)
+	subStepDone(err)
+	if err != nil {
+		return trace, err
+	}
+	trace.secretsChecksum = secretsChecksum(values)
+	return trace, nil
+}
+
+func (t manifestTrace) annotations() map[string]string {
+	out := map[string]string{}
+	for key, value := range map[string]string{
+		traceAnnotationOpID:            t.opID,
+		traceAnnotationReleaseID:       t.releaseID,
+		traceAnnotationSourceCommit:    t.sourceCommit,
+		traceAnnotationSpecHash:        t.specHash,
+		traceAnnotationPlatformVersion: t.platformVersion,
+	} {
+		if value != "" {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+func stampManifestTrace(rendered []byte, trace manifestTrace) ([]byte, error) {
+	annotations := trace.annotations()
+	if len(annotations) == 0 && trace.secretsChecksum == "" {
+		return rendered, nil
+	}
+	nodes, err := kio.FromBytes(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("parse rendered manifests: %w", err)
+	}
+	for _, node := range nodes {
+		for _, key := range sortedKeys(annotations) {
+			if err = node.PipeE(kyaml.SetAnnotation(key, annotations[key])); err != nil {
+				return nil, fmt.Errorf("stamp %s annotation: %w", key, err)
+			}
+		}
+		if trace.secretsChecksum == "" || node.GetKind() != "Deployment" {
+			continue
+		}
+		err = node.PipeE(
+			kyaml.LookupCreate(kyaml.MappingNode, "spec", "template", "metadata", "annotations"),
+			kyaml.SetField(secretAnnotationChecksum, kyaml.NewStringRNode(trace.secretsChecksum)),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("stamp %s annotation: %w", secretAnnotationChecksum, err)
+		}
+	}
+	out, err := kio.StringAll(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("encode stamped manifests: %w", err)
+	}
+	return []byte(out), nil
+}
+
+// readRenderedEnvSourceCommit recovers the source commit stamped on the
+// environment's rendered deployment; it returns "" when none was recorded.
+func readRenderedEnvSourceCommit(artifacts ArtifactStore, projectID string, env string) string {
+	path := filepath.ToSlash(filepath.Join("deploy", normalizeEnvironmentName(env), manifestFileDeployment))
+	raw, err := artifacts.ReadFile(projectID, path)
+	if err != nil {
+		return ""
+	}
+	for line := range strings.SplitSeq(string(raw), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), traceAnnotationSourceCommit+":")
+		if ok {
+			return strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return ""
+}
@@ -10,8 +10,11 @@ import (
 	"github.com/nats-io/nats.go/jetstream"
 )
 
+// workerSkippedMessage marks results passed along without running the step.
+const workerSkippedMessage = "skipped due to upstream error"
+
 func skipWorkerResult(opMsg ProjectOpMsg, workerName string) WorkerResultMsg {
-	res := newWorkerResultMsg("skipped due to upstream error")
+	res := newWorkerResultMsg(workerSkippedMessage)
 	res.OpID = opMsg.OpID
 	res.Kind = opMsg.Kind
 	res.ProjectID = opMsg.ProjectID
@@ -24,6 +27,10 @@ func skipWorkerResult(opMsg ProjectOpMsg, workerName string) WorkerResultMsg {
 	res.RollbackScope = opMsg.RollbackScope
 	res.RollbackOverride = opMsg.RollbackOverride
 	res.Delivery = opMsg.Delivery
+	res.Execution = opMsg.Execution
+	res.SpecChange = opMsg.SpecChange
+	res.CloneOf = opMsg.CloneOf
+	res.CI = opMsg.CI
 	res.Worker = workerName
 	res.Err = opMsg.Err
 	res.At = time.Now().UTC()
@@ -48,6 +55,10 @@ func finalizeWorkerResult(
 	res.RollbackScope = opMsg.RollbackScope
 	res.RollbackOverride = opMsg.RollbackOverride
 	res.Delivery = opMsg.Delivery
+	res.Execution = opMsg.Execution
+	res.SpecChange = opMsg.SpecChange
+	res.CloneOf = opMsg.CloneOf
+	res.CI = opMsg.CI
 	if res.Err == "" {
 		res.Err = opMsg.Err
 	}
@@ -80,7 +91,7 @@ func publishWorkerPoison(
 	}
 	_, err = js.Publish(
 		ctx,
-		subjectWorkerPoison,
+		workerOrgSubject(natsSubject(subjectWorkerPoison), workerSubjectOrg(msg.SubjectIn)),
 		body,
 		jetstream.WithMsgID(workerPoisonMessageID(msg)),
 	)
@@ -0,0 +1,300 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Op resume: after a restart or leader change, ops left queued/running are
+// matched against the worker pipeline stream. Un-acked messages redeliver on
+// their own; an op whose last message was already acked is re-driven from
+// that message, and one with nothing left in the stream is failed instead of
+// staying "running" forever.
+////////////////////////////////////////////////////////////////////////////////
+
+type opResumeAction int
+
+const (
+	// The op's last message is still pending on its consumer.
+	opResumeWait opResumeAction = iota
+	// The last message was acked but nothing followed; publish it again.
+	opResumeRedeliver
+	// The last message is a final result the op was never finalized with.
+	opResumeFinalize
+	// Nothing in the stream can move the op any more.
+	opResumeFail
+)
+
+const opResumeLostMessage = "op did not resume: its pipeline message is no longer in the worker stream; re-run the operation"
+
+type opResumeReport struct {
+	ScannedOps  int
+	Waiting     int
+	Redelivered int
+	Finalized   int
+	Failed      int
+}
+
+// pipelineTail is the newest worker stream message carrying an op.
+type pipelineTail struct {
+	seq     uint64
+	subject string
+	data    []byte
+}
+
+// pipelineSubjectWorkers maps each streamed subject a worker consumes to
+// that worker. Subjects missing here carry final results.
+func pipelineSubjectWorkers() map[string]string {
+	return map[string]string{
+		natsSubject(subjectProjectOpStart):   "registrar",
+		natsSubject(subjectRegistrationDone): "repoBootstrap",
+		natsSubject(subjectBootstrapDone):    "imageBuilder",
+		natsSubject(subjectBuildDone):        "manifestRenderer",
+		natsSubject(subjectDeploymentStart):  "deployer",
+		natsSubject(subjectPromotionStart):   "promoter",
+		natsSubject(subjectCleanupStart):     "artifactCleaner",
+		natsSubject(subjectUpgradeStart):     "runtimeUpgrader",
+	}
+}
+
+// runOpResume resumes stuck ops once per leadership term, then holds the
+// term until it ends so runSingletonJob does not start it again.
+func runOpResume(
+	ctx context.Context,
+	js jetstream.JetStream,
+	store *Store,
+	artifacts ArtifactStore,
+	resumeLog sourceLogger,
+) {
+	report, err := resumeStuckOps(ctx, js, store, artifacts, time.Now().UTC(), resumeLog)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		resumeLog.Warnf("op resume failed: %v", err)
+	case report.ScannedOps > 0:
+		resumeLog.Infof(
+			"op resume: scanned_ops=%d waiting=%d redelivered=%d finalized=%d failed=%d",
+			report.ScannedOps,
+			report.Waiting,
+			report.Redelivered,
+			report.Finalized,
+			report.Failed,
+		)
+	}
+	<-ctx.Done()
+}
+
+// resumeStuckOps checks every queued or running op requested before
+// now-opResumeGrace. Younger ops may still have their start message in
+// flight from another replica, so they are left alone.
+func resumeStuckOps(
+	ctx context.Context,
+	js jetstream.JetStream,
+	store *Store,
+	artifacts ArtifactStore,
+	now time.Time,
+	resumeLog sourceLogger,
+) (opResumeReport, error) {
+	var report opResumeReport
+	ops, err := store.listAllOps(ctx)
+	if err != nil {
+		return report, err
+	}
+	stuck := map[string]Operation{}
+	for _, op := range ops {
+		if isOperationStatusActive(op.Status) && op.Requested.Before(now.Add(-opResumeGrace)) {
+			stuck[op.ID] = op
+		}
+	}
+	report.ScannedOps = len(stuck)
+	if len(stuck) == 0 {
+		return report, nil
+	}
+
+	tails, err := lastPipelineMessages(ctx, js, stuck)
+	if err != nil {
+		return report, err
+	}
+	ackFloors, err := workerAckFloors(ctx, js)
+	if err != nil {
+		return report, err
+	}
+
+	for _, op := range sortedOps(stuck) {
+		tail, found := tails[op.ID]
+		switch planOpResume(tail, found, ackFloors) {
+		case opResumeWait:
+			report.Waiting++
+		case opResumeRedeliver:
+			msgID := fmt.Sprintf("resume/%s/%d", op.ID, tail.seq)
+			if _, pubErr := js.Publish(ctx, tail.subject, tail.data, jetstream.WithMsgID(msgID)); pubErr != nil {
+				return report, fmt.Errorf("redeliver op %s: %w", op.ID, pubErr)
+			}
+			resumeLog.Infof("resumed op=%s kind=%s subject=%s", op.ID, op.Kind, tail.subject)
+			report.Redelivered++
+		case opResumeFinalize:
+			var res WorkerResultMsg
+			if json.Unmarshal(tail.data, &res) != nil {
+				res.Err = "final worker result could not be decoded"
+			}
+			finalizeResumedOp(ctx, store, artifacts, op, res.Err, resumeLog)
+			report.Finalized++
+		case opResumeFail:
+			resumeLog.Warnf("op=%s kind=%s: %s", op.ID, op.Kind, opResumeLostMessage)
+			finalizeResumedOp(ctx, store, artifacts, op, opResumeLostMessage, resumeLog)
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+// planOpResume decides what moves an op forward given the newest stream
+// message for it and each worker subject's ack floor. A subject without an
+// ack floor has no consumer yet; the consumer delivers everything once it
+// is created, so the op waits for it.
+func planOpResume(tail pipelineTail, found bool, ackFloors map[string]uint64) opResumeAction {
+	if !found {
+		return opResumeFail
+	}
+	if _, consumed := pipelineSubjectWorkers()[workerBaseSubject(tail.subject)]; !consumed {
+		return opResumeFinalize
+	}
+	floor, ok := ackFloors[workerSubjectWithoutOrg(tail.subject)]
+	if !ok || tail.seq > floor {
+		return opResumeWait
+	}
+	return opResumeRedeliver
+}
+
+// lastPipelineMessages replays the worker stream once and keeps the newest
+// message for each op in ops.
+func lastPipelineMessages(
+	ctx context.Context,
+	js jetstream.JetStream,
+	ops map[string]Operation,
+) (map[string]pipelineTail, error) {
+	tails := map[string]pipelineTail{}
+	stream, err := js.Stream(ctx, natsStreamName(streamWorkerPipeline))
+	if err != nil {
+		return nil, err
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.State.Msgs == 0 {
+		return tails, nil
+	}
+
+	var cfg jetstream.OrderedConsumerConfig
+	cfg.FilterSubjects = opResumeSubjects()
+	cfg.DeliverPolicy = jetstream.DeliverAllPolicy
+	consumer, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		msg, nextErr := consumer.Next(jetstream.FetchMaxWait(workerDeliveryFetchWait))
+		if nextErr != nil {
+			if errors.Is(nextErr, nats.ErrTimeout) || errors.Is(nextErr, context.DeadlineExceeded) {
+				return tails, nil
+			}
+			return nil, nextErr
+		}
+		meta, metaErr := msg.Metadata()
+		if metaErr != nil {
+			return nil, metaErr
+		}
+		var carrier struct {
+			OpID string `json:"op_id"`
+		}
+		if json.Unmarshal(msg.Data(), &carrier) == nil {
+			if _, wanted := ops[carrier.OpID]; wanted {
+				tails[carrier.OpID] = pipelineTail{
+					seq:     meta.Sequence.Stream,
+					subject: msg.Subject(),
+					data:    msg.Data(),
+				}
+			}
+		}
+		if meta.NumPending == 0 || meta.Sequence.Stream >= info.State.LastSeq {
+			return tails, nil
+		}
+	}
+}
+
+// opResumeSubjects is every stream subject except poison markers, which
+// record a failure the poison path already finalized.
+func opResumeSubjects() []string {
+	subjects := sortedKeys(pipelineSubjectWorkers())
+	return workerStreamSubjects(append(subjects, finalResultSubjects()...))
+}
+
+// workerAckFloors reports, per worker subject partition, the last stream
+// sequence its consumer has acked. Each partition consumer takes one message
+// at a time, so everything at or below the floor is done and everything
+// above it is pending.
+func workerAckFloors(ctx context.Context, js jetstream.JetStream) (map[string]uint64, error) {
+	floors := map[string]uint64{}
+	for subject, workerName := range pipelineSubjectWorkers() {
+		for partition := range workerConcurrency() {
+			consumer, err := js.Consumer(
+				ctx,
+				natsStreamName(streamWorkerPipeline),
+				workerPartitionConsumerName(workerName, partition),
+			)
+			if errors.Is(err, jetstream.ErrConsumerNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			info, err := consumer.Info(ctx)
+			if err != nil {
+				return nil, err
+			}
+			floors[workerPartitionSubject(subject, partition)] = info.AckFloor.Stream
+		}
+	}
+	return floors, nil
+}
+
+// finalizeResumedOp closes op the way its final worker would have: done
+// when errText is empty, error otherwise.
+func finalizeResumedOp(
+	ctx context.Context,
+	store *Store,
+	artifacts ArtifactStore,
+	op Operation,
+	errText string,
+	resumeLog sourceLogger,
+) {
+	if errText != "" {
+		var opMsg ProjectOpMsg
+		opMsg.OpID = op.ID
+		markWorkerDeliveryFailure(ctx, store, artifacts, opMsg, errText, resumeLog)
+		return
+	}
+	if err := finalizeOp(context.WithoutCancel(ctx), store, op.ID, op.ProjectID, op.Kind, opStatusDone, ""); err != nil {
+		resumeLog.Warnf("finalize resumed op=%s failed: %v", op.ID, err)
+	}
+	if op.Kind == OpCI {
+		if err := finalizeSourceCommitPendingOp(artifacts, op.ProjectID, op.ID, true); err != nil {
+			resumeLog.Warnf("finalize ci commit state for resumed op=%s failed: %v", op.ID, err)
+		}
+	}
+}
+
+func sortedOps(ops map[string]Operation) []Operation {
+	out := make([]Operation, 0, len(ops))
+	for _, id := range sortedKeys(ops) {
+		out = append(out, ops[id])
+	}
+	return out
+}
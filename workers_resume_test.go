@@ -0,0 +1,127 @@
+//nolint:testpackage // Resume tests drive unexported stream and consumer wiring directly.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestWorkers_PlanOpResume(t *testing.T) {
+	t.Parallel()
+
+	registered := natsSubject(subjectRegistrationDone)
+	built, deployed := natsSubject(subjectBuildDone), natsSubject(subjectDeployDone)
+	floors := map[string]uint64{registered: 10}
+	cases := []struct {
+		name  string
+		tail  pipelineTail
+		found bool
+		want  opResumeAction
+	}{
+		{name: "no message", tail: pipelineTail{seq: 0, subject: "", data: nil}, found: false, want: opResumeFail},
+		{name: "final result", tail: pipelineTail{seq: 4, subject: deployed, data: nil}, found: true, want: opResumeFinalize},
+		{name: "pending", tail: pipelineTail{seq: 11, subject: registered, data: nil}, found: true, want: opResumeWait},
+		{name: "acked", tail: pipelineTail{seq: 10, subject: registered, data: nil}, found: true, want: opResumeRedeliver},
+		{name: "no consumer", tail: pipelineTail{seq: 1, subject: built, data: nil}, found: true, want: opResumeWait},
+	}
+	for _, tc := range cases {
+		if got := planOpResume(tc.tail, tc.found, floors); got != tc.want {
+			t.Fatalf("%s: expected action %d, got %d", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestWorkers_ResumeStuckOpsAfterRestart(t *testing.T) {
+	requireLoopbackListenCapability(t)
+
+	fixture := startPersistentNATSFixture(t, t.TempDir()+"/nats-store")
+	defer fixture.close()
+	ctx := context.Background()
+	if err := ensureWorkerDeliveryStream(ctx, fixture.js); err != nil {
+		t.Fatalf("ensure stream: %v", err)
+	}
+
+	requested := time.Now().UTC().Add(-time.Hour)
+	for _, id := range []string{"op-acked", "op-pending", "op-lost", "op-final"} {
+		op := Operation{
+			ID:        id,
+			Kind:      OpCreate,
+			ProjectID: "project-resume",
+			Delivery:  DeliveryLifecycle{Stage: "", Environment: "", FromEnv: "", ToEnv: ""},
+			Execution: OpExecution{DryRun: false, Trace: false},
+			Requested: requested,
+			Finished:  time.Time{},
+			Status:    opStatusRunning,
+			Error:     "",
+			Steps:     []OpStep{},
+		}
+		if err := fixture.store.PutOp(ctx, op); err != nil {
+			t.Fatalf("put op %s: %v", id, err)
+		}
+	}
+	publish := func(subject, opID string) {
+		t.Helper()
+		body, _ := json.Marshal(map[string]string{"op_id": opID, "project_id": "project-resume"})
+		if _, err := fixture.js.Publish(ctx, subject, body); err != nil {
+			t.Fatalf("publish %s: %v", subject, err)
+		}
+	}
+	publish(natsSubject(subjectRegistrationDone), "op-acked")
+	publish(natsSubject(subjectProjectOpStart), "op-pending")
+	publish(natsSubject(subjectDeployDone), "op-final")
+
+	// repoBootstrap consumed op-acked's message before the "restart";
+	// registrar has op-pending outstanding.
+	bootstrap, err := fixture.js.CreateOrUpdateConsumer(ctx, natsStreamName(streamWorkerPipeline),
+		workerConsumerConfig("repoBootstrap", natsSubject(subjectRegistrationDone), 0))
+	if err != nil {
+		t.Fatalf("create consumer: %v", err)
+	}
+	msg, err := bootstrap.Next(jetstream.FetchMaxWait(2 * time.Second))
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if err = msg.DoubleAck(ctx); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if _, err = fixture.js.CreateOrUpdateConsumer(ctx, natsStreamName(streamWorkerPipeline),
+		workerConsumerConfig("registrar", natsSubject(subjectProjectOpStart), 0)); err != nil {
+		t.Fatalf("create consumer: %v", err)
+	}
+
+	report, err := resumeStuckOps(ctx, fixture.js, fixture.store, NewFSArtifacts(t.TempDir()),
+		time.Now().UTC(), appLoggerForProcess().Source("test"))
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	want := opResumeReport{ScannedOps: 4, Waiting: 1, Redelivered: 1, Finalized: 1, Failed: 1}
+	if report != want {
+		t.Fatalf("expected report %+v, got %+v", want, report)
+	}
+
+	info, err := bootstrap.Info(ctx)
+	if err != nil {
+		t.Fatalf("consumer info: %v", err)
+	}
+	if info.NumPending != 1 {
+		t.Fatalf("expected op-acked redelivered to repoBootstrap, pending=%d", info.NumPending)
+	}
+	for id, status := range map[string]string{
+		"op-acked":   opStatusRunning,
+		"op-pending": opStatusRunning,
+		"op-lost":    opStatusError,
+		"op-final":   opStatusDone,
+	} {
+		op, getErr := fixture.store.GetOp(ctx, id)
+		if getErr != nil {
+			t.Fatalf("get op %s: %v", id, getErr)
+		}
+		if op.Status != status {
+			t.Fatalf("expected %s status %q, got %q", id, status, op.Status)
+		}
+	}
+}